@@ -2,9 +2,85 @@
 /* SPDX-License-Identifier: GPL-2.0    */
 /* Copyright 2023 Authors of KubeArmor */
 
+#define CAPTURE_BLOCKED_WRITES
+
 #include "shared.h"
 #include "syscalls.h"
 
+// the rates of the Throttle rules are given in executions per minute
+#define THROTTLE_PERIOD_NS 60000000000ULL
+
+// the refills are capped to keep the credits from overflowing
+#define THROTTLE_MAX_ELAPSED_NS (1000 * THROTTLE_PERIOD_NS)
+
+struct throttle_key {
+  struct outer_key okey;
+  bufs_k rule;
+};
+
+// a token costs THROTTLE_PERIOD_NS credits, and rate credits are refilled per
+// nanosecond up to burst tokens
+struct throttle_bucket {
+  u64 rate;
+  u64 burst;
+  s64 credits;
+  u64 last;
+};
+
+struct {
+  __uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+  __type(key, u32);
+  __type(value, struct throttle_key);
+  __uint(max_entries, 1);
+} throttle_key_buf SEC(".maps");
+
+// (container, Throttle rule) -> token bucket, programmed by the userspace
+struct {
+  __uint(type, BPF_MAP_TYPE_HASH);
+  __type(key, struct throttle_key);
+  __type(value, struct throttle_bucket);
+  __uint(max_entries, 4096);
+} kubearmor_throttle SEC(".maps");
+
+// throttled consumes a token of the Throttle rule of a container, and returns
+// true if none was left
+static __always_inline bool throttled(struct outer_key *okey, bufs_k *rule) {
+  u32 zero = 0;
+  struct throttle_key *tkey = bpf_map_lookup_elem(&throttle_key_buf, &zero);
+  if (tkey == NULL)
+    return false;
+
+  tkey->okey = *okey;
+  bpf_probe_read(&tkey->rule, sizeof(bufs_k), rule);
+
+  struct throttle_bucket *bucket = bpf_map_lookup_elem(&kubearmor_throttle, tkey);
+  if (bucket == NULL)
+    return false;
+
+  // the concurrent refills on other CPUs may lose a few consumed tokens
+  u64 now = bpf_ktime_get_ns();
+  if (now > bucket->last) {
+    u64 elapsed = now - bucket->last;
+    if (elapsed > THROTTLE_MAX_ELAPSED_NS)
+      elapsed = THROTTLE_MAX_ELAPSED_NS;
+
+    s64 credits = bucket->credits + (s64)(elapsed * bucket->rate);
+    s64 capacity = (s64)(bucket->burst * THROTTLE_PERIOD_NS);
+    if (credits > capacity)
+      credits = capacity;
+
+    bucket->credits = credits;
+    bucket->last = now;
+  }
+
+  if (bucket->credits < (s64)THROTTLE_PERIOD_NS)
+    return true;
+
+  __sync_fetch_and_add(&bucket->credits, -(s64)THROTTLE_PERIOD_NS);
+
+  return false;
+}
+
 SEC("lsm/bprm_check_security")
 int BPF_PROG(enforce_proc, struct linux_binprm *bprm, int ret) {
   struct task_struct *t = (struct task_struct *)bpf_get_current_task();
@@ -72,10 +148,56 @@ int BPF_PROG(enforce_proc, struct linux_binprm *bprm, int ret) {
   void *src_ptr = &src_buf->buf[*src_offset];
   bpf_probe_read_str(store->source, MAX_STRING_SIZE, src_ptr);
 
+  // Fileless executions are denied by the FILELESS key, except from the sources
+  // with their own FILELESS keys
+  if (is_fileless(bprm->file)) {
+    bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+    pk->path[0] = FILELESS;
+    struct data_t *fileless = bpf_map_lookup_elem(inner, pk);
+
+    if (fileless && (fileless->processmask & RULE_DENY)) {
+      bpf_probe_read_str(pk->source, MAX_STRING_SIZE, store->source);
+      if (bpf_map_lookup_elem(inner, pk)) {
+        return ret;
+      }
+
+      task_info = bpf_ringbuf_reserve(&events, sizeof(event), 0);
+      if (!task_info) {
+        return -EPERM;
+      }
+
+      __builtin_memset(task_info->data.path, 0, sizeof(task_info->data.path));
+      __builtin_memset(task_info->data.source, 0, sizeof(task_info->data.source));
+
+      init_context(task_info);
+      // the path follows the FILELESS byte to flag the event
+      task_info->data.path[0] = FILELESS;
+      bpf_probe_read_str(&task_info->data.path[1], MAX_STRING_SIZE - 1, store->path);
+      bpf_probe_read_str(&task_info->data.source, MAX_STRING_SIZE, store->source);
+      task_info->event_id = _SECURITY_BPRM_CHECK;
+      task_info->retval = -EPERM;
+
+      bpf_ringbuf_submit(task_info, 0);
+      return -EPERM;
+    }
+  }
+
+  // the key of an exact match, for its Throttle rule
+  bufs_k *matched = NULL;
+
+  // the key of the matched rule, for the hits of its Allow rule
+  bufs_k *allowed = pk;
+
+  struct allow_buf *abuf = get_allow_buf();
+  if (abuf == NULL)
+    return 0;
+
   struct data_t *val = bpf_map_lookup_elem(inner, store);
 
   if (val && (val->processmask & RULE_EXEC)) {
     match = true;
+    matched = store;
+    allowed = store;
     goto decision;
   }
 
@@ -114,6 +236,7 @@ int BPF_PROG(enforce_proc, struct linux_binprm *bprm, int ret) {
                                        // for a true match in subdirectories
             recursivebuthint = true;
             val = dirval;
+            abuf->dirlen = i + 2;
           } else {
             continue; // We continue the loop to see if we have more nested
                       // directories and set match to false
@@ -127,6 +250,10 @@ int BPF_PROG(enforce_proc, struct linux_binprm *bprm, int ret) {
 
   if (recursivebuthint) {
     match = true;
+    bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+    bpf_probe_read_str(pk->path, abuf->dirlen & (MAX_STRING_SIZE * 2 - 1),
+                       store->path);
+    bpf_probe_read_str(pk->source, MAX_STRING_SIZE, store->source);
     goto decision;
   }
   if (match) {
@@ -143,6 +270,7 @@ int BPF_PROG(enforce_proc, struct linux_binprm *bprm, int ret) {
 
   if (val && (val->processmask & RULE_EXEC)) {
     match = true;
+    matched = pk;
     goto decision;
   }
 
@@ -173,6 +301,7 @@ int BPF_PROG(enforce_proc, struct linux_binprm *bprm, int ret) {
           } else if (dirval->processmask & RULE_RECURSIVE) {
             recursivebuthint = true;
             val = dirval;
+            abuf->dirlen = i + 2;
           } else {
             continue; // We continue the loop to see if we have more nested
                       // directories and set match to false
@@ -189,6 +318,9 @@ int BPF_PROG(enforce_proc, struct linux_binprm *bprm, int ret) {
 
   if (recursivebuthint) {
     match = true;
+    bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+    bpf_probe_read_str(pk->path, abuf->dirlen & (MAX_STRING_SIZE * 2 - 1),
+                       store->path);
     goto decision;
   } else {
     if (match && dirval) {
@@ -214,11 +346,17 @@ decision:
   task_info->retval = -EPERM;
 
   if (match) {
+    // the executions over the rate of a Throttle rule are denied
+    if (matched && throttled(&okey, matched)) {
+      bpf_ringbuf_submit(task_info, 0);
+      return -EPERM;
+    }
     if (val && (val->processmask & RULE_OWNER)) {
-      if (!is_owner(bprm->file)) {
+      if (!is_owner(bprm->file, inner)) {
         bpf_ringbuf_submit(task_info, 0);
         return -EPERM;
       } else {
+        count_allowed(abuf, &okey, allowed);
         bpf_ringbuf_discard(task_info, 0);
         return ret;
       }
@@ -227,6 +365,9 @@ decision:
         bpf_ringbuf_submit(task_info, 0);
       return -EPERM;
     }
+
+    // allowed whatever the posture is
+    count_allowed(abuf, &okey, allowed);
   } 
 
   bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
@@ -250,7 +391,8 @@ decision:
   return ret;
 }
 
-static inline int match_net_rules(int type, int protocol, u32 eventID) {
+static inline int match_net_rules(int family, int type, int protocol,
+                                   u32 eventID) {
   event *task_info;
 
   struct task_struct *t = (struct task_struct *)bpf_get_current_task();
@@ -324,6 +466,13 @@ static inline int match_net_rules(int type, int protocol, u32 eventID) {
   p->path[0] = p0;
   p->path[1] = p1;
 
+  // the key of the matched rule, for the hits of its Allow rule
+  bufs_k *matched = p;
+
+  struct allow_buf *abuf = get_allow_buf();
+  if (abuf == NULL)
+    return 0;
+
   struct data_t *val = bpf_map_lookup_elem(inner, p);
 
   if (val) {
@@ -345,6 +494,29 @@ static inline int match_net_rules(int type, int protocol, u32 eventID) {
     goto decision;
   }
 
+  // the rules of socket families (e.g., packet), with and without the source
+  bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+  store->path[0] = sock_family;
+  store->path[1] = family;
+  bpf_probe_read_str(store->source, MAX_STRING_SIZE, ptr);
+
+  val = bpf_map_lookup_elem(inner, store);
+
+  if (!val) {
+    bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+    store->path[0] = sock_family;
+    store->path[1] = family;
+
+    val = bpf_map_lookup_elem(inner, store);
+  }
+
+  if (val) {
+    match = true;
+    matched = store;
+    p->path[0] = sock_family;
+    p->path[1] = family;
+  }
+
 decision:
 
   task_info = bpf_ringbuf_reserve(&events, sizeof(event), 0);
@@ -363,6 +535,10 @@ decision:
 
   task_info->retval = -EPERM;
 
+  // the matches of Allow rules are allowed whatever the posture is
+  if (match)
+    count_allowed(abuf, &okey, matched);
+
   bpf_map_update_elem(&bufk, &one, z, BPF_ANY);
   p->path[0] = dnet;
   struct data_t *allow = bpf_map_lookup_elem(inner, p);
@@ -387,14 +563,15 @@ decision:
 
 SEC("lsm/socket_create")
 int BPF_PROG(enforce_net_create, int family, int type, int protocol) {
-  return match_net_rules(type, protocol, _SOCKET_CREATE);
+  return match_net_rules(family, type, protocol, _SOCKET_CREATE);
 }
 
 #define LSM_NET(name, ID)                                                      \
   int BPF_PROG(name, struct socket *sock) {                                    \
     int type = sock->type;                                                     \
     int protocol = sock->sk->sk_protocol;                                      \
-    return match_net_rules(type, protocol, ID);                                \
+    int family = sock->sk->__sk_common.skc_family;                             \
+    return match_net_rules(family, type, protocol, ID);                        \
   }
 
 SEC("lsm/socket_connect")
@@ -403,6 +580,158 @@ LSM_NET(enforce_net_connect, _SOCKET_CONNECT);
 SEC("lsm/socket_accept")
 LSM_NET(enforce_net_accept, _SOCKET_ACCEPT);
 
+// the maximum size of the samples of the blocked writes, the monitor caps them
+// to the configured size
+#define CAPTURE_MAX_BYTES 4096
+
+// the write syscalls (write, pwrite64) per architecture
+#define X86_WRITE 1
+#define X86_PWRITE64 18
+#define ARM64_WRITE 64
+#define ARM64_PWRITE64 68
+
+// the registers of the syscalls per architecture (CO-RE flavors of pt_regs)
+struct pt_regs___x86 {
+  unsigned long di;
+  unsigned long si;
+  unsigned long dx;
+  unsigned long r10;
+  unsigned long orig_ax;
+} __attribute__((preserve_access_index));
+
+struct pt_regs___arm64 {
+  u64 regs[31];
+  u64 orig_x0;
+  s32 syscallno;
+} __attribute__((preserve_access_index));
+
+struct capture_key {
+  u64 ts;
+  u32 host_pid;
+  u32 pad;
+};
+
+struct write_capture {
+  s64 fd;
+  s64 offset;
+  u64 size;
+  u32 len;
+  u32 pad;
+  u8 sample[CAPTURE_MAX_BYTES];
+};
+
+struct {
+  __uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+  __type(key, u32);
+  __type(value, struct write_capture);
+  __uint(max_entries, 1);
+} write_capture_buf SEC(".maps");
+
+// the samples of the blocked writes, by the timestamp and the pid of their
+// events (taken by the monitor once it reads the events)
+struct {
+  __uint(type, BPF_MAP_TYPE_LRU_HASH);
+  __type(key, struct capture_key);
+  __type(value, struct write_capture);
+  __uint(max_entries, 64);
+} write_captures SEC(".maps");
+
+static __always_inline void capture_blocked_write(event *task_info,
+                                                  struct path *f_path,
+                                                  u32 eventID, void *inner) {
+  // the buffers are only known to the writes
+  if (eventID != _FILE_PERMISSION)
+    return;
+
+  // the registers of the tasks aren't available to older kernels, the monitor
+  // looks at /proc instead
+  if (!bpf_core_enum_value_exists(enum bpf_func_id, BPF_FUNC_task_pt_regs))
+    return;
+
+  u32 zero = 0;
+  bufs_k *z = bpf_map_lookup_elem(&bufk, &zero);
+  if (z == NULL)
+    return;
+
+  u32 two = 2;
+  bufs_k *pk = bpf_map_lookup_elem(&bufk, &two);
+  if (pk == NULL)
+    return;
+
+  bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+  pk->path[0] = CAPTURE_WRITES;
+  if (!bpf_map_lookup_elem(inner, pk))
+    return;
+
+  struct task_struct *t = bpf_get_current_task_btf();
+  struct pt_regs *regs = (struct pt_regs *)bpf_task_pt_regs(t);
+
+  s64 fd, offset;
+  u64 buf, count;
+  bool write, pwrite;
+
+  if (bpf_core_field_exists(((struct pt_regs___x86 *)regs)->orig_ax)) {
+    struct pt_regs___x86 *r = (struct pt_regs___x86 *)regs;
+    long nr = BPF_CORE_READ(r, orig_ax);
+    write = nr == X86_WRITE;
+    pwrite = nr == X86_PWRITE64;
+    fd = BPF_CORE_READ(r, di);
+    buf = BPF_CORE_READ(r, si);
+    count = BPF_CORE_READ(r, dx);
+    offset = BPF_CORE_READ(r, r10);
+  } else if (bpf_core_field_exists(
+                 ((struct pt_regs___arm64 *)regs)->syscallno)) {
+    struct pt_regs___arm64 *r = (struct pt_regs___arm64 *)regs;
+    s32 nr = BPF_CORE_READ(r, syscallno);
+    write = nr == ARM64_WRITE;
+    pwrite = nr == ARM64_PWRITE64;
+    fd = BPF_CORE_READ(r, orig_x0);
+    buf = BPF_CORE_READ(r, regs[1]);
+    count = BPF_CORE_READ(r, regs[2]);
+    offset = BPF_CORE_READ(r, regs[3]);
+  } else {
+    return;
+  }
+
+  if (!write && !pwrite)
+    return;
+
+  // the handle of the write must be the blocked file (not a write in progress
+  // to another file, e.g., by writev or io_uring)
+  unsigned int max_fds = BPF_CORE_READ(t, files, fdt, max_fds);
+  if (fd < 0 || fd >= max_fds)
+    return;
+
+  struct file **fds = BPF_CORE_READ(t, files, fdt, fd);
+  struct file *file = NULL;
+  bpf_probe_read_kernel(&file, sizeof(file), &fds[fd]);
+  if (file == NULL)
+    return;
+
+  if (BPF_CORE_READ(file, f_path.dentry) != f_path->dentry ||
+      BPF_CORE_READ(file, f_path.mnt) != f_path->mnt)
+    return;
+
+  struct write_capture *capture =
+      bpf_map_lookup_elem(&write_capture_buf, &zero);
+  if (capture == NULL)
+    return;
+
+  capture->fd = fd;
+  capture->offset = pwrite ? offset : BPF_CORE_READ(file, f_pos);
+  capture->size = count;
+  capture->len = 0;
+
+  u32 len = count < CAPTURE_MAX_BYTES ? count : CAPTURE_MAX_BYTES;
+  if (len > 0 && len <= CAPTURE_MAX_BYTES &&
+      bpf_probe_read_user(capture->sample, len, (void *)buf) == 0)
+    capture->len = len;
+
+  struct capture_key key = {.ts = task_info->ts,
+                            .host_pid = task_info->host_pid};
+  bpf_map_update_elem(&write_captures, &key, capture, BPF_ANY);
+}
+
 SEC("lsm/file_open")
 int BPF_PROG(enforce_file, struct file *file) { // check if ret code available
   struct path f_path = BPF_CORE_READ(file, f_path);
@@ -419,4 +748,448 @@ int BPF_PROG(enforce_file_perm, struct file *file, int mask) {
 
   struct path f_path = BPF_CORE_READ(file, f_path);
   return match_and_enforce_path_hooks(&f_path, dfilewrite, _FILE_PERMISSION);
-}
\ No newline at end of file
+}
+// checks if a signal rule denies a signal, unless its target is excluded or out
+// of the scope of the rule
+static __always_inline bool deny_signal(struct data_t *val, u8 scope, bool self,
+                                        bool child) {
+  if (!val || !(val->processmask & RULE_DENY))
+    return false;
+  if (self && (val->filemask & SIGNAL_EXCLUDE_SELF))
+    return false;
+  if (child && (val->filemask & SIGNAL_EXCLUDE_CHILDREN))
+    return false;
+  if ((val->filemask & SIGNAL_SCOPE_CROSS_CONTAINER) &&
+      scope != sig_cross_container)
+    return false;
+  if ((val->filemask & SIGNAL_SCOPE_HOST) && scope != sig_host)
+    return false;
+  return true;
+}
+
+// the monitor reports the denied signals (SECURITY_TASK_KILL), so no event is
+// sent from here
+SEC("lsm/task_kill")
+int BPF_PROG(enforce_signal, struct task_struct *p, struct kernel_siginfo *info,
+             int sig, const struct cred *cred, int ret) {
+  if (ret != 0 || sig <= 0)
+    return ret;
+
+  struct task_struct *t = (struct task_struct *)bpf_get_current_task();
+
+  struct outer_key okey;
+  get_outer_key(&okey, t);
+
+  u32 *inner = bpf_map_lookup_elem(&kubearmor_containers, &okey);
+
+  if (!inner) {
+    return ret;
+  }
+
+  u32 zero = 0;
+  bufs_k *z = bpf_map_lookup_elem(&bufk, &zero);
+  if (z == NULL)
+    return ret;
+
+  u32 one = 1;
+  bufs_k *store = bpf_map_lookup_elem(&bufk, &one);
+  if (store == NULL)
+    return ret;
+
+  bpf_map_update_elem(&bufk, &one, z, BPF_ANY);
+
+  u32 two = 2;
+  bufs_k *pk = bpf_map_lookup_elem(&bufk, &two);
+  if (pk == NULL)
+    return ret;
+
+  // Extract full path of the sender (the source of the rules)
+  struct file *file_p = get_task_file(t);
+  if (file_p != NULL) {
+    bufs_t *src_buf = get_buf(PATH_BUFFER);
+    if (src_buf == NULL)
+      return ret;
+    struct path f_src = BPF_CORE_READ(file_p, f_path);
+    if (prepend_path(&f_src, src_buf)) {
+      u32 *src_offset = get_buf_off(PATH_BUFFER);
+      if (src_offset == NULL)
+        return ret;
+      void *src_ptr = &src_buf->buf[*src_offset];
+      bpf_probe_read_str(store->source, MAX_STRING_SIZE, src_ptr);
+    }
+  }
+
+  // Extract full path of the target
+  file_p = get_task_file(p);
+  if (file_p != NULL) {
+    bufs_t *path_buf = get_buf(PATH_BUFFER);
+    if (path_buf == NULL)
+      return ret;
+    struct path f_path = BPF_CORE_READ(file_p, f_path);
+    if (prepend_path(&f_path, path_buf)) {
+      u32 *path_offset = get_buf_off(PATH_BUFFER);
+      if (path_offset == NULL)
+        return ret;
+      void *path_ptr = &path_buf->buf[*path_offset];
+      bpf_probe_read_str(store->path, MAX_STRING_SIZE, path_ptr);
+    }
+  }
+
+  u32 sender = bpf_get_current_pid_tgid() >> 32;
+  bool self = BPF_CORE_READ(p, tgid) == sender;
+  bool child = BPF_CORE_READ(p, real_parent, tgid) == sender;
+
+  // the target in the same container (or the host) is in no scope
+  struct outer_key tkey;
+  get_outer_key(&tkey, p);
+
+  u8 scope = sig_any;
+  if (tkey.pid_ns != okey.pid_ns || tkey.mnt_ns != okey.mnt_ns) {
+    if (tkey.pid_ns == 0 && tkey.mnt_ns == 0)
+      scope = sig_host;
+    else
+      scope = sig_cross_container;
+  }
+
+  // the rules of the target path, the scope of the target, and any target,
+  // from the sender or any source
+#pragma unroll
+  for (int i = 0; i < 6; i++) {
+    u8 target = sig_any;
+    if (i < 2) {
+      if (store->path[0] == '\0')
+        continue;
+      target = sig_path;
+    } else if (i < 4) {
+      if (scope == sig_any)
+        continue;
+      target = scope;
+    }
+
+    if ((i % 2 == 0) && store->source[0] == '\0')
+      continue;
+
+    bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+    pk->path[0] = SIGNAL;
+    pk->path[1] = sig;
+    pk->path[2] = target;
+    if (target == sig_path)
+      bpf_probe_read_str(&pk->path[3], MAX_STRING_SIZE - 3, store->path);
+    if (i % 2 == 0)
+      bpf_probe_read_str(pk->source, MAX_STRING_SIZE, store->source);
+
+    if (deny_signal(bpf_map_lookup_elem(inner, pk), scope, self, child))
+      return -EPERM;
+  }
+
+  return ret;
+}
+
+// connections to the sockets of the container runtimes, matched by the inode of
+// the socket file rather than by its path in the container, so that the sockets
+// mounted at other paths are denied as well (the monitor reports the denied
+// connections, so no event is sent from here)
+SEC("lsm/unix_stream_connect")
+int BPF_PROG(enforce_runtime_socket, struct sock *sock, struct sock *other,
+             struct sock *newsk, int ret) {
+  if (ret != 0)
+    return ret;
+
+  struct task_struct *t = (struct task_struct *)bpf_get_current_task();
+
+  struct outer_key okey;
+  get_outer_key(&okey, t);
+
+  u32 *inner = bpf_map_lookup_elem(&kubearmor_containers, &okey);
+
+  if (!inner) {
+    return ret;
+  }
+
+  // abstract sockets have no file
+  struct unix_sock *u = (struct unix_sock *)other;
+  struct path sock_path = BPF_CORE_READ(u, path);
+  if (sock_path.dentry == NULL)
+    return ret;
+
+  u64 ino = BPF_CORE_READ(sock_path.dentry, d_inode, i_ino);
+  u32 dev = BPF_CORE_READ(sock_path.dentry, d_sb, s_dev);
+
+  u32 zero = 0;
+  bufs_k *z = bpf_map_lookup_elem(&bufk, &zero);
+  if (z == NULL)
+    return ret;
+
+  u32 one = 1;
+  bufs_k *store = bpf_map_lookup_elem(&bufk, &one);
+  if (store == NULL)
+    return ret;
+
+  bpf_map_update_elem(&bufk, &one, z, BPF_ANY);
+
+  u32 two = 2;
+  bufs_k *pk = bpf_map_lookup_elem(&bufk, &two);
+  if (pk == NULL)
+    return ret;
+
+  // Extract full path of the source (the process connecting)
+  struct file *file_p = get_task_file(t);
+  if (file_p != NULL) {
+    bufs_t *src_buf = get_buf(PATH_BUFFER);
+    if (src_buf == NULL)
+      return ret;
+    struct path f_src = BPF_CORE_READ(file_p, f_path);
+    if (prepend_path(&f_src, src_buf)) {
+      u32 *src_offset = get_buf_off(PATH_BUFFER);
+      if (src_offset == NULL)
+        return ret;
+      void *src_ptr = &src_buf->buf[*src_offset];
+      bpf_probe_read_str(store->source, MAX_STRING_SIZE, src_ptr);
+    }
+  }
+
+  // the rules from the source, then from any source
+#pragma unroll
+  for (int i = 0; i < 2; i++) {
+    if (i == 0 && store->source[0] == '\0')
+      continue;
+
+    bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+    pk->path[0] = RUNTIME_SOCKET;
+    __builtin_memcpy(&pk->path[1], &ino, sizeof(ino));
+    __builtin_memcpy(&pk->path[9], &dev, sizeof(dev));
+    if (i == 0)
+      bpf_probe_read_str(pk->source, MAX_STRING_SIZE, store->source);
+
+    struct data_t *val = bpf_map_lookup_elem(inner, pk);
+    if (val && (val->processmask & RULE_DENY))
+      return -EPERM;
+  }
+
+  return ret;
+}
+
+// changes of file attributes, matched by the kind of change and the name of the
+// xattr (exact, up to the dot of its namespace, or any name) from the source or
+// any source, and then by the targets of the matched rules (the monitor reports
+// the denied changes, so no event is sent from here)
+static __always_inline int match_and_enforce_file_attr(struct path *f_path,
+                                                       u8 change,
+                                                       const char *name) {
+  struct task_struct *t = (struct task_struct *)bpf_get_current_task();
+
+  struct outer_key okey;
+  get_outer_key(&okey, t);
+
+  u32 *inner = bpf_map_lookup_elem(&kubearmor_containers, &okey);
+
+  if (!inner) {
+    return 0;
+  }
+
+  u32 zero = 0;
+  bufs_k *z = bpf_map_lookup_elem(&bufk, &zero);
+  if (z == NULL)
+    return 0;
+
+  u32 one = 1;
+  bufs_k *store = bpf_map_lookup_elem(&bufk, &one);
+  if (store == NULL)
+    return 0;
+
+  bpf_map_update_elem(&bufk, &one, z, BPF_ANY);
+
+  u32 two = 2;
+  bufs_k *pk = bpf_map_lookup_elem(&bufk, &two);
+  if (pk == NULL)
+    return 0;
+
+  // the ids of the rules matched by the name, whose targets are looked up
+  u32 three = 3;
+  bpf_map_update_elem(&bufk, &three, z, BPF_ANY);
+  bufs_k *ids = bpf_map_lookup_elem(&bufk, &three);
+  if (ids == NULL)
+    return 0;
+
+  // the name of the xattr (none for the inode flags)
+  if (name != NULL)
+    bpf_probe_read_str(store->path, MAX_STRING_SIZE, name);
+
+  // Extract full path of the source (the process changing the attributes)
+  struct file *file_p = get_task_file(t);
+  if (file_p != NULL) {
+    bufs_t *src_buf = get_buf(PATH_BUFFER);
+    if (src_buf == NULL)
+      return 0;
+    struct path f_src = BPF_CORE_READ(file_p, f_path);
+    if (prepend_path(&f_src, src_buf)) {
+      u32 *src_offset = get_buf_off(PATH_BUFFER);
+      if (src_offset == NULL)
+        return 0;
+      void *src_ptr = &src_buf->buf[*src_offset];
+      bpf_probe_read_str(store->source, MAX_STRING_SIZE, src_ptr);
+    }
+  }
+
+  struct data_t *val;
+
+  // the exact name, then any name
+#pragma unroll
+  for (int i = 0; i < 4; i++) {
+    if (i < 2 && name == NULL)
+      continue;
+    if ((i % 2 == 0) && store->source[0] == '\0')
+      continue;
+
+    bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+    pk->path[0] = FILE_ATTR;
+    pk->path[1] = change;
+    if (i < 2)
+      bpf_probe_read_str(&pk->path[2], MAX_STRING_SIZE - 2, store->path);
+    if (i % 2 == 0)
+      bpf_probe_read_str(pk->source, MAX_STRING_SIZE, store->source);
+
+    val = bpf_map_lookup_elem(inner, pk);
+    if (val) {
+      if (val->filemask & RULE_DENY)
+        return -EPERM;
+      ids->path[i] = val->processmask;
+    }
+  }
+
+  // the namespace, up to the first dot of the name
+  if (name != NULL) {
+    for (int i = 0; i < MAX_STRING_SIZE - 3; i++) {
+      if (store->path[i] == '\0')
+        break;
+      if (store->path[i] != '.')
+        continue;
+
+#pragma unroll
+      for (int j = 4; j < FILE_ATTR_KEYS; j++) {
+        if ((j % 2 == 0) && store->source[0] == '\0')
+          continue;
+
+        bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+        pk->path[0] = FILE_ATTR;
+        pk->path[1] = change;
+        bpf_probe_read_str(&pk->path[2], i + 2, store->path);
+        if (j % 2 == 0)
+          bpf_probe_read_str(pk->source, MAX_STRING_SIZE, store->source);
+
+        val = bpf_map_lookup_elem(inner, pk);
+        if (val) {
+          if (val->filemask & RULE_DENY)
+            return -EPERM;
+          ids->path[j] = val->processmask;
+        }
+      }
+
+      break;
+    }
+  }
+
+  bool matched = false;
+#pragma unroll
+  for (int j = 0; j < FILE_ATTR_KEYS; j++) {
+    if (ids->path[j] != 0)
+      matched = true;
+  }
+  if (!matched)
+    return 0;
+
+  /* Extract full path from the path of the file */
+  bufs_t *path_buf = get_buf(PATH_BUFFER);
+  if (path_buf == NULL)
+    return 0;
+
+  if (!prepend_path(f_path, path_buf))
+    return 0;
+
+  u32 *path_offset = get_buf_off(PATH_BUFFER);
+  if (path_offset == NULL)
+    return 0;
+
+  bpf_map_update_elem(&bufk, &one, z, BPF_ANY);
+  void *path_ptr = &path_buf->buf[*path_offset];
+  bpf_probe_read_str(store->path, MAX_STRING_SIZE, path_ptr);
+
+  // the directories of the file (with their trailing slashes), then the file
+  for (int i = 0; i < MAX_STRING_SIZE - 3; i++) {
+    char c = store->path[i];
+    if (c != '/' && c != '\0')
+      continue;
+
+    u32 len = i + 1;
+    if (c == '/')
+      len = i + 2;
+
+#pragma unroll
+    for (int j = 0; j < FILE_ATTR_KEYS; j++) {
+      if (ids->path[j] == 0)
+        continue;
+
+      bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+      pk->path[0] = FILE_ATTR_TARGET;
+      pk->path[1] = ids->path[j];
+      bpf_probe_read_str(&pk->path[2], len, store->path);
+
+      if (bpf_map_lookup_elem(inner, pk))
+        return -EPERM;
+    }
+
+    if (c == '\0')
+      break;
+  }
+
+  return 0;
+}
+
+// the xattr hooks only have the dentry of the file, whose path is taken from the
+// root of the task (the files of other mounts are told by their paths in their
+// file systems)
+static __always_inline int match_and_enforce_xattr(struct dentry *dentry,
+                                                   u8 change,
+                                                   const char *name) {
+  struct task_struct *t = (struct task_struct *)bpf_get_current_task();
+
+  struct path f_path;
+  f_path.mnt = BPF_CORE_READ(t, fs, root.mnt);
+  f_path.dentry = dentry;
+
+  return match_and_enforce_file_attr(&f_path, change, name);
+}
+
+SEC("lsm/inode_setxattr")
+int BPF_PROG(enforce_set_xattr, void *idmap, struct dentry *dentry,
+             const char *name, const void *value, size_t size, int flags,
+             int ret) {
+  if (ret != 0)
+    return ret;
+
+  return match_and_enforce_xattr(dentry, attr_set_xattr, name);
+}
+
+SEC("lsm/inode_removexattr")
+int BPF_PROG(enforce_remove_xattr, void *idmap, struct dentry *dentry,
+             const char *name, int ret) {
+  if (ret != 0)
+    return ret;
+
+  return match_and_enforce_xattr(dentry, attr_remove_xattr, name);
+}
+
+// changes of the inode flags (e.g., chattr +i)
+SEC("lsm/file_ioctl")
+int BPF_PROG(enforce_set_flags, struct file *file, unsigned int cmd,
+             unsigned long arg, int ret) {
+  if (ret != 0)
+    return ret;
+
+  if (cmd != FS_IOC_SETFLAGS && cmd != FS_IOC32_SETFLAGS)
+    return ret;
+
+  struct path f_path = BPF_CORE_READ(file, f_path);
+
+  return match_and_enforce_file_attr(&f_path, attr_set_flags, NULL);
+}
@@ -27,9 +27,10 @@ enum deny_by_default {
   dnet
 }; // check if the list is whitelist/blacklist
 enum network_check_type {
-  sock_type = 2,
+  sock_family = 1,
+  sock_type,
   sock_proto
-}; // configure to check for network protocol or socket type
+}; // configure to check for socket family, network protocol or socket type
 
 typedef struct buffers {
   char buf[MAX_BUFFER_SIZE];
@@ -64,7 +65,7 @@ struct {
   __uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
   __type(key, u32);
   __type(value, bufs_k);
-  __uint(max_entries, 3);
+  __uint(max_entries, 4);
 } bufk SEC(".maps");
 
 struct outer_key {
@@ -127,6 +128,61 @@ struct outer_hash {
 
 struct outer_hash kubearmor_containers SEC(".maps");
 
+struct allow_key {
+  struct outer_key okey;
+  bufs_k rule;
+};
+
+// the id of an Allow rule, given by the userspace, and its hits
+struct allow_hits {
+  u32 id;
+  u64 hits;
+};
+
+// the key of the Allow rule which allowed an access, and the length of the
+// recursive directory matched while the lookups of its subdirectories go on
+// (kept out of the stack not to be tracked by the verifier)
+struct allow_buf {
+  struct allow_key key;
+  u32 dirlen;
+};
+
+struct {
+  __uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+  __type(key, u32);
+  __type(value, struct allow_buf);
+  __uint(max_entries, 1);
+} allow_bufs SEC(".maps");
+
+// (container, Allow rule) -> hits, programmed by the userspace for the Allow
+// rules of the policies with logAllowed (shared by the path hooks)
+struct {
+  __uint(type, BPF_MAP_TYPE_HASH);
+  __type(key, struct allow_key);
+  __type(value, struct allow_hits);
+  __uint(max_entries, 4096);
+  __uint(pinning, LIBBPF_PIN_BY_NAME);
+} kubearmor_allow_hits SEC(".maps");
+
+static __always_inline struct allow_buf *get_allow_buf() {
+  u32 zero = 0;
+  return bpf_map_lookup_elem(&allow_bufs, &zero);
+}
+
+// count_allowed counts a hit of the Allow rule, given by its key, which
+// allowed an access
+static __always_inline void count_allowed(struct allow_buf *abuf,
+                                          struct outer_key *okey,
+                                          bufs_k *rule) {
+  abuf->key.okey = *okey;
+  bpf_probe_read(&abuf->key.rule, sizeof(bufs_k), rule);
+
+  struct allow_hits *hits =
+      bpf_map_lookup_elem(&kubearmor_allow_hits, &abuf->key);
+  if (hits)
+    __sync_fetch_and_add(&hits->hits, 1);
+}
+
 static __always_inline bufs_t *get_buf(int idx) {
   return bpf_map_lookup_elem(&bufs, &idx);
 }
@@ -290,22 +346,122 @@ static __always_inline u32 init_context(event *event_data) {
   return 0;
 }
 
-static bool is_owner(struct file *file_p) {
-  kuid_t owner = BPF_CORE_READ(file_p, f_inode, i_uid);
-  unsigned int z = bpf_get_current_uid_gid();
-  if (owner.val != z)
+#define OWNER_GROUP 104
+
+// checks if the group of a file is the fsGroup of the pod, which the enforcer
+// keeps in the rule map as a key (OWNER_GROUP followed by the gid in little endian)
+static bool is_owner_group(kgid_t group, void *inner) {
+  u32 zero = 0;
+  bufs_k *z = bpf_map_lookup_elem(&bufk, &zero);
+  if (z == NULL)
+    return false;
+
+  u32 two = 2;
+  bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+  bufs_k *pk = bpf_map_lookup_elem(&bufk, &two);
+  if (pk == NULL)
     return false;
+
+  pk->path[0] = OWNER_GROUP;
+  pk->path[1] = group.val & 0xff;
+  pk->path[2] = (group.val >> 8) & 0xff;
+  pk->path[3] = (group.val >> 16) & 0xff;
+  pk->path[4] = (group.val >> 24) & 0xff;
+
+  return bpf_map_lookup_elem(inner, pk) != NULL;
+}
+
+#define FILELESS 105
+
+// checks if an executable has no path (memfd_create, O_TMPFILE, deleted files),
+// memfd and O_TMPFILE inodes are created unlinked
+static bool is_fileless(struct file *file_p) {
+  return BPF_CORE_READ(file_p, f_inode, i_nlink) == 0;
+}
+
+#define SIGNAL 106
+
+// the targets of signal rules, following the SIGNAL byte and the signal number
+enum signal_target { sig_any = 0, sig_path, sig_cross_container, sig_host };
+
+#define SIGNAL_EXCLUDE_SELF 1 << 0
+#define SIGNAL_EXCLUDE_CHILDREN 1 << 1
+#define SIGNAL_SCOPE_CROSS_CONTAINER 1 << 2
+#define SIGNAL_SCOPE_HOST 1 << 3
+
+// the keys of runtime socket rules, followed by the inode (u64) and the device
+// (u32) of the socket file
+#define RUNTIME_SOCKET 107
+
+// the keys of the rules of file attribute changes, followed by the kind of
+// change and the name of the xattr (the exact name, its namespace up to the
+// dot, or nothing for any name), and of their targets, followed by the id of
+// the rule and the file (or the directory, with a trailing slash)
+#define FILE_ATTR 108
+#define FILE_ATTR_TARGET 109
+
+enum file_attr_change { attr_set_xattr = 1, attr_remove_xattr, attr_set_flags };
+
+// the name keys looked up per change (exact, namespace, any) with and without
+// the source
+#define FILE_ATTR_KEYS 6
+
+#define FS_IOC_SETFLAGS 0x40086602
+#define FS_IOC32_SETFLAGS 0x40046602
+
+// the key of the containers whose blocked writes are captured (captureOnBlock
+// rules)
+#define CAPTURE_WRITES 110
+
+#ifdef CAPTURE_BLOCKED_WRITES
+// captures a sample of a blocked write (defined by the enforcer, the path hooks
+// have no writes to capture)
+static __always_inline void capture_blocked_write(event *task_info,
+                                                  struct path *f_path,
+                                                  u32 eventID, void *inner);
+#else
+static __always_inline void capture_blocked_write(event *task_info,
+                                                  struct path *f_path,
+                                                  u32 eventID, void *inner) {}
+#endif
+
+// checks if the task owns a file, the owner of the file being compared with the
+// fsuid of the task (as the kernel and the owner rules of AppArmor do), or the
+// group of the file with the fsGroup of the pod
+static bool is_owner_inode(struct inode *inode, void *inner) {
+  struct task_struct *t = (struct task_struct *)bpf_get_current_task();
+  kuid_t fsuid = BPF_CORE_READ(t, cred, fsuid);
+  kuid_t owner = BPF_CORE_READ(inode, i_uid);
+  if (owner.val != fsuid.val)
+    return is_owner_group(BPF_CORE_READ(inode, i_gid), inner);
   return true;
 }
 
-static bool is_owner_path(struct dentry *dent) {
-  kuid_t owner = BPF_CORE_READ(dent, d_inode, i_uid);
-  unsigned int z = bpf_get_current_uid_gid();
-  if (owner.val != z)
+static bool is_owner(struct file *file_p, void *inner) {
+  return is_owner_inode(BPF_CORE_READ(file_p, f_inode), inner);
+}
+
+static bool is_owner_path(struct dentry *dent, void *inner) {
+  return is_owner_inode(BPF_CORE_READ(dent, d_inode), inner);
+}
+
+// checks if the flags of a matched file rule permit an access: readOnly rules
+// (without RULE_WRITE) permit the reads, ownerOnly rules the accesses of the
+// owner of the file, and the rules with both the reads of the owner
+static bool is_permitted(struct data_t *val, bool write, struct dentry *dent,
+                         void *inner) {
+  if (write && !(val->filemask & RULE_WRITE))
     return false;
+  if (val->filemask & RULE_OWNER)
+    return is_owner_path(dent, inner);
   return true;
 }
 
+// checks if the flags of a matched file rule restrict the accesses at all
+static bool has_file_flags(struct data_t *val) {
+  return (val->filemask & RULE_OWNER) || !(val->filemask & RULE_WRITE);
+}
+
 static inline int match_and_enforce_path_hooks(struct path *f_path, u32 id , u32 eventID) {
   struct task_struct *t = (struct task_struct *)bpf_get_current_task();
 
@@ -382,10 +538,18 @@ static inline int match_and_enforce_path_hooks(struct path *f_path, u32 id , u32
   void *ptr = &src_buf->buf[*src_offset];
   bpf_probe_read_str(store->source, MAX_STRING_SIZE, ptr);
 
+  // the key of the matched rule, for the hits of its Allow rule
+  bufs_k *matched = pk;
+
+  struct allow_buf *abuf = get_allow_buf();
+  if (abuf == NULL)
+    return 0;
+
   struct data_t *val = bpf_map_lookup_elem(inner, store);
 
   if (val && (val->filemask & RULE_READ)) {
     match = true;
+    matched = store;
     goto decision;
   }
 
@@ -416,6 +580,7 @@ static inline int match_and_enforce_path_hooks(struct path *f_path, u32 id , u32
             val = dirval;
             if (dirval->filemask & RULE_HINT) {
               recursivebuthint = true;
+              abuf->dirlen = i + 2;
               continue;
             } else {
               goto decision;
@@ -434,6 +599,10 @@ static inline int match_and_enforce_path_hooks(struct path *f_path, u32 id , u32
 
   if (recursivebuthint) {
     match = true;
+    bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+    bpf_probe_read_str(pk->path, abuf->dirlen & (MAX_STRING_SIZE * 2 - 1),
+                       store->path);
+    bpf_probe_read_str(pk->source, MAX_STRING_SIZE, store->source);
     goto decision;
   }
   if (match) {
@@ -478,6 +647,7 @@ static inline int match_and_enforce_path_hooks(struct path *f_path, u32 id , u32
         val = dirval;
         if (dirval->filemask & RULE_HINT) {
           recursivebuthint = true;
+          abuf->dirlen = i + 2;
           continue;
         }
         goto decision;
@@ -487,6 +657,9 @@ static inline int match_and_enforce_path_hooks(struct path *f_path, u32 id , u32
 
   if (recursivebuthint) {
     match = true;
+    bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+    bpf_probe_read_str(pk->path, abuf->dirlen & (MAX_STRING_SIZE * 2 - 1),
+                       store->path);
     goto decision;
   } else if (match && dirval) {
     val = dirval;
@@ -511,78 +684,41 @@ decision:
   task_info->event_id = eventID;
   task_info->retval = -EPERM;
   
-  if (id == dpath) { // Path Hooks
-    if (match) {
-      if (val && (val->filemask & RULE_OWNER)) {
-        if (!is_owner_path(f_path->dentry)) {
-          bpf_ringbuf_submit(task_info, 0);
-          return -EPERM;
-        }
-        bpf_ringbuf_discard(task_info, 0);
-        return 0;
-      }
-      if (val && (val->filemask & RULE_DENY)) {
+  // the path hooks and the writes modify the file, the opens are checked
+  // again by the writes
+  bool write = id != dfileread;
+
+  if (match && val) {
+    bool permitted = is_permitted(val, write, f_path->dentry, inner);
+
+    if (val->filemask & RULE_DENY) {
+      // a Block rule denies the accesses which its flags don't permit
+      if (!permitted || !has_file_flags(val)) {
+        // before the event, which the sample is looked up for
+        capture_blocked_write(task_info, f_path, eventID, inner);
         bpf_ringbuf_submit(task_info, 0);
         return -EPERM;
       }
+      bpf_ringbuf_discard(task_info, 0);
+      return 0;
     }
 
-    bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
-    pk->path[0] = dfile;
-    struct data_t *allow = bpf_map_lookup_elem(inner, pk);
-
-    if (allow) {
-      if (!match) {
-        bpf_ringbuf_submit(task_info, 0);
-        return -EPERM;
-      }
-    }
-
-  } else if (id == dfileread) { // file open
-    if (match) {
-      if (val && (val->filemask & RULE_OWNER)) {
-        if (!is_owner_path(f_path->dentry)) {
-          bpf_ringbuf_submit(task_info, 0);
-          return -EPERM;
-        }
-        bpf_ringbuf_discard(task_info, 0);
-        return 0;
-      }
-      if (val && (val->filemask & RULE_READ) && !(val->filemask & RULE_WRITE)) {
-        bpf_ringbuf_discard(task_info, 0);
-        // Read Only Policy, Decision making will be done in lsm/file_permission
-        return 0;
-      }
-      if (val && (val->filemask & RULE_DENY)) {
-        bpf_ringbuf_submit(task_info, 0);
-        return -EPERM;
-      }
-    }
-
-    bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
-    pk->path[0] = dfile;
-    struct data_t *allow = bpf_map_lookup_elem(inner, pk);
-
-    if (allow && !match) {
-      bpf_ringbuf_submit(task_info, 0);
-      return -EPERM;
-    }
-  } else if (id == dfilewrite) { // fule write
-    if (match) {
-      if (val && (val->filemask & RULE_DENY)) {
-        bpf_ringbuf_submit(task_info, 0);
-        return -EPERM;
-      }
+    // an Allow rule allows the accesses which its flags permit
+    if (permitted) {
+      count_allowed(abuf, &okey, matched);
+      bpf_ringbuf_discard(task_info, 0);
+      return 0;
     }
+  }
 
-    bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
-    pk->path[0] = dfile;
-    struct data_t *allow = bpf_map_lookup_elem(inner, pk);
+  bpf_map_update_elem(&bufk, &two, z, BPF_ANY);
+  pk->path[0] = dfile;
+  struct data_t *allow = bpf_map_lookup_elem(inner, pk);
 
-    if (allow && !match) {
-      bpf_ringbuf_submit(task_info, 0);
-      return -EPERM;
-    }
+  // the accesses not allowed are denied by the allow-list
+  if (allow) {
+    bpf_ringbuf_submit(task_info, 0);
+    return -EPERM;
   }
   bpf_ringbuf_discard(task_info, 0);
 
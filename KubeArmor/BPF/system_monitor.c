@@ -85,6 +85,9 @@
 #define PTRACE_REQ_T 23UL
 #define MOUNT_FLAG_T 24UL
 #define UMOUNT_FLAG_T 25UL
+#define FILE_FLAGS_T 26UL
+#define NS_FLAGS_T 27UL
+#define SOCK_ID_T 28UL
 
 #define MAX_ARGS 6
 #define ENC_ARG_TYPE(n, type) type << (8 * n)
@@ -108,6 +111,13 @@
 
 #define UNDEFINED_SYSCALL 1000
 
+// ioctl commands changing inode flags (chattr)
+#define FS_IOC_SETFLAGS 0x40086602
+#define FS_IOC32_SETFLAGS 0x40046602
+
+// namespace flags of unshare/setns
+#define CLONE_NEWNET 0x40000000
+
 #if defined(bpf_target_x86)
 enum
 {
@@ -119,6 +129,15 @@ enum
     _SYS_UNLINKAT = 263,
     _SYS_CHOWN = 92,
     _SYS_FCHOWNAT = 260,
+    _SYS_SETXATTR = 188,
+    _SYS_LSETXATTR = 189,
+    _SYS_FSETXATTR = 190,
+    _SYS_REMOVEXATTR = 197,
+    _SYS_LREMOVEXATTR = 198,
+    _SYS_FREMOVEXATTR = 199,
+    _SYS_IOCTL = 16,
+    _SYS_UNSHARE = 272,
+    _SYS_SETNS = 308,
     _SYS_SETUID = 105,
     _SYS_SETGID = 106,
     _SYS_MOUNT = 165,
@@ -146,6 +165,15 @@ enum
     _SYS_UNLINKAT = 35,
     _SYS_CHOWN = UNDEFINED_SYSCALL,
     _SYS_FCHOWNAT = 54,
+    _SYS_SETXATTR = 5,
+    _SYS_LSETXATTR = 6,
+    _SYS_FSETXATTR = 7,
+    _SYS_REMOVEXATTR = 14,
+    _SYS_LREMOVEXATTR = 15,
+    _SYS_FREMOVEXATTR = 16,
+    _SYS_IOCTL = 29,
+    _SYS_UNSHARE = 97,
+    _SYS_SETNS = 268,
     _SYS_SETUID = 146,
     _SYS_SETGID = 144,
     _SYS_MOUNT = 165,
@@ -173,6 +201,7 @@ enum
     _SYS_PTRACE = 101,
     // lsm
     _SECURITY_BPRM_CHECK = 352,
+    _SECURITY_TASK_KILL = 353,
 
     // accept/connect
     _TCP_CONNECT = 400,
@@ -297,6 +326,7 @@ enum
     _PROCESS_PROBE = 1,
     _NETWORK_PROBE = 2,
     _CAPS_PROBE = 3,
+    _SIGNAL_PROBE = 4,
 
     _TRACE_SYSCALL = 0,
     _IGNORE_SYSCALL = 1,
@@ -395,6 +425,127 @@ static struct file *get_task_file(struct task_struct *task)
     return READ_KERN(mm->exe_file);
 }
 
+#ifndef S_IFSOCK
+#define S_IFMT 00170000
+#define S_IFSOCK 0140000
+#endif
+
+// the file of an fd of the current task if it is a socket
+static __always_inline struct file *get_socket_file(long fd)
+{
+    struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+
+    if (fd < 0)
+        return NULL;
+
+    struct files_struct *files = READ_KERN(task->files);
+    if (files == NULL)
+        return NULL;
+
+    struct fdtable *fdt = READ_KERN(files->fdt);
+    if (fdt == NULL || fd >= READ_KERN(fdt->max_fds))
+        return NULL;
+
+    struct file **fds = READ_KERN(fdt->fd);
+    struct file *file = NULL;
+    bpf_probe_read(&file, sizeof(file), &fds[fd]);
+    if (file == NULL)
+        return NULL;
+
+    struct inode *inode = READ_KERN(file->f_inode);
+    if (inode == NULL || (READ_KERN(inode->i_mode) & S_IFMT) != S_IFSOCK)
+        return NULL;
+
+    return file;
+}
+
+#ifdef BTF_SUPPORTED
+// the references of the files before 6.13 (f_count) and since (f_ref, counted from 0)
+struct file___count
+{
+    atomic_long_t f_count;
+} __attribute__((preserve_access_index));
+
+struct file___ref
+{
+    struct
+    {
+        atomic_long_t refcnt;
+    } f_ref;
+} __attribute__((preserve_access_index));
+#endif
+
+// whether the fd table holds the only reference of a file
+static __always_inline int is_last_file_ref(struct file *file)
+{
+#ifdef BTF_SUPPORTED
+    if (bpf_core_field_exists(((struct file___ref *)file)->f_ref))
+        return READ_KERN(((struct file___ref *)file)->f_ref.refcnt.counter) == 0;
+
+    return READ_KERN(((struct file___count *)file)->f_count.counter) == 1;
+#elif LINUX_VERSION_CODE >= KERNEL_VERSION(6, 13, 0)
+    return READ_KERN(file->f_ref.refcnt.counter) == 0;
+#else
+    return READ_KERN(file->f_count.counter) == 1;
+#endif
+}
+
+// struct socket -> cookie of the socket
+BPF_MAP(sock_cookies, BPF_MAP_TYPE_LRU_HASH, u64, u64, 65536);
+BPF_PERCPU_ARRAY(sock_cookie_gen, u64, 1);
+
+// the cookie of the socket of an fd of the current task (0 if it isn't a socket or its creation wasn't seen), which
+// identifies the socket across fork, exec and fd passing (SCM_RIGHTS), unlike the fd, and is never reused, unlike the
+// inode number (the cookies of the kernel can't be generated from kprobes, so the sockets get their own ones)
+static __always_inline u64 get_socket_id(long fd, int create)
+{
+    struct file *file = get_socket_file(fd);
+    if (file == NULL)
+        return 0;
+
+    u64 sock = (u64)READ_KERN(file->private_data);
+    if (sock == 0)
+        return 0;
+
+    if (!create)
+    {
+        u64 *cookie = bpf_map_lookup_elem(&sock_cookies, &sock);
+        return cookie == NULL ? 0 : *cookie;
+    }
+
+    u32 zero = 0;
+    u64 *gen = bpf_map_lookup_elem(&sock_cookie_gen, &zero);
+    if (gen == NULL)
+        return 0;
+
+    // unique across the cpus
+    *gen += 1;
+    u64 cookie = (*gen << 12) | (bpf_get_smp_processor_id() & 0xfff);
+
+    bpf_map_update_elem(&sock_cookies, &sock, &cookie, BPF_ANY);
+
+    return cookie;
+}
+
+// the cookie of the socket of an fd being closed if it is its last fd (0 otherwise), the socket is forgotten then
+static __always_inline u64 put_socket_id(long fd)
+{
+    struct file *file = get_socket_file(fd);
+    if (file == NULL || !is_last_file_ref(file))
+        return 0;
+
+    u64 sock = (u64)READ_KERN(file->private_data);
+
+    u64 *cookie = bpf_map_lookup_elem(&sock_cookies, &sock);
+    if (cookie == NULL)
+        return 0;
+
+    u64 id = *cookie;
+    bpf_map_delete_elem(&sock_cookies, &sock);
+
+    return id;
+}
+
 static __always_inline void get_outer_key(struct outer_key *pokey,
                                           struct task_struct *t)
 {
@@ -910,6 +1061,23 @@ static __always_inline int save_args_to_buffer(u64 types, args_t *args)
         case UNLINKAT_FLAG_T:
             save_to_buffer(bufs_p, (void *)&(args->args[i]), sizeof(int), UNLINKAT_FLAG_T);
             break;
+        case NS_FLAGS_T:
+            save_to_buffer(bufs_p, (void *)&(args->args[i]), sizeof(int), NS_FLAGS_T);
+            break;
+        case SOCK_ID_T:
+            save_to_buffer(bufs_p, (void *)&(args->args[i]), sizeof(u64), SOCK_ID_T);
+            break;
+        case FILE_FLAGS_T:
+        {
+            // the argument is a pointer to the new inode flags
+            int flags = 0;
+            if (args->args[i])
+            {
+                bpf_probe_read(&flags, sizeof(int), (void *)args->args[i]);
+            }
+            save_to_buffer(bufs_p, (void *)&flags, sizeof(int), FILE_FLAGS_T);
+            break;
+        }
         }
     }
 
@@ -1335,10 +1503,17 @@ int kprobe__do_exit(struct pt_regs *ctx)
 
     const long code = PT_REGS_PARM1(ctx);
 
+    // whether the last thread of the process exits (its sockets are forgotten then)
+    struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+    struct signal_struct *signal = READ_KERN(task->signal);
+
+    args_t args = {};
+    args.args[0] = (signal != NULL && READ_KERN(signal->live.counter) == 1);
+
     init_context(&context);
 
     context.event_id = _DO_EXIT;
-    context.argnum = 0;
+    context.argnum = 1;
     context.retval = code;
 
     remove_pid_ns();
@@ -1350,6 +1525,7 @@ int kprobe__do_exit(struct pt_regs *ctx)
         return 0;
 
     save_context_to_buffer(bufs_p, (void *)&context);
+    save_args_to_buffer(ARG_TYPE0(INT_T), &args);
 
     events_perf_submit(ctx);
 
@@ -1424,7 +1600,12 @@ static __always_inline int get_arg_num(u64 types)
     return argnum;
 }
 
-static __always_inline int trace_ret_generic(u32 id, struct pt_regs *ctx, u64 types, u32 scope)
+// the sockets of the syscalls of sockets (SOCK_ID_T), the one of their fd argument in args[4] and the one of their
+// returned fd in args[5] (a socket created then)
+#define SOCK_ARG 1
+#define SOCK_RET 2
+
+static __always_inline int trace_ret_sockets(u32 id, struct pt_regs *ctx, u64 types, u32 scope, u32 sockets)
 {
     if (skip_syscall())
         return 0;
@@ -1456,6 +1637,11 @@ static __always_inline int trace_ret_generic(u32 id, struct pt_regs *ctx, u64 ty
         return 0;
     }
 
+    if (sockets & SOCK_ARG)
+        args.args[4] = get_socket_id(args.args[0], 0);
+    if ((sockets & SOCK_RET) && context.retval >= 0)
+        args.args[5] = get_socket_id(context.retval, 1);
+
     set_buffer_offset(DATA_BUF_TYPE, sizeof(sys_context_t));
 
     bufs_t *bufs_p = get_buffer(DATA_BUF_TYPE);
@@ -1468,6 +1654,11 @@ static __always_inline int trace_ret_generic(u32 id, struct pt_regs *ctx, u64 ty
     return 0;
 }
 
+static __always_inline int trace_ret_generic(u32 id, struct pt_regs *ctx, u64 types, u32 scope)
+{
+    return trace_ret_sockets(id, ctx, types, scope, 0);
+}
+
 #define DIR_PROC "/proc/"
 static __always_inline int isProcDir(char *path)
 {
@@ -1605,13 +1796,25 @@ int kprobe__close(struct pt_regs *ctx)
     if (skip_syscall())
         return 0;
 
-    return save_args(_SYS_CLOSE, ctx);
+    save_args(_SYS_CLOSE, ctx);
+
+    u32 tgid = bpf_get_current_pid_tgid();
+    u64 id = ((u64)_SYS_CLOSE << 32) | tgid;
+
+    args_t *args = bpf_map_lookup_elem(&args_map, &id);
+    if (args == NULL)
+        return 0;
+
+    // the socket closed with its last fd, the fd is released on return
+    args->args[4] = put_socket_id(args->args[0]);
+
+    return 0;
 }
 
 SEC("kretprobe/__x64_sys_close")
 int kretprobe__close(struct pt_regs *ctx)
 {
-    return trace_ret_generic(_SYS_CLOSE, ctx, ARG_TYPE0(INT_T), _FILE_PROBE);
+    return trace_ret_generic(_SYS_CLOSE, ctx, ARG_TYPE0(INT_T) | ARG_TYPE4(SOCK_ID_T), _FILE_PROBE);
 }
 
 SEC("kprobe/__x64_sys_chown")
@@ -1642,6 +1845,245 @@ int kretprobe__fchownat(struct pt_regs *ctx)
     return trace_ret_generic(_SYS_FCHOWNAT, ctx, ARG_TYPE0(INT_T) | ARG_TYPE1(FILE_TYPE_T) | ARG_TYPE2(INT_T) | ARG_TYPE3(INT_T) | ARG_TYPE4(INT_T), _FILE_PROBE);
 }
 
+SEC("kprobe/__x64_sys_setxattr")
+int kprobe__setxattr(struct pt_regs *ctx)
+{
+    if (skip_syscall())
+        return 0;
+    return save_args(_SYS_SETXATTR, ctx);
+}
+
+SEC("kretprobe/__x64_sys_setxattr")
+int kretprobe__setxattr(struct pt_regs *ctx)
+{
+    return trace_ret_generic(_SYS_SETXATTR, ctx, ARG_TYPE0(FILE_TYPE_T) | ARG_TYPE1(STR_T), _FILE_PROBE);
+}
+
+SEC("kprobe/__x64_sys_lsetxattr")
+int kprobe__lsetxattr(struct pt_regs *ctx)
+{
+    if (skip_syscall())
+        return 0;
+    return save_args(_SYS_LSETXATTR, ctx);
+}
+
+SEC("kretprobe/__x64_sys_lsetxattr")
+int kretprobe__lsetxattr(struct pt_regs *ctx)
+{
+    return trace_ret_generic(_SYS_LSETXATTR, ctx, ARG_TYPE0(FILE_TYPE_T) | ARG_TYPE1(STR_T), _FILE_PROBE);
+}
+
+SEC("kprobe/__x64_sys_fsetxattr")
+int kprobe__fsetxattr(struct pt_regs *ctx)
+{
+    if (skip_syscall())
+        return 0;
+    return save_args(_SYS_FSETXATTR, ctx);
+}
+
+SEC("kretprobe/__x64_sys_fsetxattr")
+int kretprobe__fsetxattr(struct pt_regs *ctx)
+{
+    return trace_ret_generic(_SYS_FSETXATTR, ctx, ARG_TYPE0(INT_T) | ARG_TYPE1(STR_T), _FILE_PROBE);
+}
+
+SEC("kprobe/__x64_sys_removexattr")
+int kprobe__removexattr(struct pt_regs *ctx)
+{
+    if (skip_syscall())
+        return 0;
+    return save_args(_SYS_REMOVEXATTR, ctx);
+}
+
+SEC("kretprobe/__x64_sys_removexattr")
+int kretprobe__removexattr(struct pt_regs *ctx)
+{
+    return trace_ret_generic(_SYS_REMOVEXATTR, ctx, ARG_TYPE0(FILE_TYPE_T) | ARG_TYPE1(STR_T), _FILE_PROBE);
+}
+
+SEC("kprobe/__x64_sys_lremovexattr")
+int kprobe__lremovexattr(struct pt_regs *ctx)
+{
+    if (skip_syscall())
+        return 0;
+    return save_args(_SYS_LREMOVEXATTR, ctx);
+}
+
+SEC("kretprobe/__x64_sys_lremovexattr")
+int kretprobe__lremovexattr(struct pt_regs *ctx)
+{
+    return trace_ret_generic(_SYS_LREMOVEXATTR, ctx, ARG_TYPE0(FILE_TYPE_T) | ARG_TYPE1(STR_T), _FILE_PROBE);
+}
+
+SEC("kprobe/__x64_sys_fremovexattr")
+int kprobe__fremovexattr(struct pt_regs *ctx)
+{
+    if (skip_syscall())
+        return 0;
+    return save_args(_SYS_FREMOVEXATTR, ctx);
+}
+
+SEC("kretprobe/__x64_sys_fremovexattr")
+int kretprobe__fremovexattr(struct pt_regs *ctx)
+{
+    return trace_ret_generic(_SYS_FREMOVEXATTR, ctx, ARG_TYPE0(INT_T) | ARG_TYPE1(STR_T), _FILE_PROBE);
+}
+
+SEC("kprobe/__x64_sys_ioctl")
+int kprobe__ioctl(struct pt_regs *ctx)
+{
+    if (skip_syscall())
+        return 0;
+
+    // only trace the changes of inode flags (e.g., chattr +i)
+    unsigned int cmd = 0;
+#if LINUX_VERSION_CODE < KERNEL_VERSION(4, 17, 0)
+    cmd = PT_REGS_PARM2(ctx);
+#else
+    struct pt_regs *ctx2 = (struct pt_regs *)PT_REGS_PARM1(ctx);
+    bpf_probe_read(&cmd, sizeof(cmd), &PT_REGS_PARM2(ctx2));
+#endif
+    if (cmd != FS_IOC_SETFLAGS && cmd != FS_IOC32_SETFLAGS)
+        return 0;
+
+    return save_args(_SYS_IOCTL, ctx);
+}
+
+SEC("kretprobe/__x64_sys_ioctl")
+int kretprobe__ioctl(struct pt_regs *ctx)
+{
+    return trace_ret_generic(_SYS_IOCTL, ctx, ARG_TYPE0(INT_T) | ARG_TYPE2(FILE_FLAGS_T), _FILE_PROBE);
+}
+
+SEC("kprobe/__x64_sys_unshare")
+int kprobe__unshare(struct pt_regs *ctx)
+{
+    if (skip_syscall())
+        return 0;
+
+    // only trace new network namespaces
+    unsigned long flags = 0;
+#if LINUX_VERSION_CODE < KERNEL_VERSION(4, 17, 0)
+    flags = PT_REGS_PARM1(ctx);
+#else
+    struct pt_regs *ctx2 = (struct pt_regs *)PT_REGS_PARM1(ctx);
+    bpf_probe_read(&flags, sizeof(flags), &PT_REGS_PARM1(ctx2));
+#endif
+    if (!(flags & CLONE_NEWNET))
+        return 0;
+
+    return save_args(_SYS_UNSHARE, ctx);
+}
+
+SEC("kretprobe/__x64_sys_unshare")
+int kretprobe__unshare(struct pt_regs *ctx)
+{
+    return trace_ret_generic(_SYS_UNSHARE, ctx, ARG_TYPE0(NS_FLAGS_T), _PROCESS_PROBE);
+}
+
+SEC("kprobe/__x64_sys_setns")
+int kprobe__setns(struct pt_regs *ctx)
+{
+    if (skip_syscall())
+        return 0;
+
+    // only trace network namespaces (nstype 0 allows any namespace, resolved in userspace)
+    int nstype = 0;
+#if LINUX_VERSION_CODE < KERNEL_VERSION(4, 17, 0)
+    nstype = PT_REGS_PARM2(ctx);
+#else
+    struct pt_regs *ctx2 = (struct pt_regs *)PT_REGS_PARM1(ctx);
+    bpf_probe_read(&nstype, sizeof(nstype), &PT_REGS_PARM2(ctx2));
+#endif
+    if (nstype != 0 && nstype != CLONE_NEWNET)
+        return 0;
+
+    return save_args(_SYS_SETNS, ctx);
+}
+
+SEC("kretprobe/__x64_sys_setns")
+int kretprobe__setns(struct pt_regs *ctx)
+{
+    return trace_ret_generic(_SYS_SETNS, ctx, ARG_TYPE0(INT_T) | ARG_TYPE1(NS_FLAGS_T), _PROCESS_PROBE);
+}
+
+SEC("kprobe/security_task_kill")
+int kprobe__security_task_kill(struct pt_regs *ctx)
+{
+    if (skip_syscall())
+        return 0;
+
+    // signal 0 only checks if the target exists
+    int sig = (int)PT_REGS_PARM3(ctx);
+    if (sig <= 0)
+        return 0;
+
+    //  args:  struct task_struct *p, struct kernel_siginfo *info, int sig, const struct cred *cred
+    args_t args = {};
+    args.args[0] = PT_REGS_PARM1(ctx);
+    args.args[2] = sig;
+
+    u32 tgid = bpf_get_current_pid_tgid();
+    u64 id = ((u64)_SECURITY_TASK_KILL << 32) | tgid;
+
+    bpf_map_update_elem(&args_map, &id, &args, BPF_ANY);
+
+    return 0;
+}
+
+SEC("kretprobe/security_task_kill")
+int kretprobe__security_task_kill(struct pt_regs *ctx)
+{
+    if (skip_syscall())
+        return 0;
+
+    sys_context_t context = {};
+    args_t args = {};
+
+    if (load_args(_SECURITY_TASK_KILL, &args) != 0)
+        return 0;
+
+    struct task_struct *p = (struct task_struct *)args.args[0];
+    if (p == NULL)
+        return 0;
+
+    init_context(&context);
+
+    context.event_id = _SECURITY_TASK_KILL;
+    context.argnum = 6;
+    context.retval = PT_REGS_RC(ctx);
+
+    // the denied signals are reported regardless of the visibility
+    if (context.retval >= 0 && drop_syscall(_SIGNAL_PROBE))
+        return 0;
+
+    // the target, which is resolved to its container in userspace
+    int sig = args.args[2];
+    int pid = READ_KERN(p->tgid);
+    struct task_struct *parent = READ_KERN(p->real_parent);
+    int ppid = READ_KERN(parent->tgid);
+    int pid_ns = get_task_pid_ns_id(p);
+    int mnt_ns = get_task_mnt_ns_id(p);
+
+    set_buffer_offset(DATA_BUF_TYPE, sizeof(sys_context_t));
+
+    bufs_t *bufs_p = get_buffer(DATA_BUF_TYPE);
+    if (bufs_p == NULL)
+        return 0;
+
+    save_context_to_buffer(bufs_p, (void *)&context);
+    save_to_buffer(bufs_p, (void *)&sig, sizeof(int), INT_T);
+    save_to_buffer(bufs_p, (void *)&pid, sizeof(int), INT_T);
+    save_to_buffer(bufs_p, (void *)&ppid, sizeof(int), INT_T);
+    save_to_buffer(bufs_p, (void *)&pid_ns, sizeof(int), INT_T);
+    save_to_buffer(bufs_p, (void *)&mnt_ns, sizeof(int), INT_T);
+    save_str_to_buffer(bufs_p, (void *)GET_FIELD_ADDR(p->comm));
+
+    events_perf_submit(ctx);
+
+    return 0;
+}
+
 SEC("kprobe/__x64_sys_setuid")
 int kprobe__setuid(struct pt_regs *ctx)
 {
@@ -1790,7 +2232,7 @@ int kprobe__socket(struct pt_regs *ctx)
 SEC("kretprobe/__x64_sys_socket")
 int kretprobe__socket(struct pt_regs *ctx)
 {
-    return trace_ret_generic(_SYS_SOCKET, ctx, ARG_TYPE0(SOCK_DOM_T) | ARG_TYPE1(SOCK_TYPE_T) | ARG_TYPE2(INT_T), _NETWORK_PROBE);
+    return trace_ret_sockets(_SYS_SOCKET, ctx, ARG_TYPE0(SOCK_DOM_T) | ARG_TYPE1(SOCK_TYPE_T) | ARG_TYPE2(INT_T) | ARG_TYPE5(SOCK_ID_T), _NETWORK_PROBE, SOCK_RET);
 }
 
 SEC("kprobe/__x64_sys_connect")
@@ -1805,7 +2247,7 @@ int kprobe__connect(struct pt_regs *ctx)
 SEC("kretprobe/__x64_sys_connect")
 int kretprobe__connect(struct pt_regs *ctx)
 {
-    return trace_ret_generic(_SYS_CONNECT, ctx, ARG_TYPE0(INT_T) | ARG_TYPE1(SOCKADDR_T), _NETWORK_PROBE);
+    return trace_ret_sockets(_SYS_CONNECT, ctx, ARG_TYPE0(INT_T) | ARG_TYPE1(SOCKADDR_T) | ARG_TYPE4(SOCK_ID_T), _NETWORK_PROBE, SOCK_ARG);
 }
 
 SEC("kprobe/__x64_sys_accept")
@@ -1820,7 +2262,7 @@ int kprobe__accept(struct pt_regs *ctx)
 SEC("kretprobe/__x64_sys_accept")
 int kretprobe__accept(struct pt_regs *ctx)
 {
-    return trace_ret_generic(_SYS_ACCEPT, ctx, ARG_TYPE0(INT_T) | ARG_TYPE1(SOCKADDR_T), _NETWORK_PROBE);
+    return trace_ret_sockets(_SYS_ACCEPT, ctx, ARG_TYPE0(INT_T) | ARG_TYPE1(SOCKADDR_T) | ARG_TYPE4(SOCK_ID_T) | ARG_TYPE5(SOCK_ID_T), _NETWORK_PROBE, SOCK_ARG | SOCK_RET);
 }
 
 SEC("kprobe/__x64_sys_bind")
@@ -1861,6 +2303,7 @@ static __always_inline int get_connection_info(struct sock_common *conn, struct
         sockv4->sin_family = conn->skc_family;
         sockv4->sin_addr.s_addr = conn->skc_daddr;
         sockv4->sin_port = (event == _TCP_CONNECT) ? conn->skc_dport : (conn->skc_num >> 8) | (conn->skc_num << 8);
+        __builtin_memset(sockv4->sin_zero, 0, sizeof(sockv4->sin_zero));
         args->args[1] = (unsigned long)sockv4;
         context->event_id = (event == _TCP_CONNECT) ? _TCP_CONNECT : _TCP_ACCEPT;
         break;
@@ -1869,6 +2312,9 @@ static __always_inline int get_connection_info(struct sock_common *conn, struct
         sockv6->sin6_family = conn->skc_family;
         sockv6->sin6_port = (event == _TCP_CONNECT) ? conn->skc_dport : (conn->skc_num >> 8) | (conn->skc_num << 8);
         bpf_probe_read(&sockv6->sin6_addr.in6_u.u6_addr16, sizeof(sockv6->sin6_addr.in6_u.u6_addr16), conn->skc_v6_daddr.in6_u.u6_addr16);
+        sockv6->sin6_flowinfo = 0;
+        // the interface of the link-local peers, which is the zone of their addresses
+        sockv6->sin6_scope_id = conn->skc_bound_dev_if;
         args->args[1] = (unsigned long)sockv6;
         context->event_id = (event == _TCP_CONNECT) ? _TCP_CONNECT_v6 : _TCP_ACCEPT_v6;
         break;
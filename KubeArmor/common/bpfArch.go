@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package common
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/cilium/ebpf/btf"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ============== //
+// == BPF Arch == //
+// ============== //
+
+// features of KubeArmor, whose BPF programs are built per architecture
+const (
+	BPFFeatureSystemMonitor            = "system monitor"
+	BPFFeatureProcessEnforcement       = "process enforcement"
+	BPFFeatureFileEnforcement          = "file enforcement"
+	BPFFeaturePathEnforcement          = "path enforcement"
+	BPFFeatureNetworkEnforcement       = "network enforcement"
+	BPFFeatureSignalEnforcement        = "signal enforcement"
+	BPFFeatureRuntimeSocketEnforcement = "runtime socket enforcement"
+	BPFFeatureFileAttributeEnforcement = "file attribute enforcement"
+)
+
+// BPFFeatures are all the features of KubeArmor with BPF programs
+var BPFFeatures = []string{
+	BPFFeatureSystemMonitor,
+	BPFFeatureProcessEnforcement,
+	BPFFeatureFileEnforcement,
+	BPFFeaturePathEnforcement,
+	BPFFeatureNetworkEnforcement,
+	BPFFeatureSignalEnforcement,
+	BPFFeatureRuntimeSocketEnforcement,
+	BPFFeatureFileAttributeEnforcement,
+}
+
+// BPFArchFeatures are the features whose BPF programs are available per architecture
+var BPFArchFeatures = map[string][]string{
+	"amd64": BPFFeatures,
+	"arm64": BPFFeatures,
+	// the socket hooks read the socket addresses with the little-endian layouts
+	"s390x": {
+		BPFFeatureSystemMonitor,
+		BPFFeatureProcessEnforcement,
+		BPFFeatureFileEnforcement,
+		BPFFeaturePathEnforcement,
+		BPFFeatureSignalEnforcement,
+		BPFFeatureFileAttributeEnforcement,
+	},
+}
+
+// BPFArch is the architecture the BPF objects are selected for (overridden by the tests)
+var BPFArch = runtime.GOARCH
+
+// bpfArchFeature checks if the programs of a feature are built for the architecture
+func bpfArchFeature(feature string) bool {
+	return ContainsElement(BPFArchFeatures[BPFArch], feature)
+}
+
+// bpfKernelHooks are the LSM hooks of the features whose programs are built for their prototypes since Linux 5.12
+// (with the mount idmap, or user namespace, first), by their numbers of arguments
+var bpfKernelHooks = map[string]map[string]int{
+	BPFFeatureFileAttributeEnforcement: {
+		"bpf_lsm_inode_setxattr":    6,
+		"bpf_lsm_inode_removexattr": 3,
+	},
+}
+
+var (
+	kernelHookArgs     map[string]int
+	kernelHookArgsOnce sync.Once
+)
+
+// getKernelHookArgs returns the numbers of arguments of the LSM hooks of the kernel (overridden by the tests)
+var getKernelHookArgs = func() map[string]int {
+	kernelHookArgsOnce.Do(func() {
+		kernelHookArgs = map[string]int{}
+
+		// without the BTF of the kernel, the BPF LSM enforcer isn't loaded at all
+		spec, err := btf.LoadKernelSpec()
+		if err != nil {
+			return
+		}
+
+		for _, hooks := range bpfKernelHooks {
+			for hook := range hooks {
+				var fn *btf.Func
+				if err := spec.TypeByName(hook, &fn); err != nil {
+					continue
+				}
+				if proto, ok := fn.Type.(*btf.FuncProto); ok {
+					kernelHookArgs[hook] = len(proto.Params)
+				}
+			}
+		}
+	})
+
+	return kernelHookArgs
+}
+
+// bpfKernelFeatureError returns why the hooks of a feature differ on the kernel (nil if unknown)
+func bpfKernelFeatureError(feature string) error {
+	args := getKernelHookArgs()
+
+	for hook, want := range bpfKernelHooks[feature] {
+		if got, ok := args[hook]; ok && got != want {
+			return fmt.Errorf("%s unavailable on this kernel (%s has %d arguments)", feature, hook, got)
+		}
+	}
+
+	return nil
+}
+
+// BPFFeatureAvailable Function checks if the programs of a feature are available on the architecture
+func BPFFeatureAvailable(feature string) bool {
+	return BPFFeatureError(feature) == nil
+}
+
+// BPFFeatureError Function returns why a feature is unavailable on the architecture or the kernel (nil if available)
+func BPFFeatureError(feature string) error {
+	if !bpfArchFeature(feature) {
+		return fmt.Errorf("%s unavailable on %s", feature, BPFArch)
+	}
+
+	return bpfKernelFeatureError(feature)
+}
+
+// GetBPFFeatures Function returns the availability of all the features on the architecture
+func GetBPFFeatures() []tp.BPFFeatureState {
+	features := []tp.BPFFeatureState{}
+
+	for _, feature := range BPFFeatures {
+		state := tp.BPFFeatureState{Feature: feature, Arch: BPFArch, Available: true}
+		if err := BPFFeatureError(feature); err != nil {
+			state.Available = false
+			state.Reason = err.Error()
+		}
+		features = append(features, state)
+	}
+
+	sort.Slice(features, func(i, j int) bool {
+		return features[i].Feature < features[j].Feature
+	})
+
+	return features
+}
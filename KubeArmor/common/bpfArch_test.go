@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package common
+
+import (
+	"testing"
+)
+
+func TestBPFFeatureErrorKernel(t *testing.T) {
+	prevArch := BPFArch
+	prevArgs := getKernelHookArgs
+	defer func() {
+		BPFArch = prevArch
+		getKernelHookArgs = prevArgs
+	}()
+
+	BPFArch = "amd64"
+
+	// the hooks of Linux 5.12 and later
+	getKernelHookArgs = func() map[string]int {
+		return map[string]int{"bpf_lsm_inode_setxattr": 6, "bpf_lsm_inode_removexattr": 3}
+	}
+	if err := BPFFeatureError(BPFFeatureFileAttributeEnforcement); err != nil {
+		t.Errorf("[FAIL] Unexpected error with the hooks of Linux 5.12 (%s)", err.Error())
+	}
+
+	// the hooks before the mount idmaps (or user namespaces)
+	getKernelHookArgs = func() map[string]int {
+		return map[string]int{"bpf_lsm_inode_setxattr": 5, "bpf_lsm_inode_removexattr": 2}
+	}
+	err := BPFFeatureError(BPFFeatureFileAttributeEnforcement)
+	if err == nil || (err.Error() != "file attribute enforcement unavailable on this kernel (bpf_lsm_inode_setxattr has 5 arguments)" &&
+		err.Error() != "file attribute enforcement unavailable on this kernel (bpf_lsm_inode_removexattr has 2 arguments)") {
+		t.Errorf("[FAIL] Unexpected error with the hooks of older kernels (%v)", err)
+	}
+	if !BPFFeatureAvailable(BPFFeatureSignalEnforcement) {
+		t.Errorf("[FAIL] Expected the other features with the hooks of older kernels")
+	}
+
+	// unknown hooks (no BTF)
+	getKernelHookArgs = func() map[string]int {
+		return map[string]int{}
+	}
+	if !BPFFeatureAvailable(BPFFeatureFileAttributeEnforcement) {
+		t.Errorf("[FAIL] Expected file attribute enforcement without the BTF of the kernel")
+	}
+
+	// the architecture first
+	BPFArch = "riscv64"
+	if err := BPFFeatureError(BPFFeatureFileAttributeEnforcement); err == nil || err.Error() != "file attribute enforcement unavailable on riscv64" {
+		t.Errorf("[FAIL] Unexpected error on riscv64 (%v)", err)
+	}
+
+	t.Log("[PASS] Checked the BPF features on the kernel")
+}
@@ -375,40 +375,6 @@ func IsK8sEnv() bool {
 	return false
 }
 
-// ContainerRuntimeSocketMap Structure
-var ContainerRuntimeSocketMap = map[string][]string{
-	"docker": {
-		"/var/run/docker.sock",
-		"/run/docker.sock",
-	},
-	"containerd": {
-		"/var/snap/microk8s/common/run/containerd.sock",
-		"/run/k3s/containerd/containerd.sock",
-		"/run/containerd/containerd.sock",
-		"/var/run/containerd/containerd.sock",
-		"/run/dockershim.sock",
-	},
-	"cri-o": {
-		"/var/run/crio/crio.sock",
-		"/run/crio/crio.sock",
-	},
-}
-
-// GetCRISocket Function
-func GetCRISocket(ContainerRuntime string) string {
-	for k := range ContainerRuntimeSocketMap {
-		if ContainerRuntime != "" && k != ContainerRuntime {
-			continue
-		}
-		for _, candidate := range ContainerRuntimeSocketMap[k] {
-			if _, err := os.Stat(candidate); err == nil {
-				return candidate
-			}
-		}
-	}
-	return ""
-}
-
 // GetControllingPodOwner Function returns the pod's Controlling OnwerReference
 func GetControllingPodOwner(ownerRefs []metav1.OwnerReference) *metav1.OwnerReference {
 	for _, ownerRef := range ownerRefs {
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package common
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ============ //
+// == Images == //
+// ============ //
+
+// The image names and digests are only taken from what the container runtime (or the kubelet) reports.
+// Nothing here resolves a tag into a digest, so no registry is ever contacted (air-gapped clusters,
+// no imagePullSecrets needed), and a tag which is moved in the registry is only seen once a container
+// is started from it.
+
+const (
+	defaultRegistry = "docker.io"
+	defaultRepoPath = "library/"
+	defaultImageTag = "latest"
+)
+
+// imageRefPrefixes are the prefixes of the image IDs reported by the runtimes (e.g., docker-pullable://nginx@sha256:...)
+var imageRefPrefixes = []string{"docker-pullable://", "docker://", "containerd://", "cri-o://"}
+
+// ParseImage splits an image into its normalized name, its tag and its digest,
+// e.g., "nginx" -> ("docker.io/library/nginx", "latest", ""),
+// "quay.io/org/app@sha256:abc" -> ("quay.io/org/app", "", "sha256:abc"),
+// and "sha256:abc" (an image ID) -> ("", "", "sha256:abc")
+func ParseImage(image string) (string, string, string) {
+	image = strings.TrimSpace(image)
+	for _, prefix := range imageRefPrefixes {
+		image = strings.TrimPrefix(image, prefix)
+	}
+
+	if image == "" {
+		return "", "", ""
+	}
+
+	digest := ""
+	if idx := strings.Index(image, "@"); idx != -1 {
+		image, digest = image[:idx], image[idx+1:]
+	}
+
+	// an image ID (sha256:<hex>) without any name
+	if strings.HasPrefix(image, "sha256:") {
+		return "", "", image
+	}
+
+	tag := ""
+	if idx := strings.LastIndex(image, ":"); idx > strings.LastIndex(image, "/") {
+		image, tag = image[:idx], image[idx+1:]
+	}
+
+	// the first component is a registry if it has a domain, a port, or is localhost (or a pattern in a selector)
+	registry, repo := defaultRegistry, image
+	if idx := strings.Index(image, "/"); idx != -1 {
+		if first := image[:idx]; strings.ContainsAny(first, ".:*?[") || first == "localhost" {
+			registry, repo = first, image[idx+1:]
+		}
+	}
+
+	if registry == "index.docker.io" || registry == "registry-1.docker.io" {
+		registry = defaultRegistry
+	}
+	if registry == defaultRegistry && !strings.Contains(repo, "/") {
+		repo = defaultRepoPath + repo
+	}
+
+	// a pinned image has no default tag
+	if tag == "" && digest == "" {
+		tag = defaultImageTag
+	}
+
+	return registry + "/" + repo, tag, digest
+}
+
+// NormalizeImage returns an image as registry/repository[:tag][@digest], so that the image names
+// reported by docker, containerd and CRI-O are all the same for the same image
+func NormalizeImage(image string) string {
+	name, tag, digest := ParseImage(image)
+
+	normalized := name
+	if tag != "" {
+		normalized = normalized + ":" + tag
+	}
+	if digest != "" {
+		if normalized == "" {
+			return digest
+		}
+		normalized = normalized + "@" + digest
+	}
+
+	return normalized
+}
+
+// GetImageDigest returns the digest in an image reference reported by a runtime (empty if there is none)
+func GetImageDigest(imageRef string) string {
+	_, _, digest := ParseImage(imageRef)
+	return digest
+}
+
+// GetContainerImage returns the image of a container from the image name and the image reference reported by a runtime
+func GetContainerImage(imageName, imageRef string) string {
+	image := NormalizeImage(imageName)
+
+	// the name has a digest already
+	if GetImageDigest(image) != "" {
+		return image
+	}
+
+	if digest := GetImageDigest(imageRef); digest != "" {
+		if image == "" {
+			return digest
+		}
+		return image + "@" + digest
+	}
+
+	return image
+}
+
+// MatchImage checks if an image selector matches the image of a container, where a selector with a digest
+// is only compared with the digest reported by the runtime, and any other selector is matched (as a glob pattern)
+// against the image name reported by the runtime
+func MatchImage(selector, containerImage string) bool {
+	name, tag, digest := ParseImage(selector)
+	imageName, imageTag, imageDigest := ParseImage(containerImage)
+
+	if digest != "" {
+		return digest == imageDigest
+	}
+
+	if imageName == "" {
+		return false
+	}
+
+	if matched, err := filepath.Match(name, imageName); err != nil || !matched {
+		return false
+	}
+
+	// an image pinned by its digest only has no tag (only matched by "*")
+	matched, err := filepath.Match(tag, imageTag)
+	return err == nil && matched
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package common
+
+import (
+	"testing"
+)
+
+const testDigest = "sha256:0d17b565c37bcbd895e9d92315a05c1c3c9a29f762b011a10c54a66cd53c9b31"
+
+func TestGetContainerImage(t *testing.T) {
+	for _, tc := range []struct {
+		runtime   string
+		imageName string
+		imageRef  string
+		expected  string
+	}{
+		// docker (the image as it was run)
+		{"docker", "nginx", "", "docker.io/library/nginx:latest"},
+		{"docker", "nginx:1.25", "", "docker.io/library/nginx:1.25"},
+		{"docker", "library/nginx", "", "docker.io/library/nginx:latest"},
+		{"docker", "index.docker.io/library/nginx:1.25", "", "docker.io/library/nginx:1.25"},
+		{"docker", " bitnami/redis:7.2 ", "", "docker.io/bitnami/redis:7.2"},
+		{"docker", "localhost:5000/app", "", "localhost:5000/app:latest"},
+		{"docker", "registry:5000/team/app:v1", "", "registry:5000/team/app:v1"},
+		{"docker", testDigest, "", testDigest},
+
+		// containerd (the image as it was pulled)
+		{"containerd", "docker.io/library/nginx:latest", "", "docker.io/library/nginx:latest"},
+		{"containerd", "docker.io/library/nginx@" + testDigest, "", "docker.io/library/nginx@" + testDigest},
+		{"containerd", "ghcr.io/org/app:v2", "", "ghcr.io/org/app:v2"},
+
+		// CRI-O (the image and the image ref)
+		{"crio", "quay.io/org/app:v1", "quay.io/org/app@" + testDigest, "quay.io/org/app:v1@" + testDigest},
+		{"crio", "docker.io/library/nginx:latest", "docker.io/library/nginx@" + testDigest, "docker.io/library/nginx:latest@" + testDigest},
+		{"crio", "", "quay.io/org/app@" + testDigest, testDigest},
+
+		// kubelet (the image and the image ID)
+		{"kubelet", "nginx", "docker-pullable://nginx@" + testDigest, "docker.io/library/nginx:latest@" + testDigest},
+		{"kubelet", "nginx:1.25", testDigest, "docker.io/library/nginx:1.25@" + testDigest},
+		{"kubelet", "", "", ""},
+	} {
+		if image := GetContainerImage(tc.imageName, tc.imageRef); image != tc.expected {
+			t.Errorf("[FAIL] Unexpected image of %q/%q from %s (%q, expected %q)", tc.imageName, tc.imageRef, tc.runtime, image, tc.expected)
+		}
+	}
+
+	t.Log("[PASS] Normalized the images reported by the runtimes")
+}
+
+func TestMatchImage(t *testing.T) {
+	// the same image as reported by docker, containerd, and CRI-O
+	images := map[string]string{
+		"docker":     GetContainerImage("nginx:1.25", ""),
+		"containerd": GetContainerImage("docker.io/library/nginx:1.25", ""),
+		"crio":       GetContainerImage("docker.io/library/nginx:1.25", "docker.io/library/nginx@"+testDigest),
+	}
+
+	for _, tc := range []struct {
+		selector string
+		expected map[string]bool
+	}{
+		{"nginx:1.25", map[string]bool{"docker": true, "containerd": true, "crio": true}},
+		{"docker.io/library/nginx:1.*", map[string]bool{"docker": true, "containerd": true, "crio": true}},
+		{"index.docker.io/nginx:*", map[string]bool{"docker": true, "containerd": true, "crio": true}},
+		{"nginx", map[string]bool{}}, // latest
+		{"nginx:1.24", map[string]bool{}},
+		{"quay.io/nginx:1.25", map[string]bool{}},
+		{"*/library/*:*", map[string]bool{"docker": true, "containerd": true, "crio": true}},
+
+		// the digests are only known from the runtime, and are never resolved from a tag
+		{"nginx@" + testDigest, map[string]bool{"crio": true}},
+		{testDigest, map[string]bool{"crio": true}},
+		{"nginx@sha256:1111111111111111111111111111111111111111111111111111111111111111", map[string]bool{}},
+	} {
+		for runtime, image := range images {
+			if matched := MatchImage(tc.selector, image); matched != tc.expected[runtime] {
+				t.Errorf("[FAIL] Unexpected match of %q with %q from %s (%v)", tc.selector, image, runtime, matched)
+			}
+		}
+	}
+
+	t.Log("[PASS] Matched the image selectors identically on the runtimes")
+}
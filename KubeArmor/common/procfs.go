@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+)
+
+// inode of the initial pid namespace (PROC_PID_INIT_INO)
+const initPidNSInode uint32 = 0xEFFFFFFC
+
+var (
+	// the default proc mount
+	defaultProcFsMount = "/proc"
+
+	// proc mounts to look for the initial pid namespace in
+	// when the default proc mount belongs to a nested pid namespace (e.g., kind)
+	hostProcFsCandidates = []string{"/host/proc"}
+
+	// proc mount of the pid namespace where container runtimes report pids
+	procFsMount = defaultProcFsMount
+
+	// proc mount of the initial pid namespace where the kernel reports host pids
+	hostProcPath = defaultProcFsMount
+)
+
+// getPidNamespace returns the inode of the pid namespace a proc mount belongs to (0 if unknown)
+func getPidNamespace(procPath string) uint32 {
+	data, err := os.Readlink(filepath.Join(procPath, "1", "ns", "pid"))
+	if err != nil {
+		return 0
+	}
+
+	var ns uint32
+	if _, err := fmt.Sscanf(data, "pid:[%d]\n", &ns); err != nil {
+		return 0
+	}
+
+	return ns
+}
+
+// detectHostProcPath finds the proc mount of the initial pid namespace
+//   - The proc mount of runtimes belongs to the initial pid namespace
+//     (KubeArmor and the runtimes run on the host) - use it
+//   - Otherwise, the runtimes are nested (e.g., the containerd of a kind
+//     node), and host pids are resolved through the first candidate
+//     belonging to the initial pid namespace
+//   - No candidate found - fall back to the proc mount of runtimes
+func detectHostProcPath(procPath string, candidates []string) (string, bool) {
+	if getPidNamespace(procPath) == initPidNSInode {
+		return procPath, false
+	}
+
+	for _, candidate := range candidates {
+		if getPidNamespace(candidate) == initPidNSInode {
+			return candidate, true
+		}
+	}
+
+	return procPath, true
+}
+
+// SetupProcFs sets the proc mounts of runtimes and the host (autodetected if empty)
+func SetupProcFs(procPath, hostPath string) {
+	if procPath == "" {
+		procPath = defaultProcFsMount
+	}
+
+	procFsMount = filepath.Clean(procPath)
+
+	if hostPath != "" {
+		hostProcPath = filepath.Clean(hostPath)
+		kg.Printf("Using %s for container runtimes and %s for host processes", procFsMount, hostProcPath)
+		return
+	}
+
+	detected, nested := detectHostProcPath(procFsMount, hostProcFsCandidates)
+	hostProcPath = detected
+
+	if !nested {
+		kg.Printf("Using %s for container runtimes and host processes", procFsMount)
+	} else if hostProcPath != procFsMount {
+		kg.Printf("Detected nested container runtimes, using %s for container runtimes and %s for host processes", procFsMount, hostProcPath)
+	} else {
+		kg.Warnf("Detected nested container runtimes, but no proc mount of the host is found (host processes may be mis-attributed, see hostProcPath)")
+	}
+}
+
+// GetProcPath returns a path under the proc mount of container runtimes (e.g., pids from runtimes)
+func GetProcPath(elem ...string) string {
+	return filepath.Join(append([]string{procFsMount}, elem...)...)
+}
+
+// GetHostProcPath returns a path under the proc mount of the initial pid namespace (e.g., pids from the kernel)
+func GetHostProcPath(elem ...string) string {
+	return filepath.Join(append([]string{hostProcPath}, elem...)...)
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeProcRoot creates a proc root whose pid 1 is in the given pid namespace
+func fakeProcRoot(t *testing.T, pidNS string) string {
+	root := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(root, "1", "ns"), 0750); err != nil {
+		t.Fatalf("[FAIL] Failed to create a fake proc root (%s)", err.Error())
+	}
+
+	if err := os.Symlink("pid:["+pidNS+"]", filepath.Join(root, "1", "ns", "pid")); err != nil {
+		t.Fatalf("[FAIL] Failed to create a fake pid namespace (%s)", err.Error())
+	}
+
+	return root
+}
+
+func TestSetupProcFs(t *testing.T) {
+	// the proc mount of a kind node, and the one of the host
+	nodeProc := fakeProcRoot(t, "4026532460")
+	hostProc := fakeProcRoot(t, "4026531836")
+
+	if path, nested := detectHostProcPath(hostProc, nil); path != hostProc || nested {
+		t.Errorf("[FAIL] Unexpected proc mount on the host (%s, %t)", path, nested)
+	}
+
+	if path, nested := detectHostProcPath(nodeProc, []string{filepath.Join(nodeProc, "missing"), hostProc}); path != hostProc || !nested {
+		t.Errorf("[FAIL] Unexpected proc mount in a nested runtime (%s, %t)", path, nested)
+	}
+
+	if path, nested := detectHostProcPath(nodeProc, nil); path != nodeProc || !nested {
+		t.Errorf("[FAIL] Unexpected fallback in a nested runtime (%s, %t)", path, nested)
+	}
+
+	defer func(candidates []string, procPath, hostPath string) {
+		hostProcFsCandidates, procFsMount, hostProcPath = candidates, procPath, hostPath
+	}(hostProcFsCandidates, procFsMount, hostProcPath)
+
+	hostProcFsCandidates = []string{hostProc}
+
+	// runtime pids and kernel pids are resolved through different mounts
+	SetupProcFs(nodeProc, "")
+
+	if path := GetProcPath("42", "ns", "pid"); path != filepath.Join(nodeProc, "42", "ns", "pid") {
+		t.Errorf("[FAIL] Unexpected path of a runtime pid (%s)", path)
+	}
+
+	if path := GetHostProcPath("42", "exe"); path != filepath.Join(hostProc, "42", "exe") {
+		t.Errorf("[FAIL] Unexpected path of a host pid (%s)", path)
+	}
+
+	// the configured host proc mount wins over autodetection
+	SetupProcFs(nodeProc, nodeProc+"/")
+
+	if path := GetHostProcPath(); path != nodeProc {
+		t.Errorf("[FAIL] Unexpected configured host proc mount (%s)", path)
+	}
+
+	t.Log("[PASS] Resolved the proc mounts of runtimes and the host")
+}
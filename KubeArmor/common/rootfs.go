@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package common
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maximum number of symbolic links followed in a path (MAXSYMLINKS)
+const maxSymlinks = 40
+
+// ErrTooManySymlinks is returned when a path has a loop of symbolic links
+var ErrTooManySymlinks = errors.New("too many levels of symbolic links")
+
+// ResolvePathInRoot resolves a path in a root filesystem (e.g., the MergedDir of a container) as if the root were "/",
+// so that symbolic links (absolute ones and "..") never point out of the root
+func ResolvePathInRoot(root, path string) (string, error) {
+	root = filepath.Clean(root)
+
+	pending := strings.Split(path, "/")
+	resolved := "/"
+	links := 0
+
+	for len(pending) > 0 {
+		part := pending[0]
+		pending = pending[1:]
+
+		if part == "" || part == "." {
+			continue
+		}
+
+		if part == ".." {
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, part)
+
+		info, err := os.Lstat(filepath.Join(root, next))
+		if err != nil {
+			return "", err
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		links++
+		if links > maxSymlinks {
+			return "", ErrTooManySymlinks
+		}
+
+		target, err := os.Readlink(filepath.Join(root, next))
+		if err != nil {
+			return "", err
+		}
+
+		// absolute links are relative to the root, and relative ones to the current directory
+		if strings.HasPrefix(target, "/") {
+			resolved = "/"
+		}
+
+		pending = append(strings.Split(target, "/"), pending...)
+	}
+
+	return filepath.Join(root, resolved), nil
+}
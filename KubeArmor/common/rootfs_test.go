@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package common
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvePathInRoot(t *testing.T) {
+	root := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(root, "usr", "bin"), 0750); err != nil {
+		t.Fatalf("[FAIL] Failed to create a fake root (%s)", err.Error())
+	}
+	if err := os.WriteFile(filepath.Join(root, "usr", "bin", "wget"), []byte{}, 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to create a fake root (%s)", err.Error())
+	}
+
+	// usrmerge (/bin -> usr/bin), an absolute link, an escaping link, and a loop
+	links := map[string]string{
+		"bin":     "usr/bin",
+		"sbin":    "/usr/bin",
+		"escape":  "../../../../etc",
+		"loop":    "loop",
+		"usr/lib": "../../../usr/bin",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatalf("[FAIL] Failed to create a fake root (%s)", err.Error())
+		}
+	}
+
+	wget := filepath.Join(root, "usr", "bin", "wget")
+
+	for _, path := range []string{"/usr/bin/wget", "/bin/wget", "/sbin/wget", "/usr/lib/wget", "/usr/../bin/./wget"} {
+		if resolved, err := ResolvePathInRoot(root, path); err != nil || resolved != wget {
+			t.Errorf("[FAIL] Unexpected resolution of %s (%s, %v)", path, resolved, err)
+		}
+	}
+
+	// links never point out of the root
+	if resolved, err := ResolvePathInRoot(root, "/escape"); err == nil || resolved != "" {
+		t.Errorf("[FAIL] Expected /escape to be resolved in the root (%s)", resolved)
+	}
+
+	if _, err := ResolvePathInRoot(root, "/usr/bin/wgett"); !os.IsNotExist(err) {
+		t.Errorf("[FAIL] Expected a missing path (%v)", err)
+	}
+
+	if _, err := ResolvePathInRoot(root, "/loop/wget"); !errors.Is(err, ErrTooManySymlinks) {
+		t.Errorf("[FAIL] Expected a symbolic link loop (%v)", err)
+	}
+
+	t.Log("[PASS] Resolved paths in a root filesystem")
+}
@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package common
+
+import (
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// ============= //
+// == Signals == //
+// ============= //
+
+// GetSignalName returns the name of a signal (e.g., SIGKILL), or its number if unnamed
+func GetSignalName(sig int) string {
+	if name := unix.SignalName(syscall.Signal(sig)); name != "" {
+		return name
+	}
+	return strconv.Itoa(sig)
+}
+
+// GetSignalNumber returns the number of a signal given by its name (SIGKILL, KILL) or number
+func GetSignalNumber(signal string) (int, bool) {
+	signal = strings.ToUpper(strings.TrimSpace(signal))
+
+	if num, err := strconv.Atoi(signal); err == nil {
+		return num, num > 0 && num < 65
+	}
+
+	if !strings.HasPrefix(signal, "SIG") {
+		signal = "SIG" + signal
+	}
+
+	if num := unix.SignalNum(signal); num != 0 {
+		return int(num), true
+	}
+
+	return 0, false
+}
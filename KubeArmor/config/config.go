@@ -7,7 +7,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"flag"
 
@@ -23,16 +25,28 @@ type KubearmorConfig struct {
 	GRPC              string // gRPC Port to use
 	LogPath           string // Log file to use
 	SELinuxProfileDir string // Directory to store SELinux profiles
+	StateDir          string // Writable directory of the state of the daemon (policy cache, state files, temp files)
+	HostEtcDir        string // Directory of the host /etc (the AppArmor profiles are written in its apparmor.d)
 	CRISocket         string // Container runtime to use
+	PodmanSocket      string // Podman API socket to use for unorchestrated containers
+
+	RuntimeSockets []string // Sockets of the container runtimes matched by runtime socket rules, besides the well-known ones
+
+	GRPCListeners []GRPCListener  // gRPC listeners and their services (the gRPC port with all services if empty)
+	GRPCAuthz     []GRPCAuthzRule // identities of the gRPC clients and their allowed methods (all clients are allowed if empty)
 
 	Visibility     string // Container visibility to use
 	HostVisibility string // Host visibility to use
 
+	DefaultVisibility string // Visibility of the namespaces without the visibility annotation (Visibility if empty)
+
 	Policy     bool // Enable/Disable policy enforcement
 	HostPolicy bool // Enable/Disable host policy enforcement
 	KVMAgent   bool // Enable/Disable KVM Agent
 	K8sEnv     bool // Is k8s env ?
 
+	ApplyHostPoliciesFirst bool // Apply host policies before container policies at startup
+
 	DefaultFilePosture         string // Default Enforcement Action in Global File Context
 	DefaultNetworkPosture      string // Default Enforcement Action in Global Network Context
 	DefaultCapabilitiesPosture string // Default Enforcement Action in Global Capabilities Context
@@ -47,25 +61,187 @@ type KubearmorConfig struct {
 	BPFFsPath         string   // path to the BPF filesystem
 	EnforcerAlerts    bool     // policy enforcer
 
+	K8sEvents            bool // Enable/Disable k8s events for critical Block alerts
+	K8sEventsMinSeverity int  // Minimum severity of alerts reported as k8s events
+
+	SeverityLevels []SeverityLevel // Named levels of the numeric severities
+
+	PolicyCacheKeyFile string // Key file to sign the policy cache with
+	MigratePolicyCache bool   // Sign the policy cache of an older KubeArmor (without digests) once
+	SelfProtection     bool   // Enable/Disable host rules protecting the local state of KubeArmor
+
+	PolicyOverrideFile string // Node-local file of the emergency policy overrides (disabled if empty)
+
+	AppArmorLayeredProfiles bool // Enable/Disable per-image base layers shared by AppArmor profiles
+
+	NsMapGCInterval time.Duration // Interval to collect the stale namespace entries of containers (0 to disable)
+
+	FlowSummaryInterval time.Duration // Interval to report the summaries of outgoing connections (0 to disable)
+	FlowSummaryMaxFlows int           // Maximum number of flows aggregated between two summaries
+
+	RecentExecs    int // Number of the recent executions kept per container (0 to disable)
+	RecentExecsMax int // Maximum number of the recent executions kept for all the containers
+
+	SensitiveHostPaths []string // Host paths flagged when mounted into containers, in addition to the built-in ones
+
+	ProcFsMount  string // Proc mount of the pid namespace of container runtimes
+	HostProcPath string // Proc mount of the initial pid namespace (autodetected if empty)
+
+	WebhookURL                string        // URL to post alerts to (disabled if empty)
+	WebhookHeaders            []string      // Headers of webhook requests (key=value)
+	WebhookSecretFile         string        // File of the shared secret to sign webhook requests with
+	WebhookMinSeverity        int           // Minimum severity of alerts posted to the webhook
+	WebhookEnforcers          []string      // Enforcers of alerts posted to the webhook (all if empty)
+	WebhookBatchSize          int           // Maximum number of alerts per webhook request
+	WebhookBatchInterval      time.Duration // Interval to post pending alerts to the webhook
+	WebhookCAFile             string        // CA certificates to verify the webhook server with
+	WebhookInsecureSkipVerify bool          // Skip the TLS verification of the webhook server
+
+	LogArchive        bool          // Write the log file as compressed time-based segments in the log path
+	LogArchiveSegment time.Duration // Time span of each segment of the log archive
+	LogArchiveMaxSize int           // Maximum total size of the log archive (MB)
+	LogArchiveMaxAge  time.Duration // Maximum age of the segments of the log archive
+
+	CaptureMaxBytes int    // Maximum size of the samples of blocked writes (captureOnBlock)
+	CaptureRedact   string // Redaction of the samples of blocked writes and the arguments of the recent executions (none|hash)
+
+	NodeQuiesce         string        // Quiescing of enforcement changes while the node is cordoned (auto|on|off)
+	NodeQuiesceInterval time.Duration // Interval of the batched removals while quiesced
+
+	DriftCheckInterval time.Duration // Interval of the checks of the applied policies against the API server (0 to disable)
+	DriftAutoCorrect   bool          // Apply the missing policies and remove the stale ones on drift
+
+	SinkQueueSize    int           // Size of the queue of each alert sink
+	SinkDrainTimeout time.Duration // Deadline to drain the queue of each alert sink on shutdown
+	SinkLagThreshold time.Duration // A sink is degraded once its alerts wait for longer than this (disabled if 0)
+	SinkLagUnready   bool          // KubeArmor is reported as not ready while a sink lags behind over the threshold
+
+	DurableSinkFile        string   // File to persist alerts to with at-least-once delivery (disabled if empty)
+	DurableSinkActions     []string // Actions of alerts persisted to the durable sink
+	DurableSinkJournalSize int      // Maximum number of journaled alerts not persisted yet
+	DurableSinkOverflow    string   // Handling of alerts once the journal is full (block|oldest)
+
+	MetricsAddr        string // Address to serve the Prometheus metrics on (disabled if empty)
+	MetricsMaxPolicies int    // Maximum number of policies tracked in the metrics, the others are tracked as "other"
+
+	TelemetrySchemaVersion string // Major version of the schema of the emitted alerts and logs
+
+	DetachIdleProbes bool          // Detach the probes of the event classes which no visibility or policy needs
+	ProbeDetachDelay time.Duration // Time an event class stays idle before its probes are detached
+
+	AlertQueueSize  int    // Size of the queue of each WatchAlerts client
+	AlertDropPolicy string // Alert dropped for a WatchAlerts client whose queue is full (oldest|newest)
+	AlertWorkers    int    // Number of workers delivering the alerts to the WatchAlerts clients
+	LogQueueSize    int    // Size of the queue of each WatchLogs client
+	LogDropPolicy   string // Log dropped for a WatchLogs client whose queue is full (oldest|newest)
+
+	LowMemoryMode       bool // Apply the profile of the low memory mode (each setting can still be overridden)
+	EventBufferPages    int  // Pages of the per-CPU buffer of the system events
+	EventChannelSize    int  // Size of the channel of the system events in userspace
+	EventReplay         bool // Retry the events of the containers which are not known yet
+	StrictAttribution   bool // Raise alerts for the events whose container can't be attributed
+	EnrichmentCacheSize int  // Maximum number of processes in the enrichment caches (socket creators, exec sessions)
+	ScopedInformers     bool // Watch only the node and the pods of the node (with field selectors)
+	GCPercent           int  // GOGC of the daemon (0 for the runtime default)
+
+	ContainerRetryWindow time.Duration // Time the containers which fail to be added are retried with backoff
+	CRIRequestTimeout    time.Duration // Timeout of each call to the CRI runtime
+
+	DestroyedContainerRetention time.Duration // Time the metadata of the destroyed containers is kept for their late alerts (0 to disable)
+	DestroyedContainerLimit     int           // Maximum number of the destroyed containers kept
+
+	CRIPollingInterval    time.Duration // Interval of listing the containers of the runtime (or of re-subscribing to its events)
+	CRIPollingMaxInterval time.Duration // Interval the polling backs off to while the containers don't change
+
+	PreferNRI bool   // Receive the lifecycle events of the containers as an NRI plugin if the NRI socket exists
+	NRISocket string // NRI socket of containerd or CRI-O
+
+	EnrichmentStages map[string]bool // Enrichment stages enabled or disabled explicitly (the others keep their defaults)
+
+	TelemetryFieldPolicy map[string]string // Fields of the alerts and the logs dropped or hashed before they're emitted (field -> drop|hash)
+
+	AppArmorAttachThreshold time.Duration // Time the AppArmor profile of a new container can take to be attached before it's alerted
+	AppArmorPreStart        bool          // Load the AppArmor profiles of the containers over NRI before the containers are created
+
+	RuleConsolidationRatio float64 // Fraction of the entries of a directory allowed by exact matchPaths above which they're merged (0 to disable)
+	MaxEndpointRules       int     // Number of effective rules of an endpoint above which a warning is logged (0 to disable)
 }
 
 // GlobalCfg Global configuration for Kubearmor
 var GlobalCfg KubearmorConfig
 
+// MinCRIPollingInterval is the shortest interval of polling the runtime accepted
+const MinCRIPollingInterval = 10 * time.Millisecond
+
+// DefaultNRISocket is the socket NRI plugins connect to (containerd 1.7+, CRI-O 1.26+)
+const DefaultNRISocket = "/var/run/nri/nri.sock"
+
+// ProbeDataPath is the file of the runtime configuration read by karmor probe, at a fixed path since the client reads it
+// there (it isn't relocated with the state directory)
+const ProbeDataPath = "/tmp/karmorProbeData.cfg"
+
+// paths in the state directory (relocated by SetStateDir)
+var (
+	PolicyDir          = "/opt/kubearmor/policies/"
+	PolicyDigestDir    = "/opt/kubearmor/digests/"
+	PolicyMigratedPath = "/opt/kubearmor/digests.migrated"
+	PIDFilePath        = "/opt/kubearmor/kubearmor.pid"
+	NsMapStatePath     = "/opt/kubearmor/nsmap.json"
+	AppArmorStatePath  = "/opt/kubearmor/apparmor.json"
+	TempDir            = "/opt/kubearmor/tmp"
+	AlertJournalDir    = "/opt/kubearmor/journal"
+
+	PolicyOverrideStatePath = "/opt/kubearmor/overrides.json"
+	TelemetrySaltPath       = "/opt/kubearmor/telemetry.salt"
+)
+
+// SetStateDir relocates the paths written by the daemon into the given directory
+func SetStateDir(stateDir string) {
+	stateDir = filepath.Clean(stateDir)
+
+	PolicyDir = filepath.Join(stateDir, "policies") + "/"
+	PolicyDigestDir = filepath.Join(stateDir, "digests") + "/"
+	PolicyMigratedPath = filepath.Join(stateDir, "digests.migrated")
+	PIDFilePath = filepath.Join(stateDir, "kubearmor.pid")
+	NsMapStatePath = filepath.Join(stateDir, "nsmap.json")
+	AppArmorStatePath = filepath.Join(stateDir, "apparmor.json")
+	TempDir = filepath.Join(stateDir, "tmp")
+	AlertJournalDir = filepath.Join(stateDir, "journal")
+	PolicyOverrideStatePath = filepath.Join(stateDir, "overrides.json")
+	TelemetrySaltPath = filepath.Join(stateDir, "telemetry.salt")
+}
+
+// AppArmorProfileDir returns the directory of the AppArmor profiles on the host
+func AppArmorProfileDir() string {
+	if GlobalCfg.HostEtcDir == "" {
+		return "/etc/apparmor.d"
+	}
+
+	return filepath.Join(GlobalCfg.HostEtcDir, "apparmor.d")
+}
+
 // Config const
 const (
-	PolicyDir                            string = "/opt/kubearmor/policies/"
-	PIDFilePath                          string = "/opt/kubearmor/kubearmor.pid"
 	ConfigCluster                        string = "cluster"
 	ConfigHost                           string = "host"
 	ConfigGRPC                           string = "gRPC"
+	ConfigGRPCListeners                  string = "grpcListeners"
+	ConfigGRPCAuthz                      string = "grpcAuthz"
 	ConfigLogPath                        string = "logPath"
 	ConfigSELinuxProfileDir              string = "seLinuxProfileDir"
+	ConfigStateDir                       string = "stateDir"
+	ConfigHostEtcDir                     string = "hostEtcDir"
 	ConfigCRISocket                      string = "criSocket"
+	ConfigPodmanSocket                   string = "podmanSocket"
+	ConfigRuntimeSockets                 string = "runtimeSockets"
+	ConfigPreferNRI                      string = "preferNRI"
+	ConfigNRISocket                      string = "nriSocket"
 	ConfigVisibility                     string = "visibility"
 	ConfigHostVisibility                 string = "hostVisibility"
+	ConfigDefaultVisibility              string = "defaultVisibility"
 	ConfigKubearmorPolicy                string = "enableKubeArmorPolicy"
 	ConfigKubearmorHostPolicy            string = "enableKubeArmorHostPolicy"
+	ConfigApplyHostPoliciesFirst         string = "applyHostPoliciesFirst"
 	ConfigKubearmorVM                    string = "enableKubeArmorVm"
 	ConfigDefaultFilePosture             string = "defaultFilePosture"
 	ConfigDefaultNetworkPosture          string = "defaultNetworkPosture"
@@ -79,6 +255,79 @@ const (
 	LsmOrder                             string = "lsm"
 	BPFFsPath                            string = "bpfFsPath"
 	EnforcerAlerts                       string = "enforcerAlerts"
+	ConfigK8sEvents                      string = "k8sEvents"
+	ConfigK8sEventsMinSeverity           string = "k8sEventsMinSeverity"
+	ConfigSeverityLabels                 string = "severityLabels"
+	ConfigPolicyCacheKeyFile             string = "policyCacheKeyFile"
+	ConfigMigratePolicyCache             string = "migratePolicyCache"
+	ConfigSelfProtection                 string = "selfProtection"
+	ConfigPolicyOverrideFile             string = "policyOverrideFile"
+	ConfigAppArmorLayeredProfiles        string = "appArmorLayeredProfiles"
+	ConfigNsMapGCInterval                string = "nsMapGCInterval"
+	ConfigFlowSummaryInterval            string = "flowSummaryInterval"
+	ConfigFlowSummaryMaxFlows            string = "flowSummaryMaxFlows"
+	ConfigRecentExecs                    string = "recentExecs"
+	ConfigRecentExecsMax                 string = "recentExecsMax"
+	ConfigSensitiveHostPaths             string = "sensitiveHostPaths"
+	ConfigProcFsMount                    string = "procfsMount"
+	ConfigHostProcPath                   string = "hostProcPath"
+	ConfigWebhookURL                     string = "webhookURL"
+	ConfigWebhookHeaders                 string = "webhookHeaders"
+	ConfigWebhookSecretFile              string = "webhookSecretFile"
+	ConfigWebhookMinSeverity             string = "webhookMinSeverity"
+	ConfigWebhookEnforcers               string = "webhookEnforcers"
+	ConfigWebhookBatchSize               string = "webhookBatchSize"
+	ConfigWebhookBatchInterval           string = "webhookBatchInterval"
+	ConfigWebhookCAFile                  string = "webhookCAFile"
+	ConfigWebhookInsecureSkipVerify      string = "webhookInsecureSkipVerify"
+	ConfigLogArchive                     string = "logArchive"
+	ConfigLogArchiveSegment              string = "logArchiveSegment"
+	ConfigLogArchiveMaxSize              string = "logArchiveMaxSize"
+	ConfigLogArchiveMaxAge               string = "logArchiveMaxAge"
+	ConfigCaptureMaxBytes                string = "captureMaxBytes"
+	ConfigCaptureRedact                  string = "captureRedact"
+	ConfigNodeQuiesce                    string = "nodeQuiesce"
+	ConfigNodeQuiesceInterval            string = "nodeQuiesceInterval"
+	ConfigDriftCheckInterval             string = "driftCheckInterval"
+	ConfigDriftAutoCorrect               string = "driftAutoCorrect"
+	ConfigSinkQueueSize                  string = "sinkQueueSize"
+	ConfigSinkDrainTimeout               string = "sinkDrainTimeout"
+	ConfigSinkLagThreshold               string = "sinkLagThreshold"
+	ConfigSinkLagUnready                 string = "sinkLagUnready"
+	ConfigDurableSinkFile                string = "durableSinkFile"
+	ConfigDurableSinkActions             string = "durableSinkActions"
+	ConfigDurableSinkJournalSize         string = "durableSinkJournalSize"
+	ConfigDurableSinkOverflow            string = "durableSinkOverflow"
+	ConfigMetricsAddr                    string = "metricsAddr"
+	ConfigMetricsMaxPolicies             string = "metricsMaxPolicies"
+	ConfigTelemetrySchemaVersion         string = "telemetrySchemaVersion"
+	ConfigDetachIdleProbes               string = "detachIdleProbes"
+	ConfigProbeDetachDelay               string = "probeDetachDelay"
+	ConfigAlertQueueSize                 string = "alertQueueSize"
+	ConfigAlertDropPolicy                string = "alertDropPolicy"
+	ConfigAlertWorkers                   string = "alertWorkers"
+	ConfigLogQueueSize                   string = "logQueueSize"
+	ConfigLogDropPolicy                  string = "logDropPolicy"
+	ConfigLowMemoryMode                  string = "lowMemoryMode"
+	ConfigEventBufferPages               string = "eventBufferPages"
+	ConfigEventChannelSize               string = "eventChannelSize"
+	ConfigEventReplay                    string = "eventReplay"
+	ConfigStrictAttribution              string = "strictAttribution"
+	ConfigEnrichmentCacheSize            string = "enrichmentCacheSize"
+	ConfigScopedInformers                string = "scopedInformers"
+	ConfigGCPercent                      string = "gcPercent"
+	ConfigContainerRetryWindow           string = "containerRetryWindow"
+	ConfigCRIRequestTimeout              string = "criRequestTimeout"
+	ConfigDestroyedContainerRetention    string = "destroyedContainerRetention"
+	ConfigDestroyedContainerLimit        string = "destroyedContainerLimit"
+	ConfigCRIPollingInterval             string = "criPollingInterval"
+	ConfigCRIPollingMaxInterval          string = "criPollingMaxInterval"
+	ConfigEnrichmentStages               string = "enrichmentStages"
+	ConfigTelemetryFieldPolicy           string = "telemetryFieldPolicy"
+	ConfigAppArmorAttachThreshold        string = "apparmorAttachThreshold"
+	ConfigAppArmorPreStart               string = "apparmorPreStart"
+	ConfigRuleConsolidationRatio         string = "ruleConsolidationRatio"
+	ConfigMaxEndpointRules               string = "maxEndpointRules"
 )
 
 func readCmdLineParams() {
@@ -87,15 +336,25 @@ func readCmdLineParams() {
 	hostStr := flag.String(ConfigHost, strings.Split(hostname, ".")[0], "host name")
 
 	grpcStr := flag.String(ConfigGRPC, "32767", "gRPC port number")
+	grpcListenersStr := flag.String(ConfigGRPCListeners, "", "gRPC listeners separated by ';' (e.g., unix:///var/run/kubearmor.sock?services=policy,probe,admin;tcp://:32767?services=log), the gRPC port with all services if empty")
+	grpcAuthzStr := flag.String(ConfigGRPCAuthz, "", "identities of the gRPC clients and their allowed methods separated by ';' (e.g., uid:0=*;uid:1000=LogService/Watch*;san:telemetry.kubearmor.io=LogService/*), all clients are allowed if empty")
 	logStr := flag.String(ConfigLogPath, "none", "log file path, {path|stdout|none}")
-	seLinuxProfileDirStr := flag.String(ConfigSELinuxProfileDir, "/tmp/kubearmor.selinux", "SELinux profile directory")
+	seLinuxProfileDirStr := flag.String(ConfigSELinuxProfileDir, "", "SELinux profile directory, selinux in the state directory if empty")
+	stateDirStr := flag.String(ConfigStateDir, "/opt/kubearmor", "writable directory of the policy cache, the state files and the temp files")
+	hostEtcDirStr := flag.String(ConfigHostEtcDir, "/etc", "directory of the host /etc, the AppArmor profiles are written in its apparmor.d")
 	criSocket := flag.String(ConfigCRISocket, "", "path to CRI socket (format: unix:///path/to/file.sock)")
+	podmanSocket := flag.String(ConfigPodmanSocket, "", "path to Podman API socket for unorchestrated containers (format: unix:///run/podman/podman.sock)")
+	preferNRI := flag.Bool(ConfigPreferNRI, false, "receiving the lifecycle events of the containers from containerd or CRI-O as an NRI plugin if the NRI socket exists, monitoring the runtime otherwise")
+	nriSocket := flag.String(ConfigNRISocket, DefaultNRISocket, "path to the NRI socket of containerd or CRI-O")
+	runtimeSockets := flag.String(ConfigRuntimeSockets, "", "comma-separated sockets of the container runtimes matched by runtime socket rules, besides the well-known ones and the monitored one")
 
-	visStr := flag.String(ConfigVisibility, "process,file,network,capabilities", "Container Visibility to use [process,file,network,capabilities,none]")
-	hostVisStr := flag.String(ConfigHostVisibility, "default", "Host Visibility to use [process,file,network,capabilities,none] (default \"none\" for k8s, \"process,file,network,capabilities\" for VM)")
+	visStr := flag.String(ConfigVisibility, "process,file,network,capabilities", "Container Visibility to use [process,file,network,capabilities,signal,none]")
+	hostVisStr := flag.String(ConfigHostVisibility, "default", "Host Visibility to use [process,file,network,capabilities,signal,none] (default \"none\" for k8s, \"process,file,network,capabilities\" for VM)")
+	defaultVisStr := flag.String(ConfigDefaultVisibility, "", "Visibility of the namespaces without the kubearmor-visibility annotation [process,file,network,capabilities,signal,none] (the container visibility if empty)")
 
 	policyB := flag.Bool(ConfigKubearmorPolicy, true, "enabling KubeArmorPolicy")
 	hostPolicyB := flag.Bool(ConfigKubearmorHostPolicy, false, "enabling KubeArmorHostPolicy")
+	applyHostPoliciesFirstB := flag.Bool(ConfigApplyHostPoliciesFirst, true, "applying host policies before container policies at startup")
 	kvmAgentB := flag.Bool(ConfigKubearmorVM, false, "enabling KubeArmorVM")
 	k8sEnvB := flag.Bool(ConfigK8sEnv, true, "is k8s env?")
 
@@ -116,6 +375,102 @@ func readCmdLineParams() {
 	bpfFsPath := flag.String(BPFFsPath, "/sys/fs/bpf", "Path to the BPF filesystem to use for storing maps")
 	enforcerAlerts := flag.Bool(EnforcerAlerts, true, "ebpf alerts")
 
+	k8sEventsB := flag.Bool(ConfigK8sEvents, false, "creating k8s events on pods for critical Block alerts")
+	k8sEventsMinSeverity := flag.String(ConfigK8sEventsMinSeverity, "7", "minimum alert severity to be reported as k8s events {1-10, or a severity label}")
+
+	severityLabels := flag.String(ConfigSeverityLabels, DefaultSeverityLabels, "named levels of the alert severities (format: label:min-max,...)")
+
+	policyCacheKeyFile := flag.String(ConfigPolicyCacheKeyFile, "", "path to a key (e.g., a mounted secret) to sign the policy cache with")
+	migratePolicyCacheB := flag.Bool(ConfigMigratePolicyCache, false, "trusting and signing the policy cache of an older KubeArmor without digests, once (on the upgrade)")
+	selfProtectionB := flag.Bool(ConfigSelfProtection, false, "enabling host rules protecting the policy cache and config of KubeArmor")
+
+	policyOverrideFile := flag.String(ConfigPolicyOverrideFile, "", "path to a node-local file of emergency policy overrides with mandatory TTLs (disabled if empty)")
+
+	appArmorLayeredProfilesB := flag.Bool(ConfigAppArmorLayeredProfiles, false, "sharing a base AppArmor profile among the containers of the same image")
+
+	nsMapGCInterval := flag.Duration(ConfigNsMapGCInterval, 5*time.Minute, "interval to collect the namespaces of containers removed without destroy events (0 to disable)")
+
+	flowSummaryInterval := flag.Duration(ConfigFlowSummaryInterval, 0, "interval to report the summaries of outgoing connections per destination (0 to disable)")
+	flowSummaryMaxFlows := flag.Int(ConfigFlowSummaryMaxFlows, 4096, "maximum number of flows per summary, the others are summarized together")
+
+	recentExecs := flag.Int(ConfigRecentExecs, 128, "number of the recent executions kept per container for getRecentExecs (0 to disable)")
+	recentExecsMax := flag.Int(ConfigRecentExecsMax, 65536, "maximum number of the recent executions kept for all the containers, the oldest ones are evicted first")
+
+	sensitiveHostPaths := flag.String(ConfigSensitiveHostPaths, "", "comma-separated host paths flagged when mounted into containers, in addition to /, /etc, /proc and the container runtime sockets")
+
+	procFsMount := flag.String(ConfigProcFsMount, "/proc", "path to the proc mount of container runtimes")
+	hostProcPath := flag.String(ConfigHostProcPath, "", "path to the proc mount of the host pid namespace, for nested runtimes such as kind (autodetected if empty)")
+
+	webhookURL := flag.String(ConfigWebhookURL, "", "URL to post alerts to (e.g., https://soar.example.com/hooks/kubearmor)")
+	webhookHeaders := flag.String(ConfigWebhookHeaders, "", "headers of webhook requests (format: key1=value1,key2=value2)")
+	webhookSecretFile := flag.String(ConfigWebhookSecretFile, "", "path to a shared secret to sign webhook requests with (HMAC-SHA256)")
+	webhookMinSeverity := flag.String(ConfigWebhookMinSeverity, "1", "minimum alert severity to be posted to the webhook {1-10, or a severity label}")
+	webhookEnforcers := flag.String(ConfigWebhookEnforcers, "", "enforcers of alerts to be posted to the webhook (format: BPFLSM,AppArmor,SELinux,eBPF Monitor,KubeArmor)")
+	webhookBatchSize := flag.Int(ConfigWebhookBatchSize, 100, "maximum number of alerts per webhook request")
+	webhookBatchInterval := flag.Duration(ConfigWebhookBatchInterval, 5*time.Second, "interval to post pending alerts to the webhook")
+	webhookCAFile := flag.String(ConfigWebhookCAFile, "", "path to CA certificates to verify the webhook server with")
+	webhookInsecureSkipVerifyB := flag.Bool(ConfigWebhookInsecureSkipVerify, false, "skipping the TLS verification of the webhook server")
+
+	logArchiveB := flag.Bool(ConfigLogArchive, false, "writing the log file as zstd-compressed segments in the log path (a directory)")
+	logArchiveSegment := flag.Duration(ConfigLogArchiveSegment, time.Hour, "time span of each segment of the log archive")
+	logArchiveMaxSize := flag.Int(ConfigLogArchiveMaxSize, 1024, "maximum total size of the log archive in MB (0 for no limit)")
+	logArchiveMaxAge := flag.Duration(ConfigLogArchiveMaxAge, 7*24*time.Hour, "maximum age of the segments of the log archive (0 for no limit)")
+
+	captureMaxBytes := flag.Int(ConfigCaptureMaxBytes, 64, "maximum size of the samples of blocked writes attached to alerts (up to 4096)")
+	captureRedact := flag.String(ConfigCaptureRedact, "hash", "redaction of the samples of blocked writes and the arguments of the recent executions {none|hash}")
+
+	nodeQuiesce := flag.String(ConfigNodeQuiesce, "auto", "quiescing of enforcement changes during node maintenance {auto (while cordoned)|on|off}")
+	nodeQuiesceInterval := flag.Duration(ConfigNodeQuiesceInterval, 30*time.Second, "interval of the batched removals while quiesced")
+
+	driftCheckInterval := flag.Duration(ConfigDriftCheckInterval, 5*time.Minute, "interval of the checks of the applied policies against the API server (0 to disable)")
+	driftAutoCorrectB := flag.Bool(ConfigDriftAutoCorrect, true, "applying the missing policies and removing the stale ones on drift")
+
+	sinkQueueSize := flag.Int(ConfigSinkQueueSize, 1024, "size of the queue of each alert sink (alerts are dropped for a sink once its queue is full)")
+	sinkDrainTimeout := flag.Duration(ConfigSinkDrainTimeout, 5*time.Second, "deadline to drain the queue of each alert sink on shutdown")
+	sinkLagThreshold := flag.Duration(ConfigSinkLagThreshold, 5*time.Minute, "a sink is degraded once its oldest queued alert, or its last sent alert, waited for longer than this (disabled if 0)")
+	sinkLagUnready := flag.Bool(ConfigSinkLagUnready, false, "report KubeArmor as not ready in the health check while a sink lags behind over -sinkLagThreshold")
+
+	durableSinkFile := flag.String(ConfigDurableSinkFile, "", "file to persist alerts to with at-least-once delivery, through a write-ahead journal in the state directory (disabled if empty)")
+	durableSinkActions := flag.String(ConfigDurableSinkActions, "Block", "actions of alerts persisted to the durable sink (format: Block,Audit)")
+	durableSinkJournalSize := flag.Int(ConfigDurableSinkJournalSize, 10000, "maximum number of journaled alerts not persisted to the durable sink yet")
+	durableSinkOverflow := flag.String(ConfigDurableSinkOverflow, "block", "handling of alerts once the journal of the durable sink is full {block (the producers wait)|oldest (the oldest alert is dropped)}")
+
+	metricsAddr := flag.String(ConfigMetricsAddr, "", "address to serve the Prometheus metrics on (e.g., :9090), disabled if empty")
+	metricsMaxPolicies := flag.Int(ConfigMetricsMaxPolicies, 100, "maximum number of policies tracked in the metrics, the others are tracked as \"other\"")
+
+	telemetrySchemaVersion := flag.String(ConfigTelemetrySchemaVersion, "1", "major version of the schema of the emitted alerts and logs (the previous one stays available for a release after a breaking change)")
+
+	detachIdleProbes := flag.Bool(ConfigDetachIdleProbes, true, "detaching the probes of the file and network events while no visibility or policy on the node needs them")
+	probeDetachDelay := flag.Duration(ConfigProbeDetachDelay, 30*time.Second, "time an event class stays idle before its probes are detached")
+
+	alertQueueSize := flag.Int(ConfigAlertQueueSize, 1000, "size of the queue of each WatchAlerts client")
+	alertDropPolicy := flag.String(ConfigAlertDropPolicy, "oldest", "alert dropped for a WatchAlerts client whose queue is full (oldest|newest)")
+	alertWorkers := flag.Int(ConfigAlertWorkers, 2, "number of workers delivering the alerts to the WatchAlerts clients, apart from the logs")
+	logQueueSize := flag.Int(ConfigLogQueueSize, 1000, "size of the queue of each WatchLogs client")
+	logDropPolicy := flag.String(ConfigLogDropPolicy, "newest", "log dropped for a WatchLogs client whose queue is full (oldest|newest)")
+
+	lowMemoryModeB := flag.Bool(ConfigLowMemoryMode, false, "applying the profile of the low memory mode for small edge nodes (each setting can still be overridden)")
+	eventBufferPages := flag.Int(ConfigEventBufferPages, 1024, "pages of the per-CPU buffer of the system events")
+	eventChannelSize := flag.Int(ConfigEventChannelSize, 8192, "size of the channel of the system events in userspace")
+	eventReplayB := flag.Bool(ConfigEventReplay, true, "retrying the events of the containers which are not known yet")
+	strictAttributionB := flag.Bool(ConfigStrictAttribution, false, "raising alerts for the events whose container can't be attributed (after the replay of the events)")
+	enrichmentCacheSize := flag.Int(ConfigEnrichmentCacheSize, 65536, "maximum number of processes in the enrichment caches (socket creators, exec sessions)")
+	scopedInformersB := flag.Bool(ConfigScopedInformers, false, "watching only the node and the pods of the node with field selectors (KUBEARMOR_NODENAME is needed for the pods)")
+	gcPercent := flag.Int(ConfigGCPercent, 0, "GOGC of the daemon (0 for the runtime default)")
+
+	containerRetryWindow := flag.Duration(ConfigContainerRetryWindow, 2*time.Minute, "time the containers which fail to be added (e.g., before their pods are known) are retried with backoff")
+	enrichmentStages := flag.String(ConfigEnrichmentStages, "", "enrichment stages of the alerts and the logs to enable or disable (format: stage=true|false,...), e.g., hostName=false")
+	telemetryFieldPolicy := flag.String(ConfigTelemetryFieldPolicy, "", "fields of the alerts and the logs to drop or to hash with the salt of the node (format: field=drop|hash,...), e.g., arguments=drop,labels=hash")
+	appArmorAttachThreshold := flag.Duration(ConfigAppArmorAttachThreshold, 30*time.Second, "time the AppArmor profile of a new container can take to be attached before a warning alert is raised (0 to disable the alerts)")
+	appArmorPreStartB := flag.Bool(ConfigAppArmorPreStart, false, "loading the AppArmor profile of a container with the policies selecting it before the container is created, over NRI (-preferNRI), which delays the creation of the containers")
+	ruleConsolidationRatio := flag.Float64(ConfigRuleConsolidationRatio, 0, "fraction of the entries of a directory allowed by the exact matchPaths of a policy above which they're suggested to be merged into a directory rule, 0 to disable the consolidation of the rules")
+	maxEndpointRules := flag.Int(ConfigMaxEndpointRules, 1000, "number of effective rules of an endpoint above which a warning is logged (0 to disable the warning)")
+	criRequestTimeout := flag.Duration(ConfigCRIRequestTimeout, 5*time.Second, "timeout of each call to the CRI runtime (e.g., listing the containers or getting the status of a container)")
+	destroyedContainerRetention := flag.Duration(ConfigDestroyedContainerRetention, time.Minute, "time the metadata of the destroyed containers is kept to enrich the alerts and the logs received after their destroy (0 to disable)")
+	destroyedContainerLimit := flag.Int(ConfigDestroyedContainerLimit, 1024, "maximum number of the destroyed containers kept, the oldest ones are dropped first")
+	criPollingInterval := flag.Duration(ConfigCRIPollingInterval, 100*time.Millisecond, "interval of listing the containers of the runtime, or of re-subscribing to its events (at least 10ms)")
+	criPollingMaxInterval := flag.Duration(ConfigCRIPollingMaxInterval, 5*time.Second, "interval the polling of the runtime backs off to while the containers don't change")
+
 	flags := []string{}
 	flag.VisitAll(func(f *flag.Flag) {
 		kv := fmt.Sprintf("%s:%v", f.Name, f.Value)
@@ -129,15 +484,25 @@ func readCmdLineParams() {
 	viper.SetDefault(ConfigHost, *hostStr)
 
 	viper.SetDefault(ConfigGRPC, *grpcStr)
+	viper.SetDefault(ConfigGRPCListeners, *grpcListenersStr)
+	viper.SetDefault(ConfigGRPCAuthz, *grpcAuthzStr)
 	viper.SetDefault(ConfigLogPath, *logStr)
 	viper.SetDefault(ConfigSELinuxProfileDir, *seLinuxProfileDirStr)
+	viper.SetDefault(ConfigStateDir, *stateDirStr)
+	viper.SetDefault(ConfigHostEtcDir, *hostEtcDirStr)
 	viper.SetDefault(ConfigCRISocket, *criSocket)
+	viper.SetDefault(ConfigPodmanSocket, *podmanSocket)
+	viper.SetDefault(ConfigPreferNRI, *preferNRI)
+	viper.SetDefault(ConfigNRISocket, *nriSocket)
+	viper.SetDefault(ConfigRuntimeSockets, *runtimeSockets)
 
 	viper.SetDefault(ConfigVisibility, *visStr)
 	viper.SetDefault(ConfigHostVisibility, *hostVisStr)
+	viper.SetDefault(ConfigDefaultVisibility, *defaultVisStr)
 
 	viper.SetDefault(ConfigKubearmorPolicy, *policyB)
 	viper.SetDefault(ConfigKubearmorHostPolicy, *hostPolicyB)
+	viper.SetDefault(ConfigApplyHostPoliciesFirst, *applyHostPoliciesFirstB)
 	viper.SetDefault(ConfigKubearmorVM, *kvmAgentB)
 	viper.SetDefault(ConfigK8sEnv, *k8sEnvB)
 
@@ -158,6 +523,102 @@ func readCmdLineParams() {
 	viper.SetDefault(BPFFsPath, *bpfFsPath)
 
 	viper.SetDefault(EnforcerAlerts, *enforcerAlerts)
+
+	viper.SetDefault(ConfigK8sEvents, *k8sEventsB)
+	viper.SetDefault(ConfigK8sEventsMinSeverity, *k8sEventsMinSeverity)
+
+	viper.SetDefault(ConfigSeverityLabels, *severityLabels)
+
+	viper.SetDefault(ConfigPolicyCacheKeyFile, *policyCacheKeyFile)
+	viper.SetDefault(ConfigMigratePolicyCache, *migratePolicyCacheB)
+	viper.SetDefault(ConfigSelfProtection, *selfProtectionB)
+
+	viper.SetDefault(ConfigPolicyOverrideFile, *policyOverrideFile)
+
+	viper.SetDefault(ConfigAppArmorLayeredProfiles, *appArmorLayeredProfilesB)
+
+	viper.SetDefault(ConfigNsMapGCInterval, *nsMapGCInterval)
+
+	viper.SetDefault(ConfigFlowSummaryInterval, *flowSummaryInterval)
+	viper.SetDefault(ConfigFlowSummaryMaxFlows, *flowSummaryMaxFlows)
+
+	viper.SetDefault(ConfigRecentExecs, *recentExecs)
+	viper.SetDefault(ConfigRecentExecsMax, *recentExecsMax)
+
+	viper.SetDefault(ConfigSensitiveHostPaths, *sensitiveHostPaths)
+
+	viper.SetDefault(ConfigProcFsMount, *procFsMount)
+	viper.SetDefault(ConfigHostProcPath, *hostProcPath)
+
+	viper.SetDefault(ConfigWebhookURL, *webhookURL)
+	viper.SetDefault(ConfigWebhookHeaders, *webhookHeaders)
+	viper.SetDefault(ConfigWebhookSecretFile, *webhookSecretFile)
+	viper.SetDefault(ConfigWebhookMinSeverity, *webhookMinSeverity)
+	viper.SetDefault(ConfigWebhookEnforcers, *webhookEnforcers)
+	viper.SetDefault(ConfigWebhookBatchSize, *webhookBatchSize)
+	viper.SetDefault(ConfigWebhookBatchInterval, *webhookBatchInterval)
+	viper.SetDefault(ConfigWebhookCAFile, *webhookCAFile)
+	viper.SetDefault(ConfigWebhookInsecureSkipVerify, *webhookInsecureSkipVerifyB)
+
+	viper.SetDefault(ConfigLogArchive, *logArchiveB)
+	viper.SetDefault(ConfigLogArchiveSegment, *logArchiveSegment)
+	viper.SetDefault(ConfigLogArchiveMaxSize, *logArchiveMaxSize)
+	viper.SetDefault(ConfigLogArchiveMaxAge, *logArchiveMaxAge)
+
+	viper.SetDefault(ConfigCaptureMaxBytes, *captureMaxBytes)
+	viper.SetDefault(ConfigCaptureRedact, *captureRedact)
+
+	viper.SetDefault(ConfigNodeQuiesce, *nodeQuiesce)
+	viper.SetDefault(ConfigNodeQuiesceInterval, *nodeQuiesceInterval)
+
+	viper.SetDefault(ConfigDriftCheckInterval, *driftCheckInterval)
+	viper.SetDefault(ConfigDriftAutoCorrect, *driftAutoCorrectB)
+
+	viper.SetDefault(ConfigSinkQueueSize, *sinkQueueSize)
+	viper.SetDefault(ConfigSinkDrainTimeout, *sinkDrainTimeout)
+	viper.SetDefault(ConfigSinkLagThreshold, *sinkLagThreshold)
+	viper.SetDefault(ConfigSinkLagUnready, *sinkLagUnready)
+
+	viper.SetDefault(ConfigDurableSinkFile, *durableSinkFile)
+	viper.SetDefault(ConfigDurableSinkActions, *durableSinkActions)
+	viper.SetDefault(ConfigDurableSinkJournalSize, *durableSinkJournalSize)
+	viper.SetDefault(ConfigDurableSinkOverflow, *durableSinkOverflow)
+
+	viper.SetDefault(ConfigMetricsAddr, *metricsAddr)
+	viper.SetDefault(ConfigMetricsMaxPolicies, *metricsMaxPolicies)
+
+	viper.SetDefault(ConfigTelemetrySchemaVersion, *telemetrySchemaVersion)
+
+	viper.SetDefault(ConfigDetachIdleProbes, *detachIdleProbes)
+	viper.SetDefault(ConfigProbeDetachDelay, *probeDetachDelay)
+
+	viper.SetDefault(ConfigAlertQueueSize, *alertQueueSize)
+	viper.SetDefault(ConfigAlertDropPolicy, *alertDropPolicy)
+	viper.SetDefault(ConfigAlertWorkers, *alertWorkers)
+	viper.SetDefault(ConfigLogQueueSize, *logQueueSize)
+	viper.SetDefault(ConfigLogDropPolicy, *logDropPolicy)
+
+	viper.SetDefault(ConfigLowMemoryMode, *lowMemoryModeB)
+	viper.SetDefault(ConfigEventBufferPages, *eventBufferPages)
+	viper.SetDefault(ConfigEventChannelSize, *eventChannelSize)
+	viper.SetDefault(ConfigEventReplay, *eventReplayB)
+	viper.SetDefault(ConfigStrictAttribution, *strictAttributionB)
+	viper.SetDefault(ConfigEnrichmentCacheSize, *enrichmentCacheSize)
+	viper.SetDefault(ConfigScopedInformers, *scopedInformersB)
+	viper.SetDefault(ConfigGCPercent, *gcPercent)
+
+	viper.SetDefault(ConfigContainerRetryWindow, *containerRetryWindow)
+	viper.SetDefault(ConfigCRIRequestTimeout, *criRequestTimeout)
+	viper.SetDefault(ConfigDestroyedContainerRetention, *destroyedContainerRetention)
+	viper.SetDefault(ConfigDestroyedContainerLimit, *destroyedContainerLimit)
+	viper.SetDefault(ConfigCRIPollingInterval, *criPollingInterval)
+	viper.SetDefault(ConfigCRIPollingMaxInterval, *criPollingMaxInterval)
+	viper.SetDefault(ConfigEnrichmentStages, *enrichmentStages)
+	viper.SetDefault(ConfigTelemetryFieldPolicy, *telemetryFieldPolicy)
+	viper.SetDefault(ConfigAppArmorAttachThreshold, *appArmorAttachThreshold)
+	viper.SetDefault(ConfigAppArmorPreStart, *appArmorPreStartB)
+	viper.SetDefault(ConfigRuleConsolidationRatio, *ruleConsolidationRatio)
+	viper.SetDefault(ConfigMaxEndpointRules, *maxEndpointRules)
 }
 
 // LoadConfig Load configuration
@@ -183,12 +644,39 @@ func LoadConfig() error {
 		}
 	}
 
+	// the settings of the low memory mode are applied first, so that the rest is loaded as usual
+	GlobalCfg.LowMemoryMode = viper.GetBool(ConfigLowMemoryMode)
+	if GlobalCfg.LowMemoryMode {
+		kg.Printf("Low memory mode [%s]", strings.Join(applyLowMemoryProfile(isExplicitlySet), " "))
+	}
+
 	GlobalCfg.Cluster = viper.GetString(ConfigCluster)
 	GlobalCfg.Host = viper.GetString(ConfigHost)
 
 	GlobalCfg.GRPC = viper.GetString(ConfigGRPC)
 	GlobalCfg.LogPath = viper.GetString(ConfigLogPath)
 
+	GlobalCfg.StateDir = viper.GetString(ConfigStateDir)
+	GlobalCfg.HostEtcDir = viper.GetString(ConfigHostEtcDir)
+	SetStateDir(GlobalCfg.StateDir)
+
+	GlobalCfg.SELinuxProfileDir = viper.GetString(ConfigSELinuxProfileDir)
+	if GlobalCfg.SELinuxProfileDir == "" {
+		GlobalCfg.SELinuxProfileDir = filepath.Join(GlobalCfg.StateDir, "selinux") + "/"
+	}
+
+	listeners, err := ParseGRPCListeners(viper.GetString(ConfigGRPCListeners))
+	if err != nil {
+		return err
+	}
+	GlobalCfg.GRPCListeners = listeners
+
+	authz, err := ParseGRPCAuthz(viper.GetString(ConfigGRPCAuthz))
+	if err != nil {
+		return err
+	}
+	GlobalCfg.GRPCAuthz = authz
+
 	GlobalCfg.CRISocket = os.Getenv("CRI_SOCKET")
 	if GlobalCfg.CRISocket == "" {
 		GlobalCfg.CRISocket = viper.GetString(ConfigCRISocket)
@@ -198,11 +686,28 @@ func LoadConfig() error {
 		return fmt.Errorf("CRI socket must start with 'unix://' (%s is invalid)", GlobalCfg.CRISocket)
 	}
 
+	GlobalCfg.PodmanSocket = viper.GetString(ConfigPodmanSocket)
+	if GlobalCfg.PodmanSocket != "" && !strings.HasPrefix(GlobalCfg.PodmanSocket, "unix://") {
+		return fmt.Errorf("Podman socket must start with 'unix://' (%s is invalid)", GlobalCfg.PodmanSocket)
+	}
+
+	GlobalCfg.PreferNRI = viper.GetBool(ConfigPreferNRI)
+	GlobalCfg.NRISocket = strings.TrimPrefix(viper.GetString(ConfigNRISocket), "unix://")
+
+	GlobalCfg.RuntimeSockets = []string{}
+	for _, socket := range strings.Split(viper.GetString(ConfigRuntimeSockets), ",") {
+		if socket = strings.TrimPrefix(strings.TrimSpace(socket), "unix://"); socket != "" {
+			GlobalCfg.RuntimeSockets = append(GlobalCfg.RuntimeSockets, socket)
+		}
+	}
+
 	GlobalCfg.Visibility = viper.GetString(ConfigVisibility)
 	GlobalCfg.HostVisibility = viper.GetString(ConfigHostVisibility)
+	GlobalCfg.DefaultVisibility = viper.GetString(ConfigDefaultVisibility)
 
 	GlobalCfg.Policy = viper.GetBool(ConfigKubearmorPolicy)
 	GlobalCfg.HostPolicy = viper.GetBool(ConfigKubearmorHostPolicy)
+	GlobalCfg.ApplyHostPoliciesFirst = viper.GetBool(ConfigApplyHostPoliciesFirst)
 	GlobalCfg.KVMAgent = viper.GetBool(ConfigKubearmorVM)
 	GlobalCfg.K8sEnv = viper.GetBool(ConfigK8sEnv)
 
@@ -238,6 +743,147 @@ func LoadConfig() error {
 	GlobalCfg.BPFFsPath = viper.GetString(BPFFsPath)
 	GlobalCfg.EnforcerAlerts = viper.GetBool(EnforcerAlerts)
 
+	GlobalCfg.K8sEvents = viper.GetBool(ConfigK8sEvents)
+
+	levels, err := ParseSeverityLabels(viper.GetString(ConfigSeverityLabels))
+	if err != nil {
+		return err
+	}
+	GlobalCfg.SeverityLevels = levels
+
+	if GlobalCfg.K8sEventsMinSeverity, err = ParseSeverity(levels, viper.GetString(ConfigK8sEventsMinSeverity)); err != nil {
+		return err
+	}
+
+	GlobalCfg.PolicyCacheKeyFile = viper.GetString(ConfigPolicyCacheKeyFile)
+	GlobalCfg.MigratePolicyCache = viper.GetBool(ConfigMigratePolicyCache)
+	GlobalCfg.SelfProtection = viper.GetBool(ConfigSelfProtection)
+
+	GlobalCfg.PolicyOverrideFile = viper.GetString(ConfigPolicyOverrideFile)
+
+	GlobalCfg.AppArmorLayeredProfiles = viper.GetBool(ConfigAppArmorLayeredProfiles)
+
+	GlobalCfg.NsMapGCInterval = viper.GetDuration(ConfigNsMapGCInterval)
+
+	GlobalCfg.FlowSummaryInterval = viper.GetDuration(ConfigFlowSummaryInterval)
+	GlobalCfg.FlowSummaryMaxFlows = viper.GetInt(ConfigFlowSummaryMaxFlows)
+
+	GlobalCfg.RecentExecs = viper.GetInt(ConfigRecentExecs)
+	GlobalCfg.RecentExecsMax = viper.GetInt(ConfigRecentExecsMax)
+
+	GlobalCfg.SensitiveHostPaths = []string{}
+	for _, path := range strings.Split(viper.GetString(ConfigSensitiveHostPaths), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			GlobalCfg.SensitiveHostPaths = append(GlobalCfg.SensitiveHostPaths, path)
+		}
+	}
+
+	GlobalCfg.ProcFsMount = viper.GetString(ConfigProcFsMount)
+	GlobalCfg.HostProcPath = viper.GetString(ConfigHostProcPath)
+
+	GlobalCfg.WebhookURL = viper.GetString(ConfigWebhookURL)
+	if headers := viper.GetString(ConfigWebhookHeaders); headers != "" {
+		GlobalCfg.WebhookHeaders = strings.Split(headers, ",")
+	}
+	GlobalCfg.WebhookSecretFile = viper.GetString(ConfigWebhookSecretFile)
+	if GlobalCfg.WebhookMinSeverity, err = ParseSeverity(levels, viper.GetString(ConfigWebhookMinSeverity)); err != nil {
+		return err
+	}
+	if enforcers := viper.GetString(ConfigWebhookEnforcers); enforcers != "" {
+		GlobalCfg.WebhookEnforcers = strings.Split(enforcers, ",")
+	}
+	GlobalCfg.WebhookBatchSize = viper.GetInt(ConfigWebhookBatchSize)
+	GlobalCfg.WebhookBatchInterval = viper.GetDuration(ConfigWebhookBatchInterval)
+	GlobalCfg.WebhookCAFile = viper.GetString(ConfigWebhookCAFile)
+	GlobalCfg.WebhookInsecureSkipVerify = viper.GetBool(ConfigWebhookInsecureSkipVerify)
+
+	GlobalCfg.LogArchive = viper.GetBool(ConfigLogArchive)
+	GlobalCfg.LogArchiveSegment = viper.GetDuration(ConfigLogArchiveSegment)
+	GlobalCfg.LogArchiveMaxSize = viper.GetInt(ConfigLogArchiveMaxSize)
+	GlobalCfg.LogArchiveMaxAge = viper.GetDuration(ConfigLogArchiveMaxAge)
+
+	GlobalCfg.CaptureMaxBytes = viper.GetInt(ConfigCaptureMaxBytes)
+	GlobalCfg.CaptureRedact = viper.GetString(ConfigCaptureRedact)
+
+	GlobalCfg.NodeQuiesce = viper.GetString(ConfigNodeQuiesce)
+	GlobalCfg.NodeQuiesceInterval = viper.GetDuration(ConfigNodeQuiesceInterval)
+
+	GlobalCfg.DriftCheckInterval = viper.GetDuration(ConfigDriftCheckInterval)
+	GlobalCfg.DriftAutoCorrect = viper.GetBool(ConfigDriftAutoCorrect)
+
+	GlobalCfg.SinkQueueSize = viper.GetInt(ConfigSinkQueueSize)
+	GlobalCfg.SinkDrainTimeout = viper.GetDuration(ConfigSinkDrainTimeout)
+	GlobalCfg.SinkLagThreshold = viper.GetDuration(ConfigSinkLagThreshold)
+	GlobalCfg.SinkLagUnready = viper.GetBool(ConfigSinkLagUnready)
+
+	GlobalCfg.DurableSinkFile = viper.GetString(ConfigDurableSinkFile)
+	if actions := viper.GetString(ConfigDurableSinkActions); actions != "" {
+		GlobalCfg.DurableSinkActions = strings.Split(actions, ",")
+	}
+	GlobalCfg.DurableSinkJournalSize = viper.GetInt(ConfigDurableSinkJournalSize)
+	GlobalCfg.DurableSinkOverflow = viper.GetString(ConfigDurableSinkOverflow)
+	if GlobalCfg.DurableSinkOverflow != "block" && GlobalCfg.DurableSinkOverflow != "oldest" {
+		return fmt.Errorf("invalid overflow of the durable sink (%s), expected block or oldest", GlobalCfg.DurableSinkOverflow)
+	}
+
+	GlobalCfg.MetricsAddr = viper.GetString(ConfigMetricsAddr)
+	GlobalCfg.MetricsMaxPolicies = viper.GetInt(ConfigMetricsMaxPolicies)
+
+	GlobalCfg.TelemetrySchemaVersion = viper.GetString(ConfigTelemetrySchemaVersion)
+
+	GlobalCfg.DetachIdleProbes = viper.GetBool(ConfigDetachIdleProbes)
+	GlobalCfg.ProbeDetachDelay = viper.GetDuration(ConfigProbeDetachDelay)
+
+	GlobalCfg.AlertQueueSize = viper.GetInt(ConfigAlertQueueSize)
+	GlobalCfg.AlertDropPolicy = viper.GetString(ConfigAlertDropPolicy)
+	GlobalCfg.AlertWorkers = viper.GetInt(ConfigAlertWorkers)
+	GlobalCfg.LogQueueSize = viper.GetInt(ConfigLogQueueSize)
+	GlobalCfg.LogDropPolicy = viper.GetString(ConfigLogDropPolicy)
+
+	GlobalCfg.EventBufferPages = viper.GetInt(ConfigEventBufferPages)
+	GlobalCfg.EventChannelSize = viper.GetInt(ConfigEventChannelSize)
+	GlobalCfg.EventReplay = viper.GetBool(ConfigEventReplay)
+	GlobalCfg.StrictAttribution = viper.GetBool(ConfigStrictAttribution)
+	GlobalCfg.EnrichmentCacheSize = viper.GetInt(ConfigEnrichmentCacheSize)
+	GlobalCfg.ScopedInformers = viper.GetBool(ConfigScopedInformers)
+	GlobalCfg.GCPercent = viper.GetInt(ConfigGCPercent)
+
+	GlobalCfg.ContainerRetryWindow = viper.GetDuration(ConfigContainerRetryWindow)
+	GlobalCfg.CRIRequestTimeout = viper.GetDuration(ConfigCRIRequestTimeout)
+
+	GlobalCfg.DestroyedContainerRetention = viper.GetDuration(ConfigDestroyedContainerRetention)
+	GlobalCfg.DestroyedContainerLimit = viper.GetInt(ConfigDestroyedContainerLimit)
+
+	GlobalCfg.CRIPollingInterval = viper.GetDuration(ConfigCRIPollingInterval)
+	if GlobalCfg.CRIPollingInterval < MinCRIPollingInterval {
+		return fmt.Errorf("invalid CRI polling interval (%s), expected at least %s", GlobalCfg.CRIPollingInterval, MinCRIPollingInterval)
+	}
+	GlobalCfg.CRIPollingMaxInterval = viper.GetDuration(ConfigCRIPollingMaxInterval)
+	if GlobalCfg.CRIPollingMaxInterval < GlobalCfg.CRIPollingInterval {
+		return fmt.Errorf("invalid maximum CRI polling interval (%s), expected at least the CRI polling interval (%s)", GlobalCfg.CRIPollingMaxInterval, GlobalCfg.CRIPollingInterval)
+	}
+
+	stages, err := ParseEnrichmentStages(viper.GetString(ConfigEnrichmentStages))
+	if err != nil {
+		return err
+	}
+	GlobalCfg.EnrichmentStages = stages
+
+	fields, err := ParseTelemetryFieldPolicy(viper.GetString(ConfigTelemetryFieldPolicy))
+	if err != nil {
+		return err
+	}
+	GlobalCfg.TelemetryFieldPolicy = fields
+
+	GlobalCfg.AppArmorAttachThreshold = viper.GetDuration(ConfigAppArmorAttachThreshold)
+	GlobalCfg.AppArmorPreStart = viper.GetBool(ConfigAppArmorPreStart)
+
+	GlobalCfg.RuleConsolidationRatio = viper.GetFloat64(ConfigRuleConsolidationRatio)
+	if GlobalCfg.RuleConsolidationRatio < 0 || GlobalCfg.RuleConsolidationRatio > 1 {
+		return fmt.Errorf("invalid rule consolidation ratio (%v), expected a fraction between 0 and 1", GlobalCfg.RuleConsolidationRatio)
+	}
+	GlobalCfg.MaxEndpointRules = viper.GetInt(ConfigMaxEndpointRules)
+
 	kg.Printf("Final Configuration [%+v]", GlobalCfg)
 
 	return nil
@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ======================= //
+// == Enrichment Stages == //
+// ======================= //
+
+// ParseEnrichmentStages parses the toggles of the enrichment stages (stage=true|false,...)
+func ParseEnrichmentStages(toggles string) (map[string]bool, error) {
+	stages := map[string]bool{}
+
+	for _, entry := range strings.Split(toggles, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		stage, value, ok := strings.Cut(entry, "=")
+		if stage = strings.TrimSpace(stage); !ok || stage == "" {
+			return nil, fmt.Errorf("invalid enrichment stage (%s), expected stage=true|false", entry)
+		}
+
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid toggle of the enrichment stage %s (%s), expected true or false", stage, value)
+		}
+
+		stages[stage] = enabled
+	}
+
+	return stages, nil
+}
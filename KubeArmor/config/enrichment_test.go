@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package config
+
+import (
+	"testing"
+)
+
+func TestParseEnrichmentStages(t *testing.T) {
+	stages, err := ParseEnrichmentStages(" hostName=false, severityLabel=true,,")
+	if err != nil || len(stages) != 2 || stages["hostName"] || !stages["severityLabel"] {
+		t.Errorf("[FAIL] Unexpected toggles (%v, %v)", stages, err)
+	}
+
+	for _, toggles := range []string{"hostName", "=true", "hostName=maybe"} {
+		if _, err := ParseEnrichmentStages(toggles); err == nil {
+			t.Errorf("[FAIL] Expected the toggles to be rejected (%s)", toggles)
+		}
+	}
+
+	t.Log("[PASS] Parsed the toggles of the enrichment stages")
+}
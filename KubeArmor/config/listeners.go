@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ==================== //
+// == gRPC Listeners == //
+// ==================== //
+
+// gRPC services which can be exposed on each listener
+const (
+	GRPCServiceLog    = "log"    // logs, alerts, messages and health checks
+	GRPCServicePolicy = "policy" // policies in non-k8s mode
+	GRPCServiceProbe  = "probe"  // probe data
+	GRPCServiceAdmin  = "admin"  // on-demand resyncs
+)
+
+// GRPCServices are all of the gRPC services
+var GRPCServices = []string{GRPCServiceLog, GRPCServicePolicy, GRPCServiceProbe, GRPCServiceAdmin}
+
+// DefaultSocketMode is the mode of the socket files of the UDS listeners
+const DefaultSocketMode os.FileMode = 0660
+
+// GRPCListener Structure
+type GRPCListener struct {
+	Network string // tcp | unix
+	Address string // host:port, or the path of the socket file
+
+	// mode of the socket file
+	Mode os.FileMode
+
+	Services []string
+
+	// server certificate and key, and the CA to verify the clients with (mutual TLS)
+	TLSCertFile string
+	TLSKeyFile  string
+	TLSCAFile   string
+}
+
+// Serves checks if a listener exposes a service
+func (l GRPCListener) Serves(service string) bool {
+	for _, s := range l.Services {
+		if s == service {
+			return true
+		}
+	}
+	return false
+}
+
+// String Function
+func (l GRPCListener) String() string {
+	return l.Network + "://" + l.Address
+}
+
+// ParseGRPCListeners parses the listeners separated by ';', each one given as a URL with options
+// (e.g., unix:///var/run/kubearmor.sock?mode=0600&services=policy,probe, tcp://:32767?services=log&tlsCert=...&tlsKey=...)
+func ParseGRPCListeners(spec string) ([]GRPCListener, error) {
+	listeners := []GRPCListener{}
+
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		u, err := url.Parse(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gRPC listener (%s): %s", entry, err.Error())
+		}
+
+		listener := GRPCListener{Network: u.Scheme, Mode: DefaultSocketMode, Services: GRPCServices}
+
+		switch u.Scheme {
+		case "unix":
+			listener.Address = u.Path
+		case "tcp":
+			listener.Address = u.Host
+		default:
+			return nil, fmt.Errorf("invalid gRPC listener (%s), expected unix:// or tcp://", entry)
+		}
+
+		if listener.Address == "" {
+			return nil, fmt.Errorf("invalid gRPC listener (%s), no address", entry)
+		}
+
+		query := u.Query()
+
+		if mode := query.Get("mode"); mode != "" {
+			if listener.Network != "unix" {
+				return nil, fmt.Errorf("invalid gRPC listener (%s), mode is only for unix sockets", entry)
+			}
+			value, err := strconv.ParseUint(mode, 8, 32)
+			if err != nil || value > 0777 {
+				return nil, fmt.Errorf("invalid mode of gRPC listener (%s)", entry)
+			}
+			listener.Mode = os.FileMode(value)
+		}
+
+		if services := query.Get("services"); services != "" {
+			listener.Services = []string{}
+			for _, service := range strings.Split(services, ",") {
+				service = strings.TrimSpace(service)
+				known := false
+				for _, s := range GRPCServices {
+					if s == service {
+						known = true
+					}
+				}
+				if !known {
+					return nil, fmt.Errorf("unknown service (%s) of gRPC listener (%s), expected %s", service, entry, strings.Join(GRPCServices, ", "))
+				}
+				listener.Services = append(listener.Services, service)
+			}
+		}
+
+		listener.TLSCertFile = query.Get("tlsCert")
+		listener.TLSKeyFile = query.Get("tlsKey")
+		listener.TLSCAFile = query.Get("tlsCA")
+
+		if (listener.TLSCertFile == "") != (listener.TLSKeyFile == "") || (listener.TLSCAFile != "" && listener.TLSCertFile == "") {
+			return nil, fmt.Errorf("invalid TLS settings of gRPC listener (%s), expected tlsCert and tlsKey", entry)
+		}
+
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// identities of the clients of the gRPC listeners
+const (
+	GRPCIdentityUID = "uid:" // peer of a UDS listener (SO_PEERCRED)
+	GRPCIdentitySAN = "san:" // subject alternative name of the client certificate of a mTLS listener
+)
+
+// GRPCAuthzRule Structure
+type GRPCAuthzRule struct {
+	Identity string // uid:<uid> | san:<name>
+
+	// patterns of the allowed methods, given as Service/Method (e.g., LogService/Watch*), or * for all methods
+	Methods []string
+}
+
+// ParseGRPCAuthz parses the rules separated by ';', each one mapping an identity to its allowed methods
+// (e.g., uid:0=*;uid:1000=LogService/Watch*,ProbeService/*;san:telemetry.kubearmor.io=LogService/*)
+func ParseGRPCAuthz(spec string) ([]GRPCAuthzRule, error) {
+	rules := []GRPCAuthzRule{}
+
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		identity, methods, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid gRPC authorization rule (%s), expected identity=methods", entry)
+		}
+
+		rule := GRPCAuthzRule{Identity: strings.TrimSpace(identity), Methods: []string{}}
+
+		switch {
+		case strings.HasPrefix(rule.Identity, GRPCIdentityUID):
+			if _, err := strconv.ParseUint(strings.TrimPrefix(rule.Identity, GRPCIdentityUID), 10, 32); err != nil {
+				return nil, fmt.Errorf("invalid uid of gRPC authorization rule (%s)", entry)
+			}
+		case strings.HasPrefix(rule.Identity, GRPCIdentitySAN):
+			if strings.TrimPrefix(rule.Identity, GRPCIdentitySAN) == "" {
+				return nil, fmt.Errorf("invalid san of gRPC authorization rule (%s)", entry)
+			}
+		default:
+			return nil, fmt.Errorf("invalid identity of gRPC authorization rule (%s), expected uid:<uid> or san:<name>", entry)
+		}
+
+		for _, method := range strings.Split(methods, ",") {
+			method = strings.TrimSpace(method)
+			if method == "" {
+				continue
+			}
+			if _, err := path.Match(method, ""); err != nil {
+				return nil, fmt.Errorf("invalid method (%s) of gRPC authorization rule (%s)", method, entry)
+			}
+			rule.Methods = append(rule.Methods, method)
+		}
+
+		if len(rule.Methods) == 0 {
+			return nil, fmt.Errorf("invalid gRPC authorization rule (%s), no methods", entry)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
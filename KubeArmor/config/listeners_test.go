@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package config
+
+import (
+	"testing"
+)
+
+func TestParseGRPCListeners(t *testing.T) {
+	listeners, err := ParseGRPCListeners("unix:///var/run/kubearmor.sock?services=policy,probe;tcp://:32767?services=log&tlsCert=/certs/tls.crt&tlsKey=/certs/tls.key&tlsCA=/certs/ca.crt")
+	if err != nil || len(listeners) != 2 {
+		t.Fatalf("[FAIL] Failed to parse the listeners (%+v, %v)", listeners, err)
+	}
+
+	if uds := listeners[0]; uds.Address != "/var/run/kubearmor.sock" || uds.Mode != DefaultSocketMode || !uds.Serves(GRPCServicePolicy) || uds.Serves(GRPCServiceLog) {
+		t.Errorf("[FAIL] Unexpected UDS listener (%+v)", uds)
+	}
+
+	if tcp := listeners[1]; tcp.Address != ":32767" || tcp.TLSCAFile != "/certs/ca.crt" || len(tcp.Services) != 1 {
+		t.Errorf("[FAIL] Unexpected TCP listener (%+v)", tcp)
+	}
+
+	// all services by default
+	if listeners, err := ParseGRPCListeners("tcp://:32767"); err != nil || len(listeners[0].Services) != len(GRPCServices) {
+		t.Errorf("[FAIL] Expected all services (%+v, %v)", listeners, err)
+	}
+
+	for _, spec := range []string{"udp://:32767", "tcp://", "tcp://:32767?services=logs", "tcp://:32767?mode=0600", "unix:///a.sock?mode=999", "tcp://:32767?tlsCert=/tls.crt"} {
+		if _, err := ParseGRPCListeners(spec); err == nil {
+			t.Errorf("[FAIL] Expected the listener (%s) to be rejected", spec)
+		}
+	}
+
+	t.Log("[PASS] Parsed the gRPC listeners")
+}
+
+func TestParseGRPCAuthz(t *testing.T) {
+	rules, err := ParseGRPCAuthz("uid:0=*; uid:1000=LogService/Watch*,ProbeService/*; san:spiffe://cluster/telemetry=LogService/*")
+	if err != nil || len(rules) != 3 {
+		t.Fatalf("[FAIL] Failed to parse the rules (%+v, %v)", rules, err)
+	}
+
+	if rule := rules[1]; rule.Identity != "uid:1000" || len(rule.Methods) != 2 || rule.Methods[0] != "LogService/Watch*" {
+		t.Errorf("[FAIL] Unexpected rule (%+v)", rule)
+	}
+
+	if rule := rules[2]; rule.Identity != "san:spiffe://cluster/telemetry" || rule.Methods[0] != "LogService/*" {
+		t.Errorf("[FAIL] Unexpected rule (%+v)", rule)
+	}
+
+	for _, spec := range []string{"uid:1000", "uid:root=*", "san:=*", "gid:0=*", "uid:0=", "uid:0=LogService/[Watch"} {
+		if _, err := ParseGRPCAuthz(spec); err == nil {
+			t.Errorf("[FAIL] Expected the rule (%s) to be rejected", spec)
+		}
+	}
+
+	t.Log("[PASS] Parsed the gRPC authorization rules")
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ===================== //
+// == Low Memory Mode == //
+// ===================== //
+
+// LowMemorySetting is a reduction of the low memory mode
+type LowMemorySetting struct {
+	Key   string
+	Value interface{}
+
+	// what is lost with the reduction
+	Tradeoff string
+}
+
+// LowMemoryProfile is the profile of the low memory mode, for small edge nodes (e.g., 512MB), each setting of which
+// can still be overridden individually (by a flag, an env var, or the config file)
+var LowMemoryProfile = []LowMemorySetting{
+	{
+		// 64 pages per CPU instead of 1024 (256KB instead of 4MB per CPU with 4KB pages)
+		Key: ConfigEventBufferPages, Value: 64,
+		Tradeoff: "bursts of events overflow the per-CPU buffers sooner, and the events over them are lost",
+	},
+	{
+		Key: ConfigEventChannelSize, Value: 1024,
+		Tradeoff: "the events wait less in userspace, so a slow consumer makes the kernel buffers overflow sooner",
+	},
+	{
+		Key: ConfigEventReplay, Value: false,
+		Tradeoff: "the events of the containers started just before they are known to KubeArmor are dropped rather than retried",
+	},
+	{
+		Key: ConfigEnrichmentCacheSize, Value: 1024,
+		Tradeoff: "the socket creators and the exec sessions of fewer processes are remembered, so the events of the evicted ones aren't enriched",
+	},
+	{
+		Key: ConfigScopedInformers, Value: true,
+		Tradeoff: "only the node and the pods of this node are watched (KUBEARMOR_NODENAME is needed for the pods)",
+	},
+	{
+		Key: ConfigSinkQueueSize, Value: 128,
+		Tradeoff: "the alerts are dropped for a slow alert sink sooner",
+	},
+	{
+		Key: ConfigAlertQueueSize, Value: 100,
+		Tradeoff: "the alerts are dropped for a slow WatchAlerts client sooner",
+	},
+	{
+		Key: ConfigAlertWorkers, Value: 1,
+		Tradeoff: "all the WatchAlerts clients are served by a single worker, so a slow client delays the others",
+	},
+	{
+		Key: ConfigLogQueueSize, Value: 100,
+		Tradeoff: "the logs are dropped for a slow WatchLogs client sooner",
+	},
+	{
+		Key: ConfigFlowSummaryMaxFlows, Value: 256,
+		Tradeoff: "more flows are summarized together as other",
+	},
+	{
+		Key: ConfigRecentExecsMax, Value: 4096,
+		Tradeoff: "fewer recent executions are kept for getRecentExecs, the oldest ones are evicted sooner",
+	},
+	{
+		Key: ConfigMetricsMaxPolicies, Value: 20,
+		Tradeoff: "more policies are tracked together as other in the metrics",
+	},
+	{
+		// GOGC=50 instead of 100
+		Key: ConfigGCPercent, Value: 50,
+		Tradeoff: "the heap is collected twice as often, at the cost of CPU",
+	},
+}
+
+// isExplicitlySet checks if a setting is given by a flag, an env var, or the config file
+func isExplicitlySet(key string) bool {
+	explicit := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == key {
+			explicit = true
+		}
+	})
+	if explicit {
+		return true
+	}
+
+	if _, ok := os.LookupEnv(strings.ToUpper(key)); ok {
+		return true
+	}
+
+	return viper.InConfig(key)
+}
+
+// applyLowMemoryProfile applies the settings of the low memory mode which are not set explicitly, and returns the active profile
+func applyLowMemoryProfile(isExplicit func(key string) bool) []string {
+	profile := []string{}
+
+	for _, setting := range LowMemoryProfile {
+		if isExplicit(setting.Key) {
+			profile = append(profile, fmt.Sprintf("%s:%v (overridden)", setting.Key, viper.Get(setting.Key)))
+			continue
+		}
+
+		viper.Set(setting.Key, setting.Value)
+		profile = append(profile, fmt.Sprintf("%s:%v", setting.Key, setting.Value))
+	}
+
+	return profile
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ===================== //
+// == Severity Labels == //
+// ===================== //
+
+// DefaultSeverityLabels is the default mapping table of the numeric severities to named levels
+const DefaultSeverityLabels = "low:1-3,medium:4-6,high:7-8,critical:9-10"
+
+// DefaultSeverityLevels are the levels of the default mapping table, used until the configuration is loaded
+var DefaultSeverityLevels = []SeverityLevel{
+	{Label: "low", Min: 1, Max: 3},
+	{Label: "medium", Min: 4, Max: 6},
+	{Label: "high", Min: 7, Max: 8},
+	{Label: "critical", Min: 9, Max: 10},
+}
+
+// SeverityLevel Structure
+type SeverityLevel struct {
+	Label string
+
+	// range of numeric severities (inclusive)
+	Min int
+	Max int
+}
+
+// ParseSeverityLabels parses a mapping table (label:min-max,...) whose ranges are in 1-10
+// and don't overlap
+func ParseSeverityLabels(table string) ([]SeverityLevel, error) {
+	levels := []SeverityLevel{}
+	covered := map[int]string{}
+
+	for _, entry := range strings.Split(table, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		label, bounds, ok := strings.Cut(entry, ":")
+		if !ok || label == "" {
+			return nil, fmt.Errorf("invalid severity label (%s), expected label:min-max", entry)
+		}
+
+		level := SeverityLevel{Label: strings.ToLower(strings.TrimSpace(label))}
+
+		minBound, maxBound, isRange := strings.Cut(bounds, "-")
+		if !isRange {
+			maxBound = minBound
+		}
+
+		var err error
+		if level.Min, err = strconv.Atoi(strings.TrimSpace(minBound)); err != nil {
+			return nil, fmt.Errorf("invalid severity label (%s), expected label:min-max", entry)
+		}
+		if level.Max, err = strconv.Atoi(strings.TrimSpace(maxBound)); err != nil {
+			return nil, fmt.Errorf("invalid severity label (%s), expected label:min-max", entry)
+		}
+
+		if level.Min < 1 || level.Max > 10 || level.Min > level.Max {
+			return nil, fmt.Errorf("invalid range of severity label %s (%d-%d), expected a range in 1-10", level.Label, level.Min, level.Max)
+		}
+
+		for severity := level.Min; severity <= level.Max; severity++ {
+			if other, ok := covered[severity]; ok {
+				return nil, fmt.Errorf("severity %d is mapped to both %s and %s", severity, other, level.Label)
+			}
+			covered[severity] = level.Label
+		}
+
+		levels = append(levels, level)
+	}
+
+	return levels, nil
+}
+
+// SeverityLabel returns the named level of a numeric severity ("" if unmapped)
+func SeverityLabel(levels []SeverityLevel, severity string) string {
+	if levels == nil {
+		levels = DefaultSeverityLevels
+	}
+
+	value, err := strconv.Atoi(severity)
+	if err != nil {
+		return ""
+	}
+
+	for _, level := range levels {
+		if value >= level.Min && value <= level.Max {
+			return level.Label
+		}
+	}
+
+	return ""
+}
+
+// ParseSeverity returns the numeric severity of a number, or the lowest severity of a named level
+func ParseSeverity(levels []SeverityLevel, severity string) (int, error) {
+	if levels == nil {
+		levels = DefaultSeverityLevels
+	}
+
+	severity = strings.TrimSpace(severity)
+
+	if value, err := strconv.Atoi(severity); err == nil {
+		return value, nil
+	}
+
+	for _, level := range levels {
+		if strings.EqualFold(level.Label, severity) {
+			return level.Min, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown severity (%s)", severity)
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package config
+
+import (
+	"testing"
+)
+
+func TestSeverityLabels(t *testing.T) {
+	levels, err := ParseSeverityLabels(DefaultSeverityLabels)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to parse the default table (%s)", err.Error())
+	}
+
+	for severity, label := range map[string]string{"1": "low", "5": "medium", "7": "high", "8": "high", "10": "critical", "0": "", "x": ""} {
+		if got := SeverityLabel(levels, severity); got != label {
+			t.Errorf("[FAIL] Expected %s for severity %s (%s)", label, severity, got)
+		}
+	}
+
+	for value, severity := range map[string]int{"high": 7, " Critical ": 9, "3": 3} {
+		if got, err := ParseSeverity(levels, value); err != nil || got != severity {
+			t.Errorf("[FAIL] Expected %d for %s (%d, %v)", severity, value, got, err)
+		}
+	}
+	if _, err := ParseSeverity(levels, "urgent"); err == nil {
+		t.Errorf("[FAIL] Expected an unknown label to be rejected")
+	}
+
+	// custom tables replace the default one
+	levels, err = ParseSeverityLabels("info:1-5, page:6-10")
+	if err != nil || SeverityLabel(levels, "6") != "page" {
+		t.Errorf("[FAIL] Unexpected custom table (%+v, %v)", levels, err)
+	}
+	if _, err := ParseSeverity(levels, "high"); err == nil {
+		t.Errorf("[FAIL] Expected the default labels to be replaced")
+	}
+
+	for _, table := range []string{"low:0-3", "low:1-11", "low:5-3", "low", "low:1-5,high:5-10", "low:a-b"} {
+		if _, err := ParseSeverityLabels(table); err == nil {
+			t.Errorf("[FAIL] Expected the table (%s) to be rejected", table)
+		}
+	}
+
+	t.Log("[PASS] Mapped the severities to named levels")
+}
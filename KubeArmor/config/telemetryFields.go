@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ============================ //
+// == Telemetry Field Policy == //
+// ============================ //
+
+// actions of the telemetry field policy
+const (
+	TelemetryFieldDrop = "drop"
+	TelemetryFieldHash = "hash"
+)
+
+// TelemetryFields are the fields of the alerts and the logs which can be dropped or hashed
+var TelemetryFields = []string{
+	"owner",
+	"owner.ref",
+	"owner.name",
+	"owner.namespace",
+	"podName",
+	"labels",
+	"containerName",
+	"containerImage",
+	"parentProcessName",
+	"processName",
+	"source",
+	"resource",
+	"arguments", // the arguments in the resource of the process events
+	"cwd",
+	"data",
+}
+
+// ParseTelemetryFieldPolicy parses the fields to drop or hash (field=drop|hash,...)
+func ParseTelemetryFieldPolicy(policy string) (map[string]string, error) {
+	fields := map[string]string{}
+
+	for _, entry := range strings.Split(policy, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		field, action, ok := strings.Cut(entry, "=")
+		if field = strings.TrimSpace(field); !ok || field == "" {
+			return nil, fmt.Errorf("invalid telemetry field (%s), expected field=drop|hash", entry)
+		}
+
+		known := false
+		for _, name := range TelemetryFields {
+			if name == field {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown telemetry field (%s), expected one of %s", field, strings.Join(TelemetryFields, ","))
+		}
+
+		action = strings.ToLower(strings.TrimSpace(action))
+		if action != TelemetryFieldDrop && action != TelemetryFieldHash {
+			return nil, fmt.Errorf("invalid action of the telemetry field %s (%s), expected drop or hash", field, action)
+		}
+
+		fields[field] = action
+	}
+
+	return fields, nil
+}
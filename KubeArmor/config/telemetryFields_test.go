@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package config
+
+import (
+	"testing"
+)
+
+func TestParseTelemetryFieldPolicy(t *testing.T) {
+	fields, err := ParseTelemetryFieldPolicy(" arguments=drop, labels=HASH,,")
+	if err != nil || len(fields) != 2 || fields["arguments"] != TelemetryFieldDrop || fields["labels"] != TelemetryFieldHash {
+		t.Errorf("[FAIL] Unexpected fields (%v, %v)", fields, err)
+	}
+
+	for _, policy := range []string{"labels", "=drop", "labels=mask", "userName=drop"} {
+		if _, err := ParseTelemetryFieldPolicy(policy); err == nil {
+			t.Errorf("[FAIL] Expected the field policy to be rejected (%s)", policy)
+		}
+	}
+
+	t.Log("[PASS] Parsed the telemetry field policy")
+}
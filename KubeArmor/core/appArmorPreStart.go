@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/containerd/nri/pkg/api"
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ======================== //
+// == AppArmor Pre-Start == //
+// ======================== //
+
+// the labels of the revisions of the pods, which are not in the labels of the endpoints
+var revisionLabels = []string{"pod-template-hash", "pod-template-generation", "controller-revision-hash"}
+
+// prepareAppArmorProfile loads the AppArmor profile of a container being created (overridden by the tests)
+var prepareAppArmorProfile = func(dm *KubeArmorDaemon, endPoint tp.EndPoint, podName, containerName, profile string) error {
+	return dm.RuntimeEnforcer.PrepareAppArmorProfile(endPoint, podName, containerName, profile)
+}
+
+// preStartEndPoint builds the endpoint of a container being created from the labels and the annotations of its pod,
+// and returns false if no policy selects the container yet
+func (dm *KubeArmorDaemon) preStartEndPoint(pod *api.PodSandbox, containerName string) (tp.EndPoint, bool) {
+	endPoint := tp.EndPoint{
+		NamespaceName: pod.GetNamespace(),
+		EndPointName:  pod.GetName(),
+		ContainerName: containerName,
+		Labels:        map[string]string{},
+		Identities:    []string{"namespaceName=" + pod.GetNamespace()},
+	}
+
+	for k, v := range pod.GetLabels() {
+		if kl.ContainsElement(revisionLabels, k) {
+			continue
+		}
+		endPoint.Labels[k] = v
+		endPoint.Identities = append(endPoint.Identities, k+"="+v)
+	}
+
+	sort.Strings(endPoint.Identities)
+
+	switch pod.GetAnnotations()["kubearmor-policy"] {
+	case "enabled":
+		endPoint.PolicyEnabled = tp.KubeArmorPolicyEnabled
+	case "audited":
+		endPoint.PolicyEnabled = tp.KubeArmorPolicyAudited
+	default:
+		return endPoint, false
+	}
+
+	for _, policy := range dm.GetSecurityPolicies(endPoint.Identities) {
+		if len(policy.Spec.Selector.Containers) == 0 || kl.ContainsElement(policy.Spec.Selector.Containers, containerName) {
+			endPoint.SecurityPolicies = append(endPoint.SecurityPolicies, policy)
+		}
+	}
+
+	if len(endPoint.SecurityPolicies) == 0 {
+		return endPoint, false
+	}
+
+	dm.DefaultPosturesLock.Lock()
+	if posture, ok := dm.DefaultPostures[endPoint.NamespaceName]; ok {
+		endPoint.DefaultPosture = posture
+	} else {
+		endPoint.DefaultPosture = getGlobalDefaultPosture()
+	}
+	dm.DefaultPosturesLock.Unlock()
+
+	endPoint.PostureOverride = getPostureOverride(pod.GetAnnotations())
+	endPoint.DefaultPosture = applyPostureOverride(endPoint.DefaultPosture, endPoint.PostureOverride)
+
+	return endPoint, true
+}
+
+// expectedAppArmorProfile returns the profile KubeArmor generates for a container of the endpoints with the given
+// labels (i.e., of the pods of the same owner), or "" if no such endpoint is known yet
+func (dm *KubeArmorDaemon) expectedAppArmorProfile(namespaceName string, labels map[string]string, containerName string) string {
+	dm.EndPointsLock.RLock()
+	defer dm.EndPointsLock.RUnlock()
+
+	for _, endPoint := range dm.EndPoints {
+		if endPoint.NamespaceName == namespaceName && endPoint.Owner.Name != "" && reflect.DeepEqual(endPoint.Labels, labels) {
+			return "kubearmor-" + namespaceName + "-" + endPoint.Owner.Name + "-" + containerName
+		}
+	}
+
+	return ""
+}
+
+// preStartAppArmorProfile loads the AppArmor profile given to a container being created by the annotation of its pod
+// with the policies selecting the container, so that the container is enforced from its first exec rather than once
+// the policies are applied after its start, and returns the loaded profile ("" if none)
+func (dm *KubeArmorDaemon) preStartAppArmorProfile(pod *api.PodSandbox, ctr *api.Container) string {
+	endPoint, ok := dm.preStartEndPoint(pod, ctr.GetName())
+	if !ok {
+		return ""
+	}
+
+	// the profile of the container is set by the annotation of its pod (e.g., by the admission controller), since the
+	// runtime doesn't let NRI plugins change it
+	profile := nriAppArmorProfile(pod, ctr)
+	if profile == "" {
+		if expected := dm.expectedAppArmorProfile(endPoint.NamespaceName, endPoint.Labels, ctr.GetName()); expected != "" {
+			dm.Logger.Warnf("The container %s/%s/%s is created without its AppArmor profile (%s), its policies are enforced once its pod is annotated and restarted", pod.GetNamespace(), pod.GetName(), ctr.GetName(), expected)
+		} else {
+			dm.Logger.Warnf("The container %s/%s/%s is created without an AppArmor profile, its policies are enforced once its pod is annotated and restarted", pod.GetNamespace(), pod.GetName(), ctr.GetName())
+		}
+		return ""
+	}
+	if profile == "unconfined" || !strings.HasPrefix(profile, "kubearmor-") {
+		return ""
+	}
+
+	if err := prepareAppArmorProfile(dm, endPoint, pod.GetName(), ctr.GetName(), profile); err != nil {
+		dm.Logger.Warnf("Failed to load the AppArmor profile of %s/%s/%s before its start (%s, %s)", pod.GetNamespace(), pod.GetName(), ctr.GetName(), profile, err.Error())
+		return ""
+	}
+
+	dm.AppArmorAttachment.ProfilesGenerated(pod.GetNamespace(), pod.GetName(), map[string]string{ctr.GetName(): profile})
+
+	dm.Logger.Printf("Loaded the AppArmor profile of %s/%s/%s before its start (%s)", pod.GetNamespace(), pod.GetName(), ctr.GetName(), profile)
+
+	return profile
+}
+
+// CreateContainer Function loads the AppArmor profile of a container before the runtime creates it (-apparmorPreStart)
+func (nh *NRIHandler) CreateContainer(pod *api.PodSandbox, ctr *api.Container) (*api.ContainerAdjustment, []*api.ContainerUpdate, error) {
+	// the creation of the container isn't failed by KubeArmor
+	nh.dm.preStartAppArmorProfile(pod, ctr)
+
+	return nil, nil, nil
+}
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/containerd/nri/pkg/api"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	"github.com/kubearmor/KubeArmor/KubeArmor/enforcer"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestPreStartAppArmorProfile(t *testing.T) {
+	prevCfg := cfg.GlobalCfg
+	defer func() { cfg.GlobalCfg = prevCfg }()
+
+	// the profiles are loaded by the enforcer
+	type prepared struct {
+		endPoint tp.EndPoint
+		podName  string
+		profile  string
+	}
+	loaded := []prepared{}
+
+	prevPrepare := prepareAppArmorProfile
+	defer func() { prepareAppArmorProfile = prevPrepare }()
+
+	prepareAppArmorProfile = func(_ *KubeArmorDaemon, endPoint tp.EndPoint, podName, _, profile string) error {
+		loaded = append(loaded, prepared{endPoint, podName, profile})
+		return nil
+	}
+
+	dm := newCrioTestDaemon()
+	dm.Logger.Output = "none"
+	dm.Logger.SeverityRangesLock = new(sync.RWMutex)
+	dm.Logger.SinksLock = new(sync.RWMutex)
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "web", "policyName": "block-sh"}}
+	policy.Spec.Selector.Identities = []string{"namespaceName=web", "app=frontend"}
+	policy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Block"}}
+	dm.SecurityPolicies = []tp.SecurityPolicy{policy}
+
+	// a sibling pod is known already
+	dm.EndPoints = []tp.EndPoint{{NamespaceName: "web", EndPointName: "frontend-x2k8p", Owner: tp.PodOwner{Ref: "Deployment", Name: "frontend"}, Labels: map[string]string{"app": "frontend"}}}
+
+	pod := &api.PodSandbox{
+		Name:      "frontend-7d4b9",
+		Namespace: "web",
+		Labels:    map[string]string{"app": "frontend", "pod-template-hash": "7d4b9"},
+		Annotations: map[string]string{
+			"kubearmor-policy":              "enabled",
+			nriAppArmorAnnotation + "nginx": "localhost/kubearmor-web-frontend-nginx",
+		},
+	}
+	nginx := &api.Container{Name: "nginx"}
+
+	if profile := dm.preStartAppArmorProfile(pod, nginx); profile != "kubearmor-web-frontend-nginx" || len(loaded) != 1 {
+		t.Fatalf("[FAIL] Expected the profile of the container to be loaded (%s)", profile)
+	}
+
+	endPoint := loaded[0].endPoint
+	if loaded[0].podName != "frontend-7d4b9" || len(endPoint.SecurityPolicies) != 1 || endPoint.PolicyEnabled != tp.KubeArmorPolicyEnabled {
+		t.Errorf("[FAIL] Unexpected endpoint of the container (%s, %+v)", loaded[0].podName, endPoint)
+	}
+	if _, ok := endPoint.Labels["pod-template-hash"]; ok || endPoint.DefaultPosture.FileSource == "" {
+		t.Errorf("[FAIL] Expected the labels and the posture of the endpoint (%v, %+v)", endPoint.Labels, endPoint.DefaultPosture)
+	}
+
+	// the profile of a container without its annotation can't be changed
+	if expected := dm.expectedAppArmorProfile("web", map[string]string{"app": "frontend"}, "sidecar"); expected != "kubearmor-web-frontend-sidecar" {
+		t.Errorf("[FAIL] Unexpected profile expected for the labels (%s)", expected)
+	}
+	if profile := dm.preStartAppArmorProfile(pod, &api.Container{Name: "sidecar"}); profile != "" || len(loaded) != 1 {
+		t.Errorf("[FAIL] Unexpected profile of a container without its annotation (%s)", profile)
+	}
+
+	// no policy selects the container, or the policies are disabled
+	other := &api.PodSandbox{Name: "api-5f6c8", Namespace: "web", Labels: map[string]string{"app": "api"}, Annotations: pod.Annotations}
+	disabled := &api.PodSandbox{Name: pod.Name, Namespace: pod.Namespace, Labels: pod.Labels, Annotations: map[string]string{nriAppArmorAnnotation + "nginx": "localhost/kubearmor-web-frontend-nginx"}}
+
+	if dm.preStartAppArmorProfile(other, nginx) != "" || dm.preStartAppArmorProfile(disabled, nginx) != "" || len(loaded) != 1 {
+		t.Errorf("[FAIL] Expected no profile to be loaded without policies (%d)", len(loaded))
+	}
+
+	// the creation of the containers is only received with the AppArmor enforcer
+	nh := newNRIHandler(dm, RuntimeCrio)
+	cfg.GlobalCfg.AppArmorPreStart = true
+
+	if mask, err := nh.Configure("", RuntimeCrio, "1.26.0"); err != nil || mask.IsSet(api.Event_CREATE_CONTAINER) {
+		t.Errorf("[FAIL] Unexpected events without the AppArmor enforcer (%s, %v)", mask.PrettyString(), err)
+	}
+
+	dm.RuntimeEnforcer = &enforcer.RuntimeEnforcer{EnforcerType: "AppArmor"}
+
+	if mask, err := nh.Configure("", RuntimeCrio, "1.26.0"); err != nil || !mask.IsSet(api.Event_CREATE_CONTAINER) || !mask.IsSet(api.Event_START_CONTAINER) {
+		t.Errorf("[FAIL] Expected the creation of the containers to be received (%s, %v)", mask.PrettyString(), err)
+	}
+
+	t.Log("[PASS] Loaded the AppArmor profiles of the containers before their creation")
+}
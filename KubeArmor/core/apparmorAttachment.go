@@ -0,0 +1,395 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ========================= //
+// == AppArmor Attachment == //
+// ========================= //
+
+// AppArmorAttachmentPolicyName is the policy name of the alerts about the attachment of the AppArmor profiles
+const AppArmorAttachmentPolicyName = "kubearmor-apparmor-attachment"
+
+// AppArmor states of the process of a container
+const (
+	AppArmorAttached     = "attached"     // confined by the profile of KubeArmor
+	AppArmorOtherProfile = "otherProfile" // confined by another profile (e.g., the default profile of the runtime)
+	AppArmorUnconfined   = "unconfined"   // not confined at all
+	AppArmorUnknown      = "unknown"      // the process is gone, or its attr can't be read
+)
+
+// the interval of checking the profiles of the containers
+var appArmorAttachmentInterval = 2 * time.Second
+
+// AppArmorContainer Structure
+type AppArmorContainer struct {
+	ContainerID   string
+	NamespaceName string
+	PodName       string
+	ContainerName string
+	Pid           uint32
+
+	// the profile generated by KubeArmor for the container (empty until the pod is known)
+	Profile string
+
+	FirstSeen        time.Time
+	ProfileGenerated time.Time
+	Attached         time.Time
+
+	// the last state seen, and the profile confining the process if it isn't the one of KubeArmor
+	State        string
+	OtherProfile string
+
+	// the lag exceeding the threshold, or the missing attachment, is alerted once
+	Alerted bool
+}
+
+// AppArmorAttachment Structure
+type AppArmorAttachment struct {
+	// container ID -> container
+	containers map[string]*AppArmorContainer
+
+	// namespace/pod/container -> profile generated by KubeArmor, and when
+	profiles  map[string]string
+	generated map[string]time.Time
+
+	lags       *prometheus.HistogramVec
+	unattached *prometheus.CounterVec
+
+	lock *sync.Mutex
+}
+
+// NewAppArmorAttachment Function
+func NewAppArmorAttachment() *AppArmorAttachment {
+	aa := &AppArmorAttachment{}
+
+	aa.containers = map[string]*AppArmorContainer{}
+	aa.profiles = map[string]string{}
+	aa.generated = map[string]time.Time{}
+
+	aa.lags = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "kubearmor",
+		Name:      "apparmor_attachment_lag_seconds",
+		Help:      "Time from the detection of a container to its AppArmor profile being generated and attached, per stage",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12), // 100ms - 204s
+	}, []string{"stage"})
+
+	aa.unattached = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kubearmor",
+		Name:      "apparmor_unattached_containers_total",
+		Help:      "Number of the containers whose AppArmor profile wasn't attached (the pods need a restart), per state",
+	}, []string{"state"})
+
+	aa.lock = new(sync.Mutex)
+
+	return aa
+}
+
+// Register Function serves the metrics with the given registry
+func (aa *AppArmorAttachment) Register(registry *prometheus.Registry) {
+	registry.MustRegister(aa.lags, aa.unattached)
+}
+
+// profileKey Function
+func profileKey(namespaceName, podName, containerName string) string {
+	return namespaceName + "/" + podName + "/" + containerName
+}
+
+// ContainerSeen Function starts to track the attachment of a new container
+func (aa *AppArmorAttachment) ContainerSeen(container tp.Container) {
+	if aa == nil || container.Pid == 0 {
+		return
+	}
+
+	aa.lock.Lock()
+	defer aa.lock.Unlock()
+
+	if _, ok := aa.containers[container.ContainerID]; ok {
+		return
+	}
+
+	ac := &AppArmorContainer{
+		ContainerID:   container.ContainerID,
+		NamespaceName: container.NamespaceName,
+		PodName:       container.EndPointName,
+		ContainerName: container.ContainerName,
+		Pid:           container.Pid,
+		FirstSeen:     time.Now(),
+		State:         AppArmorUnknown,
+	}
+
+	key := profileKey(ac.NamespaceName, ac.PodName, ac.ContainerName)
+	if profile, ok := aa.profiles[key]; ok {
+		ac.Profile = profile
+		ac.ProfileGenerated = aa.generated[key]
+	}
+
+	aa.containers[container.ContainerID] = ac
+}
+
+// ContainerRemoved Function
+func (aa *AppArmorAttachment) ContainerRemoved(containerID string) {
+	if aa == nil {
+		return
+	}
+
+	aa.lock.Lock()
+	defer aa.lock.Unlock()
+
+	delete(aa.containers, containerID)
+}
+
+// ProfilesGenerated Function records the profiles generated for the containers of a pod (container name -> profile)
+func (aa *AppArmorAttachment) ProfilesGenerated(namespaceName, podName string, profiles map[string]string) {
+	if aa == nil {
+		return
+	}
+
+	aa.lock.Lock()
+	defer aa.lock.Unlock()
+
+	now := time.Now()
+
+	for containerName, profile := range profiles {
+		// the profiles not managed by KubeArmor (e.g., unconfined) aren't tracked
+		if !strings.HasPrefix(profile, "kubearmor-") {
+			continue
+		}
+
+		key := profileKey(namespaceName, podName, containerName)
+		if aa.profiles[key] == profile {
+			continue
+		}
+
+		aa.profiles[key] = profile
+		aa.generated[key] = now
+
+		// the containers seen before the pod
+		for _, ac := range aa.containers {
+			if ac.Profile == "" && profileKey(ac.NamespaceName, ac.PodName, ac.ContainerName) == key {
+				ac.Profile = profile
+				ac.ProfileGenerated = now
+			}
+		}
+	}
+}
+
+// ProfilesRemoved Function forgets the profiles of a deleted pod
+func (aa *AppArmorAttachment) ProfilesRemoved(namespaceName, podName string) {
+	if aa == nil {
+		return
+	}
+
+	aa.lock.Lock()
+	defer aa.lock.Unlock()
+
+	prefix := namespaceName + "/" + podName + "/"
+	for key := range aa.profiles {
+		if strings.HasPrefix(key, prefix) {
+			delete(aa.profiles, key)
+			delete(aa.generated, key)
+		}
+	}
+}
+
+// readAppArmorAttr Function reads the AppArmor label of a process (e.g., "name (enforce)" or "unconfined")
+func readAppArmorAttr(pid uint32) (string, error) {
+	pidStr := strconv.FormatUint(uint64(pid), 10)
+
+	// the attr of the LSM stacking interface, or the legacy one
+	data, err := os.ReadFile(kl.GetProcPath(pidStr, "attr", "apparmor", "current"))
+	if err != nil {
+		data, err = os.ReadFile(kl.GetProcPath(pidStr, "attr", "current"))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return strings.TrimSpace(strings.TrimRight(string(data), "\x00")), nil
+}
+
+// getAppArmorState Function returns the state of a process given its AppArmor label, and the profile confining it
+func getAppArmorState(label, profile string) (string, string) {
+	if label == "" {
+		return AppArmorUnknown, ""
+	}
+
+	if label == "unconfined" {
+		return AppArmorUnconfined, ""
+	}
+
+	// "name (mode)"
+	name := label
+	if idx := strings.LastIndex(label, " ("); idx > 0 && strings.HasSuffix(label, ")") {
+		name = label[:idx]
+	}
+
+	if name == profile {
+		return AppArmorAttached, name
+	}
+
+	return AppArmorOtherProfile, name
+}
+
+// Check Function checks the profiles of the containers, and returns the ones attached since the last check and the
+// ones to alert
+func (aa *AppArmorAttachment) Check(now time.Time, threshold time.Duration) []AppArmorContainer {
+	if aa == nil {
+		return nil
+	}
+
+	aa.lock.Lock()
+	defer aa.lock.Unlock()
+
+	alerts := []AppArmorContainer{}
+
+	for containerID, ac := range aa.containers {
+		if !ac.Attached.IsZero() || ac.Alerted {
+			continue
+		}
+
+		// no profile of KubeArmor for the container (e.g., policies disabled in the namespace)
+		if ac.Profile == "" {
+			if now.Sub(ac.FirstSeen) > threshold {
+				delete(aa.containers, containerID)
+			}
+			continue
+		}
+
+		label, err := readAppArmorAttr(ac.Pid)
+		if err != nil {
+			// the container is gone
+			delete(aa.containers, containerID)
+			continue
+		}
+
+		ac.State, ac.OtherProfile = getAppArmorState(label, ac.Profile)
+
+		switch ac.State {
+		case AppArmorAttached:
+			ac.Attached = now
+			ac.OtherProfile = ""
+
+			aa.lags.WithLabelValues("generated").Observe(nonNegative(ac.ProfileGenerated.Sub(ac.FirstSeen)).Seconds())
+			aa.lags.WithLabelValues("attached").Observe(ac.Attached.Sub(ac.FirstSeen).Seconds())
+
+			if threshold > 0 && ac.Attached.Sub(ac.FirstSeen) > threshold {
+				ac.Alerted = true
+			}
+			alerts = append(alerts, *ac)
+
+		case AppArmorOtherProfile, AppArmorUnconfined:
+			// the profile is only attached when the container starts, so the pod needs a restart
+			if threshold > 0 && now.Sub(ac.FirstSeen) > threshold {
+				ac.Alerted = true
+				aa.unattached.WithLabelValues(ac.State).Inc()
+				alerts = append(alerts, *ac)
+			}
+		}
+	}
+
+	return alerts
+}
+
+// nonNegative Function
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// ================= //
+// == Daemon Side == //
+// ================= //
+
+// checkAppArmorAttachment Function checks the profiles of the containers, and logs and alerts the results
+func (dm *KubeArmorDaemon) checkAppArmorAttachment(now time.Time) {
+	for _, ac := range dm.AppArmorAttachment.Check(now, cfg.GlobalCfg.AppArmorAttachThreshold) {
+		if ac.State == AppArmorAttached {
+			dm.Logger.Printf("Detected a container (attached/%.12s/profile=%s/lag=%s)", ac.ContainerID, ac.Profile, ac.Attached.Sub(ac.FirstSeen).Round(time.Millisecond))
+		}
+
+		if ac.Alerted {
+			dm.reportAppArmorAttachment(ac, now)
+		}
+	}
+}
+
+// reportAppArmorAttachment Function raises a warning alert for a container whose profile is attached late, or not at all
+func (dm *KubeArmorDaemon) reportAppArmorAttachment(ac AppArmorContainer, now time.Time) {
+	if dm.Logger == nil {
+		return
+	}
+
+	log := tp.Log{}
+
+	timestamp, updatedTime := kl.GetDateTimeNow()
+
+	log.Timestamp = timestamp
+	log.UpdatedTime = updatedTime
+
+	log.NamespaceName = ac.NamespaceName
+	log.PodName = ac.PodName
+	log.ContainerID = ac.ContainerID
+	log.ContainerName = ac.ContainerName
+
+	log.Type = "MatchedPolicy"
+	log.PolicyName = AppArmorAttachmentPolicyName
+	log.Severity = "4"
+	log.Tags = "KUBEARMOR,APPARMOR"
+
+	switch ac.State {
+	case AppArmorAttached:
+		log.Message = fmt.Sprintf("AppArmor profile was attached %s after the container started", ac.Attached.Sub(ac.FirstSeen).Round(time.Second))
+	case AppArmorOtherProfile:
+		log.Message = fmt.Sprintf("AppArmor profile is not attached, the container is confined by %s (the pod needs a restart)", ac.OtherProfile)
+	default:
+		log.Message = "AppArmor profile is not attached, the container is unconfined (the pod needs a restart)"
+	}
+
+	log.Source = "kubearmor"
+	log.ProcessName = "kubearmor"
+	log.Operation = "Process"
+	log.Resource = "profile=" + ac.Profile + " state=" + ac.State
+	log.Data = fmt.Sprintf("firstSeen=%s profileGenerated=%s", ac.FirstSeen.UTC().Format(time.RFC3339Nano), ac.ProfileGenerated.UTC().Format(time.RFC3339Nano))
+	if !ac.Attached.IsZero() {
+		log.Data = log.Data + " attached=" + ac.Attached.UTC().Format(time.RFC3339Nano)
+	}
+
+	log.Enforcer = "KubeArmor"
+	log.Action = "Audit"
+	log.Result = "Passed"
+
+	dm.Logger.PushSummaryLog(log)
+}
+
+// WatchAppArmorAttachment Function checks the profiles of the containers periodically
+func (dm *KubeArmorDaemon) WatchAppArmorAttachment() {
+	ticker := time.NewTicker(appArmorAttachmentInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-StopChan:
+			return
+		case now := <-ticker.C:
+			dm.checkAppArmorAttachment(now)
+		}
+	}
+}
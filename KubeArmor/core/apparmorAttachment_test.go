@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+func TestAppArmorAttachment(t *testing.T) {
+	proc := t.TempDir()
+
+	// the attrs of the containers, with the LSM stacking interface or the legacy one
+	attrs := map[string]string{
+		"101/attr/apparmor/current": "kubearmor-default-nginx-nginx (enforce)\n",
+		"102/attr/current":          "cri-containerd.apparmor.d (enforce)\n",
+		"103/attr/current":          "unconfined\n",
+		"106/attr/current":          "kubearmor-default-redis-redis (complain)\x00",
+	}
+	for path, content := range attrs {
+		path = filepath.Join(proc, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("[FAIL] Failed to create the fake proc (%s)", err.Error())
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("[FAIL] Failed to create the fake proc (%s)", err.Error())
+		}
+	}
+
+	kl.SetupProcFs(proc, proc)
+	defer kl.SetupProcFs("/proc", "/proc")
+
+	prevThreshold := cfg.GlobalCfg.AppArmorAttachThreshold
+	defer func() { cfg.GlobalCfg.AppArmorAttachThreshold = prevThreshold }()
+	cfg.GlobalCfg.AppArmorAttachThreshold = time.Minute
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	// subscribe to the alerts
+	alerts := make(chan *pb.Alert, 8)
+	fd.AlertLock = new(sync.RWMutex)
+	fd.AlertStructs = map[string]fd.AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+	defer func() { fd.AlertStructs = map[string]fd.AlertStruct{} }()
+
+	dm := newCrioTestDaemon()
+	dm.Logger.Output = "none"
+	dm.Logger.SeverityRangesLock = new(sync.RWMutex)
+	dm.Logger.SinksLock = new(sync.RWMutex)
+
+	dm.AppArmorAttachment = NewAppArmorAttachment()
+
+	// the profile of the pod is generated before its container is seen, or after
+	dm.AppArmorAttachment.ProfilesGenerated("default", "nginx", map[string]string{"nginx": "kubearmor-default-nginx-nginx"})
+	dm.AppArmorAttachment.ProfilesGenerated("default", "legacy", map[string]string{"legacy": "kubearmor-default-legacy-legacy", "sidecar": "unconfined"})
+
+	for _, container := range []tp.Container{
+		{ContainerID: "attached", NamespaceName: "default", EndPointName: "nginx", ContainerName: "nginx", Pid: 101},
+		{ContainerID: "runtime-default", NamespaceName: "default", EndPointName: "legacy", ContainerName: "legacy", Pid: 102},
+		{ContainerID: "unconfined", NamespaceName: "default", EndPointName: "debug", ContainerName: "debug", Pid: 103},
+		{ContainerID: "gone", NamespaceName: "default", EndPointName: "nginx", ContainerName: "nginx", Pid: 104},
+		{ContainerID: "untracked", NamespaceName: "default", EndPointName: "legacy", ContainerName: "sidecar", Pid: 105},
+		{ContainerID: "late", NamespaceName: "default", EndPointName: "redis", ContainerName: "redis", Pid: 106},
+	} {
+		dm.AppArmorAttachment.ContainerSeen(container)
+	}
+
+	dm.AppArmorAttachment.ProfilesGenerated("default", "debug", map[string]string{"debug": "kubearmor-default-debug-debug"})
+
+	states := func() map[string]string {
+		states := map[string]string{}
+		for id, ac := range dm.AppArmorAttachment.containers {
+			states[id] = ac.State
+		}
+		return states
+	}
+
+	// within the threshold, only the attached container is reported
+	dm.checkAppArmorAttachment(time.Now())
+
+	if got := states(); len(got) != 5 || got["attached"] != AppArmorAttached || got["runtime-default"] != AppArmorOtherProfile || got["unconfined"] != AppArmorUnconfined || got["late"] != AppArmorUnknown {
+		t.Errorf("[FAIL] Unexpected states within the threshold (%v)", got)
+	}
+
+	select {
+	case alert := <-alerts:
+		t.Errorf("[FAIL] Unexpected alert within the threshold (%+v)", alert)
+	default:
+	}
+
+	// beyond the threshold, the late attachment and the missing ones are alerted once
+	dm.AppArmorAttachment.ProfilesGenerated("default", "redis", map[string]string{"redis": "kubearmor-default-redis-redis"})
+
+	for i := 0; i < 2; i++ {
+		dm.checkAppArmorAttachment(time.Now().Add(2 * time.Minute))
+	}
+
+	if got := states(); len(got) != 4 || got["late"] != AppArmorAttached {
+		t.Errorf("[FAIL] Unexpected states beyond the threshold (%v)", got)
+	}
+
+	messages := map[string]string{}
+	for len(alerts) > 0 {
+		alert := <-alerts
+		if alert.PolicyName != AppArmorAttachmentPolicyName {
+			t.Errorf("[FAIL] Unexpected alert (%+v)", alert)
+		}
+		messages[alert.ContainerID] = alert.Message
+	}
+
+	if len(messages) != 3 ||
+		!strings.Contains(messages["runtime-default"], "confined by cri-containerd.apparmor.d") ||
+		!strings.Contains(messages["unconfined"], "unconfined") ||
+		!strings.Contains(messages["late"], "attached 2m0s after") {
+		t.Errorf("[FAIL] Unexpected alerts beyond the threshold (%v)", messages)
+	}
+
+	// the removed containers aren't tracked anymore
+	dm.AppArmorAttachment.ContainerRemoved("attached")
+	if _, ok := states()["attached"]; ok {
+		t.Errorf("[FAIL] Expected the removed container not to be tracked")
+	}
+
+	t.Log("[PASS] Tracked the attachment of the AppArmor profiles")
+}
@@ -0,0 +1,350 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	kspinformer "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/client/informers/externalversions"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ====================== //
+// == Cluster Policies == //
+// ====================== //
+
+// ClusterPolicies Structure keeps the cluster security policies and the namespaces they are expanded into
+type ClusterPolicies struct {
+	// cluster policies (name -> policy)
+	Policies map[string]*ksp.KubeArmorClusterPolicy
+
+	// labels of the namespaces (namespace -> labels)
+	Namespaces map[string]map[string]string
+
+	// namespaces in which each cluster policy is applied (name -> namespaces)
+	Expanded map[string]map[string]bool
+
+	Lock *sync.Mutex
+}
+
+// NewClusterPolicies Function
+func NewClusterPolicies() *ClusterPolicies {
+	cp := &ClusterPolicies{}
+
+	cp.Policies = map[string]*ksp.KubeArmorClusterPolicy{}
+	cp.Namespaces = map[string]map[string]string{}
+	cp.Expanded = map[string]map[string]bool{}
+	cp.Lock = new(sync.Mutex)
+
+	return cp
+}
+
+// matchNamespaceSelector checks if the labels of a namespace match all the labels and expressions of a selector
+// (an empty selector matches all namespaces)
+func matchNamespaceSelector(selector ksp.NamespaceSelectorType, labels map[string]string) bool {
+	for k, v := range selector.MatchLabels {
+		if value, ok := labels[k]; !ok || value != v {
+			return false
+		}
+	}
+
+	for _, expression := range selector.MatchExpressions {
+		if !matchLabelExpression(expression.Key, string(expression.Operator), expression.Values, labels) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ClusterPolicy checks if a security policy is expanded from a cluster policy
+func ClusterPolicy(metadata map[string]string) bool {
+	return metadata["clusterPolicyName"] != ""
+}
+
+// expandClusterPolicy returns the security policy of a cluster policy in a namespace
+func expandClusterPolicy(policy *ksp.KubeArmorClusterPolicy, namespaceName string) ksp.KubeArmorPolicy {
+	expanded := ksp.KubeArmorPolicy{}
+
+	expanded.Name = policy.Name
+	expanded.Namespace = namespaceName
+	expanded.Annotations = policy.Annotations
+	expanded.CreationTimestamp = policy.CreationTimestamp
+	expanded.Spec = *policy.Spec.KubeArmorPolicySpec.DeepCopy()
+
+	return expanded
+}
+
+// namespacedSecurityPolicy checks if a security policy of the namespace has the name of a cluster policy
+func (dm *KubeArmorDaemon) namespacedSecurityPolicy(namespaceName, policyName string) bool {
+	dm.SecurityPoliciesLock.RLock()
+	defer dm.SecurityPoliciesLock.RUnlock()
+
+	for _, policy := range dm.SecurityPolicies {
+		if policy.Metadata["namespaceName"] == namespaceName && policy.Metadata["policyName"] == policyName && !ClusterPolicy(policy.Metadata) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeClusterSecurityPolicy removes the security policy of a cluster policy in a namespace, and returns it
+func (dm *KubeArmorDaemon) removeClusterSecurityPolicy(namespaceName, policyName string) (tp.SecurityPolicy, bool) {
+	dm.SecurityPoliciesLock.Lock()
+	defer dm.SecurityPoliciesLock.Unlock()
+
+	for idx, policy := range dm.SecurityPolicies {
+		if policy.Metadata["namespaceName"] == namespaceName && policy.Metadata["policyName"] == policyName && ClusterPolicy(policy.Metadata) {
+			dm.SecurityPolicies = append(dm.SecurityPolicies[:idx], dm.SecurityPolicies[idx+1:]...)
+			return policy, true
+		}
+	}
+
+	return tp.SecurityPolicy{}, false
+}
+
+// applyClusterPolicy applies a cluster policy in a namespace (the cluster policies should be locked)
+func (dm *KubeArmorDaemon) applyClusterPolicy(policy *ksp.KubeArmorClusterPolicy, namespaceName string) {
+	// a policy of the namespace with the same name takes precedence
+	if dm.namespacedSecurityPolicy(namespaceName, policy.Name) {
+		dm.Logger.Warnf("Skipped a Cluster Security Policy in a namespace with a Security Policy of the same name (%s/%s)", namespaceName, policy.Name)
+		return
+	}
+
+	secPolicy, err := dm.CreateSecurityPolicy(expandClusterPolicy(policy, namespaceName))
+	if err != nil {
+		dm.Logger.Warnf("Error ADD, %s", err)
+		dm.Logger.PushPolicyEvent(KubeArmorClusterPolicyKind, namespaceName, policy.Name, fd.PolicyFailed, err.Error(), nil)
+		return
+	}
+	secPolicy.Metadata["clusterPolicyName"] = policy.Name
+
+	if _, ok := dm.ClusterPolicies.Expanded[policy.Name]; !ok {
+		dm.ClusterPolicies.Expanded[policy.Name] = map[string]bool{}
+	}
+	dm.ClusterPolicies.Expanded[policy.Name][namespaceName] = true
+
+	if dm.upsertSecurityPolicy(secPolicy) {
+		dm.Logger.Printf("Detected a Cluster Security Policy (added/%s/%s)", namespaceName, policy.Name)
+		dm.UpdateSecurityPolicy("ADDED", secPolicy)
+	} else {
+		dm.Logger.Printf("Detected a Cluster Security Policy (modified/%s/%s)", namespaceName, policy.Name)
+		dm.UpdateSecurityPolicy("MODIFIED", secPolicy)
+	}
+}
+
+// withdrawClusterPolicy removes a cluster policy from a namespace (the cluster policies should be locked)
+func (dm *KubeArmorDaemon) withdrawClusterPolicy(policyName, namespaceName string) {
+	delete(dm.ClusterPolicies.Expanded[policyName], namespaceName)
+	if len(dm.ClusterPolicies.Expanded[policyName]) == 0 {
+		delete(dm.ClusterPolicies.Expanded, policyName)
+	}
+
+	secPolicy, ok := dm.removeClusterSecurityPolicy(namespaceName, policyName)
+	if !ok {
+		return
+	}
+
+	dm.Logger.Printf("Detected a Cluster Security Policy (deleted/%s/%s)", namespaceName, policyName)
+	dm.UpdateSecurityPolicy("DELETED", secPolicy)
+}
+
+// sortedNamespaces returns the known namespaces and the namespaces in which a cluster policy is applied
+func (dm *KubeArmorDaemon) sortedNamespaces(policyName string) []string {
+	namespaces := []string{}
+	for namespaceName := range dm.ClusterPolicies.Namespaces {
+		namespaces = append(namespaces, namespaceName)
+	}
+	for namespaceName := range dm.ClusterPolicies.Expanded[policyName] {
+		if _, ok := dm.ClusterPolicies.Namespaces[namespaceName]; !ok {
+			namespaces = append(namespaces, namespaceName)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// syncClusterPolicy applies a cluster policy in the namespaces matching its selector, and removes it from the others
+// (the cluster policies should be locked)
+func (dm *KubeArmorDaemon) syncClusterPolicy(policy *ksp.KubeArmorClusterPolicy, modified bool, namespaces []string) {
+	for _, namespaceName := range namespaces {
+		labels, ok := dm.ClusterPolicies.Namespaces[namespaceName]
+		matched := ok && matchNamespaceSelector(policy.Spec.NamespaceSelector, labels)
+		applied := dm.ClusterPolicies.Expanded[policy.Name][namespaceName]
+
+		if matched && (!applied || modified) {
+			dm.applyClusterPolicy(policy, namespaceName)
+		} else if !matched && applied {
+			dm.withdrawClusterPolicy(policy.Name, namespaceName)
+		}
+	}
+}
+
+// addClusterPolicy Function
+func (dm *KubeArmorDaemon) addClusterPolicy(policy *ksp.KubeArmorClusterPolicy) {
+	dm.ClusterPolicies.Lock.Lock()
+	defer dm.ClusterPolicies.Lock.Unlock()
+
+	_, modified := dm.ClusterPolicies.Policies[policy.Name]
+	dm.ClusterPolicies.Policies[policy.Name] = policy
+
+	dm.syncClusterPolicy(policy, modified, dm.sortedNamespaces(policy.Name))
+}
+
+// deleteClusterPolicy removes a cluster policy from all the namespaces in which it is applied
+func (dm *KubeArmorDaemon) deleteClusterPolicy(policy *ksp.KubeArmorClusterPolicy) {
+	dm.ClusterPolicies.Lock.Lock()
+	defer dm.ClusterPolicies.Lock.Unlock()
+
+	delete(dm.ClusterPolicies.Policies, policy.Name)
+
+	namespaces := []string{}
+	for namespaceName := range dm.ClusterPolicies.Expanded[policy.Name] {
+		namespaces = append(namespaces, namespaceName)
+	}
+	sort.Strings(namespaces)
+
+	for _, namespaceName := range namespaces {
+		dm.withdrawClusterPolicy(policy.Name, namespaceName)
+	}
+}
+
+// updateClusterPolicyNamespace applies (or removes) the cluster policies in a namespace whose labels changed
+func (dm *KubeArmorDaemon) updateClusterPolicyNamespace(action, namespaceName string, labels map[string]string) {
+	dm.ClusterPolicies.Lock.Lock()
+	defer dm.ClusterPolicies.Lock.Unlock()
+
+	if action == "DELETED" {
+		delete(dm.ClusterPolicies.Namespaces, namespaceName)
+	} else {
+		dm.ClusterPolicies.Namespaces[namespaceName] = labels
+	}
+
+	names := []string{}
+	for name := range dm.ClusterPolicies.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		dm.syncClusterPolicy(dm.ClusterPolicies.Policies[name], false, []string{namespaceName})
+	}
+}
+
+// restoreClusterPolicy applies a cluster policy again in a namespace once the policy of the same name is deleted
+func (dm *KubeArmorDaemon) restoreClusterPolicy(namespaceName, policyName string) {
+	dm.ClusterPolicies.Lock.Lock()
+	defer dm.ClusterPolicies.Lock.Unlock()
+
+	policy, ok := dm.ClusterPolicies.Policies[policyName]
+	if !ok {
+		return
+	}
+
+	if labels, ok := dm.ClusterPolicies.Namespaces[namespaceName]; ok && matchNamespaceSelector(policy.Spec.NamespaceSelector, labels) {
+		dm.applyClusterPolicy(policy, namespaceName)
+	}
+}
+
+// kubeArmorClusterPolicyEventHandler returns the handler of the events of the cluster security policies
+func (dm *KubeArmorDaemon) kubeArmorClusterPolicyEventHandler() cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if policy, ok := obj.(*ksp.KubeArmorClusterPolicy); ok {
+				dm.addClusterPolicy(policy)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if policy, ok := newObj.(*ksp.KubeArmorClusterPolicy); ok {
+				// skip the updates of metadata and status only
+				if old, ok := oldObj.(*ksp.KubeArmorClusterPolicy); ok && old.Generation != 0 && old.Generation == policy.Generation {
+					return
+				}
+				dm.addClusterPolicy(policy)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			// the final state of a policy deleted while the watch was down
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if policy, ok := obj.(*ksp.KubeArmorClusterPolicy); ok {
+				dm.deleteClusterPolicy(policy)
+			}
+		},
+	}
+}
+
+// clusterPolicyNamespaceEventHandler returns the handler of the events of the namespaces selected by the cluster policies
+func (dm *KubeArmorDaemon) clusterPolicyNamespaceEventHandler() cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				dm.updateClusterPolicyNamespace("ADDED", ns.Name, ns.Labels)
+			}
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			if ns, ok := newObj.(*corev1.Namespace); ok {
+				dm.updateClusterPolicyNamespace("MODIFIED", ns.Name, ns.Labels)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				dm.updateClusterPolicyNamespace("DELETED", ns.Name, nil)
+			}
+		},
+	}
+}
+
+// WatchClusterPolicies Function
+func (dm *KubeArmorDaemon) WatchClusterPolicies() {
+	for {
+		if !K8s.CheckCustomResourceDefinition("kubearmorclusterpolicies") {
+			time.Sleep(time.Second * 1)
+			continue
+		} else {
+			break
+		}
+	}
+
+	// the host security policies come first
+	dm.waitForHostPolicies()
+
+	// the labels of the namespaces are known before the cluster policies are expanded
+	nsFactory := informers.NewSharedInformerFactory(K8s.K8sClient, 0)
+
+	nsInformer := nsFactory.Core().V1().Namespaces().Informer()
+	if _, err := nsInformer.AddEventHandler(dm.clusterPolicyNamespaceEventHandler()); err != nil {
+		dm.Logger.Err("Couldn't start watching the namespaces of KubeArmor Cluster Security Policies")
+		return
+	}
+
+	go nsFactory.Start(wait.NeverStop)
+	nsFactory.WaitForCacheSync(wait.NeverStop)
+
+	factory := kspinformer.NewSharedInformerFactory(K8s.KSPClient, 0)
+
+	informer := factory.Security().V1().KubeArmorClusterPolicies().Informer()
+	if _, err := informer.AddEventHandler(dm.kubeArmorClusterPolicyEventHandler()); err != nil {
+		dm.Logger.Err("Couldn't start watching KubeArmor Cluster Security Policies")
+		return
+	}
+
+	go factory.Start(wait.NeverStop)
+	factory.WaitForCacheSync(wait.NeverStop)
+
+	dm.Logger.Print("Started watching KubeArmor Cluster Security Policies")
+}
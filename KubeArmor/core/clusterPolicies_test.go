@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"sort"
+	"testing"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestMatchNamespaceSelector(t *testing.T) {
+	labels := map[string]string{"team": "payments", "tier": "prod"}
+
+	selectors := []struct {
+		selector ksp.NamespaceSelectorType
+		matched  bool
+	}{
+		{ksp.NamespaceSelectorType{}, true},
+		{ksp.NamespaceSelectorType{MatchLabels: map[string]string{"team": "payments"}}, true},
+		{ksp.NamespaceSelectorType{MatchLabels: map[string]string{"team": "search"}}, false},
+		{ksp.NamespaceSelectorType{MatchExpressions: []ksp.MatchExpressionType{{Key: "tier", Operator: "In", Values: []string{"prod", "staging"}}}}, true},
+		{ksp.NamespaceSelectorType{MatchExpressions: []ksp.MatchExpressionType{{Key: "tier", Operator: "NotIn", Values: []string{"prod"}}}}, false},
+		{ksp.NamespaceSelectorType{MatchExpressions: []ksp.MatchExpressionType{{Key: "canary", Operator: "NotIn", Values: []string{"true"}}}}, true},
+		{ksp.NamespaceSelectorType{MatchExpressions: []ksp.MatchExpressionType{{Key: "team", Operator: "Exists"}}}, true},
+		{ksp.NamespaceSelectorType{MatchExpressions: []ksp.MatchExpressionType{{Key: "team", Operator: "DoesNotExist"}}}, false},
+		// both the labels and the expressions should match
+		{ksp.NamespaceSelectorType{MatchLabels: map[string]string{"team": "payments"}, MatchExpressions: []ksp.MatchExpressionType{{Key: "tier", Operator: "In", Values: []string{"dev"}}}}, false},
+	}
+
+	for idx, s := range selectors {
+		if matched := matchNamespaceSelector(s.selector, labels); matched != s.matched {
+			t.Errorf("[FAIL] Selector %d: expected %v, got %v", idx, s.matched, matched)
+		}
+	}
+
+	t.Log("[PASS] Matched the namespaces by their labels and expressions")
+}
+
+// getEndPointPolicyNames returns the names of the policies of each endpoint
+func getEndPointPolicyNames(dm *KubeArmorDaemon) map[string][]string {
+	dm.EndPointsLock.RLock()
+	defer dm.EndPointsLock.RUnlock()
+
+	names := map[string][]string{}
+	for _, endPoint := range dm.EndPoints {
+		names[endPoint.EndPointName] = []string{}
+		for _, policy := range endPoint.SecurityPolicies {
+			names[endPoint.EndPointName] = append(names[endPoint.EndPointName], policy.Metadata["namespaceName"]+"/"+policy.Metadata["policyName"])
+		}
+		sort.Strings(names[endPoint.EndPointName])
+	}
+	return names
+}
+
+func TestClusterPolicies(t *testing.T) {
+	prevPolicy := cfg.GlobalCfg.Policy
+	defer func() { cfg.GlobalCfg.Policy = prevPolicy }()
+	cfg.GlobalCfg.Policy = true
+
+	dm := newPolicyOrderDaemon()
+	dm.EndPoints = append(dm.EndPoints, tp.EndPoint{
+		NamespaceName: "api",
+		EndPointName:  "checkout",
+		Identities:    []string{"namespaceName=api"},
+		PolicyEnabled: tp.KubeArmorPolicyEnabled,
+	})
+
+	nsHandler := dm.clusterPolicyNamespaceEventHandler()
+	nsHandler.OnAdd(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: map[string]string{"team": "payments"}}})
+	nsHandler.OnAdd(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "api", Labels: map[string]string{"team": "search"}}})
+
+	policy := &ksp.KubeArmorClusterPolicy{ObjectMeta: metav1.ObjectMeta{Name: "deny-shadow", Generation: 1}}
+	policy.Spec.NamespaceSelector.MatchLabels = map[string]string{"team": "payments"}
+	policy.Spec.Action = "Block"
+	policy.Spec.File.MatchPaths = []ksp.FilePathType{{Path: "/etc/shadow"}}
+
+	handler := dm.kubeArmorClusterPolicyEventHandler()
+	handler.OnAdd(policy)
+
+	// the cluster policy applies to the namespaces matching its selector only
+	if names := getEndPointPolicyNames(dm); len(names["frontend"]) != 1 || names["frontend"][0] != "web/deny-shadow" || len(names["checkout"]) != 0 {
+		t.Fatalf("[FAIL] Expected the cluster policy in the web namespace only (%v)", names)
+	}
+
+	// a namespace gaining the label later starts matching
+	nsHandler.OnUpdate(nil, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "api", Labels: map[string]string{"team": "payments"}}})
+
+	if names := getEndPointPolicyNames(dm); len(names["checkout"]) != 1 || names["checkout"][0] != "api/deny-shadow" {
+		t.Fatalf("[FAIL] Expected the cluster policy in the api namespace (%v)", names)
+	}
+
+	// a policy of the namespace with the same name takes precedence, and the cluster policy applies again once it's deleted
+	namespaced := newOrderedPolicy("uid-1", "10", "/bin/sh")
+	namespaced.Name = "deny-shadow"
+	deliverPolicyEvents(dm.kubeArmorPolicyEventHandler(), []watch.Event{{Type: watch.Added, Object: namespaced}})
+
+	if paths := getAppliedPaths(dm); len(paths) != 1 || paths[0] != "/bin/sh" {
+		t.Errorf("[FAIL] Expected the policy of the namespace to replace the cluster policy (%v)", paths)
+	}
+
+	deliverPolicyEvents(dm.kubeArmorPolicyEventHandler(), []watch.Event{{Type: watch.Deleted, Object: namespaced}})
+
+	if names := getEndPointPolicyNames(dm); len(names["frontend"]) != 1 || getMatchedActions(dm)[0] != "Block" {
+		t.Errorf("[FAIL] Expected the cluster policy to apply again (%v)", names)
+	}
+	if paths := getAppliedPaths(dm); len(paths) != 0 {
+		t.Errorf("[FAIL] Expected the rules of the cluster policy only (%v)", paths)
+	}
+
+	// the cluster policies are not reported as a drift from the listed policies
+	if drift := dm.detectPolicyDrift(map[string]*ksp.KubeArmorPolicy{}); !drift.Empty() {
+		t.Errorf("[FAIL] Expected no drift (%s)", drift.String())
+	}
+
+	// a namespace losing the label stops matching
+	nsHandler.OnUpdate(nil, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: map[string]string{}}})
+
+	if names := getEndPointPolicyNames(dm); len(names["frontend"]) != 0 || len(names["checkout"]) != 1 {
+		t.Fatalf("[FAIL] Expected the cluster policy to be removed from the web namespace (%v)", names)
+	}
+
+	// deleting the cluster policy removes it from all the namespaces
+	nsHandler.OnUpdate(nil, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: map[string]string{"team": "payments"}}})
+	handler.OnDelete(policy)
+
+	if names := getEndPointPolicyNames(dm); len(names["frontend"]) != 0 || len(names["checkout"]) != 0 {
+		t.Errorf("[FAIL] Expected the cluster policy to be removed from all the namespaces (%v)", names)
+	}
+	if len(dm.SecurityPolicies) != 0 || len(dm.ClusterPolicies.Expanded) != 0 {
+		t.Errorf("[FAIL] Expected no security policies left (%v)", dm.SecurityPolicies)
+	}
+
+	t.Log("[PASS] Expanded the cluster policies into the namespaces matching their selectors")
+}
@@ -0,0 +1,408 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	mon "github.com/kubearmor/KubeArmor/KubeArmor/monitor"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ========================= //
+// == Config Change Diffs == //
+// ========================= //
+
+// kinds of the effects of a config change
+const (
+	ConfigEffectPosture        = "posture"
+	ConfigEffectVisibility     = "visibility"
+	ConfigEffectHostVisibility = "hostVisibility"
+	ConfigEffectProbes         = "probes"
+)
+
+// visibility classes of the namespaces and of the host
+var visibilityClasses = []string{"process", "file", "network", "capabilities", "signal"}
+
+// ConfigFieldChange Structure
+type ConfigFieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// ConfigEffect Structure is a derived effect of a config change on an endpoint, a namespace, or the node
+type ConfigEffect struct {
+	Namespace string // empty for the effects on the node
+	EndPoint  string // empty for the effects on the namespace
+
+	Kind   string // posture, visibility, hostVisibility, or probes
+	Target string // operation, visibility class, or event class
+
+	Old string
+	New string
+}
+
+// ConfigDiff Structure
+type ConfigDiff struct {
+	Fields   []ConfigFieldChange
+	Effects  []ConfigEffect
+	Warnings []string
+}
+
+// dynamicConfig Structure holds the fields of the configuration reloaded from the ConfigMap
+type dynamicConfig struct {
+	DefaultFilePosture         string
+	DefaultNetworkPosture      string
+	DefaultCapabilitiesPosture string
+
+	Visibility        string
+	HostVisibility    string
+	DefaultVisibility string
+}
+
+// currentDynamicConfig returns the fields of the configuration reloaded from the ConfigMap
+func currentDynamicConfig() dynamicConfig {
+	return dynamicConfig{
+		DefaultFilePosture:         cfg.GlobalCfg.DefaultFilePosture,
+		DefaultNetworkPosture:      cfg.GlobalCfg.DefaultNetworkPosture,
+		DefaultCapabilitiesPosture: cfg.GlobalCfg.DefaultCapabilitiesPosture,
+
+		Visibility:        cfg.GlobalCfg.Visibility,
+		HostVisibility:    cfg.GlobalCfg.HostVisibility,
+		DefaultVisibility: cfg.GlobalCfg.DefaultVisibility,
+	}
+}
+
+// fields returns the fields by their keys in the ConfigMap, in a stable order
+func (dc dynamicConfig) fields() [][2]string {
+	return [][2]string{
+		{cfg.ConfigDefaultFilePosture, dc.DefaultFilePosture},
+		{cfg.ConfigDefaultNetworkPosture, dc.DefaultNetworkPosture},
+		{cfg.ConfigDefaultCapabilitiesPosture, dc.DefaultCapabilitiesPosture},
+		{cfg.ConfigVisibility, dc.Visibility},
+		{cfg.ConfigHostVisibility, dc.HostVisibility},
+		{cfg.ConfigDefaultVisibility, dc.DefaultVisibility},
+	}
+}
+
+// defaultVisibility returns the visibility of the namespaces without the visibility annotation
+func (dc dynamicConfig) defaultVisibility() string {
+	if dc.DefaultVisibility != "" {
+		return dc.DefaultVisibility
+	}
+	return dc.Visibility
+}
+
+// defaultPosture returns the global default posture
+func (dc dynamicConfig) defaultPosture() tp.DefaultPosture {
+	return tp.DefaultPosture{
+		FileAction:         dc.DefaultFilePosture,
+		NetworkAction:      dc.DefaultNetworkPosture,
+		CapabilitiesAction: dc.DefaultCapabilitiesPosture,
+
+		FileSource:         tp.PostureSourceGlobal,
+		NetworkSource:      tp.PostureSourceGlobal,
+		CapabilitiesSource: tp.PostureSourceGlobal,
+	}
+}
+
+// validateConfigVisibility returns the warnings of the unknown classes of a visibility
+func validateConfigVisibility(key, visibility string) []string {
+	warnings := []string{}
+
+	for _, class := range strings.Split(visibility, ",") {
+		class = strings.TrimSpace(class)
+		if class == "" || class == "none" || kl.ContainsElement(visibilityClasses, class) {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("%s has an unknown visibility class (%s)", key, class))
+	}
+
+	return warnings
+}
+
+// resolveConfigMap returns the configuration reloaded from the data of the ConfigMap, and the warnings of the values
+// which are ignored or replaced
+func resolveConfigMap(data map[string]string) (dynamicConfig, []string) {
+	resolved := dynamicConfig{}
+	warnings := []string{}
+
+	for _, posture := range []struct {
+		key    string
+		action *string
+	}{
+		{cfg.ConfigDefaultFilePosture, &resolved.DefaultFilePosture},
+		{cfg.ConfigDefaultNetworkPosture, &resolved.DefaultNetworkPosture},
+		{cfg.ConfigDefaultCapabilitiesPosture, &resolved.DefaultCapabilitiesPosture},
+	} {
+		value, ok := data[posture.key]
+
+		*posture.action = validateGlobalDefaultPosture(value)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("%s is unset, %s is used", posture.key, *posture.action))
+		} else if !strings.EqualFold(value, *posture.action) {
+			warnings = append(warnings, fmt.Sprintf("%s is invalid (%q), %s is used", posture.key, value, *posture.action))
+		}
+	}
+
+	resolved.Visibility = data[cfg.ConfigVisibility]
+	resolved.HostVisibility = data[cfg.ConfigHostVisibility]
+
+	// the default visibility is kept unless set
+	resolved.DefaultVisibility = cfg.GlobalCfg.DefaultVisibility
+	if defaultVisibility, ok := data[cfg.ConfigDefaultVisibility]; ok {
+		resolved.DefaultVisibility = defaultVisibility
+	}
+
+	warnings = append(warnings, validateConfigVisibility(cfg.ConfigVisibility, resolved.Visibility)...)
+	warnings = append(warnings, validateConfigVisibility(cfg.ConfigHostVisibility, resolved.HostVisibility)...)
+	warnings = append(warnings, validateConfigVisibility(cfg.ConfigDefaultVisibility, resolved.DefaultVisibility)...)
+
+	known := map[string]bool{}
+	for _, field := range resolved.fields() {
+		known[field[0]] = true
+	}
+
+	keys := []string{}
+	for key := range data {
+		if !known[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		warnings = append(warnings, fmt.Sprintf("%s isn't reloaded, it's ignored", key))
+	}
+
+	return resolved, warnings
+}
+
+// getVisibilityClass returns whether a class is visible
+func getVisibilityClass(visibility tp.Visibility, class string) bool {
+	switch class {
+	case "process":
+		return visibility.Process
+	case "file":
+		return visibility.File
+	case "network":
+		return visibility.Network
+	case "capabilities":
+		return visibility.Capabilities
+	case "signal":
+		return visibility.Signal
+	default:
+		return false
+	}
+}
+
+// diffVisibility returns the effects of the classes whose visibility flips
+func diffVisibility(namespace, kind string, old, new tp.Visibility) []ConfigEffect {
+	effects := []ConfigEffect{}
+
+	for _, class := range visibilityClasses {
+		if before, after := getVisibilityClass(old, class), getVisibilityClass(new, class); before != after {
+			effects = append(effects, ConfigEffect{Namespace: namespace, Kind: kind, Target: class, Old: strconv.FormatBool(before), New: strconv.FormatBool(after)})
+		}
+	}
+
+	return effects
+}
+
+// diffPosture returns the effects of the operations whose default posture changes for an endpoint
+func diffPosture(endPoint tp.EndPoint, new tp.DefaultPosture) []ConfigEffect {
+	effects := []ConfigEffect{}
+
+	for _, op := range []string{"File", "Network", "Capabilities"} {
+		before, _ := getOperationPosture(endPoint.DefaultPosture, op)
+		after, _ := getOperationPosture(new, op)
+
+		if before != after {
+			effects = append(effects, ConfigEffect{Namespace: endPoint.NamespaceName, EndPoint: endPoint.EndPointName, Kind: ConfigEffectPosture, Target: strings.ToLower(op), Old: before, New: after})
+		}
+	}
+
+	return effects
+}
+
+// getNamespaceDefaultPosture returns the default posture of a namespace, from its annotations and the global default
+func getNamespaceDefaultPosture(ns *corev1.Namespace, global tp.DefaultPosture) (tp.DefaultPosture, bool) {
+	posture := global
+
+	annotated := 0
+	for _, annotation := range []struct {
+		key    string
+		action *string
+		source *string
+	}{
+		{filePostureKey, &posture.FileAction, &posture.FileSource},
+		{networkPostureKey, &posture.NetworkAction, &posture.NetworkSource},
+		{capabilitiesPostureKey, &posture.CapabilitiesAction, &posture.CapabilitiesSource},
+	} {
+		if action := getPostureAnnotation(ns.Annotations, annotation.key); action != "" {
+			*annotation.action, *annotation.source = action, getNamespacePostureSource(ns.Name, true)
+			annotated++
+		}
+	}
+
+	// whether the namespace is fully annotated
+	return posture, annotated == 3
+}
+
+// diffConfig returns the changes of the fields of the configuration and their effects on the endpoints, the
+// namespaces and the probes of this node, without applying anything
+func (dm *KubeArmorDaemon) diffConfig(candidate dynamicConfig, namespaces []corev1.Namespace) ConfigDiff {
+	diff := ConfigDiff{Fields: []ConfigFieldChange{}, Effects: []ConfigEffect{}, Warnings: []string{}}
+
+	current := currentDynamicConfig()
+
+	old, new := current.fields(), candidate.fields()
+	for idx := range old {
+		if old[idx][1] != new[idx][1] {
+			diff.Fields = append(diff.Fields, ConfigFieldChange{Field: old[idx][0], Old: old[idx][1], New: new[idx][1]})
+		}
+	}
+
+	// the default postures of the endpoints
+	dm.EndPointsLock.RLock()
+	for idx := range namespaces {
+		ns := &namespaces[idx]
+
+		posture, fullyAnnotated := getNamespaceDefaultPosture(ns, candidate.defaultPosture())
+		if fullyAnnotated {
+			continue
+		}
+
+		for _, endPoint := range dm.EndPoints {
+			if endPoint.NamespaceName == ns.Name {
+				diff.Effects = append(diff.Effects, diffPosture(endPoint, applyPostureOverride(posture, endPoint.PostureOverride))...)
+			}
+		}
+	}
+	dm.EndPointsLock.RUnlock()
+
+	// the visibility of the namespaces without the visibility annotation
+	visibilities := map[string]tp.Visibility{}
+
+	if dm.SystemMonitor != nil {
+		visibility := dm.parseVisibility(candidate.defaultVisibility())
+		previous := dm.parseVisibility(current.defaultVisibility())
+
+		dm.SystemMonitor.BpfMapLock.RLock()
+		for idx := range namespaces {
+			ns := &namespaces[idx]
+
+			if _, annotated := getNamespaceVisibility(ns); annotated || kl.ContainsElement(dm.SystemMonitor.UntrackedNamespaces, ns.Name) {
+				continue
+			}
+
+			before := previous
+			if val, ok := dm.SystemMonitor.NamespacePidsMap[ns.Name]; ok {
+				before = tp.Visibility{File: val.File, Process: val.Process, Network: val.Network, Capabilities: val.Capability, Signal: val.Signal}
+			}
+
+			visibilities[ns.Name] = visibility
+			diff.Effects = append(diff.Effects, diffVisibility(ns.Name, ConfigEffectVisibility, before, visibility)...)
+		}
+		dm.SystemMonitor.BpfMapLock.RUnlock()
+	}
+
+	// the visibility of the host
+	if cfg.GlobalCfg.HostPolicy {
+		diff.Effects = append(diff.Effects, diffVisibility("", ConfigEffectHostVisibility, dm.parseVisibility(current.HostVisibility), dm.parseVisibility(candidate.HostVisibility))...)
+	}
+
+	// the probes of the event classes which are attached or detached on demand
+	if dm.SystemMonitor != nil && cfg.GlobalCfg.DetachIdleProbes {
+		demand := dm.SystemMonitor.GetVisibilityDemand(visibilities, candidate.HostVisibility)
+		for class, demanded := range dm.getPolicyEventClasses() {
+			demand[class] = demand[class] || demanded
+		}
+
+		states := dm.SystemMonitor.GetEventClasses()
+		for _, class := range mon.EventClasses {
+			state, ok := states[class]
+			if !ok {
+				continue
+			}
+
+			if state.Attached && !demand[class] {
+				diff.Effects = append(diff.Effects, ConfigEffect{Kind: ConfigEffectProbes, Target: class, Old: "attached", New: "detached"})
+			} else if !state.Attached && demand[class] {
+				diff.Effects = append(diff.Effects, ConfigEffect{Kind: ConfigEffectProbes, Target: class, Old: "detached", New: "attached"})
+			}
+		}
+	}
+
+	sort.SliceStable(diff.Effects, func(i, j int) bool {
+		a, b := diff.Effects[i], diff.Effects[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.EndPoint < b.EndPoint
+	})
+
+	return diff
+}
+
+// listNamespaces returns the namespaces of the cluster (none if KubeArmor isn't running in Kubernetes)
+func listNamespaces() ([]corev1.Namespace, error) {
+	if K8s.K8sClient == nil {
+		return nil, nil
+	}
+
+	nsList, err := K8s.K8sClient.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return nsList.Items, nil
+}
+
+// PreviewConfigChange returns the changes a candidate ConfigMap would make once reloaded, without applying anything
+func (dm *KubeArmorDaemon) PreviewConfigChange(data map[string]string) (ConfigDiff, error) {
+	namespaces, err := listNamespaces()
+	if err != nil {
+		return ConfigDiff{}, fmt.Errorf("failed to list the namespaces (%s)", err.Error())
+	}
+
+	candidate, warnings := resolveConfigMap(data)
+
+	diff := dm.diffConfig(candidate, namespaces)
+	diff.Warnings = append(diff.Warnings, warnings...)
+
+	return diff, nil
+}
+
+// logConfigDiff logs the changes made by a reload of the ConfigMap
+func (dm *KubeArmorDaemon) logConfigDiff(diff ConfigDiff) {
+	if len(diff.Fields) == 0 && len(diff.Effects) == 0 {
+		dm.Logger.Print("Reloaded the ConfigMap, nothing changed")
+		return
+	}
+
+	for _, field := range diff.Fields {
+		dm.Logger.Printf("Reloaded %s from the ConfigMap (%q -> %q)", field.Field, field.Old, field.New)
+	}
+
+	for _, effect := range diff.Effects {
+		scope := "the node"
+		if effect.EndPoint != "" {
+			scope = effect.Namespace + "/" + effect.EndPoint
+		} else if effect.Namespace != "" {
+			scope = "namespace " + effect.Namespace
+		}
+
+		dm.Logger.Printf("Changed the %s of %s for %s (%s -> %s)", effect.Kind, effect.Target, scope, effect.Old, effect.New)
+	}
+}
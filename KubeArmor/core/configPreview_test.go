@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	mon "github.com/kubearmor/KubeArmor/KubeArmor/monitor"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPreviewConfigChange(t *testing.T) {
+	prevCfg := cfg.GlobalCfg
+	defer func() { cfg.GlobalCfg = prevCfg }()
+
+	cfg.GlobalCfg.DefaultFilePosture = "audit"
+	cfg.GlobalCfg.DefaultNetworkPosture = "audit"
+	cfg.GlobalCfg.DefaultCapabilitiesPosture = "audit"
+	cfg.GlobalCfg.Visibility = "process,file"
+	cfg.GlobalCfg.DefaultVisibility = ""
+	cfg.GlobalCfg.HostVisibility = "process"
+	cfg.GlobalCfg.HostPolicy = true
+	cfg.GlobalCfg.DetachIdleProbes = true
+
+	global := getGlobalDefaultPosture()
+	annotated := global
+	annotated.FileAction, annotated.FileSource = "block", getNamespacePostureSource("payments", true)
+	overridden := global
+	overridden.FileAction, overridden.FileSource = "block", tp.PostureSourceEndPoint
+
+	dm := NewKubeArmorDaemon()
+	dm.EndPoints = []tp.EndPoint{
+		{NamespaceName: "web", EndPointName: "frontend", DefaultPosture: global},
+		{NamespaceName: "web", EndPointName: "api", DefaultPosture: overridden, PostureOverride: tp.DefaultPosture{FileAction: "block", FileSource: tp.PostureSourceEndPoint}},
+		{NamespaceName: "payments", EndPointName: "checkout", DefaultPosture: annotated},
+	}
+
+	// the namespaces with containers on this node
+	dm.SystemMonitor = &mon.SystemMonitor{BpfMapLock: new(sync.RWMutex), ProbesLock: new(sync.Mutex)}
+	dm.SystemMonitor.NamespacePidsMap = map[string]mon.NsVisibility{
+		"web":      {NsKeys: []mon.NsKey{{PidNS: 1, MntNS: 1}}, Process: true, File: true},
+		"payments": {NsKeys: []mon.NsKey{{PidNS: 2, MntNS: 2}}, Process: true, File: true},
+		"audit":    {NsKeys: []mon.NsKey{{PidNS: 3, MntNS: 3}}, Process: true},
+	}
+	dm.SystemMonitor.EventClasses = map[string]*mon.EventClassState{
+		mon.EventClassFile:    {Attached: true},
+		mon.EventClassNetwork: {},
+	}
+
+	namespaces := []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "payments", Annotations: map[string]string{filePostureKey: "block"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "audit", Annotations: map[string]string{visibilityKey: "process"}}},
+	}
+
+	candidate, warnings := resolveConfigMap(map[string]string{
+		cfg.ConfigDefaultFilePosture:         "block",
+		cfg.ConfigDefaultNetworkPosture:      "Audit",
+		cfg.ConfigDefaultCapabilitiesPosture: "allow",
+		cfg.ConfigVisibility:                 "process,network",
+		cfg.ConfigHostVisibility:             "process",
+		"cluster":                            "default",
+	})
+
+	if len(warnings) != 2 || !strings.Contains(warnings[0], cfg.ConfigDefaultCapabilitiesPosture) || !strings.Contains(warnings[1], "cluster") {
+		t.Errorf("[FAIL] Unexpected warnings (%v)", warnings)
+	}
+
+	diff := dm.diffConfig(candidate, namespaces)
+
+	fields := []string{}
+	for _, field := range diff.Fields {
+		fields = append(fields, fmt.Sprintf("%s: %s -> %s", field.Field, field.Old, field.New))
+	}
+
+	expectedFields := []string{
+		"defaultFilePosture: audit -> block",
+		"visibility: process,file -> process,network",
+	}
+	if strings.Join(fields, "\n") != strings.Join(expectedFields, "\n") {
+		t.Errorf("[FAIL] Unexpected changes of the fields\n%s", strings.Join(fields, "\n"))
+	}
+
+	effects := []string{}
+	for _, effect := range diff.Effects {
+		effects = append(effects, fmt.Sprintf("%s/%s %s:%s %s -> %s", effect.Namespace, effect.EndPoint, effect.Kind, effect.Target, effect.Old, effect.New))
+	}
+
+	// the annotated postures and visibility, and the posture overrides of the pods, are kept
+	expectedEffects := []string{
+		"/ probes:file attached -> detached",
+		"/ probes:network detached -> attached",
+		"payments/ visibility:file true -> false",
+		"payments/ visibility:network false -> true",
+		"web/ visibility:file true -> false",
+		"web/ visibility:network false -> true",
+		"web/frontend posture:file audit -> block",
+	}
+	if strings.Join(effects, "\n") != strings.Join(expectedEffects, "\n") {
+		t.Errorf("[FAIL] Unexpected effects\n%s", strings.Join(effects, "\n"))
+	}
+
+	// nothing is applied
+	if cfg.GlobalCfg.DefaultFilePosture != "audit" || cfg.GlobalCfg.Visibility != "process,file" || dm.EndPoints[0].DefaultPosture != global {
+		t.Errorf("[FAIL] Expected the preview not to change the configuration")
+	}
+	if !dm.SystemMonitor.NamespacePidsMap["web"].File || dm.SystemMonitor.NamespacePidsMap["web"].Network {
+		t.Errorf("[FAIL] Expected the preview not to change the visibility")
+	}
+
+	// the same config doesn't change anything
+	if same, _ := resolveConfigMap(map[string]string{
+		cfg.ConfigDefaultFilePosture:         "audit",
+		cfg.ConfigDefaultNetworkPosture:      "audit",
+		cfg.ConfigDefaultCapabilitiesPosture: "audit",
+		cfg.ConfigVisibility:                 "process,file",
+		cfg.ConfigHostVisibility:             "process",
+	}); len(dm.diffConfig(same, namespaces).Fields) != 0 {
+		t.Errorf("[FAIL] Expected no changes for the same config")
+	}
+
+	// served by the admin service
+	preview, err := (&Admin{Preview: dm.PreviewConfigChange}).PreviewConfigChange(context.Background(), &pb.ConfigPreviewRequest{
+		Data: map[string]string{cfg.ConfigDefaultFilePosture: "block"},
+	})
+	if err != nil || len(preview.Fields) == 0 || preview.Fields[0].Field != cfg.ConfigDefaultFilePosture || preview.Fields[0].New != "block" {
+		t.Errorf("[FAIL] Unexpected preview of the admin service (%v, %v)", preview, err)
+	}
+
+	t.Log("[PASS] Previewed the effects of a config change")
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ===================== //
+// == Container Audit == //
+// ===================== //
+
+// the interval of auditing the containers against the listing of the runtime
+var containerAuditInterval = 1 * time.Minute
+
+// destroyContainer removes a container deleted from the runtime, and returns why it isn't removed, in which case the
+// destroy is retried by the next cycle of the monitor (overridden by the tests to inject the failures)
+var destroyContainer = func(dm *KubeArmorDaemon, containerID string) error {
+	if container, ok := dm.removeContainer(containerID); ok {
+		dm.Logger.Printf("Detected a container (removed/%.12s/pidns=%d/mntns=%d)", containerID, container.PidNS, container.MntNS)
+	}
+
+	return nil
+}
+
+// removeContainer Function removes a container from its endpoint and the namespace maps, and returns false if the
+// container isn't known
+func (dm *KubeArmorDaemon) removeContainer(containerID string) (tp.Container, bool) {
+	dm.ContainersLock.Lock()
+	container, ok := dm.Containers[containerID]
+	if !ok {
+		dm.ContainersLock.Unlock()
+		return tp.Container{}, false
+	}
+	if !dm.K8sEnabled {
+		dm.EndPointsLock.Lock()
+		dm.MatchandRemoveContainerFromEndpoint(containerID)
+		dm.EndPointsLock.Unlock()
+	}
+	delete(dm.Containers, containerID)
+	profileInUse := dm.containerProfileInUse(container)
+	dm.ContainersLock.Unlock()
+
+	dm.AppArmorAttachment.ContainerRemoved(containerID)
+
+	// the events still in flight are enriched from the destroyed container
+	dm.Logger.AddDestroyedContainer(container)
+
+	dm.EndPointsLock.Lock()
+	dm.detachContainerFromEndPoint(container, profileInUse)
+	dm.EndPointsLock.Unlock()
+
+	if dm.SystemMonitor != nil && cfg.GlobalCfg.Policy {
+		// update NsMap
+		dm.SystemMonitor.DeleteContainerIDFromNsMap(containerID, container.NamespaceName, container.PidNS, container.MntNS)
+		dm.RuntimeEnforcer.UnregisterContainer(containerID)
+	}
+
+	return container, true
+}
+
+// auditContainers Function compares the containers added from the runtime with the containers it lists (and the ones
+// the monitor is still tracking), removes the ones left behind by the lost destroy events, and returns how many
+// are repaired
+func (dm *KubeArmorDaemon) auditContainers(listed map[string]struct{}) int {
+	leaked := []string{}
+
+	dm.ContainersLock.RLock()
+	for containerID, container := range dm.Containers {
+		// the containers created by the K8s watcher without the namespaces aren't from the runtime
+		if container.PidNS == 0 && container.MntNS == 0 {
+			continue
+		}
+
+		if _, ok := listed[containerID]; !ok {
+			leaked = append(leaked, containerID)
+		}
+	}
+	dm.ContainersLock.RUnlock()
+
+	sort.Strings(leaked)
+
+	repaired := 0
+	for _, containerID := range leaked {
+		if err := destroyContainer(dm, containerID); err != nil {
+			dm.Logger.Warnf("Failed to remove a leaked container (%.12s, %s)", containerID, err.Error())
+			continue
+		}
+		repaired++
+	}
+
+	if repaired > 0 {
+		total := atomic.AddUint64(&dm.ContainerLeaks, uint64(repaired))
+		dm.Logger.Warnf("Repaired %d leaked containers (%d in total)", repaired, total)
+	}
+
+	return repaired
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	"github.com/kubearmor/KubeArmor/KubeArmor/testutil"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCrioContainerChurn(t *testing.T) {
+	for _, withEvents := range []bool{false, true} {
+		name := "Listing"
+		if withEvents {
+			name = "EventStream"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			testCrioContainerChurn(t, withEvents)
+		})
+	}
+}
+
+// testCrioContainerChurn starts and deletes the containers faster than they are listed, with the transient failures
+// of adding and removing them, and checks that none is leaked
+func testCrioContainerChurn(t *testing.T, withEvents bool) {
+	prevWindow, prevResync, prevAudit, prevDestroy := cfg.GlobalCfg.ContainerRetryWindow, crioResyncInterval, containerAuditInterval, destroyContainer
+	defer func() {
+		cfg.GlobalCfg.ContainerRetryWindow, crioResyncInterval, containerAuditInterval, destroyContainer = prevWindow, prevResync, prevAudit, prevDestroy
+	}()
+	cfg.GlobalCfg.ContainerRetryWindow = 5 * time.Second
+	crioResyncInterval = 100 * time.Millisecond
+	containerAuditInterval = 200 * time.Millisecond
+
+	// the first removal of every container fails
+	failed := map[string]bool{}
+	failedLock := new(sync.Mutex)
+	destroyContainer = func(dm *KubeArmorDaemon, containerID string) error {
+		failedLock.Lock()
+		defer failedLock.Unlock()
+
+		if !failed[containerID] {
+			failed[containerID] = true
+			return errors.New("transient failure")
+		}
+
+		return prevDestroy(dm, containerID)
+	}
+
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if withEvents {
+		fake.EnableEvents()
+	}
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = false
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	dm := newCrioTestDaemon()
+
+	countContainers := func() int {
+		dm.ContainersLock.RLock()
+		defer dm.ContainersLock.RUnlock()
+		return len(dm.Containers)
+	}
+
+	StopChan = make(chan struct{})
+	go dm.MonitorCrioEvents()
+
+	for i := 0; i < 60; i++ {
+		// the status of the containers can't be read from time to time
+		if i%4 == 0 {
+			fake.SetFault("ContainerStatus", testutil.Fault{Err: status.Error(codes.Unavailable, "not ready")})
+		} else if i%4 == 2 {
+			fake.ClearFault("ContainerStatus")
+		}
+
+		containerID := fmt.Sprintf("churn-%d", i)
+		fake.AddContainer(testutil.FakeContainer{
+			ID:        containerID,
+			Name:      containerID,
+			Namespace: "default",
+			PodName:   containerID + "-pod",
+			Pid:       os.Getpid(),
+		})
+
+		// the containers live for a few listings at most
+		if i >= 2 {
+			fake.DeleteContainer(fmt.Sprintf("churn-%d", i-2))
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	fake.ClearFault("ContainerStatus")
+
+	waitFor(t, "the running containers to be added", func() bool {
+		dm.ContainersLock.RLock()
+		defer dm.ContainersLock.RUnlock()
+		return len(dm.Containers) == 2 && dm.Containers["churn-58"].MntNS != 0 && dm.Containers["churn-59"].MntNS != 0
+	})
+
+	fake.DeleteContainer("churn-58")
+	fake.DeleteContainer("churn-59")
+
+	waitFor(t, "the deleted containers to be removed", func() bool {
+		return countContainers() == 0
+	})
+
+	// let an audit run over the settled containers
+	time.Sleep(2 * containerAuditInterval)
+
+	if leaks := dm.GetContainerLeaks(); leaks != 0 {
+		t.Errorf("[FAIL] Expected no leaked containers after the churn (%d)", leaks)
+	}
+
+	// a container whose destroy is lost (e.g., removed behind the back of the monitor) is repaired by the audit
+	dm.ContainersLock.Lock()
+	dm.Containers["lost"] = tp.Container{ContainerID: "lost", NamespaceName: "default", PidNS: 1, MntNS: 1}
+	dm.ContainersLock.Unlock()
+
+	waitFor(t, "the leaked container to be repaired", func() bool {
+		return countContainers() == 0
+	})
+
+	if leaks := dm.GetContainerLeaks(); leaks != 1 {
+		t.Errorf("[FAIL] Expected the leaked container to be counted (%d)", leaks)
+	}
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	t.Log("[PASS] Leaked no containers under churn")
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"sort"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// =========================== //
+// == Container Enforcement == //
+// =========================== //
+
+// errPolicyNotApplied is the reason of the policies which the enforcer hasn't loaded yet
+const errPolicyNotApplied = "not applied by the enforcer yet"
+
+// policyActions returns the actions of the rules of a policy
+func policyActions(secPolicy tp.SecurityPolicy) []string {
+	actions := []string{}
+
+	for _, rule := range fd.MergeSecurityPolicies([]tp.SecurityPolicy{secPolicy}).Rules {
+		if rule.Action != "" && !kl.ContainsElement(actions, rule.Action) {
+			actions = append(actions, rule.Action)
+		}
+	}
+
+	// policies without matched rules (e.g., raw AppArmor rules)
+	if len(actions) == 0 && secPolicy.Spec.Action != "" {
+		actions = append(actions, secPolicy.Spec.Action)
+	}
+
+	sort.Strings(actions)
+
+	return actions
+}
+
+// getPolicyEnforcement returns the states of the policies of an endpoint, based on the last update of its rules
+func getPolicyEnforcement(endPoint tp.EndPoint, applied tp.AppliedEndPoint, ok bool) []tp.PolicyEnforcement {
+	policies := []tp.PolicyEnforcement{}
+
+	for _, secPolicy := range endPoint.SecurityPolicies {
+		policy := tp.PolicyEnforcement{
+			PolicyName: secPolicy.Metadata["policyName"],
+			Actions:    policyActions(secPolicy),
+		}
+
+		if !ok || !kl.ContainsElement(applied.Policies, policy.PolicyName) {
+			// the policy is added after the last update, or the policies of the endpoint are disabled
+			policy.Error = errPolicyNotApplied
+		} else if applied.Error != "" {
+			policy.Error = applied.Error
+		} else {
+			policy.Loaded = true
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies
+}
+
+// GetContainerEnforcement returns the endpoint of each container, its AppArmor profile, and whether each of its
+// policies is loaded by the enforcer (or why it isn't)
+func (dm *KubeArmorDaemon) GetContainerEnforcement() []tp.ContainerEnforcement {
+	containers := []tp.ContainerEnforcement{}
+
+	dm.ContainersLock.RLock()
+	dm.EndPointsLock.RLock()
+
+	for _, container := range dm.Containers {
+		state := tp.ContainerEnforcement{
+			ContainerID:     container.ContainerID,
+			ContainerName:   container.ContainerName,
+			NamespaceName:   container.NamespaceName,
+			EndPointName:    container.EndPointName,
+			AppArmorProfile: container.AppArmorProfile,
+			PolicyEnabled:   container.PolicyEnabled == tp.KubeArmorPolicyEnabled,
+			Policies:        []tp.PolicyEnforcement{},
+		}
+
+		if len(container.RiskyMounts) > 0 {
+			state.RiskyMounts = formatMountFindings(container.RiskyMounts)
+		}
+
+		for _, endPoint := range dm.EndPoints {
+			if !kl.ContainsElement(endPoint.Containers, container.ContainerID) {
+				continue
+			}
+
+			state.NamespaceName = endPoint.NamespaceName
+			state.EndPointName = endPoint.EndPointName
+			state.PolicyEnabled = endPoint.PolicyEnabled == tp.KubeArmorPolicyEnabled
+
+			applied, ok := dm.RuntimeEnforcer.GetAppliedEndPoint(endPoint)
+			if ok {
+				state.Enforcer = applied.Enforcer
+			}
+
+			state.Policies = getPolicyEnforcement(endPoint, applied, ok)
+			break
+		}
+
+		containers = append(containers, state)
+	}
+
+	dm.EndPointsLock.RUnlock()
+	dm.ContainersLock.RUnlock()
+
+	sort.Slice(containers, func(i, j int) bool {
+		if containers[i].NamespaceName != containers[j].NamespaceName {
+			return containers[i].NamespaceName < containers[j].NamespaceName
+		}
+		if containers[i].EndPointName != containers[j].EndPointName {
+			return containers[i].EndPointName < containers[j].EndPointName
+		}
+		return containers[i].ContainerID < containers[j].ContainerID
+	})
+
+	return containers
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetPolicyEnforcement(t *testing.T) {
+	blockSh := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "block-sh"}}
+	blockSh.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Block"}}
+	blockSh.Spec.File.MatchPaths = []tp.FilePathType{{Path: "/etc/passwd", Action: "Audit"}}
+
+	rawAppArmor := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "raw-apparmor"}}
+	rawAppArmor.Spec.AppArmor = "deny /tmp/** w,"
+	rawAppArmor.Spec.Action = "Block"
+
+	added := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "added"}}
+
+	endPoint := tp.EndPoint{NamespaceName: "web", EndPointName: "frontend", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{blockSh, rawAppArmor, added}
+
+	applied := tp.AppliedEndPoint{Enforcer: "AppArmor", Policies: []string{"block-sh", "raw-apparmor"}, Updated: time.Now()}
+
+	policies := getPolicyEnforcement(endPoint, applied, true)
+	if len(policies) != 3 {
+		t.Fatalf("[FAIL] Expected the states of 3 policies (%+v)", policies)
+	}
+
+	if !policies[0].Loaded || len(policies[0].Actions) != 2 || policies[0].Actions[0] != "Audit" || policies[0].Actions[1] != "Block" {
+		t.Errorf("[FAIL] Expected a loaded policy with the actions of its rules (%+v)", policies[0])
+	}
+	if !policies[1].Loaded || len(policies[1].Actions) != 1 || policies[1].Actions[0] != "Block" {
+		t.Errorf("[FAIL] Expected a loaded policy with the action of its spec (%+v)", policies[1])
+	}
+	if policies[2].Loaded || policies[2].Error != errPolicyNotApplied {
+		t.Errorf("[FAIL] Expected the policy added after the update not to be loaded (%+v)", policies[2])
+	}
+
+	// the update failed
+	applied.Error = "apparmor_parser: Unable to replace profile"
+
+	policies = getPolicyEnforcement(endPoint, applied, true)
+	if policies[0].Loaded || policies[0].Error != applied.Error {
+		t.Errorf("[FAIL] Expected the error of the enforcer (%+v)", policies[0])
+	}
+
+	// nothing applied yet
+	policies = getPolicyEnforcement(endPoint, tp.AppliedEndPoint{}, false)
+	if policies[0].Loaded || policies[0].Error != errPolicyNotApplied {
+		t.Errorf("[FAIL] Expected the policy not to be applied yet (%+v)", policies[0])
+	}
+
+	t.Log("[PASS] Got the states of the policies of an endpoint")
+}
+
+func TestGetContainerEnforcement(t *testing.T) {
+	dm := NewKubeArmorDaemon()
+
+	probe := &Probe{}
+	if _, err := probe.GetContainerEnforcement(context.Background(), nil); status.Code(err) != codes.Unavailable {
+		t.Errorf("[FAIL] Expected the enforcement not to be served (%v)", err)
+	}
+
+	blockSh := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "block-sh"}}
+	blockSh.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Block"}}
+
+	dm.Containers["nginx"] = tp.Container{ContainerID: "nginx", ContainerName: "nginx", NamespaceName: "web", EndPointName: "frontend", AppArmorProfile: "kubearmor-web-frontend-nginx",
+		RiskyMounts: []tp.MountFinding{{Source: "/var/run/docker.sock", Destination: "/var/run/docker.sock", Reason: "container runtime socket"}}}
+	dm.Containers["redis"] = tp.Container{ContainerID: "redis", ContainerName: "redis", NamespaceName: "Unknown", EndPointName: "Unknown"}
+
+	dm.EndPoints = []tp.EndPoint{{
+		NamespaceName:    "web",
+		EndPointName:     "frontend",
+		ContainerName:    "nginx",
+		Containers:       []string{"nginx"},
+		PolicyEnabled:    tp.KubeArmorPolicyEnabled,
+		SecurityPolicies: []tp.SecurityPolicy{blockSh},
+	}}
+
+	probe.GetEnforcement = dm.GetContainerEnforcement
+
+	res, err := probe.GetContainerEnforcement(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to get the enforcement (%s)", err.Error())
+	}
+
+	if len(res.Containers) != 2 {
+		t.Fatalf("[FAIL] Expected 2 containers (%+v)", res.Containers)
+	}
+
+	// sorted by namespace
+	unknown, nginx := res.Containers[0], res.Containers[1]
+
+	if unknown.ContainerID != "redis" || unknown.PolicyEnabled || len(unknown.Policies) != 0 {
+		t.Errorf("[FAIL] Expected the container without endpoint to have no policies (%+v)", unknown)
+	}
+
+	if nginx.Endpoint != "frontend" || nginx.ApparmorProfile != "kubearmor-web-frontend-nginx" || !nginx.PolicyEnabled {
+		t.Errorf("[FAIL] Expected the endpoint of the container (%+v)", nginx)
+	}
+
+	// without an enforcer, the policies are never loaded
+	if len(nginx.Policies) != 1 || nginx.Policies[0].PolicyName != "block-sh" || nginx.Policies[0].Loaded || nginx.Policies[0].Error != errPolicyNotApplied {
+		t.Errorf("[FAIL] Expected the policy not to be loaded (%+v)", nginx.Policies)
+	}
+
+	if nginx.Enforcer != "" {
+		t.Errorf("[FAIL] Expected no enforcer (%s)", nginx.Enforcer)
+	}
+
+	// the risky mounts are served in every mode
+	if len(nginx.RiskyMounts) != 1 || nginx.RiskyMounts[0] != "/var/run/docker.sock:/var/run/docker.sock (container runtime socket)" || len(unknown.RiskyMounts) != 0 {
+		t.Errorf("[FAIL] Expected the risky mounts of the container (%v)", nginx.RiskyMounts)
+	}
+
+	t.Log("[PASS] Got the enforcement of the containers")
+}
@@ -0,0 +1,328 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ===================== //
+// == Container State == //
+// ===================== //
+
+// interval of the checks of the paused containers
+var containerStateInterval = 5 * time.Second
+
+// the cgroup filesystem, where the freezer keeps the state of the containers paused by their runtime
+var cgroupFsPath = "/sys/fs/cgroup"
+
+// cgroupFrozen Function checks if a cgroup of a process (given by the content of /proc/<pid>/cgroup) is frozen
+func cgroupFrozen(cgroups, fsPath string) bool {
+	unified := ""
+
+	for _, line := range strings.Split(strings.TrimSpace(cgroups), "\n") {
+		// hierarchy-ID:controllers:path
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		// cgroup v1, the state of the freezer controller
+		if kl.ContainsElement(strings.Split(fields[1], ","), "freezer") {
+			data, err := os.ReadFile(filepath.Join(fsPath, "freezer", fields[2], "freezer.state"))
+			if err != nil {
+				return false
+			}
+			state := strings.TrimSpace(string(data))
+			return state == "FROZEN" || state == "FREEZING"
+		}
+
+		if fields[0] == "0" && fields[1] == "" {
+			unified = fields[2]
+		}
+	}
+
+	if unified == "" {
+		return false
+	}
+
+	// cgroup v2, the frozen field of the events
+	data, err := os.ReadFile(filepath.Join(fsPath, unified, "cgroup.events"))
+	if err != nil {
+		return false
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "frozen 1" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getContainerState Function returns the state of a container given by its process (empty if unknown)
+var getContainerState = func(pid uint32) string {
+	if pid == 0 {
+		return ""
+	}
+
+	cgroups, err := os.ReadFile(kl.GetProcPath(strconv.FormatUint(uint64(pid), 10), "cgroup"))
+	if err != nil {
+		return ""
+	}
+
+	if cgroupFrozen(string(cgroups), cgroupFsPath) {
+		return tp.ContainerStatePaused
+	}
+
+	return tp.ContainerStateRunning
+}
+
+// getProcessStartTime Function returns the start time of a process in clock ticks since boot (0 if it's gone)
+var getProcessStartTime = func(pid uint32) uint64 {
+	if pid == 0 {
+		return 0
+	}
+
+	data, err := os.ReadFile(kl.GetProcPath(strconv.FormatUint(uint64(pid), 10), "stat"))
+	if err != nil {
+		return 0
+	}
+
+	// the fields after the name of the process, which may contain spaces
+	fields := strings.Fields(string(data[strings.LastIndexByte(string(data), ')')+1:]))
+
+	// starttime, the 22nd field
+	if len(fields) < 20 {
+		return 0
+	}
+
+	startTime, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return startTime
+}
+
+// containerRestarted Function checks if the process of a container is gone or replaced, as the runtime restarts
+// the container in place (with the same ID)
+func containerRestarted(container tp.Container) bool {
+	if container.Pid == 0 || container.StartTime == 0 {
+		return false
+	}
+
+	return getProcessStartTime(container.Pid) != container.StartTime
+}
+
+// restartedContainers Function returns the containers of a runtime restarted in place, among the tracked ones
+func (dm *KubeArmorDaemon) restartedContainers(runtime string, tracked func(containerID string) bool) []string {
+	restarted := []string{}
+
+	dm.ContainersLock.RLock()
+	defer dm.ContainersLock.RUnlock()
+
+	for containerID, container := range dm.Containers {
+		if container.Runtime == runtime && tracked(containerID) && containerRestarted(container) {
+			restarted = append(restarted, containerID)
+		}
+	}
+
+	return restarted
+}
+
+// restartContainer Function refreshes a container restarted in place (or unpaused) given its info from the runtime,
+// and returns errCrioContainerUnknown if the container isn't added yet
+func (dm *KubeArmorDaemon) restartContainer(containerID string, info tp.Container) error {
+	// a paused container keeps its processes, and its updates are deferred until it's unpaused
+	if info.State == tp.ContainerStatePaused {
+		dm.ContainersLock.RLock()
+		_, ok := dm.Containers[containerID]
+		dm.ContainersLock.RUnlock()
+
+		if !ok {
+			return errCrioContainerUnknown
+		}
+
+		dm.pauseContainer(containerID)
+		return nil
+	}
+
+	// stopped, refreshed once it's started again
+	if info.Pid == 0 {
+		return nil
+	}
+
+	return dm.refreshContainer(containerID, info, dm.unpauseContainer(containerID))
+}
+
+// setContainerState Function keeps the state of a container, and returns its previous state
+func (dm *KubeArmorDaemon) setContainerState(containerID, state string) (string, bool) {
+	dm.ContainersLock.Lock()
+	defer dm.ContainersLock.Unlock()
+
+	container, ok := dm.Containers[containerID]
+	if !ok {
+		return "", false
+	}
+
+	prev := container.State
+	container.State = state
+	dm.Containers[containerID] = container
+
+	return prev, true
+}
+
+// pauseContainer Function marks a container paused, and logs it once
+func (dm *KubeArmorDaemon) pauseContainer(containerID string) {
+	if prev, ok := dm.setContainerState(containerID, tp.ContainerStatePaused); ok && prev != tp.ContainerStatePaused {
+		dm.Logger.Printf("Detected a container (paused/%.12s), deferring its updates until it's unpaused", containerID)
+	}
+}
+
+// unpauseContainer Function marks a container running, and returns whether it was paused
+func (dm *KubeArmorDaemon) unpauseContainer(containerID string) bool {
+	prev, ok := dm.setContainerState(containerID, tp.ContainerStateRunning)
+	if !ok || prev != tp.ContainerStatePaused {
+		return false
+	}
+
+	dm.Logger.Printf("Detected a container (unpaused/%.12s)", containerID)
+
+	return true
+}
+
+// refreshContainer Function refreshes the pid and the namespaces of a container restarted in place, or applies its
+// namespaces and its rules again once it's unpaused (forced), and returns errCrioContainerUnknown if the container
+// isn't added yet
+func (dm *KubeArmorDaemon) refreshContainer(containerID string, info tp.Container, force bool) error {
+	dm.ContainersLock.Lock()
+	container, ok := dm.Containers[containerID]
+	if !ok {
+		dm.ContainersLock.Unlock()
+		return errCrioContainerUnknown
+	}
+
+	restarted := container.Pid != info.Pid || container.PidNS != info.PidNS || container.MntNS != info.MntNS ||
+		(container.StartTime != 0 && container.StartTime != info.StartTime)
+	if !restarted && !force {
+		dm.ContainersLock.Unlock()
+		return nil
+	}
+
+	prev := container
+
+	container.Pid = info.Pid
+	container.StartTime = info.StartTime
+	container.PidNS = info.PidNS
+	container.MntNS = info.MntNS
+
+	dm.Containers[containerID] = container
+	dm.ContainersLock.Unlock()
+
+	if dm.SystemMonitor != nil && cfg.GlobalCfg.Policy {
+		if prev.PidNS != container.PidNS || prev.MntNS != container.MntNS {
+			// update NsMap
+			dm.SystemMonitor.DeleteContainerIDFromNsMap(containerID, prev.NamespaceName, prev.PidNS, prev.MntNS)
+			dm.SystemMonitor.AddContainerIDToNsMap(containerID, container.NamespaceName, container.PidNS, container.MntNS)
+
+			dm.RuntimeEnforcer.UnregisterContainer(containerID)
+			dm.RuntimeEnforcer.RegisterContainer(containerID, container.PidNS, container.MntNS)
+		} else if dm.SystemMonitor.LookupContainerID(container.PidNS, container.MntNS, 0, 0) != containerID {
+			// the namespaces of a container paused when it was added are registered once it's unpaused
+			dm.SystemMonitor.AddContainerIDToNsMap(containerID, container.NamespaceName, container.PidNS, container.MntNS)
+			dm.RuntimeEnforcer.RegisterContainer(containerID, container.PidNS, container.MntNS)
+		}
+
+		// the rules of the container are kept by the entry of its old namespaces, or deferred while it was paused
+		if dm.RuntimeEnforcer != nil && dm.RuntimeEnforcer.EnforcerType == "BPFLSM" {
+			dm.EndPointsLock.RLock()
+			for _, endPoint := range dm.EndPoints {
+				if endPoint.PolicyEnabled == tp.KubeArmorPolicyEnabled && kl.ContainsElement(endPoint.Containers, containerID) {
+					dm.RuntimeEnforcer.UpdateSecurityPolicies(endPoint)
+				}
+			}
+			dm.EndPointsLock.RUnlock()
+		}
+	}
+
+	if restarted {
+		dm.Logger.Printf("Detected a container (restarted/%.12s/pidns=%d/mntns=%d)", containerID, container.PidNS, container.MntNS)
+	}
+
+	// the profile is attached to the new process again
+	dm.AppArmorAttachment.ContainerRemoved(containerID)
+	dm.AppArmorAttachment.ContainerSeen(container)
+
+	return nil
+}
+
+// refreshUnpausedContainer Function refreshes a container unpaused, through its runtime if it can tell
+func (dm *KubeArmorDaemon) refreshUnpausedContainer(ctx context.Context, containerID string) error {
+	if crio := dm.getCrio(); crio != nil && crio.HasContainer(containerID) {
+		return dm.restartCrioContainer(ctx, containerID)
+	}
+
+	dm.ContainersLock.RLock()
+	container, ok := dm.Containers[containerID]
+	dm.ContainersLock.RUnlock()
+
+	if !ok {
+		return errCrioContainerUnknown
+	}
+
+	dm.unpauseContainer(containerID)
+
+	return dm.refreshContainer(containerID, container, true)
+}
+
+// checkContainerStates Function detects the containers paused and unpaused by their runtime
+func (dm *KubeArmorDaemon) checkContainerStates(ctx context.Context) {
+	containers := map[string]tp.Container{}
+
+	dm.ContainersLock.RLock()
+	for containerID, container := range dm.Containers {
+		if container.Pid != 0 {
+			containers[containerID] = container
+		}
+	}
+	dm.ContainersLock.RUnlock()
+
+	for containerID, container := range containers {
+		state := getContainerState(container.Pid)
+
+		if state == tp.ContainerStatePaused && container.State != tp.ContainerStatePaused {
+			dm.pauseContainer(containerID)
+		} else if state == tp.ContainerStateRunning && container.State == tp.ContainerStatePaused {
+			if err := dm.refreshUnpausedContainer(ctx, containerID); err != nil {
+				dm.Logger.Warnf("Failed to refresh an unpaused container (%.12s, %s)", containerID, err.Error())
+			}
+		}
+	}
+}
+
+// WatchContainerStates Function defers the updates of the paused containers until they're unpaused
+func (dm *KubeArmorDaemon) WatchContainerStates() {
+	ticker := time.NewTicker(containerStateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-StopChan:
+			return
+		case <-ticker.C:
+			dm.checkContainerStates(context.Background())
+		}
+	}
+}
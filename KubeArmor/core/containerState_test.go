@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	"github.com/kubearmor/KubeArmor/KubeArmor/monitor"
+	"github.com/kubearmor/KubeArmor/KubeArmor/testutil"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+// freezeCgroup sets the frozen field of the events of a fake cgroup v2
+func freezeCgroup(t *testing.T, path string, frozen bool) {
+	events := "populated 1\nfrozen 0\n"
+	if frozen {
+		events = "populated 1\nfrozen 1\n"
+	}
+	if err := os.WriteFile(filepath.Join(path, "cgroup.events"), []byte(events), 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to write the fake cgroup (%s)", err.Error())
+	}
+}
+
+func TestCgroupFrozen(t *testing.T) {
+	fsPath := t.TempDir()
+
+	// cgroup v2
+	if err := os.MkdirAll(filepath.Join(fsPath, "kubepods", "nginx"), 0750); err != nil {
+		t.Fatalf("[FAIL] Failed to create the fake cgroups (%s)", err.Error())
+	}
+	freezeCgroup(t, filepath.Join(fsPath, "kubepods", "nginx"), true)
+
+	if !cgroupFrozen("0::/kubepods/nginx\n", fsPath) {
+		t.Errorf("[FAIL] Expected the cgroup v2 to be frozen")
+	}
+
+	freezeCgroup(t, filepath.Join(fsPath, "kubepods", "nginx"), false)
+
+	if cgroupFrozen("0::/kubepods/nginx\n", fsPath) {
+		t.Errorf("[FAIL] Expected the cgroup v2 not to be frozen")
+	}
+
+	// cgroup v1, the freezer controller wins over the unified hierarchy
+	if err := os.MkdirAll(filepath.Join(fsPath, "freezer", "kubepods", "redis"), 0750); err != nil {
+		t.Fatalf("[FAIL] Failed to create the fake cgroups (%s)", err.Error())
+	}
+	if err := os.WriteFile(filepath.Join(fsPath, "freezer", "kubepods", "redis", "freezer.state"), []byte("FROZEN\n"), 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to write the fake cgroup (%s)", err.Error())
+	}
+
+	if !cgroupFrozen("12:freezer:/kubepods/redis\n4:cpu,cpuacct:/kubepods/redis\n0::/kubepods/nginx\n", fsPath) {
+		t.Errorf("[FAIL] Expected the cgroup v1 to be frozen")
+	}
+
+	// unknown cgroups
+	if cgroupFrozen("0::/missing\n", fsPath) || cgroupFrozen("", fsPath) {
+		t.Errorf("[FAIL] Expected the unknown cgroups not to be frozen")
+	}
+
+	t.Log("[PASS] Read the state of the freezer")
+}
+
+func TestContainerPause(t *testing.T) {
+	// the namespaces and the cgroup of the container
+	procDir := t.TempDir()
+	if err := os.MkdirAll(procDir+"/1001/ns", 0750); err != nil {
+		t.Fatalf("[FAIL] Failed to create the fake procfs (%s)", err.Error())
+	}
+	if err := os.Symlink("pid:[4026531001]", procDir+"/1001/ns/pid"); err != nil {
+		t.Fatalf("[FAIL] Failed to create the fake procfs (%s)", err.Error())
+	}
+	if err := os.Symlink("mnt:[4026531001]", procDir+"/1001/ns/mnt"); err != nil {
+		t.Fatalf("[FAIL] Failed to create the fake procfs (%s)", err.Error())
+	}
+	if err := os.WriteFile(procDir+"/1001/cgroup", []byte("0::/kubepods/nginx\n"), 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to create the fake procfs (%s)", err.Error())
+	}
+
+	kl.SetupProcFs(procDir, procDir)
+	defer kl.SetupProcFs("/proc", "/proc")
+
+	prevFsPath, prevPolicy := cgroupFsPath, cfg.GlobalCfg.Policy
+	defer func() { cgroupFsPath, cfg.GlobalCfg.Policy = prevFsPath, prevPolicy }()
+
+	cgroupFsPath = t.TempDir()
+	cgroup := filepath.Join(cgroupFsPath, "kubepods", "nginx")
+	if err := os.MkdirAll(cgroup, 0750); err != nil {
+		t.Fatalf("[FAIL] Failed to create the fake cgroups (%s)", err.Error())
+	}
+
+	// the root filesystem of the container, without wget
+	rootPath := t.TempDir()
+
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	fake.EnableEvents()
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+
+	messages := make(chan *pb.Message, 64)
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = map[string]fd.MsgStruct{"test": {Filter: "all", Broadcast: messages}}
+	defer func() { fd.MsgStructs = make(map[string]fd.MsgStruct) }()
+
+	dm := newCrioTestDaemon()
+
+	// the policies of the endpoint are not under test
+	cfg.GlobalCfg.Policy = false
+	dm.UpdateEndPointWithPod("ADDED", tp.K8sPod{
+		Metadata:    map[string]string{"namespaceName": "default", "podName": "nginx-pod"},
+		Annotations: map[string]string{"kubearmor-policy": "enabled"},
+		Labels:      map[string]string{"app": "nginx"},
+		Containers:  map[string]string{"nginx": "nginx"},
+	})
+	cfg.GlobalCfg.Policy = true
+
+	inNsMap := func() bool {
+		dm.SystemMonitor.NsMapLock.RLock()
+		defer dm.SystemMonitor.NsMapLock.RUnlock()
+		return dm.SystemMonitor.NsMap[monitor.NsKey{PidNS: 4026531001, MntNS: 4026531001}] == "nginx"
+	}
+
+	containerState := func() string {
+		dm.ContainersLock.RLock()
+		defer dm.ContainersLock.RUnlock()
+		return dm.Containers["nginx"].State
+	}
+
+	StopChan = make(chan struct{})
+	go dm.MonitorCrioEvents()
+
+	waitFor(t, "the event stream to be connected", func() bool {
+		return fake.EventStreams() == 1
+	})
+
+	// the container is paused (e.g., for checkpointing) when it's added
+	freezeCgroup(t, cgroup, true)
+
+	fake.AddContainer(testutil.FakeContainer{
+		ID:        "nginx",
+		Name:      "nginx",
+		Namespace: "default",
+		PodName:   "nginx-pod",
+		Pid:       1001,
+		RootPath:  rootPath,
+	})
+
+	waitFor(t, "the container to be added", func() bool {
+		return containerState() == tp.ContainerStatePaused
+	})
+
+	if inNsMap() {
+		t.Errorf("[FAIL] Expected the namespaces of the paused container to be deferred")
+	}
+
+	// a policy is updated while the container is paused, its root filesystem isn't looked up
+	secPolicy := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "default", "policyName": "block-downloads"}}
+	secPolicy.Spec.Action = "Block"
+	secPolicy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/usr/bin/wget"}}
+
+	if warnings := dm.checkPolicyPaths(secPolicy, []string{"nginx"}); len(warnings) != 0 {
+		t.Errorf("[FAIL] Expected the paths not to be checked while the container is paused (%v)", warnings)
+	}
+
+	// the container is restarted by the runtime and checked again while paused, without any change
+	fake.RestartContainer("nginx", 1001)
+	time.Sleep(200 * time.Millisecond)
+
+	dm.checkContainerStates(context.Background())
+	dm.checkContainerStates(context.Background())
+
+	if inNsMap() || containerState() != tp.ContainerStatePaused {
+		t.Errorf("[FAIL] Expected the container to remain paused (%s)", containerState())
+	}
+
+	// the container is unpaused
+	freezeCgroup(t, cgroup, false)
+	dm.checkContainerStates(context.Background())
+
+	if !inNsMap() || containerState() != tp.ContainerStateRunning {
+		t.Errorf("[FAIL] Expected the namespaces of the unpaused container to be registered (%s)", containerState())
+	}
+
+	// the deferred check of the paths is done by the next update
+	if warnings := dm.checkPolicyPaths(secPolicy, []string{"nginx"}); len(warnings) != 1 {
+		t.Errorf("[FAIL] Expected the paths to be checked once the container is unpaused (%v)", warnings)
+	}
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	// a single log for the pause, and one for the unpause
+	paused, unpaused := 0, 0
+	for len(messages) > 0 {
+		msg := <-messages
+		if strings.Contains(msg.Message, "Detected a container (paused/nginx)") {
+			paused++
+		} else if strings.Contains(msg.Message, "Detected a container (unpaused/nginx)") {
+			unpaused++
+		} else if msg.Level == "WARN" {
+			t.Errorf("[FAIL] Unexpected warning (%s)", msg.Message)
+		}
+	}
+
+	if paused != 1 || unpaused != 1 {
+		t.Errorf("[FAIL] Expected a single log for each transition (%d paused, %d unpaused)", paused, unpaused)
+	}
+
+	t.Log("[PASS] Deferred the updates of a paused container until it was unpaused")
+}
@@ -6,6 +6,7 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -19,6 +20,7 @@ import (
 
 	pb "github.com/containerd/containerd/api/services/containers/v1"
 	pt "github.com/containerd/containerd/api/services/tasks/v1"
+	apitask "github.com/containerd/containerd/api/types/task"
 	"github.com/containerd/containerd/namespaces"
 	"github.com/containerd/typeurl/v2"
 	"google.golang.org/grpc"
@@ -30,9 +32,6 @@ import (
 // == Containerd Handler == //
 // ======================== //
 
-// Containerd Handler
-var Containerd *ContainerdHandler
-
 // init Function
 func init() {
 	// Spec -> google.protobuf.Any
@@ -65,6 +64,12 @@ type ContainerdHandler struct {
 
 	// active containers
 	containers map[string]context.Context
+
+	// the last audit of the containers
+	audited time.Time
+
+	// state reported by the health probe
+	health *runtimeHealth
 }
 
 // NewContainerdHandler Function
@@ -101,6 +106,8 @@ func NewContainerdHandler() *ContainerdHandler {
 	// active containers
 	ch.containers = map[string]context.Context{}
 
+	ch.health = newRuntimeHealth(RuntimeContainerd, cfg.GlobalCfg.CRISocket)
+
 	kg.Print("Initialized Containerd Handler")
 
 	return ch
@@ -115,6 +122,11 @@ func (ch *ContainerdHandler) Close() {
 	}
 }
 
+// Health Function returns the state of the connection to containerd
+func (ch *ContainerdHandler) Health() tp.RuntimeHandlerHealth {
+	return ch.health.snapshot(grpcConnected(ch.conn))
+}
+
 // ==================== //
 // == Container Info == //
 // ==================== //
@@ -127,7 +139,7 @@ func (ch *ContainerdHandler) GetContainerInfo(ctx context.Context, containerID s
 		return tp.Container{}, err
 	}
 
-	container := tp.Container{}
+	container := tp.Container{Runtime: RuntimeContainerd}
 
 	// == container base == //
 
@@ -146,6 +158,9 @@ func (ch *ContainerdHandler) GetContainerInfo(ctx context.Context, containerID s
 		}
 	}
 
+	// the image as it was pulled (the digest is only known from the image store, which isn't looked up)
+	container.ContainerImage = kl.GetContainerImage(res.Container.Image, "")
+
 	iface, err := typeurl.UnmarshalAny(res.Container.Spec)
 	if err != nil {
 		return tp.Container{}, err
@@ -162,23 +177,40 @@ func (ch *ContainerdHandler) GetContainerInfo(ctx context.Context, containerID s
 		container.MergedDir = spec.Root.Path
 	}
 
+	// risky host mounts
+	container.RiskyMounts = ClassifyMounts(spec.Mounts, sensitiveHostPaths())
+
+	// cgroup, identity and resource limits of the runtime spec
+	applyRuntimeSpec(&container, spec)
+	container.Privileged = specPrivileged(spec)
+
 	// == //
 
 	taskReq := pt.ListPidsRequest{ContainerID: container.ContainerID}
-	if taskRes, err := Containerd.taskClient.ListPids(ctx, &taskReq); err == nil {
+	if taskRes, err := ch.taskClient.ListPids(ctx, &taskReq); err == nil {
 		if len(taskRes.Processes) == 0 {
 			return container, err
 		}
 
 		pid := strconv.Itoa(int(taskRes.Processes[0].Pid))
+		container.Pid = taskRes.Processes[0].Pid
+		container.StartTime = getProcessStartTime(container.Pid)
+
+		// the task of a paused container (e.g., for checkpointing)
+		container.State = tp.ContainerStateRunning
+		if task, err := ch.taskClient.Get(ctx, &pt.GetRequest{ContainerID: container.ContainerID}); err == nil && task.Process != nil {
+			if task.Process.Status == apitask.Status_PAUSED || task.Process.Status == apitask.Status_PAUSING {
+				container.State = tp.ContainerStatePaused
+			}
+		}
 
-		if data, err := os.Readlink("/proc/" + pid + "/ns/pid"); err == nil {
+		if data, err := os.Readlink(kl.GetProcPath(pid, "ns", "pid")); err == nil {
 			if _, err := fmt.Sscanf(data, "pid:[%d]\n", &container.PidNS); err != nil {
 				kg.Warnf("Unable to get PidNS (%s, %s, %s)", containerID, pid, err.Error())
 			}
 		}
 
-		if data, err := os.Readlink("/proc/" + pid + "/ns/mnt"); err == nil {
+		if data, err := os.Readlink(kl.GetProcPath(pid, "ns", "mnt")); err == nil {
 			if _, err := fmt.Sscanf(data, "mnt:[%d]\n", &container.MntNS); err != nil {
 				kg.Warnf("Unable to get MntNS (%s, %s, %s)", containerID, pid, err.Error())
 			}
@@ -196,25 +228,32 @@ func (ch *ContainerdHandler) GetContainerInfo(ctx context.Context, containerID s
 // == Containerd Events == //
 // ======================= //
 
-// GetContainerdContainers Function
-func (ch *ContainerdHandler) GetContainerdContainers() map[string]context.Context {
+// GetContainerdContainers Function gets IDs of all containers, and fails if either namespace can't be listed (rather
+// than the containers of the namespace being seen as deleted)
+func (ch *ContainerdHandler) GetContainerdContainers() (map[string]context.Context, error) {
 	containers := map[string]context.Context{}
 
 	req := pb.ListContainersRequest{}
 
-	if containerList, err := ch.client.List(ch.docker, &req); err == nil {
-		for _, container := range containerList.Containers {
-			containers[container.ID] = ch.docker
-		}
+	containerList, err := ch.client.List(ch.docker, &req)
+	if err != nil {
+		return nil, err
 	}
 
-	if containerList, err := ch.client.List(ch.containerd, &req); err == nil {
-		for _, container := range containerList.Containers {
-			containers[container.ID] = ch.containerd
-		}
+	for _, container := range containerList.Containers {
+		containers[container.ID] = ch.docker
+	}
+
+	containerList, err = ch.client.List(ch.containerd, &req)
+	if err != nil {
+		return nil, err
 	}
 
-	return containers
+	for _, container := range containerList.Containers {
+		containers[container.ID] = ch.containerd
+	}
+
+	return containers, nil
 }
 
 // GetNewContainerdContainers Function
@@ -237,25 +276,22 @@ func (ch *ContainerdHandler) GetDeletedContainerdContainers(containers map[strin
 	for globalContainerID := range ch.containers {
 		if _, ok := containers[globalContainerID]; !ok {
 			deletedContainers[globalContainerID] = context.TODO()
-			delete(ch.containers, globalContainerID)
 		}
 	}
 
-	ch.containers = containers
-
 	return deletedContainers
 }
 
 // UpdateContainerdContainer Function
 func (dm *KubeArmorDaemon) UpdateContainerdContainer(ctx context.Context, containerID, action string) bool {
 	// check if Containerd exists
-	if Containerd == nil {
+	if dm.containerd == nil {
 		return false
 	}
 
 	if action == "start" {
 		// get container information from containerd client
-		container, err := Containerd.GetContainerInfo(ctx, containerID)
+		container, err := dm.containerd.GetContainerInfo(ctx, containerID)
 		if err != nil {
 			return false
 		}
@@ -268,6 +304,13 @@ func (dm *KubeArmorDaemon) UpdateContainerdContainer(ctx context.Context, contai
 		if _, ok := dm.Containers[container.ContainerID]; !ok {
 			dm.Containers[container.ContainerID] = container
 			dm.ContainersLock.Unlock()
+
+			// the K8s watcher may have created the endpoint without the container info
+			if dm.K8sEnabled {
+				dm.EndPointsLock.Lock()
+				dm.attachContainerToEndPoint(container)
+				dm.EndPointsLock.Unlock()
+			}
 		} else if dm.Containers[container.ContainerID].PidNS == 0 && dm.Containers[container.ContainerID].MntNS == 0 {
 			// this entry was updated by kubernetes before docker detects it
 			// thus, we here use the info given by kubernetes instead of the info given by docker
@@ -278,6 +321,7 @@ func (dm *KubeArmorDaemon) UpdateContainerdContainer(ctx context.Context, contai
 
 			container.ContainerName = dm.Containers[container.ContainerID].ContainerName
 			container.ContainerImage = dm.Containers[container.ContainerID].ContainerImage
+			container.ContainerType = dm.Containers[container.ContainerID].ContainerType
 
 			container.PolicyEnabled = dm.Containers[container.ContainerID].PolicyEnabled
 
@@ -285,33 +329,26 @@ func (dm *KubeArmorDaemon) UpdateContainerdContainer(ctx context.Context, contai
 			container.FileVisibilityEnabled = dm.Containers[container.ContainerID].FileVisibilityEnabled
 			container.NetworkVisibilityEnabled = dm.Containers[container.ContainerID].NetworkVisibilityEnabled
 			container.CapabilitiesVisibilityEnabled = dm.Containers[container.ContainerID].CapabilitiesVisibilityEnabled
+			container.SignalVisibilityEnabled = dm.Containers[container.ContainerID].SignalVisibilityEnabled
 
 			dm.Containers[container.ContainerID] = container
 			dm.ContainersLock.Unlock()
 
 			dm.EndPointsLock.Lock()
-			for idx, endPoint := range dm.EndPoints {
-				if endPoint.NamespaceName == container.NamespaceName && endPoint.EndPointName == container.EndPointName && kl.ContainsElement(endPoint.Containers, container.ContainerID) {
-					// update containers
-					if !kl.ContainsElement(endPoint.Containers, container.ContainerID) {
-						dm.EndPoints[idx].Containers = append(dm.EndPoints[idx].Containers, container.ContainerID)
-					}
-
-					// update apparmor profiles
-					if !kl.ContainsElement(endPoint.AppArmorProfiles, container.AppArmorProfile) {
-						dm.EndPoints[idx].AppArmorProfiles = append(dm.EndPoints[idx].AppArmorProfiles, container.AppArmorProfile)
-					}
-
-					break
-				}
-			}
+			dm.attachContainerToEndPoint(container)
 			dm.EndPointsLock.Unlock()
 		} else {
 			dm.ContainersLock.Unlock()
 			return false
 		}
 
-		if dm.SystemMonitor != nil && cfg.GlobalCfg.Policy {
+		// the namespaces of a paused container are registered once it's unpaused
+		paused := container.State == tp.ContainerStatePaused
+		if paused {
+			dm.Logger.Printf("Detected a container (paused/%.12s), deferring its updates until it's unpaused", containerID)
+		}
+
+		if dm.SystemMonitor != nil && cfg.GlobalCfg.Policy && !paused {
 			// update NsMap
 			dm.SystemMonitor.AddContainerIDToNsMap(containerID, container.NamespaceName, container.PidNS, container.MntNS)
 			dm.RuntimeEnforcer.RegisterContainer(containerID, container.PidNS, container.MntNS)
@@ -327,43 +364,16 @@ func (dm *KubeArmorDaemon) UpdateContainerdContainer(ctx context.Context, contai
 
 		dm.Logger.Printf("Detected a container (added/%.12s/pidns=%d/mntns=%d)", containerID, container.PidNS, container.MntNS)
 
+		dm.reportRiskyMounts(container)
+		if !paused {
+			dm.AppArmorAttachment.ContainerSeen(container)
+		}
+
 	} else if action == "destroy" {
-		dm.ContainersLock.Lock()
-		container, ok := dm.Containers[containerID]
+		container, ok := dm.removeContainer(containerID)
 		if !ok {
-			dm.ContainersLock.Unlock()
 			return false
 		}
-		if !dm.K8sEnabled {
-			dm.EndPointsLock.Lock()
-			dm.MatchandRemoveContainerFromEndpoint(containerID)
-			dm.EndPointsLock.Unlock()
-		}
-		delete(dm.Containers, containerID)
-		dm.ContainersLock.Unlock()
-
-		dm.EndPointsLock.Lock()
-		for idx, endPoint := range dm.EndPoints {
-			if endPoint.NamespaceName == container.NamespaceName && endPoint.EndPointName == container.EndPointName && kl.ContainsElement(endPoint.Containers, container.ContainerID) {
-
-				// update apparmor profiles
-				for idxA, profile := range endPoint.AppArmorProfiles {
-					if profile == container.AppArmorProfile {
-						dm.EndPoints[idx].AppArmorProfiles = append(dm.EndPoints[idx].AppArmorProfiles[:idxA], dm.EndPoints[idx].AppArmorProfiles[idxA+1:]...)
-						break
-					}
-				}
-
-				break
-			}
-		}
-		dm.EndPointsLock.Unlock()
-
-		if dm.SystemMonitor != nil && cfg.GlobalCfg.Policy {
-			// update NsMap
-			dm.SystemMonitor.DeleteContainerIDFromNsMap(containerID, container.NamespaceName, container.PidNS, container.MntNS)
-			dm.RuntimeEnforcer.UnregisterContainer(containerID)
-		}
 
 		dm.Logger.Printf("Detected a container (removed/%.12s/pidns=%d/mntns=%d)", containerID, container.PidNS, container.MntNS)
 	}
@@ -371,52 +381,114 @@ func (dm *KubeArmorDaemon) UpdateContainerdContainer(ctx context.Context, contai
 	return true
 }
 
+// restartContainerdContainer Function refreshes the pid and the namespaces of a container restarted in place (or
+// unpaused), keeping its endpoint association
+func (dm *KubeArmorDaemon) restartContainerdContainer(ctx context.Context, containerID string) error {
+	info, err := dm.containerd.GetContainerInfo(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	return dm.restartContainer(containerID, info)
+}
+
 // MonitorContainerdEvents Function
 func (dm *KubeArmorDaemon) MonitorContainerdEvents() {
 	dm.WgDaemon.Add(1)
 	defer dm.WgDaemon.Done()
 
-	Containerd = NewContainerdHandler()
+	dm.containerd = NewContainerdHandler()
 
 	// check if Containerd exists
-	if Containerd == nil {
+	if dm.containerd == nil {
+		dm.trackRuntimeHealth(RuntimeContainerd, unreachableRuntime(RuntimeContainerd, cfg.GlobalCfg.CRISocket, errRuntimeUnreachable))
 		return
 	}
+	dm.trackRuntimeHealth(RuntimeContainerd, dm.containerd.Health)
 
 	dm.Logger.Print("Started to monitor Containerd events")
 
+	listed := false
+
+	// the listing backs off while the containers don't change
+	poller := newRuntimePoller()
+
 	for {
+		changed := false
+
 		select {
 		case <-StopChan:
 			return
 
 		default:
-			containers := Containerd.GetContainerdContainers()
+			containers, err := dm.containerd.GetContainerdContainers()
+			dm.containerd.health.recordList(err)
+			if err != nil {
+				dm.Logger.Warnf("Failed to list Containerd containers (%s)", err.Error())
+				break
+			}
+
+			newContainers := dm.containerd.GetNewContainerdContainers(containers)
+			deletedContainers := dm.containerd.GetDeletedContainerdContainers(containers)
+
+			changed = len(newContainers) > 0 || len(deletedContainers) > 0
 
-			invalidContainers := []string{}
+			// the snapshot only advances for the containers processed, the others are processed by the next listing
+			for containerID, context := range newContainers {
+				if dm.UpdateContainerdContainer(context, containerID, "start") {
+					dm.containerd.containers[containerID] = context
+				}
+			}
 
-			newContainers := Containerd.GetNewContainerdContainers(containers)
-			deletedContainers := Containerd.GetDeletedContainerdContainers(containers)
+			// the initial listing is done
+			if !listed {
+				dm.finalizeNsMapAdoption()
+				listed = true
+			}
 
-			if len(newContainers) > 0 {
-				for containerID, context := range newContainers {
-					if !dm.UpdateContainerdContainer(context, containerID, "start") {
-						invalidContainers = append(invalidContainers, containerID)
-					}
+			// the containers restarted in place keep their IDs, and are found by their processes (the stopped ones
+			// can't be refreshed until they're started again)
+			restarted := dm.restartedContainers(RuntimeContainerd, func(containerID string) bool {
+				_, ok := dm.containerd.containers[containerID]
+				return ok
+			})
+			for _, containerID := range restarted {
+				if err := dm.restartContainerdContainer(dm.containerd.containers[containerID], containerID); err != nil && !errors.Is(err, errCrioContainerUnknown) {
+					dm.Logger.Debugf("Failed to refresh a restarted container (%.12s, %s)", containerID, err.Error())
 				}
 			}
+			changed = changed || len(restarted) > 0
+
+			for containerID := range deletedContainers {
+				if err := destroyContainer(dm, containerID); err != nil {
+					dm.Logger.Warnf("Failed to remove a container (%.12s, %s)", containerID, err.Error())
+					continue
+				}
 
-			for _, invalidContainerID := range invalidContainers {
-				delete(Containerd.containers, invalidContainerID)
+				delete(dm.containerd.containers, containerID)
 			}
+			dm.containerd.health.setContainers(len(dm.containerd.containers))
+
+			if time.Since(dm.containerd.audited) >= containerAuditInterval {
+				dm.containerd.audited = time.Now()
 
-			if len(deletedContainers) > 0 {
-				for containerID, context := range deletedContainers {
-					dm.UpdateContainerdContainer(context, containerID, "destroy")
+				// the containers still tracked aren't leaked
+				tracked := map[string]struct{}{}
+				for containerID := range containers {
+					tracked[containerID] = struct{}{}
 				}
+				for containerID := range dm.containerd.containers {
+					tracked[containerID] = struct{}{}
+				}
+
+				dm.auditContainers(tracked)
 			}
 		}
 
-		time.Sleep(time.Millisecond * 500)
+		select {
+		case <-StopChan:
+			return
+		case <-time.After(poller.next(changed)):
+		}
 	}
 }
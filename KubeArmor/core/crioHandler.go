@@ -6,9 +6,12 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
@@ -17,6 +20,8 @@ import (
 	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
 	spec "github.com/opencontainers/runtime-spec/specs-go"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	pb "k8s.io/cri-api/pkg/apis/runtime/v1"
 )
 
@@ -29,9 +34,62 @@ type CrioHandler struct {
 	client pb.RuntimeServiceClient
 
 	// containers is a map with empty value to have lookups in constant time
-	containers map[string]struct{}
+	containers     map[string]struct{}
+	containersLock *sync.RWMutex
+
+	// the initial listing is done
+	listed bool
+
+	// the last audit of the containers
+	audited time.Time
+
+	// containers which failed to be added, retried with backoff
+	retries     map[string]*tp.ContainerRetry
+	retriesLock *sync.Mutex
+
+	// state reported by the health probe
+	health *runtimeHealth
 }
 
+var (
+	// errCrioContainerKnown is returned for the containers which are added already
+	errCrioContainerKnown = errors.New("container is known already")
+
+	// errNoCrioContainerInfo is returned for the containers without an ID in their status
+	errNoCrioContainerInfo = errors.New("no container info")
+
+	// errCrioContainerUnknown is returned for the restarts of the containers which aren't added yet
+	errCrioContainerUnknown = errors.New("container is unknown")
+
+	// errCrioEventsUnimplemented is returned for the runtimes without the event stream
+	errCrioEventsUnimplemented = errors.New("event stream is unimplemented")
+
+	// errCrioV1Unimplemented is returned for the runtimes serving only v1alpha2 (CRI-O < 1.20)
+	errCrioV1Unimplemented = errors.New("v1 runtime API is unimplemented")
+)
+
+var (
+	// the timeout of the Version call checking the runtime API on connect
+	crioProbeTimeout = 5 * time.Second
+
+	// the interval of reconciling the containers with the listing, for the missed events
+	crioResyncInterval = 30 * time.Second
+
+	// the delay before reconnecting the event stream
+	crioReconnectDelay = 1 * time.Second
+
+	// the backoff of re-dialing CRI-O once the connection is lost, doubled up to crioRedialMaxBackoff
+	crioRedialBackoff    = 1 * time.Second
+	crioRedialMaxBackoff = 30 * time.Second
+
+	// the backoff of the retries of a container, doubled up to crioRetryMaxBackoff
+	crioRetryBackoff    = 100 * time.Millisecond
+	crioRetryMaxBackoff = 10 * time.Second
+
+	// the maximum number of the containers retried, the others are retried by the next listing
+	crioMaxRetries = 1024
+)
+
 // CrioContainerInfo struct corresponds to CRI-O's container info returned
 // with container status
 type CrioContainerInfo struct {
@@ -41,16 +99,14 @@ type CrioContainerInfo struct {
 	Privileged  bool      `json:"privileged"`
 }
 
-// Crio Handler
-var Crio *CrioHandler
-
-// NewCrioHandler Function creates a new Crio handler
-func NewCrioHandler() *CrioHandler {
+// NewCrioHandler Function creates a new Crio handler. It returns errCrioV1Unimplemented if CRI-O doesn't serve the
+// v1 runtime API, or the error of the connection otherwise.
+func NewCrioHandler() (*CrioHandler, error) {
 	ch := &CrioHandler{}
 
 	conn, err := grpc.Dial(cfg.GlobalCfg.CRISocket, grpc.WithInsecure())
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	ch.conn = conn
@@ -58,9 +114,70 @@ func NewCrioHandler() *CrioHandler {
 	// The runtime service client can be used for all RPCs
 	ch.client = pb.NewRuntimeServiceClient(ch.conn)
 
+	// the runtimes serving only v1alpha2 (CRI-O < 1.20) reply to v1 with Unimplemented
+	ctx, cancel := context.WithTimeout(context.Background(), crioProbeTimeout)
+	defer cancel()
+
+	version, err := ch.client.Version(ctx, &pb.VersionRequest{})
+	if err != nil {
+		ch.Close()
+		if status.Code(err) == codes.Unimplemented {
+			return nil, fmt.Errorf("%w (%s)", errCrioV1Unimplemented, err.Error())
+		}
+		return nil, err
+	}
+
+	kg.Printf("Connected to %s %s (CRI %s)", version.RuntimeName, version.RuntimeVersion, version.RuntimeApiVersion)
+
 	ch.containers = make(map[string]struct{})
+	ch.containersLock = new(sync.RWMutex)
+
+	ch.retries = make(map[string]*tp.ContainerRetry)
+	ch.retriesLock = new(sync.Mutex)
+
+	ch.health = newRuntimeHealth(RuntimeCrio, cfg.GlobalCfg.CRISocket)
+
+	return ch, nil
+}
+
+// parseCrioContainerInfo parses the runtime specific info in the verbose status of a container
+func parseCrioContainerInfo(info map[string]string) (CrioContainerInfo, error) {
+	var containerInfo CrioContainerInfo
+
+	if err := json.Unmarshal([]byte(info["info"]), &containerInfo); err != nil {
+		return CrioContainerInfo{}, err
+	}
+
+	return containerInfo, nil
+}
+
+// crioImageNameAnnotation is the annotation of the runtime spec with the image name the container was created from
+const crioImageNameAnnotation = "io.kubernetes.cri-o.ImageName"
+
+// crioContainerImage returns the image of a container as name@digest from its status, where the image may be
+// reported by its ID (then the name is taken from the runtime spec or the image reference), and the image
+// reference may be a repository digest or a bare digest
+func crioContainerImage(status *pb.ContainerStatus, annotations map[string]string) string {
+	image := status.GetImage().GetImage()
+
+	if name, _, _ := kl.ParseImage(image); name == "" {
+		if annotated := annotations[crioImageNameAnnotation]; annotated != "" {
+			image = annotated
+		} else if name, _, _ := kl.ParseImage(status.GetImageRef()); name != "" {
+			image = status.GetImageRef()
+		}
+	}
 
-	return ch
+	return kl.GetContainerImage(image, status.GetImageRef())
+}
+
+// withCrioTimeout Function bounds a call to CRI-O with the request timeout, so that a hung runtime doesn't block the
+// monitor
+func withCrioTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if cfg.GlobalCfg.CRIRequestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, cfg.GlobalCfg.CRIRequestTimeout)
 }
 
 // Close the connection
@@ -72,6 +189,11 @@ func (ch *CrioHandler) Close() {
 	}
 }
 
+// Health Function returns the state of the connection to CRI-O
+func (ch *CrioHandler) Health() tp.RuntimeHandlerHealth {
+	return ch.health.snapshot(grpcConnected(ch.conn))
+}
+
 // ==================== //
 // == Container Info == //
 // ==================== //
@@ -85,12 +207,15 @@ func (ch *CrioHandler) GetContainerInfo(ctx context.Context, containerID string)
 		Verbose:     true,
 	}
 
+	ctx, cancel := withCrioTimeout(ctx)
+	defer cancel()
+
 	res, err := ch.client.ContainerStatus(ctx, req)
 	if err != nil {
 		return tp.Container{}, err
 	}
 
-	container := tp.Container{}
+	container := tp.Container{Runtime: RuntimeCrio}
 
 	// == container base == //
 	resContainerStatus := res.Status
@@ -111,21 +236,44 @@ func (ch *CrioHandler) GetContainerInfo(ctx context.Context, containerID string)
 	}
 
 	// extracting the runtime specific "info"
-	var containerInfo CrioContainerInfo
-	err = json.Unmarshal([]byte(res.Info["info"]), &containerInfo)
+	containerInfo, err := parseCrioContainerInfo(res.Info)
 	if err != nil {
 		return tp.Container{}, err
 	}
 
+	// the pod information which the container lacks, from its sandbox
+	if containerInfo.SandboxID != "" {
+		if sandbox, err := ch.getSandboxStatus(ctx, containerInfo.SandboxID); err == nil {
+			applyCrioSandbox(&container, sandbox)
+		} else {
+			kg.Debugf("Unable to get the sandbox of a container (%.12s, %s)", containerID, err.Error())
+		}
+	}
+
+	// the image and the digest reported by CRI-O
+	container.ContainerImage = crioContainerImage(resContainerStatus, containerInfo.RuntimeSpec.Annotations)
+
 	// path to container's root storage
 	container.AppArmorProfile = containerInfo.RuntimeSpec.Process.ApparmorProfile
 
 	// path to the rootfs
 	container.MergedDir = containerInfo.RuntimeSpec.Root.Path
 
+	// risky host mounts
+	container.RiskyMounts = ClassifyMounts(containerInfo.RuntimeSpec.Mounts, sensitiveHostPaths())
+
+	// cgroup, identity and resource limits of the runtime spec
+	applyRuntimeSpec(&container, &containerInfo.RuntimeSpec)
+	container.Privileged = containerInfo.Privileged
+
 	pid := strconv.Itoa(containerInfo.Pid)
+	container.Pid = uint32(containerInfo.Pid)
+	container.StartTime = getProcessStartTime(container.Pid)
 
-	if data, err := os.Readlink("/proc/" + pid + "/ns/pid"); err == nil {
+	// CRI reports the paused containers as running, their cgroups are frozen
+	container.State = getContainerState(container.Pid)
+
+	if data, err := os.Readlink(kl.GetProcPath(pid, "ns", "pid")); err == nil {
 		if _, err := fmt.Sscanf(data, "pid:[%d]\n", &container.PidNS); err != nil {
 			kg.Warnf("Unable to get PidNS (%s, %s, %s)", containerID, pid, err.Error())
 		}
@@ -133,7 +281,7 @@ func (ch *CrioHandler) GetContainerInfo(ctx context.Context, containerID string)
 		return container, err
 	}
 
-	if data, err := os.Readlink("/proc/" + pid + "/ns/mnt"); err == nil {
+	if data, err := os.Readlink(kl.GetProcPath(pid, "ns", "mnt")); err == nil {
 		if _, err := fmt.Sscanf(data, "mnt:[%d]\n", &container.MntNS); err != nil {
 			kg.Warnf("Unable to get MntNS (%s, %s, %s)", containerID, pid, err.Error())
 		}
@@ -149,27 +297,33 @@ func (ch *CrioHandler) GetContainerInfo(ctx context.Context, containerID string)
 // ================= //
 
 // GetCrioContainers Function gets IDs of all containers
-func (ch *CrioHandler) GetCrioContainers() (map[string]struct{}, error) {
+func (ch *CrioHandler) GetCrioContainers(ctx context.Context) (map[string]struct{}, error) {
 	containers := make(map[string]struct{})
-	var err error
 
 	req := pb.ListContainersRequest{}
 
-	if containerList, err := ch.client.ListContainers(context.Background(), &req); err == nil {
-		for _, container := range containerList.Containers {
-			containers[container.Id] = struct{}{}
-		}
+	ctx, cancel := withCrioTimeout(ctx)
+	defer cancel()
 
-		return containers, nil
+	containerList, err := ch.client.ListContainers(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, container := range containerList.Containers {
+		containers[container.Id] = struct{}{}
 	}
 
-	return nil, err
+	return containers, nil
 }
 
 // GetNewCrioContainers Function gets new crio containers
 func (ch *CrioHandler) GetNewCrioContainers(containers map[string]struct{}) map[string]struct{} {
 	newContainers := make(map[string]struct{})
 
+	ch.containersLock.RLock()
+	defer ch.containersLock.RUnlock()
+
 	for activeContainerID := range containers {
 		if _, ok := ch.containers[activeContainerID]; !ok {
 			newContainers[activeContainerID] = struct{}{}
@@ -183,131 +337,659 @@ func (ch *CrioHandler) GetNewCrioContainers(containers map[string]struct{}) map[
 func (ch *CrioHandler) GetDeletedCrioContainers(containers map[string]struct{}) map[string]struct{} {
 	deletedContainers := make(map[string]struct{})
 
+	ch.containersLock.RLock()
+	defer ch.containersLock.RUnlock()
+
 	for globalContainerID := range ch.containers {
 		if _, ok := containers[globalContainerID]; !ok {
 			deletedContainers[globalContainerID] = struct{}{}
-			delete(ch.containers, globalContainerID)
 		}
 	}
 
-	ch.containers = containers
-
 	return deletedContainers
 }
 
-// UpdateCrioContainer Function
-func (dm *KubeArmorDaemon) UpdateCrioContainer(ctx context.Context, containerID, action string) bool {
-	if Crio == nil {
+// HasContainer Function checks if a container is known
+func (ch *CrioHandler) HasContainer(containerID string) bool {
+	ch.containersLock.RLock()
+	defer ch.containersLock.RUnlock()
+
+	_, ok := ch.containers[containerID]
+	return ok
+}
+
+// Snapshot Function returns a copy of the known containers
+func (ch *CrioHandler) Snapshot() map[string]struct{} {
+	ch.containersLock.RLock()
+	defer ch.containersLock.RUnlock()
+
+	containers := make(map[string]struct{}, len(ch.containers))
+	for containerID := range ch.containers {
+		containers[containerID] = struct{}{}
+	}
+
+	return containers
+}
+
+// NumContainers Function returns the number of the known containers
+func (ch *CrioHandler) NumContainers() int {
+	ch.containersLock.RLock()
+	defer ch.containersLock.RUnlock()
+
+	return len(ch.containers)
+}
+
+// trackContainer Function adds a container to the known containers
+func (ch *CrioHandler) trackContainer(containerID string) {
+	ch.containersLock.Lock()
+	defer ch.containersLock.Unlock()
+
+	ch.containers[containerID] = struct{}{}
+}
+
+// forgetContainer Function removes a container from the known containers
+func (ch *CrioHandler) forgetContainer(containerID string) {
+	ch.containersLock.Lock()
+	defer ch.containersLock.Unlock()
+
+	delete(ch.containers, containerID)
+}
+
+// ============= //
+// == Retries == //
+// ============= //
+
+// ScheduleRetry Function schedules the retry of a container which failed to be added, and returns false if it can't
+// be retried (the retry window is disabled, or too many containers are retried)
+func (ch *CrioHandler) ScheduleRetry(containerID string, err error) bool {
+	if cfg.GlobalCfg.ContainerRetryWindow <= 0 {
 		return false
 	}
 
-	if action == "start" {
-		// get container info from client
-		container, err := Crio.GetContainerInfo(ctx, containerID)
-		if err != nil {
+	ch.retriesLock.Lock()
+	defer ch.retriesLock.Unlock()
+
+	now := time.Now()
+
+	retry, ok := ch.retries[containerID]
+	if !ok {
+		if len(ch.retries) >= crioMaxRetries {
 			return false
 		}
 
-		if container.ContainerID == "" {
-			return false
+		retry = &tp.ContainerRetry{ContainerID: containerID, FirstFailure: now}
+		ch.retries[containerID] = retry
+	}
+
+	retry.Attempts++
+	retry.LastError = err.Error()
+
+	if now.Sub(retry.FirstFailure) >= cfg.GlobalCfg.ContainerRetryWindow {
+		retry.GaveUp = true
+		retry.NextRetry = time.Time{}
+
+		kg.Warnf("Gave up adding a container after %d attempts (%.12s, %s)", retry.Attempts, containerID, retry.LastError)
+		return true
+	}
+
+	backoff := crioRetryBackoff
+	for i := 1; i < retry.Attempts && backoff < crioRetryMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > crioRetryMaxBackoff {
+		backoff = crioRetryMaxBackoff
+	}
+
+	retry.NextRetry = now.Add(backoff)
+
+	return true
+}
+
+// DueRetries Function returns the containers whose retries are due
+func (ch *CrioHandler) DueRetries(now time.Time) []string {
+	ch.retriesLock.Lock()
+	defer ch.retriesLock.Unlock()
+
+	due := []string{}
+	for containerID, retry := range ch.retries {
+		if !retry.GaveUp && !now.Before(retry.NextRetry) {
+			due = append(due, containerID)
 		}
+	}
+	sort.Strings(due)
 
-		dm.ContainersLock.Lock()
-		if _, ok := dm.Containers[container.ContainerID]; !ok {
-			dm.Containers[container.ContainerID] = container
-			dm.ContainersLock.Unlock()
-		} else if dm.Containers[container.ContainerID].PidNS == 0 && dm.Containers[container.ContainerID].MntNS == 0 {
-			container.NamespaceName = dm.Containers[container.ContainerID].NamespaceName
-			container.EndPointName = dm.Containers[container.ContainerID].EndPointName
-			container.Labels = dm.Containers[container.ContainerID].Labels
+	return due
+}
 
-			container.ContainerName = dm.Containers[container.ContainerID].ContainerName
-			container.ContainerImage = dm.Containers[container.ContainerID].ContainerImage
+// ForgetRetry Function
+func (ch *CrioHandler) ForgetRetry(containerID string) {
+	ch.retriesLock.Lock()
+	defer ch.retriesLock.Unlock()
 
-			container.PolicyEnabled = dm.Containers[container.ContainerID].PolicyEnabled
+	delete(ch.retries, containerID)
+}
 
-			container.ProcessVisibilityEnabled = dm.Containers[container.ContainerID].ProcessVisibilityEnabled
-			container.FileVisibilityEnabled = dm.Containers[container.ContainerID].FileVisibilityEnabled
-			container.NetworkVisibilityEnabled = dm.Containers[container.ContainerID].NetworkVisibilityEnabled
-			container.CapabilitiesVisibilityEnabled = dm.Containers[container.ContainerID].CapabilitiesVisibilityEnabled
+// GetRetries Function returns the retry states of the containers
+func (ch *CrioHandler) GetRetries() []tp.ContainerRetry {
+	retries := []tp.ContainerRetry{}
 
-			dm.Containers[container.ContainerID] = container
-			dm.ContainersLock.Unlock()
+	ch.retriesLock.Lock()
+	for _, retry := range ch.retries {
+		retries = append(retries, *retry)
+	}
+	ch.retriesLock.Unlock()
 
-			dm.EndPointsLock.Lock()
-			for idx, endPoint := range dm.EndPoints {
-				if endPoint.NamespaceName == container.NamespaceName && endPoint.EndPointName == container.EndPointName && kl.ContainsElement(endPoint.Containers, container.ContainerID) {
+	sort.Slice(retries, func(i, j int) bool {
+		return retries[i].ContainerID < retries[j].ContainerID
+	})
 
-					// update apparmor profiles
-					if !kl.ContainsElement(endPoint.AppArmorProfiles, container.AppArmorProfile) {
-						dm.EndPoints[idx].AppArmorProfiles = append(dm.EndPoints[idx].AppArmorProfiles, container.AppArmorProfile)
-					}
+	return retries
+}
 
-					break
-				}
-			}
-			dm.EndPointsLock.Unlock()
-		} else {
-			dm.ContainersLock.Unlock()
-			return false
+// WatchContainerEvents Function sends the events of the containers until the stream ends, and returns its error
+func (ch *CrioHandler) WatchContainerEvents(ctx context.Context, events chan<- *pb.ContainerEventResponse) error {
+	stream, err := ch.client.GetContainerEvents(ctx, &pb.GetEventsRequest{})
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return err
 		}
 
-		if dm.SystemMonitor != nil && cfg.GlobalCfg.Policy {
-			// update NsMap
-			dm.SystemMonitor.AddContainerIDToNsMap(containerID, container.NamespaceName, container.PidNS, container.MntNS)
-			dm.RuntimeEnforcer.RegisterContainer(containerID, container.PidNS, container.MntNS)
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
 		}
+	}
+}
+
+// startCrioContainer Function adds a started container, and returns why it isn't added
+func (dm *KubeArmorDaemon) startCrioContainer(ctx context.Context, containerID string) error {
+	crio := dm.getCrio()
+	if crio == nil {
+		return errRuntimeUnreachable
+	}
+
+	// get container info from client
+	container, err := crio.GetContainerInfo(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	if container.ContainerID == "" {
+		return errNoCrioContainerInfo
+	}
+
+	return dm.addContainer(container)
+}
+
+// addContainer Function adds a started container given by its runtime (or its NRI events), completing the one the K8s
+// watcher may have created already, and returns errCrioContainerKnown if the container is added already
+func (dm *KubeArmorDaemon) addContainer(container tp.Container) error {
+	containerID := container.ContainerID
+
+	// the endpoint added for the container by its sandbox
+	var sandboxEndPoint *tp.EndPoint
 
-		if !dm.K8sEnabled {
-			dm.ContainersLock.Lock()
+	dm.ContainersLock.Lock()
+	if _, ok := dm.Containers[container.ContainerID]; !ok {
+		dm.Containers[container.ContainerID] = container
+		dm.ContainersLock.Unlock()
+
+		// the K8s watcher may have created the endpoint without the container info
+		if dm.K8sEnabled {
 			dm.EndPointsLock.Lock()
-			dm.MatchandUpdateContainerSecurityPolicies(containerID)
+			if !dm.attachContainerToEndPoint(container) {
+				// the K8s watcher may not have seen the container of the pod yet (e.g., an ephemeral container)
+				if endPoint, ok := dm.attachContainerToSandboxEndPoint(container); ok {
+					sandboxEndPoint = &endPoint
+				}
+			}
 			dm.EndPointsLock.Unlock()
-			dm.ContainersLock.Unlock()
 		}
+	} else if dm.Containers[container.ContainerID].PidNS == 0 && dm.Containers[container.ContainerID].MntNS == 0 {
+		container.NamespaceName = dm.Containers[container.ContainerID].NamespaceName
+		container.EndPointName = dm.Containers[container.ContainerID].EndPointName
+		container.Labels = dm.Containers[container.ContainerID].Labels
 
-		dm.Logger.Printf("Detected a container (added/%.12s)", containerID)
-	} else if action == "destroy" {
+		container.ContainerName = dm.Containers[container.ContainerID].ContainerName
+		container.ContainerImage = dm.Containers[container.ContainerID].ContainerImage
+		container.ContainerType = dm.Containers[container.ContainerID].ContainerType
+
+		container.PolicyEnabled = dm.Containers[container.ContainerID].PolicyEnabled
+
+		container.ProcessVisibilityEnabled = dm.Containers[container.ContainerID].ProcessVisibilityEnabled
+		container.FileVisibilityEnabled = dm.Containers[container.ContainerID].FileVisibilityEnabled
+		container.NetworkVisibilityEnabled = dm.Containers[container.ContainerID].NetworkVisibilityEnabled
+		container.CapabilitiesVisibilityEnabled = dm.Containers[container.ContainerID].CapabilitiesVisibilityEnabled
+		container.SignalVisibilityEnabled = dm.Containers[container.ContainerID].SignalVisibilityEnabled
+
+		dm.Containers[container.ContainerID] = container
+		dm.ContainersLock.Unlock()
+
+		dm.EndPointsLock.Lock()
+		dm.attachContainerToEndPoint(container)
+		dm.EndPointsLock.Unlock()
+	} else {
+		dm.ContainersLock.Unlock()
+		return errCrioContainerKnown
+	}
+
+	// the namespaces of a paused container are registered once it's unpaused
+	paused := container.State == tp.ContainerStatePaused
+	if paused {
+		dm.Logger.Printf("Detected a container (paused/%.12s), deferring its updates until it's unpaused", containerID)
+	}
+
+	if dm.SystemMonitor != nil && cfg.GlobalCfg.Policy && !paused {
+		// update NsMap
+		dm.SystemMonitor.AddContainerIDToNsMap(containerID, container.NamespaceName, container.PidNS, container.MntNS)
+		dm.RuntimeEnforcer.RegisterContainer(containerID, container.PidNS, container.MntNS)
+	}
+
+	if sandboxEndPoint != nil {
+		dm.applySandboxEndPoint(containerID, *sandboxEndPoint)
+	}
+
+	if !dm.K8sEnabled {
 		dm.ContainersLock.Lock()
-		container, ok := dm.Containers[containerID]
-		if !ok {
-			dm.ContainersLock.Unlock()
+		dm.EndPointsLock.Lock()
+		dm.MatchandUpdateContainerSecurityPolicies(containerID)
+		dm.EndPointsLock.Unlock()
+		dm.ContainersLock.Unlock()
+	}
+
+	dm.Logger.Printf("Detected a container (added/%.12s)", containerID)
+
+	dm.reportRiskyMounts(container)
+	if !paused {
+		dm.AppArmorAttachment.ContainerSeen(container)
+	}
+
+	return nil
+}
+
+// restartCrioContainer Function refreshes the pid and the namespaces of a container restarted in place (or
+// unpaused), keeping its endpoint association, and returns errCrioContainerUnknown if the container isn't added yet
+func (dm *KubeArmorDaemon) restartCrioContainer(ctx context.Context, containerID string) error {
+	crio := dm.getCrio()
+	if crio == nil {
+		return errRuntimeUnreachable
+	}
+
+	info, err := crio.GetContainerInfo(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	if info.ContainerID == "" {
+		return errNoCrioContainerInfo
+	}
+
+	return dm.restartContainer(containerID, info)
+}
+
+// getCrio Function returns the handler of CRI-O, which is replaced when CRI-O is re-dialed (nil if not monitored)
+func (dm *KubeArmorDaemon) getCrio() *CrioHandler {
+	dm.crioLock.RLock()
+	defer dm.crioLock.RUnlock()
+
+	return dm.crio
+}
+
+// setCrio Function replaces the handler of CRI-O
+func (dm *KubeArmorDaemon) setCrio(ch *CrioHandler) {
+	dm.crioLock.Lock()
+	defer dm.crioLock.Unlock()
+
+	dm.crio = ch
+}
+
+// UpdateCrioContainer Function
+func (dm *KubeArmorDaemon) UpdateCrioContainer(ctx context.Context, containerID, action string) bool {
+	if dm.getCrio() == nil {
+		return false
+	}
+
+	if action == "start" {
+		return dm.startCrioContainer(ctx, containerID) == nil
+	} else if action == "restart" || action == "update" {
+		return dm.restartCrioContainer(ctx, containerID) == nil
+	} else if action == "destroy" {
+		if _, ok := dm.removeContainer(containerID); !ok {
 			return false
 		}
-		if !dm.K8sEnabled {
-			dm.EndPointsLock.Lock()
-			dm.MatchandRemoveContainerFromEndpoint(containerID)
-			dm.EndPointsLock.Unlock()
+
+		dm.Logger.Printf("Detected a container (removed/%.12s)", containerID)
+	}
+
+	return true
+}
+
+// syncCrioContainers Function lists the containers, and starts the new ones and destroys the deleted ones. The
+// snapshot of the containers only advances for the ones processed, and the others are processed by the next listing.
+// It returns whether the listing observed new or deleted containers.
+func (dm *KubeArmorDaemon) syncCrioContainers(ctx context.Context) (bool, error) {
+	containers, err := dm.crio.GetCrioContainers(ctx)
+	dm.crio.health.recordList(err)
+	if err != nil {
+		return false, err
+	}
+	defer func() { dm.crio.health.setContainers(dm.crio.NumContainers()) }()
+
+	newContainers := dm.crio.GetNewCrioContainers(containers)
+	deletedContainers := dm.crio.GetDeletedCrioContainers(containers)
+
+	changed := len(newContainers) > 0 || len(deletedContainers) > 0
+
+	for containerID := range newContainers {
+		// stopped, the others are processed by the next listing
+		if ctx.Err() != nil {
+			return changed, ctx.Err()
 		}
-		delete(dm.Containers, containerID)
-		dm.ContainersLock.Unlock()
 
-		dm.EndPointsLock.Lock()
-		for idx, endPoint := range dm.EndPoints {
-			if endPoint.NamespaceName == container.NamespaceName && endPoint.EndPointName == container.EndPointName && kl.ContainsElement(endPoint.Containers, container.ContainerID) {
-
-				// update apparmor profiles
-				for idxA, profile := range endPoint.AppArmorProfiles {
-					if profile == container.AppArmorProfile {
-						dm.EndPoints[idx].AppArmorProfiles = append(dm.EndPoints[idx].AppArmorProfiles[:idxA], dm.EndPoints[idx].AppArmorProfiles[idxA+1:]...)
-						break
-					}
+		// the containers failed to be added are retried with backoff, or by the next listing
+		if err := dm.startCrioContainer(ctx, containerID); err != nil && !errors.Is(err, errCrioContainerKnown) {
+			if !dm.crio.ScheduleRetry(containerID, err) {
+				continue
+			}
+		}
+
+		dm.crio.trackContainer(containerID)
+	}
+
+	// the initial listing is done
+	if !dm.crio.listed {
+		dm.finalizeNsMapAdoption()
+		dm.crio.listed = true
+	}
+
+	// the containers restarted in place keep their IDs, and are found by their processes (the stopped ones can't
+	// be refreshed until they're started again)
+	for _, containerID := range dm.restartedContainers(RuntimeCrio, dm.crio.HasContainer) {
+		if err := dm.restartCrioContainer(ctx, containerID); err != nil && !errors.Is(err, errCrioContainerUnknown) {
+			dm.Logger.Debugf("Failed to refresh a restarted container (%.12s, %s)", containerID, err.Error())
+		}
+	}
+
+	for containerID := range deletedContainers {
+		dm.crio.ForgetRetry(containerID)
+
+		if err := destroyContainer(dm, containerID); err != nil {
+			dm.Logger.Warnf("Failed to remove a container (%.12s, %s)", containerID, err.Error())
+			continue
+		}
+
+		dm.crio.forgetContainer(containerID)
+	}
+
+	if time.Since(dm.crio.audited) >= containerAuditInterval {
+		dm.crio.audited = time.Now()
+
+		// the containers still tracked aren't leaked
+		tracked := make(map[string]struct{})
+		for containerID := range containers {
+			tracked[containerID] = struct{}{}
+		}
+		for containerID := range dm.crio.Snapshot() {
+			tracked[containerID] = struct{}{}
+		}
+
+		dm.auditContainers(tracked)
+	}
+
+	return changed, nil
+}
+
+// retryCrioContainers Function adds the containers whose retries are due
+func (dm *KubeArmorDaemon) retryCrioContainers(ctx context.Context) {
+	for _, containerID := range dm.crio.DueRetries(time.Now()) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := dm.startCrioContainer(ctx, containerID)
+		if err == nil || errors.Is(err, errCrioContainerKnown) {
+			dm.crio.ForgetRetry(containerID)
+			continue
+		}
+
+		dm.crio.ScheduleRetry(containerID, err)
+	}
+}
+
+// handleCrioEvent Function
+func (dm *KubeArmorDaemon) handleCrioEvent(ctx context.Context, event *pb.ContainerEventResponse) {
+	containerID := event.ContainerId
+	defer func() { dm.crio.health.setContainers(dm.crio.NumContainers()) }()
+
+	switch event.ContainerEventType {
+	case pb.ContainerEventType_CONTAINER_STARTED_EVENT:
+		// the container is restarted in place, with a new pid and new namespaces
+		if dm.crio.HasContainer(containerID) {
+			if err := dm.restartCrioContainer(ctx, containerID); err != nil && !errors.Is(err, errCrioContainerUnknown) {
+				dm.Logger.Warnf("Failed to refresh a restarted container (%.12s, %s)", containerID, err.Error())
+			}
+			return
+		}
+
+		// the container failed to be added is retried with backoff, or by the next reconciliation
+		err := dm.startCrioContainer(ctx, containerID)
+		if err == nil || errors.Is(err, errCrioContainerKnown) || dm.crio.ScheduleRetry(containerID, err) {
+			dm.crio.trackContainer(containerID)
+		}
+
+	case pb.ContainerEventType_CONTAINER_DELETED_EVENT:
+		if !dm.crio.HasContainer(containerID) {
+			return
+		}
+
+		dm.crio.ForgetRetry(containerID)
+
+		// the container failed to be removed is removed by the next reconciliation
+		if err := destroyContainer(dm, containerID); err != nil {
+			dm.Logger.Warnf("Failed to remove a container (%.12s, %s)", containerID, err.Error())
+			return
+		}
+
+		dm.crio.forgetContainer(containerID)
+	}
+}
+
+// consumeCrioEvents Function handles the events and reconciles the containers periodically until the stream ends,
+// and returns its error (nil if KubeArmor is stopped)
+func (dm *KubeArmorDaemon) consumeCrioEvents(ctx context.Context, events <-chan *pb.ContainerEventResponse, errs <-chan error, resync <-chan time.Time) error {
+	retry := time.NewTicker(crioRetryBackoff)
+	defer retry.Stop()
+
+	for {
+		select {
+		case <-StopChan:
+			return nil
+
+		case err := <-errs:
+			return err
+
+		case event := <-events:
+			dm.handleCrioEvent(ctx, event)
+
+		case <-resync:
+			if _, err := dm.syncCrioContainers(ctx); err != nil {
+				dm.Logger.Warnf("Failed to reconcile CRI-O containers (%s)", err.Error())
+			}
+
+		case <-retry.C:
+			dm.retryCrioContainers(ctx)
+		}
+	}
+}
+
+// isConnectionLost Function checks if a failed call lost the connection to CRI-O (e.g. restarted), with a Version
+// call as the dropped streams are Unavailable as well
+func (ch *CrioHandler) isConnectionLost(err error) bool {
+	if status.Code(err) != codes.Unavailable && grpcConnected(ch.conn) {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), crioProbeTimeout)
+	defer cancel()
+
+	_, err = ch.client.Version(ctx, &pb.VersionRequest{})
+	return err != nil
+}
+
+// watchCrioEvents Function keeps track of the containers with the event stream of CRI-O, which is re-synced with the
+// listing on every (re)connection. It returns nil once stopped, errCrioEventsUnimplemented if CRI-O doesn't implement
+// the stream, and the error of the call otherwise if the connection to CRI-O is lost.
+func (dm *KubeArmorDaemon) watchCrioEvents(daemonCtx context.Context) error {
+	resync := time.NewTicker(crioResyncInterval)
+	defer resync.Stop()
+
+	for {
+		ctx, cancel := context.WithCancel(daemonCtx)
+
+		events := make(chan *pb.ContainerEventResponse, 64)
+		errs := make(chan error, 1)
+
+		go func() {
+			errs <- dm.crio.WatchContainerEvents(ctx, events)
+		}()
+
+		// the containers started or deleted while disconnected
+		if _, err := dm.syncCrioContainers(daemonCtx); err != nil {
+			if daemonCtx.Err() == nil && dm.crio.isConnectionLost(err) {
+				cancel()
+				return err
+			}
+			dm.Logger.Warnf("Failed to list CRI-O containers (%s)", err.Error())
+		}
+
+		err := dm.consumeCrioEvents(daemonCtx, events, errs, resync.C)
+		cancel()
+
+		// stopped while a call was in flight
+		if err == nil || daemonCtx.Err() != nil {
+			return nil
+		}
+
+		if status.Code(err) == codes.Unimplemented {
+			return errCrioEventsUnimplemented
+		}
+
+		if dm.crio.isConnectionLost(err) {
+			return err
+		}
+
+		dm.Logger.Warnf("Lost the CRI-O event stream, reconnecting (%s)", err.Error())
+
+		select {
+		case <-StopChan:
+			return nil
+		case <-time.After(crioReconnectDelay):
+		}
+	}
+}
+
+// pollCrioContainers Function lists the containers periodically, for the runtimes without the event stream, backing
+// off while they don't change. It returns nil once stopped, and the error of the listing if the connection to CRI-O
+// is lost.
+func (dm *KubeArmorDaemon) pollCrioContainers(ctx context.Context) error {
+	poller := newRuntimePoller()
+	failing := false
+
+	for {
+		changed := false
+
+		select {
+		case <-StopChan:
+			return nil
+
+		default:
+			var err error
+			if changed, err = dm.syncCrioContainers(ctx); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				if dm.crio.isConnectionLost(err) {
+					return err
 				}
 
+				// warned once until the listing succeeds again
+				if !failing {
+					dm.Logger.Warnf("Failed to list CRI-O containers (%s)", err.Error())
+				}
+				failing = true
 				break
 			}
+			failing = false
+
+			dm.retryCrioContainers(ctx)
 		}
-		dm.EndPointsLock.Unlock()
 
-		if dm.SystemMonitor != nil && cfg.GlobalCfg.Policy {
-			// update NsMap
-			dm.SystemMonitor.DeleteContainerIDFromNsMap(containerID, container.NamespaceName, container.PidNS, container.MntNS)
-			dm.RuntimeEnforcer.UnregisterContainer(containerID)
+		select {
+		case <-StopChan:
+			return nil
+		case <-time.After(poller.next(changed)):
+		}
+	}
+}
+
+// redialCrio Function closes the connection lost to CRI-O and connects again with backoff. The containers of the
+// daemon are reconciled with the fresh listing afterwards, for the ones started or deleted while disconnected.
+// It returns false if KubeArmor is stopped before reconnecting.
+func (dm *KubeArmorDaemon) redialCrio(cause error) bool {
+	lost := dm.crio
+	lost.health.recordList(cause)
+	lost.Close()
+
+	dm.Logger.Warnf("Lost the connection to CRI-O, reconnecting (%s)", cause.Error())
+	dm.reportRuntimeMonitoring(RuntimeCrio, lost.health.socket, cause)
+
+	backoff := crioRedialBackoff
+
+	for {
+		select {
+		case <-StopChan:
+			return false
+		case <-time.After(backoff):
 		}
 
-		dm.Logger.Printf("Detected a container (removed/%.12s)", containerID)
+		if ch, err := NewCrioHandler(); err != nil {
+			dm.Logger.Warnf("Failed to reconnect to CRI-O at %s (%s)", lost.health.socket, err.Error())
+		} else {
+			// the state of the lost connection is kept
+			ch.health = lost.health
+			ch.retries, ch.retriesLock = lost.retries, lost.retriesLock
+			ch.listed = lost.listed
+
+			// the containers of the daemon added from the runtime, to be diffed with the fresh listing
+			dm.ContainersLock.RLock()
+			for containerID, container := range dm.Containers {
+				if container.PidNS != 0 || container.MntNS != 0 {
+					ch.trackContainer(containerID)
+				}
+			}
+			dm.ContainersLock.RUnlock()
+
+			dm.setCrio(ch)
+			break
+		}
+
+		backoff *= 2
+		if backoff > crioRedialMaxBackoff {
+			backoff = crioRedialMaxBackoff
+		}
 	}
 
+	dm.trackRuntimeHealth(RuntimeCrio, dm.crio.Health)
+
+	dm.Logger.Print("Reconnected to CRI-O")
+	dm.reportRuntimeMonitoring(RuntimeCrio, dm.crio.health.socket, nil)
+
 	return true
 }
 
@@ -316,50 +998,60 @@ func (dm *KubeArmorDaemon) MonitorCrioEvents() {
 	dm.WgDaemon.Add(1)
 	defer dm.WgDaemon.Done()
 
-	Crio = NewCrioHandler()
+	ch, err := NewCrioHandler()
 
-	// check if Crio exists
-	if Crio == nil {
-		return
-	}
+	// CRI-O might not be up yet (e.g. both restarted with the node), and is connected again with backoff,
+	// unless it doesn't serve the v1 runtime API
+	backoff := crioRedialBackoff
 
-	dm.Logger.Print("Started to monitor CRI-O events")
+	for ch == nil {
+		dm.trackRuntimeHealth(RuntimeCrio, unreachableRuntime(RuntimeCrio, cfg.GlobalCfg.CRISocket, errRuntimeUnreachable))
+
+		if errors.Is(err, errCrioV1Unimplemented) {
+			dm.Logger.Errf("CRI-O doesn't serve the v1 runtime API at %s (%s)", cfg.GlobalCfg.CRISocket, err.Error())
+			return
+		}
+
+		dm.Logger.Warnf("Failed to connect to CRI-O at %s, retrying in %s (%s)", cfg.GlobalCfg.CRISocket, backoff, err.Error())
 
-	for {
 		select {
 		case <-StopChan:
 			return
+		case <-time.After(backoff):
+		}
 
-		default:
-			containers, err := Crio.GetCrioContainers()
-			if err != nil {
-				return
-			}
+		backoff *= 2
+		if backoff > crioRedialMaxBackoff {
+			backoff = crioRedialMaxBackoff
+		}
 
-			invalidContainers := []string{}
+		ch, err = NewCrioHandler()
+	}
 
-			newContainers := Crio.GetNewCrioContainers(containers)
-			deletedContainers := Crio.GetDeletedCrioContainers(containers)
+	dm.setCrio(ch)
+	dm.trackRuntimeHealth(RuntimeCrio, dm.crio.Health)
 
-			if len(newContainers) > 0 {
-				for containerID := range newContainers {
-					if !dm.UpdateCrioContainer(context.Background(), containerID, "start") {
-						invalidContainers = append(invalidContainers, containerID)
-					}
-				}
-			}
+	dm.Logger.Print("Started to monitor CRI-O events")
 
-			for _, invalidContainerID := range invalidContainers {
-				delete(Crio.containers, invalidContainerID)
-			}
+	// the calls to CRI-O in flight are cancelled when KubeArmor is stopped
+	ctx, cancel := stopContext()
+	defer cancel()
 
-			if len(deletedContainers) > 0 {
-				for containerID := range deletedContainers {
-					dm.UpdateCrioContainer(context.Background(), containerID, "destroy")
-				}
-			}
+	for {
+		err := dm.watchCrioEvents(ctx)
+		if errors.Is(err, errCrioEventsUnimplemented) {
+			dm.Logger.Print("CRI-O doesn't implement the event stream, listing the containers periodically instead")
+			err = dm.pollCrioContainers(ctx)
 		}
 
-		time.Sleep(time.Millisecond * 50)
+		// stopped
+		if err == nil {
+			return
+		}
+
+		// the connection is lost, and re-created to go on
+		if !dm.redialCrio(err) {
+			return
+		}
 	}
 }
@@ -0,0 +1,1040 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	"github.com/kubearmor/KubeArmor/KubeArmor/monitor"
+	"github.com/kubearmor/KubeArmor/KubeArmor/testutil"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// waitFor polls the condition until it holds or the timeout expires
+func waitFor(t *testing.T, what string, cond func() bool) {
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("[FAIL] Timed out waiting for %s", what)
+}
+
+// newCrioTestDaemon creates a daemon with a system monitor without eBPF
+func newCrioTestDaemon() *KubeArmorDaemon {
+	dm := NewKubeArmorDaemon()
+	dm.K8sEnabled = true
+
+	dm.Logger = &fd.Feeder{Node: &dm.Node}
+
+	dm.SystemMonitor = &monitor.SystemMonitor{
+		NsMap:            map[monitor.NsKey]string{},
+		NsMapLock:        new(sync.RWMutex),
+		NamespacePidsMap: map[string]monitor.NsVisibility{},
+		BpfMapLock:       new(sync.RWMutex),
+		Logger:           dm.Logger,
+	}
+
+	return dm
+}
+
+func TestNewCrioHandler(t *testing.T) {
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+
+	ch, err := NewCrioHandler()
+	if err != nil {
+		t.Fatalf("[FAIL] Expected a handler for the runtime serving v1 (%s)", err.Error())
+	}
+	ch.Close()
+
+	// a runtime serving only v1alpha2
+	fake.SetFault("Version", testutil.Fault{Err: status.Error(codes.Unimplemented, "unknown service runtime.v1.RuntimeService")})
+
+	if ch, err := NewCrioHandler(); ch != nil || !errors.Is(err, errCrioV1Unimplemented) {
+		if ch != nil {
+			ch.Close()
+		}
+		t.Errorf("[FAIL] Expected no handler for the runtime not serving v1 (%v)", err)
+	}
+
+	// a runtime which can't be reached isn't mistaken for one not serving v1
+	fake.SetFault("Version", testutil.Fault{Err: status.Error(codes.Unavailable, "connection refused")})
+
+	if ch, err := NewCrioHandler(); ch != nil || err == nil || errors.Is(err, errCrioV1Unimplemented) {
+		if ch != nil {
+			ch.Close()
+		}
+		t.Errorf("[FAIL] Expected a connection failure for the unreachable runtime (%v)", err)
+	}
+
+	t.Log("[PASS] Checked the runtime API on connect")
+}
+
+func TestMonitorCrioEvents(t *testing.T) {
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = true
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	dm := newCrioTestDaemon()
+
+	inContainers := func(containerID string) bool {
+		dm.ContainersLock.RLock()
+		defer dm.ContainersLock.RUnlock()
+		_, ok := dm.Containers[containerID]
+		return ok
+	}
+
+	inNsMap := func(containerID string) bool {
+		dm.SystemMonitor.NsMapLock.RLock()
+		defer dm.SystemMonitor.NsMapLock.RUnlock()
+		for _, id := range dm.SystemMonitor.NsMap {
+			if id == containerID {
+				return true
+			}
+		}
+		return false
+	}
+
+	StopChan = make(chan struct{})
+	go dm.MonitorCrioEvents()
+
+	// the namespaces of the test process stand in for the ones of a container
+	fake.AddContainer(testutil.FakeContainer{
+		ID:              "nginx",
+		Name:            "nginx",
+		Namespace:       "default",
+		PodName:         "nginx-pod",
+		Pid:             os.Getpid(),
+		AppArmorProfile: "kubearmor-default-nginx",
+	})
+
+	waitFor(t, "the container to be added", func() bool {
+		return inContainers("nginx") && inNsMap("nginx")
+	})
+
+	dm.ContainersLock.RLock()
+	container := dm.Containers["nginx"]
+	dm.ContainersLock.RUnlock()
+
+	if container.NamespaceName != "default" || container.EndPointName != "nginx-pod" || container.AppArmorProfile != "kubearmor-default-nginx" {
+		t.Errorf("[FAIL] Unexpected container info (%+v)", container)
+	}
+	if container.PidNS == 0 || container.MntNS == 0 {
+		t.Errorf("[FAIL] Expected the namespaces of the container")
+	}
+
+	// a malformed info payload is not accepted, but retried
+	fake.SetFault("ContainerStatus", testutil.Fault{MalformedInfo: true})
+	fake.AddContainer(testutil.FakeContainer{
+		ID:        "redis",
+		Name:      "redis",
+		Namespace: "default",
+		PodName:   "redis-pod",
+		Pid:       os.Getpid(),
+	})
+
+	time.Sleep(200 * time.Millisecond)
+	if inContainers("redis") {
+		t.Errorf("[FAIL] Expected the container with a malformed info to be skipped")
+	}
+
+	fake.ClearFault("ContainerStatus")
+	waitFor(t, "the container to be retried", func() bool {
+		return inContainers("redis")
+	})
+
+	// deleted containers are removed from both maps
+	fake.DeleteContainer("nginx")
+	waitFor(t, "the container to be removed", func() bool {
+		return !inContainers("nginx")
+	})
+
+	fake.DeleteContainer("redis")
+	waitFor(t, "the namespaces to be removed", func() bool {
+		return !inContainers("redis") && !inNsMap("nginx") && !inNsMap("redis")
+	})
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	// the fake runtime doesn't implement the event stream, so it was asked once before falling back to polling
+	if calls := fake.Calls("GetContainerEvents"); calls != 1 {
+		t.Errorf("[FAIL] Expected the event stream to be asked once (%d)", calls)
+	}
+
+	t.Log("[PASS] Monitored CRI-O events")
+}
+
+func TestCrioEventStream(t *testing.T) {
+	prevResync, prevReconnect := crioResyncInterval, crioReconnectDelay
+	defer func() {
+		crioResyncInterval, crioReconnectDelay = prevResync, prevReconnect
+	}()
+
+	// the containers are kept track of by the events alone
+	crioResyncInterval = time.Hour
+	crioReconnectDelay = 500 * time.Millisecond
+
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	fake.EnableEvents()
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = true
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	dm := newCrioTestDaemon()
+
+	inContainers := func(containerID string) bool {
+		dm.ContainersLock.RLock()
+		defer dm.ContainersLock.RUnlock()
+		_, ok := dm.Containers[containerID]
+		return ok
+	}
+
+	StopChan = make(chan struct{})
+	go dm.MonitorCrioEvents()
+
+	waitFor(t, "the event stream to be connected", func() bool {
+		return fake.EventStreams() == 1
+	})
+
+	fake.AddContainer(testutil.FakeContainer{
+		ID:        "nginx",
+		Name:      "nginx",
+		Namespace: "default",
+		PodName:   "nginx-pod",
+		Pid:       os.Getpid(),
+	})
+
+	waitFor(t, "the container to be added", func() bool {
+		return inContainers("nginx")
+	})
+
+	// no polling while the stream is connected
+	listCalls := fake.Calls("ListContainers")
+	time.Sleep(200 * time.Millisecond)
+	if calls := fake.Calls("ListContainers"); calls != listCalls {
+		t.Errorf("[FAIL] Expected the containers not to be polled (%d calls, %d before)", calls, listCalls)
+	}
+
+	// the runtime restarts, and the events until reconnected are missed
+	fake.DropEventStreams()
+
+	fake.AddContainer(testutil.FakeContainer{
+		ID:        "redis",
+		Name:      "redis",
+		Namespace: "default",
+		PodName:   "redis-pod",
+		Pid:       os.Getpid(),
+	})
+	fake.DeleteContainer("nginx")
+
+	time.Sleep(200 * time.Millisecond)
+	if inContainers("redis") || !inContainers("nginx") {
+		t.Errorf("[FAIL] Expected the events to be missed while disconnected")
+	}
+
+	// the listing is re-synced once reconnected
+	waitFor(t, "the containers to be re-synced", func() bool {
+		return inContainers("redis") && !inContainers("nginx")
+	})
+
+	// the events are received again
+	fake.DeleteContainer("redis")
+	waitFor(t, "the container to be removed", func() bool {
+		return !inContainers("redis")
+	})
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	t.Log("[PASS] Kept track of CRI-O containers with the event stream")
+}
+
+func TestCrioContainerBeforePod(t *testing.T) {
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = false
+
+	dm := newCrioTestDaemon()
+
+	isPending := func(containerID string) bool {
+		dm.EndPointsLock.RLock()
+		defer dm.EndPointsLock.RUnlock()
+		_, ok := dm.PendingAttach[containerID]
+		return ok
+	}
+
+	// the watcher has seen the container in the pod status, but hasn't created the endpoint yet
+	dm.Containers["nginx"] = tp.Container{ContainerID: "nginx", NamespaceName: "default", EndPointName: "nginx-pod"}
+
+	StopChan = make(chan struct{})
+	go dm.MonitorCrioEvents()
+
+	fake.AddContainer(testutil.FakeContainer{
+		ID:              "nginx",
+		Name:            "nginx",
+		Namespace:       "default",
+		PodName:         "nginx-pod",
+		Pid:             os.Getpid(),
+		AppArmorProfile: "kubearmor-default-nginx",
+	})
+
+	waitFor(t, "the container to be pending", func() bool {
+		return isPending("nginx")
+	})
+
+	// the endpoint is created afterwards
+	dm.UpdateEndPointWithPod("ADDED", tp.K8sPod{
+		Metadata:    map[string]string{"namespaceName": "default", "podName": "nginx-pod"},
+		Annotations: map[string]string{"kubearmor-policy": "enabled"},
+		Labels:      map[string]string{"app": "nginx"},
+		Containers:  map[string]string{"nginx": "nginx"},
+	})
+
+	dm.EndPointsLock.RLock()
+	if len(dm.EndPoints) != 1 || !kl.ContainsElement(dm.EndPoints[0].Containers, "nginx") || !kl.ContainsElement(dm.EndPoints[0].AppArmorProfiles, "kubearmor-default-nginx") {
+		t.Errorf("[FAIL] Expected the container in its endpoint (%+v)", dm.EndPoints)
+	}
+	dm.EndPointsLock.RUnlock()
+
+	if isPending("nginx") {
+		t.Errorf("[FAIL] Expected the container not to be pending anymore")
+	}
+
+	// pending containers are forgotten once destroyed
+	dm.EndPointsLock.Lock()
+	dm.PendingAttach["redis"] = tp.Container{ContainerID: "redis"}
+	dm.EndPointsLock.Unlock()
+
+	dm.ContainersLock.Lock()
+	dm.Containers["redis"] = tp.Container{ContainerID: "redis", PidNS: 1, MntNS: 1}
+	dm.ContainersLock.Unlock()
+
+	dm.UpdateCrioContainer(context.Background(), "redis", "destroy")
+
+	if isPending("redis") {
+		t.Errorf("[FAIL] Expected the destroyed container not to be pending")
+	}
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	t.Log("[PASS] Attached a container started before its endpoint")
+}
+
+func TestCrioContainerRetry(t *testing.T) {
+	prevWindow := cfg.GlobalCfg.ContainerRetryWindow
+	defer func() {
+		cfg.GlobalCfg.ContainerRetryWindow = prevWindow
+	}()
+	cfg.GlobalCfg.ContainerRetryWindow = 5 * time.Second
+
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = false
+
+	dm := newCrioTestDaemon()
+
+	getRetry := func(containerID string) (tp.ContainerRetry, bool) {
+		for _, retry := range dm.GetContainerRetries() {
+			if retry.ContainerID == containerID {
+				return retry, true
+			}
+		}
+		return tp.ContainerRetry{}, false
+	}
+
+	inContainers := func(containerID string) bool {
+		dm.ContainersLock.RLock()
+		defer dm.ContainersLock.RUnlock()
+		_, ok := dm.Containers[containerID]
+		return ok
+	}
+
+	StopChan = make(chan struct{})
+	go dm.MonitorCrioEvents()
+
+	// the daemon restarts on a busy node, and the status of the existing container can't be read yet
+	fake.SetFault("ContainerStatus", testutil.Fault{Err: status.Error(codes.Unavailable, "not ready")})
+	fake.AddContainer(testutil.FakeContainer{
+		ID:              "nginx",
+		Name:            "nginx",
+		Namespace:       "default",
+		PodName:         "nginx-pod",
+		Pid:             os.Getpid(),
+		AppArmorProfile: "kubearmor-default-nginx",
+	})
+
+	waitFor(t, "the container to be retried", func() bool {
+		retry, ok := getRetry("nginx")
+		return ok && retry.Attempts >= 3
+	})
+
+	// retried with backoff rather than on every listing (every 50ms)
+	time.Sleep(time.Second)
+	if retry, _ := getRetry("nginx"); retry.Attempts > 7 || retry.LastError == "" || retry.GaveUp {
+		t.Errorf("[FAIL] Unexpected retries of the container (%+v)", retry)
+	}
+
+	// the endpoint arrives after the container event
+	dm.UpdateEndPointWithPod("ADDED", tp.K8sPod{
+		Metadata:    map[string]string{"namespaceName": "default", "podName": "nginx-pod"},
+		Annotations: map[string]string{"kubearmor-policy": "enabled"},
+		Labels:      map[string]string{"app": "nginx"},
+		Containers:  map[string]string{"nginx": "nginx"},
+	})
+
+	fake.ClearFault("ContainerStatus")
+	waitFor(t, "the container to be added", func() bool {
+		dm.ContainersLock.RLock()
+		defer dm.ContainersLock.RUnlock()
+		return dm.Containers["nginx"].MntNS != 0
+	})
+
+	dm.EndPointsLock.RLock()
+	if len(dm.EndPoints) != 1 || !kl.ContainsElement(dm.EndPoints[0].AppArmorProfiles, "kubearmor-default-nginx") {
+		t.Errorf("[FAIL] Expected the profile of the container in its endpoint (%+v)", dm.EndPoints)
+	}
+	dm.EndPointsLock.RUnlock()
+
+	if _, ok := getRetry("nginx"); ok {
+		t.Errorf("[FAIL] Expected the retry of the added container to be forgotten")
+	}
+
+	// the container failing over the retry window is given up, and shown as stuck
+	cfg.GlobalCfg.ContainerRetryWindow = 300 * time.Millisecond
+
+	fake.SetFault("ContainerStatus", testutil.Fault{Err: status.Error(codes.Unavailable, "not ready")})
+	fake.AddContainer(testutil.FakeContainer{
+		ID:        "redis",
+		Name:      "redis",
+		Namespace: "default",
+		PodName:   "redis-pod",
+		Pid:       os.Getpid(),
+	})
+
+	waitFor(t, "the container to be given up", func() bool {
+		retry, ok := getRetry("redis")
+		return ok && retry.GaveUp
+	})
+
+	fake.ClearFault("ContainerStatus")
+	time.Sleep(300 * time.Millisecond)
+	if inContainers("redis") {
+		t.Errorf("[FAIL] Expected the given up container not to be retried")
+	}
+
+	// the retry states are forgotten with the containers
+	fake.DeleteContainer("redis")
+	waitFor(t, "the retry to be forgotten", func() bool {
+		_, ok := getRetry("redis")
+		return !ok
+	})
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	t.Log("[PASS] Retried the containers added before their endpoints")
+}
+
+func TestCrioExitedContainerGC(t *testing.T) {
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	dm := newCrioTestDaemon()
+
+	// the policies of the endpoint are not under test
+	cfg.GlobalCfg.Policy = false
+	dm.UpdateEndPointWithPod("ADDED", tp.K8sPod{
+		Metadata:    map[string]string{"namespaceName": "default", "podName": "nginx-pod"},
+		Annotations: map[string]string{"kubearmor-policy": "enabled"},
+		Labels:      map[string]string{"app": "nginx"},
+		Containers:  map[string]string{"nginx-1": "nginx"},
+	})
+	cfg.GlobalCfg.Policy = true
+
+	StopChan = make(chan struct{})
+	go dm.MonitorCrioEvents()
+
+	// the exited instance and its replacement, with the same name in the same pod, before
+	// the pod status has been updated (the namespaces of the test process stand in for the ones of both)
+	for _, containerID := range []string{"nginx-1", "nginx-2"} {
+		fake.AddContainer(testutil.FakeContainer{
+			ID:              containerID,
+			Name:            "nginx",
+			Namespace:       "default",
+			PodName:         "nginx-pod",
+			Pid:             os.Getpid(),
+			AppArmorProfile: "kubearmor-default-nginx",
+		})
+
+		containerID := containerID
+		waitFor(t, "the container to be detected", func() bool {
+			dm.ContainersLock.RLock()
+			defer dm.ContainersLock.RUnlock()
+			return dm.Containers[containerID].MntNS != 0
+		})
+	}
+
+	// the kubelet garbage-collects the exited instance
+	fake.DeleteContainer("nginx-1")
+	waitFor(t, "the exited instance to be removed", func() bool {
+		dm.ContainersLock.RLock()
+		defer dm.ContainersLock.RUnlock()
+		_, ok := dm.Containers["nginx-1"]
+		return !ok
+	})
+
+	dm.EndPointsLock.RLock()
+	if len(dm.EndPoints) != 1 || !kl.ContainsElement(dm.EndPoints[0].AppArmorProfiles, "kubearmor-default-nginx") {
+		t.Errorf("[FAIL] Expected the profile to be kept for the running instance (%+v)", dm.EndPoints)
+	}
+	dm.EndPointsLock.RUnlock()
+
+	dm.SystemMonitor.NsMapLock.RLock()
+	if len(dm.SystemMonitor.NsMap) != 1 {
+		t.Errorf("[FAIL] Expected the namespaces of the running instance to be kept (%+v)", dm.SystemMonitor.NsMap)
+	}
+	for _, containerID := range dm.SystemMonitor.NsMap {
+		if containerID != "nginx-2" {
+			t.Errorf("[FAIL] Expected the namespaces to belong to the running instance (%s)", containerID)
+		}
+	}
+	dm.SystemMonitor.NsMapLock.RUnlock()
+
+	// the profile is removed with the last instance
+	cfg.GlobalCfg.Policy = false
+	dm.UpdateEndPointWithPod("MODIFIED", tp.K8sPod{
+		Metadata:    map[string]string{"namespaceName": "default", "podName": "nginx-pod"},
+		Annotations: map[string]string{"kubearmor-policy": "enabled"},
+		Labels:      map[string]string{"app": "nginx"},
+		Containers:  map[string]string{"nginx-2": "nginx"},
+	})
+	cfg.GlobalCfg.Policy = true
+
+	fake.DeleteContainer("nginx-2")
+	waitFor(t, "the running instance to be removed", func() bool {
+		dm.EndPointsLock.RLock()
+		defer dm.EndPointsLock.RUnlock()
+		for _, endPoint := range dm.EndPoints {
+			if kl.ContainsElement(endPoint.Containers, "nginx-2") && kl.ContainsElement(endPoint.AppArmorProfiles, "kubearmor-default-nginx") {
+				return false
+			}
+		}
+		return true
+	})
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	t.Log("[PASS] Kept the enforcement of a pod while its exited container was garbage-collected")
+}
+
+func TestMultipleDaemons(t *testing.T) {
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = true
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	// two daemons in one process, each with its own runtime handler
+	daemons := []*KubeArmorDaemon{newCrioTestDaemon(), newCrioTestDaemon()}
+
+	StopChan = make(chan struct{})
+	for _, dm := range daemons {
+		go dm.MonitorCrioEvents()
+	}
+
+	fake.AddContainer(testutil.FakeContainer{
+		ID:        "nginx",
+		Name:      "nginx",
+		Namespace: "default",
+		PodName:   "nginx-pod",
+		Pid:       os.Getpid(),
+	})
+
+	for idx, dm := range daemons {
+		dm := dm
+		waitFor(t, "daemon "+strconv.Itoa(idx)+" to add the container", func() bool {
+			dm.ContainersLock.RLock()
+			defer dm.ContainersLock.RUnlock()
+			_, ok := dm.Containers["nginx"]
+			return ok
+		})
+	}
+
+	close(StopChan)
+	for _, dm := range daemons {
+		dm.WgDaemon.Wait()
+	}
+
+	if len(daemons[0].RuntimeHandlers()) != 1 || daemons[0].crio == daemons[1].crio {
+		t.Errorf("[FAIL] Expected a runtime handler per daemon")
+	}
+
+	for _, dm := range daemons {
+		dm.CloseRuntimeHandlers()
+	}
+
+	t.Log("[PASS] Ran multiple daemons")
+}
+
+func TestCrioHungRuntime(t *testing.T) {
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	prevTimeout := cfg.GlobalCfg.CRIRequestTimeout
+	defer func() {
+		cfg.GlobalCfg.CRIRequestTimeout = prevTimeout
+	}()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = true
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	// a runtime which never replies to the listing and the status of the containers
+	fake.SetFault("ListContainers", testutil.Fault{Delay: time.Hour})
+	fake.SetFault("ContainerStatus", testutil.Fault{Delay: time.Hour})
+
+	// the calls time out
+	cfg.GlobalCfg.CRIRequestTimeout = 200 * time.Millisecond
+
+	ch, err := NewCrioHandler()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to connect to the fake CRI runtime (%s)", err.Error())
+	}
+
+	start := time.Now()
+	if _, err := ch.GetCrioContainers(context.Background()); status.Code(err) != codes.DeadlineExceeded || time.Since(start) > 2*time.Second {
+		t.Errorf("[FAIL] Expected the listing to time out (%v, %s)", err, time.Since(start))
+	}
+
+	start = time.Now()
+	if _, err := ch.GetContainerInfo(context.Background(), "nginx"); status.Code(err) != codes.DeadlineExceeded || time.Since(start) > 2*time.Second {
+		t.Errorf("[FAIL] Expected the status to time out (%v, %s)", err, time.Since(start))
+	}
+	ch.Close()
+
+	// the daemon exits while a call is in flight, before the call times out
+	cfg.GlobalCfg.CRIRequestTimeout = time.Minute
+
+	dm := newCrioTestDaemon()
+
+	listCalls := fake.Calls("ListContainers")
+
+	StopChan = make(chan struct{})
+	go dm.MonitorCrioEvents()
+
+	waitFor(t, "the listing to be in flight", func() bool {
+		return fake.Calls("ListContainers") > listCalls
+	})
+
+	stopped := make(chan struct{})
+	go func() {
+		close(StopChan)
+		dm.WgDaemon.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("[FAIL] Expected the daemon to exit while CRI-O doesn't reply")
+	}
+
+	dm.CloseRuntimeHandlers()
+
+	t.Log("[PASS] Exited while CRI-O doesn't reply")
+}
+
+func TestCrioContainerRestart(t *testing.T) {
+	// the namespaces of the instances before and after the restart
+	procDir := t.TempDir()
+	for pid, ns := range map[string]string{"1001": "4026531001", "1002": "4026531002"} {
+		if err := os.MkdirAll(procDir+"/"+pid+"/ns", 0750); err != nil {
+			t.Fatalf("[FAIL] Failed to create the fake procfs (%s)", err.Error())
+		}
+		if err := os.Symlink("pid:["+ns+"]", procDir+"/"+pid+"/ns/pid"); err != nil {
+			t.Fatalf("[FAIL] Failed to create the fake procfs (%s)", err.Error())
+		}
+		if err := os.Symlink("mnt:["+ns+"]", procDir+"/"+pid+"/ns/mnt"); err != nil {
+			t.Fatalf("[FAIL] Failed to create the fake procfs (%s)", err.Error())
+		}
+	}
+
+	kl.SetupProcFs(procDir, procDir)
+	defer kl.SetupProcFs("/proc", "/proc")
+
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	fake.EnableEvents()
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	dm := newCrioTestDaemon()
+
+	// the policies of the endpoint are not under test
+	cfg.GlobalCfg.Policy = false
+	dm.UpdateEndPointWithPod("ADDED", tp.K8sPod{
+		Metadata:    map[string]string{"namespaceName": "default", "podName": "nginx-pod"},
+		Annotations: map[string]string{"kubearmor-policy": "enabled"},
+		Labels:      map[string]string{"app": "nginx"},
+		Containers:  map[string]string{"nginx": "nginx"},
+	})
+	cfg.GlobalCfg.Policy = true
+
+	inNsMap := func(ns uint32) bool {
+		dm.SystemMonitor.NsMapLock.RLock()
+		defer dm.SystemMonitor.NsMapLock.RUnlock()
+		return dm.SystemMonitor.NsMap[monitor.NsKey{PidNS: ns, MntNS: ns}] == "nginx"
+	}
+
+	StopChan = make(chan struct{})
+	go dm.MonitorCrioEvents()
+
+	waitFor(t, "the event stream to be connected", func() bool {
+		return fake.EventStreams() == 1
+	})
+
+	fake.AddContainer(testutil.FakeContainer{
+		ID:        "nginx",
+		Name:      "nginx",
+		Namespace: "default",
+		PodName:   "nginx-pod",
+		Pid:       1001,
+	})
+
+	waitFor(t, "the container to be added", func() bool {
+		return inNsMap(4026531001)
+	})
+
+	// the container is restarted in place by the runtime
+	fake.RestartContainer("nginx", 1002)
+
+	waitFor(t, "the namespaces to be refreshed", func() bool {
+		return inNsMap(4026531002)
+	})
+
+	if inNsMap(4026531001) {
+		t.Errorf("[FAIL] Expected the namespaces of the exited instance to be removed")
+	}
+
+	dm.ContainersLock.RLock()
+	if container := dm.Containers["nginx"]; container.Pid != 1002 || container.EndPointName != "nginx-pod" {
+		t.Errorf("[FAIL] Unexpected container after the restart (%+v)", container)
+	}
+	dm.ContainersLock.RUnlock()
+
+	dm.EndPointsLock.RLock()
+	if len(dm.EndPoints) != 1 || !kl.ContainsElement(dm.EndPoints[0].Containers, "nginx") {
+		t.Errorf("[FAIL] Expected the container to be kept in its endpoint (%+v)", dm.EndPoints)
+	}
+	dm.EndPointsLock.RUnlock()
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	t.Log("[PASS] Refreshed the namespaces of a restarted container")
+}
+
+func TestCrioContainerImage(t *testing.T) {
+	const digest = "sha256:0d17b565c37bcbd895e9d92315a05c1c3c9a29f762b011a10c54a66cd53c9b31"
+	const imageID = "sha256:a8758716bb6aa4d90071160d27028fe4eaee7ce8166221a97d30440c8eac2be6"
+
+	for _, tc := range []struct {
+		name     string
+		res      *pb.ContainerStatusResponse
+		expected string
+	}{
+		{
+			"the image name and the repository digest",
+			&pb.ContainerStatusResponse{Status: &pb.ContainerStatus{Image: &pb.ImageSpec{Image: "nginx:1.25"}, ImageRef: "docker.io/library/nginx@" + digest}},
+			"docker.io/library/nginx:1.25@" + digest,
+		},
+		{
+			"the image name and a bare digest",
+			&pb.ContainerStatusResponse{Status: &pb.ContainerStatus{Image: &pb.ImageSpec{Image: "quay.io/org/app:v1"}, ImageRef: digest}},
+			"quay.io/org/app:v1@" + digest,
+		},
+		{
+			"the image ID and the repository digest",
+			&pb.ContainerStatusResponse{Status: &pb.ContainerStatus{Image: &pb.ImageSpec{Image: imageID}, ImageRef: "quay.io/org/app@" + digest}},
+			"quay.io/org/app@" + digest,
+		},
+		{
+			"the image ID with the image name in the runtime spec",
+			&pb.ContainerStatusResponse{
+				Status: &pb.ContainerStatus{Image: &pb.ImageSpec{Image: imageID}, ImageRef: digest},
+				Info:   map[string]string{"info": `{"runtimeSpec":{"annotations":{"io.kubernetes.cri-o.ImageName":"registry.example.com:5000/app:v2"}}}`},
+			},
+			"registry.example.com:5000/app:v2@" + digest,
+		},
+		{
+			"the image name only",
+			&pb.ContainerStatusResponse{Status: &pb.ContainerStatus{Image: &pb.ImageSpec{Image: "index.docker.io/library/redis"}}},
+			"docker.io/library/redis:latest",
+		},
+	} {
+		if tc.res.Info == nil {
+			tc.res.Info = map[string]string{"info": "{}"}
+		}
+
+		info, err := parseCrioContainerInfo(tc.res.Info)
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to parse the info of %s (%s)", tc.name, err.Error())
+		}
+
+		if image := crioContainerImage(tc.res.Status, info.RuntimeSpec.Annotations); image != tc.expected {
+			t.Errorf("[FAIL] Unexpected image for %s (%s)", tc.name, image)
+		}
+	}
+
+	t.Log("[PASS] Parsed the images of the CRI-O containers")
+}
+
+func TestCrioContainerRestartPolling(t *testing.T) {
+	procDir := t.TempDir()
+
+	// the namespaces and the start time of the instances of the container
+	startProcess := func(pid, ns, startTime string) {
+		if err := os.RemoveAll(procDir + "/" + pid); err != nil {
+			t.Fatalf("[FAIL] Failed to reset the fake procfs (%s)", err.Error())
+		}
+		if err := os.MkdirAll(procDir+"/"+pid+"/ns", 0750); err != nil {
+			t.Fatalf("[FAIL] Failed to create the fake procfs (%s)", err.Error())
+		}
+		if err := os.Symlink("pid:["+ns+"]", procDir+"/"+pid+"/ns/pid"); err != nil {
+			t.Fatalf("[FAIL] Failed to create the fake procfs (%s)", err.Error())
+		}
+		if err := os.Symlink("mnt:["+ns+"]", procDir+"/"+pid+"/ns/mnt"); err != nil {
+			t.Fatalf("[FAIL] Failed to create the fake procfs (%s)", err.Error())
+		}
+		stat := pid + " (nginx: master) S 1 " + pid + " " + pid + " 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 " + startTime + " 0 0\n"
+		if err := os.WriteFile(procDir+"/"+pid+"/stat", []byte(stat), 0600); err != nil {
+			t.Fatalf("[FAIL] Failed to create the fake procfs (%s)", err.Error())
+		}
+	}
+
+	startProcess("1001", "4026531001", "100")
+
+	kl.SetupProcFs(procDir, procDir)
+	defer kl.SetupProcFs("/proc", "/proc")
+
+	// the runtime without the event stream
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = true
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	dm := newCrioTestDaemon()
+
+	ch, err := NewCrioHandler()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to connect to the fake CRI runtime (%s)", err.Error())
+	}
+	dm.setCrio(ch)
+	defer dm.CloseRuntimeHandlers()
+
+	inNsMap := func(ns uint32) bool {
+		dm.SystemMonitor.NsMapLock.RLock()
+		defer dm.SystemMonitor.NsMapLock.RUnlock()
+		return dm.SystemMonitor.NsMap[monitor.NsKey{PidNS: ns, MntNS: ns}] == "nginx"
+	}
+
+	fake.AddContainer(testutil.FakeContainer{ID: "nginx", Name: "nginx", Namespace: "default", PodName: "nginx-pod", Pid: 1001})
+
+	if _, err := dm.syncCrioContainers(context.Background()); err != nil || !inNsMap(4026531001) {
+		t.Fatalf("[FAIL] Expected the container to be added (%v)", err)
+	}
+
+	// restarted in place with a new pid, the container IDs are the same between the polls
+	if err := os.RemoveAll(procDir + "/1001"); err != nil {
+		t.Fatalf("[FAIL] Failed to reset the fake procfs (%s)", err.Error())
+	}
+	startProcess("1002", "4026531002", "200")
+	fake.RestartContainer("nginx", 1002)
+
+	if _, err := dm.syncCrioContainers(context.Background()); err != nil || !inNsMap(4026531002) || inNsMap(4026531001) {
+		t.Fatalf("[FAIL] Expected the namespaces of the new pid to be refreshed (%v)", err)
+	}
+
+	// restarted in place again, reusing the pid
+	startProcess("1002", "4026531003", "300")
+
+	if _, err := dm.syncCrioContainers(context.Background()); err != nil || !inNsMap(4026531003) || inNsMap(4026531002) {
+		t.Fatalf("[FAIL] Expected the namespaces of the reused pid to be refreshed (%v)", err)
+	}
+
+	dm.ContainersLock.RLock()
+	if container := dm.Containers["nginx"]; container.Pid != 1002 || container.StartTime != 300 {
+		t.Errorf("[FAIL] Unexpected container after the restarts (%+v)", container)
+	}
+	dm.ContainersLock.RUnlock()
+
+	t.Log("[PASS] Refreshed the namespaces of the containers restarted between two polls")
+}
+
+func TestCrioContainerSwap(t *testing.T) {
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = true
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	dm := newCrioTestDaemon()
+
+	ch, err := NewCrioHandler()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to connect to the fake CRI runtime (%s)", err.Error())
+	}
+	dm.setCrio(ch)
+	defer dm.CloseRuntimeHandlers()
+
+	inContainers := func(containerID string) bool {
+		dm.ContainersLock.RLock()
+		defer dm.ContainersLock.RUnlock()
+		_, ok := dm.Containers[containerID]
+		return ok
+	}
+
+	// the known containers are read concurrently (e.g., by the probe service), checked with -race
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				if crio := dm.getCrio(); crio != nil {
+					_ = crio.HasContainer("nginx")
+					_ = crio.Snapshot()
+				}
+				_ = dm.GetContainerRetries()
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+
+	fake.AddContainer(testutil.FakeContainer{ID: "nginx", Name: "nginx", Namespace: "default", PodName: "nginx-pod", Pid: os.Getpid()})
+
+	if changed, err := dm.syncCrioContainers(context.Background()); err != nil || !changed || !inContainers("nginx") {
+		t.Fatalf("[FAIL] Expected the container to be added (%v, %v)", changed, err)
+	}
+
+	// no change between the polls
+	if changed, err := dm.syncCrioContainers(context.Background()); err != nil || changed {
+		t.Errorf("[FAIL] Expected no change (%v, %v)", changed, err)
+	}
+
+	// one container replaced by another between two polls, the number of the containers stays the same
+	fake.DeleteContainer("nginx")
+	fake.AddContainer(testutil.FakeContainer{ID: "redis", Name: "redis", Namespace: "default", PodName: "redis-pod", Pid: os.Getpid()})
+
+	changed, err := dm.syncCrioContainers(context.Background())
+	if err != nil || !changed {
+		t.Errorf("[FAIL] Expected the swap to be a change (%v, %v)", changed, err)
+	}
+
+	if !inContainers("redis") {
+		t.Errorf("[FAIL] Expected the started container to be added")
+	}
+	if inContainers("nginx") {
+		t.Errorf("[FAIL] Expected the deleted container to be removed")
+	}
+	if snapshot := dm.crio.Snapshot(); dm.crio.HasContainer("nginx") || len(snapshot) != 1 {
+		t.Errorf("[FAIL] Unexpected snapshot of the containers (%v)", snapshot)
+	}
+
+	t.Log("[PASS] Started and destroyed the containers swapped between two polls")
+}
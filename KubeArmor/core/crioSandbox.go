@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// ================== //
+// == CRIO Sandbox == //
+// ================== //
+
+// crioSandboxSkippedLabels are the labels of a sandbox which aren't labels of the pod
+var crioSandboxSkippedLabels = []string{"pod-template-hash", "pod-template-generation", "controller-revision-hash"}
+
+// getSandboxStatus Function gets the status of the sandbox (pod) of a container
+func (ch *CrioHandler) getSandboxStatus(ctx context.Context, sandboxID string) (*pb.PodSandboxStatus, error) {
+	res, err := ch.client.PodSandboxStatus(ctx, &pb.PodSandboxStatusRequest{PodSandboxId: sandboxID})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.GetStatus(), nil
+}
+
+// applyCrioSandbox fills the pod information which a container lacks (e.g., an ephemeral container injected by
+// kubectl debug) from its sandbox, and sets the UID of the pod
+func applyCrioSandbox(container *tp.Container, sandbox *pb.PodSandboxStatus) {
+	if sandbox == nil {
+		return
+	}
+
+	metadata := sandbox.GetMetadata()
+
+	container.PodUID = metadata.GetUid()
+
+	if container.NamespaceName == "Unknown" && metadata.GetNamespace() != "" {
+		container.NamespaceName = metadata.GetNamespace()
+	}
+	if container.EndPointName == "Unknown" && metadata.GetName() != "" {
+		container.EndPointName = metadata.GetName()
+	}
+
+	if container.Labels == "" {
+		labels := []string{}
+		for k, v := range sandbox.GetLabels() {
+			if strings.HasPrefix(k, "io.kubernetes.") || kl.ContainsElement(crioSandboxSkippedLabels, k) {
+				continue
+			}
+			labels = append(labels, k+"="+v)
+		}
+		sort.Strings(labels)
+
+		container.Labels = strings.Join(labels, ",")
+	}
+}
+
+// attachContainerToSandboxEndPoint adds an endpoint for a container of a pod whose containers don't include it yet
+// (e.g., an ephemeral container the K8s watcher hasn't seen yet), based on an endpoint of the same pod matched by
+// the UID of its sandbox (EndPointsLock must be held)
+func (dm *KubeArmorDaemon) attachContainerToSandboxEndPoint(container tp.Container) (tp.EndPoint, bool) {
+	if container.PodUID == "" {
+		return tp.EndPoint{}, false
+	}
+
+	for _, endPoint := range dm.EndPoints {
+		if endPoint.PodUID != container.PodUID {
+			continue
+		}
+
+		newPoint := endPoint
+
+		newPoint.ContainerName = container.ContainerName
+		newPoint.ContainerImage = container.ContainerImage
+		newPoint.ImageDigest = kl.GetImageDigest(container.ContainerImage)
+		newPoint.Containers = []string{container.ContainerID}
+		newPoint.AppArmorProfiles = []string{container.AppArmorProfile}
+		newPoint.SecurityContext = tp.SecurityIdentity{}
+
+		newPoint.SecurityPolicies = []tp.SecurityPolicy{}
+		for _, secPolicy := range dm.GetSecurityPolicies(newPoint.Identities) {
+			if len(secPolicy.Spec.Selector.Containers) == 0 || kl.ContainsElement(secPolicy.Spec.Selector.Containers, container.ContainerName) {
+				newPoint.SecurityPolicies = append(newPoint.SecurityPolicies, secPolicy)
+			}
+		}
+
+		dm.EndPoints = append(dm.EndPoints, newPoint)
+		delete(dm.PendingAttach, container.ContainerID)
+
+		return newPoint, true
+	}
+
+	return tp.EndPoint{}, false
+}
+
+// applySandboxEndPoint updates a container attached by its sandbox with the flags of its endpoint, and enforces the
+// policies of the endpoint
+func (dm *KubeArmorDaemon) applySandboxEndPoint(containerID string, endPoint tp.EndPoint) {
+	dm.ContainersLock.Lock()
+	if container, ok := dm.Containers[containerID]; ok {
+		container.NamespaceName = endPoint.NamespaceName
+		container.EndPointName = endPoint.EndPointName
+		container.Owner = endPoint.Owner
+
+		labels := []string{}
+		for k, v := range endPoint.Labels {
+			labels = append(labels, k+"="+v)
+		}
+		sort.Strings(labels)
+		container.Labels = strings.Join(labels, ",")
+
+		container.PolicyEnabled = endPoint.PolicyEnabled
+
+		container.ProcessVisibilityEnabled = endPoint.ProcessVisibilityEnabled
+		container.FileVisibilityEnabled = endPoint.FileVisibilityEnabled
+		container.NetworkVisibilityEnabled = endPoint.NetworkVisibilityEnabled
+		container.CapabilitiesVisibilityEnabled = endPoint.CapabilitiesVisibilityEnabled
+		container.SignalVisibilityEnabled = endPoint.SignalVisibilityEnabled
+
+		dm.Containers[containerID] = container
+	}
+	dm.ContainersLock.Unlock()
+
+	dm.Logger.Printf("Attached a container to its pod by its sandbox (%s/%s/%.12s)", endPoint.NamespaceName, endPoint.EndPointName, containerID)
+
+	if cfg.GlobalCfg.Policy {
+		dm.EndPointsLock.RLock()
+		dm.Logger.UpdateSecurityPolicies("ADDED", endPoint)
+		if dm.RuntimeEnforcer != nil && endPoint.PolicyEnabled == tp.KubeArmorPolicyEnabled {
+			dm.RuntimeEnforcer.UpdateSecurityPolicies(endPoint)
+		}
+		dm.EndPointsLock.RUnlock()
+	}
+}
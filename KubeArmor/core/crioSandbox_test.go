@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"os"
+	"testing"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	"github.com/kubearmor/KubeArmor/KubeArmor/testutil"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+func TestApplyCrioSandbox(t *testing.T) {
+	sandbox := &pb.PodSandboxStatus{
+		Metadata: &pb.PodSandboxMetadata{Name: "nginx-pod", Namespace: "default", Uid: "uid-1"},
+		Labels: map[string]string{
+			"io.kubernetes.pod.name":      "nginx-pod",
+			"io.kubernetes.pod.namespace": "default",
+			"io.kubernetes.pod.uid":       "uid-1",
+			"pod-template-hash":           "abc",
+			"tier":                        "web",
+			"app":                         "nginx",
+		},
+	}
+
+	// an ephemeral container carrying no pod information
+	container := tp.Container{ContainerID: "debugger", NamespaceName: "Unknown", EndPointName: "Unknown"}
+	applyCrioSandbox(&container, sandbox)
+
+	if container.PodUID != "uid-1" || container.NamespaceName != "default" || container.EndPointName != "nginx-pod" {
+		t.Errorf("[FAIL] Expected the pod of the sandbox (%+v)", container)
+	}
+	if container.Labels != "app=nginx,tier=web" {
+		t.Errorf("[FAIL] Expected the labels of the sandbox, got %q", container.Labels)
+	}
+
+	// the pod information of the container is kept
+	container = tp.Container{ContainerID: "nginx", NamespaceName: "default", EndPointName: "nginx-pod", Labels: "app=nginx"}
+	applyCrioSandbox(&container, sandbox)
+
+	if container.Labels != "app=nginx" || container.PodUID != "uid-1" {
+		t.Errorf("[FAIL] Expected the labels of the container to be kept (%+v)", container)
+	}
+
+	t.Log("[PASS] Applied the pod information of a sandbox")
+}
+
+func TestCrioEphemeralContainer(t *testing.T) {
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = false
+
+	dm := newCrioTestDaemon()
+
+	// the endpoint of the pod, without the ephemeral container
+	dm.UpdateEndPointWithPod("ADDED", tp.K8sPod{
+		Metadata:       map[string]string{"namespaceName": "default", "podName": "nginx-pod", "podUID": "uid-1"},
+		Annotations:    map[string]string{"kubearmor-policy": "enabled"},
+		Labels:         map[string]string{"app": "nginx"},
+		Containers:     map[string]string{"nginx": "nginx"},
+		ContainerTypes: map[string]string{"nginx": tp.ContainerTypeRegular},
+	})
+
+	fake.AddSandbox(testutil.FakeSandbox{
+		ID:        "sandbox-pod",
+		Name:      "nginx-pod",
+		Namespace: "default",
+		UID:       "uid-1",
+		Labels:    map[string]string{"app": "nginx"},
+	})
+
+	StopChan = make(chan struct{})
+	go dm.MonitorCrioEvents()
+
+	// the container injected by kubectl debug lacks the labels of the pod
+	fake.AddContainer(testutil.FakeContainer{
+		ID:              "debugger",
+		Name:            "debugger",
+		SandboxID:       "sandbox-pod",
+		Pid:             os.Getpid(),
+		AppArmorProfile: "kubearmor-default-debugger",
+	})
+
+	waitFor(t, "the ephemeral container to be attached", func() bool {
+		dm.EndPointsLock.RLock()
+		defer dm.EndPointsLock.RUnlock()
+		for _, endPoint := range dm.EndPoints {
+			if kl.ContainsElement(endPoint.Containers, "debugger") {
+				return true
+			}
+		}
+		return false
+	})
+
+	dm.EndPointsLock.RLock()
+	for _, endPoint := range dm.EndPoints {
+		if !kl.ContainsElement(endPoint.Containers, "debugger") {
+			continue
+		}
+		if endPoint.EndPointName != "nginx-pod" || endPoint.ContainerName != "debugger" || endPoint.Labels["app"] != "nginx" {
+			t.Errorf("[FAIL] Expected the endpoint of the pod for the ephemeral container (%+v)", endPoint)
+		}
+	}
+	if _, ok := dm.PendingAttach["debugger"]; ok {
+		t.Errorf("[FAIL] Expected the ephemeral container not to be pending")
+	}
+	dm.EndPointsLock.RUnlock()
+
+	waitFor(t, "the ephemeral container to get the pod information", func() bool {
+		dm.ContainersLock.RLock()
+		defer dm.ContainersLock.RUnlock()
+		return dm.Containers["debugger"].Labels == "app=nginx"
+	})
+
+	dm.ContainersLock.RLock()
+	container := dm.Containers["debugger"]
+	dm.ContainersLock.RUnlock()
+
+	if container.NamespaceName != "default" || container.EndPointName != "nginx-pod" || container.PodUID != "uid-1" {
+		t.Errorf("[FAIL] Expected the pod of the sandbox (%+v)", container)
+	}
+	if container.PolicyEnabled != tp.KubeArmorPolicyEnabled {
+		t.Errorf("[FAIL] Expected the policy flag of the endpoint (%d)", container.PolicyEnabled)
+	}
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	t.Log("[PASS] Attached an ephemeral container to its pod by its sandbox")
+}
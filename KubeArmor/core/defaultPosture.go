@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ============================= //
+// == Default Posture Sources == //
+// ============================= //
+
+// annotations of default postures (namespaces and pods)
+const (
+	filePostureKey         = "kubearmor-file-posture"
+	networkPostureKey      = "kubearmor-network-posture"
+	capabilitiesPostureKey = "kubearmor-capabilities-posture"
+)
+
+var (
+	// errUnknownPostureOperation is returned when the operation of a request has no default posture
+	errUnknownPostureOperation = errors.New("unknown operation")
+
+	// errPostureEndPointNotFound is returned when the pod of a request is not known
+	errPostureEndPointNotFound = errors.New("endpoint not found")
+)
+
+// getNamespacePostureSource returns the source of a namespace posture depending on whether it's annotated
+func getNamespacePostureSource(namespace string, annotated bool) string {
+	if annotated {
+		return tp.PostureSourceNamespace + ":" + namespace
+	}
+	return tp.PostureSourceGlobal
+}
+
+// getGlobalDefaultPosture Function
+func getGlobalDefaultPosture() tp.DefaultPosture {
+	return tp.DefaultPosture{
+		FileAction:         cfg.GlobalCfg.DefaultFilePosture,
+		NetworkAction:      cfg.GlobalCfg.DefaultNetworkPosture,
+		CapabilitiesAction: cfg.GlobalCfg.DefaultCapabilitiesPosture,
+
+		FileSource:         tp.PostureSourceGlobal,
+		NetworkSource:      tp.PostureSourceGlobal,
+		CapabilitiesSource: tp.PostureSourceGlobal,
+	}
+}
+
+// getPostureAnnotation returns the posture of an annotation, or an empty string if it's not set or invalid
+func getPostureAnnotation(annotations map[string]string, key string) string {
+	switch annotations[key] {
+	case "audit", "Audit":
+		return "audit"
+	case "block", "Block":
+		return "block"
+	default:
+		return ""
+	}
+}
+
+// getPostureOverride returns the default postures set by the annotations of a pod
+func getPostureOverride(annotations map[string]string) tp.DefaultPosture {
+	override := tp.DefaultPosture{
+		FileAction:         getPostureAnnotation(annotations, filePostureKey),
+		NetworkAction:      getPostureAnnotation(annotations, networkPostureKey),
+		CapabilitiesAction: getPostureAnnotation(annotations, capabilitiesPostureKey),
+	}
+
+	if override.FileAction != "" {
+		override.FileSource = tp.PostureSourceEndPoint
+	}
+	if override.NetworkAction != "" {
+		override.NetworkSource = tp.PostureSourceEndPoint
+	}
+	if override.CapabilitiesAction != "" {
+		override.CapabilitiesSource = tp.PostureSourceEndPoint
+	}
+
+	return override
+}
+
+// tightensPosture checks if the posture of an override is stricter than the given one, as the pods can only tighten
+// the postures set by the admins (anyone who can create a pod could downgrade them otherwise)
+func tightensPosture(posture, override string) bool {
+	return override == "block" && posture != "block"
+}
+
+// applyPostureOverride replaces the postures which are tightened by the override
+func applyPostureOverride(posture, override tp.DefaultPosture) tp.DefaultPosture {
+	if tightensPosture(posture.FileAction, override.FileAction) {
+		posture.FileAction, posture.FileSource = override.FileAction, override.FileSource
+	}
+	if tightensPosture(posture.NetworkAction, override.NetworkAction) {
+		posture.NetworkAction, posture.NetworkSource = override.NetworkAction, override.NetworkSource
+	}
+	if tightensPosture(posture.CapabilitiesAction, override.CapabilitiesAction) {
+		posture.CapabilitiesAction, posture.CapabilitiesSource = override.CapabilitiesAction, override.CapabilitiesSource
+	}
+	return posture
+}
+
+// getOperationPosture returns the posture of an operation and the layer which set it
+func getOperationPosture(posture tp.DefaultPosture, operation string) (string, string) {
+	switch operation {
+	case "File":
+		return posture.FileAction, posture.FileSource
+	case "Network":
+		return posture.NetworkAction, posture.NetworkSource
+	case "Capabilities":
+		return posture.CapabilitiesAction, posture.CapabilitiesSource
+	default:
+		return "", ""
+	}
+}
+
+// normalizePostureOperation maps an operation to the one its default posture is kept for
+func normalizePostureOperation(operation string) (string, bool) {
+	switch strings.ToLower(operation) {
+	case "file", "process":
+		return "File", true
+	case "network":
+		return "Network", true
+	case "capabilities":
+		return "Capabilities", true
+	default:
+		return "", false
+	}
+}
+
+// explainDefaultPosture resolves the posture of an operation from the layers (the lowest precedence first)
+func explainDefaultPosture(operation string, global, namespace, endPoint tp.DefaultPosture, namespaceName string) tp.PostureExplanation {
+	explanation := tp.PostureExplanation{Operation: operation}
+
+	globalPosture, _ := getOperationPosture(global, operation)
+	explanation.Layers = append(explanation.Layers, tp.PostureLayer{Source: tp.PostureSourceGlobal, Posture: globalPosture})
+
+	// the namespace layer only counts if the namespace is annotated for the operation
+	namespaceSource := getNamespacePostureSource(namespaceName, true)
+	namespacePosture, source := getOperationPosture(namespace, operation)
+	if source != namespaceSource {
+		namespacePosture = ""
+	}
+	explanation.Layers = append(explanation.Layers, tp.PostureLayer{Source: namespaceSource, Posture: namespacePosture})
+
+	endPointPosture, _ := getOperationPosture(endPoint, operation)
+	explanation.Layers = append(explanation.Layers, tp.PostureLayer{Source: tp.PostureSourceEndPoint, Posture: endPointPosture})
+
+	// the last layer setting the posture wins, except the endpoint layer which can only tighten it
+	applied := -1
+	for idx, layer := range explanation.Layers[:2] {
+		if layer.Posture != "" {
+			applied = idx
+		}
+	}
+
+	resolved := ""
+	if applied >= 0 {
+		resolved = explanation.Layers[applied].Posture
+	}
+	if tightensPosture(resolved, endPointPosture) {
+		applied = 2
+	}
+
+	if applied >= 0 {
+		explanation.Layers[applied].Applied = true
+		explanation.Posture = explanation.Layers[applied].Posture
+		explanation.Source = explanation.Layers[applied].Source
+	}
+
+	return explanation
+}
+
+// ExplainPosture returns how the default posture of an operation is resolved for a pod
+func (dm *KubeArmorDaemon) ExplainPosture(namespace, pod, operation string) (tp.PostureExplanation, error) {
+	op, ok := normalizePostureOperation(operation)
+	if !ok {
+		return tp.PostureExplanation{}, fmt.Errorf("%w (%s)", errUnknownPostureOperation, operation)
+	}
+
+	found := false
+	override := tp.DefaultPosture{}
+
+	dm.EndPointsLock.RLock()
+	for _, endPoint := range dm.EndPoints {
+		if endPoint.NamespaceName == namespace && endPoint.EndPointName == pod {
+			override = endPoint.PostureOverride
+			found = true
+			break
+		}
+	}
+	dm.EndPointsLock.RUnlock()
+
+	if !found {
+		return tp.PostureExplanation{}, fmt.Errorf("%w (%s/%s)", errPostureEndPointNotFound, namespace, pod)
+	}
+
+	dm.DefaultPosturesLock.Lock()
+	namespacePosture := dm.DefaultPostures[namespace]
+	global := getGlobalDefaultPosture()
+	dm.DefaultPosturesLock.Unlock()
+
+	return explainDefaultPosture(op, global, namespacePosture, override, namespace), nil
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestExplainDefaultPosture(t *testing.T) {
+	global := tp.DefaultPosture{
+		FileAction: "audit", NetworkAction: "audit", CapabilitiesAction: "audit",
+		FileSource: tp.PostureSourceGlobal, NetworkSource: tp.PostureSourceGlobal, CapabilitiesSource: tp.PostureSourceGlobal,
+	}
+
+	// namespace annotated for file only, the rest is inherited from the global config
+	partial := tp.DefaultPosture{
+		FileAction: "block", NetworkAction: "audit", CapabilitiesAction: "audit",
+		FileSource: getNamespacePostureSource("payments", true), NetworkSource: tp.PostureSourceGlobal, CapabilitiesSource: tp.PostureSourceGlobal,
+	}
+
+	override := getPostureOverride(map[string]string{filePostureKey: "Audit", networkPostureKey: "block", capabilitiesPostureKey: "deny"})
+	if override.FileAction != "audit" || override.NetworkAction != "block" || override.CapabilitiesAction != "" || override.CapabilitiesSource != "" {
+		t.Fatalf("[FAIL] Unexpected posture override (%+v)", override)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		operation string
+		namespace tp.DefaultPosture
+		endPoint  tp.DefaultPosture
+		posture   string
+		source    string
+		applied   int
+	}{
+		{"global", "File", tp.DefaultPosture{}, tp.DefaultPosture{}, "audit", tp.PostureSourceGlobal, 0},
+		{"namespace", "File", partial, tp.DefaultPosture{}, "block", "namespace-annotation:payments", 1},
+		{"namespace (other operation)", "Network", partial, tp.DefaultPosture{}, "audit", tp.PostureSourceGlobal, 0},
+		{"endpoint loosening namespace", "File", partial, override, "block", "namespace-annotation:payments", 1},
+		{"endpoint loosening global", "File", tp.DefaultPosture{}, override, "audit", tp.PostureSourceGlobal, 0},
+		{"endpoint over global", "Network", partial, override, "block", tp.PostureSourceEndPoint, 2},
+		{"endpoint (other operation)", "Capabilities", partial, override, "audit", tp.PostureSourceGlobal, 0},
+	} {
+		explanation := explainDefaultPosture(tc.operation, global, tc.namespace, tc.endPoint, "payments")
+
+		if explanation.Posture != tc.posture || explanation.Source != tc.source {
+			t.Errorf("[FAIL] Unexpected posture for %s (%s from %s)", tc.name, explanation.Posture, explanation.Source)
+			continue
+		}
+
+		if len(explanation.Layers) != 3 {
+			t.Errorf("[FAIL] Expected 3 layers for %s, got %d", tc.name, len(explanation.Layers))
+			continue
+		}
+
+		for idx, layer := range explanation.Layers {
+			if layer.Applied != (idx == tc.applied) {
+				t.Errorf("[FAIL] Unexpected applied layer for %s (%+v)", tc.name, explanation.Layers)
+				break
+			}
+		}
+
+		// the effective posture of the endpoint agrees with the explanation
+		namespacePosture := global
+		if tc.namespace != (tp.DefaultPosture{}) {
+			namespacePosture = tc.namespace
+		}
+		if posture, source := getOperationPosture(applyPostureOverride(namespacePosture, tc.endPoint), tc.operation); posture != tc.posture || source != tc.source {
+			t.Errorf("[FAIL] Unexpected effective posture for %s (%s from %s)", tc.name, posture, source)
+		}
+	}
+
+	t.Log("[PASS] Explained default postures across layers")
+}
+
+func TestExplainPosture(t *testing.T) {
+	cfg.GlobalCfg.DefaultFilePosture = "audit"
+	cfg.GlobalCfg.DefaultNetworkPosture = "block"
+	cfg.GlobalCfg.DefaultCapabilitiesPosture = "audit"
+
+	dm := &KubeArmorDaemon{}
+	dm.EndPointsLock = new(sync.RWMutex)
+	dm.DefaultPostures = map[string]tp.DefaultPosture{}
+	dm.DefaultPosturesLock = new(sync.Mutex)
+
+	endPoint := tp.EndPoint{NamespaceName: "payments", EndPointName: "checkout"}
+	endPoint.PostureOverride = getPostureOverride(map[string]string{capabilitiesPostureKey: "block"})
+	dm.EndPoints = []tp.EndPoint{endPoint}
+
+	dm.DefaultPostures["payments"] = tp.DefaultPosture{
+		FileAction: "block", NetworkAction: "block", CapabilitiesAction: "audit",
+		FileSource: getNamespacePostureSource("payments", true), NetworkSource: tp.PostureSourceGlobal, CapabilitiesSource: tp.PostureSourceGlobal,
+	}
+
+	for operation, source := range map[string]string{
+		"process":      "namespace-annotation:payments",
+		"Network":      tp.PostureSourceGlobal,
+		"capabilities": tp.PostureSourceEndPoint,
+	} {
+		explanation, err := dm.ExplainPosture("payments", "checkout", operation)
+		if err != nil {
+			t.Errorf("[FAIL] Failed to explain the %s posture (%s)", operation, err.Error())
+		} else if explanation.Source != source || explanation.Posture != "block" {
+			t.Errorf("[FAIL] Unexpected %s posture (%s from %s)", operation, explanation.Posture, explanation.Source)
+		}
+	}
+
+	if _, err := dm.ExplainPosture("payments", "checkout", "syscall"); !errors.Is(err, errUnknownPostureOperation) {
+		t.Errorf("[FAIL] Expected an unknown operation error, got %v", err)
+	}
+
+	if _, err := dm.ExplainPosture("payments", "cart", "file"); !errors.Is(err, errPostureEndPointNotFound) {
+		t.Errorf("[FAIL] Expected an endpoint not found error, got %v", err)
+	}
+
+	t.Log("[PASS] Explained the default postures of a pod")
+}
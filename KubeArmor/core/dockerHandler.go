@@ -10,6 +10,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/events"
@@ -25,9 +26,6 @@ import (
 // == Docker Handler == //
 // ==================== //
 
-// Docker Handler
-var Docker *DockerHandler
-
 // DockerVersion Structure
 type DockerVersion struct {
 	APIVersion string `json:"ApiVersion"`
@@ -37,6 +35,12 @@ type DockerVersion struct {
 type DockerHandler struct {
 	DockerClient *client.Client
 	Version      DockerVersion
+
+	// cgroup driver of the docker daemon (cgroupfs|systemd)
+	CgroupDriver string
+
+	// state reported by the health probe (the containers are the running ones listed)
+	health *runtimeHealth
 }
 
 // NewDockerHandler Function
@@ -45,7 +49,14 @@ func NewDockerHandler() (*DockerHandler, error) {
 
 	// try to create a new docker client
 	// If env DOCKER_API_VERSION set - NegotiateAPIVersion() won't do anything
-	DockerClient, err := client.NewClientWithOpts(client.FromEnv)
+	opts := []client.Opt{client.FromEnv}
+
+	// the detected (or set) socket unless DOCKER_HOST is set
+	if os.Getenv("DOCKER_HOST") == "" && strings.Contains(cfg.GlobalCfg.CRISocket, "docker") {
+		opts = append(opts, client.WithHost(cfg.GlobalCfg.CRISocket))
+	}
+
+	DockerClient, err := client.NewClientWithOpts(opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +75,13 @@ func NewDockerHandler() (*DockerHandler, error) {
 	}
 
 	docker.DockerClient = DockerClient
+	docker.health = newRuntimeHealth(RuntimeDocker, DockerClient.DaemonHost())
+
+	if info, err := DockerClient.Info(context.Background()); err == nil {
+		docker.CgroupDriver = info.CgroupDriver
+	} else {
+		kg.Warnf("Unable to get the cgroup driver of Docker (%s)", err.Error())
+	}
 
 	kg.Printf("Initialized Docker Handler (version: %s)", clientVersion)
 
@@ -79,22 +97,35 @@ func (dh *DockerHandler) Close() {
 	}
 }
 
+// Health Function returns the state of the connection to Docker
+func (dh *DockerHandler) Health() tp.RuntimeHandlerHealth {
+	return dh.health.snapshot(dh.DockerClient != nil)
+}
+
+// recordDockerList Function records the result of a listing of the running containers
+func (dh *DockerHandler) recordDockerList(containerList []types.Container, err error) {
+	dh.health.recordList(err)
+	if err == nil {
+		dh.health.setContainers(len(containerList))
+	}
+}
+
 // ==================== //
 // == Container Info == //
 // ==================== //
 
 // GetContainerInfo Function
-func (dh *DockerHandler) GetContainerInfo(containerID string) (tp.Container, error) {
+func (dh *DockerHandler) GetContainerInfo(ctx context.Context, containerID string) (tp.Container, error) {
 	if dh.DockerClient == nil {
 		return tp.Container{}, errors.New("no docker client")
 	}
 
-	inspect, err := dh.DockerClient.ContainerInspect(context.Background(), containerID)
+	inspect, err := dh.DockerClient.ContainerInspect(ctx, containerID)
 	if err != nil {
 		return tp.Container{}, err
 	}
 
-	container := tp.Container{}
+	container := tp.Container{Runtime: RuntimeDocker}
 
 	// == container base == //
 
@@ -114,21 +145,32 @@ func (dh *DockerHandler) GetContainerInfo(containerID string) (tp.Container, err
 		}
 	}
 
+	// the image as it was run (docker doesn't report the repo digest of a container, the image ID is a local one)
+	container.ContainerImage = kl.GetContainerImage(inspect.Config.Image, "")
+
 	container.AppArmorProfile = inspect.AppArmorProfile
 
 	container.MergedDir = inspect.GraphDriver.Data["MergedDir"]
 
+	// risky host mounts
+	container.RiskyMounts = ClassifyMounts(dockerMounts(inspect.Mounts), sensitiveHostPaths())
+
+	// cgroup, identity and resource limits
+	applyDockerInspect(&container, inspect, dh.CgroupDriver)
+
 	// == //
 
 	pid := strconv.Itoa(inspect.State.Pid)
+	container.Pid = uint32(inspect.State.Pid)
+	container.StartTime = getProcessStartTime(container.Pid)
 
-	if data, err := os.Readlink("/proc/" + pid + "/ns/pid"); err == nil {
+	if data, err := os.Readlink(kl.GetProcPath(pid, "ns", "pid")); err == nil {
 		if _, err := fmt.Sscanf(data, "pid:[%d]\n", &container.PidNS); err != nil {
 			kg.Warnf("Unable to get PidNS (%s, %s, %s)", containerID, pid, err.Error())
 		}
 	}
 
-	if data, err := os.Readlink("/proc/" + pid + "/ns/mnt"); err == nil {
+	if data, err := os.Readlink(kl.GetProcPath(pid, "ns", "mnt")); err == nil {
 		if _, err := fmt.Sscanf(data, "mnt:[%d]\n", &container.MntNS); err != nil {
 			kg.Warnf("Unable to get MntNS (%s, %s, %s)", containerID, pid, err.Error())
 		}
@@ -143,14 +185,13 @@ func (dh *DockerHandler) GetContainerInfo(containerID string) (tp.Container, err
 // == Docker Event Channel == //
 // ========================== //
 
-// GetEventChannel Function
-func (dh *DockerHandler) GetEventChannel() <-chan events.Message {
+// GetEventChannel Function returns the channel of the events, and the channel of the error ending them
+func (dh *DockerHandler) GetEventChannel() (<-chan events.Message, <-chan error) {
 	if dh.DockerClient != nil {
-		event, _ := dh.DockerClient.Events(context.Background(), types.EventsOptions{})
-		return event
+		return dh.DockerClient.Events(context.Background(), types.EventsOptions{})
 	}
 
-	return nil
+	return nil, nil
 }
 
 // =================== //
@@ -161,26 +202,12 @@ func (dh *DockerHandler) GetEventChannel() <-chan events.Message {
 func (dm *KubeArmorDaemon) SetContainerVisibility(containerID string) {
 
 	// get container information from docker client
-	container, err := Docker.GetContainerInfo(containerID)
+	container, err := dm.docker.GetContainerInfo(context.Background(), containerID)
 	if err != nil {
 		return
 	}
 
-	if strings.Contains(cfg.GlobalCfg.Visibility, "process") {
-		container.ProcessVisibilityEnabled = true
-	}
-	if strings.Contains(cfg.GlobalCfg.Visibility, "file") {
-		container.FileVisibilityEnabled = true
-	}
-	if strings.Contains(cfg.GlobalCfg.Visibility, "network") {
-		container.NetworkVisibilityEnabled = true
-	}
-	if strings.Contains(cfg.GlobalCfg.Visibility, "capabilities") {
-		container.CapabilitiesVisibilityEnabled = true
-	}
-
-	container.EndPointName = container.ContainerName
-	container.NamespaceName = "container_namespace"
+	setUnorchestratedContainer(&container)
 
 	dm.Containers[container.ContainerID] = container
 }
@@ -188,18 +215,24 @@ func (dm *KubeArmorDaemon) SetContainerVisibility(containerID string) {
 // GetAlreadyDeployedDockerContainers Function
 func (dm *KubeArmorDaemon) GetAlreadyDeployedDockerContainers() {
 	// check if Docker exists else instantiate
-	if Docker == nil {
+	if dm.docker == nil {
 		var err error
-		Docker, err = NewDockerHandler()
+		dm.docker, err = NewDockerHandler()
 		if err != nil {
+			dm.trackRuntimeHealth(RuntimeDocker, unreachableRuntime(RuntimeDocker, cfg.GlobalCfg.CRISocket, err))
 			dm.Logger.Errf("Failed to create new Docker client: %s", err)
+			return
 		}
+		dm.trackRuntimeHealth(RuntimeDocker, dm.docker.Health)
 	}
 
-	if containerList, err := Docker.DockerClient.ContainerList(context.Background(), types.ContainerListOptions{}); err == nil {
+	containerList, err := dm.docker.DockerClient.ContainerList(context.Background(), types.ContainerListOptions{})
+	dm.docker.recordDockerList(containerList, err)
+
+	if err == nil {
 		for _, dcontainer := range containerList {
 			// get container information from docker client
-			container, err := Docker.GetContainerInfo(dcontainer.ID)
+			container, err := dm.docker.GetContainerInfo(context.Background(), dcontainer.ID)
 			if err != nil {
 				continue
 			}
@@ -222,6 +255,7 @@ func (dm *KubeArmorDaemon) GetAlreadyDeployedDockerContainers() {
 
 					container.ContainerName = dm.Containers[container.ContainerID].ContainerName
 					container.ContainerImage = dm.Containers[container.ContainerID].ContainerImage
+					container.ContainerType = dm.Containers[container.ContainerID].ContainerType
 
 					container.PolicyEnabled = dm.Containers[container.ContainerID].PolicyEnabled
 
@@ -229,6 +263,7 @@ func (dm *KubeArmorDaemon) GetAlreadyDeployedDockerContainers() {
 					container.FileVisibilityEnabled = dm.Containers[container.ContainerID].FileVisibilityEnabled
 					container.NetworkVisibilityEnabled = dm.Containers[container.ContainerID].NetworkVisibilityEnabled
 					container.CapabilitiesVisibilityEnabled = dm.Containers[container.ContainerID].CapabilitiesVisibilityEnabled
+					container.SignalVisibilityEnabled = dm.Containers[container.ContainerID].SignalVisibilityEnabled
 
 					dm.Containers[container.ContainerID] = container
 					dm.ContainersLock.Unlock()
@@ -267,8 +302,13 @@ func (dm *KubeArmorDaemon) GetAlreadyDeployedDockerContainers() {
 				}
 
 				dm.Logger.Printf("Detected a container (added/%.12s)", container.ContainerID)
+
+				dm.reportRiskyMounts(container)
+				dm.AppArmorAttachment.ContainerSeen(container)
 			}
 		}
+
+		dm.finalizeNsMapAdoption()
 	} else {
 		dm.Logger.Warnf("Error while listing containers: %s", err)
 	}
@@ -277,7 +317,7 @@ func (dm *KubeArmorDaemon) GetAlreadyDeployedDockerContainers() {
 // UpdateDockerContainer Function
 func (dm *KubeArmorDaemon) UpdateDockerContainer(containerID, action string) {
 	// check if Docker exists
-	if Docker == nil {
+	if dm.docker == nil {
 		return
 	}
 
@@ -287,7 +327,7 @@ func (dm *KubeArmorDaemon) UpdateDockerContainer(containerID, action string) {
 		var err error
 
 		// get container information from docker client
-		container, err = Docker.GetContainerInfo(containerID)
+		container, err = dm.docker.GetContainerInfo(context.Background(), containerID)
 		if err != nil {
 			return
 		}
@@ -300,6 +340,13 @@ func (dm *KubeArmorDaemon) UpdateDockerContainer(containerID, action string) {
 		if _, ok := dm.Containers[containerID]; !ok {
 			dm.Containers[containerID] = container
 			dm.ContainersLock.Unlock()
+
+			// the K8s watcher may have created the endpoint without the container info
+			if dm.K8sEnabled {
+				dm.EndPointsLock.Lock()
+				dm.attachContainerToEndPoint(container)
+				dm.EndPointsLock.Unlock()
+			}
 		} else if dm.Containers[containerID].PidNS == 0 && dm.Containers[containerID].MntNS == 0 {
 			// this entry was updated by kubernetes before docker detects it
 			// thus, we here use the info given by kubernetes instead of the info given by docker
@@ -310,6 +357,7 @@ func (dm *KubeArmorDaemon) UpdateDockerContainer(containerID, action string) {
 
 			container.ContainerName = dm.Containers[containerID].ContainerName
 			container.ContainerImage = dm.Containers[containerID].ContainerImage
+			container.ContainerType = dm.Containers[containerID].ContainerType
 
 			container.PolicyEnabled = dm.Containers[containerID].PolicyEnabled
 
@@ -317,25 +365,21 @@ func (dm *KubeArmorDaemon) UpdateDockerContainer(containerID, action string) {
 			container.FileVisibilityEnabled = dm.Containers[containerID].FileVisibilityEnabled
 			container.NetworkVisibilityEnabled = dm.Containers[containerID].NetworkVisibilityEnabled
 			container.CapabilitiesVisibilityEnabled = dm.Containers[containerID].CapabilitiesVisibilityEnabled
+			container.SignalVisibilityEnabled = dm.Containers[containerID].SignalVisibilityEnabled
 
 			dm.Containers[containerID] = container
 			dm.ContainersLock.Unlock()
 
 			dm.EndPointsLock.Lock()
-			for idx, endPoint := range dm.EndPoints {
-				if endPoint.NamespaceName == container.NamespaceName && endPoint.EndPointName == container.EndPointName && kl.ContainsElement(endPoint.Containers, container.ContainerID) {
-
-					// update apparmor profiles
-					if !kl.ContainsElement(endPoint.AppArmorProfiles, container.AppArmorProfile) {
-						dm.EndPoints[idx].AppArmorProfiles = append(dm.EndPoints[idx].AppArmorProfiles, container.AppArmorProfile)
-					}
-
-					break
-				}
-			}
+			dm.attachContainerToEndPoint(container)
 			dm.EndPointsLock.Unlock()
 		} else {
 			dm.ContainersLock.Unlock()
+
+			// restarted in place by its restart policy (without a stop event), with a new process
+			if err := dm.restartContainer(containerID, container); err != nil {
+				dm.Logger.Warnf("Failed to refresh a restarted container (%.12s, %s)", containerID, err.Error())
+			}
 			return
 		}
 
@@ -362,52 +406,45 @@ func (dm *KubeArmorDaemon) UpdateDockerContainer(containerID, action string) {
 
 		dm.Logger.Printf("Detected a container (added/%.12s)", containerID)
 
+		dm.reportRiskyMounts(container)
+		dm.AppArmorAttachment.ContainerSeen(container)
+
 	} else if action == "stop" || action == "destroy" {
 		// case 1: kill -> die -> stop
 		// case 2: kill -> die -> destroy
 		// case 3: destroy
 
-		if !dm.K8sEnabled {
-			dm.ContainersLock.Lock()
-			dm.EndPointsLock.Lock()
-			dm.MatchandRemoveContainerFromEndpoint(containerID)
-			dm.EndPointsLock.Unlock()
-			dm.ContainersLock.Unlock()
+		// the container failed to be removed is removed by the next audit
+		if err := destroyContainer(dm, containerID); err != nil {
+			dm.Logger.Warnf("Failed to remove a container (%.12s, %s)", containerID, err.Error())
 		}
+	}
+}
 
-		dm.ContainersLock.Lock()
-		container, ok := dm.Containers[containerID]
-		if !ok {
-			dm.ContainersLock.Unlock()
-			return
-		}
-		delete(dm.Containers, containerID)
-		dm.ContainersLock.Unlock()
-
-		dm.EndPointsLock.Lock()
-		for idx, endPoint := range dm.EndPoints {
-			if endPoint.NamespaceName == container.NamespaceName && endPoint.EndPointName == container.EndPointName && kl.ContainsElement(endPoint.Containers, container.ContainerID) {
-
-				// update apparmor profiles
-				for idxA, profile := range endPoint.AppArmorProfiles {
-					if profile == container.AppArmorProfile {
-						dm.EndPoints[idx].AppArmorProfiles = append(dm.EndPoints[idx].AppArmorProfiles[:idxA], dm.EndPoints[idx].AppArmorProfiles[idxA+1:]...)
-						break
-					}
-				}
+// auditDockerContainers Function removes the containers which aren't running anymore, left behind by the lost
+// destroy events
+func (dm *KubeArmorDaemon) auditDockerContainers() {
+	containerList, err := dm.docker.DockerClient.ContainerList(context.Background(), types.ContainerListOptions{})
+	dm.docker.recordDockerList(containerList, err)
+	if err != nil {
+		dm.Logger.Warnf("Failed to list Docker containers (%s)", err.Error())
+		return
+	}
 
-				break
-			}
-		}
-		dm.EndPointsLock.Unlock()
+	running := map[string]struct{}{}
+	for _, container := range containerList {
+		running[container.ID] = struct{}{}
+	}
 
-		if dm.SystemMonitor != nil && cfg.GlobalCfg.Policy {
-			// update NsMap
-			dm.SystemMonitor.DeleteContainerIDFromNsMap(containerID, container.NamespaceName, container.PidNS, container.MntNS)
-			dm.RuntimeEnforcer.UnregisterContainer(containerID)
-		}
+	dm.auditContainers(running)
 
-		dm.Logger.Printf("Detected a container (removed/%.12s)", containerID)
+	// the containers restarted in place whose events were lost
+	restarted := dm.restartedContainers(RuntimeDocker, func(containerID string) bool {
+		_, ok := running[containerID]
+		return ok
+	})
+	for _, containerID := range restarted {
+		dm.UpdateDockerContainer(containerID, "start")
 	}
 }
 
@@ -417,27 +454,55 @@ func (dm *KubeArmorDaemon) MonitorDockerEvents() {
 	defer dm.WgDaemon.Done()
 
 	// check if Docker exists else instantiate
-	if Docker == nil {
+	if dm.docker == nil {
 		var err error
-		Docker, err = NewDockerHandler()
+		dm.docker, err = NewDockerHandler()
 		if err != nil {
+			dm.trackRuntimeHealth(RuntimeDocker, unreachableRuntime(RuntimeDocker, cfg.GlobalCfg.CRISocket, err))
 			dm.Logger.Errf("Failed to create new Docker client: %s", err)
+			return
 		}
+		dm.trackRuntimeHealth(RuntimeDocker, dm.docker.Health)
 	}
 
 	dm.Logger.Print("Started to monitor Docker events")
 
-	EventChan := Docker.GetEventChannel()
+	EventChan, ErrChan := dm.docker.GetEventChannel()
+
+	// re-subscribing backs off while the events keep failing
+	poller := newRuntimePoller()
+
+	audit := time.NewTicker(containerAuditInterval)
+	defer audit.Stop()
 
 	for {
 		select {
 		case <-StopChan:
 			return
 
+		case <-audit.C:
+			dm.auditDockerContainers()
+
+		case err := <-ErrChan:
+			dm.Logger.Warnf("Lost the Docker events, subscribing again (%s)", err.Error())
+
+			select {
+			case <-StopChan:
+				return
+			case <-time.After(poller.next(false)):
+			}
+
+			EventChan, ErrChan = dm.docker.GetEventChannel()
+
+			// the containers stopped meanwhile
+			dm.auditDockerContainers()
+
 		case msg, valid := <-EventChan:
 			if !valid {
+				EventChan = nil
 				continue
 			}
+			poller.next(true)
 
 			// if message type is container
 			if msg.Type == "container" {
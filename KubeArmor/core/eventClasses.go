@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	mon "github.com/kubearmor/KubeArmor/KubeArmor/monitor"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// =================== //
+// == Event Classes == //
+// =================== //
+
+// getRuleEventClasses adds the event classes which the rules of a policy are matched against
+func getRuleEventClasses(demand map[string]bool, file tp.FileType, network tp.NetworkType, syscalls tp.SyscallsType, appArmor string) {
+	// the syscall rules and the raw AppArmor rules may cover any event
+	if len(syscalls.MatchSyscalls) > 0 || len(syscalls.MatchPaths) > 0 || appArmor != "" {
+		demand[mon.EventClassFile] = true
+		demand[mon.EventClassNetwork] = true
+		return
+	}
+
+	if len(file.MatchPaths) > 0 || len(file.MatchDirectories) > 0 || len(file.MatchPatterns) > 0 ||
+		len(file.MatchXattrs) > 0 || len(file.MatchImmutable) > 0 {
+		demand[mon.EventClassFile] = true
+	}
+
+	if len(network.MatchProtocols) > 0 {
+		demand[mon.EventClassNetwork] = true
+	}
+}
+
+// getPolicyEventClasses returns the event classes needed by the policies of the endpoints and of the host
+func (dm *KubeArmorDaemon) getPolicyEventClasses() map[string]bool {
+	demand := map[string]bool{}
+
+	if cfg.GlobalCfg.Policy {
+		dm.EndPointsLock.RLock()
+		for _, endPoint := range dm.EndPoints {
+			for _, policy := range endPoint.SecurityPolicies {
+				getRuleEventClasses(demand, policy.Spec.File, policy.Spec.Network, policy.Spec.Syscalls, policy.Spec.AppArmor)
+			}
+		}
+		dm.EndPointsLock.RUnlock()
+	}
+
+	if cfg.GlobalCfg.HostPolicy {
+		dm.HostSecurityPoliciesLock.RLock()
+		for _, policy := range dm.HostSecurityPolicies {
+			if kl.IsK8sEnv() && !matchNodeSelector(policy.Spec.NodeSelector, dm.Node.Identities) {
+				continue
+			}
+			getRuleEventClasses(demand, policy.Spec.File, policy.Spec.Network, policy.Spec.Syscalls, policy.Spec.AppArmor)
+		}
+		dm.HostSecurityPoliciesLock.RUnlock()
+	}
+
+	return demand
+}
+
+// requestEventClassUpdate checks if the probes of a detached event class are needed again
+func (dm *KubeArmorDaemon) requestEventClassUpdate() {
+	if dm.SystemMonitor == nil {
+		return
+	}
+	dm.SystemMonitor.RequestEventClassUpdate()
+}
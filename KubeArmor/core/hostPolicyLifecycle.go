@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"sort"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// =========================== //
+// == Host Policy Lifecycle == //
+// =========================== //
+
+// isK8sEnv selects the host policies by the labels of the node (all of them are enforced otherwise)
+var isK8sEnv = kl.IsK8sEnv
+
+// countHostPolicyRules returns the number of the rules of a host policy
+func countHostPolicyRules(spec tp.HostSecuritySpec) int {
+	return countPolicyRules(tp.SecuritySpec{
+		Process:      spec.Process,
+		File:         spec.File,
+		Network:      spec.Network,
+		Capabilities: spec.Capabilities,
+		Syscalls:     spec.Syscalls,
+	})
+}
+
+// updateHostPolicyEnforcement raises the alerts of the host policies whose enforcement on the node starts or stops,
+// once per transition (HostSecurityPoliciesLock must be held)
+func (dm *KubeArmorDaemon) updateHostPolicyEnforcement(secPolicies []tp.HostSecurityPolicy, enforcer string) {
+	transitions := []fd.HostPolicyTransition{}
+
+	enforced := map[string]int{}
+	for _, policy := range secPolicies {
+		policyName := policy.Metadata["policyName"]
+		enforced[policyName] = countHostPolicyRules(policy.Spec)
+
+		if _, ok := dm.EnforcedHostPolicies[policyName]; !ok {
+			transitions = append(transitions, fd.HostPolicyTransition{PolicyName: policyName, Enforced: true, Rules: enforced[policyName], Enforcer: enforcer})
+		}
+	}
+
+	for policyName, rules := range dm.EnforcedHostPolicies {
+		if _, ok := enforced[policyName]; ok {
+			continue
+		}
+
+		// the policy is kept unless deleted, but its node selector doesn't match the labels of the node anymore
+		reason := fd.HostPolicyDeleted
+		for _, policy := range dm.HostSecurityPolicies {
+			if policy.Metadata["policyName"] == policyName {
+				reason = fd.HostPolicySelectorMismatch
+				break
+			}
+		}
+
+		transitions = append(transitions, fd.HostPolicyTransition{PolicyName: policyName, Reason: reason, Rules: rules, Enforcer: enforcer})
+	}
+
+	dm.EnforcedHostPolicies = enforced
+
+	sort.Slice(transitions, func(i, j int) bool {
+		return transitions[i].PolicyName < transitions[j].PolicyName
+	})
+
+	for _, transition := range transitions {
+		if transition.Enforced {
+			dm.Logger.Printf("Started to enforce a host security policy on this node (%s, %d rules)", transition.PolicyName, transition.Rules)
+		} else {
+			dm.Logger.Printf("Stopped enforcing a host security policy on this node (%s, %s)", transition.PolicyName, transition.Reason)
+		}
+
+		dm.Logger.PushHostPolicyTransition(transition)
+	}
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// hardenNodeEvent returns an event of a host policy selecting the edge nodes
+func hardenNodeEvent(eventType string, paths ...string) tp.K8sKubeArmorHostPolicyEvent {
+	event := tp.K8sKubeArmorHostPolicyEvent{Type: eventType}
+	event.Object.Metadata.Name = "harden-node"
+	event.Object.Spec.NodeSelector.MatchLabels = map[string]string{"role": "edge"}
+	event.Object.Spec.Action = "Block"
+
+	for _, path := range paths {
+		event.Object.Spec.Process.MatchPaths = append(event.Object.Spec.Process.MatchPaths, tp.ProcessPathType{Path: path})
+	}
+
+	return event
+}
+
+func TestHostPolicyLifecycle(t *testing.T) {
+	t.Setenv("KUBEARMOR_NODENAME", "worker-1")
+
+	prevHostPolicy, prevK8sEnv := cfg.GlobalCfg.HostPolicy, isK8sEnv
+	defer func() {
+		cfg.GlobalCfg.HostPolicy, isK8sEnv = prevHostPolicy, prevK8sEnv
+	}()
+	cfg.GlobalCfg.HostPolicy = true
+	isK8sEnv = func() bool { return true }
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	// subscribe to the alerts and the policy events
+	alerts := make(chan *pb.Alert, 16)
+	fd.AlertLock = new(sync.RWMutex)
+	fd.AlertStructs = map[string]fd.AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+	defer func() { fd.AlertStructs = map[string]fd.AlertStruct{} }()
+
+	events := make(chan *pb.PolicyEvent, 16)
+	fd.PolicyEventStructs = map[string]fd.PolicyEventStruct{"test": {Filter: "all", Broadcast: events}}
+	fd.AppliedPolicies = map[string]*pb.PolicyEvent{}
+	fd.PolicyEventLock = new(sync.RWMutex)
+	defer func() { fd.PolicyEventStructs = map[string]fd.PolicyEventStruct{} }()
+
+	dm := NewKubeArmorDaemon()
+	dm.Logger = &fd.Feeder{Node: &tp.Node{NodeName: "worker-1"}}
+	dm.Logger.Output = "none"
+	dm.Logger.SeverityRangesLock = new(sync.RWMutex)
+	dm.Logger.SecurityPolicies = map[string]tp.MatchPolicies{}
+	dm.Logger.SecurityPoliciesLock = new(sync.RWMutex)
+	dm.Logger.SinksLock = new(sync.RWMutex)
+
+	// a durable sink of the Block alerts only
+	dir := t.TempDir()
+	store, err := fd.NewFileStore(filepath.Join(dir, "alerts.jsonl"))
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to open the file store (%s)", err.Error())
+	}
+	sink, err := fd.NewDurableSink(fd.DurableSinkConfig{Actions: []string{"Block"}, Journal: fd.AlertJournalConfig{Dir: filepath.Join(dir, "journal")}}, store)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create the durable sink (%s)", err.Error())
+	}
+	dm.Logger.AddSink(sink)
+
+	// the transitions raise one alert and one policy event each
+	expectTransition := func(what, policyName, reason string) {
+		select {
+		case alert := <-alerts:
+			if alert.PolicyName != policyName || alert.HostName != "worker-1" || alert.Enforcer != "KubeArmor" {
+				t.Errorf("[FAIL] Unexpected alert for %s (%+v)", what, alert)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("[FAIL] Expected an alert for %s", what)
+		}
+
+		for {
+			select {
+			case event := <-events:
+				if event.Action != fd.PolicyEnforced && event.Action != fd.PolicyUnenforced {
+					continue // applied, updated or removed
+				}
+				if event.PolicyName != "harden-node" || event.Reason != reason || event.RuleCount != 2 || event.Endpoints[0] != "worker-1" {
+					t.Errorf("[FAIL] Unexpected policy event for %s (%+v)", what, event)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("[FAIL] Expected a policy event for %s", what)
+			}
+			break
+		}
+	}
+
+	expectNoTransition := func(what string) {
+		select {
+		case alert := <-alerts:
+			t.Errorf("[FAIL] Unexpected alert after %s (%+v)", what, alert)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1", Labels: map[string]string{"role": "edge"}}}
+	client := fake.NewSimpleClientset(node)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	if !dm.watchK8sNodes(client, stopCh) {
+		t.Fatal("[FAIL] Failed to watch the nodes")
+	}
+
+	updateLabels := func(labels map[string]string) {
+		node.Labels = labels
+		if _, err := client.CoreV1().Nodes().Update(context.Background(), node, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("[FAIL] Failed to update the labels of the node (%s)", err.Error())
+		}
+	}
+
+	// a host policy selecting the node
+	dm.ParseAndUpdateHostSecurityPolicy(hardenNodeEvent("ADDED", "/bin/sh", "/usr/bin/curl"))
+	expectTransition("the added policy", fd.HostPolicyEnforcedPolicyName, "")
+
+	// no transition while the policy stays enforced
+	dm.ParseAndUpdateHostSecurityPolicy(hardenNodeEvent("MODIFIED", "/bin/sh", "/usr/bin/wget"))
+	expectNoTransition("the modified policy")
+
+	// the node isn't selected anymore, then again
+	updateLabels(map[string]string{"role": "web"})
+	expectTransition("the changed labels", fd.HostPolicyUnenforcedPolicyName, fd.HostPolicySelectorMismatch)
+
+	updateLabels(map[string]string{"role": "web", "zone": "a"})
+	expectNoTransition("the other labels")
+
+	updateLabels(map[string]string{"role": "edge"})
+	expectTransition("the restored labels", fd.HostPolicyEnforcedPolicyName, "")
+
+	// the policy is deleted
+	dm.ParseAndUpdateHostSecurityPolicy(hardenNodeEvent("DELETED", "/bin/sh", "/usr/bin/wget"))
+	expectTransition("the deleted policy", fd.HostPolicyUnenforcedPolicyName, fd.HostPolicyDeleted)
+	expectNoTransition("the deleted policy")
+
+	// the records are journaled for the durable sink, whatever their action
+	if pending, _ := sink.JournalStats(); pending != 4 {
+		t.Errorf("[FAIL] Expected the 4 transitions to be journaled (%d)", pending)
+	}
+	_ = sink.Close()
+
+	t.Log("[PASS] Raised the alerts of the host policies enforced on the node")
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"time"
@@ -24,6 +25,7 @@ import (
 
 	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
 	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
 	kspclient "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/client/clientset/versioned"
 )
 
@@ -330,6 +332,30 @@ func (kh *K8sHandler) PatchDeploymentWithSELinuxAnnotations(namespaceName, deplo
 	return nil
 }
 
+// ============== //
+// == Policies == //
+// ============== //
+
+// PatchPolicyAnnotations Function
+func (kh *K8sHandler) PatchPolicyAnnotations(kind, namespaceName, policyName string, annotations map[string]string) error {
+	if !kl.IsK8sEnv() || kh.KSPClient == nil { // not Kubernetes
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"annotations": annotations}})
+	if err != nil {
+		return err
+	}
+
+	if kind == "KubeArmorHostPolicy" {
+		_, err = kh.KSPClient.SecurityV1().KubeArmorHostPolicies().Patch(context.Background(), policyName, types.MergePatchType, patch, metav1.PatchOptions{})
+	} else {
+		_, err = kh.KSPClient.SecurityV1().KubeArmorPolicies(namespaceName).Patch(context.Background(), policyName, types.MergePatchType, patch, metav1.PatchOptions{})
+	}
+
+	return err
+}
+
 // ================ //
 // == ReplicaSet == //
 // ================ //
@@ -426,8 +452,14 @@ func (kh *K8sHandler) WatchK8sPods() *http.Response {
 		return nil
 	}
 
+	// only the pods of the node with the scoped informers
+	selector := ""
+	if nodeName := scopedNodeName(); nodeName != "" {
+		selector = "&fieldSelector=" + url.QueryEscape("spec.nodeName="+nodeName)
+	}
+
 	if kl.IsInK8sCluster() { // kube-apiserver
-		URL := "https://" + kh.K8sHost + ":" + kh.K8sPort + "/api/v1/pods?watch=true"
+		URL := "https://" + kh.K8sHost + ":" + kh.K8sPort + "/api/v1/pods?watch=true" + selector
 
 		req, err := http.NewRequest("GET", URL, nil)
 		if err != nil {
@@ -446,7 +478,7 @@ func (kh *K8sHandler) WatchK8sPods() *http.Response {
 	}
 
 	// kube-proxy (local)
-	URL := "http://" + kh.K8sHost + ":" + kh.K8sPort + "/api/v1/pods?watch=true"
+	URL := "http://" + kh.K8sHost + ":" + kh.K8sPort + "/api/v1/pods?watch=true" + selector
 
 	if resp, err := http.Get(URL); err == nil /* #nosec */ {
 		return resp
@@ -534,6 +566,21 @@ func (kh *K8sHandler) WatchK8sSecurityPolicies() *http.Response {
 	return nil
 }
 
+// ListK8sHostSecurityPolicies Function
+func (kh *K8sHandler) ListK8sHostSecurityPolicies() ([]tp.K8sKubeArmorHostPolicy, error) {
+	resBody, err := kh.DoRequest("GET", nil, "/apis/security.kubearmor.com/v1/kubearmorhostpolicies")
+	if err != nil {
+		return nil, err
+	}
+
+	policies := tp.K8sKubeArmorHostPolicies{}
+	if err := json.Unmarshal(resBody, &policies); err != nil {
+		return nil, err
+	}
+
+	return policies.Items, nil
+}
+
 // WatchK8sHostSecurityPolicies Function
 func (kh *K8sHandler) WatchK8sHostSecurityPolicies() *http.Response {
 	if !kl.IsK8sEnv() { // not Kubernetes
@@ -5,12 +5,20 @@ package core
 
 import (
 	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
 	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	mon "github.com/kubearmor/KubeArmor/KubeArmor/monitor"
 	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
 	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // KarmorData Structure
@@ -19,6 +27,8 @@ type KarmorData struct {
 	KernelVersion           string
 	KubeletVersion          string
 	ContainerRuntime        string
+	MonitoredRuntime        string
+	MonitoredRuntimeSocket  string
 	ActiveLSM               string
 	KernelHeaderPresent     bool
 	HostSecurity            bool
@@ -26,12 +36,52 @@ type KarmorData struct {
 	ContainerDefaultPosture tp.DefaultPosture
 	HostDefaultPosture      tp.DefaultPosture
 	HostVisibility          string
+	NodeQuiesce             string
+	Quiesced                bool
+	QuiescedSince           string
 }
 
 // Karmor provides structure to serve Policy gRPC service
 type Probe struct {
 	pb.ProbeServiceServer
-	GetContainerData func() ([]string, map[string]*pb.ContainerData, map[string]*pb.HostSecurityPolicies)
+	GetContainerData       func() ([]string, map[string]*pb.ContainerData, map[string]*pb.HostSecurityPolicies)
+	GetPosture             func(namespace, pod, operation string) (tp.PostureExplanation, error)
+	GetNsMapGCStats        func() mon.NsMapGCStats
+	GetEnforcementFailures func() map[string]uint64
+	GetDegradedEndPoints   func() []tp.DegradedEndPoint
+	GetEventClasses        func() map[string]mon.EventClassState
+	GetEffectivePolicies   func() []tp.EffectivePolicy
+	GetContainerRetries    func() []tp.ContainerRetry
+	GetContainerLeaks      func() uint64
+	GetContainerRuntime    func() (string, string)
+	GetDaemonHealth        func() tp.DaemonHealth
+	GetEnforcement         func() []tp.ContainerEnforcement
+	QueryRecentExecs       func(containerID, namespace, pod string, since time.Time) []tp.ExecRecord
+}
+
+// newProbe returns the probe service with the getters served in every mode (K8s and unorchestrated)
+func (dm *KubeArmorDaemon) newProbe() *Probe {
+	probe := &Probe{}
+
+	probe.GetDaemonHealth = dm.GetHealth
+	probe.GetEnforcement = dm.GetContainerEnforcement
+	probe.GetPosture = dm.ExplainPosture
+	probe.GetDegradedEndPoints = dm.RuntimeEnforcer.GetDegradedEndPoints
+	probe.GetEffectivePolicies = dm.GetEffectivePolicies
+	probe.GetEnforcementFailures = dm.Logger.GetEnforcementFailures
+	probe.GetContainerRetries = dm.GetContainerRetries
+	probe.GetContainerLeaks = dm.GetContainerLeaks
+
+	if dm.SystemMonitor != nil {
+		probe.GetNsMapGCStats = dm.SystemMonitor.GetNsMapGCStats
+		probe.GetEventClasses = dm.SystemMonitor.GetEventClasses
+	}
+
+	if dm.SystemMonitor != nil && dm.SystemMonitor.RecentExecs != nil {
+		probe.QueryRecentExecs = dm.GetRecentExecs
+	}
+
+	return probe
 }
 
 // SetKarmorData generates runtime configuration for KubeArmor to be consumed by kArmor
@@ -54,6 +104,8 @@ func (dm *KubeArmorDaemon) SetKarmorData() {
 	kd.KernelVersion = dm.Node.KernelVersion
 	kd.KubeletVersion = dm.Node.KubeletVersion
 	kd.ContainerRuntime = dm.Node.ContainerRuntimeVersion
+	kd.MonitoredRuntime = dm.Node.ContainerRuntime
+	kd.MonitoredRuntimeSocket = dm.Node.ContainerRuntimeSocket
 	if dm.RuntimeEnforcer != nil {
 		kd.ActiveLSM = dm.RuntimeEnforcer.EnforcerType
 
@@ -66,7 +118,16 @@ func (dm *KubeArmorDaemon) SetKarmorData() {
 	}
 	kd.KernelHeaderPresent = true //this is always true since KubeArmor is running
 	kd.HostVisibility = dm.Node.Annotations["kubearmor-visibility"]
-	err := kl.WriteToFile(kd, "/tmp/karmorProbeData.cfg")
+
+	// enforcement changes quiesced during node maintenance
+	quiesce := dm.NodeQuiesce.Stats()
+	kd.NodeQuiesce = quiesce.Mode
+	kd.Quiesced = quiesce.Quiesced
+	if quiesce.Quiesced {
+		kd.QuiescedSince = quiesce.Since.Format(time.RFC3339)
+	}
+
+	err := kl.WriteToFile(kd, cfg.ProbeDataPath)
 	if err != nil {
 		dm.Logger.Errf("Error writing karmor config data (%s)", err.Error())
 	}
@@ -76,10 +137,20 @@ func (dm *KubeArmorDaemon) SetKarmorData() {
 // SetKarmorContainerData() keeps track of containers and the applied policies
 func (dm *KubeArmorDaemon) SetProbeContainerData() ([]string, map[string]*pb.ContainerData, map[string]*pb.HostSecurityPolicies) {
 	var containerlist []string
+	riskyMounts := map[string][]string{}
+	images := map[string]string{}
 	dm.ContainersLock.Lock()
 	for _, value := range dm.Containers {
 
 		containerlist = append(containerlist, value.ContainerName)
+
+		if value.ContainerImage != "" {
+			images[value.ContainerID] = value.ContainerImage
+		}
+
+		if len(value.RiskyMounts) > 0 {
+			riskyMounts[value.ContainerID] = formatMountFindings(value.RiskyMounts)
+		}
 	}
 	dm.ContainersLock.Unlock()
 
@@ -95,9 +166,22 @@ func (dm *KubeArmorDaemon) SetProbeContainerData() ([]string, map[string]*pb.Con
 			policyNames = append(policyNames, policy.Metadata["policyName"])
 
 		}
+		var mounts []string
+		var containerImages []string
+
+		for _, containerID := range ep.Containers {
+			mounts = append(mounts, riskyMounts[containerID]...)
+
+			if image, ok := images[containerID]; ok && !kl.ContainsElement(containerImages, image) {
+				containerImages = append(containerImages, image)
+			}
+		}
+
 		containerMap[ep.EndPointName] = &pb.ContainerData{
-			PolicyList:    policyNames,
-			PolicyEnabled: int32(ep.PolicyEnabled),
+			PolicyList:      policyNames,
+			PolicyEnabled:   int32(ep.PolicyEnabled),
+			RiskyMounts:     mounts,
+			ContainerImages: containerImages,
 		}
 	}
 	dm.EndPointsLock.Unlock()
@@ -129,14 +213,318 @@ func (dm *KubeArmorDaemon) SetProbeContainerData() ([]string, map[string]*pb.Con
 
 }
 
+// GetEffectivePolicies merges the security policies of each endpoint into its effective policy
+func (dm *KubeArmorDaemon) GetEffectivePolicies() []tp.EffectivePolicy {
+	effectivePolicies := []tp.EffectivePolicy{}
+
+	dm.EndPointsLock.RLock()
+	for _, endPoint := range dm.EndPoints {
+		effective := fd.MergeSecurityPolicies(endPoint.SecurityPolicies)
+		effective.NamespaceName = endPoint.NamespaceName
+		effective.EndPointName = endPoint.EndPointName
+
+		effectivePolicies = append(effectivePolicies, effective)
+	}
+	dm.EndPointsLock.RUnlock()
+
+	sort.Slice(effectivePolicies, func(i, j int) bool {
+		if effectivePolicies[i].NamespaceName != effectivePolicies[j].NamespaceName {
+			return effectivePolicies[i].NamespaceName < effectivePolicies[j].NamespaceName
+		}
+		return effectivePolicies[i].EndPointName < effectivePolicies[j].EndPointName
+	})
+
+	return effectivePolicies
+}
+
+// GetContainerRetries returns the retry states of the containers which failed to be added
+func (dm *KubeArmorDaemon) GetContainerRetries() []tp.ContainerRetry {
+	crio := dm.getCrio()
+	if crio == nil {
+		return []tp.ContainerRetry{}
+	}
+
+	return crio.GetRetries()
+}
+
+// GetContainerLeaks returns the number of the leaked containers repaired by the audits
+func (dm *KubeArmorDaemon) GetContainerLeaks() uint64 {
+	return atomic.LoadUint64(&dm.ContainerLeaks)
+}
+
+// GetRecentExecs returns the recent executions of a container (or of the containers of a pod) since the given time
+func (dm *KubeArmorDaemon) GetRecentExecs(containerID, namespace, pod string, since time.Time) []tp.ExecRecord {
+	if dm.SystemMonitor == nil || dm.SystemMonitor.RecentExecs == nil {
+		return []tp.ExecRecord{}
+	}
+
+	if containerID != "" {
+		return dm.SystemMonitor.RecentExecs.QueryContainer(containerID, since)
+	}
+
+	return dm.SystemMonitor.RecentExecs.QueryPod(namespace, pod, since)
+}
+
 // GetProbeData() sends policy data through grpc client
 func (p *Probe) GetProbeData(c context.Context, in *empty.Empty) (*pb.ProbeResponse, error) {
+	res := &pb.ProbeResponse{}
+
+	// the containers and their policies are only served in unorchestrated mode (kubectl serves them in K8s)
+	if p.GetContainerData != nil {
+		res.ContainerList, res.ContainerMap, res.HostMap = p.GetContainerData()
+	}
+
+	// evictions of stale namespace entries, which hint missed destroy events
+	if p.GetNsMapGCStats != nil {
+		res.NsMapEvictions = p.GetNsMapGCStats().Evictions
+	}
+
+	// Block verdicts which weren't applied, per enforcer
+	if p.GetEnforcementFailures != nil {
+		res.EnforcementFailures = p.GetEnforcementFailures()
+	}
+
+	// event classes whose probes are attached (or detached while nothing needs them)
+	if p.GetEventClasses != nil {
+		res.EventClasses = map[string]*pb.EventClass{}
+		for class, state := range p.GetEventClasses() {
+			res.EventClasses[class] = &pb.EventClass{
+				Attached: state.Attached,
+				Demanded: state.Demanded,
+				Probes:   int32(state.Probes),
+				Since:    state.Since.Format(time.RFC3339),
+			}
+		}
+	}
+
+	// containers which failed to be added, and are retried (or given up)
+	if p.GetContainerRetries != nil {
+		for _, retry := range p.GetContainerRetries() {
+			containerRetry := &pb.ContainerRetry{
+				ContainerID:  retry.ContainerID,
+				Attempts:     int32(retry.Attempts),
+				FirstFailure: retry.FirstFailure.Unix(),
+				LastError:    retry.LastError,
+				GaveUp:       retry.GaveUp,
+			}
+			if !retry.NextRetry.IsZero() {
+				containerRetry.NextRetry = retry.NextRetry.Unix()
+			}
+
+			res.ContainerRetries = append(res.ContainerRetries, containerRetry)
+		}
+	}
+
+	// containers which were left behind by the lost destroy events, and removed by the audits
+	if p.GetContainerLeaks != nil {
+		res.ContainerLeaks = p.GetContainerLeaks()
+	}
+
+	// container runtime monitored, as set or detected
+	if p.GetContainerRuntime != nil {
+		res.ContainerRuntime, res.ContainerRuntimeSocket = p.GetContainerRuntime()
+	}
+
+	return res, nil
+}
+
+// ExplainPosture sends the resolution of the default posture of a pod through grpc client
+func (p *Probe) ExplainPosture(c context.Context, in *pb.PostureRequest) (*pb.PostureExplanation, error) {
+	if p.GetPosture == nil {
+		return nil, status.Error(codes.Unavailable, "postures aren't explained")
+	}
+
+	explanation, err := p.GetPosture(in.Namespace, in.Pod, in.Operation)
+	if errors.Is(err, errUnknownPostureOperation) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	res := &pb.PostureExplanation{
+		Operation: explanation.Operation,
+		Posture:   explanation.Posture,
+		Source:    explanation.Source,
+	}
+
+	for _, layer := range explanation.Layers {
+		res.Layers = append(res.Layers, &pb.PostureLayer{
+			Source:  layer.Source,
+			Posture: layer.Posture,
+			Applied: layer.Applied,
+		})
+	}
+
+	return res, nil
+}
+
+// GetEnforcementState sends the endpoints enforced in Audit only after enforcer errors, and the effective policies
+// of the endpoints through grpc client
+func (p *Probe) GetEnforcementState(c context.Context, in *empty.Empty) (*pb.EnforcementState, error) {
+	res := &pb.EnforcementState{}
+
+	if p.GetEffectivePolicies != nil {
+		for _, effective := range p.GetEffectivePolicies() {
+			policy := &pb.EffectivePolicy{
+				Namespace:             effective.NamespaceName,
+				Endpoint:              effective.EndPointName,
+				Policies:              effective.Policies,
+				ProcessAllowList:      effective.ProcessAllowList,
+				FileAllowList:         effective.FileAllowList,
+				NetworkAllowList:      effective.NetworkAllowList,
+				CapabilitiesAllowList: effective.CapabilitiesAllowList,
+			}
+
+			for _, rule := range effective.Rules {
+				policy.Rules = append(policy.Rules, &pb.EffectiveRule{
+					Kind:         rule.Kind,
+					Entity:       rule.Entity,
+					Source:       rule.Source,
+					Action:       rule.Action,
+					OwnerOnly:    rule.OwnerOnly,
+					ReadOnly:     rule.ReadOnly,
+					Recursive:    rule.Recursive,
+					Policy:       rule.Policy,
+					Overridden:   rule.Overridden,
+					OverriddenBy: rule.OverriddenBy,
+				})
+			}
+
+			res.EffectivePolicies = append(res.EffectivePolicies, policy)
+		}
+	}
+
+	if p.GetDegradedEndPoints == nil {
+		return res, nil
+	}
+
+	for _, endPoint := range p.GetDegradedEndPoints() {
+		res.Endpoints = append(res.Endpoints, &pb.DegradedEndpoint{
+			Namespace: endPoint.NamespaceName,
+			Endpoint:  endPoint.EndPointName,
+			Container: endPoint.ContainerName,
+			Enforcer:  endPoint.Enforcer,
+			Error:     endPoint.Error,
+			Since:     endPoint.Since.Unix(),
+			Retries:   int32(endPoint.Retries),
+		})
+	}
+	res.Degraded = len(res.Endpoints) > 0
+
+	return res, nil
+}
+
+// GetHealth sends the state of the connections to the container runtimes, and whether the enforcer and the system
+// monitor are initialized through grpc client
+func (p *Probe) GetHealth(c context.Context, in *empty.Empty) (*pb.HealthResponse, error) {
+	if p.GetDaemonHealth == nil {
+		return nil, status.Error(codes.Unavailable, "health isn't served")
+	}
+
+	health := p.GetDaemonHealth()
+
+	res := &pb.HealthResponse{
+		EnforcerInitialized: health.EnforcerInitialized,
+		Enforcer:            health.Enforcer,
+		MonitorInitialized:  health.MonitorInitialized,
+	}
+
+	for _, runtime := range health.Runtimes {
+		handler := &pb.RuntimeHandlerHealth{
+			Runtime:    runtime.Runtime,
+			Socket:     runtime.Socket,
+			Connected:  runtime.Connected,
+			Containers: int32(runtime.Containers),
+			LastError:  runtime.LastError,
+		}
+		if !runtime.LastList.IsZero() {
+			handler.LastList = runtime.LastList.Unix()
+		}
+
+		res.Runtimes = append(res.Runtimes, handler)
+	}
+
+	for _, feature := range health.Features {
+		res.Features = append(res.Features, &pb.BPFFeature{
+			Feature:   feature.Feature,
+			Arch:      feature.Arch,
+			Available: feature.Available,
+			Reason:    feature.Reason,
+		})
+	}
+
+	return res, nil
+}
+
+// GetContainerEnforcement sends the endpoint of each container, its AppArmor profile, and whether each of its policies
+// is loaded by the enforcer (or why it isn't) through grpc client
+func (p *Probe) GetContainerEnforcement(c context.Context, in *empty.Empty) (*pb.ContainerEnforcementResponse, error) {
+	if p.GetEnforcement == nil {
+		return nil, status.Error(codes.Unavailable, "container enforcement isn't served")
+	}
+
+	res := &pb.ContainerEnforcementResponse{}
+
+	for _, container := range p.GetEnforcement() {
+		state := &pb.ContainerEnforcement{
+			ContainerID:     container.ContainerID,
+			ContainerName:   container.ContainerName,
+			Namespace:       container.NamespaceName,
+			Endpoint:        container.EndPointName,
+			ApparmorProfile: container.AppArmorProfile,
+			PolicyEnabled:   container.PolicyEnabled,
+			Enforcer:        container.Enforcer,
+			RiskyMounts:     container.RiskyMounts,
+		}
+
+		for _, policy := range container.Policies {
+			state.Policies = append(state.Policies, &pb.PolicyEnforcement{
+				PolicyName: policy.PolicyName,
+				Actions:    policy.Actions,
+				Loaded:     policy.Loaded,
+				Error:      policy.Error,
+			})
+		}
+
+		res.Containers = append(res.Containers, state)
+	}
+
+	return res, nil
+}
+
+// GetRecentExecs sends the recent executions of a container (or of the containers of a pod) through grpc client
+func (p *Probe) GetRecentExecs(c context.Context, in *pb.RecentExecsRequest) (*pb.RecentExecsResponse, error) {
+	if p.QueryRecentExecs == nil {
+		return nil, status.Error(codes.Unavailable, "recent executions aren't kept (recentExecs is 0)")
+	}
+
+	if in.ContainerID == "" && (in.Namespace == "" || in.Pod == "") {
+		return nil, status.Error(codes.InvalidArgument, "either a container or a namespace and a pod is required")
+	}
+
+	since := time.Time{}
+	if in.Since > 0 {
+		since = time.Unix(in.Since, 0)
+	}
+
+	res := &pb.RecentExecsResponse{}
 
-	containerList, containerMap, hostMap := p.GetContainerData()
-	res := &pb.ProbeResponse{
-		ContainerList: containerList,
-		ContainerMap:  containerMap,
-		HostMap:       hostMap,
+	for _, exec := range p.QueryRecentExecs(in.ContainerID, in.Namespace, in.Pod, since) {
+		res.Execs = append(res.Execs, &pb.ExecRecord{
+			TimestampNano:  exec.Timestamp.UnixNano(),
+			ContainerID:    exec.ContainerID,
+			Namespace:      exec.NamespaceName,
+			Pod:            exec.PodName,
+			HostPID:        exec.HostPID,
+			Pid:            exec.PID,
+			Ppid:           exec.PPID,
+			Uid:            exec.UID,
+			ExecPath:       exec.ExecPath,
+			ParentExecPath: exec.ParentExecPath,
+			ArgsHash:       exec.ArgsHash,
+			Args:           exec.Args,
+			Redacted:       exec.Redacted,
+		})
 	}
 
 	return res, nil
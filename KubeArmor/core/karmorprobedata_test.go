@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	mon "github.com/kubearmor/KubeArmor/KubeArmor/monitor"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestProbeInK8s(t *testing.T) {
+	dm := NewKubeArmorDaemon()
+	dm.K8sEnabled = true
+	dm.Logger = &fd.Feeder{Node: &dm.Node, EnforcementFailures: map[string]uint64{}, EnforcementFailuresLock: new(sync.RWMutex)}
+
+	probe := dm.newProbe()
+
+	// the postures of the pods are explained in K8s too (only there the namespaces are annotated)
+	if _, err := probe.ExplainPosture(context.Background(), &pb.PostureRequest{Namespace: "payments", Pod: "checkout", Operation: "file"}); status.Code(err) != codes.NotFound {
+		t.Errorf("[FAIL] Expected the posture of an unknown pod not to be found, got %v", err)
+	}
+
+	// the endpoints enforced in Audit only are served in K8s too
+	if probe.GetDegradedEndPoints == nil {
+		t.Errorf("[FAIL] Expected the degraded endpoints to be served in K8s")
+	} else if state, err := probe.GetEnforcementState(context.Background(), &empty.Empty{}); err != nil || state.Degraded {
+		t.Errorf("[FAIL] Unexpected enforcement state without an enforcer (%v, %v)", state, err)
+	}
+
+	// the effective policies of the pods are served in K8s too
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "payments", "policyName": "block-shell"}}
+	policy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Block"}}
+	dm.EndPoints = []tp.EndPoint{{NamespaceName: "payments", EndPointName: "checkout", SecurityPolicies: []tp.SecurityPolicy{policy}}}
+
+	if state, err := probe.GetEnforcementState(context.Background(), &empty.Empty{}); err != nil || len(state.EffectivePolicies) != 1 || state.EffectivePolicies[0].Endpoint != "checkout" {
+		t.Errorf("[FAIL] Expected the effective policy of the pod (%v, %v)", state, err)
+	}
+
+	// the Block verdicts which weren't applied are served in K8s too
+	dm.Logger.EnforcementFailures["AppArmor"] = 1
+
+	if data, err := probe.GetProbeData(context.Background(), &empty.Empty{}); err != nil || data.EnforcementFailures["AppArmor"] != 1 || len(data.ContainerMap) != 0 {
+		t.Errorf("[FAIL] Expected the enforcement failures in K8s (%v, %v)", data, err)
+	}
+
+	// the containers retried and the leaked ones are served in K8s too
+	dm.ContainerLeaks = 2
+
+	if data, err := probe.GetProbeData(context.Background(), &empty.Empty{}); err != nil || data.ContainerLeaks != 2 || len(data.ContainerRetries) != 0 {
+		t.Errorf("[FAIL] Expected the container leaks in K8s (%v, %v)", data, err)
+	}
+
+	// the evictions of the stale namespace entries and the states of the event classes are served in K8s too
+	dm.SystemMonitor = &mon.SystemMonitor{NsMapLock: new(sync.RWMutex), NsMapGCStats: mon.NsMapGCStats{Evictions: 3},
+		ProbesLock: new(sync.Mutex), EventClasses: map[string]*mon.EventClassState{"network": {Attached: true, Demanded: true, Probes: 2}}}
+
+	if data, err := dm.newProbe().GetProbeData(context.Background(), &empty.Empty{}); err != nil || data.NsMapEvictions != 3 {
+		t.Errorf("[FAIL] Expected the namespace map evictions in K8s (%v, %v)", data, err)
+	} else if class := data.EventClasses["network"]; class == nil || !class.Attached || class.Probes != 2 {
+		t.Errorf("[FAIL] Expected the event classes in K8s (%v)", data.EventClasses)
+	}
+
+	t.Log("[PASS] Served the probe in K8s")
+}
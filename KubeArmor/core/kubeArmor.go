@@ -5,6 +5,7 @@
 package core
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"strings"
@@ -17,7 +18,7 @@ import (
 	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
 	"github.com/kubearmor/KubeArmor/KubeArmor/policy"
 	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
-	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc"
 
 	efc "github.com/kubearmor/KubeArmor/KubeArmor/enforcer"
 	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
@@ -39,6 +40,23 @@ func init() {
 	StopChan = make(chan struct{})
 }
 
+// stopContext Function returns a context which is cancelled when KubeArmor is stopped, so that the calls in flight
+// don't block the shutdown
+func stopContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stop := StopChan
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
 // KubeArmorDaemon Structure
 type KubeArmorDaemon struct {
 	// node
@@ -68,10 +86,17 @@ type KubeArmorDaemon struct {
 	HostSecurityPolicies     []tp.HostSecurityPolicy
 	HostSecurityPoliciesLock *sync.RWMutex
 
+	// opened once the host security policies are applied at startup (nil if not waited for)
+	HostPoliciesSynced *StartupGate
+
 	//DefaultPosture (namespace -> postures)
 	DefaultPostures     map[string]tp.DefaultPosture
 	DefaultPosturesLock *sync.Mutex
 
+	// SeverityRange (namespace -> severity range)
+	SeverityRanges     map[string]tp.SeverityRange
+	SeverityRangesLock *sync.RWMutex
+
 	// pid map
 	ActiveHostPidMap map[string]tp.PidMap
 	ActivePidMapLock *sync.RWMutex
@@ -79,6 +104,9 @@ type KubeArmorDaemon struct {
 	// logger
 	Logger *fd.Feeder
 
+	// k8s event sink (nil unless enabled)
+	K8sEventSink *fd.K8sEventSink
+
 	// system monitor
 	SystemMonitor *mon.SystemMonitor
 
@@ -88,11 +116,73 @@ type KubeArmorDaemon struct {
 	// kvm agent
 	KVMAgent *kvm.KVMAgent
 
+	// container runtime handlers
+	crio       *CrioHandler
+	containerd *ContainerdHandler
+	docker     *DockerHandler
+	podman     *PodmanHandler
+
+	// the handler of CRI-O is replaced when CRI-O is re-dialed
+	crioLock *sync.RWMutex
+
+	// the lifecycle events of containerd or CRI-O received as an NRI plugin, the handler is dropped once its
+	// connection is lost (then the runtime is monitored instead)
+	nri     *NRIHandler
+	nriLock *sync.RWMutex
+
+	// state of the runtime handlers reported by the health probe (runtime -> state)
+	RuntimeHealth     map[string]func() tp.RuntimeHandlerHealth
+	RuntimeHealthLock *sync.RWMutex
+
 	// WgDaemon Handler
 	WgDaemon sync.WaitGroup
 
 	// system monitor lock
 	MonitorLock *sync.RWMutex
+
+	// started containers whose endpoints don't exist yet (guarded by EndPointsLock)
+	PendingAttach map[string]tp.Container
+
+	// quiesce mode during node maintenance
+	NodeQuiesce *NodeQuiesce
+
+	// last checks of the matchPaths of policies (namespace/policy -> check)
+	PolicyPathChecks     map[string]policyPathCheck
+	PolicyPathChecksLock *sync.Mutex
+
+	// notes of the consolidation of the rules of policies (namespace/policy -> notes),
+	// and the rule counts of the endpoints warned about (namespace/endpoint/container -> count)
+	RuleConsolidations     map[string][]string
+	EndPointRuleWarnings   map[string]int
+	RuleConsolidationsLock *sync.Mutex
+
+	// host security policies enforced on the node (policy -> rules, guarded by HostSecurityPoliciesLock)
+	EnforcedHostPolicies map[string]int
+
+	// order of the events of the security policies
+	PolicyOrder *PolicyOrder
+
+	// policies whose Block rules are audited until their maturation period elapses
+	PolicyMaturation *PolicyMaturation
+
+	// policies active within the windows of their schedules only
+	PolicySchedules *PolicySchedules
+
+	// overrides of the node-local override file, applied with the highest priority until they expire
+	PolicyOverrides *PolicyOverrides
+
+	// cluster policies, expanded into the security policies of the namespaces matching their selectors
+	ClusterPolicies *ClusterPolicies
+
+	// on-demand resync (held while running)
+	ResyncLock *sync.Mutex
+	LastResync time.Time
+
+	// containers leaked by the lost destroy events, repaired by the audits (atomic)
+	ContainerLeaks uint64
+
+	// attachment of the AppArmor profiles to the new containers
+	AppArmorAttachment *AppArmorAttachment
 }
 
 // NewKubeArmorDaemon Function
@@ -111,16 +201,21 @@ func NewKubeArmorDaemon() *KubeArmorDaemon {
 	dm.ContainersLock = new(sync.RWMutex)
 	dm.EndPoints = []tp.EndPoint{}
 	dm.EndPointsLock = new(sync.RWMutex)
+	dm.PendingAttach = map[string]tp.Container{}
 
 	dm.SecurityPolicies = []tp.SecurityPolicy{}
 	dm.SecurityPoliciesLock = new(sync.RWMutex)
 
 	dm.HostSecurityPolicies = []tp.HostSecurityPolicy{}
 	dm.HostSecurityPoliciesLock = new(sync.RWMutex)
+	dm.EnforcedHostPolicies = map[string]int{}
 
 	dm.DefaultPostures = map[string]tp.DefaultPosture{}
 	dm.DefaultPosturesLock = new(sync.Mutex)
 
+	dm.SeverityRanges = map[string]tp.SeverityRange{}
+	dm.SeverityRangesLock = new(sync.RWMutex)
+
 	dm.ActiveHostPidMap = map[string]tp.PidMap{}
 	dm.ActivePidMapLock = new(sync.RWMutex)
 
@@ -129,15 +224,45 @@ func NewKubeArmorDaemon() *KubeArmorDaemon {
 	dm.RuntimeEnforcer = nil
 	dm.KVMAgent = nil
 
+	dm.RuntimeHealth = map[string]func() tp.RuntimeHandlerHealth{}
+	dm.RuntimeHealthLock = new(sync.RWMutex)
+
+	dm.crioLock = new(sync.RWMutex)
+	dm.nriLock = new(sync.RWMutex)
+
 	dm.WgDaemon = sync.WaitGroup{}
 
 	dm.MonitorLock = new(sync.RWMutex)
 
+	dm.NodeQuiesce = NewNodeQuiesce(cfg.GlobalCfg.NodeQuiesce, cfg.GlobalCfg.NodeQuiesceInterval)
+
+	dm.PolicyPathChecks = map[string]policyPathCheck{}
+	dm.PolicyPathChecksLock = new(sync.Mutex)
+
+	dm.RuleConsolidations = map[string][]string{}
+	dm.EndPointRuleWarnings = map[string]int{}
+	dm.RuleConsolidationsLock = new(sync.Mutex)
+
+	dm.PolicyOrder = NewPolicyOrder()
+
+	dm.PolicyMaturation = NewPolicyMaturation()
+
+	dm.PolicySchedules = NewPolicySchedules()
+
+	dm.PolicyOverrides = NewPolicyOverrides()
+
+	dm.ClusterPolicies = NewClusterPolicies()
+
+	dm.ResyncLock = new(sync.Mutex)
+
 	return dm
 }
 
 // DestroyKubeArmorDaemon Function
 func (dm *KubeArmorDaemon) DestroyKubeArmorDaemon() {
+	// apply the removals deferred during node maintenance
+	dm.NodeQuiesce.Stop()
+
 	if dm.RuntimeEnforcer != nil {
 		// close runtime enforcer
 		if dm.CloseRuntimeEnforcer() {
@@ -179,6 +304,9 @@ func (dm *KubeArmorDaemon) DestroyKubeArmorDaemon() {
 	kg.Print("Waiting for routine terminations")
 	dm.WgDaemon.Wait()
 
+	// close runtime handlers
+	dm.CloseRuntimeHandlers()
+
 	// delete pid file
 	if _, err := os.Stat(cfg.PIDFilePath); err == nil {
 		kg.Print("Deleting PID file")
@@ -206,6 +334,93 @@ func (dm *KubeArmorDaemon) ServeLogFeeds() {
 	defer dm.WgDaemon.Done()
 
 	go dm.Logger.ServeLogFeeds()
+
+	// serve the metrics if configured
+	if cfg.GlobalCfg.MetricsAddr != "" {
+		go dm.Logger.ServeMetrics(cfg.GlobalCfg.MetricsAddr)
+	}
+}
+
+// InitK8sEventSink Function
+func (dm *KubeArmorDaemon) InitK8sEventSink() bool {
+	// no API server to report to in standalone mode
+	if !dm.K8sEnabled || K8s.K8sClient == nil {
+		return false
+	}
+
+	sink := fd.NewK8sEventSink(K8s.K8sClient, cfg.GlobalCfg.K8sEventsMinSeverity, time.Second*10)
+	if sink == nil {
+		return false
+	}
+	sink.Start()
+
+	dm.K8sEventSink = sink
+	dm.Logger.AddSink(sink)
+	return true
+}
+
+// InitWebhookSink Function
+func (dm *KubeArmorDaemon) InitWebhookSink() error {
+	config := fd.WebhookSinkConfig{
+		URL:                cfg.GlobalCfg.WebhookURL,
+		Headers:            map[string]string{},
+		MinSeverity:        cfg.GlobalCfg.WebhookMinSeverity,
+		Enforcers:          cfg.GlobalCfg.WebhookEnforcers,
+		BatchSize:          cfg.GlobalCfg.WebhookBatchSize,
+		BatchInterval:      cfg.GlobalCfg.WebhookBatchInterval,
+		CAFile:             cfg.GlobalCfg.WebhookCAFile,
+		InsecureSkipVerify: cfg.GlobalCfg.WebhookInsecureSkipVerify,
+	}
+
+	for _, header := range cfg.GlobalCfg.WebhookHeaders {
+		if key, val, ok := strings.Cut(header, "="); ok {
+			config.Headers[strings.TrimSpace(key)] = strings.TrimSpace(val)
+		}
+	}
+
+	if cfg.GlobalCfg.WebhookSecretFile != "" {
+		secret, err := os.ReadFile(cfg.GlobalCfg.WebhookSecretFile)
+		if err != nil {
+			return err
+		}
+		config.Secret = strings.TrimSpace(string(secret))
+	}
+
+	sink, err := fd.NewWebhookSink(config)
+	if err != nil {
+		return err
+	}
+	sink.Start()
+
+	dm.Logger.AddSink(sink)
+	return nil
+}
+
+// InitDurableSink Function
+func (dm *KubeArmorDaemon) InitDurableSink() error {
+	store, err := fd.NewFileStore(cfg.GlobalCfg.DurableSinkFile)
+	if err != nil {
+		return err
+	}
+
+	config := fd.DurableSinkConfig{
+		Actions: cfg.GlobalCfg.DurableSinkActions,
+		Journal: fd.AlertJournalConfig{
+			Dir:        cfg.AlertJournalDir,
+			MaxRecords: cfg.GlobalCfg.DurableSinkJournalSize,
+			Overflow:   cfg.GlobalCfg.DurableSinkOverflow,
+		},
+	}
+
+	sink, err := fd.NewDurableSink(config, store)
+	if err != nil {
+		_ = store.Close()
+		return err
+	}
+	sink.Start()
+
+	dm.Logger.AddSink(sink)
+	return nil
 }
 
 // CloseLogger Function
@@ -228,6 +443,10 @@ func (dm *KubeArmorDaemon) InitSystemMonitor() bool {
 		return false
 	}
 
+	if dm.SystemMonitor.Unattributed != nil && dm.Logger.PolicyMetrics != nil {
+		dm.SystemMonitor.Unattributed.Register(dm.Logger.PolicyMetrics.Registry)
+	}
+
 	if err := dm.SystemMonitor.InitBPF(); err != nil {
 		kg.Errf("Failed to initialize BPF (%s)", err.Error())
 		return false
@@ -245,6 +464,9 @@ func (dm *KubeArmorDaemon) MonitorSystemEvents() {
 		go dm.SystemMonitor.TraceSyscall()
 		go dm.SystemMonitor.UpdateLogs()
 		go dm.SystemMonitor.CleanUpExitedHostPids()
+		go dm.SystemMonitor.CollectNsMap()
+		go dm.SystemMonitor.SummarizeFlows()
+		go dm.SystemMonitor.WatchEventClasses(dm.getPolicyEventClasses)
 	}
 }
 
@@ -324,6 +546,16 @@ func GetOSSigChannel() chan os.Signal {
 
 // KubeArmor Function
 func KubeArmor() {
+	// resolve the proc mounts of container runtimes and the host
+	kl.SetupProcFs(cfg.GlobalCfg.ProcFsMount, cfg.GlobalCfg.HostProcPath)
+
+	// fail fast if a path to write to is not writable (e.g., with readOnlyRootFilesystem)
+	lsms, _ := os.ReadFile("/sys/kernel/security/lsm")
+	if err := checkWritablePaths(requiredWritablePaths(string(lsms))); err != nil {
+		kg.Err(err.Error())
+		return
+	}
+
 	// create a daemon
 	dm := NewKubeArmorDaemon()
 	// Enable KubeArmorHostPolicy for both VM and KVMAgent and in non-k8s env
@@ -427,6 +659,33 @@ func KubeArmor() {
 	}
 	dm.Logger.Print("Initialized KubeArmor Logger")
 
+	// reduce telemetry while the node is under maintenance
+	dm.NodeQuiesce.SetOnChange(dm.onNodeQuiesce)
+
+	if cfg.GlobalCfg.K8sEvents {
+		if dm.InitK8sEventSink() {
+			dm.Logger.Printf("Started to report Block alerts (severity >= %d) as k8s events", cfg.GlobalCfg.K8sEventsMinSeverity)
+		} else {
+			dm.Logger.Print("Skipped reporting alerts as k8s events since no API server is available")
+		}
+	}
+
+	if cfg.GlobalCfg.WebhookURL != "" {
+		if err := dm.InitWebhookSink(); err != nil {
+			dm.Logger.Warnf("Failed to start posting alerts to the webhook (%s)", err.Error())
+		} else {
+			dm.Logger.Printf("Started to post alerts (severity >= %d) to the webhook", cfg.GlobalCfg.WebhookMinSeverity)
+		}
+	}
+
+	if cfg.GlobalCfg.DurableSinkFile != "" {
+		if err := dm.InitDurableSink(); err != nil {
+			dm.Logger.Warnf("Failed to start persisting alerts to the durable sink (%s)", err.Error())
+		} else {
+			dm.Logger.Printf("Started to persist %s alerts to %s", strings.Join(cfg.GlobalCfg.DurableSinkActions, ","), cfg.GlobalCfg.DurableSinkFile)
+		}
+	}
+
 	// == //
 
 	// Containerized workloads with Host
@@ -452,6 +711,17 @@ func KubeArmor() {
 		} else {
 			dm.Logger.Print("Initialized KubeArmor Enforcer")
 
+			// the profiles of KubeArmor are only attached to the containers started after they are generated
+			if dm.K8sEnabled && cfg.GlobalCfg.Policy && dm.RuntimeEnforcer.EnforcerType == "AppArmor" {
+				dm.AppArmorAttachment = NewAppArmorAttachment()
+				if dm.Logger.PolicyMetrics != nil {
+					dm.AppArmorAttachment.Register(dm.Logger.PolicyMetrics.Registry)
+				}
+
+				go dm.WatchAppArmorAttachment()
+				dm.Logger.Print("Started to watch the attachment of AppArmor profiles")
+			}
+
 			if cfg.GlobalCfg.Policy && !cfg.GlobalCfg.HostPolicy {
 				dm.Logger.Print("Started to protect containers")
 			} else if !cfg.GlobalCfg.Policy && cfg.GlobalCfg.HostPolicy {
@@ -469,136 +739,33 @@ func KubeArmor() {
 
 		dm.SetContainerNSVisibility()
 
-		// Check if cri socket set, if not then auto detect
-		if cfg.GlobalCfg.CRISocket == "" {
-			if kl.GetCRISocket("") == "" {
-				dm.Logger.Warnf("Error while looking for CRI socket file")
-				enableContainerPolicy = false
-			} else {
-				cfg.GlobalCfg.CRISocket = "unix://" + kl.GetCRISocket("")
-			}
-		}
-
-		// monitor containers
-		if strings.Contains(cfg.GlobalCfg.CRISocket, "docker") {
-			// update already deployed containers
-			dm.GetAlreadyDeployedDockerContainers()
-			// monitor docker events
-			go dm.MonitorDockerEvents()
-		} else if strings.Contains(cfg.GlobalCfg.CRISocket, "containerd") {
-			// monitor containerd events
-			go dm.MonitorContainerdEvents()
-		} else if strings.Contains(cfg.GlobalCfg.CRISocket, "cri-o") {
-			// monitor crio events
-			go dm.MonitorCrioEvents()
-		} else {
-			dm.Logger.Warnf("Failed to monitor containers: %s is not a supported CRI socket.", cfg.GlobalCfg.CRISocket)
+		// the sockets set explicitly, or the detected runtime
+		if runtime, err := dm.selectContainerRuntime(); err != nil {
+			dm.Logger.Warnf("Failed to monitor containers (%s)", err.Error())
 			enableContainerPolicy = false
+		} else {
+			dm.monitorContainerRuntime(runtime)
 		}
-
-		dm.Logger.Printf("Using %s for monitoring containers", cfg.GlobalCfg.CRISocket)
 	}
 
 	if dm.K8sEnabled && cfg.GlobalCfg.Policy {
-		// check if the CRI socket set while executing kubearmor exists
-		if cfg.GlobalCfg.CRISocket != "" {
-			trimmedSocket := strings.TrimPrefix(cfg.GlobalCfg.CRISocket, "unix://")
-			if _, err := os.Stat(trimmedSocket); err != nil {
-				dm.Logger.Warnf("Error while looking for CRI socket file: %s", err.Error())
-
-				// destroy the daemon
-				dm.DestroyKubeArmorDaemon()
-				return
-			}
-
-			// monitor containers
-			if strings.Contains(dm.Node.ContainerRuntimeVersion, "docker") || strings.Contains(cfg.GlobalCfg.CRISocket, "docker") {
-				// update already deployed containers
-				dm.GetAlreadyDeployedDockerContainers()
-				// monitor docker events
-				go dm.MonitorDockerEvents()
-			} else if strings.Contains(dm.Node.ContainerRuntimeVersion, "containerd") || strings.Contains(cfg.GlobalCfg.CRISocket, "containerd") {
-				// monitor containerd events
-				go dm.MonitorContainerdEvents()
-			} else if strings.Contains(dm.Node.ContainerRuntimeVersion, "cri-o") || strings.Contains(cfg.GlobalCfg.CRISocket, "cri-o") {
-				// monitor crio events
-				go dm.MonitorCrioEvents()
-			} else {
-				dm.Logger.Errf("Failed to monitor containers: %s is not a supported CRI socket.", cfg.GlobalCfg.CRISocket)
-				// destroy the daemon
-				dm.DestroyKubeArmorDaemon()
-				return
-			}
-
-			dm.Logger.Printf("Using %s for monitoring containers", cfg.GlobalCfg.CRISocket)
-
-		} else { // CRI socket not set, we'll have to auto detect
-			dm.Logger.Print("CRI socket not set. Trying to detect.")
-
-			if strings.HasPrefix(dm.Node.ContainerRuntimeVersion, "docker") {
-				socketFile := kl.GetCRISocket("docker")
-
-				if socketFile != "" {
-					cfg.GlobalCfg.CRISocket = "unix://" + socketFile
-
-					// update already deployed containers
-					dm.GetAlreadyDeployedDockerContainers()
-
-					// monitor docker events
-					go dm.MonitorDockerEvents()
-				} else {
-					// we might have to use containerd's socket as docker's socket is not
-					// available
-					socketFile := kl.GetCRISocket("containerd")
-
-					if socketFile != "" {
-						cfg.GlobalCfg.CRISocket = "unix://" + socketFile
-
-						// monitor containerd events
-						go dm.MonitorContainerdEvents()
-					} else {
-						dm.Logger.Err("Failed to monitor containers (Docker socket file is not accessible)")
-
-						// destroy the daemon
-						dm.DestroyKubeArmorDaemon()
-
-						return
-					}
-				}
-			} else if strings.HasPrefix(dm.Node.ContainerRuntimeVersion, "containerd") { // containerd
-				socketFile := kl.GetCRISocket("containerd")
-
-				if socketFile != "" {
-					cfg.GlobalCfg.CRISocket = "unix://" + socketFile
-
-					// monitor containerd events
-					go dm.MonitorContainerdEvents()
-				} else {
-					dm.Logger.Err("Failed to monitor containers (Containerd socket file is not accessible)")
-
-					// destroy the daemon
-					dm.DestroyKubeArmorDaemon()
-
-					return
-				}
-			} else if strings.HasPrefix(dm.Node.ContainerRuntimeVersion, "cri-o") { // cri-o
-				socketFile := kl.GetCRISocket("cri-o")
+		// the CRI socket set while executing kubearmor, or the detected runtime
+		runtime, err := dm.selectContainerRuntime()
+		if err != nil {
+			dm.Logger.Errf("Failed to monitor containers (%s)", err.Error())
 
-				if socketFile != "" {
-					cfg.GlobalCfg.CRISocket = "unix://" + socketFile
+			// destroy the daemon
+			dm.DestroyKubeArmorDaemon()
 
-					// monitor cri-o events
-					go dm.MonitorCrioEvents()
-				} else {
-					dm.Logger.Err("Failed to monitor containers (CRI-O socket file is not accessible)")
+			return
+		}
 
-					// destroy the daemon
-					dm.DestroyKubeArmorDaemon()
+		dm.monitorContainerRuntime(runtime)
+	}
 
-					return
-				}
-			}
-		}
+	if cfg.GlobalCfg.Policy && enableContainerPolicy {
+		// defer the updates of the paused containers until they're unpaused
+		go dm.WatchContainerStates()
 	}
 
 	// == //
@@ -608,7 +775,16 @@ func KubeArmor() {
 
 	// == //
 
+	if dm.K8sEnabled && cfg.GlobalCfg.Policy && cfg.GlobalCfg.HostPolicy && cfg.GlobalCfg.ApplyHostPoliciesFirst {
+		// apply the host security policies before the container security policies
+		dm.HostPoliciesSynced = NewStartupGate()
+	}
+
 	if dm.K8sEnabled && cfg.GlobalCfg.Policy {
+		// batch removals while the node is under maintenance
+		dm.NodeQuiesce.Start()
+		dm.Logger.Printf("Started to watch node maintenance (nodeQuiesce=%s)", dm.NodeQuiesce.Mode)
+
 		// watch k8s pods
 		go dm.WatchK8sPods()
 		dm.Logger.Print("Started to monitor Pod events")
@@ -617,6 +793,10 @@ func KubeArmor() {
 		go dm.WatchSecurityPolicies()
 		dm.Logger.Print("Started to monitor security policies")
 
+		// watch cluster security policies
+		go dm.WatchClusterPolicies()
+		dm.Logger.Print("Started to monitor cluster security policies")
+
 		// watch default posture
 		go dm.WatchDefaultPosture()
 		dm.Logger.Print("Started to monitor per-namespace default posture")
@@ -633,6 +813,19 @@ func KubeArmor() {
 		dm.Logger.Print("Started to monitor host security policies")
 	}
 
+	if cfg.GlobalCfg.Policy || cfg.GlobalCfg.HostPolicy {
+		// enforce the Block rules of the policies once their maturation period elapses
+		go dm.WatchPolicyMaturation()
+	}
+
+	if cfg.GlobalCfg.Policy {
+		// activate and deactivate the policies when the windows of their schedules start and end
+		go dm.WatchPolicySchedules()
+	}
+
+	// the health of the runtime handlers is served in every mode
+	probe := dm.newProbe()
+
 	if !dm.K8sEnabled && (enableContainerPolicy || cfg.GlobalCfg.HostPolicy) {
 		policyService := &policy.ServiceServer{}
 		if enableContainerPolicy {
@@ -644,15 +837,26 @@ func KubeArmor() {
 			dm.Node.PolicyEnabled = tp.KubeArmorPolicyEnabled
 			dm.Logger.Print("Started to monitor host security policies on gRPC")
 		}
-		pb.RegisterPolicyServiceServer(dm.Logger.LogServer, policyService)
+		dm.Logger.RegisterService(cfg.GRPCServicePolicy, func(server *grpc.Server) {
+			pb.RegisterPolicyServiceServer(server, policyService)
+		})
 		//Enable grpc service to send kubearmor data to client in unorchestrated mode
-		probe := &Probe{}
 		probe.GetContainerData = dm.SetProbeContainerData
-		pb.RegisterProbeServiceServer(dm.Logger.LogServer, probe)
+		probe.GetContainerRuntime = dm.GetContainerRuntime
 
 	}
 
-	reflection.Register(dm.Logger.LogServer) // Helps grpc clients list out what all svc/endpoints available
+	dm.Logger.RegisterService(cfg.GRPCServiceProbe, func(server *grpc.Server) {
+		pb.RegisterProbeServiceServer(server, probe)
+	})
+
+	// serve on-demand resyncs, the previews of config changes, and the simulations of policies
+	dm.Logger.RegisterService(cfg.GRPCServiceAdmin, func(server *grpc.Server) {
+		pb.RegisterAdminServiceServer(server, &Admin{Resync: dm.TriggerResync, Preview: dm.PreviewConfigChange, Simulate: dm.SimulatePolicyTrace})
+	})
+
+	// trigger a resync on SIGUSR1 as well
+	go dm.WatchResyncSignal()
 
 	// serve log feeds
 	go dm.ServeLogFeeds()
@@ -667,6 +871,17 @@ func KubeArmor() {
 		// Restore and apply all kubearmor host security policies
 		dm.restoreKubeArmorPolicies()
 	}
+
+	if cfg.GlobalCfg.HostPolicy {
+		// apply the host policies of KubeArmor (e.g., protecting its policy cache and config)
+		dm.ApplyDefaultHostPolicies()
+	}
+
+	if cfg.GlobalCfg.PolicyOverrideFile != "" && (cfg.GlobalCfg.Policy || cfg.GlobalCfg.HostPolicy) {
+		// apply the emergency overrides of the node, even without the API server
+		go dm.WatchPolicyOverrides()
+		dm.Logger.Printf("Started to watch the policy overrides (%s)", cfg.GlobalCfg.PolicyOverrideFile)
+	}
 	// == //
 
 	// Init KvmAgent
@@ -9,12 +9,15 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
 	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
 	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
 	"github.com/kubearmor/KubeArmor/KubeArmor/monitor"
 	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
@@ -25,6 +28,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -79,6 +83,8 @@ func (dm *KubeArmorDaemon) HandleNodeAnnotations(node *tp.Node) {
 			node.NetworkVisibilityEnabled = true
 		} else if visibility == "capabilities" {
 			node.CapabilitiesVisibilityEnabled = true
+		} else if visibility == "signal" {
+			node.SignalVisibilityEnabled = true
 		}
 	}
 }
@@ -92,6 +98,14 @@ func matchHost(hostName string) bool {
 	return nodeName == cfg.GlobalCfg.Host
 }
 
+// scopedNodeName returns the name of the node which the watches are scoped to ("" to watch all the nodes)
+func scopedNodeName() string {
+	if !cfg.GlobalCfg.ScopedInformers {
+		return ""
+	}
+	return os.Getenv("KUBEARMOR_NODENAME")
+}
+
 func (dm *KubeArmorDaemon) checkAndUpdateNode(item *corev1.Node) {
 	if !matchHost(item.Name) {
 		return
@@ -140,17 +154,42 @@ func (dm *KubeArmorDaemon) checkAndUpdateNode(item *corev1.Node) {
 
 	dm.HandleNodeAnnotations(&node)
 
-	// update node info
+	// update node info, keeping the monitored runtime
 	dm.NodeLock.Lock()
+	node.ContainerRuntime = dm.Node.ContainerRuntime
+	node.ContainerRuntimeSocket = dm.Node.ContainerRuntimeSocket
+	labelsChanged := !reflect.DeepEqual(dm.Node.Identities, node.Identities)
 	dm.Node = node
 	dm.NodeLock.Unlock()
+
+	// the host security policies selected by the labels of the node
+	if labelsChanged && cfg.GlobalCfg.HostPolicy {
+		dm.UpdateHostSecurityPolicies()
+	}
+
+	// quiesce enforcement changes while the node is cordoned
+	dm.NodeQuiesce.SetCordoned(item.Spec.Unschedulable)
 }
 
 // WatchK8sNodes Function
 func (dm *KubeArmorDaemon) WatchK8sNodes() {
 	kg.Printf("GlobalCfg.Host=%s, KUBEARMOR_NODENAME=%s", cfg.GlobalCfg.Host, os.Getenv("KUBEARMOR_NODENAME"))
 
-	factory := informers.NewSharedInformerFactory(K8s.K8sClient, 0)
+	if dm.watchK8sNodes(K8s.K8sClient, wait.NeverStop) {
+		kg.Print("Started watching node information")
+	}
+}
+
+// watchK8sNodes watches the nodes with the given client until stopped
+func (dm *KubeArmorDaemon) watchK8sNodes(client kubernetes.Interface, stopCh <-chan struct{}) bool {
+	options := []informers.SharedInformerOption{}
+	if nodeName := scopedNodeName(); nodeName != "" {
+		options = append(options, informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = "metadata.name=" + nodeName
+		}))
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0, options...)
 	informer := factory.Core().V1().Nodes().Informer()
 
 	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -166,13 +205,13 @@ func (dm *KubeArmorDaemon) WatchK8sNodes() {
 		},
 	}); err != nil {
 		kg.Err("Couldn't Start Watching node information")
-		return
+		return false
 	}
 
-	go factory.Start(wait.NeverStop)
-	factory.WaitForCacheSync(wait.NeverStop)
-	kg.Print("Started watching node information")
+	go factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
 
+	return true
 }
 
 // ================ //
@@ -181,12 +220,16 @@ func (dm *KubeArmorDaemon) WatchK8sNodes() {
 
 // UpdateEndPointWithPod Function
 func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
+	// the endpoints may need the probes of a detached event class
+	defer dm.requestEventClassUpdate()
+
 	if action == "ADDED" {
 		// create a new endpoint
 		newPoint := tp.EndPoint{}
 
 		newPoint.NamespaceName = pod.Metadata["namespaceName"]
 		newPoint.EndPointName = pod.Metadata["podName"]
+		newPoint.PodUID = pod.Metadata["podUID"]
 		newPoint.Owner.Ref = pod.Metadata["owner.controller"]
 		newPoint.Owner.Name = pod.Metadata["owner.controllerName"]
 		newPoint.Owner.Namespace = pod.Metadata["owner.namespace"]
@@ -223,6 +266,8 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 				newPoint.NetworkVisibilityEnabled = true
 			} else if visibility == "capabilities" {
 				newPoint.CapabilitiesVisibilityEnabled = true
+			} else if visibility == "signal" {
+				newPoint.SignalVisibilityEnabled = true
 			}
 		}
 
@@ -235,6 +280,7 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 		}
 
 		containersAppArmorProfiles := map[string]string{}
+		imageDigests := map[string]string{}
 
 		// update containers and apparmors
 		dm.ContainersLock.Lock()
@@ -254,7 +300,10 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 			container.Labels = strings.Join(labels, ",")
 
 			container.ContainerName = pod.Containers[containerID]
-			container.ContainerImage = pod.ContainerImages[containerID]
+			// keep the digest resolved by the runtime (ImageRef) if the pod status has none
+			container.ContainerImage = kl.GetContainerImage(pod.ContainerImages[containerID], container.ContainerImage)
+			container.ContainerType = pod.ContainerTypes[containerID]
+			container.SecurityContext = pod.SecurityContexts[pod.Containers[containerID]]
 
 			container.PolicyEnabled = newPoint.PolicyEnabled
 
@@ -262,8 +311,10 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 			container.FileVisibilityEnabled = newPoint.FileVisibilityEnabled
 			container.NetworkVisibilityEnabled = newPoint.NetworkVisibilityEnabled
 			container.CapabilitiesVisibilityEnabled = newPoint.CapabilitiesVisibilityEnabled
+			container.SignalVisibilityEnabled = newPoint.SignalVisibilityEnabled
 
 			containersAppArmorProfiles[containerID] = container.AppArmorProfile
+			imageDigests[containerID] = kl.GetImageDigest(container.ContainerImage)
 			if !kl.ContainsElement(newPoint.AppArmorProfiles, container.AppArmorProfile) {
 				newPoint.AppArmorProfiles = append(newPoint.AppArmorProfiles, container.AppArmorProfile)
 			}
@@ -276,15 +327,14 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 		if val, ok := dm.DefaultPostures[newPoint.NamespaceName]; ok {
 			newPoint.DefaultPosture = val
 		} else {
-			globalDefaultPosture := tp.DefaultPosture{
-				FileAction:         cfg.GlobalCfg.DefaultFilePosture,
-				NetworkAction:      cfg.GlobalCfg.DefaultNetworkPosture,
-				CapabilitiesAction: cfg.GlobalCfg.DefaultCapabilitiesPosture,
-			}
-			newPoint.DefaultPosture = globalDefaultPosture
+			newPoint.DefaultPosture = getGlobalDefaultPosture()
 		}
 		dm.DefaultPosturesLock.Unlock()
 
+		// apply the default postures of the pod over the ones of the namespace
+		newPoint.PostureOverride = getPostureOverride(pod.Annotations)
+		newPoint.DefaultPosture = applyPostureOverride(newPoint.DefaultPosture, newPoint.PostureOverride)
+
 		// update security policies with the identities
 		newPoint.SecurityPolicies = dm.GetSecurityPolicies(newPoint.Identities)
 
@@ -297,6 +347,9 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 			endpoint.AppArmorProfiles = append(endpoint.AppArmorProfiles, containersAppArmorProfiles[k])
 			endpoint.Containers = append(endpoint.Containers, k)
 			endpoint.ContainerName = v
+			endpoint.ContainerImage = pod.ContainerImages[k]
+			endpoint.ImageDigest = imageDigests[k]
+			endpoint.SecurityContext = pod.SecurityContexts[v]
 
 			for _, secPolicy := range newPoint.SecurityPolicies {
 				if len(secPolicy.Spec.Selector.Containers) == 0 || kl.ContainsElement(secPolicy.Spec.Selector.Containers, v) {
@@ -309,13 +362,18 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 
 		dm.EndPointsLock.Lock()
 
+		// attach the containers started before the endpoint was created
+		dm.attachPendingContainers(endpoints)
+
 		// add the endpoint into the endpoint list
 		dm.EndPoints = append(dm.EndPoints, endpoints...)
 
 		if cfg.GlobalCfg.Policy {
 			// update security policies
 			for _, endpoint := range endpoints {
+				dm.adviseEndPointRules(endpoint)
 				dm.Logger.UpdateSecurityPolicies(action, endpoint)
+				dm.Logger.UpdateEndPointPosture(action, endpoint)
 				if dm.RuntimeEnforcer != nil && newPoint.PolicyEnabled == tp.KubeArmorPolicyEnabled {
 					// enforce security policies
 					dm.RuntimeEnforcer.UpdateSecurityPolicies(endpoint)
@@ -344,6 +402,7 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 		} else {
 			newEndPoint.NamespaceName = pod.Metadata["namespaceName"]
 			newEndPoint.EndPointName = pod.Metadata["podName"]
+			newEndPoint.PodUID = pod.Metadata["podUID"]
 			newEndPoint.Labels = map[string]string{}
 			newEndPoint.Identities = []string{"namespaceName=" + pod.Metadata["namespaceName"]}
 
@@ -370,6 +429,7 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 			newEndPoint.FileVisibilityEnabled = false
 			newEndPoint.NetworkVisibilityEnabled = false
 			newEndPoint.CapabilitiesVisibilityEnabled = false
+			newEndPoint.SignalVisibilityEnabled = false
 
 			// parse annotations and update visibility flags
 			for _, visibility := range strings.Split(pod.Annotations["kubearmor-visibility"], ",") {
@@ -381,6 +441,8 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 					newEndPoint.NetworkVisibilityEnabled = true
 				} else if visibility == "capabilities" {
 					newEndPoint.CapabilitiesVisibilityEnabled = true
+				} else if visibility == "signal" {
+					newEndPoint.SignalVisibilityEnabled = true
 				}
 			}
 
@@ -394,6 +456,7 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 			}
 
 			containersAppArmorProfiles := map[string]string{}
+			imageDigests := map[string]string{}
 
 			// update containers and apparmors
 			dm.ContainersLock.Lock()
@@ -413,7 +476,10 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 				container.Labels = strings.Join(labels, ",")
 
 				container.ContainerName = pod.Containers[containerID]
-				container.ContainerImage = pod.ContainerImages[containerID]
+				// keep the digest resolved by the runtime (ImageRef) if the pod status has none
+				container.ContainerImage = kl.GetContainerImage(pod.ContainerImages[containerID], container.ContainerImage)
+				container.ContainerType = pod.ContainerTypes[containerID]
+				container.SecurityContext = pod.SecurityContexts[pod.Containers[containerID]]
 
 				container.PolicyEnabled = newEndPoint.PolicyEnabled
 
@@ -421,8 +487,10 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 				container.FileVisibilityEnabled = newEndPoint.FileVisibilityEnabled
 				container.NetworkVisibilityEnabled = newEndPoint.NetworkVisibilityEnabled
 				container.CapabilitiesVisibilityEnabled = newEndPoint.CapabilitiesVisibilityEnabled
+				container.SignalVisibilityEnabled = newEndPoint.SignalVisibilityEnabled
 
 				containersAppArmorProfiles[containerID] = container.AppArmorProfile
+				imageDigests[containerID] = kl.GetImageDigest(container.ContainerImage)
 				if !kl.ContainsElement(newEndPoint.AppArmorProfiles, container.AppArmorProfile) {
 					newEndPoint.AppArmorProfiles = append(newEndPoint.AppArmorProfiles, container.AppArmorProfile)
 				}
@@ -435,15 +503,14 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 			if val, ok := dm.DefaultPostures[newEndPoint.NamespaceName]; ok {
 				newEndPoint.DefaultPosture = val
 			} else {
-				globalDefaultPosture := tp.DefaultPosture{
-					FileAction:         cfg.GlobalCfg.DefaultFilePosture,
-					NetworkAction:      cfg.GlobalCfg.DefaultNetworkPosture,
-					CapabilitiesAction: cfg.GlobalCfg.DefaultCapabilitiesPosture,
-				}
-				newEndPoint.DefaultPosture = globalDefaultPosture
+				newEndPoint.DefaultPosture = getGlobalDefaultPosture()
 			}
 			dm.DefaultPosturesLock.Unlock()
 
+			// apply the default postures of the pod over the ones of the namespace
+			newEndPoint.PostureOverride = getPostureOverride(pod.Annotations)
+			newEndPoint.DefaultPosture = applyPostureOverride(newEndPoint.DefaultPosture, newEndPoint.PostureOverride)
+
 			// get security policies according to the updated identities
 			newEndPoint.SecurityPolicies = dm.GetSecurityPolicies(newEndPoint.Identities)
 
@@ -456,6 +523,9 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 				endpoint.AppArmorProfiles = append(endpoint.AppArmorProfiles, containersAppArmorProfiles[k])
 				endpoint.Containers = append(endpoint.Containers, k)
 				endpoint.ContainerName = v
+				endpoint.ContainerImage = pod.ContainerImages[k]
+				endpoint.ImageDigest = imageDigests[k]
+				endpoint.SecurityContext = pod.SecurityContexts[v]
 
 				for _, secPolicy := range newEndPoint.SecurityPolicies {
 					if len(secPolicy.Spec.Selector.Containers) == 0 || kl.ContainsElement(secPolicy.Spec.Selector.Containers, v) {
@@ -468,6 +538,9 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 
 			dm.EndPointsLock.Lock()
 
+			// attach the containers started before the endpoint was updated
+			dm.attachPendingContainers(endpoints)
+
 			idx := 0
 			nidx := 0
 			for nidx < len(endpoints) && idx < len(dm.EndPoints) {
@@ -481,7 +554,9 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 			for _, endpoint := range endpoints {
 				if cfg.GlobalCfg.Policy {
 					// update security policies
+					dm.adviseEndPointRules(endpoint)
 					dm.Logger.UpdateSecurityPolicies(action, endpoint)
+					dm.Logger.UpdateEndPointPosture(action, endpoint)
 
 					if dm.RuntimeEnforcer != nil && endpoint.PolicyEnabled == tp.KubeArmorPolicyEnabled {
 						// enforce security policies
@@ -500,16 +575,41 @@ func (dm *KubeArmorDaemon) UpdateEndPointWithPod(action string, pod tp.K8sPod) {
 		for idx < endpointsLength {
 			endpoint := dm.EndPoints[idx]
 			if pod.Metadata["namespaceName"] == endpoint.NamespaceName && pod.Metadata["podName"] == endpoint.EndPointName {
+				dm.Logger.UpdateEndPointPosture("DELETED", endpoint)
+				dm.forgetEndPointRules(endpoint)
 				dm.EndPoints = append(dm.EndPoints[:idx], dm.EndPoints[idx+1:]...)
 				endpointsLength--
 				idx--
 			}
 			idx++
 		}
+
+		// stop retrying the rules of the deleted pod
+		dm.RuntimeEnforcer.ForgetEndPoints(pod.Metadata["namespaceName"], pod.Metadata["podName"])
+
+		// forget the aggregated events of the deleted pod
+		dm.K8sEventSink.RemovePod(pod.Metadata["namespaceName"], pod.Metadata["podName"])
+
 		dm.EndPointsLock.Unlock()
 	}
 }
 
+// addPodContainers adds the containers of a pod with their type (the init and ephemeral containers are matched to
+// the pod like the regular ones)
+func addPodContainers(pod *tp.K8sPod, statuses []corev1.ContainerStatus, containerType string) {
+	for _, container := range statuses {
+		if len(container.ContainerID) > 0 {
+			cid := strings.Split(container.ContainerID, "://")
+			if len(cid) == 2 { // always true because k8s spec defines format as '<type>://<container_id>'
+				containerID := cid[1]
+				pod.Containers[containerID] = container.Name
+				pod.ContainerImages[containerID] = kl.GetContainerImage(container.Image, container.ImageID)
+				pod.ContainerTypes[containerID] = containerType
+			}
+		}
+	}
+}
+
 // WatchK8sPods Function
 func (dm *KubeArmorDaemon) WatchK8sPods() {
 	for {
@@ -544,6 +644,7 @@ func (dm *KubeArmorDaemon) WatchK8sPods() {
 				pod.Metadata = map[string]string{}
 				pod.Metadata["namespaceName"] = event.Object.ObjectMeta.Namespace
 				pod.Metadata["podName"] = event.Object.ObjectMeta.Name
+				pod.Metadata["podUID"] = string(event.Object.ObjectMeta.UID)
 
 				controllerName, controller, namespace, err := getTopLevelOwner(event.Object.ObjectMeta, event.Object.Namespace, event.Object.Kind)
 				if err != nil {
@@ -581,16 +682,12 @@ func (dm *KubeArmorDaemon) WatchK8sPods() {
 
 				pod.Containers = map[string]string{}
 				pod.ContainerImages = map[string]string{}
-				for _, container := range event.Object.Status.ContainerStatuses {
-					if len(container.ContainerID) > 0 {
-						cid := strings.Split(container.ContainerID, "://")
-						if len(cid) == 2 { // always true because k8s spec defines format as '<type>://<container_id>'
-							containerID := cid[1]
-							pod.Containers[containerID] = container.Name
-							pod.ContainerImages[containerID] = container.Image + kl.GetSHA256ofImage(container.ImageID)
-						}
-					}
-				}
+				pod.ContainerTypes = map[string]string{}
+				addPodContainers(&pod, event.Object.Status.ContainerStatuses, tp.ContainerTypeRegular)
+				addPodContainers(&pod, event.Object.Status.InitContainerStatuses, tp.ContainerTypeInit)
+				addPodContainers(&pod, event.Object.Status.EphemeralContainerStatuses, tp.ContainerTypeEphemeral)
+
+				pod.SecurityContexts = getSecurityContexts(event.Object.Spec)
 
 				// == Policy == //
 
@@ -726,8 +823,12 @@ func (dm *KubeArmorDaemon) WatchK8sPods() {
 					}
 
 					if event.Type == "ADDED" {
+						// deferred removals go first not to remove the profiles of a pod with the same name
+						dm.NodeQuiesce.Flush()
+
 						// update apparmor profiles
 						dm.RuntimeEnforcer.UpdateAppArmorProfiles(pod.Metadata["podName"], "ADDED", appArmorAnnotations)
+						dm.AppArmorAttachment.ProfilesGenerated(pod.Metadata["namespaceName"], pod.Metadata["podName"], appArmorAnnotations)
 
 						if updateAppArmor && pod.Annotations["kubearmor-policy"] == "enabled" {
 							if deploymentName, ok := pod.Metadata["owner.controllerName"]; ok {
@@ -765,8 +866,12 @@ func (dm *KubeArmorDaemon) WatchK8sPods() {
 							}
 						}
 					} else if event.Type == "DELETED" {
-						// update apparmor profiles
-						dm.RuntimeEnforcer.UpdateAppArmorProfiles(pod.Metadata["podName"], "DELETED", appArmorAnnotations)
+						// update apparmor profiles (in batches during node maintenance)
+						podName := pod.Metadata["podName"]
+						dm.AppArmorAttachment.ProfilesRemoved(pod.Metadata["namespaceName"], podName)
+						dm.NodeQuiesce.Remove(func() {
+							dm.RuntimeEnforcer.UpdateAppArmorProfiles(podName, "DELETED", appArmorAnnotations)
+						})
 					}
 				}
 
@@ -808,6 +913,11 @@ func (dm *KubeArmorDaemon) WatchK8sPods() {
 					dm.Logger.Printf("Detected a Pod (%s/%s/%s)", strings.ToLower(event.Type), pod.Metadata["namespaceName"], pod.Metadata["podName"])
 				}
 
+				// no regeneration for the pods being deleted during node maintenance
+				if event.Type == "MODIFIED" && event.Object.DeletionTimestamp != nil && dm.NodeQuiesce.IsQuiesced() {
+					continue
+				}
+
 				// update a endpoint corresponding to the pod
 				dm.UpdateEndPointWithPod(event.Type, pod)
 			}
@@ -829,7 +939,7 @@ func (dm *KubeArmorDaemon) GetSecurityPolicies(identities []string) []tp.Securit
 	secPolicies := []tp.SecurityPolicy{}
 
 	for _, policy := range dm.SecurityPolicies {
-		if kl.MatchIdentities(policy.Spec.Selector.Identities, identities) {
+		if matchSelector(policy.Spec.Selector, identities) {
 			secPolicy := tp.SecurityPolicy{}
 			if err := kl.Clone(policy, &secPolicy); err != nil {
 				dm.Logger.Errf("Failed to clone a policy (%s)", err.Error())
@@ -843,12 +953,25 @@ func (dm *KubeArmorDaemon) GetSecurityPolicies(identities []string) []tp.Securit
 
 // UpdateSecurityPolicy Function
 func (dm *KubeArmorDaemon) UpdateSecurityPolicy(action string, secPolicy tp.SecurityPolicy) {
+	// the policy may need the probes of a detached event class
+	defer dm.requestEventClassUpdate()
+
+	start := time.Now()
+
 	dm.EndPointsLock.Lock()
-	defer dm.EndPointsLock.Unlock()
+
+	endpoints := []string{}
+	containers := []string{}
+
+	// time spent on generating and loading the rules of the policy
+	times := fd.PolicyApplyTimes{}
 
 	for idx, endPoint := range dm.EndPoints {
 		// update a security policy
-		if kl.MatchIdentities(secPolicy.Spec.Selector.Identities, endPoint.Identities) && (len(secPolicy.Spec.Selector.Containers) == 0 || kl.ContainsElement(secPolicy.Spec.Selector.Containers, endPoint.ContainerName)) {
+		if matchSelector(secPolicy.Spec.Selector, endPoint.Identities) && (len(secPolicy.Spec.Selector.Containers) == 0 || kl.ContainsElement(secPolicy.Spec.Selector.Containers, endPoint.ContainerName)) {
+			endpoints = append(endpoints, endPoint.EndPointName)
+			containers = append(containers, endPoint.Containers...)
+
 			if action == "ADDED" {
 				// add a new security policy if it doesn't exist
 				new := true
@@ -879,18 +1002,54 @@ func (dm *KubeArmorDaemon) UpdateSecurityPolicy(action string, secPolicy tp.Secu
 			}
 
 			if cfg.GlobalCfg.Policy {
+				// warn about the endpoints with too many rules
+				dm.adviseEndPointRules(dm.EndPoints[idx])
+
 				// update security policies
 				dm.Logger.UpdateSecurityPolicies("UPDATED", dm.EndPoints[idx])
 
 				if dm.RuntimeEnforcer != nil {
 					if dm.EndPoints[idx].PolicyEnabled == tp.KubeArmorPolicyEnabled {
 						// enforce security policies
-						dm.RuntimeEnforcer.UpdateSecurityPolicies(dm.EndPoints[idx])
+						times = times.Add(dm.RuntimeEnforcer.UpdateSecurityPolicies(dm.EndPoints[idx]))
 					}
 				}
 			}
 		}
 	}
+
+	dm.EndPointsLock.Unlock()
+
+	if action == "DELETED" {
+		dm.Logger.PolicyMetrics.ForgetPolicy(secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"])
+	} else if cfg.GlobalCfg.Policy {
+		dm.Logger.PolicyMetrics.ObservePolicyApply(secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"], dm.Logger.Enforcer, times, time.Since(start))
+	}
+
+	// notify policy watchers, with the differences of the enforcement on this node
+	// and the matchPaths found in none of the selected containers
+	differences := []string{}
+	warnings := []string{}
+	if action != "DELETED" {
+		differences = fd.AnalyzePolicyCompatibility(dm.Logger.Enforcer, secPolicy.Spec)
+		warnings = append(dm.checkPolicyPaths(secPolicy, containers), dm.ruleConsolidationNotes(secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"])...)
+	} else {
+		dm.forgetPolicyPaths(secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"])
+		dm.forgetRuleConsolidation(secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"])
+	}
+
+	kind := KubeArmorPolicyKind
+	if ClusterPolicy(secPolicy.Metadata) {
+		kind = KubeArmorClusterPolicyKind
+	}
+
+	dm.Logger.PushPolicyEventWithCompatibility(kind, secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"], policyEventAction(action), "", endpoints, differences, warnings)
+
+	// the Block rules are enforced once the maturation period elapses
+	dm.trackPolicyMaturation(kind, secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"], secPolicy.Metadata, action == "DELETED")
+
+	// the policy is active within the windows of its schedule only
+	dm.trackPolicySchedule(kind, secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"], secPolicy.Spec.Schedule, action == "DELETED")
 }
 
 // CreateSecurityPolicy object from a policy CRD
@@ -899,11 +1058,45 @@ func (dm *KubeArmorDaemon) CreateSecurityPolicy(policy ksp.KubeArmorPolicy) (sec
 	secPolicy.Metadata["namespaceName"] = policy.Namespace
 	secPolicy.Metadata["policyName"] = policy.Name
 
+	// matchPaths of Block rules expected not to exist in containers
+	if expectMissing, ok := policy.Annotations[ksp.ExpectMissingAnnotation]; ok {
+		secPolicy.Metadata["expectMissing"] = expectMissing
+	}
+
 	if err := kl.Clone(policy.Spec, &secPolicy.Spec); err != nil {
 		dm.Logger.Errf("Failed to clone a spec (%s)", err.Error())
 		return tp.SecurityPolicy{}, err
 	}
 
+	// rules inherit the action of the policy, one of them is needed
+	if err := validatePolicyActions(secPolicy.Spec); err != nil {
+		return tp.SecurityPolicy{}, err
+	}
+
+	if err := validateFileAttributeActions(secPolicy.Spec.File, secPolicy.Spec.Action); err != nil {
+		return tp.SecurityPolicy{}, err
+	}
+
+	if err := validateMatchExpressions(secPolicy.Spec.Selector.MatchExpressions); err != nil {
+		return tp.SecurityPolicy{}, err
+	}
+
+	if err := validatePolicyMode(secPolicy.Spec.Mode); err != nil {
+		return tp.SecurityPolicy{}, err
+	}
+
+	if err := validatePolicySchedule(secPolicy.Spec.Schedule); err != nil {
+		return tp.SecurityPolicy{}, err
+	}
+
+	// Block rules are audited until the maturation period elapses since the creation of the policy
+	if err := setPolicyMaturation(secPolicy.Metadata, policyCreationTime(policy.CreationTimestamp, nil, dm.maturationTime()), secPolicy.Spec.MaturationPeriod); err != nil {
+		return tp.SecurityPolicy{}, err
+	}
+
+	// the node-local overrides take precedence over the other policies until they expire
+	dm.setPolicyOverride(secPolicy.Metadata, KubeArmorPolicyKind, policy.Namespace, policy.Name)
+
 	kl.ObjCommaExpandFirstDupOthers(&secPolicy.Spec.Network.MatchProtocols)
 	kl.ObjCommaExpandFirstDupOthers(&secPolicy.Spec.Capabilities.MatchCapabilities)
 
@@ -1057,6 +1250,113 @@ func (dm *KubeArmorDaemon) CreateSecurityPolicy(policy ksp.KubeArmorPolicy) (sec
 		}
 	}
 
+	if len(secPolicy.Spec.Process.MatchNamespaces) > 0 {
+		for idx, ns := range secPolicy.Spec.Process.MatchNamespaces {
+			if ns.Severity == 0 {
+				if secPolicy.Spec.Process.Severity != 0 {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Severity = secPolicy.Spec.Process.Severity
+				} else {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Severity = secPolicy.Spec.Severity
+				}
+			}
+
+			if len(ns.Tags) == 0 {
+				if len(secPolicy.Spec.Process.Tags) > 0 {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Tags = secPolicy.Spec.Process.Tags
+				} else {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Tags = secPolicy.Spec.Tags
+				}
+			}
+
+			if len(ns.Message) == 0 {
+				if len(secPolicy.Spec.Process.Message) > 0 {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Message = secPolicy.Spec.Process.Message
+				} else {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Message = secPolicy.Spec.Message
+				}
+			}
+
+			if len(ns.Action) == 0 {
+				if len(secPolicy.Spec.Process.Action) > 0 {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Action = secPolicy.Spec.Process.Action
+				} else {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Action = secPolicy.Spec.Action
+				}
+			}
+		}
+	}
+
+	if len(secPolicy.Spec.Process.MatchSignals) > 0 {
+		for idx, sig := range secPolicy.Spec.Process.MatchSignals {
+			if sig.Severity == 0 {
+				if secPolicy.Spec.Process.Severity != 0 {
+					secPolicy.Spec.Process.MatchSignals[idx].Severity = secPolicy.Spec.Process.Severity
+				} else {
+					secPolicy.Spec.Process.MatchSignals[idx].Severity = secPolicy.Spec.Severity
+				}
+			}
+
+			if len(sig.Tags) == 0 {
+				if len(secPolicy.Spec.Process.Tags) > 0 {
+					secPolicy.Spec.Process.MatchSignals[idx].Tags = secPolicy.Spec.Process.Tags
+				} else {
+					secPolicy.Spec.Process.MatchSignals[idx].Tags = secPolicy.Spec.Tags
+				}
+			}
+
+			if len(sig.Message) == 0 {
+				if len(secPolicy.Spec.Process.Message) > 0 {
+					secPolicy.Spec.Process.MatchSignals[idx].Message = secPolicy.Spec.Process.Message
+				} else {
+					secPolicy.Spec.Process.MatchSignals[idx].Message = secPolicy.Spec.Message
+				}
+			}
+
+			if len(sig.Action) == 0 {
+				if len(secPolicy.Spec.Process.Action) > 0 {
+					secPolicy.Spec.Process.MatchSignals[idx].Action = secPolicy.Spec.Process.Action
+				} else {
+					secPolicy.Spec.Process.MatchSignals[idx].Action = secPolicy.Spec.Action
+				}
+			}
+		}
+	}
+
+	if fileless := secPolicy.Spec.Process.BlockFileless; fileless != nil {
+		if fileless.Severity == 0 {
+			if secPolicy.Spec.Process.Severity != 0 {
+				fileless.Severity = secPolicy.Spec.Process.Severity
+			} else {
+				fileless.Severity = secPolicy.Spec.Severity
+			}
+		}
+
+		if len(fileless.Tags) == 0 {
+			if len(secPolicy.Spec.Process.Tags) > 0 {
+				fileless.Tags = secPolicy.Spec.Process.Tags
+			} else {
+				fileless.Tags = secPolicy.Spec.Tags
+			}
+		}
+
+		if len(fileless.Message) == 0 {
+			if len(secPolicy.Spec.Process.Message) > 0 {
+				fileless.Message = secPolicy.Spec.Process.Message
+			} else {
+				fileless.Message = secPolicy.Spec.Message
+			}
+		}
+
+		// only Audit and Block are inherited by fileless rules
+		if len(fileless.Action) == 0 {
+			if secPolicy.Spec.Process.Action == "Audit" || secPolicy.Spec.Process.Action == "Block" {
+				fileless.Action = secPolicy.Spec.Process.Action
+			} else if secPolicy.Spec.Action == "Audit" || secPolicy.Spec.Action == "Block" {
+				fileless.Action = secPolicy.Spec.Action
+			}
+		}
+	}
+
 	if len(secPolicy.Spec.File.MatchPaths) > 0 {
 		for idx, path := range secPolicy.Spec.File.MatchPaths {
 			if path.Severity == 0 {
@@ -1165,6 +1465,78 @@ func (dm *KubeArmorDaemon) CreateSecurityPolicy(policy ksp.KubeArmorPolicy) (sec
 		}
 	}
 
+	if len(secPolicy.Spec.File.MatchXattrs) > 0 {
+		for idx, xattr := range secPolicy.Spec.File.MatchXattrs {
+			if xattr.Severity == 0 {
+				if secPolicy.Spec.File.Severity != 0 {
+					secPolicy.Spec.File.MatchXattrs[idx].Severity = secPolicy.Spec.File.Severity
+				} else {
+					secPolicy.Spec.File.MatchXattrs[idx].Severity = secPolicy.Spec.Severity
+				}
+			}
+
+			if len(xattr.Tags) == 0 {
+				if len(secPolicy.Spec.File.Tags) > 0 {
+					secPolicy.Spec.File.MatchXattrs[idx].Tags = secPolicy.Spec.File.Tags
+				} else {
+					secPolicy.Spec.File.MatchXattrs[idx].Tags = secPolicy.Spec.Tags
+				}
+			}
+
+			if len(xattr.Message) == 0 {
+				if len(secPolicy.Spec.File.Message) > 0 {
+					secPolicy.Spec.File.MatchXattrs[idx].Message = secPolicy.Spec.File.Message
+				} else {
+					secPolicy.Spec.File.MatchXattrs[idx].Message = secPolicy.Spec.Message
+				}
+			}
+
+			if len(xattr.Action) == 0 {
+				if len(secPolicy.Spec.File.Action) > 0 {
+					secPolicy.Spec.File.MatchXattrs[idx].Action = secPolicy.Spec.File.Action
+				} else {
+					secPolicy.Spec.File.MatchXattrs[idx].Action = secPolicy.Spec.Action
+				}
+			}
+		}
+	}
+
+	if len(secPolicy.Spec.File.MatchImmutable) > 0 {
+		for idx, imm := range secPolicy.Spec.File.MatchImmutable {
+			if imm.Severity == 0 {
+				if secPolicy.Spec.File.Severity != 0 {
+					secPolicy.Spec.File.MatchImmutable[idx].Severity = secPolicy.Spec.File.Severity
+				} else {
+					secPolicy.Spec.File.MatchImmutable[idx].Severity = secPolicy.Spec.Severity
+				}
+			}
+
+			if len(imm.Tags) == 0 {
+				if len(secPolicy.Spec.File.Tags) > 0 {
+					secPolicy.Spec.File.MatchImmutable[idx].Tags = secPolicy.Spec.File.Tags
+				} else {
+					secPolicy.Spec.File.MatchImmutable[idx].Tags = secPolicy.Spec.Tags
+				}
+			}
+
+			if len(imm.Message) == 0 {
+				if len(secPolicy.Spec.File.Message) > 0 {
+					secPolicy.Spec.File.MatchImmutable[idx].Message = secPolicy.Spec.File.Message
+				} else {
+					secPolicy.Spec.File.MatchImmutable[idx].Message = secPolicy.Spec.Message
+				}
+			}
+
+			if len(imm.Action) == 0 {
+				if len(secPolicy.Spec.File.Action) > 0 {
+					secPolicy.Spec.File.MatchImmutable[idx].Action = secPolicy.Spec.File.Action
+				} else {
+					secPolicy.Spec.File.MatchImmutable[idx].Action = secPolicy.Spec.Action
+				}
+			}
+		}
+	}
+
 	if len(secPolicy.Spec.Network.MatchProtocols) > 0 {
 		for idx, proto := range secPolicy.Spec.Network.MatchProtocols {
 			if proto.Severity == 0 {
@@ -1201,6 +1573,42 @@ func (dm *KubeArmorDaemon) CreateSecurityPolicy(policy ksp.KubeArmorPolicy) (sec
 		}
 	}
 
+	if len(secPolicy.Spec.Network.MatchRuntimeSockets) > 0 {
+		for idx, sock := range secPolicy.Spec.Network.MatchRuntimeSockets {
+			if sock.Severity == 0 {
+				if secPolicy.Spec.Network.Severity != 0 {
+					secPolicy.Spec.Network.MatchRuntimeSockets[idx].Severity = secPolicy.Spec.Network.Severity
+				} else {
+					secPolicy.Spec.Network.MatchRuntimeSockets[idx].Severity = secPolicy.Spec.Severity
+				}
+			}
+
+			if len(sock.Tags) == 0 {
+				if len(secPolicy.Spec.Network.Tags) > 0 {
+					secPolicy.Spec.Network.MatchRuntimeSockets[idx].Tags = secPolicy.Spec.Network.Tags
+				} else {
+					secPolicy.Spec.Network.MatchRuntimeSockets[idx].Tags = secPolicy.Spec.Tags
+				}
+			}
+
+			if len(sock.Message) == 0 {
+				if len(secPolicy.Spec.Network.Message) > 0 {
+					secPolicy.Spec.Network.MatchRuntimeSockets[idx].Message = secPolicy.Spec.Network.Message
+				} else {
+					secPolicy.Spec.Network.MatchRuntimeSockets[idx].Message = secPolicy.Spec.Message
+				}
+			}
+
+			if len(sock.Action) == 0 {
+				if len(secPolicy.Spec.Network.Action) > 0 {
+					secPolicy.Spec.Network.MatchRuntimeSockets[idx].Action = secPolicy.Spec.Network.Action
+				} else {
+					secPolicy.Spec.Network.MatchRuntimeSockets[idx].Action = secPolicy.Spec.Action
+				}
+			}
+		}
+	}
+
 	if len(secPolicy.Spec.Capabilities.MatchCapabilities) > 0 {
 		for idx, cap := range secPolicy.Spec.Capabilities.MatchCapabilities {
 			if cap.Severity == 0 {
@@ -1294,9 +1702,179 @@ func (dm *KubeArmorDaemon) CreateSecurityPolicy(policy ksp.KubeArmorPolicy) (sec
 
 		}
 	}
+
+	// merge the rules of large allowlists
+	dm.consolidateSecurityPolicy(&secPolicy, policy.Annotations[ksp.RuleConsolidationAnnotation])
+
 	return
 }
 
+// checkKubeArmorPolicyEvent checks the order of an event of a policy, and schedules the reconciliation of
+// a policy recreated right after being deleted (the policy should be locked by LockPolicy)
+func (dm *KubeArmorDaemon) checkKubeArmorPolicyEvent(action string, policy *ksp.KubeArmorPolicy) bool {
+	applied, reason, reconcile := dm.PolicyOrder.CheckEvent(policy.Namespace+"/"+policy.Name, action, policy.ObjectMeta)
+	if !applied {
+		dm.Logger.Warnf("Discarded an out-of-order event of a Security Policy (%s/%s/%s, %s)", strings.ToLower(action), policy.Namespace, policy.Name, reason)
+		return false
+	}
+
+	if reconcile {
+		namespaceName, policyName := policy.Namespace, policy.Name
+		time.AfterFunc(policyReconcileWindow, func() {
+			dm.reconcileSecurityPolicy(namespaceName, policyName)
+		})
+	}
+
+	return true
+}
+
+// upsertSecurityPolicy adds a security policy, or replaces the existing one with the same name
+func (dm *KubeArmorDaemon) upsertSecurityPolicy(secPolicy tp.SecurityPolicy) bool {
+	dm.SecurityPoliciesLock.Lock()
+	defer dm.SecurityPoliciesLock.Unlock()
+
+	for idx, policy := range dm.SecurityPolicies {
+		if policy.Metadata["namespaceName"] == secPolicy.Metadata["namespaceName"] && policy.Metadata["policyName"] == secPolicy.Metadata["policyName"] {
+			dm.SecurityPolicies[idx] = secPolicy
+			return false
+		}
+	}
+
+	dm.SecurityPolicies = append(dm.SecurityPolicies, secPolicy)
+	return true
+}
+
+// removeSecurityPolicy removes a security policy (other than the ones of the cluster policies), and returns it
+func (dm *KubeArmorDaemon) removeSecurityPolicy(namespaceName, policyName string) (tp.SecurityPolicy, bool) {
+	dm.SecurityPoliciesLock.Lock()
+	defer dm.SecurityPoliciesLock.Unlock()
+
+	for idx, policy := range dm.SecurityPolicies {
+		if policy.Metadata["namespaceName"] == namespaceName && policy.Metadata["policyName"] == policyName && !ClusterPolicy(policy.Metadata) {
+			dm.SecurityPolicies = append(dm.SecurityPolicies[:idx], dm.SecurityPolicies[idx+1:]...)
+			return policy, true
+		}
+	}
+
+	return tp.SecurityPolicy{}, false
+}
+
+// addKubeArmorPolicy Function
+func (dm *KubeArmorDaemon) addKubeArmorPolicy(policy *ksp.KubeArmorPolicy) {
+	unlock := dm.PolicyOrder.LockPolicy(policy.Namespace + "/" + policy.Name)
+	defer unlock()
+
+	if !dm.checkKubeArmorPolicyEvent("ADDED", policy) {
+		return
+	}
+
+	// create a security policy
+	secPolicy, err := dm.CreateSecurityPolicy(*policy)
+	if err != nil {
+		dm.Logger.Warnf("Error ADD, %s", err)
+		dm.Logger.PushPolicyEvent(KubeArmorPolicyKind, policy.Namespace, policy.Name, fd.PolicyFailed, err.Error(), nil)
+		return
+	}
+
+	// a policy recreated before the delete of its previous instance replaces the previous one
+	if dm.upsertSecurityPolicy(secPolicy) {
+		dm.Logger.Printf("Detected a Security Policy (added/%s/%s)", secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"])
+
+		// apply security policies to pods
+		dm.UpdateSecurityPolicy("ADDED", secPolicy)
+	} else {
+		dm.Logger.Printf("Detected a Security Policy (added/%s/%s, replaced the previous one)", secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"])
+
+		// apply security policies to pods
+		dm.UpdateSecurityPolicy("MODIFIED", secPolicy)
+	}
+
+	// report the compatibility on this node
+	dm.annotatePolicyCompatibility(KubeArmorPolicyKind, policy.Namespace, policy.Name, policy.Annotations, fd.AnalyzePolicyCompatibility(dm.Logger.Enforcer, secPolicy.Spec), dm.policyWarnings(policy.Namespace, policy.Name))
+}
+
+// modifyKubeArmorPolicy Function
+func (dm *KubeArmorDaemon) modifyKubeArmorPolicy(policy *ksp.KubeArmorPolicy) {
+	unlock := dm.PolicyOrder.LockPolicy(policy.Namespace + "/" + policy.Name)
+	defer unlock()
+
+	if !dm.checkKubeArmorPolicyEvent("MODIFIED", policy) {
+		return
+	}
+
+	secPolicy, err := dm.CreateSecurityPolicy(*policy)
+	if err != nil {
+		dm.Logger.PushPolicyEvent(KubeArmorPolicyKind, policy.Namespace, policy.Name, fd.PolicyFailed, err.Error(), nil)
+		return
+	}
+
+	action := "MODIFIED"
+	if dm.upsertSecurityPolicy(secPolicy) {
+		// the add of the policy was discarded or failed
+		action = "ADDED"
+	}
+
+	dm.Logger.Printf("Detected a Security Policy (modified/%s/%s)", secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"])
+
+	// apply security policies to pods
+	dm.UpdateSecurityPolicy(action, secPolicy)
+
+	// report the compatibility on this node
+	dm.annotatePolicyCompatibility(KubeArmorPolicyKind, policy.Namespace, policy.Name, policy.Annotations, fd.AnalyzePolicyCompatibility(dm.Logger.Enforcer, secPolicy.Spec), dm.policyWarnings(policy.Namespace, policy.Name))
+}
+
+// deleteKubeArmorPolicy Function
+func (dm *KubeArmorDaemon) deleteKubeArmorPolicy(policy *ksp.KubeArmorPolicy) {
+	unlock := dm.PolicyOrder.LockPolicy(policy.Namespace + "/" + policy.Name)
+	defer unlock()
+
+	if !dm.checkKubeArmorPolicyEvent("DELETED", policy) {
+		return
+	}
+
+	secPolicy, ok := dm.removeSecurityPolicy(policy.Namespace, policy.Name)
+	if !ok {
+		return
+	}
+
+	dm.Logger.Printf("Detected a Security Policy (deleted/%s/%s)", secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"])
+
+	// apply security policies to pods
+	dm.UpdateSecurityPolicy("DELETED", secPolicy)
+
+	// a cluster policy of the same name applies to the namespace again
+	dm.restoreClusterPolicy(policy.Namespace, policy.Name)
+}
+
+// kubeArmorPolicyEventHandler returns the handler of the events of the security policies
+func (dm *KubeArmorDaemon) kubeArmorPolicyEventHandler() cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if policy, ok := obj.(*ksp.KubeArmorPolicy); ok {
+				dm.addKubeArmorPolicy(policy)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if policy, ok := newObj.(*ksp.KubeArmorPolicy); ok {
+				// skip the updates of metadata and status only (e.g., compatibility reports of nodes)
+				if old, ok := oldObj.(*ksp.KubeArmorPolicy); ok && old.Generation != 0 && old.Generation == policy.Generation {
+					return
+				}
+				dm.modifyKubeArmorPolicy(policy)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			// the final state of a policy deleted while the watch was down
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if policy, ok := obj.(*ksp.KubeArmorPolicy); ok {
+				dm.deleteKubeArmorPolicy(policy)
+			}
+		},
+	}
+}
+
 // WatchSecurityPolicies Function
 func (dm *KubeArmorDaemon) WatchSecurityPolicies() {
 	for {
@@ -1308,90 +1886,22 @@ func (dm *KubeArmorDaemon) WatchSecurityPolicies() {
 		}
 	}
 
+	// the host security policies come first
+	dm.waitForHostPolicies()
+
 	factory := kspinformer.NewSharedInformerFactory(K8s.KSPClient, 0)
 
 	informer := factory.Security().V1().KubeArmorPolicies().Informer()
-	if _, err := informer.AddEventHandler(
-		cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				// create a security policy
-				if policy, ok := obj.(*ksp.KubeArmorPolicy); ok {
-
-					secPolicy, err := dm.CreateSecurityPolicy(*policy)
-					if err != nil {
-						dm.Logger.Warnf("Error ADD, %s", err)
-						return
-					}
-					dm.SecurityPoliciesLock.Lock()
-					new := true
-					for _, policy := range dm.SecurityPolicies {
-						if policy.Metadata["namespaceName"] == secPolicy.Metadata["namespaceName"] && policy.Metadata["policyName"] == secPolicy.Metadata["policyName"] {
-							new = false
-							break
-						}
-					}
-					if new {
-						dm.SecurityPolicies = append(dm.SecurityPolicies, secPolicy)
-					}
-					dm.SecurityPoliciesLock.Unlock()
-					dm.Logger.Printf("Detected a Security Policy (added/%s/%s)", secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"])
-
-					// apply security policies to pods
-					dm.UpdateSecurityPolicy("ADDED", secPolicy)
-
-				}
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				if policy, ok := newObj.(*ksp.KubeArmorPolicy); ok {
-					secPolicy, err := dm.CreateSecurityPolicy(*policy)
-					if err != nil {
-						return
-					}
-
-					dm.SecurityPoliciesLock.Lock()
-					for idx, policy := range dm.SecurityPolicies {
-						if policy.Metadata["namespaceName"] == secPolicy.Metadata["namespaceName"] && policy.Metadata["policyName"] == secPolicy.Metadata["policyName"] {
-							dm.SecurityPolicies[idx] = secPolicy
-							break
-						}
-					}
-					dm.SecurityPoliciesLock.Unlock()
-
-					dm.Logger.Printf("Detected a Security Policy (modified/%s/%s)", secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"])
-
-					// apply security policies to pods
-					dm.UpdateSecurityPolicy("MODIFIED", secPolicy)
-				}
-			},
-			DeleteFunc: func(obj interface{}) {
-				if policy, ok := obj.(*ksp.KubeArmorPolicy); ok {
-					secPolicy, err := dm.CreateSecurityPolicy(*policy)
-					if err != nil {
-						return
-					}
-					dm.SecurityPoliciesLock.Lock()
-					for idx, policy := range dm.SecurityPolicies {
-						if policy.Metadata["namespaceName"] == secPolicy.Metadata["namespaceName"] && policy.Metadata["policyName"] == secPolicy.Metadata["policyName"] {
-							dm.SecurityPolicies = append(dm.SecurityPolicies[:idx], dm.SecurityPolicies[idx+1:]...)
-							break
-						}
-					}
-					dm.SecurityPoliciesLock.Unlock()
-
-					dm.Logger.Printf("Detected a Security Policy (deleted/%s/%s)", secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"])
-
-					// apply security policies to pods
-					dm.UpdateSecurityPolicy("DELETED", secPolicy)
-				}
-			},
-		},
-	); err != nil {
+	if _, err := informer.AddEventHandler(dm.kubeArmorPolicyEventHandler()); err != nil {
 		dm.Logger.Err("Couldn't start watching KubeArmor Security Policies")
 		return
 	}
 
 	go factory.Start(wait.NeverStop)
 	factory.WaitForCacheSync(wait.NeverStop)
+
+	// check the applied policies against the listed ones
+	go dm.WatchPolicyDrift(factory.Security().V1().KubeArmorPolicies().Lister())
 }
 
 // ================================= //
@@ -1400,14 +1910,22 @@ func (dm *KubeArmorDaemon) WatchSecurityPolicies() {
 
 // UpdateHostSecurityPolicies Function
 func (dm *KubeArmorDaemon) UpdateHostSecurityPolicies() {
+	// the host policies may need the probes of a detached event class
+	defer dm.requestEventClassUpdate()
+
 	dm.HostSecurityPoliciesLock.Lock()
 	defer dm.HostSecurityPoliciesLock.Unlock()
 
+	dm.NodeLock.RLock()
+	identities, policyEnabled := dm.Node.Identities, dm.Node.PolicyEnabled
+	dm.NodeLock.RUnlock()
+
 	secPolicies := []tp.HostSecurityPolicy{}
 
 	for _, policy := range dm.HostSecurityPolicies {
-		if kl.IsK8sEnv() {
-			if kl.MatchIdentities(policy.Spec.NodeSelector.Identities, dm.Node.Identities) {
+		if isK8sEnv() {
+			// the node-local overrides select the node
+			if matchNodeSelector(policy.Spec.NodeSelector, identities) || fd.PolicyOverride(policy.Metadata) {
 				secPolicies = append(secPolicies, policy)
 			}
 		} else { // KubeArmorVM and KVMAgent
@@ -1419,17 +1937,44 @@ func (dm *KubeArmorDaemon) UpdateHostSecurityPolicies() {
 		// update host security policies
 		dm.Logger.UpdateHostSecurityPolicies("UPDATED", secPolicies)
 
+		enforcer := "none"
 		if dm.RuntimeEnforcer != nil {
-			if dm.Node.PolicyEnabled == tp.KubeArmorPolicyEnabled {
+			if policyEnabled == tp.KubeArmorPolicyEnabled {
 				// enforce host security policies
 				dm.RuntimeEnforcer.UpdateHostSecurityPolicies(secPolicies)
+				enforcer = dm.RuntimeEnforcer.EnforcerType
 			}
 		}
+
+		// alerts of the host security policies whose enforcement starts or stops
+		dm.updateHostPolicyEnforcement(secPolicies, enforcer)
 	}
 }
 
 // ParseAndUpdateHostSecurityPolicy Function
 func (dm *KubeArmorDaemon) ParseAndUpdateHostSecurityPolicy(event tp.K8sKubeArmorHostPolicyEvent) pb.PolicyStatus {
+	status := dm.parseAndUpdateHostSecurityPolicy(event)
+
+	// notify policy watchers, with the differences of the enforcement on this node
+	action, reason := policyStatusToEventAction(status)
+
+	differences := []string{}
+	if (action == fd.PolicyApplied || action == fd.PolicyUpdated) && dm.hostPolicySelectsNode(event.Object.Spec.NodeSelector) {
+		differences = fd.AnalyzeHostPolicyCompatibility(dm.Logger.Enforcer, event.Object.Spec)
+		dm.annotatePolicyCompatibility(KubeArmorHostPolicyKind, "", event.Object.Metadata.Name, event.Object.Metadata.Annotations, differences, nil)
+	}
+
+	dm.NodeLock.RLock()
+	nodeName := dm.Node.NodeName
+	dm.NodeLock.RUnlock()
+
+	dm.Logger.PushPolicyEventWithCompatibility(KubeArmorHostPolicyKind, "", event.Object.Metadata.Name, action, reason, []string{nodeName}, differences, nil)
+
+	return status
+}
+
+// createHostSecurityPolicy creates a host security policy from a policy event, with the status of a rejected policy
+func (dm *KubeArmorDaemon) createHostSecurityPolicy(event tp.K8sKubeArmorHostPolicyEvent) (tp.HostSecurityPolicy, pb.PolicyStatus, error) {
 	// create a host security policy
 
 	secPolicy := tp.HostSecurityPolicy{}
@@ -1439,9 +1984,32 @@ func (dm *KubeArmorDaemon) ParseAndUpdateHostSecurityPolicy(event tp.K8sKubeArmo
 
 	if err := kl.Clone(event.Object.Spec, &secPolicy.Spec); err != nil {
 		dm.Logger.Errf("Failed to clone a spec (%s)", err.Error())
-		return pb.PolicyStatus_Failure
+		return tp.HostSecurityPolicy{}, pb.PolicyStatus_Failure, err
+	}
+
+	if event.Type != "DELETED" {
+		if err := validateFileAttributeActions(secPolicy.Spec.File, secPolicy.Spec.Action); err != nil {
+			return tp.HostSecurityPolicy{}, pb.PolicyStatus_Invalid, err
+		}
+
+		if err := validateMatchExpressions(secPolicy.Spec.NodeSelector.MatchExpressions); err != nil {
+			return tp.HostSecurityPolicy{}, pb.PolicyStatus_Invalid, err
+		}
+
+		if err := validatePolicyMode(secPolicy.Spec.Mode); err != nil {
+			return tp.HostSecurityPolicy{}, pb.PolicyStatus_Invalid, err
+		}
+
+		// Block rules are audited until the maturation period elapses since the creation of the policy
+		created := policyCreationTime(event.Object.Metadata.CreationTimestamp, dm.hostPolicyMetadata(event.Object.Metadata.Name), dm.maturationTime())
+		if err := setPolicyMaturation(secPolicy.Metadata, created, secPolicy.Spec.MaturationPeriod); err != nil {
+			return tp.HostSecurityPolicy{}, pb.PolicyStatus_Invalid, err
+		}
 	}
 
+	// the node-local overrides take precedence over the other policies until they expire
+	dm.setPolicyOverride(secPolicy.Metadata, KubeArmorHostPolicyKind, "", event.Object.Metadata.Name)
+
 	kl.ObjCommaExpandFirstDupOthers(&secPolicy.Spec.Network.MatchProtocols)
 	kl.ObjCommaExpandFirstDupOthers(&secPolicy.Spec.Capabilities.MatchCapabilities)
 
@@ -1578,6 +2146,113 @@ func (dm *KubeArmorDaemon) ParseAndUpdateHostSecurityPolicy(event tp.K8sKubeArmo
 		}
 	}
 
+	if len(secPolicy.Spec.Process.MatchNamespaces) > 0 {
+		for idx, ns := range secPolicy.Spec.Process.MatchNamespaces {
+			if ns.Severity == 0 {
+				if secPolicy.Spec.Process.Severity != 0 {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Severity = secPolicy.Spec.Process.Severity
+				} else {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Severity = secPolicy.Spec.Severity
+				}
+			}
+
+			if len(ns.Tags) == 0 {
+				if len(secPolicy.Spec.Process.Tags) > 0 {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Tags = secPolicy.Spec.Process.Tags
+				} else {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Tags = secPolicy.Spec.Tags
+				}
+			}
+
+			if len(ns.Message) == 0 {
+				if len(secPolicy.Spec.Process.Message) > 0 {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Message = secPolicy.Spec.Process.Message
+				} else {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Message = secPolicy.Spec.Message
+				}
+			}
+
+			if len(ns.Action) == 0 {
+				if len(secPolicy.Spec.Process.Action) > 0 {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Action = secPolicy.Spec.Process.Action
+				} else {
+					secPolicy.Spec.Process.MatchNamespaces[idx].Action = secPolicy.Spec.Action
+				}
+			}
+		}
+	}
+
+	if len(secPolicy.Spec.Process.MatchSignals) > 0 {
+		for idx, sig := range secPolicy.Spec.Process.MatchSignals {
+			if sig.Severity == 0 {
+				if secPolicy.Spec.Process.Severity != 0 {
+					secPolicy.Spec.Process.MatchSignals[idx].Severity = secPolicy.Spec.Process.Severity
+				} else {
+					secPolicy.Spec.Process.MatchSignals[idx].Severity = secPolicy.Spec.Severity
+				}
+			}
+
+			if len(sig.Tags) == 0 {
+				if len(secPolicy.Spec.Process.Tags) > 0 {
+					secPolicy.Spec.Process.MatchSignals[idx].Tags = secPolicy.Spec.Process.Tags
+				} else {
+					secPolicy.Spec.Process.MatchSignals[idx].Tags = secPolicy.Spec.Tags
+				}
+			}
+
+			if len(sig.Message) == 0 {
+				if len(secPolicy.Spec.Process.Message) > 0 {
+					secPolicy.Spec.Process.MatchSignals[idx].Message = secPolicy.Spec.Process.Message
+				} else {
+					secPolicy.Spec.Process.MatchSignals[idx].Message = secPolicy.Spec.Message
+				}
+			}
+
+			if len(sig.Action) == 0 {
+				if len(secPolicy.Spec.Process.Action) > 0 {
+					secPolicy.Spec.Process.MatchSignals[idx].Action = secPolicy.Spec.Process.Action
+				} else {
+					secPolicy.Spec.Process.MatchSignals[idx].Action = secPolicy.Spec.Action
+				}
+			}
+		}
+	}
+
+	if fileless := secPolicy.Spec.Process.BlockFileless; fileless != nil {
+		if fileless.Severity == 0 {
+			if secPolicy.Spec.Process.Severity != 0 {
+				fileless.Severity = secPolicy.Spec.Process.Severity
+			} else {
+				fileless.Severity = secPolicy.Spec.Severity
+			}
+		}
+
+		if len(fileless.Tags) == 0 {
+			if len(secPolicy.Spec.Process.Tags) > 0 {
+				fileless.Tags = secPolicy.Spec.Process.Tags
+			} else {
+				fileless.Tags = secPolicy.Spec.Tags
+			}
+		}
+
+		if len(fileless.Message) == 0 {
+			if len(secPolicy.Spec.Process.Message) > 0 {
+				fileless.Message = secPolicy.Spec.Process.Message
+			} else {
+				fileless.Message = secPolicy.Spec.Message
+			}
+		}
+
+		// only Audit and Block are inherited by fileless rules
+		if len(fileless.Action) == 0 {
+			if secPolicy.Spec.Process.Action == "Audit" || secPolicy.Spec.Process.Action == "Block" {
+				fileless.Action = secPolicy.Spec.Process.Action
+			} else if secPolicy.Spec.Action == "Audit" || secPolicy.Spec.Action == "Block" {
+				fileless.Action = secPolicy.Spec.Action
+			}
+		}
+	}
+
 	if len(secPolicy.Spec.File.MatchPaths) > 0 {
 		for idx, path := range secPolicy.Spec.File.MatchPaths {
 			if path.Severity == 0 {
@@ -1686,6 +2361,78 @@ func (dm *KubeArmorDaemon) ParseAndUpdateHostSecurityPolicy(event tp.K8sKubeArmo
 		}
 	}
 
+	if len(secPolicy.Spec.File.MatchXattrs) > 0 {
+		for idx, xattr := range secPolicy.Spec.File.MatchXattrs {
+			if xattr.Severity == 0 {
+				if secPolicy.Spec.File.Severity != 0 {
+					secPolicy.Spec.File.MatchXattrs[idx].Severity = secPolicy.Spec.File.Severity
+				} else {
+					secPolicy.Spec.File.MatchXattrs[idx].Severity = secPolicy.Spec.Severity
+				}
+			}
+
+			if len(xattr.Tags) == 0 {
+				if len(secPolicy.Spec.File.Tags) > 0 {
+					secPolicy.Spec.File.MatchXattrs[idx].Tags = secPolicy.Spec.File.Tags
+				} else {
+					secPolicy.Spec.File.MatchXattrs[idx].Tags = secPolicy.Spec.Tags
+				}
+			}
+
+			if len(xattr.Message) == 0 {
+				if len(secPolicy.Spec.File.Message) > 0 {
+					secPolicy.Spec.File.MatchXattrs[idx].Message = secPolicy.Spec.File.Message
+				} else {
+					secPolicy.Spec.File.MatchXattrs[idx].Message = secPolicy.Spec.Message
+				}
+			}
+
+			if len(xattr.Action) == 0 {
+				if len(secPolicy.Spec.File.Action) > 0 {
+					secPolicy.Spec.File.MatchXattrs[idx].Action = secPolicy.Spec.File.Action
+				} else {
+					secPolicy.Spec.File.MatchXattrs[idx].Action = secPolicy.Spec.Action
+				}
+			}
+		}
+	}
+
+	if len(secPolicy.Spec.File.MatchImmutable) > 0 {
+		for idx, imm := range secPolicy.Spec.File.MatchImmutable {
+			if imm.Severity == 0 {
+				if secPolicy.Spec.File.Severity != 0 {
+					secPolicy.Spec.File.MatchImmutable[idx].Severity = secPolicy.Spec.File.Severity
+				} else {
+					secPolicy.Spec.File.MatchImmutable[idx].Severity = secPolicy.Spec.Severity
+				}
+			}
+
+			if len(imm.Tags) == 0 {
+				if len(secPolicy.Spec.File.Tags) > 0 {
+					secPolicy.Spec.File.MatchImmutable[idx].Tags = secPolicy.Spec.File.Tags
+				} else {
+					secPolicy.Spec.File.MatchImmutable[idx].Tags = secPolicy.Spec.Tags
+				}
+			}
+
+			if len(imm.Message) == 0 {
+				if len(secPolicy.Spec.File.Message) > 0 {
+					secPolicy.Spec.File.MatchImmutable[idx].Message = secPolicy.Spec.File.Message
+				} else {
+					secPolicy.Spec.File.MatchImmutable[idx].Message = secPolicy.Spec.Message
+				}
+			}
+
+			if len(imm.Action) == 0 {
+				if len(secPolicy.Spec.File.Action) > 0 {
+					secPolicy.Spec.File.MatchImmutable[idx].Action = secPolicy.Spec.File.Action
+				} else {
+					secPolicy.Spec.File.MatchImmutable[idx].Action = secPolicy.Spec.Action
+				}
+			}
+		}
+	}
+
 	if len(secPolicy.Spec.Network.MatchProtocols) > 0 {
 		for idx, proto := range secPolicy.Spec.Network.MatchProtocols {
 			if proto.Severity == 0 {
@@ -1816,6 +2563,19 @@ func (dm *KubeArmorDaemon) ParseAndUpdateHostSecurityPolicy(event tp.K8sKubeArmo
 		}
 	}
 
+	return secPolicy, pb.PolicyStatus_Applied, nil
+}
+
+// parseAndUpdateHostSecurityPolicy Function
+func (dm *KubeArmorDaemon) parseAndUpdateHostSecurityPolicy(event tp.K8sKubeArmorHostPolicyEvent) pb.PolicyStatus {
+	secPolicy, status, err := dm.createHostSecurityPolicy(event)
+	if err != nil {
+		if status == pb.PolicyStatus_Invalid {
+			dm.Logger.Warnf("Rejected a host security policy (%s, %s)", event.Object.Metadata.Name, err.Error())
+		}
+		return status
+	}
+
 	// update a security policy into the policy list
 
 	dm.HostSecurityPoliciesLock.Lock()
@@ -1863,7 +2623,13 @@ func (dm *KubeArmorDaemon) ParseAndUpdateHostSecurityPolicy(event tp.K8sKubeArmo
 	// apply security policies to a host
 	dm.UpdateHostSecurityPolicies()
 
-	if !cfg.GlobalCfg.K8sEnv && (cfg.GlobalCfg.KVMAgent || cfg.GlobalCfg.HostPolicy) {
+	// the Block rules are enforced once the maturation period elapses
+	dm.trackPolicyMaturation(KubeArmorHostPolicyKind, "", secPolicy.Metadata["policyName"], secPolicy.Metadata, event.Type == "DELETED")
+
+	// the default host policies and the overrides (which must not outlive their TTL) aren't backed up
+	_, override := dm.policyOverrideExpiry(KubeArmorHostPolicyKind, "", secPolicy.Metadata["policyName"])
+
+	if !cfg.GlobalCfg.K8sEnv && (cfg.GlobalCfg.KVMAgent || cfg.GlobalCfg.HostPolicy) && !isDefaultHostPolicy(secPolicy.Metadata["policyName"]) && !override {
 		if event.Type == "ADDED" || event.Type == "MODIFIED" {
 			// backup HostSecurityPolicy to file
 			dm.backupKubeArmorHostPolicy(secPolicy)
@@ -1881,12 +2647,24 @@ func (dm *KubeArmorDaemon) ParseAndUpdateHostSecurityPolicy(event tp.K8sKubeArmo
 
 // WatchHostSecurityPolicies Function
 func (dm *KubeArmorDaemon) WatchHostSecurityPolicies() {
+	synced := false
+
 	for {
 		if !K8s.CheckCustomResourceDefinition("kubearmorhostpolicies") {
 			time.Sleep(time.Second * 1)
 			continue
 		}
 
+		// apply the existing host security policies before the container security policies
+		if !synced {
+			if err := dm.syncHostSecurityPolicies(K8s.ListK8sHostSecurityPolicies, dm.ParseAndUpdateHostSecurityPolicy); err != nil {
+				dm.Logger.Warnf("Failed to list host security policies (%s)", err.Error())
+			} else {
+				dm.HostPoliciesSynced.Open()
+			}
+			synced = true
+		}
+
 		if resp := K8s.WatchK8sHostSecurityPolicies(); resp != nil {
 			defer func() {
 				if err := resp.Body.Close(); err != nil {
@@ -1921,32 +2699,29 @@ func (dm *KubeArmorDaemon) WatchHostSecurityPolicies() {
 // == Default Posture == //
 // ===================== //
 
-func (dm *KubeArmorDaemon) updatEndpointsWithCM(cm *corev1.ConfigMap, action string) {
+func (dm *KubeArmorDaemon) updatEndpointsWithCM(namespaces []corev1.Namespace, action string) {
 	dm.EndPointsLock.Lock()
 	defer dm.EndPointsLock.Unlock()
 
 	dm.DefaultPosturesLock.Lock()
 	defer dm.DefaultPosturesLock.Unlock()
 
-	// get all namespaces
-	nsList, err := K8s.K8sClient.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		kg.Err("unable to fetch namespace list")
-		return
-	}
-
 	// for each namespace if needed change endpoint depfault posture
-	for _, ns := range nsList.Items {
+	for _, ns := range namespaces {
 		ns := ns
-		fp, fa := validateDefaultPosture("kubearmor-file-posture", &ns, cm.Data[cfg.ConfigDefaultFilePosture])
-		np, na := validateDefaultPosture("kubearmor-network-posture", &ns, cm.Data[cfg.ConfigDefaultNetworkPosture])
-		cp, ca := validateDefaultPosture("kubearmor-capabilities-posture", &ns, cm.Data[cfg.ConfigDefaultCapabilitiesPosture])
+		fp, fa := validateDefaultPosture(filePostureKey, &ns, cfg.GlobalCfg.DefaultFilePosture)
+		np, na := validateDefaultPosture(networkPostureKey, &ns, cfg.GlobalCfg.DefaultNetworkPosture)
+		cp, ca := validateDefaultPosture(capabilitiesPostureKey, &ns, cfg.GlobalCfg.DefaultCapabilitiesPosture)
 		annotated := fa || na || ca      // if namespace is annotated for atleast one posture
 		fullyannotated := fa && na && ca // if namespace is fully annotated
 		posture := tp.DefaultPosture{
 			FileAction:         fp,
 			NetworkAction:      np,
 			CapabilitiesAction: cp,
+
+			FileSource:         getNamespacePostureSource(ns.Name, fa),
+			NetworkSource:      getNamespacePostureSource(ns.Name, na),
+			CapabilitiesSource: getNamespacePostureSource(ns.Name, ca),
 		}
 
 		// skip if namespace is fully annotated
@@ -1960,7 +2735,7 @@ func (dm *KubeArmorDaemon) updatEndpointsWithCM(cm *corev1.ConfigMap, action str
 				continue
 			}
 
-			if endpoint.DefaultPosture != posture { // optimization, only if its needed to update the posture
+			if endpoint.DefaultPosture != applyPostureOverride(posture, endpoint.PostureOverride) { // optimization, only if its needed to update the posture
 				dm.Logger.Printf("updating default posture for %s in %s", ns.Name, endpoint.EndPointName)
 				dm.UpdateDefaultPostureWithCM(&dm.EndPoints[idx], action, ns.Name, posture, annotated)
 			}
@@ -1980,7 +2755,8 @@ func (dm *KubeArmorDaemon) UpdateDefaultPostureWithCM(endPoint *tp.EndPoint, act
 	dm.Logger.UpdateDefaultPosture(action, namespace, defaultPosture)
 
 	// update the endpoint with updated default posture
-	endPoint.DefaultPosture = defaultPosture
+	endPoint.DefaultPosture = applyPostureOverride(defaultPosture, endPoint.PostureOverride)
+	dm.Logger.UpdateEndPointPosture("MODIFIED", *endPoint)
 	dm.Logger.Printf("Updated default posture for %s with %v", endPoint.EndPointName, endPoint.DefaultPosture)
 	if cfg.GlobalCfg.Policy {
 		// update security policies
@@ -2037,12 +2813,14 @@ func (dm *KubeArmorDaemon) UpdateDefaultPosture(action string, namespace string,
 	for idx, endPoint := range dm.EndPoints {
 		// update a security policy
 		if namespace == endPoint.NamespaceName {
-			if dm.EndPoints[idx].DefaultPosture == defaultPosture {
+			posture := applyPostureOverride(defaultPosture, endPoint.PostureOverride)
+			if dm.EndPoints[idx].DefaultPosture == posture {
 				continue
 			}
 
 			dm.Logger.Printf("Updating default posture for %s with %v namespace default %v", endPoint.EndPointName, dm.EndPoints[idx].DefaultPosture, defaultPosture)
-			dm.EndPoints[idx].DefaultPosture = defaultPosture
+			dm.EndPoints[idx].DefaultPosture = posture
+			dm.Logger.UpdateEndPointPosture("MODIFIED", dm.EndPoints[idx])
 
 			if cfg.GlobalCfg.Policy {
 				// update security policies
@@ -2057,6 +2835,69 @@ func (dm *KubeArmorDaemon) UpdateDefaultPosture(action string, namespace string,
 	}
 }
 
+// ==================== //
+// == Severity Range == //
+// ==================== //
+
+// severity range annotations
+const (
+	minSeverityKey = "kubearmor.io/min-severity"
+	maxSeverityKey = "kubearmor.io/max-severity"
+)
+
+// parseSeverityAnnotation returns the severity annotated on a namespace (0 if absent or invalid)
+func (dm *KubeArmorDaemon) parseSeverityAnnotation(ns *corev1.Namespace, key string) int {
+	value, ok := ns.Annotations[key]
+	if !ok {
+		return 0
+	}
+
+	severity, err := strconv.Atoi(value)
+	if err != nil || severity < 1 || severity > 10 {
+		dm.Logger.Warnf("Invalid %s annotation for namespace %s (%s)", key, ns.Name, value)
+		return 0
+	}
+
+	return severity
+}
+
+// getSeverityRange returns the severity range annotated on a namespace
+func (dm *KubeArmorDaemon) getSeverityRange(ns *corev1.Namespace) tp.SeverityRange {
+	severityRange := tp.SeverityRange{
+		Min: dm.parseSeverityAnnotation(ns, minSeverityKey),
+		Max: dm.parseSeverityAnnotation(ns, maxSeverityKey),
+	}
+
+	if severityRange.Min > 0 && severityRange.Max > 0 && severityRange.Min > severityRange.Max {
+		dm.Logger.Warnf("Ignored the severity range for namespace %s (min-severity %d > max-severity %d)", ns.Name, severityRange.Min, severityRange.Max)
+		return tp.SeverityRange{}
+	}
+
+	return severityRange
+}
+
+// UpdateSeverityRange Function keeps the severity range of a namespace, which the feeder applies to its alerts
+func (dm *KubeArmorDaemon) UpdateSeverityRange(action string, namespace string, severityRange tp.SeverityRange) {
+	dm.SeverityRangesLock.Lock()
+	defer dm.SeverityRangesLock.Unlock()
+
+	if action == "DELETED" || severityRange == (tp.SeverityRange{}) {
+		if _, ok := dm.SeverityRanges[namespace]; !ok {
+			return
+		}
+		delete(dm.SeverityRanges, namespace)
+		severityRange = tp.SeverityRange{}
+	} else {
+		if dm.SeverityRanges[namespace] == severityRange {
+			return
+		}
+		dm.SeverityRanges[namespace] = severityRange
+	}
+
+	dm.Logger.Printf("Namespace %s severity range configured %+v", namespace, severityRange)
+	dm.Logger.UpdateSeverityRange(action, namespace, severityRange)
+}
+
 func validateGlobalDefaultPosture(posture string) string {
 	switch posture {
 	case "audit", "Audit":
@@ -2078,6 +2919,9 @@ func (dm *KubeArmorDaemon) validateVisibility(scope string, visibility string) b
 
 // UpdateVisibility Function
 func (dm *KubeArmorDaemon) UpdateVisibility(action string, namespace string, visibility tp.Visibility) {
+	// the namespace may need the probes of a detached event class
+	defer dm.requestEventClassUpdate()
+
 	dm.SystemMonitor.BpfMapLock.Lock()
 	defer dm.SystemMonitor.BpfMapLock.Unlock()
 
@@ -2087,6 +2931,7 @@ func (dm *KubeArmorDaemon) UpdateVisibility(action string, namespace string, vis
 			val.File = visibility.File
 			val.Network = visibility.Network
 			val.Process = visibility.Process
+			val.Signal = visibility.Signal
 			dm.SystemMonitor.NamespacePidsMap[namespace] = val
 			for _, nskey := range val.NsKeys {
 				dm.SystemMonitor.UpdateNsKeyMap("MODIFIED", nskey, visibility)
@@ -2098,6 +2943,7 @@ func (dm *KubeArmorDaemon) UpdateVisibility(action string, namespace string, vis
 				Process:    visibility.Process,
 				Capability: visibility.Capabilities,
 				Network:    visibility.Network,
+				Signal:     visibility.Signal,
 			}
 		}
 		dm.Logger.Printf("Namespace %s visibiliy configured %+v", namespace, visibility)
@@ -2114,32 +2960,15 @@ func (dm *KubeArmorDaemon) UpdateVisibility(action string, namespace string, vis
 
 var visibilityKey string = "kubearmor-visibility"
 
-func (dm *KubeArmorDaemon) updateVisibilityWithCM(cm *corev1.ConfigMap, action string) {
+func (dm *KubeArmorDaemon) updateVisibilityWithCM(namespaces []corev1.Namespace) {
 
 	// we overwrite
 
-	// get all namespaces
-	nsList, err := K8s.K8sClient.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		kg.Err("unable to fetch namespace list")
-		return
-	}
-
-	// for each namespace if needed change the visibility
-	for _, ns := range nsList.Items {
-		// if namespace is annotated with visibility annotation don't update on config map change
-		if _, found := ns.Annotations[visibilityKey]; found || kl.ContainsElement(dm.SystemMonitor.UntrackedNamespaces, ns.Name) {
-			continue
-		}
+	// if namespace is annotated with visibility annotation don't update on config map change
+	dm.updateDefaultVisibility(namespaces)
 
-		visibility := tp.Visibility{
-			File:         strings.Contains(cm.Data[cfg.ConfigVisibility], "file"),
-			Process:      strings.Contains(cm.Data[cfg.ConfigVisibility], "process"),
-			Network:      strings.Contains(cm.Data[cfg.ConfigVisibility], "network"),
-			Capabilities: strings.Contains(cm.Data[cfg.ConfigVisibility], "capabilities"),
-		}
-		dm.UpdateVisibility("MODIFIED", ns.Name, visibility)
-	}
+	// the host visibility may need the probes of a detached event class
+	dm.requestEventClassUpdate()
 }
 
 // UpdateGlobalPosture Function
@@ -2169,77 +2998,60 @@ func (dm *KubeArmorDaemon) WatchDefaultPosture() {
 	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			if ns, ok := obj.(*corev1.Namespace); ok {
-				fp, fa := validateDefaultPosture("kubearmor-file-posture", ns, cfg.GlobalCfg.DefaultFilePosture)
-				np, na := validateDefaultPosture("kubearmor-network-posture", ns, cfg.GlobalCfg.DefaultNetworkPosture)
-				cp, ca := validateDefaultPosture("kubearmor-capabilities-posture", ns, cfg.GlobalCfg.DefaultCapabilitiesPosture)
+				fp, fa := validateDefaultPosture(filePostureKey, ns, cfg.GlobalCfg.DefaultFilePosture)
+				np, na := validateDefaultPosture(networkPostureKey, ns, cfg.GlobalCfg.DefaultNetworkPosture)
+				cp, ca := validateDefaultPosture(capabilitiesPostureKey, ns, cfg.GlobalCfg.DefaultCapabilitiesPosture)
 				defaultPosture := tp.DefaultPosture{
 					FileAction:         fp,
 					NetworkAction:      np,
 					CapabilitiesAction: cp,
+
+					FileSource:         getNamespacePostureSource(ns.Name, fa),
+					NetworkSource:      getNamespacePostureSource(ns.Name, na),
+					CapabilitiesSource: getNamespacePostureSource(ns.Name, ca),
 				}
 				annotated := fa || na || ca
-				// Set Visibility to Global Default
-				visibility := tp.Visibility{
-					File:         dm.validateVisibility("file", cfg.GlobalCfg.Visibility),
-					Process:      dm.validateVisibility("process", cfg.GlobalCfg.Visibility),
-					Network:      dm.validateVisibility("network", cfg.GlobalCfg.Visibility),
-					Capabilities: dm.validateVisibility("capabilities", cfg.GlobalCfg.Visibility),
-				}
-
-				// Set Visibility to Namespace Annotation if exists
-				if ns.Annotations != nil && ns.Annotations[visibilityKey] != "" {
-					visibility = tp.Visibility{
-						File:         dm.validateVisibility("file", ns.Annotations[visibilityKey]),
-						Process:      dm.validateVisibility("process", ns.Annotations[visibilityKey]),
-						Network:      dm.validateVisibility("network", ns.Annotations[visibilityKey]),
-						Capabilities: dm.validateVisibility("capabilities", ns.Annotations[visibilityKey]),
-					}
-				}
+				// Set Visibility to Namespace Annotation if exists, or to the Default Visibility
+				nsVisibility, _ := getNamespaceVisibility(ns)
+				visibility := dm.parseVisibility(nsVisibility)
 				dm.UpdateDefaultPosture("ADDED", ns.Name, defaultPosture, annotated)
 				dm.UpdateVisibility("ADDED", ns.Name, visibility)
+				dm.UpdateSeverityRange("ADDED", ns.Name, dm.getSeverityRange(ns))
 			}
 		},
 		UpdateFunc: func(_, new interface{}) {
 			if ns, ok := new.(*corev1.Namespace); ok {
-				fp, fa := validateDefaultPosture("kubearmor-file-posture", ns, cfg.GlobalCfg.DefaultFilePosture)
-				np, na := validateDefaultPosture("kubearmor-network-posture", ns, cfg.GlobalCfg.DefaultNetworkPosture)
-				cp, ca := validateDefaultPosture("kubearmor-capabilities-posture", ns, cfg.GlobalCfg.DefaultCapabilitiesPosture)
+				fp, fa := validateDefaultPosture(filePostureKey, ns, cfg.GlobalCfg.DefaultFilePosture)
+				np, na := validateDefaultPosture(networkPostureKey, ns, cfg.GlobalCfg.DefaultNetworkPosture)
+				cp, ca := validateDefaultPosture(capabilitiesPostureKey, ns, cfg.GlobalCfg.DefaultCapabilitiesPosture)
 				defaultPosture := tp.DefaultPosture{
 					FileAction:         fp,
 					NetworkAction:      np,
 					CapabilitiesAction: cp,
+
+					FileSource:         getNamespacePostureSource(ns.Name, fa),
+					NetworkSource:      getNamespacePostureSource(ns.Name, na),
+					CapabilitiesSource: getNamespacePostureSource(ns.Name, ca),
 				}
 				annotated := fa || na || ca
-				// Set Visibility to Global Default
-				visibility := tp.Visibility{
-					File:         dm.validateVisibility("file", cfg.GlobalCfg.Visibility),
-					Process:      dm.validateVisibility("process", cfg.GlobalCfg.Visibility),
-					Network:      dm.validateVisibility("network", cfg.GlobalCfg.Visibility),
-					Capabilities: dm.validateVisibility("capabilities", cfg.GlobalCfg.Visibility),
-				}
-
-				// Set Visibility to Namespace Annotation if exists
-				if ns.Annotations != nil && ns.Annotations[visibilityKey] != "" {
-					visibility = tp.Visibility{
-						File:         dm.validateVisibility("file", ns.Annotations[visibilityKey]),
-						Process:      dm.validateVisibility("process", ns.Annotations[visibilityKey]),
-						Network:      dm.validateVisibility("network", ns.Annotations[visibilityKey]),
-						Capabilities: dm.validateVisibility("capabilities", ns.Annotations[visibilityKey]),
-					}
-				}
+				// Set Visibility to Namespace Annotation if exists, or to the Default Visibility
+				nsVisibility, _ := getNamespaceVisibility(ns)
+				visibility := dm.parseVisibility(nsVisibility)
 				dm.UpdateDefaultPosture("MODIFIED", ns.Name, defaultPosture, annotated)
 				dm.UpdateVisibility("MODIFIED", ns.Name, visibility)
+				dm.UpdateSeverityRange("MODIFIED", ns.Name, dm.getSeverityRange(ns))
 
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			if ns, ok := obj.(*corev1.Namespace); ok {
-				_, fa := validateDefaultPosture("kubearmor-file-posture", ns, cfg.GlobalCfg.DefaultFilePosture)
-				_, na := validateDefaultPosture("kubearmor-network-posture", ns, cfg.GlobalCfg.DefaultNetworkPosture)
-				_, ca := validateDefaultPosture("kubearmor-capabilities-posture", ns, cfg.GlobalCfg.DefaultCapabilitiesPosture)
+				_, fa := validateDefaultPosture(filePostureKey, ns, cfg.GlobalCfg.DefaultFilePosture)
+				_, na := validateDefaultPosture(networkPostureKey, ns, cfg.GlobalCfg.DefaultNetworkPosture)
+				_, ca := validateDefaultPosture(capabilitiesPostureKey, ns, cfg.GlobalCfg.DefaultCapabilitiesPosture)
 				annotated := fa || na || ca
 				dm.UpdateDefaultPosture("DELETED", ns.Name, tp.DefaultPosture{}, annotated)
 				dm.UpdateVisibility("DELETED", ns.Name, tp.Visibility{})
+				dm.UpdateSeverityRange("DELETED", ns.Name, tp.SeverityRange{})
 			}
 		},
 	}); err != nil {
@@ -2252,6 +3064,36 @@ func (dm *KubeArmorDaemon) WatchDefaultPosture() {
 	dm.Logger.Print("Started watching Default Posture Annotations and namespace")
 }
 
+// reloadConfigMap applies the global postures and the visibility of the ConfigMap, and logs what it changed
+func (dm *KubeArmorDaemon) reloadConfigMap(cm *corev1.ConfigMap, action string) {
+	config, warnings := resolveConfigMap(cm.Data)
+	for _, warning := range warnings {
+		dm.Logger.Warnf("Reloading the ConfigMap: %s", warning)
+	}
+
+	// get all namespaces
+	namespaces, err := listNamespaces()
+	if err != nil {
+		kg.Err("unable to fetch namespace list")
+		return
+	}
+
+	diff := dm.diffConfig(config, namespaces)
+
+	cfg.GlobalCfg.HostVisibility = config.HostVisibility
+	cfg.GlobalCfg.Visibility = config.Visibility
+	cfg.GlobalCfg.DefaultVisibility = config.DefaultVisibility
+
+	dm.UpdateGlobalPosture(config.defaultPosture())
+
+	// update default posture for endpoints
+	dm.updatEndpointsWithCM(namespaces, action)
+	// update visibility for namespaces
+	dm.updateVisibilityWithCM(namespaces)
+
+	dm.logConfigDiff(diff)
+}
+
 // WatchConfigMap function
 func (dm *KubeArmorDaemon) WatchConfigMap() {
 	configMapLabelOption := informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
@@ -2265,48 +3107,12 @@ func (dm *KubeArmorDaemon) WatchConfigMap() {
 	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			if cm, ok := obj.(*corev1.ConfigMap); ok && cm.Namespace == cmNS {
-				cfg.GlobalCfg.HostVisibility = cm.Data[cfg.ConfigHostVisibility]
-				cfg.GlobalCfg.Visibility = cm.Data[cfg.ConfigVisibility]
-				globalPosture := tp.DefaultPosture{
-					FileAction:         cm.Data[cfg.ConfigDefaultFilePosture],
-					NetworkAction:      cm.Data[cfg.ConfigDefaultNetworkPosture],
-					CapabilitiesAction: cm.Data[cfg.ConfigDefaultCapabilitiesPosture],
-				}
-				currentGlobalPosture := tp.DefaultPosture{
-					FileAction:         cfg.GlobalCfg.DefaultFilePosture,
-					NetworkAction:      cfg.GlobalCfg.DefaultNetworkPosture,
-					CapabilitiesAction: cfg.GlobalCfg.DefaultCapabilitiesPosture,
-				}
-				dm.Logger.Printf("Current Global Posture is %v", currentGlobalPosture)
-				dm.UpdateGlobalPosture(globalPosture)
-
-				// update default posture for endpoints
-				dm.updatEndpointsWithCM(cm, "ADDED")
-				// update visibility for namespaces
-				dm.updateVisibilityWithCM(cm, "ADDED")
+				dm.reloadConfigMap(cm, "ADDED")
 			}
 		},
 		UpdateFunc: func(_, new interface{}) {
 			if cm, ok := new.(*corev1.ConfigMap); ok && cm.Namespace == cmNS {
-				cfg.GlobalCfg.HostVisibility = cm.Data[cfg.ConfigHostVisibility]
-				cfg.GlobalCfg.Visibility = cm.Data[cfg.ConfigVisibility]
-				globalPosture := tp.DefaultPosture{
-					FileAction:         cm.Data[cfg.ConfigDefaultFilePosture],
-					NetworkAction:      cm.Data[cfg.ConfigDefaultNetworkPosture],
-					CapabilitiesAction: cm.Data[cfg.ConfigDefaultCapabilitiesPosture],
-				}
-				currentGlobalPosture := tp.DefaultPosture{
-					FileAction:         cfg.GlobalCfg.DefaultFilePosture,
-					NetworkAction:      cfg.GlobalCfg.DefaultNetworkPosture,
-					CapabilitiesAction: cfg.GlobalCfg.DefaultCapabilitiesPosture,
-				}
-				dm.Logger.Printf("Current Global Posture is %v", currentGlobalPosture)
-				dm.UpdateGlobalPosture(globalPosture)
-
-				// update default posture for endpoints
-				dm.updatEndpointsWithCM(cm, "MODIFIED")
-				// update visibility for namespaces
-				dm.updateVisibilityWithCM(cm, "MODIFIED")
+				dm.reloadConfigMap(cm, "MODIFIED")
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
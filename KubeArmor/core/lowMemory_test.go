@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	"github.com/kubearmor/KubeArmor/KubeArmor/monitor"
+	"github.com/kubearmor/KubeArmor/KubeArmor/testutil"
+)
+
+// lowMemoryTestMode tells the test binary to run as a daemon in the given mode and report its RSS
+const lowMemoryTestMode = "KUBEARMOR_LOW_MEMORY_TEST"
+
+// readRSS returns the resident set size of the test process in kB
+func readRSS() (int, error) {
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) >= 2 && fields[0] == "VmRSS:" {
+			return strconv.Atoi(fields[1])
+		}
+	}
+
+	return 0, fmt.Errorf("no VmRSS in /proc/self/status")
+}
+
+// runLowMemoryDaemon loads the configuration, keeps track of the containers of the fake runtime, and fills the
+// enrichment caches and the event channel as a busy node would, then prints the RSS
+func runLowMemoryDaemon(t *testing.T) {
+	os.Args = os.Args[:1]
+	if err := cfg.LoadConfig(); err != nil {
+		t.Fatalf("[FAIL] Failed to load the configuration (%s)", err.Error())
+	}
+
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	dm := newCrioTestDaemon()
+	dm.SystemMonitor = monitor.NewSystemMonitor(&dm.Node, &dm.NodeLock, dm.Logger, &dm.Containers, &dm.ContainersLock, &dm.ActiveHostPidMap, &dm.ActivePidMapLock, &dm.MonitorLock)
+
+	StopChan = make(chan struct{})
+	go dm.MonitorCrioEvents()
+
+	containerIDs := []string{}
+	for i := 0; i < 8; i++ {
+		containerID := fmt.Sprintf("app-%d", i)
+		containerIDs = append(containerIDs, containerID)
+
+		fake.AddContainer(testutil.FakeContainer{
+			ID:        containerID,
+			Name:      containerID,
+			Namespace: "default",
+			PodName:   containerID + "-pod",
+			Pid:       os.Getpid(),
+		})
+	}
+
+	waitFor(t, "the containers to be added", func() bool {
+		dm.ContainersLock.RLock()
+		defer dm.ContainersLock.RUnlock()
+		return len(dm.Containers) == len(containerIDs)
+	})
+
+	// the processes of the containers open sockets, and their sessions are looked up (beyond the pids of the host)
+	for i := 0; i < 65536; i++ {
+		hostPid := uint32(1<<30 + i)
+		containerID := containerIDs[i%len(containerIDs)]
+
+		dm.SystemMonitor.SocketTracker.AddSocket(uint64(hostPid), monitor.SocketOwner{HostPID: hostPid, ProcessName: fmt.Sprintf("/usr/local/bin/worker-%d", i)})
+		dm.SystemMonitor.GetSession(containerID, hostPid, 1<<30)
+	}
+
+	// the events wait in userspace
+	events := make(chan []byte, monitor.EventChannelSize())
+	for i := 0; i < cap(events); i++ {
+		events <- make([]byte, 512)
+	}
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	runtime.GC()
+	debug.FreeOSMemory()
+
+	rss, err := readRSS()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to read the RSS (%s)", err.Error())
+	}
+
+	fmt.Printf("RSS=%d events=%d sockets=%d\n", rss, len(events), dm.SystemMonitor.SocketTracker.Len())
+}
+
+// lowMemoryRSS runs the test binary as a daemon with the given env vars and returns its RSS in kB
+func lowMemoryRSS(t *testing.T, env ...string) int {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLowMemoryMode$") // #nosec
+	cmd.Env = append(append(os.Environ(), lowMemoryTestMode+"=1"), env...)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to run the daemon (%s)\n%s", err.Error(), out)
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "RSS=") {
+			var rss, events, sockets int
+			if _, err := fmt.Sscanf(line, "RSS=%d events=%d sockets=%d", &rss, &events, &sockets); err == nil {
+				t.Logf("%v: %d kB (%d events, %d sockets)", env, rss, events, sockets)
+				return rss
+			}
+		}
+	}
+
+	t.Fatalf("[FAIL] No RSS reported by the daemon\n%s", out)
+	return 0
+}
+
+func TestLowMemoryMode(t *testing.T) {
+	if os.Getenv(lowMemoryTestMode) != "" {
+		runLowMemoryDaemon(t)
+		return
+	}
+
+	if _, err := readRSS(); err != nil {
+		t.Skipf("No RSS to compare (%s)", err.Error())
+	}
+
+	defaultRSS := lowMemoryRSS(t)
+	lowRSS := lowMemoryRSS(t, "LOWMEMORYMODE=true")
+
+	// the enrichment caches and the event channel alone are ~10MB apart
+	if defaultRSS-lowRSS < 4096 {
+		t.Errorf("[FAIL] Expected the low memory mode to use less memory (default: %d kB, low memory: %d kB)", defaultRSS, lowRSS)
+	}
+
+	// the settings of the profile can still be overridden
+	overriddenRSS := lowMemoryRSS(t, "LOWMEMORYMODE=true", "ENRICHMENTCACHESIZE=65536", "EVENTCHANNELSIZE=8192")
+	if overriddenRSS-lowRSS < 4096 {
+		t.Errorf("[FAIL] Expected the overridden settings to be kept (low memory: %d kB, overridden: %d kB)", lowRSS, overriddenRSS)
+	}
+
+	t.Log("[PASS] Used less memory in the low memory mode")
+}
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// ================= //
+// == Mount Audit == //
+// ================= //
+
+// RiskyMountsPolicyName is the policy name of the alerts of risky mounts
+const RiskyMountsPolicyName = "kubearmor-risky-mounts"
+
+// mount finding reasons
+const (
+	MountReasonSensitivePath = "sensitive host path"
+	MountReasonBidirectional = "bidirectional propagation"
+)
+
+// DefaultSensitiveHostPaths are the host paths flagged when mounted into containers
+var DefaultSensitiveHostPaths = []string{
+	"/",
+	"/etc",
+	"/proc",
+	"/var/run/docker.sock",
+	"/run/docker.sock",
+	"/run/containerd/containerd.sock",
+	"/var/run/crio/crio.sock",
+}
+
+// sensitiveHostPaths returns the built-in and configured sensitive host paths
+func sensitiveHostPaths() []string {
+	return append(append([]string{}, DefaultSensitiveHostPaths...), cfg.GlobalCfg.SensitiveHostPaths...)
+}
+
+// isSensitiveHostPath checks if a host path is, or is under, one of the sensitive paths
+// (only the root itself for "/")
+func isSensitiveHostPath(source string, sensitive []string) bool {
+	source = filepath.Clean(source)
+
+	for _, path := range sensitive {
+		path = filepath.Clean(path)
+
+		if source == path {
+			return true
+		}
+		if path != "/" && strings.HasPrefix(source, path+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClassifyMounts returns the bind mounts of an OCI runtime spec from sensitive host paths
+// or with bidirectional propagation
+func ClassifyMounts(mounts []specs.Mount, sensitive []string) []tp.MountFinding {
+	findings := []tp.MountFinding{}
+
+	for _, mount := range mounts {
+		bind := mount.Type == "bind" || kl.ContainsElement(mount.Options, "bind") || kl.ContainsElement(mount.Options, "rbind")
+		if !bind {
+			continue
+		}
+
+		reasons := []string{}
+		propagation := ""
+
+		if isSensitiveHostPath(mount.Source, sensitive) {
+			reasons = append(reasons, MountReasonSensitivePath)
+		}
+
+		// Bidirectional in Kubernetes is rshared
+		if kl.ContainsElement(mount.Options, "rshared") || kl.ContainsElement(mount.Options, "shared") {
+			propagation = "Bidirectional"
+			reasons = append(reasons, MountReasonBidirectional)
+		}
+
+		if len(reasons) == 0 {
+			continue
+		}
+
+		findings = append(findings, tp.MountFinding{
+			Source:      mount.Source,
+			Destination: mount.Destination,
+			Propagation: propagation,
+			Reason:      strings.Join(reasons, ", "),
+		})
+	}
+
+	return findings
+}
+
+// dockerMounts converts the mounts of a Docker container into OCI mounts
+func dockerMounts(mountPoints []types.MountPoint) []specs.Mount {
+	mounts := []specs.Mount{}
+
+	for _, mountPoint := range mountPoints {
+		mount := specs.Mount{
+			Destination: mountPoint.Destination,
+			Type:        string(mountPoint.Type),
+			Source:      mountPoint.Source,
+		}
+		if mountPoint.Propagation != "" {
+			mount.Options = append(mount.Options, string(mountPoint.Propagation))
+		}
+
+		mounts = append(mounts, mount)
+	}
+
+	return mounts
+}
+
+// formatMountFindings Function
+func formatMountFindings(findings []tp.MountFinding) []string {
+	formatted := []string{}
+
+	for _, finding := range findings {
+		formatted = append(formatted, finding.Source+":"+finding.Destination+" ("+finding.Reason+")")
+	}
+
+	return formatted
+}
+
+// reportRiskyMounts raises an informational alert listing the risky mounts of a new container
+func (dm *KubeArmorDaemon) reportRiskyMounts(container tp.Container) {
+	if len(container.RiskyMounts) == 0 || dm.Logger == nil {
+		return
+	}
+
+	log := tp.Log{}
+
+	timestamp, updatedTime := kl.GetDateTimeNow()
+
+	log.Timestamp = timestamp
+	log.UpdatedTime = updatedTime
+
+	log.NamespaceName = container.NamespaceName
+	log.PodName = container.EndPointName
+	log.ContainerID = container.ContainerID
+	log.ContainerName = container.ContainerName
+	log.ContainerImage = container.ContainerImage
+	log.Privileged = container.Privileged
+
+	log.Type = "MatchedPolicy"
+	log.PolicyName = RiskyMountsPolicyName
+	log.Severity = "1"
+	log.Tags = "KUBEARMOR,MOUNT"
+	log.Message = "Container has risky host mounts"
+
+	log.Source = "kubearmor"
+	log.ProcessName = "kubearmor"
+	log.Operation = "File"
+	log.Resource = strings.Join(formatMountFindings(container.RiskyMounts), ",")
+
+	log.Enforcer = "KubeArmor"
+	log.Action = "Audit"
+	log.Result = "Passed"
+
+	dm.Logger.PushSummaryLog(log)
+}
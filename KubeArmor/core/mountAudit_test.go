@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	"github.com/kubearmor/KubeArmor/KubeArmor/testutil"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// mounts of the runtime spec of a pod with hostPath volumes
+const runtimeSpecMounts = `[
+	{"destination": "/proc", "type": "proc", "source": "proc", "options": ["nosuid", "noexec", "nodev"]},
+	{"destination": "/etc/hosts", "type": "bind", "source": "/var/lib/kubelet/pods/0b6f/etc-hosts", "options": ["rbind", "rprivate", "rw"]},
+	{"destination": "/var/run/secrets/kubernetes.io/serviceaccount", "type": "bind", "source": "/var/lib/kubelet/pods/0b6f/volumes/kubernetes.io~projected/kube-api-access", "options": ["rbind", "rprivate", "ro"]},
+	{"destination": "/host", "type": "bind", "source": "/", "options": ["rbind", "rslave", "ro"]},
+	{"destination": "/host-etc", "source": "/etc/", "options": ["rbind", "rprivate", "ro"]},
+	{"destination": "/host/proc/1", "type": "bind", "source": "/proc/1", "options": ["rbind", "rprivate"]},
+	{"destination": "/var/run/docker.sock", "type": "bind", "source": "/var/run/docker.sock", "options": ["rbind", "rprivate", "rw"]},
+	{"destination": "/var/log/pods", "type": "bind", "source": "/var/log/pods", "options": ["rbind", "rprivate", "ro"]},
+	{"destination": "/mnt/csi", "type": "bind", "source": "/var/lib/kubelet/plugins", "options": ["rbind", "rshared", "rw"]},
+	{"destination": "/data", "type": "bind", "source": "/srv/data", "options": ["rbind", "rprivate", "rw"]}
+]`
+
+func TestClassifyMounts(t *testing.T) {
+	mounts := []specs.Mount{}
+	if err := json.Unmarshal([]byte(runtimeSpecMounts), &mounts); err != nil {
+		t.Fatalf("[FAIL] Failed to parse the fixture (%s)", err.Error())
+	}
+
+	findings := ClassifyMounts(mounts, append(DefaultSensitiveHostPaths, "/srv/data"))
+
+	expected := map[string]string{
+		"/host":                MountReasonSensitivePath,
+		"/host-etc":            MountReasonSensitivePath,
+		"/host/proc/1":         MountReasonSensitivePath,
+		"/var/run/docker.sock": MountReasonSensitivePath,
+		"/mnt/csi":             MountReasonBidirectional,
+		"/data":                MountReasonSensitivePath,
+	}
+
+	if len(findings) != len(expected) {
+		t.Errorf("[FAIL] Expected %d findings (%+v)", len(expected), findings)
+	}
+
+	for _, finding := range findings {
+		if reason, ok := expected[finding.Destination]; !ok || reason != finding.Reason {
+			t.Errorf("[FAIL] Unexpected finding (%+v)", finding)
+		}
+		if finding.Destination == "/mnt/csi" && finding.Propagation != "Bidirectional" {
+			t.Errorf("[FAIL] Expected the propagation of the mount (%+v)", finding)
+		}
+	}
+
+	// paths beside the sensitive ones are not flagged
+	if isSensitiveHostPath("/etcd", DefaultSensitiveHostPaths) || isSensitiveHostPath("/var/run/docker.sock.bak", DefaultSensitiveHostPaths) {
+		t.Errorf("[FAIL] Expected prefixes of sensitive paths not to be flagged")
+	}
+
+	t.Log("[PASS] Classified the mounts of a runtime spec")
+}
+
+func TestCrioRiskyMounts(t *testing.T) {
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = false
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	// subscribe to the alerts
+	alerts := make(chan *pb.Alert, 1)
+	fd.AlertLock = new(sync.RWMutex)
+	fd.AlertStructs = map[string]fd.AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+	defer func() { fd.AlertStructs = map[string]fd.AlertStruct{} }()
+
+	dm := newCrioTestDaemon()
+	dm.Logger.Output = "none"
+	dm.Logger.SeverityRangesLock = new(sync.RWMutex)
+	dm.Logger.SinksLock = new(sync.RWMutex)
+
+	ch, err := NewCrioHandler()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to connect to the fake CRI runtime (%s)", err.Error())
+	}
+	dm.crio = ch
+	defer dm.CloseRuntimeHandlers()
+
+	fake.AddContainer(testutil.FakeContainer{
+		ID:         "agent",
+		Name:       "agent",
+		Namespace:  "monitoring",
+		PodName:    "agent-pod",
+		Pid:        os.Getpid(),
+		Privileged: true,
+		Mounts: []specs.Mount{
+			{Destination: "/var/run/docker.sock", Type: "bind", Source: "/var/run/docker.sock", Options: []string{"rbind", "rprivate"}},
+			{Destination: "/data", Type: "bind", Source: "/srv/data", Options: []string{"rbind", "rprivate"}},
+		},
+	})
+
+	if !dm.UpdateCrioContainer(context.Background(), "agent", "start") {
+		t.Fatalf("[FAIL] Failed to add the container")
+	}
+
+	container := dm.Containers["agent"]
+	if len(container.RiskyMounts) != 1 || container.RiskyMounts[0].Source != "/var/run/docker.sock" {
+		t.Errorf("[FAIL] Unexpected findings of the container (%+v)", container.RiskyMounts)
+	}
+
+	// an alert lists the findings once
+	select {
+	case alert := <-alerts:
+		if alert.PolicyName != RiskyMountsPolicyName || alert.PodName != "agent-pod" || !alert.Privileged || alert.Resource != "/var/run/docker.sock:/var/run/docker.sock (sensitive host path)" {
+			t.Errorf("[FAIL] Unexpected alert (%+v)", alert)
+		}
+	default:
+		t.Errorf("[FAIL] Expected an alert of the risky mounts")
+	}
+
+	// the findings are shown with the endpoint in the probe data
+	dm.EndPoints = append(dm.EndPoints, tp.EndPoint{NamespaceName: "monitoring", EndPointName: "agent-pod", Containers: []string{"agent"}})
+
+	_, containerMap, _ := dm.SetProbeContainerData()
+	if mounts := containerMap["agent-pod"].GetRiskyMounts(); len(mounts) != 1 || mounts[0] != "/var/run/docker.sock:/var/run/docker.sock (sensitive host path)" {
+		t.Errorf("[FAIL] Unexpected findings in the probe data (%v)", mounts)
+	}
+
+	t.Log("[PASS] Flagged the risky mounts of a container")
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"strings"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ========================== //
+// == Namespace Visibility == //
+// ========================== //
+
+// getDefaultVisibility returns the visibility of the namespaces without the visibility annotation
+func getDefaultVisibility() string {
+	if cfg.GlobalCfg.DefaultVisibility != "" {
+		return cfg.GlobalCfg.DefaultVisibility
+	}
+	return cfg.GlobalCfg.Visibility
+}
+
+// getNamespaceVisibility returns the visibility annotated in a namespace, or the default visibility,
+// and whether the namespace is annotated
+func getNamespaceVisibility(ns *corev1.Namespace) (string, bool) {
+	if ns.Annotations != nil && strings.TrimSpace(ns.Annotations[visibilityKey]) != "" {
+		return ns.Annotations[visibilityKey], true
+	}
+	return getDefaultVisibility(), false
+}
+
+// parseVisibility Function
+func (dm *KubeArmorDaemon) parseVisibility(visibility string) tp.Visibility {
+	return tp.Visibility{
+		File:         dm.validateVisibility("file", visibility),
+		Process:      dm.validateVisibility("process", visibility),
+		Network:      dm.validateVisibility("network", visibility),
+		Capabilities: dm.validateVisibility("capabilities", visibility),
+		Signal:       dm.validateVisibility("signal", visibility),
+	}
+}
+
+// updateDefaultVisibility applies the default visibility to the namespaces without the visibility annotation
+func (dm *KubeArmorDaemon) updateDefaultVisibility(namespaces []corev1.Namespace) {
+	visibility := dm.parseVisibility(getDefaultVisibility())
+
+	for idx := range namespaces {
+		ns := &namespaces[idx]
+
+		// the namespaces annotated explicitly keep their visibility
+		if _, annotated := getNamespaceVisibility(ns); annotated || kl.ContainsElement(dm.SystemMonitor.UntrackedNamespaces, ns.Name) {
+			continue
+		}
+
+		dm.UpdateVisibility("MODIFIED", ns.Name, visibility)
+	}
+}
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"sync"
+	"testing"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	"github.com/kubearmor/KubeArmor/KubeArmor/monitor"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNamespaceDefaultVisibility(t *testing.T) {
+	prevVisibility, prevDefaultVisibility := cfg.GlobalCfg.Visibility, cfg.GlobalCfg.DefaultVisibility
+	defer func() {
+		cfg.GlobalCfg.Visibility, cfg.GlobalCfg.DefaultVisibility = prevVisibility, prevDefaultVisibility
+	}()
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	dm := NewKubeArmorDaemon()
+	dm.Logger = &fd.Feeder{Node: &tp.Node{}}
+	dm.SystemMonitor = &monitor.SystemMonitor{
+		NamespacePidsMap: map[string]monitor.NsVisibility{},
+		BpfMapLock:       new(sync.RWMutex),
+	}
+
+	cfg.GlobalCfg.Visibility = "process,file,network,capabilities"
+	cfg.GlobalCfg.DefaultVisibility = ""
+
+	plain := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "legacy"}}
+	annotated := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "audit", Annotations: map[string]string{visibilityKey: "file"}}}
+
+	// namespaces created before the default visibility is set
+	for _, ns := range []corev1.Namespace{plain, annotated} {
+		visibility, _ := getNamespaceVisibility(&ns)
+		dm.UpdateVisibility("ADDED", ns.Name, dm.parseVisibility(visibility))
+	}
+
+	if val := dm.SystemMonitor.NamespacePidsMap["legacy"]; !val.File || !val.Capability {
+		t.Errorf("[FAIL] Expected the container visibility without a default visibility (%+v)", val)
+	}
+
+	cfg.GlobalCfg.DefaultVisibility = "process,network"
+	dm.updateDefaultVisibility([]corev1.Namespace{plain, annotated})
+
+	if val := dm.SystemMonitor.NamespacePidsMap["legacy"]; val.File || val.Capability || !val.Process || !val.Network {
+		t.Errorf("[FAIL] Expected the default visibility in a namespace created before (%+v)", val)
+	}
+	if val := dm.SystemMonitor.NamespacePidsMap["audit"]; !val.File || val.Process || val.Network {
+		t.Errorf("[FAIL] Unexpected change of an annotated namespace (%+v)", val)
+	}
+
+	// namespaces created after the default visibility is set
+	created := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}
+	if visibility, annotated := getNamespaceVisibility(&created); visibility != "process,network" || annotated {
+		t.Errorf("[FAIL] Expected the default visibility in a new namespace (%s)", visibility)
+	}
+
+	created.Annotations = map[string]string{visibilityKey: "none"}
+	if visibility, annotated := getNamespaceVisibility(&created); visibility != "none" || !annotated {
+		t.Errorf("[FAIL] Expected the annotated visibility in a new namespace (%s)", visibility)
+	}
+
+	t.Log("[PASS] Applied the default visibility to the namespaces without the annotation")
+}
+
+func TestNamespaceSeverityRange(t *testing.T) {
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	dm := NewKubeArmorDaemon()
+	dm.Logger = &fd.Feeder{Node: &tp.Node{}}
+	dm.Logger.SeverityRanges = map[string]tp.SeverityRange{}
+	dm.Logger.SeverityRangesLock = new(sync.RWMutex)
+
+	alert := tp.Log{Type: "MatchedPolicy", NamespaceName: "pci", Severity: "2"}
+
+	// the range annotated on the namespace is applied by the feeder
+	pci := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "pci", Annotations: map[string]string{minSeverityKey: "7"}}}
+	dm.UpdateSeverityRange("ADDED", pci.Name, dm.getSeverityRange(&pci))
+
+	if log := dm.Logger.ApplySeverityRange(alert); log.Severity != "7" || log.PolicySeverity != "2" {
+		t.Errorf("[FAIL] Expected the severity to be clamped to the namespace range (%s, %s)", log.Severity, log.PolicySeverity)
+	}
+
+	// and forgotten once the annotation is removed
+	pci.Annotations = nil
+	dm.UpdateSeverityRange("MODIFIED", pci.Name, dm.getSeverityRange(&pci))
+
+	if log := dm.Logger.ApplySeverityRange(alert); log.Severity != "2" || log.PolicySeverity != "" {
+		t.Errorf("[FAIL] Unexpected clamping without the annotation (%s, %s)", log.Severity, log.PolicySeverity)
+	}
+
+	t.Log("[PASS] Applied the severity range of the namespace to the alerts")
+}
@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+)
+
+// ================== //
+// == Node Quiesce == //
+// ================== //
+
+// quiesce modes
+const (
+	NodeQuiesceAuto = "auto" // while the node is cordoned
+	NodeQuiesceOn   = "on"
+	NodeQuiesceOff  = "off"
+
+	defaultNodeQuiesceInterval = 30 * time.Second
+)
+
+// NodeQuiesceStats Structure
+type NodeQuiesceStats struct {
+	Mode     string
+	Cordoned bool
+	Quiesced bool
+	Since    time.Time
+
+	Pending  int
+	Deferred uint64
+	Batches  uint64
+}
+
+// NodeQuiesce Structure
+type NodeQuiesce struct {
+	Mode     string
+	Interval time.Duration
+
+	// called on every change of the quiesce mode
+	OnChange func(quiesced bool)
+
+	cordoned bool
+	quiesced bool
+	since    time.Time
+
+	// removals deferred while quiesced, in order
+	pending []func()
+
+	deferred uint64
+	batches  uint64
+
+	Lock *sync.Mutex
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewNodeQuiesce Function
+func NewNodeQuiesce(mode string, interval time.Duration) *NodeQuiesce {
+	nq := &NodeQuiesce{}
+
+	if mode != NodeQuiesceOn && mode != NodeQuiesceOff {
+		mode = NodeQuiesceAuto
+	}
+	nq.Mode = mode
+
+	if interval <= 0 {
+		interval = defaultNodeQuiesceInterval
+	}
+	nq.Interval = interval
+
+	nq.pending = []func(){}
+	nq.Lock = new(sync.Mutex)
+
+	nq.stop = make(chan struct{})
+
+	// the override takes effect right away
+	nq.update()
+
+	return nq
+}
+
+// update re-evaluates the quiesce mode, and returns the removals to run on resume
+func (nq *NodeQuiesce) update() []func() {
+	nq.Lock.Lock()
+
+	quiesced := nq.Mode == NodeQuiesceOn || (nq.Mode == NodeQuiesceAuto && nq.cordoned)
+	if quiesced == nq.quiesced {
+		nq.Lock.Unlock()
+		return nil
+	}
+
+	nq.quiesced = quiesced
+
+	var removals []func()
+
+	if quiesced {
+		nq.since = time.Now().UTC()
+	} else {
+		nq.since = time.Time{}
+
+		// resume with what was deferred
+		removals = nq.pending
+		nq.pending = []func(){}
+		if len(removals) > 0 {
+			nq.batches++
+		}
+	}
+
+	onChange := nq.OnChange
+
+	nq.Lock.Unlock()
+
+	if quiesced {
+		kg.Printf("Quiesced enforcement changes for node maintenance (mode=%s)", nq.Mode)
+	} else {
+		kg.Printf("Resumed enforcement changes after node maintenance (%d deferred removals)", len(removals))
+	}
+
+	if onChange != nil {
+		onChange(quiesced)
+	}
+
+	return removals
+}
+
+// SetOnChange sets the callback of the changes, and calls it with the current mode
+func (nq *NodeQuiesce) SetOnChange(onChange func(quiesced bool)) {
+	nq.Lock.Lock()
+	nq.OnChange = onChange
+	quiesced := nq.quiesced
+	nq.Lock.Unlock()
+
+	onChange(quiesced)
+}
+
+// SetCordoned updates the cordon state of the node (spec.unschedulable)
+func (nq *NodeQuiesce) SetCordoned(cordoned bool) {
+	nq.Lock.Lock()
+	changed := nq.cordoned != cordoned
+	nq.cordoned = cordoned
+	nq.Lock.Unlock()
+
+	if !changed {
+		return
+	}
+
+	for _, removal := range nq.update() {
+		removal()
+	}
+}
+
+// IsQuiesced Function
+func (nq *NodeQuiesce) IsQuiesced() bool {
+	nq.Lock.Lock()
+	defer nq.Lock.Unlock()
+
+	return nq.quiesced
+}
+
+// Remove runs a removal, or defers it to the next batch while quiesced
+func (nq *NodeQuiesce) Remove(removal func()) {
+	nq.Lock.Lock()
+
+	if nq.quiesced {
+		nq.pending = append(nq.pending, removal)
+		nq.deferred++
+		nq.Lock.Unlock()
+		return
+	}
+
+	nq.Lock.Unlock()
+
+	removal()
+}
+
+// Flush runs the deferred removals in a batch, and returns the number of them
+func (nq *NodeQuiesce) Flush() int {
+	nq.Lock.Lock()
+
+	removals := nq.pending
+	nq.pending = []func(){}
+	if len(removals) > 0 {
+		nq.batches++
+	}
+
+	nq.Lock.Unlock()
+
+	for _, removal := range removals {
+		removal()
+	}
+
+	return len(removals)
+}
+
+// Start flushes the deferred removals periodically
+func (nq *NodeQuiesce) Start() {
+	nq.wg.Add(1)
+
+	go func() {
+		defer nq.wg.Done()
+
+		ticker := time.NewTicker(nq.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-nq.stop:
+				return
+			case <-ticker.C:
+				if n := nq.Flush(); n > 0 {
+					kg.Printf("Applied %d deferred removals in a batch", n)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic flushes, and applies what is left
+func (nq *NodeQuiesce) Stop() {
+	close(nq.stop)
+	nq.wg.Wait()
+
+	nq.Flush()
+}
+
+// Stats returns the state of the quiesce mode
+func (nq *NodeQuiesce) Stats() NodeQuiesceStats {
+	nq.Lock.Lock()
+	defer nq.Lock.Unlock()
+
+	return NodeQuiesceStats{
+		Mode:     nq.Mode,
+		Cordoned: nq.cordoned,
+		Quiesced: nq.quiesced,
+		Since:    nq.since,
+		Pending:  len(nq.pending),
+		Deferred: nq.deferred,
+		Batches:  nq.batches,
+	}
+}
+
+// onNodeQuiesce reflects the quiesce mode in the telemetry and the probe data
+func (dm *KubeArmorDaemon) onNodeQuiesce(quiesced bool) {
+	if dm.Logger != nil {
+		dm.Logger.Quiesced.Store(quiesced)
+	}
+
+	dm.SetKarmorData()
+}
@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// waitForQuiesce waits until the quiesce mode changes to the given one
+func waitForQuiesce(t *testing.T, nq *NodeQuiesce, quiesced bool) {
+	for i := 0; i < 100; i++ {
+		if nq.IsQuiesced() == quiesced {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("[FAIL] Expected quiesced=%v after the node update", quiesced)
+}
+
+func TestNodeQuiesceOnCordon(t *testing.T) {
+	t.Setenv("KUBEARMOR_NODENAME", "worker-1")
+
+	dm := NewKubeArmorDaemon()
+
+	changes := []bool{}
+	removed := []string{}
+	lock := new(sync.Mutex)
+
+	dm.NodeQuiesce.SetOnChange(func(quiesced bool) {
+		lock.Lock()
+		changes = append(changes, quiesced)
+		lock.Unlock()
+	})
+
+	remove := func(podName string) func() {
+		return func() {
+			lock.Lock()
+			removed = append(removed, podName)
+			lock.Unlock()
+		}
+	}
+
+	countRemoved := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(removed)
+	}
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}}
+	client := fake.NewSimpleClientset(node)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	if !dm.watchK8sNodes(client, stopCh) {
+		t.Fatal("[FAIL] Failed to watch the nodes")
+	}
+
+	// removals are applied right away on a schedulable node
+	dm.NodeQuiesce.Remove(remove("pod-a"))
+	if countRemoved() != 1 {
+		t.Errorf("[FAIL] Expected an immediate removal (%v)", removed)
+	}
+
+	// cordon the node
+	node.Spec.Unschedulable = true
+	if _, err := client.CoreV1().Nodes().Update(context.Background(), node, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("[FAIL] Failed to cordon the node (%s)", err.Error())
+	}
+	waitForQuiesce(t, dm.NodeQuiesce, true)
+
+	dm.NodeQuiesce.Remove(remove("pod-b"))
+	dm.NodeQuiesce.Remove(remove("pod-c"))
+
+	if stats := dm.NodeQuiesce.Stats(); countRemoved() != 1 || stats.Pending != 2 || !stats.Cordoned {
+		t.Errorf("[FAIL] Expected the removals to be deferred while cordoned (%v, %+v)", removed, stats)
+	}
+
+	// deferred removals are applied in a batch
+	if n := dm.NodeQuiesce.Flush(); n != 2 || countRemoved() != 3 || dm.NodeQuiesce.Stats().Batches != 1 {
+		t.Errorf("[FAIL] Expected a batch of 2 removals (%d, %v)", n, removed)
+	}
+
+	dm.NodeQuiesce.Remove(remove("pod-d"))
+
+	// uncordon the node
+	node.Spec.Unschedulable = false
+	if _, err := client.CoreV1().Nodes().Update(context.Background(), node, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("[FAIL] Failed to uncordon the node (%s)", err.Error())
+	}
+	waitForQuiesce(t, dm.NodeQuiesce, false)
+
+	if stats := dm.NodeQuiesce.Stats(); countRemoved() != 4 || stats.Pending != 0 || stats.Batches != 2 {
+		t.Errorf("[FAIL] Expected the deferred removals to be applied on resume (%v, %+v)", removed, stats)
+	}
+
+	lock.Lock()
+	if len(changes) != 3 || changes[0] || !changes[1] || changes[2] {
+		t.Errorf("[FAIL] Unexpected changes of the quiesce mode (%v)", changes)
+	}
+	lock.Unlock()
+
+	t.Log("[PASS] Quiesced enforcement changes while the node was cordoned")
+}
+
+func TestNodeQuiesceOverride(t *testing.T) {
+	// never quiesced
+	nq := NewNodeQuiesce(NodeQuiesceOff, 0)
+	nq.SetCordoned(true)
+
+	if nq.IsQuiesced() {
+		t.Errorf("[FAIL] Expected no quiesce mode with nodeQuiesce=off")
+	}
+
+	// always quiesced
+	nq = NewNodeQuiesce(NodeQuiesceOn, 0)
+	nq.SetCordoned(false)
+
+	removed := 0
+	nq.Remove(func() { removed++ })
+
+	if !nq.IsQuiesced() || removed != 0 {
+		t.Errorf("[FAIL] Expected the quiesce mode with nodeQuiesce=on (%d)", removed)
+	}
+
+	// what is left is applied on stop
+	nq.Stop()
+
+	if removed != 1 {
+		t.Errorf("[FAIL] Expected the deferred removal to be applied on stop (%d)", removed)
+	}
+
+	t.Log("[PASS] Overrode the quiesce mode")
+}
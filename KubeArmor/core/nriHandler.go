@@ -0,0 +1,387 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/containerd/nri/pkg/api"
+	"github.com/containerd/nri/pkg/stub"
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// ================= //
+// == NRI Handler == //
+// ================= //
+
+// the name and the index of KubeArmor as an NRI plugin (after the plugins adjusting the containers)
+const (
+	nriPluginName = "kubearmor"
+	nriPluginIdx  = "90"
+)
+
+// annotations of the containers given by NRI
+const (
+	nriAppArmorAnnotation = "container.apparmor.security.beta.kubernetes.io/"
+	nriImageAnnotation    = "io.kubernetes.cri.image-name" // containerd
+)
+
+// errNoNRIContainerPid is returned for the containers given by NRI without their pid
+var errNoNRIContainerPid = errors.New("no pid of the container")
+
+// nriDial connects to the NRI socket (overridden by the tests to drop the connection)
+var nriDial = func(socket string) (net.Conn, error) {
+	return net.Dial("unix", socket)
+}
+
+// NRIHandler Structure receives the lifecycle events of the containers of containerd or CRI-O as an NRI plugin, which
+// are delivered before the containers start (replacing the monitoring of the runtime)
+type NRIHandler struct {
+	dm *KubeArmorDaemon
+
+	stub stub.Stub
+
+	// the runtime behind NRI
+	runtime string
+
+	// the containers added from NRI
+	containers     map[string]tp.Container
+	containersLock *sync.RWMutex
+
+	// closed by KubeArmor, rather than by the runtime
+	closed bool
+
+	// called once the connection to the runtime is lost
+	onClose func()
+
+	health *runtimeHealth
+}
+
+// newNRIHandler Function returns a handler of the NRI events of the given runtime (not connected yet)
+func newNRIHandler(dm *KubeArmorDaemon, runtime string) *NRIHandler {
+	return &NRIHandler{
+		dm:             dm,
+		runtime:        runtime,
+		containers:     map[string]tp.Container{},
+		containersLock: new(sync.RWMutex),
+		health:         newRuntimeHealth(runtime, "unix://"+cfg.GlobalCfg.NRISocket),
+	}
+}
+
+// Start Function registers KubeArmor as an NRI plugin, and returns once the runtime has configured it (the running
+// containers are synchronized right after)
+func (nh *NRIHandler) Start(ctx context.Context, onClose func()) error {
+	nh.onClose = onClose
+
+	s, err := stub.New(nh,
+		stub.WithPluginName(nriPluginName),
+		stub.WithPluginIdx(nriPluginIdx),
+		stub.WithSocketPath(cfg.GlobalCfg.NRISocket),
+		stub.WithDialer(nriDial),
+		stub.WithOnClose(nh.connClosed))
+	if err != nil {
+		return err
+	}
+
+	nh.stub = s
+
+	return s.Start(ctx)
+}
+
+// connClosed Function falls back to the monitoring of the runtime once the connection to NRI is lost
+func (nh *NRIHandler) connClosed() {
+	nh.containersLock.Lock()
+	closed := nh.closed
+	nh.closed = true
+	nh.containersLock.Unlock()
+
+	if closed {
+		return
+	}
+
+	nh.health.recordList(errors.New("lost the connection to NRI"))
+
+	if nh.onClose != nil {
+		nh.onClose()
+	}
+}
+
+// Close Function
+func (nh *NRIHandler) Close() {
+	nh.containersLock.Lock()
+	nh.closed = true
+	nh.containersLock.Unlock()
+
+	if nh.stub != nil {
+		nh.stub.Stop()
+	}
+}
+
+// Health Function
+func (nh *NRIHandler) Health() tp.RuntimeHandlerHealth {
+	nh.containersLock.RLock()
+	connected := !nh.closed && nh.stub != nil
+	nh.containersLock.RUnlock()
+
+	return nh.health.snapshot(connected)
+}
+
+// GetContainerInfo Function returns a container added from NRI
+func (nh *NRIHandler) GetContainerInfo(_ context.Context, containerID string) (tp.Container, error) {
+	nh.containersLock.RLock()
+	defer nh.containersLock.RUnlock()
+
+	container, ok := nh.containers[containerID]
+	if !ok {
+		return tp.Container{}, errCrioContainerUnknown
+	}
+
+	return container, nil
+}
+
+// nriAppArmorProfile Function returns the AppArmor profile of a container set by the annotation of its pod
+func nriAppArmorProfile(pod *api.PodSandbox, ctr *api.Container) string {
+	profile := pod.GetAnnotations()[nriAppArmorAnnotation+ctr.GetName()]
+	return strings.TrimPrefix(profile, "localhost/")
+}
+
+// nriContainer Function builds a container from the NRI data of the container and of its pod, the container needs
+// to have its pid (i.e., to be created by the runtime)
+func nriContainer(pod *api.PodSandbox, ctr *api.Container) (tp.Container, error) {
+	container := tp.Container{}
+
+	container.ContainerID = ctr.GetId()
+	container.ContainerName = ctr.GetName()
+
+	container.NamespaceName = "Unknown"
+	container.EndPointName = "Unknown"
+
+	if namespace := pod.GetNamespace(); namespace != "" {
+		container.NamespaceName = namespace
+	} else if val, ok := ctr.GetLabels()["io.kubernetes.pod.namespace"]; ok {
+		container.NamespaceName = val
+	}
+	if name := pod.GetName(); name != "" {
+		container.EndPointName = name
+	} else if val, ok := ctr.GetLabels()["io.kubernetes.pod.name"]; ok {
+		container.EndPointName = val
+	}
+
+	// the image as it was pulled (containerd), or as it is annotated by CRI-O
+	image := ctr.GetAnnotations()[nriImageAnnotation]
+	if image == "" {
+		image = ctr.GetAnnotations()[crioImageNameAnnotation]
+	}
+	container.ContainerImage = kl.GetContainerImage(image, "")
+
+	container.AppArmorProfile = nriAppArmorProfile(pod, ctr)
+
+	// risky host mounts
+	mounts := []specs.Mount{}
+	for _, mount := range ctr.GetMounts() {
+		mounts = append(mounts, mount.ToOCI(nil))
+	}
+	container.RiskyMounts = ClassifyMounts(mounts, sensitiveHostPaths())
+
+	// cgroup and resource limits
+	if linux := ctr.GetLinux(); linux != nil {
+		spec := &specs.Spec{Linux: &specs.Linux{CgroupsPath: linux.GetCgroupsPath()}}
+		if linux.GetResources() != nil {
+			spec.Linux.Resources = linux.GetResources().ToOCI()
+		}
+		applyRuntimeSpec(&container, spec)
+	}
+
+	if ctr.GetPid() == 0 {
+		return container, errNoNRIContainerPid
+	}
+
+	pid := strconv.Itoa(int(ctr.GetPid()))
+	container.Pid = ctr.GetPid()
+	container.StartTime = getProcessStartTime(container.Pid)
+
+	// the root filesystem of the container, as seen from the host
+	container.MergedDir = kl.GetProcPath(pid, "root")
+
+	if data, err := os.Readlink(kl.GetProcPath(pid, "ns", "pid")); err == nil {
+		if _, err := fmt.Sscanf(data, "pid:[%d]\n", &container.PidNS); err != nil {
+			return container, err
+		}
+	} else {
+		return container, err
+	}
+
+	if data, err := os.Readlink(kl.GetProcPath(pid, "ns", "mnt")); err == nil {
+		if _, err := fmt.Sscanf(data, "mnt:[%d]\n", &container.MntNS); err != nil {
+			return container, err
+		}
+	} else {
+		return container, err
+	}
+
+	return container, nil
+}
+
+// startContainer Function adds a container given by NRI
+func (nh *NRIHandler) startContainer(pod *api.PodSandbox, ctr *api.Container) error {
+	container, err := nriContainer(pod, ctr)
+	if err != nil {
+		return err
+	}
+	container.Runtime = nh.runtime
+
+	if err := nh.dm.addContainer(container); err != nil && !errors.Is(err, errCrioContainerKnown) {
+		return err
+	}
+
+	nh.containersLock.Lock()
+	nh.containers[container.ContainerID] = container
+	nh.health.setContainers(len(nh.containers))
+	nh.containersLock.Unlock()
+
+	return nil
+}
+
+// Configure Function
+func (nh *NRIHandler) Configure(_, runtime, version string) (stub.EventMask, error) {
+	nh.dm.Logger.Printf("Connected to %s %s over NRI", runtime, version)
+
+	events := []string{"StartContainer", "RemoveContainer"}
+
+	// the creation of the containers waits for their AppArmor profiles
+	if cfg.GlobalCfg.AppArmorPreStart && nh.dm.RuntimeEnforcer != nil && nh.dm.RuntimeEnforcer.EnforcerType == "AppArmor" {
+		events = append(events, "CreateContainer")
+	}
+
+	return api.ParseEventMask(events...)
+}
+
+// Synchronize Function adds the running containers on connect, and removes the ones which were deleted meanwhile
+func (nh *NRIHandler) Synchronize(pods []*api.PodSandbox, containers []*api.Container) ([]*api.ContainerUpdate, error) {
+	podsByID := map[string]*api.PodSandbox{}
+	for _, pod := range pods {
+		podsByID[pod.GetId()] = pod
+	}
+
+	listed := map[string]struct{}{}
+
+	for _, ctr := range containers {
+		if ctr.GetState() != api.ContainerState_CONTAINER_RUNNING {
+			continue
+		}
+
+		listed[ctr.GetId()] = struct{}{}
+
+		if err := nh.startContainer(podsByID[ctr.GetPodSandboxId()], ctr); err != nil {
+			nh.dm.Logger.Warnf("Failed to add a container from NRI (%.12s, %s)", ctr.GetId(), err.Error())
+		}
+	}
+
+	nh.health.recordList(nil)
+
+	// the containers removed while KubeArmor wasn't connected
+	nh.dm.auditContainers(listed)
+	nh.dm.finalizeNsMapAdoption()
+
+	return nil, nil
+}
+
+// StartContainer Function adds a container before it starts, so that none of its processes runs unmonitored
+func (nh *NRIHandler) StartContainer(pod *api.PodSandbox, ctr *api.Container) error {
+	if err := nh.startContainer(pod, ctr); err != nil {
+		// the start of the container isn't failed by KubeArmor
+		nh.dm.Logger.Warnf("Failed to add a container from NRI (%.12s, %s)", ctr.GetId(), err.Error())
+	}
+
+	return nil
+}
+
+// RemoveContainer Function removes a deleted container
+func (nh *NRIHandler) RemoveContainer(_ *api.PodSandbox, ctr *api.Container) error {
+	nh.containersLock.Lock()
+	delete(nh.containers, ctr.GetId())
+	nh.health.setContainers(len(nh.containers))
+	nh.containersLock.Unlock()
+
+	if err := destroyContainer(nh.dm, ctr.GetId()); err != nil {
+		nh.dm.Logger.Warnf("Failed to remove a container (%.12s, %s)", ctr.GetId(), err.Error())
+	}
+
+	return nil
+}
+
+// useNRI Function checks if the lifecycle events of the containers of a runtime are received from NRI
+func useNRI(runtime string) bool {
+	if !cfg.GlobalCfg.PreferNRI || (runtime != RuntimeContainerd && runtime != RuntimeCrio) {
+		return false
+	}
+
+	_, err := os.Stat(cfg.GlobalCfg.NRISocket)
+	return err == nil
+}
+
+// MonitorNRIEvents Function registers KubeArmor as an NRI plugin of the given runtime, and returns false if it fails,
+// in which case the runtime is monitored instead. The runtime is monitored as well once the connection is lost.
+func (dm *KubeArmorDaemon) MonitorNRIEvents(runtime string) bool {
+	nh := newNRIHandler(dm, runtime)
+
+	// the plugin is served until KubeArmor is stopped
+	ctx, cancel := stopContext()
+
+	if err := nh.Start(ctx, func() {
+		cancel()
+
+		// stopped
+		select {
+		case <-StopChan:
+			return
+		default:
+		}
+
+		dm.Logger.Warnf("Lost the connection to %s over NRI, monitoring %s instead", cfg.GlobalCfg.NRISocket, runtime)
+		dm.reportRuntimeMonitoring(runtime, "unix://"+cfg.GlobalCfg.NRISocket, errors.New("lost the connection to NRI"))
+
+		dm.setNRI(nil)
+		dm.startRuntimeMonitor(runtime)
+	}); err != nil {
+		cancel()
+		nh.Close()
+
+		dm.Logger.Warnf("Failed to register to %s as an NRI plugin (%s), monitoring %s instead", cfg.GlobalCfg.NRISocket, err.Error(), runtime)
+		return false
+	}
+
+	dm.setNRI(nh)
+	dm.trackRuntimeHealth(runtime, nh.Health)
+
+	dm.Logger.Printf("Started to receive the events of %s over NRI", runtime)
+
+	return true
+}
+
+// getNRI Function returns the NRI handler (nil if NRI isn't used)
+func (dm *KubeArmorDaemon) getNRI() *NRIHandler {
+	dm.nriLock.RLock()
+	defer dm.nriLock.RUnlock()
+
+	return dm.nri
+}
+
+// setNRI Function replaces the NRI handler
+func (dm *KubeArmorDaemon) setNRI(nh *NRIHandler) {
+	dm.nriLock.Lock()
+	defer dm.nriLock.Unlock()
+
+	dm.nri = nh
+}
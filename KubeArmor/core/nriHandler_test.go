@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containerd/nri/pkg/adaptation"
+	"github.com/containerd/nri/pkg/api"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	"github.com/kubearmor/KubeArmor/KubeArmor/testutil"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+func TestMonitorNRIEvents(t *testing.T) {
+	prevCfg := cfg.GlobalCfg
+	defer func() { cfg.GlobalCfg = prevCfg }()
+
+	dir := t.TempDir()
+
+	// CRI-O is monitored once NRI is gone
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(dir + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = true
+	cfg.GlobalCfg.PreferNRI = true
+	cfg.GlobalCfg.NRISocket = dir + "/nri.sock"
+
+	// the connection to NRI is dropped to stand in for a restart of the runtime
+	var nriConn net.Conn
+	prevDial := nriDial
+	defer func() { nriDial = prevDial }()
+	nriDial = func(socket string) (net.Conn, error) {
+		conn, err := prevDial(socket)
+		nriConn = conn
+		return conn, err
+	}
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	// the namespaces of the test process stand in for the ones of the containers
+	pod := &api.PodSandbox{Id: "nginx-pod", Name: "nginx-pod", Namespace: "default", Annotations: map[string]string{
+		nriAppArmorAnnotation + "nginx": "localhost/kubearmor-default-nginx",
+	}}
+	nginx := &api.Container{Id: "nginx", PodSandboxId: "nginx-pod", Name: "nginx", State: api.ContainerState_CONTAINER_RUNNING, Pid: uint32(os.Getpid()),
+		Linux: &api.LinuxContainer{CgroupsPath: "kubepods-nginx.slice", Resources: &api.LinuxResources{Memory: &api.LinuxMemory{Limit: api.Int64(64 << 20)}}}}
+
+	// the runtime side of NRI, with a running container
+	runtime, err := adaptation.New("cri-o", "1.26.0",
+		func(ctx context.Context, cb adaptation.SyncCB) error {
+			_, err := cb(ctx, []*api.PodSandbox{pod}, []*api.Container{nginx})
+			return err
+		},
+		func(context.Context, []*api.ContainerUpdate) ([]*api.ContainerUpdate, error) {
+			return nil, nil
+		},
+		adaptation.WithPluginPath(t.TempDir()),
+		adaptation.WithPluginConfigPath(t.TempDir()),
+		adaptation.WithSocketPath(cfg.GlobalCfg.NRISocket))
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create the NRI runtime (%s)", err.Error())
+	}
+	if err := runtime.Start(); err != nil {
+		t.Fatalf("[FAIL] Failed to start the NRI runtime (%s)", err.Error())
+	}
+	defer runtime.Stop()
+
+	// subscribe to the alerts
+	alerts := make(chan *pb.Alert, 16)
+	fd.AlertLock = new(sync.RWMutex)
+	fd.AlertStructs = map[string]fd.AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+	defer func() { fd.AlertStructs = map[string]fd.AlertStruct{} }()
+
+	dm := newCrioTestDaemon()
+	dm.Logger.Output = "none"
+	dm.Logger.SeverityRangesLock = new(sync.RWMutex)
+	dm.Logger.SinksLock = new(sync.RWMutex)
+
+	inContainers := func(containerID string) bool {
+		dm.ContainersLock.RLock()
+		defer dm.ContainersLock.RUnlock()
+		_, ok := dm.Containers[containerID]
+		return ok
+	}
+
+	StopChan = make(chan struct{})
+
+	if !useNRI(RuntimeCrio) || useNRI(RuntimeDocker) {
+		t.Fatalf("[FAIL] Expected NRI to be used for CRI-O only")
+	}
+
+	dm.monitorContainerRuntime(DetectedRuntime{Runtime: RuntimeCrio, Socket: fake.Endpoint()})
+
+	if dm.getNRI() == nil || dm.getCrio() != nil {
+		t.Fatalf("[FAIL] Expected the events of CRI-O to be received over NRI")
+	}
+
+	// the running containers are synchronized on connect
+	waitFor(t, "the running container to be added", func() bool {
+		return inContainers("nginx")
+	})
+
+	dm.ContainersLock.RLock()
+	container := dm.Containers["nginx"]
+	dm.ContainersLock.RUnlock()
+
+	if container.NamespaceName != "default" || container.EndPointName != "nginx-pod" || container.AppArmorProfile != "kubearmor-default-nginx" {
+		t.Errorf("[FAIL] Unexpected container info (%+v)", container)
+	}
+	if container.PidNS == 0 || container.MntNS == 0 || container.CgroupPath != "kubepods-nginx.slice" || container.Resources.MemoryLimit != 64<<20 {
+		t.Errorf("[FAIL] Expected the namespaces, the cgroup and the limits of the container (%+v)", container)
+	}
+
+	// a new container is added before the runtime starts it
+	redis := &api.Container{Id: "redis", PodSandboxId: "nginx-pod", Name: "redis", State: api.ContainerState_CONTAINER_CREATED, Pid: uint32(os.Getpid())}
+
+	if err := runtime.StartContainer(context.Background(), &api.StateChangeEvent{Pod: pod, Container: redis}); err != nil {
+		t.Fatalf("[FAIL] Failed to start the container (%s)", err.Error())
+	}
+	if !inContainers("redis") {
+		t.Errorf("[FAIL] Expected the container to be added before it starts")
+	}
+
+	if err := runtime.RemoveContainer(context.Background(), &api.StateChangeEvent{Pod: pod, Container: redis}); err != nil {
+		t.Fatalf("[FAIL] Failed to remove the container (%s)", err.Error())
+	}
+	if inContainers("redis") {
+		t.Errorf("[FAIL] Expected the container to be removed")
+	}
+
+	// CRI-O is monitored instead once the connection to NRI is lost
+	_ = nriConn.Close()
+
+	select {
+	case alert := <-alerts:
+		if alert.PolicyName != RuntimeMonitoringDegradedPolicyName {
+			t.Errorf("[FAIL] Unexpected alert for the lost connection (%+v)", alert)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("[FAIL] Expected an alert for the lost connection")
+	}
+
+	waitFor(t, "the monitoring of CRI-O", func() bool {
+		return dm.getNRI() == nil && dm.getCrio() != nil
+	})
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	t.Log("[PASS] Received the events of the containers over NRI")
+}
@@ -0,0 +1,513 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ==================== //
+// == Podman Handler == //
+// ==================== //
+
+// the libpod endpoints (Podman >= 4.0), served over the unix socket
+const podmanAPI = "http://podman/v4.0.0/libpod"
+
+var (
+	// the timeout of the requests to the Podman API, except the event stream
+	podmanRequestTimeout = 10 * time.Second
+
+	// the interval of reconciling the containers with the listing, for the missed events
+	podmanResyncInterval = 30 * time.Second
+
+	// the delay before reconnecting the event stream
+	podmanReconnectDelay = 1 * time.Second
+)
+
+// PodmanVersion Structure
+type PodmanVersion struct {
+	Version    string `json:"Version"`
+	APIVersion string `json:"APIVersion"`
+}
+
+// PodmanInspect Structure corresponds to the podman inspect output of a container
+type PodmanInspect struct {
+	ID          string `json:"Id"`
+	Name        string `json:"Name"`
+	ImageName   string `json:"ImageName"`
+	ImageDigest string `json:"ImageDigest"`
+
+	State struct {
+		Pid int `json:"Pid"`
+	} `json:"State"`
+
+	AppArmorProfile string `json:"AppArmorProfile"`
+
+	GraphDriver struct {
+		Data map[string]string `json:"Data"`
+	} `json:"GraphDriver"`
+
+	Config struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+
+	// the mounts of podman have the same fields as the ones of docker
+	Mounts []types.MountPoint `json:"Mounts"`
+}
+
+// PodmanHandler Structure
+type PodmanHandler struct {
+	// client of the Podman API
+	client *http.Client
+
+	// containers is a map with empty value to have lookups in constant time
+	containers map[string]struct{}
+
+	// the initial listing is done
+	listed bool
+
+	// the last audit of the containers
+	audited time.Time
+
+	// state reported by the health probe
+	health *runtimeHealth
+}
+
+// NewPodmanHandler Function creates a new Podman handler
+func NewPodmanHandler() *PodmanHandler {
+	ph := &PodmanHandler{}
+
+	ph.client = newPodmanClient(cfg.GlobalCfg.PodmanSocket)
+
+	ctx, cancel := context.WithTimeout(context.Background(), podmanRequestTimeout)
+	defer cancel()
+
+	version := PodmanVersion{}
+	if err := ph.get(ctx, "/version", &version); err != nil {
+		kg.Errf("Failed to connect to Podman at %s (%s)", cfg.GlobalCfg.PodmanSocket, err.Error())
+		ph.Close()
+		return nil
+	}
+
+	kg.Printf("Connected to Podman %s (API %s)", version.Version, version.APIVersion)
+
+	ph.containers = make(map[string]struct{})
+
+	ph.health = newRuntimeHealth(RuntimePodman, cfg.GlobalCfg.PodmanSocket)
+
+	return ph
+}
+
+// newPodmanClient returns a client of the Podman API served on the given socket
+func newPodmanClient(socket string) *http.Client {
+	socket = strings.TrimPrefix(socket, "unix://")
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+}
+
+// Close Function
+func (ph *PodmanHandler) Close() {
+	if ph.client != nil {
+		ph.client.CloseIdleConnections()
+	}
+}
+
+// Health Function returns the state of the connection to Podman
+func (ph *PodmanHandler) Health() tp.RuntimeHandlerHealth {
+	return ph.health.snapshot(ph.client != nil)
+}
+
+// get Function sends a request to the Podman API, and decodes its response into v
+func (ph *PodmanHandler) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, podmanAPI+path, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := ph.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", path, res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// ==================== //
+// == Container Info == //
+// ==================== //
+
+// GetContainerInfo Function gets info of a particular container
+func (ph *PodmanHandler) GetContainerInfo(ctx context.Context, containerID string) (tp.Container, error) {
+	inspect := PodmanInspect{}
+	if err := ph.get(ctx, "/containers/"+url.PathEscape(containerID)+"/json", &inspect); err != nil {
+		return tp.Container{}, err
+	}
+
+	container := tp.Container{Runtime: RuntimePodman}
+
+	// == container base == //
+
+	container.ContainerID = inspect.ID
+	container.ContainerName = strings.TrimLeft(inspect.Name, "/")
+
+	container.NamespaceName = "Unknown"
+	container.EndPointName = "Unknown"
+
+	// the pods played from kubernetes yaml (podman kube play)
+	if val, ok := inspect.Config.Labels["io.kubernetes.pod.namespace"]; ok {
+		container.NamespaceName = val
+	}
+	if val, ok := inspect.Config.Labels["io.kubernetes.pod.name"]; ok {
+		container.EndPointName = val
+	}
+
+	container.ContainerImage = kl.GetContainerImage(inspect.ImageName, inspect.ImageDigest)
+
+	container.AppArmorProfile = inspect.AppArmorProfile
+
+	container.MergedDir = inspect.GraphDriver.Data["MergedDir"]
+
+	// risky host mounts
+	container.RiskyMounts = ClassifyMounts(dockerMounts(inspect.Mounts), sensitiveHostPaths())
+
+	// == //
+
+	if inspect.State.Pid == 0 {
+		return container, errors.New("container is not running")
+	}
+
+	pid := strconv.Itoa(inspect.State.Pid)
+	container.Pid = uint32(inspect.State.Pid)
+
+	if data, err := os.Readlink(kl.GetProcPath(pid, "ns", "pid")); err == nil {
+		if _, err := fmt.Sscanf(data, "pid:[%d]\n", &container.PidNS); err != nil {
+			kg.Warnf("Unable to get PidNS (%s, %s, %s)", containerID, pid, err.Error())
+		}
+	} else {
+		return container, err
+	}
+
+	if data, err := os.Readlink(kl.GetProcPath(pid, "ns", "mnt")); err == nil {
+		if _, err := fmt.Sscanf(data, "mnt:[%d]\n", &container.MntNS); err != nil {
+			kg.Warnf("Unable to get MntNS (%s, %s, %s)", containerID, pid, err.Error())
+		}
+	} else {
+		return container, err
+	}
+
+	return container, nil
+}
+
+// =================== //
+// == Podman Events == //
+// =================== //
+
+// GetPodmanContainers Function gets IDs of the running containers
+func (ph *PodmanHandler) GetPodmanContainers() (map[string]struct{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), podmanRequestTimeout)
+	defer cancel()
+
+	containerList := []struct {
+		ID string `json:"Id"`
+	}{}
+	if err := ph.get(ctx, "/containers/json", &containerList); err != nil {
+		return nil, err
+	}
+
+	containers := make(map[string]struct{})
+	for _, container := range containerList {
+		containers[container.ID] = struct{}{}
+	}
+
+	return containers, nil
+}
+
+// WatchContainerEvents Function sends the events of the containers until the stream ends, and returns its error
+func (ph *PodmanHandler) WatchContainerEvents(ctx context.Context, eventChan chan<- events.Message) error {
+	filters := url.QueryEscape(`{"type":["container"]}`)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, podmanAPI+"/events?stream=true&filters="+filters, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := ph.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("/events returned %s", res.Status)
+	}
+
+	decoder := json.NewDecoder(res.Body)
+
+	for {
+		event := events.Message{}
+		if err := decoder.Decode(&event); err != nil {
+			return err
+		}
+
+		select {
+		case eventChan <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// startPodmanContainer Function adds a started container, and returns false if it isn't added
+func (dm *KubeArmorDaemon) startPodmanContainer(ctx context.Context, containerID string) bool {
+	container, err := dm.podman.GetContainerInfo(ctx, containerID)
+	if err != nil {
+		dm.Logger.Warnf("Failed to inspect a Podman container (%.12s, %s)", containerID, err.Error())
+		return false
+	}
+
+	if !dm.K8sEnabled {
+		setUnorchestratedContainer(&container)
+	}
+
+	dm.ContainersLock.Lock()
+	if _, ok := dm.Containers[container.ContainerID]; ok {
+		dm.ContainersLock.Unlock()
+		return true
+	}
+	dm.Containers[container.ContainerID] = container
+	dm.ContainersLock.Unlock()
+
+	if dm.SystemMonitor != nil && cfg.GlobalCfg.Policy {
+		// update NsMap
+		dm.SystemMonitor.AddContainerIDToNsMap(container.ContainerID, container.NamespaceName, container.PidNS, container.MntNS)
+		dm.RuntimeEnforcer.RegisterContainer(container.ContainerID, container.PidNS, container.MntNS)
+	}
+
+	if !dm.K8sEnabled {
+		dm.ContainersLock.Lock()
+		dm.EndPointsLock.Lock()
+		dm.MatchandUpdateContainerSecurityPolicies(container.ContainerID)
+		dm.EndPointsLock.Unlock()
+		dm.ContainersLock.Unlock()
+	}
+
+	dm.Logger.Printf("Detected a container (added/%.12s/pidns=%d/mntns=%d)", container.ContainerID, container.PidNS, container.MntNS)
+
+	dm.reportRiskyMounts(container)
+	dm.AppArmorAttachment.ContainerSeen(container)
+
+	return true
+}
+
+// UpdatePodmanContainer Function
+func (dm *KubeArmorDaemon) UpdatePodmanContainer(ctx context.Context, containerID, action string) bool {
+	if dm.podman == nil {
+		return false
+	}
+
+	if action == "start" {
+		return dm.startPodmanContainer(ctx, containerID)
+	} else if action == "destroy" {
+		container, ok := dm.removeContainer(containerID)
+		if !ok {
+			return false
+		}
+
+		dm.Logger.Printf("Detected a container (removed/%.12s/pidns=%d/mntns=%d)", containerID, container.PidNS, container.MntNS)
+	}
+
+	return true
+}
+
+// syncPodmanContainers Function lists the containers, and starts the new ones and destroys the deleted ones (the
+// snapshot of the containers only advances for the ones processed)
+func (dm *KubeArmorDaemon) syncPodmanContainers() error {
+	containers, err := dm.podman.GetPodmanContainers()
+	dm.podman.health.recordList(err)
+	if err != nil {
+		return err
+	}
+	defer func() { dm.podman.health.setContainers(len(dm.podman.containers)) }()
+
+	for containerID := range containers {
+		if _, ok := dm.podman.containers[containerID]; ok {
+			continue
+		}
+
+		if dm.startPodmanContainer(context.Background(), containerID) {
+			dm.podman.containers[containerID] = struct{}{}
+		}
+	}
+
+	// the initial listing is done
+	if !dm.podman.listed {
+		dm.finalizeNsMapAdoption()
+		dm.podman.listed = true
+	}
+
+	for containerID := range dm.podman.containers {
+		if _, ok := containers[containerID]; ok {
+			continue
+		}
+
+		if err := destroyContainer(dm, containerID); err != nil {
+			dm.Logger.Warnf("Failed to remove a container (%.12s, %s)", containerID, err.Error())
+			continue
+		}
+
+		delete(dm.podman.containers, containerID)
+	}
+
+	if time.Since(dm.podman.audited) >= containerAuditInterval {
+		dm.podman.audited = time.Now()
+
+		// the containers still tracked aren't leaked
+		tracked := make(map[string]struct{})
+		for containerID := range containers {
+			tracked[containerID] = struct{}{}
+		}
+		for containerID := range dm.podman.containers {
+			tracked[containerID] = struct{}{}
+		}
+
+		dm.auditContainers(tracked)
+	}
+
+	return nil
+}
+
+// handlePodmanEvent Function
+func (dm *KubeArmorDaemon) handlePodmanEvent(event events.Message) {
+	containerID := event.Actor.ID
+	defer func() { dm.podman.health.setContainers(len(dm.podman.containers)) }()
+
+	switch event.Action {
+	case "start":
+		if _, ok := dm.podman.containers[containerID]; ok {
+			return
+		}
+
+		// the container failed to be added is added by the next reconciliation
+		if dm.startPodmanContainer(context.Background(), containerID) {
+			dm.podman.containers[containerID] = struct{}{}
+		}
+
+	case "died", "remove":
+		if _, ok := dm.podman.containers[containerID]; !ok {
+			return
+		}
+
+		// the container failed to be removed is removed by the next reconciliation
+		if err := destroyContainer(dm, containerID); err != nil {
+			dm.Logger.Warnf("Failed to remove a container (%.12s, %s)", containerID, err.Error())
+			return
+		}
+
+		delete(dm.podman.containers, containerID)
+	}
+}
+
+// MonitorPodmanEvents Function keeps track of the containers with the event stream of libpod, which is re-synced
+// with the listing on every (re)connection and periodically
+func (dm *KubeArmorDaemon) MonitorPodmanEvents() {
+	dm.WgDaemon.Add(1)
+	defer dm.WgDaemon.Done()
+
+	dm.podman = NewPodmanHandler()
+
+	// check if Podman exists
+	if dm.podman == nil {
+		dm.trackRuntimeHealth(RuntimePodman, unreachableRuntime(RuntimePodman, cfg.GlobalCfg.PodmanSocket, errRuntimeUnreachable))
+		return
+	}
+	dm.trackRuntimeHealth(RuntimePodman, dm.podman.Health)
+
+	dm.Logger.Print("Started to monitor Podman events")
+
+	resync := time.NewTicker(podmanResyncInterval)
+	defer resync.Stop()
+
+	for {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		eventChan := make(chan events.Message, 64)
+		errs := make(chan error, 1)
+
+		go func() {
+			errs <- dm.podman.WatchContainerEvents(ctx, eventChan)
+		}()
+
+		// the containers started or deleted while disconnected
+		if err := dm.syncPodmanContainers(); err != nil {
+			dm.Logger.Warnf("Failed to list Podman containers (%s)", err.Error())
+		}
+
+		err := dm.consumePodmanEvents(eventChan, errs, resync.C)
+		cancel()
+
+		if err == nil {
+			return
+		}
+
+		dm.Logger.Warnf("Lost the Podman event stream, reconnecting (%s)", err.Error())
+
+		select {
+		case <-StopChan:
+			return
+		case <-time.After(podmanReconnectDelay):
+		}
+	}
+}
+
+// consumePodmanEvents Function handles the events and reconciles the containers periodically until the stream ends,
+// and returns its error (nil if KubeArmor is stopped)
+func (dm *KubeArmorDaemon) consumePodmanEvents(eventChan <-chan events.Message, errs <-chan error, resync <-chan time.Time) error {
+	for {
+		select {
+		case <-StopChan:
+			return nil
+
+		case err := <-errs:
+			return err
+
+		case event := <-eventChan:
+			dm.handlePodmanEvent(event)
+
+		case <-resync:
+			if err := dm.syncPodmanContainers(); err != nil {
+				dm.Logger.Warnf("Failed to reconcile Podman containers (%s)", err.Error())
+			}
+		}
+	}
+}
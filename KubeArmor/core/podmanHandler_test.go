@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	"github.com/kubearmor/KubeArmor/KubeArmor/testutil"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMonitorPodmanEvents(t *testing.T) {
+	prevSocket, prevPolicy, prevVisibility, prevReconnect := cfg.GlobalCfg.PodmanSocket, cfg.GlobalCfg.Policy, cfg.GlobalCfg.Visibility, podmanReconnectDelay
+	defer func() {
+		cfg.GlobalCfg.PodmanSocket, cfg.GlobalCfg.Policy, cfg.GlobalCfg.Visibility, podmanReconnectDelay = prevSocket, prevPolicy, prevVisibility, prevReconnect
+	}()
+	cfg.GlobalCfg.Visibility = "process,file"
+	podmanReconnectDelay = 100 * time.Millisecond
+
+	fake := testutil.NewFakePodman()
+	if err := fake.Start(t.TempDir() + "/podman.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake Podman API (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.PodmanSocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = true
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	// an unorchestrated host
+	dm := newCrioTestDaemon()
+	dm.K8sEnabled = false
+
+	dm.Logger.SecurityPolicies = map[string]tp.MatchPolicies{}
+	dm.Logger.SecurityPoliciesLock = new(sync.RWMutex)
+	dm.Logger.DefaultPostures = map[string]tp.DefaultPosture{}
+	dm.Logger.EndPointPostures = map[string]tp.DefaultPosture{}
+	dm.Logger.DefaultPosturesLock = new(sync.Mutex)
+
+	getContainer := func(containerID string) (tp.Container, bool) {
+		dm.ContainersLock.RLock()
+		defer dm.ContainersLock.RUnlock()
+		container, ok := dm.Containers[containerID]
+		return container, ok
+	}
+
+	// a policy for the container, applied before it runs
+	if status := dm.parseAndUpdateContainerSecurityPolicy(tp.K8sKubeArmorPolicyEvent{
+		Type: "ADDED",
+		Object: tp.K8sKubeArmorPolicy{
+			Metadata: metav1.ObjectMeta{Name: "block-shell"},
+			Spec: tp.SecuritySpec{
+				Selector: tp.SelectorType{MatchLabels: map[string]string{"kubearmor.io/container.name": "nginx"}},
+				Process:  tp.ProcessType{MatchPaths: []tp.ProcessPathType{{Path: "/bin/sh"}}},
+				Action:   "Block",
+			},
+		},
+	}); status != pb.PolicyStatus_Applied {
+		t.Fatalf("[FAIL] Failed to apply the policy (%s)", status)
+	}
+
+	// a container running before the daemon starts
+	fake.AddContainer(testutil.FakeContainer{
+		ID:              "redis",
+		Name:            "redis",
+		Image:           "docker.io/library/redis:7",
+		Pid:             os.Getpid(),
+		AppArmorProfile: "containers-default-0.50.1",
+		RootPath:        "/var/lib/containers/storage/overlay/redis/merged",
+	})
+
+	StopChan = make(chan struct{})
+	go dm.MonitorPodmanEvents()
+
+	waitFor(t, "the running container to be listed", func() bool {
+		_, ok := getContainer("redis")
+		return ok
+	})
+
+	container, _ := getContainer("redis")
+	if container.PidNS == 0 || container.MntNS == 0 || container.AppArmorProfile != "containers-default-0.50.1" || container.MergedDir != "/var/lib/containers/storage/overlay/redis/merged" {
+		t.Errorf("[FAIL] Unexpected info of the container (%+v)", container)
+	}
+	if container.NamespaceName != "container_namespace" || container.EndPointName != "redis" || !container.ProcessVisibilityEnabled {
+		t.Errorf("[FAIL] Expected the container to be unorchestrated (%+v)", container)
+	}
+
+	waitFor(t, "the event stream", func() bool {
+		return fake.EventStreams() == 1
+	})
+
+	// podman run
+	fake.AddContainer(testutil.FakeContainer{
+		ID:    "nginx",
+		Name:  "nginx",
+		Image: "docker.io/library/nginx:latest",
+		Pid:   os.Getpid(),
+	})
+
+	waitFor(t, "the started container to be added", func() bool {
+		_, ok := getContainer("nginx")
+		return ok
+	})
+
+	dm.EndPointsLock.RLock()
+	applied := false
+	for _, ep := range dm.EndPoints {
+		if ep.EndPointName == "nginx" && kl.ContainsElement(ep.Containers, "nginx") && len(ep.SecurityPolicies) == 1 {
+			applied = true
+		}
+	}
+	dm.EndPointsLock.RUnlock()
+
+	if !applied {
+		t.Errorf("[FAIL] Expected the policy to apply to the container")
+	}
+
+	// podman rm -f
+	fake.StopContainer("nginx")
+
+	waitFor(t, "the stopped container to be removed", func() bool {
+		_, ok := getContainer("nginx")
+		return !ok
+	})
+
+	// the container removed while disconnected is removed by the re-sync on reconnect
+	fake.DropEventStreams()
+	fake.StopContainer("redis")
+
+	waitFor(t, "the container to be removed on reconnect", func() bool {
+		_, ok := getContainer("redis")
+		return !ok
+	})
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	t.Log("[PASS] Kept track of the Podman containers")
+}
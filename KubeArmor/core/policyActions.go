@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ==================== //
+// == Policy Actions == //
+// ==================== //
+
+// missingPolicyActions returns the rules which have no action of their own, nor one to inherit
+// from their section or from the policy
+func missingPolicyActions(spec tp.SecuritySpec) []string {
+	missing := []string{}
+
+	if spec.Action != "" {
+		return missing
+	}
+
+	check := func(section, sectionAction string, actions []string) {
+		if sectionAction != "" {
+			return
+		}
+		for idx, action := range actions {
+			if action == "" {
+				missing = append(missing, fmt.Sprintf("%s[%d]", section, idx))
+			}
+		}
+	}
+
+	actions := func(n int, action func(idx int) string) []string {
+		list := make([]string, n)
+		for idx := range list {
+			list[idx] = action(idx)
+		}
+		return list
+	}
+
+	process := spec.Process
+	check("process.matchPaths", process.Action, actions(len(process.MatchPaths), func(idx int) string { return process.MatchPaths[idx].Action }))
+	check("process.matchDirectories", process.Action, actions(len(process.MatchDirectories), func(idx int) string { return process.MatchDirectories[idx].Action }))
+	check("process.matchPatterns", process.Action, actions(len(process.MatchPatterns), func(idx int) string { return process.MatchPatterns[idx].Action }))
+	check("process.matchNamespaces", process.Action, actions(len(process.MatchNamespaces), func(idx int) string { return process.MatchNamespaces[idx].Action }))
+	check("process.matchSignals", process.Action, actions(len(process.MatchSignals), func(idx int) string { return process.MatchSignals[idx].Action }))
+	if process.BlockFileless != nil && process.BlockFileless.Action == "" && process.Action == "" {
+		missing = append(missing, "process.blockFileless")
+	}
+
+	file := spec.File
+	check("file.matchPaths", file.Action, actions(len(file.MatchPaths), func(idx int) string { return file.MatchPaths[idx].Action }))
+	check("file.matchDirectories", file.Action, actions(len(file.MatchDirectories), func(idx int) string { return file.MatchDirectories[idx].Action }))
+	check("file.matchPatterns", file.Action, actions(len(file.MatchPatterns), func(idx int) string { return file.MatchPatterns[idx].Action }))
+	check("file.matchXattrs", file.Action, actions(len(file.MatchXattrs), func(idx int) string { return file.MatchXattrs[idx].Action }))
+	check("file.matchImmutable", file.Action, actions(len(file.MatchImmutable), func(idx int) string { return file.MatchImmutable[idx].Action }))
+
+	network := spec.Network
+	check("network.matchProtocols", network.Action, actions(len(network.MatchProtocols), func(idx int) string { return network.MatchProtocols[idx].Action }))
+	check("network.matchRuntimeSockets", network.Action, actions(len(network.MatchRuntimeSockets), func(idx int) string { return network.MatchRuntimeSockets[idx].Action }))
+
+	capabilities := spec.Capabilities
+	check("capabilities.matchCapabilities", capabilities.Action, actions(len(capabilities.MatchCapabilities), func(idx int) string { return capabilities.MatchCapabilities[idx].Action }))
+
+	return missing
+}
+
+// validatePolicyActions rejects a policy with rules left without any action
+func validatePolicyActions(spec tp.SecuritySpec) error {
+	if missing := missingPolicyActions(spec); len(missing) > 0 {
+		return fmt.Errorf("no action for %s, set spec.action or the action of the rules", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// validateFileAttributeActions rejects a policy with Block xattr rules whose names can't be matched by any enforcer
+// (only the BPF LSM enforcer blocks the changes of xattrs, with an exact name, a namespace or any name)
+func validateFileAttributeActions(file tp.FileType, action string) error {
+	for idx, xattr := range file.MatchXattrs {
+		ruleAction := xattr.Action
+		if ruleAction == "" {
+			ruleAction = file.Action
+		}
+		if ruleAction == "" {
+			ruleAction = action
+		}
+
+		if _, ok := fd.XattrNameKey(xattr.Name); !ok && ruleAction == "Block" {
+			return fmt.Errorf("unenforceable name %s for file.matchXattrs[%d], block an exact name, a namespace (e.g., security.*) or *", xattr.Name, idx)
+		}
+	}
+	return nil
+}
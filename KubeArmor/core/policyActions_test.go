@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"testing"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPolicyLevelAction(t *testing.T) {
+	dm := NewKubeArmorDaemon()
+
+	policy := ksp.KubeArmorPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "audit-all"}}
+	policy.Spec.Action = "Audit"
+	policy.Spec.Process.MatchPaths = []ksp.ProcessPathType{{Path: "/bin/sh"}, {Path: "/usr/bin/curl", Action: "Block"}}
+	policy.Spec.File.MatchDirectories = []ksp.FileDirectoryType{{Directory: "/etc/"}}
+
+	// rules without an action inherit the one of the policy
+	secPolicy, err := dm.CreateSecurityPolicy(policy)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create the policy (%s)", err.Error())
+	}
+
+	if secPolicy.Spec.Process.MatchPaths[0].Action != "Audit" || secPolicy.Spec.Process.MatchPaths[1].Action != "Block" || secPolicy.Spec.File.MatchDirectories[0].Action != "Audit" {
+		t.Errorf("[FAIL] Unexpected actions of the rules (%+v, %+v)", secPolicy.Spec.Process.MatchPaths, secPolicy.Spec.File.MatchDirectories)
+	}
+
+	// the action of a section is enough
+	policy.Spec.Action = ""
+	policy.Spec.File.Action = "Block"
+	policy.Spec.Process.Action = "Audit"
+
+	if _, err := dm.CreateSecurityPolicy(policy); err != nil {
+		t.Errorf("[FAIL] Expected the actions of the sections to be inherited (%s)", err.Error())
+	}
+
+	// rules left without any action are rejected
+	policy.Spec.Process.Action = ""
+
+	if _, err := dm.CreateSecurityPolicy(policy); err == nil || err.Error() != "no action for process.matchPaths[0], set spec.action or the action of the rules" {
+		t.Errorf("[FAIL] Expected the policy to be rejected (%v)", err)
+	}
+
+	t.Log("[PASS] Inherited the action of the policy")
+}
+
+func TestFileAttributeActions(t *testing.T) {
+	dm := NewKubeArmorDaemon()
+
+	policy := ksp.KubeArmorPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "protect-attributes"}}
+	policy.Spec.Action = "Block"
+	policy.Spec.File.MatchXattrs = []ksp.FileXattrType{{Name: "security.*"}, {Name: "user.checksum"}, {Name: "*"}, {Name: "user.[ab]", Action: "Audit"}}
+
+	// exact names, namespaces and any name are blocked by the BPF LSM enforcer, and the others audited
+	if _, err := dm.CreateSecurityPolicy(policy); err != nil {
+		t.Errorf("[FAIL] Expected the xattr rules to be accepted (%s)", err.Error())
+	}
+
+	// the other names can't be blocked by any enforcer
+	policy.Spec.File.MatchXattrs[3].Action = ""
+
+	if _, err := dm.CreateSecurityPolicy(policy); err == nil || err.Error() != "unenforceable name user.[ab] for file.matchXattrs[3], block an exact name, a namespace (e.g., security.*) or *" {
+		t.Errorf("[FAIL] Expected the xattr rule to be rejected (%v)", err)
+	}
+
+	// the same for the host policies
+	hostPolicy := tp.K8sKubeArmorHostPolicy{}
+	hostPolicy.Metadata.Name = "protect-host-attributes"
+	hostPolicy.Spec.File.Action = "Block"
+	hostPolicy.Spec.File.MatchXattrs = []tp.FileXattrType{{Name: "trusted.?"}}
+
+	if _, status, err := dm.createHostSecurityPolicy(tp.K8sKubeArmorHostPolicyEvent{Type: "ADDED", Object: hostPolicy}); err == nil || status != pb.PolicyStatus_Invalid {
+		t.Errorf("[FAIL] Expected the host xattr rule to be rejected (%v, %v)", status, err)
+	}
+
+	t.Log("[PASS] Validated the actions of the xattr rules")
+}
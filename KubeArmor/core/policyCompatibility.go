@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"encoding/json"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+)
+
+// ========================== //
+// == Policy Compatibility == //
+// ========================== //
+
+// newPolicyCompatibilityAnnotations returns the annotations reporting the compatibility of a policy on this node,
+// or nil if the policy already has them
+func (dm *KubeArmorDaemon) newPolicyCompatibilityAnnotations(annotations map[string]string, differences, warnings []string) map[string]string {
+	if len(dm.Node.NodeName) == 0 {
+		return nil
+	}
+
+	report, err := json.Marshal(ksp.PolicyCompatibilityReport{Node: dm.Node.NodeName, Enforcer: dm.Logger.Enforcer, Differences: differences, Warnings: warnings})
+	if err != nil {
+		return nil
+	}
+
+	// the names of the nodes longer than the name part of annotations are hashed
+	key := ksp.PolicyCompatibilityKey(dm.Node.NodeName)
+
+	// unchanged (this also stops the updates triggered by our own patches)
+	if annotations[key] == string(report) {
+		return nil
+	}
+
+	return map[string]string{key: string(report)}
+}
+
+// annotatePolicyCompatibility reports the compatibility of a policy on this node through its annotations,
+// which the controller aggregates into the conditions of the policy
+func (dm *KubeArmorDaemon) annotatePolicyCompatibility(kind, namespaceName, policyName string, annotations map[string]string, differences, warnings []string) {
+	if !cfg.GlobalCfg.K8sEnv {
+		return
+	}
+
+	patch := dm.newPolicyCompatibilityAnnotations(annotations, differences, warnings)
+	if patch == nil {
+		return
+	}
+
+	if err := K8s.PatchPolicyAnnotations(kind, namespaceName, policyName, patch); err != nil {
+		dm.Logger.Warnf("Failed to report the compatibility of %s/%s (%s)", namespaceName, policyName, err.Error())
+	}
+}
+
+// hostPolicySelectsNode checks if a host policy is applied to this node
+func (dm *KubeArmorDaemon) hostPolicySelectsNode(selector tp.NodeSelectorType) bool {
+	selector.Identities = []string{}
+	for k, v := range selector.MatchLabels {
+		selector.Identities = append(selector.Identities, k+"="+v)
+	}
+
+	dm.NodeLock.RLock()
+	defer dm.NodeLock.RUnlock()
+
+	return matchNodeSelector(selector, dm.Node.Identities)
+}
@@ -0,0 +1,285 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	ksplister "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/client/listers/security.kubearmor.com/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ================== //
+// == Policy Drift == //
+// ================== //
+
+// PolicyDriftName is the name of the alerts of the drift of the applied policies
+const PolicyDriftName = "kubearmor-policy-drift"
+
+// PolicyDrift Structure
+type PolicyDrift struct {
+	// in the API server, but not applied
+	Missing []string
+
+	// applied, but not in the API server anymore
+	Stale []string
+}
+
+// Empty Function
+func (pd PolicyDrift) Empty() bool {
+	return len(pd.Missing) == 0 && len(pd.Stale) == 0
+}
+
+// String Function
+func (pd PolicyDrift) String() string {
+	return fmt.Sprintf("missing %v, stale %v", pd.Missing, pd.Stale)
+}
+
+// PolicyDriftDetector keeps the drift found by the previous check, so that only the drift lasting across two checks
+// is reported (rather than the events which are still being handled)
+type PolicyDriftDetector struct {
+	Lister ksplister.KubeArmorPolicyLister
+
+	// "missing:namespace/policy" or "stale:namespace/policy"
+	suspected map[string]struct{}
+}
+
+// NewPolicyDriftDetector Function
+func NewPolicyDriftDetector(lister ksplister.KubeArmorPolicyLister) *PolicyDriftDetector {
+	return &PolicyDriftDetector{Lister: lister, suspected: map[string]struct{}{}}
+}
+
+// loadCachedPolicyKeys returns the keys of the security policies in the local policy cache, which are applied
+// without the API server (a tampered cache vouches for none)
+var loadCachedPolicyKeys = func() map[string]struct{} {
+	keys := map[string]struct{}{}
+
+	if _, err := os.Stat(cfg.PolicyDir); err != nil {
+		return keys
+	}
+
+	policyFiles, _, err := loadPolicyCache(cfg.PolicyDir, cfg.PolicyDigestDir, getPolicyCacheKey())
+	if err != nil {
+		return keys
+	}
+
+	for _, data := range policyFiles {
+		var k struct {
+			Metadata map[string]string `json:"metadata"`
+		}
+
+		if err := json.Unmarshal(data, &k); err != nil {
+			continue
+		}
+
+		// host policies aren't compared
+		if namespaceName, ok := k.Metadata["namespaceName"]; ok {
+			keys[namespaceName+"/"+k.Metadata["policyName"]] = struct{}{}
+		}
+	}
+
+	return keys
+}
+
+// detectPolicyDrift compares the applied security policies with the listed ones
+func (dm *KubeArmorDaemon) detectPolicyDrift(listed map[string]*ksp.KubeArmorPolicy) PolicyDrift {
+	drift := PolicyDrift{Missing: []string{}, Stale: []string{}}
+
+	applied := map[string]struct{}{}
+
+	dm.SecurityPoliciesLock.RLock()
+	for _, secPolicy := range dm.SecurityPolicies {
+		// the node-local overrides and the cluster policies are never listed
+		if fd.PolicyOverride(secPolicy.Metadata) || ClusterPolicy(secPolicy.Metadata) {
+			continue
+		}
+		applied[policyKey(secPolicy)] = struct{}{}
+	}
+	dm.SecurityPoliciesLock.RUnlock()
+
+	for key := range listed {
+		if _, ok := applied[key]; !ok {
+			drift.Missing = append(drift.Missing, key)
+		}
+	}
+
+	cached := loadCachedPolicyKeys()
+	for key := range applied {
+		if _, ok := listed[key]; ok {
+			continue
+		}
+		if _, ok := cached[key]; ok {
+			continue
+		}
+		drift.Stale = append(drift.Stale, key)
+	}
+
+	sort.Strings(drift.Missing)
+	sort.Strings(drift.Stale)
+
+	return drift
+}
+
+// correctPolicyDrift applies the missing security policies and removes the stale ones, and returns how many are corrected
+func (dm *KubeArmorDaemon) correctPolicyDrift(drift PolicyDrift, listed map[string]*ksp.KubeArmorPolicy) int {
+	corrected := 0
+
+	for _, key := range drift.Missing {
+		policy := listed[key].DeepCopy()
+		dm.addKubeArmorPolicy(policy)
+
+		if _, ok := dm.getSecurityPolicy(policy.Namespace, policy.Name); ok {
+			corrected++
+		}
+	}
+
+	for _, key := range drift.Stale {
+		namespaceName, policyName, _ := strings.Cut(key, "/")
+
+		unlock := dm.PolicyOrder.LockPolicy(key)
+		dm.PolicyOrder.forget(key)
+		if secPolicy, ok := dm.removeSecurityPolicy(namespaceName, policyName); ok {
+			dm.UpdateSecurityPolicy("DELETED", secPolicy)
+			corrected++
+		}
+		unlock()
+	}
+
+	return corrected
+}
+
+// getSecurityPolicy returns an applied security policy
+func (dm *KubeArmorDaemon) getSecurityPolicy(namespaceName, policyName string) (tp.SecurityPolicy, bool) {
+	dm.SecurityPoliciesLock.RLock()
+	defer dm.SecurityPoliciesLock.RUnlock()
+
+	for _, secPolicy := range dm.SecurityPolicies {
+		if secPolicy.Metadata["namespaceName"] == namespaceName && secPolicy.Metadata["policyName"] == policyName {
+			return secPolicy, true
+		}
+	}
+
+	return tp.SecurityPolicy{}, false
+}
+
+// reportPolicyDrift raises a warning alert naming the drifted policies
+func (dm *KubeArmorDaemon) reportPolicyDrift(drift PolicyDrift, corrected bool) {
+	dm.Logger.Warnf("Detected the drift of the Security Policies from the API server (%s)", drift)
+
+	log := tp.Log{}
+
+	timestamp, updatedTime := kl.GetDateTimeNow()
+
+	log.Timestamp = timestamp
+	log.UpdatedTime = updatedTime
+
+	log.Type = "MatchedPolicy"
+	log.PolicyName = PolicyDriftName
+	log.Severity = "5"
+	log.Tags = "KUBEARMOR,DRIFT"
+	log.Message = "KubeArmor applied policies drifted from the API server"
+
+	log.Source = "kubearmor"
+	log.ProcessName = "kubearmor"
+	log.Operation = "Policy"
+	log.Resource = strings.Join(append(append([]string{}, drift.Missing...), drift.Stale...), ",")
+	log.Data = drift.String()
+
+	log.Enforcer = "KubeArmor"
+	log.Action = "Audit"
+	if corrected {
+		log.Result = "Corrected"
+	} else {
+		log.Result = "Not corrected"
+	}
+
+	dm.Logger.PushSummaryLog(log)
+}
+
+// CheckPolicyDrift compares the applied security policies with the ones listed by the informer, reports the drift
+// found by the previous check as well, and corrects it unless DriftAutoCorrect is false
+func (dm *KubeArmorDaemon) CheckPolicyDrift(pd *PolicyDriftDetector) PolicyDrift {
+	policies, err := pd.Lister.List(labels.Everything())
+	if err != nil {
+		dm.Logger.Warnf("Failed to list Security Policies to check the drift (%s)", err.Error())
+		return PolicyDrift{}
+	}
+
+	listed := map[string]*ksp.KubeArmorPolicy{}
+	for _, policy := range policies {
+		listed[policy.Namespace+"/"+policy.Name] = policy
+	}
+
+	found := dm.detectPolicyDrift(listed)
+
+	suspected := map[string]struct{}{}
+	drift := PolicyDrift{Missing: []string{}, Stale: []string{}}
+
+	for _, key := range found.Missing {
+		suspected["missing:"+key] = struct{}{}
+		if _, ok := pd.suspected["missing:"+key]; ok {
+			drift.Missing = append(drift.Missing, key)
+		}
+	}
+	for _, key := range found.Stale {
+		suspected["stale:"+key] = struct{}{}
+		if _, ok := pd.suspected["stale:"+key]; ok {
+			drift.Stale = append(drift.Stale, key)
+		}
+	}
+
+	pd.suspected = suspected
+
+	corrected := 0
+	if !drift.Empty() {
+		if cfg.GlobalCfg.DriftAutoCorrect {
+			corrected = dm.correctPolicyDrift(drift, listed)
+
+			// the corrected drift is not suspected anymore
+			for _, key := range drift.Missing {
+				delete(pd.suspected, "missing:"+key)
+			}
+			for _, key := range drift.Stale {
+				delete(pd.suspected, "stale:"+key)
+			}
+		}
+
+		dm.reportPolicyDrift(drift, cfg.GlobalCfg.DriftAutoCorrect)
+	}
+
+	dm.Logger.PolicyMetrics.ObservePolicyDrift(len(drift.Missing), len(drift.Stale), corrected)
+
+	return drift
+}
+
+// WatchPolicyDrift checks the drift of the applied security policies periodically
+func (dm *KubeArmorDaemon) WatchPolicyDrift(lister ksplister.KubeArmorPolicyLister) {
+	if cfg.GlobalCfg.DriftCheckInterval <= 0 {
+		return
+	}
+
+	pd := NewPolicyDriftDetector(lister)
+
+	ticker := time.NewTicker(cfg.GlobalCfg.DriftCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-StopChan:
+			return
+		case <-ticker.C:
+			dm.CheckPolicyDrift(pd)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"sync"
+	"testing"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	ksplister "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/client/listers/security.kubearmor.com/v1"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"k8s.io/client-go/tools/cache"
+)
+
+// scrapePolicyDrift returns the drift gauges and the number of corrections in the registry
+func scrapePolicyDrift(t *testing.T, pm *fd.PolicyMetrics) map[string]float64 {
+	families, err := pm.Registry.Gather()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to scrape the metrics (%s)", err.Error())
+	}
+
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			switch family.GetName() {
+			case "kubearmor_policy_drift":
+				values[metric.GetLabel()[0].GetValue()] = metric.GetGauge().GetValue()
+			case "kubearmor_policy_drift_corrections_total":
+				values["corrections"] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+
+	return values
+}
+
+func TestPolicyDrift(t *testing.T) {
+	prevPolicy, prevAutoCorrect, prevCached := cfg.GlobalCfg.Policy, cfg.GlobalCfg.DriftAutoCorrect, loadCachedPolicyKeys
+	defer func() {
+		cfg.GlobalCfg.Policy, cfg.GlobalCfg.DriftAutoCorrect, loadCachedPolicyKeys = prevPolicy, prevAutoCorrect, prevCached
+	}()
+	cfg.GlobalCfg.Policy = true
+
+	// a policy applied offline from the local policy cache
+	loadCachedPolicyKeys = func() map[string]struct{} {
+		return map[string]struct{}{"web/cached-policy": {}}
+	}
+
+	// the informer keeps the policies in the indexer, which the test changes without any event
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	pd := NewPolicyDriftDetector(ksplister.NewKubeArmorPolicyLister(indexer))
+
+	dm := newPolicyOrderDaemon()
+	dm.Logger.Output = "none"
+	dm.Logger.SeverityRangesLock = new(sync.RWMutex)
+	dm.Logger.SinksLock = new(sync.RWMutex)
+	dm.Logger.PolicyMetrics = fd.NewPolicyMetrics(10)
+
+	alerts := make(chan *pb.Alert, 4)
+	fd.AlertLock = new(sync.RWMutex)
+	fd.AlertStructs = map[string]fd.AlertStruct{"all": {Filter: "all", Broadcast: alerts}}
+	defer func() { fd.AlertStructs = map[string]fd.AlertStruct{} }()
+
+	v1 := newOrderedPolicy("uid-1", "10", "/bin/sh")
+	if err := indexer.Add(v1); err != nil {
+		t.Fatalf("[FAIL] Failed to list a policy (%s)", err.Error())
+	}
+	dm.kubeArmorPolicyEventHandler().OnAdd(v1)
+
+	cachedPolicy := newOrderedPolicy("uid-2", "11", "/bin/dash")
+	cachedPolicy.Name = "cached-policy"
+	dm.kubeArmorPolicyEventHandler().OnAdd(cachedPolicy)
+
+	if drift := dm.CheckPolicyDrift(pd); !drift.Empty() {
+		t.Errorf("[FAIL] Unexpected drift of a cached policy (%s)", drift)
+	}
+
+	// the delete is missed during a partition, and another policy is added behind the back of the daemon
+	if err := indexer.Delete(v1); err != nil {
+		t.Fatalf("[FAIL] Failed to remove a policy (%s)", err.Error())
+	}
+	v2 := newOrderedPolicy("uid-3", "12", "/usr/bin/curl")
+	v2.Name = "block-curl"
+	if err := indexer.Add(v2); err != nil {
+		t.Fatalf("[FAIL] Failed to list a policy (%s)", err.Error())
+	}
+
+	// the drift seen once may be the events being handled
+	if drift := dm.CheckPolicyDrift(pd); !drift.Empty() {
+		t.Errorf("[FAIL] Expected the drift to be confirmed by the next check (%s)", drift)
+	}
+
+	// not corrected
+	cfg.GlobalCfg.DriftAutoCorrect = false
+
+	drift := dm.CheckPolicyDrift(pd)
+	if len(drift.Missing) != 1 || drift.Missing[0] != "web/block-curl" || len(drift.Stale) != 1 || drift.Stale[0] != "web/block-shell" {
+		t.Fatalf("[FAIL] Unexpected drift (%s)", drift)
+	}
+	if paths := getAppliedPaths(dm); len(paths) != 2 {
+		t.Errorf("[FAIL] Expected the drift not to be corrected (%v)", paths)
+	}
+
+	if alert := <-alerts; alert.PolicyName != PolicyDriftName || alert.Resource != "web/block-curl,web/block-shell" || alert.Result != "Not corrected" {
+		t.Errorf("[FAIL] Unexpected alert of the drift (%s, %s, %s)", alert.PolicyName, alert.Resource, alert.Result)
+	}
+	if values := scrapePolicyDrift(t, dm.Logger.PolicyMetrics); values["missing"] != 1 || values["stale"] != 1 || values["corrections"] != 0 {
+		t.Errorf("[FAIL] Unexpected metrics of the drift (%v)", values)
+	}
+
+	// corrected
+	cfg.GlobalCfg.DriftAutoCorrect = true
+
+	if drift := dm.CheckPolicyDrift(pd); len(drift.Missing) != 1 || len(drift.Stale) != 1 {
+		t.Fatalf("[FAIL] Unexpected drift (%s)", drift)
+	}
+
+	paths := getAppliedPaths(dm)
+	if len(paths) != 2 || paths[0] != "/bin/dash" || paths[1] != "/usr/bin/curl" {
+		t.Errorf("[FAIL] Expected the drift to be corrected (%v)", paths)
+	}
+
+	if alert := <-alerts; alert.Result != "Corrected" {
+		t.Errorf("[FAIL] Unexpected alert of the drift (%s)", alert.Result)
+	}
+	if values := scrapePolicyDrift(t, dm.Logger.PolicyMetrics); values["corrections"] != 2 {
+		t.Errorf("[FAIL] Unexpected metrics of the drift (%v)", values)
+	}
+
+	if drift := dm.CheckPolicyDrift(pd); !drift.Empty() {
+		t.Errorf("[FAIL] Unexpected drift after the correction (%s)", drift)
+	}
+
+	t.Log("[PASS] Detected and corrected the drift of the applied policies")
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+// =================== //
+// == Policy Events == //
+// =================== //
+
+// policy kinds
+const (
+	KubeArmorPolicyKind        = "KubeArmorPolicy"
+	KubeArmorHostPolicyKind    = "KubeArmorHostPolicy"
+	KubeArmorClusterPolicyKind = "KubeArmorClusterPolicy"
+)
+
+// policyEventAction converts an update type (ADDED, MODIFIED, DELETED) into a policy event action
+func policyEventAction(action string) string {
+	switch action {
+	case "ADDED":
+		return fd.PolicyApplied
+	case "MODIFIED":
+		return fd.PolicyUpdated
+	case "DELETED":
+		return fd.PolicyRemoved
+	}
+	return fd.PolicyFailed
+}
+
+// policyStatusToEventAction converts the status of a policy update into a policy event action and reason
+func policyStatusToEventAction(status pb.PolicyStatus) (string, string) {
+	switch status {
+	case pb.PolicyStatus_Applied:
+		return fd.PolicyApplied, ""
+	case pb.PolicyStatus_Modified:
+		return fd.PolicyUpdated, ""
+	case pb.PolicyStatus_Deleted:
+		return fd.PolicyRemoved, ""
+	}
+	return fd.PolicyFailed, status.String()
+}
+
+// getPolicyEndpoints returns the names of the endpoints which have the given policy
+func (dm *KubeArmorDaemon) getPolicyEndpoints(namespace, policyName string) []string {
+	dm.EndPointsLock.RLock()
+	defer dm.EndPointsLock.RUnlock()
+
+	endpoints := []string{}
+
+	for _, endPoint := range dm.EndPoints {
+		for _, policy := range endPoint.SecurityPolicies {
+			if policy.Metadata["namespaceName"] == namespace && policy.Metadata["policyName"] == policyName {
+				endpoints = append(endpoints, endPoint.EndPointName)
+				break
+			}
+		}
+	}
+
+	return endpoints
+}
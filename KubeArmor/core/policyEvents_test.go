@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/grpc"
+)
+
+// policyEventStream is a fake client of WatchPolicies
+type policyEventStream struct {
+	grpc.ServerStream
+
+	ctx    context.Context
+	events chan *pb.PolicyEvent
+}
+
+func (s *policyEventStream) Send(event *pb.PolicyEvent) error {
+	s.events <- event
+	return nil
+}
+
+func (s *policyEventStream) Context() context.Context {
+	return s.ctx
+}
+
+// expectPolicyEvents checks that the given events, and no others, were sent before a marker event pushed afterwards
+func (s *policyEventStream) expectPolicyEvents(t *testing.T, dm *KubeArmorDaemon, change string, actions ...string) {
+	dm.Logger.PushPolicyEvent(KubeArmorPolicyKind, "marker", change, fd.PolicyFailed, "", nil)
+
+	received := []string{}
+	for {
+		select {
+		case event := <-s.events:
+			if event.NamespaceName == "marker" {
+				if len(received) != len(actions) {
+					t.Errorf("[FAIL] Expected %v policy events on %s, got %v", actions, change, received)
+					return
+				}
+				for idx := range actions {
+					if received[idx] != actions[idx] {
+						t.Errorf("[FAIL] Expected %v policy events on %s, got %v", actions, change, received)
+						return
+					}
+				}
+				return
+			}
+			received = append(received, event.Action)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("[FAIL] Timed out waiting for the policy events of %s", change)
+		}
+	}
+}
+
+func TestPolicyEvents(t *testing.T) {
+	dm := newPolicyOrderDaemon()
+	handler := dm.kubeArmorPolicyEventHandler()
+
+	// the gRPC service might have been stopped by other tests
+	fd.Running = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := &policyEventStream{ctx: ctx, events: make(chan *pb.PolicyEvent, 16)}
+	go func() {
+		_ = (&fd.LogService{}).WatchPolicies(&pb.RequestMessage{Filter: "all"}, stream)
+	}()
+
+	for i := 0; i < 100; i++ {
+		fd.PolicyEventLock.RLock()
+		n := len(fd.PolicyEventStructs)
+		fd.PolicyEventLock.RUnlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	added := newOrderedPolicy("uid-1", "1", "/bin/sh")
+	handler.OnAdd(added)
+	stream.expectPolicyEvents(t, dm, "add", fd.PolicyApplied)
+
+	modified := newOrderedPolicy("uid-1", "2", "/bin/bash")
+	modified.Generation = 2
+	handler.OnUpdate(added, modified)
+	stream.expectPolicyEvents(t, dm, "update", fd.PolicyUpdated)
+
+	// the updates of the status only are not changes of the policy
+	status := modified.DeepCopy()
+	status.ResourceVersion = "3"
+	handler.OnUpdate(modified, status)
+	stream.expectPolicyEvents(t, dm, "status update")
+
+	handler.OnDelete(status)
+	stream.expectPolicyEvents(t, dm, "delete", fd.PolicyRemoved)
+
+	// a repeated delete is not a change
+	handler.OnDelete(status)
+	stream.expectPolicyEvents(t, dm, "repeated delete")
+
+	if event := fd.AppliedPolicies[KubeArmorPolicyKind+"/web/block-shell"]; event != nil {
+		t.Errorf("[FAIL] Unexpected applied policy after the delete (%+v)", event)
+	}
+
+	t.Log("[PASS] Sent one policy event per change")
+}
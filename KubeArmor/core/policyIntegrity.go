@@ -0,0 +1,300 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ============================ //
+// == Policy Cache Integrity == //
+// ============================ //
+
+// digestSuffix is appended to the name of a cached policy to get the name of its digest file
+const digestSuffix = ".sha256"
+
+// errPolicyCacheTampered is returned when a cached policy does not match its digest
+var errPolicyCacheTampered = errors.New("policy cache integrity check failed")
+
+// getPolicyCacheKey Function
+func getPolicyCacheKey() []byte {
+	if cfg.GlobalCfg.PolicyCacheKeyFile == "" {
+		return nil
+	}
+
+	key, err := os.ReadFile(cfg.GlobalCfg.PolicyCacheKeyFile)
+	if err != nil {
+		kg.Warnf("Failed to read the policy cache key (%s)", err.Error())
+		return nil
+	}
+
+	return []byte(strings.TrimSpace(string(key)))
+}
+
+// computePolicyDigest returns an HMAC of the data if a key is given, or a plain SHA-256 otherwise
+func computePolicyDigest(data, key []byte) string {
+	if len(key) > 0 {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(data)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writePolicyDigest stores the digest of a cached policy in the digest directory
+func writePolicyDigest(digestDir, fileName string, data, key []byte) error {
+	if _, err := os.Stat(digestDir); err != nil {
+		if err = os.MkdirAll(digestDir, 0700); err != nil {
+			return err
+		}
+	}
+
+	return os.WriteFile(digestDir+fileName+digestSuffix, []byte(computePolicyDigest(data, key)), 0600)
+}
+
+// removePolicyDigest Function
+func removePolicyDigest(digestDir, fileName string) {
+	if err := os.Remove(digestDir + fileName + digestSuffix); err != nil && !os.IsNotExist(err) {
+		kg.Warnf("Failed to remove the policy digest (%s)", err.Error())
+	}
+}
+
+// migratePolicyCache writes the digests of the policies cached by an older KubeArmor (without digests), only if it
+// is enabled explicitly, and only once (the marker written then is never removed by KubeArmor), and returns the
+// number of the digests written
+//
+// Otherwise, a cache without digests is refused, as it is all what removing the digest directory takes to get
+// tampered policies trusted.
+func migratePolicyCache(policyDir, digestDir, markerPath string, key []byte) (int, error) {
+	if _, err := os.Stat(markerPath); err == nil {
+		return 0, errors.New("the policy cache has been migrated already")
+	} else if !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	if _, err := os.Stat(digestDir); err == nil || !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	policyFiles, err := os.ReadDir(policyDir)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(digestDir, 0700); err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+
+	for _, file := range policyFiles {
+		if file.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(policyDir + file.Name())
+		if err != nil {
+			return migrated, err
+		}
+
+		if err := writePolicyDigest(digestDir, file.Name(), data, key); err != nil {
+			return migrated, err
+		}
+
+		migrated++
+	}
+
+	return migrated, os.WriteFile(markerPath, []byte(fmt.Sprintf("%d\n", migrated)), 0600)
+}
+
+// loadPolicyCache reads all cached policies and verifies them against their digests,
+// the whole cache is rejected if any of them does not match (or has no digest)
+func loadPolicyCache(policyDir, digestDir string, key []byte) ([][]byte, string, error) {
+	policies := [][]byte{}
+
+	policyFiles, err := os.ReadDir(policyDir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, file := range policyFiles {
+		if file.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(policyDir + file.Name())
+		if err != nil {
+			return nil, policyDir + file.Name(), err
+		}
+
+		digest, err := os.ReadFile(digestDir + file.Name() + digestSuffix)
+		if err != nil {
+			return nil, policyDir + file.Name(), fmt.Errorf("%w (missing digest)", errPolicyCacheTampered)
+		}
+
+		if !hmac.Equal([]byte(strings.TrimSpace(string(digest))), []byte(computePolicyDigest(data, key))) {
+			return nil, policyDir + file.Name(), fmt.Errorf("%w (digest mismatch)", errPolicyCacheTampered)
+		}
+
+		policies = append(policies, data)
+	}
+
+	return policies, "", nil
+}
+
+// reportPolicyCacheTampering raises a high-severity alert for a tampered policy cache
+func (dm *KubeArmorDaemon) reportPolicyCacheTampering(fileName string, err error) {
+	dm.Logger.Errf("Refused to restore the policy cache (%s, %s)", fileName, err.Error())
+
+	log := tp.Log{}
+
+	timestamp, updatedTime := kl.GetDateTimeNow()
+
+	log.Timestamp = timestamp
+	log.UpdatedTime = updatedTime
+
+	log.Type = "MatchedPolicy"
+	log.PolicyName = tp.SelfProtectionPolicyName
+	log.Severity = "10"
+	log.Tags = "KUBEARMOR,INTEGRITY"
+	log.Message = "KubeArmor policy cache has been tampered with"
+
+	log.Source = "kubearmor"
+	log.ProcessName = "kubearmor"
+	log.Operation = "File"
+	log.Resource = fileName
+	log.Data = err.Error()
+
+	log.Enforcer = "KubeArmor"
+	log.Action = "Block"
+	log.Result = "Integrity check failed"
+
+	dm.Logger.PushLog(log)
+}
+
+// GetSelfProtectionHostPolicy returns a host policy which makes the local state of KubeArmor read-only to the processes
+// other than KubeArmor, which keeps writing the policy cache and its digests
+func GetSelfProtectionHostPolicy() tp.K8sKubeArmorHostPolicy {
+	policy := tp.K8sKubeArmorHostPolicy{}
+
+	policy.Metadata = metav1.ObjectMeta{Name: tp.SelfProtectionPolicyName}
+
+	policy.Spec.Severity = 10
+	policy.Spec.Tags = []string{"KUBEARMOR", "INTEGRITY"}
+	policy.Spec.Message = "KubeArmor local state is being modified"
+	policy.Spec.Action = "Block"
+
+	daemon, err := os.Executable()
+	if err != nil {
+		kg.Warnf("Failed to get the executable of KubeArmor (%s)", err.Error())
+	}
+
+	for _, dir := range []string{cfg.PolicyDir, cfg.PolicyDigestDir} {
+		policy.Spec.File.MatchDirectories = append(policy.Spec.File.MatchDirectories, tp.FileDirectoryType{
+			Directory: dir,
+			ReadOnly:  true,
+			Recursive: true,
+		})
+
+		// the rules of a source are matched before the ones of any source
+		if daemon != "" {
+			policy.Spec.File.MatchDirectories = append(policy.Spec.File.MatchDirectories, tp.FileDirectoryType{
+				Directory:  dir,
+				Recursive:  true,
+				FromSource: []tp.MatchSourceType{{Path: daemon}},
+				Action:     "Allow",
+			})
+		}
+	}
+
+	// the marker of the migration of the policy cache keeps it from being migrated again
+	policy.Spec.File.MatchPaths = append(policy.Spec.File.MatchPaths, tp.FilePathType{
+		Path:     cfg.PolicyMigratedPath,
+		ReadOnly: true,
+	})
+
+	if daemon != "" {
+		policy.Spec.File.MatchPaths = append(policy.Spec.File.MatchPaths, tp.FilePathType{
+			Path:       cfg.PolicyMigratedPath,
+			FromSource: []tp.MatchSourceType{{Path: daemon}},
+			Action:     "Allow",
+		})
+	}
+
+	if cfgFile := os.Getenv("KUBEARMOR_CFG"); cfgFile != "" {
+		policy.Spec.File.MatchPaths = append(policy.Spec.File.MatchPaths, tp.FilePathType{
+			Path:     cfgFile,
+			ReadOnly: true,
+		})
+	}
+
+	// the responders write the override file, so its writes are audited, while its state is kept by KubeArmor only
+	if cfg.GlobalCfg.PolicyOverrideFile != "" {
+		policy.Spec.File.MatchPaths = append(policy.Spec.File.MatchPaths, tp.FilePathType{
+			Path:     cfg.GlobalCfg.PolicyOverrideFile,
+			ReadOnly: true,
+			Action:   "Audit",
+		}, tp.FilePathType{
+			Path:     cfg.PolicyOverrideStatePath,
+			ReadOnly: true,
+		})
+
+		if daemon != "" {
+			policy.Spec.File.MatchPaths = append(policy.Spec.File.MatchPaths, tp.FilePathType{
+				Path:       cfg.PolicyOverrideStatePath,
+				FromSource: []tp.MatchSourceType{{Path: daemon}},
+				Action:     "Allow",
+			})
+		}
+	}
+
+	return policy
+}
+
+// GetDefaultHostPolicies returns the host policies generated by KubeArmor at startup (not backed up)
+func GetDefaultHostPolicies() []tp.K8sKubeArmorHostPolicy {
+	policies := []tp.K8sKubeArmorHostPolicy{}
+
+	if cfg.GlobalCfg.SelfProtection {
+		policies = append(policies, GetSelfProtectionHostPolicy())
+	}
+
+	return policies
+}
+
+// isDefaultHostPolicy Function
+func isDefaultHostPolicy(policyName string) bool {
+	for _, policy := range GetDefaultHostPolicies() {
+		if policy.Metadata.Name == policyName {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyDefaultHostPolicies Function
+func (dm *KubeArmorDaemon) ApplyDefaultHostPolicies() {
+	for _, policy := range GetDefaultHostPolicies() {
+		dm.ParseAndUpdateHostSecurityPolicy(tp.K8sKubeArmorHostPolicyEvent{
+			Type:   "ADDED",
+			Object: policy,
+		})
+		dm.Logger.Printf("Applied the default host policy (%s)", policy.Metadata.Name)
+	}
+}
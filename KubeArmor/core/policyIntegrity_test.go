@@ -0,0 +1,267 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+func TestPolicyCacheIntegrity(t *testing.T) {
+	policyDir := t.TempDir() + "/"
+	digestDir := t.TempDir() + "/"
+
+	key := []byte("secret")
+
+	policies := map[string][]byte{
+		"host-policy.yaml":      []byte(`{"metadata":{"policyName":"host-policy"}}`),
+		"container-policy.yaml": []byte(`{"metadata":{"policyName":"container-policy","namespaceName":"default"}}`),
+	}
+
+	for name, data := range policies {
+		if err := os.WriteFile(policyDir+name, data, 0600); err != nil {
+			t.Fatalf("[FAIL] Failed to write a policy (%s)", err.Error())
+		}
+		if err := writePolicyDigest(digestDir, name, data, key); err != nil {
+			t.Fatalf("[FAIL] Failed to write a digest (%s)", err.Error())
+		}
+	}
+
+	// intact cache
+	loaded, _, err := loadPolicyCache(policyDir, digestDir, key)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to load an intact cache (%s)", err.Error())
+	}
+	if len(loaded) != len(policies) {
+		t.Fatalf("[FAIL] Expected %d policies, got %d", len(policies), len(loaded))
+	}
+
+	// a digest computed without the key is rejected
+	if _, _, err := loadPolicyCache(policyDir, digestDir, nil); !errors.Is(err, errPolicyCacheTampered) {
+		t.Errorf("[FAIL] Expected the cache to be rejected without the key (%v)", err)
+	}
+
+	// corrupted policy
+	if err := os.WriteFile(policyDir+"host-policy.yaml", []byte(`{"metadata":{"policyName":"allow-all"}}`), 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to corrupt a policy (%s)", err.Error())
+	}
+
+	loaded, fileName, err := loadPolicyCache(policyDir, digestDir, key)
+	if !errors.Is(err, errPolicyCacheTampered) {
+		t.Fatalf("[FAIL] Expected the corrupted cache to be rejected (%v)", err)
+	}
+	if loaded != nil {
+		t.Errorf("[FAIL] Expected no policies to be loaded from a corrupted cache")
+	}
+	if fileName != policyDir+"host-policy.yaml" {
+		t.Errorf("[FAIL] Unexpected tampered file (%s)", fileName)
+	}
+
+	// injected policy without a digest
+	if err := os.WriteFile(policyDir+"host-policy.yaml", policies["host-policy.yaml"], 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to restore a policy (%s)", err.Error())
+	}
+	if err := os.WriteFile(policyDir+"injected.yaml", []byte(`{}`), 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to inject a policy (%s)", err.Error())
+	}
+
+	if _, _, err := loadPolicyCache(policyDir, digestDir, key); !errors.Is(err, errPolicyCacheTampered) {
+		t.Errorf("[FAIL] Expected the cache with an injected policy to be rejected (%v)", err)
+	}
+
+	t.Log("[PASS] Verified the integrity of the policy cache")
+}
+
+func TestPolicyCacheMigration(t *testing.T) {
+	policyDir := t.TempDir() + "/"
+	digestDir := t.TempDir() + "/digests/"
+	markerPath := t.TempDir() + "/digests.migrated"
+
+	key := []byte("secret")
+
+	// the cache of an older KubeArmor, without the digest directory
+	if err := os.WriteFile(policyDir+"host-policy.yaml", []byte(`{"metadata":{"policyName":"host-policy"}}`), 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to write a policy (%s)", err.Error())
+	}
+
+	// refused unless migrated
+	if _, _, err := loadPolicyCache(policyDir, digestDir, key); !errors.Is(err, errPolicyCacheTampered) {
+		t.Fatalf("[FAIL] Expected the cache without digests to be refused (%v)", err)
+	}
+
+	if migrated, err := migratePolicyCache(policyDir, digestDir, markerPath, key); err != nil || migrated != 1 {
+		t.Fatalf("[FAIL] Failed to migrate the cache of an older KubeArmor (%d, %v)", migrated, err)
+	}
+
+	loaded, _, err := loadPolicyCache(policyDir, digestDir, key)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to load the migrated cache (%s)", err.Error())
+	}
+	if len(loaded) != 1 {
+		t.Fatalf("[FAIL] Expected 1 policy, got %d", len(loaded))
+	}
+
+	// the digests are verified after the migration
+	if err := os.WriteFile(policyDir+"injected.yaml", []byte(`{}`), 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to inject a policy (%s)", err.Error())
+	}
+
+	if _, _, err := loadPolicyCache(policyDir, digestDir, key); !errors.Is(err, errPolicyCacheTampered) {
+		t.Errorf("[FAIL] Expected the cache with an injected policy to be rejected after the migration (%v)", err)
+	}
+
+	// removing the digests doesn't get the cache migrated again
+	if err := os.RemoveAll(digestDir); err != nil {
+		t.Fatalf("[FAIL] Failed to remove the digests (%s)", err.Error())
+	}
+
+	if migrated, err := migratePolicyCache(policyDir, digestDir, markerPath, key); err == nil || migrated != 0 {
+		t.Errorf("[FAIL] Expected the cache not to be migrated twice (%d, %v)", migrated, err)
+	}
+	if _, err := os.Stat(digestDir); !os.IsNotExist(err) {
+		t.Errorf("[FAIL] Unexpected digests written on the second migration")
+	}
+
+	t.Log("[PASS] Migrated the policy cache without digests once")
+}
+
+// newRestoreTestDaemon returns a daemon with a state directory in a temporary directory, and the alerts it raises
+func newRestoreTestDaemon(t *testing.T) (*KubeArmorDaemon, chan *pb.Alert) {
+	prevPolicyDir, prevDigestDir, prevMigratedPath := cfg.PolicyDir, cfg.PolicyDigestDir, cfg.PolicyMigratedPath
+	prevMigrate, prevHostPolicy := cfg.GlobalCfg.MigratePolicyCache, cfg.GlobalCfg.HostPolicy
+	t.Cleanup(func() {
+		cfg.PolicyDir, cfg.PolicyDigestDir, cfg.PolicyMigratedPath = prevPolicyDir, prevDigestDir, prevMigratedPath
+		cfg.GlobalCfg.MigratePolicyCache, cfg.GlobalCfg.HostPolicy = prevMigrate, prevHostPolicy
+	})
+
+	stateDir := t.TempDir()
+	cfg.PolicyDir = stateDir + "/policies/"
+	cfg.PolicyDigestDir = stateDir + "/digests/"
+	cfg.PolicyMigratedPath = stateDir + "/digests.migrated"
+	cfg.GlobalCfg.HostPolicy = false
+
+	if err := os.MkdirAll(cfg.PolicyDir, 0700); err != nil {
+		t.Fatalf("[FAIL] Failed to create the policy cache (%s)", err.Error())
+	}
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	alerts := make(chan *pb.Alert, 16)
+	fd.AlertLock = new(sync.RWMutex)
+	fd.AlertStructs = map[string]fd.AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+	t.Cleanup(func() { fd.AlertStructs = map[string]fd.AlertStruct{} })
+
+	dm := NewKubeArmorDaemon()
+	dm.Logger = &fd.Feeder{Node: &tp.Node{NodeName: "worker-1"}}
+	dm.Logger.Output = "none"
+	dm.Logger.SeverityRangesLock = new(sync.RWMutex)
+	dm.Logger.SecurityPolicies = map[string]tp.MatchPolicies{}
+	dm.Logger.SecurityPoliciesLock = new(sync.RWMutex)
+	dm.Logger.DefaultPostures = map[string]tp.DefaultPosture{}
+	dm.Logger.EndPointPostures = map[string]tp.DefaultPosture{}
+	dm.Logger.DefaultPosturesLock = new(sync.Mutex)
+	dm.Logger.SinksLock = new(sync.RWMutex)
+
+	return dm, alerts
+}
+
+func TestRestorePolicyCache(t *testing.T) {
+	dm, alerts := newRestoreTestDaemon(t)
+
+	// the cache of an older KubeArmor, without the digest directory
+	if err := os.WriteFile(cfg.PolicyDir+"host-policy.yaml", []byte(`{"metadata":{"policyName":"host-policy"}}`), 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to write a policy (%s)", err.Error())
+	}
+
+	expectTampering := func(what string) {
+		select {
+		case alert := <-alerts:
+			if alert.PolicyName != tp.SelfProtectionPolicyName || alert.Severity != "10" || alert.Resource != cfg.PolicyDir+"host-policy.yaml" {
+				t.Errorf("[FAIL] Unexpected alert for %s (%+v)", what, alert)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("[FAIL] Expected an integrity alert for %s", what)
+		}
+
+		if len(dm.HostSecurityPolicies) != 0 {
+			t.Errorf("[FAIL] Expected no policies to be restored for %s, got %d", what, len(dm.HostSecurityPolicies))
+		}
+	}
+
+	// refused without the migration
+	dm.restoreKubeArmorPolicies()
+	expectTampering("the cache without digests")
+
+	// trusted once with the migration
+	cfg.GlobalCfg.MigratePolicyCache = true
+	dm.restoreKubeArmorPolicies()
+
+	if len(dm.HostSecurityPolicies) != 1 || dm.HostSecurityPolicies[0].Metadata["policyName"] != "host-policy" {
+		t.Fatalf("[FAIL] Expected the migrated policy to be restored (%+v)", dm.HostSecurityPolicies)
+	}
+	select {
+	case alert := <-alerts:
+		t.Errorf("[FAIL] Unexpected alert for the migrated cache (%+v)", alert)
+	default:
+	}
+
+	// tampered after the removal of the digests, even with the migration enabled
+	dm.HostSecurityPolicies = []tp.HostSecurityPolicy{}
+	if err := os.RemoveAll(cfg.PolicyDigestDir); err != nil {
+		t.Fatalf("[FAIL] Failed to remove the digests (%s)", err.Error())
+	}
+	if err := os.WriteFile(cfg.PolicyDir+"host-policy.yaml", []byte(`{"metadata":{"policyName":"allow-all"}}`), 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to tamper with a policy (%s)", err.Error())
+	}
+
+	dm.restoreKubeArmorPolicies()
+	expectTampering("the cache without digests after the migration")
+
+	t.Log("[PASS] Restored the policy cache only when verified")
+}
+
+func TestSelfProtectionHostPolicy(t *testing.T) {
+	cfg.GlobalCfg.SelfProtection = true
+	defer func() { cfg.GlobalCfg.SelfProtection = false }()
+
+	if !isDefaultHostPolicy(tp.SelfProtectionPolicyName) {
+		t.Errorf("[FAIL] Expected the self-protection policy in the default host policies")
+	}
+
+	daemon, err := os.Executable()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to get the executable (%s)", err.Error())
+	}
+
+	policy := GetSelfProtectionHostPolicy()
+
+	for _, dir := range []string{cfg.PolicyDir, cfg.PolicyDigestDir} {
+		blocked, exempted := false, false
+		for _, rule := range policy.Spec.File.MatchDirectories {
+			if rule.Directory != dir {
+				continue
+			}
+			if len(rule.FromSource) == 0 && rule.ReadOnly && rule.Action == "" {
+				blocked = true
+			}
+			if len(rule.FromSource) == 1 && rule.FromSource[0].Path == daemon && !rule.ReadOnly && rule.Action == "Allow" {
+				exempted = true
+			}
+		}
+		if !blocked || !exempted {
+			t.Errorf("[FAIL] Expected %s to be read-only to the processes other than KubeArmor", dir)
+		}
+	}
+
+	t.Log("[PASS] Exempted KubeArmor from the self-protection policy")
+}
@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ======================= //
+// == Policy Maturation == //
+// ======================= //
+
+// interval of the checks of the maturation periods
+const policyMaturationInterval = 30 * time.Second
+
+// maturingPolicy Structure
+type maturingPolicy struct {
+	Kind          string
+	NamespaceName string
+	PolicyName    string
+	MaturesAt     time.Time
+}
+
+// PolicyMaturation Structure keeps the policies in their maturation period (kind/namespace/policy -> policy)
+type PolicyMaturation struct {
+	Policies map[string]maturingPolicy
+	Lock     *sync.Mutex
+}
+
+// NewPolicyMaturation Function
+func NewPolicyMaturation() *PolicyMaturation {
+	pm := &PolicyMaturation{}
+
+	pm.Policies = map[string]maturingPolicy{}
+	pm.Lock = new(sync.Mutex)
+
+	return pm
+}
+
+// maturationTime returns the time of the clock of the maturation periods
+func (dm *KubeArmorDaemon) maturationTime() time.Time {
+	if dm.Logger != nil && dm.Logger.Now != nil {
+		return dm.Logger.Now()
+	}
+	return time.Now()
+}
+
+// policyCreationTime returns the creation time of a policy, or, for the policies without one (e.g., received over
+// gRPC), the creation time of its previous version, so that updating the policy doesn't restart its maturation
+func policyCreationTime(created metav1.Time, previous map[string]string, now time.Time) time.Time {
+	if !created.IsZero() {
+		return created.Time
+	}
+
+	if creationTimestamp, err := time.Parse(time.RFC3339, previous["creationTimestamp"]); err == nil {
+		return creationTimestamp
+	}
+
+	return now
+}
+
+// hostPolicyMetadata returns the metadata of the host policy applied already (nil if none)
+func (dm *KubeArmorDaemon) hostPolicyMetadata(policyName string) map[string]string {
+	dm.HostSecurityPoliciesLock.RLock()
+	defer dm.HostSecurityPoliciesLock.RUnlock()
+
+	for _, policy := range dm.HostSecurityPolicies {
+		if policy.Metadata["policyName"] == policyName {
+			return policy.Metadata
+		}
+	}
+
+	return nil
+}
+
+// containerPolicyMetadata returns the metadata of the container policy applied already (nil if none)
+func (dm *KubeArmorDaemon) containerPolicyMetadata(namespaceName, policyName string) map[string]string {
+	dm.EndPointsLock.RLock()
+	defer dm.EndPointsLock.RUnlock()
+
+	for _, endPoint := range dm.EndPoints {
+		for _, policy := range endPoint.SecurityPolicies {
+			if policy.Metadata["namespaceName"] == namespaceName && policy.Metadata["policyName"] == policyName {
+				return policy.Metadata
+			}
+		}
+	}
+
+	return nil
+}
+
+// setPolicyMaturation keeps the creation time of a policy and the end of its maturation period in its metadata.
+// The period is measured from the creation of the policy, so restarting the daemon doesn't restart it.
+func setPolicyMaturation(metadata map[string]string, created time.Time, period string) error {
+	metadata["creationTimestamp"] = created.UTC().Format(time.RFC3339)
+
+	if period == "" {
+		return nil
+	}
+
+	duration, err := time.ParseDuration(period)
+	if err != nil || duration < 0 {
+		return fmt.Errorf("invalid maturationPeriod %q, set a duration (e.g., 24h)", period)
+	}
+
+	metadata["maturesAt"] = created.Add(duration).UTC().Format(time.RFC3339Nano)
+
+	return nil
+}
+
+// trackPolicyMaturation keeps a policy in its maturation period until the period elapses
+func (dm *KubeArmorDaemon) trackPolicyMaturation(kind, namespaceName, policyName string, metadata map[string]string, deleted bool) {
+	key := kind + "/" + namespaceName + "/" + policyName
+
+	dm.PolicyMaturation.Lock.Lock()
+	defer dm.PolicyMaturation.Lock.Unlock()
+
+	if deleted || !fd.PolicyMaturing(metadata, dm.maturationTime()) {
+		delete(dm.PolicyMaturation.Policies, key)
+		return
+	}
+
+	dm.PolicyMaturation.Policies[key] = maturingPolicy{
+		Kind:          kind,
+		NamespaceName: namespaceName,
+		PolicyName:    policyName,
+		MaturesAt:     fd.PolicyMaturesAt(metadata),
+	}
+}
+
+// matureSecurityPolicies enforces the Block rules of the policies whose maturation period elapsed,
+// and returns the policies matured
+func (dm *KubeArmorDaemon) matureSecurityPolicies() []string {
+	now := dm.maturationTime()
+
+	matured := []maturingPolicy{}
+
+	dm.PolicyMaturation.Lock.Lock()
+	for key, policy := range dm.PolicyMaturation.Policies {
+		if !now.Before(policy.MaturesAt) {
+			matured = append(matured, policy)
+			delete(dm.PolicyMaturation.Policies, key)
+		}
+	}
+	dm.PolicyMaturation.Lock.Unlock()
+
+	sort.Slice(matured, func(i, j int) bool {
+		return matured[i].MaturesAt.Before(matured[j].MaturesAt)
+	})
+
+	keys := []string{}
+
+	hostPolicies := false
+
+	for _, policy := range matured {
+		endpoints := []string{}
+
+		if policy.Kind == KubeArmorHostPolicyKind {
+			hostPolicies = true
+
+			dm.NodeLock.RLock()
+			endpoints = append(endpoints, dm.Node.NodeName)
+			dm.NodeLock.RUnlock()
+		} else {
+			endpoints = dm.reapplyPolicyEndPoints(policy.NamespaceName, policy.PolicyName)
+		}
+
+		dm.Logger.Printf("Matured a security policy (%s/%s/%s), enforcing its Block rules", policy.Kind, policy.NamespaceName, policy.PolicyName)
+		dm.Logger.PushPolicyEvent(policy.Kind, policy.NamespaceName, policy.PolicyName, fd.PolicyMatured, "maturation period elapsed", endpoints)
+
+		keys = append(keys, policy.Kind+"/"+policy.NamespaceName+"/"+policy.PolicyName)
+	}
+
+	if hostPolicies {
+		dm.UpdateHostSecurityPolicies()
+	}
+
+	return keys
+}
+
+// reapplyPolicyEndPoints applies the rules of the endpoints with a policy again, and returns the endpoints
+func (dm *KubeArmorDaemon) reapplyPolicyEndPoints(namespaceName, policyName string) []string {
+	endpoints := []string{}
+
+	dm.EndPointsLock.Lock()
+	defer dm.EndPointsLock.Unlock()
+
+	for idx, endPoint := range dm.EndPoints {
+		found := false
+		for _, policy := range endPoint.SecurityPolicies {
+			if policy.Metadata["namespaceName"] == namespaceName && policy.Metadata["policyName"] == policyName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+
+		endpoints = append(endpoints, endPoint.EndPointName)
+
+		if cfg.GlobalCfg.Policy {
+			dm.Logger.UpdateSecurityPolicies("MODIFIED", dm.EndPoints[idx])
+
+			if dm.RuntimeEnforcer != nil && dm.EndPoints[idx].PolicyEnabled == tp.KubeArmorPolicyEnabled {
+				dm.RuntimeEnforcer.UpdateSecurityPolicies(dm.EndPoints[idx])
+			}
+		}
+	}
+
+	return endpoints
+}
+
+// WatchPolicyMaturation enforces the Block rules of the policies once their maturation period elapses
+func (dm *KubeArmorDaemon) WatchPolicyMaturation() {
+	ticker := time.NewTicker(policyMaturationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-StopChan:
+			return
+		case <-ticker.C:
+			dm.matureSecurityPolicies()
+		}
+	}
+}
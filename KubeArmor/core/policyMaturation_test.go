@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// getMatchedActions returns the actions of the rules matched for the endpoint
+func getMatchedActions(dm *KubeArmorDaemon) []string {
+	dm.Logger.SecurityPoliciesLock.RLock()
+	defer dm.Logger.SecurityPoliciesLock.RUnlock()
+
+	actions := []string{}
+	for _, policy := range dm.Logger.SecurityPolicies["web_frontend"].Policies {
+		actions = append(actions, policy.Action)
+	}
+	return actions
+}
+
+func TestPolicyMaturation(t *testing.T) {
+	prevPolicy := cfg.GlobalCfg.Policy
+	defer func() { cfg.GlobalCfg.Policy = prevPolicy }()
+	cfg.GlobalCfg.Policy = true
+
+	created := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := created.Add(time.Hour)
+
+	policy := newOrderedPolicy("uid-1", "10", "/bin/sh")
+	policy.CreationTimestamp = metav1.NewTime(created)
+	policy.Spec.MaturationPeriod = "24h"
+
+	dm := newPolicyOrderDaemon()
+	dm.Logger.Now = func() time.Time { return now }
+
+	events := make(chan *pb.PolicyEvent, 16)
+	fd.PolicyEventStructs = map[string]fd.PolicyEventStruct{"test": {Filter: "all", Broadcast: events}}
+	defer func() { fd.PolicyEventStructs = map[string]fd.PolicyEventStruct{} }()
+
+	deliverPolicyEvents(dm.kubeArmorPolicyEventHandler(), []watch.Event{{Type: watch.Added, Object: policy}})
+
+	// the Block rules are audited during the maturation period
+	if actions := getMatchedActions(dm); len(actions) != 1 || actions[0] != fd.MaturingBlockAction {
+		t.Fatalf("[FAIL] Expected the Block rule to be audited (%v)", actions)
+	}
+	if matured := dm.matureSecurityPolicies(); len(matured) != 0 {
+		t.Errorf("[FAIL] Expected no policy to mature yet (%v)", matured)
+	}
+
+	// the period is measured from the creation of the policy, so a restart doesn't restart it
+	restarted := newPolicyOrderDaemon()
+	restarted.Logger.Now = func() time.Time { return now }
+	fd.PolicyEventStructs = map[string]fd.PolicyEventStruct{"test": {Filter: "all", Broadcast: events}}
+
+	deliverPolicyEvents(restarted.kubeArmorPolicyEventHandler(), []watch.Event{{Type: watch.Added, Object: policy}})
+
+	if maturing := restarted.PolicyMaturation.Policies["KubeArmorPolicy/web/block-shell"]; !maturing.MaturesAt.Equal(created.Add(24 * time.Hour)) {
+		t.Errorf("[FAIL] Expected the policy to mature 24h after its creation (%v)", maturing.MaturesAt)
+	}
+
+	for len(events) > 0 {
+		<-events
+	}
+
+	// the Block rules are enforced once the period elapses
+	now = created.Add(24 * time.Hour)
+
+	if matured := restarted.matureSecurityPolicies(); len(matured) != 1 || matured[0] != "KubeArmorPolicy/web/block-shell" {
+		t.Fatalf("[FAIL] Expected the policy to mature (%v)", matured)
+	}
+	if actions := getMatchedActions(restarted); len(actions) != 1 || actions[0] != "Block" {
+		t.Errorf("[FAIL] Expected the Block rule to be enforced (%v)", actions)
+	}
+
+	select {
+	case event := <-events:
+		if event.Action != fd.PolicyMatured || event.PolicyName != "block-shell" || len(event.Endpoints) != 1 || event.Endpoints[0] != "frontend" {
+			t.Errorf("[FAIL] Unexpected policy event (%+v)", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("[FAIL] Expected a policy event of the maturation")
+	}
+
+	// an invalid period is rejected
+	policy.Spec.MaturationPeriod = "1d"
+	if _, err := dm.CreateSecurityPolicy(*policy); err == nil || err.Error() != `invalid maturationPeriod "1d", set a duration (e.g., 24h)` {
+		t.Errorf("[FAIL] Expected the policy to be rejected (%v)", err)
+	}
+
+	t.Log("[PASS] Audited the Block rules during the maturation period")
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"sync"
+	"testing"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+// scrapePolicyMetrics returns the number of observations per policy/stage in the registry
+func scrapePolicyMetrics(t *testing.T, pm *fd.PolicyMetrics) map[string]uint64 {
+	families, err := pm.Registry.Gather()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to scrape the metrics (%s)", err.Error())
+	}
+
+	counts := map[string]uint64{}
+	for _, family := range families {
+		if family.GetName() != "kubearmor_policy_apply_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["enforcer"] != "AppArmor" {
+				t.Errorf("[FAIL] Unexpected enforcer label (%s)", labels["enforcer"])
+			}
+			counts[labels["policy"]+"/"+labels["stage"]] += metric.GetHistogram().GetSampleCount()
+		}
+	}
+
+	return counts
+}
+
+func TestPolicyMetrics(t *testing.T) {
+	prevPolicy := cfg.GlobalCfg.Policy
+	defer func() { cfg.GlobalCfg.Policy = prevPolicy }()
+	cfg.GlobalCfg.Policy = true
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	fd.PolicyEventStructs = map[string]fd.PolicyEventStruct{}
+	fd.AppliedPolicies = map[string]*pb.PolicyEvent{}
+	fd.PolicyEventLock = new(sync.RWMutex)
+
+	dm := NewKubeArmorDaemon()
+	dm.Logger = &fd.Feeder{Node: &tp.Node{}, Enforcer: "AppArmor"}
+	dm.Logger.SecurityPolicies = map[string]tp.MatchPolicies{}
+	dm.Logger.SecurityPoliciesLock = new(sync.RWMutex)
+	dm.Logger.DefaultPostures = map[string]tp.DefaultPosture{}
+	dm.Logger.EndPointPostures = map[string]tp.DefaultPosture{}
+	dm.Logger.DefaultPosturesLock = new(sync.Mutex)
+
+	// up to two policies are tracked
+	dm.Logger.PolicyMetrics = fd.NewPolicyMetrics(2)
+
+	dm.EndPoints = []tp.EndPoint{{
+		NamespaceName: "web",
+		EndPointName:  "frontend",
+		Identities:    []string{"namespaceName=web"},
+		PolicyEnabled: tp.KubeArmorPolicyEnabled,
+	}}
+
+	newPolicy := func(name string) tp.SecurityPolicy {
+		policy := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "web", "policyName": name}}
+		policy.Spec.Selector.Identities = []string{"namespaceName=web"}
+		policy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Block"}}
+		return policy
+	}
+
+	for _, name := range []string{"block-sh", "block-curl", "block-wget", "block-nc"} {
+		dm.UpdateSecurityPolicy("ADDED", newPolicy(name))
+	}
+	dm.UpdateSecurityPolicy("MODIFIED", newPolicy("block-sh"))
+
+	counts := scrapePolicyMetrics(t, dm.Logger.PolicyMetrics)
+
+	for key, expected := range map[string]uint64{
+		"web/block-sh/generate":     2,
+		"web/block-sh/load":         2,
+		"web/block-sh/total":        2,
+		"web/block-curl/total":      1,
+		fd.OtherPolicies + "/total": 2,
+	} {
+		if counts[key] != expected {
+			t.Errorf("[FAIL] Unexpected number of observations of %s (%d, expected %d)", key, counts[key], expected)
+		}
+	}
+
+	if len(counts) != 9 {
+		t.Errorf("[FAIL] Expected the policies beyond the limit to be tracked as %q (%v)", fd.OtherPolicies, counts)
+	}
+
+	// the last application is reported in the policy status
+	lastApply := dm.Logger.PolicyMetrics.GetLastApplyDuration("web", "block-sh")
+	if event := fd.AppliedPolicies[KubeArmorPolicyKind+"/web/block-sh"]; lastApply <= 0 || event == nil || event.LastApplyDuration != lastApply.Microseconds() {
+		t.Errorf("[FAIL] Expected the last apply duration in the policy status (%v)", event)
+	}
+
+	// the deleted policies free their slots
+	dm.UpdateSecurityPolicy("DELETED", newPolicy("block-curl"))
+	dm.UpdateSecurityPolicy("MODIFIED", newPolicy("block-nc"))
+
+	counts = scrapePolicyMetrics(t, dm.Logger.PolicyMetrics)
+
+	if _, ok := counts["web/block-curl/total"]; ok {
+		t.Errorf("[FAIL] Unexpected metrics of a deleted policy")
+	}
+	if counts["web/block-nc/total"] != 1 {
+		t.Errorf("[FAIL] Expected a policy to be tracked once a slot is freed (%v)", counts)
+	}
+
+	t.Log("[PASS] Recorded the time spent on applying the policies")
+}
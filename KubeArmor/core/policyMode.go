@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"fmt"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ================= //
+// == Policy Mode == //
+// ================= //
+
+// validatePolicyMode rejects the modes other than Audit and Enforce (Enforce if empty)
+func validatePolicyMode(mode string) error {
+	if mode != "" && mode != tp.PolicyModeAudit && mode != tp.PolicyModeEnforce {
+		return fmt.Errorf("invalid mode %q, expected %s or %s", mode, tp.PolicyModeAudit, tp.PolicyModeEnforce)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"testing"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestPolicyMode(t *testing.T) {
+	prevPolicy := cfg.GlobalCfg.Policy
+	defer func() { cfg.GlobalCfg.Policy = prevPolicy }()
+	cfg.GlobalCfg.Policy = true
+
+	policy := newOrderedPolicy("uid-1", "10", "/bin/sh")
+	policy.Spec.Mode = tp.PolicyModeAudit
+
+	dm := newPolicyOrderDaemon()
+	handler := dm.kubeArmorPolicyEventHandler()
+
+	deliverPolicyEvents(handler, []watch.Event{{Type: watch.Added, Object: policy}})
+
+	// the Block rules are audited and flagged as would-be-blocked
+	if actions := getMatchedActions(dm); len(actions) != 1 || actions[0] != "Audit (Block)" {
+		t.Fatalf("[FAIL] Expected the Block rule to be audited (%v)", actions)
+	}
+	if match := dm.Logger.SecurityPolicies["web_frontend"].Policies[0]; !match.WouldBeBlocked || match.PolicyName != "block-shell" {
+		t.Errorf("[FAIL] Expected the match to be flagged as would-be-blocked (%+v)", match)
+	}
+	if enforced := dm.Logger.EnforcedSecurityPolicies(dm.EndPoints[0].SecurityPolicies); len(enforced) != 0 {
+		t.Errorf("[FAIL] Expected the policy not to be enforced (%d)", len(enforced))
+	}
+
+	// switching to Enforce regenerates the rules in place
+	enforced := newOrderedPolicy("uid-1", "11", "/bin/sh")
+	enforced.Generation = 2
+	enforced.Spec.Mode = tp.PolicyModeEnforce
+
+	handler.OnUpdate(policy, enforced)
+
+	if actions := getMatchedActions(dm); len(actions) != 1 || actions[0] != "Block" {
+		t.Fatalf("[FAIL] Expected the Block rule to be enforced (%v)", actions)
+	}
+	if match := dm.Logger.SecurityPolicies["web_frontend"].Policies[0]; match.WouldBeBlocked {
+		t.Errorf("[FAIL] Expected the match not to be flagged (%+v)", match)
+	}
+	if enforced := dm.Logger.EnforcedSecurityPolicies(dm.EndPoints[0].SecurityPolicies); len(enforced) != 1 {
+		t.Errorf("[FAIL] Expected the policy to be enforced (%d)", len(enforced))
+	}
+
+	// an invalid mode is rejected
+	policy.Spec.Mode = "DryRun"
+	if _, err := dm.CreateSecurityPolicy(*policy); err == nil || err.Error() != `invalid mode "DryRun", expected Audit or Enforce` {
+		t.Errorf("[FAIL] Expected the policy to be rejected (%v)", err)
+	}
+
+	t.Log("[PASS] Audited the Block rules of the policies in Audit mode")
+}
@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ================== //
+// == Policy Order == //
+// ================== //
+
+// policyReconcileWindow is the time after a delete within which a new add of the same policy is checked again
+var policyReconcileWindow = 5 * time.Second
+
+// getKubeArmorPolicy fetches a policy from the API server (nil if it doesn't exist)
+var getKubeArmorPolicy = func(namespaceName, policyName string) (*ksp.KubeArmorPolicy, error) {
+	policy, err := K8s.KSPClient.SecurityV1().KubeArmorPolicies(namespaceName).Get(context.Background(), policyName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	return policy, err
+}
+
+// policyVersion Structure
+type policyVersion struct {
+	UID             string
+	ResourceVersion string
+	Generation      int64
+
+	Deleted   bool
+	DeletedAt time.Time
+}
+
+// PolicyOrder keeps the last applied version of each policy, so that the events delivered out of order are ignored
+type PolicyOrder struct {
+	// namespace/policy -> last applied version
+	versions map[string]policyVersion
+
+	// namespace/policy -> lock serializing the updates of the policy
+	keyLocks map[string]*sync.Mutex
+
+	lock *sync.Mutex
+}
+
+// NewPolicyOrder Function
+func NewPolicyOrder() *PolicyOrder {
+	po := &PolicyOrder{}
+
+	po.versions = map[string]policyVersion{}
+	po.keyLocks = map[string]*sync.Mutex{}
+
+	po.lock = new(sync.Mutex)
+
+	return po
+}
+
+// LockPolicy serializes the updates of a policy, and returns the function to unlock it
+func (po *PolicyOrder) LockPolicy(key string) func() {
+	po.lock.Lock()
+	keyLock, ok := po.keyLocks[key]
+	if !ok {
+		keyLock = new(sync.Mutex)
+		po.keyLocks[key] = keyLock
+	}
+	po.lock.Unlock()
+
+	keyLock.Lock()
+	return keyLock.Unlock
+}
+
+// compareResourceVersions compares two resourceVersions (0 if they can't be compared)
+func compareResourceVersions(a, b string) int {
+	va, errA := strconv.ParseUint(a, 10, 64)
+	vb, errB := strconv.ParseUint(b, 10, 64)
+	if errA != nil || errB != nil || va == vb {
+		return 0
+	}
+	if va < vb {
+		return -1
+	}
+	return 1
+}
+
+// isOlder checks if an object is older than the last applied version of the same object
+func (v policyVersion) isOlder(meta metav1.ObjectMeta) bool {
+	if v.Generation != 0 && meta.Generation != 0 && meta.Generation != v.Generation {
+		return meta.Generation < v.Generation
+	}
+	return compareResourceVersions(meta.ResourceVersion, v.ResourceVersion) < 0
+}
+
+// CheckEvent checks if an event of a policy is to be applied (otherwise, it returns why it is discarded),
+// and if the policy is to be fetched again once the events around its recreation are settled
+// (the policy should be locked by LockPolicy)
+func (po *PolicyOrder) CheckEvent(key, action string, meta metav1.ObjectMeta) (bool, string, bool) {
+	po.lock.Lock()
+	defer po.lock.Unlock()
+
+	last, ok := po.versions[key]
+
+	reconcile := false
+
+	if ok {
+		sameObject := last.UID == "" || meta.UID == "" || last.UID == string(meta.UID)
+
+		switch action {
+		case "ADDED", "MODIFIED":
+			if sameObject && last.Deleted {
+				return false, "the policy was deleted already", false
+			}
+			if sameObject && last.isOlder(meta) {
+				return false, "an older resourceVersion (" + meta.ResourceVersion + " < " + last.ResourceVersion + ")", false
+			}
+			if !sameObject && !last.Deleted && compareResourceVersions(meta.ResourceVersion, last.ResourceVersion) < 0 {
+				return false, "a previous instance of the policy (" + meta.ResourceVersion + " < " + last.ResourceVersion + ")", false
+			}
+
+			// recreated right after being deleted
+			reconcile = last.Deleted && time.Since(last.DeletedAt) < policyReconcileWindow
+		case "DELETED":
+			if !sameObject && !last.Deleted {
+				return false, "a previous instance of the policy (the policy was recreated)", false
+			}
+		}
+	}
+
+	version := policyVersion{UID: string(meta.UID), ResourceVersion: meta.ResourceVersion, Generation: meta.Generation}
+	if action == "DELETED" {
+		version.Deleted = true
+		version.DeletedAt = time.Now()
+	}
+	po.versions[key] = version
+
+	return true, "", reconcile
+}
+
+// IsApplied checks if an object is the last applied version of a policy
+func (po *PolicyOrder) IsApplied(key string, meta metav1.ObjectMeta) bool {
+	po.lock.Lock()
+	defer po.lock.Unlock()
+
+	last, ok := po.versions[key]
+	return ok && !last.Deleted && last.UID == string(meta.UID) && last.ResourceVersion == meta.ResourceVersion
+}
+
+// IsDeleted checks if a policy is deleted (or was never applied)
+func (po *PolicyOrder) IsDeleted(key string) bool {
+	po.lock.Lock()
+	defer po.lock.Unlock()
+
+	last, ok := po.versions[key]
+	return !ok || last.Deleted
+}
+
+// reconcileSecurityPolicy fetches a policy again, and applies it if the final state differs from the applied one
+func (dm *KubeArmorDaemon) reconcileSecurityPolicy(namespaceName, policyName string) {
+	key := namespaceName + "/" + policyName
+
+	policy, err := getKubeArmorPolicy(namespaceName, policyName)
+	if err != nil {
+		dm.Logger.Warnf("Failed to fetch a Security Policy to reconcile (%s, %s)", key, err.Error())
+		return
+	}
+
+	if policy == nil {
+		unlock := dm.PolicyOrder.LockPolicy(key)
+		defer unlock()
+
+		if dm.PolicyOrder.IsDeleted(key) {
+			return
+		}
+
+		dm.Logger.Warnf("Reconciled a Security Policy (%s, deleted after its recreation)", key)
+
+		dm.PolicyOrder.forget(key)
+		if secPolicy, ok := dm.removeSecurityPolicy(namespaceName, policyName); ok {
+			dm.UpdateSecurityPolicy("DELETED", secPolicy)
+		}
+		return
+	}
+
+	if dm.PolicyOrder.IsApplied(key, policy.ObjectMeta) {
+		return
+	}
+
+	dm.Logger.Warnf("Reconciled a Security Policy (%s, resourceVersion %s)", key, policy.ResourceVersion)
+	dm.addKubeArmorPolicy(policy)
+}
+
+// forget marks a policy as deleted without any event
+func (po *PolicyOrder) forget(key string) {
+	po.lock.Lock()
+	defer po.lock.Unlock()
+
+	last := po.versions[key]
+	last.Deleted = true
+	last.DeletedAt = time.Now()
+	po.versions[key] = last
+}
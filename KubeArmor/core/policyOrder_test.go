@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// deliverPolicyEvents delivers the events of a fake watcher to the handler of the security policies
+func deliverPolicyEvents(handler cache.ResourceEventHandlerFuncs, events []watch.Event) {
+	watcher := watch.NewFakeWithChanSize(len(events), false)
+	for _, event := range events {
+		watcher.Action(event.Type, event.Object)
+	}
+	watcher.Stop()
+
+	for event := range watcher.ResultChan() {
+		switch event.Type {
+		case watch.Added:
+			handler.OnAdd(event.Object)
+		case watch.Deleted:
+			handler.OnDelete(event.Object)
+		}
+	}
+}
+
+// newPolicyOrderDaemon returns a daemon with an endpoint in the web namespace
+func newPolicyOrderDaemon() *KubeArmorDaemon {
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	fd.PolicyEventStructs = map[string]fd.PolicyEventStruct{}
+	fd.AppliedPolicies = map[string]*pb.PolicyEvent{}
+	fd.PolicyEventLock = new(sync.RWMutex)
+
+	dm := NewKubeArmorDaemon()
+	dm.Logger = &fd.Feeder{Node: &tp.Node{}}
+	dm.Logger.SecurityPolicies = map[string]tp.MatchPolicies{}
+	dm.Logger.SecurityPoliciesLock = new(sync.RWMutex)
+	dm.Logger.DefaultPostures = map[string]tp.DefaultPosture{}
+	dm.Logger.EndPointPostures = map[string]tp.DefaultPosture{}
+	dm.Logger.DefaultPosturesLock = new(sync.Mutex)
+
+	dm.EndPoints = []tp.EndPoint{{
+		NamespaceName: "web",
+		EndPointName:  "frontend",
+		Identities:    []string{"namespaceName=web"},
+		PolicyEnabled: tp.KubeArmorPolicyEnabled,
+	}}
+
+	return dm
+}
+
+// newOrderedPolicy returns a version of the block-shell policy
+func newOrderedPolicy(uid, resourceVersion, path string) *ksp.KubeArmorPolicy {
+	policy := &ksp.KubeArmorPolicy{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       "web",
+		Name:            "block-shell",
+		UID:             types.UID(uid),
+		ResourceVersion: resourceVersion,
+		Generation:      1,
+	}}
+	policy.Spec.Action = "Block"
+	policy.Spec.Process.MatchPaths = []ksp.ProcessPathType{{Path: ksp.MatchPathType(path)}}
+	return policy
+}
+
+// getAppliedPaths returns the process paths of the policies of the endpoint
+func getAppliedPaths(dm *KubeArmorDaemon) []string {
+	dm.EndPointsLock.RLock()
+	defer dm.EndPointsLock.RUnlock()
+
+	paths := []string{}
+	for _, policy := range dm.EndPoints[0].SecurityPolicies {
+		for _, path := range policy.Spec.Process.MatchPaths {
+			paths = append(paths, path.Path)
+		}
+	}
+	return paths
+}
+
+func TestPolicyOrder(t *testing.T) {
+	prevPolicy, prevGet, prevWindow := cfg.GlobalCfg.Policy, getKubeArmorPolicy, policyReconcileWindow
+	defer func() {
+		cfg.GlobalCfg.Policy, getKubeArmorPolicy, policyReconcileWindow = prevPolicy, prevGet, prevWindow
+	}()
+	cfg.GlobalCfg.Policy = true
+
+	v1 := newOrderedPolicy("uid-1", "10", "/bin/sh")
+	v2 := newOrderedPolicy("uid-2", "12", "/bin/bash")
+
+	// the policy is recreated, and add(v2) is delivered before delete(v1)
+	dm := newPolicyOrderDaemon()
+	deliverPolicyEvents(dm.kubeArmorPolicyEventHandler(), []watch.Event{
+		{Type: watch.Added, Object: v1},
+		{Type: watch.Added, Object: v2},
+		{Type: watch.Deleted, Object: v1},
+	})
+
+	if paths := getAppliedPaths(dm); len(paths) != 1 || paths[0] != "/bin/bash" {
+		t.Fatalf("[FAIL] Expected the rules of v2 to remain (%v)", paths)
+	}
+	if len(dm.SecurityPolicies) != 1 || dm.SecurityPolicies[0].Spec.Process.MatchPaths[0].Path != "/bin/bash" {
+		t.Errorf("[FAIL] Expected v2 to remain in the security policies (%+v)", dm.SecurityPolicies)
+	}
+
+	// the events of the previous versions of the same object are ignored
+	v2old := newOrderedPolicy("uid-2", "11", "/bin/dash")
+	deliverPolicyEvents(dm.kubeArmorPolicyEventHandler(), []watch.Event{{Type: watch.Added, Object: v2old}})
+
+	if paths := getAppliedPaths(dm); len(paths) != 1 || paths[0] != "/bin/bash" {
+		t.Errorf("[FAIL] Expected a stale add to be ignored (%v)", paths)
+	}
+
+	t.Log("[PASS] Ignored the out-of-order events of a recreated policy")
+}
+
+func TestPolicyReconcile(t *testing.T) {
+	prevPolicy, prevGet, prevWindow := cfg.GlobalCfg.Policy, getKubeArmorPolicy, policyReconcileWindow
+	defer func() {
+		cfg.GlobalCfg.Policy, getKubeArmorPolicy, policyReconcileWindow = prevPolicy, prevGet, prevWindow
+	}()
+	cfg.GlobalCfg.Policy = true
+	policyReconcileWindow = 10 * time.Millisecond
+
+	// the final state of the policy in the API server
+	current := newOrderedPolicy("uid-3", "22", "/usr/bin/wget")
+	currentLock := new(sync.Mutex)
+	fetched := make(chan struct{}, 1)
+	getKubeArmorPolicy = func(namespaceName, policyName string) (*ksp.KubeArmorPolicy, error) {
+		currentLock.Lock()
+		defer currentLock.Unlock()
+		defer func() { fetched <- struct{}{} }()
+		return current, nil
+	}
+
+	// the policy is deleted and added again, and then updated while the watch misses the event
+	dm := newPolicyOrderDaemon()
+	deliverPolicyEvents(dm.kubeArmorPolicyEventHandler(), []watch.Event{
+		{Type: watch.Added, Object: newOrderedPolicy("uid-1", "10", "/bin/sh")},
+		{Type: watch.Deleted, Object: newOrderedPolicy("uid-1", "11", "/bin/sh")},
+		{Type: watch.Added, Object: newOrderedPolicy("uid-3", "20", "/usr/bin/curl")},
+	})
+
+	select {
+	case <-fetched:
+	case <-time.After(5 * time.Second):
+		t.Fatal("[FAIL] Expected the recreated policy to be fetched again")
+	}
+
+	paths := getAppliedPaths(dm)
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); paths = getAppliedPaths(dm) {
+		if len(paths) == 1 && paths[0] == "/usr/bin/wget" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(paths) != 1 || paths[0] != "/usr/bin/wget" {
+		t.Fatalf("[FAIL] Expected the final state of the policy to be applied (%v)", paths)
+	}
+
+	// the policy is gone in the end
+	currentLock.Lock()
+	current = nil
+	currentLock.Unlock()
+
+	dm.reconcileSecurityPolicy("web", "block-shell")
+	<-fetched
+
+	if paths := getAppliedPaths(dm); len(paths) != 0 || len(dm.SecurityPolicies) != 0 {
+		t.Errorf("[FAIL] Expected the deleted policy to be removed (%v)", paths)
+	}
+
+	t.Log("[PASS] Reconciled a policy recreated right after being deleted")
+}
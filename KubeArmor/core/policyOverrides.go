@@ -0,0 +1,530 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// ====================== //
+// == Policy Overrides == //
+// ====================== //
+
+// PolicyOverrideTTLAnnotation is the annotation of the time to live of each policy of the override file (mandatory)
+const PolicyOverrideTTLAnnotation = "kubearmor.io/override-ttl"
+
+// PolicyOverridePrefix is prepended to the names of the overrides, so that they don't replace the policies of the
+// same name
+const PolicyOverridePrefix = "kubearmor-override-"
+
+// interval of the checks of the expiry of the overrides
+var policyOverrideInterval = 10 * time.Second
+
+// time the override file is given to settle after a change (e.g., written by an editor) before it's read
+var policyOverrideDebounce = 500 * time.Millisecond
+
+// policyOverride Structure
+type policyOverride struct {
+	Kind          string
+	NamespaceName string
+	PolicyName    string
+
+	// digest of the policy in the file, and the end of its TTL
+	Digest    string
+	ExpiresAt time.Time
+
+	secPolicy  ksp.KubeArmorPolicy
+	hostPolicy tp.K8sKubeArmorHostPolicy
+}
+
+// key returns the key of an override (kind/namespace/policy)
+func (po policyOverride) key() string {
+	return po.Kind + "/" + po.NamespaceName + "/" + po.PolicyName
+}
+
+// policyOverrideState Structure is the persisted expiry of an override, which a restart doesn't extend
+type policyOverrideState struct {
+	Digest    string    `json:"digest"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// PolicyOverrides Structure keeps the overrides of the node-local override file
+type PolicyOverrides struct {
+	// kind/namespace/policy -> override applied
+	Applied map[string]policyOverride
+
+	// kind/namespace/policy -> persisted state of the overrides in the file (applied or expired)
+	State map[string]policyOverrideState
+
+	// serializes the reloads of the file and the expiries
+	Lock *sync.Mutex
+
+	// kind/namespace/policy -> expiry of the overrides, read when the policies are created
+	expiries     map[string]time.Time
+	expiriesLock *sync.RWMutex
+}
+
+// NewPolicyOverrides Function
+func NewPolicyOverrides() *PolicyOverrides {
+	po := &PolicyOverrides{}
+
+	po.Applied = map[string]policyOverride{}
+	po.State = map[string]policyOverrideState{}
+	po.Lock = new(sync.Mutex)
+
+	po.expiries = map[string]time.Time{}
+	po.expiriesLock = new(sync.RWMutex)
+
+	return po
+}
+
+// policyOverrideExpiry returns the expiry of a policy if it's an override
+func (dm *KubeArmorDaemon) policyOverrideExpiry(kind, namespaceName, policyName string) (time.Time, bool) {
+	dm.PolicyOverrides.expiriesLock.RLock()
+	defer dm.PolicyOverrides.expiriesLock.RUnlock()
+
+	expiresAt, ok := dm.PolicyOverrides.expiries[kind+"/"+namespaceName+"/"+policyName]
+	return expiresAt, ok
+}
+
+// setPolicyOverride marks an override in the metadata of its policy, which gives its rules the highest priority
+func (dm *KubeArmorDaemon) setPolicyOverride(metadata map[string]string, kind, namespaceName, policyName string) {
+	if expiresAt, ok := dm.policyOverrideExpiry(kind, namespaceName, policyName); ok {
+		metadata["overrideExpiresAt"] = expiresAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// policyDigest returns the digest of a policy in the override file
+func policyDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// parsePolicyOverride parses a policy of the override file changed at the given time. The TTL runs from the last
+// change of the file, but a policy kept unchanged never gets a later expiry than the persisted one.
+func parsePolicyOverride(data []byte, modTime time.Time, state map[string]policyOverrideState) (policyOverride, error) {
+	override := policyOverride{Digest: policyDigest(data)}
+
+	object := struct {
+		Kind     string            `json:"kind"`
+		Metadata metav1.ObjectMeta `json:"metadata"`
+	}{}
+
+	if err := json.Unmarshal(data, &object); err != nil {
+		return override, err
+	}
+
+	override.Kind = object.Kind
+	override.NamespaceName = object.Metadata.Namespace
+
+	if object.Metadata.Name == "" {
+		return override, errors.New("missing metadata.name")
+	}
+	override.PolicyName = PolicyOverridePrefix + object.Metadata.Name
+
+	ttl, ok := object.Metadata.Annotations[PolicyOverrideTTLAnnotation]
+	if !ok {
+		return override, fmt.Errorf("missing the %s annotation, overrides need a TTL", PolicyOverrideTTLAnnotation)
+	}
+
+	duration, err := time.ParseDuration(ttl)
+	if err != nil || duration <= 0 {
+		return override, fmt.Errorf("invalid %s %q, set a duration (e.g., 30m)", PolicyOverrideTTLAnnotation, ttl)
+	}
+
+	switch object.Kind {
+	case KubeArmorPolicyKind:
+		if override.NamespaceName == "" {
+			return override, errors.New("missing metadata.namespace")
+		}
+		if err := json.Unmarshal(data, &override.secPolicy); err != nil {
+			return override, err
+		}
+		override.secPolicy.Name = override.PolicyName
+		override.secPolicy.CreationTimestamp = metav1.NewTime(modTime)
+
+	case KubeArmorHostPolicyKind:
+		override.NamespaceName = ""
+		if err := json.Unmarshal(data, &override.hostPolicy); err != nil {
+			return override, err
+		}
+		override.hostPolicy.Metadata.Name = override.PolicyName
+		override.hostPolicy.Metadata.CreationTimestamp = metav1.NewTime(modTime)
+
+	default:
+		return override, fmt.Errorf("unsupported kind %q, set %s or %s", object.Kind, KubeArmorPolicyKind, KubeArmorHostPolicyKind)
+	}
+
+	override.ExpiresAt = modTime.Add(duration)
+	if prev, ok := state[override.key()]; ok && prev.Digest == override.Digest && prev.ExpiresAt.Before(override.ExpiresAt) {
+		override.ExpiresAt = prev.ExpiresAt
+	}
+
+	return override, nil
+}
+
+// rejectedPolicyOverride Structure
+type rejectedPolicyOverride struct {
+	Override policyOverride
+	Err      error
+}
+
+// readPolicyOverrides reads the policies of the override file, and returns the valid ones and the rejected ones
+func readPolicyOverrides(path string, state map[string]policyOverrideState) ([]policyOverride, []rejectedPolicyOverride, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	overrides := []policyOverride{}
+	rejected := []rejectedPolicyOverride{}
+
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+
+	for {
+		document := json.RawMessage{}
+		if err := decoder.Decode(&document); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		if len(document) == 0 || string(document) == "null" {
+			continue
+		}
+
+		override, err := parsePolicyOverride(document, info.ModTime(), state)
+		if err != nil {
+			rejected = append(rejected, rejectedPolicyOverride{Override: override, Err: err})
+			continue
+		}
+
+		overrides = append(overrides, override)
+	}
+
+	return overrides, rejected, nil
+}
+
+// loadPolicyOverrideState reads the persisted expiries of the overrides
+func loadPolicyOverrideState(path string) map[string]policyOverrideState {
+	state := map[string]policyOverrideState{}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return state
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return map[string]policyOverrideState{}
+	}
+
+	return state
+}
+
+// savePolicyOverrideState persists the expiries of the overrides (Lock should be held)
+func (dm *KubeArmorDaemon) savePolicyOverrideState() {
+	data, err := json.Marshal(dm.PolicyOverrides.State)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.PolicyOverrideStatePath), 0700); err != nil {
+		dm.Logger.Warnf("Failed to save the state of the policy overrides (%s)", err.Error())
+		return
+	}
+
+	if err := os.WriteFile(cfg.PolicyOverrideStatePath, data, 0600); err != nil {
+		dm.Logger.Warnf("Failed to save the state of the policy overrides (%s)", err.Error())
+	}
+}
+
+// applyPolicyOverride applies an override as a policy with the highest priority (Lock should be held)
+func (dm *KubeArmorDaemon) applyPolicyOverride(override policyOverride) error {
+	key := override.key()
+
+	dm.PolicyOverrides.expiriesLock.Lock()
+	dm.PolicyOverrides.expiries[key] = override.ExpiresAt
+	dm.PolicyOverrides.expiriesLock.Unlock()
+
+	_, modified := dm.PolicyOverrides.Applied[key]
+
+	endpoints := []string{}
+
+	if override.Kind == KubeArmorHostPolicyKind {
+		event := tp.K8sKubeArmorHostPolicyEvent{Type: "ADDED", Object: override.hostPolicy}
+		if modified {
+			event.Type = "MODIFIED"
+		}
+
+		if status := dm.ParseAndUpdateHostSecurityPolicy(event); status != pb.PolicyStatus_Applied && status != pb.PolicyStatus_Modified {
+			dm.forgetPolicyOverride(key)
+			return fmt.Errorf("host policy %s", status.String())
+		}
+
+		dm.NodeLock.RLock()
+		endpoints = append(endpoints, dm.Node.NodeName)
+		dm.NodeLock.RUnlock()
+	} else {
+		secPolicy, err := dm.CreateSecurityPolicy(override.secPolicy)
+		if err != nil {
+			dm.forgetPolicyOverride(key)
+			return err
+		}
+
+		action := "MODIFIED"
+		if dm.upsertSecurityPolicy(secPolicy) {
+			action = "ADDED"
+		}
+
+		dm.UpdateSecurityPolicy(action, secPolicy)
+
+		endpoints = dm.getPolicyEndpoints(override.NamespaceName, override.PolicyName)
+	}
+
+	dm.PolicyOverrides.Applied[key] = override
+
+	dm.Logger.Printf("Applied a policy override (%s, expires at %s)", key, override.ExpiresAt.UTC().Format(time.RFC3339))
+
+	dm.Logger.PushPolicyOverrideTransition(fd.PolicyOverrideTransition{
+		Kind:          override.Kind,
+		NamespaceName: override.NamespaceName,
+		PolicyName:    override.PolicyName,
+		ExpiresAt:     override.ExpiresAt,
+		Endpoints:     endpoints,
+	})
+
+	return nil
+}
+
+// forgetPolicyOverride Function
+func (dm *KubeArmorDaemon) forgetPolicyOverride(key string) {
+	dm.PolicyOverrides.expiriesLock.Lock()
+	delete(dm.PolicyOverrides.expiries, key)
+	dm.PolicyOverrides.expiriesLock.Unlock()
+}
+
+// removePolicyOverride removes an override which expired or was removed from the file (Lock should be held)
+func (dm *KubeArmorDaemon) removePolicyOverride(override policyOverride, reason string) {
+	key := override.key()
+
+	endpoints := []string{}
+
+	if override.Kind == KubeArmorHostPolicyKind {
+		dm.ParseAndUpdateHostSecurityPolicy(tp.K8sKubeArmorHostPolicyEvent{Type: "DELETED", Object: override.hostPolicy})
+
+		dm.NodeLock.RLock()
+		endpoints = append(endpoints, dm.Node.NodeName)
+		dm.NodeLock.RUnlock()
+	} else {
+		endpoints = dm.getPolicyEndpoints(override.NamespaceName, override.PolicyName)
+
+		if secPolicy, ok := dm.removeSecurityPolicy(override.NamespaceName, override.PolicyName); ok {
+			dm.UpdateSecurityPolicy("DELETED", secPolicy)
+		}
+	}
+
+	dm.forgetPolicyOverride(key)
+	delete(dm.PolicyOverrides.Applied, key)
+
+	dm.Logger.Printf("Removed a policy override (%s, %s)", key, reason)
+
+	dm.Logger.PushPolicyOverrideTransition(fd.PolicyOverrideTransition{
+		Kind:          override.Kind,
+		NamespaceName: override.NamespaceName,
+		PolicyName:    override.PolicyName,
+		Expired:       true,
+		ExpiresAt:     override.ExpiresAt,
+		Reason:        reason,
+		Endpoints:     endpoints,
+	})
+}
+
+// sortedOverrideKeys Function
+func sortedOverrideKeys(overrides map[string]policyOverride) []string {
+	keys := []string{}
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// reloadPolicyOverrides applies the overrides of the file, and removes the ones expired or removed from it
+func (dm *KubeArmorDaemon) reloadPolicyOverrides() {
+	dm.PolicyOverrides.Lock.Lock()
+	defer dm.PolicyOverrides.Lock.Unlock()
+
+	now := dm.maturationTime()
+
+	overrides, rejected, err := readPolicyOverrides(cfg.GlobalCfg.PolicyOverrideFile, dm.PolicyOverrides.State)
+	if err != nil && !os.IsNotExist(err) {
+		// the overrides applied are kept until they expire
+		dm.Logger.Warnf("Failed to read the policy overrides (%s)", err.Error())
+		return
+	}
+
+	for _, reject := range rejected {
+		dm.Logger.Warnf("Rejected a policy override (%s, %s)", reject.Override.key(), reject.Err.Error())
+		dm.Logger.PushPolicyEvent(reject.Override.Kind, reject.Override.NamespaceName, reject.Override.PolicyName, fd.PolicyFailed, reject.Err.Error(), nil)
+	}
+
+	state := map[string]policyOverrideState{}
+	desired := map[string]policyOverride{}
+
+	for _, override := range overrides {
+		key := override.key()
+		state[key] = policyOverrideState{Digest: override.Digest, ExpiresAt: override.ExpiresAt}
+
+		if now.Before(override.ExpiresAt) {
+			desired[key] = override
+		} else if _, ok := dm.PolicyOverrides.Applied[key]; !ok {
+			dm.Logger.Printf("Ignored an expired policy override (%s, expired at %s)", key, override.ExpiresAt.UTC().Format(time.RFC3339))
+		}
+	}
+
+	for _, key := range sortedOverrideKeys(dm.PolicyOverrides.Applied) {
+		if _, ok := desired[key]; ok {
+			continue
+		}
+
+		reason := "removed from the override file"
+		if _, ok := state[key]; ok {
+			reason = "expired"
+		}
+
+		dm.removePolicyOverride(dm.PolicyOverrides.Applied[key], reason)
+	}
+
+	for _, key := range sortedOverrideKeys(desired) {
+		if applied, ok := dm.PolicyOverrides.Applied[key]; ok && applied.Digest == desired[key].Digest && applied.ExpiresAt.Equal(desired[key].ExpiresAt) {
+			continue
+		}
+
+		if err := dm.applyPolicyOverride(desired[key]); err != nil {
+			dm.Logger.Warnf("Failed to apply a policy override (%s, %s)", key, err.Error())
+		}
+	}
+
+	dm.PolicyOverrides.State = state
+	dm.savePolicyOverrideState()
+}
+
+// expirePolicyOverrides removes the overrides whose TTL elapsed, and returns them
+func (dm *KubeArmorDaemon) expirePolicyOverrides() []string {
+	dm.PolicyOverrides.Lock.Lock()
+	defer dm.PolicyOverrides.Lock.Unlock()
+
+	now := dm.maturationTime()
+
+	expired := []string{}
+
+	for _, key := range sortedOverrideKeys(dm.PolicyOverrides.Applied) {
+		if override := dm.PolicyOverrides.Applied[key]; !now.Before(override.ExpiresAt) {
+			dm.removePolicyOverride(override, "expired")
+			expired = append(expired, key)
+		}
+	}
+
+	return expired
+}
+
+// WatchPolicyOverrides applies the overrides of the node-local override file as it changes, and removes them once
+// they expire
+func (dm *KubeArmorDaemon) WatchPolicyOverrides() {
+	path := filepath.Clean(cfg.GlobalCfg.PolicyOverrideFile)
+
+	dm.PolicyOverrides.Lock.Lock()
+	dm.PolicyOverrides.State = loadPolicyOverrideState(cfg.PolicyOverrideStatePath)
+	dm.PolicyOverrides.Lock.Unlock()
+
+	dm.reloadPolicyOverrides()
+
+	var events chan fsnotify.Event
+	var errs chan error
+
+	// the file may be replaced as a whole (e.g., by an editor), so its directory is watched
+	if watcher, err := fsnotify.NewWatcher(); err != nil {
+		dm.Logger.Warnf("Failed to watch the policy overrides (%s)", err.Error())
+	} else if err := watcher.Add(filepath.Dir(path)); err != nil {
+		dm.Logger.Warnf("Failed to watch the policy overrides (%s)", err.Error())
+		_ = watcher.Close()
+	} else {
+		defer watcher.Close()
+		events, errs = watcher.Events, watcher.Errors
+	}
+
+	ticker := time.NewTicker(policyOverrideInterval)
+	defer ticker.Stop()
+
+	var timer *time.Timer
+	fire := make(chan struct{}, 1)
+
+	for {
+		select {
+		case <-StopChan:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+
+			if filepath.Clean(event.Name) != path {
+				continue
+			}
+
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(policyOverrideDebounce, func() {
+				select {
+				case fire <- struct{}{}:
+				default:
+				}
+			})
+
+		case <-fire:
+			dm.reloadPolicyOverrides()
+
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			dm.Logger.Warnf("Failed to watch the policy overrides (%s)", err.Error())
+
+		case <-ticker.C:
+			dm.expirePolicyOverrides()
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const testPolicyOverrides = `apiVersion: security.kubearmor.com/v1
+kind: KubeArmorPolicy
+metadata:
+  name: allow-shell
+  namespace: web
+  annotations:
+    kubearmor.io/override-ttl: 30m
+spec:
+  action: Allow
+  process:
+    matchPaths:
+    - path: /bin/sh
+---
+apiVersion: security.kubearmor.com/v1
+kind: KubeArmorPolicy
+metadata:
+  name: block-curl
+  namespace: web
+spec:
+  action: Block
+  process:
+    matchPaths:
+    - path: /usr/bin/curl
+`
+
+// getMatchedPolicyNames returns the names of the policies of the rules matched for the endpoint
+func getMatchedPolicyNames(dm *KubeArmorDaemon) []string {
+	dm.Logger.SecurityPoliciesLock.RLock()
+	defer dm.Logger.SecurityPoliciesLock.RUnlock()
+
+	names := []string{}
+	for _, policy := range dm.Logger.SecurityPolicies["web_frontend"].Policies {
+		names = append(names, policy.PolicyName)
+	}
+	return names
+}
+
+// drainPolicyEvents returns the actions of the policy events pushed so far
+func drainPolicyEvents(events chan *pb.PolicyEvent) []string {
+	actions := []string{}
+	for len(events) > 0 {
+		event := <-events
+		actions = append(actions, event.PolicyName+":"+event.Action)
+	}
+	return actions
+}
+
+// drainOverrideAlerts returns the policy names of the alerts of the overrides pushed so far
+func drainOverrideAlerts(messages chan *pb.Alert) []string {
+	names := []string{}
+	for len(messages) > 0 {
+		alert := <-messages
+		if alert.PolicyName == fd.PolicyOverrideAppliedPolicyName || alert.PolicyName == fd.PolicyOverrideExpiredPolicyName {
+			names = append(names, alert.PolicyName)
+		}
+	}
+	return names
+}
+
+func TestPolicyOverrides(t *testing.T) {
+	prevPolicy, prevFile, prevState := cfg.GlobalCfg.Policy, cfg.GlobalCfg.PolicyOverrideFile, cfg.PolicyOverrideStatePath
+	defer func() {
+		cfg.GlobalCfg.Policy, cfg.GlobalCfg.PolicyOverrideFile, cfg.PolicyOverrideStatePath = prevPolicy, prevFile, prevState
+	}()
+	cfg.GlobalCfg.Policy = true
+
+	dir := t.TempDir()
+	cfg.GlobalCfg.PolicyOverrideFile = dir + "/overrides.yaml"
+	cfg.PolicyOverrideStatePath = dir + "/state/overrides.json"
+
+	written := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := written.Add(10 * time.Minute)
+
+	if err := os.WriteFile(cfg.GlobalCfg.PolicyOverrideFile, []byte(testPolicyOverrides), 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to write the override file (%s)", err.Error())
+	}
+	if err := os.Chtimes(cfg.GlobalCfg.PolicyOverrideFile, written, written); err != nil {
+		t.Fatalf("[FAIL] Failed to set the time of the override file (%s)", err.Error())
+	}
+
+	newDaemon := func() (*KubeArmorDaemon, chan *pb.PolicyEvent, chan *pb.Alert) {
+		dm := newPolicyOrderDaemon()
+		dm.Logger.Now = func() time.Time { return now }
+
+		events := make(chan *pb.PolicyEvent, 16)
+		fd.PolicyEventStructs = map[string]fd.PolicyEventStruct{"test": {Filter: "all", Broadcast: events}}
+
+		alerts := make(chan *pb.Alert, 16)
+		fd.AlertLock = new(sync.RWMutex)
+		fd.AlertStructs = map[string]fd.AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+
+		dm.Logger.Output = "none"
+		dm.Logger.SeverityRangesLock = new(sync.RWMutex)
+		dm.Logger.SinksLock = new(sync.RWMutex)
+
+		// a regular policy blocks the shell
+		deliverPolicyEvents(dm.kubeArmorPolicyEventHandler(), []watch.Event{{Type: watch.Added, Object: newOrderedPolicy("uid-1", "10", "/bin/sh")}})
+		drainPolicyEvents(events)
+
+		return dm, events, alerts
+	}
+	defer func() {
+		fd.PolicyEventStructs, fd.AlertStructs = map[string]fd.PolicyEventStruct{}, map[string]fd.AlertStruct{}
+	}()
+
+	dm, events, alerts := newDaemon()
+	dm.reloadPolicyOverrides()
+
+	// the override allows the shell with the highest priority, the Block rule of the regular policy is left out
+	if names := getMatchedPolicyNames(dm); len(names) != 1 || names[0] != PolicyOverridePrefix+"allow-shell" {
+		t.Fatalf("[FAIL] Expected the override to take precedence over the regular policy (%v)", names)
+	}
+	if actions := getMatchedActions(dm); len(actions) != 1 || actions[0] != "Allow" {
+		t.Errorf("[FAIL] Expected the shell to be allowed (%v)", actions)
+	}
+
+	// the policy without a TTL is rejected
+	if actions := drainPolicyEvents(events); len(actions) != 3 ||
+		actions[0] != PolicyOverridePrefix+"block-curl:"+fd.PolicyFailed ||
+		actions[1] != PolicyOverridePrefix+"allow-shell:"+fd.PolicyApplied ||
+		actions[2] != PolicyOverridePrefix+"allow-shell:"+fd.PolicyOverridden {
+		t.Errorf("[FAIL] Unexpected policy events (%v)", actions)
+	}
+	if names := drainOverrideAlerts(alerts); len(names) != 1 || names[0] != fd.PolicyOverrideAppliedPolicyName {
+		t.Errorf("[FAIL] Expected an alert of the override (%v)", names)
+	}
+
+	// the override isn't reported as a drift from the API server
+	if drift := dm.detectPolicyDrift(map[string]*ksp.KubeArmorPolicy{"web/block-shell": newOrderedPolicy("uid-1", "10", "/bin/sh")}); !drift.Empty() {
+		t.Errorf("[FAIL] Expected no drift (%s)", drift.String())
+	}
+
+	// after a restart, touching the file doesn't extend the TTL of the override kept unchanged
+	touched := written.Add(15 * time.Minute)
+	if err := os.Chtimes(cfg.GlobalCfg.PolicyOverrideFile, touched, touched); err != nil {
+		t.Fatalf("[FAIL] Failed to set the time of the override file (%s)", err.Error())
+	}
+	now = written.Add(20 * time.Minute)
+
+	restarted, events, alerts := newDaemon()
+	restarted.PolicyOverrides.State = loadPolicyOverrideState(cfg.PolicyOverrideStatePath)
+	restarted.reloadPolicyOverrides()
+
+	key := KubeArmorPolicyKind + "/web/" + PolicyOverridePrefix + "allow-shell"
+	if override, ok := restarted.PolicyOverrides.Applied[key]; !ok || !override.ExpiresAt.Equal(written.Add(30*time.Minute)) {
+		t.Fatalf("[FAIL] Expected the override to expire 30m after the file was written (%v)", override.ExpiresAt)
+	}
+
+	// the override expires
+	now = written.Add(30 * time.Minute)
+
+	if expired := restarted.expirePolicyOverrides(); len(expired) != 1 || expired[0] != key {
+		t.Fatalf("[FAIL] Expected the override to expire (%v)", expired)
+	}
+	if actions := getMatchedActions(restarted); len(actions) != 1 || actions[0] != "Block" {
+		t.Errorf("[FAIL] Expected the regular policy to be enforced again (%v)", actions)
+	}
+
+	found := false
+	for _, action := range drainPolicyEvents(events) {
+		found = found || action == PolicyOverridePrefix+"allow-shell:"+fd.PolicyExpired
+	}
+	if !found {
+		t.Errorf("[FAIL] Expected a policy event of the expiry")
+	}
+	if names := drainOverrideAlerts(alerts); len(names) != 2 || names[1] != fd.PolicyOverrideExpiredPolicyName {
+		t.Errorf("[FAIL] Expected an alert of the expiry (%v)", names)
+	}
+
+	// an expired override is never applied again after a restart
+	now = written.Add(31 * time.Minute)
+
+	expired, _, _ := newDaemon()
+	expired.PolicyOverrides.State = loadPolicyOverrideState(cfg.PolicyOverrideStatePath)
+	expired.reloadPolicyOverrides()
+
+	if len(expired.PolicyOverrides.Applied) != 0 {
+		t.Errorf("[FAIL] Expected the expired override not to be applied (%v)", expired.PolicyOverrides.Applied)
+	}
+	if actions := getMatchedActions(expired); len(actions) != 1 || actions[0] != "Block" {
+		t.Errorf("[FAIL] Expected the regular policy only (%v)", actions)
+	}
+
+	t.Log("[PASS] Applied the policy overrides with the highest priority until they expired")
+}
@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ================== //
+// == Policy Paths == //
+// ================== //
+
+// minimum interval between the checks of the same policy
+const policyPathCheckInterval = time.Minute
+
+// policyPathCheck Structure
+type policyPathCheck struct {
+	Spec     string
+	Checked  time.Time
+	Warnings []string
+}
+
+// expectsMissing checks if a path is listed in the expectMissing annotation of a policy
+func expectsMissing(expectMissing, path string) bool {
+	for _, expected := range strings.Split(expectMissing, ",") {
+		expected = strings.TrimSpace(expected)
+		if expected == "*" || expected == path {
+			return true
+		}
+	}
+	return false
+}
+
+// exactPolicyPaths returns the matchPaths of a policy to look for in containers
+//   - Globs are skipped, since they don't have to match anything
+//   - Block rules on paths expected not to exist (expectMissing) are skipped
+func exactPolicyPaths(spec tp.SecuritySpec, expectMissing string) []string {
+	paths := []string{}
+
+	addPath := func(path, action string) {
+		if path == "" || strings.ContainsAny(path, "*?[") {
+			return
+		}
+		if action == "" {
+			action = spec.Action
+		}
+		if action == "Block" && expectsMissing(expectMissing, path) {
+			return
+		}
+		if !kl.ContainsElement(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+
+	for _, rule := range spec.Process.MatchPaths {
+		addPath(rule.Path, rule.Action)
+	}
+	for _, rule := range spec.File.MatchPaths {
+		addPath(rule.Path, rule.Action)
+	}
+
+	return paths
+}
+
+// findMissingPaths returns the paths which exist in none of the given root filesystems
+func findMissingPaths(paths []string, mergedDirs []string) []string {
+	missing := []string{}
+
+	for _, path := range paths {
+		found := false
+		for _, mergedDir := range mergedDirs {
+			if _, err := kl.ResolvePathInRoot(mergedDir, path); err == nil {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, path)
+		}
+	}
+
+	return missing
+}
+
+// checkPolicyPaths looks for the exact matchPaths of a policy in the given containers,
+// and returns warnings for the ones found in none of them (likely typos)
+func (dm *KubeArmorDaemon) checkPolicyPaths(secPolicy tp.SecurityPolicy, containerIDs []string) []string {
+	key := secPolicy.Metadata["namespaceName"] + "/" + secPolicy.Metadata["policyName"]
+
+	spec, err := json.Marshal(secPolicy.Spec)
+	if err != nil {
+		return nil
+	}
+	specStr := string(spec) + secPolicy.Metadata["expectMissing"]
+
+	// rate-limited per policy, unless the policy changed
+	dm.PolicyPathChecksLock.Lock()
+	if check, ok := dm.PolicyPathChecks[key]; ok && check.Spec == specStr && time.Since(check.Checked) < policyPathCheckInterval {
+		dm.PolicyPathChecksLock.Unlock()
+		return check.Warnings
+	}
+	dm.PolicyPathChecksLock.Unlock()
+
+	mergedDirs := []string{}
+	paused := false
+
+	dm.ContainersLock.RLock()
+	for _, containerID := range containerIDs {
+		container, ok := dm.Containers[containerID]
+		if !ok || container.MergedDir == "" {
+			continue
+		}
+
+		// the root filesystems of the paused containers are looked up once they're unpaused
+		if container.State == tp.ContainerStatePaused {
+			paused = true
+			continue
+		}
+
+		mergedDirs = append(mergedDirs, container.MergedDir)
+	}
+	dm.ContainersLock.RUnlock()
+
+	warnings := []string{}
+
+	// nothing to tell without containers (the paths may exist once they start)
+	if len(mergedDirs) > 0 {
+		for _, path := range findMissingPaths(exactPolicyPaths(secPolicy.Spec, secPolicy.Metadata["expectMissing"]), mergedDirs) {
+			warnings = append(warnings, fmt.Sprintf("%s not found in any of %d containers", path, len(mergedDirs)))
+		}
+	}
+
+	if len(warnings) > 0 {
+		kg.Warnf("Detected matchPaths found in no container (%s, %s)", key, strings.Join(warnings, "; "))
+	}
+
+	// checked again without the rate limit until the paused containers are unpaused
+	checked := time.Now()
+	if paused {
+		checked = time.Time{}
+	}
+
+	dm.PolicyPathChecksLock.Lock()
+	dm.PolicyPathChecks[key] = policyPathCheck{Spec: specStr, Checked: checked, Warnings: warnings}
+	dm.PolicyPathChecksLock.Unlock()
+
+	return warnings
+}
+
+// policyPathWarnings returns the warnings of the last check of a policy
+func (dm *KubeArmorDaemon) policyPathWarnings(namespaceName, policyName string) []string {
+	dm.PolicyPathChecksLock.Lock()
+	defer dm.PolicyPathChecksLock.Unlock()
+
+	return dm.PolicyPathChecks[namespaceName+"/"+policyName].Warnings
+}
+
+// forgetPolicyPaths removes the last check of a deleted policy
+func (dm *KubeArmorDaemon) forgetPolicyPaths(namespaceName, policyName string) {
+	dm.PolicyPathChecksLock.Lock()
+	defer dm.PolicyPathChecksLock.Unlock()
+
+	delete(dm.PolicyPathChecks, namespaceName+"/"+policyName)
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestCheckPolicyPaths(t *testing.T) {
+	dm := NewKubeArmorDaemon()
+
+	// two containers, with wget in one of them only
+	for _, containerID := range []string{"c1", "c2"} {
+		mergedDir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(mergedDir, "usr", "bin"), 0750); err != nil {
+			t.Fatalf("[FAIL] Failed to create a fake container (%s)", err.Error())
+		}
+		dm.Containers[containerID] = tp.Container{ContainerID: containerID, MergedDir: mergedDir}
+	}
+
+	if err := os.WriteFile(filepath.Join(dm.Containers["c2"].MergedDir, "usr", "bin", "wget"), []byte{}, 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to create a fake binary (%s)", err.Error())
+	}
+
+	secPolicy := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "default", "policyName": "block-downloads", "expectMissing": "/usr/bin/curl"}}
+	secPolicy.Spec.Action = "Block"
+	secPolicy.Spec.Process.MatchPaths = []tp.ProcessPathType{
+		{Path: "/usr/bin/wget"},
+		{Path: "/usr/bin/wgett"},
+		{Path: "/usr/bin/curl"},
+		{Path: "/usr/bin/nc*"},
+	}
+
+	// globs and expected missing paths are skipped
+	warnings := dm.checkPolicyPaths(secPolicy, []string{"c1", "c2"})
+	if len(warnings) != 1 || warnings[0] != "/usr/bin/wgett not found in any of 2 containers" {
+		t.Errorf("[FAIL] Unexpected warnings (%v)", warnings)
+	}
+
+	if warnings := dm.policyPathWarnings("default", "block-downloads"); len(warnings) != 1 {
+		t.Errorf("[FAIL] Expected the warnings of the last check (%v)", warnings)
+	}
+
+	// the check is rate-limited
+	if err := os.WriteFile(filepath.Join(dm.Containers["c1"].MergedDir, "usr", "bin", "wgett"), []byte{}, 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to create a fake binary (%s)", err.Error())
+	}
+
+	if warnings := dm.checkPolicyPaths(secPolicy, []string{"c1", "c2"}); len(warnings) != 1 {
+		t.Errorf("[FAIL] Expected the last check within the interval (%v)", warnings)
+	}
+
+	dm.PolicyPathChecks["default/block-downloads"] = policyPathCheck{}
+
+	if warnings := dm.checkPolicyPaths(secPolicy, []string{"c1", "c2"}); len(warnings) != 0 {
+		t.Errorf("[FAIL] Expected no warnings once the path exists (%v)", warnings)
+	}
+
+	// expectMissing only applies to Block rules
+	secPolicy.Spec.Action = "Audit"
+
+	if warnings := dm.checkPolicyPaths(secPolicy, []string{"c1", "c2"}); len(warnings) != 1 || warnings[0] != "/usr/bin/curl not found in any of 2 containers" {
+		t.Errorf("[FAIL] Unexpected warnings of an Audit policy (%v)", warnings)
+	}
+
+	// nothing is checked without containers
+	dm.PolicyPathChecks["default/block-downloads"] = policyPathCheck{}
+
+	if warnings := dm.checkPolicyPaths(secPolicy, nil); len(warnings) != 0 {
+		t.Errorf("[FAIL] Unexpected warnings without containers (%v)", warnings)
+	}
+
+	t.Log("[PASS] Warned on matchPaths found in no container")
+}
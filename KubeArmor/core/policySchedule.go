@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+)
+
+// ===================== //
+// == Policy Schedule == //
+// ===================== //
+
+// longest wait between the checks of the schedules, so that changes of the clock are caught up
+const policyScheduleInterval = time.Minute
+
+// scheduledPolicy Structure
+type scheduledPolicy struct {
+	Kind          string
+	NamespaceName string
+	PolicyName    string
+
+	Schedule *ksp.PolicySchedule
+
+	// whether the policy is within a window, and when the next window starts or ends
+	Active   bool
+	Boundary time.Time
+}
+
+// PolicySchedules Structure keeps the policies with a schedule (kind/namespace/policy -> policy)
+type PolicySchedules struct {
+	Policies map[string]*scheduledPolicy
+	Lock     *sync.Mutex
+
+	// wakes up the timer when a policy is tracked
+	Wake chan struct{}
+}
+
+// NewPolicySchedules Function
+func NewPolicySchedules() *PolicySchedules {
+	ps := &PolicySchedules{}
+
+	ps.Policies = map[string]*scheduledPolicy{}
+	ps.Lock = new(sync.Mutex)
+
+	ps.Wake = make(chan struct{}, 1)
+
+	return ps
+}
+
+// validatePolicySchedule rejects the schedules whose windows or time zone can't be parsed
+func validatePolicySchedule(schedule *tp.ScheduleType) error {
+	_, err := fd.ParsePolicySchedule(schedule)
+	return err
+}
+
+// trackPolicySchedule keeps a policy with a schedule until it is deleted, its state being the one the rules were
+// generated with (so a restart of the daemon recomputes it)
+func (dm *KubeArmorDaemon) trackPolicySchedule(kind, namespaceName, policyName string, schedule *tp.ScheduleType, deleted bool) {
+	key := kind + "/" + namespaceName + "/" + policyName
+
+	dm.PolicySchedules.Lock.Lock()
+	defer dm.PolicySchedules.Lock.Unlock()
+
+	parsed, err := fd.ParsePolicySchedule(schedule)
+	if deleted || err != nil || parsed == nil {
+		delete(dm.PolicySchedules.Policies, key)
+		return
+	}
+
+	active, boundary := parsed.Active(dm.maturationTime())
+
+	dm.PolicySchedules.Policies[key] = &scheduledPolicy{
+		Kind:          kind,
+		NamespaceName: namespaceName,
+		PolicyName:    policyName,
+		Schedule:      parsed,
+		Active:        active,
+		Boundary:      boundary,
+	}
+
+	select {
+	case dm.PolicySchedules.Wake <- struct{}{}:
+	default:
+	}
+}
+
+// schedulePolicies applies the rules of the policies whose window started or ended again, and returns the policies
+// activated or deactivated
+func (dm *KubeArmorDaemon) schedulePolicies() []string {
+	now := dm.maturationTime()
+
+	changed := []scheduledPolicy{}
+
+	dm.PolicySchedules.Lock.Lock()
+	for _, policy := range dm.PolicySchedules.Policies {
+		if policy.Boundary.IsZero() || now.Before(policy.Boundary) {
+			continue
+		}
+
+		// the windows may overlap, so the state doesn't change at every boundary
+		active, boundary := policy.Schedule.Active(now)
+		policy.Boundary = boundary
+
+		if active != policy.Active {
+			policy.Active = active
+			changed = append(changed, *policy)
+		}
+	}
+	dm.PolicySchedules.Lock.Unlock()
+
+	sort.Slice(changed, func(i, j int) bool {
+		return changed[i].Kind+"/"+changed[i].NamespaceName+"/"+changed[i].PolicyName < changed[j].Kind+"/"+changed[j].NamespaceName+"/"+changed[j].PolicyName
+	})
+
+	keys := []string{}
+
+	for _, policy := range changed {
+		endpoints := dm.reapplyPolicyEndPoints(policy.NamespaceName, policy.PolicyName)
+
+		if policy.Active {
+			dm.Logger.Printf("Activated a security policy (%s/%s/%s), a window of its schedule started", policy.Kind, policy.NamespaceName, policy.PolicyName)
+			dm.Logger.PushPolicyEvent(policy.Kind, policy.NamespaceName, policy.PolicyName, fd.PolicyActivated, "schedule window started", endpoints)
+		} else {
+			dm.Logger.Printf("Deactivated a security policy (%s/%s/%s), the windows of its schedule ended", policy.Kind, policy.NamespaceName, policy.PolicyName)
+			dm.Logger.PushPolicyEvent(policy.Kind, policy.NamespaceName, policy.PolicyName, fd.PolicyDeactivated, "schedule window ended", endpoints)
+		}
+
+		keys = append(keys, policy.Kind+"/"+policy.NamespaceName+"/"+policy.PolicyName)
+	}
+
+	return keys
+}
+
+// nextScheduleCheck returns the time until the next window of the schedules starts or ends
+func (dm *KubeArmorDaemon) nextScheduleCheck() time.Duration {
+	now := dm.maturationTime()
+
+	wait := policyScheduleInterval
+
+	dm.PolicySchedules.Lock.Lock()
+	for _, policy := range dm.PolicySchedules.Policies {
+		if !policy.Boundary.IsZero() && policy.Boundary.Sub(now) < wait {
+			wait = policy.Boundary.Sub(now)
+		}
+	}
+	dm.PolicySchedules.Lock.Unlock()
+
+	if wait < 0 {
+		return 0
+	}
+
+	return wait
+}
+
+// WatchPolicySchedules activates and deactivates the policies when the windows of their schedules start and end
+func (dm *KubeArmorDaemon) WatchPolicySchedules() {
+	timer := time.NewTimer(dm.nextScheduleCheck())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-StopChan:
+			return
+		case <-dm.PolicySchedules.Wake:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		case <-timer.C:
+			dm.schedulePolicies()
+		}
+
+		timer.Reset(dm.nextScheduleCheck())
+	}
+}
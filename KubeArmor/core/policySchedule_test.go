@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestPolicySchedule(t *testing.T) {
+	prevPolicy := cfg.GlobalCfg.Policy
+	defer func() { cfg.GlobalCfg.Policy = prevPolicy }()
+	cfg.GlobalCfg.Policy = true
+
+	day := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	now := day.Add(12 * time.Hour)
+
+	// interactive shells are denied out of business hours (20:00-06:00), and during the maintenance (05:00-08:00)
+	policy := newOrderedPolicy("uid-1", "10", "/bin/sh")
+	policy.Spec.Schedule = &ksp.ScheduleType{Windows: []ksp.ScheduleWindowType{
+		{Start: "0 20 * * *", End: "0 6 * * *"},
+		{Start: "0 5 * * *", End: "0 8 * * *"},
+	}}
+
+	dm := newPolicyOrderDaemon()
+	dm.Logger.Now = func() time.Time { return now }
+
+	events := make(chan *pb.PolicyEvent, 16)
+	fd.PolicyEventStructs = map[string]fd.PolicyEventStruct{"test": {Filter: "all", Broadcast: events}}
+	defer func() { fd.PolicyEventStructs = map[string]fd.PolicyEventStruct{} }()
+
+	deliverPolicyEvents(dm.kubeArmorPolicyEventHandler(), []watch.Event{{Type: watch.Added, Object: policy}})
+
+	// out of the windows, the policy is neither matched nor enforced
+	if actions := getMatchedActions(dm); len(actions) != 0 {
+		t.Fatalf("[FAIL] Expected the inactive policy not to be matched (%v)", actions)
+	}
+	if enforced := dm.Logger.EnforcedSecurityPolicies(dm.EndPoints[0].SecurityPolicies); len(enforced) != 0 {
+		t.Errorf("[FAIL] Expected the inactive policy not to be enforced (%d)", len(enforced))
+	}
+	if scheduled := dm.PolicySchedules.Policies["KubeArmorPolicy/web/block-shell"]; scheduled == nil || scheduled.Active || !scheduled.Boundary.Equal(day.Add(20*time.Hour)) {
+		t.Fatalf("[FAIL] Expected the policy to wait for the next window (%+v)", scheduled)
+	}
+
+	for len(events) > 0 {
+		<-events
+	}
+
+	// the policy is activated when the window starts
+	now = day.Add(20*time.Hour - time.Minute)
+	if changed := dm.schedulePolicies(); len(changed) != 0 {
+		t.Errorf("[FAIL] Expected no change before the window (%v)", changed)
+	}
+
+	now = day.Add(20 * time.Hour)
+	if changed := dm.schedulePolicies(); len(changed) != 1 || changed[0] != "KubeArmorPolicy/web/block-shell" {
+		t.Fatalf("[FAIL] Expected the policy to be activated (%v)", changed)
+	}
+	if actions := getMatchedActions(dm); len(actions) != 1 || actions[0] != "Block" {
+		t.Errorf("[FAIL] Expected the Block rule to be enforced (%v)", actions)
+	}
+	if enforced := dm.Logger.EnforcedSecurityPolicies(dm.EndPoints[0].SecurityPolicies); len(enforced) != 1 {
+		t.Errorf("[FAIL] Expected the active policy to be enforced (%d)", len(enforced))
+	}
+
+	select {
+	case event := <-events:
+		if event.Action != fd.PolicyActivated || event.PolicyName != "block-shell" || len(event.Endpoints) != 1 || event.Endpoints[0] != "frontend" {
+			t.Errorf("[FAIL] Unexpected policy event (%+v)", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("[FAIL] Expected a policy event of the activation")
+	}
+
+	// the windows overlap, so the policy stays active until the last one ends
+	now = day.Add(30 * time.Hour)
+	if changed := dm.schedulePolicies(); len(changed) != 0 {
+		t.Errorf("[FAIL] Expected the policy to stay active within the overlapping window (%v)", changed)
+	}
+
+	now = day.Add(32 * time.Hour)
+	if changed := dm.schedulePolicies(); len(changed) != 1 {
+		t.Fatalf("[FAIL] Expected the policy to be deactivated (%v)", changed)
+	}
+	if actions := getMatchedActions(dm); len(actions) != 0 {
+		t.Errorf("[FAIL] Expected the inactive policy not to be matched (%v)", actions)
+	}
+
+	// a restart in the middle of a window recomputes the state
+	now = day.Add(23 * time.Hour)
+
+	restarted := newPolicyOrderDaemon()
+	restarted.Logger.Now = func() time.Time { return now }
+
+	deliverPolicyEvents(restarted.kubeArmorPolicyEventHandler(), []watch.Event{{Type: watch.Added, Object: policy}})
+
+	if actions := getMatchedActions(restarted); len(actions) != 1 || actions[0] != "Block" {
+		t.Errorf("[FAIL] Expected the Block rule to be enforced after the restart (%v)", actions)
+	}
+	if scheduled := restarted.PolicySchedules.Policies["KubeArmorPolicy/web/block-shell"]; scheduled == nil || !scheduled.Active || !scheduled.Boundary.Equal(day.Add(29*time.Hour)) {
+		t.Errorf("[FAIL] Expected the policy to be active until the next boundary (%+v)", scheduled)
+	}
+
+	// the time zones and the names of the days are supported
+	weekdays, err := fd.ParsePolicySchedule(&tp.ScheduleType{Windows: []tp.ScheduleWindowType{{Start: "0 9 * * MON-FRI", End: "30 17 * * mon-fri"}}, TimeZone: "Asia/Kolkata"})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to parse the schedule (%s)", err.Error())
+	}
+	// Thursday, 10:00 in Kolkata
+	if active, boundary := weekdays.Active(time.Date(2023, 6, 1, 4, 30, 0, 0, time.UTC)); !active || !boundary.Equal(time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("[FAIL] Expected the weekday window to be active until 17:30 in Kolkata (%v, %v)", active, boundary)
+	}
+	// Saturday
+	if active, _ := weekdays.Active(time.Date(2023, 6, 3, 6, 0, 0, 0, time.UTC)); active {
+		t.Errorf("[FAIL] Expected the weekday window to be inactive on Saturday")
+	}
+
+	// an invalid schedule is rejected
+	policy.Spec.Schedule = &ksp.ScheduleType{Windows: []ksp.ScheduleWindowType{{Start: "0 25 * * *", End: "0 6 * * *"}}}
+	if _, err := dm.CreateSecurityPolicy(*policy); err == nil || err.Error() != `invalid schedule start, invalid hour in "0 25 * * *" (value 25 out of range [0, 23])` {
+		t.Errorf("[FAIL] Expected the policy to be rejected (%v)", err)
+	}
+	policy.Spec.Schedule = &ksp.ScheduleType{Windows: []ksp.ScheduleWindowType{{Start: "0 20 * * *", End: "0 6 * * *"}}, TimeZone: "Mars/Olympus"}
+	if _, err := dm.CreateSecurityPolicy(*policy); err == nil || err.Error() != `invalid schedule, unknown timeZone "Mars/Olympus"` {
+		t.Errorf("[FAIL] Expected the policy to be rejected (%v)", err)
+	}
+
+	t.Log("[PASS] Activated and deactivated the policies at the windows of their schedules")
+}
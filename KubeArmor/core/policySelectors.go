@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ====================== //
+// == Policy Selectors == //
+// ====================== //
+
+// matchLabelExpression checks if the labels meet a requirement on the value of a label
+func matchLabelExpression(key, operator string, values []string, labels map[string]string) bool {
+	value, ok := labels[key]
+
+	switch operator {
+	case "In":
+		return ok && kl.ContainsElement(values, value)
+	case "NotIn":
+		return !ok || !kl.ContainsElement(values, value)
+	case "Exists":
+		return ok
+	case "DoesNotExist":
+		return !ok
+	}
+
+	return false
+}
+
+// identityLabels returns the labels of the identities (key=value) of an endpoint or a node
+func identityLabels(identities []string) map[string]string {
+	labels := map[string]string{}
+	for _, identity := range identities {
+		if k, v, ok := strings.Cut(identity, "="); ok {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+// matchExpressions checks if the identities meet all the expressions of a selector
+func matchExpressions(expressions []tp.MatchExpressionType, identities []string) bool {
+	if len(expressions) == 0 {
+		return true
+	}
+
+	labels := identityLabels(identities)
+	for _, expression := range expressions {
+		if !matchLabelExpression(expression.Key, expression.Operator, expression.Values, labels) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchSelector checks if an endpoint matches both the labels and the expressions of the selector of a policy
+func matchSelector(selector tp.SelectorType, identities []string) bool {
+	return kl.MatchIdentities(selector.Identities, identities) && matchExpressions(selector.MatchExpressions, identities)
+}
+
+// matchNodeSelector checks if the node matches both the labels and the expressions of the selector of a host policy
+func matchNodeSelector(selector tp.NodeSelectorType, identities []string) bool {
+	// a selector of expressions only has no identities
+	if len(selector.Identities) == 0 && len(selector.MatchExpressions) > 0 {
+		return matchExpressions(selector.MatchExpressions, identities)
+	}
+
+	return kl.MatchIdentities(selector.Identities, identities) && matchExpressions(selector.MatchExpressions, identities)
+}
+
+// validateMatchExpressions rejects the expressions without a key, with an unknown operator, or with values not fitting the operator
+func validateMatchExpressions(expressions []tp.MatchExpressionType) error {
+	for idx, expression := range expressions {
+		if expression.Key == "" {
+			return fmt.Errorf("no key in matchExpressions[%d]", idx)
+		}
+
+		switch expression.Operator {
+		case "In", "NotIn":
+			if len(expression.Values) == 0 {
+				return fmt.Errorf("no values in matchExpressions[%d] (%s %s), expected at least one value", idx, expression.Key, expression.Operator)
+			}
+		case "Exists", "DoesNotExist":
+			if len(expression.Values) > 0 {
+				return fmt.Errorf("values in matchExpressions[%d] (%s %s), expected no values", idx, expression.Key, expression.Operator)
+			}
+		default:
+			return fmt.Errorf("invalid operator in matchExpressions[%d] (%s), expected In, NotIn, Exists or DoesNotExist", idx, expression.Operator)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"testing"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestMatchSelector(t *testing.T) {
+	identities := []string{"canary=true", "namespaceName=web", "tier=frontend"}
+
+	selectors := []struct {
+		name     string
+		selector tp.SelectorType
+		matched  bool
+	}{
+		{"In", tp.SelectorType{MatchExpressions: []tp.MatchExpressionType{{Key: "tier", Operator: "In", Values: []string{"frontend", "api"}}}}, true},
+		{"In without the value", tp.SelectorType{MatchExpressions: []tp.MatchExpressionType{{Key: "tier", Operator: "In", Values: []string{"api"}}}}, false},
+		{"In without the label", tp.SelectorType{MatchExpressions: []tp.MatchExpressionType{{Key: "app", Operator: "In", Values: []string{"web"}}}}, false},
+		{"NotIn", tp.SelectorType{MatchExpressions: []tp.MatchExpressionType{{Key: "tier", Operator: "NotIn", Values: []string{"api"}}}}, true},
+		{"NotIn with the value", tp.SelectorType{MatchExpressions: []tp.MatchExpressionType{{Key: "canary", Operator: "NotIn", Values: []string{"true"}}}}, false},
+		{"NotIn without the label", tp.SelectorType{MatchExpressions: []tp.MatchExpressionType{{Key: "app", Operator: "NotIn", Values: []string{"web"}}}}, true},
+		{"Exists", tp.SelectorType{MatchExpressions: []tp.MatchExpressionType{{Key: "canary", Operator: "Exists"}}}, true},
+		{"Exists without the label", tp.SelectorType{MatchExpressions: []tp.MatchExpressionType{{Key: "app", Operator: "Exists"}}}, false},
+		{"DoesNotExist", tp.SelectorType{MatchExpressions: []tp.MatchExpressionType{{Key: "app", Operator: "DoesNotExist"}}}, true},
+		{"DoesNotExist with the label", tp.SelectorType{MatchExpressions: []tp.MatchExpressionType{{Key: "canary", Operator: "DoesNotExist"}}}, false},
+		{"labels and expressions", tp.SelectorType{Identities: []string{"tier=frontend"}, MatchExpressions: []tp.MatchExpressionType{{Key: "canary", Operator: "Exists"}}}, true},
+		{"labels but not expressions", tp.SelectorType{Identities: []string{"tier=frontend"}, MatchExpressions: []tp.MatchExpressionType{{Key: "canary", Operator: "DoesNotExist"}}}, false},
+		{"expressions but not labels", tp.SelectorType{Identities: []string{"tier=api"}, MatchExpressions: []tp.MatchExpressionType{{Key: "canary", Operator: "Exists"}}}, false},
+		{"unknown operator", tp.SelectorType{MatchExpressions: []tp.MatchExpressionType{{Key: "tier", Operator: "Gt", Values: []string{"1"}}}}, false},
+	}
+
+	for _, s := range selectors {
+		// the identities of a policy always include its namespace
+		s.selector.Identities = append(s.selector.Identities, "namespaceName=web")
+
+		if matched := matchSelector(s.selector, identities); matched != s.matched {
+			t.Errorf("[FAIL] %s: expected %v, got %v", s.name, s.matched, matched)
+		}
+	}
+
+	// a host policy selecting the node by expressions only
+	node := []string{"kubernetes.io/os=linux", "node-role=worker"}
+
+	if !matchNodeSelector(tp.NodeSelectorType{MatchExpressions: []tp.MatchExpressionType{{Key: "node-role", Operator: "In", Values: []string{"worker"}}}}, node) {
+		t.Errorf("[FAIL] Expected the node to match the expressions")
+	}
+	if matchNodeSelector(tp.NodeSelectorType{MatchExpressions: []tp.MatchExpressionType{{Key: "node-role", Operator: "NotIn", Values: []string{"worker"}}}}, node) {
+		t.Errorf("[FAIL] Expected the node not to match the expressions")
+	}
+	if matchNodeSelector(tp.NodeSelectorType{}, node) {
+		t.Errorf("[FAIL] Expected an empty node selector to match no node")
+	}
+
+	t.Log("[PASS] Matched the selectors with labels and expressions")
+}
+
+func TestValidateMatchExpressions(t *testing.T) {
+	valid := []tp.MatchExpressionType{
+		{Key: "tier", Operator: "In", Values: []string{"frontend"}},
+		{Key: "tier", Operator: "NotIn", Values: []string{"api"}},
+		{Key: "canary", Operator: "Exists"},
+		{Key: "canary", Operator: "DoesNotExist"},
+	}
+	if err := validateMatchExpressions(valid); err != nil {
+		t.Errorf("[FAIL] Expected the expressions to be valid (%s)", err.Error())
+	}
+
+	invalid := map[string]tp.MatchExpressionType{
+		"no values of In":        {Key: "tier", Operator: "In"},
+		"no values of NotIn":     {Key: "tier", Operator: "NotIn", Values: []string{}},
+		"values of Exists":       {Key: "canary", Operator: "Exists", Values: []string{"true"}},
+		"values of DoesNotExist": {Key: "canary", Operator: "DoesNotExist", Values: []string{"true"}},
+		"no key":                 {Operator: "Exists"},
+		"unknown operator":       {Key: "tier", Operator: "Gt", Values: []string{"1"}},
+	}
+	for name, expression := range invalid {
+		if err := validateMatchExpressions([]tp.MatchExpressionType{expression}); err == nil {
+			t.Errorf("[FAIL] Expected the expression with %s to be rejected", name)
+		}
+	}
+
+	// the policies with invalid expressions are rejected
+	dm := newPolicyOrderDaemon()
+
+	policy := newOrderedPolicy("uid-1", "10", "/bin/sh")
+	policy.Spec.Selector.MatchExpressions = []ksp.MatchExpressionType{{Key: "tier", Operator: "In"}}
+
+	if _, err := dm.CreateSecurityPolicy(*policy); err == nil || err.Error() != "no values in matchExpressions[0] (tier In), expected at least one value" {
+		t.Errorf("[FAIL] Expected the policy to be rejected (%v)", err)
+	}
+
+	t.Log("[PASS] Validated the values of the expressions")
+}
+
+func TestPolicySelectorExpressions(t *testing.T) {
+	prevPolicy := cfg.GlobalCfg.Policy
+	defer func() { cfg.GlobalCfg.Policy = prevPolicy }()
+	cfg.GlobalCfg.Policy = true
+
+	dm := newPolicyOrderDaemon()
+	dm.EndPoints[0].Identities = []string{"namespaceName=web", "tier=frontend"}
+	dm.EndPoints = append(dm.EndPoints,
+		tp.EndPoint{NamespaceName: "web", EndPointName: "api", Identities: []string{"namespaceName=web", "tier=api"}, PolicyEnabled: tp.KubeArmorPolicyEnabled},
+		tp.EndPoint{NamespaceName: "web", EndPointName: "api-canary", Identities: []string{"canary=true", "namespaceName=web", "tier=api"}, PolicyEnabled: tp.KubeArmorPolicyEnabled},
+		tp.EndPoint{NamespaceName: "web", EndPointName: "db", Identities: []string{"namespaceName=web", "tier=db"}, PolicyEnabled: tp.KubeArmorPolicyEnabled},
+	)
+
+	// tier in (frontend, api), and not canary=true
+	policy := newOrderedPolicy("uid-1", "10", "/bin/sh")
+	policy.Spec.Selector.MatchExpressions = []ksp.MatchExpressionType{
+		{Key: "tier", Operator: "In", Values: []string{"frontend", "api"}},
+		{Key: "canary", Operator: "NotIn", Values: []string{"true"}},
+	}
+	deliverPolicyEvents(dm.kubeArmorPolicyEventHandler(), []watch.Event{{Type: watch.Added, Object: policy}})
+
+	names := getEndPointPolicyNames(dm)
+	if len(names["frontend"]) != 1 || len(names["api"]) != 1 || len(names["api-canary"]) != 0 || len(names["db"]) != 0 {
+		t.Errorf("[FAIL] Expected the policy in the frontend and api endpoints only (%v)", names)
+	}
+
+	// the endpoints created later get the policy as well
+	if policies := dm.GetSecurityPolicies([]string{"namespaceName=web", "tier=api"}); len(policies) != 1 {
+		t.Errorf("[FAIL] Expected the policy of a new api endpoint (%d)", len(policies))
+	}
+	if policies := dm.GetSecurityPolicies([]string{"canary=true", "namespaceName=web", "tier=frontend"}); len(policies) != 0 {
+		t.Errorf("[FAIL] Expected no policy for a new canary endpoint (%d)", len(policies))
+	}
+
+	t.Log("[PASS] Applied the policy to the endpoints matching its expressions")
+}
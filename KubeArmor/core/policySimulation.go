@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+)
+
+// ======================= //
+// == Policy Simulation == //
+// ======================= //
+
+// errInvalidSimulation is returned for the simulations of the invalid policies
+var errInvalidSimulation = errors.New("invalid policy")
+
+// errNoTrace is returned if no events are given and the node keeps no alert archive
+var errNoTrace = errors.New("no events given, and no alert archive on the node")
+
+// traceIdentities returns the identities of the pod of a recorded event (its namespace and labels)
+func traceIdentities(log tp.Log) []string {
+	identities := []string{"namespaceName=" + log.NamespaceName}
+	for _, label := range strings.Split(log.Labels, ",") {
+		if label != "" {
+			identities = append(identities, label)
+		}
+	}
+	return identities
+}
+
+// SimulatePolicy evaluates a KubeArmorPolicy or a KubeArmorHostPolicy (JSON) against a recorded trace, compiled and
+// matched the same as the live policies, without applying it
+func (dm *KubeArmorDaemon) SimulatePolicy(data []byte, trace fd.LogTrace) (fd.SimulationResult, error) {
+	object := struct {
+		Kind string `json:"kind"`
+	}{}
+
+	if err := json.Unmarshal(data, &object); err != nil {
+		return fd.SimulationResult{}, fmt.Errorf("%w: %s", errInvalidSimulation, err.Error())
+	}
+
+	switch object.Kind {
+	case KubeArmorPolicyKind:
+		policy := ksp.KubeArmorPolicy{}
+		if err := json.Unmarshal(data, &policy); err != nil {
+			return fd.SimulationResult{}, fmt.Errorf("%w: %s", errInvalidSimulation, err.Error())
+		}
+		if policy.Namespace == "" {
+			return fd.SimulationResult{}, fmt.Errorf("%w: missing metadata.namespace", errInvalidSimulation)
+		}
+
+		secPolicy, err := dm.CreateSecurityPolicy(policy)
+		if err != nil {
+			return fd.SimulationResult{}, fmt.Errorf("%w: %s", errInvalidSimulation, err.Error())
+		}
+
+		selector := secPolicy.Spec.Selector
+		selects := func(log tp.Log) bool {
+			return matchSelector(selector, traceIdentities(log)) && (len(selector.Containers) == 0 || kl.ContainsElement(selector.Containers, log.ContainerName))
+		}
+
+		return dm.Logger.SimulateSecurityPolicy(secPolicy, selects, trace)
+
+	case KubeArmorHostPolicyKind:
+		policy := tp.K8sKubeArmorHostPolicy{}
+		if err := json.Unmarshal(data, &policy); err != nil {
+			return fd.SimulationResult{}, fmt.Errorf("%w: %s", errInvalidSimulation, err.Error())
+		}
+
+		secPolicy, _, err := dm.createHostSecurityPolicy(tp.K8sKubeArmorHostPolicyEvent{Type: "ADDED", Object: policy})
+		if err != nil {
+			return fd.SimulationResult{}, fmt.Errorf("%w: %s", errInvalidSimulation, err.Error())
+		}
+
+		// the trace is of this node, whatever the node selector
+		return dm.Logger.SimulateHostSecurityPolicy(secPolicy, trace)
+	}
+
+	return fd.SimulationResult{}, fmt.Errorf("%w: unsupported kind %q, set %s or %s", errInvalidSimulation, object.Kind, KubeArmorPolicyKind, KubeArmorHostPolicyKind)
+}
+
+// SimulatePolicyTrace evaluates a policy against the given events (JSON lines of the file sink), or the events of
+// the alert archive of the node within [since, until) if none
+func (dm *KubeArmorDaemon) SimulatePolicyTrace(data, events []byte, since, until time.Time) (fd.SimulationResult, error) {
+	if len(events) > 0 {
+		return dm.SimulatePolicy(data, fd.ReadLogTrace(bytes.NewReader(events)))
+	}
+
+	if dm.Logger.Archive == nil {
+		return fd.SimulationResult{}, errNoTrace
+	}
+
+	return dm.SimulatePolicy(data, fd.ArchiveLogTrace(dm.Logger.Archive.Config.Dir, since, until))
+}
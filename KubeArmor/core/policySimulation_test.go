@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+)
+
+// simulationPolicies are the sample policies run against the recorded trace
+var simulationPolicies = []string{
+	`{"kind":"KubeArmorPolicy","metadata":{"name":"block-shell","namespace":"web"},"spec":{
+		"selector":{"matchLabels":{"app":"frontend"}},
+		"process":{"matchPaths":[{"path":"/bin/sh"},{"path":"/usr/bin/curl"}]},
+		"file":{"matchPaths":[{"path":"/etc/shadow","ownerOnly":true}]},
+		"action":"Block"}}`,
+	`{"kind":"KubeArmorPolicy","metadata":{"name":"block-binaries","namespace":"web"},"spec":{
+		"selector":{"matchExpressions":[{"key":"app","operator":"In","values":["frontend","api"]}]},
+		"process":{"matchPatterns":[{"pattern":"/usr/bin/*"}]},
+		"action":"Block"}}`,
+	`{"kind":"KubeArmorHostPolicy","metadata":{"name":"harden-host"},"spec":{
+		"nodeSelector":{"matchLabels":{"kubernetes.io/hostname":"node-1"}},
+		"process":{"matchPaths":[{"path":"/usr/bin/apt"}]},
+		"file":{"matchPaths":[{"path":"/etc/shadow","action":"Audit"}]},
+		"action":"Block"}}`,
+}
+
+// simulationSummary Structure is the matched set of a sample policy, compared with the golden file
+type simulationSummary struct {
+	Policy    string
+	Evaluated int
+
+	Matches []simulationMatchSummary
+
+	Differences map[string][]string
+	Warnings    []string
+}
+
+// simulationMatchSummary Structure
+type simulationMatchSummary struct {
+	Index       int
+	Action      string
+	Result      string
+	PolicyName  string
+	MatchedRule string
+}
+
+func TestSimulatePolicy(t *testing.T) {
+	prevHost := cfg.GlobalCfg.Host
+	defer func() { cfg.GlobalCfg.Host = prevHost }()
+	cfg.GlobalCfg.Host = "node-1"
+
+	dm := newPolicyOrderDaemon()
+	dm.Logger.Enforcer = "AppArmor"
+
+	trace, err := os.ReadFile("testdata/simulationTrace.jsonl")
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to read the trace (%s)", err.Error())
+	}
+
+	summaries := []simulationSummary{}
+
+	for _, policy := range simulationPolicies {
+		result, err := dm.SimulatePolicyTrace([]byte(policy), trace, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to simulate the policy (%s)", err.Error())
+		}
+
+		object := struct {
+			Metadata struct{ Name string }
+		}{}
+		_ = json.Unmarshal([]byte(policy), &object)
+
+		summary := simulationSummary{Policy: object.Metadata.Name, Evaluated: result.Evaluated, Matches: []simulationMatchSummary{}, Differences: result.Differences, Warnings: result.Warnings}
+		for _, match := range result.Matches {
+			summary.Matches = append(summary.Matches, simulationMatchSummary{
+				Index:       match.Index,
+				Action:      match.Action,
+				Result:      match.Result,
+				PolicyName:  match.Log.PolicyName,
+				MatchedRule: match.Log.MatchedRule,
+			})
+		}
+		summaries = append(summaries, summary)
+	}
+
+	// nothing is applied
+	if len(dm.SecurityPolicies) != 0 || len(dm.HostSecurityPolicies) != 0 || len(dm.Logger.SecurityPolicies) != 0 {
+		t.Errorf("[FAIL] Expected the simulated policies not to be applied")
+	}
+
+	got, _ := json.MarshalIndent(summaries, "", "  ")
+	got = append(got, '\n')
+
+	golden := "testdata/policySimulation.golden.json"
+	if *updateGolden {
+		if err := os.WriteFile(golden, got, 0600); err != nil {
+			t.Fatalf("[FAIL] Failed to update %s (%s)", golden, err.Error())
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to read %s (%s)", golden, err.Error())
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("[FAIL] Unexpected matches (go test -run TestSimulatePolicy -update to accept)\n%s", got)
+	}
+
+	// the invalid policies are rejected
+	if _, err := dm.SimulatePolicyTrace([]byte(`{"kind":"KubeArmorClusterPolicy","metadata":{"name":"x"}}`), trace, time.Time{}, time.Time{}); !errors.Is(err, errInvalidSimulation) {
+		t.Errorf("[FAIL] Expected the unsupported kind to be rejected (%v)", err)
+	}
+	if _, err := dm.SimulatePolicyTrace([]byte(`{"kind":"KubeArmorPolicy","metadata":{"name":"x","namespace":"web"},"spec":{"mode":"DryRun"}}`), trace, time.Time{}, time.Time{}); !errors.Is(err, errInvalidSimulation) {
+		t.Errorf("[FAIL] Expected the invalid policy to be rejected (%v)", err)
+	}
+
+	// the archive is read if no events are given
+	if _, err := dm.SimulatePolicyTrace([]byte(simulationPolicies[0]), nil, time.Time{}, time.Time{}); !errors.Is(err, errNoTrace) {
+		t.Errorf("[FAIL] Expected no trace without an archive (%v)", err)
+	}
+
+	archive, err := fd.NewAlertArchive(fd.AlertArchiveConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create the archive (%s)", err.Error())
+	}
+	archive.Now = func() time.Time { return time.Unix(1717236000, 0) }
+	for _, line := range bytes.Split(bytes.TrimSpace(trace), []byte{'\n'}) {
+		if err := archive.Write(line); err != nil {
+			t.Fatalf("[FAIL] Failed to write the archive (%s)", err.Error())
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("[FAIL] Failed to close the archive (%s)", err.Error())
+	}
+	dm.Logger.Archive = archive
+
+	// the events from 10:00:01 on
+	result, err := dm.SimulatePolicyTrace([]byte(simulationPolicies[0]), nil, time.Unix(1717236001, 0), time.Time{})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to simulate the policy on the archive (%s)", err.Error())
+	}
+	if len(result.Matches) != 1 || result.Matches[0].Log.Resource != "/usr/bin/curl" {
+		t.Errorf("[FAIL] Expected the events of the archive within the bounds (%+v)", result.Matches)
+	}
+
+	t.Log("[PASS] Simulated the policies against a recorded trace")
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mon "github.com/kubearmor/KubeArmor/KubeArmor/monitor"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetRecentExecs(t *testing.T) {
+	dm := NewKubeArmorDaemon()
+
+	probe := &Probe{}
+	if _, err := probe.GetRecentExecs(context.Background(), &pb.RecentExecsRequest{ContainerID: "nginx"}); status.Code(err) != codes.Unavailable {
+		t.Errorf("[FAIL] Expected the recent executions not to be served (%v)", err)
+	}
+
+	// without a monitor
+	if execs := dm.GetRecentExecs("nginx", "", "", time.Time{}); len(execs) != 0 {
+		t.Errorf("[FAIL] Expected no recent executions (%+v)", execs)
+	}
+
+	dm.SystemMonitor = &mon.SystemMonitor{RecentExecs: mon.NewRecentExecs(4, 16, "none")}
+
+	start := time.Now()
+
+	dm.SystemMonitor.RecentExecs.Record(tp.ExecRecord{Timestamp: start.Add(-time.Hour), ContainerID: "nginx-0123", NamespaceName: "web", PodName: "frontend", ExecPath: "/bin/sh"}, []string{"-c", "id"})
+	dm.SystemMonitor.RecentExecs.Record(tp.ExecRecord{Timestamp: start, ContainerID: "nginx-0123", NamespaceName: "web", PodName: "frontend", ExecPath: "/usr/bin/id"}, nil)
+	dm.SystemMonitor.RecentExecs.Record(tp.ExecRecord{Timestamp: start, ContainerID: "redis-4567", NamespaceName: "web", PodName: "cache", ExecPath: "/usr/bin/redis-cli"}, nil)
+
+	probe.QueryRecentExecs = dm.GetRecentExecs
+
+	if _, err := probe.GetRecentExecs(context.Background(), &pb.RecentExecsRequest{Namespace: "web"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("[FAIL] Expected a container or a pod to be required (%v)", err)
+	}
+
+	res, err := probe.GetRecentExecs(context.Background(), &pb.RecentExecsRequest{ContainerID: "nginx"})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to get the recent executions (%s)", err.Error())
+	}
+
+	if len(res.Execs) != 2 || res.Execs[0].ExecPath != "/bin/sh" || res.Execs[1].ExecPath != "/usr/bin/id" {
+		t.Fatalf("[FAIL] Expected the executions of the container in order (%+v)", res.Execs)
+	}
+	if res.Execs[0].Pod != "frontend" || len(res.Execs[0].Args) != 2 || res.Execs[0].ArgsHash == "" {
+		t.Errorf("[FAIL] Expected the details of the execution (%+v)", res.Execs[0])
+	}
+
+	res, err = probe.GetRecentExecs(context.Background(), &pb.RecentExecsRequest{Namespace: "web", Pod: "frontend", Since: start.Add(-time.Minute).Unix()})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to get the recent executions (%s)", err.Error())
+	}
+
+	if len(res.Execs) != 1 || res.Execs[0].ExecPath != "/usr/bin/id" {
+		t.Errorf("[FAIL] Expected the executions of the pod since the given time (%+v)", res.Execs)
+	}
+
+	t.Log("[PASS] Got the recent executions through the probe service")
+}
@@ -0,0 +1,606 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/golang/protobuf/ptypes/empty"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	mon "github.com/kubearmor/KubeArmor/KubeArmor/monitor"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ============ //
+// == Resync == //
+// ============ //
+
+// ResyncMinInterval is the minimum interval between two resyncs
+const ResyncMinInterval = time.Minute
+
+var (
+	errResyncInProgress  = errors.New("a resync is already in progress")
+	errResyncRateLimited = errors.New("the last resync was less than a minute ago")
+)
+
+// ResyncSummary Structure
+type ResyncSummary struct {
+	ContainersAdded   []string
+	ContainersRemoved []string
+	NsMapRestored     []string
+
+	PoliciesAdded   []string
+	PoliciesRemoved []string
+
+	EndPointsReapplied int
+
+	Duration time.Duration
+}
+
+// String Function
+func (rs ResyncSummary) String() string {
+	return fmt.Sprintf("containers +%d/-%d, nsMap +%d, policies +%d/-%d, endpoints %d, took %s",
+		len(rs.ContainersAdded), len(rs.ContainersRemoved), len(rs.NsMapRestored),
+		len(rs.PoliciesAdded), len(rs.PoliciesRemoved), rs.EndPointsReapplied, rs.Duration)
+}
+
+// runtimeContainers Structure
+type runtimeContainers struct {
+	// running containers, with the function registering each of them
+	Running map[string]func() bool
+
+	// functions unregistering a container, per runtime
+	Remove map[string]func(containerID string) bool
+
+	// containers known before the listing started, the ones registered in the meantime aren't stale
+	Known map[string]struct{}
+
+	// whether every runtime was listed, containers are only removed then
+	Complete bool
+}
+
+// listRuntimeContainers lists the containers from the runtime handlers
+func (dm *KubeArmorDaemon) listRuntimeContainers() runtimeContainers {
+	rc := runtimeContainers{Running: map[string]func() bool{}, Remove: map[string]func(string) bool{}, Known: map[string]struct{}{}, Complete: true}
+
+	dm.ContainersLock.RLock()
+	for containerID := range dm.Containers {
+		rc.Known[containerID] = struct{}{}
+	}
+	dm.ContainersLock.RUnlock()
+
+	if dm.containerd != nil {
+		containers, err := dm.containerd.GetContainerdContainers()
+		if err != nil {
+			kg.Warnf("Failed to list Containerd containers (%s)", err.Error())
+			rc.Complete = false
+		}
+
+		for containerID, ctx := range containers {
+			containerID, ctx := containerID, ctx
+			rc.Running[containerID] = func() bool {
+				return dm.UpdateContainerdContainer(ctx, containerID, "start")
+			}
+		}
+
+		rc.Remove[RuntimeContainerd] = func(containerID string) bool {
+			return dm.UpdateContainerdContainer(context.TODO(), containerID, "destroy")
+		}
+	}
+
+	if crio := dm.getCrio(); crio != nil {
+		containers, err := crio.GetCrioContainers(context.Background())
+		if err != nil {
+			kg.Warnf("Failed to list CRI-O containers (%s)", err.Error())
+			rc.Complete = false
+		}
+
+		for containerID := range containers {
+			containerID := containerID
+			rc.Running[containerID] = func() bool {
+				return dm.UpdateCrioContainer(context.Background(), containerID, "start")
+			}
+		}
+
+		rc.Remove[RuntimeCrio] = func(containerID string) bool {
+			return dm.UpdateCrioContainer(context.Background(), containerID, "destroy")
+		}
+	}
+
+	if dm.podman != nil {
+		containers, err := dm.podman.GetPodmanContainers()
+		if err != nil {
+			kg.Warnf("Failed to list Podman containers (%s)", err.Error())
+			rc.Complete = false
+		}
+
+		for containerID := range containers {
+			containerID := containerID
+			rc.Running[containerID] = func() bool {
+				return dm.UpdatePodmanContainer(context.Background(), containerID, "start")
+			}
+		}
+
+		rc.Remove[RuntimePodman] = func(containerID string) bool {
+			return dm.UpdatePodmanContainer(context.Background(), containerID, "destroy")
+		}
+	}
+
+	if dm.docker != nil && dm.containerd == nil {
+		containers, err := dm.docker.DockerClient.ContainerList(context.Background(), types.ContainerListOptions{})
+		if err != nil {
+			kg.Warnf("Failed to list Docker containers (%s)", err.Error())
+			rc.Complete = false
+		}
+
+		for _, container := range containers {
+			containerID := container.ID
+			rc.Running[containerID] = func() bool {
+				dm.UpdateDockerContainer(containerID, "start")
+				return true
+			}
+		}
+
+		rc.Remove[RuntimeDocker] = func(containerID string) bool {
+			dm.UpdateDockerContainer(containerID, "destroy")
+			return true
+		}
+	}
+
+	if len(rc.Remove) == 0 {
+		rc.Complete = false
+	}
+
+	return rc
+}
+
+// removeFunc returns the function unregistering a container from its runtime (the only one monitored if unknown)
+func (rc runtimeContainers) removeFunc(runtime string) func(containerID string) bool {
+	if remove, ok := rc.Remove[runtime]; ok {
+		return remove
+	}
+
+	if runtime == "" && len(rc.Remove) == 1 {
+		for _, remove := range rc.Remove {
+			return remove
+		}
+	}
+
+	return nil
+}
+
+// reconcileContainers registers the running containers missed by the runtime handlers,
+// and unregisters the containers known before the listing that the runtimes don't list anymore
+func (dm *KubeArmorDaemon) reconcileContainers(rc runtimeContainers) ([]string, []string) {
+	missing := []string{}
+	stale := []string{}
+
+	// containerID -> the runtime of a stale container
+	runtimes := map[string]string{}
+
+	dm.ContainersLock.RLock()
+	for containerID := range rc.Running {
+		if container, ok := dm.Containers[containerID]; !ok || (container.PidNS == 0 && container.MntNS == 0) {
+			missing = append(missing, containerID)
+		}
+	}
+	if rc.Complete {
+		for containerID, container := range dm.Containers {
+			if container.PidNS == 0 && container.MntNS == 0 {
+				continue
+			}
+			if _, ok := rc.Known[containerID]; !ok {
+				continue
+			}
+			if _, ok := rc.Running[containerID]; !ok {
+				stale = append(stale, containerID)
+				runtimes[containerID] = container.Runtime
+			}
+		}
+	}
+	dm.ContainersLock.RUnlock()
+
+	sort.Strings(missing)
+	sort.Strings(stale)
+
+	added := []string{}
+	for _, containerID := range missing {
+		if rc.Running[containerID]() {
+			added = append(added, containerID)
+		}
+	}
+
+	removed := []string{}
+	for _, containerID := range stale {
+		remove := rc.removeFunc(runtimes[containerID])
+		if remove == nil {
+			kg.Warnf("Skipped a stale container of an unmonitored runtime (%.12s, %s)", containerID, runtimes[containerID])
+			continue
+		}
+		if remove(containerID) {
+			removed = append(removed, containerID)
+		}
+	}
+
+	return added, removed
+}
+
+// reconcileNsMap restores the NsMap entries of the known containers
+func (dm *KubeArmorDaemon) reconcileNsMap() []string {
+	restored := []string{}
+
+	if dm.SystemMonitor == nil || !cfg.GlobalCfg.Policy {
+		return restored
+	}
+
+	containers := []tp.Container{}
+
+	dm.ContainersLock.RLock()
+	for _, container := range dm.Containers {
+		if container.PidNS != 0 || container.MntNS != 0 {
+			containers = append(containers, container)
+		}
+	}
+	dm.ContainersLock.RUnlock()
+
+	for _, container := range containers {
+		dm.SystemMonitor.NsMapLock.RLock()
+		containerID, ok := dm.SystemMonitor.NsMap[mon.NsKey{PidNS: container.PidNS, MntNS: container.MntNS}]
+		dm.SystemMonitor.NsMapLock.RUnlock()
+
+		if ok && containerID == container.ContainerID {
+			continue
+		}
+
+		dm.SystemMonitor.AddContainerIDToNsMap(container.ContainerID, container.NamespaceName, container.PidNS, container.MntNS)
+		if dm.RuntimeEnforcer != nil {
+			dm.RuntimeEnforcer.RegisterContainer(container.ContainerID, container.PidNS, container.MntNS)
+		}
+
+		restored = append(restored, container.ContainerID)
+	}
+
+	sort.Strings(restored)
+
+	return restored
+}
+
+// policyKey returns the namespace/name of a security policy
+func policyKey(secPolicy tp.SecurityPolicy) string {
+	return secPolicy.Metadata["namespaceName"] + "/" + secPolicy.Metadata["policyName"]
+}
+
+// reconcileSecurityPolicies adds the desired policies missing from the daemon,
+// and removes the ones which are not desired anymore
+func (dm *KubeArmorDaemon) reconcileSecurityPolicies(desired []tp.SecurityPolicy) ([]tp.SecurityPolicy, []tp.SecurityPolicy) {
+	desiredKeys := map[string]struct{}{}
+	for _, secPolicy := range desired {
+		desiredKeys[policyKey(secPolicy)] = struct{}{}
+	}
+
+	added := []tp.SecurityPolicy{}
+	removed := []tp.SecurityPolicy{}
+
+	dm.SecurityPoliciesLock.Lock()
+	currentKeys := map[string]struct{}{}
+	kept := []tp.SecurityPolicy{}
+	for _, secPolicy := range dm.SecurityPolicies {
+		currentKeys[policyKey(secPolicy)] = struct{}{}
+		// the node-local overrides are kept until they expire, and the cluster policies are kept by their own watcher
+		if _, ok := desiredKeys[policyKey(secPolicy)]; ok || fd.PolicyOverride(secPolicy.Metadata) || ClusterPolicy(secPolicy.Metadata) {
+			kept = append(kept, secPolicy)
+		} else {
+			removed = append(removed, secPolicy)
+		}
+	}
+	for _, secPolicy := range desired {
+		if _, ok := currentKeys[policyKey(secPolicy)]; !ok {
+			kept = append(kept, secPolicy)
+			added = append(added, secPolicy)
+		}
+	}
+	dm.SecurityPolicies = kept
+	dm.SecurityPoliciesLock.Unlock()
+
+	return added, removed
+}
+
+// resyncSecurityPolicies re-lists the security policies from the API server (or the policy dir)
+func (dm *KubeArmorDaemon) resyncSecurityPolicies() ([]string, []string) {
+	added := []string{}
+	removed := []string{}
+
+	if !cfg.GlobalCfg.Policy {
+		return added, removed
+	}
+
+	if dm.K8sEnabled {
+		if K8s == nil || K8s.KSPClient == nil {
+			return added, removed
+		}
+
+		policies, err := K8s.KSPClient.SecurityV1().KubeArmorPolicies("").List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			kg.Warnf("Failed to list security policies (%s)", err.Error())
+			return added, removed
+		}
+
+		desired := []tp.SecurityPolicy{}
+		for _, policy := range policies.Items {
+			secPolicy, err := dm.CreateSecurityPolicy(policy)
+			if err != nil {
+				kg.Warnf("Failed to resync a security policy (%s/%s, %s)", policy.Namespace, policy.Name, err.Error())
+				continue
+			}
+			desired = append(desired, secPolicy)
+		}
+
+		addedPolicies, removedPolicies := dm.reconcileSecurityPolicies(desired)
+
+		for _, secPolicy := range addedPolicies {
+			dm.UpdateSecurityPolicy("ADDED", secPolicy)
+			added = append(added, policyKey(secPolicy))
+		}
+		for _, secPolicy := range removedPolicies {
+			dm.UpdateSecurityPolicy("DELETED", secPolicy)
+			removed = append(removed, policyKey(secPolicy))
+		}
+
+		return added, removed
+	}
+
+	// policies received on gRPC are only restored from the policy dir, since nothing else lists them
+	if _, err := os.Stat(cfg.PolicyDir); err != nil {
+		return added, removed
+	}
+
+	policyFiles, _, err := loadPolicyCache(cfg.PolicyDir, cfg.PolicyDigestDir, getPolicyCacheKey())
+	if err != nil {
+		kg.Warnf("Failed to read the policy cache (%s)", err.Error())
+		return added, removed
+	}
+
+	known := map[string]struct{}{}
+
+	dm.SecurityPoliciesLock.RLock()
+	for _, secPolicy := range dm.SecurityPolicies {
+		known[policyKey(secPolicy)] = struct{}{}
+	}
+	dm.SecurityPoliciesLock.RUnlock()
+
+	for _, data := range policyFiles {
+		var k struct {
+			Metadata map[string]string `json:"metadata"`
+		}
+
+		if err := json.Unmarshal(data, &k); err != nil {
+			continue
+		}
+
+		// host policies are re-applied as they are
+		if _, ok := k.Metadata["namespaceName"]; !ok {
+			continue
+		}
+
+		key := k.Metadata["namespaceName"] + "/" + k.Metadata["policyName"]
+		if _, ok := known[key]; ok {
+			continue
+		}
+
+		var containerPolicy tp.K8sKubeArmorPolicy
+		if err := json.Unmarshal(data, &containerPolicy); err != nil {
+			continue
+		}
+		containerPolicy.Metadata.Name = k.Metadata["policyName"]
+
+		if dm.ParseAndUpdateContainerSecurityPolicy(tp.K8sKubeArmorPolicyEvent{Type: "ADDED", Object: containerPolicy}) == pb.PolicyStatus_Applied {
+			added = append(added, key)
+		}
+	}
+
+	return added, removed
+}
+
+// reapplySecurityPolicies regenerates the enforcement of all endpoints and of the host
+func (dm *KubeArmorDaemon) reapplySecurityPolicies() int {
+	reapplied := 0
+
+	if cfg.GlobalCfg.Policy {
+		dm.EndPointsLock.Lock()
+		for _, endPoint := range dm.EndPoints {
+			if dm.Logger != nil {
+				dm.Logger.UpdateSecurityPolicies("UPDATED", endPoint)
+			}
+			if dm.RuntimeEnforcer != nil && endPoint.PolicyEnabled == tp.KubeArmorPolicyEnabled {
+				dm.RuntimeEnforcer.UpdateSecurityPolicies(endPoint)
+			}
+			reapplied++
+		}
+		dm.EndPointsLock.Unlock()
+	}
+
+	if cfg.GlobalCfg.HostPolicy && dm.RuntimeEnforcer != nil {
+		dm.UpdateHostSecurityPolicies()
+	}
+
+	return reapplied
+}
+
+// TriggerResync re-lists the containers and the policies, reconciles the state of the daemon
+// with them, and regenerates the enforcement (one at a time, at most once a minute)
+func (dm *KubeArmorDaemon) TriggerResync() (ResyncSummary, error) {
+	if !dm.ResyncLock.TryLock() {
+		return ResyncSummary{}, errResyncInProgress
+	}
+	defer dm.ResyncLock.Unlock()
+
+	if !dm.LastResync.IsZero() && time.Since(dm.LastResync) < ResyncMinInterval {
+		return ResyncSummary{}, errResyncRateLimited
+	}
+	dm.LastResync = time.Now()
+
+	summary := ResyncSummary{}
+
+	summary.ContainersAdded, summary.ContainersRemoved = dm.reconcileContainers(dm.listRuntimeContainers())
+	summary.NsMapRestored = dm.reconcileNsMap()
+	summary.PoliciesAdded, summary.PoliciesRemoved = dm.resyncSecurityPolicies()
+	summary.EndPointsReapplied = dm.reapplySecurityPolicies()
+
+	summary.Duration = time.Since(dm.LastResync)
+
+	kg.Printf("Resynced containers and policies (%s)", summary)
+
+	return summary, nil
+}
+
+// WatchResyncSignal triggers a resync on SIGUSR1
+func (dm *KubeArmorDaemon) WatchResyncSignal() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR1)
+	defer signal.Stop(sigChan)
+
+	for {
+		select {
+		case <-StopChan:
+			return
+		case <-sigChan:
+			if _, err := dm.TriggerResync(); err != nil {
+				kg.Warnf("Skipped a resync (%s)", err.Error())
+			}
+		}
+	}
+}
+
+// =================== //
+// == Admin Service == //
+// =================== //
+
+// Admin provides structure to serve the admin gRPC service
+type Admin struct {
+	pb.AdminServiceServer
+	Resync  func() (ResyncSummary, error)
+	Preview func(data map[string]string) (ConfigDiff, error)
+
+	Simulate func(policy, events []byte, since, until time.Time) (fd.SimulationResult, error)
+}
+
+// TriggerResync Function
+func (a *Admin) TriggerResync(ctx context.Context, in *empty.Empty) (*pb.ResyncResponse, error) {
+	if a.Resync == nil {
+		return nil, status.Error(codes.Unimplemented, "resync is not available")
+	}
+
+	summary, err := a.Resync()
+	if errors.Is(err, errResyncInProgress) || errors.Is(err, errResyncRateLimited) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.ResyncResponse{
+		ContainersAdded:    summary.ContainersAdded,
+		ContainersRemoved:  summary.ContainersRemoved,
+		NsMapRestored:      summary.NsMapRestored,
+		PoliciesAdded:      summary.PoliciesAdded,
+		PoliciesRemoved:    summary.PoliciesRemoved,
+		EndpointsReapplied: int32(summary.EndPointsReapplied),
+		DurationMs:         summary.Duration.Milliseconds(),
+	}, nil
+}
+
+// PreviewConfigChange Function
+func (a *Admin) PreviewConfigChange(ctx context.Context, in *pb.ConfigPreviewRequest) (*pb.ConfigPreview, error) {
+	if a.Preview == nil {
+		return nil, status.Error(codes.Unimplemented, "config preview is not available")
+	}
+
+	diff, err := a.Preview(in.Data)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	preview := &pb.ConfigPreview{Warnings: diff.Warnings}
+
+	for _, field := range diff.Fields {
+		preview.Fields = append(preview.Fields, &pb.ConfigFieldChange{Field: field.Field, Old: field.Old, New: field.New})
+	}
+
+	for _, effect := range diff.Effects {
+		preview.Effects = append(preview.Effects, &pb.ConfigEffect{
+			Namespace: effect.Namespace,
+			Endpoint:  effect.EndPoint,
+			Kind:      effect.Kind,
+			Target:    effect.Target,
+			Old:       effect.Old,
+			New:       effect.New,
+		})
+	}
+
+	return preview, nil
+}
+
+// unixTime returns the time of unix seconds (zero for no bound)
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// SimulatePolicy Function
+func (a *Admin) SimulatePolicy(ctx context.Context, in *pb.SimulationRequest) (*pb.SimulationResult, error) {
+	if a.Simulate == nil {
+		return nil, status.Error(codes.Unimplemented, "policy simulation is not available")
+	}
+
+	result, err := a.Simulate(in.Policy, in.Events, unixTime(in.Since), unixTime(in.Until))
+	if errors.Is(err, errInvalidSimulation) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if errors.Is(err, errNoTrace) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	simulation := &pb.SimulationResult{Evaluated: int64(result.Evaluated), Warnings: result.Warnings}
+
+	for _, match := range result.Matches {
+		event, _ := json.Marshal(match.Log)
+		simulation.Matches = append(simulation.Matches, &pb.SimulatedMatch{
+			Index:       int64(match.Index),
+			Action:      match.Action,
+			Result:      match.Result,
+			PolicyName:  match.Log.PolicyName,
+			MatchedRule: match.Log.MatchedRule,
+			Event:       string(event),
+		})
+	}
+
+	enforcers := []string{}
+	for enforcer := range result.Differences {
+		enforcers = append(enforcers, enforcer)
+	}
+	sort.Strings(enforcers)
+
+	for _, enforcer := range enforcers {
+		simulation.Differences = append(simulation.Differences, &pb.EnforcerDifferences{Enforcer: enforcer, Differences: result.Differences[enforcer]})
+	}
+
+	return simulation, nil
+}
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"errors"
+	"testing"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestReconcileContainers(t *testing.T) {
+	dm := NewKubeArmorDaemon()
+
+	// c1 is known, c2 was missed, c3 is gone, c4 was registered without namespaces
+	dm.Containers["c1"] = tp.Container{ContainerID: "c1", PidNS: 1, MntNS: 1}
+	dm.Containers["c3"] = tp.Container{ContainerID: "c3", PidNS: 3, MntNS: 3}
+	dm.Containers["c4"] = tp.Container{ContainerID: "c4"}
+
+	started := []string{}
+	start := func(containerID string) func() bool {
+		return func() bool {
+			started = append(started, containerID)
+			dm.Containers[containerID] = tp.Container{ContainerID: containerID, PidNS: 9, MntNS: 9}
+			return true
+		}
+	}
+
+	rc := runtimeContainers{
+		Running: map[string]func() bool{"c1": start("c1"), "c2": start("c2"), "c4": start("c4")},
+		Remove: map[string]func(string) bool{
+			RuntimeContainerd: func(containerID string) bool {
+				delete(dm.Containers, containerID)
+				return true
+			},
+		},
+		Known:    map[string]struct{}{"c1": {}, "c3": {}, "c4": {}},
+		Complete: false,
+	}
+
+	// nothing is removed unless every runtime was listed
+	added, removed := dm.reconcileContainers(rc)
+	if len(added) != 2 || added[0] != "c2" || added[1] != "c4" || len(removed) != 0 {
+		t.Errorf("[FAIL] Unexpected reconciliation of a partial listing (%v, %v)", added, removed)
+	}
+
+	rc.Complete = true
+
+	added, removed = dm.reconcileContainers(rc)
+	if len(added) != 0 || len(removed) != 1 || removed[0] != "c3" {
+		t.Errorf("[FAIL] Unexpected reconciliation of a complete listing (%v, %v)", added, removed)
+	}
+
+	if _, ok := dm.Containers["c3"]; ok || len(started) != 2 {
+		t.Errorf("[FAIL] Unexpected containers (%v, %v)", dm.Containers, started)
+	}
+
+	t.Log("[PASS] Reconciled the containers with the runtime")
+}
+
+func TestReconcileContainersDuringListing(t *testing.T) {
+	dm := NewKubeArmorDaemon()
+
+	removedBy := map[string]string{}
+	remove := func(runtime string) func(string) bool {
+		return func(containerID string) bool {
+			removedBy[containerID] = runtime
+			delete(dm.Containers, containerID)
+			return true
+		}
+	}
+
+	rc := runtimeContainers{
+		Running:  map[string]func() bool{},
+		Remove:   map[string]func(string) bool{RuntimeDocker: remove(RuntimeDocker), RuntimePodman: remove(RuntimePodman)},
+		Known:    map[string]struct{}{"docker": {}, "podman": {}, "unknown": {}},
+		Complete: true,
+	}
+
+	dm.Containers["docker"] = tp.Container{ContainerID: "docker", PidNS: 1, MntNS: 1, Runtime: RuntimeDocker}
+	dm.Containers["podman"] = tp.Container{ContainerID: "podman", PidNS: 2, MntNS: 2, Runtime: RuntimePodman}
+	dm.Containers["unknown"] = tp.Container{ContainerID: "unknown", PidNS: 3, MntNS: 3}
+
+	// registered after the listing started
+	dm.Containers["new"] = tp.Container{ContainerID: "new", PidNS: 4, MntNS: 4, Runtime: RuntimeDocker}
+
+	_, removed := dm.reconcileContainers(rc)
+	if len(removed) != 2 || removed[0] != "docker" || removed[1] != "podman" {
+		t.Errorf("[FAIL] Unexpected stale containers (%v)", removed)
+	}
+
+	// each container is removed by its own runtime
+	if removedBy["docker"] != RuntimeDocker || removedBy["podman"] != RuntimePodman {
+		t.Errorf("[FAIL] Unexpected runtimes of the removals (%v)", removedBy)
+	}
+
+	// the container of an unknown runtime is kept with several runtimes, and so is the one registered in the meantime
+	for _, containerID := range []string{"unknown", "new"} {
+		if _, ok := dm.Containers[containerID]; !ok {
+			t.Errorf("[FAIL] Unexpected removal of %s", containerID)
+		}
+	}
+
+	t.Log("[PASS] Removed the stale containers known before the listing by their runtimes")
+}
+
+func TestTriggerResyncRateLimit(t *testing.T) {
+	dm := NewKubeArmorDaemon()
+
+	if _, err := dm.TriggerResync(); err != nil {
+		t.Fatalf("[FAIL] Failed to resync (%s)", err.Error())
+	}
+
+	if _, err := dm.TriggerResync(); !errors.Is(err, errResyncRateLimited) {
+		t.Errorf("[FAIL] Expected the resync to be rate-limited (%v)", err)
+	}
+
+	// one resync at a time
+	dm.LastResync = dm.LastResync.Add(-ResyncMinInterval)
+	dm.ResyncLock.Lock()
+
+	if _, err := dm.TriggerResync(); !errors.Is(err, errResyncInProgress) {
+		t.Errorf("[FAIL] Expected a resync in progress (%v)", err)
+	}
+
+	dm.ResyncLock.Unlock()
+
+	if _, err := dm.TriggerResync(); err != nil {
+		t.Errorf("[FAIL] Failed to resync after the interval (%s)", err.Error())
+	}
+
+	t.Log("[PASS] Rate-limited the resyncs")
+}
@@ -0,0 +1,409 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+)
+
+// ======================== //
+// == Rule Consolidation == //
+// ======================== //
+
+// RuleConsolidationApproximate is the value of the ruleConsolidation annotation of the policies
+// whose exact matchPaths can be merged into directory rules
+const RuleConsolidationApproximate = "approximate"
+
+// minimum number of the matchPaths of a directory to merge
+const minConsolidatedPaths = 2
+
+// dirEntries returns the names of the entries of a directory (ending with /)
+type dirEntries func(dir string) []string
+
+// ruleAttributes returns the attributes of a rule apart from the given fields (its path or its directory),
+// in a form which can be compared between the path and the directory rules
+func ruleAttributes(rule interface{}, fields ...string) string {
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return ""
+	}
+
+	attrs := map[string]interface{}{}
+	if err := json.Unmarshal(data, &attrs); err != nil {
+		return ""
+	}
+
+	for _, field := range fields {
+		delete(attrs, field)
+	}
+
+	// the keys of a map are sorted
+	key, err := json.Marshal(attrs)
+	if err != nil {
+		return ""
+	}
+
+	return string(key)
+}
+
+// directoryRule converts a path rule into the rule of the given directory with the same attributes,
+// and returns false if the directory rule can't keep all of them (e.g., the rate of a Throttle rule)
+func directoryRule[P any, D any](path P, dir string) (D, bool) {
+	var rule D
+
+	data, err := json.Marshal(path)
+	if err != nil {
+		return rule, false
+	}
+
+	attrs := map[string]interface{}{}
+	if err := json.Unmarshal(data, &attrs); err != nil {
+		return rule, false
+	}
+	delete(attrs, "path")
+	attrs["dir"] = dir
+
+	if data, err = json.Marshal(attrs); err != nil {
+		return rule, false
+	}
+	if err := json.Unmarshal(data, &rule); err != nil {
+		return rule, false
+	}
+
+	return rule, ruleAttributes(rule, "dir", "recursive") == ruleAttributes(path, "path")
+}
+
+// coversPath checks if a directory rule matches a path
+func coversPath(dir string, recursive bool, path string) bool {
+	if !strings.HasSuffix(dir, "/") {
+		dir = dir + "/"
+	}
+
+	if recursive {
+		return strings.HasPrefix(path, dir)
+	}
+
+	return filepath.Dir(path)+"/" == dir
+}
+
+// consolidateRules merges the matchPaths of a kind of rules (process or file)
+//   - The duplicated matchPaths, and the ones matched by a matchDirectories rule with the same attributes, are removed
+//     (this doesn't change what the rules match)
+//   - The exact matchPaths of a directory with the same attributes, which allow at least the given ratio of the entries
+//     of the directory, are reported, and merged into a matchDirectories rule if approximate (the directory rule also
+//     matches the entries of the directory which weren't allowed one by one, including the ones created later)
+func consolidateRules[P any, D any](kind string, paths []P, dirs []D, pathOf func(P) string, dirOf func(D) (string, bool), entries dirEntries, ratio float64, approximate bool) ([]P, []D, []string) {
+	notes := []string{}
+
+	dirKeys := make([]string, len(dirs))
+	for idx, dir := range dirs {
+		dirKeys[idx] = ruleAttributes(dir, "dir", "recursive")
+	}
+
+	// lossless
+	kept := []P{}
+	keys := []string{}
+	seen := map[string]bool{}
+	removed := 0
+
+	for _, path := range paths {
+		key := ruleAttributes(path, "path")
+
+		if seen[pathOf(path)+key] {
+			removed++
+			continue
+		}
+
+		covered := false
+		for idx, dir := range dirs {
+			if d, recursive := dirOf(dir); dirKeys[idx] == key && coversPath(d, recursive, pathOf(path)) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			removed++
+			continue
+		}
+
+		seen[pathOf(path)+key] = true
+		kept = append(kept, path)
+		keys = append(keys, key)
+	}
+
+	if removed > 0 {
+		notes = append(notes, fmt.Sprintf("%s.matchPaths: removed %d rules already matched by other rules", kind, removed))
+	} else {
+		// unchanged
+		kept = paths
+	}
+
+	if ratio <= 0 || entries == nil {
+		return kept, dirs, notes
+	}
+
+	// the exact matchPaths by directory and attributes
+	type pathGroup struct {
+		dir     string
+		indices []int
+	}
+
+	groups := map[string]*pathGroup{}
+	order := []string{}
+
+	for idx, path := range kept {
+		p := pathOf(path)
+		if strings.ContainsAny(p, "*?[") || strings.HasSuffix(p, "/") {
+			continue
+		}
+
+		dir := filepath.Dir(p) + "/"
+		if dir == "//" {
+			dir = "/"
+		}
+
+		groupKey := dir + "\x00" + keys[idx]
+		if _, ok := groups[groupKey]; !ok {
+			groups[groupKey] = &pathGroup{dir: dir}
+			order = append(order, groupKey)
+		}
+		groups[groupKey].indices = append(groups[groupKey].indices, idx)
+	}
+
+	merged := map[int]bool{}
+
+	for _, groupKey := range order {
+		group := groups[groupKey]
+		if len(group.indices) < minConsolidatedPaths {
+			continue
+		}
+
+		names := entries(group.dir)
+		if len(names) == 0 {
+			continue
+		}
+
+		allowed := 0
+		for _, idx := range group.indices {
+			if kl.ContainsElement(names, filepath.Base(pathOf(kept[idx]))) {
+				allowed++
+			}
+		}
+
+		if float64(allowed) < ratio*float64(len(names)) {
+			continue
+		}
+
+		rule, ok := directoryRule[P, D](kept[group.indices[0]], group.dir)
+		if !ok {
+			continue
+		}
+
+		if !approximate {
+			notes = append(notes, fmt.Sprintf("%s.matchPaths: %d of %d entries of %s are allowed one by one, they can be merged into matchDirectories %s (%s)",
+				kind, allowed, len(names), group.dir, group.dir, ksp.RuleConsolidationAnnotation+"="+RuleConsolidationApproximate))
+			continue
+		}
+
+		for _, idx := range group.indices {
+			merged[idx] = true
+		}
+		dirs = append(dirs, rule)
+
+		notes = append(notes, fmt.Sprintf("%s.matchPaths: merged %d rules into matchDirectories %s (%d of %d entries were allowed)",
+			kind, len(group.indices), group.dir, allowed, len(names)))
+	}
+
+	if len(merged) > 0 {
+		consolidated := []P{}
+		for idx, path := range kept {
+			if !merged[idx] {
+				consolidated = append(consolidated, path)
+			}
+		}
+		kept = consolidated
+	}
+
+	return kept, dirs, notes
+}
+
+// ConsolidateSecurityPolicy merges the process and file rules of a policy (see consolidateRules),
+// and returns the notes of the consolidation
+func ConsolidateSecurityPolicy(spec *tp.SecuritySpec, entries dirEntries, ratio float64, approximate bool) []string {
+	notes := []string{}
+
+	processPaths, processDirs, processNotes := consolidateRules("process", spec.Process.MatchPaths, spec.Process.MatchDirectories,
+		func(p tp.ProcessPathType) string { return p.Path },
+		func(d tp.ProcessDirectoryType) (string, bool) { return d.Directory, d.Recursive },
+		entries, ratio, approximate)
+	spec.Process.MatchPaths, spec.Process.MatchDirectories = processPaths, processDirs
+	notes = append(notes, processNotes...)
+
+	filePaths, fileDirs, fileNotes := consolidateRules("file", spec.File.MatchPaths, spec.File.MatchDirectories,
+		func(p tp.FilePathType) string { return p.Path },
+		func(d tp.FileDirectoryType) (string, bool) { return d.Directory, d.Recursive },
+		entries, ratio, approximate)
+	spec.File.MatchPaths, spec.File.MatchDirectories = filePaths, fileDirs
+	notes = append(notes, fileNotes...)
+
+	return notes
+}
+
+// containerDirEntries returns the entries of a directory in the root filesystems of the given containers
+func containerDirEntries(mergedDirs []string) dirEntries {
+	return func(dir string) []string {
+		names := []string{}
+
+		for _, mergedDir := range mergedDirs {
+			entries, err := os.ReadDir(filepath.Join(mergedDir, dir))
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if !kl.ContainsElement(names, entry.Name()) {
+					names = append(names, entry.Name())
+				}
+			}
+		}
+
+		sort.Strings(names)
+		return names
+	}
+}
+
+// consolidateSecurityPolicy merges the rules of a policy (unless the consolidation is disabled), with the entries
+// of the directories in the containers currently selected by the policy, and keeps the notes of the consolidation
+func (dm *KubeArmorDaemon) consolidateSecurityPolicy(secPolicy *tp.SecurityPolicy, mode string) {
+	if cfg.GlobalCfg.RuleConsolidationRatio <= 0 {
+		return
+	}
+
+	mergedDirs := []string{}
+
+	dm.EndPointsLock.RLock()
+	containerIDs := []string{}
+	for _, endPoint := range dm.EndPoints {
+		if matchSelector(secPolicy.Spec.Selector, endPoint.Identities) && (len(secPolicy.Spec.Selector.Containers) == 0 || kl.ContainsElement(secPolicy.Spec.Selector.Containers, endPoint.ContainerName)) {
+			containerIDs = append(containerIDs, endPoint.Containers...)
+		}
+	}
+	dm.EndPointsLock.RUnlock()
+
+	dm.ContainersLock.RLock()
+	for _, containerID := range containerIDs {
+		// the root filesystems of the paused containers are looked up once they're unpaused
+		if container, ok := dm.Containers[containerID]; ok && container.MergedDir != "" && container.State != tp.ContainerStatePaused {
+			mergedDirs = append(mergedDirs, container.MergedDir)
+		}
+	}
+	dm.ContainersLock.RUnlock()
+
+	var entries dirEntries
+	if len(mergedDirs) > 0 {
+		entries = containerDirEntries(mergedDirs)
+	}
+
+	notes := ConsolidateSecurityPolicy(&secPolicy.Spec, entries, cfg.GlobalCfg.RuleConsolidationRatio, mode == RuleConsolidationApproximate)
+
+	key := secPolicy.Metadata["namespaceName"] + "/" + secPolicy.Metadata["policyName"]
+	if len(notes) > 0 {
+		dm.Logger.Printf("Consolidated the rules of a security policy (%s, %s)", key, strings.Join(notes, "; "))
+	}
+
+	dm.RuleConsolidationsLock.Lock()
+	dm.RuleConsolidations[key] = notes
+	dm.RuleConsolidationsLock.Unlock()
+}
+
+// ruleConsolidationNotes returns the notes of the last consolidation of a policy
+func (dm *KubeArmorDaemon) ruleConsolidationNotes(namespaceName, policyName string) []string {
+	dm.RuleConsolidationsLock.Lock()
+	defer dm.RuleConsolidationsLock.Unlock()
+
+	return dm.RuleConsolidations[namespaceName+"/"+policyName]
+}
+
+// forgetRuleConsolidation removes the notes of a deleted policy
+func (dm *KubeArmorDaemon) forgetRuleConsolidation(namespaceName, policyName string) {
+	dm.RuleConsolidationsLock.Lock()
+	defer dm.RuleConsolidationsLock.Unlock()
+
+	delete(dm.RuleConsolidations, namespaceName+"/"+policyName)
+}
+
+// policyWarnings returns the warnings reported with the compatibility of a policy
+// (the matchPaths found in no container, and the notes of the consolidation of its rules)
+func (dm *KubeArmorDaemon) policyWarnings(namespaceName, policyName string) []string {
+	return append(append([]string{}, dm.policyPathWarnings(namespaceName, policyName)...), dm.ruleConsolidationNotes(namespaceName, policyName)...)
+}
+
+// ==================== //
+// == Endpoint Rules == //
+// ==================== //
+
+// countPolicyRules returns the number of the rules of a policy
+func countPolicyRules(spec tp.SecuritySpec) int {
+	count := len(spec.Process.MatchPaths) + len(spec.Process.MatchDirectories) + len(spec.Process.MatchPatterns) +
+		len(spec.Process.MatchNamespaces) + len(spec.Process.MatchSignals)
+	count += len(spec.File.MatchPaths) + len(spec.File.MatchDirectories) + len(spec.File.MatchPatterns) +
+		len(spec.File.MatchXattrs) + len(spec.File.MatchImmutable)
+	count += len(spec.Network.MatchProtocols) + len(spec.Capabilities.MatchCapabilities)
+	count += len(spec.Syscalls.MatchSyscalls) + len(spec.Syscalls.MatchPaths)
+
+	return count
+}
+
+// endPointRulesKey returns the key of an endpoint in the rule counts warned about
+func endPointRulesKey(endPoint tp.EndPoint) string {
+	return endPoint.NamespaceName + "/" + endPoint.EndPointName + "/" + endPoint.ContainerName
+}
+
+// adviseEndPointRules warns once when the effective rules of an endpoint exceed the advised maximum
+func (dm *KubeArmorDaemon) adviseEndPointRules(endPoint tp.EndPoint) {
+	if cfg.GlobalCfg.MaxEndpointRules <= 0 {
+		return
+	}
+
+	count := 0
+	for _, secPolicy := range endPoint.SecurityPolicies {
+		count += countPolicyRules(secPolicy.Spec)
+	}
+
+	key := endPointRulesKey(endPoint)
+
+	dm.RuleConsolidationsLock.Lock()
+	defer dm.RuleConsolidationsLock.Unlock()
+
+	if count <= cfg.GlobalCfg.MaxEndpointRules {
+		delete(dm.EndPointRuleWarnings, key)
+		return
+	}
+
+	// warned about already (unless the rules changed)
+	if dm.EndPointRuleWarnings[key] == count {
+		return
+	}
+	dm.EndPointRuleWarnings[key] = count
+
+	dm.Logger.Warnf("Detected an endpoint with %d rules, more than the advised %d (%s), consider merging the matchPaths of its policies into directory rules (-%s)",
+		count, cfg.GlobalCfg.MaxEndpointRules, key, cfg.ConfigRuleConsolidationRatio)
+}
+
+// forgetEndPointRules removes the rule count of a deleted endpoint
+func (dm *KubeArmorDaemon) forgetEndPointRules(endPoint tp.EndPoint) {
+	dm.RuleConsolidationsLock.Lock()
+	defer dm.RuleConsolidationsLock.Unlock()
+
+	delete(dm.EndPointRuleWarnings, endPointRulesKey(endPoint))
+}
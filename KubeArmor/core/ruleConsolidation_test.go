@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files")
+
+// generatedAllowlist returns the spec of a generated allowlist, as expanded by CreateSecurityPolicy
+func generatedAllowlist() tp.SecuritySpec {
+	spec := tp.SecuritySpec{Action: "Allow"}
+
+	for _, name := range []string{"cat", "grep", "ls", "nginx", "sed", "sh", "tar", "sh"} {
+		spec.Process.MatchPaths = append(spec.Process.MatchPaths, tp.ProcessPathType{Path: "/usr/bin/" + name, Severity: 1, Action: "Allow"})
+	}
+
+	// not merged, a directory rule can't keep the rate
+	spec.Process.MatchPaths = append(spec.Process.MatchPaths,
+		tp.ProcessPathType{Path: "/usr/sbin/nginx", Severity: 1, Action: "Allow"},
+		tp.ProcessPathType{Path: "/usr/sbin/curl", Severity: 1, Rate: 10, Action: "Throttle"},
+		tp.ProcessPathType{Path: "/usr/sbin/wget", Severity: 1, Rate: 10, Action: "Throttle"})
+
+	spec.File.MatchDirectories = []tp.FileDirectoryType{
+		{Directory: "/var/log/", Recursive: true, Severity: 1, Action: "Allow"},
+	}
+	spec.File.MatchPaths = []tp.FilePathType{
+		{Path: "/var/log/nginx/access.log", Severity: 1, Action: "Allow"},
+		{Path: "/var/log/nginx/error.log", ReadOnly: true, Severity: 1, Action: "Allow"},
+		{Path: "/etc/nginx/nginx.conf", ReadOnly: true, Severity: 1, Action: "Allow"},
+		{Path: "/etc/nginx/mime.types", ReadOnly: true, Severity: 1, Action: "Allow"},
+		{Path: "/etc/nginx/fastcgi_params", Severity: 1, Action: "Allow"},
+	}
+
+	return spec
+}
+
+// allowlistEntries are the entries of the directories in the containers
+var allowlistEntries = map[string][]string{
+	"/usr/bin/":   {"cat", "grep", "ls", "nginx", "sed", "sh", "tar", "vi"},
+	"/usr/sbin/":  {"curl", "nginx", "wget"},
+	"/etc/nginx/": {"conf.d", "fastcgi_params", "mime.types", "nginx.conf"},
+}
+
+// matchedRules returns the attributes of the process and file rules of a spec which match a path
+// (the rules of the same attributes are enforced the same way)
+func matchedRules(spec tp.SecuritySpec, path string) []string {
+	matched := map[string]bool{}
+
+	for _, rule := range spec.Process.MatchPaths {
+		if rule.Path == path {
+			matched["process"+ruleAttributes(rule, "path")] = true
+		}
+	}
+	for _, rule := range spec.Process.MatchDirectories {
+		if coversPath(rule.Directory, rule.Recursive, path) {
+			matched["process"+ruleAttributes(rule, "dir", "recursive")] = true
+		}
+	}
+	for _, rule := range spec.File.MatchPaths {
+		if rule.Path == path {
+			matched["file"+ruleAttributes(rule, "path")] = true
+		}
+	}
+	for _, rule := range spec.File.MatchDirectories {
+		if coversPath(rule.Directory, rule.Recursive, path) {
+			matched["file"+ruleAttributes(rule, "dir", "recursive")] = true
+		}
+	}
+
+	rules := []string{}
+	for rule := range matched {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	return rules
+}
+
+// samplePaths returns the paths to compare the enforcement of the rules on
+func samplePaths(spec tp.SecuritySpec) []string {
+	paths := []string{"/usr/bin/sub/cat", "/usr/local/bin/nginx", "/var/log/nginx/other.log", "/etc/passwd", "/etc/nginx/conf.d/default.conf"}
+
+	for _, rule := range spec.Process.MatchPaths {
+		paths = append(paths, rule.Path)
+	}
+	for _, rule := range spec.File.MatchPaths {
+		paths = append(paths, rule.Path)
+	}
+	for dir, names := range allowlistEntries {
+		for _, name := range names {
+			paths = append(paths, dir+name)
+		}
+	}
+
+	return paths
+}
+
+func TestRuleConsolidation(t *testing.T) {
+	entries := func(dir string) []string { return allowlistEntries[dir] }
+
+	input := generatedAllowlist()
+
+	lossless := generatedAllowlist()
+	losslessNotes := ConsolidateSecurityPolicy(&lossless, entries, 0.75, false)
+
+	approximate := generatedAllowlist()
+	approximateNotes := ConsolidateSecurityPolicy(&approximate, entries, 0.75, true)
+
+	got, err := json.MarshalIndent(map[string]interface{}{
+		"input":       input,
+		"lossless":    map[string]interface{}{"spec": lossless, "notes": losslessNotes},
+		"approximate": map[string]interface{}{"spec": approximate, "notes": approximateNotes},
+	}, "", "  ")
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to marshal the consolidated rules (%s)", err.Error())
+	}
+	got = append(got, '\n')
+
+	golden := "testdata/ruleConsolidation.golden.json"
+	if *updateGolden {
+		if err := os.WriteFile(golden, got, 0600); err != nil {
+			t.Fatalf("[FAIL] Failed to update %s (%s)", golden, err.Error())
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to read %s (%s)", golden, err.Error())
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("[FAIL] Unexpected consolidated rules (go test -run TestRuleConsolidation -update to accept)\n%s", got)
+	}
+
+	// the lossless consolidation is enforced the same way
+	for _, path := range samplePaths(input) {
+		before, after := matchedRules(input, path), matchedRules(lossless, path)
+		if !reflect.DeepEqual(before, after) {
+			t.Errorf("[FAIL] Expected the same rules for %s (%v, %v)", path, before, after)
+		}
+	}
+
+	// the approximate one only matches the other entries of the merged directories as well
+	for _, path := range samplePaths(input) {
+		before, after := matchedRules(input, path), matchedRules(approximate, path)
+		if reflect.DeepEqual(before, after) {
+			continue
+		}
+
+		for _, rule := range before {
+			if !kl.ContainsElement(after, rule) {
+				t.Errorf("[FAIL] Expected the rules of %s to be kept (%v, %v)", path, before, after)
+			}
+		}
+		if path != "/usr/bin/vi" {
+			t.Errorf("[FAIL] Expected only the entries of the merged directories to match more rules (%s, %v)", path, after)
+		}
+	}
+
+	t.Log("[PASS] Consolidated the rules of a generated allowlist")
+}
+
+func TestEndPointRuleAdvisory(t *testing.T) {
+	prevMax := cfg.GlobalCfg.MaxEndpointRules
+	defer func() { cfg.GlobalCfg.MaxEndpointRules = prevMax }()
+	cfg.GlobalCfg.MaxEndpointRules = 10
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	dm := NewKubeArmorDaemon()
+	dm.Logger = &fd.Feeder{Node: &dm.Node}
+
+	endPoint := tp.EndPoint{NamespaceName: "web", EndPointName: "nginx", ContainerName: "nginx"}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{{Spec: generatedAllowlist()}}
+
+	if count := countPolicyRules(endPoint.SecurityPolicies[0].Spec); count != 17 {
+		t.Errorf("[FAIL] Unexpected number of rules (%d)", count)
+	}
+
+	dm.adviseEndPointRules(endPoint)
+	if dm.EndPointRuleWarnings["web/nginx/nginx"] != 17 {
+		t.Errorf("[FAIL] Expected a warning for the endpoint (%v)", dm.EndPointRuleWarnings)
+	}
+
+	// no warning once the rules are consolidated
+	ConsolidateSecurityPolicy(&endPoint.SecurityPolicies[0].Spec, func(dir string) []string { return allowlistEntries[dir] }, 0.75, true)
+
+	dm.adviseEndPointRules(endPoint)
+	if _, ok := dm.EndPointRuleWarnings["web/nginx/nginx"]; ok {
+		t.Errorf("[FAIL] Expected the warning to be cleared (%v)", dm.EndPointRuleWarnings)
+	}
+
+	t.Log("[PASS] Warned about the endpoints with too many rules")
+}
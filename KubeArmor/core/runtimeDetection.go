@@ -0,0 +1,288 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	ctrver "github.com/containerd/containerd/api/services/version/v1"
+	"github.com/docker/docker/client"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	cri "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// ======================= //
+// == Runtime Detection == //
+// ======================= //
+
+// container runtimes
+const (
+	RuntimeContainerd = "containerd"
+	RuntimeCrio       = "cri-o"
+	RuntimeDocker     = "docker"
+	RuntimePodman     = "podman"
+)
+
+// RuntimeCandidate Structure
+type RuntimeCandidate struct {
+	Runtime string
+	Socket  string
+}
+
+// runtimeCandidates are the well-known sockets of the container runtimes, in the order of the detection
+var runtimeCandidates = []RuntimeCandidate{
+	{Runtime: RuntimeContainerd, Socket: "/run/containerd/containerd.sock"},
+	{Runtime: RuntimeCrio, Socket: "/var/run/crio/crio.sock"},
+	{Runtime: RuntimeDocker, Socket: "/var/run/docker.sock"},
+	{Runtime: RuntimePodman, Socket: "/run/podman/podman.sock"},
+
+	// the other locations of the sockets (e.g., k3s, microk8s)
+	{Runtime: RuntimeContainerd, Socket: "/var/run/containerd/containerd.sock"},
+	{Runtime: RuntimeContainerd, Socket: "/run/k3s/containerd/containerd.sock"},
+	{Runtime: RuntimeContainerd, Socket: "/var/snap/microk8s/common/run/containerd.sock"},
+	{Runtime: RuntimeCrio, Socket: "/run/crio/crio.sock"},
+	{Runtime: RuntimeDocker, Socket: "/run/docker.sock"},
+}
+
+// the timeout of the Version RPC of a candidate
+var runtimeProbeTimeout = 3 * time.Second
+
+// DetectedRuntime Structure
+type DetectedRuntime struct {
+	Runtime string
+	Socket  string // unix://...
+	Version string
+}
+
+// runtimeOfSocket returns the runtime of an explicitly set socket
+func runtimeOfSocket(socket string) string {
+	switch {
+	case strings.Contains(socket, "docker"):
+		return RuntimeDocker
+	case strings.Contains(socket, "containerd"):
+		return RuntimeContainerd
+	case strings.Contains(socket, "crio"), strings.Contains(socket, "cri-o"):
+		return RuntimeCrio
+	case strings.Contains(socket, "podman"):
+		return RuntimePodman
+	}
+
+	return ""
+}
+
+// probeRuntimeVersion asks the runtime listening on a socket for its version
+func probeRuntimeVersion(ctx context.Context, runtime, socket string) (string, error) {
+	switch runtime {
+	case RuntimeContainerd, RuntimeCrio:
+		conn, err := grpc.DialContext(ctx, "unix://"+socket, grpc.WithInsecure(), grpc.WithBlock())
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+
+		if runtime == RuntimeContainerd {
+			res, err := ctrver.NewVersionClient(conn).Version(ctx, &emptypb.Empty{})
+			if err != nil {
+				return "", err
+			}
+			return res.Version, nil
+		}
+
+		res, err := cri.NewRuntimeServiceClient(conn).Version(ctx, &cri.VersionRequest{})
+		if err != nil {
+			return "", err
+		}
+		return res.RuntimeVersion, nil
+
+	case RuntimeDocker:
+		dockerClient, err := client.NewClientWithOpts(client.WithHost("unix://"+socket), client.WithAPIVersionNegotiation())
+		if err != nil {
+			return "", err
+		}
+		defer dockerClient.Close()
+
+		version, err := dockerClient.ServerVersion(ctx)
+		if err != nil {
+			return "", err
+		}
+		return version.Version, nil
+
+	case RuntimePodman:
+		ph := &PodmanHandler{client: newPodmanClient(socket)}
+		defer ph.Close()
+
+		version := PodmanVersion{}
+		if err := ph.get(ctx, "/version", &version); err != nil {
+			return "", err
+		}
+		return version.Version, nil
+	}
+
+	return "", fmt.Errorf("unsupported runtime %s", runtime)
+}
+
+// detectContainerRuntime probes the well-known sockets and returns the first one answering the Version RPC, trying
+// the sockets of the preferred runtime first (e.g., the runtime reported by the kubelet)
+func (dm *KubeArmorDaemon) detectContainerRuntime(preferred string, withPodman bool) (DetectedRuntime, error) {
+	candidates := []RuntimeCandidate{}
+	for _, candidate := range runtimeCandidates {
+		if candidate.Runtime == preferred {
+			candidates = append(candidates, candidate)
+		}
+	}
+	for _, candidate := range runtimeCandidates {
+		if candidate.Runtime != preferred {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	for _, candidate := range candidates {
+		if candidate.Runtime == RuntimePodman && !withPodman {
+			continue
+		}
+
+		info, err := os.Stat(candidate.Socket)
+		if err != nil {
+			dm.Logger.Printf("Skipped %s at %s (%s)", candidate.Runtime, candidate.Socket, unwrapPathError(err))
+			continue
+		} else if info.Mode()&os.ModeSocket == 0 {
+			dm.Logger.Printf("Skipped %s at %s (not a socket)", candidate.Runtime, candidate.Socket)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), runtimeProbeTimeout)
+		version, err := probeRuntimeVersion(ctx, candidate.Runtime, candidate.Socket)
+		cancel()
+
+		if err != nil {
+			dm.Logger.Warnf("Skipped %s at %s (no answer to the Version RPC: %s)", candidate.Runtime, candidate.Socket, err.Error())
+			continue
+		}
+
+		detected := DetectedRuntime{Runtime: candidate.Runtime, Socket: "unix://" + candidate.Socket, Version: version}
+
+		dm.Logger.Printf("Detected %s %s at %s", detected.Runtime, detected.Version, candidate.Socket)
+
+		return detected, nil
+	}
+
+	return DetectedRuntime{}, errors.New("no container runtime answered at the well-known sockets")
+}
+
+// selectContainerRuntime returns the runtime to monitor, the one of the explicitly set socket if any, or the
+// detected one otherwise
+func (dm *KubeArmorDaemon) selectContainerRuntime() (DetectedRuntime, error) {
+	dm.NodeLock.RLock()
+	runtimeVersion := dm.Node.ContainerRuntimeVersion
+	dm.NodeLock.RUnlock()
+
+	// the sockets set explicitly win over the detection
+	if cfg.GlobalCfg.PodmanSocket != "" {
+		return DetectedRuntime{Runtime: RuntimePodman, Socket: cfg.GlobalCfg.PodmanSocket}, nil
+	}
+
+	if cfg.GlobalCfg.CRISocket != "" {
+		if _, err := os.Stat(strings.TrimPrefix(cfg.GlobalCfg.CRISocket, "unix://")); err != nil {
+			return DetectedRuntime{}, fmt.Errorf("CRI socket %s is not accessible (%s)", cfg.GlobalCfg.CRISocket, unwrapPathError(err))
+		}
+
+		// either the runtime reported by the kubelet or the one of the socket
+		for _, runtime := range []string{RuntimeDocker, RuntimeContainerd, RuntimeCrio} {
+			if strings.Contains(runtimeVersion, runtime) || runtimeOfSocket(cfg.GlobalCfg.CRISocket) == runtime {
+				return DetectedRuntime{Runtime: runtime, Socket: cfg.GlobalCfg.CRISocket}, nil
+			}
+		}
+
+		return DetectedRuntime{}, fmt.Errorf("%s is not a supported CRI socket", cfg.GlobalCfg.CRISocket)
+	}
+
+	dm.Logger.Print("CRI socket not set. Trying to detect.")
+
+	detected, err := dm.detectContainerRuntime(runtimeOfSocket(strings.SplitN(runtimeVersion, "://", 2)[0]), !dm.K8sEnabled)
+	if err != nil {
+		return DetectedRuntime{}, err
+	}
+
+	if detected.Runtime == RuntimePodman {
+		cfg.GlobalCfg.PodmanSocket = detected.Socket
+	} else {
+		cfg.GlobalCfg.CRISocket = detected.Socket
+	}
+
+	return detected, nil
+}
+
+// runtimeSocketPaths returns the sockets matched by runtime socket rules, the monitored one, the well-known ones and
+// the ones set in the config
+func runtimeSocketPaths(detected DetectedRuntime) []string {
+	paths := []string{strings.TrimPrefix(detected.Socket, "unix://")}
+
+	for _, candidate := range runtimeCandidates {
+		paths = append(paths, candidate.Socket)
+	}
+
+	return append(paths, cfg.GlobalCfg.RuntimeSockets...)
+}
+
+// monitorContainerRuntime keeps track of the containers of the selected runtime, and exports it in the node info
+func (dm *KubeArmorDaemon) monitorContainerRuntime(detected DetectedRuntime) {
+	dm.NodeLock.Lock()
+	dm.Node.ContainerRuntime = detected.Runtime
+	dm.Node.ContainerRuntimeSocket = detected.Socket
+	if dm.Node.ContainerRuntimeVersion == "" && detected.Version != "" {
+		dm.Node.ContainerRuntimeVersion = detected.Runtime + "://" + detected.Version
+	}
+	dm.NodeLock.Unlock()
+
+	// the connections to the runtime sockets from the containers are matched by runtime socket rules
+	if dm.SystemMonitor != nil && dm.SystemMonitor.RuntimeSockets != nil {
+		dm.SystemMonitor.RuntimeSockets.Update(runtimeSocketPaths(detected))
+	}
+
+	// the lifecycle events of containerd and CRI-O are received as an NRI plugin if preferred, the runtime is
+	// monitored otherwise
+	if useNRI(detected.Runtime) && dm.MonitorNRIEvents(detected.Runtime) {
+		dm.Logger.Printf("Using %s for monitoring containers", cfg.GlobalCfg.NRISocket)
+		return
+	}
+
+	dm.startRuntimeMonitor(detected.Runtime)
+
+	dm.Logger.Printf("Using %s for monitoring containers", detected.Socket)
+}
+
+// startRuntimeMonitor starts to monitor the containers of a runtime
+func (dm *KubeArmorDaemon) startRuntimeMonitor(runtime string) {
+	switch runtime {
+	case RuntimeDocker:
+		// update already deployed containers
+		dm.GetAlreadyDeployedDockerContainers()
+		// monitor docker events
+		go dm.MonitorDockerEvents()
+	case RuntimeContainerd:
+		// monitor containerd events
+		go dm.MonitorContainerdEvents()
+	case RuntimeCrio:
+		// monitor crio events
+		go dm.MonitorCrioEvents()
+	case RuntimePodman:
+		// monitor podman events
+		go dm.MonitorPodmanEvents()
+	}
+}
+
+// GetContainerRuntime returns the monitored runtime and its socket
+func (dm *KubeArmorDaemon) GetContainerRuntime() (string, string) {
+	dm.NodeLock.RLock()
+	defer dm.NodeLock.RUnlock()
+
+	return dm.Node.ContainerRuntime, dm.Node.ContainerRuntimeSocket
+}
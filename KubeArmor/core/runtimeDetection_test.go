@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	"github.com/kubearmor/KubeArmor/KubeArmor/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDetectContainerRuntime(t *testing.T) {
+	dir := t.TempDir()
+
+	prevCandidates, prevTimeout := runtimeCandidates, runtimeProbeTimeout
+	prevCRISocket, prevPodmanSocket := cfg.GlobalCfg.CRISocket, cfg.GlobalCfg.PodmanSocket
+	defer func() {
+		runtimeCandidates, runtimeProbeTimeout = prevCandidates, prevTimeout
+		cfg.GlobalCfg.CRISocket, cfg.GlobalCfg.PodmanSocket = prevCRISocket, prevPodmanSocket
+	}()
+	runtimeProbeTimeout = 500 * time.Millisecond
+
+	// containerd, not answering at first
+	containerd := testutil.NewFakeRuntime(testutil.FlavorContainerd)
+	containerd.SetFault("Version", testutil.Fault{Err: status.Error(codes.Unavailable, "starting")})
+	if err := containerd.Start(dir + "/containerd.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake containerd (%s)", err.Error())
+	}
+	defer containerd.Stop()
+
+	// a stale docker socket
+	if err := os.WriteFile(dir+"/docker.sock", []byte{}, 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to create the stale socket (%s)", err.Error())
+	}
+
+	podman := testutil.NewFakePodman()
+	if err := podman.Start(dir + "/podman.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake Podman API (%s)", err.Error())
+	}
+	defer podman.Stop()
+
+	runtimeCandidates = []RuntimeCandidate{
+		{Runtime: RuntimeContainerd, Socket: dir + "/containerd.sock"},
+		{Runtime: RuntimeCrio, Socket: dir + "/crio.sock"},
+		{Runtime: RuntimeDocker, Socket: dir + "/docker.sock"},
+		{Runtime: RuntimePodman, Socket: dir + "/podman.sock"},
+	}
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	dm := newCrioTestDaemon()
+	dm.K8sEnabled = false
+
+	reset := func() {
+		cfg.GlobalCfg.CRISocket, cfg.GlobalCfg.PodmanSocket = "", ""
+	}
+
+	// the first candidate answering
+	reset()
+	detected, err := dm.selectContainerRuntime()
+	if err != nil || detected.Runtime != RuntimePodman || cfg.GlobalCfg.PodmanSocket != "unix://"+dir+"/podman.sock" || cfg.GlobalCfg.CRISocket != "" {
+		t.Errorf("[FAIL] Expected Podman to be detected (%+v, %v)", detected, err)
+	}
+
+	// the first candidate in the order once it answers
+	containerd.ClearFault("Version")
+
+	reset()
+	detected, err = dm.selectContainerRuntime()
+	if err != nil || detected.Runtime != RuntimeContainerd || detected.Version != "fake" || cfg.GlobalCfg.CRISocket != "unix://"+dir+"/containerd.sock" {
+		t.Errorf("[FAIL] Expected containerd to be detected (%+v, %v)", detected, err)
+	}
+
+	// no Podman on a k8s node
+	containerd.SetFault("Version", testutil.Fault{Err: status.Error(codes.Unavailable, "starting")})
+	dm.K8sEnabled = true
+
+	reset()
+	if detected, err := dm.selectContainerRuntime(); err == nil {
+		t.Errorf("[FAIL] Expected no runtime to be detected on a k8s node (%+v)", detected)
+	}
+
+	// the CRI socket set explicitly wins, even if another runtime answers
+	dm.K8sEnabled = false
+
+	reset()
+	cfg.GlobalCfg.CRISocket = "unix://" + dir + "/containerd.sock"
+	if detected, err := dm.selectContainerRuntime(); err != nil || detected.Runtime != RuntimeContainerd {
+		t.Errorf("[FAIL] Expected the CRI socket set to be used (%+v, %v)", detected, err)
+	}
+
+	reset()
+	cfg.GlobalCfg.CRISocket = "unix://" + dir + "/crio.sock"
+	if _, err := dm.selectContainerRuntime(); err == nil || !strings.Contains(err.Error(), "not accessible") {
+		t.Errorf("[FAIL] Expected the missing CRI socket to fail (%v)", err)
+	}
+
+	// the detected runtime is monitored and shown by the probe
+	reset()
+	detected, err = dm.selectContainerRuntime()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to detect a runtime (%s)", err.Error())
+	}
+
+	StopChan = make(chan struct{})
+	dm.monitorContainerRuntime(detected)
+
+	probe := &Probe{GetContainerData: dm.SetProbeContainerData, GetContainerRuntime: dm.GetContainerRuntime}
+
+	res, err := probe.GetProbeData(context.Background(), nil)
+	if err != nil || res.ContainerRuntime != RuntimePodman || res.ContainerRuntimeSocket != "unix://"+dir+"/podman.sock" {
+		t.Errorf("[FAIL] Expected the probe to show the detected runtime (%v, %v)", res, err)
+	}
+
+	if dm.Node.ContainerRuntimeVersion != "podman://4.4.1" {
+		t.Errorf("[FAIL] Expected the version of the detected runtime in the node info (%s)", dm.Node.ContainerRuntimeVersion)
+	}
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	t.Log("[PASS] Detected the container runtime")
+}
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ===================== //
+// == Runtime Handler == //
+// ===================== //
+
+// RuntimeHandler Interface
+type RuntimeHandler interface {
+	// GetContainerInfo Function
+	GetContainerInfo(ctx context.Context, containerID string) (tp.Container, error)
+
+	// Close Function
+	Close()
+}
+
+var (
+	_ RuntimeHandler = (*CrioHandler)(nil)
+	_ RuntimeHandler = (*ContainerdHandler)(nil)
+	_ RuntimeHandler = (*DockerHandler)(nil)
+	_ RuntimeHandler = (*PodmanHandler)(nil)
+	_ RuntimeHandler = (*NRIHandler)(nil)
+)
+
+// RuntimeHandlers Function returns the handlers of the monitored container runtimes
+func (dm *KubeArmorDaemon) RuntimeHandlers() []RuntimeHandler {
+	handlers := []RuntimeHandler{}
+
+	if crio := dm.getCrio(); crio != nil {
+		handlers = append(handlers, crio)
+	}
+	if dm.containerd != nil {
+		handlers = append(handlers, dm.containerd)
+	}
+	if dm.docker != nil {
+		handlers = append(handlers, dm.docker)
+	}
+	if dm.podman != nil {
+		handlers = append(handlers, dm.podman)
+	}
+	if nri := dm.getNRI(); nri != nil {
+		handlers = append(handlers, nri)
+	}
+
+	return handlers
+}
+
+// CloseRuntimeHandlers Function
+func (dm *KubeArmorDaemon) CloseRuntimeHandlers() {
+	for _, handler := range dm.RuntimeHandlers() {
+		handler.Close()
+	}
+
+	dm.setCrio(nil)
+	dm.containerd = nil
+	dm.docker = nil
+	dm.podman = nil
+	dm.setNRI(nil)
+}
+
+// ========================= //
+// == Pending Attachments == //
+// ========================= //
+
+// attachContainerToEndPoint adds the AppArmor profile of a started container to its endpoint,
+// or keeps the container pending if the K8s watcher hasn't created the endpoint yet
+// (EndPointsLock must be held)
+func (dm *KubeArmorDaemon) attachContainerToEndPoint(container tp.Container) bool {
+	for idx, endPoint := range dm.EndPoints {
+		if kl.ContainsElement(endPoint.Containers, container.ContainerID) {
+			// update apparmor profiles
+			if !kl.ContainsElement(endPoint.AppArmorProfiles, container.AppArmorProfile) {
+				dm.EndPoints[idx].AppArmorProfiles = append(dm.EndPoints[idx].AppArmorProfiles, container.AppArmorProfile)
+			}
+
+			delete(dm.PendingAttach, container.ContainerID)
+			return true
+		}
+	}
+
+	// endpoints are only created by the K8s watcher in K8s mode
+	if dm.K8sEnabled {
+		dm.PendingAttach[container.ContainerID] = container
+	}
+
+	return false
+}
+
+// attachPendingContainers adds the AppArmor profiles of the pending containers
+// to the endpoints being created or updated (EndPointsLock must be held)
+func (dm *KubeArmorDaemon) attachPendingContainers(endPoints []tp.EndPoint) {
+	for containerID, container := range dm.PendingAttach {
+		for idx, endPoint := range endPoints {
+			if !kl.ContainsElement(endPoint.Containers, containerID) {
+				continue
+			}
+
+			if !kl.ContainsElement(endPoint.AppArmorProfiles, container.AppArmorProfile) {
+				endPoints[idx].AppArmorProfiles = append(endPoints[idx].AppArmorProfiles, container.AppArmorProfile)
+				dm.Logger.Printf("Attached a pending container to its endpoint (%s/%s/%.12s)", endPoint.NamespaceName, endPoint.EndPointName, containerID)
+			}
+
+			delete(dm.PendingAttach, containerID)
+			break
+		}
+	}
+}
+
+// ======================= //
+// == Container Removal == //
+// ======================= //
+
+// containerProfileInUse returns true if another container of the same endpoint still uses the
+// AppArmor profile of a removed container, e.g., when the kubelet garbage-collects the exited
+// instance of a restarted container (ContainersLock must be held)
+func (dm *KubeArmorDaemon) containerProfileInUse(container tp.Container) bool {
+	if container.EndPointName == "" || container.AppArmorProfile == "" {
+		return false
+	}
+
+	for containerID, other := range dm.Containers {
+		if containerID == container.ContainerID {
+			continue
+		}
+
+		if other.NamespaceName == container.NamespaceName && other.EndPointName == container.EndPointName && other.AppArmorProfile == container.AppArmorProfile {
+			return true
+		}
+	}
+
+	return false
+}
+
+// detachContainerFromEndPoint removes the AppArmor profile of a removed container from its endpoint,
+// unless the profile is still in use (EndPointsLock must be held)
+func (dm *KubeArmorDaemon) detachContainerFromEndPoint(container tp.Container, profileInUse bool) {
+	delete(dm.PendingAttach, container.ContainerID)
+
+	if profileInUse {
+		dm.Logger.Printf("Kept the AppArmor profile of a removed container in use by another instance (%s/%s/%.12s)", container.NamespaceName, container.EndPointName, container.ContainerID)
+		return
+	}
+
+	for idx, endPoint := range dm.EndPoints {
+		if endPoint.NamespaceName == container.NamespaceName && endPoint.EndPointName == container.EndPointName && kl.ContainsElement(endPoint.Containers, container.ContainerID) {
+
+			// update apparmor profiles
+			for idxA, profile := range endPoint.AppArmorProfiles {
+				if profile == container.AppArmorProfile {
+					dm.EndPoints[idx].AppArmorProfiles = append(dm.EndPoints[idx].AppArmorProfiles[:idxA], dm.EndPoints[idx].AppArmorProfiles[idxA+1:]...)
+					break
+				}
+			}
+
+			break
+		}
+	}
+}
+
+// finalizeNsMapAdoption validates the visibility entries pinned by the previous instance once the
+// running containers are registered
+func (dm *KubeArmorDaemon) finalizeNsMapAdoption() {
+	if dm.SystemMonitor != nil && cfg.GlobalCfg.Policy {
+		dm.SystemMonitor.FinalizeNsMapAdoption()
+	}
+}
@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ==================== //
+// == Runtime Health == //
+// ==================== //
+
+// errRuntimeUnreachable is reported for the runtimes whose handler failed to connect
+var errRuntimeUnreachable = errors.New("failed to connect to the runtime")
+
+// policy names of the alerts of the monitoring of the container runtimes
+const (
+	RuntimeMonitoringDegradedPolicyName = "kubearmor-runtime-monitoring-degraded"
+	RuntimeMonitoringRestoredPolicyName = "kubearmor-runtime-monitoring-restored"
+)
+
+// runtimeHealth Structure keeps the state of a runtime handler for the health probe, updated by the monitor loop
+// of the handler and read by the probe
+type runtimeHealth struct {
+	runtime string
+	socket  string
+
+	containers int
+	lastList   time.Time
+	lastError  string
+
+	lock *sync.RWMutex
+}
+
+// newRuntimeHealth Function
+func newRuntimeHealth(runtime, socket string) *runtimeHealth {
+	return &runtimeHealth{runtime: runtime, socket: socket, lock: new(sync.RWMutex)}
+}
+
+// recordList Function records the result of a listing of the containers
+func (rh *runtimeHealth) recordList(err error) {
+	rh.lock.Lock()
+	defer rh.lock.Unlock()
+
+	if err != nil {
+		rh.lastError = err.Error()
+		return
+	}
+
+	rh.lastList = time.Now()
+	rh.lastError = ""
+}
+
+// setContainers Function records the number of the containers tracked by the handler
+func (rh *runtimeHealth) setContainers(count int) {
+	rh.lock.Lock()
+	defer rh.lock.Unlock()
+
+	rh.containers = count
+}
+
+// snapshot Function returns the state of the handler, which is connected unless the last listing failed
+func (rh *runtimeHealth) snapshot(connected bool) tp.RuntimeHandlerHealth {
+	rh.lock.RLock()
+	defer rh.lock.RUnlock()
+
+	return tp.RuntimeHandlerHealth{
+		Runtime:    rh.runtime,
+		Socket:     rh.socket,
+		Connected:  connected && rh.lastError == "",
+		Containers: rh.containers,
+		LastList:   rh.lastList,
+		LastError:  rh.lastError,
+	}
+}
+
+// grpcConnected Function checks if a gRPC connection to a runtime is usable (an idle one reconnects on the next call)
+func grpcConnected(conn *grpc.ClientConn) bool {
+	if conn == nil {
+		return false
+	}
+
+	state := conn.GetState()
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
+}
+
+// unreachableRuntime Function returns the state of a runtime whose handler failed to connect
+func unreachableRuntime(runtime, socket string, err error) func() tp.RuntimeHandlerHealth {
+	rh := newRuntimeHealth(runtime, socket)
+	rh.recordList(err)
+
+	return func() tp.RuntimeHandlerHealth {
+		return rh.snapshot(false)
+	}
+}
+
+// trackRuntimeHealth Function adds a runtime handler to the ones reported by the health probe
+func (dm *KubeArmorDaemon) trackRuntimeHealth(runtime string, health func() tp.RuntimeHandlerHealth) {
+	dm.RuntimeHealthLock.Lock()
+	defer dm.RuntimeHealthLock.Unlock()
+
+	dm.RuntimeHealth[runtime] = health
+}
+
+// GetHealth Function returns the state of the runtime handlers, the enforcer and the system monitor
+func (dm *KubeArmorDaemon) GetHealth() tp.DaemonHealth {
+	health := tp.DaemonHealth{Runtimes: []tp.RuntimeHandlerHealth{}}
+
+	dm.RuntimeHealthLock.RLock()
+	for _, runtime := range dm.RuntimeHealth {
+		health.Runtimes = append(health.Runtimes, runtime())
+	}
+	dm.RuntimeHealthLock.RUnlock()
+
+	sort.Slice(health.Runtimes, func(i, j int) bool {
+		return health.Runtimes[i].Runtime < health.Runtimes[j].Runtime
+	})
+
+	if dm.RuntimeEnforcer != nil {
+		health.EnforcerInitialized = true
+		health.Enforcer = dm.RuntimeEnforcer.EnforcerType
+	}
+	health.MonitorInitialized = dm.SystemMonitor != nil
+
+	// the features lacking their BPF programs on the architecture of the node
+	health.Features = kl.GetBPFFeatures()
+
+	return health
+}
+
+// reportRuntimeMonitoring raises a warning alert when the connection to a runtime is lost (cause != nil), and an
+// alert when the monitoring of the runtime is restored
+func (dm *KubeArmorDaemon) reportRuntimeMonitoring(runtime, socket string, cause error) {
+	log := tp.Log{}
+
+	timestamp, updatedTime := kl.GetDateTimeNow()
+
+	log.Timestamp = timestamp
+	log.UpdatedTime = updatedTime
+
+	log.Type = "MatchedHostPolicy"
+	log.Tags = "KUBEARMOR,RUNTIME"
+	log.ATags = []string{"KUBEARMOR", "RUNTIME"}
+
+	if cause != nil {
+		log.PolicyName = RuntimeMonitoringDegradedPolicyName
+		log.Severity = "5"
+		log.Message = "Lost the connection to " + runtime + ", the containers aren't tracked until reconnected"
+		log.Data = "runtime=" + runtime + " socket=" + socket + " error=" + cause.Error()
+		log.Result = "Degraded"
+	} else {
+		log.PolicyName = RuntimeMonitoringRestoredPolicyName
+		log.Severity = "1"
+		log.Message = "Reconnected to " + runtime + ", the containers are tracked again"
+		log.Data = "runtime=" + runtime + " socket=" + socket
+		log.Result = "Restored"
+	}
+
+	log.Source = "kubearmor"
+	log.ProcessName = "kubearmor"
+	log.Operation = "Runtime"
+	log.Resource = socket
+
+	log.Enforcer = "KubeArmor"
+	log.Action = "Audit"
+
+	dm.Logger.PushSummaryLog(log)
+}
@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	"github.com/kubearmor/KubeArmor/KubeArmor/testutil"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRuntimeHealth(t *testing.T) {
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(t.TempDir() + "/crio.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = true
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	dm := newCrioTestDaemon()
+	probe := &Probe{GetDaemonHealth: dm.GetHealth}
+
+	criHealth := func() *pb.RuntimeHandlerHealth {
+		res, err := probe.GetHealth(context.Background(), nil)
+		if err != nil || len(res.Runtimes) != 1 {
+			return nil
+		}
+		return res.Runtimes[0]
+	}
+
+	StopChan = make(chan struct{})
+	go dm.MonitorCrioEvents()
+
+	fake.AddContainer(testutil.FakeContainer{
+		ID:        "nginx",
+		Name:      "nginx",
+		Namespace: "default",
+		PodName:   "nginx-pod",
+		Pid:       os.Getpid(),
+	})
+
+	// the probe reads the state while the monitor loop updates it
+	waitFor(t, "the container to be tracked", func() bool {
+		health := criHealth()
+		return health != nil && health.Containers == 1
+	})
+
+	res, err := probe.GetHealth(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to get the health (%s)", err.Error())
+	}
+
+	health := res.Runtimes[0]
+	if health.Runtime != RuntimeCrio || health.Socket != fake.Endpoint() || !health.Connected || health.LastList == 0 || health.LastError != "" {
+		t.Errorf("[FAIL] Unexpected health of CRI-O (%v)", health)
+	}
+	if !res.MonitorInitialized || res.EnforcerInitialized {
+		t.Errorf("[FAIL] Unexpected state of the system monitor and the enforcer (%v)", res)
+	}
+
+	// a listing which fails
+	fake.SetFault("ListContainers", testutil.Fault{Err: status.Error(codes.Unavailable, "runtime is down")})
+
+	waitFor(t, "the failed listing to be reported", func() bool {
+		health := criHealth()
+		return health != nil && !health.Connected && health.LastError != "" && health.LastList > 0
+	})
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	// a runtime which can't be reached yet
+	prevBackoff := crioRedialBackoff
+	defer func() {
+		crioRedialBackoff = prevBackoff
+	}()
+	crioRedialBackoff = 50 * time.Millisecond
+
+	socketPath := t.TempDir() + "/late.sock"
+	cfg.GlobalCfg.CRISocket = "unix://" + socketPath
+
+	StopChan = make(chan struct{})
+
+	dm = newCrioTestDaemon()
+	probe.GetDaemonHealth = dm.GetHealth
+	go dm.MonitorCrioEvents()
+
+	waitFor(t, "CRI-O to be reported unreachable", func() bool {
+		health := criHealth()
+		return health != nil && !health.Connected && health.LastError == errRuntimeUnreachable.Error()
+	})
+
+	// connected once CRI-O is up, without restarting KubeArmor
+	late := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := late.Start(socketPath); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer late.Stop()
+
+	waitFor(t, "CRI-O to be connected", func() bool {
+		health := criHealth()
+		return health != nil && health.Connected && health.LastError == ""
+	})
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	t.Log("[PASS] Reported the health of the runtime handlers")
+}
+
+func TestCrioRedial(t *testing.T) {
+	prevBackoff := crioRedialBackoff
+	defer func() {
+		crioRedialBackoff = prevBackoff
+	}()
+	crioRedialBackoff = 50 * time.Millisecond
+
+	socketPath := t.TempDir() + "/crio.sock"
+
+	fake := testutil.NewFakeRuntime(testutil.FlavorCrio)
+	if err := fake.Start(socketPath); err != nil {
+		t.Fatalf("[FAIL] Failed to start the fake CRI runtime (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	cfg.GlobalCfg.CRISocket = fake.Endpoint()
+	cfg.GlobalCfg.Policy = true
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	// subscribe to the alerts
+	alerts := make(chan *pb.Alert, 16)
+	fd.AlertLock = new(sync.RWMutex)
+	fd.AlertStructs = map[string]fd.AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+	defer func() { fd.AlertStructs = map[string]fd.AlertStruct{} }()
+
+	dm := newCrioTestDaemon()
+	dm.Logger.Output = "none"
+	dm.Logger.SeverityRangesLock = new(sync.RWMutex)
+	dm.Logger.SinksLock = new(sync.RWMutex)
+
+	expectAlert := func(what, policyName string) {
+		select {
+		case alert := <-alerts:
+			if alert.PolicyName != policyName || alert.Resource != fake.Endpoint() {
+				t.Errorf("[FAIL] Unexpected alert for %s (%+v)", what, alert)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("[FAIL] Expected an alert for %s", what)
+		}
+	}
+
+	inContainers := func(containerID string) bool {
+		dm.ContainersLock.RLock()
+		defer dm.ContainersLock.RUnlock()
+		_, ok := dm.Containers[containerID]
+		return ok
+	}
+
+	fake.AddContainer(testutil.FakeContainer{
+		ID:        "nginx",
+		Name:      "nginx",
+		Namespace: "default",
+		PodName:   "nginx-pod",
+		Pid:       os.Getpid(),
+	})
+
+	StopChan = make(chan struct{})
+	go dm.MonitorCrioEvents()
+
+	waitFor(t, "the container to be added", func() bool {
+		return inContainers("nginx")
+	})
+
+	// CRI-O is restarted, and the containers change meanwhile
+	fake.Stop()
+	expectAlert("the lost connection", RuntimeMonitoringDegradedPolicyName)
+
+	if health := dm.GetHealth(); len(health.Runtimes) != 1 || health.Runtimes[0].Connected {
+		t.Errorf("[FAIL] Expected CRI-O to be reported disconnected (%+v)", health)
+	}
+
+	fake.DeleteContainer("nginx")
+	fake.AddContainer(testutil.FakeContainer{
+		ID:        "redis",
+		Name:      "redis",
+		Namespace: "default",
+		PodName:   "redis-pod",
+		Pid:       os.Getpid(),
+	})
+
+	if err := fake.Start(socketPath); err != nil {
+		t.Fatalf("[FAIL] Failed to restart the fake CRI runtime (%s)", err.Error())
+	}
+	expectAlert("the restored connection", RuntimeMonitoringRestoredPolicyName)
+
+	// the containers are reconciled once reconnected
+	waitFor(t, "the containers to be reconciled", func() bool {
+		return inContainers("redis") && !inContainers("nginx")
+	})
+
+	waitFor(t, "CRI-O to be reported connected", func() bool {
+		health := dm.GetHealth()
+		return len(health.Runtimes) == 1 && health.Runtimes[0].Connected && health.Runtimes[0].Containers == 1
+	})
+
+	close(StopChan)
+	dm.WgDaemon.Wait()
+	dm.CloseRuntimeHandlers()
+
+	t.Log("[PASS] Re-dialed CRI-O once restarted")
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+)
+
+// ===================== //
+// == Runtime Polling == //
+// ===================== //
+
+// pollIdleCycles is the number of the polls without changes after which the polling backs off
+var pollIdleCycles = 10
+
+// runtimePoller Structure adapts the interval of polling a runtime, backing off while its containers don't change
+type runtimePoller struct {
+	base time.Duration
+	max  time.Duration
+
+	interval time.Duration
+
+	// polls without changes since the last change
+	idle int
+}
+
+// newRuntimePoller Function returns a poller with the configured polling intervals
+func newRuntimePoller() *runtimePoller {
+	base := cfg.GlobalCfg.CRIPollingInterval
+	if base < cfg.MinCRIPollingInterval {
+		base = cfg.MinCRIPollingInterval
+	}
+
+	max := cfg.GlobalCfg.CRIPollingMaxInterval
+	if max < base {
+		max = base
+	}
+
+	return &runtimePoller{base: base, max: max, interval: base}
+}
+
+// next Function returns the interval before the next poll, given whether the last one observed a change. The
+// interval is doubled (up to the maximum) once no change is observed for pollIdleCycles polls, and snaps back to
+// the base interval after a change.
+func (rp *runtimePoller) next(changed bool) time.Duration {
+	if changed {
+		rp.idle = 0
+		rp.interval = rp.base
+		return rp.interval
+	}
+
+	rp.idle++
+
+	if rp.idle > pollIdleCycles {
+		rp.interval *= 2
+		if rp.interval > rp.max {
+			rp.interval = rp.max
+		}
+	}
+
+	return rp.interval
+}
@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+)
+
+func TestRuntimePoller(t *testing.T) {
+	prevInterval, prevMaxInterval, prevIdleCycles := cfg.GlobalCfg.CRIPollingInterval, cfg.GlobalCfg.CRIPollingMaxInterval, pollIdleCycles
+	defer func() {
+		cfg.GlobalCfg.CRIPollingInterval, cfg.GlobalCfg.CRIPollingMaxInterval, pollIdleCycles = prevInterval, prevMaxInterval, prevIdleCycles
+	}()
+	cfg.GlobalCfg.CRIPollingInterval = 100 * time.Millisecond
+	cfg.GlobalCfg.CRIPollingMaxInterval = time.Second
+	pollIdleCycles = 3
+
+	poller := newRuntimePoller()
+
+	// the base interval for the first idle cycles
+	for i := 0; i < pollIdleCycles; i++ {
+		if interval := poller.next(false); interval != 100*time.Millisecond {
+			t.Errorf("[FAIL] Expected the base interval for the idle cycle %d (%s)", i+1, interval)
+		}
+	}
+
+	// then doubled up to the maximum
+	for _, expected := range []time.Duration{200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		if interval := poller.next(false); interval != expected {
+			t.Errorf("[FAIL] Expected the interval to back off to %s (%s)", expected, interval)
+		}
+	}
+
+	// and back to the base interval after a change
+	if interval := poller.next(true); interval != 100*time.Millisecond {
+		t.Errorf("[FAIL] Expected the base interval after a change (%s)", interval)
+	}
+	if interval := poller.next(false); interval != 100*time.Millisecond {
+		t.Errorf("[FAIL] Expected the idle cycles to be counted again after a change (%s)", interval)
+	}
+
+	// the intervals below the minimum aren't used (e.g., without the configuration)
+	cfg.GlobalCfg.CRIPollingInterval, cfg.GlobalCfg.CRIPollingMaxInterval = 0, 0
+	if interval := newRuntimePoller().next(false); interval != cfg.MinCRIPollingInterval {
+		t.Errorf("[FAIL] Expected the minimum interval (%s)", interval)
+	}
+
+	t.Log("[PASS] Backed off the polling of the runtime while the containers don't change")
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ================== //
+// == Runtime Spec == //
+// ================== //
+
+// applyRuntimeSpec Function sets the cgroup path, the identity and the resource limits of a container from its runtime spec
+func applyRuntimeSpec(container *tp.Container, spec *specs.Spec) {
+	if spec.Process != nil {
+		uid, gid := spec.Process.User.UID, spec.Process.User.GID
+		container.UID, container.GID = &uid, &gid
+	}
+
+	if spec.Linux == nil {
+		return
+	}
+
+	container.CgroupPath = spec.Linux.CgroupsPath
+
+	if res := spec.Linux.Resources; res != nil {
+		if res.Memory != nil && res.Memory.Limit != nil && *res.Memory.Limit > 0 {
+			container.Resources.MemoryLimit = *res.Memory.Limit
+		}
+		if res.CPU != nil {
+			if res.CPU.Quota != nil && *res.CPU.Quota > 0 {
+				container.Resources.CPUQuota = *res.CPU.Quota
+			}
+			if res.CPU.Period != nil {
+				container.Resources.CPUPeriod = *res.CPU.Period
+			}
+		}
+		if res.Pids != nil && res.Pids.Limit > 0 {
+			container.Resources.PidsLimit = res.Pids.Limit
+		}
+	}
+}
+
+// specPrivileged Function checks if a runtime spec is the one of a privileged container
+// (containerd doesn't keep the flag, a privileged container has CAP_SYS_ADMIN and no masked or read-only paths)
+func specPrivileged(spec *specs.Spec) bool {
+	if spec.Process == nil || spec.Process.Capabilities == nil || spec.Linux == nil {
+		return false
+	}
+
+	hasSysAdmin := false
+	for _, capability := range spec.Process.Capabilities.Bounding {
+		if capability == "CAP_SYS_ADMIN" {
+			hasSysAdmin = true
+			break
+		}
+	}
+
+	return hasSysAdmin && len(spec.Linux.MaskedPaths) == 0 && len(spec.Linux.ReadonlyPaths) == 0
+}
+
+// applyDockerInspect Function sets the cgroup path, the identity and the resource limits of a container from docker inspect
+func applyDockerInspect(container *tp.Container, inspect types.ContainerJSON, cgroupDriver string) {
+	if inspect.Config != nil {
+		container.UID, container.GID = parseDockerUser(inspect.Config.User)
+	}
+
+	if inspect.ContainerJSONBase == nil || inspect.HostConfig == nil {
+		return
+	}
+
+	container.Privileged = inspect.HostConfig.Privileged
+	container.CgroupPath = dockerCgroupPath(inspect.HostConfig.CgroupParent, cgroupDriver, inspect.ID)
+
+	res := inspect.HostConfig.Resources
+	container.Resources.MemoryLimit = res.Memory
+	container.Resources.CPUQuota = res.CPUQuota
+	if res.CPUPeriod > 0 {
+		container.Resources.CPUPeriod = uint64(res.CPUPeriod)
+	}
+	if res.PidsLimit != nil && *res.PidsLimit > 0 {
+		container.Resources.PidsLimit = *res.PidsLimit
+	}
+}
+
+// dockerCgroupPath Function returns the cgroupsPath docker gives to the runtime spec of a container
+func dockerCgroupPath(cgroupParent, cgroupDriver, containerID string) string {
+	if cgroupDriver == "systemd" {
+		if cgroupParent == "" {
+			cgroupParent = "system.slice"
+		}
+		return cgroupParent + ":docker:" + containerID
+	}
+
+	if cgroupParent == "" {
+		cgroupParent = "/docker"
+	}
+	return path.Join(cgroupParent, containerID)
+}
+
+// parseDockerUser Function returns the UID and the GID of a docker user (user[:group]), the names and the primary
+// group of a user are unknown since they're only resolved with the passwd file of the container
+func parseDockerUser(user string) (*uint32, *uint32) {
+	if user == "" {
+		uid, gid := uint32(0), uint32(0)
+		return &uid, &gid
+	}
+
+	name, group, hasGroup := strings.Cut(user, ":")
+
+	uid, err := strconv.ParseUint(name, 10, 32)
+	if err != nil {
+		return nil, nil
+	}
+	id := uint32(uid)
+
+	if !hasGroup {
+		return &id, nil
+	}
+
+	parsed, err := strconv.ParseUint(group, 10, 32)
+	if err != nil {
+		return &id, nil
+	}
+	gid := uint32(parsed)
+
+	return &id, &gid
+}
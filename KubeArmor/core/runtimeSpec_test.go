@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// verbose info of a privileged CRI-O container (systemd cgroup driver)
+const crioPrivilegedInfo = `{
+	"sandboxID": "4c1e",
+	"pid": 4242,
+	"privileged": true,
+	"runtimeSpec": {
+		"ociVersion": "1.0.2-dev",
+		"process": {"user": {"uid": 1000, "gid": 3000}, "args": ["/pause"], "cwd": "/"},
+		"root": {"path": "/var/lib/containers/storage/overlay/1f2e/merged"},
+		"linux": {
+			"cgroupsPath": "kubepods-burstable-pod0b6f.slice:crio:9d3c",
+			"resources": {
+				"memory": {"limit": 268435456},
+				"cpu": {"shares": 102, "quota": 50000, "period": 100000},
+				"pids": {"limit": 1024}
+			}
+		}
+	}
+}`
+
+// runtime specs of containerd containers (a privileged one and a default one)
+const (
+	containerdPrivilegedSpec = `{
+		"process": {
+			"user": {"uid": 0, "gid": 0},
+			"capabilities": {"bounding": ["CAP_CHOWN", "CAP_SYS_ADMIN", "CAP_NET_ADMIN"]}
+		},
+		"root": {"path": "rootfs"},
+		"linux": {"cgroupsPath": "/kubepods/besteffort/pod7a1c/5e2f"}
+	}`
+
+	containerdDefaultSpec = `{
+		"process": {
+			"user": {"uid": 65534, "gid": 65534},
+			"capabilities": {"bounding": ["CAP_CHOWN", "CAP_NET_BIND_SERVICE"]}
+		},
+		"root": {"path": "rootfs"},
+		"linux": {
+			"cgroupsPath": "kubepods-besteffort-pod7a1c.slice:cri-containerd:5e2f",
+			"resources": {"memory": {"limit": 0}, "cpu": {"quota": -1, "period": 100000}},
+			"maskedPaths": ["/proc/kcore"],
+			"readonlyPaths": ["/proc/sys"]
+		}
+	}`
+)
+
+// docker inspect of a container run with --privileged --user 1000:1000 --memory 512m --pids-limit 100
+const dockerPrivilegedInspect = `{
+	"Id": "b7e0",
+	"Name": "/agent",
+	"State": {"Pid": 4343},
+	"HostConfig": {"Privileged": true, "CgroupParent": "", "Memory": 536870912, "CpuQuota": 0, "CpuPeriod": 0, "PidsLimit": 100},
+	"Config": {"User": "1000:1000", "Image": "agent:latest"}
+}`
+
+func TestRuntimeSpec(t *testing.T) {
+	uint32Of := func(v *uint32) int64 {
+		if v == nil {
+			return -1
+		}
+		return int64(*v)
+	}
+
+	// CRI-O
+	containerInfo, err := parseCrioContainerInfo(map[string]string{"info": crioPrivilegedInfo})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to parse the CRI-O info (%s)", err.Error())
+	}
+
+	crio := tp.Container{}
+	applyRuntimeSpec(&crio, &containerInfo.RuntimeSpec)
+	crio.Privileged = containerInfo.Privileged
+
+	if crio.CgroupPath != "kubepods-burstable-pod0b6f.slice:crio:9d3c" || uint32Of(crio.UID) != 1000 || uint32Of(crio.GID) != 3000 || !crio.Privileged {
+		t.Errorf("[FAIL] Unexpected CRI-O container (%+v)", crio)
+	}
+	if crio.Resources != (tp.ContainerResources{MemoryLimit: 268435456, CPUQuota: 50000, CPUPeriod: 100000, PidsLimit: 1024}) {
+		t.Errorf("[FAIL] Unexpected resource limits of the CRI-O container (%+v)", crio.Resources)
+	}
+
+	// containerd
+	for name, tc := range map[string]struct {
+		spec       string
+		cgroupPath string
+		uid        int64
+		privileged bool
+	}{
+		"privileged": {containerdPrivilegedSpec, "/kubepods/besteffort/pod7a1c/5e2f", 0, true},
+		"default":    {containerdDefaultSpec, "kubepods-besteffort-pod7a1c.slice:cri-containerd:5e2f", 65534, false},
+	} {
+		spec := specs.Spec{}
+		if err := json.Unmarshal([]byte(tc.spec), &spec); err != nil {
+			t.Fatalf("[FAIL] Failed to parse the containerd spec (%s)", err.Error())
+		}
+
+		containerd := tp.Container{}
+		applyRuntimeSpec(&containerd, &spec)
+		containerd.Privileged = specPrivileged(&spec)
+
+		if containerd.CgroupPath != tc.cgroupPath || uint32Of(containerd.UID) != tc.uid || containerd.Privileged != tc.privileged {
+			t.Errorf("[FAIL] Unexpected containerd container (%s, %+v)", name, containerd)
+		}
+		if name == "default" && containerd.Resources != (tp.ContainerResources{CPUPeriod: 100000}) {
+			t.Errorf("[FAIL] Expected the unlimited resources to be 0 (%+v)", containerd.Resources)
+		}
+	}
+
+	// Docker
+	inspect := types.ContainerJSON{}
+	if err := json.Unmarshal([]byte(dockerPrivilegedInspect), &inspect); err != nil {
+		t.Fatalf("[FAIL] Failed to parse the docker inspect (%s)", err.Error())
+	}
+
+	docker := tp.Container{}
+	applyDockerInspect(&docker, inspect, "systemd")
+
+	if docker.CgroupPath != "system.slice:docker:b7e0" || uint32Of(docker.UID) != 1000 || uint32Of(docker.GID) != 1000 || !docker.Privileged {
+		t.Errorf("[FAIL] Unexpected Docker container (%+v)", docker)
+	}
+	if docker.Resources != (tp.ContainerResources{MemoryLimit: 536870912, PidsLimit: 100}) {
+		t.Errorf("[FAIL] Unexpected resource limits of the Docker container (%+v)", docker.Resources)
+	}
+
+	if path := dockerCgroupPath("", "cgroupfs", "b7e0"); path != "/docker/b7e0" {
+		t.Errorf("[FAIL] Unexpected cgroupfs path of the Docker container (%s)", path)
+	}
+
+	// the users only known by name in the container
+	for user, expected := range map[string][2]int64{"": {0, 0}, "1000": {1000, -1}, "nginx": {-1, -1}, "1000:www": {1000, -1}} {
+		if uid, gid := parseDockerUser(user); uint32Of(uid) != expected[0] || uint32Of(gid) != expected[1] {
+			t.Errorf("[FAIL] Unexpected identity of the Docker user %q (%d, %d)", user, uint32Of(uid), uint32Of(gid))
+		}
+	}
+
+	t.Log("[PASS] Extracted the cgroup path, the identity and the resource limits of the containers")
+}
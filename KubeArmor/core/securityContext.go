@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ====================== //
+// == Security Context == //
+// ====================== //
+
+// getSecurityContexts returns the effective identities of the containers of a pod, where the
+// security context of a container overrides the one of the pod (fsGroup is only set for pods)
+func getSecurityContexts(spec corev1.PodSpec) map[string]tp.SecurityIdentity {
+	identities := map[string]tp.SecurityIdentity{}
+
+	pod := tp.SecurityIdentity{}
+	if spec.SecurityContext != nil {
+		pod.RunAsUser = spec.SecurityContext.RunAsUser
+		pod.RunAsGroup = spec.SecurityContext.RunAsGroup
+		pod.FsGroup = spec.SecurityContext.FSGroup
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+
+	for _, container := range containers {
+		identity := pod
+
+		if container.SecurityContext != nil {
+			if container.SecurityContext.RunAsUser != nil {
+				identity.RunAsUser = container.SecurityContext.RunAsUser
+			}
+			if container.SecurityContext.RunAsGroup != nil {
+				identity.RunAsGroup = container.SecurityContext.RunAsGroup
+			}
+		}
+
+		identities[container.Name] = identity
+	}
+
+	return identities
+}
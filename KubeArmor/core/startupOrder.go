@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+// =================== //
+// == Startup Order == //
+// =================== //
+
+// hostPolicySyncTimeout is how long the container security policies wait for the host security policies at startup
+var hostPolicySyncTimeout = 30 * time.Second
+
+// StartupGate blocks the startup steps depending on another one until it's done
+type StartupGate struct {
+	ready chan struct{}
+	once  sync.Once
+}
+
+// NewStartupGate Function
+func NewStartupGate() *StartupGate {
+	return &StartupGate{ready: make(chan struct{})}
+}
+
+// Open releases the waiting steps
+func (g *StartupGate) Open() {
+	if g == nil {
+		return
+	}
+
+	g.once.Do(func() {
+		close(g.ready)
+	})
+}
+
+// Wait waits until the gate is opened, and returns false if it timed out
+func (g *StartupGate) Wait(timeout time.Duration) bool {
+	if g == nil {
+		return true
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-g.ready:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// waitForHostPolicies waits for the host security policies to be applied, if configured
+func (dm *KubeArmorDaemon) waitForHostPolicies() {
+	if dm.HostPoliciesSynced == nil {
+		return
+	}
+
+	dm.Logger.Print("Waiting for host security policies to be applied")
+
+	if !dm.HostPoliciesSynced.Wait(hostPolicySyncTimeout) {
+		dm.Logger.Warnf("Host security policies weren't applied in %s, applying container security policies anyway", hostPolicySyncTimeout)
+		return
+	}
+
+	dm.Logger.Print("Applied host security policies, applying container security policies")
+}
+
+// syncHostSecurityPolicies applies the existing host security policies
+func (dm *KubeArmorDaemon) syncHostSecurityPolicies(list func() ([]tp.K8sKubeArmorHostPolicy, error), apply func(tp.K8sKubeArmorHostPolicyEvent) pb.PolicyStatus) error {
+	policies, err := list()
+	if err != nil {
+		return err
+	}
+
+	for _, policy := range policies {
+		if policy.Status.Status != "" && policy.Status.Status != "OK" {
+			continue
+		}
+
+		apply(tp.K8sKubeArmorHostPolicyEvent{Type: "ADDED", Object: policy})
+	}
+
+	dm.Logger.Printf("Applied %d host security policies at startup", len(policies))
+
+	return nil
+}
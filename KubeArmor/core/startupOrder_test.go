@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+func TestHostPoliciesFirst(t *testing.T) {
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	dm := NewKubeArmorDaemon()
+	dm.Logger = &fd.Feeder{Node: &tp.Node{}}
+	dm.HostPoliciesSynced = NewStartupGate()
+
+	order := []string{}
+	lock := new(sync.Mutex)
+
+	record := func(step string) {
+		lock.Lock()
+		order = append(order, step)
+		lock.Unlock()
+	}
+
+	// the container security policies wait for the host security policies
+	done := make(chan struct{})
+	go func() {
+		dm.waitForHostPolicies()
+		record("container")
+		close(done)
+	}()
+
+	// a slow API server
+	time.Sleep(50 * time.Millisecond)
+
+	list := func() ([]tp.K8sKubeArmorHostPolicy, error) {
+		policy := tp.K8sKubeArmorHostPolicy{}
+		policy.Metadata.Name = "harden-node"
+		return []tp.K8sKubeArmorHostPolicy{policy}, nil
+	}
+	apply := func(event tp.K8sKubeArmorHostPolicyEvent) pb.PolicyStatus {
+		record("host/" + event.Object.Metadata.Name)
+		return pb.PolicyStatus_Applied
+	}
+
+	if err := dm.syncHostSecurityPolicies(list, apply); err != nil {
+		t.Fatalf("[FAIL] Failed to sync host security policies (%s)", err.Error())
+	}
+	dm.HostPoliciesSynced.Open()
+
+	<-done
+
+	if len(order) != 2 || order[0] != "host/harden-node" || order[1] != "container" {
+		t.Errorf("[FAIL] Expected the host security policies first (%v)", order)
+	}
+
+	// list errors leave the gate closed
+	failing := func() ([]tp.K8sKubeArmorHostPolicy, error) {
+		return nil, errors.New("connection refused")
+	}
+	if err := dm.syncHostSecurityPolicies(failing, apply); err == nil {
+		t.Errorf("[FAIL] Expected the error of the list")
+	}
+
+	t.Log("[PASS] Applied host security policies before container security policies")
+}
+
+func TestHostPoliciesFirstTimeout(t *testing.T) {
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	prevTimeout := hostPolicySyncTimeout
+	defer func() { hostPolicySyncTimeout = prevTimeout }()
+	hostPolicySyncTimeout = 50 * time.Millisecond
+
+	dm := NewKubeArmorDaemon()
+	dm.Logger = &fd.Feeder{Node: &tp.Node{}}
+
+	// the host security policies never sync
+	dm.HostPoliciesSynced = NewStartupGate()
+
+	done := make(chan struct{})
+	go func() {
+		dm.waitForHostPolicies()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("[FAIL] Expected the container security policies not to wait forever")
+	}
+
+	// no gate without host security policies
+	dm.HostPoliciesSynced = nil
+	if !dm.HostPoliciesSynced.Wait(time.Millisecond) {
+		t.Errorf("[FAIL] Expected no wait without the gate")
+	}
+
+	t.Log("[PASS] Timed out waiting for host security policies")
+}
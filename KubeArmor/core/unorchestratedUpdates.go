@@ -5,14 +5,17 @@ package core
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"sort"
 	"strings"
 
 	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
 	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
 	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
 	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
 	pb "github.com/kubearmor/KubeArmor/protobuf"
 )
 
@@ -33,10 +36,35 @@ func (dm *KubeArmorDaemon) SetContainerNSVisibility() {
 	if strings.Contains(cfg.GlobalCfg.Visibility, "capabilities") {
 		visibility.Capabilities = true
 	}
+	if strings.Contains(cfg.GlobalCfg.Visibility, "signal") {
+		visibility.Signal = true
+	}
 
 	dm.UpdateVisibility("ADDED", "container_namespace", visibility)
 }
 
+// setUnorchestratedContainer sets the visibility of an un-orchestrated container, and names its endpoint after it
+func setUnorchestratedContainer(container *tp.Container) {
+	if strings.Contains(cfg.GlobalCfg.Visibility, "process") {
+		container.ProcessVisibilityEnabled = true
+	}
+	if strings.Contains(cfg.GlobalCfg.Visibility, "file") {
+		container.FileVisibilityEnabled = true
+	}
+	if strings.Contains(cfg.GlobalCfg.Visibility, "network") {
+		container.NetworkVisibilityEnabled = true
+	}
+	if strings.Contains(cfg.GlobalCfg.Visibility, "capabilities") {
+		container.CapabilitiesVisibilityEnabled = true
+	}
+	if strings.Contains(cfg.GlobalCfg.Visibility, "signal") {
+		container.SignalVisibilityEnabled = true
+	}
+
+	container.EndPointName = container.ContainerName
+	container.NamespaceName = "container_namespace"
+}
+
 // ====================================== //
 // == Container Security Policy Update == //
 // ====================================== //
@@ -82,6 +110,39 @@ func (dm *KubeArmorDaemon) MatchandRemoveContainerFromEndpoint(cid string) {
 
 // ParseAndUpdateContainerSecurityPolicy Function
 func (dm *KubeArmorDaemon) ParseAndUpdateContainerSecurityPolicy(event tp.K8sKubeArmorPolicyEvent) pb.PolicyStatus {
+	// endpoints having the policy before the update (for removal)
+	endpoints := dm.getPolicyEndpoints("container_namespace", event.Object.Metadata.Name)
+
+	status := dm.parseAndUpdateContainerSecurityPolicy(event)
+
+	if status == pb.PolicyStatus_Applied || status == pb.PolicyStatus_Modified || status == pb.PolicyStatus_Deleted {
+		dm.trackPolicyMaturation(KubeArmorPolicyKind, "container_namespace", event.Object.Metadata.Name, dm.containerPolicyMetadata("container_namespace", event.Object.Metadata.Name), status == pb.PolicyStatus_Deleted)
+		dm.trackPolicySchedule(KubeArmorPolicyKind, "container_namespace", event.Object.Metadata.Name, event.Object.Spec.Schedule, status == pb.PolicyStatus_Deleted)
+	}
+
+	for _, endpoint := range dm.getPolicyEndpoints("container_namespace", event.Object.Metadata.Name) {
+		if !kl.ContainsElement(endpoints, endpoint) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	// notify policy watchers, with the differences of the enforcement on this node
+	action, reason := policyStatusToEventAction(status)
+
+	differences := []string{}
+	if action == fd.PolicyApplied || action == fd.PolicyUpdated {
+		differences = fd.AnalyzePolicyCompatibility(dm.Logger.Enforcer, event.Object.Spec)
+	}
+
+	dm.Logger.PushPolicyEventWithCompatibility(KubeArmorPolicyKind, "container_namespace", event.Object.Metadata.Name, action, reason, endpoints, differences, dm.policyPathWarnings("container_namespace", event.Object.Metadata.Name))
+
+	return status
+}
+
+// parseAndUpdateContainerSecurityPolicy Function
+func (dm *KubeArmorDaemon) parseAndUpdateContainerSecurityPolicy(event tp.K8sKubeArmorPolicyEvent) pb.PolicyStatus {
+	// the endpoints may need the probes of a detached event class
+	defer dm.requestEventClassUpdate()
 
 	// create a container security policy
 	secPolicy := tp.SecurityPolicy{}
@@ -90,11 +151,51 @@ func (dm *KubeArmorDaemon) ParseAndUpdateContainerSecurityPolicy(event tp.K8sKub
 	secPolicy.Metadata["namespaceName"] = "container_namespace" //event.Object.Metadata.Namespace
 	secPolicy.Metadata["policyName"] = event.Object.Metadata.Name
 
+	// matchPaths of Block rules expected not to exist in containers
+	if expectMissing, ok := event.Object.Metadata.Annotations[ksp.ExpectMissingAnnotation]; ok {
+		secPolicy.Metadata["expectMissing"] = expectMissing
+	}
+
 	if err := kl.Clone(event.Object.Spec, &secPolicy.Spec); err != nil {
 		dm.Logger.Errf("Failed to clone a spec (%s)", err.Error())
 		return pb.PolicyStatus_Failure
 	}
 
+	// rules inherit the action of the policy, one of them is needed
+	if event.Type != "DELETED" {
+		if err := validatePolicyActions(secPolicy.Spec); err != nil {
+			dm.Logger.Warnf("Rejected a security policy (%s, %s)", event.Object.Metadata.Name, err.Error())
+			return pb.PolicyStatus_Invalid
+		}
+
+		if err := validateFileAttributeActions(secPolicy.Spec.File, secPolicy.Spec.Action); err != nil {
+			dm.Logger.Warnf("Rejected a security policy (%s, %s)", event.Object.Metadata.Name, err.Error())
+			return pb.PolicyStatus_Invalid
+		}
+
+		if err := validateMatchExpressions(secPolicy.Spec.Selector.MatchExpressions); err != nil {
+			dm.Logger.Warnf("Rejected a security policy (%s, %s)", event.Object.Metadata.Name, err.Error())
+			return pb.PolicyStatus_Invalid
+		}
+
+		if err := validatePolicyMode(secPolicy.Spec.Mode); err != nil {
+			dm.Logger.Warnf("Rejected a security policy (%s, %s)", event.Object.Metadata.Name, err.Error())
+			return pb.PolicyStatus_Invalid
+		}
+
+		if err := validatePolicySchedule(secPolicy.Spec.Schedule); err != nil {
+			dm.Logger.Warnf("Rejected a security policy (%s, %s)", event.Object.Metadata.Name, err.Error())
+			return pb.PolicyStatus_Invalid
+		}
+
+		// Block rules are audited until the maturation period elapses since the creation of the policy
+		created := policyCreationTime(event.Object.Metadata.CreationTimestamp, dm.containerPolicyMetadata("container_namespace", event.Object.Metadata.Name), dm.maturationTime())
+		if err := setPolicyMaturation(secPolicy.Metadata, created, secPolicy.Spec.MaturationPeriod); err != nil {
+			dm.Logger.Warnf("Rejected a security policy (%s, %s)", event.Object.Metadata.Name, err.Error())
+			return pb.PolicyStatus_Invalid
+		}
+	}
+
 	kl.ObjCommaExpandFirstDupOthers(&secPolicy.Spec.Network.MatchProtocols)
 	kl.ObjCommaExpandFirstDupOthers(&secPolicy.Spec.Capabilities.MatchCapabilities)
 
@@ -467,18 +568,13 @@ func (dm *KubeArmorDaemon) ParseAndUpdateContainerSecurityPolicy(event tp.K8sKub
 			}
 		}
 
-		if kl.MatchIdentities(secPolicy.Spec.Selector.Identities, endPoint.Identities) && i < 0 {
+		if matchSelector(secPolicy.Spec.Selector, endPoint.Identities) && i < 0 {
 			i = endPointIndex
 			newPoint = endPoint
 		}
 	}
 
-	globalDefaultPosture := tp.DefaultPosture{
-		FileAction:         cfg.GlobalCfg.DefaultFilePosture,
-		NetworkAction:      cfg.GlobalCfg.DefaultNetworkPosture,
-		CapabilitiesAction: cfg.GlobalCfg.DefaultCapabilitiesPosture,
-	}
-	newPoint.DefaultPosture = globalDefaultPosture
+	newPoint.DefaultPosture = getGlobalDefaultPosture()
 
 	// check that a security policy should exist before performing delete operation
 	policymatch := 0
@@ -523,6 +619,7 @@ func (dm *KubeArmorDaemon) ParseAndUpdateContainerSecurityPolicy(event tp.K8sKub
 			newPoint.FileVisibilityEnabled = true
 			newPoint.NetworkVisibilityEnabled = true
 			newPoint.CapabilitiesVisibilityEnabled = true
+			newPoint.SignalVisibilityEnabled = true
 			newPoint.Containers = []string{}
 			dm.ContainersLock.Lock()
 			for idx, ctr := range dm.Containers {
@@ -612,6 +709,9 @@ func (dm *KubeArmorDaemon) backupKubeArmorHostPolicy(policy tp.HostSecurityPolic
 				if err := file.Close(); err != nil {
 					dm.Logger.Errf(err.Error())
 				}
+				if err := writePolicyDigest(cfg.PolicyDigestDir, policy.Metadata["policyName"]+".yaml", policyBytes, getPolicyCacheKey()); err != nil {
+					dm.Logger.Errf("Failed to store the policy digest (%s)", err.Error())
+				}
 			}
 		}
 	}
@@ -636,6 +736,9 @@ func (dm *KubeArmorDaemon) backupKubeArmorContainerPolicy(policy tp.SecurityPoli
 				if err := file.Close(); err != nil {
 					dm.Logger.Errf(err.Error())
 				}
+				if err := writePolicyDigest(cfg.PolicyDigestDir, policy.Metadata["policyName"]+".yaml", policyBytes, getPolicyCacheKey()); err != nil {
+					dm.Logger.Errf("Failed to store the policy digest (%s)", err.Error())
+				}
 			}
 		}
 	}
@@ -647,49 +750,65 @@ func (dm *KubeArmorDaemon) restoreKubeArmorPolicies() {
 		return
 	}
 
-	// List all policies files from "/opt/kubearmor/policies" path
-	if policyFiles, err := os.ReadDir(cfg.PolicyDir); err == nil {
-		for _, file := range policyFiles {
-			if data, err := os.ReadFile(cfg.PolicyDir + file.Name()); err == nil {
+	// the cache of an older KubeArmor has no digests, and is trusted on the upgrade only if it's enabled explicitly
+	if cfg.GlobalCfg.MigratePolicyCache {
+		if migrated, err := migratePolicyCache(cfg.PolicyDir, cfg.PolicyDigestDir, cfg.PolicyMigratedPath, getPolicyCacheKey()); err != nil {
+			kg.Warnf("Failed to migrate the policy cache (%s)", err.Error())
+		} else if migrated > 0 {
+			kg.Warnf("Wrote the digests of %d cached policies without digests, verifying them from now on", migrated)
+		}
+	}
 
-				var k struct {
-					Metadata map[string]string `json:"metadata"`
-				}
+	// List all policies files from "/opt/kubearmor/policies" path and verify them
+	policyFiles, fileName, err := loadPolicyCache(cfg.PolicyDir, cfg.PolicyDigestDir, getPolicyCacheKey())
+	if err != nil {
+		if errors.Is(err, errPolicyCacheTampered) {
+			// refuse to load the whole cache, policies will be reconciled by the control plane
+			dm.reportPolicyCacheTampering(fileName, err)
+		} else {
+			kg.Warnf("Failed to read the policy cache (%s)", err.Error())
+		}
+		return
+	}
 
-				err := json.Unmarshal(data, &k)
-				if err != nil {
-					kg.Errf("Failed to unmarshal policy: %v", err)
-					continue
-				}
+	for _, data := range policyFiles {
+		var k struct {
+			Metadata map[string]string `json:"metadata"`
+		}
 
-				if _, ok := k.Metadata["namespaceName"]; ok { // ContainerPolicy contains namespaceName
-					var containerPolicy tp.K8sKubeArmorPolicy
-					if err := json.Unmarshal(data, &containerPolicy); err == nil {
-						containerPolicy.Metadata.Name = k.Metadata["policyName"]
-						dm.ParseAndUpdateContainerSecurityPolicy(tp.K8sKubeArmorPolicyEvent{
-							Type:   "ADDED",
-							Object: containerPolicy,
-						})
-					}
+		err := json.Unmarshal(data, &k)
+		if err != nil {
+			kg.Errf("Failed to unmarshal policy: %v", err)
+			continue
+		}
 
-				} else { // HostSecurityPolicy
-					var hostPolicy tp.HostSecurityPolicy
-					if err := json.Unmarshal(data, &hostPolicy); err == nil {
-						dm.HostSecurityPolicies = append(dm.HostSecurityPolicies, hostPolicy)
-					} else {
-						kg.Errf("Failed to unmarshal host policy: %v", err)
-					}
-				}
+		if _, ok := k.Metadata["namespaceName"]; ok { // ContainerPolicy contains namespaceName
+			var containerPolicy tp.K8sKubeArmorPolicy
+			if err := json.Unmarshal(data, &containerPolicy); err == nil {
+				containerPolicy.Metadata.Name = k.Metadata["policyName"]
+				dm.ParseAndUpdateContainerSecurityPolicy(tp.K8sKubeArmorPolicyEvent{
+					Type:   "ADDED",
+					Object: containerPolicy,
+				})
+			}
+
+		} else { // HostSecurityPolicy
+			var hostPolicy tp.HostSecurityPolicy
+			if err := json.Unmarshal(data, &hostPolicy); err == nil {
+				dm.HostSecurityPolicies = append(dm.HostSecurityPolicies, hostPolicy)
+				dm.trackPolicyMaturation(KubeArmorHostPolicyKind, "", hostPolicy.Metadata["policyName"], hostPolicy.Metadata, false)
+			} else {
+				kg.Errf("Failed to unmarshal host policy: %v", err)
 			}
 		}
+	}
 
-		if len(policyFiles) != 0 {
-			if len(dm.HostSecurityPolicies) != 0 {
-				dm.UpdateHostSecurityPolicies()
-			}
-		} else {
-			kg.Warn("No policies found for restoration")
+	if len(policyFiles) != 0 {
+		if len(dm.HostSecurityPolicies) != 0 {
+			dm.UpdateHostSecurityPolicies()
 		}
+	} else {
+		kg.Warn("No policies found for restoration")
 	}
 }
 
@@ -706,4 +825,6 @@ func (dm *KubeArmorDaemon) removeBackUpPolicy(name string) {
 	if err := os.Remove(fname); err != nil {
 		kg.Errf("unable to delete file:%s err=%s", fname, err.Error())
 	}
+
+	removePolicyDigest(cfg.PolicyDigestDir, name+".yaml")
 }
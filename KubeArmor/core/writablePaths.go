@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+)
+
+// ==================== //
+// == Writable Paths == //
+// ==================== //
+
+// requiredWritablePaths returns the directories the daemon writes to with the current configuration and the active
+// LSMs (the content of /sys/kernel/security/lsm)
+func requiredWritablePaths(lsms string) []string {
+	// policy cache, state files, PID file, temp files
+	paths := []string{cfg.GlobalCfg.StateDir, cfg.TempDir}
+
+	// log file, or the directory of the archive
+	if output := cfg.GlobalCfg.LogPath; output != "" && output != "stdout" && output != "none" {
+		if cfg.GlobalCfg.LogArchive {
+			paths = append(paths, output)
+		} else {
+			paths = append(paths, filepath.Dir(output))
+		}
+	}
+
+	enforced := cfg.GlobalCfg.Policy || cfg.GlobalCfg.HostPolicy
+
+	// AppArmor profiles, in the apparmor.d of the host
+	if enforced && strings.Contains(lsms, "apparmor") {
+		paths = append(paths, cfg.AppArmorProfileDir())
+	}
+
+	// SELinux host profiles
+	if cfg.GlobalCfg.HostPolicy && strings.Contains(lsms, "selinux") {
+		paths = append(paths, cfg.GlobalCfg.SELinuxProfileDir)
+	}
+
+	return paths
+}
+
+// checkWritablePaths creates the given directories if needed, and fails with the ones which can't be written to
+func checkWritablePaths(paths []string) error {
+	failures := []string{}
+	checked := map[string]struct{}{}
+
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		if _, ok := checked[path]; ok {
+			continue
+		}
+		checked[path] = struct{}{}
+
+		if err := os.MkdirAll(path, 0750); err != nil {
+			failures = append(failures, fmt.Sprintf("%s (%s)", path, unwrapPathError(err)))
+			continue
+		}
+
+		file, err := os.CreateTemp(path, ".kubearmor-")
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s (%s)", path, unwrapPathError(err)))
+			continue
+		}
+
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("required paths are not writable: %s (set stateDir, hostEtcDir, logPath or seLinuxProfileDir to writable mounts)", strings.Join(failures, ", "))
+	}
+
+	return nil
+}
+
+// unwrapPathError returns the reason of a path error without the path
+func unwrapPathError(err error) string {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err.Error()
+	}
+
+	return err.Error()
+}
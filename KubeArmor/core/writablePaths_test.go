@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package core
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestWritablePaths(t *testing.T) {
+	dir := t.TempDir()
+
+	prevCfg := cfg.GlobalCfg
+	defer func() {
+		cfg.GlobalCfg = prevCfg
+		cfg.SetStateDir("/opt/kubearmor")
+	}()
+
+	// a read-only root filesystem, where the paths can't be created (the root is a regular file here, since the
+	// permissions don't apply to root)
+	readOnly := dir + "/rootfs"
+	if err := os.WriteFile(readOnly, []byte{}, 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to create the read-only root (%s)", err.Error())
+	}
+
+	cfg.GlobalCfg.Policy = true
+	cfg.GlobalCfg.HostPolicy = false
+	cfg.GlobalCfg.LogPath = "stdout"
+	cfg.GlobalCfg.StateDir = readOnly + "/opt/kubearmor"
+	cfg.GlobalCfg.HostEtcDir = readOnly + "/etc"
+	cfg.SetStateDir(cfg.GlobalCfg.StateDir)
+
+	err := checkWritablePaths(requiredWritablePaths("lockdown,capability,apparmor"))
+	if err == nil {
+		t.Fatalf("[FAIL] Expected the read-only paths to fail the check")
+	}
+
+	for _, path := range []string{cfg.GlobalCfg.StateDir, cfg.TempDir, readOnly + "/etc/apparmor.d"} {
+		if !strings.Contains(err.Error(), path+" (") {
+			t.Errorf("[FAIL] Expected %s to be listed (%s)", path, err.Error())
+		}
+	}
+
+	// the writable paths relocated to the mounted volumes
+	cfg.GlobalCfg.StateDir = dir + "/state"
+	cfg.GlobalCfg.HostEtcDir = dir + "/host/etc"
+	cfg.GlobalCfg.LogPath = dir + "/logs/kubearmor.log"
+	cfg.SetStateDir(cfg.GlobalCfg.StateDir)
+
+	if err := checkWritablePaths(requiredWritablePaths("lockdown,capability,apparmor")); err != nil {
+		t.Fatalf("[FAIL] Expected the relocated paths to be writable (%s)", err.Error())
+	}
+
+	for _, path := range []string{dir + "/state/tmp", dir + "/host/etc/apparmor.d", dir + "/logs"} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("[FAIL] Expected %s to be created (%s)", path, err.Error())
+		}
+	}
+
+	// the state of the daemon is written in the state directory
+	dm := newCrioTestDaemon()
+
+	policy := tp.HostSecurityPolicy{Metadata: map[string]string{"policyName": "audit-passwd"}}
+	dm.backupKubeArmorHostPolicy(policy)
+
+	if cfg.PolicyDir != dir+"/state/policies/" {
+		t.Errorf("[FAIL] Unexpected policy directory (%s)", cfg.PolicyDir)
+	}
+
+	if _, err := os.Stat(cfg.PolicyDir + "audit-passwd.yaml"); err != nil {
+		t.Errorf("[FAIL] Expected the policy to be backed up in the state directory (%s)", err.Error())
+	}
+
+	t.Log("[PASS] Checked the writable paths")
+}
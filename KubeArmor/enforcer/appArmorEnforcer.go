@@ -5,13 +5,16 @@
 package enforcer
 
 import (
+	"errors"
+	"fmt"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
 	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
 	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
 	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
@@ -37,6 +40,26 @@ type AppArmorEnforcer struct {
 	AppArmorProfiles     map[string][]string
 	AppArmorProfilesLock *sync.RWMutex
 
+	// base layers shared by the profiles of the same image (keyed by its digest)
+	LayeredProfiles  bool
+	BaseProfiles     map[string]*BaseProfile
+	ImageRules       map[string]map[string]Rules
+	ProfileImages    map[string]string
+	ProfileBases     map[string]string
+	CompileStats     ProfileCompileStats
+	BaseProfilesLock *sync.Mutex
+
+	// profiles loaded by the previous instance
+	ProfileStatePath string
+	ProfileState     appArmorProfileState
+	ProfileStateLock *sync.Mutex
+
+	// includes of the OS missing since the profiles were generated, replaced in the self-contained profiles
+	BrokenIncludes     map[string]struct{}
+	BrokenIncludesLock *sync.RWMutex
+	includeWatcher     *fsnotify.Watcher
+	includeWatcherDone chan struct{}
+
 	// Regex used to get profile Names
 	rgx *regexp.Regexp
 }
@@ -49,7 +72,7 @@ func NewAppArmorEnforcer(node tp.Node, logger *fd.Feeder) *AppArmorEnforcer {
 	ae.Logger = logger
 
 	// default profile
-	ae.ApparmorDefault = `## == Managed by KubeArmor == ##
+	ae.ApparmorDefault = appArmorOwnershipMarker + `
 	
 #include <tunables/global>
 profile apparmor-default flags=(attach_disconnected,mediate_deleted) {
@@ -96,19 +119,38 @@ deny /sys/kernel/security/** rwklx,
 	ae.AppArmorProfiles = map[string][]string{}
 	ae.AppArmorProfilesLock = &sync.RWMutex{}
 
-	files, err := os.ReadDir("/etc/apparmor.d")
+	// base layers
+	ae.LayeredProfiles = false
+	ae.BaseProfiles = map[string]*BaseProfile{}
+	ae.ImageRules = map[string]map[string]Rules{}
+	ae.ProfileImages = map[string]string{}
+	ae.ProfileBases = map[string]string{}
+	ae.BaseProfilesLock = &sync.Mutex{}
+
+	// profile state
+	ae.ProfileStatePath = cfg.AppArmorStatePath
+	ae.ProfileStateLock = &sync.Mutex{}
+
+	// broken includes
+	ae.BrokenIncludes = map[string]struct{}{}
+	ae.BrokenIncludesLock = &sync.RWMutex{}
+
+	// profiles in the apparmor.d of the host
+	appArmorProfileDir = cfg.AppArmorProfileDir()
+
+	files, err := os.ReadDir(appArmorProfileDir)
 	if err != nil {
-		ae.Logger.Errf("Failed to read /etc/apparmor.d (%s)", err.Error())
+		ae.Logger.Errf("Failed to read %s (%s)", appArmorProfileDir, err.Error())
 		return nil
 	}
 
 	existingProfiles := []string{}
 
-	if pids, err := os.ReadDir(filepath.Clean("/proc")); err == nil {
+	if pids, err := os.ReadDir(kl.GetHostProcPath()); err == nil {
 		for _, f := range pids {
 			if f.IsDir() {
 				if _, err := strconv.Atoi(f.Name()); err == nil {
-					if content, err := os.ReadFile(filepath.Clean("/proc/" + f.Name() + "/attr/current")); err == nil {
+					if content, err := os.ReadFile(kl.GetHostProcPath(f.Name(), "attr", "current")); err == nil {
 						line := strings.Split(string(content), "\n")[0]
 						words := strings.Split(line, " ")
 
@@ -122,47 +164,61 @@ deny /sys/kernel/security/** rwklx,
 		}
 	}
 
+	ae.removeInactiveProfiles(files, existingProfiles)
+
+	ae.RemoveStaleBaseLayers()
+
+	ae.restoreProfileState()
+
+	if cfg.GlobalCfg.AppArmorLayeredProfiles {
+		ae.EnableLayeredProfiles()
+	}
+
+	if cfg.GlobalCfg.HostPolicy {
+		if ok := ae.RegisterAppArmorHostProfile(); !ok {
+			return nil
+		}
+	}
+
+	return ae
+}
+
+// removeInactiveProfiles removes the profiles of KubeArmor left by the previous instance and not used by any container
+func (ae *AppArmorEnforcer) removeInactiveProfiles(files []os.DirEntry, existingProfiles []string) {
 	for _, file := range files {
 		if !file.Type().IsRegular() {
-			ae.Logger.Printf("skipping /etc/apparmor.d/%s since not a regular file", file.Name())
+			ae.Logger.Printf("skipping %s since not a regular file", getProfilePath(file.Name()))
 			continue
 		}
 
 		fileName := file.Name()
 
-		data, err := os.ReadFile(filepath.Clean("/etc/apparmor.d/" + fileName))
+		data, err := os.ReadFile(getProfilePath(fileName))
 		if err != nil {
-			ae.Logger.Errf("Failed to read /etc/apparmor.d/%s (%s)", fileName, err.Error())
+			ae.Logger.Errf("Failed to read %s (%s)", getProfilePath(fileName), err.Error())
 			continue
 		}
 		str := string(data)
 
-		if strings.Contains(str, "KubeArmor") {
+		// the profiles of the other managers are never removed
+		if isOwnedProfile(str) {
 			if kl.ContainsElement(existingProfiles, fileName) {
 				continue // if the profile is used by a running container, do not remove it
 			}
 
-			if err := kl.RunCommandAndWaitWithErr("apparmor_parser", []string{"-R", "/etc/apparmor.d/" + fileName}); err != nil {
-				ae.Logger.Warnf("Unable to detach /etc/apparmor.d/%s (%s)", fileName, err.Error())
+			if err := runAppArmorParser("-R", getProfilePath(fileName)); err != nil {
+				ae.Logger.Warnf("Unable to detach %s (%s)", getProfilePath(fileName), err.Error())
 				continue // still need to check other profiles
 			}
 
-			if err := os.Remove(filepath.Clean("/etc/apparmor.d/" + fileName)); err != nil {
-				ae.Logger.Warnf("Unable to remove /etc/apparmor.d/%s (%s)", fileName, err.Error())
+			if err := os.Remove(getProfilePath(fileName)); err != nil {
+				ae.Logger.Warnf("Unable to remove %s (%s)", getProfilePath(fileName), err.Error())
 				continue // still need to check other profiles
 			}
 
 			ae.Logger.Printf("Removed an inactive AppArmor profile (%s)", fileName)
 		}
 	}
-
-	if cfg.GlobalCfg.HostPolicy {
-		if ok := ae.RegisterAppArmorHostProfile(); !ok {
-			return nil
-		}
-	}
-
-	return ae
 }
 
 // DestroyAppArmorEnforcer Function
@@ -172,6 +228,8 @@ func (ae *AppArmorEnforcer) DestroyAppArmorEnforcer() error {
 		return nil
 	}
 
+	ae.stopWatchingAppArmorIncludes()
+
 	for profile := range ae.AppArmorProfiles {
 		ae.UnregisterAppArmorProfile("", profile)
 	}
@@ -180,6 +238,12 @@ func (ae *AppArmorEnforcer) DestroyAppArmorEnforcer() error {
 		ae.UnregisterAppArmorHostProfile()
 	}
 
+	if ae.LayeredProfiles {
+		stats := ae.GetCompileStats()
+		ae.Logger.Printf("Compiled %d layered and %d monolithic AppArmor profiles (base layers: %d reused, %d written, saved %s)",
+			stats.Layered, stats.Monolithic, stats.BaseHits, stats.BaseMisses, stats.Saved)
+	}
+
 	ae = nil
 
 	return nil
@@ -199,11 +263,11 @@ func (ae *AppArmorEnforcer) RegisterAppArmorProfile(podName, profileName string)
 	ae.AppArmorProfilesLock.Lock()
 	defer ae.AppArmorProfilesLock.Unlock()
 
-	if _, err := os.Stat(filepath.Clean("/etc/apparmor.d/" + profileName)); err == nil {
-		if content, err := os.ReadFile(filepath.Clean("/etc/apparmor.d/" + profileName)); err != nil {
+	if _, err := os.Stat(getProfilePath(profileName)); err == nil {
+		if content, err := os.ReadFile(getProfilePath(profileName)); err != nil {
 			ae.Logger.Warnf("Unable to register the AppArmor profile (%s, %s))", profileName, err.Error())
 			return false
-		} else if !strings.Contains(string(content), "KubeArmor") {
+		} else if !isOwnedProfile(string(content)) {
 			ae.Logger.Warnf("Unable to register the AppArmor profile (%s) (out-of-control)", profileName)
 			return false
 		}
@@ -216,9 +280,17 @@ func (ae *AppArmorEnforcer) RegisterAppArmorProfile(podName, profileName string)
 		return true
 	}
 
+	// adopt the profile loaded by the previous instance
+	if ae.isProfileLoaded(profileName) {
+		ae.AppArmorProfiles[profileName] = []string{podName}
+		ae.Logger.Printf("Adopted the AppArmor profile loaded before (%s)", profileName)
+		return true
+	}
+
 	newProfile := strings.Replace(ae.ApparmorDefault, "apparmor-default", profileName, -1)
+	newProfile, _ = ae.selfContainedProfile(newProfile)
 
-	newFile, err := os.Create(filepath.Clean("/etc/apparmor.d/" + profileName))
+	newFile, err := os.Create(getProfilePath(profileName))
 	if err != nil {
 		ae.Logger.Warnf("Unable to create the AppArmor profile (%s, %s)", profileName, err.Error())
 		return false
@@ -234,11 +306,13 @@ func (ae *AppArmorEnforcer) RegisterAppArmorProfile(podName, profileName string)
 		return false
 	}
 
-	if err := kl.RunCommandAndWaitWithErr("apparmor_parser", []string{"-r", "-W", "/etc/apparmor.d/" + profileName}); err != nil {
+	if err := runAppArmorParser("-r", "-W", getProfilePath(profileName)); err != nil {
 		ae.Logger.Warnf("Unable to register the AppArmor profile (%s, %s)", profileName, err.Error())
 		return false
 	}
 
+	ae.setProfileHash(profileName, newProfile)
+
 	ae.AppArmorProfiles[profileName] = []string{podName}
 
 	ae.Logger.Printf("Registered the AppArmor profile (%s)", profileName)
@@ -272,22 +346,23 @@ func (ae *AppArmorEnforcer) UnregisterAppArmorProfile(podName, profileName strin
 		return false
 	}
 
-	if _, err := os.Stat(filepath.Clean("/etc/apparmor.d/" + profileName)); err != nil {
+	if _, err := os.Stat(getProfilePath(profileName)); err != nil {
 		ae.Logger.Warnf("Unable to find the AppArmor profile (%s, %s)", profileName, err.Error())
 		return false
 	}
 
-	if content, err := os.ReadFile(filepath.Clean("/etc/apparmor.d/" + profileName)); err != nil {
+	if content, err := os.ReadFile(getProfilePath(profileName)); err != nil {
 		ae.Logger.Warnf("Unable to read the AppArmor profile (%s, %s)", profileName, err.Error())
 		return false
-	} else if !strings.Contains(string(content), "KubeArmor") {
+	} else if !isOwnedProfile(string(content)) {
 		ae.Logger.Warnf("Unable to unregister the AppArmor profile (%s) (out-of-control)", profileName)
 		return false
 	}
 
 	newProfile := strings.Replace(ae.ApparmorDefault, "apparmor-default", profileName, -1)
+	newProfile, _ = ae.selfContainedProfile(newProfile)
 
-	newFile, err := os.Create(filepath.Clean("/etc/apparmor.d/" + profileName))
+	newFile, err := os.Create(getProfilePath(profileName))
 	if err != nil {
 		ae.Logger.Warnf("Unable to open the AppArmor profile (%s, %s)", profileName, err.Error())
 		return false
@@ -303,13 +378,18 @@ func (ae *AppArmorEnforcer) UnregisterAppArmorProfile(podName, profileName strin
 		return false
 	}
 
-	if err := kl.RunCommandAndWaitWithErr("apparmor_parser", []string{"-r", "-W", "/etc/apparmor.d/" + profileName}); err != nil {
+	if err := runAppArmorParser("-r", "-W", getProfilePath(profileName)); err != nil {
 		ae.Logger.Warnf("Unable to unregister the AppArmor profile (%s, %s)", profileName, err.Error())
 		return false
 	}
 
 	delete(ae.AppArmorProfiles, profileName)
 
+	// the default profile stays loaded until the file is removed at the next start
+	ae.setProfileHash(profileName, newProfile)
+
+	ae.RemoveBaseLayer(profileName)
+
 	ae.Logger.Printf("Unregistered the AppArmor profile (%s)", profileName)
 
 	return true
@@ -319,8 +399,8 @@ func (ae *AppArmorEnforcer) UnregisterAppArmorProfile(podName, profileName strin
 // == AppArmor Host Profile Management == //
 // ====================================== //
 
-// AppArmorEnforcer constants
-const appArmorHostFile = "/etc/apparmor.d/kubearmor.host"
+// appArmorHostProfile is the file of the host profile in the profile directory
+const appArmorHostProfile = "kubearmor.host"
 
 // ClearKubeArmorHostFile Function
 func (ae *AppArmorEnforcer) ClearKubeArmorHostFile(fileName string) {
@@ -339,7 +419,7 @@ func (ae *AppArmorEnforcer) CreateAppArmorHostProfile() error {
 		return nil
 	}
 
-	apparmorHostDefault := `## == Managed by KubeArmor == ##
+	apparmorHostDefault := appArmorOwnershipMarker + `
 #include <tunables/global>
 
 profile kubearmor.host /{usr/,}bin/*sh flags=(attach_disconnected,mediate_deleted) {
@@ -360,7 +440,11 @@ capability,
 ## == POLICY END == ##
 }
 `
-	newfile, err := os.Create(filepath.Clean(appArmorHostFile))
+	if err := checkProfileOwnership(appArmorHostProfile); err != nil {
+		return err
+	}
+
+	newfile, err := os.Create(getProfilePath(appArmorHostProfile))
 	if err != nil {
 		ae.Logger.Warnf("Unable to open the KubeArmor host profile in %s (%s)", cfg.GlobalCfg.Host, err.Error())
 		return err
@@ -396,14 +480,14 @@ func (ae *AppArmorEnforcer) RegisterAppArmorHostProfile() bool {
 		return false
 	}
 
-	if err := kl.RunCommandAndWaitWithErr("apparmor_parser", []string{"-r", "-W", "-C", appArmorHostFile}); err != nil {
+	if err := runAppArmorParser("-r", "-W", "-C", getProfilePath(appArmorHostProfile)); err != nil {
 		ae.Logger.Warnf("Unable to register the KubeArmor host profile in %s (%s)", cfg.GlobalCfg.Host, err.Error())
 		return false
 	}
 
 	ae.Logger.Printf("Registered the KubeArmor host profile in %s", cfg.GlobalCfg.Host)
 
-	ae.ClearKubeArmorHostFile(appArmorHostFile)
+	ae.ClearKubeArmorHostFile(getProfilePath(appArmorHostProfile))
 
 	return true
 }
@@ -421,24 +505,29 @@ func (ae *AppArmorEnforcer) UnregisterAppArmorHostProfile() bool {
 	if err := ae.CreateAppArmorHostProfile(); err != nil {
 		ae.Logger.Warnf("Unable to reset the KubeArmor host profile in %s", cfg.GlobalCfg.Host)
 
-		if err := os.Remove(appArmorHostFile); err != nil {
+		// the profile of another manager is left as it is
+		if errors.Is(err, errForeignProfile) {
+			return false
+		}
+
+		if err := os.Remove(getProfilePath(appArmorHostProfile)); err != nil {
 			ae.Logger.Warnf("Unable to remove the KubeArmor host profile from %s (%s)", cfg.GlobalCfg.Host, err.Error())
 		}
 
 		return false
 	}
 
-	if err := kl.RunCommandAndWaitWithErr("apparmor_parser", []string{"-r", "-W", "-C", appArmorHostFile}); err != nil {
+	if err := runAppArmorParser("-r", "-W", "-C", getProfilePath(appArmorHostProfile)); err != nil {
 		ae.Logger.Warnf("Unable to reset the KubeArmor host profile in %s", cfg.GlobalCfg.Host)
 
-		if err := os.Remove(appArmorHostFile); err != nil {
+		if err := os.Remove(getProfilePath(appArmorHostProfile)); err != nil {
 			ae.Logger.Warnf("Unable to remove the KubeArmor host profile from %s (%s)", cfg.GlobalCfg.Host, err.Error())
 		}
 
 		return false
 	}
 
-	if err := os.Remove(appArmorHostFile); err != nil {
+	if err := os.Remove(getProfilePath(appArmorHostProfile)); err != nil {
 		ae.Logger.Warnf("Unable to remove the KubeArmor host profile from %s (%s)", cfg.GlobalCfg.Host, err.Error())
 		return false
 	}
@@ -452,13 +541,37 @@ func (ae *AppArmorEnforcer) UnregisterAppArmorHostProfile() bool {
 // == Security Policy Enforcement == //
 // ================================= //
 
-// UpdateAppArmorProfile Function
-func (ae *AppArmorEnforcer) UpdateAppArmorProfile(endPoint tp.EndPoint, appArmorProfile string, securityPolicies []tp.SecurityPolicy) {
-	if policyCount, newProfile, ok := ae.GenerateAppArmorProfile(appArmorProfile, securityPolicies, endPoint.DefaultPosture); ok {
-		newfile, err := os.Create(filepath.Clean("/etc/apparmor.d/" + appArmorProfile))
+// UpdateAppArmorProfile updates an AppArmor profile, and returns the time spent on generating and loading it
+func (ae *AppArmorEnforcer) UpdateAppArmorProfile(endPoint tp.EndPoint, appArmorProfile string, securityPolicies []tp.SecurityPolicy) (fd.PolicyApplyTimes, error) {
+	times := fd.PolicyApplyTimes{}
+
+	// the layers are shared by the containers of the same image digest only, as the tags are mutable
+	image := ""
+	if ae.LayeredProfiles {
+		image = endPoint.ImageDigest
+	}
+
+	start := time.Now()
+	policyCount, newProfile, ok := ae.GenerateLayeredAppArmorProfile(appArmorProfile, image, securityPolicies, endPoint.DefaultPosture)
+	times.Generate = time.Since(start)
+
+	if ok {
+		// without the includes of the OS missing since an upgrade
+		newProfile, _ = ae.selfContainedProfile(newProfile)
+
+		// the profile of another manager with the same name is never overwritten
+		if err := checkProfileOwnership(appArmorProfile); err != nil {
+			ae.Logger.Warnf("Unable to update the AppArmor profile (%s, %s)", appArmorProfile, err.Error())
+			return times, err
+		}
+
+		// keep the previous profile to regenerate the new one in the next attempt if it isn't loaded
+		oldProfile, _ := os.ReadFile(getProfilePath(appArmorProfile))
+
+		newfile, err := os.Create(getProfilePath(appArmorProfile))
 		if err != nil {
 			ae.Logger.Warnf("Unable to open an AppArmor profile (%s, %s)", appArmorProfile, err.Error())
-			return
+			return times, err
 		}
 
 		if _, err := newfile.WriteString(newProfile); err != nil {
@@ -468,7 +581,7 @@ func (ae *AppArmorEnforcer) UpdateAppArmorProfile(endPoint tp.EndPoint, appArmor
 				ae.Logger.Warnf("Unable to close the AppArmor profile (%s, %s)", appArmorProfile, err.Error())
 			}
 
-			return
+			return times, err
 		}
 
 		if err := newfile.Sync(); err != nil {
@@ -478,32 +591,52 @@ func (ae *AppArmorEnforcer) UpdateAppArmorProfile(endPoint tp.EndPoint, appArmor
 				ae.Logger.Warnf("Unable to close the AppArmor profile (%s, %s)", appArmorProfile, err.Error())
 			}
 
-			return
+			return times, err
 		}
 
 		if err := newfile.Close(); err != nil {
 			ae.Logger.Warnf("Unable to close the AppArmor profile (%s, %s)", appArmorProfile, err.Error())
-			return
+			return times, err
 		}
 
-		if err := kl.RunCommandAndWaitWithErr("apparmor_parser", []string{"-r", "-W", "/etc/apparmor.d/" + appArmorProfile}); err != nil {
+		start = time.Now()
+		err = runAppArmorParser("-r", "-W", getProfilePath(appArmorProfile))
+		times.Load = time.Since(start)
+
+		if err != nil {
 			ae.Logger.Warnf("Unable to update %d security rule(s) to %s/%s/%s (%s)", policyCount, endPoint.NamespaceName, endPoint.EndPointName, appArmorProfile, err.Error())
-			return
+
+			if err := os.WriteFile(getProfilePath(appArmorProfile), oldProfile, 0600); err != nil {
+				ae.Logger.Warnf("Unable to restore the AppArmor profile (%s, %s)", appArmorProfile, err.Error())
+			}
+
+			return times, err
 		}
 
+		ae.recordCompileTime(appArmorProfile, image != "", len(newProfile), times.Load)
+
+		ae.setProfileHash(appArmorProfile, newProfile)
+
 		ae.Logger.Printf("Updated %d security rule(s) to %s/%s/%s", policyCount, endPoint.NamespaceName, endPoint.EndPointName, appArmorProfile)
 	} else if newProfile != "" {
 		ae.Logger.Errf("Error Generating %s AppArmor profile: %s", appArmorProfile, newProfile)
+		return times, fmt.Errorf("failed to generate the AppArmor profile %s (%s)", appArmorProfile, newProfile)
 	}
+
+	return times, nil
 }
 
 // UpdateSecurityPolicies Function
-func (ae *AppArmorEnforcer) UpdateSecurityPolicies(endPoint tp.EndPoint) {
+func (ae *AppArmorEnforcer) UpdateSecurityPolicies(endPoint tp.EndPoint) (fd.PolicyApplyTimes, error) {
 	// skip if AppArmorEnforcer is not active
 	if ae == nil {
-		return
+		return fd.PolicyApplyTimes{}, nil
 	}
 
+	var updateErr error
+
+	times := fd.PolicyApplyTimes{}
+
 	appArmorProfiles := []string{}
 
 	for _, appArmorProfile := range endPoint.AppArmorProfiles {
@@ -517,14 +650,29 @@ func (ae *AppArmorEnforcer) UpdateSecurityPolicies(endPoint tp.EndPoint) {
 	}
 
 	if endPoint.PolicyEnabled == tp.KubeArmorPolicyEnabled {
+		// the owner rules of AppArmor only compare the owner of a file with the uid of a process
+		if endPoint.SecurityContext.FsGroup != nil && fd.UsesPodOwnerIdentity(endPoint.SecurityPolicies) {
+			ae.Logger.Warnf("AppArmor can't match the files owned by the fsGroup of %s/%s, ownerOnly rules only match the files owned by the process", endPoint.NamespaceName, endPoint.EndPointName)
+		}
+
 		for _, appArmorProfile := range appArmorProfiles {
-			ae.UpdateAppArmorProfile(endPoint, appArmorProfile, endPoint.SecurityPolicies)
+			profileTimes, err := ae.UpdateAppArmorProfile(endPoint, appArmorProfile, endPoint.SecurityPolicies)
+			if err != nil && updateErr == nil {
+				updateErr = err
+			}
+			times = times.Add(profileTimes)
 		}
 	} else { // PolicyDisabled
 		for _, appArmorProfile := range appArmorProfiles {
-			ae.UpdateAppArmorProfile(endPoint, appArmorProfile, []tp.SecurityPolicy{})
+			profileTimes, err := ae.UpdateAppArmorProfile(endPoint, appArmorProfile, []tp.SecurityPolicy{})
+			if err != nil && updateErr == nil {
+				updateErr = err
+			}
+			times = times.Add(profileTimes)
 		}
 	}
+
+	return times, updateErr
 }
 
 // ====================================== //
@@ -540,7 +688,14 @@ func (ae *AppArmorEnforcer) UpdateAppArmorHostProfile(secPolicies []tp.HostSecur
 	}
 
 	if policyCount, newProfile, ok := ae.GenerateAppArmorHostProfile(secPolicies, globalDefaultPosture); ok {
-		newfile, err := os.Create(filepath.Clean(appArmorHostFile))
+		newProfile, _ = ae.selfContainedProfile(newProfile)
+
+		if err := checkProfileOwnership(appArmorHostProfile); err != nil {
+			ae.Logger.Warnf("Unable to update the KubeArmor host profile in %s (%s)", cfg.GlobalCfg.Host, err.Error())
+			return
+		}
+
+		newfile, err := os.Create(getProfilePath(appArmorHostProfile))
 		if err != nil {
 			ae.Logger.Warnf("Unable to open the KubeArmor host profile in %s (%s)", cfg.GlobalCfg.Host, err.Error())
 			return
@@ -571,14 +726,14 @@ func (ae *AppArmorEnforcer) UpdateAppArmorHostProfile(secPolicies []tp.HostSecur
 			return
 		}
 
-		if err := kl.RunCommandAndWaitWithErr("apparmor_parser", []string{"-r", "-W", appArmorHostFile}); err != nil {
+		if err := runAppArmorParser("-r", "-W", getProfilePath(appArmorHostProfile)); err != nil {
 			ae.Logger.Warnf("Unable to update %d host security rule(s) to the KubeArmor host profile in %s (%s)", policyCount, cfg.GlobalCfg.Host, err.Error())
 			return
 		}
 
 		ae.Logger.Printf("Updated %d host security rules to the KubeArmor host profile in %s", policyCount, cfg.GlobalCfg.Host)
 
-		ae.ClearKubeArmorHostFile(appArmorHostFile)
+		ae.ClearKubeArmorHostFile(getProfilePath(appArmorHostProfile))
 	}
 }
 
@@ -265,3 +265,41 @@ func TestHostAppArmorProfile(t *testing.T) {
 	}
 	t.Log("[PASS] Destroyed logger")
 }
+
+func TestAppArmorEffectiveProfile(t *testing.T) {
+	ae := &AppArmorEnforcer{}
+
+	allow := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "web", "policyName": "allow-shell"}}
+	allow.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Allow"}, {Path: "/bin/ls", Action: "Allow"}}
+
+	block := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "web", "policyName": "block-shell"}}
+	block.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Block"}}
+
+	posture := tp.DefaultPosture{FileAction: "block", NetworkAction: "audit", CapabilitiesAction: "audit"}
+
+	for _, policies := range [][]tp.SecurityPolicy{{allow, block}, {block, allow}} {
+		_, profile := ae.GenerateProfileBody(policies, posture)
+
+		// the Block rule takes precedence, whatever the order of the policies is
+		if rule := profile.ProcessPaths["/bin/sh"]; !rule.Deny || rule.Allow {
+			t.Errorf("[FAIL] Unexpected rule of an overridden Allow rule (%+v)", rule)
+		}
+		if rule := profile.ProcessPaths["/bin/ls"]; rule.Deny || !rule.Allow {
+			t.Errorf("[FAIL] Unexpected rule of an Allow rule (%+v)", rule)
+		}
+
+		// the process section is still an allow-list
+		if profile.File || !profile.Network || !profile.Capabilities {
+			t.Errorf("[FAIL] Unexpected headers of the profile (%+v)", profile.ProfileHeader)
+		}
+	}
+
+	// the section of an Allow rule overridden for all its entities is still an allow-list
+	allow.Spec.Process.MatchPaths = allow.Spec.Process.MatchPaths[:1]
+
+	if _, profile := ae.GenerateProfileBody([]tp.SecurityPolicy{allow, block}, posture); profile.File {
+		t.Errorf("[FAIL] Expected an allow-list with the Allow rule overridden")
+	}
+
+	t.Log("[PASS] Generated the profile from the effective policy")
+}
@@ -518,7 +518,7 @@ func (ae *AppArmorEnforcer) BlockedHostCapabilitiesMatchCapabilities(cap tp.Capa
 
 // GenerateHostProfileHead Function
 func (ae *AppArmorEnforcer) GenerateHostProfileHead() string {
-	profileHead := `## == Managed by KubeArmor == ##
+	profileHead := appArmorOwnershipMarker + `
 
 #include <tunables/global>
 
@@ -0,0 +1,343 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package enforcer
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ============================== //
+// == AppArmor Include Watcher == //
+// ============================== //
+
+// AppArmorIncludeBrokenPolicyName is the policy name of the alerts of the profiles broken by the includes of the OS
+const AppArmorIncludeBrokenPolicyName = "kubearmor-apparmor-include-broken"
+
+// appArmorIncludeDirs are the directories of the includes of the OS, relative to the profile directory
+var appArmorIncludeDirs = []string{"abstractions", "tunables"}
+
+// appArmorIncludeDebounce is the delay of the validation after the last change of the includes (e.g., by an upgrade)
+var appArmorIncludeDebounce = 2 * time.Second
+
+// appArmorIncludeRegex matches the includes of a profile (e.g., #include <abstractions/base>)
+var appArmorIncludeRegex = regexp.MustCompile(`^\s*#?include\s+(if exists\s+)?<([^>]+)>`)
+
+// appArmorSelfContained are the rules replacing the includes of the OS in the self-contained profiles
+var appArmorSelfContained = map[string]string{
+	"tunables/global": `@{PROC}=/proc/
+`,
+	"abstractions/base": `  /etc/ld.so.cache mr,
+  /etc/ld.so.conf r,
+  /etc/ld.so.conf.d/{,*.conf} r,
+  /etc/ld.so.preload r,
+  /{usr/,}lib{,32,64}/** mr,
+  /etc/localtime r,
+  /usr/share/zoneinfo/** r,
+  /usr/lib/locale/** mr,
+  /usr/share/locale/** r,
+  /dev/null rw,
+  /dev/zero rw,
+  /dev/full rw,
+  /dev/random r,
+  /dev/urandom r,
+  /dev/log w,
+  /proc/sys/kernel/{cap_last_cap,ngroups_max,version} r,
+  /proc/sys/vm/overcommit_memory r,
+  /sys/devices/system/cpu/online r,
+  /proc/meminfo r,
+  /proc/stat r,
+  /proc/cpuinfo r,
+  signal (receive) peer=unconfined,
+`,
+}
+
+// profileIncludes returns the includes of the OS in a profile (the optional ones are skipped)
+func profileIncludes(profile string) []string {
+	includes := []string{}
+
+	for _, line := range strings.Split(profile, "\n") {
+		matches := appArmorIncludeRegex.FindStringSubmatch(line)
+		if matches == nil || matches[1] != "" {
+			continue
+		}
+
+		if !kl.ContainsElement(includes, matches[2]) {
+			includes = append(includes, matches[2])
+		}
+	}
+
+	return includes
+}
+
+// missingIncludes returns the includes of a profile missing in the profile directory
+func missingIncludes(profile string) []string {
+	missing := []string{}
+
+	for _, include := range profileIncludes(profile) {
+		if _, err := os.Stat(filepath.Join(appArmorProfileDir, include)); err != nil {
+			missing = append(missing, include)
+		}
+	}
+
+	return missing
+}
+
+// selfContainedProfile replaces the broken includes of a profile with their rules, and returns false if a broken
+// include can't be replaced
+func (ae *AppArmorEnforcer) selfContainedProfile(profile string) (string, bool) {
+	if ae.BrokenIncludesLock == nil {
+		return profile, true
+	}
+
+	ae.BrokenIncludesLock.RLock()
+	defer ae.BrokenIncludesLock.RUnlock()
+
+	if len(ae.BrokenIncludes) == 0 {
+		return profile, true
+	}
+
+	ok := true
+
+	lines := strings.Split(profile, "\n")
+	for idx, line := range lines {
+		matches := appArmorIncludeRegex.FindStringSubmatch(line)
+		if matches == nil || matches[1] != "" {
+			continue
+		}
+
+		if _, broken := ae.BrokenIncludes[matches[2]]; !broken {
+			continue
+		}
+
+		rules, found := appArmorSelfContained[matches[2]]
+		if !found {
+			ok = false
+			continue
+		}
+
+		lines[idx] = "## == " + matches[2] + " (self-contained) == ##\n" + strings.TrimSuffix(rules, "\n")
+	}
+
+	return strings.Join(lines, "\n"), ok
+}
+
+// updateBrokenIncludes records the missing includes, and forgets the ones restored since
+func (ae *AppArmorEnforcer) updateBrokenIncludes(missing []string) {
+	ae.BrokenIncludesLock.Lock()
+	defer ae.BrokenIncludesLock.Unlock()
+
+	for include := range ae.BrokenIncludes {
+		if _, err := os.Stat(filepath.Join(appArmorProfileDir, include)); err == nil {
+			delete(ae.BrokenIncludes, include)
+		}
+	}
+
+	for _, include := range missing {
+		ae.BrokenIncludes[include] = struct{}{}
+	}
+}
+
+// appArmorIncludeLog Function
+func appArmorIncludeLog(profile string, missing []string, fallback bool) tp.Log {
+	log := tp.Log{}
+
+	timestamp, updatedTime := kl.GetDateTimeNow()
+
+	log.Timestamp = timestamp
+	log.UpdatedTime = updatedTime
+
+	log.Type = "MatchedHostPolicy"
+	log.PolicyName = AppArmorIncludeBrokenPolicyName
+	log.Severity = "5"
+	log.Tags = "KUBEARMOR,ENFORCEMENT"
+	log.ATags = strings.Split(log.Tags, ",")
+
+	if fallback {
+		log.Message = "The AppArmor profile " + profile + " is broken by the missing include " + strings.Join(missing, ", ") + ", loaded a self-contained profile instead"
+		log.Result = "Passed"
+	} else {
+		log.Message = "The AppArmor profile " + profile + " is broken by the missing include " + strings.Join(missing, ", ") + ", its rules can't be updated until the include is restored"
+		log.Result = "Degraded"
+	}
+
+	log.Source = "kubearmor"
+	log.ProcessName = "kubearmor"
+	log.Operation = "AppArmor"
+	log.Resource = getProfilePath(profile)
+	log.Data = "profile=" + profile + " include=" + strings.Join(missing, ",") + " fallback=" + strconv.FormatBool(fallback)
+
+	log.Enforcer = "KubeArmor"
+	log.Action = "Audit"
+
+	return log
+}
+
+// ValidateAppArmorProfiles checks if the profiles of KubeArmor still compile with the includes of the OS, and loads
+// the self-contained profiles instead of the ones broken by missing includes. It returns the broken profiles.
+func (ae *AppArmorEnforcer) ValidateAppArmorProfiles() []string {
+	// skip if AppArmorEnforcer is not active
+	if ae == nil {
+		return nil
+	}
+
+	ae.AppArmorProfilesLock.RLock()
+	names := []string{}
+	for name := range ae.AppArmorProfiles {
+		names = append(names, name)
+	}
+	ae.AppArmorProfilesLock.RUnlock()
+
+	sort.Strings(names)
+
+	ae.updateBrokenIncludes(nil)
+
+	broken := []string{}
+
+	for _, name := range names {
+		data, err := os.ReadFile(getProfilePath(name))
+		if err != nil || !isOwnedProfile(string(data)) {
+			continue
+		}
+
+		// compiled without being loaded
+		if err := runAppArmorParser("-Q", "-K", getProfilePath(name)); err == nil {
+			continue
+		}
+
+		missing := missingIncludes(string(data))
+		if len(missing) == 0 {
+			ae.Logger.Warnf("The AppArmor profile %s fails to compile, although its includes exist", name)
+			continue
+		}
+
+		ae.updateBrokenIncludes(missing)
+
+		fallback := false
+
+		if newProfile, ok := ae.selfContainedProfile(string(data)); ok {
+			if err := os.WriteFile(getProfilePath(name), []byte(newProfile), 0600); err != nil {
+				ae.Logger.Warnf("Unable to write the self-contained AppArmor profile (%s, %s)", name, err.Error())
+			} else if err := runAppArmorParser("-r", "-W", getProfilePath(name)); err != nil {
+				ae.Logger.Warnf("Unable to load the self-contained AppArmor profile (%s, %s)", name, err.Error())
+
+				if err := os.WriteFile(getProfilePath(name), data, 0600); err != nil {
+					ae.Logger.Warnf("Unable to restore the AppArmor profile (%s, %s)", name, err.Error())
+				}
+			} else {
+				ae.setProfileHash(name, newProfile)
+				fallback = true
+			}
+		}
+
+		ae.Logger.Warnf("The AppArmor profile %s is broken by the missing include %s (self-contained: %t)", name, strings.Join(missing, ", "), fallback)
+		ae.Logger.PushSummaryLog(appArmorIncludeLog(name, missing, fallback))
+
+		broken = append(broken, name)
+	}
+
+	return broken
+}
+
+// isIncludeEvent checks if a change in the profile directory is a change of the includes of the OS
+func isIncludeEvent(profileDir, path string) bool {
+	rel, err := filepath.Rel(profileDir, path)
+	if err != nil {
+		return false
+	}
+
+	dir, _, _ := strings.Cut(rel, string(filepath.Separator))
+	return kl.ContainsElement(appArmorIncludeDirs, dir)
+}
+
+// WatchAppArmorIncludes validates the profiles with validate (ValidateAppArmorProfiles, under the lock of the
+// enforcer) whenever the includes of the OS change
+func (ae *AppArmorEnforcer) WatchAppArmorIncludes(validate func()) error {
+	profileDir := appArmorProfileDir
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	// the include directories may be replaced as a whole
+	if err := watcher.Add(profileDir); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	for _, dir := range appArmorIncludeDirs {
+		_ = watcher.Add(filepath.Join(profileDir, dir))
+	}
+
+	ae.includeWatcher = watcher
+	ae.includeWatcherDone = make(chan struct{})
+
+	go func() {
+		defer close(ae.includeWatcherDone)
+
+		var timer *time.Timer
+		fire := make(chan struct{}, 1)
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					if timer != nil {
+						timer.Stop()
+					}
+					return
+				}
+
+				if !isIncludeEvent(profileDir, event.Name) {
+					continue
+				}
+
+				if event.Has(fsnotify.Create) && kl.ContainsElement(appArmorIncludeDirs, filepath.Base(event.Name)) {
+					_ = watcher.Add(event.Name)
+				}
+
+				// an upgrade changes many files at once
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(appArmorIncludeDebounce, func() {
+					select {
+					case fire <- struct{}{}:
+					default:
+					}
+				})
+
+			case <-fire:
+				validate()
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				ae.Logger.Warnf("Failed to watch the AppArmor includes (%s)", err.Error())
+			}
+		}
+	}()
+
+	return nil
+}
+
+// stopWatchingAppArmorIncludes Function
+func (ae *AppArmorEnforcer) stopWatchingAppArmorIncludes() {
+	if ae.includeWatcher != nil {
+		_ = ae.includeWatcher.Close()
+		<-ae.includeWatcherDone
+
+		ae.includeWatcher = nil
+	}
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package enforcer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+func TestWatchAppArmorIncludes(t *testing.T) {
+	dir := t.TempDir()
+
+	prevProfileDir, prevLoadedProfiles, prevParser, prevDebounce := appArmorProfileDir, appArmorLoadedProfiles, runAppArmorParser, appArmorIncludeDebounce
+	defer func() {
+		appArmorProfileDir, appArmorLoadedProfiles, runAppArmorParser, appArmorIncludeDebounce = prevProfileDir, prevLoadedProfiles, prevParser, prevDebounce
+	}()
+
+	appArmorProfileDir = dir
+	appArmorLoadedProfiles = dir + "/loaded"
+	appArmorIncludeDebounce = 200 * time.Millisecond
+
+	// the includes of the OS
+	for _, include := range []string{"tunables/global", "abstractions/base", "abstractions/nameservice"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, include)), 0750); err != nil {
+			t.Fatalf("[FAIL] Failed to create the include directory (%s)", err.Error())
+		}
+		if err := os.WriteFile(filepath.Join(dir, include), []byte("# "+include+"\n"), 0600); err != nil {
+			t.Fatalf("[FAIL] Failed to create the include (%s)", err.Error())
+		}
+	}
+
+	// the parser fails on the missing includes
+	loaded := []string{}
+	runAppArmorParser = func(args ...string) error {
+		data, err := os.ReadFile(args[len(args)-1])
+		if err != nil {
+			return err
+		}
+		if missing := missingIncludes(string(data)); len(missing) > 0 {
+			return errors.New("could not open " + missing[0])
+		}
+		if args[0] == "-r" {
+			loaded = append(loaded, filepath.Base(args[len(args)-1]))
+		}
+		return nil
+	}
+
+	feeder.MsgLock = new(sync.RWMutex)
+	feeder.MsgStructs = make(map[string]feeder.MsgStruct)
+
+	// subscribe to the alerts
+	alerts := make(chan *pb.Alert, 4)
+	feeder.AlertLock = new(sync.RWMutex)
+	feeder.AlertStructs = map[string]feeder.AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+	defer func() { feeder.AlertStructs = map[string]feeder.AlertStruct{} }()
+
+	ae := newStateTestEnforcer("")
+	ae.Logger.Output = "none"
+	ae.Logger.SecurityPolicies = map[string]tp.MatchPolicies{}
+	ae.Logger.SecurityPoliciesLock = new(sync.RWMutex)
+	ae.Logger.SeverityRangesLock = new(sync.RWMutex)
+	ae.Logger.SinksLock = new(sync.RWMutex)
+
+	web := "## == Managed by KubeArmor == ##\n#include <tunables/global>\nprofile kubearmor-default-web {\n  #include <abstractions/base>\n  deny /bin/sh x,\n}\n"
+	dns := "## == Managed by KubeArmor == ##\n#include <tunables/global>\nprofile kubearmor-default-dns {\n  #include <abstractions/base>\n  #include <abstractions/nameservice>\n}\n"
+
+	for name, profile := range map[string]string{"kubearmor-default-web": web, "kubearmor-default-dns": dns} {
+		if err := os.WriteFile(getProfilePath(name), []byte(profile), 0600); err != nil {
+			t.Fatalf("[FAIL] Failed to write the profile (%s)", err.Error())
+		}
+		ae.AppArmorProfiles[name] = []string{name}
+	}
+
+	// validated by the watcher
+	validated := make(chan []string, 1)
+	if err := ae.WatchAppArmorIncludes(func() {
+		select {
+		case validated <- ae.ValidateAppArmorProfiles():
+		default:
+		}
+	}); err != nil {
+		t.Fatalf("[FAIL] Failed to watch the includes (%s)", err.Error())
+	}
+	defer ae.stopWatchingAppArmorIncludes()
+
+	// an upgrade removes two abstractions
+	for _, include := range []string{"abstractions/base", "abstractions/nameservice"} {
+		if err := os.Remove(filepath.Join(dir, include)); err != nil {
+			t.Fatalf("[FAIL] Failed to remove the include (%s)", err.Error())
+		}
+	}
+
+	var broken []string
+	select {
+	case broken = <-validated:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("[FAIL] The removal of the includes wasn't detected")
+	}
+
+	if strings.Join(broken, ",") != "kubearmor-default-dns,kubearmor-default-web" {
+		t.Errorf("[FAIL] Unexpected broken profiles (%v)", broken)
+	}
+
+	// no rules replace the nameservice abstraction
+	dnsAlert, webAlert := <-alerts, <-alerts
+	if dnsAlert.PolicyName != AppArmorIncludeBrokenPolicyName || dnsAlert.Result != "Degraded" || !strings.Contains(dnsAlert.Data, "include=abstractions/base,abstractions/nameservice") {
+		t.Errorf("[FAIL] Unexpected alert of the dns profile (%s, %s, %s)", dnsAlert.PolicyName, dnsAlert.Result, dnsAlert.Data)
+	}
+	if data, _ := os.ReadFile(getProfilePath("kubearmor-default-dns")); string(data) != dns {
+		t.Errorf("[FAIL] Expected the dns profile to be kept (%s)", string(data))
+	}
+
+	// the base abstraction is replaced by its rules
+	if webAlert.Result != "Passed" || !strings.Contains(webAlert.Message, "self-contained") || webAlert.Resource != getProfilePath("kubearmor-default-web") {
+		t.Errorf("[FAIL] Unexpected alert of the web profile (%s, %s)", webAlert.Result, webAlert.Message)
+	}
+
+	data, _ := os.ReadFile(getProfilePath("kubearmor-default-web"))
+	if strings.Contains(string(data), "#include <abstractions/base>") || !strings.Contains(string(data), "/etc/ld.so.cache mr,") ||
+		!strings.Contains(string(data), "#include <tunables/global>") || len(loaded) != 1 || loaded[0] != "kubearmor-default-web" {
+		t.Errorf("[FAIL] Expected the self-contained web profile to be loaded (%v, %s)", loaded, string(data))
+	}
+
+	// the next profiles are generated without the broken include
+	if profile, ok := ae.selfContainedProfile(web); !ok || strings.Contains(profile, "#include <abstractions/base>") {
+		t.Errorf("[FAIL] Expected a self-contained profile (%s)", profile)
+	}
+
+	t.Log("[PASS] Detected the profiles broken by the removed includes")
+}
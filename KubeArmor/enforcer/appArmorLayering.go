@@ -0,0 +1,444 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package enforcer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	sprig "github.com/Masterminds/sprig/v3"
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+)
+
+// =============================== //
+// == Layered AppArmor Profiles == //
+// =============================== //
+
+// appArmorBaseDir is the directory of the base layers, relative to the profile directory
+const appArmorBaseDir = "abstractions"
+
+// appArmorBasePrefix is the prefix of the file names of the base layers
+const appArmorBasePrefix = "kubearmor-base-"
+
+// minimum version of apparmor_parser supporting the include rule used by the layered profiles
+const minLayeredParserMajor, minLayeredParserMinor = 3, 0
+
+// regexes to get the version of apparmor_parser and the base layers included by a profile
+var (
+	parserVersionRegex = regexp.MustCompile(`version (\d+)\.(\d+)`)
+	baseIncludeRegex   = regexp.MustCompile(`include <` + appArmorBaseDir + `/(` + appArmorBasePrefix + `[0-9a-f]+)>`)
+)
+
+// BaseProfile Structure
+type BaseProfile struct {
+	Name  string
+	Image string
+
+	Rules
+
+	// child profiles including this base layer
+	Profiles map[string]struct{}
+
+	// time saved by this base layer per child profile, measured on its first child profile
+	Saving   time.Duration
+	Measured bool
+}
+
+// ProfileCompileStats Structure
+type ProfileCompileStats struct {
+	Layered      int
+	LayeredTime  time.Duration
+	LayeredBytes int
+
+	Monolithic      int
+	MonolithicTime  time.Duration
+	MonolithicBytes int
+
+	// time saved by the layered profiles, from the savings measured for their base layers
+	Saved time.Duration
+
+	// base layers reused from the cache or newly written
+	BaseHits   int
+	BaseMisses int
+}
+
+// parserSupportsLayering checks if apparmor_parser supports the include rule
+func parserSupportsLayering(version string) bool {
+	matches := parserVersionRegex.FindStringSubmatch(version)
+	if len(matches) != 3 {
+		return false
+	}
+
+	major, _ := strconv.Atoi(matches[1])
+	minor, _ := strconv.Atoi(matches[2])
+
+	if major != minLayeredParserMajor {
+		return major > minLayeredParserMajor
+	}
+
+	return minor >= minLayeredParserMinor
+}
+
+// EnableLayeredProfiles enables the base layers if apparmor_parser supports them
+func (ae *AppArmorEnforcer) EnableLayeredProfiles() {
+	version, err := kl.GetCommandOutputWithErr("apparmor_parser", []string{"--version"})
+	if err != nil || !parserSupportsLayering(version) {
+		ae.Logger.Warnf("apparmor_parser does not support layered profiles, falling back to monolithic profiles")
+		return
+	}
+
+	ae.LayeredProfiles = true
+
+	ae.Logger.Print("Enabled layered AppArmor profiles")
+}
+
+// rulesIntersection returns the rules shared by both rule sets
+func rulesIntersection(a, b Rules) Rules {
+	rules := Rules{}
+	rules.Init()
+
+	for path, rule := range a.FilePaths {
+		if val, ok := b.FilePaths[path]; ok && val == rule {
+			rules.FilePaths[path] = rule
+		}
+	}
+
+	for proto, rule := range a.NetworkRules {
+		if val, ok := b.NetworkRules[proto]; ok && val == rule {
+			rules.NetworkRules[proto] = rule
+		}
+	}
+
+	for cap, rule := range a.CapabilitiesRules {
+		if val, ok := b.CapabilitiesRules[cap]; ok && val == rule {
+			rules.CapabilitiesRules[cap] = rule
+		}
+	}
+
+	return rules
+}
+
+// copyLayerRules copies the rules which can be moved into a base layer
+func copyLayerRules(src Rules) Rules {
+	rules := Rules{}
+	rules.Init()
+
+	for path, rule := range src.FilePaths {
+		rules.FilePaths[path] = rule
+	}
+	for proto, rule := range src.NetworkRules {
+		rules.NetworkRules[proto] = rule
+	}
+	for cap, rule := range src.CapabilitiesRules {
+		rules.CapabilitiesRules[cap] = rule
+	}
+
+	return rules
+}
+
+// getBaseProfileName returns the name of a base layer keyed by the image and the hash of the rules
+func getBaseProfileName(image string, rules Rules) string {
+	entries := []string{}
+
+	for path, rule := range rules.FilePaths {
+		entries = append(entries, fmt.Sprintf("file:%s:%+v", path, rule))
+	}
+	for proto, rule := range rules.NetworkRules {
+		entries = append(entries, fmt.Sprintf("network:%s:%+v", proto, rule))
+	}
+	for cap, rule := range rules.CapabilitiesRules {
+		entries = append(entries, fmt.Sprintf("capability:%s:%+v", cap, rule))
+	}
+
+	sort.Strings(entries)
+
+	sum := sha256.Sum256([]byte(image + "\n" + strings.Join(entries, "\n")))
+
+	return appArmorBasePrefix + hex.EncodeToString(sum[:])[:16]
+}
+
+// getBaseProfilePath Function
+func getBaseProfilePath(name string) string {
+	return getProfilePath(appArmorBaseDir + "/" + name)
+}
+
+// writeBaseProfile renders a base layer into its file
+func writeBaseProfile(base *BaseProfile) error {
+	allFuncs := sprig.GenericFuncMap()
+	delete(allFuncs, "env")
+	delete(allFuncs, "expandenv")
+
+	t, err := template.New("apparmor").Funcs(allFuncs).Parse(BaseTemplate)
+	if err != nil {
+		return err
+	}
+
+	if t, err = t.New("base-layer").Parse(BaseLayerTemplate); err != nil {
+		return err
+	}
+
+	var np bytes.Buffer
+	if err := t.ExecuteTemplate(&np, "base-layer", base); err != nil {
+		return err
+	}
+
+	if err := checkProfileOwnership(appArmorBaseDir + "/" + base.Name); err != nil {
+		return err
+	}
+
+	return os.WriteFile(getBaseProfilePath(base.Name), np.Bytes(), 0600)
+}
+
+// releaseBaseProfile detaches a profile from its base layer and removes the layer once unused
+func (ae *AppArmorEnforcer) releaseBaseProfile(appArmorProfile string) {
+	name, ok := ae.ProfileBases[appArmorProfile]
+	if !ok {
+		return
+	}
+	delete(ae.ProfileBases, appArmorProfile)
+
+	base, ok := ae.BaseProfiles[name]
+	if !ok {
+		return
+	}
+
+	delete(base.Profiles, appArmorProfile)
+
+	if len(base.Profiles) == 0 {
+		delete(ae.BaseProfiles, name)
+
+		if err := os.Remove(getBaseProfilePath(name)); err != nil && !os.IsNotExist(err) {
+			ae.Logger.Warnf("Unable to remove the AppArmor base layer (%s, %s)", name, err.Error())
+		}
+	}
+}
+
+// ApplyBaseLayer moves the rules shared with the other profiles of the same image (digest) into a base layer,
+// and leaves only the profile-specific rules in the given profile
+func (ae *AppArmorEnforcer) ApplyBaseLayer(appArmorProfile, image string, profile *Profile) error {
+	ae.BaseProfilesLock.Lock()
+	defer ae.BaseProfilesLock.Unlock()
+
+	// invalidate the previous layer if the image of the profile has changed
+	if prevImage, ok := ae.ProfileImages[appArmorProfile]; ok && prevImage != image {
+		delete(ae.ImageRules[prevImage], appArmorProfile)
+		if len(ae.ImageRules[prevImage]) == 0 {
+			delete(ae.ImageRules, prevImage)
+		}
+	}
+	ae.ProfileImages[appArmorProfile] = image
+
+	if _, ok := ae.ImageRules[image]; !ok {
+		ae.ImageRules[image] = map[string]Rules{}
+	}
+
+	rules := copyLayerRules(profile.Rules)
+	ae.ImageRules[image][appArmorProfile] = rules
+
+	// the base layer holds the rules common to all the profiles of the image
+	baseRules := rules
+	for name, siblingRules := range ae.ImageRules[image] {
+		if name != appArmorProfile {
+			baseRules = rulesIntersection(baseRules, siblingRules)
+		}
+	}
+
+	name := getBaseProfileName(image, baseRules)
+
+	if prev, ok := ae.ProfileBases[appArmorProfile]; !ok || prev != name {
+		if _, ok := ae.BaseProfiles[name]; ok {
+			ae.CompileStats.BaseHits++
+		} else {
+			base := &BaseProfile{Name: name, Image: image, Rules: baseRules, Profiles: map[string]struct{}{}}
+			if err := writeBaseProfile(base); err != nil {
+				return err
+			}
+			ae.BaseProfiles[name] = base
+			ae.CompileStats.BaseMisses++
+		}
+
+		// a previous layer stays valid for the profiles still including it
+		ae.releaseBaseProfile(appArmorProfile)
+
+		ae.BaseProfiles[name].Profiles[appArmorProfile] = struct{}{}
+		ae.ProfileBases[appArmorProfile] = name
+	} else {
+		ae.CompileStats.BaseHits++
+	}
+
+	// keep the profile-specific rules only
+	for path := range baseRules.FilePaths {
+		delete(profile.FilePaths, path)
+	}
+	for proto := range baseRules.NetworkRules {
+		delete(profile.NetworkRules, proto)
+	}
+	for cap := range baseRules.CapabilitiesRules {
+		delete(profile.CapabilitiesRules, cap)
+	}
+
+	profile.Includes = []string{name}
+
+	return nil
+}
+
+// RemoveBaseLayer Function
+func (ae *AppArmorEnforcer) RemoveBaseLayer(appArmorProfile string) {
+	ae.BaseProfilesLock.Lock()
+	defer ae.BaseProfilesLock.Unlock()
+
+	if image, ok := ae.ProfileImages[appArmorProfile]; ok {
+		delete(ae.ImageRules[image], appArmorProfile)
+		if len(ae.ImageRules[image]) == 0 {
+			delete(ae.ImageRules, image)
+		}
+		delete(ae.ProfileImages, appArmorProfile)
+	}
+
+	ae.releaseBaseProfile(appArmorProfile)
+}
+
+// RemoveStaleBaseLayers removes the base layers not included by any profile in the profile directory
+func (ae *AppArmorEnforcer) RemoveStaleBaseLayers() {
+	layers, err := os.ReadDir(getProfilePath(appArmorBaseDir))
+	if err != nil {
+		return
+	}
+
+	profiles, err := os.ReadDir(appArmorProfileDir)
+	if err != nil {
+		return
+	}
+
+	included := map[string]struct{}{}
+	for _, profile := range profiles {
+		if !profile.Type().IsRegular() {
+			continue
+		}
+
+		data, err := os.ReadFile(getProfilePath(profile.Name()))
+		if err != nil || !isOwnedProfile(string(data)) {
+			continue
+		}
+
+		for _, match := range baseIncludeRegex.FindAllStringSubmatch(string(data), -1) {
+			included[match[1]] = struct{}{}
+		}
+	}
+
+	for _, layer := range layers {
+		if !strings.HasPrefix(layer.Name(), appArmorBasePrefix) {
+			continue
+		}
+
+		if _, ok := included[layer.Name()]; ok {
+			continue
+		}
+
+		// the layers of the other managers are left as they are
+		if data, err := os.ReadFile(getBaseProfilePath(layer.Name())); err != nil || !isOwnedProfile(string(data)) {
+			continue
+		}
+
+		if err := os.Remove(getBaseProfilePath(layer.Name())); err != nil {
+			ae.Logger.Warnf("Unable to remove the AppArmor base layer (%s, %s)", layer.Name(), err.Error())
+			continue
+		}
+
+		ae.Logger.Printf("Removed an inactive AppArmor base layer (%s)", layer.Name())
+	}
+}
+
+// timeAppArmorParser measures the time apparmor_parser takes to compile a profile, without loading it into the
+// kernel or reading the cache
+func timeAppArmorParser(profile string) (time.Duration, error) {
+	file, err := createTempFile("apparmor-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(profile); err != nil {
+		_ = file.Close()
+		return 0, err
+	}
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	err = runAppArmorParser("-Q", "-T", file.Name())
+
+	return time.Since(start), err
+}
+
+// measureBaseSaving compiles the monolithic and the layered profile of the first child profile of a base layer,
+// and keeps the difference of their compile times as the time saved by the layer for each of its child profiles
+func (ae *AppArmorEnforcer) measureBaseSaving(appArmorProfile, monolithicProfile, layeredProfile string) {
+	ae.BaseProfilesLock.Lock()
+	base, ok := ae.BaseProfiles[ae.ProfileBases[appArmorProfile]]
+	if !ok || base.Measured {
+		ae.BaseProfilesLock.Unlock()
+		return
+	}
+	ae.BaseProfilesLock.Unlock()
+
+	monolithicTime, err := timeAppArmorParser(monolithicProfile)
+	if err != nil {
+		ae.Logger.Debugf("Unable to measure the compile time of the monolithic AppArmor profile (%s, %s)", appArmorProfile, err.Error())
+		return
+	}
+
+	layeredTime, err := timeAppArmorParser(layeredProfile)
+	if err != nil {
+		ae.Logger.Debugf("Unable to measure the compile time of the layered AppArmor profile (%s, %s)", appArmorProfile, err.Error())
+		return
+	}
+
+	ae.BaseProfilesLock.Lock()
+	defer ae.BaseProfilesLock.Unlock()
+
+	base.Saving = monolithicTime - layeredTime
+	base.Measured = true
+
+	ae.Logger.Debugf("Measured the time saved by the AppArmor base layer (%s, monolithic %s, layered %s)", base.Name, monolithicTime, layeredTime)
+}
+
+// recordCompileTime keeps track of the time spent on compiling profiles and of their size
+func (ae *AppArmorEnforcer) recordCompileTime(appArmorProfile string, layered bool, size int, elapsed time.Duration) {
+	ae.BaseProfilesLock.Lock()
+	defer ae.BaseProfilesLock.Unlock()
+
+	if layered {
+		ae.CompileStats.Layered++
+		ae.CompileStats.LayeredTime += elapsed
+		ae.CompileStats.LayeredBytes += size
+		if base, ok := ae.BaseProfiles[ae.ProfileBases[appArmorProfile]]; ok {
+			ae.CompileStats.Saved += base.Saving
+		}
+	} else {
+		ae.CompileStats.Monolithic++
+		ae.CompileStats.MonolithicTime += elapsed
+		ae.CompileStats.MonolithicBytes += size
+	}
+
+	ae.Logger.Debugf("Compiled the AppArmor profile (%s, layered=%t, %d bytes, %s)", appArmorProfile, layered, size, elapsed)
+}
+
+// GetCompileStats Function
+func (ae *AppArmorEnforcer) GetCompileStats() ProfileCompileStats {
+	ae.BaseProfilesLock.Lock()
+	defer ae.BaseProfilesLock.Unlock()
+
+	return ae.CompileStats
+}
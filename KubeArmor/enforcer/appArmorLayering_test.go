@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package enforcer
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+
+	sprig "github.com/Masterminds/sprig/v3"
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	"github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+)
+
+func TestLayeredAppArmorProfile(t *testing.T) {
+	// parser versions
+	for version, expected := range map[string]bool{
+		"AppArmor parser version 2.13.3": false,
+		"AppArmor parser version 3.0.4":  true,
+		"AppArmor parser version 4.0.1":  true,
+		"unknown":                        false,
+	} {
+		if parserSupportsLayering(version) != expected {
+			t.Errorf("[FAIL] Unexpected layering support for %q", version)
+		}
+	}
+
+	// rules shared by two profiles of the same image
+	a := Rules{}
+	a.Init()
+	a.FilePaths["/etc/passwd"] = RuleConfig{ReadOnly: true, Deny: true}
+	a.FilePaths["/tmp/"] = RuleConfig{Dir: true, Allow: true}
+	a.NetworkRules["raw"] = RuleConfig{Deny: true}
+
+	b := copyLayerRules(a)
+	b.FilePaths["/tmp/"] = RuleConfig{Dir: true, Recursive: true, Allow: true}
+	b.CapabilitiesRules["net_raw"] = RuleConfig{Deny: true}
+
+	base := rulesIntersection(a, b)
+	if len(base.FilePaths) != 1 || len(base.NetworkRules) != 1 || len(base.CapabilitiesRules) != 0 {
+		t.Fatalf("[FAIL] Unexpected base rules (%+v)", base)
+	}
+
+	// base layers are keyed by the image and the rules
+	name := getBaseProfileName("nginx@sha256:1234", base)
+	if !strings.HasPrefix(name, appArmorBasePrefix) {
+		t.Errorf("[FAIL] Unexpected base layer name (%s)", name)
+	}
+	if name != getBaseProfileName("nginx@sha256:1234", rulesIntersection(b, a)) {
+		t.Errorf("[FAIL] Expected the same base layer for the same rules")
+	}
+	if name == getBaseProfileName("nginx@sha256:5678", base) {
+		t.Errorf("[FAIL] Expected a new base layer for a new image")
+	}
+	if name == getBaseProfileName("nginx@sha256:1234", a) {
+		t.Errorf("[FAIL] Expected a new base layer for new rules")
+	}
+
+	// child profiles include the base layer
+	profile := Profile{Name: "kubearmor-default-nginx"}
+	profile.Init()
+	profile.Includes = []string{name}
+
+	allFuncs := sprig.GenericFuncMap()
+	tmpl, err := template.New("apparmor").Funcs(allFuncs).Parse(BaseTemplate)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to parse the template (%s)", err.Error())
+	}
+
+	var np bytes.Buffer
+	if err := tmpl.Execute(&np, profile); err != nil {
+		t.Fatalf("[FAIL] Failed to generate a profile (%s)", err.Error())
+	}
+	if !strings.Contains(np.String(), "include <abstractions/"+name+">") {
+		t.Errorf("[FAIL] Expected the profile to include the base layer")
+	}
+
+	// monolithic profiles include nothing
+	profile.Includes = nil
+	np.Reset()
+	if err := tmpl.Execute(&np, profile); err != nil {
+		t.Fatalf("[FAIL] Failed to generate a profile (%s)", err.Error())
+	}
+	if baseIncludeRegex.MatchString(np.String()) {
+		t.Errorf("[FAIL] Expected a monolithic profile")
+	}
+
+	t.Log("[PASS] Generated layered AppArmor profiles")
+}
+
+func TestImageDigestLayers(t *testing.T) {
+	const digest = "sha256:0d17b565c37bcbd895e9d92315a05c1c3c9a29f762b011a10c54a66cd53c9b31"
+
+	prevProfileDir := appArmorProfileDir
+	defer func() { appArmorProfileDir = prevProfileDir }()
+
+	appArmorProfileDir = t.TempDir()
+	if err := os.MkdirAll(getProfilePath(appArmorBaseDir), 0750); err != nil {
+		t.Fatalf("[FAIL] Failed to create the directory of the base layers (%s)", err.Error())
+	}
+
+	ae := newStateTestEnforcer(t.TempDir() + "/state.json")
+
+	// the images of the endpoints, from the pod status and the image resolved by the runtime (ImageRef)
+	digests := map[string]string{
+		"kubearmor-default-web-nginx": kl.GetImageDigest(kl.GetContainerImage("nginx:1.25", "docker.io/library/nginx@"+digest)),
+		"kubearmor-staging-api-nginx": kl.GetImageDigest(kl.GetContainerImage("docker.io/library/nginx:stable", "docker.io/library/nginx@"+digest)),
+		"kubearmor-default-web-redis": kl.GetImageDigest(kl.GetContainerImage("redis:7", "")),
+	}
+
+	// the layers are keyed by the digest only, never by the (mutable) tag
+	if digests["kubearmor-default-web-nginx"] != digest || digests["kubearmor-staging-api-nginx"] != digest || digests["kubearmor-default-web-redis"] != "" {
+		t.Fatalf("[FAIL] Unexpected image digests of the endpoints (%v)", digests)
+	}
+
+	for _, name := range []string{"kubearmor-default-web-nginx", "kubearmor-staging-api-nginx"} {
+		profile := Profile{Name: name}
+		profile.Init()
+		profile.FilePaths["/etc/passwd"] = RuleConfig{ReadOnly: true, Deny: true}
+
+		if err := ae.ApplyBaseLayer(name, digests[name], &profile); err != nil {
+			t.Fatalf("[FAIL] Failed to apply the base layer (%s)", err.Error())
+		}
+	}
+
+	// the endpoints of the same digest with different tags share a base layer
+	if len(ae.BaseProfiles) != 1 || ae.ProfileBases["kubearmor-default-web-nginx"] != ae.ProfileBases["kubearmor-staging-api-nginx"] {
+		t.Errorf("[FAIL] Expected one base layer for the image digest (%v)", ae.ProfileBases)
+	}
+
+	t.Log("[PASS] Keyed the base layers by the image digests of the endpoints")
+}
+
+func TestBaseLayerSaving(t *testing.T) {
+	prevProfileDir, prevParser, prevTempDir := appArmorProfileDir, runAppArmorParser, cfg.TempDir
+	defer func() {
+		appArmorProfileDir, runAppArmorParser, cfg.TempDir = prevProfileDir, prevParser, prevTempDir
+	}()
+
+	appArmorProfileDir = t.TempDir()
+	cfg.TempDir = t.TempDir()
+
+	// the parser takes longer on the monolithic profiles
+	invocations := 0
+	runAppArmorParser = func(args ...string) error {
+		invocations++
+
+		if args[0] != "-Q" {
+			return nil
+		}
+
+		data, err := os.ReadFile(args[len(args)-1])
+		if err != nil {
+			return err
+		}
+
+		if baseIncludeRegex.Match(data) {
+			time.Sleep(10 * time.Millisecond)
+		} else {
+			time.Sleep(60 * time.Millisecond)
+		}
+		return nil
+	}
+
+	feeder.MsgLock = new(sync.RWMutex)
+	feeder.MsgStructs = make(map[string]feeder.MsgStruct)
+
+	ae := newStateTestEnforcer(t.TempDir() + "/state.json")
+
+	name := getBaseProfileName("nginx@sha256:1234", Rules{})
+	ae.BaseProfiles[name] = &BaseProfile{Name: name, Profiles: map[string]struct{}{}}
+	ae.ProfileBases["kubearmor-default-web-nginx"] = name
+	ae.ProfileBases["kubearmor-staging-api-nginx"] = name
+
+	layered := "profile kubearmor-default-web-nginx {\n  include <" + appArmorBaseDir + "/" + name + ">\n}\n"
+	monolithic := "profile kubearmor-default-web-nginx {\n  deny /etc/passwd rwklx,\n}\n"
+
+	// measured on the first child profile only
+	ae.measureBaseSaving("kubearmor-default-web-nginx", monolithic, layered)
+	ae.measureBaseSaving("kubearmor-staging-api-nginx", monolithic, layered)
+
+	base := ae.BaseProfiles[name]
+	if !base.Measured || base.Saving < 40*time.Millisecond || invocations != 2 {
+		t.Fatalf("[FAIL] Unexpected measurement of the base layer (saving=%s, invocations=%d)", base.Saving, invocations)
+	}
+
+	// the time saved is counted for each child profile compiled
+	ae.recordCompileTime("kubearmor-default-web-nginx", true, len(layered), 10*time.Millisecond)
+	ae.recordCompileTime("kubearmor-staging-api-nginx", true, len(layered), 10*time.Millisecond)
+	ae.recordCompileTime("kubearmor-default-web-redis", false, len(monolithic), 60*time.Millisecond)
+
+	if stats := ae.GetCompileStats(); stats.Layered != 2 || stats.Monolithic != 1 || stats.Saved != 2*base.Saving {
+		t.Errorf("[FAIL] Unexpected compile stats (%+v)", stats)
+	}
+
+	t.Log("[PASS] Measured the time saved by the base layers")
+}
@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package enforcer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ================================ //
+// == AppArmor Profile Ownership == //
+// ================================ //
+
+// AppArmorOwnerID is the ownership UUID of KubeArmor, marked in the header of its AppArmor profiles
+const AppArmorOwnerID = "8d2c6f4e-1b7a-4f3e-9c5d-0a6e3b2f7c41"
+
+// appArmorOwnershipMarker is the header of the AppArmor profiles generated by KubeArmor
+const appArmorOwnershipMarker = "## == Managed by KubeArmor (owner: " + AppArmorOwnerID + ") == ##"
+
+// appArmorLegacyMarker is the header of the profiles generated before the ownership marker, still owned after an upgrade
+const appArmorLegacyMarker = "## == Managed by KubeArmor == ##"
+
+// errForeignProfile is returned for the profiles of the other managers, which KubeArmor never modifies or deletes
+var errForeignProfile = errors.New("not managed by KubeArmor")
+
+// isOwnedProfile checks if an AppArmor profile carries the ownership marker of KubeArmor
+func isOwnedProfile(profile string) bool {
+	for _, line := range strings.Split(profile, "\n") {
+		if line = strings.TrimSpace(line); line == appArmorOwnershipMarker || line == appArmorLegacyMarker {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProfileOwnership checks if a profile can be written by KubeArmor, i.e., it doesn't exist yet, it's empty, or it's owned
+func checkProfileOwnership(name string) error {
+	data, err := os.ReadFile(getProfilePath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 || isOwnedProfile(string(data)) {
+		return nil
+	}
+
+	return fmt.Errorf("the AppArmor profile %s is %w", name, errForeignProfile)
+}
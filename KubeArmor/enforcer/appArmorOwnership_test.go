@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package enforcer
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestIsOwnedProfile(t *testing.T) {
+	profiles := map[string]bool{
+		appArmorOwnershipMarker + "\nprofile kubearmor-default-web {\n}\n":         true,
+		"\n" + appArmorLegacyMarker + "\nprofile kubearmor-default-web {\n}\n":     true,
+		"# generated by another tool, not KubeArmor\nprofile foreign {\n}\n":       false,
+		"## == Managed by KubeArmor (owner: another-uuid) == ##\nprofile x {\n}\n": false,
+		"": false,
+	}
+
+	for profile, owned := range profiles {
+		if isOwnedProfile(profile) != owned {
+			t.Errorf("[FAIL] Expected the ownership of %q to be %v", profile, owned)
+		}
+	}
+
+	t.Log("[PASS] Recognized the profiles of KubeArmor by their marker")
+}
+
+func TestForeignAppArmorProfiles(t *testing.T) {
+	dir := t.TempDir()
+
+	prevProfileDir, prevLoadedProfiles, prevParser := appArmorProfileDir, appArmorLoadedProfiles, runAppArmorParser
+	defer func() {
+		appArmorProfileDir, appArmorLoadedProfiles, runAppArmorParser = prevProfileDir, prevLoadedProfiles, prevParser
+	}()
+
+	appArmorProfileDir = dir
+	appArmorLoadedProfiles = dir + "/loaded"
+
+	parsed := []string{}
+	runAppArmorParser = func(args ...string) error {
+		if path := args[len(args)-1]; strings.HasPrefix(path, dir+"/") {
+			parsed = append(parsed, strings.TrimPrefix(path, dir+"/"))
+		}
+		return nil
+	}
+
+	feeder.MsgLock = new(sync.RWMutex)
+	feeder.MsgStructs = make(map[string]feeder.MsgStruct)
+
+	// the profiles and the base layers of another manager, including one with the name of a KubeArmor profile
+	foreign := map[string]string{
+		"foreign-nginx":                          "# generated by another tool, not KubeArmor\nprofile foreign-nginx {\n}\n",
+		"kubearmor-default-web":                  "# generated by another tool\nprofile kubearmor-default-web {\n}\n",
+		"abstractions/kubearmor-base-0123456789": "# generated by another tool\n",
+		appArmorHostProfile:                      "# generated by another tool\nprofile kubearmor.host {\n}\n",
+	}
+
+	// the profiles of KubeArmor left by the previous instance
+	owned := map[string]string{
+		"kubearmor-default-old":                  appArmorOwnershipMarker + "\nprofile kubearmor-default-old {\n}\n",
+		"kubearmor-default-legacy":               appArmorLegacyMarker + "\nprofile kubearmor-default-legacy {\n}\n",
+		"abstractions/kubearmor-base-9876543210": appArmorOwnershipMarker + "\n",
+	}
+
+	if err := os.MkdirAll(dir+"/abstractions", 0750); err != nil {
+		t.Fatalf("[FAIL] Failed to create the profile directory (%s)", err.Error())
+	}
+	for _, profiles := range []map[string]string{foreign, owned} {
+		for name, profile := range profiles {
+			if err := os.WriteFile(getProfilePath(name), []byte(profile), 0600); err != nil {
+				t.Fatalf("[FAIL] Failed to write the profile %s (%s)", name, err.Error())
+			}
+		}
+	}
+
+	ae := newStateTestEnforcer(dir + "/state/apparmor.json")
+
+	// orphan cleanup
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to read the profile directory (%s)", err.Error())
+	}
+	ae.removeInactiveProfiles(files, []string{})
+	ae.RemoveStaleBaseLayers()
+
+	for name := range owned {
+		if _, err := os.Stat(getProfilePath(name)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("[FAIL] Expected the inactive profile %s to be removed (%v)", name, err)
+		}
+	}
+
+	// the profile of another manager with the name of a KubeArmor profile
+	if ae.RegisterAppArmorProfile("web", "kubearmor-default-web") {
+		t.Errorf("[FAIL] Expected the foreign profile not to be registered")
+	}
+
+	endPoint := tp.EndPoint{NamespaceName: "default", EndPointName: "web"}
+	if _, err := ae.UpdateAppArmorProfile(endPoint, "kubearmor-default-web", []tp.SecurityPolicy{}); !errors.Is(err, errForeignProfile) {
+		t.Errorf("[FAIL] Expected the foreign profile not to be updated (%v)", err)
+	}
+
+	if ae.UnregisterAppArmorProfile("", "kubearmor-default-web") {
+		t.Errorf("[FAIL] Expected the foreign profile not to be unregistered")
+	}
+
+	// the host profile of another manager
+	if err := ae.CreateAppArmorHostProfile(); !errors.Is(err, errForeignProfile) {
+		t.Errorf("[FAIL] Expected the foreign host profile not to be overwritten (%v)", err)
+	}
+	if ae.UnregisterAppArmorHostProfile() {
+		t.Errorf("[FAIL] Expected the foreign host profile not to be unregistered")
+	}
+
+	// none of the foreign profiles are modified, deleted, or loaded
+	for name, profile := range foreign {
+		if data, err := os.ReadFile(getProfilePath(name)); err != nil || string(data) != profile {
+			t.Errorf("[FAIL] Expected the foreign profile %s to be left as it is (%v)", name, err)
+		}
+	}
+
+	for _, name := range parsed {
+		if _, ok := foreign[name]; ok {
+			t.Errorf("[FAIL] Expected the foreign profile %s not to be parsed", name)
+		}
+	}
+
+	// the profiles of KubeArmor are still created next to them
+	if !ae.RegisterAppArmorProfile("api", "kubearmor-default-api") {
+		t.Fatalf("[FAIL] Failed to register the profile")
+	}
+	if _, err := ae.UpdateAppArmorProfile(endPoint, "kubearmor-default-api", []tp.SecurityPolicy{}); err != nil {
+		t.Errorf("[FAIL] Failed to update the profile (%s)", err.Error())
+	}
+	if data, err := os.ReadFile(getProfilePath("kubearmor-default-api")); err != nil || !strings.Contains(string(data), appArmorOwnershipMarker+"\n") {
+		t.Errorf("[FAIL] Expected the ownership marker in the profile (%v)", err)
+	}
+
+	t.Log("[PASS] Left the AppArmor profiles of the other managers alone")
+}
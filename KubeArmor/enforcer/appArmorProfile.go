@@ -7,12 +7,12 @@ import (
 	"bufio"
 	"bytes"
 	"os"
-	"path/filepath"
 	"strings"
 	"text/template"
 
 	sprig "github.com/Masterminds/sprig/v3"
 	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
 	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
 	"k8s.io/utils/strings/slices"
 )
@@ -292,6 +292,84 @@ func (ae *AppArmorEnforcer) SetCapabilitiesMatchCapabilities(cap tp.Capabilities
 	}
 }
 
+// ruleSection returns the header section of the profile with the rules of a kind, and whether it's under the default
+// posture of block
+func ruleSection(kind string, defaultPosture tp.DefaultPosture) (string, bool) {
+	switch kind {
+	case "networkProtocol":
+		return "network", defaultPosture.NetworkAction == "block"
+	case "capability":
+		return "capabilities", defaultPosture.CapabilitiesAction == "block"
+	}
+
+	// process and file rules
+	return "file", defaultPosture.FileAction == "block"
+}
+
+// SetEffectiveRule adds a rule of the effective policy to the profile (Throttle and Audit rules aren't enforced)
+func (ae *AppArmorEnforcer) SetEffectiveRule(rule tp.EffectiveRule, prof *Profile, defaultPosture tp.DefaultPosture) {
+	deny := rule.Action == "Block"
+	if !deny && rule.Action != "Allow" {
+		return
+	}
+
+	// the header of an allow-list is denied by default
+	_, block := ruleSection(rule.Kind, defaultPosture)
+	head := deny || !block
+
+	var fromSource []tp.MatchSourceType
+	if rule.Source != "" {
+		fromSource = []tp.MatchSourceType{{Path: rule.Source}}
+	}
+
+	switch rule.Kind {
+	case "processPath":
+		ae.SetProcessMatchPaths(tp.ProcessPathType{Path: rule.Entity, OwnerOnly: rule.OwnerOnly, FromSource: fromSource}, prof, deny, head)
+	case "processDirectory":
+		ae.SetProcessMatchDirectories(tp.ProcessDirectoryType{Directory: rule.Entity, Recursive: rule.Recursive, OwnerOnly: rule.OwnerOnly, FromSource: fromSource}, prof, deny, head)
+	case "processPattern":
+		ae.SetProcessMatchPatterns(tp.ProcessPatternType{Pattern: rule.Entity, OwnerOnly: rule.OwnerOnly}, prof, deny, head)
+	case "filePath":
+		ae.SetFileMatchPaths(tp.FilePathType{Path: rule.Entity, ReadOnly: rule.ReadOnly, OwnerOnly: rule.OwnerOnly, FromSource: fromSource}, prof, deny, head)
+	case "fileDirectory":
+		ae.SetFileMatchDirectories(tp.FileDirectoryType{Directory: rule.Entity, Recursive: rule.Recursive, ReadOnly: rule.ReadOnly, OwnerOnly: rule.OwnerOnly, FromSource: fromSource}, prof, deny, head)
+	case "filePattern":
+		ae.SetFileMatchPatterns(tp.FilePatternType{Pattern: rule.Entity, ReadOnly: rule.ReadOnly, OwnerOnly: rule.OwnerOnly}, prof, deny, head)
+	case "networkProtocol":
+		ae.SetNetworkMatchProtocols(tp.NetworkProtocolType{Protocol: rule.Entity, FromSource: fromSource}, prof, deny, head)
+	case "capability":
+		ae.SetCapabilitiesMatchCapabilities(tp.CapabilitiesCapabilityType{Capability: rule.Entity, FromSource: fromSource}, prof, deny, head)
+	}
+}
+
+// keepAllowList denies the section of an overridden Allow rule by default under the default posture of block, as the
+// rule would have
+func keepAllowList(rule tp.EffectiveRule, prof *Profile, defaultPosture tp.DefaultPosture) {
+	section, block := ruleSection(rule.Kind, defaultPosture)
+	if !block {
+		return
+	}
+
+	deny := func(header *ProfileHeader) {
+		switch section {
+		case "network":
+			header.Network = false
+		case "capabilities":
+			header.Capabilities = false
+		default:
+			header.File = false
+		}
+	}
+
+	deny(&prof.ProfileHeader)
+
+	// the subprofile of the source is created by the rule taking precedence
+	if val, ok := prof.FromSource[rule.Source]; ok && rule.Source != "" {
+		deny(&val.ProfileHeader)
+		prof.FromSource[rule.Source] = val
+	}
+}
+
 // == //
 
 // GenerateProfileBody Function
@@ -311,81 +389,21 @@ func (ae *AppArmorEnforcer) GenerateProfileBody(securityPolicies []tp.SecurityPo
 				profile.NativeRules = append(profile.NativeRules, line)
 			}
 		}
+	}
 
-		if len(secPolicy.Spec.Process.MatchPaths) > 0 {
-			for _, path := range secPolicy.Spec.Process.MatchPaths {
-				if path.Action == "Allow" {
-					ae.SetProcessMatchPaths(path, &profile, false, defaultPosture.FileAction != "block")
-				} else if path.Action == "Block" {
-					ae.SetProcessMatchPaths(path, &profile, true, true)
-				}
-			}
-		}
-		if len(secPolicy.Spec.Process.MatchDirectories) > 0 {
-			for _, dir := range secPolicy.Spec.Process.MatchDirectories {
-				if dir.Action == "Allow" {
-					ae.SetProcessMatchDirectories(dir, &profile, false, defaultPosture.FileAction != "block")
-				} else if dir.Action == "Block" {
-					ae.SetProcessMatchDirectories(dir, &profile, true, true)
-				}
-			}
-		}
-		if len(secPolicy.Spec.Process.MatchPatterns) > 0 {
-			for _, pat := range secPolicy.Spec.Process.MatchPatterns {
-				if pat.Action == "Allow" {
-					ae.SetProcessMatchPatterns(pat, &profile, false, defaultPosture.FileAction != "block")
-				} else if pat.Action == "Block" {
-					ae.SetProcessMatchPatterns(pat, &profile, true, true)
-				}
-			}
-		}
-
-		if len(secPolicy.Spec.File.MatchPaths) > 0 {
-			for _, path := range secPolicy.Spec.File.MatchPaths {
-				if path.Action == "Allow" {
-					ae.SetFileMatchPaths(path, &profile, false, defaultPosture.FileAction != "block")
-				} else if path.Action == "Block" {
-					ae.SetFileMatchPaths(path, &profile, true, true)
-				}
-			}
-		}
-		if len(secPolicy.Spec.File.MatchDirectories) > 0 {
-			for _, dir := range secPolicy.Spec.File.MatchDirectories {
-				if dir.Action == "Allow" {
-					ae.SetFileMatchDirectories(dir, &profile, false, defaultPosture.FileAction != "block")
-				} else if dir.Action == "Block" {
-					ae.SetFileMatchDirectories(dir, &profile, true, true)
-				}
-			}
-		}
-		if len(secPolicy.Spec.File.MatchPatterns) > 0 {
-			for _, pat := range secPolicy.Spec.File.MatchPatterns {
-				if pat.Action == "Allow" {
-					ae.SetFileMatchPatterns(pat, &profile, false, defaultPosture.FileAction != "block")
-				} else if pat.Action == "Block" {
-					ae.SetFileMatchPatterns(pat, &profile, true, true)
-				}
-			}
-		}
+	// the rules are programmed from the effective policy, so the profile is the same whatever the order of the policies is
+	effective := fd.MergeSecurityPolicies(securityPolicies)
 
-		if len(secPolicy.Spec.Network.MatchProtocols) > 0 {
-			for _, proto := range secPolicy.Spec.Network.MatchProtocols {
-				if proto.Action == "Allow" {
-					ae.SetNetworkMatchProtocols(proto, &profile, false, defaultPosture.NetworkAction != "block")
-				} else if proto.Action == "Block" {
-					ae.SetNetworkMatchProtocols(proto, &profile, true, true)
-				}
-			}
+	for _, rule := range effective.Rules {
+		if !rule.Overridden {
+			ae.SetEffectiveRule(rule, &profile, defaultPosture)
 		}
+	}
 
-		if len(secPolicy.Spec.Capabilities.MatchCapabilities) > 0 {
-			for _, cap := range secPolicy.Spec.Capabilities.MatchCapabilities {
-				if cap.Action == "Allow" {
-					ae.SetCapabilitiesMatchCapabilities(cap, &profile, false, defaultPosture.CapabilitiesAction != "block")
-				} else if cap.Action == "Block" {
-					ae.SetCapabilitiesMatchCapabilities(cap, &profile, true, true)
-				}
-			}
+	// the sections with Allow rules overridden for some entities are still allow-lists
+	for _, rule := range effective.Rules {
+		if rule.Overridden && rule.Action == "Allow" {
+			keepAllowList(rule, &profile, defaultPosture)
 		}
 	}
 
@@ -450,15 +468,21 @@ func (ae *AppArmorEnforcer) GenerateProfileBody(securityPolicies []tp.SecurityPo
 
 // GenerateAppArmorProfile Function
 func (ae *AppArmorEnforcer) GenerateAppArmorProfile(appArmorProfile string, securityPolicies []tp.SecurityPolicy, defaultPosture tp.DefaultPosture) (int, string, bool) {
+	return ae.GenerateLayeredAppArmorProfile(appArmorProfile, "", securityPolicies, defaultPosture)
+}
+
+// GenerateLayeredAppArmorProfile generates a profile including the base layer of the given image,
+// or a monolithic profile if no image is given
+func (ae *AppArmorEnforcer) GenerateLayeredAppArmorProfile(appArmorProfile, image string, securityPolicies []tp.SecurityPolicy, defaultPosture tp.DefaultPosture) (int, string, bool) {
 	// check apparmor profile
 
-	if _, err := os.Stat(filepath.Clean("/etc/apparmor.d/" + appArmorProfile)); os.IsNotExist(err) {
+	if _, err := os.Stat(getProfilePath(appArmorProfile)); os.IsNotExist(err) {
 		return 0, err.Error(), false
 	}
 
 	// get the old profile
 
-	profile, err := os.ReadFile(filepath.Clean("/etc/apparmor.d/" + appArmorProfile))
+	profile, err := os.ReadFile(getProfilePath(appArmorProfile))
 	if err != nil {
 		return 0, err.Error(), false
 	}
@@ -470,6 +494,15 @@ func (ae *AppArmorEnforcer) GenerateAppArmorProfile(appArmorProfile string, secu
 
 	newProfile.Name = appArmorProfile
 
+	if image != "" {
+		if err := ae.ApplyBaseLayer(appArmorProfile, image, &newProfile); err != nil {
+			ae.Logger.Warnf("Unable to apply the AppArmor base layer (%s, %s), falling back to a monolithic profile", appArmorProfile, err.Error())
+			ae.RemoveBaseLayer(appArmorProfile)
+			count, newProfile = ae.GenerateProfileBody(securityPolicies, defaultPosture)
+			newProfile.Name = appArmorProfile
+		}
+	}
+
 	// https://helm.sh/docs/howto/charts_tips_and_tricks/
 	// Extend go template with sprig functions
 
@@ -491,6 +524,17 @@ func (ae *AppArmorEnforcer) GenerateAppArmorProfile(appArmorProfile string, secu
 	// check the new profile with the old profile
 
 	if np.String() != oldProfile {
+		if len(newProfile.Includes) > 0 {
+			// the monolithic profile, to measure the time saved by the base layer
+			_, monolithicProfile := ae.GenerateProfileBody(securityPolicies, defaultPosture)
+			monolithicProfile.Name = appArmorProfile
+
+			var mp bytes.Buffer
+			if err := t.Execute(&mp, monolithicProfile); err == nil {
+				ae.measureBaseSaving(appArmorProfile, mp.String(), np.String())
+			}
+		}
+
 		// check if we need to off load profile
 		oldProfilesNames := ae.rgx.FindAllString(oldProfile, -1)
 		newProfilesNames := ae.rgx.FindAllString(np.String(), -1)
@@ -501,7 +545,7 @@ func (ae *AppArmorEnforcer) GenerateAppArmorProfile(appArmorProfile string, secu
 			}
 		}
 		if len(profileToDelete) != 0 {
-			file, err := os.CreateTemp("/tmp", "apparmor-")
+			file, err := createTempFile("apparmor-")
 			if err != nil {
 				ae.Logger.Warnf("Unable to create tmp file, err=%s", err.Error())
 			} else {
@@ -517,7 +561,7 @@ func (ae *AppArmorEnforcer) GenerateAppArmorProfile(appArmorProfile string, secu
 				if err != nil {
 					ae.Logger.Warnf("Cannot flush tmp file writer buffer, err=%s", err.Error())
 				}
-				if err := kl.RunCommandAndWaitWithErr("apparmor_parser", []string{"-R", file.Name()}); err != nil {
+				if err := runAppArmorParser("-R", file.Name()); err != nil {
 					ae.Logger.Warnf("Unable to unload %d unused apparmor profiles, err=%s", len(profileToDelete), err.Error())
 				}
 			}
@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package enforcer
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+)
+
+// ============================ //
+// == AppArmor Profile State == //
+// ============================ //
+
+// appArmorProfileDir is the directory of the AppArmor profiles (the apparmor.d of the host /etc)
+var appArmorProfileDir = "/etc/apparmor.d"
+
+// appArmorLoadedProfiles lists the profiles loaded in the kernel
+var appArmorLoadedProfiles = "/sys/kernel/security/apparmor/profiles"
+
+// runAppArmorParser Function
+var runAppArmorParser = func(args ...string) error {
+	return kl.RunCommandAndWaitWithErr("apparmor_parser", appArmorParserArgs(args...))
+}
+
+// appArmorParserArgs resolves the includes of the profiles (e.g., <tunables/global>) in the profile directory
+// when it is not /etc/apparmor.d
+func appArmorParserArgs(args ...string) []string {
+	if filepath.Clean(appArmorProfileDir) == "/etc/apparmor.d" {
+		return args
+	}
+
+	return append([]string{"--base", appArmorProfileDir}, args...)
+}
+
+// createTempFile creates a temp file in the temp directory of the daemon
+func createTempFile(pattern string) (*os.File, error) {
+	if err := os.MkdirAll(cfg.TempDir, 0750); err != nil {
+		return nil, err
+	}
+
+	return os.CreateTemp(cfg.TempDir, pattern)
+}
+
+// appArmorProfileState keeps the profiles loaded by KubeArmor for the next start
+type appArmorProfileState struct {
+	// profile names of the containers (pod/container)
+	Containers map[string]string `json:"containers"`
+
+	// hashes of the loaded profiles
+	Profiles map[string]string `json:"profiles"`
+}
+
+// getProfilePath Function
+func getProfilePath(name string) string {
+	return filepath.Clean(appArmorProfileDir + "/" + name)
+}
+
+// hashProfile Function
+func hashProfile(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadAppArmorProfileState Function
+func loadAppArmorProfileState(path string) appArmorProfileState {
+	state := appArmorProfileState{}
+
+	if data, err := os.ReadFile(filepath.Clean(path)); err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+
+	if state.Containers == nil {
+		state.Containers = map[string]string{}
+	}
+	if state.Profiles == nil {
+		state.Profiles = map[string]string{}
+	}
+
+	return state
+}
+
+// loadedAppArmorProfiles returns the names of the profiles loaded in the kernel
+func loadedAppArmorProfiles() map[string]struct{} {
+	loaded := map[string]struct{}{}
+
+	file, err := os.Open(filepath.Clean(appArmorLoadedProfiles))
+	if err != nil {
+		return loaded
+	}
+	defer func() { _ = file.Close() }()
+
+	// e.g., kubearmor-default-nginx (enforce)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if name, _, ok := strings.Cut(scanner.Text(), " ("); ok {
+			loaded[name] = struct{}{}
+		}
+	}
+
+	return loaded
+}
+
+// restoreProfileState loads the state of the previous instance, and forgets the profiles removed since
+func (ae *AppArmorEnforcer) restoreProfileState() {
+	ae.ProfileStateLock.Lock()
+	defer ae.ProfileStateLock.Unlock()
+
+	ae.ProfileState = loadAppArmorProfileState(ae.ProfileStatePath)
+
+	for name := range ae.ProfileState.Profiles {
+		if _, err := os.Stat(getProfilePath(name)); err != nil {
+			delete(ae.ProfileState.Profiles, name)
+		}
+	}
+
+	for container, name := range ae.ProfileState.Containers {
+		if _, ok := ae.ProfileState.Profiles[name]; !ok {
+			delete(ae.ProfileState.Containers, container)
+		}
+	}
+}
+
+// saveProfileState Function
+func (ae *AppArmorEnforcer) saveProfileState() {
+	if ae.ProfileStatePath == "" {
+		return
+	}
+
+	ae.ProfileStateLock.Lock()
+	defer ae.ProfileStateLock.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(ae.ProfileStatePath), 0750); err != nil {
+		ae.Logger.Warnf("Unable to create the directory of the AppArmor profile state (%s)", err.Error())
+		return
+	}
+
+	if err := kl.WriteToFile(ae.ProfileState, ae.ProfileStatePath); err != nil {
+		ae.Logger.Warnf("Unable to save the AppArmor profile state (%s)", err.Error())
+	}
+}
+
+// isProfileLoaded checks if the file of a profile is the one loaded by KubeArmor and still in the kernel
+func (ae *AppArmorEnforcer) isProfileLoaded(name string) bool {
+	ae.ProfileStateLock.Lock()
+	hash, ok := ae.ProfileState.Profiles[name]
+	ae.ProfileStateLock.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	// the file may be edited out of band
+	data, err := os.ReadFile(getProfilePath(name))
+	if err != nil || hashProfile(string(data)) != hash {
+		return false
+	}
+
+	_, loaded := loadedAppArmorProfiles()[name]
+	return loaded
+}
+
+// setProfileHash records the content of a loaded profile ("" to forget the profile)
+func (ae *AppArmorEnforcer) setProfileHash(name, content string) {
+	ae.ProfileStateLock.Lock()
+	if content == "" {
+		delete(ae.ProfileState.Profiles, name)
+	} else {
+		ae.ProfileState.Profiles[name] = hashProfile(content)
+	}
+	ae.ProfileStateLock.Unlock()
+
+	ae.saveProfileState()
+}
+
+// SetContainerProfile records the profile of a container ("" to forget the container)
+func (ae *AppArmorEnforcer) SetContainerProfile(podName, containerName, profileName string) {
+	// skip if AppArmorEnforcer is not active
+	if ae == nil {
+		return
+	}
+
+	key := podName + "/" + containerName
+
+	ae.ProfileStateLock.Lock()
+	if prev, ok := ae.ProfileState.Containers[key]; ok && prev == profileName {
+		ae.ProfileStateLock.Unlock()
+		return
+	}
+
+	if profileName == "" {
+		delete(ae.ProfileState.Containers, key)
+	} else {
+		ae.ProfileState.Containers[key] = profileName
+	}
+	ae.ProfileStateLock.Unlock()
+
+	ae.saveProfileState()
+}
+
+// GetContainerProfile returns the recorded profile of a container
+func (ae *AppArmorEnforcer) GetContainerProfile(podName, containerName string) (string, bool) {
+	// skip if AppArmorEnforcer is not active
+	if ae == nil {
+		return "", false
+	}
+
+	ae.ProfileStateLock.Lock()
+	defer ae.ProfileStateLock.Unlock()
+
+	name, ok := ae.ProfileState.Containers[podName+"/"+containerName]
+	return name, ok
+}
@@ -0,0 +1,246 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package enforcer
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	"github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// newStateTestEnforcer creates an AppArmor enforcer without touching the profiles of the host
+func newStateTestEnforcer(statePath string) *AppArmorEnforcer {
+	ae := &AppArmorEnforcer{Logger: &feeder.Feeder{Node: &tp.Node{}}}
+
+	ae.ApparmorDefault = appArmorOwnershipMarker + "\nprofile apparmor-default flags=(attach_disconnected,mediate_deleted) {\n}\n"
+	ae.rgx = regexp.MustCompile("profile kubearmor-.* {")
+
+	ae.AppArmorProfiles = map[string][]string{}
+	ae.AppArmorProfilesLock = new(sync.RWMutex)
+
+	ae.BaseProfiles = map[string]*BaseProfile{}
+	ae.ImageRules = map[string]map[string]Rules{}
+	ae.ProfileImages = map[string]string{}
+	ae.ProfileBases = map[string]string{}
+	ae.BaseProfilesLock = new(sync.Mutex)
+
+	ae.ProfileStatePath = statePath
+	ae.ProfileStateLock = new(sync.Mutex)
+	ae.restoreProfileState()
+
+	ae.BrokenIncludes = map[string]struct{}{}
+	ae.BrokenIncludesLock = new(sync.RWMutex)
+
+	return ae
+}
+
+func TestAppArmorProfileState(t *testing.T) {
+	dir := t.TempDir()
+
+	prevProfileDir, prevLoadedProfiles, prevParser := appArmorProfileDir, appArmorLoadedProfiles, runAppArmorParser
+	defer func() {
+		appArmorProfileDir, appArmorLoadedProfiles, runAppArmorParser = prevProfileDir, prevLoadedProfiles, prevParser
+	}()
+
+	appArmorProfileDir = dir
+	appArmorLoadedProfiles = dir + "/loaded"
+
+	// the parser loads the profiles into the fake kernel
+	invocations := 0
+	runAppArmorParser = func(args ...string) error {
+		invocations++
+
+		if args[0] != "-r" {
+			return nil
+		}
+
+		name := args[len(args)-1][len(dir)+1:]
+
+		loaded, _ := os.ReadFile(appArmorLoadedProfiles)
+		if !strings.Contains(string(loaded), name+" (enforce)") {
+			return os.WriteFile(appArmorLoadedProfiles, append(loaded, []byte(name+" (enforce)\n")...), 0600)
+		}
+		return nil
+	}
+
+	feeder.MsgLock = new(sync.RWMutex)
+	feeder.MsgStructs = make(map[string]feeder.MsgStruct)
+
+	statePath := dir + "/state/apparmor.json"
+	profileName := "kubearmor-default-nginx"
+	endPoint := tp.EndPoint{NamespaceName: "default", EndPointName: "nginx"}
+
+	// the first instance loads the profile
+	first := newStateTestEnforcer(statePath)
+
+	if !first.RegisterAppArmorProfile("nginx", profileName) {
+		t.Fatalf("[FAIL] Failed to register the profile")
+	}
+	first.SetContainerProfile("nginx", "nginx", profileName)
+	first.UpdateAppArmorProfile(endPoint, profileName, []tp.SecurityPolicy{})
+
+	if invocations != 2 {
+		t.Fatalf("[FAIL] Expected the profile to be loaded twice (%d)", invocations)
+	}
+
+	// the restarted instance adopts the loaded profile
+	invocations = 0
+
+	restarted := newStateTestEnforcer(statePath)
+
+	if name, ok := restarted.GetContainerProfile("nginx", "nginx"); !ok || name != profileName {
+		t.Errorf("[FAIL] Expected the profile of the container to be kept (%s)", name)
+	}
+
+	if !restarted.RegisterAppArmorProfile("nginx", profileName) {
+		t.Fatalf("[FAIL] Failed to register the profile after the restart")
+	}
+	restarted.UpdateAppArmorProfile(endPoint, profileName, []tp.SecurityPolicy{})
+
+	if invocations != 0 {
+		t.Errorf("[FAIL] Expected no parser invocations after the restart (%d)", invocations)
+	}
+
+	if pods := restarted.AppArmorProfiles[profileName]; len(pods) != 1 || pods[0] != "nginx" {
+		t.Errorf("[FAIL] Expected the profile to be registered (%v)", pods)
+	}
+
+	// profiles edited out of band are reloaded
+	if err := os.WriteFile(dir+"/"+profileName, []byte("## == Managed by KubeArmor == ##\n"), 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to edit the profile (%s)", err.Error())
+	}
+
+	edited := newStateTestEnforcer(statePath)
+	if !edited.RegisterAppArmorProfile("nginx", profileName) || invocations != 1 {
+		t.Errorf("[FAIL] Expected the edited profile to be reloaded (%d)", invocations)
+	}
+
+	// profiles missing in the kernel, e.g., after a reboot, are reloaded
+	invocations = 0
+
+	if err := os.Remove(appArmorLoadedProfiles); err != nil {
+		t.Fatalf("[FAIL] Failed to unload the profiles (%s)", err.Error())
+	}
+
+	rebooted := newStateTestEnforcer(statePath)
+	if !rebooted.RegisterAppArmorProfile("nginx", profileName) || invocations != 1 {
+		t.Errorf("[FAIL] Expected the profile to be reloaded after a reboot (%d)", invocations)
+	}
+
+	t.Log("[PASS] Reused the AppArmor profiles across restarts")
+}
+
+func TestAppArmorProfilePaths(t *testing.T) {
+	dir := t.TempDir()
+
+	prevProfileDir, prevHostEtcDir, prevTempDir := appArmorProfileDir, cfg.GlobalCfg.HostEtcDir, cfg.TempDir
+	defer func() {
+		appArmorProfileDir, cfg.GlobalCfg.HostEtcDir, cfg.TempDir = prevProfileDir, prevHostEtcDir, prevTempDir
+	}()
+
+	// the defaults
+	appArmorProfileDir = "/etc/apparmor.d"
+	if args := appArmorParserArgs("-r", "-W", getProfilePath(appArmorHostProfile)); strings.Join(args, " ") != "-r -W /etc/apparmor.d/kubearmor.host" {
+		t.Errorf("[FAIL] Unexpected arguments of apparmor_parser (%v)", args)
+	}
+
+	// the host /etc mounted elsewhere, with the state in a writable directory (readOnlyRootFilesystem)
+	cfg.GlobalCfg.HostEtcDir = dir + "/host/etc"
+	cfg.TempDir = dir + "/state/tmp"
+
+	appArmorProfileDir = cfg.AppArmorProfileDir()
+	profileDir := dir + "/host/etc/apparmor.d"
+
+	if path := getProfilePath(appArmorHostProfile); path != profileDir+"/kubearmor.host" {
+		t.Errorf("[FAIL] Unexpected path of the host profile (%s)", path)
+	}
+
+	if path := getBaseProfilePath("kubearmor-base-0123"); path != profileDir+"/abstractions/kubearmor-base-0123" {
+		t.Errorf("[FAIL] Unexpected path of the base layer (%s)", path)
+	}
+
+	// the includes are resolved in the mounted apparmor.d
+	if args := appArmorParserArgs("-R", getProfilePath("kubearmor-default")); strings.Join(args, " ") != "--base "+profileDir+" -R "+profileDir+"/kubearmor-default" {
+		t.Errorf("[FAIL] Unexpected arguments of apparmor_parser (%v)", args)
+	}
+
+	file, err := createTempFile("apparmor-")
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create a temp file (%s)", err.Error())
+	}
+	_ = file.Close()
+
+	if filepath.Dir(file.Name()) != cfg.TempDir {
+		t.Errorf("[FAIL] Expected the temp file in the state directory (%s)", file.Name())
+	}
+
+	t.Log("[PASS] Used the configured AppArmor paths")
+}
+
+func TestPrepareAppArmorProfile(t *testing.T) {
+	dir := t.TempDir()
+
+	prevProfileDir, prevLoadedProfiles, prevParser := appArmorProfileDir, appArmorLoadedProfiles, runAppArmorParser
+	defer func() {
+		appArmorProfileDir, appArmorLoadedProfiles, runAppArmorParser = prevProfileDir, prevLoadedProfiles, prevParser
+	}()
+
+	appArmorProfileDir = dir
+	appArmorLoadedProfiles = dir + "/loaded"
+
+	loads := 0
+	runAppArmorParser = func(args ...string) error {
+		loads++
+		return nil
+	}
+
+	feeder.MsgLock = new(sync.RWMutex)
+	feeder.MsgStructs = make(map[string]feeder.MsgStruct)
+
+	ae := newStateTestEnforcer(dir + "/apparmor.json")
+	re := &RuntimeEnforcer{Logger: ae.Logger, EnforcerType: "AppArmor", appArmorEnforcer: ae, enforceLock: new(sync.Mutex)}
+
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "block-sh"}}
+	policy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Block"}}
+
+	endPoint := tp.EndPoint{NamespaceName: "web", EndPointName: "frontend-7d4b9", ContainerName: "nginx", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{policy}
+
+	profileName := "kubearmor-web-frontend-nginx"
+
+	// the profile doesn't exist yet
+	if err := re.PrepareAppArmorProfile(endPoint, "frontend-7d4b9", "nginx", profileName); err != nil {
+		t.Fatalf("[FAIL] Failed to prepare the profile (%s)", err.Error())
+	}
+
+	profile, err := os.ReadFile(filepath.Join(dir, profileName))
+	if err != nil || !strings.Contains(string(profile), "deny /bin/sh") {
+		t.Errorf("[FAIL] Expected the profile to block /bin/sh\n%s", string(profile))
+	}
+
+	if name, ok := ae.GetContainerProfile("frontend-7d4b9", "nginx"); !ok || name != profileName {
+		t.Errorf("[FAIL] Expected the profile of the container to be recorded (%s)", name)
+	}
+
+	// registered, then loaded with the rules
+	if loads != 2 {
+		t.Errorf("[FAIL] Expected the profile to be loaded twice (%d)", loads)
+	}
+
+	// another pod of the same owner shares the loaded profile
+	loads = 0
+
+	if err := re.PrepareAppArmorProfile(endPoint, "frontend-x2k8p", "nginx", profileName); err != nil || loads != 0 {
+		t.Errorf("[FAIL] Expected the loaded profile to be reused (%v, %d)", err, loads)
+	}
+
+	t.Log("[PASS] Loaded the AppArmor profile of a container before its creation")
+}
@@ -50,6 +50,9 @@ type Profile struct {
 	Rules
 	FromSource  map[string]FromSourceConfig
 	NativeRules []string
+
+	// base layers shared with the profiles of the same image
+	Includes []string
 }
 
 // Init initialises elements Profike Structure
@@ -63,7 +66,7 @@ func (p *Profile) Init() {
 
 // BaseTemplate for AppArmor profiles
 const BaseTemplate = `
-## == Managed by KubeArmor == ##
+` + appArmorOwnershipMarker + `
 #include <tunables/global>
 {{- $ctx := .}}
 {{- $regex := ".*?(\\[|\\*|\\+|\\?|\\$|\\|)+.*"}}
@@ -75,6 +78,7 @@ const BaseTemplate = `
 ## == Dispatcher profile START == ##
 profile {{.Name}} flags=(attach_disconnected,mediate_deleted) {
 	{{- template "pre-section" . }}
+	{{- template "includes" . }}
   {{template "file-section" . }}
 	## == DISPATCHER START == ##
   {{- range $source, $value:= $.FromSource}}
@@ -180,6 +184,7 @@ profile {{$.Name}}-{{$source}} {
             profile {{$value}} {
             {{$value}} rix,
             {{template "pre-section" $ctx}}
+            {{- template "includes" $ctx}}
             {{template "file-section" $ctx}}
             {{template "network-section" $ctx}}
   					{{template "capabilities-section" $ctx}}
@@ -209,6 +214,12 @@ profile {{$.Name}}-{{$source}} {
 	## == PRE END == ##
 {{- end}}
 
+{{define "includes"}}
+	{{- range .Includes}}
+	include <abstractions/{{.}}>
+	{{- end}}
+{{- end}}
+
 {{define "network-section"}}
   ## == Network START == ##
 	{{- range $value, $data := .NetworkRules}}
@@ -306,3 +317,12 @@ profile {{$.Name}}-{{$source}} {
 	## == Native Policy END == ##
 {{ end}}
 `
+
+// BaseLayerTemplate for the base layers of AppArmor profiles, included by the profiles of the same image
+const BaseLayerTemplate = `
+` + appArmorOwnershipMarker + `
+## == Base layer ({{.Image}}) == ##
+{{template "file-section" .}}
+{{template "network-section" .}}
+{{template "capabilities-section" .}}
+`
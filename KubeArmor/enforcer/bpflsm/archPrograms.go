@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package bpflsm
+
+import (
+	"fmt"
+
+	"github.com/cilium/ebpf"
+
+	"github.com/kubearmor/KubeArmor/KubeArmor/common"
+)
+
+// ======================= //
+// == BPF Arch Programs == //
+// ======================= //
+
+// enforcerProgramFeatures maps the programs of the enforcer objects to their features
+var enforcerProgramFeatures = map[string]string{
+	"enforce_proc":           common.BPFFeatureProcessEnforcement,
+	"enforce_file":           common.BPFFeatureFileEnforcement,
+	"enforce_file_perm":      common.BPFFeatureFileEnforcement,
+	"enforce_net_create":     common.BPFFeatureNetworkEnforcement,
+	"enforce_net_connect":    common.BPFFeatureNetworkEnforcement,
+	"enforce_net_accept":     common.BPFFeatureNetworkEnforcement,
+	"enforce_signal":         common.BPFFeatureSignalEnforcement,
+	"enforce_runtime_socket": common.BPFFeatureRuntimeSocketEnforcement,
+	"enforce_set_xattr":      common.BPFFeatureFileAttributeEnforcement,
+	"enforce_remove_xattr":   common.BPFFeatureFileAttributeEnforcement,
+	"enforce_set_flags":      common.BPFFeatureFileAttributeEnforcement,
+}
+
+// enforcerFeatures are the features of the enforcer, in the order they are reported
+var enforcerFeatures = []string{
+	common.BPFFeatureProcessEnforcement,
+	common.BPFFeatureFileEnforcement,
+	common.BPFFeaturePathEnforcement,
+	common.BPFFeatureNetworkEnforcement,
+	common.BPFFeatureSignalEnforcement,
+	common.BPFFeatureRuntimeSocketEnforcement,
+	common.BPFFeatureFileAttributeEnforcement,
+}
+
+// loadEnforcerSpec returns the enforcer objects embedded for the architecture (overridden by the tests)
+var loadEnforcerSpec = loadEnforcer
+
+// enforcerSpecForArch returns the enforcer objects without the programs unavailable on the architecture. The objects
+// must have the programs of all the features built for the architecture, and the enforcer fails without its process
+// or file programs.
+func enforcerSpecForArch() (*ebpf.CollectionSpec, error) {
+	spec, err := loadEnforcerSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	for name, feature := range enforcerProgramFeatures {
+		if !common.BPFFeatureAvailable(feature) {
+			delete(spec.Programs, name)
+		} else if _, ok := spec.Programs[name]; !ok {
+			return nil, fmt.Errorf("no %s program in the BPF objects of %s (%s)", name, common.BPFArch, feature)
+		}
+	}
+
+	for _, feature := range []string{common.BPFFeatureProcessEnforcement, common.BPFFeatureFileEnforcement} {
+		if err := common.BPFFeatureError(feature); err != nil {
+			return nil, err
+		}
+	}
+
+	return spec, nil
+}
+
+// loadEnforcerObjectsForArch loads the enforcer objects, leaving the programs unavailable on the architecture nil
+func loadEnforcerObjectsForArch(obj *enforcerObjects, opts *ebpf.CollectionOptions) error {
+	spec, err := enforcerSpecForArch()
+	if err != nil {
+		return err
+	}
+
+	coll, err := ebpf.NewCollectionWithOptions(spec, *opts)
+	if err != nil {
+		return err
+	}
+	defer coll.Close()
+
+	obj.EnforceProc = coll.DetachProgram("enforce_proc")
+	obj.EnforceFile = coll.DetachProgram("enforce_file")
+	obj.EnforceFilePerm = coll.DetachProgram("enforce_file_perm")
+	obj.EnforceNetCreate = coll.DetachProgram("enforce_net_create")
+	obj.EnforceNetConnect = coll.DetachProgram("enforce_net_connect")
+	obj.EnforceNetAccept = coll.DetachProgram("enforce_net_accept")
+	obj.EnforceSignal = coll.DetachProgram("enforce_signal")
+	obj.EnforceRuntimeSocket = coll.DetachProgram("enforce_runtime_socket")
+	obj.EnforceSetXattr = coll.DetachProgram("enforce_set_xattr")
+	obj.EnforceRemoveXattr = coll.DetachProgram("enforce_remove_xattr")
+	obj.EnforceSetFlags = coll.DetachProgram("enforce_set_flags")
+
+	obj.AllowBufs = coll.DetachMap("allow_bufs")
+	obj.Bufk = coll.DetachMap("bufk")
+	obj.Bufs = coll.DetachMap("bufs")
+	obj.BufsOff = coll.DetachMap("bufs_off")
+	obj.Events = coll.DetachMap("events")
+	obj.KubearmorAllowHits = coll.DetachMap("kubearmor_allow_hits")
+	obj.KubearmorContainers = coll.DetachMap("kubearmor_containers")
+	obj.KubearmorThrottle = coll.DetachMap("kubearmor_throttle")
+	obj.ThrottleKeyBuf = coll.DetachMap("throttle_key_buf")
+	obj.WriteCaptureBuf = coll.DetachMap("write_capture_buf")
+	obj.WriteCaptures = coll.DetachMap("write_captures")
+
+	return nil
+}
+
+// enforcerGaps returns the features of the enforcer unavailable on the architecture, whose rules are audited
+func enforcerGaps() []string {
+	gaps := []string{}
+
+	for _, feature := range enforcerFeatures {
+		if err := common.BPFFeatureError(feature); err != nil {
+			gaps = append(gaps, err.Error())
+		}
+	}
+
+	return gaps
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package bpflsm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cilium/ebpf"
+
+	"github.com/kubearmor/KubeArmor/KubeArmor/common"
+)
+
+func TestEnforcerSpecForArch(t *testing.T) {
+	prevArch := common.BPFArch
+	prevLoad := loadEnforcerSpec
+	defer func() {
+		common.BPFArch = prevArch
+		loadEnforcerSpec = prevLoad
+	}()
+
+	// the objects embedded for the architecture, without the given programs
+	objectsWithout := func(missing string) func() (*ebpf.CollectionSpec, error) {
+		return func() (*ebpf.CollectionSpec, error) {
+			spec := &ebpf.CollectionSpec{Programs: map[string]*ebpf.ProgramSpec{}}
+			for name := range enforcerProgramFeatures {
+				if name != missing {
+					spec.Programs[name] = &ebpf.ProgramSpec{Name: name, Type: ebpf.LSM}
+				}
+			}
+			return spec, nil
+		}
+	}
+
+	common.BPFArch = "s390x"
+	loadEnforcerSpec = objectsWithout("")
+
+	spec, err := enforcerSpecForArch()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to select the programs of s390x (%s)", err.Error())
+	}
+
+	if _, ok := spec.Programs["enforce_net_connect"]; ok {
+		t.Errorf("[FAIL] Unexpected network program on s390x")
+	}
+	if _, ok := spec.Programs["enforce_signal"]; !ok {
+		t.Errorf("[FAIL] Expected the signal program on s390x")
+	}
+
+	gaps := enforcerGaps()
+	if len(gaps) != 2 || gaps[0] != "network enforcement unavailable on s390x" ||
+		gaps[1] != "runtime socket enforcement unavailable on s390x" {
+		t.Errorf("[FAIL] Unexpected gaps of s390x (%v)", gaps)
+	}
+
+	// the probe matrix
+	for _, feature := range common.GetBPFFeatures() {
+		if feature.Arch != "s390x" || feature.Available == strings.Contains(strings.Join(gaps, ","), feature.Feature) {
+			t.Errorf("[FAIL] Unexpected availability of %s (%+v)", feature.Feature, feature)
+		}
+	}
+
+	// a program built for the architecture is required
+	loadEnforcerSpec = objectsWithout("enforce_signal")
+
+	if _, err := enforcerSpecForArch(); err == nil || err.Error() != "no enforce_signal program in the BPF objects of s390x (signal enforcement)" {
+		t.Errorf("[FAIL] Expected the enforcer to fail without the signal program (%v)", err)
+	}
+
+	// the programs unavailable on the architecture aren't
+	loadEnforcerSpec = objectsWithout("enforce_net_connect")
+
+	if _, err := enforcerSpecForArch(); err != nil {
+		t.Errorf("[FAIL] Failed to select the programs of s390x without the network programs (%s)", err.Error())
+	}
+
+	// nothing is enforced without the process and file programs
+	common.BPFArch = "riscv64"
+	loadEnforcerSpec = objectsWithout("")
+
+	if _, err := enforcerSpecForArch(); err == nil || err.Error() != "process enforcement unavailable on riscv64" {
+		t.Errorf("[FAIL] Expected the enforcer to fail on riscv64 (%v)", err)
+	}
+
+	t.Log("[PASS] Selected the BPF programs per architecture")
+}
@@ -45,6 +45,10 @@ type BPFEnforcer struct {
 	ContainerMap     map[string]ContainerKV
 	ContainerMapLock *sync.RWMutex
 
+	// id -> hits of an Allow rule of a policy with logAllowed (protected by the lock of the container map)
+	AllowHits       map[uint32]*AllowHits
+	lastAllowRuleID uint32
+
 	obj     enforcerObjects
 	objPath enforcer_pathObjects
 
@@ -71,6 +75,7 @@ func NewBPFEnforcer(node tp.Node, pinpath string, logger *fd.Feeder, monitor *mo
 	be.Probes = make(map[string]link.Link)
 	be.ContainerMap = make(map[string]ContainerKV)
 	be.ContainerMapLock = new(sync.RWMutex)
+	be.AllowHits = make(map[uint32]*AllowHits)
 
 	be.InnerMapSpec = &ebpf.MapSpec{
 		Type:       ebpf.Hash,
@@ -95,7 +100,8 @@ func NewBPFEnforcer(node tp.Node, pinpath string, logger *fd.Feeder, monitor *mo
 		return be, err
 	}
 
-	if err := loadEnforcerObjects(&be.obj, &ebpf.CollectionOptions{
+	// the programs unavailable on the architecture are left out, rather than failing the verifier
+	if err := loadEnforcerObjectsForArch(&be.obj, &ebpf.CollectionOptions{
 		Maps: ebpf.MapOptions{
 			PinPath: pinpath,
 		},
@@ -104,40 +110,33 @@ func NewBPFEnforcer(node tp.Node, pinpath string, logger *fd.Feeder, monitor *mo
 		return be, err
 	}
 
-	be.Probes[be.obj.EnforceProc.String()], err = link.AttachLSM(link.LSMOptions{Program: be.obj.EnforceProc})
-	if err != nil {
-		be.Logger.Errf("opening lsm %s: %s", be.obj.EnforceProc.String(), err)
-		return be, err
-	}
-
-	be.Probes[be.obj.EnforceFile.String()], err = link.AttachLSM(link.LSMOptions{Program: be.obj.EnforceFile})
-	if err != nil {
-		be.Logger.Errf("opening lsm %s: %s", be.obj.EnforceFile.String(), err)
-		return be, err
-	}
-
-	be.Probes[be.obj.EnforceFilePerm.String()], err = link.AttachLSM(link.LSMOptions{Program: be.obj.EnforceFilePerm})
-	if err != nil {
-		be.Logger.Errf("opening lsm %s: %s", be.obj.EnforceFilePerm.String(), err)
-		return be, err
-	}
-
-	be.Probes[be.obj.EnforceNetCreate.String()], err = link.AttachLSM(link.LSMOptions{Program: be.obj.EnforceNetCreate})
-	if err != nil {
-		be.Logger.Errf("opening lsm %s: %s", be.obj.EnforceNetCreate.String(), err)
-		return be, err
+	for _, gap := range enforcerGaps() {
+		be.Logger.Warnf("BPF LSM %s, its rules are audited instead", gap)
 	}
 
-	be.Probes[be.obj.EnforceNetConnect.String()], err = link.AttachLSM(link.LSMOptions{Program: be.obj.EnforceNetConnect})
-	if err != nil {
-		be.Logger.Errf("opening lsm %s: %s", be.obj.EnforceNetConnect.String(), err)
-		return be, err
-	}
+	for _, prog := range []*ebpf.Program{
+		be.obj.EnforceProc,
+		be.obj.EnforceFile,
+		be.obj.EnforceFilePerm,
+		be.obj.EnforceNetCreate,
+		be.obj.EnforceNetConnect,
+		be.obj.EnforceNetAccept,
+		be.obj.EnforceSignal,
+		be.obj.EnforceRuntimeSocket,
+		be.obj.EnforceSetXattr,
+		be.obj.EnforceRemoveXattr,
+		be.obj.EnforceSetFlags,
+	} {
+		// unavailable on the architecture
+		if prog == nil {
+			continue
+		}
 
-	be.Probes[be.obj.EnforceNetAccept.String()], err = link.AttachLSM(link.LSMOptions{Program: be.obj.EnforceNetAccept})
-	if err != nil {
-		be.Logger.Errf("opening lsm %s: %s", be.obj.EnforceNetAccept.String(), err)
-		return be, err
+		be.Probes[prog.String()], err = link.AttachLSM(link.LSMOptions{Program: prog})
+		if err != nil {
+			be.Logger.Errf("opening lsm %s: %s", prog.String(), err)
+			return be, err
+		}
 	}
 
 	/*
@@ -145,77 +144,80 @@ func NewBPFEnforcer(node tp.Node, pinpath string, logger *fd.Feeder, monitor *mo
 
 		Create, Link, Unlink, Symlink, Rename, MkDir, RmDir, Chown, Chmod, Truncate
 
-		These will only work if the system has `CONFIG_SECURITY_PATH=y`
+		These will only work if the system has `CONFIG_SECURITY_PATH=y`, and are skipped on the architectures they
+		aren't built for
 
 		We only warn if we fail to load the following hooks
 	*/
 
-	if err := loadEnforcer_pathObjects(&be.objPath, &ebpf.CollectionOptions{
-		Maps: ebpf.MapOptions{
-			PinPath: common.GetMapRoot(),
-		},
-	}); err != nil {
-		be.Logger.Warnf("error loading BPF LSM Path objects. This usually suggests that the system doesn't have the system has `CONFIG_SECURITY_PATH=y`: %v", err)
-	} else {
-		be.Probes[be.objPath.EnforceMknod.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceMknod})
-		if err != nil {
-			be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceMknod.String(), err)
-		}
+	if common.BPFFeatureAvailable(common.BPFFeaturePathEnforcement) {
+		if err := loadEnforcer_pathObjects(&be.objPath, &ebpf.CollectionOptions{
+			Maps: ebpf.MapOptions{
+				PinPath: common.GetMapRoot(),
+			},
+		}); err != nil {
+			be.Logger.Warnf("error loading BPF LSM Path objects. This usually suggests that the system doesn't have the system has `CONFIG_SECURITY_PATH=y`: %v", err)
+		} else {
+			be.Probes[be.objPath.EnforceMknod.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceMknod})
+			if err != nil {
+				be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceMknod.String(), err)
+			}
 
-		be.Probes[be.objPath.EnforceLinkSrc.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceLinkSrc})
-		if err != nil {
-			be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceLinkSrc.String(), err)
-		}
+			be.Probes[be.objPath.EnforceLinkSrc.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceLinkSrc})
+			if err != nil {
+				be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceLinkSrc.String(), err)
+			}
 
-		be.Probes[be.objPath.EnforceLinkDst.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceLinkDst})
-		if err != nil {
-			be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceLinkDst.String(), err)
-		}
+			be.Probes[be.objPath.EnforceLinkDst.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceLinkDst})
+			if err != nil {
+				be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceLinkDst.String(), err)
+			}
 
-		be.Probes[be.objPath.EnforceUnlink.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceUnlink})
-		if err != nil {
-			be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceUnlink.String(), err)
-		}
+			be.Probes[be.objPath.EnforceUnlink.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceUnlink})
+			if err != nil {
+				be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceUnlink.String(), err)
+			}
 
-		be.Probes[be.objPath.EnforceSymlink.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceSymlink})
-		if err != nil {
-			be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceSymlink.String(), err)
-		}
+			be.Probes[be.objPath.EnforceSymlink.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceSymlink})
+			if err != nil {
+				be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceSymlink.String(), err)
+			}
 
-		be.Probes[be.objPath.EnforceMkdir.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceMkdir})
-		if err != nil {
-			be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceMkdir.String(), err)
-		}
+			be.Probes[be.objPath.EnforceMkdir.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceMkdir})
+			if err != nil {
+				be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceMkdir.String(), err)
+			}
 
-		be.Probes[be.objPath.EnforceChmod.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceChmod})
-		if err != nil {
-			be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceChmod.String(), err)
-		}
+			be.Probes[be.objPath.EnforceChmod.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceChmod})
+			if err != nil {
+				be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceChmod.String(), err)
+			}
 
-		// We do not support Chown for now because of limitations of bpf_trampoline https://github.com/iovisor/bcc/issues/3657
-		// be.Probes[be.objPath.EnforceChown.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceChown})
-		// if err != nil {
-		// 	be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceChown.String(), err)
-		// }
+			// We do not support Chown for now because of limitations of bpf_trampoline https://github.com/iovisor/bcc/issues/3657
+			// be.Probes[be.objPath.EnforceChown.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceChown})
+			// if err != nil {
+			// 	be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceChown.String(), err)
+			// }
 
-		be.Probes[be.objPath.EnforceTruncate.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceTruncate})
-		if err != nil {
-			be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceTruncate.String(), err)
-		}
+			be.Probes[be.objPath.EnforceTruncate.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceTruncate})
+			if err != nil {
+				be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceTruncate.String(), err)
+			}
 
-		be.Probes[be.objPath.EnforceRenameNew.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceRenameNew})
-		if err != nil {
-			be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceRenameNew.String(), err)
-		}
+			be.Probes[be.objPath.EnforceRenameNew.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceRenameNew})
+			if err != nil {
+				be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceRenameNew.String(), err)
+			}
 
-		be.Probes[be.objPath.EnforceRenameOld.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceRenameOld})
-		if err != nil {
-			be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceRenameOld.String(), err)
-		}
+			be.Probes[be.objPath.EnforceRenameOld.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceRenameOld})
+			if err != nil {
+				be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceRenameOld.String(), err)
+			}
 
-		be.Probes[be.objPath.EnforceRmdir.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceRmdir})
-		if err != nil {
-			be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceRmdir.String(), err)
+			be.Probes[be.objPath.EnforceRmdir.String()], err = link.AttachLSM(link.LSMOptions{Program: be.objPath.EnforceRmdir})
+			if err != nil {
+				be.Logger.Warnf("opening lsm %s: %s", be.objPath.EnforceRmdir.String(), err)
+			}
 		}
 	}
 
@@ -224,10 +226,15 @@ func NewBPFEnforcer(node tp.Node, pinpath string, logger *fd.Feeder, monitor *mo
 		be.Logger.Errf("opening ringbuf reader: %s", err)
 		return be, err
 	}
-	be.EventsChannel = make(chan []byte, mon.SyscallChannelSize)
+	be.EventsChannel = make(chan []byte, mon.EventChannelSize())
 
 	go be.TraceEvents()
 
+	// the hits of the Allow rules are reported with the allow telemetry
+	if be.Logger.AllowTelemetry != nil {
+		be.Logger.AllowTelemetry.SetCollector("BPFLSM", be.collectAllowHits)
+	}
+
 	if cfg.GlobalCfg.HostPolicy {
 		be.AddHostToMap()
 	}
@@ -256,6 +263,93 @@ type eventBPF struct {
 	Data InnerKey
 }
 
+// takeWriteCapture returns the sample of a blocked write taken at the hook of the write, if any
+func (be *BPFEnforcer) takeWriteCapture(event eventBPF, path string) *tp.WriteCapture {
+	if be.obj.WriteCaptures == nil {
+		return nil
+	}
+
+	var capture enforcerWriteCapture
+
+	key := enforcerCaptureKey{Ts: event.Ts, HostPid: event.HostPID}
+	if err := be.obj.WriteCaptures.LookupAndDelete(&key, &capture); err != nil {
+		return nil
+	}
+
+	if capture.Len > uint32(len(capture.Sample)) {
+		capture.Len = uint32(len(capture.Sample))
+	}
+
+	return fd.NewHookedWriteCapture(int32(capture.Fd), path, capture.Offset, int64(capture.Size), capture.Sample[:capture.Len])
+}
+
+// collectAllowHits records the hits of the Allow rules counted since the last collection
+func (be *BPFEnforcer) collectAllowHits() {
+	type allowHit struct {
+		containerID string
+		rule        AllowRule
+		hits        uint64
+	}
+
+	hits := []allowHit{}
+
+	be.ContainerMapLock.Lock()
+	if be.obj.KubearmorAllowHits != nil {
+		var key AllowKey
+		var val enforcerAllowHits
+
+		iter := be.obj.KubearmorAllowHits.Iterate()
+		for iter.Next(&key, &val) {
+			last, ok := be.AllowHits[val.Id]
+			if !ok || val.Hits <= last.Hits {
+				continue
+			}
+			hits = append(hits, allowHit{containerID: last.ContainerID, rule: last.Rule, hits: val.Hits - last.Hits})
+			last.Hits = val.Hits
+		}
+		if err := iter.Err(); err != nil {
+			be.Logger.Warnf("error reading the hits of the allow rules: %s", err)
+		}
+	}
+	be.ContainerMapLock.Unlock()
+
+	for _, hit := range hits {
+		log := tp.Log{}
+
+		if hit.containerID == "host" {
+			log.Type = "MatchedHostPolicy"
+		} else {
+			log.Type = "MatchedPolicy"
+			if be.Monitor != nil {
+				log.ContainerID = hit.containerID
+				log = be.Monitor.UpdateContainerInfoByContainerID(log)
+			}
+		}
+
+		log.Source = "kubearmor"
+		log.ProcessName = "kubearmor"
+
+		log.PolicyName = hit.rule.PolicyName
+		log.Operation = hit.rule.Operation
+		log.Enforcer = "BPFLSM"
+
+		be.Logger.AllowTelemetry.RecordHits(log, hit.rule.Rule, int(hit.hits))
+	}
+}
+
+// getFilelessResource returns the path of a fileless execution (following the FILELESS byte),
+// and flags the execution in the data of its log
+func getFilelessResource(path [256]byte, data string) (string, string) {
+	resource := string(bytes.Trim(path[1:], "\x00"))
+
+	name, _ := fd.GetFilelessName(resource)
+	if name == "" {
+		name = "anonymous"
+	}
+
+	return resource, data + " fileless=" + name
+}
+
 // TraceEvents traces events generated by bpflsm enforcer
 func (be *BPFEnforcer) TraceEvents() {
 
@@ -303,6 +397,8 @@ func (be *BPFEnforcer) TraceEvents() {
 		log := be.Monitor.BuildLogBase(event.EventID, mon.ContextCombined{
 			ContainerID: containerID,
 			ContextSys: mon.SyscallContext{
+				Ts: event.Ts,
+
 				PID:  event.PID,
 				PPID: event.PPID,
 				UID:  event.UID,
@@ -321,13 +417,21 @@ func (be *BPFEnforcer) TraceEvents() {
 			log.Result = "Permission denied"
 			log.Data = "lsm=" + mon.GetSyscallName(int32(event.EventID))
 
+			if event.EventID == mon.FilePermission {
+				log.Capture = be.takeWriteCapture(event, log.Resource)
+			}
+
 		case mon.SocketCreate, mon.SocketConnect, mon.SocketAccept:
 			var sockProtocol int32
 			sockProtocol = int32(event.Data.Path[1])
 			log.Operation = "Network"
 			log.Enforcer = "BPFLSM"
 			log.Result = "Permission denied"
-			if event.Data.Path[0] == 2 {
+			if event.Data.Path[0] == FAMILY {
+				if event.Data.Path[1] == netFamily["PACKET"] {
+					log.Resource = fd.GetProtocolFromName("packet")
+				}
+			} else if event.Data.Path[0] == 2 {
 				if event.Data.Path[1] == 3 {
 					log.Resource = fd.GetProtocolFromName("raw")
 				}
@@ -343,6 +447,11 @@ func (be *BPFEnforcer) TraceEvents() {
 			log.Enforcer = "BPFLSM"
 			log.Result = "Permission denied"
 			log.Data = "lsm=" + mon.GetSyscallName(int32(event.EventID))
+
+			// the executions of files without a path are flagged
+			if event.Data.Path[0] == FILELESS {
+				log.Resource, log.Data = getFilelessResource(event.Data.Path, log.Data)
+			}
 		}
 
 		be.Logger.PushLog(log)
@@ -351,17 +460,29 @@ func (be *BPFEnforcer) TraceEvents() {
 }
 
 // UpdateSecurityPolicies loops through containers present in the input endpoint and updates rules for each container
-func (be *BPFEnforcer) UpdateSecurityPolicies(endPoint tp.EndPoint) {
+func (be *BPFEnforcer) UpdateSecurityPolicies(endPoint tp.EndPoint) (fd.PolicyApplyTimes, error) {
 	// skip if BPFEnforcer is not active
 	if be == nil {
-		return
+		return fd.PolicyApplyTimes{}, nil
 	}
 
+	var updateErr error
+
+	times := fd.PolicyApplyTimes{}
+
 	for _, cid := range endPoint.Containers {
 		be.Logger.Printf("Updating container rules for %s", cid)
-		be.UpdateContainerRules(cid, endPoint.SecurityPolicies, endPoint.DefaultPosture)
+		containerTimes, err := be.updateContainerRules(cid, endPoint.SecurityPolicies, endPoint.DefaultPosture)
+		if err != nil && updateErr == nil {
+			updateErr = err
+		}
+		times = times.Add(containerTimes)
+
+		gid, ok := getOwnerGroup(endPoint)
+		be.UpdateOwnerGroup(cid, gid, ok)
 	}
 
+	return times, updateErr
 }
 
 // UpdateHostSecurityPolicies updates rules for the host
@@ -384,6 +505,10 @@ func (be *BPFEnforcer) DestroyBPFEnforcer() error {
 
 	errBPFCleanUp := false
 
+	if be.Logger.AllowTelemetry != nil {
+		be.Logger.AllowTelemetry.SetCollector("BPFLSM", nil)
+	}
+
 	if err := be.obj.Close(); err != nil {
 		be.Logger.Err(err.Error())
 		errBPFCleanUp = true
@@ -0,0 +1,212 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package bpflsm
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/btf"
+)
+
+// enforcerObjectFiles are the enforcer objects embedded for each byte order
+var enforcerObjectFiles = []string{"enforcer_bpfel.o", "enforcer_bpfeb.o"}
+
+// pathObjectFiles are the path enforcer objects embedded for each byte order
+var pathObjectFiles = []string{"enforcer_path_bpfel.o", "enforcer_path_bpfeb.o"}
+
+// programSourceLines returns the source lines of a program of a BPF object (from its BTF line info)
+func programSourceLines(t *testing.T, object, program string) []*btf.Line {
+	spec, err := ebpf.LoadCollectionSpec(object)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to load %s (%s)", object, err.Error())
+	}
+
+	progSpec, ok := spec.Programs[program]
+	if !ok {
+		t.Fatalf("[FAIL] No %s program in %s", program, object)
+	}
+
+	lines := []*btf.Line{}
+	for _, ins := range progSpec.Instructions {
+		if line, ok := ins.Source().(*btf.Line); ok {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// hasSourceLine checks if a line of the given file contains the given code
+func hasSourceLine(lines []*btf.Line, file, code string) bool {
+	for _, line := range lines {
+		if strings.HasSuffix(line.FileName(), file) && strings.Contains(line.Line(), code) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEnforcerObjectsFileless(t *testing.T) {
+	for _, object := range enforcerObjectFiles {
+		lines := programSourceLines(t, object, "enforce_proc")
+
+		if !hasSourceLine(lines, "enforcer.bpf.c", "if (is_fileless(bprm->file))") {
+			t.Errorf("[FAIL] The enforce_proc program of %s doesn't check fileless executions", object)
+		}
+		if !hasSourceLine(lines, "shared.h", "f_inode, i_nlink) == 0") {
+			t.Errorf("[FAIL] The enforce_proc program of %s doesn't inline is_fileless", object)
+		}
+	}
+
+	t.Log("[PASS] Checked fileless executions in the embedded enforcer objects")
+}
+
+func TestEnforcerObjectsPrograms(t *testing.T) {
+	for _, object := range enforcerObjectFiles {
+		spec, err := ebpf.LoadCollectionSpec(object)
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to load %s (%s)", object, err.Error())
+		}
+
+		for name := range enforcerProgramFeatures {
+			if _, ok := spec.Programs[name]; !ok {
+				t.Errorf("[FAIL] No %s program in %s", name, object)
+			}
+		}
+	}
+
+	// the programs of the architecture are selected from the embedded objects
+	if _, err := enforcerSpecForArch(); err != nil {
+		t.Errorf("[FAIL] Failed to select the programs of the embedded objects (%s)", err.Error())
+	}
+
+	t.Log("[PASS] Found all the programs in the embedded enforcer objects")
+}
+
+func TestEnforcerObjectsRuntimeSocket(t *testing.T) {
+	for _, object := range enforcerObjectFiles {
+		lines := programSourceLines(t, object, "enforce_runtime_socket")
+
+		if !hasSourceLine(lines, "enforcer.bpf.c", "pk->path[0] = RUNTIME_SOCKET") {
+			t.Errorf("[FAIL] The enforce_runtime_socket program of %s doesn't look up the keys of the sockets", object)
+		}
+		if !hasSourceLine(lines, "enforcer.bpf.c", "if (val && (val->processmask & RULE_DENY))") {
+			t.Errorf("[FAIL] The enforce_runtime_socket program of %s doesn't deny the connections", object)
+		}
+	}
+
+	t.Log("[PASS] Checked the runtime socket rules in the embedded enforcer objects")
+}
+
+func TestEnforcerObjectsWriteCapture(t *testing.T) {
+	for _, object := range enforcerObjectFiles {
+		lines := programSourceLines(t, object, "enforce_file_perm")
+
+		if !hasSourceLine(lines, "enforcer.bpf.c", "pk->path[0] = CAPTURE_WRITES") {
+			t.Errorf("[FAIL] The enforce_file_perm program of %s doesn't look up the capture key", object)
+		}
+		if !hasSourceLine(lines, "enforcer.bpf.c", "bpf_map_update_elem(&write_captures, &key, capture, BPF_ANY)") {
+			t.Errorf("[FAIL] The enforce_file_perm program of %s doesn't sample the blocked writes", object)
+		}
+	}
+
+	t.Log("[PASS] Checked the samples of the blocked writes in the embedded enforcer objects")
+}
+
+func TestEnforcerObjectsUpToDate(t *testing.T) {
+	// the sources, by the paths of the line info (relative to this package, as bpf2go is run from it)
+	sources := map[string][]string{}
+
+	for _, object := range append(enforcerObjectFiles, pathObjectFiles...) {
+		spec, err := ebpf.LoadCollectionSpec(object)
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to load %s (%s)", object, err.Error())
+		}
+
+		for name := range spec.Programs {
+			for _, line := range programSourceLines(t, object, name) {
+				// compiler generated
+				if line.LineNumber() == 0 {
+					continue
+				}
+
+				source, ok := sources[line.FileName()]
+				if !ok {
+					data, err := os.ReadFile(line.FileName())
+					if err != nil {
+						t.Fatalf("[FAIL] Failed to read the source of %s (%s)", object, err.Error())
+					}
+					source = strings.Split(string(data), "\n")
+					sources[line.FileName()] = source
+				}
+
+				if n := int(line.LineNumber()); n > len(source) || source[n-1] != line.Line() {
+					t.Errorf("[FAIL] The %s program of %s was built from an older %s (line %d: %q), run go generate", name, object, line.FileName(), n, line.Line())
+					break
+				}
+			}
+		}
+	}
+
+	t.Log("[PASS] Built the embedded BPF objects from the current sources")
+}
+
+func TestEnforcerObjectsFileRules(t *testing.T) {
+	for _, object := range enforcerObjectFiles {
+		for _, program := range []string{"enforce_file", "enforce_file_perm"} {
+			lines := programSourceLines(t, object, program)
+
+			for _, code := range []string{
+				"bool permitted = is_permitted(val, write, f_path->dentry, inner);",
+				"if (!permitted || !has_file_flags(val)) {",
+				"return is_owner_group(BPF_CORE_READ(inode, i_gid), inner);",
+			} {
+				if !hasSourceLine(lines, "shared.h", code) {
+					t.Errorf("[FAIL] The %s program of %s doesn't have %q", program, object, code)
+				}
+			}
+		}
+	}
+
+	t.Log("[PASS] Checked the file rules in the embedded enforcer objects")
+}
+
+func TestEnforcerObjectsFileAttributes(t *testing.T) {
+	for _, object := range enforcerObjectFiles {
+		for _, program := range []string{"enforce_set_xattr", "enforce_remove_xattr", "enforce_set_flags"} {
+			lines := programSourceLines(t, object, program)
+
+			for _, code := range []string{
+				"pk->path[0] = FILE_ATTR;",
+				"pk->path[0] = FILE_ATTR_TARGET;",
+				"if (val->filemask & RULE_DENY)",
+			} {
+				if !hasSourceLine(lines, "enforcer.bpf.c", code) {
+					t.Errorf("[FAIL] The %s program of %s doesn't have %q", program, object, code)
+				}
+			}
+		}
+
+		if lines := programSourceLines(t, object, "enforce_set_flags"); !hasSourceLine(lines, "enforcer.bpf.c", "if (cmd != FS_IOC_SETFLAGS && cmd != FS_IOC32_SETFLAGS)") {
+			t.Errorf("[FAIL] The enforce_set_flags program of %s doesn't check the ioctl of the inode flags", object)
+		}
+	}
+
+	t.Log("[PASS] Checked the file attribute rules in the embedded enforcer objects")
+}
+
+func TestEnforcerObjectsSocketFamily(t *testing.T) {
+	for _, object := range enforcerObjectFiles {
+		for _, program := range []string{"enforce_net_create", "enforce_net_connect", "enforce_net_accept"} {
+			if lines := programSourceLines(t, object, program); !hasSourceLine(lines, "enforcer.bpf.c", "store->path[1] = family;") {
+				t.Errorf("[FAIL] The %s program of %s doesn't match the socket families", program, object)
+			}
+		}
+	}
+
+	t.Log("[PASS] Checked the socket family rules in the embedded enforcer objects")
+}
@@ -12,6 +12,25 @@ import (
 	"github.com/cilium/ebpf"
 )
 
+type enforcerAllowBuf struct {
+	Key    enforcerAllowKey
+	Dirlen uint32
+}
+
+type enforcerAllowHits struct {
+	Id   uint32
+	_    [4]byte
+	Hits uint64
+}
+
+type enforcerAllowKey struct {
+	Okey struct {
+		PidNs uint32
+		MntNs uint32
+	}
+	Rule enforcerBufsK
+}
+
 type enforcerBufsK struct {
 	Path   [256]int8
 	Source [256]int8
@@ -19,6 +38,36 @@ type enforcerBufsK struct {
 
 type enforcerBufsT struct{ Buf [32768]int8 }
 
+type enforcerCaptureKey struct {
+	Ts      uint64
+	HostPid uint32
+	Pad     uint32
+}
+
+type enforcerThrottleBucket struct {
+	Rate    uint64
+	Burst   uint64
+	Credits int64
+	Last    uint64
+}
+
+type enforcerThrottleKey struct {
+	Okey struct {
+		PidNs uint32
+		MntNs uint32
+	}
+	Rule enforcerBufsK
+}
+
+type enforcerWriteCapture struct {
+	Fd     int64
+	Offset int64
+	Size   uint64
+	Len    uint32
+	Pad    uint32
+	Sample [4096]uint8
+}
+
 // loadEnforcer returns the embedded CollectionSpec for enforcer.
 func loadEnforcer() (*ebpf.CollectionSpec, error) {
 	reader := bytes.NewReader(_EnforcerBytes)
@@ -60,23 +109,34 @@ type enforcerSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type enforcerProgramSpecs struct {
-	EnforceFile       *ebpf.ProgramSpec `ebpf:"enforce_file"`
-	EnforceFilePerm   *ebpf.ProgramSpec `ebpf:"enforce_file_perm"`
-	EnforceNetAccept  *ebpf.ProgramSpec `ebpf:"enforce_net_accept"`
-	EnforceNetConnect *ebpf.ProgramSpec `ebpf:"enforce_net_connect"`
-	EnforceNetCreate  *ebpf.ProgramSpec `ebpf:"enforce_net_create"`
-	EnforceProc       *ebpf.ProgramSpec `ebpf:"enforce_proc"`
+	EnforceFile          *ebpf.ProgramSpec `ebpf:"enforce_file"`
+	EnforceFilePerm      *ebpf.ProgramSpec `ebpf:"enforce_file_perm"`
+	EnforceNetAccept     *ebpf.ProgramSpec `ebpf:"enforce_net_accept"`
+	EnforceNetConnect    *ebpf.ProgramSpec `ebpf:"enforce_net_connect"`
+	EnforceNetCreate     *ebpf.ProgramSpec `ebpf:"enforce_net_create"`
+	EnforceProc          *ebpf.ProgramSpec `ebpf:"enforce_proc"`
+	EnforceRemoveXattr   *ebpf.ProgramSpec `ebpf:"enforce_remove_xattr"`
+	EnforceRuntimeSocket *ebpf.ProgramSpec `ebpf:"enforce_runtime_socket"`
+	EnforceSetFlags      *ebpf.ProgramSpec `ebpf:"enforce_set_flags"`
+	EnforceSetXattr      *ebpf.ProgramSpec `ebpf:"enforce_set_xattr"`
+	EnforceSignal        *ebpf.ProgramSpec `ebpf:"enforce_signal"`
 }
 
 // enforcerMapSpecs contains maps before they are loaded into the kernel.
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type enforcerMapSpecs struct {
+	AllowBufs           *ebpf.MapSpec `ebpf:"allow_bufs"`
 	Bufk                *ebpf.MapSpec `ebpf:"bufk"`
 	Bufs                *ebpf.MapSpec `ebpf:"bufs"`
 	BufsOff             *ebpf.MapSpec `ebpf:"bufs_off"`
 	Events              *ebpf.MapSpec `ebpf:"events"`
+	KubearmorAllowHits  *ebpf.MapSpec `ebpf:"kubearmor_allow_hits"`
 	KubearmorContainers *ebpf.MapSpec `ebpf:"kubearmor_containers"`
+	KubearmorThrottle   *ebpf.MapSpec `ebpf:"kubearmor_throttle"`
+	ThrottleKeyBuf      *ebpf.MapSpec `ebpf:"throttle_key_buf"`
+	WriteCaptureBuf     *ebpf.MapSpec `ebpf:"write_capture_buf"`
+	WriteCaptures       *ebpf.MapSpec `ebpf:"write_captures"`
 }
 
 // enforcerObjects contains all objects after they have been loaded into the kernel.
@@ -98,20 +158,32 @@ func (o *enforcerObjects) Close() error {
 //
 // It can be passed to loadEnforcerObjects or ebpf.CollectionSpec.LoadAndAssign.
 type enforcerMaps struct {
+	AllowBufs           *ebpf.Map `ebpf:"allow_bufs"`
 	Bufk                *ebpf.Map `ebpf:"bufk"`
 	Bufs                *ebpf.Map `ebpf:"bufs"`
 	BufsOff             *ebpf.Map `ebpf:"bufs_off"`
 	Events              *ebpf.Map `ebpf:"events"`
+	KubearmorAllowHits  *ebpf.Map `ebpf:"kubearmor_allow_hits"`
 	KubearmorContainers *ebpf.Map `ebpf:"kubearmor_containers"`
+	KubearmorThrottle   *ebpf.Map `ebpf:"kubearmor_throttle"`
+	ThrottleKeyBuf      *ebpf.Map `ebpf:"throttle_key_buf"`
+	WriteCaptureBuf     *ebpf.Map `ebpf:"write_capture_buf"`
+	WriteCaptures       *ebpf.Map `ebpf:"write_captures"`
 }
 
 func (m *enforcerMaps) Close() error {
 	return _EnforcerClose(
+		m.AllowBufs,
 		m.Bufk,
 		m.Bufs,
 		m.BufsOff,
 		m.Events,
+		m.KubearmorAllowHits,
 		m.KubearmorContainers,
+		m.KubearmorThrottle,
+		m.ThrottleKeyBuf,
+		m.WriteCaptureBuf,
+		m.WriteCaptures,
 	)
 }
 
@@ -119,12 +191,17 @@ func (m *enforcerMaps) Close() error {
 //
 // It can be passed to loadEnforcerObjects or ebpf.CollectionSpec.LoadAndAssign.
 type enforcerPrograms struct {
-	EnforceFile       *ebpf.Program `ebpf:"enforce_file"`
-	EnforceFilePerm   *ebpf.Program `ebpf:"enforce_file_perm"`
-	EnforceNetAccept  *ebpf.Program `ebpf:"enforce_net_accept"`
-	EnforceNetConnect *ebpf.Program `ebpf:"enforce_net_connect"`
-	EnforceNetCreate  *ebpf.Program `ebpf:"enforce_net_create"`
-	EnforceProc       *ebpf.Program `ebpf:"enforce_proc"`
+	EnforceFile          *ebpf.Program `ebpf:"enforce_file"`
+	EnforceFilePerm      *ebpf.Program `ebpf:"enforce_file_perm"`
+	EnforceNetAccept     *ebpf.Program `ebpf:"enforce_net_accept"`
+	EnforceNetConnect    *ebpf.Program `ebpf:"enforce_net_connect"`
+	EnforceNetCreate     *ebpf.Program `ebpf:"enforce_net_create"`
+	EnforceProc          *ebpf.Program `ebpf:"enforce_proc"`
+	EnforceRemoveXattr   *ebpf.Program `ebpf:"enforce_remove_xattr"`
+	EnforceRuntimeSocket *ebpf.Program `ebpf:"enforce_runtime_socket"`
+	EnforceSetFlags      *ebpf.Program `ebpf:"enforce_set_flags"`
+	EnforceSetXattr      *ebpf.Program `ebpf:"enforce_set_xattr"`
+	EnforceSignal        *ebpf.Program `ebpf:"enforce_signal"`
 }
 
 func (p *enforcerPrograms) Close() error {
@@ -135,6 +212,11 @@ func (p *enforcerPrograms) Close() error {
 		p.EnforceNetConnect,
 		p.EnforceNetCreate,
 		p.EnforceProc,
+		p.EnforceRemoveXattr,
+		p.EnforceRuntimeSocket,
+		p.EnforceSetFlags,
+		p.EnforceSetXattr,
+		p.EnforceSignal,
 	)
 }
 
@@ -12,6 +12,25 @@ import (
 	"github.com/cilium/ebpf"
 )
 
+type enforcer_pathAllowBuf struct {
+	Key    enforcer_pathAllowKey
+	Dirlen uint32
+}
+
+type enforcer_pathAllowHits struct {
+	Id   uint32
+	_    [4]byte
+	Hits uint64
+}
+
+type enforcer_pathAllowKey struct {
+	Okey struct {
+		PidNs uint32
+		MntNs uint32
+	}
+	Rule enforcer_pathBufsK
+}
+
 type enforcer_pathBufsK struct {
 	Path   [256]int8
 	Source [256]int8
@@ -77,10 +96,12 @@ type enforcer_pathProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type enforcer_pathMapSpecs struct {
+	AllowBufs           *ebpf.MapSpec `ebpf:"allow_bufs"`
 	Bufk                *ebpf.MapSpec `ebpf:"bufk"`
 	Bufs                *ebpf.MapSpec `ebpf:"bufs"`
 	BufsOff             *ebpf.MapSpec `ebpf:"bufs_off"`
 	Events              *ebpf.MapSpec `ebpf:"events"`
+	KubearmorAllowHits  *ebpf.MapSpec `ebpf:"kubearmor_allow_hits"`
 	KubearmorContainers *ebpf.MapSpec `ebpf:"kubearmor_containers"`
 }
 
@@ -103,19 +124,23 @@ func (o *enforcer_pathObjects) Close() error {
 //
 // It can be passed to loadEnforcer_pathObjects or ebpf.CollectionSpec.LoadAndAssign.
 type enforcer_pathMaps struct {
+	AllowBufs           *ebpf.Map `ebpf:"allow_bufs"`
 	Bufk                *ebpf.Map `ebpf:"bufk"`
 	Bufs                *ebpf.Map `ebpf:"bufs"`
 	BufsOff             *ebpf.Map `ebpf:"bufs_off"`
 	Events              *ebpf.Map `ebpf:"events"`
+	KubearmorAllowHits  *ebpf.Map `ebpf:"kubearmor_allow_hits"`
 	KubearmorContainers *ebpf.Map `ebpf:"kubearmor_containers"`
 }
 
 func (m *enforcer_pathMaps) Close() error {
 	return _Enforcer_pathClose(
+		m.AllowBufs,
 		m.Bufk,
 		m.Bufs,
 		m.BufsOff,
 		m.Events,
+		m.KubearmorAllowHits,
 		m.KubearmorContainers,
 	)
 }
@@ -18,6 +18,8 @@ func (be *BPFEnforcer) UpdateHostRules(securityPolicies []tp.HostSecurityPolicy)
 	// Typecast HostSecurityPolicy spec to normal SecurityPolicies
 	for _, secPolicy := range securityPolicies {
 		var hostPolicy tp.SecurityPolicy
+		hostPolicy.Metadata = secPolicy.Metadata
+		hostPolicy.Spec.LogAllowed = secPolicy.Spec.LogAllowed
 		if err := kl.Clone(secPolicy.Spec.Process, &hostPolicy.Spec.Process); err != nil {
 			be.Logger.Warnf("Error cloning host policy spec process to sec policy construct")
 		}
@@ -29,6 +29,25 @@ type InnerKey struct {
 	Source [256]byte
 }
 
+// ThrottleKey Structure identifies the token bucket of a Throttle rule of a container
+type ThrottleKey struct {
+	Key  NsKey
+	Rule InnerKey
+}
+
+// AllowKey Structure identifies the hits of an Allow rule of a container
+type AllowKey struct {
+	Key  NsKey
+	Rule InnerKey
+}
+
+// AllowHits Structure keeps the hits of an Allow rule of a container last read from the enforcer
+type AllowHits struct {
+	ContainerID string
+	Rule        AllowRule
+	Hits        uint64
+}
+
 // AddContainerIDToMap adds container metadata to Outer eBPF container Map for initialising enforcement tracking and initiates an InnerMap to store the container specific rules
 func (be *BPFEnforcer) AddContainerIDToMap(containerID string, pidns, mntns uint32) {
 	key := NsKey{PidNS: pidns, MntNS: mntns}
@@ -62,6 +81,8 @@ func (be *BPFEnforcer) DeleteContainerIDFromMap(containerID string) {
 			be.Logger.Errf("error deleting container %s from outer map: %s", containerID, err.Error())
 		}
 	}
+	be.updateThrottleRules(be.ContainerMap[containerID].Key, be.ContainerMap[containerID].Rules.ThrottleRules, nil)
+	be.updateAllowRules(containerID, be.ContainerMap[containerID].Key, be.ContainerMap[containerID].Rules.AllowRules, nil)
 	if err := be.ContainerMap[containerID].Map.Close(); err != nil {
 		be.Logger.Errf("error closing container map for %s: %s", containerID, err)
 	}
@@ -4,12 +4,19 @@
 package bpflsm
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/cilium/ebpf"
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	mon "github.com/kubearmor/KubeArmor/KubeArmor/monitor"
 	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	"golang.org/x/sys/unix"
 )
 
 // Bit Flags for Map Rule Mask
@@ -38,6 +45,53 @@ var (
 	NETWHITELIST  = InnerKey{Path: [256]byte{103}}
 )
 
+// OWNERGROUP is the first byte of the key holding the fsGroup which owns the files of ownerOnly rules
+const OWNERGROUP uint8 = 104
+
+// FILELESS is the first byte of the keys of fileless rules (followed by the excepted sources),
+// and of the paths of the events of the fileless executions
+const FILELESS uint8 = 105
+
+// SIGNAL is the first byte of the keys of signal rules, followed by the signal and the target (and its path)
+const SIGNAL uint8 = 106
+
+// Targets of Signal Rules
+const (
+	SIGANY            uint8 = 0
+	SIGPATH           uint8 = 1
+	SIGCROSSCONTAINER uint8 = 2
+	SIGHOST           uint8 = 3
+)
+
+// Bit Flags for Signal Rules (FILE)
+const (
+	SIGEXCLUDESELF         uint8 = 1 << 0
+	SIGEXCLUDECHILDREN     uint8 = 1 << 1
+	SIGSCOPECROSSCONTAINER uint8 = 1 << 2
+	SIGSCOPEHOST           uint8 = 1 << 3
+)
+
+// RUNTIMESOCKET is the first byte of the keys of runtime socket rules, followed by the inode and the device of the socket
+const RUNTIMESOCKET uint8 = 107
+
+// FILEATTR is the first byte of the keys of xattr and immutable rules, followed by the change and the name of the
+// xattr (exact, the namespace with its dot, or nothing for any name), and FILEATTRTARGET of the keys of their targets,
+// followed by the id of the rule (in the PROCESS value of its key) and the file (or the directory with its slash)
+const (
+	FILEATTR       uint8 = 108
+	FILEATTRTARGET uint8 = 109
+)
+
+// Changes of File Attribute Rules
+const (
+	ATTRSETXATTR    uint8 = 1
+	ATTRREMOVEXATTR uint8 = 2
+	ATTRSETFLAGS    uint8 = 3
+)
+
+// CAPTUREWRITES is the key of the containers whose blocked writes are sampled (captureOnBlock rules)
+var CAPTUREWRITES = InnerKey{Path: [256]byte{110}}
+
 // Protocol Identifiers for Network Rules
 var protocols = map[string]uint8{
 	"ICMP":   1,
@@ -51,6 +105,11 @@ var netType = map[string]uint8{
 	"RAW": 3,
 }
 
+// Socket Family Identifiers for Network Rules
+var netFamily = map[string]uint8{
+	"PACKET": unix.AF_PACKET,
+}
+
 // Array Keys for Network Rule Keys
 const (
 	FAMILY   uint8 = 1
@@ -66,6 +125,18 @@ type RuleList struct {
 	ProcWhiteListPosture bool
 	FileWhiteListPosture bool
 	NetWhiteListPosture  bool
+
+	// rates of Throttle rules, whose token buckets are kept by the enforcer
+	ThrottleRules map[InnerKey]ThrottleRate
+
+	// Allow rules of the policies with logAllowed, whose hits are counted by the enforcer
+	AllowRules map[InnerKey]AllowRule
+
+	// key of the fsGroup owning the files of ownerOnly rules
+	OwnerGroupKey *InnerKey
+
+	// the blocked writes are sampled for the captureOnBlock rules
+	CaptureWrites bool
 }
 
 // Init prepares the RuleList object
@@ -78,206 +149,236 @@ func (r *RuleList) Init() {
 
 	r.NetworkRuleList = make(map[InnerKey][2]uint8)
 	r.NetWhiteListPosture = false
+
+	r.ThrottleRules = make(map[InnerKey]ThrottleRate)
+
+	r.AllowRules = make(map[InnerKey]AllowRule)
 }
 
-// UpdateContainerRules updates individual container map with new rules and resolves conflicting rules
-func (be *BPFEnforcer) UpdateContainerRules(id string, securityPolicies []tp.SecurityPolicy, defaultPosture tp.DefaultPosture) {
+// ThrottleRate Structure contains the executions per minute and the bucket size of a Throttle rule
+type ThrottleRate struct {
+	Rate  uint64
+	Burst uint64
+}
 
-	var newrules RuleList
+// throttlePeriod is the period of the rates of the Throttle rules, a token costs as many credits as its nanoseconds
+const throttlePeriod = uint64(time.Minute)
 
-	newrules.Init()
+// newThrottleRate returns the rate of a Throttle rule (bursting up to the rate if no burst is given)
+func newThrottleRate(rate, burst int) ThrottleRate {
+	if burst <= 0 {
+		burst = rate
+	}
+	return ThrottleRate{Rate: uint64(rate), Burst: uint64(burst)}
+}
 
-	// Generate Fresh Rule Set based on Updated Security Policies
-	for _, secPolicy := range securityPolicies {
-		for _, path := range secPolicy.Spec.Process.MatchPaths {
+// AllowRule Structure identifies an Allow rule of a policy with logAllowed, as reported by the allow telemetry
+type AllowRule struct {
+	PolicyName string
+	Operation  string
+	Rule       string
 
-			var val [2]uint8
-			val[PROCESS] = val[PROCESS] | EXEC
-			if path.OwnerOnly {
-				val[PROCESS] = val[PROCESS] | OWNER
-			}
-			if len(path.FromSource) == 0 {
-				var key InnerKey
-				copy(key.Path[:], []byte(path.Path))
-				if path.Action == "Allow" {
-					if defaultPosture.FileAction == "block" {
-						newrules.ProcWhiteListPosture = true
-					}
-					newrules.ProcessRuleList[key] = val
-				} else if path.Action == "Block" {
-					val[PROCESS] = val[PROCESS] | DENY
-					newrules.ProcessRuleList[key] = val
-				}
-			} else {
-				for _, src := range path.FromSource {
-					var key InnerKey
-					copy(key.Path[:], []byte(path.Path))
-					copy(key.Source[:], []byte(src.Path))
-					if path.Action == "Allow" {
-						if defaultPosture.FileAction == "block" {
-							newrules.ProcWhiteListPosture = true
-						}
-						newrules.ProcessRuleList[key] = val
-					} else if path.Action == "Block" {
-						val[PROCESS] = val[PROCESS] | DENY
-						newrules.ProcessRuleList[key] = val
-					}
-				}
-			}
+	// id of the hits of the rule in the enforcer, given once programmed
+	ID uint32
+}
+
+// newAllowRule returns the Allow rule of an effective rule, named as the feeder names the rules it matches
+func newAllowRule(rule tp.EffectiveRule, operation, resource string) AllowRule {
+	if rule.Source != "" {
+		resource = resource + " fromSource=" + rule.Source
+	}
+
+	// namespace/policy
+	policyName := rule.Policy[strings.LastIndex(rule.Policy, "/")+1:]
+
+	return AllowRule{PolicyName: policyName, Operation: operation, Rule: resource}
+}
+
+// signalToMap adds the keys of a signal rule for each signal, target and source
+func signalToMap(sig tp.ProcessSignalType, m map[InnerKey][2]uint8) {
+	var flags uint8
+	if sig.ExcludeSelf {
+		flags = flags | SIGEXCLUDESELF
+	}
+	if sig.ExcludeChildren {
+		flags = flags | SIGEXCLUDECHILDREN
+	}
+
+	target := SIGANY
+	switch sig.Scope {
+	case "cross-container":
+		target = SIGCROSSCONTAINER
+		flags = flags | SIGSCOPECROSSCONTAINER
+	case "host":
+		target = SIGHOST
+		flags = flags | SIGSCOPEHOST
+	}
+
+	keys := []InnerKey{}
+	for _, path := range sig.Target {
+		if len(path.Path) == 0 {
+			continue
 		}
+		key := InnerKey{Path: [256]byte{SIGNAL, 0, SIGPATH}}
+		copy(key.Path[3:], []byte(path.Path))
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		keys = append(keys, InnerKey{Path: [256]byte{SIGNAL, 0, target}})
+	}
 
-		for _, dir := range secPolicy.Spec.Process.MatchDirectories {
-			var val [2]uint8
-			val[PROCESS] = val[PROCESS] | EXEC
-			if dir.OwnerOnly {
-				val[PROCESS] = val[PROCESS] | OWNER
-			}
-			if dir.Recursive {
-				val[PROCESS] = val[PROCESS] | RECURSIVE
-			}
-			if len(dir.FromSource) == 0 {
-				if dir.Action == "Allow" {
-					if defaultPosture.FileAction == "block" {
-						newrules.ProcWhiteListPosture = true
-					}
-					dirtoMap(PROCESS, dir.Directory, "", newrules.ProcessRuleList, val)
-				} else if dir.Action == "Block" {
-					val[PROCESS] = val[PROCESS] | DENY
-					dirtoMap(PROCESS, dir.Directory, "", newrules.ProcessRuleList, val)
-				}
-			} else {
-				for _, src := range dir.FromSource {
-					if dir.Action == "Allow" {
-						if defaultPosture.FileAction == "block" {
-							newrules.ProcWhiteListPosture = true
-						}
-						dirtoMap(PROCESS, dir.Directory, src.Path, newrules.ProcessRuleList, val)
-					} else if dir.Action == "Block" {
-						val[PROCESS] = val[PROCESS] | DENY
-						dirtoMap(PROCESS, dir.Directory, src.Path, newrules.ProcessRuleList, val)
-					}
-				}
-			}
+	for _, signal := range sig.Signals {
+		num, ok := kl.GetSignalNumber(signal)
+		if !ok {
+			continue
 		}
 
-		for _, path := range secPolicy.Spec.File.MatchPaths {
-			var val [2]uint8
-			val[FILE] = val[FILE] | READ
-			if path.OwnerOnly {
-				val[FILE] = val[FILE] | OWNER
-			}
-			if !path.ReadOnly {
-				val[FILE] = val[FILE] | WRITE
+		for _, key := range keys {
+			key.Path[1] = uint8(num)
+
+			if len(sig.FromSource) == 0 {
+				m[key] = [2]uint8{DENY, flags}
+				continue
 			}
-			if len(path.FromSource) == 0 {
-				var key InnerKey
-				copy(key.Path[:], []byte(path.Path))
-				if path.Action == "Allow" {
-					if defaultPosture.FileAction == "block" {
-						newrules.FileWhiteListPosture = true
-					}
-					newrules.FileRuleList[key] = val
-				} else if path.Action == "Block" {
-					val[FILE] = val[FILE] | DENY
-					newrules.FileRuleList[key] = val
-				}
-			} else {
-				for _, src := range path.FromSource {
-					var key InnerKey
-					copy(key.Path[:], []byte(path.Path))
-					copy(key.Source[:], []byte(src.Path))
-					if path.Action == "Allow" {
-						if defaultPosture.FileAction == "block" {
-							newrules.FileWhiteListPosture = true
-						}
-						newrules.FileRuleList[key] = val
-					} else if path.Action == "Block" {
-						val[FILE] = val[FILE] | DENY
-						newrules.FileRuleList[key] = val
-					}
+
+			for _, src := range sig.FromSource {
+				if len(src.Path) == 0 {
+					continue
 				}
+				srcKey := key
+				copy(srcKey.Source[:], []byte(src.Path))
+				m[srcKey] = [2]uint8{DENY, flags}
 			}
 		}
+	}
+}
 
-		for _, dir := range secPolicy.Spec.File.MatchDirectories {
-			var val [2]uint8
-			val[FILE] = val[FILE] | READ
-			if dir.OwnerOnly {
-				val[FILE] = val[FILE] | OWNER
-			}
-			if !dir.ReadOnly {
-				val[FILE] = val[FILE] | WRITE
-			}
-			if dir.Recursive {
-				val[FILE] = val[FILE] | RECURSIVE
+// fileAttributeToMap adds the keys of a rule of file attributes for each change and source, denying the changes of
+// any file, or of the targets with their own keys and the id of the key of the rule (one of up to 255 ids)
+func fileAttributeToMap(changes []uint8, name, target string, sources []tp.MatchSourceType, m map[InnerKey][2]uint8, ids *uint8) {
+	keys := []InnerKey{}
+	for _, change := range changes {
+		key := InnerKey{Path: [256]byte{FILEATTR, change}}
+		copy(key.Path[2:255], []byte(name))
+
+		if len(sources) == 0 {
+			keys = append(keys, key)
+			continue
+		}
+
+		for _, src := range sources {
+			if len(src.Path) == 0 {
+				continue
 			}
-			if len(dir.FromSource) == 0 {
-				if dir.Action == "Allow" {
-					if defaultPosture.FileAction == "block" {
-						newrules.FileWhiteListPosture = true
-					}
-					dirtoMap(FILE, dir.Directory, "", newrules.FileRuleList, val)
-				} else if dir.Action == "Block" {
-					val[FILE] = val[FILE] | DENY
-					dirtoMap(FILE, dir.Directory, "", newrules.FileRuleList, val)
-				}
-			} else {
-				for _, src := range dir.FromSource {
-					if dir.Action == "Allow" {
-						if defaultPosture.FileAction == "block" {
-							newrules.FileWhiteListPosture = true
-						}
-						dirtoMap(FILE, dir.Directory, src.Path, newrules.FileRuleList, val)
-					} else if dir.Action == "Block" {
-						val[FILE] = val[FILE] | DENY
-						dirtoMap(FILE, dir.Directory, src.Path, newrules.FileRuleList, val)
-					}
-				}
+			srcKey := key
+			copy(srcKey.Source[:], []byte(src.Path))
+			keys = append(keys, srcKey)
+		}
+	}
+
+	for _, key := range keys {
+		val := m[key]
+
+		if target == "" {
+			val[FILE] = val[FILE] | DENY
+			m[key] = val
+			continue
+		}
+
+		if val[PROCESS] == 0 {
+			if *ids == 255 {
+				continue
 			}
+			*ids++
+			val[PROCESS] = *ids
+			m[key] = val
 		}
 
-		for _, net := range secPolicy.Spec.Network.MatchProtocols {
-			var val [2]uint8
-			var key = InnerKey{Path: [256]byte{}, Source: [256]byte{}}
-			if val, ok := protocols[strings.ToUpper(net.Protocol)]; ok {
-				key.Path[0] = PROTOCOL
-				key.Path[1] = val
-			} else if val, ok := netType[strings.ToUpper(net.Protocol)]; ok {
-				key.Path[0] = TYPE
-				key.Path[1] = val
-			}
-
-			if len(net.FromSource) == 0 {
-				if net.Action == "Allow" {
-					if defaultPosture.NetworkAction == "block" {
-						newrules.NetWhiteListPosture = true
-					}
-					newrules.NetworkRuleList[key] = val
-				} else if net.Action == "Block" {
-					val[NETWORK] = val[NETWORK] | DENY
-					newrules.NetworkRuleList[key] = val
-				}
-			} else {
-				for _, src := range net.FromSource {
-					var source [256]byte
-					copy(source[:], []byte(src.Path))
-					key.Source = source
-					if net.Action == "Allow" {
-						if defaultPosture.NetworkAction == "block" {
-							newrules.NetWhiteListPosture = true
-						}
-						newrules.NetworkRuleList[key] = val
-					} else if net.Action == "Block" {
-						val[NETWORK] = val[NETWORK] | DENY
-						newrules.NetworkRuleList[key] = val
-					}
+		targetKey := InnerKey{Path: [256]byte{FILEATTRTARGET, val[PROCESS]}}
+		copy(targetKey.Path[2:255], []byte(target))
+		m[targetKey] = [2]uint8{0, DENY}
+	}
+}
 
-				}
+// xattrChanges returns the changes of the operations of an xattr rule (all without operations)
+func xattrChanges(operations []string) []uint8 {
+	if len(operations) == 0 {
+		return []uint8{ATTRSETXATTR, ATTRREMOVEXATTR}
+	}
+
+	changes := []uint8{}
+	for _, operation := range operations {
+		switch operation {
+		case "set":
+			changes = append(changes, ATTRSETXATTR)
+		case "remove":
+			changes = append(changes, ATTRREMOVEXATTR)
+		}
+	}
+	return changes
+}
+
+// kernelDev converts the device number of stat into the one of the kernel (MKDEV)
+func kernelDev(dev uint64) uint32 {
+	return unix.Major(dev)<<20 | unix.Minor(dev)
+}
+
+// runtimeSocketToMap adds the keys of a runtime socket rule for each socket and source
+func runtimeSocketToMap(sock tp.NetworkRuntimeSocketType, sockets map[mon.SocketInode]string, m map[InnerKey][2]uint8) {
+	for inode := range sockets {
+		key := InnerKey{Path: [256]byte{RUNTIMESOCKET}}
+		binary.LittleEndian.PutUint64(key.Path[1:9], inode.Ino)
+		binary.LittleEndian.PutUint32(key.Path[9:13], kernelDev(inode.Dev))
+
+		if len(sock.FromSource) == 0 {
+			m[key] = [2]uint8{DENY}
+			continue
+		}
+
+		for _, src := range sock.FromSource {
+			if len(src.Path) == 0 {
+				continue
 			}
+			srcKey := key
+			copy(srcKey.Source[:], []byte(src.Path))
+			m[srcKey] = [2]uint8{DENY}
 		}
 	}
+}
 
-	fuseProcAndFileRules(newrules.ProcessRuleList, newrules.FileRuleList)
+// runtimeSockets returns the sockets of the container runtimes to deny to a container, none to the container of the
+// daemon, which is identified by its namespaces
+func (be *BPFEnforcer) runtimeSockets(id string) map[mon.SocketInode]string {
+	if be.Monitor == nil || be.Monitor.RuntimeSockets == nil {
+		return nil
+	}
+
+	be.ContainerMapLock.RLock()
+	key := be.ContainerMap[id].Key
+	be.ContainerMapLock.RUnlock()
+
+	if daemon := be.Monitor.Daemon; daemon.PidNS != 0 && key == (NsKey{PidNS: daemon.PidNS, MntNS: daemon.MntNS}) {
+		return nil
+	}
+
+	return be.Monitor.RuntimeSockets.Inodes()
+}
+
+// UpdateContainerRules updates individual container map with new rules and resolves conflicting rules
+func (be *BPFEnforcer) UpdateContainerRules(id string, securityPolicies []tp.SecurityPolicy, defaultPosture tp.DefaultPosture) error {
+	_, err := be.updateContainerRules(id, securityPolicies, defaultPosture)
+	return err
+}
+
+// updateContainerRules updates the rules of a container, and returns the time spent on generating the rules and updating the map
+func (be *BPFEnforcer) updateContainerRules(id string, securityPolicies []tp.SecurityPolicy, defaultPosture tp.DefaultPosture) (fd.PolicyApplyTimes, error) {
+	times := fd.PolicyApplyTimes{}
+
+	start := time.Now()
+
+	newrules := GenerateContainerRules(securityPolicies, defaultPosture, be.runtimeSockets(id))
+
+	times.Generate = time.Since(start)
 
 	be.ContainerMapLock.Lock()
 	defer be.ContainerMapLock.Unlock()
@@ -286,9 +387,14 @@ func (be *BPFEnforcer) UpdateContainerRules(id string, securityPolicies []tp.Sec
 	if _, ok := be.ContainerMap[id]; !ok {
 		// It maybe possible that CRI has unregistered the containers but K8s construct still has not sent this update while the policy was being applied,
 		// so the need to check if the container is present in the map before we apply policy.
-		return
+		return times, nil
 	}
 
+	start = time.Now()
+
+	// the first error of the updates of the map, the rules are put again in the next update
+	var putErr error
+
 	// Check for differences in Fresh Rules Set and Existing Ruleset
 	be.resolveConflicts(newrules.ProcWhiteListPosture, be.ContainerMap[id].Rules.ProcWhiteListPosture, newrules.ProcessRuleList, be.ContainerMap[id].Rules.ProcessRuleList, be.ContainerMap[id].Map)
 	be.resolveConflicts(newrules.FileWhiteListPosture, be.ContainerMap[id].Rules.FileWhiteListPosture, newrules.FileRuleList, be.ContainerMap[id].Rules.FileRuleList, be.ContainerMap[id].Map)
@@ -299,6 +405,10 @@ func (be *BPFEnforcer) UpdateContainerRules(id string, securityPolicies []tp.Sec
 		list.Rules.ProcWhiteListPosture = newrules.ProcWhiteListPosture
 		list.Rules.FileWhiteListPosture = newrules.FileWhiteListPosture
 		list.Rules.NetWhiteListPosture = newrules.NetWhiteListPosture
+		be.updateThrottleRules(list.Key, list.Rules.ThrottleRules, newrules.ThrottleRules)
+		list.Rules.ThrottleRules = newrules.ThrottleRules
+		be.updateAllowRules(id, list.Key, list.Rules.AllowRules, newrules.AllowRules)
+		list.Rules.AllowRules = newrules.AllowRules
 
 		be.ContainerMap[id] = list
 	}
@@ -306,6 +416,9 @@ func (be *BPFEnforcer) UpdateContainerRules(id string, securityPolicies []tp.Sec
 	if newrules.ProcWhiteListPosture {
 		if err := be.ContainerMap[id].Map.Put(PROCWHITELIST, [2]uint8{}); err != nil {
 			be.Logger.Errf("error adding proc whitelist key rule to map for container %s: %s", id, err)
+			if putErr == nil {
+				putErr = err
+			}
 		}
 	} else {
 		if err := be.ContainerMap[id].Map.Delete(PROCWHITELIST); err != nil {
@@ -318,12 +431,18 @@ func (be *BPFEnforcer) UpdateContainerRules(id string, securityPolicies []tp.Sec
 		be.ContainerMap[id].Rules.ProcessRuleList[key] = val
 		if err := be.ContainerMap[id].Map.Put(key, val); err != nil {
 			be.Logger.Errf("error adding rule to map for container %s: %s", id, err)
+			if putErr == nil {
+				putErr = err
+			}
 		}
 	}
 
 	if newrules.FileWhiteListPosture {
 		if err := be.ContainerMap[id].Map.Put(FILEWHITELIST, [2]uint8{}); err != nil {
 			be.Logger.Errf("error adding file whitelist key rule to map for container %s: %s", id, err)
+			if putErr == nil {
+				putErr = err
+			}
 		}
 	} else {
 		if err := be.ContainerMap[id].Map.Delete(FILEWHITELIST); err != nil {
@@ -336,12 +455,33 @@ func (be *BPFEnforcer) UpdateContainerRules(id string, securityPolicies []tp.Sec
 		be.ContainerMap[id].Rules.FileRuleList[key] = val
 		if err := be.ContainerMap[id].Map.Put(key, val); err != nil {
 			be.Logger.Errf("error adding rule to map for container %s: %s", id, err)
+			if putErr == nil {
+				putErr = err
+			}
+		}
+	}
+
+	if newrules.CaptureWrites {
+		if err := be.ContainerMap[id].Map.Put(CAPTUREWRITES, [2]uint8{}); err != nil {
+			be.Logger.Errf("error adding capture key rule to map for container %s: %s", id, err)
+			if putErr == nil {
+				putErr = err
+			}
+		}
+	} else {
+		if err := be.ContainerMap[id].Map.Delete(CAPTUREWRITES); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				be.Logger.Err(err.Error())
+			}
 		}
 	}
 
 	if newrules.NetWhiteListPosture {
 		if err := be.ContainerMap[id].Map.Put(NETWHITELIST, [2]uint8{}); err != nil {
 			be.Logger.Errf("error adding network key rule to map for container %s: %s", id, err)
+			if putErr == nil {
+				putErr = err
+			}
 		}
 	} else {
 		if err := be.ContainerMap[id].Map.Delete(NETWHITELIST); err != nil {
@@ -354,7 +494,274 @@ func (be *BPFEnforcer) UpdateContainerRules(id string, securityPolicies []tp.Sec
 		be.ContainerMap[id].Rules.NetworkRuleList[key] = val
 		if err := be.ContainerMap[id].Map.Put(key, val); err != nil {
 			be.Logger.Errf("error adding rule to map for container %s: %s", id, err)
+			if putErr == nil {
+				putErr = err
+			}
+		}
+	}
+
+	times.Load = time.Since(start)
+
+	return times, putErr
+}
+
+// GenerateContainerRules generates the rules of a container from its security policies, the connections to the given
+// runtime sockets being matched by the runtime socket rules
+func GenerateContainerRules(securityPolicies []tp.SecurityPolicy, defaultPosture tp.DefaultPosture, sockets map[mon.SocketInode]string) RuleList {
+	var newrules RuleList
+
+	newrules.Init()
+
+	// Generate Fresh Rule Set based on the merged rules of the Security Policies
+	effective := fd.MergeSecurityPolicies(securityPolicies)
+
+	for _, rule := range effective.Rules {
+		var key InnerKey
+		copy(key.Source[:], []byte(rule.Source))
+
+		switch rule.Kind {
+		case "processPath", "processDirectory":
+			// the allow-list is kept with the Allow rules overridden for some entities
+			if rule.Action == "Allow" && defaultPosture.FileAction == "block" {
+				newrules.ProcWhiteListPosture = true
+			}
+			if rule.Overridden {
+				continue
+			}
+
+			var val [2]uint8
+			val[PROCESS] = val[PROCESS] | EXEC
+			if rule.OwnerOnly {
+				val[PROCESS] = val[PROCESS] | OWNER
+			}
+			if rule.Recursive {
+				val[PROCESS] = val[PROCESS] | RECURSIVE
+			}
+
+			if rule.Action == "Block" {
+				val[PROCESS] = val[PROCESS] | DENY
+			} else if rule.Action != "Allow" && rule.Action != "Throttle" {
+				continue
+			}
+
+			// the hits of the Allow rules are counted with the key of the file or of the directory
+			copy(key.Path[:], []byte(rule.Entity))
+			if rule.LogAllowed {
+				newrules.AllowRules[key] = newAllowRule(rule, "Process", rule.Entity)
+			}
+
+			if rule.Kind == "processDirectory" {
+				if rule.Action != "Throttle" {
+					dirtoMap(PROCESS, rule.Entity, rule.Source, newrules.ProcessRuleList, val)
+				}
+				continue
+			}
+
+			newrules.ProcessRuleList[key] = val
+
+			// allowed until the token bucket of the rule is empty
+			if rule.Action == "Throttle" {
+				newrules.ThrottleRules[key] = newThrottleRate(rule.Rate, rule.Burst)
+			}
+
+		case "filePath", "fileDirectory":
+			// the exemptions of KubeArmor from the self-protection policy are kept out of the allow-list
+			if rule.Action == "Allow" && defaultPosture.FileAction == "block" && rule.Policy != tp.SelfProtectionPolicyName {
+				newrules.FileWhiteListPosture = true
+			}
+			if rule.Overridden {
+				continue
+			}
+
+			var val [2]uint8
+			val[FILE] = val[FILE] | READ
+			if rule.OwnerOnly {
+				val[FILE] = val[FILE] | OWNER
+			}
+			if !rule.ReadOnly {
+				val[FILE] = val[FILE] | WRITE
+			}
+			if rule.Recursive {
+				val[FILE] = val[FILE] | RECURSIVE
+			}
+
+			if rule.Action == "Block" {
+				val[FILE] = val[FILE] | DENY
+				if rule.CaptureOnBlock {
+					newrules.CaptureWrites = true
+				}
+			} else if rule.Action != "Allow" {
+				continue
+			}
+
+			copy(key.Path[:], []byte(rule.Entity))
+			if rule.LogAllowed {
+				newrules.AllowRules[key] = newAllowRule(rule, "File", rule.Entity)
+			}
+
+			if rule.Kind == "fileDirectory" {
+				dirtoMap(FILE, rule.Entity, rule.Source, newrules.FileRuleList, val)
+				continue
+			}
+
+			newrules.FileRuleList[key] = val
+
+		case "networkProtocol":
+			if proto, ok := protocols[strings.ToUpper(rule.Entity)]; ok {
+				key.Path[0] = PROTOCOL
+				key.Path[1] = proto
+			} else if sockType, ok := netType[strings.ToUpper(rule.Entity)]; ok {
+				key.Path[0] = TYPE
+				key.Path[1] = sockType
+			} else if family, ok := netFamily[strings.ToUpper(rule.Entity)]; ok {
+				key.Path[0] = FAMILY
+				key.Path[1] = family
+			} else {
+				continue
+			}
+
+			if rule.Action == "Allow" && defaultPosture.NetworkAction == "block" {
+				newrules.NetWhiteListPosture = true
+			}
+			if rule.Overridden {
+				continue
+			}
+
+			var val [2]uint8
+			if rule.Action == "Block" {
+				val[NETWORK] = val[NETWORK] | DENY
+			} else if rule.Action != "Allow" {
+				continue
+			}
+
+			newrules.NetworkRuleList[key] = val
+
+			if rule.LogAllowed {
+				newrules.AllowRules[key] = newAllowRule(rule, "Network", fd.GetProtocolFromName(rule.Entity))
+			}
+		}
+	}
+
+	// the ids of the xattr and immutable rules with targets
+	var fileAttrIDs uint8
+
+	for _, secPolicy := range securityPolicies {
+		// fileless executions are denied with the key of the rule, except from the sources with their own keys
+		if fileless := secPolicy.Spec.Process.BlockFileless; fileless != nil && fileless.Action == "Block" {
+			key := InnerKey{Path: [256]byte{FILELESS}}
+			newrules.ProcessRuleList[key] = [2]uint8{EXEC | DENY}
+
+			for _, src := range fileless.ExceptFromSource {
+				if len(src.Path) == 0 {
+					continue
+				}
+				srcKey := InnerKey{Path: [256]byte{FILELESS}}
+				copy(srcKey.Source[:], []byte(src.Path))
+				newrules.ProcessRuleList[srcKey] = [2]uint8{EXEC}
+			}
+		}
+
+		// signals are denied with the keys of the signal, the target and the source
+		for _, sig := range secPolicy.Spec.Process.MatchSignals {
+			if sig.Action == "Block" {
+				signalToMap(sig, newrules.ProcessRuleList)
+			}
+		}
+
+		// connections to the runtime sockets are denied with the keys of their inodes and the sources
+		for _, sock := range secPolicy.Spec.Network.MatchRuntimeSockets {
+			if sock.Action == "Block" {
+				runtimeSocketToMap(sock, sockets, newrules.ProcessRuleList)
+			}
+		}
+
+		// changes of xattrs and inode flags are denied with the keys of the change, the name and the source, and of the
+		// targets of the rule
+		for _, xattr := range secPolicy.Spec.File.MatchXattrs {
+			if xattr.Action != "Block" {
+				continue
+			}
+			// rejected by the validation of the policies
+			name, ok := fd.XattrNameKey(xattr.Name)
+			if !ok {
+				continue
+			}
+			fileAttributeToMap(xattrChanges(xattr.Operations), name, xattr.Path, xattr.FromSource, newrules.FileRuleList, &fileAttrIDs)
+		}
+		for _, imm := range secPolicy.Spec.File.MatchImmutable {
+			if imm.Action == "Block" {
+				fileAttributeToMap([]uint8{ATTRSETFLAGS}, "", imm.Path, imm.FromSource, newrules.FileRuleList, &fileAttrIDs)
+			}
+		}
+	}
+
+	fuseProcAndFileRules(newrules.ProcessRuleList, newrules.FileRuleList)
+
+	return newrules
+}
+
+// updateThrottleRules programs the token buckets of the Throttle rules of a container, the buckets of the rules
+// whose rates are unchanged keep their tokens
+func (be *BPFEnforcer) updateThrottleRules(key NsKey, oldRules, newRules map[InnerKey]ThrottleRate) {
+	if be.obj.KubearmorThrottle == nil {
+		return
+	}
+
+	for rule, rate := range oldRules {
+		if newRate, ok := newRules[rule]; ok && newRate == rate {
+			continue
+		}
+		if err := be.obj.KubearmorThrottle.Delete(ThrottleKey{Key: key, Rule: rule}); err != nil && !errors.Is(err, os.ErrNotExist) {
+			be.Logger.Errf("error deleting throttle rule %s from map: %s", string(bytes.Trim(rule.Path[:], "\x00")), err)
+		}
+	}
+
+	for rule, rate := range newRules {
+		if oldRate, ok := oldRules[rule]; ok && oldRate == rate {
+			continue
+		}
+
+		// a new bucket is full
+		bucket := enforcerThrottleBucket{Rate: rate.Rate, Burst: rate.Burst, Credits: int64(rate.Burst * throttlePeriod)}
+		if err := be.obj.KubearmorThrottle.Put(ThrottleKey{Key: key, Rule: rule}, bucket); err != nil {
+			be.Logger.Errf("error adding throttle rule %s to map: %s", string(bytes.Trim(rule.Path[:], "\x00")), err)
+		}
+	}
+}
+
+// updateAllowRules programs the hits of the Allow rules of a container, the hits of the unchanged rules are kept
+// (the lock of the container map should be held)
+func (be *BPFEnforcer) updateAllowRules(id string, key NsKey, oldRules, newRules map[InnerKey]AllowRule) {
+	if be.obj.KubearmorAllowHits == nil {
+		return
+	}
+
+	for rule, old := range oldRules {
+		if newRule, ok := newRules[rule]; ok && newRule.PolicyName == old.PolicyName && newRule.Operation == old.Operation && newRule.Rule == old.Rule {
+			newRule.ID = old.ID
+			newRules[rule] = newRule
+			continue
+		}
+		if err := be.obj.KubearmorAllowHits.Delete(AllowKey{Key: key, Rule: rule}); err != nil && !errors.Is(err, os.ErrNotExist) {
+			be.Logger.Errf("error deleting allow rule %s from map: %s", old.Rule, err)
 		}
+		delete(be.AllowHits, old.ID)
+	}
+
+	for rule, newRule := range newRules {
+		if newRule.ID != 0 {
+			continue
+		}
+
+		be.lastAllowRuleID++
+		newRule.ID = be.lastAllowRuleID
+		newRules[rule] = newRule
+
+		if err := be.obj.KubearmorAllowHits.Put(AllowKey{Key: key, Rule: rule}, enforcerAllowHits{Id: newRule.ID}); err != nil {
+			be.Logger.Errf("error adding allow rule %s to map: %s", newRule.Rule, err)
+			continue
+		}
+		be.AllowHits[newRule.ID] = &AllowHits{ContainerID: id, Rule: newRule}
 	}
 }
 
@@ -424,3 +831,52 @@ func dirtoMap(idx int, p, src string, m map[InnerKey][2]uint8, val [2]uint8) {
 		m[key] = val
 	}
 }
+
+// ownerGroupKey returns the key of an fsGroup (the gid in little endian)
+func ownerGroupKey(gid uint32) InnerKey {
+	key := InnerKey{}
+	key.Path[0] = OWNERGROUP
+	key.Path[1] = uint8(gid)
+	key.Path[2] = uint8(gid >> 8)
+	key.Path[3] = uint8(gid >> 16)
+	key.Path[4] = uint8(gid >> 24)
+	return key
+}
+
+// getOwnerGroup returns the fsGroup of an endpoint if any of its ownerOnly rules uses the pod identity
+func getOwnerGroup(endPoint tp.EndPoint) (uint32, bool) {
+	if endPoint.SecurityContext.FsGroup == nil || !fd.UsesPodOwnerIdentity(endPoint.SecurityPolicies) {
+		return 0, false
+	}
+
+	return uint32(*endPoint.SecurityContext.FsGroup), true
+}
+
+// UpdateOwnerGroup sets (or removes) the fsGroup owning the files of the ownerOnly rules of a container
+func (be *BPFEnforcer) UpdateOwnerGroup(id string, gid uint32, ok bool) {
+	be.ContainerMapLock.Lock()
+	defer be.ContainerMapLock.Unlock()
+
+	kv, exists := be.ContainerMap[id]
+	if !exists {
+		return
+	}
+
+	if prev := kv.Rules.OwnerGroupKey; prev != nil && (!ok || *prev != ownerGroupKey(gid)) {
+		if err := kv.Map.Delete(*prev); err != nil && !errors.Is(err, os.ErrNotExist) {
+			be.Logger.Err(err.Error())
+		}
+		kv.Rules.OwnerGroupKey = nil
+	}
+
+	if ok {
+		key := ownerGroupKey(gid)
+		if err := kv.Map.Put(key, [2]uint8{}); err != nil {
+			be.Logger.Errf("error adding owner group key to map for container %s: %s", id, err)
+		} else {
+			kv.Rules.OwnerGroupKey = &key
+		}
+	}
+
+	be.ContainerMap[id] = kv
+}
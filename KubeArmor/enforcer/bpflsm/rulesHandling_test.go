@@ -0,0 +1,612 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package bpflsm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	mon "github.com/kubearmor/KubeArmor/KubeArmor/monitor"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestThrottleRules(t *testing.T) {
+	be := &BPFEnforcer{}
+
+	be.InnerMapSpec = &ebpf.MapSpec{
+		Type:       ebpf.Hash,
+		KeySize:    512,
+		ValueSize:  2,
+		MaxEntries: 256,
+	}
+
+	im, err := ebpf.NewMap(be.InnerMapSpec)
+	if err != nil {
+		t.Skipf("Skipped as BPF maps are not available (%s)", err.Error())
+	}
+	defer im.Close()
+
+	tm, err := ebpf.NewMap(&ebpf.MapSpec{
+		Type:       ebpf.Hash,
+		KeySize:    uint32(binary.Size(ThrottleKey{})),
+		ValueSize:  uint32(binary.Size(enforcerThrottleBucket{})),
+		MaxEntries: 16,
+	})
+	if err != nil {
+		t.Skipf("Skipped as BPF maps are not available (%s)", err.Error())
+	}
+	defer tm.Close()
+
+	be.obj.KubearmorThrottle = tm
+
+	var rules RuleList
+	rules.Init()
+
+	be.ContainerMap = map[string]ContainerKV{"web": {Key: NsKey{PidNS: 1, MntNS: 2}, Map: im, Rules: rules}}
+	be.ContainerMapLock = new(sync.RWMutex)
+
+	policy := tp.SecurityPolicy{}
+	policy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/usr/bin/curl", Action: "Throttle", Rate: 5}}
+
+	var key InnerKey
+	copy(key.Path[:], []byte("/usr/bin/curl"))
+
+	bucketKey := ThrottleKey{Key: NsKey{PidNS: 1, MntNS: 2}, Rule: key}
+
+	// programmed as allowed executions, without turning the posture into an allowlist
+	be.UpdateContainerRules("web", []tp.SecurityPolicy{policy}, tp.DefaultPosture{FileAction: "block"})
+
+	var val [2]uint8
+	if err := im.Lookup(key, &val); err != nil || val[PROCESS] != EXEC {
+		t.Errorf("[FAIL] Unexpected value of a throttle rule (%08b)", val[PROCESS])
+	}
+	if be.ContainerMap["web"].Rules.ProcWhiteListPosture {
+		t.Errorf("[FAIL] Unexpected allowlist posture for a throttle rule")
+	}
+
+	// a full bucket of the container, bursting up to the rate
+	var bucket enforcerThrottleBucket
+	if err := tm.Lookup(bucketKey, &bucket); err != nil {
+		t.Fatalf("[FAIL] Failed to look up the bucket of the throttle rule (%s)", err.Error())
+	}
+	if bucket.Rate != 5 || bucket.Burst != 5 || bucket.Credits != int64(5*throttlePeriod) {
+		t.Errorf("[FAIL] Unexpected bucket of a throttle rule (%+v)", bucket)
+	}
+
+	// the tokens consumed by the enforcer are kept on policy updates
+	bucket.Credits = 0
+	if err := tm.Put(bucketKey, bucket); err != nil {
+		t.Fatalf("[FAIL] Failed to update the bucket (%s)", err.Error())
+	}
+
+	be.UpdateContainerRules("web", []tp.SecurityPolicy{policy}, tp.DefaultPosture{FileAction: "block"})
+
+	if err := tm.Lookup(bucketKey, &bucket); err != nil || bucket.Credits != 0 {
+		t.Errorf("[FAIL] Expected the bucket to be kept after a policy update (%+v)", bucket)
+	}
+
+	// a new bucket once the rate is changed
+	policy.Spec.Process.MatchPaths[0].Burst = 2
+
+	be.UpdateContainerRules("web", []tp.SecurityPolicy{policy}, tp.DefaultPosture{FileAction: "block"})
+
+	if err := tm.Lookup(bucketKey, &bucket); err != nil || bucket.Burst != 2 || bucket.Credits != int64(2*throttlePeriod) {
+		t.Errorf("[FAIL] Expected a new bucket after the rate was changed (%+v)", bucket)
+	}
+
+	// removed rules aren't programmed anymore
+	be.UpdateContainerRules("web", []tp.SecurityPolicy{}, tp.DefaultPosture{FileAction: "block"})
+
+	if err := im.Lookup(key, &val); err == nil {
+		t.Errorf("[FAIL] Unexpected throttle rule after the policy was removed (%08b)", val[PROCESS])
+	}
+	if err := tm.Lookup(bucketKey, &bucket); err == nil {
+		t.Errorf("[FAIL] Unexpected bucket after the policy was removed (%+v)", bucket)
+	}
+
+	t.Log("[PASS] Programmed throttle rules")
+}
+
+func TestAllowRules(t *testing.T) {
+	be := &BPFEnforcer{Logger: &feeder.Feeder{Node: &tp.Node{}}}
+	be.Logger.AllowTelemetry = feeder.NewAllowTelemetry(time.Hour)
+
+	be.InnerMapSpec = &ebpf.MapSpec{
+		Type:       ebpf.Hash,
+		KeySize:    512,
+		ValueSize:  2,
+		MaxEntries: 256,
+	}
+
+	im, err := ebpf.NewMap(be.InnerMapSpec)
+	if err != nil {
+		t.Skipf("Skipped as BPF maps are not available (%s)", err.Error())
+	}
+	defer im.Close()
+
+	hm, err := ebpf.NewMap(&ebpf.MapSpec{
+		Type:       ebpf.Hash,
+		KeySize:    uint32(binary.Size(AllowKey{})),
+		ValueSize:  uint32(binary.Size(enforcerAllowHits{})),
+		MaxEntries: 16,
+	})
+	if err != nil {
+		t.Skipf("Skipped as BPF maps are not available (%s)", err.Error())
+	}
+	defer hm.Close()
+
+	be.obj.KubearmorAllowHits = hm
+	be.AllowHits = map[uint32]*AllowHits{}
+
+	var rules RuleList
+	rules.Init()
+
+	be.ContainerMap = map[string]ContainerKV{"web": {Key: NsKey{PidNS: 1, MntNS: 2}, Map: im, Rules: rules}}
+	be.ContainerMapLock = new(sync.RWMutex)
+
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "payments", "policyName": "allow-app"}}
+	policy.Spec.LogAllowed = true
+	policy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/app", Action: "Allow"}}
+
+	quiet := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "payments", "policyName": "allow-shell"}}
+	quiet.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Allow"}}
+
+	var key InnerKey
+	copy(key.Path[:], []byte("/app"))
+
+	hitsKey := AllowKey{Key: NsKey{PidNS: 1, MntNS: 2}, Rule: key}
+
+	// only the Allow rules of the policies with logAllowed are counted
+	be.UpdateContainerRules("web", []tp.SecurityPolicy{policy, quiet}, tp.DefaultPosture{})
+
+	var val enforcerAllowHits
+	if err := hm.Lookup(hitsKey, &val); err != nil || val.Id == 0 {
+		t.Fatalf("[FAIL] Expected the hits of the allow rule (%+v)", val)
+	}
+	if len(be.ContainerMap["web"].Rules.AllowRules) != 1 {
+		t.Errorf("[FAIL] Expected 1 counted allow rule, got %+v", be.ContainerMap["web"].Rules.AllowRules)
+	}
+	id := val.Id
+
+	// the hits counted by the enforcer are recorded as deltas
+	val.Hits = 3
+	if err := hm.Put(hitsKey, val); err != nil {
+		t.Fatalf("[FAIL] Failed to update the hits (%s)", err.Error())
+	}
+
+	be.collectAllowHits()
+	be.collectAllowHits()
+
+	logs := be.Logger.AllowTelemetry.Flush()
+	if len(logs) != 1 || logs[0].PolicyName != "allow-app" || logs[0].Operation != "Process" || logs[0].Resource != "/app" || logs[0].Data != "hits=3" {
+		t.Fatalf("[FAIL] Unexpected telemetry of the allow rule (%+v)", logs)
+	}
+
+	// the counts are kept on policy updates
+	be.UpdateContainerRules("web", []tp.SecurityPolicy{policy, quiet}, tp.DefaultPosture{})
+
+	if err := hm.Lookup(hitsKey, &val); err != nil || val.Id != id || val.Hits != 3 {
+		t.Errorf("[FAIL] Expected the hits to be kept after a policy update (%+v)", val)
+	}
+
+	// removed rules aren't counted anymore
+	be.UpdateContainerRules("web", []tp.SecurityPolicy{quiet}, tp.DefaultPosture{})
+
+	if err := hm.Lookup(hitsKey, &val); err == nil {
+		t.Errorf("[FAIL] Unexpected hits after the policy was removed (%+v)", val)
+	}
+	if len(be.AllowHits) != 0 {
+		t.Errorf("[FAIL] Unexpected allow rules after the policy was removed (%+v)", be.AllowHits)
+	}
+
+	t.Log("[PASS] Counted the hits of allow rules")
+}
+
+func TestOwnerGroup(t *testing.T) {
+	be := &BPFEnforcer{}
+
+	be.InnerMapSpec = &ebpf.MapSpec{
+		Type:       ebpf.Hash,
+		KeySize:    512,
+		ValueSize:  2,
+		MaxEntries: 256,
+	}
+
+	im, err := ebpf.NewMap(be.InnerMapSpec)
+	if err != nil {
+		t.Skipf("Skipped as BPF maps are not available (%s)", err.Error())
+	}
+	defer im.Close()
+
+	var rules RuleList
+	rules.Init()
+
+	be.ContainerMap = map[string]ContainerKV{"web": {Map: im, Rules: rules}}
+	be.ContainerMapLock = new(sync.RWMutex)
+
+	fsGroup := int64(2000)
+
+	policy := tp.SecurityPolicy{}
+	policy.Spec.File.MatchDirectories = []tp.FileDirectoryType{{Directory: "/data/", Recursive: true, OwnerOnly: true}}
+
+	endPoint := tp.EndPoint{Containers: []string{"web"}, SecurityPolicies: []tp.SecurityPolicy{policy}}
+	endPoint.SecurityContext.FsGroup = &fsGroup
+
+	// the fsGroup is kept as a key of the rule map (gid in little endian)
+	gid, ok := getOwnerGroup(endPoint)
+	if !ok || gid != 2000 {
+		t.Fatalf("[FAIL] Expected the fsGroup of the endpoint (%d, %t)", gid, ok)
+	}
+	be.UpdateOwnerGroup("web", gid, ok)
+
+	key := InnerKey{Path: [256]byte{OWNERGROUP, 0xd0, 0x07}}
+
+	var val [2]uint8
+	if err := im.Lookup(key, &val); err != nil {
+		t.Errorf("[FAIL] Expected the owner group key (%s)", err.Error())
+	}
+
+	// policies using the process identity don't need the key
+	endPoint.SecurityPolicies[0].Spec.OwnerIdentity = "Process"
+
+	gid, ok = getOwnerGroup(endPoint)
+	be.UpdateOwnerGroup("web", gid, ok)
+
+	if err := im.Lookup(key, &val); err == nil {
+		t.Errorf("[FAIL] Unexpected owner group key for the process identity")
+	}
+
+	t.Log("[PASS] Programmed the owner group")
+}
+
+func TestUpdateContainerRulesErrors(t *testing.T) {
+	be := &BPFEnforcer{Logger: &feeder.Feeder{Node: &tp.Node{}}}
+
+	feeder.MsgLock = new(sync.RWMutex)
+	feeder.MsgStructs = make(map[string]feeder.MsgStruct)
+
+	// a full rule map
+	be.InnerMapSpec = &ebpf.MapSpec{
+		Type:       ebpf.Hash,
+		KeySize:    512,
+		ValueSize:  2,
+		MaxEntries: 1,
+	}
+
+	im, err := ebpf.NewMap(be.InnerMapSpec)
+	if err != nil {
+		t.Skipf("Skipped as BPF maps are not available (%s)", err.Error())
+	}
+	defer im.Close()
+
+	var rules RuleList
+	rules.Init()
+
+	be.ContainerMap = map[string]ContainerKV{"web": {Map: im, Rules: rules}}
+	be.ContainerMapLock = new(sync.RWMutex)
+
+	policy := tp.SecurityPolicy{}
+	policy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Block"}, {Path: "/bin/bash", Action: "Block"}}
+
+	if err := be.UpdateContainerRules("web", []tp.SecurityPolicy{policy}, tp.DefaultPosture{}); err == nil {
+		t.Errorf("[FAIL] Expected an error from the full rule map")
+	}
+
+	// the rules are put again in the next update
+	be.InnerMapSpec.MaxEntries = 256
+
+	bigger, err := ebpf.NewMap(be.InnerMapSpec)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create a rule map (%s)", err.Error())
+	}
+	defer bigger.Close()
+
+	kv := be.ContainerMap["web"]
+	kv.Map = bigger
+	be.ContainerMap["web"] = kv
+
+	if err := be.UpdateContainerRules("web", []tp.SecurityPolicy{policy}, tp.DefaultPosture{}); err != nil {
+		t.Errorf("[FAIL] Expected the rules to be applied (%s)", err.Error())
+	}
+
+	t.Log("[PASS] Reported the errors of the rule map")
+}
+
+func TestFilelessRules(t *testing.T) {
+	// a synthetic event of a denied memfd execution
+	var raw eventBPF
+	raw.EventID = mon.SecurityBprmCheck
+	raw.Data.Path[0] = FILELESS
+	copy(raw.Data.Path[1:], []byte("/memfd:payload"))
+	copy(raw.Data.Source[:], []byte("/bin/sh"))
+
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, raw); err != nil {
+		t.Fatalf("[FAIL] Failed to encode an event (%s)", err.Error())
+	}
+
+	var event eventBPF
+	if err := binary.Read(buf, binary.LittleEndian, &event); err != nil {
+		t.Fatalf("[FAIL] Failed to decode an event (%s)", err.Error())
+	}
+
+	if event.Data.Path[0] != FILELESS {
+		t.Fatalf("[FAIL] Expected a fileless execution")
+	}
+	if resource, data := getFilelessResource(event.Data.Path, "lsm=SECURITY_BPRM_CHECK"); resource != "/memfd:payload" || data != "lsm=SECURITY_BPRM_CHECK fileless=memfd:payload" {
+		t.Errorf("[FAIL] Unexpected fileless execution (%s, %s)", resource, data)
+	}
+
+	// executions of O_TMPFILE files have no memfd name
+	var tmpfile [256]byte
+	tmpfile[0] = FILELESS
+	copy(tmpfile[1:], []byte("/tmp/#1835021"))
+
+	if _, data := getFilelessResource(tmpfile, "lsm=SECURITY_BPRM_CHECK"); data != "lsm=SECURITY_BPRM_CHECK fileless=anonymous" {
+		t.Errorf("[FAIL] Unexpected anonymous fileless execution (%s)", data)
+	}
+
+	be := &BPFEnforcer{}
+
+	be.InnerMapSpec = &ebpf.MapSpec{
+		Type:       ebpf.Hash,
+		KeySize:    512,
+		ValueSize:  2,
+		MaxEntries: 256,
+	}
+
+	im, err := ebpf.NewMap(be.InnerMapSpec)
+	if err != nil {
+		t.Skipf("Skipped as BPF maps are not available (%s)", err.Error())
+	}
+	defer im.Close()
+
+	var rules RuleList
+	rules.Init()
+
+	be.ContainerMap = map[string]ContainerKV{"web": {Map: im, Rules: rules}}
+	be.ContainerMapLock = new(sync.RWMutex)
+
+	policy := tp.SecurityPolicy{}
+	policy.Spec.Process.BlockFileless = &tp.ProcessFilelessType{ExceptFromSource: []tp.MatchSourceType{{Path: "/usr/bin/runc"}}, Action: "Block"}
+
+	key := InnerKey{Path: [256]byte{FILELESS}}
+	except := InnerKey{Path: [256]byte{FILELESS}}
+	copy(except.Source[:], []byte("/usr/bin/runc"))
+
+	be.UpdateContainerRules("web", []tp.SecurityPolicy{policy}, tp.DefaultPosture{})
+
+	var val [2]uint8
+	if err := im.Lookup(key, &val); err != nil || val[PROCESS] != EXEC|DENY {
+		t.Errorf("[FAIL] Expected the fileless key (%08b)", val[PROCESS])
+	}
+	if err := im.Lookup(except, &val); err != nil || val[PROCESS] != EXEC {
+		t.Errorf("[FAIL] Expected the key of the excepted source (%08b)", val[PROCESS])
+	}
+
+	// audited in the user space only
+	policy.Spec.Process.BlockFileless.Action = "Audit"
+	be.UpdateContainerRules("web", []tp.SecurityPolicy{policy}, tp.DefaultPosture{})
+
+	if err := im.Lookup(key, &val); err == nil {
+		t.Errorf("[FAIL] Unexpected fileless key of an audit rule")
+	}
+	if err := im.Lookup(except, &val); err == nil {
+		t.Errorf("[FAIL] Unexpected key of an excepted source of an audit rule")
+	}
+
+	t.Log("[PASS] Programmed the fileless rules")
+}
+
+func TestSignalRules(t *testing.T) {
+	rules := map[InnerKey][2]uint8{}
+
+	signalToMap(tp.ProcessSignalType{Signals: []string{"SIGKILL", "stop"}, Scope: "cross-container", ExcludeChildren: true, Action: "Block"}, rules)
+	signalToMap(tp.ProcessSignalType{Signals: []string{"15", "SIGBOGUS"}, Target: []tp.MatchSourceType{{Path: "/usr/bin/fluentd"}},
+		FromSource: []tp.MatchSourceType{{Path: "/bin/kill"}}, ExcludeSelf: true, Action: "Block"}, rules)
+
+	if len(rules) != 3 {
+		t.Fatalf("[FAIL] Unexpected keys of the signal rules (%d)", len(rules))
+	}
+
+	for _, sig := range []uint8{9, 19} {
+		key := InnerKey{Path: [256]byte{SIGNAL, sig, SIGCROSSCONTAINER}}
+		if val, ok := rules[key]; !ok || val[PROCESS] != DENY || val[FILE] != SIGEXCLUDECHILDREN|SIGSCOPECROSSCONTAINER {
+			t.Errorf("[FAIL] Expected the key of signal %d to other containers (%08b, %08b)", sig, val[PROCESS], val[FILE])
+		}
+	}
+
+	key := InnerKey{Path: [256]byte{SIGNAL, 15, SIGPATH}}
+	copy(key.Path[3:], []byte("/usr/bin/fluentd"))
+	copy(key.Source[:], []byte("/bin/kill"))
+
+	if val, ok := rules[key]; !ok || val[PROCESS] != DENY || val[FILE] != SIGEXCLUDESELF {
+		t.Errorf("[FAIL] Expected the key of the target from the source (%08b, %08b)", val[PROCESS], val[FILE])
+	}
+
+	t.Log("[PASS] Programmed the signal rules")
+}
+
+func TestRuntimeSocketRules(t *testing.T) {
+	rules := map[InnerKey][2]uint8{}
+
+	// /run/containerd/containerd.sock on a tmpfs (0:25)
+	sockets := map[mon.SocketInode]string{{Dev: 25, Ino: 1042}: "/run/containerd/containerd.sock"}
+
+	runtimeSocketToMap(tp.NetworkRuntimeSocketType{Action: "Block"}, sockets, rules)
+	runtimeSocketToMap(tp.NetworkRuntimeSocketType{FromSource: []tp.MatchSourceType{{Path: "/usr/bin/docker"}}, Action: "Block"}, sockets, rules)
+
+	if len(rules) != 2 {
+		t.Fatalf("[FAIL] Unexpected keys of the runtime socket rules (%d)", len(rules))
+	}
+
+	key := InnerKey{Path: [256]byte{RUNTIMESOCKET, 0x12, 0x04, 0, 0, 0, 0, 0, 0, 25}}
+	if val, ok := rules[key]; !ok || val[PROCESS] != DENY {
+		t.Errorf("[FAIL] Expected the key of the inode and the device of the socket (%08b)", val[PROCESS])
+	}
+
+	copy(key.Source[:], []byte("/usr/bin/docker"))
+	if _, ok := rules[key]; !ok {
+		t.Errorf("[FAIL] Expected the key of the socket from the source")
+	}
+
+	// 259:3 (e.g., /dev/nvme0n1p3) in the encoding of the kernel
+	if dev := kernelDev(0x10303); dev != 259<<20|3 {
+		t.Errorf("[FAIL] Unexpected device number of the kernel (%x)", dev)
+	}
+
+	t.Log("[PASS] Programmed the runtime socket rules")
+}
+
+func TestFileAttributeRules(t *testing.T) {
+	policy := tp.SecurityPolicy{}
+	policy.Spec.File.MatchXattrs = []tp.FileXattrType{
+		{Name: "security.*", Operations: []string{"set"}, Action: "Block"},
+		{Name: "user.checksum", Path: "/etc/", FromSource: []tp.MatchSourceType{{Path: "/usr/bin/setfattr"}}, Action: "Block"},
+		{Name: "user.checksum", Path: "/usr/bin/", FromSource: []tp.MatchSourceType{{Path: "/usr/bin/setfattr"}}, Action: "Block"},
+		{Name: "trusted.*", Action: "Audit"},
+		{Name: "user.[ab]", Action: "Block"},
+	}
+	policy.Spec.File.MatchImmutable = []tp.FileImmutableType{{Path: "/etc/passwd", Action: "Block"}}
+
+	rules := GenerateContainerRules([]tp.SecurityPolicy{policy}, tp.DefaultPosture{}, nil).FileRuleList
+
+	// the name keys of the changes (and their sources), and the target keys of their ids
+	nameKey := func(change uint8, name, source string) InnerKey {
+		key := InnerKey{Path: [256]byte{FILEATTR, change}}
+		copy(key.Path[2:], []byte(name))
+		copy(key.Source[:], []byte(source))
+		return key
+	}
+	targetKey := func(id uint8, target string) InnerKey {
+		key := InnerKey{Path: [256]byte{FILEATTRTARGET, id}}
+		copy(key.Path[2:], []byte(target))
+		return key
+	}
+
+	if val, ok := rules[nameKey(ATTRSETXATTR, "security.", "")]; !ok || val != [2]uint8{0, DENY} {
+		t.Errorf("[FAIL] Expected the key of the namespace of a rule without a target (%v)", val)
+	}
+	if _, ok := rules[nameKey(ATTRREMOVEXATTR, "security.", "")]; ok {
+		t.Errorf("[FAIL] Unexpected key of an operation out of the rule")
+	}
+
+	// the targets of the same name and source share the id of their key
+	for _, change := range []uint8{ATTRSETXATTR, ATTRREMOVEXATTR} {
+		val, ok := rules[nameKey(change, "user.checksum", "/usr/bin/setfattr")]
+		if !ok || val[PROCESS] == 0 || val[FILE] != 0 {
+			t.Errorf("[FAIL] Expected the key of the name from the source (%v)", val)
+			continue
+		}
+		for _, target := range []string{"/etc/", "/usr/bin/"} {
+			if _, ok := rules[targetKey(val[PROCESS], target)]; !ok {
+				t.Errorf("[FAIL] Expected the key of target %s of id %d", target, val[PROCESS])
+			}
+		}
+	}
+
+	if val, ok := rules[nameKey(ATTRSETFLAGS, "", "")]; !ok || val[PROCESS] == 0 {
+		t.Errorf("[FAIL] Expected the key of the immutable rule (%v)", val)
+	} else if _, ok := rules[targetKey(val[PROCESS], "/etc/passwd")]; !ok {
+		t.Errorf("[FAIL] Expected the key of the target of the immutable rule")
+	}
+
+	// audited, or unmatched by the enforcer
+	if len(rules) != 9 {
+		t.Errorf("[FAIL] Unexpected keys of the file attribute rules (%d)", len(rules))
+	}
+
+	t.Log("[PASS] Programmed the file attribute rules")
+}
+
+func TestDualStackNetworkRules(t *testing.T) {
+	secPolicy := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "default", "policyName": "block-protocols"}}
+	secPolicy.Spec.Network.MatchProtocols = []tp.NetworkProtocolType{{Protocol: "tcp", Action: "Block"}, {Protocol: "UDP", Action: "Block"}, {Protocol: "icmp", Action: "Block"}}
+
+	rules := GenerateContainerRules([]tp.SecurityPolicy{secPolicy}, tp.DefaultPosture{}, nil)
+
+	// the keys are the protocols only, so the rules match the IPv4 and the IPv6 sockets alike (ICMPv6 being another protocol)
+	if len(rules.NetworkRuleList) != 3 {
+		t.Fatalf("[FAIL] Unexpected keys of the network rules (%v)", rules.NetworkRuleList)
+	}
+	for _, proto := range []uint8{6, 17, 1} {
+		if val, ok := rules.NetworkRuleList[InnerKey{Path: [256]byte{PROTOCOL, proto}}]; !ok || val[NETWORK] != DENY {
+			t.Errorf("[FAIL] Expected the key of protocol %d (%08b)", proto, val[NETWORK])
+		}
+	}
+
+	t.Log("[PASS] Programmed the network rules of both address families")
+}
+
+func TestPacketNetworkRules(t *testing.T) {
+	secPolicy := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "default", "policyName": "block-packet"}}
+	secPolicy.Spec.Network.MatchProtocols = []tp.NetworkProtocolType{{Protocol: "packet", Action: "Block", FromSource: []tp.MatchSourceType{{Path: "/usr/sbin/tcpdump"}}}}
+
+	rules := GenerateContainerRules([]tp.SecurityPolicy{secPolicy}, tp.DefaultPosture{}, nil)
+
+	// the packet sockets are matched by their family
+	key := InnerKey{Path: [256]byte{FAMILY, 17}}
+	copy(key.Source[:], "/usr/sbin/tcpdump")
+
+	if val, ok := rules.NetworkRuleList[key]; !ok || val[NETWORK] != DENY || len(rules.NetworkRuleList) != 1 {
+		t.Errorf("[FAIL] Unexpected keys of the packet rule (%v)", rules.NetworkRuleList)
+	}
+
+	t.Log("[PASS] Programmed the network rules of the packet sockets")
+}
+
+func TestSelfProtectionRules(t *testing.T) {
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": tp.SelfProtectionPolicyName}}
+	policy.Spec.File.MatchDirectories = []tp.FileDirectoryType{
+		{Directory: "/opt/kubearmor/policies/", ReadOnly: true, Recursive: true, Action: "Block"},
+		{Directory: "/opt/kubearmor/policies/", Recursive: true, FromSource: []tp.MatchSourceType{{Path: "/usr/local/bin/kubearmor"}}, Action: "Allow"},
+	}
+
+	rules := GenerateContainerRules([]tp.SecurityPolicy{policy}, tp.DefaultPosture{FileAction: "block"}, nil)
+
+	// the exemption of KubeArmor doesn't turn the posture into an allow-list
+	if rules.FileWhiteListPosture {
+		t.Errorf("[FAIL] Unexpected file allow-list of the self-protection policy")
+	}
+
+	key := InnerKey{}
+	copy(key.Path[:], []byte("/opt/kubearmor/policies/"))
+	if val := rules.FileRuleList[key]; val[FILE]&DENY == 0 || val[FILE]&WRITE != 0 {
+		t.Errorf("[FAIL] Expected the directory to be read-only (%v)", val)
+	}
+
+	copy(key.Source[:], []byte("/usr/local/bin/kubearmor"))
+	if val := rules.FileRuleList[key]; val[FILE]&DENY != 0 || val[FILE]&WRITE == 0 {
+		t.Errorf("[FAIL] Expected the directory to be writable by KubeArmor (%v)", val)
+	}
+
+	// the Allow rules of the other policies still do
+	policy.Metadata["policyName"] = "allow-kubearmor"
+	if rules := GenerateContainerRules([]tp.SecurityPolicy{policy}, tp.DefaultPosture{FileAction: "block"}, nil); !rules.FileWhiteListPosture {
+		t.Errorf("[FAIL] Expected the file allow-list of an Allow rule")
+	}
+
+	t.Log("[PASS] Exempted KubeArmor from the self-protection policy")
+}
+
+func TestCaptureWritesRules(t *testing.T) {
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "protect-data"}}
+	policy.Spec.File.MatchDirectories = []tp.FileDirectoryType{{Directory: "/data/", Recursive: true, Action: "Block"}}
+
+	if rules := GenerateContainerRules([]tp.SecurityPolicy{policy}, tp.DefaultPosture{}, nil); rules.CaptureWrites {
+		t.Errorf("[FAIL] Unexpected capture of the writes without captureOnBlock")
+	}
+
+	policy.Spec.File.MatchDirectories[0].CaptureOnBlock = true
+	if rules := GenerateContainerRules([]tp.SecurityPolicy{policy}, tp.DefaultPosture{}, nil); !rules.CaptureWrites {
+		t.Errorf("[FAIL] Expected the capture of the writes of a captureOnBlock rule")
+	}
+
+	t.Log("[PASS] Sampled the blocked writes of the captureOnBlock rules")
+}
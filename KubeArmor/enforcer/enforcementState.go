@@ -0,0 +1,348 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package enforcer
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ======================= //
+// == Enforcement State == //
+// ======================= //
+
+// policy names of the alerts of the enforcement state
+const (
+	EnforcementDegradedPolicyName  = "kubearmor-enforcement-degraded"
+	EnforcementRecoveredPolicyName = "kubearmor-enforcement-recovered"
+)
+
+// enforcementRetryInterval is the interval between the retries of the degraded endpoints
+var enforcementRetryInterval = 30 * time.Second
+
+// degradedEndPoint keeps the latest endpoint to apply again in the retries
+type degradedEndPoint struct {
+	state    tp.DegradedEndPoint
+	endPoint tp.EndPoint
+}
+
+// getDegradedEndPointKey Function
+func getDegradedEndPointKey(endPoint tp.EndPoint) string {
+	return endPoint.NamespaceName + "/" + endPoint.EndPointName + "/" + endPoint.ContainerName
+}
+
+// initEnforcementState Function
+func (re *RuntimeEnforcer) initEnforcementState() {
+	re.degradedEndPoints = map[string]*degradedEndPoint{}
+	re.degradedEndPointsLock = new(sync.Mutex)
+
+	re.appliedEndPoints = map[string]tp.AppliedEndPoint{}
+	re.appliedEndPointsLock = new(sync.RWMutex)
+
+	re.enforceLock = new(sync.Mutex)
+
+	re.RetryInterval = enforcementRetryInterval
+	re.stopRetry = make(chan struct{})
+}
+
+// applySecurityPolicies updates the rules of an endpoint in the enforcer
+func (re *RuntimeEnforcer) applySecurityPolicies(endPoint tp.EndPoint) (fd.PolicyApplyTimes, error) {
+	endPoint = withoutEndPointSessionRules(endPoint)
+
+	// the rules of the maturing policies and of the policies in the Audit mode are audited by the feeder only
+	endPoint.SecurityPolicies = re.Logger.EnforcedSecurityPolicies(endPoint.SecurityPolicies)
+
+	// the rules of the policy overrides take precedence over the other rules
+	endPoint.SecurityPolicies = fd.OverriddenSecurityPolicies(endPoint.SecurityPolicies)
+
+	if re.EnforcerType == "BPFLSM" {
+		return re.bpfEnforcer.UpdateSecurityPolicies(endPoint)
+	} else if re.EnforcerType == "AppArmor" {
+		return re.appArmorEnforcer.UpdateSecurityPolicies(endPoint)
+	}
+
+	return fd.PolicyApplyTimes{}, nil
+}
+
+// enforceSecurityPolicies applies the rules of an endpoint, and degrades (or restores) its enforcement
+// based on the result (enforceLock should be held)
+func (re *RuntimeEnforcer) enforceSecurityPolicies(endPoint tp.EndPoint, retry bool) fd.PolicyApplyTimes {
+	times, err := re.applySecurityPolicies(endPoint)
+	re.recordAppliedEndPoint(endPoint, err)
+
+	if err != nil {
+		re.degradeEndPoint(endPoint, err)
+	} else {
+		re.restoreEndPoint(endPoint, retry)
+	}
+
+	return times
+}
+
+// degradeEndPoint enforces the policies of an endpoint in Audit only until its rules are applied again
+func (re *RuntimeEnforcer) degradeEndPoint(endPoint tp.EndPoint, err error) {
+	key := getDegradedEndPointKey(endPoint)
+
+	re.degradedEndPointsLock.Lock()
+
+	degraded, exists := re.degradedEndPoints[key]
+	if !exists {
+		degraded = &degradedEndPoint{state: tp.DegradedEndPoint{
+			NamespaceName: endPoint.NamespaceName,
+			EndPointName:  endPoint.EndPointName,
+			ContainerName: endPoint.ContainerName,
+			Enforcer:      re.EnforcerType,
+			Since:         time.Now(),
+		}}
+		re.degradedEndPoints[key] = degraded
+	} else {
+		degraded.state.Retries++
+	}
+
+	degraded.state.Error = err.Error()
+	degraded.endPoint = endPoint
+
+	startRetry := !re.retrying
+	re.retrying = true
+
+	re.Logger.EnforcementDegraded.Store(true)
+
+	re.degradedEndPointsLock.Unlock()
+
+	// the feeder reports the Block verdicts of the endpoint as Audit
+	audited := endPoint
+	if audited.PolicyEnabled == tp.KubeArmorPolicyEnabled {
+		audited.PolicyEnabled = tp.KubeArmorPolicyAudited
+	}
+	re.Logger.UpdateSecurityPolicies("MODIFIED", audited)
+
+	if !exists {
+		re.Logger.Warnf("Degraded the enforcement of %s to Audit (%s)", key, err.Error())
+		re.Logger.PushSummaryLog(enforcementStateLog(endPoint, EnforcementDegradedPolicyName, "8",
+			"Enforcement degraded to Audit after "+re.EnforcerType+" errors", err.Error()))
+	}
+
+	if startRetry {
+		go re.retryDegradedEndPoints()
+	}
+}
+
+// restoreEndPoint enforces the policies of a degraded endpoint again
+// (the feeder is already updated unless the rules are applied in a retry)
+func (re *RuntimeEnforcer) restoreEndPoint(endPoint tp.EndPoint, retry bool) {
+	key := getDegradedEndPointKey(endPoint)
+
+	re.degradedEndPointsLock.Lock()
+
+	degraded, exists := re.degradedEndPoints[key]
+	if exists {
+		delete(re.degradedEndPoints, key)
+		re.Logger.EnforcementDegraded.Store(len(re.degradedEndPoints) > 0)
+	}
+
+	re.degradedEndPointsLock.Unlock()
+
+	if !exists {
+		return
+	}
+
+	if retry {
+		re.Logger.UpdateSecurityPolicies("MODIFIED", endPoint)
+	}
+
+	re.Logger.Printf("Restored the enforcement of %s (degraded for %s)", key, time.Since(degraded.state.Since).Round(time.Second))
+	re.Logger.PushSummaryLog(enforcementStateLog(endPoint, EnforcementRecoveredPolicyName, "1",
+		"Enforcement restored after "+re.EnforcerType+" errors", degraded.state.Error))
+}
+
+// retryDegradedEndPoints applies the rules of the degraded endpoints again until all of them are restored
+func (re *RuntimeEnforcer) retryDegradedEndPoints() {
+	ticker := time.NewTicker(re.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-re.stopRetry:
+			return
+		case <-ticker.C:
+		}
+
+		if !re.retryDegradedEndPointsOnce() {
+			return
+		}
+	}
+}
+
+// retryDegradedEndPointsOnce applies the rules of the degraded endpoints again,
+// and returns whether some endpoints are still degraded
+func (re *RuntimeEnforcer) retryDegradedEndPointsOnce() bool {
+	re.degradedEndPointsLock.Lock()
+	keys := make([]string, 0, len(re.degradedEndPoints))
+	for key := range re.degradedEndPoints {
+		keys = append(keys, key)
+	}
+	re.degradedEndPointsLock.Unlock()
+
+	for _, key := range keys {
+		re.enforceLock.Lock()
+
+		// the endpoint may be updated (or restored) in the meantime
+		re.degradedEndPointsLock.Lock()
+		degraded, exists := re.degradedEndPoints[key]
+		var endPoint tp.EndPoint
+		if exists {
+			endPoint = degraded.endPoint
+		}
+		re.degradedEndPointsLock.Unlock()
+
+		if exists {
+			re.enforceSecurityPolicies(endPoint, true)
+		}
+
+		re.enforceLock.Unlock()
+	}
+
+	re.degradedEndPointsLock.Lock()
+	defer re.degradedEndPointsLock.Unlock()
+
+	if len(re.degradedEndPoints) == 0 {
+		re.retrying = false
+		return false
+	}
+
+	return true
+}
+
+// ForgetEndPoints stops retrying the degraded endpoints of a deleted pod
+func (re *RuntimeEnforcer) ForgetEndPoints(namespaceName, endPointName string) {
+	// skip if runtime enforcer is not active
+	if re == nil {
+		return
+	}
+
+	prefix := namespaceName + "/" + endPointName + "/"
+
+	re.degradedEndPointsLock.Lock()
+	defer re.degradedEndPointsLock.Unlock()
+
+	for key := range re.degradedEndPoints {
+		if strings.HasPrefix(key, prefix) {
+			delete(re.degradedEndPoints, key)
+		}
+	}
+
+	re.Logger.EnforcementDegraded.Store(len(re.degradedEndPoints) > 0)
+
+	re.appliedEndPointsLock.Lock()
+	for key := range re.appliedEndPoints {
+		if strings.HasPrefix(key, prefix) {
+			delete(re.appliedEndPoints, key)
+		}
+	}
+	re.appliedEndPointsLock.Unlock()
+}
+
+// GetDegradedEndPoints returns the endpoints enforced in Audit only
+func (re *RuntimeEnforcer) GetDegradedEndPoints() []tp.DegradedEndPoint {
+	endPoints := []tp.DegradedEndPoint{}
+
+	// skip if runtime enforcer is not active
+	if re == nil {
+		return endPoints
+	}
+
+	re.degradedEndPointsLock.Lock()
+	defer re.degradedEndPointsLock.Unlock()
+
+	keys := make([]string, 0, len(re.degradedEndPoints))
+	for key := range re.degradedEndPoints {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		endPoints = append(endPoints, re.degradedEndPoints[key].state)
+	}
+
+	return endPoints
+}
+
+// recordAppliedEndPoint keeps the policies of an endpoint in the last update of its rules, and its error
+func (re *RuntimeEnforcer) recordAppliedEndPoint(endPoint tp.EndPoint, err error) {
+	applied := tp.AppliedEndPoint{
+		Enforcer: re.EnforcerType,
+		Policies: []string{},
+		Updated:  time.Now(),
+	}
+
+	// the rules of the policies are removed from the profiles while the policies are disabled
+	if endPoint.PolicyEnabled == tp.KubeArmorPolicyEnabled {
+		for _, secPolicy := range endPoint.SecurityPolicies {
+			applied.Policies = append(applied.Policies, secPolicy.Metadata["policyName"])
+		}
+	}
+
+	if err != nil {
+		applied.Error = err.Error()
+	}
+
+	re.appliedEndPointsLock.Lock()
+	re.appliedEndPoints[getDegradedEndPointKey(endPoint)] = applied
+	re.appliedEndPointsLock.Unlock()
+}
+
+// GetAppliedEndPoint returns the policies of an endpoint in the last update of its rules, and its error
+func (re *RuntimeEnforcer) GetAppliedEndPoint(endPoint tp.EndPoint) (tp.AppliedEndPoint, bool) {
+	// skip if runtime enforcer is not active
+	if re == nil || re.appliedEndPointsLock == nil {
+		return tp.AppliedEndPoint{}, false
+	}
+
+	re.appliedEndPointsLock.RLock()
+	defer re.appliedEndPointsLock.RUnlock()
+
+	applied, ok := re.appliedEndPoints[getDegradedEndPointKey(endPoint)]
+	return applied, ok
+}
+
+// enforcementStateLog returns the alert raised when the enforcement of an endpoint changes
+func enforcementStateLog(endPoint tp.EndPoint, policyName, severity, message, reason string) tp.Log {
+	log := tp.Log{}
+
+	timestamp, updatedTime := kl.GetDateTimeNow()
+
+	log.Timestamp = timestamp
+	log.UpdatedTime = updatedTime
+
+	log.NamespaceName = endPoint.NamespaceName
+	log.PodName = endPoint.EndPointName
+	log.ContainerName = endPoint.ContainerName
+	log.ContainerImage = endPoint.ContainerImage
+	if len(endPoint.Containers) > 0 {
+		log.ContainerID = endPoint.Containers[0]
+	}
+
+	log.Type = "MatchedPolicy"
+	log.PolicyName = policyName
+	log.Severity = severity
+	log.Tags = "KUBEARMOR,ENFORCEMENT"
+	log.ATags = strings.Split(log.Tags, ",")
+	log.Message = message
+
+	log.Source = "kubearmor"
+	log.ProcessName = "kubearmor"
+	log.Data = "error=" + reason
+
+	log.Enforcer = "KubeArmor"
+	log.Action = "Audit"
+	log.Result = "Passed"
+
+	return log
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package enforcer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+func TestEnforcementState(t *testing.T) {
+	dir := t.TempDir()
+
+	prevProfileDir, prevLoadedProfiles, prevParser := appArmorProfileDir, appArmorLoadedProfiles, runAppArmorParser
+	defer func() {
+		appArmorProfileDir, appArmorLoadedProfiles, runAppArmorParser = prevProfileDir, prevLoadedProfiles, prevParser
+	}()
+
+	appArmorProfileDir = dir
+	appArmorLoadedProfiles = dir + "/loaded"
+
+	runAppArmorParser = func(args ...string) error {
+		return nil
+	}
+
+	feeder.MsgLock = new(sync.RWMutex)
+	feeder.MsgStructs = make(map[string]feeder.MsgStruct)
+
+	// subscribe to the alerts
+	alerts := make(chan *pb.Alert, 4)
+	feeder.AlertLock = new(sync.RWMutex)
+	feeder.AlertStructs = map[string]feeder.AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+	defer func() { feeder.AlertStructs = map[string]feeder.AlertStruct{} }()
+
+	ae := newStateTestEnforcer("")
+
+	logger := ae.Logger
+	logger.Output = "none"
+	logger.SecurityPolicies = map[string]tp.MatchPolicies{}
+	logger.SecurityPoliciesLock = new(sync.RWMutex)
+	logger.SeverityRangesLock = new(sync.RWMutex)
+	logger.SinksLock = new(sync.RWMutex)
+	logger.EnforcementFailures = map[string]uint64{}
+	logger.EnforcementFailuresLock = new(sync.RWMutex)
+
+	re := &RuntimeEnforcer{Logger: logger, EnforcerType: "AppArmor", appArmorEnforcer: ae}
+	re.initEnforcementState()
+	defer close(re.stopRetry)
+
+	// the retries are triggered by hand
+	re.retrying = true
+
+	profileName := "kubearmor-web-frontend"
+	if !ae.RegisterAppArmorProfile("frontend", profileName) {
+		t.Fatalf("[FAIL] Failed to register the profile")
+	}
+
+	// the parser fails until it's fixed
+	parserErr := errors.New("apparmor_parser: Unable to replace profile")
+	runAppArmorParser = func(args ...string) error {
+		return parserErr
+	}
+
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "block-sh"}}
+	policy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Block"}}
+
+	endPoint := tp.EndPoint{NamespaceName: "web", EndPointName: "frontend", ContainerName: "nginx", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.AppArmorProfiles = []string{profileName}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{policy}
+
+	// the feeder is updated before the enforcer
+	logger.UpdateSecurityPolicies("ADDED", endPoint)
+	re.UpdateSecurityPolicies(endPoint)
+
+	degraded := re.GetDegradedEndPoints()
+	if len(degraded) != 1 || degraded[0].Enforcer != "AppArmor" || degraded[0].Error != parserErr.Error() {
+		t.Fatalf("[FAIL] Expected the endpoint to be degraded (%+v)", degraded)
+	}
+
+	if applied, ok := re.GetAppliedEndPoint(endPoint); !ok || applied.Error != parserErr.Error() || len(applied.Policies) != 1 || applied.Policies[0] != "block-sh" {
+		t.Errorf("[FAIL] Expected the failed update of the policies to be recorded (%+v)", applied)
+	}
+
+	if alert := <-alerts; alert.PolicyName != EnforcementDegradedPolicyName || alert.PodName != "frontend" {
+		t.Errorf("[FAIL] Expected a degraded alert (%s)", alert.PolicyName)
+	}
+
+	if !logger.EnforcementDegraded.Load() {
+		t.Errorf("[FAIL] Expected the health to report the degraded enforcement")
+	}
+
+	if action := logger.SecurityPolicies["web_frontend"].Policies[0].Action; action != "Audit (Block)" {
+		t.Errorf("[FAIL] Expected the Block rule to be audited (%s)", action)
+	}
+
+	// a failed retry keeps the endpoint degraded without another alert
+	if !re.retryDegradedEndPointsOnce() {
+		t.Fatalf("[FAIL] Expected the endpoint to stay degraded")
+	}
+
+	if degraded := re.GetDegradedEndPoints(); len(degraded) != 1 || degraded[0].Retries != 1 {
+		t.Errorf("[FAIL] Expected a failed retry (%+v)", degraded)
+	}
+
+	// the parser recovers
+	runAppArmorParser = func(args ...string) error {
+		return nil
+	}
+
+	if re.retryDegradedEndPointsOnce() {
+		t.Fatalf("[FAIL] Expected the endpoint to be restored")
+	}
+
+	if alert := <-alerts; alert.PolicyName != EnforcementRecoveredPolicyName {
+		t.Errorf("[FAIL] Expected a recovered alert (%s)", alert.PolicyName)
+	}
+
+	if logger.EnforcementDegraded.Load() || len(re.GetDegradedEndPoints()) != 0 {
+		t.Errorf("[FAIL] Expected the enforcement to be restored")
+	}
+
+	if applied, ok := re.GetAppliedEndPoint(endPoint); !ok || applied.Error != "" || applied.Enforcer != "AppArmor" {
+		t.Errorf("[FAIL] Expected the policies to be recorded as loaded (%+v)", applied)
+	}
+
+	if action := logger.SecurityPolicies["web_frontend"].Policies[0].Action; action != "Block" {
+		t.Errorf("[FAIL] Expected the Block rule to be enforced again (%s)", action)
+	}
+
+	t.Log("[PASS] Degraded and restored the enforcement after parser errors")
+}
@@ -0,0 +1,315 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package enforcer
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"text/template"
+
+	sprig "github.com/Masterminds/sprig/v3"
+	"github.com/kubearmor/KubeArmor/KubeArmor/enforcer/bpflsm"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// conformanceEvent Structure is an access to a file
+type conformanceEvent struct {
+	Path  string
+	Dir   bool
+	Write bool
+	Owner bool
+}
+
+func (e conformanceEvent) String() string {
+	access := "read"
+	if e.Write {
+		access = "write"
+	}
+	owner := "other"
+	if e.Owner {
+		owner = "owner"
+	}
+	return fmt.Sprintf("%s %s by %s", access, e.Path, owner)
+}
+
+// conformanceEvents are the accesses evaluated for each rule
+func conformanceEvents() []conformanceEvent {
+	events := []conformanceEvent{}
+
+	for _, owner := range []bool{true, false} {
+		// the directory itself is listed
+		events = append(events, conformanceEvent{Path: "/secret", Dir: true, Owner: owner})
+
+		for _, path := range []string{"/secret/token", "/secret/nested/token", "/secretive/token", "/other/token"} {
+			for _, write := range []bool{false, true} {
+				events = append(events, conformanceEvent{Path: path, Write: write, Owner: owner})
+			}
+		}
+	}
+
+	return events
+}
+
+// appArmorFileRule Structure is a rule of the file section of a profile
+type appArmorFileRule struct {
+	Deny  bool
+	Owner string // owner, other, or any
+	Glob  *regexp.Regexp
+	Perms string
+}
+
+// appArmorGlob converts an AppArmor glob (with {,} alternations) into a regexp
+func appArmorGlob(glob string) *regexp.Regexp {
+	re := "^"
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**"):
+			re += ".+"
+			i++
+		case glob[i] == '*':
+			re += "[^/]+"
+		case glob[i] == '{':
+			re += "(?:"
+		case glob[i] == '}':
+			re += ")"
+		case glob[i] == ',':
+			re += "|"
+		default:
+			re += regexp.QuoteMeta(string(glob[i]))
+		}
+	}
+	return regexp.MustCompile(re + "$")
+}
+
+// renderFileRules renders the AppArmor profile of the policies, and returns whether the files are permitted by
+// default and the rules of the file section
+func renderFileRules(t *testing.T, securityPolicies []tp.SecurityPolicy, defaultPosture tp.DefaultPosture) (bool, []appArmorFileRule) {
+	ae := &AppArmorEnforcer{}
+
+	_, profile := ae.GenerateProfileBody(securityPolicies, defaultPosture)
+	profile.Name = "kubearmor-conformance"
+
+	tmpl, err := template.New("apparmor").Funcs(sprig.GenericFuncMap()).Parse(BaseTemplate)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to parse the template (%s)", err.Error())
+	}
+
+	var np bytes.Buffer
+	if err := tmpl.Execute(&np, profile); err != nil {
+		t.Fatalf("[FAIL] Failed to generate a profile (%s)", err.Error())
+	}
+
+	text := np.String()
+
+	pre := text[strings.Index(text, "## == PRE START == ##"):strings.Index(text, "## == PRE END == ##")]
+	section := text[strings.Index(text, "## == File/Dir START == ##"):strings.Index(text, "## == File/Dir END == ##")]
+
+	rules := []appArmorFileRule{}
+
+	for _, line := range strings.Split(section, "\n") {
+		fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(line), ","))
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		rule := appArmorFileRule{Owner: "any"}
+		if fields[0] == "deny" {
+			rule.Deny = true
+			fields = fields[1:]
+		}
+		if fields[0] == "owner" || fields[0] == "other" {
+			rule.Owner = fields[0]
+			fields = fields[1:]
+		}
+		rule.Glob = appArmorGlob(fields[0])
+		rule.Perms = fields[1]
+
+		rules = append(rules, rule)
+	}
+
+	return strings.Contains(pre, "file,"), rules
+}
+
+// appArmorVerdict evaluates an access with the rules of a profile (a denial wins over the permissions)
+func appArmorVerdict(fileAll bool, rules []appArmorFileRule, event conformanceEvent) bool {
+	name := event.Path
+	if event.Dir {
+		name += "/"
+	}
+
+	perm := "r"
+	if event.Write {
+		perm = "w"
+	}
+
+	allowed := fileAll
+
+	for _, rule := range rules {
+		if !rule.Glob.MatchString(name) || !strings.Contains(rule.Perms, perm) {
+			continue
+		}
+		if (rule.Owner == "owner" && !event.Owner) || (rule.Owner == "other" && event.Owner) {
+			continue
+		}
+		if rule.Deny {
+			return false
+		}
+		allowed = true
+	}
+
+	return allowed
+}
+
+// bpfFileLookup mirrors the lookup of match_and_enforce_path_hooks (BPF/shared.h) for the rules without sources
+func bpfFileLookup(rules map[bpflsm.InnerKey][2]uint8, path string) ([2]uint8, bool) {
+	key := func(p string) bpflsm.InnerKey {
+		k := bpflsm.InnerKey{}
+		copy(k.Path[:], []byte(p))
+		return k
+	}
+
+	if val, ok := rules[key(path)]; ok && val[bpflsm.FILE]&bpflsm.READ != 0 {
+		return val, true
+	}
+
+	var val, dirval [2]uint8
+	match, recursiveButHint := false, false
+
+	for i := 0; i < len(path); i++ {
+		if path[i] != '/' {
+			continue
+		}
+
+		match = false
+
+		found, ok := rules[key(path[:i+1])]
+		if !ok {
+			break
+		}
+		dirval = found
+
+		if dirval[bpflsm.FILE]&bpflsm.DIR != 0 && dirval[bpflsm.FILE]&bpflsm.READ != 0 {
+			match = true
+			if dirval[bpflsm.FILE]&bpflsm.RECURSIVE == 0 {
+				continue
+			}
+			val = dirval
+			if dirval[bpflsm.FILE]&bpflsm.HINT != 0 {
+				recursiveButHint = true
+				continue
+			}
+			return val, true
+		}
+	}
+
+	if recursiveButHint {
+		return val, true
+	}
+
+	return dirval, match
+}
+
+// bpfHookVerdict mirrors the decision of match_and_enforce_path_hooks (BPF/shared.h)
+func bpfHookVerdict(rules bpflsm.RuleList, path string, write, owner bool) bool {
+	if val, match := bpfFileLookup(rules.FileRuleList, path); match {
+		mask := val[bpflsm.FILE]
+
+		permitted := !(write && mask&bpflsm.WRITE == 0) && (mask&bpflsm.OWNER == 0 || owner)
+
+		if mask&bpflsm.DENY != 0 {
+			return permitted && (mask&bpflsm.OWNER != 0 || mask&bpflsm.WRITE == 0)
+		}
+		if permitted {
+			return true
+		}
+	}
+
+	return !rules.FileWhiteListPosture
+}
+
+// bpfVerdict evaluates an access with the rules of a container, the files being opened (lsm/file_open) and then
+// written (lsm/file_permission)
+func bpfVerdict(rules bpflsm.RuleList, event conformanceEvent) bool {
+	if !bpfHookVerdict(rules, event.Path, false, event.Owner) {
+		return false
+	}
+	if event.Write {
+		return bpfHookVerdict(rules, event.Path, true, event.Owner)
+	}
+	return true
+}
+
+// expectedVerdict evaluates an access with the semantics of the policy matcher
+func expectedVerdict(rule tp.FileDirectoryType, dir bool, event conformanceEvent) bool {
+	matched := event.Path == rule.Directory
+	if dir {
+		matched = fd.InDirectory(rule.Directory, rule.Recursive, event.Path)
+	}
+
+	if rule.Action == "Block" {
+		return !matched || !fd.BlockedByRule(rule.ReadOnly, rule.OwnerOnly, event.Write, event.Owner)
+	}
+
+	// the allow-list
+	return matched && fd.PermittedByFlags(rule.ReadOnly, rule.OwnerOnly, event.Write, event.Owner)
+}
+
+func TestFileRuleConformance(t *testing.T) {
+	defaultPosture := tp.DefaultPosture{FileAction: "block", NetworkAction: "block", CapabilitiesAction: "block"}
+
+	checked := 0
+
+	for _, dir := range []bool{true, false} {
+		for _, action := range []string{"Block", "Allow"} {
+			for flags := 0; flags < 8; flags++ {
+				rule := tp.FileDirectoryType{
+					ReadOnly:  flags&1 != 0,
+					OwnerOnly: flags&2 != 0,
+					Recursive: flags&4 != 0,
+					Action:    action,
+				}
+
+				secPolicy := tp.SecurityPolicy{}
+				secPolicy.Metadata = map[string]string{"namespaceName": "default", "policyName": "conformance"}
+				secPolicy.Spec.Action = action
+
+				if dir {
+					rule.Directory = "/secret/"
+					secPolicy.Spec.File.MatchDirectories = []tp.FileDirectoryType{rule}
+				} else if !rule.Recursive {
+					rule.Directory = "/secret/token"
+					secPolicy.Spec.File.MatchPaths = []tp.FilePathType{{Path: rule.Directory, ReadOnly: rule.ReadOnly, OwnerOnly: rule.OwnerOnly, Action: action}}
+				} else {
+					continue
+				}
+
+				name := fmt.Sprintf("%s %s (recursive=%v readOnly=%v ownerOnly=%v)", action, rule.Directory, rule.Recursive, rule.ReadOnly, rule.OwnerOnly)
+
+				secPolicies := []tp.SecurityPolicy{secPolicy}
+
+				fileAll, appArmorRules := renderFileRules(t, secPolicies, defaultPosture)
+				bpfRules := bpflsm.GenerateContainerRules(secPolicies, defaultPosture, nil)
+
+				for _, event := range conformanceEvents() {
+					expected := expectedVerdict(rule, dir, event)
+
+					if got := appArmorVerdict(fileAll, appArmorRules, event); got != expected {
+						t.Errorf("[FAIL] %s: AppArmor permitted=%v, expected %v (%s)", name, got, expected, event)
+					}
+					if got := bpfVerdict(bpfRules, event); got != expected {
+						t.Errorf("[FAIL] %s: BPF-LSM permitted=%v, expected %v (%s)", name, got, expected, event)
+					}
+
+					checked++
+				}
+			}
+		}
+	}
+
+	t.Logf("[PASS] Gave the same verdicts on the file rules with both enforcers (%d accesses)", checked)
+}
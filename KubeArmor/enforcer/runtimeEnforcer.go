@@ -9,6 +9,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	probe "github.com/kubearmor/KubeArmor/KubeArmor/utils/bpflsmprobe"
 
@@ -36,6 +38,22 @@ type RuntimeEnforcer struct {
 
 	// LSM - SELinux
 	seLinuxEnforcer *SELinuxEnforcer
+
+	// endpoints enforced in Audit only after enforcer errors
+	degradedEndPoints     map[string]*degradedEndPoint
+	degradedEndPointsLock *sync.Mutex
+
+	// policies of the endpoints in the last update of their rules, and its error
+	appliedEndPoints     map[string]tp.AppliedEndPoint
+	appliedEndPointsLock *sync.RWMutex
+
+	// serializes the updates of the rules with the retries
+	enforceLock *sync.Mutex
+
+	// retries of the degraded endpoints
+	RetryInterval time.Duration
+	retrying      bool
+	stopRetry     chan struct{}
 }
 
 // selectLsm Function
@@ -96,6 +114,12 @@ apparmor:
 		re.Logger.Print("Initialized AppArmor Enforcer")
 		re.EnforcerType = "AppArmor"
 		logger.UpdateEnforcer(re.EnforcerType)
+
+		// the upgrades of the OS may remove the includes of the profiles
+		if err := re.appArmorEnforcer.WatchAppArmorIncludes(re.validateAppArmorProfiles); err != nil {
+			re.Logger.Warnf("Unable to watch the AppArmor includes (%s)", err.Error())
+		}
+
 		return re
 	}
 	goto lsmselection
@@ -128,6 +152,7 @@ func NewRuntimeEnforcer(node tp.Node, pinpath string, logger *fd.Feeder, monitor
 	availablelsms := []string{"bpf", "selinux", "apparmor"}
 	re := &RuntimeEnforcer{}
 	re.Logger = logger
+	re.initEnforcementState()
 
 	lsms := []string{}
 
@@ -201,32 +226,68 @@ func (re *RuntimeEnforcer) UpdateAppArmorProfiles(podName, action string, profil
 	}
 
 	if re.EnforcerType == "AppArmor" {
-		for _, profile := range profiles {
+		for container, profile := range profiles {
 			if profile == "unconfined" {
 				continue
 			}
 
 			if action == "ADDED" {
+				if prev, ok := re.appArmorEnforcer.GetContainerProfile(podName, container); ok && prev != profile {
+					re.Logger.Printf("The AppArmor profile of %s/%s was renamed (%s -> %s)", podName, container, prev, profile)
+				}
+
 				re.appArmorEnforcer.RegisterAppArmorProfile(podName, profile)
+				re.appArmorEnforcer.SetContainerProfile(podName, container, profile)
 			} else if action == "DELETED" {
 				re.appArmorEnforcer.UnregisterAppArmorProfile(podName, profile)
+				re.appArmorEnforcer.SetContainerProfile(podName, container, "")
 			}
 		}
 	}
 }
 
-// UpdateSecurityPolicies Function
-func (re *RuntimeEnforcer) UpdateSecurityPolicies(endPoint tp.EndPoint) {
+// validateAppArmorProfiles Function
+func (re *RuntimeEnforcer) validateAppArmorProfiles() {
+	re.enforceLock.Lock()
+	defer re.enforceLock.Unlock()
+
+	re.appArmorEnforcer.ValidateAppArmorProfiles()
+}
+
+// PrepareAppArmorProfile registers the AppArmor profile of a container being created, and applies the policies of
+// its endpoint to the profile, so that the container is enforced from its first exec
+func (re *RuntimeEnforcer) PrepareAppArmorProfile(endPoint tp.EndPoint, podName, containerName, profile string) error {
 	// skip if runtime enforcer is not active
-	if re == nil {
-		return
+	if re == nil || re.EnforcerType != "AppArmor" {
+		return nil
 	}
 
-	if re.EnforcerType == "BPFLSM" {
-		re.bpfEnforcer.UpdateSecurityPolicies(endPoint)
-	} else if re.EnforcerType == "AppArmor" {
-		re.appArmorEnforcer.UpdateSecurityPolicies(endPoint)
+	re.enforceLock.Lock()
+	defer re.enforceLock.Unlock()
+
+	if !re.appArmorEnforcer.RegisterAppArmorProfile(podName, profile) {
+		return fmt.Errorf("failed to register the AppArmor profile %s", profile)
 	}
+	re.appArmorEnforcer.SetContainerProfile(podName, containerName, profile)
+
+	endPoint.AppArmorProfiles = []string{profile}
+
+	_, err := re.appArmorEnforcer.UpdateSecurityPolicies(endPoint)
+	return err
+}
+
+// UpdateSecurityPolicies applies the policies of an endpoint, and returns the time spent on generating and loading the rules
+func (re *RuntimeEnforcer) UpdateSecurityPolicies(endPoint tp.EndPoint) fd.PolicyApplyTimes {
+	// skip if runtime enforcer is not active
+	if re == nil {
+		return fd.PolicyApplyTimes{}
+	}
+
+	re.enforceLock.Lock()
+	defer re.enforceLock.Unlock()
+
+	// Block rules fall back to Audit while the rules can't be applied
+	return re.enforceSecurityPolicies(endPoint, false)
 }
 
 // UpdateHostSecurityPolicies Function
@@ -236,6 +297,19 @@ func (re *RuntimeEnforcer) UpdateHostSecurityPolicies(secPolicies []tp.HostSecur
 		return
 	}
 
+	secPolicies = withoutHostSessionRules(secPolicies)
+
+	// the rules of the maturing policies and of the policies in the Audit mode are audited by the feeder only
+	secPolicies = re.Logger.EnforcedHostSecurityPolicies(secPolicies)
+
+	// the rules of the policy overrides take precedence over the other rules
+	secPolicies = fd.OverriddenHostSecurityPolicies(secPolicies)
+
+	// the host profiles don't confine KubeArmor, which its exemptions would confine by the profiles of their source
+	if re.EnforcerType != "BPFLSM" {
+		secPolicies = withoutSelfExemptions(secPolicies)
+	}
+
 	if re.EnforcerType == "BPFLSM" {
 		re.bpfEnforcer.UpdateHostSecurityPolicies(secPolicies)
 	} else if re.EnforcerType == "AppArmor" {
@@ -245,6 +319,34 @@ func (re *RuntimeEnforcer) UpdateHostSecurityPolicies(secPolicies []tp.HostSecur
 	}
 }
 
+// withoutSelfExemptions returns the host policies without the Allow rules of the self-protection policy, which exempt
+// KubeArmor from its Block rules
+func withoutSelfExemptions(secPolicies []tp.HostSecurityPolicy) []tp.HostSecurityPolicy {
+	hostPolicies := []tp.HostSecurityPolicy{}
+	for _, secPolicy := range secPolicies {
+		if secPolicy.Metadata["policyName"] == tp.SelfProtectionPolicyName {
+			paths := []tp.FilePathType{}
+			for _, path := range secPolicy.Spec.File.MatchPaths {
+				if path.Action != "Allow" {
+					paths = append(paths, path)
+				}
+			}
+			secPolicy.Spec.File.MatchPaths = paths
+
+			dirs := []tp.FileDirectoryType{}
+			for _, dir := range secPolicy.Spec.File.MatchDirectories {
+				if dir.Action != "Allow" {
+					dirs = append(dirs, dir)
+				}
+			}
+			secPolicy.Spec.File.MatchDirectories = dirs
+		}
+		hostPolicies = append(hostPolicies, secPolicy)
+	}
+
+	return hostPolicies
+}
+
 // DestroyRuntimeEnforcer Function
 func (re *RuntimeEnforcer) DestroyRuntimeEnforcer() error {
 	// skip if runtime enforcer is not active
@@ -252,6 +354,11 @@ func (re *RuntimeEnforcer) DestroyRuntimeEnforcer() error {
 		return nil
 	}
 
+	// stop retrying the degraded endpoints
+	if re.stopRetry != nil {
+		close(re.stopRetry)
+	}
+
 	errorLSM := false
 
 	if re.EnforcerType == "BPFLSM" {
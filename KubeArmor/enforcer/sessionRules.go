@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package enforcer
+
+import (
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// =================== //
+// == Session Rules == //
+// =================== //
+
+// withoutSessionRules returns the process and file rules without the ones conditioned on the exec sessions,
+// which are audited by the feeder since the enforcers can't tell the sessions apart
+func withoutSessionRules(process tp.ProcessType, file tp.FileType) (tp.ProcessType, tp.FileType) {
+	var processPaths []tp.ProcessPathType
+	for _, rule := range process.MatchPaths {
+		if !fd.IsSessionRule(rule.OnlyExecSession, rule.ExcludeExecSession, rule.Action) {
+			processPaths = append(processPaths, rule)
+		}
+	}
+	process.MatchPaths = processPaths
+
+	var processDirs []tp.ProcessDirectoryType
+	for _, rule := range process.MatchDirectories {
+		if !fd.IsSessionRule(rule.OnlyExecSession, rule.ExcludeExecSession, rule.Action) {
+			processDirs = append(processDirs, rule)
+		}
+	}
+	process.MatchDirectories = processDirs
+
+	var processPatterns []tp.ProcessPatternType
+	for _, rule := range process.MatchPatterns {
+		if !fd.IsSessionRule(rule.OnlyExecSession, rule.ExcludeExecSession, rule.Action) {
+			processPatterns = append(processPatterns, rule)
+		}
+	}
+	process.MatchPatterns = processPatterns
+
+	var filePaths []tp.FilePathType
+	for _, rule := range file.MatchPaths {
+		if !fd.IsSessionRule(rule.OnlyExecSession, rule.ExcludeExecSession, rule.Action) {
+			filePaths = append(filePaths, rule)
+		}
+	}
+	file.MatchPaths = filePaths
+
+	var fileDirs []tp.FileDirectoryType
+	for _, rule := range file.MatchDirectories {
+		if !fd.IsSessionRule(rule.OnlyExecSession, rule.ExcludeExecSession, rule.Action) {
+			fileDirs = append(fileDirs, rule)
+		}
+	}
+	file.MatchDirectories = fileDirs
+
+	var filePatterns []tp.FilePatternType
+	for _, rule := range file.MatchPatterns {
+		if !fd.IsSessionRule(rule.OnlyExecSession, rule.ExcludeExecSession, rule.Action) {
+			filePatterns = append(filePatterns, rule)
+		}
+	}
+	file.MatchPatterns = filePatterns
+
+	return process, file
+}
+
+// withoutEndPointSessionRules returns an endpoint whose policies are without the rules conditioned on the exec sessions
+func withoutEndPointSessionRules(endPoint tp.EndPoint) tp.EndPoint {
+	secPolicies := []tp.SecurityPolicy{}
+	for _, secPolicy := range endPoint.SecurityPolicies {
+		secPolicy.Spec.Process, secPolicy.Spec.File = withoutSessionRules(secPolicy.Spec.Process, secPolicy.Spec.File)
+		secPolicies = append(secPolicies, secPolicy)
+	}
+	endPoint.SecurityPolicies = secPolicies
+
+	return endPoint
+}
+
+// withoutHostSessionRules returns the host policies without the rules conditioned on the exec sessions
+func withoutHostSessionRules(secPolicies []tp.HostSecurityPolicy) []tp.HostSecurityPolicy {
+	hostPolicies := []tp.HostSecurityPolicy{}
+	for _, secPolicy := range secPolicies {
+		secPolicy.Spec.Process, secPolicy.Spec.File = withoutSessionRules(secPolicy.Spec.Process, secPolicy.Spec.File)
+		hostPolicies = append(hostPolicies, secPolicy)
+	}
+
+	return hostPolicies
+}
@@ -0,0 +1,571 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+)
+
+// =================== //
+// == Alert Archive == //
+// =================== //
+
+// archive constants
+const (
+	AlertArchivePrefix = "alerts-"
+	AlertArchiveSuffix = ".jsonl.zst"
+
+	// suffix of the segment being written (or left behind by a crash)
+	AlertArchivePartSuffix = ".part"
+
+	// suffix of a partial segment being finished on restart
+	alertArchiveRecoverSuffix = ".recover"
+
+	AlertArchiveDefaultSegment = time.Hour
+	AlertArchiveMinSegment     = time.Minute
+
+	// interval to flush the pending records of the current segment
+	AlertArchiveFlushInterval = time.Second
+
+	alertArchiveHourLayout   = "20060102T15"
+	alertArchiveMinuteLayout = "20060102T1504"
+)
+
+// AlertArchiveConfig Structure
+type AlertArchiveConfig struct {
+	Dir string
+
+	// time span of each segment
+	Segment time.Duration
+
+	// retention (no limit if 0)
+	MaxSize int64
+	MaxAge  time.Duration
+}
+
+// ArchiveSegment Structure
+type ArchiveSegment struct {
+	Path string
+
+	// start of the time span of the segment, and the sequence of the segments with the same start
+	Start time.Time
+	Seq   int
+
+	Size int64
+
+	// being written, or left behind by a crash
+	Partial bool
+}
+
+// AlertArchive writes records into zstd-compressed, time-based segments
+type AlertArchive struct {
+	Config AlertArchiveConfig
+
+	// clock, replaceable for testing
+	Now func() time.Time
+
+	// current segment
+	file    *os.File
+	encoder *zstd.Encoder
+	segment ArchiveSegment
+	end     time.Time
+
+	archiveLock *sync.Mutex
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// archiveSegmentName returns the file name of a segment
+func archiveSegmentName(start time.Time, seq int, span time.Duration) string {
+	layout := alertArchiveHourLayout
+	if span%time.Hour != 0 {
+		layout = alertArchiveMinuteLayout
+	}
+
+	name := AlertArchivePrefix + start.UTC().Format(layout)
+	if seq > 0 {
+		name = name + "-" + strconv.Itoa(seq)
+	}
+
+	return name + AlertArchiveSuffix
+}
+
+// parseArchiveSegmentName returns the segment of a file name, or false for the other files
+func parseArchiveSegmentName(name string) (ArchiveSegment, bool) {
+	segment := ArchiveSegment{}
+
+	if !strings.HasPrefix(name, AlertArchivePrefix) {
+		return segment, false
+	}
+	name = strings.TrimPrefix(name, AlertArchivePrefix)
+
+	if strings.HasSuffix(name, AlertArchivePartSuffix) {
+		segment.Partial = true
+		name = strings.TrimSuffix(name, AlertArchivePartSuffix)
+	}
+
+	if !strings.HasSuffix(name, AlertArchiveSuffix) {
+		return segment, false
+	}
+	name = strings.TrimSuffix(name, AlertArchiveSuffix)
+
+	if stamp, seq, ok := strings.Cut(name, "-"); ok {
+		n, err := strconv.Atoi(seq)
+		if err != nil || n <= 0 {
+			return segment, false
+		}
+		segment.Seq = n
+		name = stamp
+	}
+
+	layout := alertArchiveHourLayout
+	if len(name) == len(alertArchiveMinuteLayout) {
+		layout = alertArchiveMinuteLayout
+	}
+
+	start, err := time.Parse(layout, name)
+	if err != nil {
+		return segment, false
+	}
+	segment.Start = start
+
+	return segment, true
+}
+
+// ListArchiveSegments returns the segments in a directory in the order they were written
+func ListArchiveSegments(dir string) ([]ArchiveSegment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := []ArchiveSegment{}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		segment, ok := parseArchiveSegmentName(entry.Name())
+		if !ok {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		segment.Path = filepath.Join(dir, entry.Name())
+		segment.Size = info.Size()
+
+		segments = append(segments, segment)
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		if !segments[i].Start.Equal(segments[j].Start) {
+			return segments[i].Start.Before(segments[j].Start)
+		}
+		return segments[i].Seq < segments[j].Seq
+	})
+
+	return segments, nil
+}
+
+// NewAlertArchive Function
+func NewAlertArchive(config AlertArchiveConfig) (*AlertArchive, error) {
+	if config.Dir == "" {
+		return nil, errors.New("no archive directory")
+	}
+
+	if config.Segment <= 0 {
+		config.Segment = AlertArchiveDefaultSegment
+	}
+	if config.Segment < AlertArchiveMinSegment {
+		config.Segment = AlertArchiveMinSegment
+	}
+
+	if err := os.MkdirAll(config.Dir, 0750); err != nil {
+		return nil, err
+	}
+
+	aa := &AlertArchive{}
+
+	aa.Config = config
+	aa.Now = time.Now
+
+	aa.archiveLock = new(sync.Mutex)
+
+	aa.stop = make(chan struct{})
+
+	// finish (or discard) the segments left behind by a crash
+	if err := aa.recoverSegments(); err != nil {
+		return nil, err
+	}
+
+	aa.prune()
+
+	return aa, nil
+}
+
+// recoverSegments finishes the partial segments with the records which can still be read, and discards the others
+func (aa *AlertArchive) recoverSegments() error {
+	entries, err := os.ReadDir(aa.Config.Dir)
+	if err != nil {
+		return err
+	}
+
+	// the leftovers of an interrupted recovery
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), AlertArchivePrefix) && strings.HasSuffix(entry.Name(), alertArchiveRecoverSuffix) {
+			if err := os.Remove(filepath.Join(aa.Config.Dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	segments, err := ListArchiveSegments(aa.Config.Dir)
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		if !segment.Partial {
+			continue
+		}
+
+		records := 0
+
+		// re-compress the complete records into a finished segment
+		final := strings.TrimSuffix(segment.Path, AlertArchivePartSuffix)
+		tmp := final + alertArchiveRecoverSuffix
+
+		if err := func() error {
+			// #nosec
+			out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+			if err != nil {
+				return err
+			}
+			defer out.Close()
+
+			encoder, err := zstd.NewWriter(out, zstd.WithEncoderConcurrency(1))
+			if err != nil {
+				return err
+			}
+
+			if err := readArchiveSegment(segment, func(record []byte) bool {
+				if _, err := encoder.Write(append(record, '\n')); err != nil {
+					return false
+				}
+				records++
+				return true
+			}); err != nil {
+				kg.Warnf("Failed to read the partial segment %s (%s)", segment.Path, err.Error())
+			}
+
+			if err := encoder.Close(); err != nil {
+				return err
+			}
+
+			return out.Sync()
+		}(); err != nil {
+			return err
+		}
+
+		if records > 0 {
+			if err := os.Rename(tmp, final); err != nil {
+				return err
+			}
+			kg.Printf("Finished the partial segment %s (%d records)", final, records)
+		} else {
+			if err := os.Remove(tmp); err != nil {
+				return err
+			}
+			kg.Printf("Discarded the empty partial segment %s", segment.Path)
+		}
+
+		if err := os.Remove(segment.Path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// openSegment starts the segment covering the given time
+func (aa *AlertArchive) openSegment(now time.Time) error {
+	start := now.UTC().Truncate(aa.Config.Segment)
+
+	// a segment with the same start may exist after a restart
+	seq := 0
+	for {
+		path := filepath.Join(aa.Config.Dir, archiveSegmentName(start, seq, aa.Config.Segment))
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			if _, err := os.Stat(path + AlertArchivePartSuffix); errors.Is(err, os.ErrNotExist) {
+				break
+			}
+		}
+		seq++
+	}
+
+	path := filepath.Join(aa.Config.Dir, archiveSegmentName(start, seq, aa.Config.Segment)+AlertArchivePartSuffix)
+
+	// #nosec
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	encoder, err := zstd.NewWriter(file, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	aa.file = file
+	aa.encoder = encoder
+	aa.segment = ArchiveSegment{Path: path, Start: start, Seq: seq, Partial: true}
+	aa.end = start.Add(aa.Config.Segment)
+
+	return nil
+}
+
+// finishSegment completes the current segment, and renames it once it is safely on disk
+func (aa *AlertArchive) finishSegment() error {
+	if aa.encoder == nil {
+		return nil
+	}
+
+	file, encoder, path := aa.file, aa.encoder, aa.segment.Path
+
+	aa.file = nil
+	aa.encoder = nil
+
+	if err := encoder.Close(); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(path, strings.TrimSuffix(path, AlertArchivePartSuffix))
+}
+
+// prune removes the segments over the age and size limits, from the oldest one
+func (aa *AlertArchive) prune() {
+	if aa.Config.MaxAge <= 0 && aa.Config.MaxSize <= 0 {
+		return
+	}
+
+	segments, err := ListArchiveSegments(aa.Config.Dir)
+	if err != nil {
+		kg.Warnf("Failed to list the segments of the archive (%s)", err.Error())
+		return
+	}
+
+	total := int64(0)
+	for _, segment := range segments {
+		total += segment.Size
+	}
+
+	cutoff := aa.Now().Add(-aa.Config.MaxAge)
+
+	for _, segment := range segments {
+		// the current segment is never removed
+		if segment.Partial {
+			continue
+		}
+
+		expired := aa.Config.MaxAge > 0 && segment.Start.Add(aa.Config.Segment).Before(cutoff)
+		oversize := aa.Config.MaxSize > 0 && total > aa.Config.MaxSize
+
+		if !expired && !oversize {
+			break
+		}
+
+		if err := os.Remove(segment.Path); err != nil {
+			kg.Warnf("Failed to remove the segment %s (%s)", segment.Path, err.Error())
+			continue
+		}
+
+		total -= segment.Size
+	}
+}
+
+// Write appends a record (a line of JSON) to the current segment
+func (aa *AlertArchive) Write(record []byte) error {
+	aa.archiveLock.Lock()
+	defer aa.archiveLock.Unlock()
+
+	now := aa.Now()
+
+	// roll over
+	if aa.encoder != nil && !now.Before(aa.end) {
+		if err := aa.finishSegment(); err != nil {
+			return err
+		}
+		aa.prune()
+	}
+
+	if aa.encoder == nil {
+		if err := aa.openSegment(now); err != nil {
+			return err
+		}
+	}
+
+	if _, err := aa.encoder.Write(record); err != nil {
+		return err
+	}
+
+	_, err := aa.encoder.Write([]byte{'\n'})
+	return err
+}
+
+// Flush writes the pending records of the current segment, and finishes it once its time span is over
+func (aa *AlertArchive) Flush() error {
+	aa.archiveLock.Lock()
+	defer aa.archiveLock.Unlock()
+
+	if aa.encoder == nil {
+		return nil
+	}
+
+	if !aa.Now().Before(aa.end) {
+		if err := aa.finishSegment(); err != nil {
+			return err
+		}
+		aa.prune()
+		return nil
+	}
+
+	return aa.encoder.Flush()
+}
+
+// Start Function
+func (aa *AlertArchive) Start() {
+	aa.wg.Add(1)
+
+	go func() {
+		defer aa.wg.Done()
+
+		ticker := time.NewTicker(AlertArchiveFlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-aa.stop:
+				return
+			case <-ticker.C:
+				if err := aa.Flush(); err != nil {
+					kg.Warnf("Failed to flush the archive (%s)", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// Close Function
+func (aa *AlertArchive) Close() error {
+	close(aa.stop)
+	aa.wg.Wait()
+
+	aa.archiveLock.Lock()
+	defer aa.archiveLock.Unlock()
+
+	return aa.finishSegment()
+}
+
+// readArchiveSegment passes the complete records of a segment to fn until it returns false
+func readArchiveSegment(segment ArchiveSegment, fn func(record []byte) bool) error {
+	// #nosec
+	file, err := os.Open(segment.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder, err := zstd.NewReader(file, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return err
+	}
+	defer decoder.Close()
+
+	reader := bufio.NewReader(decoder)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			// the tail of a partial segment is still being written (or was cut by a crash)
+			if segment.Partial {
+				return nil
+			}
+			return fmt.Errorf("%s: %w", segment.Path, err)
+		}
+
+		if record := bytes.TrimSuffix(line, []byte{'\n'}); len(record) > 0 {
+			if !fn(record) {
+				return errStopArchiveRead
+			}
+		}
+	}
+}
+
+// errStopArchiveRead is returned once fn asks to stop reading
+var errStopArchiveRead = errors.New("stopped reading the archive")
+
+// ReadAlertArchive passes the records of the segments overlapping [since, until) to fn
+// in the order they were written, until fn returns false (zero times for no bounds)
+func ReadAlertArchive(dir string, since, until time.Time, fn func(record []byte) bool) error {
+	segments, err := ListArchiveSegments(dir)
+	if err != nil {
+		return err
+	}
+
+	for i, segment := range segments {
+		if !until.IsZero() && !segment.Start.Before(until) {
+			break
+		}
+
+		// a segment ends where the next one starts
+		if !since.IsZero() && i+1 < len(segments) {
+			if next := segments[i+1].Start; next.After(segment.Start) && !next.After(since) {
+				continue
+			}
+		}
+
+		if err := readArchiveSegment(segment, fn); err != nil {
+			if errors.Is(err, errStopArchiveRead) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
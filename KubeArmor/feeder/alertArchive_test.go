@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// readAll returns the records of an archive
+func readAll(t *testing.T, dir string, since, until time.Time) []string {
+	records := []string{}
+	if err := ReadAlertArchive(dir, since, until, func(record []byte) bool {
+		records = append(records, string(record))
+		return true
+	}); err != nil {
+		t.Fatalf("[FAIL] Failed to read the archive (%s)", err.Error())
+	}
+	return records
+}
+
+func TestAlertArchive(t *testing.T) {
+	dir := t.TempDir()
+
+	archive, err := NewAlertArchive(AlertArchiveConfig{Dir: dir, Segment: time.Hour})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create an archive (%s)", err.Error())
+	}
+
+	now := time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)
+	archive.Now = func() time.Time { return now }
+
+	// 3 records per hour for 3 hours
+	for i := 0; i < 9; i++ {
+		if i > 0 && i%3 == 0 {
+			now = now.Add(time.Hour)
+		}
+		if err := archive.Write([]byte(fmt.Sprintf(`{"seq":%d}`, i))); err != nil {
+			t.Fatalf("[FAIL] Failed to write a record (%s)", err.Error())
+		}
+	}
+
+	segments, err := ListArchiveSegments(dir)
+	if err != nil || len(segments) != 3 {
+		t.Fatalf("[FAIL] Expected 3 segments (%+v)", segments)
+	}
+	if filepath.Base(segments[0].Path) != "alerts-20240101T00.jsonl.zst" || segments[0].Partial || !segments[2].Partial {
+		t.Errorf("[FAIL] Unexpected segments after rollovers (%+v)", segments)
+	}
+
+	// the current segment is read as well once flushed
+	if err := archive.Flush(); err != nil {
+		t.Fatalf("[FAIL] Failed to flush the archive (%s)", err.Error())
+	}
+
+	if records := readAll(t, dir, time.Time{}, time.Time{}); len(records) != 9 || records[0] != `{"seq":0}` || records[8] != `{"seq":8}` {
+		t.Errorf("[FAIL] Unexpected records across segments (%v)", records)
+	}
+
+	// only the segments in the time range
+	since := time.Date(2024, 1, 1, 1, 10, 0, 0, time.UTC)
+	until := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+
+	if records := readAll(t, dir, since, until); len(records) != 3 || records[0] != `{"seq":3}` {
+		t.Errorf("[FAIL] Unexpected records in the time range (%v)", records)
+	}
+
+	// the last segment is finished on close
+	if err := archive.Close(); err != nil {
+		t.Fatalf("[FAIL] Failed to close the archive (%s)", err.Error())
+	}
+
+	if segments, _ := ListArchiveSegments(dir); len(segments) != 3 || segments[2].Partial {
+		t.Errorf("[FAIL] Expected the last segment to be finished (%+v)", segments)
+	}
+
+	t.Log("[PASS] Archived records in time-based segments")
+}
+
+func TestAlertArchiveRetention(t *testing.T) {
+	dir := t.TempDir()
+
+	archive, err := NewAlertArchive(AlertArchiveConfig{Dir: dir, Segment: time.Hour, MaxAge: 3 * time.Hour})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create an archive (%s)", err.Error())
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	archive.Now = func() time.Time { return now }
+
+	for i := 0; i < 6; i++ {
+		if i > 0 {
+			now = now.Add(time.Hour)
+		}
+		if err := archive.Write([]byte(fmt.Sprintf(`{"seq":%d}`, i))); err != nil {
+			t.Fatalf("[FAIL] Failed to write a record (%s)", err.Error())
+		}
+	}
+
+	// the segments ended more than 3 hours ago (before 02:00) are removed on rollovers
+	segments, _ := ListArchiveSegments(dir)
+	if len(segments) != 5 || !segments[0].Start.Equal(time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)) {
+		t.Errorf("[FAIL] Unexpected segments after pruning by age (%+v)", segments)
+	}
+
+	// the oldest segments are removed over the size limit, but never the current one
+	archive.Config.MaxAge = 0
+	archive.Config.MaxSize = 1
+	archive.prune()
+
+	if segments, _ := ListArchiveSegments(dir); len(segments) != 1 || !segments[0].Partial {
+		t.Errorf("[FAIL] Unexpected segments after pruning by size (%+v)", segments)
+	}
+
+	if err := archive.Close(); err != nil {
+		t.Fatalf("[FAIL] Failed to close the archive (%s)", err.Error())
+	}
+
+	t.Log("[PASS] Pruned the archive by age and size")
+}
+
+func TestAlertArchiveRecovery(t *testing.T) {
+	dir := t.TempDir()
+
+	// a segment cut in the middle of a record by a crash
+	path := filepath.Join(dir, "alerts-20240101T00.jsonl.zst"+AlertArchivePartSuffix)
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create a partial segment (%s)", err.Error())
+	}
+
+	encoder, _ := zstd.NewWriter(file)
+	_, _ = encoder.Write([]byte("{\"seq\":0}\n{\"seq\":1}\n"))
+	_ = encoder.Flush()
+	_, _ = encoder.Write([]byte("{\"seq\":"))
+	_ = encoder.Flush()
+	_ = file.Close()
+
+	// a segment without any complete record
+	empty := filepath.Join(dir, "alerts-20240101T01.jsonl.zst"+AlertArchivePartSuffix)
+	if err := os.WriteFile(empty, []byte{0x28, 0xb5}, 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to create a partial segment (%s)", err.Error())
+	}
+
+	archive, err := NewAlertArchive(AlertArchiveConfig{Dir: dir, Segment: time.Hour})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create an archive (%s)", err.Error())
+	}
+
+	segments, _ := ListArchiveSegments(dir)
+	if len(segments) != 1 || segments[0].Partial {
+		t.Fatalf("[FAIL] Unexpected segments after recovery (%+v)", segments)
+	}
+
+	if records := readAll(t, dir, time.Time{}, time.Time{}); len(records) != 2 || records[1] != `{"seq":1}` {
+		t.Errorf("[FAIL] Unexpected records of the recovered segment (%v)", records)
+	}
+
+	// new records of the same hour go into another segment
+	archive.Now = func() time.Time { return time.Date(2024, 1, 1, 0, 45, 0, 0, time.UTC) }
+
+	if err := archive.Write([]byte(`{"seq":2}`)); err != nil {
+		t.Fatalf("[FAIL] Failed to write a record (%s)", err.Error())
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("[FAIL] Failed to close the archive (%s)", err.Error())
+	}
+
+	if records := readAll(t, dir, time.Time{}, time.Time{}); len(records) != 3 || records[2] != `{"seq":2}` {
+		t.Errorf("[FAIL] Unexpected records after a restart (%v)", records)
+	}
+
+	t.Log("[PASS] Recovered partial segments")
+}
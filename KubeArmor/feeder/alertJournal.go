@@ -0,0 +1,454 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/protobuf/proto"
+)
+
+// =================== //
+// == Alert Journal == //
+// =================== //
+
+// journal constants
+const (
+	AlertJournalFile    = "alerts.jsonl"
+	AlertJournalAckFile = "ack"
+
+	AlertJournalDefaultSize = 10000
+
+	// overflow of a full journal (or DropOldest)
+	JournalBlock = "block"
+
+	// the journal is rewritten with the pending records once the acknowledged ones take up this much
+	alertJournalCompactSize = 4 << 20
+)
+
+// errAlertJournalClosed is returned for the alerts appended to a closed journal
+var errAlertJournalClosed = errors.New("alert journal is closed")
+
+// AlertJournalConfig Structure
+type AlertJournalConfig struct {
+	Dir string
+
+	// maximum number of the records not acknowledged yet
+	MaxRecords int
+
+	// handling of the alerts appended to a full journal (block|oldest)
+	Overflow string
+}
+
+// JournalRecord Structure
+type JournalRecord struct {
+	Offset uint64
+	Alert  *pb.Alert
+}
+
+// journalEntry is a pending record with the size of its line
+type journalEntry struct {
+	record JournalRecord
+	size   int64
+}
+
+// AlertJournal is a write-ahead journal of alerts, an alert is on disk before Append returns,
+// and it is delivered again after a restart until its offset is acknowledged
+type AlertJournal struct {
+	Config AlertJournalConfig
+
+	file *os.File
+	size int64
+
+	// offset of the next record, and the last offset acknowledged
+	next  uint64
+	acked uint64
+
+	// records not acknowledged yet (in order), and the last offset returned by Next
+	pending []journalEntry
+	cursor  uint64
+
+	// records dropped from the full journal
+	dropped uint64
+
+	closed bool
+
+	journalLock *sync.Mutex
+	journalCond *sync.Cond
+}
+
+// NewAlertJournal Function opens the journal in a directory, with the records not acknowledged before a crash
+func NewAlertJournal(config AlertJournalConfig) (*AlertJournal, error) {
+	if config.Dir == "" {
+		return nil, errors.New("no journal directory")
+	}
+
+	if config.MaxRecords <= 0 {
+		config.MaxRecords = AlertJournalDefaultSize
+	}
+	if config.Overflow != DropOldest {
+		config.Overflow = JournalBlock
+	}
+
+	if err := os.MkdirAll(config.Dir, 0750); err != nil {
+		return nil, err
+	}
+
+	aj := &AlertJournal{}
+
+	aj.Config = config
+
+	aj.journalLock = new(sync.Mutex)
+	aj.journalCond = sync.NewCond(aj.journalLock)
+
+	acked, err := readJournalAck(filepath.Join(config.Dir, AlertJournalAckFile))
+	if err != nil {
+		return nil, err
+	}
+	aj.acked = acked
+
+	// #nosec
+	file, err := os.OpenFile(filepath.Join(config.Dir, AlertJournalFile), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	aj.file = file
+
+	if err := aj.recover(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	aj.cursor = aj.acked
+
+	if len(aj.pending) > 0 {
+		kg.Printf("Delivering %d journaled alerts again (offsets %d-%d)", len(aj.pending), aj.pending[0].record.Offset, aj.next-1)
+	}
+
+	return aj, nil
+}
+
+// readJournalAck returns the last offset acknowledged (0 if none)
+func readJournalAck(path string) (uint64, error) {
+	// #nosec
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// recover reads the records of the journal, and cuts the tail of a write interrupted by a crash
+func (aj *AlertJournal) recover() error {
+	reader := bufio.NewReader(aj.file)
+
+	last := uint64(0)
+	valid := int64(0)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				kg.Warnf("Cut an incomplete record at the end of the alert journal (%d bytes)", len(line))
+			}
+			break
+		} else if err != nil {
+			return err
+		}
+
+		record := JournalRecord{}
+		if err := json.Unmarshal(line, &record); err != nil || record.Alert == nil || record.Offset <= last {
+			kg.Warnf("Cut an invalid record in the alert journal and the records after it (offset %d)", valid)
+			break
+		}
+
+		last = record.Offset
+		valid += int64(len(line))
+
+		if record.Offset > aj.acked {
+			aj.pending = append(aj.pending, journalEntry{record: record, size: int64(len(line))})
+		}
+	}
+
+	if err := aj.file.Truncate(valid); err != nil {
+		return err
+	}
+	if _, err := aj.file.Seek(valid, io.SeekStart); err != nil {
+		return err
+	}
+
+	aj.size = valid
+
+	// the offsets go on after the journal is emptied
+	if last < aj.acked {
+		last = aj.acked
+	}
+	aj.next = last + 1
+
+	return nil
+}
+
+// writeAck persists the last offset acknowledged
+func (aj *AlertJournal) writeAck(offset uint64) error {
+	path := filepath.Join(aj.Config.Dir, AlertJournalAckFile)
+	tmp := path + ".tmp"
+
+	// #nosec
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.WriteString(strconv.FormatUint(offset, 10) + "\n"); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// Append writes an alert to the journal (with a new EventID unless it has one), and returns once it is on disk
+// (the alert is copied, since it is shared by the sinks)
+func (aj *AlertJournal) Append(alert *pb.Alert) (uint64, error) {
+	aj.journalLock.Lock()
+	defer aj.journalLock.Unlock()
+
+	for !aj.closed && len(aj.pending) >= aj.Config.MaxRecords {
+		if aj.Config.Overflow != DropOldest {
+			aj.journalCond.Wait()
+			continue
+		}
+
+		// the oldest record is given up as if it were acknowledged
+		oldest := aj.pending[0].record.Offset
+		if err := aj.writeAck(oldest); err != nil {
+			return 0, err
+		}
+
+		aj.acknowledge(oldest)
+		aj.dropped++
+	}
+
+	if aj.closed {
+		return 0, errAlertJournalClosed
+	}
+
+	record := JournalRecord{Offset: aj.next, Alert: proto.Clone(alert).(*pb.Alert)}
+	if record.Alert.EventID == "" {
+		record.Alert.EventID = uuid.NewString()
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return 0, err
+	}
+	line = append(line, '\n')
+
+	if _, err = aj.file.Write(line); err == nil {
+		err = aj.file.Sync()
+	}
+	if err != nil {
+		// cut the record not on disk, or it is cut on restart
+		if terr := aj.file.Truncate(aj.size); terr == nil {
+			_, _ = aj.file.Seek(aj.size, io.SeekStart)
+		}
+		return 0, err
+	}
+
+	aj.size += int64(len(line))
+	aj.next++
+
+	aj.pending = append(aj.pending, journalEntry{record: record, size: int64(len(line))})
+	aj.journalCond.Broadcast()
+
+	return record.Offset, nil
+}
+
+// Next waits for the records after the ones returned before (up to max records),
+// and returns false once the journal is closed
+func (aj *AlertJournal) Next(max int) ([]JournalRecord, bool) {
+	aj.journalLock.Lock()
+	defer aj.journalLock.Unlock()
+
+	for {
+		if aj.closed {
+			return nil, false
+		}
+
+		// the records dropped while being delivered are skipped
+		if aj.cursor < aj.acked {
+			aj.cursor = aj.acked
+		}
+
+		records := []JournalRecord{}
+		for _, entry := range aj.pending {
+			if entry.record.Offset <= aj.cursor {
+				continue
+			}
+			if len(records) >= max {
+				break
+			}
+			records = append(records, entry.record)
+		}
+
+		if len(records) > 0 {
+			aj.cursor = records[len(records)-1].Offset
+			return records, true
+		}
+
+		aj.journalCond.Wait()
+	}
+}
+
+// Ack acknowledges the records up to the offset once they are persisted, they aren't delivered again after a restart
+func (aj *AlertJournal) Ack(offset uint64) error {
+	aj.journalLock.Lock()
+	defer aj.journalLock.Unlock()
+
+	if offset <= aj.acked {
+		return nil
+	}
+
+	if err := aj.writeAck(offset); err != nil {
+		return err
+	}
+
+	aj.acknowledge(offset)
+
+	if !aj.closed {
+		if err := aj.compact(); err != nil {
+			kg.Warnf("Failed to compact the alert journal (%s)", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// acknowledge releases the records up to the offset (journalLock must be held)
+func (aj *AlertJournal) acknowledge(offset uint64) {
+	aj.acked = offset
+
+	idx := 0
+	for idx < len(aj.pending) && aj.pending[idx].record.Offset <= offset {
+		idx++
+	}
+	aj.pending = aj.pending[idx:]
+
+	aj.journalCond.Broadcast()
+}
+
+// compact removes the acknowledged records from the journal (journalLock must be held)
+func (aj *AlertJournal) compact() error {
+	pendingSize := int64(0)
+	for _, entry := range aj.pending {
+		pendingSize += entry.size
+	}
+
+	// all records are acknowledged (the acknowledged offset is kept by the ack file)
+	if len(aj.pending) == 0 {
+		if aj.size == 0 {
+			return nil
+		}
+		if err := aj.file.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := aj.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		aj.size = 0
+		return nil
+	}
+
+	if aj.size-pendingSize < alertJournalCompactSize {
+		return nil
+	}
+
+	// rewrite the pending records
+	path := filepath.Join(aj.Config.Dir, AlertJournalFile)
+	tmp := path + ".tmp"
+
+	// #nosec
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	for _, entry := range aj.pending {
+		line, err := json.Marshal(entry.record)
+		if err != nil {
+			_ = file.Close()
+			return err
+		}
+		if _, err := writer.Write(append(line, '\n')); err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	_ = aj.file.Close()
+	aj.file = file
+	aj.size = pendingSize
+
+	return nil
+}
+
+// Stats returns the number of the records not acknowledged yet, and of the records dropped from the full journal
+func (aj *AlertJournal) Stats() (int, uint64) {
+	aj.journalLock.Lock()
+	defer aj.journalLock.Unlock()
+
+	return len(aj.pending), aj.dropped
+}
+
+// Close closes the journal, the records not acknowledged yet are delivered again after a restart
+func (aj *AlertJournal) Close() error {
+	aj.journalLock.Lock()
+	defer aj.journalLock.Unlock()
+
+	if aj.closed {
+		return nil
+	}
+
+	aj.closed = true
+	aj.journalCond.Broadcast()
+
+	return aj.file.Close()
+}
@@ -0,0 +1,447 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ================ //
+// == Alert Sink == //
+// ================ //
+
+// AlertSink is an additional destination for alerts besides gRPC clients
+type AlertSink interface {
+	Name() string
+	SendAlert(alert *pb.Alert)
+	Close() error
+}
+
+// DurableAlertSink is a sink which takes the alerts as they are raised instead of from a bounded queue
+// (e.g., to journal them before they are considered handled), and which may block while it is full
+type DurableAlertSink interface {
+	AlertSink
+
+	// alerts not persisted yet, and alerts dropped
+	JournalStats() (int, uint64)
+}
+
+// sink constants
+const (
+	DefaultSinkQueueSize    = 1024
+	DefaultSinkDrainTimeout = 5 * time.Second
+
+	// a sink is degraded for a while after dropping alerts
+	sinkDegradedWindow = 10 * time.Second
+
+	// a sink is down when it has been stuck in an alert for this long
+	sinkStallTimeout = 30 * time.Second
+)
+
+// sink health
+const (
+	SinkUp       = "up"
+	SinkDegraded = "degraded"
+	SinkDown     = "down"
+)
+
+// SinkStats Structure
+type SinkStats struct {
+	Name   string
+	Health string
+
+	Queued  int
+	Sent    uint64
+	Dropped uint64
+
+	// age of the oldest alert not sent yet, and the time the last sent alert spent before it was sent
+	OldestQueuedAge time.Duration
+	DeliveryLag     time.Duration
+
+	// the sink lags behind over the threshold
+	Lagging bool
+}
+
+// queuedAlert is an alert (or a serialized log, for a line sink) in the queue of a sink, with the time it was queued
+type queuedAlert struct {
+	alert    *pb.Alert
+	line     []byte
+	queuedAt int64
+}
+
+// SinkWorker delivers alerts to a sink from its own bounded queue,
+// so that a slow or blocked sink can't delay the other ones
+type SinkWorker struct {
+	Sink AlertSink
+
+	queue chan queuedAlert
+	done  chan struct{}
+
+	// unix nano of the alerts in the queue, oldest first
+	queuedAt     []int64
+	queuedAtLock sync.Mutex
+
+	// unix nano of when the alert in progress was queued (0 if idle), and the lag of the last sent alert
+	inFlight atomic.Int64
+	lastLag  atomic.Int64
+	lastSent atomic.Int64
+
+	// the sink is degraded when it lags behind over this (disabled if 0)
+	lagThreshold time.Duration
+
+	// clock of the delivery lag
+	now func() time.Time
+
+	// the alerts are sent to the sink by the producers
+	durable DurableAlertSink
+
+	// the sink takes the serialized logs instead of the alerts
+	lines LineSink
+
+	sent    atomic.Uint64
+	dropped atomic.Uint64
+
+	// unix nano of the last drop, and of the start of the alert in progress (0 if idle)
+	lastDrop atomic.Int64
+	busy     atomic.Int64
+
+	// the queue is closed once
+	closeOnce sync.Once
+}
+
+// NewSinkWorker Function
+func NewSinkWorker(sink AlertSink, queueSize int, lagThreshold time.Duration, now func() time.Time) *SinkWorker {
+	if queueSize <= 0 {
+		queueSize = DefaultSinkQueueSize
+	}
+
+	if now == nil {
+		now = time.Now
+	}
+
+	sw := &SinkWorker{}
+
+	sw.Sink = sink
+
+	sw.lagThreshold = lagThreshold
+	sw.now = now
+
+	if durable, ok := sink.(DurableAlertSink); ok {
+		sw.durable = durable
+	}
+
+	if lines, ok := sink.(LineSink); ok {
+		sw.lines = lines
+	}
+
+	sw.queue = make(chan queuedAlert, queueSize)
+	sw.done = make(chan struct{})
+
+	go sw.run()
+
+	return sw
+}
+
+// run delivers the queued alerts until the queue is closed and drained
+func (sw *SinkWorker) run() {
+	defer close(sw.done)
+
+	for queued := range sw.queue {
+		sw.queuedAtLock.Lock()
+		if len(sw.queuedAt) > 0 {
+			sw.queuedAt = sw.queuedAt[1:]
+		}
+		sw.queuedAtLock.Unlock()
+
+		sw.deliver(queued)
+	}
+}
+
+// deliver sends an alert to the sink, and records its delivery lag
+func (sw *SinkWorker) deliver(queued queuedAlert) {
+	sw.inFlight.Store(queued.queuedAt)
+	sw.busy.Store(sw.now().UnixNano())
+
+	if queued.line != nil {
+		sw.lines.WriteLine(queued.line)
+	} else {
+		sw.Sink.SendAlert(queued.alert)
+	}
+
+	now := sw.now().UnixNano()
+
+	sw.busy.Store(0)
+	sw.inFlight.Store(0)
+
+	sw.lastLag.Store(now - queued.queuedAt)
+	sw.lastSent.Store(now)
+
+	sw.sent.Add(1)
+}
+
+// Enqueue queues an alert without blocking, the alert is dropped if the queue is full
+// (a durable sink takes the alert right away instead)
+func (sw *SinkWorker) Enqueue(alert *pb.Alert) bool {
+	queued := queuedAlert{alert: alert, queuedAt: sw.now().UnixNano()}
+
+	if sw.durable != nil {
+		sw.deliver(queued)
+		return true
+	}
+
+	return sw.enqueue(queued)
+}
+
+// EnqueueLine queues a serialized log for a line sink without blocking, the log is dropped if the queue is full
+func (sw *SinkWorker) EnqueueLine(line []byte) bool {
+	return sw.enqueue(queuedAlert{line: line, queuedAt: sw.now().UnixNano()})
+}
+
+// enqueue Function
+func (sw *SinkWorker) enqueue(queued queuedAlert) bool {
+	// the times are kept in the order of the queue
+	sw.queuedAtLock.Lock()
+	defer sw.queuedAtLock.Unlock()
+
+	select {
+	case sw.queue <- queued:
+		sw.queuedAt = append(sw.queuedAt, queued.queuedAt)
+		return true
+	default:
+		sw.dropped.Add(1)
+		sw.lastDrop.Store(queued.queuedAt)
+		return false
+	}
+}
+
+// oldestQueuedAt returns when the oldest alert not sent yet was queued (0 if none)
+func (sw *SinkWorker) oldestQueuedAt() int64 {
+	if inFlight := sw.inFlight.Load(); inFlight != 0 {
+		return inFlight
+	}
+
+	sw.queuedAtLock.Lock()
+	defer sw.queuedAtLock.Unlock()
+
+	if len(sw.queuedAt) > 0 {
+		return sw.queuedAt[0]
+	}
+	return 0
+}
+
+// Stats returns the counters and the health of the sink
+func (sw *SinkWorker) Stats() SinkStats {
+	stats := SinkStats{
+		Name:    sw.Sink.Name(),
+		Health:  SinkUp,
+		Queued:  len(sw.queue),
+		Sent:    sw.sent.Load(),
+		Dropped: sw.dropped.Load(),
+	}
+
+	if sw.durable != nil {
+		stats.Queued, stats.Dropped = sw.durable.JournalStats()
+	}
+
+	now := sw.now().UnixNano()
+
+	if oldest := sw.oldestQueuedAt(); oldest != 0 {
+		stats.OldestQueuedAge = time.Duration(now - oldest)
+	}
+	stats.DeliveryLag = time.Duration(sw.lastLag.Load())
+
+	// the lag of the last sent alert counts for a while, as the lag of an idle sink isn't refreshed
+	if sw.lagThreshold > 0 {
+		recent := now-sw.lastSent.Load() < int64(sinkDegradedWindow)
+		stats.Lagging = stats.OldestQueuedAge > sw.lagThreshold || (recent && stats.DeliveryLag > sw.lagThreshold)
+	}
+
+	if busy := sw.busy.Load(); busy != 0 && now-busy > int64(sinkStallTimeout) {
+		stats.Health = SinkDown
+	} else if lastDrop := sw.lastDrop.Load(); lastDrop != 0 && now-lastDrop < int64(sinkDegradedWindow) {
+		stats.Health = SinkDegraded
+	} else if stats.Queued >= cap(sw.queue)/2 || stats.Lagging {
+		stats.Health = SinkDegraded
+	}
+
+	return stats
+}
+
+// Close drains the queue until the deadline, then closes the sink
+func (sw *SinkWorker) Close(timeout time.Duration) {
+	sw.closeOnce.Do(func() {
+		close(sw.queue)
+	})
+
+	select {
+	case <-sw.done:
+	case <-time.After(timeout):
+		kg.Warnf("Gave up draining the alert sink (%s, %d alerts left)", sw.Sink.Name(), len(sw.queue))
+	}
+
+	if err := sw.Sink.Close(); err != nil {
+		kg.Warnf("Failed to close the alert sink (%s, %s)", sw.Sink.Name(), err.Error())
+	}
+}
+
+// AddSink Function
+func (fd *Feeder) AddSink(sink AlertSink) {
+	fd.SinksLock.Lock()
+	defer fd.SinksLock.Unlock()
+
+	fd.Sinks = append(fd.Sinks, NewSinkWorker(sink, fd.SinkQueueSize, fd.SinkLagThreshold, fd.Now))
+	kg.Printf("Added an alert sink (%s)", sink.Name())
+}
+
+// pushAlertToSinks queues an alert for each sink
+// (the alert is shared by the sinks, which must not modify it)
+func (fd *Feeder) pushAlertToSinks(alert *pb.Alert) {
+	var durable []*SinkWorker
+
+	fd.SinksLock.RLock()
+	for _, sink := range fd.Sinks {
+		// the line sinks take the alerts with the logs
+		if sink.lines != nil {
+			continue
+		}
+		if sink.durable != nil {
+			durable = append(durable, sink)
+			continue
+		}
+		sink.Enqueue(alert)
+	}
+	fd.SinksLock.RUnlock()
+
+	// the durable sinks may block while they are full, without holding back closeSinks
+	for _, sink := range durable {
+		sink.Enqueue(alert)
+	}
+}
+
+// GetSinkStats returns the counters and the health of the sinks
+func (fd *Feeder) GetSinkStats() []SinkStats {
+	fd.SinksLock.RLock()
+	defer fd.SinksLock.RUnlock()
+
+	stats := []SinkStats{}
+	for _, sink := range fd.Sinks {
+		stats = append(stats, sink.Stats())
+	}
+
+	return stats
+}
+
+// SinksLagging checks if a sink lags behind over the threshold
+func (fd *Feeder) SinksLagging() bool {
+	for _, stats := range fd.GetSinkStats() {
+		if stats.Lagging {
+			return true
+		}
+	}
+	return false
+}
+
+// sinksLaggingUnready checks if KubeArmor isn't ready because a sink lags behind
+func (fd *Feeder) sinksLaggingUnready() bool {
+	return fd.SinkLagUnready && fd.SinksLagging()
+}
+
+// meetsMinSeverity checks if the severity of an alert is at least the given one
+func meetsMinSeverity(alert *pb.Alert, minSeverity int) bool {
+	if minSeverity <= 0 {
+		return true
+	}
+
+	severity, err := strconv.Atoi(alert.Severity)
+	if err != nil {
+		return false
+	}
+
+	return severity >= minSeverity
+}
+
+// matchesEnforcer checks if an alert (or a log) is from one of the given enforcers (all if none)
+func matchesEnforcer(enforcer string, enforcers []string) bool {
+	if len(enforcers) == 0 {
+		return true
+	}
+
+	for _, e := range enforcers {
+		if strings.EqualFold(strings.TrimSpace(e), enforcer) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// closeSinks drains and closes the sinks in parallel, each one with its own deadline
+func (fd *Feeder) closeSinks() {
+	fd.SinksLock.Lock()
+	sinks := fd.Sinks
+	fd.Sinks = nil
+	fd.fileSink = nil
+	fd.SinksLock.Unlock()
+
+	var wg sync.WaitGroup
+
+	for _, sink := range sinks {
+		wg.Add(1)
+		go func(sink *SinkWorker) {
+			defer wg.Done()
+			sink.Close(fd.SinkDrainTimeout)
+		}(sink)
+	}
+
+	wg.Wait()
+}
+
+// sinkCollector reports the age of the oldest alert queued for each sink, and the delivery lag of its last alert
+type sinkCollector struct {
+	feeder *Feeder
+
+	oldestQueuedAge *prometheus.Desc
+	deliveryLag     *prometheus.Desc
+	degraded        *prometheus.Desc
+}
+
+// newSinkCollector Function
+func newSinkCollector(fd *Feeder) *sinkCollector {
+	return &sinkCollector{
+		feeder:          fd,
+		oldestQueuedAge: prometheus.NewDesc("kubearmor_sink_oldest_queued_age_seconds", "Age of the oldest alert not sent to a sink yet", []string{"sink"}, nil),
+		deliveryLag:     prometheus.NewDesc("kubearmor_sink_delivery_lag_seconds", "Time the last alert sent to a sink spent before it was sent", []string{"sink"}, nil),
+		degraded:        prometheus.NewDesc("kubearmor_sink_degraded", "Whether a sink is degraded or down (1) or up (0)", []string{"sink"}, nil),
+	}
+}
+
+// Describe Function
+func (sc *sinkCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sc.oldestQueuedAge
+	ch <- sc.deliveryLag
+	ch <- sc.degraded
+}
+
+// Collect Function
+func (sc *sinkCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, stats := range sc.feeder.GetSinkStats() {
+		degraded := 0.0
+		if stats.Health != SinkUp {
+			degraded = 1.0
+		}
+
+		ch <- prometheus.MustNewConstMetric(sc.oldestQueuedAge, prometheus.GaugeValue, stats.OldestQueuedAge.Seconds(), stats.Name)
+		ch <- prometheus.MustNewConstMetric(sc.deliveryLag, prometheus.GaugeValue, stats.DeliveryLag.Seconds(), stats.Name)
+		ch <- prometheus.MustNewConstMetric(sc.degraded, prometheus.GaugeValue, degraded, stats.Name)
+	}
+}
@@ -0,0 +1,213 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// countingSink counts the alerts it receives
+type countingSink struct {
+	name   string
+	count  atomic.Uint64
+	closed atomic.Bool
+}
+
+func (cs *countingSink) Name() string { return cs.name }
+
+func (cs *countingSink) SendAlert(alert *pb.Alert) { cs.count.Add(1) }
+
+func (cs *countingSink) Close() error {
+	cs.closed.Store(true)
+	return nil
+}
+
+// blockedSink blocks in SendAlert until released
+type blockedSink struct {
+	release chan struct{}
+	closed  atomic.Bool
+}
+
+func (bs *blockedSink) Name() string { return "blocked" }
+
+func (bs *blockedSink) SendAlert(alert *pb.Alert) { <-bs.release }
+
+func (bs *blockedSink) Close() error {
+	bs.closed.Store(true)
+	return nil
+}
+
+func TestSinkIsolation(t *testing.T) {
+	fd := &Feeder{}
+	fd.SinksLock = new(sync.RWMutex)
+	fd.SinkQueueSize = 64
+	fd.SinkDrainTimeout = 200 * time.Millisecond
+
+	blocked := &blockedSink{release: make(chan struct{})}
+	first := &countingSink{name: "first"}
+	second := &countingSink{name: "second"}
+
+	fd.AddSink(first)
+	fd.AddSink(blocked)
+	fd.AddSink(second)
+
+	const total = 20000
+
+	alert := &pb.Alert{PolicyName: "block-shell", Severity: "7", Action: "Block"}
+
+	start := time.Now()
+	for i := 0; i < total; i++ {
+		fd.pushAlertToSinks(alert)
+
+		// keep the pace of the healthy sinks, as a busy node would
+		for first.count.Load()+uint64(fd.SinkQueueSize)/2 < uint64(i) || second.count.Load()+uint64(fd.SinkQueueSize)/2 < uint64(i) {
+			if time.Since(start) > 10*time.Second {
+				t.Fatalf("[FAIL] The healthy sinks were stalled (%d, %d)", first.count.Load(), second.count.Load())
+			}
+			time.Sleep(time.Microsecond)
+		}
+	}
+
+	// the healthy sinks get every alert, even with a blocked sink in between
+	for first.count.Load() != total || second.count.Load() != total {
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("[FAIL] Expected %d alerts for the healthy sinks (%d, %d)", total, first.count.Load(), second.count.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	stats := fd.GetSinkStats()
+	if len(stats) != 3 {
+		t.Fatalf("[FAIL] Expected the stats of 3 sinks (%+v)", stats)
+	}
+
+	if stats[0].Health != SinkUp || stats[0].Dropped != 0 || stats[2].Health != SinkUp || stats[2].Dropped != 0 {
+		t.Errorf("[FAIL] Unexpected stats of the healthy sinks (%+v, %+v)", stats[0], stats[2])
+	}
+
+	// only the blocked sink drops alerts, beyond its queue
+	if stats[1].Health != SinkDegraded || stats[1].Queued != fd.SinkQueueSize || stats[1].Dropped != uint64(total-fd.SinkQueueSize-1) {
+		t.Errorf("[FAIL] Unexpected stats of the blocked sink (%+v)", stats[1])
+	}
+
+	// each sink is drained within its own deadline
+	start = time.Now()
+	fd.closeSinks()
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("[FAIL] Closing the sinks took too long (%s)", elapsed)
+	}
+	if !first.closed.Load() || !second.closed.Load() || !blocked.closed.Load() {
+		t.Errorf("[FAIL] Expected every sink to be closed")
+	}
+
+	close(blocked.release)
+
+	t.Log("[PASS] Isolated a blocked sink from the other sinks")
+}
+
+func TestSinkDeliveryLag(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	var clockLock sync.Mutex
+	clock := func() time.Time {
+		clockLock.Lock()
+		defer clockLock.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		clockLock.Lock()
+		defer clockLock.Unlock()
+		now = now.Add(d)
+	}
+
+	fd := &Feeder{}
+	fd.SinksLock = new(sync.RWMutex)
+	fd.SinkQueueSize = 64
+	fd.SinkDrainTimeout = 200 * time.Millisecond
+	fd.SinkLagThreshold = 5 * time.Minute
+	fd.SinkLagUnready = true
+	fd.Now = clock
+
+	blocked := &blockedSink{release: make(chan struct{})}
+	fd.AddSink(blocked)
+
+	ls := &LogService{GetSinkStats: fd.GetSinkStats, IsSinkLagging: fd.sinksLaggingUnready}
+
+	alert := &pb.Alert{PolicyName: "block-shell", Severity: "7", Action: "Block"}
+	for i := 0; i < 3; i++ {
+		fd.pushAlertToSinks(alert)
+		advance(time.Minute)
+	}
+
+	// the sink is stuck in the first alert, queued 3 minutes ago
+	if stats := fd.GetSinkStats()[0]; stats.OldestQueuedAge != 3*time.Minute || stats.Lagging || stats.Health != SinkUp {
+		t.Errorf("[FAIL] Unexpected stats of the sink under the threshold (%+v)", stats)
+	}
+
+	advance(10 * time.Minute)
+
+	stats := fd.GetSinkStats()[0]
+	if stats.OldestQueuedAge != 13*time.Minute || !stats.Lagging || stats.Health != SinkDegraded {
+		t.Errorf("[FAIL] Expected the sink to lag behind (%+v)", stats)
+	}
+
+	reply, _ := ls.HealthCheck(context.Background(), &pb.NonceMessage{Nonce: 1})
+	if len(reply.Sinks) != 1 || reply.Sinks[0].OldestQueuedAgeMs != (13*time.Minute).Milliseconds() || reply.Sinks[0].Health != SinkDegraded || !reply.SinksLagging {
+		t.Errorf("[FAIL] Expected the health check to report the lag (%+v)", reply)
+	}
+
+	// the lag metrics of the sink
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newSinkCollector(fd))
+
+	metrics := map[string]float64{}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to gather the metrics (%s)", err.Error())
+	}
+	for _, family := range families {
+		metrics[family.GetName()] = family.GetMetric()[0].GetGauge().GetValue()
+	}
+	if metrics["kubearmor_sink_oldest_queued_age_seconds"] != 780 || metrics["kubearmor_sink_degraded"] != 1 {
+		t.Errorf("[FAIL] Unexpected metrics of the sink (%v)", metrics)
+	}
+
+	// once unblocked, the delivery lag of the last alert is reported
+	close(blocked.release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for fd.GetSinkStats()[0].Sent != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("[FAIL] Expected the queued alerts to be sent (%+v)", fd.GetSinkStats()[0])
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	stats = fd.GetSinkStats()[0]
+	if stats.OldestQueuedAge != 0 || stats.DeliveryLag != 11*time.Minute || !stats.Lagging {
+		t.Errorf("[FAIL] Expected the delivery lag of the last alert (%+v)", stats)
+	}
+
+	// the lag of an idle sink isn't held against it for long
+	advance(time.Minute)
+
+	if stats := fd.GetSinkStats()[0]; stats.Lagging || stats.Health != SinkUp {
+		t.Errorf("[FAIL] Expected the sink to recover (%+v)", stats)
+	}
+	if ls.IsSinkLagging() {
+		t.Errorf("[FAIL] Expected KubeArmor to be ready again")
+	}
+
+	fd.closeSinks()
+
+	t.Log("[PASS] Reported the delivery lag of a blocked sink")
+}
@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ===================== //
+// == Allow Telemetry == //
+// ===================== //
+
+// allow telemetry constants
+const (
+	AllowTelemetrySeverity      = "1"
+	AllowTelemetryFlushInterval = time.Minute
+	AllowTelemetryFlushLimit    = 100
+)
+
+// allowRecord Structure
+type allowRecord struct {
+	// the first matched log, used as the base of the telemetry
+	Log tp.Log
+
+	// the rule which allowed the operations
+	Rule string
+
+	Hits     int
+	LastSeen string
+}
+
+// AllowTelemetry aggregates the matches of Allow rules in policies with logAllowed
+type AllowTelemetry struct {
+	// interval between flushes
+	FlushInterval time.Duration
+
+	// maximum number of telemetry logs per flush, the records of the rules with fewer hits are dropped beyond it
+	FlushLimit int
+
+	// (namespace, pod / host, policy, operation, rule) -> aggregated record
+	records     map[string]*allowRecord
+	recordsLock *sync.Mutex
+
+	// the hits counted by the enforcers are recorded before each flush
+	collectors     map[string]func()
+	collectorsLock *sync.Mutex
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewAllowTelemetry Function
+func NewAllowTelemetry(flushInterval time.Duration) *AllowTelemetry {
+	at := &AllowTelemetry{}
+
+	at.FlushInterval = flushInterval
+	at.FlushLimit = AllowTelemetryFlushLimit
+
+	at.records = map[string]*allowRecord{}
+	at.recordsLock = new(sync.Mutex)
+
+	at.collectors = map[string]func(){}
+	at.collectorsLock = new(sync.Mutex)
+
+	at.stop = make(chan struct{})
+
+	return at
+}
+
+// SetCollector sets (or removes, given nil) the collector of the hits counted by an enforcer
+func (at *AllowTelemetry) SetCollector(name string, collect func()) {
+	at.collectorsLock.Lock()
+	defer at.collectorsLock.Unlock()
+
+	if collect == nil {
+		delete(at.collectors, name)
+		return
+	}
+	at.collectors[name] = collect
+}
+
+// collect records the hits counted by the enforcers
+func (at *AllowTelemetry) collect() {
+	at.collectorsLock.Lock()
+	defer at.collectorsLock.Unlock()
+
+	for _, collect := range at.collectors {
+		collect()
+	}
+}
+
+// Record aggregates a log allowed by a rule
+func (at *AllowTelemetry) Record(log tp.Log, rule string) {
+	at.RecordHits(log, rule, 1)
+}
+
+// RecordHits aggregates the hits of a rule, the log being the last allowed one
+func (at *AllowTelemetry) RecordHits(log tp.Log, rule string, hits int) {
+	key := log.NamespaceName + "/" + log.PodName + "/" + log.PolicyName + "/" + log.Operation + "/" + rule
+
+	at.recordsLock.Lock()
+	defer at.recordsLock.Unlock()
+
+	record, ok := at.records[key]
+	if !ok {
+		record = &allowRecord{Log: log, Rule: rule}
+		at.records[key] = record
+	}
+
+	record.Hits += hits
+	if log.Resource != "" {
+		record.LastSeen = log.Resource
+	}
+}
+
+// Flush returns the telemetry of the records of the rules with the most hits (at most FlushLimit), and forgets all
+// the records, the counts restarting per flush
+func (at *AllowTelemetry) Flush() []tp.Log {
+	at.recordsLock.Lock()
+
+	records := make([]*allowRecord, 0, len(at.records))
+	for _, record := range at.records {
+		records = append(records, record)
+	}
+	at.records = map[string]*allowRecord{}
+
+	at.recordsLock.Unlock()
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Hits > records[j].Hits
+	})
+
+	// the records beyond the limit are forgotten as well
+	if len(records) > at.FlushLimit {
+		kg.Warnf("Dropped the allow telemetry of %d rules, more than %d per flush", len(records)-at.FlushLimit, at.FlushLimit)
+		records = records[:at.FlushLimit]
+	}
+
+	logs := []tp.Log{}
+
+	for _, record := range records {
+		log := record.Log
+
+		log.Timestamp, log.UpdatedTime = kl.GetDateTimeNow()
+
+		log.Severity = AllowTelemetrySeverity
+		log.Action = "Allow"
+		log.Result = "Passed"
+
+		log.Resource = record.Rule
+		log.Data = "hits=" + strconv.Itoa(record.Hits)
+
+		// the enforcers count the hits without the allowed resources
+		if record.LastSeen != "" {
+			log.Data = log.Data + " last=" + record.LastSeen
+		}
+
+		logs = append(logs, log)
+	}
+
+	return logs
+}
+
+// Start Function
+func (at *AllowTelemetry) Start(push func(log tp.Log)) {
+	at.wg.Add(1)
+
+	go func() {
+		defer at.wg.Done()
+
+		ticker := time.NewTicker(at.FlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-at.stop:
+				return
+			case <-ticker.C:
+				at.collect()
+				for _, log := range at.Flush() {
+					push(log)
+				}
+			}
+		}
+	}()
+}
+
+// Close Function
+func (at *AllowTelemetry) Close() {
+	close(at.stop)
+	at.wg.Wait()
+}
+
+// setLogAllowed marks the Allow rules of the policies with logAllowed
+func setLogAllowed(matches []tp.MatchPolicy, logAllowed map[string]bool) {
+	for idx := range matches {
+		if matches[idx].Action == "Allow" || matches[idx].Action == "Audit (Allow)" {
+			matches[idx].LogAllowed = logAllowed[matches[idx].PolicyName]
+		}
+	}
+}
+
+// recordAllowed aggregates a log allowed by a rule of a policy with logAllowed
+func (fd *Feeder) recordAllowed(log tp.Log, allowedBy tp.MatchPolicy) {
+	if fd.AllowTelemetry == nil || !allowedBy.LogAllowed {
+		return
+	}
+
+	// the hits of the path, directory and protocol rules are counted by the BPF LSM enforcer, whose allowed
+	// operations don't reach the matcher
+	if fd.Enforcer == "BPFLSM" && (allowedBy.ResourceType == "Path" || allowedBy.ResourceType == "Directory" || allowedBy.ResourceType == "Protocol") {
+		return
+	}
+
+	rule := allowedBy.Resource
+	if allowedBy.IsFromSource {
+		rule = rule + " fromSource=" + allowedBy.Source
+	}
+
+	fd.AllowTelemetry.Record(log, rule)
+}
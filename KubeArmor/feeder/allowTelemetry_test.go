@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestAllowTelemetry(t *testing.T) {
+	feeder := &Feeder{}
+	feeder.SecurityPolicies = map[string]tp.MatchPolicies{}
+	feeder.SecurityPoliciesLock = new(sync.RWMutex)
+	feeder.DefaultPostures = map[string]tp.DefaultPosture{}
+	feeder.EndPointPostures = map[string]tp.DefaultPosture{}
+	feeder.DefaultPosturesLock = new(sync.Mutex)
+	feeder.AllowTelemetry = NewAllowTelemetry(time.Hour)
+
+	feeder.UpdateDefaultPosture("ADDED", "payments", tp.DefaultPosture{
+		FileAction: "block", NetworkAction: "block", CapabilitiesAction: "audit",
+	})
+
+	// only the rules of the policy with logAllowed are reported
+	allowApp := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "allow-app"}}
+	allowApp.Spec.LogAllowed = true
+	allowApp.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/app", Action: "Allow"}}
+	allowApp.Spec.Network.MatchProtocols = []tp.NetworkProtocolType{{Protocol: "tcp", Action: "Allow"}}
+
+	allowShell := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "allow-shell"}}
+	allowShell.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Allow"}}
+
+	endPoint := tp.EndPoint{NamespaceName: "payments", EndPointName: "checkout", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{allowApp, allowShell}
+	endPoint.DefaultPosture = feeder.DefaultPostures["payments"]
+	feeder.UpdateSecurityPolicies("ADDED", endPoint)
+
+	process := tp.Log{ContainerID: "checkout", NamespaceName: "payments", PodName: "checkout", Operation: "Process", Source: "/bin/sh", Resource: "/app", ProcessName: "/app", Result: "Passed"}
+	network := tp.Log{ContainerID: "checkout", NamespaceName: "payments", PodName: "checkout", Operation: "Network", Source: "/app", Resource: "domain=AF_INET type=SOCK_STREAM protocol=TCP", ProcessName: "/app", Result: "Passed"}
+	shell := tp.Log{ContainerID: "checkout", NamespaceName: "payments", PodName: "checkout", Operation: "Process", Source: "/bin/bash", Resource: "/bin/sh", ProcessName: "/bin/sh", Result: "Passed"}
+
+	for i := 0; i < 3; i++ {
+		// allowed operations are still not alerted
+		if log := feeder.UpdateMatchedPolicy(process); log.Source != "" {
+			t.Fatalf("[FAIL] Unexpected alert for an allowed process (%s, %s)", log.PolicyName, log.Action)
+		}
+	}
+	feeder.UpdateMatchedPolicy(network)
+	feeder.UpdateMatchedPolicy(shell)
+
+	logs := feeder.AllowTelemetry.Flush()
+	if len(logs) != 2 {
+		t.Fatalf("[FAIL] Expected 2 telemetry logs, got %d (%+v)", len(logs), logs)
+	}
+
+	hits := map[string]string{}
+	for _, log := range logs {
+		if log.PolicyName != "allow-app" || log.Action != "Allow" || log.Severity != AllowTelemetrySeverity || log.Type != "MatchedPolicy" {
+			t.Errorf("[FAIL] Unexpected telemetry (%s, %s, %s, %s)", log.PolicyName, log.Action, log.Severity, log.Type)
+		}
+		hits[log.Operation+":"+log.Resource] = log.Data
+	}
+
+	if hits["Process:/app"] != "hits=3 last=/app" || hits["Network:protocol=TCP,type=SOCK_STREAM"] != "hits=1 last=domain=AF_INET type=SOCK_STREAM protocol=TCP" {
+		t.Errorf("[FAIL] Unexpected aggregation (%+v)", hits)
+	}
+
+	// the counts restart per flush
+	if logs := feeder.AllowTelemetry.Flush(); len(logs) != 0 {
+		t.Errorf("[FAIL] Expected no telemetry after a flush, got %d", len(logs))
+	}
+
+	t.Log("[PASS] Aggregated the matches of Allow rules")
+}
+
+func TestAllowTelemetryFlushLimit(t *testing.T) {
+	at := NewAllowTelemetry(time.Hour)
+	at.FlushLimit = 2
+
+	// the hits counted by an enforcer are recorded before each flush
+	at.SetCollector("BPFLSM", func() {
+		at.RecordHits(tp.Log{NamespaceName: "payments", PodName: "checkout", PolicyName: "allow-app", Operation: "File"}, "/etc/app.conf", 5)
+	})
+
+	at.Record(tp.Log{NamespaceName: "payments", PodName: "checkout", PolicyName: "allow-app", Operation: "Process", Resource: "/app"}, "/app")
+	for i := 0; i < 3; i++ {
+		at.Record(tp.Log{NamespaceName: "payments", PodName: "checkout", PolicyName: "allow-app", Operation: "Process", Resource: "/bin/ls"}, "/bin/")
+	}
+
+	at.collect()
+	logs := at.Flush()
+	if len(logs) != 2 {
+		t.Fatalf("[FAIL] Expected 2 telemetry logs, got %d (%+v)", len(logs), logs)
+	}
+
+	// the rules with the most hits are reported first
+	if logs[0].Resource != "/etc/app.conf" || logs[0].Data != "hits=5" || logs[1].Resource != "/bin/" || logs[1].Data != "hits=3 last=/bin/ls" {
+		t.Errorf("[FAIL] Unexpected telemetry (%+v)", logs)
+	}
+
+	// the records beyond the limit are dropped, not kept for the next flush
+	at.SetCollector("BPFLSM", nil)
+	if logs := at.Flush(); len(logs) != 0 {
+		t.Errorf("[FAIL] Expected no telemetry after a flush, got %d (%+v)", len(logs), logs)
+	}
+
+	t.Log("[PASS] Flushed the records of the rules with the most hits")
+}
@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"sync"
+	"time"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ========================== //
+// == Destroyed Containers == //
+// ========================== //
+
+// ContainerStateTerminated marks the alerts and the logs of the containers destroyed before they were emitted
+const ContainerStateTerminated = "terminated"
+
+// DestroyedContainersPruneInterval is the interval of dropping the destroyed containers past their retention
+var DestroyedContainersPruneInterval = 10 * time.Second
+
+// destroyedContainer Structure keeps the metadata of a destroyed container (its namespaces are gone from NsMap)
+type destroyedContainer struct {
+	NamespaceName string
+	Owner         tp.PodOwner
+	PodName       string
+	Labels        string
+
+	ContainerName  string
+	ContainerImage string
+	ContainerType  string
+	Privileged     bool
+
+	Destroyed time.Time
+}
+
+// DestroyedContainers Structure keeps the destroyed containers for a while, so that the events received after their
+// destroy (e.g., the alerts of the enforcers, or the events still in the buffers) are still enriched
+type DestroyedContainers struct {
+	// clock, replaceable for testing
+	Now func() time.Time
+
+	Retention time.Duration
+	Limit     int
+
+	// container ID -> metadata, and the container IDs in the order of their destroy
+	containers     map[string]destroyedContainer
+	order          []string
+	containersLock *sync.RWMutex
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewDestroyedContainers Function
+func NewDestroyedContainers(retention time.Duration, limit int) *DestroyedContainers {
+	dc := &DestroyedContainers{}
+
+	dc.Now = time.Now
+
+	dc.Retention = retention
+	dc.Limit = limit
+
+	dc.containers = map[string]destroyedContainer{}
+	dc.order = []string{}
+	dc.containersLock = new(sync.RWMutex)
+
+	dc.stop = make(chan struct{})
+
+	return dc
+}
+
+// Add Function keeps a destroyed container, dropping the oldest one beyond the limit
+func (dc *DestroyedContainers) Add(container tp.Container) {
+	if dc == nil || container.ContainerID == "" || dc.Limit <= 0 {
+		return
+	}
+
+	dc.containersLock.Lock()
+	defer dc.containersLock.Unlock()
+
+	if _, ok := dc.containers[container.ContainerID]; !ok {
+		dc.order = append(dc.order, container.ContainerID)
+	}
+
+	dc.containers[container.ContainerID] = destroyedContainer{
+		NamespaceName:  container.NamespaceName,
+		Owner:          container.Owner,
+		PodName:        container.EndPointName,
+		Labels:         container.Labels,
+		ContainerName:  container.ContainerName,
+		ContainerImage: container.ContainerImage,
+		ContainerType:  container.ContainerType,
+		Privileged:     container.Privileged,
+		Destroyed:      dc.Now(),
+	}
+
+	for len(dc.order) > dc.Limit {
+		delete(dc.containers, dc.order[0])
+		dc.order = dc.order[1:]
+	}
+}
+
+// lookup returns a destroyed container still in its retention
+func (dc *DestroyedContainers) lookup(containerID string) (destroyedContainer, bool) {
+	dc.containersLock.RLock()
+	defer dc.containersLock.RUnlock()
+
+	container, ok := dc.containers[containerID]
+	if !ok || dc.Now().Sub(container.Destroyed) > dc.Retention {
+		return destroyedContainer{}, false
+	}
+
+	return container, true
+}
+
+// Len Function returns the number of the destroyed containers kept
+func (dc *DestroyedContainers) Len() int {
+	dc.containersLock.RLock()
+	defer dc.containersLock.RUnlock()
+
+	return len(dc.containers)
+}
+
+// Prune Function drops the destroyed containers past their retention
+func (dc *DestroyedContainers) Prune() {
+	dc.containersLock.Lock()
+	defer dc.containersLock.Unlock()
+
+	now := dc.Now()
+
+	// the containers are in the order of their destroy
+	expired := 0
+	for _, containerID := range dc.order {
+		if now.Sub(dc.containers[containerID].Destroyed) <= dc.Retention {
+			break
+		}
+		delete(dc.containers, containerID)
+		expired++
+	}
+
+	dc.order = dc.order[expired:]
+}
+
+// Start Function
+func (dc *DestroyedContainers) Start() {
+	dc.wg.Add(1)
+
+	go func() {
+		defer dc.wg.Done()
+
+		ticker := time.NewTicker(DestroyedContainersPruneInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-dc.stop:
+				return
+			case <-ticker.C:
+				dc.Prune()
+			}
+		}
+	}()
+}
+
+// Close Function
+func (dc *DestroyedContainers) Close() {
+	close(dc.stop)
+	dc.wg.Wait()
+}
+
+// AddDestroyedContainer keeps the metadata of a destroyed container for the events received after its destroy
+func (fd *Feeder) AddDestroyedContainer(container tp.Container) {
+	fd.DestroyedContainers.Add(container)
+}
+
+// enrichDestroyedContainer fills the container information of an event which the monitor couldn't find in the live
+// containers, if the container was destroyed recently, and marks the event as terminated
+func (fd *Feeder) enrichDestroyedContainer(log tp.Log) tp.Log {
+	if fd.DestroyedContainers == nil || log.ContainerID == "" || log.NamespaceName != "" || log.ContainerName != "" {
+		return log
+	}
+
+	container, ok := fd.DestroyedContainers.lookup(log.ContainerID)
+	if !ok {
+		return log
+	}
+
+	log.NamespaceName = container.NamespaceName
+	log.Owner = &container.Owner
+	log.PodName = container.PodName
+	log.Labels = container.Labels
+
+	log.ContainerName = container.ContainerName
+	log.ContainerImage = container.ContainerImage
+	log.ContainerType = container.ContainerType
+	log.Privileged = container.Privileged
+
+	log.ContainerState = ContainerStateTerminated
+
+	return log
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+func TestDestroyedContainers(t *testing.T) {
+	feeder := &Feeder{Node: &tp.Node{}, Output: "none"}
+	feeder.SecurityPolicies = map[string]tp.MatchPolicies{}
+	feeder.SecurityPoliciesLock = new(sync.RWMutex)
+	feeder.DefaultPostures = map[string]tp.DefaultPosture{}
+	feeder.EndPointPostures = map[string]tp.DefaultPosture{}
+	feeder.DefaultPosturesLock = new(sync.Mutex)
+	feeder.SeverityRangesLock = new(sync.RWMutex)
+	feeder.SinksLock = new(sync.RWMutex)
+	feeder.Enforcer = "AppArmor"
+
+	now := time.Now()
+	feeder.DestroyedContainers = NewDestroyedContainers(time.Minute, 2)
+	feeder.DestroyedContainers.Now = func() time.Time { return now }
+
+	// subscribe to the alerts
+	alerts := make(chan *pb.Alert, 4)
+	AlertLock = new(sync.RWMutex)
+	AlertStructs = map[string]AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+	defer func() { AlertStructs = map[string]AlertStruct{} }()
+
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "block-sh"}}
+	policy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Block"}}
+
+	endPoint := tp.EndPoint{NamespaceName: "web", EndPointName: "frontend", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{policy}
+	feeder.UpdateSecurityPolicies("ADDED", endPoint)
+
+	feeder.AddDestroyedContainer(tp.Container{
+		ContainerID:    "frontend",
+		ContainerName:  "nginx",
+		ContainerImage: "docker.io/library/nginx:1.25",
+		NamespaceName:  "web",
+		Owner:          tp.PodOwner{Ref: "Deployment", Name: "frontend", Namespace: "web"},
+		EndPointName:   "frontend",
+		Labels:         "app=frontend",
+		Privileged:     true,
+	})
+
+	// denied right before the destroy, and received after it (the monitor only knows the container ID)
+	denied := tp.Log{ContainerID: "frontend", Operation: "Process", Source: "/bin/bash", Resource: "/bin/sh", ProcessName: "/bin/sh", Result: "Permission denied"}
+
+	feeder.PushLog(denied)
+	alert := <-alerts
+
+	if alert.NamespaceName != "web" || alert.PodName != "frontend" || alert.Labels != "app=frontend" || alert.Owner.GetName() != "frontend" {
+		t.Errorf("[FAIL] Unexpected pod of the alert (%+v)", alert)
+	}
+	if alert.ContainerName != "nginx" || alert.ContainerImage != "docker.io/library/nginx:1.25" || !alert.Privileged {
+		t.Errorf("[FAIL] Unexpected container of the alert (%+v)", alert)
+	}
+	if alert.ContainerState != ContainerStateTerminated || alert.PolicyName != "block-sh" {
+		t.Errorf("[FAIL] Expected a terminated alert of block-sh (%s, %s)", alert.ContainerState, alert.PolicyName)
+	}
+
+	// the live containers are not marked
+	if log := feeder.enrichDestroyedContainer(tp.Log{ContainerID: "frontend", NamespaceName: "web", ContainerName: "nginx"}); log.ContainerState != "" {
+		t.Errorf("[FAIL] Unexpected state of a live container (%s)", log.ContainerState)
+	}
+
+	// only the last containers are kept
+	feeder.AddDestroyedContainer(tp.Container{ContainerID: "api", NamespaceName: "web"})
+	feeder.AddDestroyedContainer(tp.Container{ContainerID: "worker", NamespaceName: "web"})
+
+	if log := feeder.enrichDestroyedContainer(denied); feeder.DestroyedContainers.Len() != 2 || log.ContainerState != "" {
+		t.Errorf("[FAIL] Expected the oldest container to be dropped (%d)", feeder.DestroyedContainers.Len())
+	}
+
+	// past the retention
+	now = now.Add(2 * time.Minute)
+
+	if log := feeder.enrichDestroyedContainer(tp.Log{ContainerID: "api"}); log.NamespaceName != "" {
+		t.Errorf("[FAIL] Unexpected enrichment past the retention (%s)", log.NamespaceName)
+	}
+
+	feeder.DestroyedContainers.Prune()
+
+	if n := feeder.DestroyedContainers.Len(); n != 0 {
+		t.Errorf("[FAIL] Expected the containers past the retention to be pruned (%d)", n)
+	}
+
+	t.Log("[PASS] Enriched the alerts of the destroyed containers")
+}
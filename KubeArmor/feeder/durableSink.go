@@ -0,0 +1,317 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+// ================== //
+// == Durable Sink == //
+// ================== //
+
+// durable sink constants
+const (
+	DurableSinkDefaultBatchSize    = 100
+	DurableSinkDefaultRetryBackoff = time.Second
+	durableSinkMaxRetryBackoff     = 30 * time.Second
+)
+
+// DurableStore is the storage of a durable sink, the alerts are acknowledged once Persist returns without an error
+type DurableStore interface {
+	Name() string
+	Persist(alerts []*pb.Alert) error
+	Close() error
+}
+
+// DurableSinkConfig Structure
+type DurableSinkConfig struct {
+	// actions of the alerts persisted (all if empty)
+	Actions []string
+
+	Journal AlertJournalConfig
+
+	// alerts persisted at once
+	BatchSize int
+
+	// backoff of the retries while the store fails, doubled up to 30s
+	RetryBackoff time.Duration
+}
+
+// DurableSink persists alerts at least once, through a write-ahead journal
+// (the alerts delivered again after a crash keep their EventID)
+type DurableSink struct {
+	Config DurableSinkConfig
+
+	Journal *AlertJournal
+	Store   DurableStore
+
+	// alerts persisted, failures of the store, and alerts which couldn't be journaled
+	persisted atomic.Uint64
+	failures  atomic.Uint64
+	rejected  atomic.Uint64
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewDurableSink Function opens the journal of the sink, the alerts not acknowledged before a crash are persisted once started
+func NewDurableSink(config DurableSinkConfig, store DurableStore) (*DurableSink, error) {
+	if store == nil {
+		return nil, errors.New("no durable store")
+	}
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = DurableSinkDefaultBatchSize
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = DurableSinkDefaultRetryBackoff
+	}
+
+	journal, err := NewAlertJournal(config.Journal)
+	if err != nil {
+		return nil, err
+	}
+
+	ds := &DurableSink{}
+
+	ds.Config = config
+
+	ds.Journal = journal
+	ds.Store = store
+
+	ds.stop = make(chan struct{})
+
+	return ds, nil
+}
+
+// Name Function
+func (ds *DurableSink) Name() string {
+	return "durable:" + ds.Store.Name()
+}
+
+// JournalStats returns the alerts not persisted yet, and the alerts dropped from the full journal or not journaled
+func (ds *DurableSink) JournalStats() (int, uint64) {
+	pending, dropped := ds.Journal.Stats()
+	return pending, dropped + ds.rejected.Load()
+}
+
+// SendAlert journals an alert, and returns once it is on disk (or dropped from the full journal)
+// (the alerts of the enforcement of host policies on the node are persisted whatever their action)
+func (ds *DurableSink) SendAlert(alert *pb.Alert) {
+	if !matchesAction(alert.Action, ds.Config.Actions) && !lifecycleAlerts[alert.PolicyName] {
+		return
+	}
+
+	if _, err := ds.Journal.Append(alert); err != nil {
+		if ds.rejected.Add(1) == 1 {
+			kg.Warnf("Failed to journal an alert for the durable sink, the next failures are only counted (%s)", err.Error())
+		}
+	}
+}
+
+// Start Function
+func (ds *DurableSink) Start() {
+	ds.wg.Add(1)
+
+	go func() {
+		defer ds.wg.Done()
+
+		for {
+			records, ok := ds.Journal.Next(ds.Config.BatchSize)
+			if !ok {
+				return
+			}
+
+			if !ds.persist(records) {
+				return
+			}
+		}
+	}()
+}
+
+// persist stores the records until they are acknowledged, and returns false once the sink is closed
+func (ds *DurableSink) persist(records []JournalRecord) bool {
+	alerts := make([]*pb.Alert, 0, len(records))
+	for _, record := range records {
+		alerts = append(alerts, record.Alert)
+	}
+
+	backoff := ds.Config.RetryBackoff
+
+	for {
+		err := ds.Store.Persist(alerts)
+		if err == nil {
+			ds.persisted.Add(uint64(len(alerts)))
+
+			// a failed ack only delivers the records again after a restart
+			if err := ds.Journal.Ack(records[len(records)-1].Offset); err != nil {
+				kg.Warnf("Failed to acknowledge the persisted alerts (%s)", err.Error())
+			}
+			return true
+		}
+
+		if ds.failures.Add(1) == 1 || backoff >= durableSinkMaxRetryBackoff {
+			kg.Warnf("Failed to persist %d alerts to the durable sink, retrying in %s (%s)", len(alerts), backoff.String(), err.Error())
+		}
+
+		select {
+		case <-ds.stop:
+			// the records are delivered again after a restart
+			return false
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > durableSinkMaxRetryBackoff {
+			backoff = durableSinkMaxRetryBackoff
+		}
+	}
+}
+
+// Close Function stops the delivery, the alerts not persisted yet are delivered again after a restart
+func (ds *DurableSink) Close() error {
+	close(ds.stop)
+
+	if err := ds.Journal.Close(); err != nil {
+		kg.Warnf("Failed to close the alert journal (%s)", err.Error())
+	}
+
+	ds.wg.Wait()
+
+	return ds.Store.Close()
+}
+
+// matchesAction checks if an alert is of one of the given actions (all if none)
+func matchesAction(action string, actions []string) bool {
+	if len(actions) == 0 {
+		return true
+	}
+
+	for _, a := range actions {
+		if strings.EqualFold(strings.TrimSpace(a), action) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ================ //
+// == File Store == //
+// ================ //
+
+// FileStore appends alerts to a file as lines of JSON, synced before they are acknowledged
+type FileStore struct {
+	Path string
+
+	file *os.File
+}
+
+// NewFileStore Function opens the file, and cuts the tail of a write interrupted by a crash
+// (the alerts of the cut line are delivered again)
+func NewFileStore(path string) (*FileStore, error) {
+	// #nosec
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := lastLineEnd(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	if err := file.Truncate(end); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	if _, err := file.Seek(end, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &FileStore{Path: path, file: file}, nil
+}
+
+// lastLineEnd returns the size of a file up to the end of its last complete line
+func lastLineEnd(file *os.File) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 4096)
+
+	for end := info.Size(); end > 0; {
+		start := end - int64(len(buf))
+		if start < 0 {
+			start = 0
+		}
+
+		n, err := file.ReadAt(buf[:end-start], start)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+
+		for i := n - 1; i >= 0; i-- {
+			if buf[i] == '\n' {
+				return start + int64(i) + 1, nil
+			}
+		}
+
+		end = start
+	}
+
+	return 0, nil
+}
+
+// Name Function
+func (fs *FileStore) Name() string {
+	return "file"
+}
+
+// Persist Function
+func (fs *FileStore) Persist(alerts []*pb.Alert) error {
+	var buf []byte
+
+	for _, alert := range alerts {
+		line, err := json.Marshal(alert)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	offset, err := fs.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fs.file.Write(buf); err != nil {
+		// cut the partial lines, the alerts are persisted again
+		if terr := fs.file.Truncate(offset); terr == nil {
+			_, _ = fs.file.Seek(offset, io.SeekStart)
+		}
+		return err
+	}
+
+	return fs.file.Sync()
+}
+
+// Close Function
+func (fs *FileStore) Close() error {
+	return fs.file.Close()
+}
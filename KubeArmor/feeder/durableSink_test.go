@@ -0,0 +1,281 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+// crashingStore persists alerts to a file store until the alert at crashAt, where the worker is "killed"
+// (blocked until released) either before or after the batch of the alert is persisted
+type crashingStore struct {
+	store *FileStore
+
+	crashAt         int
+	persistOnCrash  bool
+	persistedAlerts int
+
+	crashed chan struct{}
+	release chan struct{}
+}
+
+func (cs *crashingStore) Name() string { return "crashing" }
+
+func (cs *crashingStore) Persist(alerts []*pb.Alert) error {
+	if cs.persistedAlerts+len(alerts) < cs.crashAt {
+		cs.persistedAlerts += len(alerts)
+		return cs.store.Persist(alerts)
+	}
+
+	if cs.persistOnCrash {
+		if err := cs.store.Persist(alerts); err != nil {
+			return err
+		}
+	}
+
+	close(cs.crashed)
+	<-cs.release
+
+	return errors.New("killed")
+}
+
+func (cs *crashingStore) Close() error { return cs.store.Close() }
+
+// killJournal closes a journal the way a crash would, without acknowledging anything else
+func killJournal(aj *AlertJournal) {
+	aj.journalLock.Lock()
+	defer aj.journalLock.Unlock()
+
+	aj.closed = true
+	aj.journalCond.Broadcast()
+	_ = aj.file.Close()
+}
+
+// readStoredAlerts returns the alerts of a file store
+func readStoredAlerts(t *testing.T, path string) []*pb.Alert {
+	// #nosec
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to open the file store (%s)", err.Error())
+	}
+	defer file.Close()
+
+	alerts := []*pb.Alert{}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		alert := &pb.Alert{}
+		if err := json.Unmarshal(scanner.Bytes(), alert); err != nil {
+			t.Fatalf("[FAIL] Invalid record in the file store (%s)", err.Error())
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return alerts
+}
+
+func TestAlertJournal(t *testing.T) {
+	dir := t.TempDir()
+
+	// the oldest records are dropped once the journal is full
+	aj, err := NewAlertJournal(AlertJournalConfig{Dir: dir, MaxRecords: 3, Overflow: DropOldest})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to open the journal (%s)", err.Error())
+	}
+
+	for i := 1; i <= 5; i++ {
+		if offset, err := aj.Append(&pb.Alert{Resource: "r" + strconv.Itoa(i)}); err != nil || offset != uint64(i) {
+			t.Fatalf("[FAIL] Failed to append an alert (%d, %v)", offset, err)
+		}
+	}
+
+	if pending, dropped := aj.Stats(); pending != 3 || dropped != 2 {
+		t.Errorf("[FAIL] Expected the 2 oldest records to be dropped (%d pending, %d dropped)", pending, dropped)
+	}
+
+	records, ok := aj.Next(10)
+	if !ok || len(records) != 3 || records[0].Offset != 3 || records[0].Alert.EventID == "" {
+		t.Fatalf("[FAIL] Unexpected records to deliver (%+v)", records)
+	}
+
+	if err := aj.Ack(4); err != nil {
+		t.Fatalf("[FAIL] Failed to acknowledge the records (%s)", err.Error())
+	}
+	_ = aj.Close()
+
+	// a record torn by a crash
+	journal, err := os.OpenFile(filepath.Join(dir, AlertJournalFile), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to open the journal file (%s)", err.Error())
+	}
+	_, _ = journal.WriteString(`{"Offset":6,"Alert":{"Reso`)
+	_ = journal.Close()
+
+	aj, err = NewAlertJournal(AlertJournalConfig{Dir: dir, MaxRecords: 1})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to reopen the journal (%s)", err.Error())
+	}
+	defer aj.Close()
+
+	redelivered, ok := aj.Next(10)
+	if !ok || len(redelivered) != 1 || redelivered[0].Offset != 5 || redelivered[0].Alert.EventID != records[2].Alert.EventID {
+		t.Fatalf("[FAIL] Expected the record not acknowledged to be delivered again (%+v)", redelivered)
+	}
+
+	// the producers wait while the journal is full
+	appended := make(chan uint64)
+	go func() {
+		offset, _ := aj.Append(&pb.Alert{Resource: "r6"})
+		appended <- offset
+	}()
+
+	select {
+	case <-appended:
+		t.Fatalf("[FAIL] Expected the producer to wait for the full journal")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := aj.Ack(5); err != nil {
+		t.Fatalf("[FAIL] Failed to acknowledge the records (%s)", err.Error())
+	}
+
+	select {
+	case offset := <-appended:
+		if offset != 6 {
+			t.Errorf("[FAIL] Expected the offsets to go on after the restart (%d)", offset)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("[FAIL] Expected the producer to go on once the journal has room")
+	}
+
+	t.Log("[PASS] Journaled the alerts with a bounded size")
+}
+
+func TestDurableSinkCrashRecovery(t *testing.T) {
+	const total = 20
+
+	for _, persistOnCrash := range []bool{false, true} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "alerts.jsonl")
+
+		config := DurableSinkConfig{
+			Actions:      []string{"Block"},
+			Journal:      AlertJournalConfig{Dir: filepath.Join(dir, "journal")},
+			BatchSize:    4,
+			RetryBackoff: 10 * time.Millisecond,
+		}
+
+		store, err := NewFileStore(path)
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to open the file store (%s)", err.Error())
+		}
+		crashing := &crashingStore{store: store, crashAt: 10, persistOnCrash: persistOnCrash, crashed: make(chan struct{}), release: make(chan struct{})}
+
+		sink, err := NewDurableSink(config, crashing)
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to create the durable sink (%s)", err.Error())
+		}
+
+		fd := &Feeder{}
+		fd.SinksLock = new(sync.RWMutex)
+		fd.AddSink(sink)
+
+		for i := 0; i < total; i++ {
+			fd.pushAlertToSinks(&pb.Alert{PolicyName: "block-shell", Action: "Block", Resource: "r" + strconv.Itoa(i)})
+		}
+		fd.pushAlertToSinks(&pb.Alert{PolicyName: "audit-shell", Action: "Audit", Resource: "audited"})
+
+		// the worker is killed in the middle of the stream
+		sink.Start()
+
+		select {
+		case <-crashing.crashed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("[FAIL] Expected the worker to reach the crash")
+		}
+
+		killJournal(sink.Journal)
+
+		// nothing is acknowledged without being persisted
+		acked, err := readJournalAck(filepath.Join(config.Journal.Dir, AlertJournalAckFile))
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to read the acknowledged offset (%s)", err.Error())
+		}
+
+		stored := readStoredAlerts(t, path)
+		if acked == 0 || uint64(len(stored)) < acked {
+			t.Errorf("[FAIL] Expected the acknowledged alerts to be persisted (%d acknowledged, %d persisted)", acked, len(stored))
+		}
+
+		// a batch torn by the crash
+		if !persistOnCrash {
+			file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+			if err != nil {
+				t.Fatalf("[FAIL] Failed to open the file store (%s)", err.Error())
+			}
+			_, _ = file.WriteString(`{"PolicyName":"block-sh`)
+			_ = file.Close()
+		}
+
+		// restart
+		store, err = NewFileStore(path)
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to reopen the file store (%s)", err.Error())
+		}
+
+		restarted, err := NewDurableSink(config, store)
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to restart the durable sink (%s)", err.Error())
+		}
+		restarted.Start()
+
+		for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+			if pending, _ := restarted.JournalStats(); pending == 0 {
+				break
+			}
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("[FAIL] Expected the alerts not acknowledged to be delivered again")
+			}
+		}
+
+		if err := restarted.Close(); err != nil {
+			t.Errorf("[FAIL] Failed to close the durable sink (%s)", err.Error())
+		}
+
+		// every alert is persisted, and the ones delivered again have the same EventID
+		eventIDs := map[string]string{}
+		for _, alert := range readStoredAlerts(t, path) {
+			if alert.Action != "Block" || alert.EventID == "" {
+				t.Errorf("[FAIL] Unexpected persisted alert (%+v)", alert)
+			}
+			if resource, ok := eventIDs[alert.EventID]; ok && resource != alert.Resource {
+				t.Errorf("[FAIL] Expected the EventID to identify one alert (%s)", alert.EventID)
+			}
+			eventIDs[alert.EventID] = alert.Resource
+		}
+
+		resources := map[string]bool{}
+		for _, resource := range eventIDs {
+			resources[resource] = true
+		}
+		if len(eventIDs) != total || len(resources) != total {
+			t.Errorf("[FAIL] Expected %d alerts to be persisted (%d EventIDs, %d alerts)", total, len(eventIDs), len(resources))
+		}
+
+		close(crashing.release)
+		_ = sink.Close()
+	}
+
+	t.Log("[PASS] Persisted every alert after the worker was killed")
+}
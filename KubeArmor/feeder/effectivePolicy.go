@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"sort"
+	"strings"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ====================== //
+// == Effective Policy == //
+// ====================== //
+
+// the kinds of the effective rules, in the order of the document
+var effectiveRuleKinds = map[string]int{
+	"processPath":      0,
+	"processDirectory": 1,
+	"processPattern":   2,
+	"filePath":         3,
+	"fileDirectory":    4,
+	"filePattern":      5,
+	"networkProtocol":  6,
+	"capability":       7,
+}
+
+// actionPrecedence returns the precedence of an action among the rules for the same entity
+func actionPrecedence(action string) int {
+	switch action {
+	case "Block":
+		return 3
+	case "Throttle":
+		return 2
+	case "Allow":
+		return 1
+	}
+
+	// Audit
+	return 0
+}
+
+// effectiveSources returns the sources of a rule, or a single empty source for the rule without fromSource
+func effectiveSources(fromSource []tp.MatchSourceType) []string {
+	if len(fromSource) == 0 {
+		return []string{""}
+	}
+
+	// the sources without a path are skipped by the enforcers
+	sources := []string{}
+	for _, src := range fromSource {
+		if src.Path != "" {
+			sources = append(sources, src.Path)
+		}
+	}
+	return sources
+}
+
+// MergeSecurityPolicies merges the rules of the security policies of an endpoint into its effective policy, which is
+// the same whatever the order of the policies is. Of the rules for the same entity from the same source, the one with
+// the most restrictive action (Block > Throttle > Allow > Audit) takes precedence, as AppArmor denies what any of them
+// blocks, and the first one (by the namespace and the name of the policies) among the ones with the same action.
+func MergeSecurityPolicies(securityPolicies []tp.SecurityPolicy) tp.EffectivePolicy {
+	effective := tp.EffectivePolicy{Policies: []string{}, Rules: []tp.EffectiveRule{}}
+
+	sorted := append([]tp.SecurityPolicy{}, securityPolicies...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return getPolicyKey(sorted[i].Metadata["namespaceName"], sorted[i].Metadata["policyName"]) < getPolicyKey(sorted[j].Metadata["namespaceName"], sorted[j].Metadata["policyName"])
+	})
+
+	rules := []tp.EffectiveRule{}
+
+	// the matches of the Allow rules of the policy are reported
+	logAllowed := false
+
+	add := func(rule tp.EffectiveRule, fromSource []tp.MatchSourceType) {
+		rule.LogAllowed = logAllowed && rule.Action == "Allow"
+		for _, source := range effectiveSources(fromSource) {
+			rule.Source = source
+			rules = append(rules, rule)
+		}
+	}
+
+	for _, secPolicy := range sorted {
+		policy := getPolicyKey(secPolicy.Metadata["namespaceName"], secPolicy.Metadata["policyName"])
+		effective.Policies = append(effective.Policies, policy)
+
+		spec := secPolicy.Spec
+		logAllowed = spec.LogAllowed
+
+		for _, path := range spec.Process.MatchPaths {
+			add(tp.EffectiveRule{Kind: "processPath", Entity: path.Path, Action: path.Action, OwnerOnly: path.OwnerOnly, Rate: path.Rate, Burst: path.Burst, Policy: policy}, path.FromSource)
+		}
+		for _, dir := range spec.Process.MatchDirectories {
+			add(tp.EffectiveRule{Kind: "processDirectory", Entity: dir.Directory, Action: dir.Action, OwnerOnly: dir.OwnerOnly, Recursive: dir.Recursive, Policy: policy}, dir.FromSource)
+		}
+		for _, pat := range spec.Process.MatchPatterns {
+			add(tp.EffectiveRule{Kind: "processPattern", Entity: pat.Pattern, Action: pat.Action, OwnerOnly: pat.OwnerOnly, Policy: policy}, nil)
+		}
+
+		for _, path := range spec.File.MatchPaths {
+			add(tp.EffectiveRule{Kind: "filePath", Entity: path.Path, Action: path.Action, OwnerOnly: path.OwnerOnly, ReadOnly: path.ReadOnly, CaptureOnBlock: path.CaptureOnBlock, Policy: policy}, path.FromSource)
+		}
+		for _, dir := range spec.File.MatchDirectories {
+			add(tp.EffectiveRule{Kind: "fileDirectory", Entity: dir.Directory, Action: dir.Action, OwnerOnly: dir.OwnerOnly, ReadOnly: dir.ReadOnly, Recursive: dir.Recursive, CaptureOnBlock: dir.CaptureOnBlock, Policy: policy}, dir.FromSource)
+		}
+		for _, pat := range spec.File.MatchPatterns {
+			add(tp.EffectiveRule{Kind: "filePattern", Entity: pat.Pattern, Action: pat.Action, OwnerOnly: pat.OwnerOnly, ReadOnly: pat.ReadOnly, CaptureOnBlock: pat.CaptureOnBlock, Policy: policy}, nil)
+		}
+
+		for _, proto := range spec.Network.MatchProtocols {
+			add(tp.EffectiveRule{Kind: "networkProtocol", Entity: strings.ToLower(proto.Protocol), Action: proto.Action, Policy: policy}, proto.FromSource)
+		}
+
+		for _, cap := range spec.Capabilities.MatchCapabilities {
+			add(tp.EffectiveRule{Kind: "capability", Entity: strings.ToLower(cap.Capability), Action: cap.Action, Policy: policy}, cap.FromSource)
+		}
+	}
+
+	// kind/entity/source -> the index of the rule taking precedence
+	winners := map[string]int{}
+	for idx, rule := range rules {
+		key := rule.Kind + "|" + rule.Entity + "|" + rule.Source
+		if winner, ok := winners[key]; !ok || actionPrecedence(rule.Action) > actionPrecedence(rules[winner].Action) {
+			winners[key] = idx
+		}
+	}
+
+	for idx, rule := range rules {
+		if winner := winners[rule.Kind+"|"+rule.Entity+"|"+rule.Source]; winner != idx {
+			rules[idx].Overridden = true
+			rules[idx].OverriddenBy = rules[winner].Policy
+		}
+
+		if rule.Action != "Allow" {
+			continue
+		}
+
+		switch rule.Kind {
+		case "processPath", "processDirectory", "processPattern":
+			effective.ProcessAllowList = true
+		case "filePath", "fileDirectory", "filePattern":
+			effective.FileAllowList = true
+		case "networkProtocol":
+			effective.NetworkAllowList = true
+		case "capability":
+			effective.CapabilitiesAllowList = true
+		}
+	}
+
+	// the rule taking precedence comes first among the ones for the same entity
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Kind != rules[j].Kind {
+			return effectiveRuleKinds[rules[i].Kind] < effectiveRuleKinds[rules[j].Kind]
+		}
+		if rules[i].Entity != rules[j].Entity {
+			return rules[i].Entity < rules[j].Entity
+		}
+		if rules[i].Source != rules[j].Source {
+			return rules[i].Source < rules[j].Source
+		}
+		return !rules[i].Overridden && rules[j].Overridden
+	})
+
+	effective.Rules = rules
+
+	return effective
+}
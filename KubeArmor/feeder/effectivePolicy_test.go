@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files")
+
+// overlappingPolicies returns the policies of an endpoint with rules for the same entities
+func overlappingPolicies() []tp.SecurityPolicy {
+	allowApp := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "web", "policyName": "allow-app"}}
+	allowApp.Spec.Process.MatchPaths = []tp.ProcessPathType{
+		{Path: "/app", Action: "Allow"},
+		{Path: "/bin/bash", Action: "Allow"},
+	}
+	allowApp.Spec.File.MatchDirectories = []tp.FileDirectoryType{
+		{Directory: "/etc/", Recursive: true, ReadOnly: true, Action: "Allow"},
+	}
+	allowApp.Spec.Network.MatchProtocols = []tp.NetworkProtocolType{
+		{Protocol: "TCP", Action: "Allow"},
+	}
+
+	blockShell := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "web", "policyName": "block-shell"}}
+	blockShell.Spec.Process.MatchPaths = []tp.ProcessPathType{
+		{Path: "/bin/bash", Action: "Block"},
+		{Path: "/usr/bin/curl", FromSource: []tp.MatchSourceType{{Path: "/app"}, {Path: "/bin/bash"}}, Action: "Block"},
+	}
+	blockShell.Spec.Network.MatchProtocols = []tp.NetworkProtocolType{
+		{Protocol: "tcp", Action: "Audit"},
+	}
+
+	auditSecrets := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "web", "policyName": "audit-secrets"}}
+	auditSecrets.Spec.File.MatchDirectories = []tp.FileDirectoryType{
+		{Directory: "/etc/", Recursive: true, Action: "Audit"},
+	}
+	auditSecrets.Spec.File.MatchPaths = []tp.FilePathType{
+		{Path: "/etc/shadow", Action: "Block"},
+	}
+
+	throttleCurl := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "web", "policyName": "throttle-curl"}}
+	throttleCurl.Spec.Process.MatchPaths = []tp.ProcessPathType{
+		{Path: "/usr/bin/curl", FromSource: []tp.MatchSourceType{{Path: "/app"}}, Action: "Throttle", Rate: 10},
+		{Path: "/usr/bin/curl", Action: "Throttle", Rate: 10},
+	}
+
+	// the same Block rule as block-shell, with another option
+	blockShellAgain := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "web", "policyName": "block-shell-again"}}
+	blockShellAgain.Spec.Process.MatchPaths = []tp.ProcessPathType{
+		{Path: "/bin/bash", OwnerOnly: true, Action: "Block"},
+	}
+	blockShellAgain.Spec.Capabilities.MatchCapabilities = []tp.CapabilitiesCapabilityType{
+		{Capability: "NET_RAW", Action: "Block"},
+	}
+
+	return []tp.SecurityPolicy{throttleCurl, blockShellAgain, allowApp, auditSecrets, blockShell}
+}
+
+func TestMergeSecurityPolicies(t *testing.T) {
+	policies := overlappingPolicies()
+
+	effective := MergeSecurityPolicies(policies)
+
+	got, err := json.MarshalIndent(effective, "", "  ")
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to marshal the effective policy (%s)", err.Error())
+	}
+	got = append(got, '\n')
+
+	golden := "testdata/effectivePolicy.golden.json"
+	if *updateGolden {
+		if err := os.WriteFile(golden, got, 0600); err != nil {
+			t.Fatalf("[FAIL] Failed to update %s (%s)", golden, err.Error())
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to read %s (%s)", golden, err.Error())
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("[FAIL] Unexpected effective policy (go test -run TestMergeSecurityPolicies -update to accept)\n%s", got)
+	}
+
+	// the same whatever the order of the policies is
+	for i := 0; i < len(policies); i++ {
+		rotated := append(append([]tp.SecurityPolicy{}, policies[i:]...), policies[:i]...)
+
+		again, _ := json.MarshalIndent(MergeSecurityPolicies(rotated), "", "  ")
+		if !bytes.Equal(append(again, '\n'), got) {
+			t.Errorf("[FAIL] Expected the same effective policy for the policies rotated by %d", i)
+		}
+	}
+
+	t.Log("[PASS] Merged overlapping policies into the effective policy")
+}
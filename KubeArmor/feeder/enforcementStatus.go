@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ======================== //
+// == Enforcement Status == //
+// ======================== //
+
+// enforcement statuses of the alerts of Block rules
+const (
+	// the operation was denied
+	EnforcementEnforced = "Enforced"
+	// the operation passed while the rule was in place
+	EnforcementFailed = "Failed"
+	// the operation passed before the rule was delivered to the enforcer, or without any enforcer
+	EnforcementBestEffort = "BestEffort"
+)
+
+// EnforcementFailurePolicyName is the policy name of the alerts of undelivered Block verdicts
+const EnforcementFailurePolicyName = "kubearmor-enforcement-failure"
+
+// enforcementFailureSeverity is the severity of the alerts of undelivered Block verdicts
+const enforcementFailureSeverity = "8"
+
+// setAttachedTimes sets when the rules of each policy were delivered, keeping the times of the
+// policies delivered already
+func setAttachedTimes(policies, previous []tp.MatchPolicy, now time.Time) {
+	attached := map[string]time.Time{}
+	for _, policy := range previous {
+		if !policy.Attached.IsZero() {
+			attached[policy.PolicyName] = policy.Attached
+		}
+	}
+
+	for idx := range policies {
+		if at, ok := attached[policies[idx].PolicyName]; ok {
+			policies[idx].Attached = at
+		} else {
+			policies[idx].Attached = now
+		}
+	}
+}
+
+// blockExpected checks if a Block rule whose source and resource matched a log should have denied it
+// (the same conditions as the Audit (Block) rules)
+func blockExpected(secPolicy tp.MatchPolicy, matchedFlags bool) bool {
+	return (matchedFlags && !secPolicy.OwnerOnly && !secPolicy.ReadOnly) ||
+		(!matchedFlags && (secPolicy.OwnerOnly || secPolicy.ReadOnly))
+}
+
+// undeliveredBlockStatus returns the enforcement status of a log which passed a matched Block rule;
+// the events up to the second in which the rule was delivered are in the attach race window
+func undeliveredBlockStatus(log tp.Log, secPolicy tp.MatchPolicy, enforcer string) string {
+	if enforcer == "" || enforcer == "eBPF Monitor" {
+		return EnforcementBestEffort
+	}
+	if !secPolicy.Attached.IsZero() && log.Timestamp <= secPolicy.Attached.Unix() {
+		return EnforcementBestEffort
+	}
+	return EnforcementFailed
+}
+
+// setEnforcementStatus sets the enforcement status of the alerts of Block rules
+func setEnforcementStatus(log *tp.Log) {
+	if log.Action != "Block" {
+		// the status of a Block rule overridden by another rule
+		log.EnforcementStatus = ""
+		return
+	}
+
+	if log.Result != "Passed" {
+		log.EnforcementStatus = EnforcementEnforced
+	}
+}
+
+// countEnforcementFailure Function
+func (fd *Feeder) countEnforcementFailure(enforcer string) {
+	if fd.EnforcementFailuresLock == nil {
+		return
+	}
+
+	fd.EnforcementFailuresLock.Lock()
+	defer fd.EnforcementFailuresLock.Unlock()
+
+	fd.EnforcementFailures[enforcer]++
+}
+
+// GetEnforcementFailures returns the number of undelivered Block verdicts per enforcer
+func (fd *Feeder) GetEnforcementFailures() map[string]uint64 {
+	failures := map[string]uint64{}
+
+	if fd.EnforcementFailuresLock == nil {
+		return failures
+	}
+
+	fd.EnforcementFailuresLock.RLock()
+	defer fd.EnforcementFailuresLock.RUnlock()
+
+	for enforcer, count := range fd.EnforcementFailures {
+		failures[enforcer] = count
+	}
+
+	return failures
+}
+
+// enforcementFailureCollector exports the undelivered Block verdicts per enforcer
+type enforcementFailureCollector struct {
+	fd *Feeder
+
+	failures *prometheus.Desc
+}
+
+// newEnforcementFailureCollector Function
+func newEnforcementFailureCollector(fd *Feeder) *enforcementFailureCollector {
+	return &enforcementFailureCollector{
+		fd:       fd,
+		failures: prometheus.NewDesc("kubearmor_enforcement_failures_total", "Number of Block verdicts which weren't applied by an enforcer", []string{"enforcer"}, nil),
+	}
+}
+
+// Describe Function
+func (ec *enforcementFailureCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ec.failures
+}
+
+// Collect Function
+func (ec *enforcementFailureCollector) Collect(ch chan<- prometheus.Metric) {
+	for enforcer, count := range ec.fd.GetEnforcementFailures() {
+		ch <- prometheus.MustNewConstMetric(ec.failures, prometheus.CounterValue, float64(count), enforcer)
+	}
+}
+
+// enforcementFailureLog returns the alert raised for an undelivered Block verdict
+func enforcementFailureLog(log tp.Log) tp.Log {
+	failure := log
+
+	failure.PolicyName = EnforcementFailurePolicyName
+	failure.PolicySeverity = ""
+	failure.Severity = enforcementFailureSeverity
+	failure.Tags = "KUBEARMOR,ENFORCEMENT"
+	failure.ATags = strings.Split(failure.Tags, ",")
+	failure.Message = fmt.Sprintf("Block verdict of %s was not applied by %s", log.PolicyName, log.Enforcer)
+
+	failure.Enforcer = "KubeArmor"
+	failure.Action = "Audit"
+	failure.EnforcementStatus = ""
+	failure.Capture = nil
+
+	return failure
+}
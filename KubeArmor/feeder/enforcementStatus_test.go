@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestEnforcementStatus(t *testing.T) {
+	feeder := &Feeder{Node: &tp.Node{}, Output: "none"}
+	feeder.SecurityPolicies = map[string]tp.MatchPolicies{}
+	feeder.SecurityPoliciesLock = new(sync.RWMutex)
+	feeder.DefaultPostures = map[string]tp.DefaultPosture{}
+	feeder.EndPointPostures = map[string]tp.DefaultPosture{}
+	feeder.DefaultPosturesLock = new(sync.Mutex)
+	feeder.SeverityRangesLock = new(sync.RWMutex)
+	feeder.SinksLock = new(sync.RWMutex)
+	feeder.EnforcementFailures = map[string]uint64{}
+	feeder.EnforcementFailuresLock = new(sync.RWMutex)
+	feeder.Enforcer = "AppArmor"
+
+	// subscribe to the alerts
+	alerts := make(chan *pb.Alert, 4)
+	AlertLock = new(sync.RWMutex)
+	AlertStructs = map[string]AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+	defer func() { AlertStructs = map[string]AlertStruct{} }()
+
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "block-sh"}}
+	policy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Block"}}
+
+	endPoint := tp.EndPoint{NamespaceName: "web", EndPointName: "frontend", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{policy}
+	feeder.UpdateSecurityPolicies("ADDED", endPoint)
+
+	// the rules were delivered a minute ago, and keep that time when the endpoint is updated
+	attached := time.Now().Add(-time.Minute)
+	feeder.SecurityPolicies["web_frontend"].Policies[0].Attached = attached
+	feeder.UpdateSecurityPolicies("MODIFIED", endPoint)
+
+	if at := feeder.SecurityPolicies["web_frontend"].Policies[0].Attached; !at.Equal(attached) {
+		t.Errorf("[FAIL] Expected the attach time to be kept (%v)", at)
+	}
+
+	exec := tp.Log{ContainerID: "frontend", NamespaceName: "web", PodName: "frontend", Operation: "Process", Source: "/bin/bash", Resource: "/bin/sh", ProcessName: "/bin/sh", Result: "Passed", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+
+	// denied by the enforcer
+	denied := exec
+	denied.Timestamp = time.Now().Unix()
+	denied.Result = "Permission denied"
+
+	feeder.pushMatchedLog(feeder.UpdateMatchedPolicy(denied))
+	if alert := <-alerts; alert.EnforcementStatus != EnforcementEnforced {
+		t.Errorf("[FAIL] Expected an enforced block (%s)", alert.EnforcementStatus)
+	}
+
+	// started before the rule was delivered
+	early := exec
+	early.Timestamp = attached.Add(-time.Second).Unix()
+
+	feeder.pushMatchedLog(feeder.UpdateMatchedPolicy(early))
+	if alert := <-alerts; alert.PolicyName != "block-sh" || alert.Action != "Block" || alert.EnforcementStatus != EnforcementBestEffort {
+		t.Errorf("[FAIL] Expected a best-effort block (%s, %s)", alert.PolicyName, alert.EnforcementStatus)
+	}
+
+	// passed while the rule was in place
+	late := exec
+	late.Timestamp = time.Now().Unix()
+
+	feeder.pushMatchedLog(feeder.UpdateMatchedPolicy(late))
+	if alert := <-alerts; alert.EnforcementStatus != EnforcementFailed || alert.Result != "Passed" {
+		t.Errorf("[FAIL] Expected a failed block (%s, %s)", alert.EnforcementStatus, alert.Result)
+	}
+
+	select {
+	case alert := <-alerts:
+		if alert.PolicyName != EnforcementFailurePolicyName || alert.Severity != "8" || alert.EnforcementStatus != "" {
+			t.Errorf("[FAIL] Unexpected alert of the failure (%+v)", alert)
+		}
+	default:
+		t.Errorf("[FAIL] Expected an alert of the failure")
+	}
+
+	if failures := feeder.GetEnforcementFailures(); len(failures) != 1 || failures["AppArmor"] != 1 {
+		t.Errorf("[FAIL] Unexpected enforcement failures (%v)", failures)
+	}
+
+	// exported along with the policy metrics
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newEnforcementFailureCollector(feeder))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to gather the metrics (%s)", err.Error())
+	}
+	if len(families) != 1 || families[0].GetName() != "kubearmor_enforcement_failures_total" || families[0].GetMetric()[0].GetCounter().GetValue() != 1 ||
+		families[0].GetMetric()[0].GetLabel()[0].GetValue() != "AppArmor" {
+		t.Errorf("[FAIL] Unexpected metrics of the enforcement failures (%v)", families)
+	}
+
+	// audited endpoints don't expect blocks
+	audited := late
+	audited.PolicyEnabled = tp.KubeArmorPolicyAudited
+
+	if log := feeder.UpdateMatchedPolicy(audited); log.EnforcementStatus != "" {
+		t.Errorf("[FAIL] Unexpected status for an audited endpoint (%s)", log.EnforcementStatus)
+	}
+
+	// no enforcer to apply the verdict
+	feeder.Enforcer = "eBPF Monitor"
+
+	if log := feeder.UpdateMatchedPolicy(late); log.EnforcementStatus != EnforcementBestEffort {
+		t.Errorf("[FAIL] Expected a best-effort block without enforcer (%s)", log.EnforcementStatus)
+	}
+
+	t.Log("[PASS] Reported the enforcement status of blocks")
+}
+
+func TestAlertEnforcer(t *testing.T) {
+	feeder := &Feeder{Node: &tp.Node{}, Output: "none"}
+	feeder.SecurityPolicies = map[string]tp.MatchPolicies{}
+	feeder.SecurityPoliciesLock = new(sync.RWMutex)
+	feeder.DefaultPostures = map[string]tp.DefaultPosture{}
+	feeder.EndPointPostures = map[string]tp.DefaultPosture{}
+	feeder.DefaultPosturesLock = new(sync.Mutex)
+	feeder.SeverityRangesLock = new(sync.RWMutex)
+	feeder.SinksLock = new(sync.RWMutex)
+
+	// subscribe to the alerts and the logs, and to the alerts of AppArmor only
+	alerts := make(chan *pb.Alert, 4)
+	appArmorAlerts := make(chan *pb.Alert, 4)
+	AlertLock = new(sync.RWMutex)
+	AlertStructs = map[string]AlertStruct{
+		"all":      {Filter: "all", Broadcast: alerts},
+		"apparmor": {Filter: "all", Enforcers: []string{"AppArmor"}, Broadcast: appArmorAlerts},
+	}
+	defer func() { AlertStructs = map[string]AlertStruct{} }()
+
+	logs := make(chan *pb.Log, 4)
+	LogLock = new(sync.RWMutex)
+	LogStructs = map[string]LogStruct{"all": {Filter: "all", Broadcast: logs}}
+	defer func() { LogStructs = map[string]LogStruct{} }()
+
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "block-sh"}}
+	policy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Block"}}
+
+	endPoint := tp.EndPoint{NamespaceName: "web", EndPointName: "frontend", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{policy}
+	feeder.UpdateSecurityPolicies("ADDED", endPoint)
+
+	denied := tp.Log{ContainerID: "frontend", NamespaceName: "web", PodName: "frontend", Operation: "Process", Source: "/bin/bash", Resource: "/bin/sh", ProcessName: "/bin/sh", Result: "Permission denied", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+
+	// the verdicts of each enforcer
+	for _, enforcer := range []string{"BPFLSM", "AppArmor", "SELinux"} {
+		feeder.Enforcer = enforcer
+
+		feeder.pushMatchedLog(feeder.UpdateMatchedPolicy(denied))
+		if alert := <-alerts; alert.Action != "Block" || alert.Enforcer != enforcer {
+			t.Errorf("[FAIL] Expected a block of %s (%s, %s)", enforcer, alert.Action, alert.Enforcer)
+		}
+	}
+
+	if len(appArmorAlerts) != 1 {
+		t.Errorf("[FAIL] Expected the alerts of AppArmor only (%d)", len(appArmorAlerts))
+	}
+
+	// visibility-only events
+	visible := tp.Log{ContainerID: "frontend", NamespaceName: "web", PodName: "frontend", Type: "ContainerLog", Operation: "File", Source: "/bin/cat", Resource: "/etc/hosts", Result: "Passed"}
+
+	feeder.pushMatchedLog(visible)
+	if log := <-logs; log.Enforcer != "eBPF Monitor" {
+		t.Errorf("[FAIL] Expected the log of the monitor (%s)", log.Enforcer)
+	}
+
+	t.Log("[PASS] Reported the enforcer of the verdicts")
+}
@@ -0,0 +1,242 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ========================= //
+// == Enrichment Pipeline == //
+// ========================= //
+
+// Enricher Interface adds information to the alerts and the logs before they are emitted
+type Enricher interface {
+	Enrich(log *tp.Log) error
+}
+
+// EnrichFunc adapts a function to the Enricher interface
+type EnrichFunc func(log *tp.Log) error
+
+// Enrich Function
+func (f EnrichFunc) Enrich(log *tp.Log) error {
+	return f(log)
+}
+
+// EnrichmentStage Structure
+type EnrichmentStage struct {
+	// name of the stage, used by -enrichmentStages and by the metrics
+	Name string
+
+	// the stages run in ascending order (then by name)
+	Order int
+
+	// whether the stage runs unless it's disabled by -enrichmentStages
+	Enabled bool
+
+	// creates the enricher of a feeder
+	New func(fd *Feeder) Enricher
+}
+
+var (
+	// registered stages (name -> stage)
+	enrichmentStages     = map[string]EnrichmentStage{}
+	enrichmentStagesLock = new(sync.RWMutex)
+)
+
+// RegisterEnrichmentStage Function registers a stage, from the init function of the file of the stage
+func RegisterEnrichmentStage(stage EnrichmentStage) {
+	if stage.Name == "" || stage.New == nil {
+		panic("enrichment stage without a name or an enricher")
+	}
+
+	enrichmentStagesLock.Lock()
+	defer enrichmentStagesLock.Unlock()
+
+	if _, ok := enrichmentStages[stage.Name]; ok {
+		panic(fmt.Sprintf("enrichment stage %s is registered already", stage.Name))
+	}
+
+	enrichmentStages[stage.Name] = stage
+}
+
+// enrichmentStep Structure
+type enrichmentStep struct {
+	name     string
+	enricher Enricher
+
+	duration prometheus.Observer
+	failures prometheus.Counter
+
+	// the first failure is logged, the others are only counted
+	failed atomic.Bool
+}
+
+// the latency of the stages is measured on one event out of enrichmentSampleRate, to keep the clock off the hot path
+var enrichmentSampleRate uint64 = 64
+
+// EnrichmentPipeline Structure
+type EnrichmentPipeline struct {
+	steps []*enrichmentStep
+
+	// events enriched, to sample the latency
+	events atomic.Uint64
+
+	// buffers of the events passed to the stages, which would escape to the heap otherwise
+	buffers sync.Pool
+
+	durations *prometheus.HistogramVec
+	failures  *prometheus.CounterVec
+}
+
+// NewEnrichmentPipeline Function creates the pipeline of the registered stages enabled by default or by the toggles
+func NewEnrichmentPipeline(fd *Feeder, toggles map[string]bool) *EnrichmentPipeline {
+	ep := &EnrichmentPipeline{}
+
+	ep.buffers.New = func() interface{} {
+		return new(tp.Log)
+	}
+
+	ep.durations = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "kubearmor",
+		Name:      "enrichment_stage_duration_seconds",
+		Help:      "Time spent on enriching an alert or a log, per stage (sampled)",
+		Buckets:   prometheus.ExponentialBuckets(0.0000001, 4, 10), // 100ns - 26ms
+	}, []string{"stage"})
+
+	ep.failures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kubearmor",
+		Name:      "enrichment_stage_failures_total",
+		Help:      "Number of the alerts and the logs which a stage failed to enrich",
+	}, []string{"stage"})
+
+	enrichmentStagesLock.RLock()
+	stages := []EnrichmentStage{}
+	for _, stage := range enrichmentStages {
+		stages = append(stages, stage)
+	}
+	enrichmentStagesLock.RUnlock()
+
+	for name := range toggles {
+		found := false
+		for _, stage := range stages {
+			if stage.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			kg.Warnf("Unknown enrichment stage (%s)", name)
+		}
+	}
+
+	sort.Slice(stages, func(i, j int) bool {
+		if stages[i].Order != stages[j].Order {
+			return stages[i].Order < stages[j].Order
+		}
+		return stages[i].Name < stages[j].Name
+	})
+
+	for _, stage := range stages {
+		enabled := stage.Enabled
+		if toggle, ok := toggles[stage.Name]; ok {
+			enabled = toggle
+		}
+		if !enabled {
+			continue
+		}
+
+		ep.steps = append(ep.steps, &enrichmentStep{
+			name:     stage.Name,
+			enricher: stage.New(fd),
+			duration: ep.durations.WithLabelValues(stage.Name),
+			failures: ep.failures.WithLabelValues(stage.Name),
+		})
+	}
+
+	return ep
+}
+
+// Register Function serves the metrics of the stages with the given registry
+func (ep *EnrichmentPipeline) Register(registry *prometheus.Registry) {
+	registry.MustRegister(ep.durations, ep.failures)
+}
+
+// Stages Function returns the names of the stages in order
+func (ep *EnrichmentPipeline) Stages() []string {
+	names := []string{}
+	for _, step := range ep.steps {
+		names = append(names, step.name)
+	}
+	return names
+}
+
+// run Function runs a stage, and turns its panic into a failure
+func (step *enrichmentStep) run(log *tp.Log) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return step.enricher.Enrich(log)
+}
+
+// Enrich Function runs the stages in order, a failing stage is skipped without dropping the event
+func (ep *EnrichmentPipeline) Enrich(log *tp.Log) {
+	sampled := enrichmentSampleRate <= 1 || ep.events.Add(1)%enrichmentSampleRate == 0
+
+	for _, step := range ep.steps {
+		var err error
+		if sampled {
+			start := time.Now()
+			err = step.run(log)
+			step.duration.Observe(time.Since(start).Seconds())
+		} else {
+			err = step.run(log)
+		}
+
+		if err != nil {
+			step.failures.Inc()
+			if !step.failed.Swap(true) {
+				kg.Warnf("Enrichment stage %s failed, the next failures are only counted (%s)", step.name, err.Error())
+			}
+		}
+	}
+}
+
+// EnrichLog Function runs the stages on a copy of the event, and returns the enriched event
+func (ep *EnrichmentPipeline) EnrichLog(log tp.Log) tp.Log {
+	buf := ep.buffers.Get().(*tp.Log)
+
+	*buf = log
+	ep.Enrich(buf)
+	log = *buf
+
+	// no references are kept by the pool
+	*buf = tp.Log{}
+	ep.buffers.Put(buf)
+
+	return log
+}
+
+// enrichment returns the pipeline of the feeder, created on first use for the feeders not created by NewFeeder
+func (fd *Feeder) enrichment() *EnrichmentPipeline {
+	fd.enrichmentOnce.Do(func() {
+		if fd.Enrichment == nil {
+			fd.Enrichment = NewEnrichmentPipeline(fd, cfg.GlobalCfg.EnrichmentStages)
+		}
+	})
+	return fd.Enrichment
+}
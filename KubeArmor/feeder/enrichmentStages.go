@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ===================== //
+// == Built-in Stages == //
+// ===================== //
+
+// orders of the built-in stages, spaced to leave room for the stages of forks
+const (
+	EnrichmentOrderSeverityLabel = 100
+	EnrichmentOrderHostName      = 200
+	EnrichmentOrderSchemaVersion = 300
+)
+
+func init() {
+	// named level of the severity of the alerts (the numeric severity stays authoritative)
+	RegisterEnrichmentStage(EnrichmentStage{
+		Name:    "severityLabel",
+		Order:   EnrichmentOrderSeverityLabel,
+		Enabled: true,
+		New: func(fd *Feeder) Enricher {
+			return EnrichFunc(func(log *tp.Log) error {
+				if log.Type == "MatchedPolicy" || log.Type == "MatchedHostPolicy" {
+					log.SeverityLabel = cfg.SeverityLabel(cfg.GlobalCfg.SeverityLevels, log.Severity)
+				}
+				return nil
+			})
+		},
+	})
+
+	// name of the node
+	RegisterEnrichmentStage(EnrichmentStage{
+		Name:    "hostName",
+		Order:   EnrichmentOrderHostName,
+		Enabled: true,
+		New: func(fd *Feeder) Enricher {
+			return EnrichFunc(func(log *tp.Log) error {
+				log.HostName = cfg.GlobalCfg.Host
+				return nil
+			})
+		},
+	})
+
+	// version of the telemetry schema
+	RegisterEnrichmentStage(EnrichmentStage{
+		Name:    "schemaVersion",
+		Order:   EnrichmentOrderSchemaVersion,
+		Enabled: true,
+		New: func(fd *Feeder) Enricher {
+			return EnrichFunc(func(log *tp.Log) error {
+				log.SchemaVersion = fd.schemaVersion()
+				return nil
+			})
+		},
+	})
+}
@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	// the stages of the tests are disabled unless they're enabled by the toggles
+	RegisterEnrichmentStage(EnrichmentStage{
+		Name:  "testFirst",
+		Order: 10,
+		New: func(fd *Feeder) Enricher {
+			return EnrichFunc(func(log *tp.Log) error {
+				log.Data = "first"
+				return nil
+			})
+		},
+	})
+	RegisterEnrichmentStage(EnrichmentStage{
+		Name:  "testFailing",
+		Order: EnrichmentOrderSeverityLabel + 1,
+		New: func(fd *Feeder) Enricher {
+			return EnrichFunc(func(log *tp.Log) error {
+				log.Data = log.Data + ",failing"
+				return errors.New("no data")
+			})
+		},
+	})
+	RegisterEnrichmentStage(EnrichmentStage{
+		Name:  "testPanicking",
+		Order: EnrichmentOrderHostName + 1,
+		New: func(fd *Feeder) Enricher {
+			return EnrichFunc(func(log *tp.Log) error {
+				var owner *tp.PodOwner
+				log.Data = log.Data + "," + owner.Name
+				return nil
+			})
+		},
+	})
+}
+
+// gatherEnrichmentFailures returns the failures per stage
+func gatherEnrichmentFailures(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to gather the metrics (%s)", err.Error())
+	}
+
+	failures := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "kubearmor_enrichment_stage_failures_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "stage" {
+					failures[label.GetValue()] = metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return failures
+}
+
+func TestEnrichmentPipeline(t *testing.T) {
+	prevHost := cfg.GlobalCfg.Host
+	defer func() { cfg.GlobalCfg.Host = prevHost }()
+	cfg.GlobalCfg.Host = "node-1"
+
+	feeder := &Feeder{SchemaVersion: "v1"}
+
+	// the built-in stages by default
+	if stages := feeder.enrichment().Stages(); !reflect.DeepEqual(stages, []string{"severityLabel", "hostName", "schemaVersion"}) {
+		t.Errorf("[FAIL] Unexpected default stages (%v)", stages)
+	}
+
+	// the stages in order, with the toggles
+	pipeline := NewEnrichmentPipeline(feeder, map[string]bool{"testFirst": true, "testFailing": true, "testPanicking": true, "hostName": false, "unknown": true})
+
+	expected := []string{"testFirst", "severityLabel", "testFailing", "testPanicking", "schemaVersion"}
+	if stages := pipeline.Stages(); !reflect.DeepEqual(stages, expected) {
+		t.Errorf("[FAIL] Unexpected stages (%v)", stages)
+	}
+
+	registry := prometheus.NewRegistry()
+	pipeline.Register(registry)
+
+	// the failing stages are skipped, and the event is still enriched by the others
+	for i := 0; i < 2; i++ {
+		log := pipeline.EnrichLog(tp.Log{Type: "MatchedPolicy", Severity: "9"})
+
+		if log.Data != "first,failing" || log.SeverityLabel != "critical" || log.HostName != "" || log.SchemaVersion != "v1" {
+			t.Errorf("[FAIL] Unexpected enrichment (%+v)", log)
+		}
+	}
+
+	failures := gatherEnrichmentFailures(t, registry)
+	if failures["testFailing"] != 2 || failures["testPanicking"] != 2 || failures["severityLabel"] != 0 {
+		t.Errorf("[FAIL] Unexpected failures of the stages (%v)", failures)
+	}
+
+	t.Log("[PASS] Enriched the events with the stages in order")
+}
+
+// benchmarkLog keeps the enriched events alive
+var benchmarkLog tp.Log
+
+// BenchmarkEnrichment compares the built-in stages run by the pipeline with the same enrichment inline
+func BenchmarkEnrichment(b *testing.B) {
+	feeder := &Feeder{SchemaVersion: "v1"}
+	pipeline := NewEnrichmentPipeline(feeder, nil)
+
+	b.Run("inline", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			log := tp.Log{Type: "MatchedPolicy", Severity: "5"}
+			log.SeverityLabel = cfg.SeverityLabel(cfg.GlobalCfg.SeverityLevels, log.Severity)
+			log.HostName = cfg.GlobalCfg.Host
+			log.SchemaVersion = feeder.schemaVersion()
+			benchmarkLog = log
+		}
+	})
+
+	b.Run("pipeline", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchmarkLog = pipeline.EnrichLog(tp.Log{Type: "MatchedPolicy", Severity: "5"})
+		}
+	})
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ================== //
+// == Exec Session == //
+// ================== //
+
+// auditSessionRule audits the Block and Throttle rules conditioned on the exec sessions, since the enforcers can't tell
+// the sessions apart (the conditions of Allow rules are ignored, as the enforcers allow the operations in any session)
+func auditSessionRule(match *tp.MatchPolicy) {
+	if !match.OnlyExecSession && !match.ExcludeExecSession {
+		return
+	}
+
+	switch match.Action {
+	case "Block", "Throttle":
+		match.Action = "Audit (" + match.Action + ")"
+	case "Allow", "Audit (Allow)":
+		kg.Warnf("Ignored the exec session conditions of an Allow rule of %s (%s)", match.PolicyName, match.Resource)
+		match.OnlyExecSession = false
+		match.ExcludeExecSession = false
+	}
+}
+
+// matchSession checks if a rule matches the session of an event
+func matchSession(secPolicy tp.MatchPolicy, log tp.Log) bool {
+	if secPolicy.OnlyExecSession && log.Session != tp.ExecSession {
+		return false
+	}
+	if secPolicy.ExcludeExecSession && log.Session == tp.ExecSession {
+		return false
+	}
+	return true
+}
+
+// IsSessionRule checks if a rule is conditioned on the exec sessions (and left to the feeder by the enforcers)
+func IsSessionRule(onlyExecSession, excludeExecSession bool, action string) bool {
+	return (onlyExecSession || excludeExecSession) && action != "Allow"
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"sync"
+	"testing"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestExecSessionPolicy(t *testing.T) {
+	feeder := &Feeder{}
+	feeder.SecurityPolicies = map[string]tp.MatchPolicies{}
+	feeder.SecurityPoliciesLock = new(sync.RWMutex)
+	feeder.DefaultPostures = map[string]tp.DefaultPosture{}
+	feeder.EndPointPostures = map[string]tp.DefaultPosture{}
+	feeder.DefaultPosturesLock = new(sync.Mutex)
+	feeder.Enforcer = "AppArmor"
+
+	// the shells of kubectl exec, and the secrets read by the workload
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "exec-sessions"}}
+	policy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/bash", OnlyExecSession: true, Action: "Block"}}
+	policy.Spec.File.MatchDirectories = []tp.FileDirectoryType{{Directory: "/run/secrets/", Recursive: true, ExcludeExecSession: true, Action: "Audit"}}
+
+	endPoint := tp.EndPoint{NamespaceName: "web", EndPointName: "frontend", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{policy}
+	feeder.UpdateSecurityPolicies("ADDED", endPoint)
+
+	bash := tp.Log{ContainerID: "frontend", NamespaceName: "web", PodName: "frontend", Operation: "Process", Source: "/usr/bin/runc",
+		ProcessName: "/bin/bash", Resource: "/bin/bash", Result: "Passed"}
+
+	// the workload runs bash
+	if log := feeder.UpdateMatchedPolicy(bash); log.PolicyName == "exec-sessions" {
+		t.Errorf("[FAIL] Unexpected alert of bash out of an exec session (%s)", log.Action)
+	}
+
+	// kubectl exec runs bash (audited, as the enforcers can't tell the sessions apart)
+	bash.Session = tp.ExecSession
+	if log := feeder.UpdateMatchedPolicy(bash); log.PolicyName != "exec-sessions" || log.Action != "Audit (Block)" || log.Session != tp.ExecSession {
+		t.Errorf("[FAIL] Expected an alert of bash in an exec session (%s, %s, %s)", log.PolicyName, log.Action, log.Session)
+	}
+
+	secret := tp.Log{ContainerID: "frontend", NamespaceName: "web", PodName: "frontend", Operation: "File", Source: "/usr/bin/app",
+		ProcessName: "/usr/bin/app", Resource: "/run/secrets/token", Result: "Passed"}
+
+	if log := feeder.UpdateMatchedPolicy(secret); log.PolicyName != "exec-sessions" || log.Action != "Audit" {
+		t.Errorf("[FAIL] Expected an alert of the secret read out of an exec session (%s, %s)", log.PolicyName, log.Action)
+	}
+
+	secret.Session = tp.ExecSession
+	if log := feeder.UpdateMatchedPolicy(secret); log.PolicyName == "exec-sessions" {
+		t.Errorf("[FAIL] Unexpected alert of the secret read in an exec session (%s)", log.Action)
+	}
+
+	// the Block rule is reported as audited on the node
+	if differences := AnalyzePolicyCompatibility(feeder.Enforcer, policy.Spec); len(differences) != 1 || differences[0] != "process path /bin/bash is conditioned on exec sessions, audited instead of enforced" {
+		t.Errorf("[FAIL] Unexpected differences of the policy (%v)", differences)
+	}
+
+	t.Log("[PASS] Matched the rules conditioned on the exec sessions")
+}
@@ -5,15 +5,16 @@
 package feeder
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
@@ -61,7 +62,14 @@ var MsgLock *sync.RWMutex
 // AlertStruct Structure
 type AlertStruct struct {
 	Filter    string
+	Enforcers []string
 	Broadcast chan *pb.Alert
+
+	// client address, queue policy, and worker delivering the alerts
+	Peer       string
+	DropPolicy string
+	Dropped    *atomic.Uint64
+	Worker     int
 }
 
 // AlertStructs Map
@@ -73,7 +81,13 @@ var AlertLock *sync.RWMutex
 // LogStruct Structure
 type LogStruct struct {
 	Filter    string
+	Enforcers []string
 	Broadcast chan *pb.Log
+
+	// client address and queue policy
+	Peer       string
+	DropPolicy string
+	Dropped    *atomic.Uint64
 }
 
 // LogStructs Map
@@ -84,12 +98,44 @@ var LogLock *sync.RWMutex
 
 // LogService Structure
 type LogService struct {
-	//
+	GetSinkStats          func() []SinkStats
+	IsEnforcementDegraded func() bool
+	IsSinkLagging         func() bool
+
+	// delivery of the alerts and the logs to the clients
+	Streams *StreamDispatcher
 }
 
 // HealthCheck Function
 func (ls *LogService) HealthCheck(ctx context.Context, nonce *pb.NonceMessage) (*pb.ReplyMessage, error) {
 	replyMessage := pb.ReplyMessage{Retval: nonce.Nonce}
+
+	// health of the alert sinks
+	if ls.GetSinkStats != nil {
+		for _, stats := range ls.GetSinkStats() {
+			replyMessage.Sinks = append(replyMessage.Sinks, &pb.SinkStatus{
+				Name:    stats.Name,
+				Health:  stats.Health,
+				Queued:  int32(stats.Queued),
+				Sent:    stats.Sent,
+				Dropped: stats.Dropped,
+
+				OldestQueuedAgeMs: stats.OldestQueuedAge.Milliseconds(),
+				DeliveryLagMs:     stats.DeliveryLag.Milliseconds(),
+			})
+		}
+	}
+
+	// not ready while a sink lags behind over the threshold (if configured)
+	if ls.IsSinkLagging != nil {
+		replyMessage.SinksLagging = ls.IsSinkLagging()
+	}
+
+	// not ready while the enforcement of some endpoints is degraded
+	if ls.IsEnforcementDegraded != nil {
+		replyMessage.EnforcementDegraded = ls.IsEnforcementDegraded()
+	}
+
 	return &replyMessage, nil
 }
 
@@ -147,16 +193,21 @@ func (ls *LogService) WatchMessages(req *pb.RequestMessage, svr pb.LogService_Wa
 }
 
 // addAlertStruct Function
-func (ls *LogService) addAlertStruct(uid string, conn chan *pb.Alert, filter string) {
+func (ls *LogService) addAlertStruct(uid string, conn chan *pb.Alert, filter string, enforcers []string, peer, dropPolicy string) {
 	AlertLock.Lock()
 	defer AlertLock.Unlock()
 
 	alertStruct := AlertStruct{}
 	alertStruct.Filter = filter
+	alertStruct.Enforcers = enforcers
 	alertStruct.Broadcast = conn
+	alertStruct.Peer = peer
+	alertStruct.DropPolicy = dropPolicy
+	alertStruct.Dropped = new(atomic.Uint64)
+	alertStruct.Worker = ls.Streams.assignAlertWorker()
 	AlertStructs[uid] = alertStruct
 
-	kg.Printf("Added a new client (%s, %s) for WatchAlerts", uid, filter)
+	kg.Printf("Added a new client (%s, %s, %s) for WatchAlerts", uid, filter, peer)
 }
 
 // removeAlertStruct Function
@@ -176,9 +227,10 @@ func (ls *LogService) WatchAlerts(req *pb.RequestMessage, svr pb.LogService_Watc
 	if req.Filter != "all" && req.Filter != "policy" {
 		return nil
 	}
-	conn := make(chan *pb.Alert, QueueSize)
+	queueSize, dropPolicy := newClientQueue(StreamAlerts)
+	conn := make(chan *pb.Alert, queueSize)
 	defer close(conn)
-	ls.addAlertStruct(uid, conn, req.Filter)
+	ls.addAlertStruct(uid, conn, req.Filter, req.Enforcers, getPeer(svr.Context()), dropPolicy)
 	defer ls.removeAlertStruct(uid)
 
 	for Running {
@@ -204,16 +256,20 @@ func (ls *LogService) WatchAlerts(req *pb.RequestMessage, svr pb.LogService_Watc
 }
 
 // addLogStruct Function
-func (ls *LogService) addLogStruct(uid string, conn chan *pb.Log, filter string) {
+func (ls *LogService) addLogStruct(uid string, conn chan *pb.Log, filter string, enforcers []string, peer, dropPolicy string) {
 	LogLock.Lock()
 	defer LogLock.Unlock()
 
 	logStruct := LogStruct{}
 	logStruct.Filter = filter
+	logStruct.Enforcers = enforcers
 	logStruct.Broadcast = conn
+	logStruct.Peer = peer
+	logStruct.DropPolicy = dropPolicy
+	logStruct.Dropped = new(atomic.Uint64)
 	LogStructs[uid] = logStruct
 
-	kg.Printf("Added a new client (%s, %s) for WatchLogs", uid, filter)
+	kg.Printf("Added a new client (%s, %s, %s) for WatchLogs", uid, filter, peer)
 }
 
 // removeLogStruct Function
@@ -233,9 +289,10 @@ func (ls *LogService) WatchLogs(req *pb.RequestMessage, svr pb.LogService_WatchL
 	if req.Filter != "all" && req.Filter != "system" {
 		return nil
 	}
-	conn := make(chan *pb.Log, QueueSize)
+	queueSize, dropPolicy := newClientQueue(StreamLogs)
+	conn := make(chan *pb.Log, queueSize)
 	defer close(conn)
-	ls.addLogStruct(uid, conn, req.Filter)
+	ls.addLogStruct(uid, conn, req.Filter, req.Enforcers, getPeer(svr.Context()), dropPolicy)
 	defer ls.removeLogStruct(uid)
 
 	for Running {
@@ -277,11 +334,17 @@ type Feeder struct {
 	Output  string
 	LogFile *os.File
 
-	// gRPC listener
-	Listener net.Listener
+	// compressed segments instead of the log file (archival mode)
+	Archive *AlertArchive
 
-	// log server
-	LogServer *grpc.Server
+	// version of the telemetry schema of the emitted records
+	SchemaVersion string
+
+	// gRPC listeners, each one with its own server
+	Listeners []*GRPCListener
+
+	// authorizer of the gRPC clients (nil if all clients are allowed)
+	Authorizer *GRPCAuthorizer
 
 	// wait group
 	WgServer sync.WaitGroup
@@ -294,11 +357,69 @@ type Feeder struct {
 	DefaultPostures     map[string]tp.DefaultPosture
 	DefaultPosturesLock *sync.Mutex
 
+	// DefaultPosture of endpoints with overrides (namespace_pod -> postures)
+	EndPointPostures map[string]tp.DefaultPosture
+
+	// SeverityRange (namespace -> severity range)
+	SeverityRanges     map[string]tp.SeverityRange
+	SeverityRangesLock *sync.RWMutex
+
 	// GKE
 	IsGKE bool
 
 	// Activated Enforcer
 	Enforcer string
+
+	// additional alert sinks, each one with its own queue
+	Sinks     []*SinkWorker
+	SinksLock *sync.RWMutex
+
+	// sink of the log file (or the archive), also in Sinks
+	fileSink *SinkWorker
+
+	SinkQueueSize    int
+	SinkDrainTimeout time.Duration
+
+	// a sink is degraded when it lags behind over the threshold, and KubeArmor isn't ready if SinkLagUnready
+	SinkLagThreshold time.Duration
+	SinkLagUnready   bool
+
+	// matches of Allow rules in policies with logAllowed
+	AllowTelemetry *AllowTelemetry
+
+	// token buckets of Throttle rules
+	Throttler *Throttler
+
+	// metadata of the recently destroyed containers (nil if not kept)
+	DestroyedContainers *DestroyedContainers
+
+	// reduced telemetry while the node is under maintenance
+	Quiesced atomic.Bool
+
+	// undelivered Block verdicts per enforcer
+	EnforcementFailures     map[string]uint64
+	EnforcementFailuresLock *sync.RWMutex
+
+	// some endpoints are enforced in Audit only after enforcer errors
+	EnforcementDegraded atomic.Bool
+
+	// clock of the maturation periods of the policies and of the delivery lag of the sinks
+	Now func() time.Time
+
+	// time spent on applying the policies
+	PolicyMetrics *PolicyMetrics
+	metricsServer *http.Server
+
+	// delivery of the alerts and the logs to the gRPC clients
+	Streams *StreamDispatcher
+
+	// enrichment stages of the alerts and the logs
+	Enrichment     *EnrichmentPipeline
+	enrichmentOnce sync.Once
+
+	// fields dropped or hashed before the alerts and the logs are emitted (nil if none)
+	TelemetryFields     *TelemetryFieldPolicy
+	telemetryFieldsOnce sync.Once
 }
 
 // NewFeeder Function
@@ -315,8 +436,24 @@ func NewFeeder(node *tp.Node, nodeLock **sync.RWMutex) *Feeder {
 	// output
 	fd.Output = cfg.GlobalCfg.LogPath
 
+	// telemetry schema
+	fd.SchemaVersion = emittedTelemetrySchema().Version
+
 	// output mode
-	if fd.Output != "stdout" && fd.Output != "none" {
+	if fd.Output != "stdout" && fd.Output != "none" && cfg.GlobalCfg.LogArchive {
+		archive, err := NewAlertArchive(AlertArchiveConfig{
+			Dir:     filepath.Clean(fd.Output),
+			Segment: cfg.GlobalCfg.LogArchiveSegment,
+			MaxSize: int64(cfg.GlobalCfg.LogArchiveMaxSize) * 1024 * 1024,
+			MaxAge:  cfg.GlobalCfg.LogArchiveMaxAge,
+		})
+		if err != nil {
+			kg.Errf("Failed to open the archive in %s (%s)", fd.Output, err.Error())
+			return nil
+		}
+		archive.Start()
+		fd.Archive = archive
+	} else if fd.Output != "stdout" && fd.Output != "none" {
 		// #nosec
 		logFile, err := os.OpenFile(filepath.Clean(fd.Output), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 		if err != nil {
@@ -326,15 +463,28 @@ func NewFeeder(node *tp.Node, nodeLock **sync.RWMutex) *Feeder {
 		fd.LogFile = logFile
 	}
 
-	// listen to gRPC port
-	listener, err := net.Listen("tcp", fd.Port)
-	if err != nil {
-		kg.Errf("Failed to listen a port (%s, %s)", fd.Port, err.Error())
-		return nil
+	// listen to gRPC port, or the configured listeners
+	listenerConfigs := cfg.GlobalCfg.GRPCListeners
+	if len(listenerConfigs) == 0 {
+		listenerConfigs = []cfg.GRPCListener{{Network: "tcp", Address: fd.Port, Services: cfg.GRPCServices}}
 	}
-	fd.Listener = listener
 
-	if cfg.GlobalCfg.GRPC == "0" {
+	// authorize the gRPC clients by their identities, or allow all of them
+	fd.Authorizer = NewGRPCAuthorizer(cfg.GlobalCfg.GRPCAuthz)
+
+	for _, config := range listenerConfigs {
+		listener, err := NewGRPCListener(config, fd.Authorizer)
+		if err != nil {
+			kg.Errf("Failed to listen (%s, %s)", config.String(), err.Error())
+			fd.closeGRPCListeners()
+			return nil
+		}
+		fd.Listeners = append(fd.Listeners, listener)
+	}
+
+	if len(cfg.GlobalCfg.GRPCListeners) == 0 && cfg.GlobalCfg.GRPC == "0" {
+		listener := fd.Listeners[0].Listener
+
 		pidFile, err := os.Create(cfg.PIDFilePath)
 		if err != nil {
 			kg.Errf("Failed to create file %s", cfg.PIDFilePath)
@@ -358,12 +508,15 @@ func NewFeeder(node *tp.Node, nodeLock **sync.RWMutex) *Feeder {
 		}
 	}
 
-	// create a log server
-	fd.LogServer = grpc.NewServer()
+	// deliver the alerts and the logs apart from each other
+	fd.Streams = NewStreamDispatcher(cfg.GlobalCfg.AlertWorkers, QueueSize)
+	fd.Streams.Start()
 
 	// register a log service
-	logService := &LogService{}
-	pb.RegisterLogServiceServer(fd.LogServer, logService)
+	logService := &LogService{GetSinkStats: fd.GetSinkStats, IsEnforcementDegraded: fd.EnforcementDegraded.Load, IsSinkLagging: fd.sinksLaggingUnready, Streams: fd.Streams}
+	fd.RegisterService(cfg.GRPCServiceLog, func(server *grpc.Server) {
+		pb.RegisterLogServiceServer(server, logService)
+	})
 
 	// initialize msg structs
 	MsgStructs = make(map[string]MsgStruct)
@@ -377,6 +530,11 @@ func NewFeeder(node *tp.Node, nodeLock **sync.RWMutex) *Feeder {
 	LogStructs = make(map[string]LogStruct)
 	LogLock = &sync.RWMutex{}
 
+	// initialize policy event structs
+	PolicyEventStructs = make(map[string]PolicyEventStruct)
+	AppliedPolicies = make(map[string]*pb.PolicyEvent)
+	PolicyEventLock = &sync.RWMutex{}
+
 	// set wait group
 	fd.WgServer = sync.WaitGroup{}
 
@@ -387,6 +545,75 @@ func NewFeeder(node *tp.Node, nodeLock **sync.RWMutex) *Feeder {
 	// initialize default postures
 	fd.DefaultPostures = map[string]tp.DefaultPosture{}
 	fd.DefaultPosturesLock = new(sync.Mutex)
+	fd.EndPointPostures = map[string]tp.DefaultPosture{}
+
+	// initialize severity ranges
+	fd.SeverityRanges = map[string]tp.SeverityRange{}
+	fd.SeverityRangesLock = new(sync.RWMutex)
+
+	// initialize alert sinks
+	fd.Sinks = []*SinkWorker{}
+	fd.SinksLock = new(sync.RWMutex)
+
+	fd.SinkQueueSize = cfg.GlobalCfg.SinkQueueSize
+	fd.SinkDrainTimeout = cfg.GlobalCfg.SinkDrainTimeout
+	if fd.SinkDrainTimeout <= 0 {
+		fd.SinkDrainTimeout = DefaultSinkDrainTimeout
+	}
+
+	fd.SinkLagThreshold = cfg.GlobalCfg.SinkLagThreshold
+	fd.SinkLagUnready = cfg.GlobalCfg.SinkLagUnready
+
+	// initialize allow telemetry
+	fd.AllowTelemetry = NewAllowTelemetry(AllowTelemetryFlushInterval)
+	fd.AllowTelemetry.Start(fd.pushMatchedLog)
+
+	// initialize throttler
+	fd.Throttler = NewThrottler()
+	fd.Throttler.Start()
+
+	// keep the destroyed containers for their late events
+	if cfg.GlobalCfg.DestroyedContainerRetention > 0 {
+		fd.DestroyedContainers = NewDestroyedContainers(cfg.GlobalCfg.DestroyedContainerRetention, cfg.GlobalCfg.DestroyedContainerLimit)
+		fd.DestroyedContainers.Start()
+	}
+
+	// initialize the counters of enforcement failures
+	fd.EnforcementFailures = map[string]uint64{}
+	fd.EnforcementFailuresLock = new(sync.RWMutex)
+
+	// the maturation periods of the policies are measured in wall-clock time
+	fd.Now = time.Now
+
+	// the logs are written into the log file (or the archive) from the queue of a sink
+	if fd.LogFile != nil || fd.Archive != nil {
+		fd.AddLogFileSink(fd.LogFile, fd.Archive)
+	}
+
+	// initialize policy metrics
+	fd.PolicyMetrics = NewPolicyMetrics(cfg.GlobalCfg.MetricsMaxPolicies)
+
+	// the queues of the gRPC clients are served along with the policy metrics
+	fd.PolicyMetrics.Registry.MustRegister(newStreamCollector(fd.Streams))
+
+	// the delivery lag of the alert sinks as well
+	fd.PolicyMetrics.Registry.MustRegister(newSinkCollector(fd))
+
+	// the undelivered Block verdicts as well
+	fd.PolicyMetrics.Registry.MustRegister(newEnforcementFailureCollector(fd))
+
+	// the calls denied to the gRPC clients as well
+	if fd.Authorizer != nil {
+		fd.PolicyMetrics.Registry.MustRegister(fd.Authorizer)
+	}
+
+	// initialize the enrichment stages
+	fd.Enrichment = NewEnrichmentPipeline(fd, cfg.GlobalCfg.EnrichmentStages)
+	fd.Enrichment.Register(fd.PolicyMetrics.Registry)
+	kg.Printf("Enabled the enrichment stages %v", fd.Enrichment.Stages())
+
+	// initialize the telemetry field policy
+	fd.TelemetryFields = newTelemetryFieldPolicy(cfg.GlobalCfg.TelemetryFieldPolicy)
 
 	// check if GKE
 	if kl.IsInK8sCluster() {
@@ -412,45 +639,39 @@ func (fd *Feeder) DestroyFeeder() error {
 	// wait for a while
 	time.Sleep(time.Second * 1)
 
-	// close listener
-	if fd.Listener != nil {
-		if err := fd.Listener.Close(); err != nil {
-			kg.Err(err.Error())
-		}
-		fd.Listener = nil
+	// close listeners
+	fd.closeGRPCListeners()
+
+	// stop metrics server
+	fd.closeMetricsServer()
+
+	// stop allow telemetry
+	if fd.AllowTelemetry != nil {
+		fd.AllowTelemetry.Close()
 	}
 
-	// close LogFile
-	if fd.LogFile != nil {
-		if err := fd.LogFile.Close(); err != nil {
-			kg.Err(err.Error())
-		}
-		fd.LogFile = nil
+	// stop throttler
+	if fd.Throttler != nil {
+		fd.Throttler.Close()
 	}
 
-	// wait for other routines
-	fd.WgServer.Wait()
+	// stop pruning the destroyed containers
+	if fd.DestroyedContainers != nil {
+		fd.DestroyedContainers.Close()
+	}
 
-	return nil
-}
+	// close alert sinks (the log file and the archive as well)
+	fd.closeSinks()
 
-// StrToFile Function
-func (fd *Feeder) StrToFile(str string) {
-	if fd.LogFile != nil {
-		// add the newline at the end of the string
-		str = str + "\n"
+	// stop delivering the alerts and the logs
+	if fd.Streams != nil {
+		fd.Streams.Close()
+	}
 
-		// write the string into the file
-		w := bufio.NewWriter(fd.LogFile)
-		if _, err := w.WriteString(str); err != nil {
-			kg.Err(err.Error())
-		}
+	// wait for other routines
+	fd.WgServer.Wait()
 
-		// flush the file buffer
-		if err := w.Flush(); err != nil {
-			kg.Err(err.Error())
-		}
-	}
+	return nil
 }
 
 // ============== //
@@ -528,9 +749,7 @@ func (fd *Feeder) ServeLogFeeds() {
 	defer fd.WgServer.Done()
 
 	// feed logs
-	if err := fd.LogServer.Serve(fd.Listener); err != nil {
-		kg.Print("Terminated the gRPC service")
-	}
+	fd.serveGRPCListeners()
 }
 
 // PushMessage Function
@@ -572,6 +791,8 @@ func (fd *Feeder) PushMessage(level, message string) {
 
 // PushLog Function
 func (fd *Feeder) PushLog(log tp.Log) {
+	// the containers destroyed before their events are matched
+	log = fd.enrichDestroyedContainer(log)
 
 	if cfg.GlobalCfg.EnforcerAlerts && fd.Enforcer == "BPFLSM" && log.Enforcer != "BPFLSM" {
 		log = fd.UpdateMatchedPolicy(log)
@@ -586,12 +807,48 @@ func (fd *Feeder) PushLog(log tp.Log) {
 		log = fd.UpdateMatchedPolicy(log)
 	}
 
+	fd.pushMatchedLog(log)
+}
+
+// PushSummaryLog sends a summary log as is, without matching policies
+func (fd *Feeder) PushSummaryLog(log tp.Log) {
+	fd.pushMatchedLog(log)
+}
+
+// pushMatchedLog sends a log whose policies are already matched
+func (fd *Feeder) pushMatchedLog(log tp.Log) {
 	if log.Source == "" {
 		return
 	}
 
-	// set hostname
-	log.HostName = cfg.GlobalCfg.Host
+	// apply the severity range of the namespace
+	log = fd.ApplySeverityRange(log)
+
+	if log.Type == "MatchedPolicy" || log.Type == "MatchedHostPolicy" {
+		setEnforcementStatus(&log)
+	}
+
+	// the events which no enforcer decided on are from the monitor
+	if log.Enforcer == "" {
+		log.Enforcer = "eBPF Monitor"
+	}
+
+	// raise an alert for the undelivered Block verdict as well
+	if log.EnforcementStatus == EnforcementFailed {
+		fd.countEnforcementFailure(log.Enforcer)
+		defer fd.pushMatchedLog(enforcementFailureLog(log))
+	}
+
+	// severity label, hostname, version of the telemetry schema, and the stages of forks
+	log = fd.enrichment().EnrichLog(log)
+
+	// only alerts while the node is under maintenance
+	if fd.Quiesced.Load() && log.Type != "MatchedPolicy" && log.Type != "MatchedHostPolicy" {
+		return
+	}
+
+	// drop or hash the fields which must not leave the node, after the policies are matched
+	fd.telemetryFields().Apply(&log)
 
 	// remove MergedDir
 	log.MergedDir = ""
@@ -602,6 +859,7 @@ func (fd *Feeder) PushLog(log tp.Log) {
 	log.FileVisibilityEnabled = false
 	log.NetworkVisibilityEnabled = false
 	log.CapabilitiesVisibilityEnabled = false
+	log.SignalVisibilityEnabled = false
 
 	// standard output / file output
 	if fd.Output == "stdout" {
@@ -609,7 +867,7 @@ func (fd *Feeder) PushLog(log tp.Log) {
 		fmt.Println(string(arr))
 	} else if fd.Output != "none" {
 		arr, _ := json.Marshal(log)
-		fd.StrToFile(string(arr))
+		fd.pushLineToFile(arr)
 	}
 
 	// gRPC output
@@ -643,6 +901,9 @@ func (fd *Feeder) PushLog(log tp.Log) {
 		pbAlert.ContainerID = log.ContainerID
 		pbAlert.ContainerName = log.ContainerName
 		pbAlert.ContainerImage = log.ContainerImage
+		pbAlert.Privileged = log.Privileged
+		pbAlert.ContainerState = log.ContainerState
+		pbAlert.ContainerType = log.ContainerType
 
 		pbAlert.HostPPID = log.HostPPID
 		pbAlert.HostPID = log.HostPID
@@ -662,10 +923,19 @@ func (fd *Feeder) PushLog(log tp.Log) {
 			pbAlert.PolicyName = log.PolicyName
 		}
 
+		pbAlert.PolicyNamespace = log.PolicyNamespace
+		pbAlert.MatchedRule = log.MatchedRule
+
 		if len(log.Severity) > 0 {
 			pbAlert.Severity = log.Severity
 		}
 
+		if len(log.PolicySeverity) > 0 {
+			pbAlert.PolicySeverity = log.PolicySeverity
+		}
+
+		pbAlert.SeverityLabel = log.SeverityLabel
+
 		if len(log.Tags) > 0 {
 			pbAlert.Tags = log.Tags
 			pbAlert.ATags = strings.Split(log.Tags, ",")
@@ -680,6 +950,26 @@ func (fd *Feeder) PushLog(log tp.Log) {
 		pbAlert.Operation = log.Operation
 		pbAlert.Resource = strings.ToValidUTF8(log.Resource, "")
 		pbAlert.Cwd = log.Cwd
+		pbAlert.SocketCreator = log.SocketCreator
+		pbAlert.Session = log.Session
+		pbAlert.ClockResync = log.ClockResync
+		pbAlert.PostureSource = log.PostureSource
+		pbAlert.WouldBeBlocked = log.WouldBeBlocked
+
+		if log.Capture != nil {
+			pbAlert.Capture = &pb.WriteCapture{
+				Source:    log.Capture.Source,
+				FD:        log.Capture.FD,
+				Path:      log.Capture.Path,
+				Offset:    log.Capture.Offset,
+				Flags:     log.Capture.Flags,
+				Size:      log.Capture.Size,
+				Sample:    log.Capture.Sample,
+				Truncated: log.Capture.Truncated,
+				Redacted:  log.Capture.Redacted,
+				Handles:   log.Capture.Handles,
+			}
+		}
 
 		if len(log.Data) > 0 {
 			pbAlert.Data = log.Data
@@ -690,24 +980,14 @@ func (fd *Feeder) PushLog(log tp.Log) {
 		}
 
 		pbAlert.Result = log.Result
+		pbAlert.EnforcementStatus = log.EnforcementStatus
+		pbAlert.OwnerIdentity = log.OwnerIdentity
+		pbAlert.SchemaVersion = log.SchemaVersion
 
-		AlertLock.Lock()
-		defer AlertLock.Unlock()
-		counter := 0
-		lenAlert := len(AlertStructs)
-
-		for uid := range AlertStructs {
-			select {
-			case AlertStructs[uid].Broadcast <- &pbAlert:
-			default:
-				counter++
-				if counter == lenAlert {
-					//Default on the last uid in Alterstruct means the Alert isnt pushed into Broadcast
-					kg.Printf("log channel busy, alert dropped.")
-				}
+		// alert sinks
+		fd.pushAlertToSinks(&pbAlert)
 
-			}
-		}
+		fd.dispatchAlert(&pbAlert)
 	} else { // ContainerLog || HostLog
 		pbLog := pb.Log{}
 
@@ -738,6 +1018,8 @@ func (fd *Feeder) PushLog(log tp.Log) {
 		pbLog.ContainerID = log.ContainerID
 		pbLog.ContainerName = log.ContainerName
 		pbLog.ContainerImage = log.ContainerImage
+		pbLog.ContainerState = log.ContainerState
+		pbLog.ContainerType = log.ContainerType
 
 		pbLog.HostPPID = log.HostPPID
 		pbLog.HostPID = log.HostPID
@@ -754,27 +1036,18 @@ func (fd *Feeder) PushLog(log tp.Log) {
 		pbLog.Operation = log.Operation
 		pbLog.Resource = strings.ToValidUTF8(log.Resource, "")
 		pbLog.Cwd = log.Cwd
+		pbLog.SocketCreator = log.SocketCreator
+		pbLog.Session = log.Session
+		pbLog.ClockResync = log.ClockResync
+		pbLog.Enforcer = log.Enforcer
 
 		if len(log.Data) > 0 {
 			pbLog.Data = log.Data
 		}
 
 		pbLog.Result = log.Result
+		pbLog.SchemaVersion = log.SchemaVersion
 
-		LogLock.Lock()
-		defer LogLock.Unlock()
-		counter := 0
-		lenlog := len(LogStructs)
-		for uid := range LogStructs {
-			select {
-			case LogStructs[uid].Broadcast <- &pbLog:
-			default:
-				counter++
-				if counter == lenlog {
-					//Default on the last uid in Logstuct means the log isnt pushed into Broadcase
-					kg.Printf("log channel busy, log dropped.")
-				}
-			}
-		}
+		fd.dispatchLog(&pbLog)
 	}
 }
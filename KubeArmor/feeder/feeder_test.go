@@ -37,3 +37,98 @@ func TestFeeder(t *testing.T) {
 	}
 	t.Log("[PASS] Destroyed logger")
 }
+
+func TestApplySeverityRange(t *testing.T) {
+	feeder := &Feeder{}
+	feeder.SeverityRanges = map[string]tp.SeverityRange{}
+	feeder.SeverityRangesLock = new(sync.RWMutex)
+
+	feeder.UpdateSeverityRange("ADDED", "pci", tp.SeverityRange{Min: 7})
+	feeder.UpdateSeverityRange("ADDED", "dev", tp.SeverityRange{Max: 3})
+
+	alert := tp.Log{Type: "MatchedPolicy", NamespaceName: "pci", Severity: "2"}
+
+	// raised to the floor
+	if log := feeder.ApplySeverityRange(alert); log.Severity != "7" || log.PolicySeverity != "2" {
+		t.Errorf("[FAIL] Expected severity 7 (policy 2), got %s (policy %s)", log.Severity, log.PolicySeverity)
+	}
+
+	// already above the floor
+	alert.Severity = "9"
+	if log := feeder.ApplySeverityRange(alert); log.Severity != "9" || log.PolicySeverity != "9" {
+		t.Errorf("[FAIL] Expected severity 9, got %s", log.Severity)
+	}
+
+	// lowered to the ceiling
+	alert.NamespaceName = "dev"
+	if log := feeder.ApplySeverityRange(alert); log.Severity != "3" || log.PolicySeverity != "9" {
+		t.Errorf("[FAIL] Expected severity 3 (policy 9), got %s (policy %s)", log.Severity, log.PolicySeverity)
+	}
+
+	// no annotations
+	alert.NamespaceName = "default"
+	if log := feeder.ApplySeverityRange(alert); log.Severity != "9" || log.PolicySeverity != "" {
+		t.Errorf("[FAIL] Expected untouched severity, got %s (policy %s)", log.Severity, log.PolicySeverity)
+	}
+
+	// annotations removed at runtime
+	feeder.UpdateSeverityRange("MODIFIED", "dev", tp.SeverityRange{})
+	alert.NamespaceName = "dev"
+	if log := feeder.ApplySeverityRange(alert); log.Severity != "9" {
+		t.Errorf("[FAIL] Expected untouched severity after removal, got %s", log.Severity)
+	}
+
+	t.Log("[PASS] Applied severity ranges")
+}
+
+func TestDefaultPostureSource(t *testing.T) {
+	feeder := &Feeder{}
+	feeder.SecurityPolicies = map[string]tp.MatchPolicies{}
+	feeder.SecurityPoliciesLock = new(sync.RWMutex)
+	feeder.DefaultPostures = map[string]tp.DefaultPosture{}
+	feeder.EndPointPostures = map[string]tp.DefaultPosture{}
+	feeder.DefaultPosturesLock = new(sync.Mutex)
+
+	feeder.UpdateDefaultPosture("ADDED", "payments", tp.DefaultPosture{
+		FileAction: "audit", NetworkAction: "audit", CapabilitiesAction: "audit",
+		FileSource: "namespace-annotation:payments", NetworkSource: tp.PostureSourceGlobal, CapabilitiesSource: tp.PostureSourceGlobal,
+	})
+
+	// allow policies make the rest of the operations fall back to the default posture
+	secPolicy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "allow-app"}}
+	secPolicy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/app", Action: "Allow"}}
+	secPolicy.Spec.Network.MatchProtocols = []tp.NetworkProtocolType{{Protocol: "tcp", Action: "Allow"}}
+
+	endPoint := tp.EndPoint{NamespaceName: "payments", EndPointName: "checkout", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{secPolicy}
+	endPoint.DefaultPosture = feeder.DefaultPostures["payments"]
+	feeder.UpdateSecurityPolicies("ADDED", endPoint)
+	feeder.UpdateEndPointPosture("ADDED", endPoint)
+
+	log := tp.Log{ContainerID: "checkout", NamespaceName: "payments", PodName: "checkout", Operation: "Process", Resource: "/bin/sh", ProcessName: "/bin/sh", Result: "Passed"}
+	if alert := feeder.UpdateMatchedPolicy(log); alert.PolicyName != "DefaultPosture" || alert.PostureSource != "namespace-annotation:payments" {
+		t.Errorf("[FAIL] Expected a namespace posture alert, got %s (%s)", alert.PolicyName, alert.PostureSource)
+	}
+
+	// the pod overrides the posture of the namespace
+	endPoint.PostureOverride = tp.DefaultPosture{FileAction: "audit", FileSource: tp.PostureSourceEndPoint}
+	endPoint.DefaultPosture.FileSource = tp.PostureSourceEndPoint
+	feeder.UpdateEndPointPosture("MODIFIED", endPoint)
+
+	if alert := feeder.UpdateMatchedPolicy(log); alert.PolicyName != "DefaultPosture" || alert.PostureSource != tp.PostureSourceEndPoint {
+		t.Errorf("[FAIL] Expected an endpoint posture alert, got %s (%s)", alert.PolicyName, alert.PostureSource)
+	}
+
+	// policy matches don't carry a posture source
+	log.Resource, log.ProcessName = "/app", "/app"
+	if alert := feeder.UpdateMatchedPolicy(log); alert.PostureSource != "" {
+		t.Errorf("[FAIL] Unexpected posture source for an allowed process (%s)", alert.PostureSource)
+	}
+
+	feeder.UpdateEndPointPosture("DELETED", endPoint)
+	if _, ok := feeder.EndPointPostures["payments_checkout"]; ok {
+		t.Errorf("[FAIL] Expected the endpoint posture to be removed")
+	}
+
+	t.Log("[PASS] Reported the sources of default postures")
+}
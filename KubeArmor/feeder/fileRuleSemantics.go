@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import "strings"
+
+// ========================= //
+// == File Rule Semantics == //
+// ========================= //
+
+// The policy matcher and the enforcers (AppArmor and BPF-LSM) give the same verdicts on the file rules:
+//
+//   - a directory rule matches the directory itself and the files directly in it, or every file under it if recursive
+//   - a readOnly rule permits the reads only
+//   - an ownerOnly rule permits the accesses of the owner of the file only (the UID of the task being the UID of the
+//     file, or the group of the file being the fsGroup of the pod)
+//   - a rule with both flags permits the reads of the owner only
+//
+// A Block rule denies the accesses which its flags don't permit (all of them without flags), and an Allow rule allows
+// the accesses which its flags permit.
+
+// InDirectory checks if a path is matched by a rule of a directory (ending with /)
+func InDirectory(dir string, recursive bool, path string) bool {
+	if !strings.HasSuffix(dir, "/") {
+		dir = dir + "/"
+	}
+
+	// the directory itself
+	if path+"/" == dir || path == dir {
+		return true
+	}
+
+	if !strings.HasPrefix(path, dir) {
+		return false
+	}
+
+	// the files directly in the directory (a sub-directory itself included)
+	return recursive || !strings.Contains(strings.TrimSuffix(path[len(dir):], "/"), "/")
+}
+
+// PermittedByFlags checks if the readOnly and ownerOnly flags of a rule permit an access
+func PermittedByFlags(readOnly, ownerOnly, write, owner bool) bool {
+	return (!readOnly || !write) && (!ownerOnly || owner)
+}
+
+// BlockedByRule checks if a Block rule denies an access
+func BlockedByRule(readOnly, ownerOnly, write, owner bool) bool {
+	return (!readOnly && !ownerOnly) || !PermittedByFlags(readOnly, ownerOnly, write, owner)
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"path/filepath"
+	"strings"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ========================= //
+// == Fileless Executions == //
+// ========================= //
+
+// FilelessExecution is the description of the alerts of fileless rules
+const FilelessExecution = "fileless execution"
+
+// GetFilelessName checks if an executable has no stable path (memfd, O_TMPFILE, deleted files),
+// and returns the name of its memfd if any
+func GetFilelessName(path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+
+	path = strings.TrimSuffix(path, " (deleted)")
+	base := filepath.Base(path)
+
+	// memfd_create(name) -> /memfd:name
+	if strings.HasPrefix(base, "memfd:") {
+		return base, true
+	}
+
+	// open(O_TMPFILE) -> #inode
+	if strings.HasPrefix(base, "#") && len(base) > 1 && strings.Trim(base[1:], "0123456789") == "" {
+		return "", true
+	}
+
+	return "", false
+}
+
+// getFilelessMessage returns the message of an alert of a fileless rule
+func getFilelessMessage(message, name string) string {
+	fileless := FilelessExecution
+	if name != "" {
+		fileless = fileless + " (" + name + ")"
+	}
+
+	if message == "" {
+		return fileless
+	}
+
+	return message + " - " + fileless
+}
+
+// matchFilelessPolicy checks if a log is an execution of a file without a path, and returns the memfd name if any
+func matchFilelessPolicy(secPolicy tp.MatchPolicy, log tp.Log) (string, bool) {
+	if log.Operation != "Process" || isNamespaceLog(log) {
+		return "", false
+	}
+
+	// the executions denied by the enforcer are flagged, the others are recognized by their paths
+	name, fileless := "", false
+	if val := getLogDataField(log.Data, "fileless"); val != "" {
+		fileless = true
+		if val != "anonymous" {
+			name = val
+		}
+	} else {
+		name, fileless = GetFilelessName(log.ProcessName)
+	}
+
+	if !fileless {
+		return "", false
+	}
+
+	// exceptions (e.g., JITs, runc init)
+	for _, src := range secPolicy.ExceptSources {
+		if src == log.Source || src == log.ParentProcessName {
+			return "", false
+		}
+	}
+
+	return name, true
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestGetFilelessName(t *testing.T) {
+	for _, tc := range []struct {
+		path     string
+		name     string
+		fileless bool
+	}{
+		{"/memfd:payload", "memfd:payload", true},
+		{"/memfd:payload (deleted)", "memfd:payload", true},
+		{"/tmp/#1835021", "", true},
+		{"/tmp/#build", "", false},
+		{"/usr/bin/memfd:tool", "memfd:tool", true},
+		{"/usr/bin/curl", "", false},
+		{"", "", false},
+	} {
+		if name, fileless := GetFilelessName(tc.path); name != tc.name || fileless != tc.fileless {
+			t.Errorf("[FAIL] Unexpected fileless execution of %q (%q, %t)", tc.path, name, fileless)
+		}
+	}
+
+	t.Log("[PASS] Recognized the executions without a path")
+}
+
+func TestFilelessPolicy(t *testing.T) {
+	feeder := &Feeder{}
+	feeder.SecurityPolicies = map[string]tp.MatchPolicies{}
+	feeder.SecurityPoliciesLock = new(sync.RWMutex)
+	feeder.DefaultPostures = map[string]tp.DefaultPosture{}
+	feeder.EndPointPostures = map[string]tp.DefaultPosture{}
+	feeder.DefaultPosturesLock = new(sync.Mutex)
+	feeder.Enforcer = "BPFLSM"
+
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "block-fileless"}}
+	policy.Spec.Process.BlockFileless = &tp.ProcessFilelessType{
+		ExceptFromSource: []tp.MatchSourceType{{Path: "/usr/bin/runc"}},
+		Severity:         8,
+		Action:           "Block",
+	}
+
+	endPoint := tp.EndPoint{NamespaceName: "web", EndPointName: "frontend", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{policy}
+	feeder.UpdateSecurityPolicies("ADDED", endPoint)
+
+	// denied by the enforcer (flagged in the data)
+	denied := tp.Log{ContainerID: "frontend", NamespaceName: "web", PodName: "frontend", Operation: "Process", Source: "/bin/sh", Resource: "/memfd:payload",
+		Data: "lsm=SECURITY_BPRM_CHECK fileless=memfd:payload", Result: "Permission denied"}
+
+	log := feeder.UpdateMatchedPolicy(denied)
+	if log.PolicyName != "block-fileless" || log.Action != "Block" || log.Enforcer != "BPFLSM" {
+		t.Errorf("[FAIL] Unexpected alert of a denied fileless execution (%s, %s, %s)", log.PolicyName, log.Action, log.Enforcer)
+	}
+	if !strings.Contains(log.Message, FilelessExecution+" (memfd:payload)") {
+		t.Errorf("[FAIL] Expected the memfd name in the message (%s)", log.Message)
+	}
+
+	// the excepted sources aren't alerted
+	excepted := tp.Log{ContainerID: "frontend", NamespaceName: "web", PodName: "frontend", Operation: "Process", Source: "/usr/bin/runc", ProcessName: "/memfd:runc_cloned:/proc/self/exe",
+		Resource: "/memfd:runc_cloned:/proc/self/exe init", Result: "Passed"}
+
+	if log := feeder.UpdateMatchedPolicy(excepted); log.PolicyName == "block-fileless" {
+		t.Errorf("[FAIL] Unexpected alert of an excepted source")
+	}
+
+	// the regular executions aren't matched
+	exec := tp.Log{ContainerID: "frontend", NamespaceName: "web", PodName: "frontend", Operation: "Process", Source: "/bin/sh", ProcessName: "/usr/bin/curl", Resource: "/usr/bin/curl", Result: "Passed"}
+
+	if log := feeder.UpdateMatchedPolicy(exec); log.PolicyName == "block-fileless" {
+		t.Errorf("[FAIL] Unexpected alert of a regular execution")
+	}
+
+	// audited with the enforcers unable to deny them
+	feeder.Enforcer = "AppArmor"
+	feeder.UpdateSecurityPolicies("MODIFIED", endPoint)
+
+	passed := tp.Log{ContainerID: "frontend", NamespaceName: "web", PodName: "frontend", Operation: "Process", Source: "/bin/sh", ProcessName: "/tmp/#1835021", Resource: "/tmp/#1835021", Result: "Passed"}
+
+	log = feeder.UpdateMatchedPolicy(passed)
+	if log.Action != "Audit (Block)" || log.Enforcer != "eBPF Monitor" || log.Message != FilelessExecution {
+		t.Errorf("[FAIL] Unexpected alert of an audited fileless execution (%s, %s, %s)", log.Action, log.Enforcer, log.Message)
+	}
+
+	t.Log("[PASS] Matched the fileless executions")
+}
@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ======================== //
+// == gRPC Authorization == //
+// ======================== //
+
+// reflectionService is allowed to all clients, so that they can list the services of a listener
+const reflectionService = "grpc.reflection."
+
+// PeerCredInfo Structure is the identity of a client of a UDS listener, given by SO_PEERCRED
+type PeerCredInfo struct {
+	credentials.CommonAuthInfo
+
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+// AuthType Function
+func (PeerCredInfo) AuthType() string {
+	return "peercred"
+}
+
+// peerCredentials Structure reads the credentials of the clients of a UDS listener on their handshake
+type peerCredentials struct{}
+
+// ClientHandshake Function
+func (peerCredentials) ClientHandshake(_ context.Context, _ string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return conn, nil, errors.New("peer credentials are only read by servers")
+}
+
+// ServerHandshake Function
+func (peerCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, nil, fmt.Errorf("no peer credentials on %s", conn.RemoteAddr().Network())
+	}
+
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ucred *unix.Ucred
+	var credErr error
+
+	if err := raw.Control(func(fd uintptr) {
+		ucred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return nil, nil, err
+	}
+	if credErr != nil {
+		return nil, nil, credErr
+	}
+
+	// the connections of a local socket can't be observed by others
+	info := PeerCredInfo{CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity}}
+	info.PID, info.UID, info.GID = ucred.Pid, ucred.Uid, ucred.Gid
+
+	return conn, info, nil
+}
+
+// Info Function
+func (peerCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "peercred"}
+}
+
+// Clone Function
+func (peerCredentials) Clone() credentials.TransportCredentials {
+	return peerCredentials{}
+}
+
+// OverrideServerName Function
+func (peerCredentials) OverrideServerName(string) error {
+	return nil
+}
+
+// certIdentities returns the identities of a client certificate, given by its subject alternative names
+func certIdentities(cert *x509.Certificate) []string {
+	identities := []string{}
+
+	for _, name := range cert.DNSNames {
+		identities = append(identities, cfg.GRPCIdentitySAN+name)
+	}
+	for _, uri := range cert.URIs {
+		identities = append(identities, cfg.GRPCIdentitySAN+uri.String())
+	}
+	for _, email := range cert.EmailAddresses {
+		identities = append(identities, cfg.GRPCIdentitySAN+email)
+	}
+	for _, ip := range cert.IPAddresses {
+		identities = append(identities, cfg.GRPCIdentitySAN+ip.String())
+	}
+
+	return identities
+}
+
+// peerIdentities returns the identities of the client of a call
+func peerIdentities(ctx context.Context) []string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.AuthInfo == nil {
+		return nil
+	}
+
+	switch info := p.AuthInfo.(type) {
+	case PeerCredInfo:
+		return []string{cfg.GRPCIdentityUID + strconv.FormatUint(uint64(info.UID), 10)}
+	case credentials.TLSInfo:
+		// only the certificates verified against the CA of the listener
+		if len(info.State.VerifiedChains) == 0 || len(info.State.PeerCertificates) == 0 {
+			return nil
+		}
+		return certIdentities(info.State.PeerCertificates[0])
+	}
+
+	return nil
+}
+
+// matchMethod checks if a method (/package.Service/Method) matches a pattern (Service/Method, or *)
+func matchMethod(pattern, method string) bool {
+	if pattern == "*" {
+		return true
+	}
+
+	name := strings.TrimPrefix(method, "/")
+	if slash := strings.Index(name, "/"); slash > 0 {
+		if dot := strings.LastIndex(name[:slash], "."); dot >= 0 {
+			name = name[dot+1:]
+		}
+	}
+
+	matched, _ := path.Match(pattern, name)
+	return matched
+}
+
+// GRPCAuthorizer Structure allows the gRPC calls based on the identities of the clients
+type GRPCAuthorizer struct {
+	Rules []cfg.GRPCAuthzRule
+
+	// method -> denied calls
+	denied     map[string]uint64
+	deniedLock *sync.RWMutex
+
+	deniedDesc *prometheus.Desc
+}
+
+// NewGRPCAuthorizer returns an authorizer of the given rules, or nil to allow all clients
+func NewGRPCAuthorizer(rules []cfg.GRPCAuthzRule) *GRPCAuthorizer {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	return &GRPCAuthorizer{
+		Rules:      rules,
+		denied:     map[string]uint64{},
+		deniedLock: new(sync.RWMutex),
+		deniedDesc: prometheus.NewDesc("kubearmor_grpc_denied_calls_total", "Number of gRPC calls denied to their clients", []string{"method"}, nil),
+	}
+}
+
+// Allowed checks if any of the identities of a client is allowed to call a method
+func (ga *GRPCAuthorizer) Allowed(identities []string, method string) bool {
+	if strings.HasPrefix(strings.TrimPrefix(method, "/"), reflectionService) {
+		return true
+	}
+
+	for _, identity := range identities {
+		for _, rule := range ga.Rules {
+			if rule.Identity != identity {
+				continue
+			}
+			for _, pattern := range rule.Methods {
+				if matchMethod(pattern, method) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// authorize allows a call, or logs and counts its denial
+func (ga *GRPCAuthorizer) authorize(ctx context.Context, method string) error {
+	identities := peerIdentities(ctx)
+	if ga.Allowed(identities, method) {
+		return nil
+	}
+
+	ga.deniedLock.Lock()
+	ga.denied[method]++
+	ga.deniedLock.Unlock()
+
+	client := "unknown client"
+	if len(identities) > 0 {
+		client = strings.Join(identities, ", ")
+	}
+
+	kg.Warnf("Denied the gRPC call of %s (%s)", method, client)
+
+	return status.Errorf(codes.PermissionDenied, "%s is not allowed for %s", method, client)
+}
+
+// UnaryInterceptor Function
+func (ga *GRPCAuthorizer) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := ga.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor Function
+func (ga *GRPCAuthorizer) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := ga.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// GetDeniedCalls returns the number of the denied calls per method
+func (ga *GRPCAuthorizer) GetDeniedCalls() map[string]uint64 {
+	ga.deniedLock.RLock()
+	defer ga.deniedLock.RUnlock()
+
+	denied := make(map[string]uint64, len(ga.denied))
+	for method, count := range ga.denied {
+		denied[method] = count
+	}
+
+	return denied
+}
+
+// Describe Function
+func (ga *GRPCAuthorizer) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ga.deniedDesc
+}
+
+// Collect Function
+func (ga *GRPCAuthorizer) Collect(ch chan<- prometheus.Metric) {
+	for method, count := range ga.GetDeniedCalls() {
+		ch <- prometheus.MustNewConstMetric(ga.deniedDesc, prometheus.CounterValue, float64(count), method)
+	}
+}
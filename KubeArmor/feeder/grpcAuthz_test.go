@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// serveAuthorized serves the log and probe services on a listener authorizing its clients with the given rules
+func serveAuthorized(t *testing.T, listener string, rules string) (*Feeder, *GRPCAuthorizer) {
+	configs, err := cfg.ParseGRPCListeners(listener)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to parse the listener (%s)", err.Error())
+	}
+
+	authz, err := cfg.ParseGRPCAuthz(rules)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to parse the rules (%s)", err.Error())
+	}
+
+	fd := &Feeder{Authorizer: NewGRPCAuthorizer(authz)}
+
+	listen, err := NewGRPCListener(configs[0], fd.Authorizer)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to listen (%s)", err.Error())
+	}
+	fd.Listeners = append(fd.Listeners, listen)
+
+	fd.RegisterService(cfg.GRPCServiceLog, func(server *grpc.Server) {
+		pb.RegisterLogServiceServer(server, &LogService{})
+	})
+	fd.RegisterService(cfg.GRPCServiceProbe, func(server *grpc.Server) {
+		pb.RegisterProbeServiceServer(server, probeStub{})
+	})
+
+	go fd.serveGRPCListeners()
+
+	return fd, fd.Authorizer
+}
+
+// checkCalls calls the health check and the probe service, and checks their codes
+func checkCalls(t *testing.T, conn *grpc.ClientConn, health, probe codes.Code) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := pb.NewLogServiceClient(conn).HealthCheck(ctx, &pb.NonceMessage{Nonce: 1}); status.Code(err) != health {
+		t.Errorf("[FAIL] Unexpected health check (%v), expected %s", err, health)
+	}
+
+	if _, err := pb.NewProbeServiceClient(conn).GetProbeData(ctx, &emptypb.Empty{}); status.Code(err) != probe {
+		t.Errorf("[FAIL] Unexpected probe (%v), expected %s", err, probe)
+	}
+}
+
+func TestGRPCAuthzPeerCred(t *testing.T) {
+	socket := t.TempDir() + "/kubearmor.sock"
+	uid := os.Getuid()
+
+	// the uid of this process may only call the health checks
+	fd, authorizer := serveAuthorized(t, "unix://"+socket, fmt.Sprintf("uid:%d=LogService/HealthCheck;uid:%d=*", uid, uid+1))
+	defer fd.closeGRPCListeners()
+
+	conn, err := grpc.Dial("unix://"+socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to connect to %s (%s)", socket, err.Error())
+	}
+	defer conn.Close()
+
+	checkCalls(t, conn, codes.OK, codes.PermissionDenied)
+
+	// the streams are authorized as well
+	stream, err := pb.NewLogServiceClient(conn).WatchAlerts(context.Background(), &pb.RequestMessage{Filter: "all"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("[FAIL] Expected the alerts to be denied (%v)", err)
+	}
+
+	denied := authorizer.GetDeniedCalls()
+	if denied["/policy.ProbeService/getProbeData"] != 1 || denied["/feeder.LogService/WatchAlerts"] != 1 || len(denied) != 2 {
+		t.Errorf("[FAIL] Unexpected denied calls (%v)", denied)
+	}
+
+	t.Log("[PASS] Authorized the clients of a UDS listener by their uid")
+}
+
+// issueCert issues a certificate signed by the given CA (self-signed if nil)
+func issueCert(t *testing.T, ca *tls.Certificate, template *x509.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to generate a key (%s)", err.Error())
+	}
+
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	parent, signer := template, interface{}(key)
+	if ca != nil {
+		parent, signer = ca.Leaf, ca.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create a certificate (%s)", err.Error())
+	}
+
+	leaf, _ := x509.ParseCertificate(der)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// writeCert writes the PEM files of a certificate, and returns their paths
+func writeCert(t *testing.T, dir, name string, cert tls.Certificate) (string, string) {
+	keyDER, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to marshal a key (%s)", err.Error())
+	}
+
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to write %s (%s)", certFile, err.Error())
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to write %s (%s)", keyFile, err.Error())
+	}
+
+	return certFile, keyFile
+}
+
+func TestGRPCAuthzTLS(t *testing.T) {
+	dir := t.TempDir()
+
+	ca := issueCert(t, nil, &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "kubearmor-ca"}, IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign})
+	server := issueCert(t, &ca, &x509.Certificate{SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "kubearmor"}, IPAddresses: []net.IP{net.ParseIP("127.0.0.1")}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+
+	caFile, _ := writeCert(t, dir, "ca", ca)
+	certFile, keyFile := writeCert(t, dir, "tls", server)
+
+	// the telemetry agent may only read the feeds, the admin may call everything
+	fd, _ := serveAuthorized(t, fmt.Sprintf("tcp://127.0.0.1:0?tlsCert=%s&tlsKey=%s&tlsCA=%s", certFile, keyFile, caFile), "san:telemetry.kubearmor.io=LogService/*;san:spiffe://cluster/admin=*")
+	defer fd.closeGRPCListeners()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+
+	admin, _ := url.Parse("spiffe://cluster/admin")
+
+	for _, client := range []struct {
+		name   string
+		cert   *x509.Certificate
+		health codes.Code
+		probe  codes.Code
+	}{
+		{"telemetry", &x509.Certificate{SerialNumber: big.NewInt(3), DNSNames: []string{"telemetry.kubearmor.io"}}, codes.OK, codes.PermissionDenied},
+		{"admin", &x509.Certificate{SerialNumber: big.NewInt(4), URIs: []*url.URL{admin}}, codes.OK, codes.OK},
+		{"other", &x509.Certificate{SerialNumber: big.NewInt(5), DNSNames: []string{"other.kubearmor.io"}}, codes.PermissionDenied, codes.PermissionDenied},
+	} {
+		client.cert.Subject = pkix.Name{CommonName: client.name}
+		client.cert.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		cert := issueCert(t, &ca, client.cert)
+
+		creds := credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}, RootCAs: pool, MinVersion: tls.VersionTLS12})
+
+		conn, err := grpc.Dial(fd.Listeners[0].Listener.Addr().String(), grpc.WithTransportCredentials(creds))
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to connect as %s (%s)", client.name, err.Error())
+		}
+
+		t.Logf("Calling as %s", client.name)
+		checkCalls(t, conn, client.health, client.probe)
+
+		_ = conn.Close()
+	}
+
+	t.Log("[PASS] Authorized the clients of a mTLS listener by their certificates")
+}
@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
+)
+
+// ==================== //
+// == gRPC Listeners == //
+// ==================== //
+
+// GRPCListener Structure
+type GRPCListener struct {
+	Config cfg.GRPCListener
+
+	Listener net.Listener
+	Server   *grpc.Server
+}
+
+// loadServerTLS returns the TLS configuration of a listener
+func loadServerTLS(config cfg.GRPCListener) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if config.TLSCAFile != "" {
+		ca, err := os.ReadFile(filepath.Clean(config.TLSCAFile))
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate in %s", config.TLSCAFile)
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// NewGRPCListener listens on the address of a listener, and creates its server (authorizing its clients if an
+// authorizer is given)
+func NewGRPCListener(config cfg.GRPCListener, authorizer *GRPCAuthorizer) (*GRPCListener, error) {
+	opts := []grpc.ServerOption{}
+
+	if config.TLSCertFile != "" {
+		tlsConfig, err := loadServerTLS(config)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	} else if config.Network == "unix" && authorizer != nil {
+		// identify the clients of the socket by their uid
+		opts = append(opts, grpc.Creds(peerCredentials{}))
+	}
+
+	if authorizer != nil {
+		opts = append(opts, grpc.ChainUnaryInterceptor(authorizer.UnaryInterceptor), grpc.ChainStreamInterceptor(authorizer.StreamInterceptor))
+	}
+
+	if config.Network == "unix" {
+		// remove the socket file left by a previous instance
+		if err := os.Remove(config.Address); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen(config.Network, config.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Network == "unix" {
+		if err := os.Chmod(config.Address, config.Mode); err != nil {
+			_ = listener.Close()
+			return nil, err
+		}
+	}
+
+	return &GRPCListener{Config: config, Listener: listener, Server: grpc.NewServer(opts...)}, nil
+}
+
+// RegisterService registers a service on the listeners exposing it
+func (fd *Feeder) RegisterService(service string, register func(*grpc.Server)) {
+	for _, listener := range fd.Listeners {
+		if listener.Config.Serves(service) {
+			if service == cfg.GRPCServicePolicy && fd.Authorizer == nil {
+				kg.Warnf("Exposing the policy service on %s to all clients, set %s to authorize them", listener.Config.String(), cfg.ConfigGRPCAuthz)
+			}
+			register(listener.Server)
+		}
+	}
+}
+
+// serveGRPCListeners serves all listeners until they are closed
+func (fd *Feeder) serveGRPCListeners() {
+	var wg sync.WaitGroup
+
+	for _, listener := range fd.Listeners {
+		// helps gRPC clients list the services of each listener
+		reflection.Register(listener.Server)
+
+		wg.Add(1)
+		go func(listener *GRPCListener) {
+			defer wg.Done()
+
+			if err := listener.Server.Serve(listener.Listener); err != nil {
+				kg.Printf("Terminated the gRPC service (%s)", listener.Config.String())
+			}
+		}(listener)
+	}
+
+	wg.Wait()
+}
+
+// closeGRPCListeners stops the servers and closes the listeners (removing the socket files)
+func (fd *Feeder) closeGRPCListeners() {
+	for _, listener := range fd.Listeners {
+		listener.Server.Stop()
+
+		// no-op if the server was serving it
+		_ = listener.Listener.Close()
+
+		if listener.Config.Network == "unix" {
+			if err := os.Remove(listener.Config.Address); err != nil && !errors.Is(err, os.ErrNotExist) {
+				kg.Err(err.Error())
+			}
+		}
+	}
+
+	fd.Listeners = nil
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// probeStub Structure
+type probeStub struct {
+	pb.UnimplementedProbeServiceServer
+}
+
+// GetProbeData Function
+func (probeStub) GetProbeData(context.Context, *emptypb.Empty) (*pb.ProbeResponse, error) {
+	return &pb.ProbeResponse{ContainerList: []string{"nginx"}}, nil
+}
+
+func TestGRPCListeners(t *testing.T) {
+	socket := t.TempDir() + "/kubearmor.sock"
+
+	configs, err := cfg.ParseGRPCListeners("unix://" + socket + "?mode=0600&services=probe,admin; tcp://127.0.0.1:0?services=log")
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to parse the listeners (%s)", err.Error())
+	}
+
+	fd := &Feeder{}
+	defer fd.closeGRPCListeners()
+
+	for _, config := range configs {
+		listener, err := NewGRPCListener(config, nil)
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to listen (%s, %s)", config.String(), err.Error())
+		}
+		fd.Listeners = append(fd.Listeners, listener)
+	}
+
+	if info, err := os.Stat(socket); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("[FAIL] Unexpected socket file (%v, %v)", info, err)
+	}
+
+	fd.RegisterService(cfg.GRPCServiceLog, func(server *grpc.Server) {
+		pb.RegisterLogServiceServer(server, &LogService{})
+	})
+	fd.RegisterService(cfg.GRPCServiceProbe, func(server *grpc.Server) {
+		pb.RegisterProbeServiceServer(server, probeStub{})
+	})
+
+	served := make(chan struct{})
+	go func() {
+		fd.serveGRPCListeners()
+		close(served)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the log service only on TCP, the probe service only on the socket
+	for _, target := range []struct {
+		address string
+		log     codes.Code
+		probe   codes.Code
+	}{
+		{"unix://" + socket, codes.Unimplemented, codes.OK},
+		{fd.Listeners[1].Listener.Addr().String(), codes.OK, codes.Unimplemented},
+	} {
+		conn, err := grpc.DialContext(ctx, target.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to connect to %s (%s)", target.address, err.Error())
+		}
+
+		_, err = pb.NewLogServiceClient(conn).HealthCheck(ctx, &pb.NonceMessage{Nonce: 1})
+		if status.Code(err) != target.log {
+			t.Errorf("[FAIL] Unexpected health check on %s (%v)", target.address, err)
+		}
+
+		_, err = pb.NewProbeServiceClient(conn).GetProbeData(ctx, &emptypb.Empty{})
+		if status.Code(err) != target.probe {
+			t.Errorf("[FAIL] Unexpected probe on %s (%v)", target.address, err)
+		}
+
+		_ = conn.Close()
+	}
+
+	// all listeners are closed on shutdown
+	fd.closeGRPCListeners()
+
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Errorf("[FAIL] Expected the listeners to stop serving")
+	}
+
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("[FAIL] Expected the socket file to be removed (%v)", err)
+	}
+
+	t.Log("[PASS] Served different services per listener")
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"strconv"
+	"strings"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// =========================== //
+// == Host Policy Lifecycle == //
+// =========================== //
+
+// policy names of the alerts of the enforcement of host policies on the node
+const (
+	HostPolicyEnforcedPolicyName   = "kubearmor-host-policy-enforced"
+	HostPolicyUnenforcedPolicyName = "kubearmor-host-policy-unenforced"
+)
+
+// reasons of the end of the enforcement of host policies on the node
+const (
+	HostPolicySelectorMismatch = "selector mismatch"
+	HostPolicyDeleted          = "deleted"
+)
+
+// lifecycleAlerts are the alerts kept as records of the enforcement on the node, whatever their action
+var lifecycleAlerts = map[string]bool{
+	HostPolicyEnforcedPolicyName:   true,
+	HostPolicyUnenforcedPolicyName: true,
+
+	PolicyOverrideAppliedPolicyName: true,
+	PolicyOverrideExpiredPolicyName: true,
+}
+
+// HostPolicyTransition is the start (or the stop) of the enforcement of a host policy on the node
+type HostPolicyTransition struct {
+	PolicyName string
+	Enforced   bool
+
+	// why the enforcement stopped (selector mismatch or deleted)
+	Reason string
+
+	Rules    int
+	Enforcer string
+}
+
+// PushHostPolicyTransition raises a host alert and pushes a policy event for the start (or the stop)
+// of the enforcement of a host policy on this node
+func (fd *Feeder) PushHostPolicyTransition(transition HostPolicyTransition) {
+	action := PolicyEnforced
+	if !transition.Enforced {
+		action = PolicyUnenforced
+	}
+
+	event := fd.newPolicyEvent("KubeArmorHostPolicy", "", transition.PolicyName, action, transition.Reason, []string{fd.Node.NodeName})
+	event.RuleCount = int32(transition.Rules)
+	fd.broadcastPolicyEvent(event)
+
+	fd.pushMatchedLog(hostPolicyTransitionLog(transition))
+}
+
+// hostPolicyTransitionLog returns the alert raised when the enforcement of a host policy on the node changes
+func hostPolicyTransitionLog(transition HostPolicyTransition) tp.Log {
+	log := tp.Log{}
+
+	timestamp, updatedTime := kl.GetDateTimeNow()
+
+	log.Timestamp = timestamp
+	log.UpdatedTime = updatedTime
+
+	log.Type = "MatchedHostPolicy"
+	log.Tags = "KUBEARMOR,HOST_POLICY"
+	log.ATags = strings.Split(log.Tags, ",")
+
+	log.Data = "policy=" + transition.PolicyName + " rules=" + strconv.Itoa(transition.Rules) + " enforcer=" + transition.Enforcer
+
+	if transition.Enforced {
+		log.PolicyName = HostPolicyEnforcedPolicyName
+		log.Severity = "1"
+		log.Message = "Host policy " + transition.PolicyName + " now enforced on this node"
+	} else {
+		log.PolicyName = HostPolicyUnenforcedPolicyName
+		log.Severity = "3"
+		log.Message = "Host policy " + transition.PolicyName + " no longer enforced on this node (" + transition.Reason + ")"
+		log.Data += " reason=" + transition.Reason
+	}
+
+	log.Source = "kubearmor"
+	log.ProcessName = "kubearmor"
+
+	log.Enforcer = "KubeArmor"
+	log.Action = "Audit"
+	log.Result = "Passed"
+
+	return log
+}
@@ -0,0 +1,322 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ===================== //
+// == K8s Event Sink == //
+// ===================== //
+
+// K8s event constants
+const (
+	K8sEventReason     = "KubeArmorBlock"
+	K8sEventSource     = "kubearmor"
+	K8sEventFlushLimit = 10
+	K8sEventMaxRecords = 4096
+
+	// the idle records are forgotten after the TTL of the k8s events
+	K8sEventRecordTTL = time.Hour
+
+	// the failed API calls are retried with a backoff doubled up to the maximum
+	K8sEventRetryBackoff    = time.Second
+	k8sEventMaxRetryBackoff = 5 * time.Minute
+)
+
+// k8sEventRecord Structure
+type k8sEventRecord struct {
+	Namespace  string
+	PodName    string
+	PolicyName string
+
+	Message string
+
+	Count   int32
+	Pending bool
+
+	FirstTime time.Time
+	LastTime  time.Time
+
+	// name of the event object once created
+	EventName string
+
+	// the next flush of the record after a failure
+	RetryTime    time.Time
+	RetryBackoff time.Duration
+}
+
+// K8sEventSink creates Kubernetes Events on the pod object for critical Block alerts
+type K8sEventSink struct {
+	client kubernetes.Interface
+
+	// minimum severity of alerts to be reported
+	MinSeverity int
+
+	// interval between flushes to the API server
+	FlushInterval time.Duration
+
+	// maximum number of API calls per flush
+	FlushLimit int
+
+	// maximum number of aggregated records, the alerts of new (pod, policy) pairs are dropped beyond it
+	MaxRecords int
+
+	// (namespace, pod, policy) -> aggregated record
+	records     map[string]*k8sEventRecord
+	recordsLock *sync.Mutex
+
+	// number of alerts dropped since the last flush
+	dropped int
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewK8sEventSink Function
+func NewK8sEventSink(client kubernetes.Interface, minSeverity int, flushInterval time.Duration) *K8sEventSink {
+	// standalone mode (no API server), nothing to do
+	if client == nil {
+		return nil
+	}
+
+	es := &K8sEventSink{}
+
+	es.client = client
+
+	es.MinSeverity = minSeverity
+	es.FlushInterval = flushInterval
+	es.FlushLimit = K8sEventFlushLimit
+	es.MaxRecords = K8sEventMaxRecords
+
+	es.records = map[string]*k8sEventRecord{}
+	es.recordsLock = new(sync.Mutex)
+
+	es.stop = make(chan struct{})
+
+	return es
+}
+
+// Name Function
+func (es *K8sEventSink) Name() string {
+	return "k8s-events"
+}
+
+// Start Function
+func (es *K8sEventSink) Start() {
+	es.wg.Add(1)
+
+	go func() {
+		defer es.wg.Done()
+
+		ticker := time.NewTicker(es.FlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-es.stop:
+				es.Flush()
+				return
+			case <-ticker.C:
+				es.Flush()
+			}
+		}
+	}()
+}
+
+// Close Function
+func (es *K8sEventSink) Close() error {
+	close(es.stop)
+	es.wg.Wait()
+	return nil
+}
+
+// isCriticalBlock Function
+func (es *K8sEventSink) isCriticalBlock(alert *pb.Alert) bool {
+	if alert.Action != "Block" {
+		return false
+	}
+
+	// only pods can be annotated with events
+	if alert.NamespaceName == "" || alert.PodName == "" {
+		return false
+	}
+
+	return meetsMinSeverity(alert, es.MinSeverity)
+}
+
+// SendAlert aggregates an alert per (pod, policy), the actual API calls are made on flush
+func (es *K8sEventSink) SendAlert(alert *pb.Alert) {
+	if es == nil || !es.isCriticalBlock(alert) {
+		return
+	}
+
+	key := alert.NamespaceName + "/" + alert.PodName + "/" + alert.PolicyName
+	now := time.Now()
+
+	es.recordsLock.Lock()
+	defer es.recordsLock.Unlock()
+
+	record, ok := es.records[key]
+	if !ok {
+		if len(es.records) >= es.MaxRecords {
+			es.dropped++
+			return
+		}
+
+		record = &k8sEventRecord{
+			Namespace:  alert.NamespaceName,
+			PodName:    alert.PodName,
+			PolicyName: alert.PolicyName,
+			FirstTime:  now,
+		}
+		es.records[key] = record
+	}
+
+	record.Count++
+	record.Pending = true
+	record.LastTime = now
+	record.Message = fmt.Sprintf("KubeArmor blocked %s operation on %s by %s (policy: %s, severity: %s)",
+		strings.ToLower(alert.Operation), alert.Resource, alert.ProcessName, alert.PolicyName, alert.Severity)
+}
+
+// RemovePod forgets the records of a deleted pod
+func (es *K8sEventSink) RemovePod(namespace, podName string) {
+	if es == nil {
+		return
+	}
+
+	prefix := namespace + "/" + podName + "/"
+
+	es.recordsLock.Lock()
+	defer es.recordsLock.Unlock()
+
+	for key := range es.records {
+		if strings.HasPrefix(key, prefix) {
+			delete(es.records, key)
+		}
+	}
+}
+
+// Flush creates or updates at most FlushLimit events for the pending records, and forgets the idle ones
+func (es *K8sEventSink) Flush() {
+	now := time.Now()
+
+	es.recordsLock.Lock()
+	pending := []k8sEventRecord{}
+	for key, record := range es.records {
+		if !record.Pending {
+			if now.Sub(record.LastTime) > K8sEventRecordTTL {
+				delete(es.records, key)
+			}
+			continue
+		}
+		if len(pending) == es.FlushLimit || now.Before(record.RetryTime) {
+			continue
+		}
+		record.Pending = false
+		pending = append(pending, *record)
+	}
+	dropped := es.dropped
+	es.dropped = 0
+	es.recordsLock.Unlock()
+
+	if dropped > 0 {
+		kg.Warnf("Dropped %d alerts for k8s events, too many (pod, policy) pairs (%d)", dropped, es.MaxRecords)
+	}
+
+	for _, record := range pending {
+		eventName, err := es.emitEvent(record)
+
+		es.recordsLock.Lock()
+		// the pod may have been deleted in the meantime
+		if stored, ok := es.records[record.Namespace+"/"+record.PodName+"/"+record.PolicyName]; ok {
+			if err != nil {
+				// retry later, the counts of the alerts received in the meantime are kept
+				stored.Pending = true
+				if stored.RetryBackoff = stored.RetryBackoff * 2; stored.RetryBackoff == 0 {
+					stored.RetryBackoff = K8sEventRetryBackoff
+				} else if stored.RetryBackoff > k8sEventMaxRetryBackoff {
+					stored.RetryBackoff = k8sEventMaxRetryBackoff
+				}
+				stored.RetryTime = time.Now().Add(stored.RetryBackoff)
+			} else {
+				stored.EventName = eventName
+				stored.RetryBackoff = 0
+				stored.RetryTime = time.Time{}
+			}
+		}
+		es.recordsLock.Unlock()
+
+		if err != nil {
+			kg.Warnf("Failed to emit a k8s event for %s/%s (%s)", record.Namespace, record.PodName, err.Error())
+		}
+	}
+}
+
+// emitEvent Function
+func (es *K8sEventSink) emitEvent(record k8sEventRecord) (string, error) {
+	events := es.client.CoreV1().Events(record.Namespace)
+
+	if record.EventName != "" {
+		event, err := events.Get(context.Background(), record.EventName, metav1.GetOptions{})
+		if err == nil {
+			event.Count = record.Count
+			event.Message = record.Message
+			event.LastTimestamp = metav1.NewTime(record.LastTime)
+
+			if _, err := events.Update(context.Background(), event, metav1.UpdateOptions{}); err != nil {
+				return record.EventName, err
+			}
+			return record.EventName, nil
+		}
+
+		// the event has expired, create a new one (otherwise, retry later not to duplicate it)
+		if !apierrors.IsNotFound(err) {
+			return record.EventName, err
+		}
+	}
+
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", record.PodName, record.FirstTime.UnixNano()),
+			Namespace: record.Namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:       "Pod",
+			APIVersion: "v1",
+			Namespace:  record.Namespace,
+			Name:       record.PodName,
+		},
+		Reason:         K8sEventReason,
+		Message:        record.Message,
+		Type:           corev1.EventTypeWarning,
+		Count:          record.Count,
+		FirstTimestamp: metav1.NewTime(record.FirstTime),
+		LastTimestamp:  metav1.NewTime(record.LastTime),
+		Source: corev1.EventSource{
+			Component: K8sEventSource,
+		},
+	}
+
+	created, err := events.Create(context.Background(), event, metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return created.Name, nil
+}
@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestK8sEventSink(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	sink := NewK8sEventSink(client, 5, time.Hour)
+	if sink == nil {
+		t.Fatal("[FAIL] Failed to create k8s event sink")
+	}
+
+	alert := &pb.Alert{
+		NamespaceName: "default",
+		PodName:       "nginx",
+		PolicyName:    "block-shell",
+		Severity:      "7",
+		Operation:     "Process",
+		Resource:      "/bin/sh",
+		ProcessName:   "/bin/sh",
+		Action:        "Block",
+	}
+
+	// aggregated into one event
+	for i := 0; i < 3; i++ {
+		sink.SendAlert(alert)
+	}
+
+	// filtered out (low severity, not blocked, not a pod)
+	sink.SendAlert(&pb.Alert{NamespaceName: "default", PodName: "nginx", PolicyName: "low", Severity: "2", Action: "Block"})
+	sink.SendAlert(&pb.Alert{NamespaceName: "default", PodName: "nginx", PolicyName: "audit", Severity: "9", Action: "Audit"})
+	sink.SendAlert(&pb.Alert{PolicyName: "host", Severity: "9", Action: "Block"})
+
+	sink.Flush()
+
+	events, err := client.CoreV1().Events("default").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to list events (%s)", err.Error())
+	}
+	if len(events.Items) != 1 {
+		t.Fatalf("[FAIL] Expected 1 event, got %d", len(events.Items))
+	}
+
+	event := events.Items[0]
+	if event.Reason != K8sEventReason || event.Type != "Warning" {
+		t.Errorf("[FAIL] Unexpected event (reason=%s, type=%s)", event.Reason, event.Type)
+	}
+	if event.InvolvedObject.Kind != "Pod" || event.InvolvedObject.Name != "nginx" {
+		t.Errorf("[FAIL] Unexpected involved object (%+v)", event.InvolvedObject)
+	}
+	if event.Count != 3 {
+		t.Errorf("[FAIL] Expected count 3, got %d", event.Count)
+	}
+
+	// the same event is updated on subsequent flushes
+	sink.SendAlert(alert)
+	sink.SendAlert(alert)
+	sink.Flush()
+
+	events, _ = client.CoreV1().Events("default").List(context.Background(), metav1.ListOptions{})
+	if len(events.Items) != 1 {
+		t.Fatalf("[FAIL] Expected 1 event after update, got %d", len(events.Items))
+	}
+	if events.Items[0].Count != 5 {
+		t.Errorf("[FAIL] Expected count 5, got %d", events.Items[0].Count)
+	}
+
+	// nothing pending, no API calls
+	actions := len(client.Actions())
+	sink.Flush()
+	if len(client.Actions()) != actions {
+		t.Errorf("[FAIL] Unexpected API calls without pending alerts")
+	}
+}
+
+func TestK8sEventSinkRateLimit(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	sink := NewK8sEventSink(client, 1, time.Hour)
+	sink.FlushLimit = 2
+
+	for _, policy := range []string{"a", "b", "c"} {
+		sink.SendAlert(&pb.Alert{NamespaceName: "default", PodName: "nginx", PolicyName: policy, Severity: "5", Action: "Block"})
+	}
+
+	sink.Flush()
+	events, _ := client.CoreV1().Events("default").List(context.Background(), metav1.ListOptions{})
+	if len(events.Items) != 2 {
+		t.Fatalf("[FAIL] Expected 2 events on the first flush, got %d", len(events.Items))
+	}
+
+	sink.Flush()
+	events, _ = client.CoreV1().Events("default").List(context.Background(), metav1.ListOptions{})
+	if len(events.Items) != 3 {
+		t.Fatalf("[FAIL] Expected 3 events on the second flush, got %d", len(events.Items))
+	}
+}
+
+func TestK8sEventSinkRecords(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	sink := NewK8sEventSink(client, 1, time.Hour)
+	sink.MaxRecords = 2
+
+	// the alerts of new pairs are dropped once full
+	for _, policy := range []string{"a", "b", "c"} {
+		sink.SendAlert(&pb.Alert{NamespaceName: "default", PodName: "nginx", PolicyName: policy, Severity: "5", Action: "Block"})
+	}
+	if len(sink.records) != 2 || sink.dropped != 1 {
+		t.Fatalf("[FAIL] Expected 2 records and 1 dropped alert, got %d and %d", len(sink.records), sink.dropped)
+	}
+
+	sink.Flush()
+	if sink.dropped != 0 {
+		t.Errorf("[FAIL] Dropped alerts not reset on flush (%d)", sink.dropped)
+	}
+
+	// forgotten once the pod is deleted
+	sink.RemovePod("default", "nginx")
+	sink.SendAlert(&pb.Alert{NamespaceName: "default", PodName: "nginx-2", PolicyName: "a", Severity: "5", Action: "Block"})
+	if len(sink.records) != 1 || sink.dropped != 0 {
+		t.Fatalf("[FAIL] Expected 1 record after the pod deletion, got %d", len(sink.records))
+	}
+
+	// forgotten once idle (after being flushed)
+	sink.Flush()
+	for _, record := range sink.records {
+		record.LastTime = time.Now().Add(-K8sEventRecordTTL - time.Minute)
+	}
+	sink.Flush()
+	if len(sink.records) != 0 {
+		t.Errorf("[FAIL] Expected no idle records, got %d", len(sink.records))
+	}
+}
+
+func TestK8sEventSinkRetry(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	sink := NewK8sEventSink(client, 1, time.Hour)
+
+	alert := &pb.Alert{NamespaceName: "default", PodName: "nginx", PolicyName: "a", Severity: "5", Action: "Block"}
+
+	sink.SendAlert(alert)
+	sink.Flush()
+
+	// the API server is unavailable
+	client.PrependReactor("get", "events", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewServiceUnavailable("unavailable")
+	})
+
+	sink.SendAlert(alert)
+	sink.Flush()
+
+	events, _ := client.CoreV1().Events("default").List(context.Background(), metav1.ListOptions{})
+	if len(events.Items) != 1 {
+		t.Fatalf("[FAIL] Expected no duplicated event, got %d events", len(events.Items))
+	}
+
+	record := sink.records["default/nginx/a"]
+	if !record.Pending || record.RetryBackoff != K8sEventRetryBackoff || record.EventName == "" {
+		t.Fatalf("[FAIL] Expected the record to be retried (%+v)", record)
+	}
+
+	// not retried before the backoff
+	actions := len(client.Actions())
+	sink.Flush()
+	if len(client.Actions()) != actions {
+		t.Errorf("[FAIL] Unexpected API calls before the backoff")
+	}
+
+	// the backoff is doubled on another failure
+	record.RetryTime = time.Time{}
+	sink.Flush()
+	if record.RetryBackoff != 2*K8sEventRetryBackoff {
+		t.Errorf("[FAIL] Expected the backoff to be doubled, got %s", record.RetryBackoff)
+	}
+
+	// updated once the API server is back
+	client.ReactionChain = client.ReactionChain[1:]
+	record.RetryTime = time.Time{}
+	sink.Flush()
+
+	events, _ = client.CoreV1().Events("default").List(context.Background(), metav1.ListOptions{})
+	if len(events.Items) != 1 || events.Items[0].Count != 2 {
+		t.Fatalf("[FAIL] Expected 1 event with count 2, got %d events", len(events.Items))
+	}
+	if record.Pending || record.RetryBackoff != 0 {
+		t.Errorf("[FAIL] Expected the record to be flushed (%+v)", record)
+	}
+}
+
+func TestK8sEventSinkStandalone(t *testing.T) {
+	if sink := NewK8sEventSink(nil, 1, time.Hour); sink != nil {
+		t.Fatal("[FAIL] Expected no sink without an API server")
+	}
+
+	// no-op on a nil sink
+	var sink *K8sEventSink
+	sink.SendAlert(&pb.Alert{NamespaceName: "default", PodName: "nginx", Severity: "10", Action: "Block"})
+}
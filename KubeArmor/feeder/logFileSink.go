@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"bufio"
+	"os"
+
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+// =================== //
+// == Log File Sink == //
+// =================== //
+
+// LineSink is a sink which takes the serialized logs (alerts included) instead of the alerts
+type LineSink interface {
+	AlertSink
+	WriteLine(line []byte)
+}
+
+// LogFileSink writes the logs as lines of JSON into the log file, or into the archive
+type LogFileSink struct {
+	file    *os.File
+	archive *AlertArchive
+}
+
+// NewLogFileSink Function
+func NewLogFileSink(file *os.File, archive *AlertArchive) *LogFileSink {
+	return &LogFileSink{file: file, archive: archive}
+}
+
+// Name Function
+func (ls *LogFileSink) Name() string {
+	return "file"
+}
+
+// SendAlert Function (the alerts are written with the logs)
+func (ls *LogFileSink) SendAlert(alert *pb.Alert) {}
+
+// WriteLine Function
+func (ls *LogFileSink) WriteLine(line []byte) {
+	if ls.archive != nil {
+		if err := ls.archive.Write(line); err != nil {
+			kg.Err(err.Error())
+		}
+		return
+	}
+
+	if ls.file != nil {
+		// write the line with the newline at the end
+		w := bufio.NewWriter(ls.file)
+		if _, err := w.Write(line); err != nil {
+			kg.Err(err.Error())
+		}
+		if err := w.WriteByte('\n'); err != nil {
+			kg.Err(err.Error())
+		}
+
+		// flush the file buffer
+		if err := w.Flush(); err != nil {
+			kg.Err(err.Error())
+		}
+	}
+}
+
+// Close Function
+func (ls *LogFileSink) Close() error {
+	if ls.archive != nil {
+		return ls.archive.Close()
+	}
+
+	if ls.file != nil {
+		return ls.file.Close()
+	}
+
+	return nil
+}
+
+// AddLogFileSink writes the logs into the log file (or the archive) from the queue of a sink,
+// so that a slow disk can't delay the other outputs
+func (fd *Feeder) AddLogFileSink(file *os.File, archive *AlertArchive) {
+	fd.SinksLock.Lock()
+	defer fd.SinksLock.Unlock()
+
+	fd.LogFile = file
+	fd.Archive = archive
+
+	fd.fileSink = NewSinkWorker(NewLogFileSink(file, archive), fd.SinkQueueSize, fd.SinkLagThreshold, fd.Now)
+	fd.Sinks = append(fd.Sinks, fd.fileSink)
+}
+
+// pushLineToFile queues a serialized log for the log file
+func (fd *Feeder) pushLineToFile(line []byte) {
+	fd.SinksLock.RLock()
+	defer fd.SinksLock.RUnlock()
+
+	if fd.fileSink != nil {
+		fd.fileSink.EnqueueLine(line)
+	}
+}
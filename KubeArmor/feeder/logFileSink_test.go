@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+func TestLogFileSink(t *testing.T) {
+	logFile, err := os.CreateTemp(t.TempDir(), "kubearmor-*.log")
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create the log file (%s)", err.Error())
+	}
+
+	fd := &Feeder{}
+	fd.SinksLock = new(sync.RWMutex)
+	fd.SinkQueueSize = 4
+	fd.SinkDrainTimeout = time.Second
+
+	fd.AddLogFileSink(logFile, nil)
+
+	// the alerts are written with the logs, not twice
+	fd.pushAlertToSinks(&pb.Alert{PolicyName: "block-shell", Severity: "7", Action: "Block"})
+
+	fd.pushLineToFile([]byte(`{"Type":"MatchedPolicy"}`))
+	fd.pushLineToFile([]byte(`{"Type":"ContainerLog"}`))
+
+	stats := fd.GetSinkStats()
+	if len(stats) != 1 || stats[0].Name != "file" {
+		t.Fatalf("[FAIL] Expected the stats of the file sink (%+v)", stats)
+	}
+
+	// the queued logs are written before the file is closed
+	fd.closeSinks()
+
+	// nothing is queued once closed
+	fd.pushLineToFile([]byte(`{"Type":"HostLog"}`))
+
+	data, err := os.ReadFile(logFile.Name())
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to read the log file (%s)", err.Error())
+	}
+
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 || lines[0] != `{"Type":"MatchedPolicy"}` || lines[1] != `{"Type":"ContainerLog"}` {
+		t.Errorf("[FAIL] Unexpected lines of the log file (%q)", string(data))
+	}
+
+	if err := logFile.Close(); err == nil {
+		t.Errorf("[FAIL] Expected the log file to be closed with the sink")
+	}
+
+	t.Log("[PASS] Wrote the logs from the queue of the file sink")
+}
@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ==================== //
+// == Owner Identity == //
+// ==================== //
+
+// owner identities of ownerOnly rules
+const (
+	// files owned by the process, or by the runAsUser or the fsGroup of the pod (default)
+	OwnerIdentityPod = "Pod"
+
+	// files owned by the process only
+	OwnerIdentityProcess = "Process"
+)
+
+// getFileOwner returns the owner and the group of a file
+func getFileOwner(path string) (uint32, uint32, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return stat.Uid, stat.Gid, true
+}
+
+// isFileOwner checks if a file is owned by a process, or by the identity of its pod if given
+func isFileOwner(uid, fileUID, fileGID uint32, identity *tp.SecurityIdentity) bool {
+	if fileUID == uid {
+		return true
+	}
+
+	if identity == nil {
+		return false
+	}
+
+	if identity.RunAsUser != nil && int64(fileUID) == *identity.RunAsUser {
+		return true
+	}
+
+	// kubelet chowns the files of the volumes to the fsGroup of the pod
+	if identity.FsGroup != nil && int64(fileGID) == *identity.FsGroup {
+		return true
+	}
+
+	return false
+}
+
+// formatOwnerIdentity returns the identity used to decide the ownership of a file
+func formatOwnerIdentity(uid int32, identity *tp.SecurityIdentity) string {
+	fields := []string{"uid=" + strconv.Itoa(int(uid))}
+
+	if identity != nil {
+		if identity.RunAsUser != nil {
+			fields = append(fields, "runAsUser="+strconv.FormatInt(*identity.RunAsUser, 10))
+		}
+		if identity.RunAsGroup != nil {
+			fields = append(fields, "runAsGroup="+strconv.FormatInt(*identity.RunAsGroup, 10))
+		}
+		if identity.FsGroup != nil {
+			fields = append(fields, "fsGroup="+strconv.FormatInt(*identity.FsGroup, 10))
+		}
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// matchOwner checks if the resource of a log is owned according to an ownerOnly rule,
+// and records the identity used for the decision
+func matchOwner(log *tp.Log, secPolicy tp.MatchPolicy) bool {
+	log.OwnerIdentity = formatOwnerIdentity(log.UID, secPolicy.OwnerIdentity)
+
+	fileUID, fileGID, ok := getFileOwner(log.MergedDir + log.Resource)
+	if !ok {
+		return false
+	}
+
+	return isFileOwner(uint32(log.UID), fileUID, fileGID, secPolicy.OwnerIdentity)
+}
+
+// setOwnerIdentity sets the identity of the pod to the ownerOnly rules of the policies
+// which don't use the process identity only
+func setOwnerIdentity(matches []tp.MatchPolicy, ownerIdentity map[string]string, identity tp.SecurityIdentity) {
+	if identity.RunAsUser == nil && identity.FsGroup == nil {
+		return
+	}
+
+	for idx := range matches {
+		if matches[idx].OwnerOnly && ownerIdentity[matches[idx].PolicyName] != OwnerIdentityProcess {
+			podIdentity := identity
+			matches[idx].OwnerIdentity = &podIdentity
+		}
+	}
+}
+
+// UsesPodOwnerIdentity checks if any ownerOnly process or file rule of the policies uses the identity of the pod
+func UsesPodOwnerIdentity(secPolicies []tp.SecurityPolicy) bool {
+	for _, secPolicy := range secPolicies {
+		if secPolicy.Spec.OwnerIdentity == OwnerIdentityProcess {
+			continue
+		}
+
+		for _, path := range secPolicy.Spec.Process.MatchPaths {
+			if path.OwnerOnly {
+				return true
+			}
+		}
+		for _, dir := range secPolicy.Spec.Process.MatchDirectories {
+			if dir.OwnerOnly {
+				return true
+			}
+		}
+		for _, path := range secPolicy.Spec.File.MatchPaths {
+			if path.OwnerOnly {
+				return true
+			}
+		}
+		for _, dir := range secPolicy.Spec.File.MatchDirectories {
+			if dir.OwnerOnly {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"os"
+	"testing"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestOwnerIdentity(t *testing.T) {
+	runAsUser, fsGroup := int64(1000), int64(2000)
+	identity := &tp.SecurityIdentity{RunAsUser: &runAsUser, FsGroup: &fsGroup}
+
+	for _, tc := range []struct {
+		name     string
+		uid      uint32
+		fileUID  uint32
+		fileGID  uint32
+		identity *tp.SecurityIdentity
+		expected bool
+	}{
+		{"owned by the process", 1000, 1000, 1000, nil, true},
+		{"owned by root", 1000, 0, 0, identity, false},
+		{"chowned to the fsGroup", 1000, 0, 2000, identity, true},
+		{"chowned to the fsGroup (process identity)", 1000, 0, 2000, nil, false},
+		{"owned by the runAsUser", 1001, 1000, 1001, identity, true},
+		{"owned by another group", 1000, 0, 3000, identity, false},
+	} {
+		if isFileOwner(tc.uid, tc.fileUID, tc.fileGID, tc.identity) != tc.expected {
+			t.Errorf("[FAIL] Unexpected ownership (%s)", tc.name)
+		}
+	}
+
+	// the identity of the pod is only set to the ownerOnly rules of the policies not using the process identity
+	matches := []tp.MatchPolicy{
+		{PolicyName: "pod", Resource: "/data/", OwnerOnly: true},
+		{PolicyName: "process", Resource: "/data/", OwnerOnly: true},
+		{PolicyName: "pod", Resource: "/etc/"},
+	}
+	setOwnerIdentity(matches, map[string]string{"pod": "", "process": OwnerIdentityProcess}, *identity)
+
+	if matches[0].OwnerIdentity == nil || matches[1].OwnerIdentity != nil || matches[2].OwnerIdentity != nil {
+		t.Errorf("[FAIL] Unexpected owner identities (%+v)", matches)
+	}
+
+	if formatted := formatOwnerIdentity(1000, identity); formatted != "uid=1000,runAsUser=1000,fsGroup=2000" {
+		t.Errorf("[FAIL] Unexpected owner identity (%s)", formatted)
+	}
+
+	t.Log("[PASS] Decided the ownership with the identity of the pod")
+}
+
+func TestMatchOwnerFsGroup(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("[SKIP] Changing the group of a file needs root")
+	}
+
+	dir := t.TempDir()
+
+	// a volume file chowned by kubelet (root:fsGroup)
+	if err := os.WriteFile(dir+"/config", []byte("data"), 0660); err != nil {
+		t.Fatalf("[FAIL] Failed to create a file (%s)", err.Error())
+	}
+	if err := os.Chown(dir+"/config", 0, 2000); err != nil {
+		t.Fatalf("[FAIL] Failed to chown a file (%s)", err.Error())
+	}
+
+	fsGroup := int64(2000)
+	policy := tp.MatchPolicy{PolicyName: "owner-only", Resource: "/config", OwnerOnly: true, OwnerIdentity: &tp.SecurityIdentity{FsGroup: &fsGroup}}
+
+	// a non-root process of the pod
+	log := tp.Log{UID: 1000, MergedDir: dir, Resource: "/config"}
+
+	if !matchOwner(&log, policy) || log.OwnerIdentity != "uid=1000,fsGroup=2000" {
+		t.Errorf("[FAIL] Expected the file to be owned by the pod (%s)", log.OwnerIdentity)
+	}
+
+	// the previous behavior only compares the uids
+	policy.OwnerIdentity = nil
+
+	if matchOwner(&log, policy) || log.OwnerIdentity != "uid=1000" {
+		t.Errorf("[FAIL] Expected the file not to be owned by the process (%s)", log.OwnerIdentity)
+	}
+
+	t.Log("[PASS] Matched the files chowned to the fsGroup")
+}
@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"sort"
+	"strings"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ========================== //
+// == Policy Compatibility == //
+// ========================== //
+
+// throttleEnforceable checks if an enforcer can deny the executions over the rate of Throttle rules
+func throttleEnforceable(enforcer string) bool {
+	return enforcer == "BPFLSM"
+}
+
+// filelessEnforceable checks if an enforcer can deny the executions of files without a path
+func filelessEnforceable(enforcer string) bool {
+	return enforcer == "BPFLSM"
+}
+
+// signalEnforceable checks if an enforcer can deny the signals to other processes
+func signalEnforceable(enforcer string) bool {
+	return enforcer == "BPFLSM" && kl.BPFFeatureAvailable(kl.BPFFeatureSignalEnforcement)
+}
+
+// runtimeSocketEnforceable checks if an enforcer can deny the connections to the sockets of the container runtimes
+func runtimeSocketEnforceable(enforcer string) bool {
+	return enforcer == "BPFLSM" && kl.BPFFeatureAvailable(kl.BPFFeatureRuntimeSocketEnforcement)
+}
+
+// fileAttributeEnforceable checks if an enforcer can deny the changes of xattrs and inode flags
+func fileAttributeEnforceable(enforcer string) bool {
+	return enforcer == "BPFLSM" && kl.BPFFeatureAvailable(kl.BPFFeatureFileAttributeEnforcement)
+}
+
+// XattrNameKey returns the name of an xattr rule as matched by the BPF LSM enforcer, an exact name, a namespace with
+// its dot (for the pattern of the names of a namespace, e.g., security.*), or nothing for any name, and false if the
+// enforcer can't match its pattern
+func XattrNameKey(pattern string) (string, bool) {
+	// the rest of a key of 256 bytes, after its type and the change
+	if len(pattern) > 253 {
+		return "", false
+	}
+
+	if pattern == "*" {
+		return "", true
+	}
+
+	if !strings.ContainsAny(pattern, `*?[\`) {
+		return pattern, true
+	}
+
+	if ns := strings.TrimSuffix(pattern, ".*"); ns != pattern && ns != "" && !strings.ContainsAny(ns, `*?[\.`) {
+		return ns + ".", true
+	}
+
+	return "", false
+}
+
+// networkEnforceable checks if an enforcer can block the network rules on the architecture of the node
+func networkEnforceable(enforcer string) bool {
+	return enforcer != "BPFLSM" || kl.BPFFeatureAvailable(kl.BPFFeatureNetworkEnforcement)
+}
+
+// patternEnforceable checks if an enforcer can match the executables and files with patterns
+func patternEnforceable(enforcer string) bool {
+	return enforcer != "BPFLSM"
+}
+
+// capabilityEnforceable checks if an enforcer can block the use of capabilities
+func capabilityEnforceable(enforcer string) bool {
+	return enforcer != "BPFLSM"
+}
+
+// unsupportedBy returns why the rules of a feature are unsupported by an enforcer, with the gap of the architecture
+// of the node if any
+func unsupportedBy(enforcer, feature string) string {
+	if enforcer == "BPFLSM" {
+		if err := kl.BPFFeatureError(feature); err != nil {
+			return "unsupported by " + enforcer + " (" + err.Error() + ")"
+		}
+	}
+	return "unsupported by " + enforcer
+}
+
+// ruleAction returns the action of a rule, inherited from its section or the policy if not set
+func ruleAction(actions ...string) string {
+	for _, action := range actions {
+		if action != "" {
+			return action
+		}
+	}
+	return "Block"
+}
+
+// AnalyzePolicyCompatibility returns how the enforcement of a policy on a node differs from its specification
+func AnalyzePolicyCompatibility(enforcer string, spec tp.SecuritySpec) []string {
+	differences := []string{}
+
+	// nothing is enforced without a runtime enforcer
+	if enforcer == "" || enforcer == "eBPF Monitor" {
+		if spec.Action != "Audit" {
+			differences = append(differences, "no runtime enforcer on the node, the rules are audited only")
+		}
+		return differences
+	}
+
+	if !patternEnforceable(enforcer) {
+		for _, pat := range spec.Process.MatchPatterns {
+			differences = append(differences, "process pattern "+pat.Pattern+" is unsupported by "+enforcer+", not enforced")
+		}
+		for _, pat := range spec.File.MatchPatterns {
+			differences = append(differences, "file pattern "+pat.Pattern+" is unsupported by "+enforcer+", not enforced")
+		}
+	}
+
+	if !throttleEnforceable(enforcer) {
+		for _, path := range spec.Process.MatchPaths {
+			if ruleAction(path.Action, spec.Process.Action, spec.Action) == "Throttle" {
+				differences = append(differences, "throttle rule "+path.Path+" is unenforceable with "+enforcer+", executions over the rate are audited")
+			}
+		}
+	}
+
+	if !networkEnforceable(enforcer) {
+		for _, proto := range spec.Network.MatchProtocols {
+			if ruleAction(proto.Action, spec.Network.Action, spec.Action) == "Block" {
+				differences = append(differences, "network protocol "+proto.Protocol+" is "+unsupportedBy(enforcer, kl.BPFFeatureNetworkEnforcement)+", audited instead of blocked")
+			}
+		}
+	}
+
+	if !signalEnforceable(enforcer) {
+		for _, sig := range spec.Process.MatchSignals {
+			if ruleAction(sig.Action, spec.Process.Action, spec.Action) == "Block" {
+				differences = append(differences, "signal rule "+strings.Join(sig.Signals, ",")+" is "+unsupportedBy(enforcer, kl.BPFFeatureSignalEnforcement)+", audited instead of blocked")
+			}
+		}
+	}
+
+	if !runtimeSocketEnforceable(enforcer) {
+		for _, sock := range spec.Network.MatchRuntimeSockets {
+			if ruleAction(sock.Action, spec.Network.Action, spec.Action) == "Block" {
+				differences = append(differences, "runtime socket rule is "+unsupportedBy(enforcer, kl.BPFFeatureRuntimeSocketEnforcement)+", audited instead of blocked")
+				break
+			}
+		}
+	}
+
+	if !fileAttributeEnforceable(enforcer) {
+		for _, xattr := range spec.File.MatchXattrs {
+			if ruleAction(xattr.Action, spec.File.Action, spec.Action) == "Block" {
+				differences = append(differences, "xattr rule "+xattr.Name+" is "+unsupportedBy(enforcer, kl.BPFFeatureFileAttributeEnforcement)+", audited instead of blocked")
+			}
+		}
+		for _, imm := range spec.File.MatchImmutable {
+			if ruleAction(imm.Action, spec.File.Action, spec.Action) == "Block" {
+				differences = append(differences, "immutable rule "+imm.Path+" is "+unsupportedBy(enforcer, kl.BPFFeatureFileAttributeEnforcement)+", audited instead of blocked")
+			}
+		}
+	}
+
+	if !capabilityEnforceable(enforcer) {
+		for _, cap := range spec.Capabilities.MatchCapabilities {
+			if ruleAction(cap.Action, spec.Capabilities.Action, spec.Action) != "Audit" {
+				differences = append(differences, "capability "+cap.Capability+" is unsupported by "+enforcer+", not enforced")
+			}
+		}
+	}
+
+	// the exec sessions are told apart in userspace only
+	sessionRule := func(rule string, onlyExecSession, excludeExecSession bool, action string) {
+		if IsSessionRule(onlyExecSession, excludeExecSession, action) && action != "Audit" {
+			differences = append(differences, rule+" is conditioned on exec sessions, audited instead of enforced")
+		}
+	}
+	for _, path := range spec.Process.MatchPaths {
+		sessionRule("process path "+path.Path, path.OnlyExecSession, path.ExcludeExecSession, ruleAction(path.Action, spec.Process.Action, spec.Action))
+	}
+	for _, dir := range spec.Process.MatchDirectories {
+		sessionRule("process directory "+dir.Directory, dir.OnlyExecSession, dir.ExcludeExecSession, ruleAction(dir.Action, spec.Process.Action, spec.Action))
+	}
+	for _, pat := range spec.Process.MatchPatterns {
+		sessionRule("process pattern "+pat.Pattern, pat.OnlyExecSession, pat.ExcludeExecSession, ruleAction(pat.Action, spec.Process.Action, spec.Action))
+	}
+	for _, path := range spec.File.MatchPaths {
+		sessionRule("file path "+path.Path, path.OnlyExecSession, path.ExcludeExecSession, ruleAction(path.Action, spec.File.Action, spec.Action))
+	}
+	for _, dir := range spec.File.MatchDirectories {
+		sessionRule("file directory "+dir.Directory, dir.OnlyExecSession, dir.ExcludeExecSession, ruleAction(dir.Action, spec.File.Action, spec.Action))
+	}
+	for _, pat := range spec.File.MatchPatterns {
+		sessionRule("file pattern "+pat.Pattern, pat.OnlyExecSession, pat.ExcludeExecSession, ruleAction(pat.Action, spec.File.Action, spec.Action))
+	}
+
+	sort.Strings(differences)
+
+	return differences
+}
+
+// AnalyzeHostPolicyCompatibility returns how the enforcement of a host policy on a node differs from its specification
+func AnalyzeHostPolicyCompatibility(enforcer string, spec tp.HostSecuritySpec) []string {
+	return AnalyzePolicyCompatibility(enforcer, tp.SecuritySpec{
+		Process:      spec.Process,
+		File:         spec.File,
+		Network:      spec.Network,
+		Capabilities: spec.Capabilities,
+		Action:       spec.Action,
+	})
+}
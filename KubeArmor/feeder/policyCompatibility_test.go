@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"testing"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestAnalyzePolicyCompatibility(t *testing.T) {
+	spec := tp.SecuritySpec{Action: "Block"}
+	spec.Process.MatchPatterns = []tp.ProcessPatternType{{Pattern: "/usr/bin/*sh"}}
+	spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/usr/bin/curl", Action: "Throttle", Rate: 5}}
+	spec.Process.MatchSignals = []tp.ProcessSignalType{{Signals: []string{"SIGKILL", "SIGSTOP"}, Scope: "cross-container"}}
+	spec.Network.MatchProtocols = []tp.NetworkProtocolType{{Protocol: "packet"}}
+
+	// patterns with the BPF LSM enforcer (packet sockets are matched by their family)
+	differences := AnalyzePolicyCompatibility("BPFLSM", spec)
+	if len(differences) != 1 {
+		t.Errorf("[FAIL] Unexpected differences with BPFLSM (%v)", differences)
+	}
+
+	// signal and throttle rules with AppArmor
+	differences = AnalyzePolicyCompatibility("AppArmor", spec)
+	if len(differences) != 2 || differences[0] != "signal rule SIGKILL,SIGSTOP is unsupported by AppArmor, audited instead of blocked" ||
+		differences[1] != "throttle rule /usr/bin/curl is unenforceable with AppArmor, executions over the rate are audited" {
+		t.Errorf("[FAIL] Unexpected differences with AppArmor (%v)", differences)
+	}
+
+	// xattr and immutable rules with AppArmor
+	spec.File.MatchImmutable = []tp.FileImmutableType{{Path: "/etc/passwd"}}
+	differences = AnalyzePolicyCompatibility("AppArmor", spec)
+	if len(differences) != 3 || differences[0] != "immutable rule /etc/passwd is unsupported by AppArmor, audited instead of blocked" {
+		t.Errorf("[FAIL] Unexpected differences of an immutable rule with AppArmor (%v)", differences)
+	}
+	spec.File.MatchImmutable = nil
+
+	// nothing is enforced without an enforcer
+	differences = AnalyzePolicyCompatibility("eBPF Monitor", spec)
+	if len(differences) != 1 {
+		t.Errorf("[FAIL] Unexpected differences without an enforcer (%v)", differences)
+	}
+
+	// audit policies are the same everywhere
+	spec.Action = "Audit"
+	spec.Process.MatchPaths = nil
+	spec.Process.MatchPatterns = nil
+	if differences = AnalyzePolicyCompatibility("eBPF Monitor", spec); len(differences) != 0 {
+		t.Errorf("[FAIL] Unexpected differences of an audit policy (%v)", differences)
+	}
+
+	t.Log("[PASS] Analyzed the compatibility of policies")
+}
+
+func TestAnalyzePolicyCompatibilityArch(t *testing.T) {
+	prevArch := kl.BPFArch
+	defer func() { kl.BPFArch = prevArch }()
+
+	kl.BPFArch = "s390x"
+
+	spec := tp.SecuritySpec{Action: "Block"}
+	spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh"}}
+	spec.Network.MatchProtocols = []tp.NetworkProtocolType{{Protocol: "tcp"}, {Protocol: "udp", Action: "Audit"}}
+
+	// the network hooks aren't built for s390x
+	differences := AnalyzePolicyCompatibility("BPFLSM", spec)
+	if len(differences) != 1 || differences[0] != "network protocol tcp is unsupported by BPFLSM (network enforcement unavailable on s390x), audited instead of blocked" {
+		t.Errorf("[FAIL] Unexpected differences with BPFLSM on s390x (%v)", differences)
+	}
+
+	if differences = AnalyzePolicyCompatibility("AppArmor", spec); len(differences) != 0 {
+		t.Errorf("[FAIL] Unexpected differences with AppArmor on s390x (%v)", differences)
+	}
+
+	t.Log("[PASS] Analyzed the compatibility of policies per architecture")
+}
+
+func TestXattrNameKey(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		key     string
+		ok      bool
+	}{
+		{"security.ima", "security.ima", true},
+		{"security.*", "security.", true},
+		{"*", "", true},
+		{"user.*.sum", "", false},
+		{"user.[ab]", "", false},
+		{"trusted.?", "", false},
+		{"user.a.*", "", false},
+		{".*", "", false},
+	} {
+		if key, ok := XattrNameKey(tc.pattern); key != tc.key || ok != tc.ok {
+			t.Errorf("[FAIL] Unexpected key of %s (%q, %t)", tc.pattern, key, ok)
+		}
+	}
+
+	t.Log("[PASS] Matched the xattr names with the keys of the BPF LSM enforcer")
+}
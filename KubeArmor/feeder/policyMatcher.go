@@ -4,14 +4,15 @@
 package feeder
 
 import (
-	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
-	"syscall"
+	"time"
 
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
 	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
 	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
 )
 
@@ -30,23 +31,13 @@ func GetProtocolFromName(proto string) string {
 		return "protocol=ICMP,type=SOCK_RAW"
 	case "raw":
 		return "type=SOCK_RAW"
+	case "packet":
+		return "domain=AF_PACKET"
 	default:
 		return "unknown"
 	}
 }
 
-func getFileProcessUID(path string) string {
-	info, err := os.Stat(path)
-	if err == nil {
-		stat := info.Sys().(*syscall.Stat_t)
-		uid := stat.Uid
-
-		return strconv.Itoa(int(uid))
-	}
-
-	return ""
-}
-
 // getOperationAndCapabilityFromName Function
 func getOperationAndCapabilityFromName(capName string) (op, capability string) {
 	switch strings.ToLower(capName) {
@@ -77,11 +68,22 @@ func (fd *Feeder) newMatchPolicy(policyEnabled int, policyName, src string, mp i
 		match.ResourceType = "Path"
 
 		match.OwnerOnly = ppt.OwnerOnly
+		match.OnlyExecSession = ppt.OnlyExecSession
+		match.ExcludeExecSession = ppt.ExcludeExecSession
+
+		match.Rate = ppt.Rate
+		match.Burst = ppt.Burst
 
 		if policyEnabled == tp.KubeArmorPolicyAudited && ppt.Action == "Allow" {
 			match.Action = "Audit (" + ppt.Action + ")"
 		} else if policyEnabled == tp.KubeArmorPolicyAudited && ppt.Action == "Block" {
 			match.Action = "Audit (" + ppt.Action + ")"
+		} else if policyEnabled == tp.KubeArmorPolicyAudited && ppt.Action == "Throttle" {
+			match.Action = "Audit (" + ppt.Action + ")"
+		} else if policyEnabled == tp.KubeArmorPolicyEnabled && !throttleEnforceable(fd.Enforcer) && ppt.Action == "Throttle" {
+			// only the BPF LSM enforcer can deny the executions over the rate
+			kg.Warnf("Throttle rule of %s (%s) is unenforceable with %s, auditing the executions over the rate instead", policyName, ppt.Path, fd.Enforcer)
+			match.Action = "Audit (" + ppt.Action + ")"
 		} else {
 			match.Action = ppt.Action
 		}
@@ -95,6 +97,8 @@ func (fd *Feeder) newMatchPolicy(policyEnabled int, policyName, src string, mp i
 		match.ResourceType = "Directory"
 
 		match.OwnerOnly = pdt.OwnerOnly
+		match.OnlyExecSession = pdt.OnlyExecSession
+		match.ExcludeExecSession = pdt.ExcludeExecSession
 		match.Recursive = pdt.Recursive
 
 		if policyEnabled == tp.KubeArmorPolicyAudited && pdt.Action == "Allow" {
@@ -114,6 +118,8 @@ func (fd *Feeder) newMatchPolicy(policyEnabled int, policyName, src string, mp i
 		match.ResourceType = "" // to be defined based on the pattern matching syntax
 
 		match.OwnerOnly = ppt.OwnerOnly
+		match.OnlyExecSession = ppt.OnlyExecSession
+		match.ExcludeExecSession = ppt.ExcludeExecSession
 
 		if policyEnabled == tp.KubeArmorPolicyAudited && ppt.Action == "Allow" {
 			match.Action = "Audit (" + ppt.Action + ")"
@@ -133,6 +139,9 @@ func (fd *Feeder) newMatchPolicy(policyEnabled int, policyName, src string, mp i
 
 		match.OwnerOnly = fpt.OwnerOnly
 		match.ReadOnly = fpt.ReadOnly
+		match.CaptureOnBlock = fpt.CaptureOnBlock
+		match.OnlyExecSession = fpt.OnlyExecSession
+		match.ExcludeExecSession = fpt.ExcludeExecSession
 
 		if policyEnabled == tp.KubeArmorPolicyAudited && fpt.Action == "Allow" {
 			match.Action = "Audit (" + fpt.Action + ")"
@@ -153,6 +162,9 @@ func (fd *Feeder) newMatchPolicy(policyEnabled int, policyName, src string, mp i
 		match.OwnerOnly = fdt.OwnerOnly
 		match.ReadOnly = fdt.ReadOnly
 		match.Recursive = fdt.Recursive
+		match.CaptureOnBlock = fdt.CaptureOnBlock
+		match.OnlyExecSession = fdt.OnlyExecSession
+		match.ExcludeExecSession = fdt.ExcludeExecSession
 
 		if policyEnabled == tp.KubeArmorPolicyAudited && fdt.Action == "Allow" {
 			match.Action = "Audit (" + fdt.Action + ")"
@@ -171,6 +183,9 @@ func (fd *Feeder) newMatchPolicy(policyEnabled int, policyName, src string, mp i
 
 		match.OwnerOnly = fpt.OwnerOnly
 		match.ReadOnly = fpt.ReadOnly
+		match.CaptureOnBlock = fpt.CaptureOnBlock
+		match.OnlyExecSession = fpt.OnlyExecSession
+		match.ExcludeExecSession = fpt.ExcludeExecSession
 
 		if policyEnabled == tp.KubeArmorPolicyAudited && fpt.Action == "Allow" {
 			match.Action = "Audit (" + fpt.Action + ")"
@@ -179,6 +194,115 @@ func (fd *Feeder) newMatchPolicy(policyEnabled int, policyName, src string, mp i
 		} else {
 			match.Action = fpt.Action
 		}
+	} else if pnt, ok := mp.(tp.ProcessNamespaceType); ok {
+		match.Severity = strconv.Itoa(pnt.Severity)
+		match.Tags = pnt.Tags
+		match.Message = pnt.Message
+
+		match.Operation = "Process"
+		match.Resource = pnt.Namespace
+		match.ResourceType = "Namespace"
+
+		match.Operations = pnt.Operations
+
+		// not enforced by the monitor, block rules are audited
+		if pnt.Action == "Block" {
+			match.Action = "Audit (" + pnt.Action + ")"
+		} else {
+			match.Action = "Audit"
+		}
+	} else if pft, ok := mp.(tp.ProcessFilelessType); ok {
+		match.Severity = strconv.Itoa(pft.Severity)
+		match.Tags = pft.Tags
+		match.Message = pft.Message
+
+		match.Operation = "Process"
+		match.ResourceType = "Fileless"
+
+		for _, src := range pft.ExceptFromSource {
+			if len(src.Path) > 0 {
+				match.ExceptSources = append(match.ExceptSources, src.Path)
+			}
+		}
+
+		if policyEnabled == tp.KubeArmorPolicyAudited && pft.Action == "Block" {
+			match.Action = "Audit (" + pft.Action + ")"
+		} else if policyEnabled == tp.KubeArmorPolicyEnabled && !filelessEnforceable(fd.Enforcer) && pft.Action == "Block" {
+			// only the BPF LSM enforcer can deny the executions of files without a path
+			kg.Warnf("Fileless rule of %s is unenforceable with %s, auditing the fileless executions instead", policyName, fd.Enforcer)
+			match.Action = "Audit (" + pft.Action + ")"
+		} else {
+			match.Action = pft.Action
+		}
+	} else if pst, ok := mp.(tp.ProcessSignalType); ok {
+		match.Severity = strconv.Itoa(pst.Severity)
+		match.Tags = pst.Tags
+		match.Message = pst.Message
+
+		match.Operation = "Signal"
+		match.ResourceType = "Signal"
+
+		for _, signal := range pst.Signals {
+			if num, ok := kl.GetSignalNumber(signal); ok {
+				match.Signals = append(match.Signals, kl.GetSignalName(num))
+			}
+		}
+
+		match.SignalScope = pst.Scope
+		match.ExcludeSelf = pst.ExcludeSelf
+		match.ExcludeChildren = pst.ExcludeChildren
+
+		if policyEnabled == tp.KubeArmorPolicyAudited && pst.Action == "Block" {
+			match.Action = "Audit (" + pst.Action + ")"
+		} else if policyEnabled == tp.KubeArmorPolicyEnabled && !signalEnforceable(fd.Enforcer) && pst.Action == "Block" {
+			// only the BPF LSM enforcer can deny the signals
+			kg.Warnf("Signal rule of %s is unenforceable with %s, auditing the signals instead", policyName, fd.Enforcer)
+			match.Action = "Audit (" + pst.Action + ")"
+		} else {
+			match.Action = pst.Action
+		}
+	} else if fxt, ok := mp.(tp.FileXattrType); ok {
+		match.Severity = strconv.Itoa(fxt.Severity)
+		match.Tags = fxt.Tags
+		match.Message = fxt.Message
+
+		match.Operation = "File"
+		match.Resource = fxt.Name
+		match.ResourceType = "Xattr"
+
+		match.Target = fxt.Path
+		match.Operations = fxt.Operations
+
+		_, nameEnforceable := XattrNameKey(fxt.Name)
+
+		if policyEnabled == tp.KubeArmorPolicyAudited && fxt.Action == "Block" {
+			match.Action = "Audit (" + fxt.Action + ")"
+		} else if policyEnabled == tp.KubeArmorPolicyEnabled && (!fileAttributeEnforceable(fd.Enforcer) || !nameEnforceable) && fxt.Action == "Block" {
+			// only the BPF LSM enforcer can deny the changes of xattrs
+			kg.Warnf("Xattr rule of %s is unenforceable with %s, auditing the changes instead", policyName, fd.Enforcer)
+			match.Action = "Audit (" + fxt.Action + ")"
+		} else {
+			match.Action = fxt.Action
+		}
+	} else if fit, ok := mp.(tp.FileImmutableType); ok {
+		match.Severity = strconv.Itoa(fit.Severity)
+		match.Tags = fit.Tags
+		match.Message = fit.Message
+
+		match.Operation = "File"
+		match.ResourceType = "Immutable"
+
+		match.Target = fit.Path
+
+		if policyEnabled == tp.KubeArmorPolicyAudited && fit.Action == "Block" {
+			match.Action = "Audit (" + fit.Action + ")"
+		} else if policyEnabled == tp.KubeArmorPolicyEnabled && !fileAttributeEnforceable(fd.Enforcer) && fit.Action == "Block" {
+			// only the BPF LSM enforcer can deny the changes of inode flags
+			kg.Warnf("Immutable rule of %s is unenforceable with %s, auditing the changes instead", policyName, fd.Enforcer)
+			match.Action = "Audit (" + fit.Action + ")"
+		} else {
+			match.Action = fit.Action
+		}
 	} else if npt, ok := mp.(tp.NetworkProtocolType); ok {
 		match.Severity = strconv.Itoa(npt.Severity)
 		match.Tags = npt.Tags
@@ -194,9 +318,31 @@ func (fd *Feeder) newMatchPolicy(policyEnabled int, policyName, src string, mp i
 			match.Action = "Audit (" + npt.Action + ")"
 		} else if policyEnabled == tp.KubeArmorPolicyEnabled && fd.IsGKE && npt.Action == "Block" {
 			match.Action = "Audit (" + npt.Action + ")"
+		} else if policyEnabled == tp.KubeArmorPolicyEnabled && !networkEnforceable(fd.Enforcer) && npt.Action == "Block" {
+			// the network hooks of the BPF LSM enforcer aren't built for every architecture
+			kg.Warnf("Network rule of %s (%s) is unenforceable with %s on %s, auditing the sockets instead", policyName, npt.Protocol, fd.Enforcer, kl.BPFArch)
+			match.Action = "Audit (" + npt.Action + ")"
 		} else {
 			match.Action = npt.Action
 		}
+	} else if nrt, ok := mp.(tp.NetworkRuntimeSocketType); ok {
+		match.Severity = strconv.Itoa(nrt.Severity)
+		match.Tags = nrt.Tags
+		match.Message = nrt.Message
+
+		match.Operation = "Network"
+		match.Resource = "runtime_socket="
+		match.ResourceType = "RuntimeSocket"
+
+		if policyEnabled == tp.KubeArmorPolicyAudited && nrt.Action == "Block" {
+			match.Action = "Audit (" + nrt.Action + ")"
+		} else if policyEnabled == tp.KubeArmorPolicyEnabled && !runtimeSocketEnforceable(fd.Enforcer) && nrt.Action == "Block" {
+			// only the BPF LSM enforcer can tell the runtime sockets apart from the others
+			kg.Warnf("Runtime socket rule of %s is unenforceable with %s, auditing the connections instead", policyName, fd.Enforcer)
+			match.Action = "Audit (" + nrt.Action + ")"
+		} else {
+			match.Action = nrt.Action
+		}
 	} else if cct, ok := mp.(tp.CapabilitiesCapabilityType); ok {
 		match.Severity = strconv.Itoa(cct.Severity)
 		match.Tags = cct.Tags
@@ -235,9 +381,47 @@ func (fd *Feeder) newMatchPolicy(policyEnabled int, policyName, src string, mp i
 		return tp.MatchPolicy{}
 	}
 
+	auditSessionRule(&match)
+
 	return match
 }
 
+// newSignalMatchPolicies returns the match policies of a signal rule, one for each pair of the target and the source
+func (fd *Feeder) newSignalMatchPolicies(policyEnabled int, policyName string, sig tp.ProcessSignalType) []tp.MatchPolicy {
+	targets := []string{}
+	for _, target := range sig.Target {
+		if len(target.Path) > 0 {
+			targets = append(targets, target.Path)
+		}
+	}
+	if len(targets) == 0 {
+		targets = append(targets, "")
+	}
+
+	sources := []string{}
+	for _, src := range sig.FromSource {
+		if len(src.Path) > 0 {
+			sources = append(sources, src.Path)
+		}
+	}
+	if len(sources) == 0 {
+		sources = append(sources, "")
+	}
+
+	matches := []tp.MatchPolicy{}
+
+	for _, target := range targets {
+		for _, fromSource := range sources {
+			match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, sig)
+			match.Resource = target
+			match.IsFromSource = len(fromSource) > 0
+			matches = append(matches, match)
+		}
+	}
+
+	return matches
+}
+
 // UpdateSecurityPolicies Function
 func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 	name := endPoint.NamespaceName + "_" + endPoint.EndPointName
@@ -250,18 +434,29 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 	// ADDED | MODIFIED
 	matches := tp.MatchPolicies{}
 
-	for _, secPolicy := range endPoint.SecurityPolicies {
+	// policies in their maturation period or in the Audit mode
+	maturing := map[string]bool{}
+	auditMode := map[string]bool{}
+
+	// the rules of the policy overrides take precedence over the other rules, and the policies out of the windows of
+	// their schedules are left out
+	for _, secPolicy := range OverriddenSecurityPolicies(fd.ScheduledSecurityPolicies(endPoint.SecurityPolicies)) {
 		policyName := secPolicy.Metadata["policyName"]
 
 		if len(secPolicy.Spec.AppArmor) > 0 {
 			continue
 		}
 
+		// the Block rules of the maturing policies and of the policies in the Audit mode are audited
+		policyEnabled, ok := fd.maturingPolicyEnabled(endPoint.PolicyEnabled, secPolicy.Metadata)
+		maturing[policyName] = ok
+		policyEnabled, auditMode[policyName] = auditModePolicyEnabled(policyEnabled, secPolicy.Spec.Mode)
+
 		for _, path := range secPolicy.Spec.Process.MatchPaths {
 			fromSource := ""
 
 			if len(path.FromSource) == 0 {
-				match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, path)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, path)
 				matches.Policies = append(matches.Policies, match)
 				continue
 			}
@@ -273,7 +468,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 					continue
 				}
 
-				match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, path)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, path)
 				match.IsFromSource = len(fromSource) > 0
 				matches.Policies = append(matches.Policies, match)
 			}
@@ -283,7 +478,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 			fromSource := ""
 
 			if len(dir.FromSource) == 0 {
-				match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, dir)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, dir)
 				matches.Policies = append(matches.Policies, match)
 				continue
 			}
@@ -295,7 +490,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 					continue
 				}
 
-				match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, dir)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, dir)
 				match.IsFromSource = len(fromSource) > 0
 				matches.Policies = append(matches.Policies, match)
 			}
@@ -308,7 +503,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 
 			fromSource := ""
 
-			match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, patt)
+			match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, patt)
 
 			regexpComp, err := regexp.Compile(patt.Pattern)
 			if err != nil {
@@ -324,11 +519,50 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 			matches.Policies = append(matches.Policies, match)
 		}
 
+		for _, ns := range secPolicy.Spec.Process.MatchNamespaces {
+			if len(ns.Namespace) == 0 || ns.Action == "Allow" {
+				continue
+			}
+
+			fromSource := ""
+
+			if len(ns.FromSource) == 0 {
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, ns)
+				matches.Policies = append(matches.Policies, match)
+				continue
+			}
+
+			for _, src := range ns.FromSource {
+				if len(src.Path) > 0 {
+					fromSource = src.Path
+				} else {
+					continue
+				}
+
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, ns)
+				match.IsFromSource = len(fromSource) > 0
+				matches.Policies = append(matches.Policies, match)
+			}
+		}
+
+		if fileless := secPolicy.Spec.Process.BlockFileless; fileless != nil && (fileless.Action == "Audit" || fileless.Action == "Block") {
+			match := fd.newMatchPolicy(policyEnabled, policyName, "", *fileless)
+			matches.Policies = append(matches.Policies, match)
+		}
+
+		for _, sig := range secPolicy.Spec.Process.MatchSignals {
+			if len(sig.Signals) == 0 || sig.Action == "Allow" {
+				continue
+			}
+
+			matches.Policies = append(matches.Policies, fd.newSignalMatchPolicies(policyEnabled, policyName, sig)...)
+		}
+
 		for _, path := range secPolicy.Spec.File.MatchPaths {
 			fromSource := ""
 
 			if len(path.FromSource) == 0 {
-				match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, path)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, path)
 				matches.Policies = append(matches.Policies, match)
 				continue
 			}
@@ -340,7 +574,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 					continue
 				}
 
-				match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, path)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, path)
 				match.IsFromSource = len(fromSource) > 0
 				matches.Policies = append(matches.Policies, match)
 			}
@@ -350,7 +584,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 			fromSource := ""
 
 			if len(dir.FromSource) == 0 {
-				match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, dir)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, dir)
 				matches.Policies = append(matches.Policies, match)
 				continue
 			}
@@ -362,7 +596,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 					continue
 				}
 
-				match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, dir)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, dir)
 				match.IsFromSource = len(fromSource) > 0
 				matches.Policies = append(matches.Policies, match)
 			}
@@ -375,7 +609,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 
 			fromSource := ""
 
-			match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, patt)
+			match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, patt)
 
 			regexpComp, err := regexp.Compile(patt.Pattern)
 			if err != nil {
@@ -391,6 +625,58 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 			matches.Policies = append(matches.Policies, match)
 		}
 
+		for _, xattr := range secPolicy.Spec.File.MatchXattrs {
+			if len(xattr.Name) == 0 || xattr.Action == "Allow" {
+				continue
+			}
+
+			fromSource := ""
+
+			if len(xattr.FromSource) == 0 {
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, xattr)
+				matches.Policies = append(matches.Policies, match)
+				continue
+			}
+
+			for _, src := range xattr.FromSource {
+				if len(src.Path) > 0 {
+					fromSource = src.Path
+				} else {
+					continue
+				}
+
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, xattr)
+				match.IsFromSource = len(fromSource) > 0
+				matches.Policies = append(matches.Policies, match)
+			}
+		}
+
+		for _, imm := range secPolicy.Spec.File.MatchImmutable {
+			if imm.Action == "Allow" {
+				continue
+			}
+
+			fromSource := ""
+
+			if len(imm.FromSource) == 0 {
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, imm)
+				matches.Policies = append(matches.Policies, match)
+				continue
+			}
+
+			for _, src := range imm.FromSource {
+				if len(src.Path) > 0 {
+					fromSource = src.Path
+				} else {
+					continue
+				}
+
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, imm)
+				match.IsFromSource = len(fromSource) > 0
+				matches.Policies = append(matches.Policies, match)
+			}
+		}
+
 		for _, proto := range secPolicy.Spec.Network.MatchProtocols {
 			if len(proto.Protocol) == 0 {
 				continue
@@ -399,7 +685,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 			fromSource := ""
 
 			if len(proto.FromSource) == 0 {
-				match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, proto)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, proto)
 				if len(match.Resource) == 0 {
 					continue
 				}
@@ -414,7 +700,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 					continue
 				}
 
-				match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, proto)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, proto)
 				if len(match.Resource) == 0 {
 					continue
 				}
@@ -424,6 +710,28 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 
 		}
 
+		for _, sock := range secPolicy.Spec.Network.MatchRuntimeSockets {
+			if sock.Action != "Audit" && sock.Action != "Block" {
+				continue
+			}
+
+			if len(sock.FromSource) == 0 {
+				match := fd.newMatchPolicy(policyEnabled, policyName, "", sock)
+				matches.Policies = append(matches.Policies, match)
+				continue
+			}
+
+			for _, src := range sock.FromSource {
+				if len(src.Path) == 0 {
+					continue
+				}
+
+				match := fd.newMatchPolicy(policyEnabled, policyName, src.Path, sock)
+				match.IsFromSource = true
+				matches.Policies = append(matches.Policies, match)
+			}
+		}
+
 		for _, cap := range secPolicy.Spec.Capabilities.MatchCapabilities {
 			if len(cap.Capability) == 0 {
 				continue
@@ -432,7 +740,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 			fromSource := ""
 
 			if len(cap.FromSource) == 0 {
-				match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, cap)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, cap)
 				if len(match.Resource) == 0 {
 					continue
 				}
@@ -447,7 +755,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 					continue
 				}
 
-				match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, cap)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, cap)
 				if len(match.Resource) == 0 {
 					continue
 				}
@@ -470,7 +778,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 			if len(syscallRule.FromSource) == 0 {
 				for _, syscallName := range syscallRule.Syscalls {
 					syscall.Syscalls = []string{syscallName}
-					match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, syscall)
+					match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, syscall)
 					if len(match.ResourceType) == 0 {
 						continue
 					}
@@ -492,7 +800,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 				}
 				for _, syscallName := range syscallRule.Syscalls {
 					syscall.Syscalls = []string{syscallName}
-					match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, syscall)
+					match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, syscall)
 					if len(match.ResourceType) == 0 {
 						continue
 					}
@@ -518,7 +826,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 			if len(syscallRule.FromSource) == 0 {
 				for _, syscallName := range syscallRule.Syscalls {
 					syscall.Syscalls = []string{syscallName}
-					match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, syscall)
+					match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, syscall)
 					if len(match.ResourceType) == 0 && len(match.Resource) == 0 {
 						continue
 					}
@@ -541,7 +849,7 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 				}
 				for _, syscallName := range syscallRule.Syscalls {
 					syscall.Syscalls = []string{syscallName}
-					match := fd.newMatchPolicy(endPoint.PolicyEnabled, policyName, fromSource, syscall)
+					match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, syscall)
 					if len(match.ResourceType) == 0 && len(match.Resource) == 0 {
 						continue
 					}
@@ -555,7 +863,24 @@ func (fd *Feeder) UpdateSecurityPolicies(action string, endPoint tp.EndPoint) {
 		}
 	}
 
+	logAllowed := map[string]bool{}
+	ownerIdentity := map[string]string{}
+	for _, secPolicy := range endPoint.SecurityPolicies {
+		logAllowed[secPolicy.Metadata["policyName"]] = secPolicy.Spec.LogAllowed
+		ownerIdentity[secPolicy.Metadata["policyName"]] = secPolicy.Spec.OwnerIdentity
+	}
+	setLogAllowed(matches.Policies, logAllowed)
+	setMaturing(matches.Policies, maturing)
+	setWouldBeBlocked(matches.Policies, auditMode)
+
+	// the policies of an endpoint are in its namespace
+	for idx := range matches.Policies {
+		matches.Policies[idx].PolicyNamespace = endPoint.NamespaceName
+	}
+	setOwnerIdentity(matches.Policies, ownerIdentity, endPoint.SecurityContext)
+
 	fd.SecurityPoliciesLock.Lock()
+	setAttachedTimes(matches.Policies, fd.SecurityPolicies[name].Policies, time.Now())
 	fd.SecurityPolicies[name] = matches
 	fd.SecurityPoliciesLock.Unlock()
 }
@@ -574,18 +899,28 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 	// ADDED | MODIFIED
 	matches := tp.MatchPolicies{}
 
-	for _, secPolicy := range secPolicies {
+	// host policies in their maturation period or in the Audit mode
+	maturing := map[string]bool{}
+	auditMode := map[string]bool{}
+
+	// the rules of the policy overrides take precedence over the other rules
+	for _, secPolicy := range OverriddenHostSecurityPolicies(secPolicies) {
 		policyName := secPolicy.Metadata["policyName"]
 
 		if len(secPolicy.Spec.AppArmor) > 0 {
 			continue
 		}
 
+		// the Block rules of the maturing policies and of the policies in the Audit mode are audited
+		policyEnabled, ok := fd.maturingPolicyEnabled(fd.Node.PolicyEnabled, secPolicy.Metadata)
+		maturing[policyName] = ok
+		policyEnabled, auditMode[policyName] = auditModePolicyEnabled(policyEnabled, secPolicy.Spec.Mode)
+
 		for _, path := range secPolicy.Spec.Process.MatchPaths {
 			fromSource := ""
 
 			if len(path.FromSource) == 0 {
-				match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, path)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, path)
 				matches.Policies = append(matches.Policies, match)
 				continue
 			}
@@ -597,7 +932,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 					continue
 				}
 
-				match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, path)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, path)
 				match.IsFromSource = len(fromSource) > 0
 				matches.Policies = append(matches.Policies, match)
 			}
@@ -607,7 +942,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 			fromSource := ""
 
 			if len(dir.FromSource) == 0 {
-				match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, dir)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, dir)
 				matches.Policies = append(matches.Policies, match)
 				continue
 			}
@@ -619,7 +954,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 					continue
 				}
 
-				match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, dir)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, dir)
 				match.IsFromSource = len(fromSource) > 0
 				matches.Policies = append(matches.Policies, match)
 			}
@@ -648,11 +983,50 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 			matches.Policies = append(matches.Policies, match)
 		}
 
+		for _, ns := range secPolicy.Spec.Process.MatchNamespaces {
+			if len(ns.Namespace) == 0 || ns.Action == "Allow" {
+				continue
+			}
+
+			fromSource := ""
+
+			if len(ns.FromSource) == 0 {
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, ns)
+				matches.Policies = append(matches.Policies, match)
+				continue
+			}
+
+			for _, src := range ns.FromSource {
+				if len(src.Path) > 0 {
+					fromSource = src.Path
+				} else {
+					continue
+				}
+
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, ns)
+				match.IsFromSource = len(fromSource) > 0
+				matches.Policies = append(matches.Policies, match)
+			}
+		}
+
+		if fileless := secPolicy.Spec.Process.BlockFileless; fileless != nil && (fileless.Action == "Audit" || fileless.Action == "Block") {
+			match := fd.newMatchPolicy(policyEnabled, policyName, "", *fileless)
+			matches.Policies = append(matches.Policies, match)
+		}
+
+		for _, sig := range secPolicy.Spec.Process.MatchSignals {
+			if len(sig.Signals) == 0 || sig.Action == "Allow" {
+				continue
+			}
+
+			matches.Policies = append(matches.Policies, fd.newSignalMatchPolicies(policyEnabled, policyName, sig)...)
+		}
+
 		for _, path := range secPolicy.Spec.File.MatchPaths {
 			fromSource := ""
 
 			if len(path.FromSource) == 0 {
-				match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, path)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, path)
 				matches.Policies = append(matches.Policies, match)
 				continue
 			}
@@ -664,7 +1038,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 					continue
 				}
 
-				match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, path)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, path)
 				match.IsFromSource = len(fromSource) > 0
 				matches.Policies = append(matches.Policies, match)
 			}
@@ -674,7 +1048,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 			fromSource := ""
 
 			if len(dir.FromSource) == 0 {
-				match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, dir)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, dir)
 				matches.Policies = append(matches.Policies, match)
 				continue
 			}
@@ -686,7 +1060,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 					continue
 				}
 
-				match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, dir)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, dir)
 				match.IsFromSource = len(fromSource) > 0
 				matches.Policies = append(matches.Policies, match)
 			}
@@ -699,7 +1073,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 
 			fromSource := ""
 
-			match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, patt)
+			match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, patt)
 
 			regexpComp, err := regexp.Compile(patt.Pattern)
 			if err != nil {
@@ -715,6 +1089,58 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 			matches.Policies = append(matches.Policies, match)
 		}
 
+		for _, xattr := range secPolicy.Spec.File.MatchXattrs {
+			if len(xattr.Name) == 0 || xattr.Action == "Allow" {
+				continue
+			}
+
+			fromSource := ""
+
+			if len(xattr.FromSource) == 0 {
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, xattr)
+				matches.Policies = append(matches.Policies, match)
+				continue
+			}
+
+			for _, src := range xattr.FromSource {
+				if len(src.Path) > 0 {
+					fromSource = src.Path
+				} else {
+					continue
+				}
+
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, xattr)
+				match.IsFromSource = len(fromSource) > 0
+				matches.Policies = append(matches.Policies, match)
+			}
+		}
+
+		for _, imm := range secPolicy.Spec.File.MatchImmutable {
+			if imm.Action == "Allow" {
+				continue
+			}
+
+			fromSource := ""
+
+			if len(imm.FromSource) == 0 {
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, imm)
+				matches.Policies = append(matches.Policies, match)
+				continue
+			}
+
+			for _, src := range imm.FromSource {
+				if len(src.Path) > 0 {
+					fromSource = src.Path
+				} else {
+					continue
+				}
+
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, imm)
+				match.IsFromSource = len(fromSource) > 0
+				matches.Policies = append(matches.Policies, match)
+			}
+		}
+
 		for _, proto := range secPolicy.Spec.Network.MatchProtocols {
 			if len(proto.Protocol) == 0 {
 				continue
@@ -723,7 +1149,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 			fromSource := ""
 
 			if len(proto.FromSource) == 0 {
-				match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, proto)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, proto)
 				if len(match.Resource) == 0 {
 					continue
 				}
@@ -738,7 +1164,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 					continue
 				}
 
-				match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, proto)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, proto)
 				if len(match.Resource) == 0 {
 					continue
 				}
@@ -755,7 +1181,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 			fromSource := ""
 
 			if len(cap.FromSource) == 0 {
-				match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, cap)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, cap)
 				if len(match.Resource) == 0 {
 					continue
 				}
@@ -770,7 +1196,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 					continue
 				}
 
-				match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, cap)
+				match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, cap)
 				if len(match.Resource) == 0 {
 					continue
 				}
@@ -793,7 +1219,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 			if len(syscallRule.FromSource) == 0 {
 				for _, syscallName := range syscallRule.Syscalls {
 					syscall.Syscalls = []string{syscallName}
-					match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, syscall)
+					match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, syscall)
 					if len(match.ResourceType) == 0 {
 						continue
 					}
@@ -815,7 +1241,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 				}
 				for _, syscallName := range syscallRule.Syscalls {
 					syscall.Syscalls = []string{syscallName}
-					match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, syscall)
+					match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, syscall)
 					if len(match.ResourceType) == 0 {
 						continue
 					}
@@ -840,7 +1266,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 			if len(syscallRule.FromSource) == 0 {
 				for _, syscallName := range syscallRule.Syscalls {
 					syscall.Syscalls = []string{syscallName}
-					match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, syscall)
+					match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, syscall)
 					if len(match.ResourceType) == 0 && len(match.Resource) == 0 {
 						continue
 					}
@@ -863,7 +1289,7 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 				}
 				for _, syscallName := range syscallRule.Syscalls {
 					syscall.Syscalls = []string{syscallName}
-					match := fd.newMatchPolicy(fd.Node.PolicyEnabled, policyName, fromSource, syscall)
+					match := fd.newMatchPolicy(policyEnabled, policyName, fromSource, syscall)
 					if len(match.ResourceType) == 0 && len(match.Resource) == 0 {
 						continue
 					}
@@ -876,7 +1302,16 @@ func (fd *Feeder) UpdateHostSecurityPolicies(action string, secPolicies []tp.Hos
 		}
 	}
 
+	logAllowed := map[string]bool{}
+	for _, secPolicy := range secPolicies {
+		logAllowed[secPolicy.Metadata["policyName"]] = secPolicy.Spec.LogAllowed
+	}
+	setLogAllowed(matches.Policies, logAllowed)
+	setMaturing(matches.Policies, maturing)
+	setWouldBeBlocked(matches.Policies, auditMode)
+
 	fd.SecurityPoliciesLock.Lock()
+	setAttachedTimes(matches.Policies, fd.SecurityPolicies[fd.Node.NodeName].Policies, time.Now())
 	fd.SecurityPolicies[fd.Node.NodeName] = matches
 	fd.SecurityPoliciesLock.Unlock()
 }
@@ -898,8 +1333,95 @@ func (fd *Feeder) UpdateDefaultPosture(action string, namespace string, defaultP
 	}
 }
 
+// UpdateEndPointPosture Function
+func (fd *Feeder) UpdateEndPointPosture(action string, endPoint tp.EndPoint) {
+	name := endPoint.NamespaceName + "_" + endPoint.EndPointName
+
+	fd.DefaultPosturesLock.Lock()
+	defer fd.DefaultPosturesLock.Unlock()
+
+	// only keep the endpoints whose postures differ from the ones of their namespaces
+	if action == "DELETED" || endPoint.PostureOverride == (tp.DefaultPosture{}) {
+		delete(fd.EndPointPostures, name)
+	} else { // ADDED or MODIFIED
+		fd.EndPointPostures[name] = endPoint.DefaultPosture
+	}
+}
+
+// getDefaultPosture returns the default postures of an endpoint (DefaultPosturesLock should be held)
+func (fd *Feeder) getDefaultPosture(namespace, name string) tp.DefaultPosture {
+	if posture, ok := fd.EndPointPostures[name]; ok {
+		return posture
+	}
+	return fd.DefaultPostures[namespace]
+}
+
+// getPostureSource returns the layer which set the default posture of an operation
+func getPostureSource(posture tp.DefaultPosture, operation string) string {
+	switch operation {
+	case "Process", "File":
+		return posture.FileSource
+	case "Network":
+		return posture.NetworkSource
+	case "Capabilities":
+		return posture.CapabilitiesSource
+	default:
+		return ""
+	}
+}
+
+// ==================== //
+// == Severity Range == //
+// ==================== //
+
+// UpdateSeverityRange Function
+func (fd *Feeder) UpdateSeverityRange(action string, namespace string, severityRange tp.SeverityRange) {
+	fd.SeverityRangesLock.Lock()
+	defer fd.SeverityRangesLock.Unlock()
+
+	if action == "DELETED" || (severityRange.Min == 0 && severityRange.Max == 0) {
+		delete(fd.SeverityRanges, namespace)
+	} else { // ADDED or MODIFIED
+		fd.SeverityRanges[namespace] = severityRange
+	}
+}
+
+// ApplySeverityRange clamps the severity of an alert into the range of its namespace
+func (fd *Feeder) ApplySeverityRange(log tp.Log) tp.Log {
+	if log.Type != "MatchedPolicy" || log.NamespaceName == "" || log.Severity == "" {
+		return log
+	}
+
+	fd.SeverityRangesLock.RLock()
+	severityRange, ok := fd.SeverityRanges[log.NamespaceName]
+	fd.SeverityRangesLock.RUnlock()
+
+	if !ok {
+		return log
+	}
+
+	severity, err := strconv.Atoi(log.Severity)
+	if err != nil {
+		return log
+	}
+
+	// keep the severity set by the policy author
+	log.PolicySeverity = log.Severity
+
+	if severityRange.Min > 0 && severity < severityRange.Min {
+		severity = severityRange.Min
+	}
+	if severityRange.Max > 0 && severity > severityRange.Max {
+		severity = severityRange.Max
+	}
+
+	log.Severity = strconv.Itoa(severity)
+
+	return log
+}
+
 // Update Log Fields based on default posture and visibility configuration and return false if no updates
-func setLogFields(log *tp.Log, existAllowPolicy bool, defaultPosture string, visibility, containerEvent bool) bool {
+func setLogFields(log *tp.Log, existAllowPolicy bool, defaultPosture, postureSource string, visibility, containerEvent bool) bool {
 	if existAllowPolicy && defaultPosture == "audit" && (*log).Result == "Passed" {
 		if containerEvent {
 			(*log).Type = "MatchedPolicy"
@@ -908,6 +1430,7 @@ func setLogFields(log *tp.Log, existAllowPolicy bool, defaultPosture string, vis
 		}
 
 		(*log).PolicyName = "DefaultPosture"
+		(*log).PostureSource = postureSource
 		(*log).Enforcer = "eBPF Monitor"
 		(*log).Action = "Audit"
 
@@ -931,12 +1454,221 @@ func setLogFields(log *tp.Log, existAllowPolicy bool, defaultPosture string, vis
 // == Policy Matches == //
 // ==================== //
 
-func getDirectoryPart(path string) string {
-	dir := filepath.Dir(path)
-	if strings.HasPrefix(dir, "/") {
-		return dir + "/"
+// setMatchedPolicy Function sets the fields of the alert of a matched rule, the same for the container and the host
+// policies (the type of the host alerts is changed once matched)
+func setMatchedPolicy(log *tp.Log, secPolicy tp.MatchPolicy) {
+	log.Type = "MatchedPolicy"
+
+	log.PolicyName = secPolicy.PolicyName
+	log.PolicyNamespace = secPolicy.PolicyNamespace
+	log.MatchedRule = getMatchedRule(secPolicy)
+
+	log.Severity = secPolicy.Severity
+	log.Tags = strings.Join(secPolicy.Tags, ",")
+	log.ATags = secPolicy.Tags
+	log.Message = secPolicy.Message
+
+	log.WouldBeBlocked = secPolicy.WouldBeBlocked
+}
+
+// setDefaultPosture Function sets the fields of the alert of the default posture, which matched no rule
+func setDefaultPosture(log *tp.Log, postureSource string) {
+	log.Type = "MatchedPolicy"
+
+	log.PolicyName = "DefaultPosture"
+	log.PolicyNamespace = ""
+	log.MatchedRule = ""
+	log.PostureSource = postureSource
+
+	log.Severity = ""
+	log.Tags = ""
+	log.ATags = []string{}
+	log.Message = ""
+
+	log.WouldBeBlocked = false
+}
+
+// getMatchedRule Function returns the rule of a policy matched by an alert (e.g., process/path:/bin/sh)
+func getMatchedRule(secPolicy tp.MatchPolicy) string {
+	kind := ""
+	resource := secPolicy.Resource
+
+	switch secPolicy.ResourceType {
+	case "Path":
+		kind = "path"
+	case "Directory":
+		kind = "directory"
+	case "Glob", "":
+		kind = "pattern"
+	case "Namespace":
+		kind = "namespace"
+	case "Fileless":
+		kind = "fileless"
+	case "Xattr":
+		kind = "xattr"
+		resource = secPolicy.Resource + "@" + secPolicy.Target
+	case "Immutable":
+		kind = "immutable"
+		resource = secPolicy.Target
+	case "Signal":
+		kind = "signal"
+		resource = strings.Join(secPolicy.Signals, ",")
+	case "Protocol":
+		kind = "protocol"
+	case "RuntimeSocket":
+		kind = "runtimeSocket"
+		resource = ""
+	case "Capability":
+		kind = "capability"
+	default:
+		// the rules of system calls are typed by the name of the call
+		kind = strings.ToLower(secPolicy.ResourceType)
+	}
+
+	rule := strings.ToLower(secPolicy.Operation) + "/" + kind
+	if resource != "" {
+		rule += ":" + resource
+	}
+
+	return rule
+}
+
+// hasLogField checks if a field (key=value) is in the resource or the data of a log
+func hasLogField(data, field string) bool {
+	for _, f := range strings.Split(data, " ") {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// getLogDataField Function
+func getLogDataField(data, key string) string {
+	for _, field := range strings.Split(data, " ") {
+		if strings.HasPrefix(field, key+"=") {
+			return strings.TrimPrefix(field, key+"=")
+		}
 	}
-	return "__not_absolute_path__"
+	return ""
+}
+
+// isNamespaceLog Function
+func isNamespaceLog(log tp.Log) bool {
+	syscallName := getLogDataField(log.Data, "syscall")
+	return log.Operation == "Process" && (syscallName == "SYS_UNSHARE" || syscallName == "SYS_SETNS")
+}
+
+// matchNamespacePolicy Function
+func matchNamespacePolicy(secPolicy tp.MatchPolicy, log tp.Log) bool {
+	if !isNamespaceLog(log) || log.Resource != "namespace="+secPolicy.Resource {
+		return false
+	}
+
+	operation := strings.ToLower(strings.TrimPrefix(getLogDataField(log.Data, "syscall"), "SYS_"))
+	if len(secPolicy.Operations) > 0 && !kl.ContainsElement(secPolicy.Operations, operation) {
+		return false
+	}
+
+	// match sources
+	if secPolicy.IsFromSource && secPolicy.Source != log.ParentProcessName && secPolicy.Source != log.ProcessName {
+		return false
+	}
+
+	return true
+}
+
+// matchFileAttributePolicy Function
+func matchFileAttributePolicy(secPolicy tp.MatchPolicy, log tp.Log) bool {
+	syscallName := getLogDataField(log.Data, "syscall")
+
+	switch secPolicy.ResourceType {
+	case "Xattr":
+		operation := ""
+
+		switch syscallName {
+		case "SYS_SETXATTR", "SYS_LSETXATTR", "SYS_FSETXATTR":
+			operation = "set"
+		case "SYS_REMOVEXATTR", "SYS_LREMOVEXATTR", "SYS_FREMOVEXATTR":
+			operation = "remove"
+		default:
+			return false
+		}
+
+		if len(secPolicy.Operations) > 0 && !kl.ContainsElement(secPolicy.Operations, operation) {
+			return false
+		}
+
+		if matched, _ := filepath.Match(secPolicy.Resource, getLogDataField(log.Data, "xattr")); !matched {
+			return false
+		}
+	case "Immutable":
+		if syscallName != "SYS_IOCTL" || getLogDataField(log.Data, "cmd") != "FS_IOC_SETFLAGS" {
+			return false
+		}
+	default:
+		return false
+	}
+
+	// match the target (a file, or a directory with a trailing slash)
+	if len(secPolicy.Target) > 0 {
+		if strings.HasSuffix(secPolicy.Target, "/") {
+			if !strings.HasPrefix(log.Resource, secPolicy.Target) {
+				return false
+			}
+		} else if secPolicy.Target != log.Resource {
+			return false
+		}
+	}
+
+	// match sources
+	if secPolicy.IsFromSource && secPolicy.Source != log.ParentProcessName && secPolicy.Source != log.ProcessName {
+		return false
+	}
+
+	return true
+}
+
+// matchSignalPolicy Function
+func matchSignalPolicy(secPolicy tp.MatchPolicy, log tp.Log) bool {
+	if len(secPolicy.Signals) > 0 && !kl.ContainsElement(secPolicy.Signals, getLogDataField(log.Data, "signal")) {
+		return false
+	}
+
+	if len(secPolicy.SignalScope) > 0 && secPolicy.SignalScope != getLogDataField(log.Data, "scope") {
+		return false
+	}
+
+	// match the target (the executable of the target process)
+	if len(secPolicy.Resource) > 0 && secPolicy.Resource != log.Resource {
+		return false
+	}
+
+	relation := getLogDataField(log.Data, "relation")
+	if (secPolicy.ExcludeSelf && relation == "self") || (secPolicy.ExcludeChildren && relation == "child") {
+		return false
+	}
+
+	// match sources
+	if secPolicy.IsFromSource && secPolicy.Source != log.ParentProcessName && secPolicy.Source != log.ProcessName {
+		return false
+	}
+
+	return true
+}
+
+// matchRuntimeSocketPolicy Function
+func matchRuntimeSocketPolicy(secPolicy tp.MatchPolicy, log tp.Log) bool {
+	if !strings.Contains(log.Resource, secPolicy.Resource) {
+		return false
+	}
+
+	// match sources
+	if secPolicy.IsFromSource && secPolicy.Source != log.ParentProcessName && secPolicy.Source != log.ProcessName {
+		return false
+	}
+
+	return true
 }
 
 // UpdateMatchedPolicy Function
@@ -945,8 +1677,21 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 	existNetworkAllowPolicy := false
 	existCapabilitiesAllowPolicy := false
 
+	// the Allow rule which matched the log
+	allowedBy := tp.MatchPolicy{}
+
+	// the Block rule which denied the log
+	blockedBy := tp.MatchPolicy{}
+
+	// the sample of a blocked write taken by the enforcer, only attached to the alerts of captureOnBlock rules
+	hooked := log.Capture
+	log.Capture = nil
+
 	fd.DefaultPosturesLock.Lock()
 	defer fd.DefaultPosturesLock.Unlock()
+
+	defaultPosture := fd.getDefaultPosture(log.NamespaceName, log.NamespaceName+"_"+log.PodName)
+
 	if log.Result == "Passed" || log.Result == "Operation not permitted" || log.Result == "Permission denied" {
 		fd.SecurityPoliciesLock.RLock()
 
@@ -967,15 +1712,12 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 					existCapabilitiesAllowPolicy = true
 				}
 
-				if fd.DefaultPostures[log.NamespaceName].FileAction == "allow" {
+				if defaultPosture.FileAction == "allow" {
 					continue
 				}
 			}
 
 			firstLogResource := strings.Split(log.Resource, " ")[0]
-			firstLogResourceDir := getDirectoryPart(firstLogResource)
-			firstLogResourceDirCount := strings.Count(firstLogResourceDir, "/")
-			procDirCount := strings.Count(getDirectoryPart(log.ProcessName), "/")
 
 			switch log.Operation {
 			case "Process", "File":
@@ -983,6 +1725,62 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 					continue
 				}
 
+				// session rules only match the events in (or out of) the exec sessions
+				if !matchSession(secPolicy, log) {
+					continue
+				}
+
+				// namespace rules only match the namespace operations, and vice versa
+				if secPolicy.ResourceType == "Namespace" || isNamespaceLog(log) {
+					if secPolicy.ResourceType == "Namespace" && log.Result == "Passed" && matchNamespacePolicy(secPolicy, log) {
+						// matched source + matched namespace + matched operation -> alert (audit log)
+
+						setMatchedPolicy(&log, secPolicy)
+
+						log.Enforcer = "eBPF Monitor"
+						log.Action = secPolicy.Action
+					}
+
+					continue
+				}
+
+				// fileless rules only match the executions of files without a path
+				if secPolicy.ResourceType == "Fileless" {
+					if name, ok := matchFilelessPolicy(secPolicy, log); ok {
+						// matched execution without a path + not excepted source -> alert
+
+						setMatchedPolicy(&log, secPolicy)
+						log.Message = getFilelessMessage(secPolicy.Message, name)
+
+						if log.Result != "Passed" {
+							log.Enforcer = fd.Enforcer
+						} else {
+							log.Enforcer = "eBPF Monitor"
+						}
+						log.Action = secPolicy.Action
+					}
+
+					continue
+				}
+
+				// xattr and immutable rules only match the changes of file attributes
+				if secPolicy.ResourceType == "Xattr" || secPolicy.ResourceType == "Immutable" {
+					if matchFileAttributePolicy(secPolicy, log) {
+						// matched source + matched attribute + matched target -> alert
+
+						setMatchedPolicy(&log, secPolicy)
+
+						if log.Result != "Passed" {
+							log.Enforcer = fd.Enforcer
+						} else {
+							log.Enforcer = "eBPF Monitor"
+						}
+						log.Action = secPolicy.Action
+					}
+
+					continue
+				}
+
 				// match sources
 				if (!secPolicy.IsFromSource) || (secPolicy.IsFromSource && (secPolicy.Source == log.ParentProcessName || secPolicy.Source == log.ProcessName)) {
 					matchedRegex := false
@@ -1005,52 +1803,46 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 					// match resources
 					if matchedRegex || (secPolicy.Operation == "File" && secPolicy.ResourceType == "Path" && secPolicy.Resource == firstLogResource) ||
 						(secPolicy.Operation == "Process" && secPolicy.ResourceType == "Path" && secPolicy.Resource == log.ProcessName) ||
-						(secPolicy.Operation == "File" && secPolicy.ResourceType == "Directory" && strings.HasPrefix(firstLogResourceDir, secPolicy.Resource) &&
-							((!secPolicy.Recursive && firstLogResourceDirCount == strings.Count(secPolicy.Resource, "/")) ||
-								(secPolicy.Recursive && firstLogResourceDirCount >= strings.Count(secPolicy.Resource, "/")))) ||
-						(secPolicy.Operation == "Process" && secPolicy.ResourceType == "Directory" && strings.HasPrefix(getDirectoryPart(log.ProcessName), secPolicy.Resource) &&
-							((!secPolicy.Recursive && procDirCount == strings.Count(secPolicy.Resource, "/")) ||
-								(secPolicy.Recursive && procDirCount >= strings.Count(secPolicy.Resource, "/")))) {
-
-						matchedFlags := false
-
-						if secPolicy.ReadOnly && log.Resource != "" && secPolicy.OwnerOnly && log.MergedDir != "" {
-							// read only && owner only
-							if strings.Contains(log.Data, "O_RDONLY") && strconv.Itoa(int(log.UID)) == getFileProcessUID(log.MergedDir+log.Resource) {
-								matchedFlags = true
-							}
-						} else if secPolicy.ReadOnly && log.Resource != "" {
-							// read only
-							if strings.Contains(log.Data, "O_RDONLY") {
-								matchedFlags = true
-							}
-						} else if secPolicy.OwnerOnly && log.MergedDir != "" {
-							// owner only
-							if strconv.Itoa(int(log.UID)) == getFileProcessUID(log.MergedDir+log.Resource) {
-								matchedFlags = true
+						(secPolicy.Operation == "File" && secPolicy.ResourceType == "Directory" && InDirectory(secPolicy.Resource, secPolicy.Recursive, firstLogResource)) ||
+						(secPolicy.Operation == "Process" && secPolicy.ResourceType == "Directory" && InDirectory(secPolicy.Resource, secPolicy.Recursive, log.ProcessName)) {
+
+						// the owner of the file can't be told without the root of the container, then ownerOnly is ignored
+						readOnly := secPolicy.ReadOnly && log.Resource != ""
+						ownerOnly := secPolicy.OwnerOnly && log.MergedDir != ""
+						write := readOnly && !strings.Contains(log.Data, "O_RDONLY")
+
+						// the owner is looked up only if it decides
+						owner := ownerOnly && !write && matchOwner(&log, secPolicy)
+
+						matchedFlags := PermittedByFlags(readOnly, ownerOnly, write, owner)
+
+						if matchedFlags && (secPolicy.Action == "Throttle" || secPolicy.Action == "Audit (Throttle)") {
+							// throttle policy or throttle policy with audit mode
+							// matched source + matched resource + matched flags + token left + expected result -> going to be skipped
+							// matched source + matched resource + matched flags + (no token left or denied) -> alert
+
+							setMatchedPolicy(&log, secPolicy)
+
+							// the enforcer denies the executions over the rate of the enforced rules by itself
+							if log.Result != "Passed" {
+								log.Enforcer = fd.Enforcer
+								log.Action = ThrottleAction
+							} else if secPolicy.Action == "Throttle" || fd.takeThrottleToken(log, secPolicy) {
+								log.Enforcer = fd.Enforcer
+								log.Action = "Allow"
+							} else {
+								log.Enforcer = "eBPF Monitor"
+								log.Action = "Audit (" + ThrottleAction + ")"
 							}
-						} else {
-							// ! read only && ! owner only
-							matchedFlags = true
+
+							continue
 						}
 
 						if matchedFlags && (secPolicy.Action == "Allow" || secPolicy.Action == "Audit (Allow)") && log.Result == "Passed" {
 							// allow policy or allow policy with audit mode
 							// matched source + matched resource + matched flags + matched action + expected result -> going to be skipped
 
-							log.Type = "MatchedPolicy"
-
-							log.PolicyName = secPolicy.PolicyName
-							log.Severity = secPolicy.Severity
-
-							if len(secPolicy.Tags) > 0 {
-								log.Tags = strings.Join(secPolicy.Tags[:], ",")
-								log.ATags = secPolicy.Tags
-							}
-
-							if len(secPolicy.Message) > 0 {
-								log.Message = secPolicy.Message
-							}
+							setMatchedPolicy(&log, secPolicy)
 
 							if log.PolicyEnabled == tp.KubeArmorPolicyAudited {
 								log.Enforcer = "eBPF Monitor"
@@ -1059,6 +1851,7 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 							}
 
 							log.Action = "Allow"
+							allowedBy = secPolicy
 
 							continue
 						}
@@ -1067,19 +1860,7 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 							// audit policy
 							// matched source + matched resource + matched flags + matched action + expected result -> alert (audit log)
 
-							log.Type = "MatchedPolicy"
-
-							log.PolicyName = secPolicy.PolicyName
-							log.Severity = secPolicy.Severity
-
-							if len(secPolicy.Tags) > 0 {
-								log.Tags = strings.Join(secPolicy.Tags[:], ",")
-								log.ATags = secPolicy.Tags
-							}
-
-							if len(secPolicy.Message) > 0 {
-								log.Message = secPolicy.Message
-							}
+							setMatchedPolicy(&log, secPolicy)
 
 							log.Enforcer = "eBPF Monitor"
 							log.Action = secPolicy.Action
@@ -1088,26 +1869,14 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 						}
 
 						if (secPolicy.Action == "Block" && log.Result != "Passed") ||
-							(matchedFlags && (!secPolicy.OwnerOnly && !secPolicy.ReadOnly) && secPolicy.Action == "Audit (Block)" && log.Result == "Passed") ||
-							(!matchedFlags && (secPolicy.OwnerOnly || secPolicy.ReadOnly) && secPolicy.Action == "Audit (Block)" && log.Result == "Passed") {
+							(matchedFlags && (!secPolicy.OwnerOnly && !secPolicy.ReadOnly) && auditedBlock(secPolicy.Action) && log.Result == "Passed") ||
+							(!matchedFlags && (secPolicy.OwnerOnly || secPolicy.ReadOnly) && auditedBlock(secPolicy.Action) && log.Result == "Passed") {
 							// block policy or block policy with audit mode
 							// matched source + matched resource + matched action + expected result -> alert
 
-							log.Type = "MatchedPolicy"
-
-							log.PolicyName = secPolicy.PolicyName
-							log.Severity = secPolicy.Severity
-
-							if len(secPolicy.Tags) > 0 {
-								log.Tags = strings.Join(secPolicy.Tags[:], ",")
-								log.ATags = secPolicy.Tags
-							}
-
-							if len(secPolicy.Message) > 0 {
-								log.Message = secPolicy.Message
-							}
+							setMatchedPolicy(&log, secPolicy)
 
-							if log.PolicyEnabled == tp.KubeArmorPolicyAudited {
+							if log.PolicyEnabled == tp.KubeArmorPolicyAudited || secPolicy.WouldBeBlocked {
 								log.Enforcer = "eBPF Monitor"
 							} else {
 								log.Enforcer = fd.Enforcer
@@ -1115,6 +1884,23 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 
 							log.Action = secPolicy.Action
 
+							if secPolicy.Action == "Block" && log.Result != "Passed" {
+								blockedBy = secPolicy
+							}
+
+							continue
+						}
+
+						if secPolicy.Action == "Block" && log.Result == "Passed" && log.PolicyEnabled == tp.KubeArmorPolicyEnabled && blockExpected(secPolicy, matchedFlags) {
+							// block policy whose verdict wasn't applied
+							// matched source + matched resource + matched flags + unexpected result -> alert (enforcement failure)
+
+							setMatchedPolicy(&log, secPolicy)
+
+							log.Enforcer = fd.Enforcer
+							log.Action = secPolicy.Action
+							log.EnforcementStatus = undeliveredBlockStatus(log, secPolicy, fd.Enforcer)
+
 							continue
 						}
 
@@ -1127,14 +1913,7 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 					if secPolicy.Action == "Allow" && log.Result != "Passed" {
 						// matched source + !(matched resource) + action = allow + result = blocked -> default posture / allow policy violation
 
-						log.Type = "MatchedPolicy"
-
-						log.PolicyName = "DefaultPosture"
-
-						log.Severity = ""
-						log.Tags = ""
-						log.ATags = []string{}
-						log.Message = ""
+						setDefaultPosture(&log, getPostureSource(defaultPosture, log.Operation))
 
 						log.Enforcer = "eBPF Monitor"
 						log.Action = "Block"
@@ -1145,20 +1924,13 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 					if secPolicy.Action == "Audit (Allow)" && log.Result == "Passed" {
 						// matched source + !(matched resource) + action = audit (allow) + result = passed -> default posture / allow policy violation (audit mode)
 
-						log.Type = "MatchedPolicy"
-
-						log.PolicyName = "DefaultPosture"
-
-						log.Severity = ""
-						log.Tags = ""
-						log.ATags = []string{}
-						log.Message = ""
+						setDefaultPosture(&log, getPostureSource(defaultPosture, log.Operation))
 
 						log.Enforcer = "eBPF Monitor"
 
-						if fd.DefaultPostures[log.NamespaceName].FileAction == "block" {
+						if defaultPosture.FileAction == "block" {
 							log.Action = "Audit (Block)"
-						} else { // fd.DefaultPostures[log.NamespaceName].FileAction == "audit"
+						} else { // defaultPosture.FileAction == "audit"
 							log.Action = "Audit"
 						}
 
@@ -1168,31 +1940,17 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 
 				// apply the default postures when log.type isn't yet known
 
-				if fd.DefaultPostures[log.NamespaceName].FileAction == "block" && secPolicy.Action == "Audit (Allow)" && log.Result == "Passed" && log.Type == "" {
+				if defaultPosture.FileAction == "block" && secPolicy.Action == "Audit (Allow)" && log.Result == "Passed" && log.Type == "" {
 					// defaultPosture = block + audit mode
-					log.Type = "MatchedPolicy"
-
-					log.PolicyName = "DefaultPosture"
-
-					log.Severity = ""
-					log.Tags = ""
-					log.ATags = []string{}
-					log.Message = ""
+					setDefaultPosture(&log, getPostureSource(defaultPosture, log.Operation))
 
 					log.Enforcer = "eBPF Monitor"
 					log.Action = "Audit (Block)"
 				}
 
-				if fd.DefaultPostures[log.NamespaceName].FileAction == "audit" && (secPolicy.Action == "Allow" || secPolicy.Action == "Audit (Allow)") && log.Result == "Passed" && log.Type == "" {
+				if defaultPosture.FileAction == "audit" && (secPolicy.Action == "Allow" || secPolicy.Action == "Audit (Allow)") && log.Result == "Passed" && log.Type == "" {
 					// defaultPosture = audit
-					log.Type = "MatchedPolicy"
-
-					log.PolicyName = "DefaultPosture"
-
-					log.Severity = ""
-					log.Tags = ""
-					log.ATags = []string{}
-					log.Message = ""
+					setDefaultPosture(&log, getPostureSource(defaultPosture, log.Operation))
 
 					log.Enforcer = "eBPF Monitor"
 					log.Action = "Audit"
@@ -1203,6 +1961,27 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 					continue
 				}
 
+				// runtime socket rules only match the connections to the sockets of the container runtimes
+				if secPolicy.ResourceType == "RuntimeSocket" {
+					if log.Type == "MatchedPolicy" || !matchRuntimeSocketPolicy(secPolicy, log) {
+						continue
+					}
+
+					// matched runtime socket + matched source -> alert
+
+					setMatchedPolicy(&log, secPolicy)
+
+					if log.Result == "Passed" {
+						log.Enforcer = "eBPF Monitor"
+					} else {
+						log.Enforcer = fd.Enforcer
+					}
+
+					log.Action = secPolicy.Action
+
+					continue
+				}
+
 				// match sources
 				if (!secPolicy.IsFromSource) || (secPolicy.IsFromSource && (secPolicy.Source == log.ParentProcessName || secPolicy.Source == log.ProcessName)) {
 					skip := false
@@ -1213,24 +1992,13 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 						}
 
 						// match resources
-						if strings.Contains(log.Resource, matchProtocol) {
+						// the fields are matched exactly (e.g., ICMP rules don't match the ICMPv6 sockets, as in the enforcers)
+						if hasLogField(log.Resource, matchProtocol) {
 							if (secPolicy.Action == "Allow" || secPolicy.Action == "Audit (Allow)") && log.Result == "Passed" {
 								// allow policy or allow policy with audit mode
 								// matched source + matched resource + matched action + expected result -> going to be skipped
 
-								log.Type = "MatchedPolicy"
-
-								log.PolicyName = secPolicy.PolicyName
-								log.Severity = secPolicy.Severity
-
-								if len(secPolicy.Tags) > 0 {
-									log.Tags = strings.Join(secPolicy.Tags[:], ",")
-									log.ATags = secPolicy.Tags
-								}
-
-								if len(secPolicy.Message) > 0 {
-									log.Message = secPolicy.Message
-								}
+								setMatchedPolicy(&log, secPolicy)
 
 								if log.PolicyEnabled == tp.KubeArmorPolicyAudited {
 									log.Enforcer = "eBPF Monitor"
@@ -1239,6 +2007,7 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 								}
 
 								log.Action = "Allow"
+								allowedBy = secPolicy
 
 								skip = true
 								continue
@@ -1248,19 +2017,7 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 								// audit policy
 								// matched source + matched resource + matched action + expected result -> alert (audit log)
 
-								log.Type = "MatchedPolicy"
-
-								log.PolicyName = secPolicy.PolicyName
-								log.Severity = secPolicy.Severity
-
-								if len(secPolicy.Tags) > 0 {
-									log.Tags = strings.Join(secPolicy.Tags[:], ",")
-									log.ATags = secPolicy.Tags
-								}
-
-								if len(secPolicy.Message) > 0 {
-									log.Message = secPolicy.Message
-								}
+								setMatchedPolicy(&log, secPolicy)
 
 								log.Enforcer = "eBPF Monitor"
 								log.Action = secPolicy.Action
@@ -1270,24 +2027,13 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 							}
 
 							if (secPolicy.Action == "Block" && log.Result != "Passed") ||
-								(secPolicy.Action == "Audit (Block)" && log.Result == "Passed") {
+								(auditedBlock(secPolicy.Action) && log.Result == "Passed") {
 								// block policy or block policy with audit mode
 								// matched source + matched resource + matched action + expected result -> alert
 
-								log.Type = "MatchedPolicy"
-
-								log.PolicyName = secPolicy.PolicyName
-								log.Severity = secPolicy.Severity
-
-								if len(secPolicy.Tags) > 0 {
-									log.Tags = strings.Join(secPolicy.Tags[:], ",")
-								}
-
-								if len(secPolicy.Message) > 0 {
-									log.Message = secPolicy.Message
-								}
+								setMatchedPolicy(&log, secPolicy)
 
-								if log.PolicyEnabled == tp.KubeArmorPolicyAudited {
+								if log.PolicyEnabled == tp.KubeArmorPolicyAudited || secPolicy.WouldBeBlocked {
 									log.Enforcer = "eBPF Monitor"
 								} else {
 									log.Enforcer = fd.Enforcer
@@ -1308,13 +2054,7 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 					if secPolicy.Action == "Allow" && log.Result != "Passed" {
 						// matched source + !(matched resource) + action = allow + result = blocked -> allow policy violation
 
-						log.Type = "MatchedPolicy"
-
-						log.PolicyName = "DefaultPosture"
-
-						log.Severity = ""
-						log.Tags = ""
-						log.Message = ""
+						setDefaultPosture(&log, getPostureSource(defaultPosture, log.Operation))
 
 						log.Enforcer = "eBPF Monitor"
 						log.Action = "Block"
@@ -1325,19 +2065,13 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 					if secPolicy.Action == "Audit (Allow)" && log.Result == "Passed" {
 						// matched source + !(matched resource) + action = audit (allow) + result = passed -> allow policy violation (audit mode)
 
-						log.Type = "MatchedPolicy"
-
-						log.PolicyName = "DefaultPosture"
-
-						log.Severity = ""
-						log.Tags = ""
-						log.Message = ""
+						setDefaultPosture(&log, getPostureSource(defaultPosture, log.Operation))
 
 						log.Enforcer = "eBPF Monitor"
 
-						if fd.DefaultPostures[log.NamespaceName].NetworkAction == "block" {
+						if defaultPosture.NetworkAction == "block" {
 							log.Action = "Audit (Block)"
-						} else { // fd.DefaultPostures[log.NamespaceName].NetworkAction == "audit"
+						} else { // defaultPosture.NetworkAction == "audit"
 							log.Action = "Audit"
 						}
 
@@ -1345,35 +2079,40 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 					}
 				}
 
-				if fd.DefaultPostures[log.NamespaceName].NetworkAction == "block" && secPolicy.Action == "Audit (Allow)" && log.Result == "Passed" {
+				if defaultPosture.NetworkAction == "block" && secPolicy.Action == "Audit (Allow)" && log.Result == "Passed" {
 					// defaultPosture = block + audit mode
 
-					log.Type = "MatchedPolicy"
-
-					log.PolicyName = "DefaultPosture"
-
-					log.Severity = ""
-					log.Tags = ""
-					log.Message = ""
+					setDefaultPosture(&log, getPostureSource(defaultPosture, log.Operation))
 
 					log.Enforcer = "eBPF Monitor"
 					log.Action = "Audit (Block)"
 				}
 
-				if fd.DefaultPostures[log.NamespaceName].NetworkAction == "audit" && (secPolicy.Action == "Allow" || secPolicy.Action == "Audit (Allow)") && log.Result == "Passed" {
+				if defaultPosture.NetworkAction == "audit" && (secPolicy.Action == "Allow" || secPolicy.Action == "Audit (Allow)") && log.Result == "Passed" {
 					// defaultPosture = audit
 
-					log.Type = "MatchedPolicy"
+					setDefaultPosture(&log, getPostureSource(defaultPosture, log.Operation))
 
-					log.PolicyName = "DefaultPosture"
+					log.Enforcer = "eBPF Monitor"
+					log.Action = "Audit"
+				}
+			case "Signal":
+				if secPolicy.Operation != log.Operation || !matchSignalPolicy(secPolicy, log) {
+					continue
+				}
 
-					log.Severity = ""
-					log.Tags = ""
-					log.Message = ""
+				// matched signal + matched scope + matched target + matched source -> alert
 
+				setMatchedPolicy(&log, secPolicy)
+
+				if log.Result == "Passed" {
 					log.Enforcer = "eBPF Monitor"
-					log.Action = "Audit"
+				} else {
+					log.Enforcer = fd.Enforcer
 				}
+
+				log.Action = secPolicy.Action
+
 			case "Syscall":
 				if secPolicy.Operation != log.Operation {
 					continue
@@ -1401,16 +2140,7 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 					matchedRule = (len(secPolicy.Resource) == 0 || matchPath) && (!secPolicy.IsFromSource || fromSource)
 
 					if matchedRule {
-						log.Type = "MatchedPolicy"
-						log.PolicyName = secPolicy.PolicyName
-						log.Severity = secPolicy.Severity
-						if len(secPolicy.Tags) > 0 {
-							log.Tags = strings.Join(secPolicy.Tags[:], ",")
-						}
-
-						if len(secPolicy.Message) > 0 {
-							log.Message = secPolicy.Message
-						}
+						setMatchedPolicy(&log, secPolicy)
 					}
 				}
 
@@ -1419,18 +2149,12 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 
 		fd.SecurityPoliciesLock.RUnlock()
 
-		if log.PolicyName == "" && log.Result != "Passed" {
+		// the kernel denies the signals of unprivileged senders as well, which isn't the default posture
+		if log.PolicyName == "" && log.Result != "Passed" && log.Operation != "Signal" {
 			// default posture (block) or native policy
 			// no matched policy, but result = blocked -> default posture
 
-			log.Type = "MatchedPolicy"
-
-			log.PolicyName = "DefaultPosture"
-
-			log.Severity = ""
-			log.Tags = ""
-			log.ATags = []string{}
-			log.Message = ""
+			setDefaultPosture(&log, getPostureSource(defaultPosture, log.Operation))
 
 			log.Enforcer = fd.Enforcer
 			log.Action = "Block"
@@ -1446,56 +2170,73 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 					FileAction:         cfg.GlobalCfg.DefaultFilePosture,
 					NetworkAction:      cfg.GlobalCfg.DefaultNetworkPosture,
 					CapabilitiesAction: cfg.GlobalCfg.DefaultCapabilitiesPosture,
+
+					FileSource:         tp.PostureSourceGlobal,
+					NetworkSource:      tp.PostureSourceGlobal,
+					CapabilitiesSource: tp.PostureSourceGlobal,
 				}
 				fd.DefaultPostures[log.NamespaceName] = globalDefaultPosture
+				defaultPosture = fd.getDefaultPosture(log.NamespaceName, log.NamespaceName+"_"+log.PodName)
 			}
 
 			if log.Operation == "Process" {
-				if setLogFields(&log, existFileAllowPolicy, fd.DefaultPostures[log.NamespaceName].FileAction, log.ProcessVisibilityEnabled, true) {
+				// namespace operations aren't covered by the allow-lists of processes
+				if setLogFields(&log, existFileAllowPolicy && !isNamespaceLog(log), defaultPosture.FileAction, defaultPosture.FileSource, log.ProcessVisibilityEnabled, true) {
 					return log
 				}
 			} else if log.Operation == "File" {
-				if setLogFields(&log, existFileAllowPolicy, fd.DefaultPostures[log.NamespaceName].FileAction, log.FileVisibilityEnabled, true) {
+				if setLogFields(&log, existFileAllowPolicy, defaultPosture.FileAction, defaultPosture.FileSource, log.FileVisibilityEnabled, true) {
 					return log
 				}
 			} else if log.Operation == "Network" {
-				if setLogFields(&log, existNetworkAllowPolicy, fd.DefaultPostures[log.NamespaceName].NetworkAction, log.NetworkVisibilityEnabled, true) {
+				if setLogFields(&log, existNetworkAllowPolicy, defaultPosture.NetworkAction, defaultPosture.NetworkSource, log.NetworkVisibilityEnabled, true) {
 					return log
 				}
 			} else if log.Operation == "Capabilities" {
-				if setLogFields(&log, existCapabilitiesAllowPolicy, fd.DefaultPostures[log.NamespaceName].CapabilitiesAction, log.CapabilitiesVisibilityEnabled, true) {
+				if setLogFields(&log, existCapabilitiesAllowPolicy, defaultPosture.CapabilitiesAction, defaultPosture.CapabilitiesSource, log.CapabilitiesVisibilityEnabled, true) {
+					return log
+				}
+			} else if log.Operation == "Signal" {
+				if setLogFields(&log, false, "", "", log.SignalVisibilityEnabled, true) {
 					return log
 				}
 			} else if log.Operation == "Syscall" {
-				if setLogFields(&log, false, "", true, true) {
+				if setLogFields(&log, false, "", "", true, true) {
 					return log
 				}
 			}
 
 		} else if log.Type == "MatchedPolicy" {
 			if log.Action == "Allow" && log.Result == "Passed" {
+				fd.recordAllowed(log, allowedBy)
 				return tp.Log{}
 			}
 
+			attachWriteCapture(&log, blockedBy, hooked)
+
 			return log
 		}
 	} else { // host
 		if log.Type == "" {
 			// host log
 			if log.Operation == "Process" {
-				if setLogFields(&log, existFileAllowPolicy, "allow", fd.Node.ProcessVisibilityEnabled, false) {
+				if setLogFields(&log, existFileAllowPolicy, "allow", "", fd.Node.ProcessVisibilityEnabled, false) {
 					return log
 				}
 			} else if log.Operation == "File" {
-				if setLogFields(&log, existFileAllowPolicy, "allow", fd.Node.FileVisibilityEnabled, false) {
+				if setLogFields(&log, existFileAllowPolicy, "allow", "", fd.Node.FileVisibilityEnabled, false) {
 					return log
 				}
 			} else if log.Operation == "Network" {
-				if setLogFields(&log, existNetworkAllowPolicy, "allow", fd.Node.NetworkVisibilityEnabled, false) {
+				if setLogFields(&log, existNetworkAllowPolicy, "allow", "", fd.Node.NetworkVisibilityEnabled, false) {
 					return log
 				}
 			} else if log.Operation == "Capabilities" {
-				if setLogFields(&log, existCapabilitiesAllowPolicy, "allow", fd.Node.CapabilitiesVisibilityEnabled, false) {
+				if setLogFields(&log, existCapabilitiesAllowPolicy, "allow", "", fd.Node.CapabilitiesVisibilityEnabled, false) {
+					return log
+				}
+			} else if log.Operation == "Signal" {
+				if setLogFields(&log, false, "allow", "", fd.Node.SignalVisibilityEnabled, false) {
 					return log
 				}
 			}
@@ -1503,9 +2244,12 @@ func (fd *Feeder) UpdateMatchedPolicy(log tp.Log) tp.Log {
 			log.Type = "MatchedHostPolicy"
 
 			if log.Action == "Allow" && log.Result == "Passed" {
+				fd.recordAllowed(log, allowedBy)
 				return tp.Log{}
 			}
 
+			attachWriteCapture(&log, blockedBy, hooked)
+
 			return log
 		}
 	}
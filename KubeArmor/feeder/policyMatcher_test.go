@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+// parityRules returns the rules shared by the container policy and the host policy
+func parityRules() (tp.ProcessType, tp.FileType, tp.SyscallsType) {
+	process := tp.ProcessType{MatchPaths: []tp.ProcessPathType{
+		{Path: "/bin/sh", Action: "Block", Severity: 5, Tags: []string{"MITRE", "T1059"}, Message: "shell executed"},
+	}}
+	file := tp.FileType{MatchDirectories: []tp.FileDirectoryType{
+		{Directory: "/etc/", Recursive: true, Action: "Audit", Severity: 3, Tags: []string{"CIS"}, Message: "configuration accessed"},
+	}}
+	syscalls := tp.SyscallsType{MatchSyscalls: []tp.SyscallMatchType{
+		{Syscalls: []string{"unlink"}, Severity: 7, Tags: []string{"MITRE", "T1070"}, Message: "file deleted"},
+	}}
+	return process, file, syscalls
+}
+
+// scopeAlert blanks the fields of an alert which depend on its scope (container or host)
+func scopeAlert(alert *pb.Alert) *pb.Alert {
+	alert.Timestamp = 0
+	alert.UpdatedTime = ""
+	alert.NamespaceName = ""
+	alert.PodName = ""
+	alert.Labels = ""
+	alert.Owner = nil
+	alert.ContainerID = ""
+	alert.ContainerName = ""
+	alert.ContainerImage = ""
+	alert.Type = ""
+	alert.PolicyNamespace = ""
+	return alert
+}
+
+func TestHostAlertParity(t *testing.T) {
+	prevHost := cfg.GlobalCfg.Host
+	defer func() { cfg.GlobalCfg.Host = prevHost }()
+	cfg.GlobalCfg.Host = "node-1"
+
+	feeder := &Feeder{Node: &tp.Node{ClusterName: "default", NodeName: "node-1", PolicyEnabled: tp.KubeArmorPolicyEnabled}, Output: "none"}
+	feeder.SecurityPolicies = map[string]tp.MatchPolicies{}
+	feeder.SecurityPoliciesLock = new(sync.RWMutex)
+	feeder.DefaultPostures = map[string]tp.DefaultPosture{}
+	feeder.EndPointPostures = map[string]tp.DefaultPosture{}
+	feeder.DefaultPosturesLock = new(sync.Mutex)
+	feeder.SeverityRangesLock = new(sync.RWMutex)
+	feeder.SinksLock = new(sync.RWMutex)
+	feeder.EnforcementFailures = map[string]uint64{}
+	feeder.EnforcementFailuresLock = new(sync.RWMutex)
+	feeder.Enforcer = "AppArmor"
+
+	// subscribe to the alerts
+	alerts := make(chan *pb.Alert, 16)
+	AlertLock = new(sync.RWMutex)
+	AlertStructs = map[string]AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+	defer func() { AlertStructs = map[string]AlertStruct{} }()
+
+	process, file, syscalls := parityRules()
+
+	// the same rules in a container policy and in a host policy
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"namespaceName": "web", "policyName": "harden"}}
+	policy.Spec.Process, policy.Spec.File, policy.Spec.Syscalls = process, file, syscalls
+
+	endPoint := tp.EndPoint{NamespaceName: "web", EndPointName: "frontend", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{policy}
+	feeder.UpdateSecurityPolicies("ADDED", endPoint)
+
+	hostPolicy := tp.HostSecurityPolicy{Metadata: map[string]string{"policyName": "harden"}}
+	hostPolicy.Spec.Process, hostPolicy.Spec.File, hostPolicy.Spec.Syscalls = process, file, syscalls
+	feeder.UpdateHostSecurityPolicies("ADDED", []tp.HostSecurityPolicy{hostPolicy})
+
+	for key := range feeder.SecurityPolicies {
+		for idx := range feeder.SecurityPolicies[key].Policies {
+			feeder.SecurityPolicies[key].Policies[idx].Attached = time.Now().Add(-time.Minute)
+		}
+	}
+
+	events := []tp.Log{
+		{Operation: "Process", Source: "/bin/bash", Resource: "/bin/sh", ProcessName: "/bin/sh", Data: "syscall=SYS_EXECVE", Result: "Permission denied"},
+		{Operation: "File", Source: "/bin/cat", Resource: "/etc/hosts", ProcessName: "/bin/cat", Data: "syscall=SYS_OPENAT flags=O_RDONLY", Result: "Passed"},
+		{Operation: "Syscall", Source: "/bin/rm", Resource: "/tmp/trace", ProcessName: "/bin/rm", Data: "SYS_UNLINK", Result: "Passed"},
+	}
+
+	receive := func(log tp.Log) *pb.Alert {
+		feeder.PushLog(log)
+
+		select {
+		case alert := <-alerts:
+			return alert
+		case <-time.After(5 * time.Second):
+			t.Fatalf("[FAIL] Expected an alert for %s", log.Resource)
+		}
+		return nil
+	}
+
+	got := []*pb.Alert{}
+
+	for _, event := range events {
+		event.Timestamp = time.Now().Unix()
+		event.UpdatedTime = time.Now().UTC().Format(time.RFC3339Nano)
+		event.HostPPID, event.HostPID, event.PPID, event.PID = 100, 101, 100, 101
+		event.ParentProcessName = "/bin/bash"
+		event.Cwd = "/"
+
+		container := event
+		container.NamespaceName = "web"
+		container.PodName = "frontend"
+		container.Labels = "app=frontend"
+		container.ContainerID = "frontend"
+		container.ContainerName = "nginx"
+		container.PolicyEnabled = tp.KubeArmorPolicyEnabled
+
+		containerAlert := receive(container)
+		hostAlert := receive(event)
+
+		if containerAlert.PolicyNamespace != "web" || hostAlert.PolicyNamespace != "" {
+			t.Errorf("[FAIL] Unexpected namespaces of the policies (%s, %s)", containerAlert.PolicyNamespace, hostAlert.PolicyNamespace)
+		}
+		if hostAlert.Type != "MatchedHostPolicy" || hostAlert.Tags == "" || hostAlert.Message == "" || hostAlert.MatchedRule == "" {
+			t.Errorf("[FAIL] Expected the host alert to carry the fields of the rule (%+v)", hostAlert)
+		}
+
+		// the alerts only differ by their scope
+		containerJSON, _ := json.Marshal(scopeAlert(containerAlert))
+		hostJSON, _ := json.Marshal(scopeAlert(hostAlert))
+		if !bytes.Equal(containerJSON, hostJSON) {
+			t.Errorf("[FAIL] Expected the same alerts for %s\n%s\n%s", event.Resource, containerJSON, hostJSON)
+		}
+
+		got = append(got, hostAlert)
+	}
+
+	golden := filepath.Join("testdata", "hostAlertParity.golden.json")
+
+	gotJSON, _ := json.MarshalIndent(got, "", "  ")
+	gotJSON = append(gotJSON, '\n')
+
+	if *updateGolden {
+		if err := os.WriteFile(golden, gotJSON, 0600); err != nil {
+			t.Fatalf("[FAIL] Failed to update %s (%s)", golden, err.Error())
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to read %s (%s)", golden, err.Error())
+	}
+	if !bytes.Equal(gotJSON, want) {
+		t.Errorf("[FAIL] Unexpected alerts (go test -run TestHostAlertParity -update to accept)\n%s", gotJSON)
+	}
+
+	t.Log("[PASS] Raised the same alerts for the container policy and the host policy")
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"time"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ======================= //
+// == Policy Maturation == //
+// ======================= //
+
+// MaturingBlockAction is the action of the alerts of the Block rules audited during the maturation period
+const MaturingBlockAction = "Maturing(Block)"
+
+// PolicyMaturesAt returns when the maturation period of a policy elapses (zero if the policy has none)
+func PolicyMaturesAt(metadata map[string]string) time.Time {
+	maturesAt, err := time.Parse(time.RFC3339Nano, metadata["maturesAt"])
+	if err != nil {
+		return time.Time{}
+	}
+	return maturesAt
+}
+
+// PolicyMaturing checks if a policy is still in its maturation period
+func PolicyMaturing(metadata map[string]string, now time.Time) bool {
+	maturesAt := PolicyMaturesAt(metadata)
+	return !maturesAt.IsZero() && now.Before(maturesAt)
+}
+
+// now returns the time of the clock of the maturation periods
+func (fd *Feeder) now() time.Time {
+	if fd != nil && fd.Now != nil {
+		return fd.Now()
+	}
+	return time.Now()
+}
+
+// maturingPolicyEnabled returns the enforcement of the rules of a policy, audited during its maturation period
+func (fd *Feeder) maturingPolicyEnabled(policyEnabled int, metadata map[string]string) (int, bool) {
+	if policyEnabled != tp.KubeArmorPolicyEnabled || !PolicyMaturing(metadata, fd.now()) {
+		return policyEnabled, false
+	}
+	return tp.KubeArmorPolicyAudited, true
+}
+
+// setMaturing marks the audited Block rules of the maturing policies
+func setMaturing(matches []tp.MatchPolicy, maturing map[string]bool) {
+	for idx := range matches {
+		if maturing[matches[idx].PolicyName] && matches[idx].Action == "Audit (Block)" {
+			matches[idx].Action = MaturingBlockAction
+		}
+	}
+}
+
+// auditedBlock checks if an action is a Block rule reported as Audit (audit mode, or maturation period)
+func auditedBlock(action string) bool {
+	return action == "Audit (Block)" || action == MaturingBlockAction
+}
@@ -0,0 +1,218 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ==================== //
+// == Policy Metrics == //
+// ==================== //
+
+// stages of the application of a policy
+const (
+	PolicyStageGenerate = "generate" // generation of the profiles / rules
+	PolicyStageLoad     = "load"     // apparmor_parser / updates of the BPF maps
+	PolicyStageTotal    = "total"    // the whole update of the policy
+)
+
+// OtherPolicies is the label of the policies beyond the maximum number of tracked policies
+const OtherPolicies = "other"
+
+// PolicyApplyTimes Structure
+type PolicyApplyTimes struct {
+	Generate time.Duration
+	Load     time.Duration
+}
+
+// Add Function
+func (t PolicyApplyTimes) Add(other PolicyApplyTimes) PolicyApplyTimes {
+	return PolicyApplyTimes{Generate: t.Generate + other.Generate, Load: t.Load + other.Load}
+}
+
+// PolicyMetrics Structure
+type PolicyMetrics struct {
+	Registry *prometheus.Registry
+
+	durations *prometheus.HistogramVec
+
+	// drift of the applied policies from the API server
+	drift       *prometheus.GaugeVec
+	corrections prometheus.Counter
+
+	// tracked policies (namespace/policy), bounded to keep the cardinality of the labels
+	maxPolicies int
+	policies    map[string]struct{}
+
+	// policy -> time spent on the last application
+	lastApply map[string]time.Duration
+
+	lock *sync.Mutex
+}
+
+// NewPolicyMetrics Function
+func NewPolicyMetrics(maxPolicies int) *PolicyMetrics {
+	pm := &PolicyMetrics{}
+
+	pm.Registry = prometheus.NewRegistry()
+
+	pm.durations = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "kubearmor",
+		Name:      "policy_apply_duration_seconds",
+		Help:      "Time spent on applying the security policies to the endpoints, per stage",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14), // 1ms - 8s
+	}, []string{"policy", "enforcer", "stage"})
+	pm.Registry.MustRegister(pm.durations)
+
+	pm.drift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "kubearmor",
+		Name:      "policy_drift",
+		Help:      "Number of the policies drifted from the API server at the last check, per kind (missing|stale)",
+	}, []string{"kind"})
+	pm.Registry.MustRegister(pm.drift)
+
+	pm.corrections = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "kubearmor",
+		Name:      "policy_drift_corrections_total",
+		Help:      "Number of the drifted policies applied or removed to match the API server",
+	})
+	pm.Registry.MustRegister(pm.corrections)
+
+	pm.maxPolicies = maxPolicies
+	pm.policies = map[string]struct{}{}
+	pm.lastApply = map[string]time.Duration{}
+
+	pm.lock = new(sync.Mutex)
+
+	return pm
+}
+
+// getPolicyKey Function
+func getPolicyKey(namespace, policyName string) string {
+	if namespace == "" {
+		return policyName
+	}
+	return namespace + "/" + policyName
+}
+
+// policyLabel returns the label of a policy, or "other" once the maximum number of policies is tracked (lock should be held)
+func (pm *PolicyMetrics) policyLabel(key string) string {
+	if _, ok := pm.policies[key]; ok {
+		return key
+	}
+
+	if len(pm.policies) >= pm.maxPolicies {
+		return OtherPolicies
+	}
+
+	pm.policies[key] = struct{}{}
+	return key
+}
+
+// ObservePolicyApply records the time spent on applying a policy
+func (pm *PolicyMetrics) ObservePolicyApply(namespace, policyName, enforcer string, times PolicyApplyTimes, total time.Duration) {
+	if pm == nil {
+		return
+	}
+
+	if enforcer == "" {
+		enforcer = "none"
+	}
+
+	key := getPolicyKey(namespace, policyName)
+
+	pm.lock.Lock()
+	label := pm.policyLabel(key)
+	pm.lastApply[key] = total
+	pm.lock.Unlock()
+
+	pm.durations.WithLabelValues(label, enforcer, PolicyStageGenerate).Observe(times.Generate.Seconds())
+	pm.durations.WithLabelValues(label, enforcer, PolicyStageLoad).Observe(times.Load.Seconds())
+	pm.durations.WithLabelValues(label, enforcer, PolicyStageTotal).Observe(total.Seconds())
+}
+
+// ForgetPolicy removes the metrics of a deleted policy, and frees its slot for another policy
+func (pm *PolicyMetrics) ForgetPolicy(namespace, policyName string) {
+	if pm == nil {
+		return
+	}
+
+	key := getPolicyKey(namespace, policyName)
+
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+
+	delete(pm.lastApply, key)
+
+	if _, ok := pm.policies[key]; ok {
+		delete(pm.policies, key)
+		pm.durations.DeletePartialMatch(prometheus.Labels{"policy": key})
+	}
+}
+
+// GetLastApplyDuration returns the time spent on the last application of a policy
+func (pm *PolicyMetrics) GetLastApplyDuration(namespace, policyName string) time.Duration {
+	if pm == nil {
+		return 0
+	}
+
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+
+	return pm.lastApply[getPolicyKey(namespace, policyName)]
+}
+
+// ObservePolicyDrift records the drift found by a check, and the number of the policies corrected
+func (pm *PolicyMetrics) ObservePolicyDrift(missing, stale, corrected int) {
+	if pm == nil {
+		return
+	}
+
+	pm.drift.WithLabelValues("missing").Set(float64(missing))
+	pm.drift.WithLabelValues("stale").Set(float64(stale))
+	pm.corrections.Add(float64(corrected))
+}
+
+// ServeMetrics serves the Prometheus metrics on the given address
+func (fd *Feeder) ServeMetrics(addr string) {
+	if fd.PolicyMetrics == nil {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(fd.PolicyMetrics.Registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/schema/telemetry", ServeTelemetrySchema)
+
+	fd.metricsServer = &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	kg.Printf("Serving the metrics on %s", addr)
+
+	if err := fd.metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		kg.Warnf("Failed to serve the metrics on %s (%s)", addr, err.Error())
+	}
+}
+
+// closeMetricsServer Function
+func (fd *Feeder) closeMetricsServer() {
+	if fd.metricsServer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := fd.metricsServer.Shutdown(ctx); err != nil {
+		kg.Warnf("Failed to stop the metrics server (%s)", err.Error())
+	}
+}
@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ================= //
+// == Policy Mode == //
+// ================= //
+
+// EnforcedSecurityPolicies returns the policies of an endpoint which the enforcers apply, i.e., the policies out of
+// their maturation period and not in the Audit mode (the Block rules of the other policies are audited by the feeder),
+// within the windows of their schedules
+func (fd *Feeder) EnforcedSecurityPolicies(secPolicies []tp.SecurityPolicy) []tp.SecurityPolicy {
+	now := fd.now()
+
+	enforced := []tp.SecurityPolicy{}
+	for _, secPolicy := range secPolicies {
+		if !PolicyMaturing(secPolicy.Metadata, now) && secPolicy.Spec.Mode != tp.PolicyModeAudit && PolicyScheduled(secPolicy.Spec.Schedule, now) {
+			enforced = append(enforced, secPolicy)
+		}
+	}
+
+	return enforced
+}
+
+// EnforcedHostSecurityPolicies returns the host policies which the enforcers apply
+func (fd *Feeder) EnforcedHostSecurityPolicies(secPolicies []tp.HostSecurityPolicy) []tp.HostSecurityPolicy {
+	now := fd.now()
+
+	enforced := []tp.HostSecurityPolicy{}
+	for _, secPolicy := range secPolicies {
+		if !PolicyMaturing(secPolicy.Metadata, now) && secPolicy.Spec.Mode != tp.PolicyModeAudit {
+			enforced = append(enforced, secPolicy)
+		}
+	}
+
+	return enforced
+}
+
+// auditModePolicyEnabled returns the enforcement of the rules of a policy, audited in the Audit mode
+func auditModePolicyEnabled(policyEnabled int, mode string) (int, bool) {
+	if policyEnabled != tp.KubeArmorPolicyEnabled || mode != tp.PolicyModeAudit {
+		return policyEnabled, false
+	}
+	return tp.KubeArmorPolicyAudited, true
+}
+
+// setWouldBeBlocked marks the Block rules audited by the Audit mode or the maturation period of their policies
+func setWouldBeBlocked(matches []tp.MatchPolicy, auditMode map[string]bool) {
+	for idx := range matches {
+		if matches[idx].Action == MaturingBlockAction || (auditMode[matches[idx].PolicyName] && matches[idx].Action == "Audit (Block)") {
+			matches[idx].WouldBeBlocked = true
+		}
+	}
+}
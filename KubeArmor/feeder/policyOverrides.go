@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"strings"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ====================== //
+// == Policy Overrides == //
+// ====================== //
+
+// policy names of the alerts of the node-local policy overrides
+const (
+	PolicyOverrideAppliedPolicyName = "kubearmor-policy-override-applied"
+	PolicyOverrideExpiredPolicyName = "kubearmor-policy-override-expired"
+)
+
+// PolicyOverrideExpiresAt returns when a policy override expires (zero if the policy isn't an override)
+func PolicyOverrideExpiresAt(metadata map[string]string) time.Time {
+	expiresAt, err := time.Parse(time.RFC3339Nano, metadata["overrideExpiresAt"])
+	if err != nil {
+		return time.Time{}
+	}
+	return expiresAt
+}
+
+// PolicyOverride checks if a policy is a node-local override
+func PolicyOverride(metadata map[string]string) bool {
+	return !PolicyOverrideExpiresAt(metadata).IsZero()
+}
+
+// PolicyOverrideTransition is the application (or the expiry) of a policy override on the node
+type PolicyOverrideTransition struct {
+	Kind          string
+	NamespaceName string
+	PolicyName    string
+
+	Expired   bool
+	ExpiresAt time.Time
+
+	// why the override stopped (expired or removed from the file)
+	Reason string
+
+	Endpoints []string
+}
+
+// PushPolicyOverrideTransition raises a host alert and pushes a policy event for the application (or the expiry)
+// of a policy override on this node
+func (fd *Feeder) PushPolicyOverrideTransition(transition PolicyOverrideTransition) {
+	action := PolicyOverridden
+	reason := "expires at " + transition.ExpiresAt.UTC().Format(time.RFC3339)
+	if transition.Expired {
+		action = PolicyExpired
+		reason = transition.Reason
+	}
+
+	fd.broadcastPolicyEvent(fd.newPolicyEvent(transition.Kind, transition.NamespaceName, transition.PolicyName, action, reason, transition.Endpoints))
+
+	fd.pushMatchedLog(policyOverrideLog(transition))
+}
+
+// policyOverrideLog returns the alert raised when a policy override is applied or expires
+func policyOverrideLog(transition PolicyOverrideTransition) tp.Log {
+	log := tp.Log{}
+
+	timestamp, updatedTime := kl.GetDateTimeNow()
+
+	log.Timestamp = timestamp
+	log.UpdatedTime = updatedTime
+
+	log.Type = "MatchedHostPolicy"
+	log.Tags = "KUBEARMOR,POLICY_OVERRIDE"
+	log.ATags = strings.Split(log.Tags, ",")
+
+	policy := transition.Kind + "/" + transition.PolicyName
+	if transition.NamespaceName != "" {
+		policy = transition.Kind + "/" + transition.NamespaceName + "/" + transition.PolicyName
+	}
+
+	log.Data = "policy=" + policy + " expiresAt=" + transition.ExpiresAt.UTC().Format(time.RFC3339)
+	if len(transition.Endpoints) > 0 {
+		log.Data += " endpoints=" + strings.Join(transition.Endpoints, ",")
+	}
+
+	if transition.Expired {
+		log.PolicyName = PolicyOverrideExpiredPolicyName
+		log.Severity = "5"
+		log.Message = "Policy override " + policy + " no longer applied on this node (" + transition.Reason + ")"
+		log.Data += " reason=" + transition.Reason
+	} else {
+		log.PolicyName = PolicyOverrideAppliedPolicyName
+		log.Severity = "8"
+		log.Message = "Policy override " + policy + " applied on this node with the highest priority"
+	}
+
+	log.Source = "kubearmor"
+	log.ProcessName = "kubearmor"
+
+	log.Enforcer = "KubeArmor"
+	log.Action = "Audit"
+	log.Result = "Passed"
+
+	return log
+}
+
+// overriddenRules adds the keys of the rules of an override, which the rules of the other policies can't contradict
+func overriddenRules(process tp.ProcessType, file tp.FileType, network tp.NetworkType, capabilities tp.CapabilitiesType, rules map[string]bool) {
+	for _, path := range process.MatchPaths {
+		rules["process/path/"+path.Path] = true
+	}
+	for _, dir := range process.MatchDirectories {
+		rules["process/dir/"+dir.Directory] = true
+	}
+	for _, path := range file.MatchPaths {
+		rules["file/path/"+path.Path] = true
+	}
+	for _, dir := range file.MatchDirectories {
+		rules["file/dir/"+dir.Directory] = true
+	}
+	for _, protocol := range network.MatchProtocols {
+		rules["network/"+strings.ToLower(protocol.Protocol)] = true
+	}
+	for _, capability := range capabilities.MatchCapabilities {
+		rules["capabilities/"+strings.ToLower(capability.Capability)] = true
+	}
+}
+
+// withoutOverriddenRules removes the rules contradicting the overrides
+func withoutOverriddenRules(process *tp.ProcessType, file *tp.FileType, network *tp.NetworkType, capabilities *tp.CapabilitiesType, rules map[string]bool) {
+	processPaths := []tp.ProcessPathType{}
+	for _, path := range process.MatchPaths {
+		if !rules["process/path/"+path.Path] {
+			processPaths = append(processPaths, path)
+		}
+	}
+	process.MatchPaths = processPaths
+
+	processDirs := []tp.ProcessDirectoryType{}
+	for _, dir := range process.MatchDirectories {
+		if !rules["process/dir/"+dir.Directory] {
+			processDirs = append(processDirs, dir)
+		}
+	}
+	process.MatchDirectories = processDirs
+
+	filePaths := []tp.FilePathType{}
+	for _, path := range file.MatchPaths {
+		if !rules["file/path/"+path.Path] {
+			filePaths = append(filePaths, path)
+		}
+	}
+	file.MatchPaths = filePaths
+
+	fileDirs := []tp.FileDirectoryType{}
+	for _, dir := range file.MatchDirectories {
+		if !rules["file/dir/"+dir.Directory] {
+			fileDirs = append(fileDirs, dir)
+		}
+	}
+	file.MatchDirectories = fileDirs
+
+	protocols := []tp.NetworkProtocolType{}
+	for _, protocol := range network.MatchProtocols {
+		if !rules["network/"+strings.ToLower(protocol.Protocol)] {
+			protocols = append(protocols, protocol)
+		}
+	}
+	network.MatchProtocols = protocols
+
+	caps := []tp.CapabilitiesCapabilityType{}
+	for _, capability := range capabilities.MatchCapabilities {
+		if !rules["capabilities/"+strings.ToLower(capability.Capability)] {
+			caps = append(caps, capability)
+		}
+	}
+	capabilities.MatchCapabilities = caps
+}
+
+// OverriddenSecurityPolicies returns the policies of an endpoint with the highest priority given to the overrides:
+// the overrides come first, and the rules of the other policies on the same paths, directories, protocols and
+// capabilities are left out
+func OverriddenSecurityPolicies(secPolicies []tp.SecurityPolicy) []tp.SecurityPolicy {
+	rules := map[string]bool{}
+
+	overrides := []tp.SecurityPolicy{}
+	for _, secPolicy := range secPolicies {
+		if PolicyOverride(secPolicy.Metadata) {
+			overriddenRules(secPolicy.Spec.Process, secPolicy.Spec.File, secPolicy.Spec.Network, secPolicy.Spec.Capabilities, rules)
+			overrides = append(overrides, secPolicy)
+		}
+	}
+
+	if len(overrides) == 0 {
+		return secPolicies
+	}
+
+	for _, secPolicy := range secPolicies {
+		if PolicyOverride(secPolicy.Metadata) {
+			continue
+		}
+		withoutOverriddenRules(&secPolicy.Spec.Process, &secPolicy.Spec.File, &secPolicy.Spec.Network, &secPolicy.Spec.Capabilities, rules)
+		overrides = append(overrides, secPolicy)
+	}
+
+	return overrides
+}
+
+// OverriddenHostSecurityPolicies returns the host policies with the highest priority given to the overrides
+func OverriddenHostSecurityPolicies(secPolicies []tp.HostSecurityPolicy) []tp.HostSecurityPolicy {
+	rules := map[string]bool{}
+
+	overrides := []tp.HostSecurityPolicy{}
+	for _, secPolicy := range secPolicies {
+		if PolicyOverride(secPolicy.Metadata) {
+			overriddenRules(secPolicy.Spec.Process, secPolicy.Spec.File, secPolicy.Spec.Network, secPolicy.Spec.Capabilities, rules)
+			overrides = append(overrides, secPolicy)
+		}
+	}
+
+	if len(overrides) == 0 {
+		return secPolicies
+	}
+
+	for _, secPolicy := range secPolicies {
+		if PolicyOverride(secPolicy.Metadata) {
+			continue
+		}
+		withoutOverriddenRules(&secPolicy.Spec.Process, &secPolicy.Spec.File, &secPolicy.Spec.Network, &secPolicy.Spec.Capabilities, rules)
+		overrides = append(overrides, secPolicy)
+	}
+
+	return overrides
+}
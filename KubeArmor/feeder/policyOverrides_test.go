@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"testing"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestOverriddenHostSecurityPolicies(t *testing.T) {
+	regular := tp.HostSecurityPolicy{Metadata: map[string]string{"policyName": "harden-node"}}
+	regular.Spec.File.MatchDirectories = []tp.FileDirectoryType{{Directory: "/etc/ssh/", Action: "Block"}, {Directory: "/var/log/", Action: "Audit"}}
+	regular.Spec.Network.MatchProtocols = []tp.NetworkProtocolType{{Protocol: "raw", Action: "Block"}}
+	regular.Spec.Capabilities.MatchCapabilities = []tp.CapabilitiesCapabilityType{{Capability: "net_raw", Action: "Block"}}
+
+	// without overrides, the policies are kept as they are
+	if policies := OverriddenHostSecurityPolicies([]tp.HostSecurityPolicy{regular}); len(policies) != 1 || len(policies[0].Spec.File.MatchDirectories) != 2 {
+		t.Fatalf("[FAIL] Expected the policies to be kept (%+v)", policies)
+	}
+
+	override := tp.HostSecurityPolicy{Metadata: map[string]string{"policyName": "kubearmor-override-ssh", "overrideExpiresAt": "2023-06-01T12:30:00Z"}}
+	override.Spec.File.MatchDirectories = []tp.FileDirectoryType{{Directory: "/etc/ssh/", Action: "Allow"}}
+	override.Spec.Network.MatchProtocols = []tp.NetworkProtocolType{{Protocol: "RAW", Action: "Allow"}}
+
+	policies := OverriddenHostSecurityPolicies([]tp.HostSecurityPolicy{regular, override})
+
+	// the override comes first, and the rules of the regular policy on the same directory and protocol are left out
+	if len(policies) != 2 || policies[0].Metadata["policyName"] != "kubearmor-override-ssh" {
+		t.Fatalf("[FAIL] Expected the override first (%+v)", policies)
+	}
+	if dirs := policies[1].Spec.File.MatchDirectories; len(dirs) != 1 || dirs[0].Directory != "/var/log/" {
+		t.Errorf("[FAIL] Expected the directory of the override to be left out (%+v)", dirs)
+	}
+	if protocols := policies[1].Spec.Network.MatchProtocols; len(protocols) != 0 {
+		t.Errorf("[FAIL] Expected the protocol of the override to be left out (%+v)", protocols)
+	}
+	if caps := policies[1].Spec.Capabilities.MatchCapabilities; len(caps) != 1 {
+		t.Errorf("[FAIL] Expected the other rules to be kept (%+v)", caps)
+	}
+
+	// the policy itself is left unchanged
+	if len(regular.Spec.File.MatchDirectories) != 2 || len(regular.Spec.Network.MatchProtocols) != 1 {
+		t.Errorf("[FAIL] Expected the regular policy not to be modified (%+v)", regular.Spec)
+	}
+
+	t.Log("[PASS] Gave the highest priority to the rules of the overrides")
+}
@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"time"
+	// the time zones of the schedules, whatever the image
+	_ "time/tzdata"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	ksp "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+)
+
+// ===================== //
+// == Policy Schedule == //
+// ===================== //
+
+// ParsePolicySchedule parses the schedule of a policy (nil if the policy has none)
+func ParsePolicySchedule(schedule *tp.ScheduleType) (*ksp.PolicySchedule, error) {
+	if schedule == nil {
+		return nil, nil
+	}
+
+	windows := []ksp.ScheduleWindowType{}
+	for _, window := range schedule.Windows {
+		windows = append(windows, ksp.ScheduleWindowType{Start: window.Start, End: window.End})
+	}
+
+	return ksp.ParsePolicySchedule(ksp.ScheduleType{Windows: windows, TimeZone: schedule.TimeZone})
+}
+
+// PolicyScheduled checks if a policy is within a window of its schedule (always if it has none)
+func PolicyScheduled(schedule *tp.ScheduleType, now time.Time) bool {
+	ps, err := ParsePolicySchedule(schedule)
+	if err != nil || ps == nil {
+		// the invalid schedules are rejected when the policies are applied
+		return true
+	}
+
+	active, _ := ps.Active(now)
+	return active
+}
+
+// ScheduledSecurityPolicies returns the policies of an endpoint within the windows of their schedules, the other
+// policies being left out of the rules and of the alerts
+func (fd *Feeder) ScheduledSecurityPolicies(secPolicies []tp.SecurityPolicy) []tp.SecurityPolicy {
+	now := fd.now()
+
+	scheduled := []tp.SecurityPolicy{}
+	for _, secPolicy := range secPolicies {
+		if PolicyScheduled(secPolicy.Spec.Schedule, now) {
+			scheduled = append(scheduled, secPolicy)
+		}
+	}
+
+	return scheduled
+}
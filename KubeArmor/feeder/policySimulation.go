@@ -0,0 +1,372 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ======================= //
+// == Policy Simulation == //
+// ======================= //
+
+// SimulationEnforcers are the enforcers whose enforcement of a simulated policy is compared
+var SimulationEnforcers = []string{"AppArmor", "BPFLSM"}
+
+// LogTrace passes the events of a recorded trace to fn until it returns false
+type LogTrace func(fn func(log tp.Log) bool) error
+
+// SimulatedMatch Structure is an event of a trace matched by a simulated policy
+type SimulatedMatch struct {
+	// position of the event in the trace
+	Index int
+
+	// action which would have applied (e.g., Block), and the result of the event when it was recorded
+	Action string
+	Result string
+
+	// the event with the fields of the matched rule
+	Log tp.Log
+}
+
+// SimulationResult Structure
+type SimulationResult struct {
+	// events in the scope of the policy (the pods it selects, or the host)
+	Evaluated int
+
+	Matches []SimulatedMatch
+
+	// differences of the enforcement by enforcer, only if the rules are enforcer-sensitive
+	Differences map[string][]string
+
+	// rules which a recorded trace can't tell
+	Warnings []string
+}
+
+// ReadLogTrace returns the trace of the events written by the file sink (JSON lines), skipping the other lines
+func ReadLogTrace(r io.Reader) LogTrace {
+	return func(fn func(log tp.Log) bool) error {
+		reader := bufio.NewReader(r)
+
+		for {
+			line, err := reader.ReadBytes('\n')
+
+			if record := bytes.TrimSpace(line); len(record) > 0 {
+				log := tp.Log{}
+				if json.Unmarshal(record, &log) == nil && log.Operation != "" && !fn(log) {
+					return nil
+				}
+			}
+
+			if errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ArchiveLogTrace returns the trace of the events in the alert archive within [since, until) (zero times for no bounds)
+func ArchiveLogTrace(dir string, since, until time.Time) LogTrace {
+	return func(fn func(log tp.Log) bool) error {
+		return ReadAlertArchive(dir, since, until, func(record []byte) bool {
+			log := tp.Log{}
+			if err := json.Unmarshal(record, &log); err != nil || log.Operation == "" {
+				return true
+			}
+
+			// the segments overlapping the bounds hold the events out of them as well
+			if ts := time.Unix(log.Timestamp, 0); (!since.IsZero() && ts.Before(since)) || (!until.IsZero() && !ts.Before(until)) {
+				return true
+			}
+
+			return fn(log)
+		})
+	}
+}
+
+// policySimulation Structure replays a trace against a feeder holding the simulated policy only
+type policySimulation struct {
+	fd *Feeder
+
+	// time of the event being replayed, for the Throttle rules
+	clock time.Time
+
+	// the events out of the windows of the schedule of the policy aren't matched
+	schedule *tp.ScheduleType
+
+	result SimulationResult
+}
+
+// newPolicySimulation returns a simulation with the default postures of fd, leaving the live state alone
+func (fd *Feeder) newPolicySimulation() *policySimulation {
+	ps := &policySimulation{result: SimulationResult{Matches: []SimulatedMatch{}, Differences: map[string][]string{}, Warnings: []string{}}}
+
+	sim := &Feeder{Node: &tp.Node{NodeName: cfg.GlobalCfg.Host, PolicyEnabled: tp.KubeArmorPolicyEnabled}, Enforcer: fd.Enforcer}
+
+	sim.SecurityPolicies = map[string]tp.MatchPolicies{}
+	sim.SecurityPoliciesLock = new(sync.RWMutex)
+
+	sim.DefaultPostures = map[string]tp.DefaultPosture{}
+	sim.EndPointPostures = map[string]tp.DefaultPosture{}
+	sim.DefaultPosturesLock = new(sync.Mutex)
+
+	fd.DefaultPosturesLock.Lock()
+	for namespace, posture := range fd.DefaultPostures {
+		sim.DefaultPostures[namespace] = posture
+	}
+	for endPoint, posture := range fd.EndPointPostures {
+		sim.EndPointPostures[endPoint] = posture
+	}
+	fd.DefaultPosturesLock.Unlock()
+
+	// the rates of the Throttle rules are measured on the clock of the trace
+	sim.Throttler = NewThrottler()
+	sim.Throttler.Now = func() time.Time { return ps.clock }
+
+	ps.fd = sim
+
+	return ps
+}
+
+// simulatedMetadata returns the metadata of a simulated policy, out of its maturation period and of the overrides
+func simulatedMetadata(metadata map[string]string) map[string]string {
+	simulated := map[string]string{}
+	for k, v := range metadata {
+		if k != "maturesAt" && k != "overrideExpiresAt" {
+			simulated[k] = v
+		}
+	}
+	return simulated
+}
+
+// simulationEvent returns the event of a recorded log without the verdict of the live policies, evaluated as an
+// attempt (Passed) whatever the recorded result
+func simulationEvent(log tp.Log) tp.Log {
+	return tp.Log{
+		Timestamp:   log.Timestamp,
+		UpdatedTime: log.UpdatedTime,
+
+		ClusterName: log.ClusterName,
+		HostName:    log.HostName,
+
+		NamespaceName: log.NamespaceName,
+		Owner:         log.Owner,
+		PodName:       log.PodName,
+		Labels:        log.Labels,
+
+		ContainerID:    log.ContainerID,
+		ContainerName:  log.ContainerName,
+		ContainerImage: log.ContainerImage,
+		Privileged:     log.Privileged,
+		ContainerState: log.ContainerState,
+		ContainerType:  log.ContainerType,
+
+		HostPPID: log.HostPPID,
+		HostPID:  log.HostPID,
+		PPID:     log.PPID,
+		PID:      log.PID,
+		UID:      log.UID,
+
+		ParentProcessName: log.ParentProcessName,
+		ProcessName:       log.ProcessName,
+
+		Source:        log.Source,
+		Operation:     log.Operation,
+		Resource:      log.Resource,
+		Cwd:           log.Cwd,
+		Data:          log.Data,
+		SocketCreator: log.SocketCreator,
+		Session:       log.Session,
+
+		Result: "Passed",
+
+		PolicyEnabled: tp.KubeArmorPolicyEnabled,
+	}
+}
+
+// simulatedAction returns the action which would have applied to an event matched in the Audit mode
+func simulatedAction(action string) string {
+	if strings.HasPrefix(action, "Audit (") && strings.HasSuffix(action, ")") {
+		return strings.TrimSuffix(strings.TrimPrefix(action, "Audit ("), ")")
+	}
+	return action
+}
+
+// replay matches a recorded event with the simulated policy
+func (ps *policySimulation) replay(idx int, recorded tp.Log) {
+	ps.result.Evaluated++
+	ps.clock = time.Unix(recorded.Timestamp, 0)
+
+	if !PolicyScheduled(ps.schedule, ps.clock) {
+		return
+	}
+
+	log := ps.fd.UpdateMatchedPolicy(simulationEvent(recorded))
+	if log.Type != "MatchedPolicy" && log.Type != "MatchedHostPolicy" {
+		return
+	}
+
+	log.Action = simulatedAction(log.Action)
+	log.Result = recorded.Result
+
+	ps.result.Matches = append(ps.result.Matches, SimulatedMatch{Index: idx, Action: log.Action, Result: recorded.Result, Log: log})
+}
+
+// simulationDifferences returns the differences of the enforcement by enforcer, only if they differ between enforcers
+func simulationDifferences(analyze func(enforcer string) []string) map[string][]string {
+	differences := map[string][]string{}
+	sensitive := false
+
+	for _, enforcer := range SimulationEnforcers {
+		differences[enforcer] = analyze(enforcer)
+		if strings.Join(differences[enforcer], "\n") != strings.Join(differences[SimulationEnforcers[0]], "\n") {
+			sensitive = true
+		}
+	}
+
+	if !sensitive {
+		return map[string][]string{}
+	}
+
+	return differences
+}
+
+// simulationWarnings returns the rules of a policy which a recorded trace can't tell
+func simulationWarnings(process tp.ProcessType, file tp.FileType, appArmor string) []string {
+	warnings := []string{}
+
+	// the owners of the files aren't recorded
+	ownerOnly := func(rule string) {
+		warnings = append(warnings, "ownerOnly rule "+rule+" can't be evaluated on a recorded trace, not matched")
+	}
+	for _, path := range process.MatchPaths {
+		if path.OwnerOnly {
+			ownerOnly("process path " + path.Path)
+		}
+	}
+	for _, dir := range process.MatchDirectories {
+		if dir.OwnerOnly {
+			ownerOnly("process directory " + dir.Directory)
+		}
+	}
+	for _, pat := range process.MatchPatterns {
+		if pat.OwnerOnly {
+			ownerOnly("process pattern " + pat.Pattern)
+		}
+	}
+	for _, path := range file.MatchPaths {
+		if path.OwnerOnly {
+			ownerOnly("file path " + path.Path)
+		}
+	}
+	for _, dir := range file.MatchDirectories {
+		if dir.OwnerOnly {
+			ownerOnly("file directory " + dir.Directory)
+		}
+	}
+	for _, pat := range file.MatchPatterns {
+		if pat.OwnerOnly {
+			ownerOnly("file pattern " + pat.Pattern)
+		}
+	}
+
+	if appArmor != "" {
+		warnings = append(warnings, "native AppArmor rules are enforced by AppArmor only, not simulated")
+	}
+
+	return warnings
+}
+
+// SimulateSecurityPolicy evaluates a container policy against a recorded trace with the matcher of the live alerts,
+// as if it had been applied to the pods of the events which selects accepts. The policy is evaluated in the Audit
+// mode, so the matches tell the action which would have applied (e.g., Block) whatever the recorded result.
+func (fd *Feeder) SimulateSecurityPolicy(secPolicy tp.SecurityPolicy, selects func(log tp.Log) bool, trace LogTrace) (SimulationResult, error) {
+	secPolicy.Metadata = simulatedMetadata(secPolicy.Metadata)
+	secPolicy.Spec.Mode = tp.PolicyModeAudit
+
+	ps := fd.newPolicySimulation()
+
+	// the schedule is evaluated at the time of each event, the rules compiled once
+	ps.schedule = secPolicy.Spec.Schedule
+	secPolicy.Spec.Schedule = nil
+
+	endPoints := map[string]struct{}{}
+
+	idx := -1
+	err := trace(func(log tp.Log) bool {
+		idx++
+
+		if log.ContainerID == "" || !selects(log) {
+			return true
+		}
+
+		// the policy is compiled once for each pod in the trace
+		if _, ok := endPoints[log.NamespaceName+"_"+log.PodName]; !ok {
+			endPoints[log.NamespaceName+"_"+log.PodName] = struct{}{}
+			ps.fd.UpdateSecurityPolicies("ADDED", tp.EndPoint{
+				NamespaceName:    log.NamespaceName,
+				EndPointName:     log.PodName,
+				PolicyEnabled:    tp.KubeArmorPolicyEnabled,
+				SecurityPolicies: []tp.SecurityPolicy{secPolicy},
+			})
+		}
+
+		ps.replay(idx, log)
+
+		return true
+	})
+	if err != nil {
+		return SimulationResult{}, err
+	}
+
+	ps.result.Differences = simulationDifferences(func(enforcer string) []string {
+		return AnalyzePolicyCompatibility(enforcer, secPolicy.Spec)
+	})
+	ps.result.Warnings = simulationWarnings(secPolicy.Spec.Process, secPolicy.Spec.File, secPolicy.Spec.AppArmor)
+
+	return ps.result, nil
+}
+
+// SimulateHostSecurityPolicy evaluates a host policy against the host events of a recorded trace, as
+// SimulateSecurityPolicy does for the container policies
+func (fd *Feeder) SimulateHostSecurityPolicy(secPolicy tp.HostSecurityPolicy, trace LogTrace) (SimulationResult, error) {
+	secPolicy.Metadata = simulatedMetadata(secPolicy.Metadata)
+	secPolicy.Spec.Mode = tp.PolicyModeAudit
+
+	ps := fd.newPolicySimulation()
+	ps.fd.UpdateHostSecurityPolicies("ADDED", []tp.HostSecurityPolicy{secPolicy})
+
+	idx := -1
+	err := trace(func(log tp.Log) bool {
+		idx++
+
+		if log.ContainerID == "" {
+			ps.replay(idx, log)
+		}
+
+		return true
+	})
+	if err != nil {
+		return SimulationResult{}, err
+	}
+
+	ps.result.Differences = simulationDifferences(func(enforcer string) []string {
+		return AnalyzeHostPolicyCompatibility(enforcer, secPolicy.Spec)
+	})
+	ps.result.Warnings = simulationWarnings(secPolicy.Spec.Process, secPolicy.Spec.File, secPolicy.Spec.AppArmor)
+
+	return ps.result, nil
+}
@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"sort"
+	"sync"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+
+	"github.com/google/uuid"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ==================== //
+// == Policy Watcher == //
+// ==================== //
+
+// policy event actions
+const (
+	PolicyApplied = "applied"
+	PolicyUpdated = "updated"
+	PolicyRemoved = "removed"
+	PolicyFailed  = "failed"
+
+	// the enforcement of a host policy on this node started or stopped
+	PolicyEnforced   = "enforced"
+	PolicyUnenforced = "unenforced"
+
+	// the maturation period of a policy elapsed, its Block rules are enforced
+	PolicyMatured = "matured"
+
+	// a window of the schedule of a policy started or ended, its rules are enforced or left out
+	PolicyActivated   = "activated"
+	PolicyDeactivated = "deactivated"
+
+	// a node-local override of a policy was applied, or expired (or was removed from the override file)
+	PolicyOverridden = "overridden"
+	PolicyExpired    = "expired"
+)
+
+// PolicyEventStruct Structure
+type PolicyEventStruct struct {
+	Filter    string
+	Broadcast chan *pb.PolicyEvent
+}
+
+// PolicyEventStructs Map
+var PolicyEventStructs map[string]PolicyEventStruct
+
+// AppliedPolicies Map (kind/namespace/policy -> last event), replayed on subscribe
+var AppliedPolicies map[string]*pb.PolicyEvent
+
+// PolicyEventLock Lock
+var PolicyEventLock *sync.RWMutex
+
+// addPolicyEventStruct registers a client and returns a snapshot of the applied policies
+func (ls *LogService) addPolicyEventStruct(uid string, conn chan *pb.PolicyEvent, filter string) []*pb.PolicyEvent {
+	PolicyEventLock.Lock()
+	defer PolicyEventLock.Unlock()
+
+	policyEventStruct := PolicyEventStruct{}
+	policyEventStruct.Filter = filter
+	policyEventStruct.Broadcast = conn
+	PolicyEventStructs[uid] = policyEventStruct
+
+	keys := []string{}
+	for key := range AppliedPolicies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	snapshot := []*pb.PolicyEvent{}
+	for _, key := range keys {
+		snapshot = append(snapshot, AppliedPolicies[key])
+	}
+
+	kg.Printf("Added a new client (%s, %s) for WatchPolicies", uid, filter)
+
+	return snapshot
+}
+
+// removePolicyEventStruct Function
+func (ls *LogService) removePolicyEventStruct(uid string) {
+	PolicyEventLock.Lock()
+	defer PolicyEventLock.Unlock()
+
+	delete(PolicyEventStructs, uid)
+
+	kg.Printf("Deleted the client (%s) for WatchPolicies", uid)
+}
+
+// WatchPolicies Function
+func (ls *LogService) WatchPolicies(req *pb.RequestMessage, svr pb.LogService_WatchPoliciesServer) error {
+	// only all the policy events can be watched
+	if req.Filter != "all" {
+		return status.Errorf(codes.InvalidArgument, "unsupported filter (%s), expected all", req.Filter)
+	}
+
+	uid := uuid.Must(uuid.NewRandom()).String()
+
+	conn := make(chan *pb.PolicyEvent, QueueSize)
+	defer close(conn)
+	snapshot := ls.addPolicyEventStruct(uid, conn, req.Filter)
+	defer ls.removePolicyEventStruct(uid)
+
+	// replay the current state first
+	for _, event := range snapshot {
+		if err := svr.Send(event); err != nil {
+			kg.Warnf("Failed to send a policy event=[%+v] err=[%s]", event, err.Error())
+			return err
+		}
+	}
+
+	for Running {
+		select {
+		case <-svr.Context().Done():
+			return nil
+		case resp := <-conn:
+			if status, ok := status.FromError(svr.Send(resp)); ok {
+				switch status.Code() {
+				case codes.OK:
+					// noop
+				case codes.Unavailable, codes.Canceled, codes.DeadlineExceeded:
+					kg.Warnf("Failed to send a policy event=[%+v] err=[%s]", resp, status.Err().Error())
+					return status.Err()
+				default:
+					return nil
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// PushPolicyEvent Function
+func (fd *Feeder) PushPolicyEvent(kind, namespace, policyName, action, reason string, endpoints []string) {
+	fd.PushPolicyEventWithCompatibility(kind, namespace, policyName, action, reason, endpoints, nil, nil)
+}
+
+// PushPolicyEventWithCompatibility pushes a policy event with the differences of the enforcement on this node,
+// and the advisory warnings of the policy (e.g., matchPaths found in no container)
+func (fd *Feeder) PushPolicyEventWithCompatibility(kind, namespace, policyName, action, reason string, endpoints, incompatibilities, warnings []string) {
+	event := fd.newPolicyEvent(kind, namespace, policyName, action, reason, endpoints)
+
+	event.Incompatibilities = incompatibilities
+	event.Warnings = warnings
+
+	if action == PolicyApplied || action == PolicyUpdated {
+		event.LastApplyDuration = fd.PolicyMetrics.GetLastApplyDuration(namespace, policyName).Microseconds()
+	}
+
+	fd.broadcastPolicyEvent(event)
+}
+
+// newPolicyEvent returns a policy event of this node
+func (fd *Feeder) newPolicyEvent(kind, namespace, policyName, action, reason string, endpoints []string) *pb.PolicyEvent {
+	event := &pb.PolicyEvent{}
+
+	timestamp, updatedTime := kl.GetDateTimeNow()
+
+	event.Timestamp = timestamp
+	event.UpdatedTime = updatedTime
+
+	event.ClusterName = fd.Node.ClusterName
+	event.HostName = fd.Node.NodeName
+
+	event.Kind = kind
+	event.NamespaceName = namespace
+	event.PolicyName = policyName
+
+	event.Action = action
+	event.Reason = reason
+	event.Endpoints = endpoints
+
+	event.Enforcer = fd.Enforcer
+
+	return event
+}
+
+// broadcastPolicyEvent sends a policy event to the clients, and keeps track of the applied policies
+func (fd *Feeder) broadcastPolicyEvent(event *pb.PolicyEvent) {
+	key := event.Kind + "/" + event.NamespaceName + "/" + event.PolicyName
+
+	PolicyEventLock.Lock()
+	defer PolicyEventLock.Unlock()
+
+	// keep track of the applied policies for new clients
+	switch event.Action {
+	case PolicyApplied, PolicyUpdated:
+		AppliedPolicies[key] = event
+	case PolicyRemoved:
+		delete(AppliedPolicies, key)
+	}
+
+	for uid := range PolicyEventStructs {
+		select {
+		case PolicyEventStructs[uid].Broadcast <- event:
+		default:
+			kg.Printf("policy event channel busy, event dropped.")
+		}
+	}
+}
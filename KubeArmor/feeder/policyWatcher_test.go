@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakePolicyStream is a fake client of WatchPolicies
+type fakePolicyStream struct {
+	grpc.ServerStream
+
+	ctx    context.Context
+	events chan *pb.PolicyEvent
+}
+
+func (s *fakePolicyStream) Send(event *pb.PolicyEvent) error {
+	s.events <- event
+	return nil
+}
+
+func (s *fakePolicyStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakePolicyStream) next(t *testing.T) *pb.PolicyEvent {
+	select {
+	case event := <-s.events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("[FAIL] Timed out waiting for a policy event")
+	}
+	return nil
+}
+
+func subscribePolicies(ctx context.Context, ls *LogService) *fakePolicyStream {
+	stream := &fakePolicyStream{ctx: ctx, events: make(chan *pb.PolicyEvent, 16)}
+	go func() {
+		_ = ls.WatchPolicies(&pb.RequestMessage{Filter: "all"}, stream)
+	}()
+	return stream
+}
+
+func waitForPolicyClients(t *testing.T, count int) {
+	for i := 0; i < 100; i++ {
+		PolicyEventLock.RLock()
+		n := len(PolicyEventStructs)
+		PolicyEventLock.RUnlock()
+		if n == count {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("[FAIL] Expected %d policy watchers", count)
+}
+
+func TestWatchPolicies(t *testing.T) {
+	PolicyEventStructs = make(map[string]PolicyEventStruct)
+	AppliedPolicies = make(map[string]*pb.PolicyEvent)
+	PolicyEventLock = &sync.RWMutex{}
+
+	// the gRPC service might have been stopped by other tests
+	Running = true
+
+	feeder := &Feeder{Node: &tp.Node{ClusterName: "default", NodeName: "node"}}
+	ls := &LogService{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// state before any subscription
+	feeder.PushPolicyEvent("KubeArmorPolicy", "default", "block-shell", PolicyApplied, "", []string{"nginx"})
+	feeder.PushPolicyEvent("KubeArmorPolicy", "default", "audit-etc", PolicyApplied, "", []string{"nginx"})
+	feeder.PushPolicyEvent("KubeArmorPolicy", "default", "audit-etc", PolicyRemoved, "", []string{"nginx"})
+	feeder.PushPolicyEvent("KubeArmorHostPolicy", "", "broken", PolicyFailed, "Invalid", []string{"node"})
+
+	first := subscribePolicies(ctx, ls)
+
+	// snapshot of the applied policies only
+	if event := first.next(t); event.PolicyName != "block-shell" || event.Action != PolicyApplied || event.HostName != "node" {
+		t.Fatalf("[FAIL] Unexpected snapshot event (%+v)", event)
+	}
+
+	waitForPolicyClients(t, 1)
+	second := subscribePolicies(ctx, ls)
+	if event := second.next(t); event.PolicyName != "block-shell" {
+		t.Fatalf("[FAIL] Unexpected snapshot event (%+v)", event)
+	}
+	waitForPolicyClients(t, 2)
+
+	// live events are delivered to all subscribers
+	feeder.PushPolicyEvent("KubeArmorPolicy", "default", "block-shell", PolicyUpdated, "", []string{"nginx", "redis"})
+	feeder.PushPolicyEvent("KubeArmorPolicy", "default", "bad", PolicyFailed, "Failure", nil)
+
+	for _, stream := range []*fakePolicyStream{first, second} {
+		if event := stream.next(t); event.Action != PolicyUpdated || len(event.Endpoints) != 2 {
+			t.Errorf("[FAIL] Unexpected update event (%+v)", event)
+		}
+		if event := stream.next(t); event.Action != PolicyFailed || event.Reason != "Failure" {
+			t.Errorf("[FAIL] Unexpected failure event (%+v)", event)
+		}
+	}
+
+	// removed policies are no longer replayed
+	feeder.PushPolicyEvent("KubeArmorPolicy", "default", "block-shell", PolicyRemoved, "", []string{"nginx", "redis"})
+
+	PolicyEventLock.RLock()
+	if len(AppliedPolicies) != 0 {
+		t.Errorf("[FAIL] Expected no applied policies, got %d", len(AppliedPolicies))
+	}
+	PolicyEventLock.RUnlock()
+
+	// the other filters are rejected
+	stream := &fakePolicyStream{ctx: ctx, events: make(chan *pb.PolicyEvent, 16)}
+	if err := ls.WatchPolicies(&pb.RequestMessage{Filter: "policy"}, stream); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("[FAIL] Expected an invalid argument error for an unsupported filter, got %v", err)
+	}
+
+	t.Log("[PASS] Watched policy events")
+}
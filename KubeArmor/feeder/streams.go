@@ -0,0 +1,340 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/peer"
+)
+
+// ============= //
+// == Streams == //
+// ============= //
+
+// drop policies of the queues of the clients
+const (
+	DropOldest = "oldest" // the oldest queued item is dropped to make room (the client gets the latest items)
+	DropNewest = "newest" // the new item is dropped
+)
+
+// names of the streams in the metrics
+const (
+	StreamAlerts = "alerts"
+	StreamLogs   = "logs"
+)
+
+// dispatchPeer is the peer label of the logs dropped before being dispatched to the clients
+const dispatchPeer = "dispatch"
+
+// getDropPolicy returns a valid drop policy
+func getDropPolicy(dropPolicy string) string {
+	if dropPolicy == DropOldest {
+		return DropOldest
+	}
+	return DropNewest
+}
+
+// getQueueSize returns the size of the queue of a client
+func getQueueSize(queueSize int) int {
+	if queueSize <= 0 {
+		return QueueSize
+	}
+	return queueSize
+}
+
+// getPeer returns the address of the client of a stream
+func getPeer(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil && p.Addr.String() != "" {
+		return p.Addr.String()
+	}
+	return "unknown"
+}
+
+// countDrop Function
+func countDrop(dropped *atomic.Uint64) {
+	if dropped != nil {
+		dropped.Add(1)
+	}
+}
+
+// enqueueAlert puts an alert into the queue of a client, and drops an alert once the queue is full
+func enqueueAlert(queue chan *pb.Alert, alert *pb.Alert, dropPolicy string, dropped *atomic.Uint64) {
+	select {
+	case queue <- alert:
+		return
+	default:
+	}
+
+	countDrop(dropped)
+
+	if dropPolicy != DropOldest {
+		return
+	}
+
+	select {
+	case <-queue:
+	default:
+	}
+
+	select {
+	case queue <- alert:
+	default: // filled up by another worker in the meantime
+	}
+}
+
+// enqueueLog puts a log into the queue of a client, and drops a log once the queue is full
+func enqueueLog(queue chan *pb.Log, log *pb.Log, dropPolicy string, dropped *atomic.Uint64) {
+	select {
+	case queue <- log:
+		return
+	default:
+	}
+
+	countDrop(dropped)
+
+	if dropPolicy != DropOldest {
+		return
+	}
+
+	select {
+	case <-queue:
+	default:
+	}
+
+	select {
+	case queue <- log:
+	default: // filled up in the meantime
+	}
+}
+
+// ======================= //
+// == Stream Dispatcher == //
+// ======================= //
+
+// StreamDispatcher delivers the alerts and the logs to the clients of WatchAlerts and WatchLogs off the path of the events,
+// the alerts with their own pool of workers (each client is served by one of them, in order), and the logs with a single worker,
+// so that a flood of logs or slow log clients never hold the alerts back
+type StreamDispatcher struct {
+	alerts []chan *pb.Alert
+	logs   chan *pb.Log
+
+	// round-robin assignment of the alert clients to the workers
+	nextWorker atomic.Uint64
+
+	// logs dropped as the log worker is behind
+	logsDropped atomic.Uint64
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewStreamDispatcher Function
+func NewStreamDispatcher(alertWorkers, queueSize int) *StreamDispatcher {
+	if alertWorkers <= 0 {
+		alertWorkers = 1
+	}
+
+	sd := &StreamDispatcher{}
+
+	for i := 0; i < alertWorkers; i++ {
+		sd.alerts = append(sd.alerts, make(chan *pb.Alert, getQueueSize(queueSize)))
+	}
+	sd.logs = make(chan *pb.Log, getQueueSize(queueSize))
+
+	sd.stop = make(chan struct{})
+
+	return sd
+}
+
+// assignAlertWorker returns the worker serving a new alert client
+func (sd *StreamDispatcher) assignAlertWorker() int {
+	if sd == nil {
+		return 0
+	}
+	return int((sd.nextWorker.Add(1) - 1) % uint64(len(sd.alerts)))
+}
+
+// Start starts the workers
+func (sd *StreamDispatcher) Start() {
+	for worker, queue := range sd.alerts {
+		sd.wg.Add(1)
+		go func(worker int, queue chan *pb.Alert) {
+			defer sd.wg.Done()
+			for {
+				select {
+				case <-sd.stop:
+					return
+				case alert := <-queue:
+					broadcastAlert(alert, worker)
+				}
+			}
+		}(worker, queue)
+	}
+
+	sd.wg.Add(1)
+	go func() {
+		defer sd.wg.Done()
+		for {
+			select {
+			case <-sd.stop:
+				return
+			case log := <-sd.logs:
+				broadcastLog(log)
+			}
+		}
+	}()
+}
+
+// Close stops the workers
+func (sd *StreamDispatcher) Close() {
+	close(sd.stop)
+	sd.wg.Wait()
+}
+
+// dispatchAlert delivers an alert to the clients (an alert waits for a worker rather than being dropped)
+func (fd *Feeder) dispatchAlert(alert *pb.Alert) {
+	sd := fd.Streams
+	if sd == nil {
+		broadcastAlert(alert, -1)
+		return
+	}
+
+	for _, queue := range sd.alerts {
+		select {
+		case queue <- alert:
+		case <-sd.stop:
+			return
+		}
+	}
+}
+
+// dispatchLog delivers a log to the clients (a log is dropped if the worker is behind)
+func (fd *Feeder) dispatchLog(log *pb.Log) {
+	sd := fd.Streams
+	if sd == nil {
+		broadcastLog(log)
+		return
+	}
+
+	select {
+	case sd.logs <- log:
+	default:
+		sd.logsDropped.Add(1)
+	}
+}
+
+// broadcastAlert puts an alert into the queues of the clients served by a worker (all the clients if -1)
+func broadcastAlert(alert *pb.Alert, worker int) {
+	AlertLock.RLock()
+	defer AlertLock.RUnlock()
+
+	for _, alertStruct := range AlertStructs {
+		if worker >= 0 && alertStruct.Worker != worker {
+			continue
+		}
+		if !matchesEnforcer(alert.Enforcer, alertStruct.Enforcers) {
+			continue
+		}
+		enqueueAlert(alertStruct.Broadcast, alert, alertStruct.DropPolicy, alertStruct.Dropped)
+	}
+}
+
+// broadcastLog puts a log into the queues of the clients
+func broadcastLog(log *pb.Log) {
+	LogLock.RLock()
+	defer LogLock.RUnlock()
+
+	for _, logStruct := range LogStructs {
+		if !matchesEnforcer(log.Enforcer, logStruct.Enforcers) {
+			continue
+		}
+		enqueueLog(logStruct.Broadcast, log, logStruct.DropPolicy, logStruct.Dropped)
+	}
+}
+
+// ==================== //
+// == Stream Metrics == //
+// ==================== //
+
+// streamCollector reports the depth of the queues of the clients and their drops, per peer
+type streamCollector struct {
+	dispatcher *StreamDispatcher
+
+	queueDepth *prometheus.Desc
+	dropped    *prometheus.Desc
+}
+
+// newStreamCollector Function
+func newStreamCollector(dispatcher *StreamDispatcher) *streamCollector {
+	return &streamCollector{
+		dispatcher: dispatcher,
+		queueDepth: prometheus.NewDesc("kubearmor_stream_queue_depth", "Number of items queued for the clients of a stream", []string{"stream", "peer"}, nil),
+		dropped:    prometheus.NewDesc("kubearmor_stream_dropped_total", "Number of items dropped for the clients of a stream", []string{"stream", "peer"}, nil),
+	}
+}
+
+// Describe Function
+func (sc *streamCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sc.queueDepth
+	ch <- sc.dropped
+}
+
+// Collect Function
+func (sc *streamCollector) Collect(ch chan<- prometheus.Metric) {
+	// stream -> peer -> depth / drops (a peer may have several clients of a stream)
+	depths := map[string]map[string]float64{StreamAlerts: {}, StreamLogs: {}}
+	drops := map[string]map[string]float64{StreamAlerts: {}, StreamLogs: {}}
+
+	if AlertLock != nil {
+		AlertLock.RLock()
+		for _, alertStruct := range AlertStructs {
+			depths[StreamAlerts][alertStruct.Peer] += float64(len(alertStruct.Broadcast))
+			if alertStruct.Dropped != nil {
+				drops[StreamAlerts][alertStruct.Peer] += float64(alertStruct.Dropped.Load())
+			}
+		}
+		AlertLock.RUnlock()
+	}
+
+	if LogLock != nil {
+		LogLock.RLock()
+		for _, logStruct := range LogStructs {
+			depths[StreamLogs][logStruct.Peer] += float64(len(logStruct.Broadcast))
+			if logStruct.Dropped != nil {
+				drops[StreamLogs][logStruct.Peer] += float64(logStruct.Dropped.Load())
+			}
+		}
+		LogLock.RUnlock()
+	}
+
+	if sc.dispatcher != nil {
+		drops[StreamLogs][dispatchPeer] += float64(sc.dispatcher.logsDropped.Load())
+	}
+
+	for stream, peers := range depths {
+		for peer, depth := range peers {
+			ch <- prometheus.MustNewConstMetric(sc.queueDepth, prometheus.GaugeValue, depth, stream, peer)
+		}
+	}
+	for stream, peers := range drops {
+		for peer, dropped := range peers {
+			ch <- prometheus.MustNewConstMetric(sc.dropped, prometheus.CounterValue, dropped, stream, peer)
+		}
+	}
+}
+
+// newClientQueue returns the queue size and the drop policy of the clients of a stream
+func newClientQueue(stream string) (int, string) {
+	if stream == StreamAlerts {
+		return getQueueSize(cfg.GlobalCfg.AlertQueueSize), getDropPolicy(cfg.GlobalCfg.AlertDropPolicy)
+	}
+	return getQueueSize(cfg.GlobalCfg.LogQueueSize), getDropPolicy(cfg.GlobalCfg.LogDropPolicy)
+}
@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// slowLogStream is a client of WatchLogs which takes a while for each log
+type slowLogStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *slowLogStream) Send(log *pb.Log) error {
+	time.Sleep(10 * time.Millisecond)
+	return nil
+}
+
+func (s *slowLogStream) Context() context.Context {
+	return s.ctx
+}
+
+// alertStream is a client of WatchAlerts which records the latency of each alert
+type alertStream struct {
+	grpc.ServerStream
+
+	ctx       context.Context
+	latencies chan time.Duration
+}
+
+func (s *alertStream) Send(alert *pb.Alert) error {
+	s.latencies <- time.Since(time.Unix(0, alert.Timestamp))
+	return nil
+}
+
+func (s *alertStream) Context() context.Context {
+	return s.ctx
+}
+
+// peerContext returns the context of a stream from a client
+func peerContext(ctx context.Context, port int) context.Context {
+	return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: port}})
+}
+
+// gatherStreamDrops returns the drops of a stream per peer
+func gatherStreamDrops(t *testing.T, registry *prometheus.Registry, stream string) map[string]float64 {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to gather the metrics (%s)", err.Error())
+	}
+
+	drops := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "kubearmor_stream_dropped_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["stream"] == stream {
+				drops[labels["peer"]] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return drops
+}
+
+func TestStreamIsolation(t *testing.T) {
+	prevLogQueue, prevLogPolicy := cfg.GlobalCfg.LogQueueSize, cfg.GlobalCfg.LogDropPolicy
+	defer func() { cfg.GlobalCfg.LogQueueSize, cfg.GlobalCfg.LogDropPolicy = prevLogQueue, prevLogPolicy }()
+	cfg.GlobalCfg.LogQueueSize = 10
+	cfg.GlobalCfg.LogDropPolicy = DropNewest
+
+	AlertStructs = map[string]AlertStruct{}
+	AlertLock = new(sync.RWMutex)
+	LogStructs = map[string]LogStruct{}
+	LogLock = new(sync.RWMutex)
+
+	// the gRPC service might have been stopped by other tests
+	Running = true
+
+	feeder := &Feeder{Streams: NewStreamDispatcher(2, QueueSize)}
+	feeder.Streams.Start()
+	defer feeder.Streams.Close()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newStreamCollector(feeder.Streams))
+
+	ls := &LogService{Streams: feeder.Streams}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := sync.WaitGroup{}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// a slow client of the logs, and a client of the alerts
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_ = ls.WatchLogs(&pb.RequestMessage{Filter: "all"}, &slowLogStream{ctx: peerContext(ctx, 40001)})
+	}()
+
+	alerts := &alertStream{ctx: peerContext(ctx, 40002), latencies: make(chan time.Duration, 100)}
+	go func() {
+		defer wg.Done()
+		_ = ls.WatchAlerts(&pb.RequestMessage{Filter: "all"}, alerts)
+	}()
+
+	for i := 0; i < 100; i++ {
+		AlertLock.RLock()
+		LogLock.RLock()
+		ready := len(AlertStructs) == 1 && len(LogStructs) == 1
+		LogLock.RUnlock()
+		AlertLock.RUnlock()
+		if ready {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// a flood of logs with some alerts in between
+	maxLatency := time.Duration(0)
+	for i := 0; i < 50; i++ {
+		for j := 0; j < 200; j++ {
+			feeder.dispatchLog(&pb.Log{Timestamp: time.Now().UnixNano()})
+		}
+		feeder.dispatchAlert(&pb.Alert{Timestamp: time.Now().UnixNano()})
+
+		select {
+		case latency := <-alerts.latencies:
+			if latency > maxLatency {
+				maxLatency = latency
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("[FAIL] Timed out waiting for an alert (%d)", i)
+		}
+	}
+
+	if maxLatency > 100*time.Millisecond {
+		t.Errorf("[FAIL] Expected the alerts not to be held back by the slow client of the logs (%v)", maxLatency)
+	}
+
+	// the drops are reported for the slow client only
+	if drops := gatherStreamDrops(t, registry, StreamLogs); drops["10.0.0.1:40001"] == 0 {
+		t.Errorf("[FAIL] Expected the drops of the slow client of the logs (%v)", drops)
+	}
+	if drops := gatherStreamDrops(t, registry, StreamAlerts); drops["10.0.0.1:40002"] != 0 {
+		t.Errorf("[FAIL] Unexpected drops of the alerts (%v)", drops)
+	}
+
+	t.Logf("[PASS] Delivered the alerts apart from a slow client of the logs (max latency: %v)", maxLatency)
+}
+
+func TestStreamDropPolicy(t *testing.T) {
+	queue := make(chan *pb.Alert, 2)
+	dropped := new(atomic.Uint64)
+
+	for i := int64(1); i <= 4; i++ {
+		enqueueAlert(queue, &pb.Alert{Timestamp: i}, DropOldest, dropped)
+	}
+
+	if first, second := <-queue, <-queue; first.Timestamp != 3 || second.Timestamp != 4 || dropped.Load() != 2 {
+		t.Errorf("[FAIL] Expected the oldest alerts to be dropped (%d, %d, %d)", first.Timestamp, second.Timestamp, dropped.Load())
+	}
+
+	for i := int64(1); i <= 4; i++ {
+		enqueueAlert(queue, &pb.Alert{Timestamp: i}, DropNewest, dropped)
+	}
+
+	if first, second := <-queue, <-queue; first.Timestamp != 1 || second.Timestamp != 2 || dropped.Load() != 4 {
+		t.Errorf("[FAIL] Expected the newest alerts to be dropped (%d, %d, %d)", first.Timestamp, second.Timestamp, dropped.Load())
+	}
+
+	t.Log("[PASS] Dropped the alerts of a full queue by the drop policy")
+}
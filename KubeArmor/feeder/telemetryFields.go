@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ============================ //
+// == Telemetry Field Policy == //
+// ============================ //
+
+// telemetrySaltSize is the size of the salt of the hashed fields
+const telemetrySaltSize = 32
+
+// TelemetryHashPrefix is the prefix of the hashed fields
+const TelemetryHashPrefix = "sha256:"
+
+// TelemetryFieldPolicy Structure drops or hashes the fields of the alerts and the logs before they're emitted
+type TelemetryFieldPolicy struct {
+	// field -> drop|hash
+	fields map[string]string
+
+	// salt of the node, so that the hashes can only be correlated within the node
+	salt []byte
+}
+
+// NewTelemetryFieldPolicy Function
+func NewTelemetryFieldPolicy(fields map[string]string, salt []byte) *TelemetryFieldPolicy {
+	return &TelemetryFieldPolicy{fields: fields, salt: salt}
+}
+
+// loadTelemetrySalt reads the salt of the node, or generates it on the first start
+func loadTelemetrySalt(path string) ([]byte, error) {
+	if salt, err := os.ReadFile(filepath.Clean(path)); err == nil && len(salt) == telemetrySaltSize {
+		return salt, nil
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	salt := make([]byte, telemetrySaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return salt, err
+	}
+	if err := os.WriteFile(filepath.Clean(path), salt, 0600); err != nil {
+		return salt, err
+	}
+
+	return salt, nil
+}
+
+// newTelemetryFieldPolicy creates the field policy of the configuration (nil if no fields are configured)
+func newTelemetryFieldPolicy(fields map[string]string) *TelemetryFieldPolicy {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var salt []byte
+	for _, action := range fields {
+		if action != cfg.TelemetryFieldHash {
+			continue
+		}
+
+		s, err := loadTelemetrySalt(cfg.TelemetrySaltPath)
+		if err != nil {
+			// the hashes are still consistent until the restart
+			kg.Warnf("Failed to keep the salt of the hashed telemetry fields in %s (%s)", cfg.TelemetrySaltPath, err.Error())
+		}
+		salt = s
+		break
+	}
+
+	kg.Printf("Dropping or hashing the telemetry fields %v", fields)
+
+	return NewTelemetryFieldPolicy(fields, salt)
+}
+
+// Hash Function returns the salted hash of a value
+func (tf *TelemetryFieldPolicy) Hash(value string) string {
+	h := sha256.New()
+	h.Write(tf.salt)
+	h.Write([]byte(value))
+	return TelemetryHashPrefix + hex.EncodeToString(h.Sum(nil))
+}
+
+// apply returns the value of a field after its action (an empty value is kept as it is)
+func (tf *TelemetryFieldPolicy) apply(action, value string) string {
+	if value == "" {
+		return value
+	}
+
+	switch action {
+	case cfg.TelemetryFieldDrop:
+		return ""
+	case cfg.TelemetryFieldHash:
+		return tf.Hash(value)
+	}
+
+	return value
+}
+
+// field returns the value of a field after the action configured for it
+func (tf *TelemetryFieldPolicy) field(name, value string) string {
+	return tf.apply(tf.fields[name], value)
+}
+
+// Apply Function drops or hashes the fields of an alert or a log
+func (tf *TelemetryFieldPolicy) Apply(log *tp.Log) {
+	if tf == nil {
+		return
+	}
+
+	// the owner is shared with the other events of the pod, so it's copied before being changed
+	if log.Owner != nil {
+		ownerAction := func(name string) string {
+			if action, ok := tf.fields[name]; ok {
+				return action
+			}
+			return tf.fields["owner"]
+		}
+
+		owner := *log.Owner
+		owner.Ref = tf.apply(ownerAction("owner.ref"), owner.Ref)
+		owner.Name = tf.apply(ownerAction("owner.name"), owner.Name)
+		owner.Namespace = tf.apply(ownerAction("owner.namespace"), owner.Namespace)
+
+		if owner.Ref == "" && owner.Name == "" && owner.Namespace == "" {
+			log.Owner = nil
+		} else {
+			log.Owner = &owner
+		}
+	}
+
+	log.PodName = tf.field("podName", log.PodName)
+	log.Labels = tf.field("labels", log.Labels)
+	log.ContainerName = tf.field("containerName", log.ContainerName)
+	log.ContainerImage = tf.field("containerImage", log.ContainerImage)
+	log.ParentProcessName = tf.field("parentProcessName", log.ParentProcessName)
+	log.ProcessName = tf.field("processName", log.ProcessName)
+	log.Source = tf.field("source", log.Source)
+
+	// the resource of the process events is the executable followed by its arguments
+	if action, ok := tf.fields["arguments"]; ok && log.Operation == "Process" {
+		if path, args, found := strings.Cut(log.Resource, " "); found {
+			if args = tf.apply(action, args); args != "" {
+				log.Resource = path + " " + args
+			} else {
+				log.Resource = path
+			}
+		}
+	}
+
+	log.Resource = tf.field("resource", log.Resource)
+	log.Cwd = tf.field("cwd", log.Cwd)
+	log.Data = tf.field("data", log.Data)
+}
+
+// telemetryFields returns the field policy of the feeder, created on first use for the feeders not created by NewFeeder
+func (fd *Feeder) telemetryFields() *TelemetryFieldPolicy {
+	fd.telemetryFieldsOnce.Do(func() {
+		if fd.TelemetryFields == nil {
+			fd.TelemetryFields = newTelemetryFieldPolicy(cfg.GlobalCfg.TelemetryFieldPolicy)
+		}
+	})
+	return fd.TelemetryFields
+}
@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+// recordingSink keeps the alerts it receives
+type recordingSink struct {
+	alerts chan *pb.Alert
+}
+
+func (rs *recordingSink) Name() string { return "recording" }
+
+func (rs *recordingSink) SendAlert(alert *pb.Alert) { rs.alerts <- alert }
+
+func (rs *recordingSink) Close() error { return nil }
+
+func TestLoadTelemetrySalt(t *testing.T) {
+	path := t.TempDir() + "/state/telemetry.salt"
+
+	salt, err := loadTelemetrySalt(path)
+	if err != nil || len(salt) != telemetrySaltSize {
+		t.Fatalf("[FAIL] Failed to generate the salt (%v)", err)
+	}
+
+	// the salt is kept across the restarts, so the hashes stay the same on the node
+	again, err := loadTelemetrySalt(path)
+	if err != nil || !bytes.Equal(salt, again) {
+		t.Errorf("[FAIL] Expected the same salt after a restart (%v)", err)
+	}
+
+	if NewTelemetryFieldPolicy(nil, salt).Hash("app=web") != NewTelemetryFieldPolicy(nil, again).Hash("app=web") {
+		t.Errorf("[FAIL] Expected the same hashes after a restart")
+	}
+
+	// the salts of the nodes differ
+	other, err := loadTelemetrySalt(t.TempDir() + "/telemetry.salt")
+	if err != nil || NewTelemetryFieldPolicy(nil, salt).Hash("app=web") == NewTelemetryFieldPolicy(nil, other).Hash("app=web") {
+		t.Errorf("[FAIL] Expected the hashes of another node to differ (%v)", err)
+	}
+
+	t.Log("[PASS] Kept the salt of the node")
+}
+
+func TestTelemetryFieldPolicy(t *testing.T) {
+	logFile, err := os.CreateTemp(t.TempDir(), "kubearmor-*.log")
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create the log file (%s)", err.Error())
+	}
+	defer logFile.Close()
+
+	feeder := &Feeder{Node: &tp.Node{ClusterName: "default", NodeName: "node-1"}, Output: logFile.Name()}
+	feeder.SecurityPolicies = map[string]tp.MatchPolicies{}
+	feeder.SecurityPoliciesLock = new(sync.RWMutex)
+	feeder.DefaultPostures = map[string]tp.DefaultPosture{}
+	feeder.EndPointPostures = map[string]tp.DefaultPosture{}
+	feeder.DefaultPosturesLock = new(sync.Mutex)
+	feeder.SeverityRangesLock = new(sync.RWMutex)
+	feeder.SinksLock = new(sync.RWMutex)
+	feeder.SinkDrainTimeout = time.Second
+	feeder.SinkQueueSize = 16
+	feeder.EnforcementFailures = map[string]uint64{}
+	feeder.EnforcementFailuresLock = new(sync.RWMutex)
+	feeder.Enforcer = "AppArmor"
+
+	// the logs are written into the log file from the queue of a sink
+	feeder.AddLogFileSink(logFile, nil)
+
+	fields, err := cfg.ParseTelemetryFieldPolicy("arguments=drop,labels=hash,owner.name=hash,owner.namespace=drop,podName=drop,cwd=hash")
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to parse the field policy (%s)", err.Error())
+	}
+	feeder.TelemetryFields = NewTelemetryFieldPolicy(fields, []byte("node-salt"))
+	hash := feeder.TelemetryFields.Hash
+
+	// subscribe to the alerts and the logs
+	alerts := make(chan *pb.Alert, 16)
+	AlertLock = new(sync.RWMutex)
+	AlertStructs = map[string]AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+	defer func() { AlertStructs = map[string]AlertStruct{} }()
+
+	logs := make(chan *pb.Log, 16)
+	LogLock = new(sync.RWMutex)
+	LogStructs = map[string]LogStruct{"test": {Filter: "all", Broadcast: logs}}
+	defer func() { LogStructs = map[string]LogStruct{} }()
+
+	sink := &recordingSink{alerts: make(chan *pb.Alert, 16)}
+	feeder.AddSink(sink)
+
+	owner := &tp.PodOwner{Ref: "Deployment", Name: "frontend", Namespace: "web"}
+
+	exec := tp.Log{
+		Timestamp:         time.Now().Unix(),
+		UpdatedTime:       time.Now().UTC().Format(time.RFC3339Nano),
+		NamespaceName:     "web",
+		Owner:             owner,
+		PodName:           "frontend-5d8f7",
+		Labels:            "app=frontend",
+		ContainerID:       "frontend",
+		ContainerName:     "nginx",
+		ParentProcessName: "/bin/bash",
+		ProcessName:       "/usr/bin/mysql",
+		Operation:         "Process",
+		Source:            "/bin/bash",
+		Resource:          "/usr/bin/mysql -u admin -psecret",
+		Cwd:               "/home/admin",
+		Result:            "Passed",
+		Type:              "MatchedPolicy",
+		PolicyName:        "audit-mysql",
+		Severity:          "3",
+		Action:            "Audit",
+	}
+
+	visible := exec
+	visible.Type = "ContainerLog"
+	visible.PolicyName, visible.Severity, visible.Action = "", "", ""
+
+	feeder.pushMatchedLog(exec)
+	feeder.pushMatchedLog(visible)
+
+	// the fields of the events themselves are left unchanged
+	if owner.Name != "frontend" || owner.Namespace != "web" {
+		t.Errorf("[FAIL] Expected the owner of the pod not to be modified (%+v)", owner)
+	}
+
+	// the records of the log file
+	// the queued logs are written once the sinks are closed
+	feeder.closeSinks()
+
+	logFile, err = os.Open(logFile.Name())
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to read the log file (%s)", err.Error())
+	}
+	defer logFile.Close()
+
+	records := []tp.Log{}
+	scanner := bufio.NewScanner(logFile)
+	for scanner.Scan() {
+		if strings.Contains(scanner.Text(), "secret") || strings.Contains(scanner.Text(), "frontend-5d8f7") {
+			t.Errorf("[FAIL] Expected the dropped fields not to be written (%s)", scanner.Text())
+		}
+
+		record := tp.Log{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("[FAIL] Failed to unmarshal the record (%s)", err.Error())
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("[FAIL] Expected 2 records (%d)", len(records))
+	}
+	for _, record := range records {
+		if record.Resource != "/usr/bin/mysql" || record.PodName != "" || record.Labels != hash("app=frontend") || record.Cwd != hash("/home/admin") ||
+			record.Owner == nil || record.Owner.Ref != "Deployment" || record.Owner.Name != hash("frontend") || record.Owner.Namespace != "" {
+			t.Errorf("[FAIL] Unexpected fields of the record (%+v, %+v)", record, record.Owner)
+		}
+		if record.ContainerName != "nginx" || record.NamespaceName != "web" {
+			t.Errorf("[FAIL] Expected the other fields to be kept (%+v)", record)
+		}
+	}
+
+	// the gRPC alert and the alert of the sink
+	select {
+	case sent := <-sink.alerts:
+		if alert := <-alerts; sent != alert {
+			t.Errorf("[FAIL] Expected the sinks and the clients to share the alert")
+		}
+		if sent.Resource != "/usr/bin/mysql" || sent.PodName != "" || sent.Labels != hash("app=frontend") || sent.Cwd != hash("/home/admin") ||
+			sent.Owner.GetName() != hash("frontend") || sent.Owner.GetNamespace() != "" {
+			t.Errorf("[FAIL] Unexpected fields of the alert (%+v)", sent)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("[FAIL] Expected the alert in the sink")
+	}
+
+	// the gRPC log
+	if log := <-logs; log.Resource != "/usr/bin/mysql" || log.PodName != "" || log.Labels != hash("app=frontend") || log.Owner.GetName() != hash("frontend") {
+		t.Errorf("[FAIL] Unexpected fields of the log (%+v)", log)
+	}
+
+	// dropping all the fields of the owner removes it
+	noOwner := NewTelemetryFieldPolicy(map[string]string{"owner": cfg.TelemetryFieldDrop}, nil)
+	log := tp.Log{Owner: owner}
+	if noOwner.Apply(&log); log.Owner != nil {
+		t.Errorf("[FAIL] Expected the owner to be dropped (%+v)", log.Owner)
+	}
+
+	// the policies are matched before, on the original fields
+	if records[0].PolicyName != "audit-mysql" || records[0].ProcessName != "/usr/bin/mysql" {
+		t.Errorf("[FAIL] Expected the policy to be matched (%+v)", records[0])
+	}
+
+	t.Log("[PASS] Dropped and hashed the telemetry fields in the log file, the gRPC streams, and the sinks")
+}
@@ -0,0 +1,275 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"bytes"
+	"context"
+	_ "embed" // embed the telemetry schemas
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ====================== //
+// == Telemetry Schema == //
+// ====================== //
+
+// TelemetrySchemaVersion is the current version of the schema of the alerts and the logs (major.minor)
+//
+// New optional fields bump the minor version. Breaking changes bump the major version, and the previous major
+// version stays in telemetrySchemas for a release so that it can still be emitted (telemetrySchemaVersion).
+const TelemetrySchemaVersion = "1.5"
+
+//go:embed schema/telemetry-v1.json
+var telemetrySchemaV1 []byte
+
+// TelemetrySchemaDoc Structure
+type TelemetrySchemaDoc struct {
+	Version string
+	Schema  []byte
+}
+
+// telemetrySchemas are the schemas which can be emitted, by major version
+var telemetrySchemas = map[string]TelemetrySchemaDoc{
+	"1": {Version: TelemetrySchemaVersion, Schema: telemetrySchemaV1},
+}
+
+// GetTelemetrySchema returns the schema of the given major version (the emitted one if empty)
+func GetTelemetrySchema(major string) (TelemetrySchemaDoc, error) {
+	if major == "" {
+		return emittedTelemetrySchema(), nil
+	}
+
+	// accept a full version as well (e.g., 1.0)
+	major = strings.SplitN(strings.TrimPrefix(major, "v"), ".", 2)[0]
+
+	if doc, ok := telemetrySchemas[major]; ok {
+		return doc, nil
+	}
+
+	return TelemetrySchemaDoc{}, fmt.Errorf("unsupported telemetry schema version %s (supported: %s)", major, strings.Join(supportedTelemetrySchemas(), ", "))
+}
+
+// supportedTelemetrySchemas Function
+func supportedTelemetrySchemas() []string {
+	majors := []string{}
+	for major := range telemetrySchemas {
+		majors = append(majors, major)
+	}
+	sort.Strings(majors)
+
+	return majors
+}
+
+// emittedTelemetrySchema returns the schema of the major version configured to be emitted
+func emittedTelemetrySchema() TelemetrySchemaDoc {
+	major := strings.SplitN(TelemetrySchemaVersion, ".", 2)[0]
+
+	if configured := cfg.GlobalCfg.TelemetrySchemaVersion; configured != "" && configured != major {
+		if doc, ok := telemetrySchemas[configured]; ok {
+			return doc
+		}
+
+		kg.Warnf("Unsupported telemetry schema version %s, emitting %s", configured, TelemetrySchemaVersion)
+	}
+
+	return telemetrySchemas[major]
+}
+
+// schemaVersion returns the version of the schema of the emitted records
+func (fd *Feeder) schemaVersion() string {
+	if fd.SchemaVersion == "" {
+		return TelemetrySchemaVersion
+	}
+
+	return fd.SchemaVersion
+}
+
+// GetTelemetrySchema Function
+func (ls *LogService) GetTelemetrySchema(ctx context.Context, req *pb.TelemetrySchemaRequest) (*pb.TelemetrySchema, error) {
+	doc, err := GetTelemetrySchema(req.Version)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return &pb.TelemetrySchema{Version: doc.Version, Schema: string(doc.Schema)}, nil
+}
+
+// ServeTelemetrySchema serves the schema (/schema/telemetry?version=1)
+func ServeTelemetrySchema(w http.ResponseWriter, r *http.Request) {
+	doc, err := GetTelemetrySchema(r.URL.Query().Get("version"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Header().Set("X-Schema-Version", doc.Version)
+	_, _ = w.Write(doc.Schema)
+}
+
+// ================ //
+// == Validation == //
+// ================ //
+
+// ValidateTelemetryRecord checks a record against a schema
+//
+// Only the keywords used by the telemetry schemas are supported (type, properties, required, additionalProperties,
+// items, enum, pattern).
+func ValidateTelemetryRecord(schema, record []byte) error {
+	var node map[string]interface{}
+	if err := json.Unmarshal(schema, &node); err != nil {
+		return fmt.Errorf("invalid schema (%s)", err.Error())
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(record))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid record (%s)", err.Error())
+	}
+
+	return validateSchemaNode(node, value, "$")
+}
+
+// schemaTypeOf returns the JSON type of a decoded value
+func schemaTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+
+	return "unknown"
+}
+
+// validateSchemaNode Function
+func validateSchemaNode(node map[string]interface{}, value interface{}, path string) error {
+	// type
+	if expected, ok := node["type"]; ok {
+		types := []string{}
+		switch t := expected.(type) {
+		case string:
+			types = append(types, t)
+		case []interface{}:
+			for _, each := range t {
+				if s, ok := each.(string); ok {
+					types = append(types, s)
+				}
+			}
+		}
+
+		actual := schemaTypeOf(value)
+
+		matched := false
+		for _, t := range types {
+			if t == actual || (t == "number" && actual == "integer") {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), actual)
+		}
+	}
+
+	// enum
+	if enum, ok := node["enum"].([]interface{}); ok {
+		matched := false
+		for _, each := range enum {
+			if fmt.Sprint(each) == fmt.Sprint(value) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+		}
+	}
+
+	// pattern
+	if pattern, ok := node["pattern"].(string); ok {
+		if s, ok := value.(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("%s: invalid pattern %s (%s)", path, pattern, err.Error())
+			}
+
+			if !re.MatchString(s) {
+				return fmt.Errorf("%s: %q does not match %s", path, s, pattern)
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := node["properties"].(map[string]interface{})
+
+		if required, ok := node["required"].([]interface{}); ok {
+			for _, each := range required {
+				if name, ok := each.(string); ok {
+					if _, ok := v[name]; !ok {
+						return fmt.Errorf("%s: missing %s", path, name)
+					}
+				}
+			}
+		}
+
+		names := []string{}
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			property, ok := properties[name].(map[string]interface{})
+			if !ok {
+				if additional, ok := node["additionalProperties"].(bool); ok && !additional {
+					return fmt.Errorf("%s: unknown field %s", path, name)
+				}
+				continue
+			}
+
+			if err := validateSchemaNode(property, v[name], path+"."+name); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		if items, ok := node["items"].(map[string]interface{}); ok {
+			for i, each := range v {
+				if err := validateSchemaNode(items, each, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"bufio"
+	"context"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+func TestTelemetrySchema(t *testing.T) {
+	logFile, err := os.CreateTemp(t.TempDir(), "kubearmor-*.log")
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create the log file (%s)", err.Error())
+	}
+	defer logFile.Close()
+
+	feeder := &Feeder{Node: &tp.Node{ClusterName: "default", NodeName: "node-1"}, Output: logFile.Name()}
+	feeder.SecurityPolicies = map[string]tp.MatchPolicies{}
+	feeder.SecurityPoliciesLock = new(sync.RWMutex)
+	feeder.DefaultPostures = map[string]tp.DefaultPosture{}
+	feeder.EndPointPostures = map[string]tp.DefaultPosture{}
+	feeder.DefaultPosturesLock = new(sync.Mutex)
+	feeder.SeverityRangesLock = new(sync.RWMutex)
+	feeder.SinksLock = new(sync.RWMutex)
+	feeder.SinkDrainTimeout = time.Second
+	feeder.EnforcementFailures = map[string]uint64{}
+	feeder.EnforcementFailuresLock = new(sync.RWMutex)
+	feeder.Enforcer = "AppArmor"
+
+	// the logs are written into the log file from the queue of a sink
+	feeder.AddLogFileSink(logFile, nil)
+
+	// subscribe to the alerts and the logs
+	alerts := make(chan *pb.Alert, 16)
+	AlertLock = new(sync.RWMutex)
+	AlertStructs = map[string]AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+	defer func() { AlertStructs = map[string]AlertStruct{} }()
+
+	logs := make(chan *pb.Log, 16)
+	LogLock = new(sync.RWMutex)
+	LogStructs = map[string]LogStruct{"test": {Filter: "all", Broadcast: logs}}
+	defer func() { LogStructs = map[string]LogStruct{} }()
+
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "block-sh"}}
+	policy.Spec.Severity = 5
+	policy.Spec.Tags = []string{"MITRE", "T1059"}
+	policy.Spec.Message = "shell executed"
+	policy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/bin/sh", Action: "Block"}}
+
+	endPoint := tp.EndPoint{NamespaceName: "web", EndPointName: "frontend", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{policy}
+	feeder.UpdateSecurityPolicies("ADDED", endPoint)
+
+	feeder.SecurityPolicies["web_frontend"].Policies[0].Attached = time.Now().Add(-time.Minute)
+
+	exec := tp.Log{
+		Timestamp:         time.Now().Unix(),
+		UpdatedTime:       time.Now().UTC().Format(time.RFC3339Nano),
+		NamespaceName:     "web",
+		Owner:             &tp.PodOwner{Ref: "Deployment", Name: "frontend", Namespace: "web"},
+		PodName:           "frontend",
+		Labels:            "app=frontend",
+		ContainerID:       "frontend",
+		ContainerName:     "nginx",
+		ContainerImage:    "docker.io/library/nginx:latest",
+		HostPPID:          100,
+		HostPID:           101,
+		PPID:              1,
+		PID:               2,
+		UID:               0,
+		ParentProcessName: "/bin/bash",
+		ProcessName:       "/bin/sh",
+		Operation:         "Process",
+		Source:            "/bin/bash",
+		Resource:          "/bin/sh",
+		Cwd:               "/",
+		Result:            "Passed",
+		PolicyEnabled:     tp.KubeArmorPolicyEnabled,
+	}
+
+	// a corpus of the alerts and the logs
+	corpus := []tp.Log{}
+
+	// an enforced block, with a sample of the write
+	denied := exec
+	denied.Result = "Permission denied"
+	denied.Capture = &tp.WriteCapture{Source: "syscall", FD: 3, Path: "/etc/passwd", Size: 4, Sample: "cm9v", Handles: []string{"3"}}
+	corpus = append(corpus, feeder.UpdateMatchedPolicy(denied))
+
+	// a failed block (followed by the alert of the enforcement failure)
+	corpus = append(corpus, feeder.UpdateMatchedPolicy(exec))
+
+	// a container log
+	visible := exec
+	visible.Type = "ContainerLog"
+	visible.Resource = "/usr/bin/env"
+	visible.ProcessName = "/usr/bin/env"
+	visible.Session = "exec"
+	visible.Data = "syscall=SYS_EXECVE"
+	corpus = append(corpus, visible)
+
+	// a host log
+	host := tp.Log{
+		Timestamp:         time.Now().Unix(),
+		UpdatedTime:       time.Now().UTC().Format(time.RFC3339Nano),
+		HostPPID:          1,
+		HostPID:           200,
+		PPID:              1,
+		PID:               200,
+		UID:               1000,
+		ParentProcessName: "/usr/lib/systemd/systemd",
+		ProcessName:       "/usr/bin/curl",
+		Type:              "HostLog",
+		Operation:         "Network",
+		Source:            "/usr/bin/curl",
+		Resource:          "remoteip=10.0.0.1 port=443 protocol=TCP",
+		Data:              "syscall=SYS_CONNECT",
+		Cwd:               "/home/user",
+		Result:            "Passed",
+		ClockResync:       true,
+	}
+	corpus = append(corpus, host)
+
+	// a host alert
+	hostAlert := host
+	hostAlert.Type = "MatchedHostPolicy"
+	hostAlert.PolicyName = "audit-curl"
+	hostAlert.Severity = "3"
+	hostAlert.Action = "Audit"
+	corpus = append(corpus, hostAlert)
+
+	for _, log := range corpus {
+		feeder.pushMatchedLog(log)
+	}
+
+	schema, err := GetTelemetrySchema("")
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to get the telemetry schema (%s)", err.Error())
+	}
+
+	// the queued logs are written once the sinks are closed
+	feeder.closeSinks()
+
+	logFile, err = os.Open(logFile.Name())
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to read the log file (%s)", err.Error())
+	}
+	defer logFile.Close()
+
+	records := 0
+	scanner := bufio.NewScanner(logFile)
+	for scanner.Scan() {
+		records++
+		if err := ValidateTelemetryRecord(schema.Schema, scanner.Bytes()); err != nil {
+			t.Errorf("[FAIL] Invalid record (%s)\n%s", err.Error(), scanner.Text())
+		}
+	}
+
+	// the corpus and the alert of the enforcement failure
+	if records != len(corpus)+1 {
+		t.Errorf("[FAIL] Expected %d records (%d)", len(corpus)+1, records)
+	}
+
+	alert := <-alerts
+	if alert.SchemaVersion != TelemetrySchemaVersion {
+		t.Errorf("[FAIL] Expected the version of the schema in the alert (%s)", alert.SchemaVersion)
+	}
+
+	log := <-logs
+	if log.SchemaVersion != TelemetrySchemaVersion {
+		t.Errorf("[FAIL] Expected the version of the schema in the log (%s)", log.SchemaVersion)
+	}
+
+	// the records which break the schema
+	invalid := map[string]string{
+		"unknown field":   `{"schemaVersion":"1.0","timestamp":1,"updatedTime":"","hostName":"","hostPPid":0,"hostPid":0,"ppid":0,"pid":0,"uid":0,"parentProcessName":"","processName":"","atags":null,"type":"ContainerLog","source":"","operation":"","resource":"","cwd":"","result":"","unknown":1}`,
+		"wrong type":      `{"schemaVersion":"1.0","timestamp":"1","updatedTime":"","hostName":"","hostPPid":0,"hostPid":0,"ppid":0,"pid":0,"uid":0,"parentProcessName":"","processName":"","atags":null,"type":"ContainerLog","source":"","operation":"","resource":"","cwd":"","result":""}`,
+		"missing field":   `{"schemaVersion":"1.0","timestamp":1}`,
+		"unknown type":    `{"schemaVersion":"1.0","timestamp":1,"updatedTime":"","hostName":"","hostPPid":0,"hostPid":0,"ppid":0,"pid":0,"uid":0,"parentProcessName":"","processName":"","atags":null,"type":"Other","source":"","operation":"","resource":"","cwd":"","result":""}`,
+		"another version": `{"schemaVersion":"2.0","timestamp":1,"updatedTime":"","hostName":"","hostPPid":0,"hostPid":0,"ppid":0,"pid":0,"uid":0,"parentProcessName":"","processName":"","atags":null,"type":"ContainerLog","source":"","operation":"","resource":"","cwd":"","result":""}`,
+	}
+
+	for name, record := range invalid {
+		if err := ValidateTelemetryRecord(schema.Schema, []byte(record)); err == nil {
+			t.Errorf("[FAIL] Expected the record with a(n) %s to be invalid", name)
+		}
+	}
+
+	t.Log("[PASS] Validated the alerts and the logs against the telemetry schema")
+}
+
+func TestGetTelemetrySchema(t *testing.T) {
+	ls := &LogService{}
+
+	// gRPC
+	res, err := ls.GetTelemetrySchema(context.Background(), &pb.TelemetrySchemaRequest{})
+	if err != nil || res.Version != TelemetrySchemaVersion || !strings.Contains(res.Schema, `"schemaVersion"`) {
+		t.Errorf("[FAIL] Unexpected telemetry schema (%v, %v)", res, err)
+	}
+
+	if _, err := ls.GetTelemetrySchema(context.Background(), &pb.TelemetrySchemaRequest{Version: "0"}); err == nil {
+		t.Errorf("[FAIL] Expected an unsupported version to fail")
+	}
+
+	// HTTP
+	w := httptest.NewRecorder()
+	ServeTelemetrySchema(w, httptest.NewRequest("GET", "/schema/telemetry?version=1.0", nil))
+	if w.Code != 200 || w.Header().Get("X-Schema-Version") != TelemetrySchemaVersion || w.Body.String() != string(telemetrySchemaV1) {
+		t.Errorf("[FAIL] Unexpected response of the schema endpoint (%d)", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	ServeTelemetrySchema(w, httptest.NewRequest("GET", "/schema/telemetry?version=0", nil))
+	if w.Code != 404 {
+		t.Errorf("[FAIL] Expected an unsupported version to be not found (%d)", w.Code)
+	}
+
+	t.Log("[PASS] Served the telemetry schema")
+}
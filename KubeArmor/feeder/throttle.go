@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"sync"
+	"time"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ============== //
+// == Throttle == //
+// ============== //
+
+// throttle constants
+const (
+	ThrottleAction = "Block(Throttle)"
+
+	// rates are given in executions per minute
+	ThrottlePeriod = time.Minute
+
+	ThrottleRefillInterval = time.Second
+)
+
+// throttleBucket Structure
+type throttleBucket struct {
+	Rule tp.MatchPolicy
+
+	Tokens   float64
+	LastFill time.Time
+}
+
+// refill adds the tokens accumulated since the last fill
+func (tb *throttleBucket) refill(now time.Time) {
+	if elapsed := now.Sub(tb.LastFill); elapsed > 0 {
+		tb.Tokens += float64(tb.Rule.Rate) * elapsed.Seconds() / ThrottlePeriod.Seconds()
+	}
+
+	if burst := float64(throttleBurst(tb.Rule)); tb.Tokens > burst {
+		tb.Tokens = burst
+	}
+
+	tb.LastFill = now
+}
+
+// throttleBurst returns the bucket size of a rule (the rate if no burst is given)
+func throttleBurst(rule tp.MatchPolicy) int {
+	if rule.Burst > 0 {
+		return rule.Burst
+	}
+	return rule.Rate
+}
+
+// Throttler keeps a token bucket per (container, Throttle rule) for the rules which aren't enforced,
+// the BPF LSM enforcer keeps the buckets of the enforced rules at its exec hook
+type Throttler struct {
+	// clock, replaceable for testing
+	Now func() time.Time
+
+	// container / policy / source / path -> bucket
+	buckets     map[string]*throttleBucket
+	bucketsLock *sync.Mutex
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewThrottler Function
+func NewThrottler() *Throttler {
+	th := &Throttler{}
+
+	th.Now = time.Now
+
+	th.buckets = map[string]*throttleBucket{}
+	th.bucketsLock = new(sync.Mutex)
+
+	th.stop = make(chan struct{})
+
+	return th
+}
+
+// Take consumes a token of a rule for an execution in a container, and returns false if no token was left
+func (th *Throttler) Take(containerID string, rule tp.MatchPolicy) bool {
+	if rule.Rate <= 0 {
+		return true
+	}
+
+	key := containerID + "/" + rule.PolicyName + "/" + rule.Source + "/" + rule.Resource
+
+	th.bucketsLock.Lock()
+	defer th.bucketsLock.Unlock()
+
+	now := th.Now()
+
+	bucket, ok := th.buckets[key]
+	if !ok || bucket.Rule.Rate != rule.Rate || bucket.Rule.Burst != rule.Burst {
+		bucket = &throttleBucket{Rule: rule, Tokens: float64(throttleBurst(rule)), LastFill: now}
+		th.buckets[key] = bucket
+	}
+
+	bucket.refill(now)
+
+	if bucket.Tokens < 1 {
+		return false
+	}
+
+	bucket.Tokens--
+
+	return true
+}
+
+// Refill drops the buckets which are full again
+func (th *Throttler) Refill() {
+	th.bucketsLock.Lock()
+	defer th.bucketsLock.Unlock()
+
+	now := th.Now()
+
+	for key, bucket := range th.buckets {
+		bucket.refill(now)
+
+		// a full bucket is the same as a new one
+		if bucket.Tokens >= float64(throttleBurst(bucket.Rule)) {
+			delete(th.buckets, key)
+		}
+	}
+}
+
+// Start Function
+func (th *Throttler) Start() {
+	th.wg.Add(1)
+
+	go func() {
+		defer th.wg.Done()
+
+		ticker := time.NewTicker(ThrottleRefillInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-th.stop:
+				return
+			case <-ticker.C:
+				th.Refill()
+			}
+		}
+	}()
+}
+
+// Close Function
+func (th *Throttler) Close() {
+	close(th.stop)
+	th.wg.Wait()
+}
+
+// takeThrottleToken consumes a token of a Throttle rule for a log
+func (fd *Feeder) takeThrottleToken(log tp.Log, rule tp.MatchPolicy) bool {
+	if fd.Throttler == nil {
+		return true
+	}
+	return fd.Throttler.Take(log.ContainerID, rule)
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestThrottler(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	throttler := NewThrottler()
+	throttler.Now = func() time.Time { return now }
+
+	// 6 executions per minute (a token per 10 seconds), up to 2 at once
+	curl := tp.MatchPolicy{PolicyName: "throttle-curl", Operation: "Process", Resource: "/usr/bin/curl", Action: "Audit (Throttle)", Rate: 6, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		if !throttler.Take("web", curl) {
+			t.Fatalf("[FAIL] Unexpected throttling within the burst")
+		}
+	}
+
+	if throttler.Take("web", curl) {
+		t.Errorf("[FAIL] Expected no token to be left")
+	}
+
+	// buckets are kept per container
+	if !throttler.Take("db", curl) {
+		t.Errorf("[FAIL] Unexpected throttling of another container")
+	}
+
+	// not refilled yet
+	now = now.Add(5 * time.Second)
+	throttler.Refill()
+
+	if throttler.Take("web", curl) {
+		t.Errorf("[FAIL] Unexpected token before the refill")
+	}
+
+	// a token is refilled per 10 seconds
+	now = now.Add(5 * time.Second)
+
+	if !throttler.Take("web", curl) {
+		t.Fatalf("[FAIL] Expected a token to be refilled")
+	}
+
+	// refills are capped at the burst, and the full buckets are dropped
+	now = now.Add(time.Hour)
+	throttler.Refill()
+
+	if len(throttler.buckets) != 0 {
+		t.Errorf("[FAIL] Expected the full buckets to be dropped (%d)", len(throttler.buckets))
+	}
+
+	for i := 0; i < 2; i++ {
+		if !throttler.Take("web", curl) {
+			t.Errorf("[FAIL] Expected %d tokens after a long idle time", 2)
+		}
+	}
+	if throttler.Take("web", curl) {
+		t.Errorf("[FAIL] Expected the bucket to be capped at the burst")
+	}
+
+	t.Log("[PASS] Throttled executions with token buckets")
+}
+
+func TestThrottlePolicy(t *testing.T) {
+	feeder := &Feeder{}
+	feeder.SecurityPolicies = map[string]tp.MatchPolicies{}
+	feeder.SecurityPoliciesLock = new(sync.RWMutex)
+	feeder.DefaultPostures = map[string]tp.DefaultPosture{}
+	feeder.EndPointPostures = map[string]tp.DefaultPosture{}
+	feeder.DefaultPosturesLock = new(sync.Mutex)
+	feeder.Enforcer = "BPFLSM"
+	feeder.Throttler = NewThrottler()
+
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "throttle-curl"}}
+	policy.Spec.Process.MatchPaths = []tp.ProcessPathType{{Path: "/usr/bin/curl", Action: "Throttle", Rate: 1, Burst: 1}}
+
+	endPoint := tp.EndPoint{NamespaceName: "web", EndPointName: "frontend", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{policy}
+	feeder.UpdateSecurityPolicies("ADDED", endPoint)
+
+	exec := tp.Log{ContainerID: "frontend", NamespaceName: "web", PodName: "frontend", Operation: "Process", Source: "/bin/sh", Resource: "/usr/bin/curl", ProcessName: "/usr/bin/curl", Result: "Passed"}
+
+	// within the rate
+	if log := feeder.UpdateMatchedPolicy(exec); log.Source != "" {
+		t.Errorf("[FAIL] Unexpected alert within the rate (%s)", log.Action)
+	}
+
+	// denied by the enforcer
+	denied := exec
+	denied.Result = "Permission denied"
+
+	if log := feeder.UpdateMatchedPolicy(denied); log.PolicyName != "throttle-curl" || log.Action != ThrottleAction {
+		t.Errorf("[FAIL] Unexpected alert of a throttled execution (%s, %s)", log.PolicyName, log.Action)
+	}
+
+	// passed over the rate, the enforcer keeps the bucket
+	if log := feeder.UpdateMatchedPolicy(exec); log.Source != "" {
+		t.Errorf("[FAIL] Unexpected alert of an execution passed by the enforcer (%s)", log.Action)
+	}
+
+	// unenforceable without the BPF LSM enforcer
+	feeder.Enforcer = "AppArmor"
+	feeder.UpdateSecurityPolicies("MODIFIED", endPoint)
+
+	if action := feeder.SecurityPolicies["web_frontend"].Policies[0].Action; action != "Audit (Throttle)" {
+		t.Errorf("[FAIL] Unexpected action of a Throttle rule with AppArmor (%s)", action)
+	}
+
+	// the executions over the rate are audited instead
+	if log := feeder.UpdateMatchedPolicy(exec); log.Source != "" {
+		t.Errorf("[FAIL] Unexpected alert within the rate (%s)", log.Action)
+	}
+	if log := feeder.UpdateMatchedPolicy(exec); log.Action != "Audit ("+ThrottleAction+")" {
+		t.Errorf("[FAIL] Unexpected alert of an execution over the rate (%s)", log.Action)
+	}
+
+	t.Log("[PASS] Matched Throttle rules")
+}
@@ -0,0 +1,333 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+// ================== //
+// == Webhook Sink == //
+// ================== //
+
+// webhook constants
+const (
+	WebhookSignatureHeader = "X-KubeArmor-Signature"
+
+	WebhookDefaultBatchSize     = 100
+	WebhookDefaultBatchInterval = 5 * time.Second
+	WebhookDefaultMaxRetries    = 3
+	WebhookDefaultRetryBackoff  = time.Second
+	WebhookDefaultTimeout       = 10 * time.Second
+)
+
+// errWebhookStatus is returned for the responses which are worth retrying (5xx)
+var errWebhookStatus = errors.New("webhook server error")
+
+// WebhookSinkConfig Structure
+type WebhookSinkConfig struct {
+	URL     string
+	Headers map[string]string
+
+	// shared secret to sign the bodies with (HMAC-SHA256), no signature if empty
+	Secret string
+
+	// minimum severity of alerts to be posted
+	MinSeverity int
+
+	// enforcers of alerts to be posted (all if empty)
+	Enforcers []string
+
+	// alerts are posted per BatchSize alerts or per BatchInterval
+	BatchSize     int
+	BatchInterval time.Duration
+
+	// retries on 5xx responses and connection errors
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	Timeout time.Duration
+
+	// TLS verification
+	CAFile             string
+	InsecureSkipVerify bool
+}
+
+// WebhookSinkStats Structure
+type WebhookSinkStats struct {
+	Sent    uint64
+	Retries uint64
+
+	// alerts dropped on persistent failures or a full buffer
+	Dropped uint64
+}
+
+// WebhookSink posts alerts to an HTTP endpoint in batches
+type WebhookSink struct {
+	Config WebhookSinkConfig
+
+	client *http.Client
+
+	pending     []*pb.Alert
+	pendingLock *sync.Mutex
+
+	stats     WebhookSinkStats
+	statsLock *sync.Mutex
+
+	flush chan struct{}
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// SignWebhookBody returns the signature of a body (sha256=<hex digest>)
+func SignWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewWebhookSink Function
+func NewWebhookSink(config WebhookSinkConfig) (*WebhookSink, error) {
+	if config.URL == "" {
+		return nil, errors.New("no webhook url")
+	}
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = WebhookDefaultBatchSize
+	}
+	if config.BatchInterval <= 0 {
+		config.BatchInterval = WebhookDefaultBatchInterval
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = WebhookDefaultMaxRetries
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = WebhookDefaultRetryBackoff
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = WebhookDefaultTimeout
+	}
+
+	// #nosec G402 skipping the verification is an explicit option
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify, MinVersion: tls.VersionTLS12}
+
+	if config.CAFile != "" {
+		ca, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates in %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	ws := &WebhookSink{}
+
+	ws.Config = config
+
+	ws.client = &http.Client{
+		Timeout:   config.Timeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+
+	ws.pending = []*pb.Alert{}
+	ws.pendingLock = new(sync.Mutex)
+
+	ws.statsLock = new(sync.Mutex)
+
+	ws.flush = make(chan struct{}, 1)
+	ws.stop = make(chan struct{})
+
+	return ws, nil
+}
+
+// Name Function
+func (ws *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Start Function
+func (ws *WebhookSink) Start() {
+	ws.wg.Add(1)
+
+	go func() {
+		defer ws.wg.Done()
+
+		ticker := time.NewTicker(ws.Config.BatchInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ws.stop:
+				ws.Flush()
+				return
+			case <-ws.flush:
+				ws.Flush()
+			case <-ticker.C:
+				ws.Flush()
+			}
+		}
+	}()
+}
+
+// Close Function
+func (ws *WebhookSink) Close() error {
+	close(ws.stop)
+	ws.wg.Wait()
+	return nil
+}
+
+// SendAlert buffers an alert, a full batch is posted right away
+func (ws *WebhookSink) SendAlert(alert *pb.Alert) {
+	if ws == nil || !meetsMinSeverity(alert, ws.Config.MinSeverity) || !matchesEnforcer(alert.Enforcer, ws.Config.Enforcers) {
+		return
+	}
+
+	ws.pendingLock.Lock()
+
+	// keep the buffer bounded while the endpoint is down
+	if len(ws.pending) >= ws.Config.BatchSize*10 {
+		ws.pendingLock.Unlock()
+		ws.addStats(0, 0, 1)
+		return
+	}
+
+	ws.pending = append(ws.pending, alert)
+	full := len(ws.pending) >= ws.Config.BatchSize
+
+	ws.pendingLock.Unlock()
+
+	if full {
+		select {
+		case ws.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush posts the pending alerts in batches
+func (ws *WebhookSink) Flush() {
+	for {
+		ws.pendingLock.Lock()
+		if len(ws.pending) == 0 {
+			ws.pendingLock.Unlock()
+			return
+		}
+
+		size := len(ws.pending)
+		if size > ws.Config.BatchSize {
+			size = ws.Config.BatchSize
+		}
+
+		batch := ws.pending[:size]
+		ws.pending = ws.pending[size:]
+		ws.pendingLock.Unlock()
+
+		if err := ws.postBatch(batch); err != nil {
+			ws.addStats(0, 0, uint64(len(batch)))
+			kg.Warnf("Dropped %d alerts for the webhook (%s, %d dropped in total)", len(batch), err.Error(), ws.GetStats().Dropped)
+		} else {
+			ws.addStats(uint64(len(batch)), 0, 0)
+		}
+	}
+}
+
+// postBatch posts a batch with bounded retries
+func (ws *WebhookSink) postBatch(batch []*pb.Alert) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	backoff := ws.Config.RetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		err = ws.post(body)
+		if err == nil || !errors.Is(err, errWebhookStatus) || attempt >= ws.Config.MaxRetries {
+			return err
+		}
+
+		ws.addStats(0, 1, 0)
+
+		select {
+		case <-time.After(backoff):
+		case <-ws.stop:
+			// shutting down, don't wait for the endpoint anymore
+			return err
+		}
+
+		backoff *= 2
+	}
+}
+
+// post Function
+func (ws *WebhookSink) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, ws.Config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, val := range ws.Config.Headers {
+		req.Header.Set(key, val)
+	}
+
+	if ws.Config.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhookBody(ws.Config.Secret, body))
+	}
+
+	res, err := ws.client.Do(req)
+	if err != nil {
+		// connection errors are retried as well
+		return fmt.Errorf("%w (%s)", errWebhookStatus, err.Error())
+	}
+	defer res.Body.Close()
+
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode >= 500 {
+		return fmt.Errorf("%w (%s)", errWebhookStatus, res.Status)
+	} else if res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response (%s)", res.Status)
+	}
+
+	return nil
+}
+
+// addStats Function
+func (ws *WebhookSink) addStats(sent, retries, dropped uint64) {
+	ws.statsLock.Lock()
+	defer ws.statsLock.Unlock()
+
+	ws.stats.Sent += sent
+	ws.stats.Retries += retries
+	ws.stats.Dropped += dropped
+}
+
+// GetStats Function
+func (ws *WebhookSink) GetStats() WebhookSinkStats {
+	ws.statsLock.Lock()
+	defer ws.statsLock.Unlock()
+
+	return ws.stats
+}
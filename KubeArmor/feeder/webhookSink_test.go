@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+// webhookServer records the requests to an in-process webhook
+type webhookServer struct {
+	lock *sync.Mutex
+
+	// status codes to respond with, in order (200 once exhausted)
+	statuses []int
+
+	requests   int
+	batches    [][]*pb.Alert
+	signatures []bool
+	headers    []string
+}
+
+func (ws *webhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	ws.requests++
+
+	status := http.StatusOK
+	if len(ws.statuses) > 0 {
+		status, ws.statuses = ws.statuses[0], ws.statuses[1:]
+	}
+
+	if status == http.StatusOK {
+		body, _ := io.ReadAll(r.Body)
+
+		batch := []*pb.Alert{}
+		_ = json.Unmarshal(body, &batch)
+
+		ws.batches = append(ws.batches, batch)
+		ws.signatures = append(ws.signatures, r.Header.Get(WebhookSignatureHeader) == SignWebhookBody("s3cr3t", body))
+		ws.headers = append(ws.headers, r.Header.Get("X-Team"))
+	}
+
+	w.WriteHeader(status)
+}
+
+func TestWebhookSink(t *testing.T) {
+	handler := &webhookServer{lock: new(sync.Mutex)}
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	sink, err := NewWebhookSink(WebhookSinkConfig{
+		URL:           server.URL,
+		Headers:       map[string]string{"X-Team": "secops"},
+		Secret:        "s3cr3t",
+		MinSeverity:   5,
+		BatchSize:     2,
+		BatchInterval: time.Hour,
+		MaxRetries:    2,
+		RetryBackoff:  time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create webhook sink (%s)", err.Error())
+	}
+
+	// batched per 2 alerts, the low severity one is filtered out
+	for _, severity := range []string{"7", "2", "5", "9"} {
+		sink.SendAlert(&pb.Alert{PolicyName: "block-shell", Severity: severity, Action: "Block"})
+	}
+	sink.Flush()
+
+	if len(handler.batches) != 2 || len(handler.batches[0]) != 2 || len(handler.batches[1]) != 1 {
+		t.Fatalf("[FAIL] Unexpected batches (%+v)", handler.batches)
+	}
+	for idx := range handler.batches {
+		if !handler.signatures[idx] || handler.headers[idx] != "secops" {
+			t.Errorf("[FAIL] Unexpected signature or headers of batch %d", idx)
+		}
+	}
+	if handler.batches[0][0].PolicyName != "block-shell" {
+		t.Errorf("[FAIL] Unexpected alert (%+v)", handler.batches[0][0])
+	}
+
+	// retried on 5xx until it's accepted
+	handler.statuses = []int{http.StatusServiceUnavailable, http.StatusBadGateway}
+	sink.SendAlert(&pb.Alert{PolicyName: "retry", Severity: "8"})
+	sink.Flush()
+
+	if stats := sink.GetStats(); stats.Sent != 4 || stats.Retries != 2 || stats.Dropped != 0 {
+		t.Errorf("[FAIL] Unexpected stats after retries (%+v)", stats)
+	}
+
+	// dropped after the retries are exhausted
+	handler.statuses = []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError}
+	sink.SendAlert(&pb.Alert{PolicyName: "drop", Severity: "8"})
+	sink.Flush()
+
+	if stats := sink.GetStats(); stats.Sent != 4 || stats.Retries != 4 || stats.Dropped != 1 {
+		t.Errorf("[FAIL] Unexpected stats after persistent failures (%+v)", stats)
+	}
+
+	// client errors aren't retried
+	requests := handler.requests
+	handler.statuses = []int{http.StatusBadRequest}
+	sink.SendAlert(&pb.Alert{PolicyName: "invalid", Severity: "8"})
+	sink.Flush()
+
+	if handler.requests != requests+1 || sink.GetStats().Dropped != 2 {
+		t.Errorf("[FAIL] Unexpected retry of a client error (%d requests, %+v)", handler.requests-requests, sink.GetStats())
+	}
+
+	t.Log("[PASS] Posted signed alert batches with retries")
+}
+
+func TestWebhookSinkInterval(t *testing.T) {
+	handler := &webhookServer{lock: new(sync.Mutex)}
+
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+
+	// CA certificates which cannot be read
+	if _, err := NewWebhookSink(WebhookSinkConfig{URL: server.URL, CAFile: "/nonexistent/ca.pem"}); err == nil {
+		t.Errorf("[FAIL] Expected an error for a missing CA file")
+	}
+
+	sink, err := NewWebhookSink(WebhookSinkConfig{URL: server.URL, BatchSize: 10, BatchInterval: 10 * time.Millisecond, InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create webhook sink (%s)", err.Error())
+	}
+	sink.Start()
+
+	sink.SendAlert(&pb.Alert{PolicyName: "interval", Severity: "1"})
+
+	for i := 0; i < 100 && sink.GetStats().Sent == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Errorf("[FAIL] Failed to close webhook sink (%s)", err.Error())
+	}
+
+	handler.lock.Lock()
+	defer handler.lock.Unlock()
+
+	if len(handler.batches) != 1 || handler.signatures[0] {
+		t.Errorf("[FAIL] Expected one unsigned batch posted on the interval (%+v)", handler.batches)
+	}
+
+	t.Log("[PASS] Posted pending alerts on the interval over TLS")
+}
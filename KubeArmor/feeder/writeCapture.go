@@ -0,0 +1,276 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// =================== //
+// == Write Capture == //
+// =================== //
+
+// capture constants
+const (
+	// hard limit of the samples, whatever the configuration is
+	CaptureMaxBytesLimit = 4096
+
+	// the open handles looked at, and reported
+	captureMaxFDs     = 1024
+	captureMaxHandles = 32
+)
+
+// captureWriteSyscalls are the write syscalls (write, pwrite64) per architecture
+var captureWriteSyscalls = map[string][]int64{
+	"amd64": {1, 18},
+	"arm64": {64, 68},
+}
+
+// isWriteIntent checks if a file log is an attempt to write
+func isWriteIntent(log tp.Log) bool {
+	// the writes denied by the BPF enforcer
+	if strings.Contains(log.Data, "lsm=FILE_PERMISSION") {
+		return true
+	}
+
+	flags := getLogDataField(log.Data, "flags")
+	for _, flag := range []string{"O_WRONLY", "O_RDWR", "O_APPEND", "O_TRUNC", "O_CREAT"} {
+		if strings.Contains(flags, flag) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseProcHex parses a syscall argument in /proc/<pid>/syscall
+func parseProcHex(arg string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(arg, "0x"), 16, 64)
+}
+
+// writeInProgress returns the handle, buffer, and size of the write a process is blocked in, if any
+func writeInProgress(pidDir string) (int, uint64, int64, bool) {
+	// #nosec
+	data, err := os.ReadFile(filepath.Join(pidDir, "syscall"))
+	if err != nil {
+		return 0, 0, 0, false
+	}
+
+	// "running", "-1 sp pc" (not in a syscall), or "nr arg0 .. arg5 sp pc"
+	fields := strings.Fields(string(data))
+	if len(fields) < 4 {
+		return 0, 0, 0, false
+	}
+
+	nr, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+
+	isWrite := false
+	for _, syscall := range captureWriteSyscalls[runtime.GOARCH] {
+		if nr == syscall {
+			isWrite = true
+		}
+	}
+	if !isWrite {
+		return 0, 0, 0, false
+	}
+
+	fd, err := parseProcHex(fields[1])
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	buf, err := parseProcHex(fields[2])
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	count, err := parseProcHex(fields[3])
+	if err != nil {
+		return 0, 0, 0, false
+	}
+
+	return int(fd), buf, int64(count), true
+}
+
+// describeHandle returns the target, offset, and flags (octal) of an open handle
+func describeHandle(pidDir string, fd string) (string, int64, string) {
+	path, _ := os.Readlink(filepath.Join(pidDir, "fd", fd))
+
+	offset := int64(0)
+	flags := ""
+
+	// #nosec
+	file, err := os.Open(filepath.Join(pidDir, "fdinfo", fd))
+	if err != nil {
+		return path, offset, flags
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, val, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "pos":
+			offset, _ = strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+		case "flags":
+			flags = strings.TrimSpace(val)
+		}
+	}
+
+	return path, offset, flags
+}
+
+// writableHandles returns the files a process has open for writing
+func writableHandles(pidDir string) []string {
+	entries, err := os.ReadDir(filepath.Join(pidDir, "fdinfo"))
+	if err != nil {
+		return nil
+	}
+
+	fds := []int{}
+	for i, entry := range entries {
+		if i >= captureMaxFDs {
+			break
+		}
+		if fd, err := strconv.Atoi(entry.Name()); err == nil {
+			fds = append(fds, fd)
+		}
+	}
+	sort.Ints(fds)
+
+	handles := []string{}
+
+	for _, fd := range fds {
+		path, offset, flags := describeHandle(pidDir, strconv.Itoa(fd))
+
+		// files only (no pipes, sockets, or anonymous inodes)
+		if !strings.HasPrefix(path, "/") {
+			continue
+		}
+
+		// O_WRONLY or O_RDWR
+		mode, err := strconv.ParseUint(flags, 8, 32)
+		if err != nil || mode&3 == 0 {
+			continue
+		}
+
+		handles = append(handles, fmt.Sprintf("fd=%d path=%s pos=%d flags=%s", fd, path, offset, flags))
+		if len(handles) >= captureMaxHandles {
+			break
+		}
+	}
+
+	return handles
+}
+
+// setWriteSample sets the sample of a capture, capped to the given size, and hashed unless redact is none
+func setWriteSample(capture *tp.WriteCapture, sample []byte, maxBytes int, redact string) {
+	if maxBytes > CaptureMaxBytesLimit {
+		maxBytes = CaptureMaxBytesLimit
+	}
+	if len(sample) > maxBytes {
+		sample = sample[:maxBytes]
+	}
+	if len(sample) == 0 {
+		return
+	}
+
+	capture.Truncated = capture.Size > int64(len(sample))
+
+	if redact == "none" {
+		capture.Sample = base64.StdEncoding.EncodeToString(sample)
+	} else {
+		digest := sha256.Sum256(sample)
+		capture.Sample = "sha256:" + hex.EncodeToString(digest[:])
+		capture.Redacted = true
+	}
+}
+
+// NewHookedWriteCapture returns the capture of a blocked write sampled by the BPF enforcer at the hook of the write
+func NewHookedWriteCapture(fd int32, path string, offset int64, size int64, sample []byte) *tp.WriteCapture {
+	capture := &tp.WriteCapture{Source: "lsm", FD: fd, Path: path, Offset: offset, Size: size}
+	setWriteSample(capture, sample, cfg.GlobalCfg.CaptureMaxBytes, cfg.GlobalCfg.CaptureRedact)
+	return capture
+}
+
+// captureBlockedWrite takes a snapshot of the write a process attempted to the blocked resource, from its proc entries
+func captureBlockedWrite(procRoot string, pid int32, resource string, maxBytes int, redact string) *tp.WriteCapture {
+	pidDir := filepath.Join(procRoot, strconv.Itoa(int(pid)))
+
+	capture := &tp.WriteCapture{Source: "fdinfo"}
+
+	// the buffer is only available while the process is still in the write, and only attributed to the blocked
+	// resource if the handle of the write is its file
+	if fd, buf, count, ok := writeInProgress(pidDir); ok {
+		if path, offset, flags := describeHandle(pidDir, strconv.Itoa(fd)); path == resource {
+			capture.Source = "syscall"
+
+			capture.FD = int32(fd)
+			capture.Path, capture.Offset, capture.Flags = path, offset, flags
+			capture.Size = count
+
+			size := count
+			if size > int64(maxBytes) {
+				size = int64(maxBytes)
+			}
+			if size > CaptureMaxBytesLimit {
+				size = CaptureMaxBytesLimit
+			}
+
+			if size > 0 {
+				sample := make([]byte, size)
+
+				// #nosec
+				if mem, err := os.Open(filepath.Join(pidDir, "mem")); err == nil {
+					n, _ := mem.ReadAt(sample, int64(buf))
+					_ = mem.Close()
+
+					setWriteSample(capture, sample[:n], maxBytes, redact)
+				}
+			}
+		}
+	}
+
+	capture.Handles = writableHandles(pidDir)
+
+	return capture
+}
+
+// attachWriteCapture attaches a snapshot of a blocked write to the alert of a captureOnBlock rule, preferring the
+// sample taken by the enforcer at the hook of the write
+func attachWriteCapture(log *tp.Log, blockedBy tp.MatchPolicy, hooked *tp.WriteCapture) {
+	if !blockedBy.CaptureOnBlock || log.PolicyName != blockedBy.PolicyName {
+		return
+	}
+
+	if log.Operation != "File" || log.Result == "Passed" || !isWriteIntent(*log) {
+		return
+	}
+
+	if hooked != nil {
+		hooked.Handles = writableHandles(filepath.Join(kl.GetHostProcPath(), strconv.Itoa(int(log.HostPID))))
+		log.Capture = hooked
+		return
+	}
+
+	log.Capture = captureBlockedWrite(kl.GetHostProcPath(), log.HostPID, log.Resource, cfg.GlobalCfg.CaptureMaxBytes, cfg.GlobalCfg.CaptureRedact)
+}
@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package feeder
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestCaptureBlockedWrite(t *testing.T) {
+	syscalls, ok := captureWriteSyscalls[runtime.GOARCH]
+	if !ok {
+		t.Skipf("Skipped as write syscalls are unknown on %s", runtime.GOARCH)
+	}
+
+	// a fake proc entry of a process blocked in write(3, 0x10, 12)
+	procRoot := t.TempDir()
+	pidDir := filepath.Join(procRoot, "1234")
+
+	for _, dir := range []string{"fd", "fdinfo"} {
+		if err := os.MkdirAll(filepath.Join(pidDir, dir), 0750); err != nil {
+			t.Fatalf("[FAIL] Failed to create a fake proc entry (%s)", err.Error())
+		}
+	}
+
+	files := map[string]string{
+		"syscall":  fmt.Sprintf("%d 0x3 0x10 0xc 0x0 0x0 0x0 0x7ffd9385db28 0x7f097b6f129d\n", syscalls[0]),
+		"mem":      strings.Repeat("\x00", 16) + "ENCRYPTED!!!",
+		"fdinfo/3": "pos:\t4096\nflags:\t0100001\nmnt_id:\t25\n",
+		"fdinfo/4": "pos:\t0\nflags:\t0100000\nmnt_id:\t25\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(pidDir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("[FAIL] Failed to create a fake proc entry (%s)", err.Error())
+		}
+	}
+
+	_ = os.Symlink("/data/customers.db", filepath.Join(pidDir, "fd", "3"))
+	_ = os.Symlink("/etc/passwd", filepath.Join(pidDir, "fd", "4"))
+
+	capture := captureBlockedWrite(procRoot, 1234, "/data/customers.db", 8, "none")
+
+	if capture.Source != "syscall" || capture.FD != 3 || capture.Path != "/data/customers.db" || capture.Offset != 4096 || capture.Size != 12 {
+		t.Errorf("[FAIL] Unexpected handle of the blocked write (%+v)", capture)
+	}
+
+	// capped at the given size
+	if sample, _ := base64.StdEncoding.DecodeString(capture.Sample); string(sample) != "ENCRYPTE" || !capture.Truncated {
+		t.Errorf("[FAIL] Unexpected sample of the blocked write (%s, %v)", string(sample), capture.Truncated)
+	}
+
+	// read-only handles aren't reported
+	if len(capture.Handles) != 1 || capture.Handles[0] != "fd=3 path=/data/customers.db pos=4096 flags=0100001" {
+		t.Errorf("[FAIL] Unexpected writable handles (%v)", capture.Handles)
+	}
+
+	// redacted samples, by default
+	if capture := captureBlockedWrite(procRoot, 1234, "/data/customers.db", 64, ""); !capture.Redacted || !strings.HasPrefix(capture.Sample, "sha256:") || capture.Truncated {
+		t.Errorf("[FAIL] Unexpected redacted sample (%+v)", capture)
+	}
+
+	// the writes to other files aren't attributed to the blocked resource
+	if capture := captureBlockedWrite(procRoot, 1234, "/etc/passwd", 64, "none"); capture.Source != "fdinfo" || capture.Sample != "" || capture.Path != "" || len(capture.Handles) != 1 {
+		t.Errorf("[FAIL] Unexpected capture of a write to another file (%+v)", capture)
+	}
+
+	// only the handles once the process left the write
+	if err := os.WriteFile(filepath.Join(pidDir, "syscall"), []byte("running\n"), 0600); err != nil {
+		t.Fatalf("[FAIL] Failed to update a fake proc entry (%s)", err.Error())
+	}
+
+	if capture := captureBlockedWrite(procRoot, 1234, "/data/customers.db", 64, "none"); capture.Source != "fdinfo" || capture.Sample != "" || len(capture.Handles) != 1 {
+		t.Errorf("[FAIL] Unexpected capture outside of a write (%+v)", capture)
+	}
+
+	t.Log("[PASS] Captured blocked writes")
+}
+
+func TestHookedWriteCapture(t *testing.T) {
+	sample := []byte("ENCRYPTED!!!")
+
+	maxBytes := cfg.GlobalCfg.CaptureMaxBytes
+	defer func() { cfg.GlobalCfg.CaptureMaxBytes = maxBytes }()
+	cfg.GlobalCfg.CaptureMaxBytes = 64
+
+	if capture := NewHookedWriteCapture(3, "/data/customers.db", 4096, 12, sample); !capture.Redacted || !strings.HasPrefix(capture.Sample, "sha256:") {
+		t.Errorf("[FAIL] Expected a redacted sample by default (%+v)", capture)
+	}
+
+	capture := &tp.WriteCapture{Size: 12}
+	setWriteSample(capture, sample, 8, "none")
+
+	if decoded, _ := base64.StdEncoding.DecodeString(capture.Sample); string(decoded) != "ENCRYPTE" || !capture.Truncated || capture.Redacted {
+		t.Errorf("[FAIL] Unexpected sample of the enforcer (%+v)", capture)
+	}
+
+	t.Log("[PASS] Capped and redacted the samples of the enforcer")
+}
+
+func TestCaptureOnBlockPolicy(t *testing.T) {
+	feeder := &Feeder{}
+	feeder.SecurityPolicies = map[string]tp.MatchPolicies{}
+	feeder.SecurityPoliciesLock = new(sync.RWMutex)
+	feeder.DefaultPostures = map[string]tp.DefaultPosture{}
+	feeder.EndPointPostures = map[string]tp.DefaultPosture{}
+	feeder.DefaultPosturesLock = new(sync.Mutex)
+	feeder.Enforcer = "AppArmor"
+
+	policy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "protect-data"}}
+	policy.Spec.File.MatchDirectories = []tp.FileDirectoryType{{Directory: "/data/", Recursive: true, CaptureOnBlock: true, Action: "Block"}}
+
+	endPoint := tp.EndPoint{NamespaceName: "web", EndPointName: "frontend", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{policy}
+	feeder.UpdateSecurityPolicies("ADDED", endPoint)
+
+	write := tp.Log{ContainerID: "frontend", NamespaceName: "web", PodName: "frontend", Operation: "File", Source: "/usr/bin/python3",
+		Resource: "/data/customers.db", ProcessName: "/usr/bin/python3", Data: "syscall=SYS_OPENAT fd=-100 flags=O_WRONLY|O_TRUNC", Result: "Permission denied"}
+
+	if log := feeder.UpdateMatchedPolicy(write); log.PolicyName != "protect-data" || log.Capture == nil {
+		t.Errorf("[FAIL] Expected a capture of the blocked write (%s, %+v)", log.PolicyName, log.Capture)
+	}
+
+	// reads aren't captured
+	read := write
+	read.Data = "syscall=SYS_OPENAT fd=-100 flags=O_RDONLY"
+
+	if log := feeder.UpdateMatchedPolicy(read); log.PolicyName != "protect-data" || log.Capture != nil {
+		t.Errorf("[FAIL] Unexpected capture of a blocked read (%s, %+v)", log.PolicyName, log.Capture)
+	}
+
+	// the samples taken by the enforcer are attached as they are
+	hooked := write
+	hooked.Data = "lsm=FILE_PERMISSION"
+	hooked.Capture = &tp.WriteCapture{Source: "lsm", Path: "/data/customers.db", Sample: "sha256:00"}
+
+	if log := feeder.UpdateMatchedPolicy(hooked); log.Capture == nil || log.Capture.Source != "lsm" {
+		t.Errorf("[FAIL] Expected the capture of the enforcer (%+v)", log.Capture)
+	}
+
+	// but not to the alerts of other rules
+	hooked.Resource = "/etc/shadow"
+	if log := feeder.UpdateMatchedPolicy(hooked); log.Capture != nil {
+		t.Errorf("[FAIL] Unexpected capture of a rule without captureOnBlock (%+v)", log.Capture)
+	}
+
+	t.Log("[PASS] Attached captures to the alerts of captureOnBlock rules")
+}
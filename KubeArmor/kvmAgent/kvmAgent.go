@@ -59,8 +59,9 @@ func NewKVMAgent(eventCb tp.KubeArmorHostPolicyEventCallback) *KVMAgent {
 	kvm.gRPCServer = gRPCServer
 
 	// Connect to gRPC server
-	ctx, _ := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	gRPCConnection, err := grpc.DialContext(ctx, kvm.gRPCServer, grpc.WithInsecure(), grpc.WithBlock())
+	cancel()
 	if err != nil {
 		kg.Errf("Not accessible to gRPC server (%s)", err.Error())
 		return nil
@@ -106,8 +107,9 @@ func (kvm *KVMAgent) ConnectToKVMService() {
 			}
 
 			// connect to gRPC server again
-			ctx, _ := context.WithTimeout(context.Background(), time.Second*5)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 			gRPCConnection, err := grpc.DialContext(ctx, kvm.gRPCServer, grpc.WithInsecure(), grpc.WithBlock())
+			cancel()
 			if err != nil {
 				kg.Errf("Not accessible to gRPC server (%s)", err.Error())
 				return
@@ -144,8 +146,9 @@ func (kvm *KVMAgent) ConnectToKVMService() {
 				}
 
 				// connect to gRPC server again
-				ctx, _ := context.WithTimeout(context.Background(), time.Second*5)
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 				gRPCConnection, err := grpc.DialContext(ctx, kvm.gRPCServer, grpc.WithInsecure(), grpc.WithBlock())
+				cancel()
 				if err != nil {
 					kg.Errf("Not accessible to gRPC server (%s)", err.Error())
 					return
@@ -7,6 +7,7 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"runtime/debug"
 
 	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
 	"github.com/kubearmor/KubeArmor/KubeArmor/core"
@@ -58,5 +59,10 @@ func main() {
 		return
 	}
 
+	// GOGC given to the daemon is respected over the configuration
+	if _, ok := os.LookupEnv("GOGC"); !ok && cfg.GlobalCfg.GCPercent > 0 {
+		debug.SetGCPercent(cfg.GlobalCfg.GCPercent)
+	}
+
 	core.KubeArmor()
 }
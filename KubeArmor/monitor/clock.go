@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"sync"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	kg "github.com/kubearmor/KubeArmor/KubeArmor/log"
+	"golang.org/x/sys/unix"
+)
+
+// ===================== //
+// == Clock Converter == //
+// ===================== //
+
+// DefaultClockDriftThreshold is the drift between the clocks triggering a recalibration
+const DefaultClockDriftThreshold = 2 * time.Second
+
+// getMonotonicTime returns CLOCK_MONOTONIC, the clock used by bpf_ktime_get_ns()
+func getMonotonicTime() time.Duration {
+	ts := unix.Timespec{}
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		return 0
+	}
+	return time.Duration(ts.Nano())
+}
+
+// ClockConverter converts kernel timestamps into wall-clock time
+//
+// CLOCK_MONOTONIC does not advance while the system is suspended, so the offset
+// between it and CLOCK_REALTIME grows after every suspend/resume (and on clock jumps).
+type ClockConverter struct {
+	// realtime - monotonic at the last calibration
+	offset time.Duration
+
+	// drift which triggers a recalibration
+	threshold time.Duration
+
+	// clock sources (replaceable for tests)
+	realtime  func() time.Time
+	monotonic func() time.Duration
+
+	lock *sync.Mutex
+}
+
+// NewClockConverter Function
+func NewClockConverter(threshold time.Duration) *ClockConverter {
+	cc := &ClockConverter{}
+
+	cc.threshold = threshold
+
+	cc.realtime = time.Now
+	cc.monotonic = getMonotonicTime
+
+	cc.lock = new(sync.Mutex)
+
+	cc.offset = cc.currentOffset()
+
+	return cc
+}
+
+// currentOffset Function
+func (cc *ClockConverter) currentOffset() time.Duration {
+	return time.Duration(cc.realtime().UnixNano()) - cc.monotonic()
+}
+
+// KtimeToWallTime converts a kernel timestamp (ns) into wall-clock time,
+// and returns true if the offset has been recalibrated for it
+func (cc *ClockConverter) KtimeToWallTime(ktime uint64) (time.Time, bool) {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+
+	// no kernel timestamp, use the current time
+	if ktime == 0 {
+		return cc.realtime(), false
+	}
+
+	resynced := false
+
+	// check the drift at emission time, events already emitted keep their timestamps
+	offset := cc.currentOffset()
+	if drift := offset - cc.offset; drift > cc.threshold || drift < -cc.threshold {
+		kg.Printf("Recalibrated the kernel clock offset (drift: %s)", drift)
+		cc.offset = offset
+		resynced = true
+	}
+
+	return time.Unix(0, int64(cc.offset)+int64(ktime)), resynced
+}
+
+// PeekWallTime converts a kernel timestamp (ns) into wall-clock time with the
+// current offset, without recalibrating it (the drift is checked by the logs)
+func (cc *ClockConverter) PeekWallTime(ktime uint64) time.Time {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+
+	// no kernel timestamp, use the current time
+	if ktime == 0 {
+		return cc.realtime()
+	}
+
+	return time.Unix(0, int64(cc.offset)+int64(ktime))
+}
+
+// GetDateTime converts a kernel timestamp into the timestamp formats of logs
+func (cc *ClockConverter) GetDateTime(ktime uint64) (int64, string, bool) {
+	tm, resynced := cc.KtimeToWallTime(ktime)
+	utc := tm.UTC()
+	return utc.Unix(), utc.Format(kl.TimeFormUTC), resynced
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClockConverter(t *testing.T) {
+	boot := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// fake clocks, the monotonic clock stops while the system is suspended
+	realtime := boot.Add(time.Hour)
+	monotonic := time.Hour
+
+	cc := &ClockConverter{
+		threshold: DefaultClockDriftThreshold,
+		realtime:  func() time.Time { return realtime },
+		monotonic: func() time.Duration { return monotonic },
+		lock:      new(sync.Mutex),
+	}
+	cc.offset = cc.currentOffset()
+
+	// an event 1s ago
+	tm, resynced := cc.KtimeToWallTime(uint64(monotonic - time.Second))
+	if resynced || !tm.Equal(realtime.Add(-time.Second)) {
+		t.Fatalf("[FAIL] Unexpected timestamp before suspend (%s, %t)", tm, resynced)
+	}
+
+	// a small drift is tolerated
+	realtime = realtime.Add(10*time.Second + 500*time.Millisecond)
+	monotonic += 10 * time.Second
+
+	if _, resynced := cc.KtimeToWallTime(uint64(monotonic)); resynced {
+		t.Errorf("[FAIL] Expected no recalibration for a small drift")
+	}
+
+	// suspend for 3 hours
+	realtime = realtime.Add(3*time.Hour + time.Second)
+	monotonic += time.Second
+
+	// peeking (e.g., by the replay of the events) leaves the recalibration to the log of the event
+	if tm := cc.PeekWallTime(uint64(monotonic)); tm.Equal(realtime) {
+		t.Errorf("[FAIL] Unexpected recalibration by a peek (%s)", tm)
+	}
+
+	tm, resynced = cc.KtimeToWallTime(uint64(monotonic))
+	if !resynced {
+		t.Errorf("[FAIL] Expected a recalibration after suspend/resume")
+	}
+	if !tm.Equal(realtime) {
+		t.Errorf("[FAIL] Expected a corrected timestamp (%s != %s)", tm, realtime)
+	}
+
+	// subsequent events are corrected without another recalibration
+	monotonic += time.Second
+	realtime = realtime.Add(time.Second)
+
+	_, updatedTime, resynced := cc.GetDateTime(uint64(monotonic))
+	if resynced {
+		t.Errorf("[FAIL] Expected no further recalibration")
+	}
+	if updatedTime != realtime.UTC().Format("2006-01-02T15:04:05.000000Z") {
+		t.Errorf("[FAIL] Unexpected timestamp after recalibration (%s)", updatedTime)
+	}
+
+	t.Log("[PASS] Converted kernel timestamps across suspend/resume")
+}
@@ -0,0 +1,368 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cilium/ebpf/link"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// =================== //
+// == Event Classes == //
+// =================== //
+
+// event classes whose probes are detached while nothing needs them
+// (the process events are always traced, they keep the process tree)
+const (
+	EventClassFile    = "file"
+	EventClassNetwork = "network"
+)
+
+// EventClasses are the event classes which can be detached
+var EventClasses = []string{EventClassFile, EventClassNetwork}
+
+// eventClassCheckInterval is the interval between the checks of the idle event classes
+var eventClassCheckInterval = 10 * time.Second
+
+// probeSpec Structure
+type probeSpec struct {
+	Name   string // key in Probes, and name of the program
+	Kind   string // kprobe, kretprobe, or tracepoint
+	Target string // kernel function, or category/event of a tracepoint
+	Class  string // event class (always attached if empty)
+}
+
+// EventClassState Structure
+type EventClassState struct {
+	Attached bool
+	Demanded bool
+	Probes   int
+
+	// time of the last attachment / detachment
+	Since time.Time
+
+	// time since which nothing needs the class
+	idleSince time.Time
+}
+
+// eventClassOf returns the event class of a system call
+func eventClassOf(syscallName string) string {
+	switch syscallName {
+	case "open", "openat", "unlink", "unlinkat", "rmdir", "chown", "fchownat",
+		"setxattr", "lsetxattr", "fsetxattr", "removexattr", "lremovexattr", "fremovexattr", "ioctl",
+		"sys_exit_openat", "security_file_open", "security_path_mknod", "security_path_unlink", "security_path_rmdir":
+		return EventClassFile
+	case "socket", "connect", "accept", "bind", "listen", "tcp_connect", "inet_csk_accept":
+		return EventClassNetwork
+	}
+	return ""
+}
+
+// getSystemMonitorProbes returns the probes of the system monitor
+func getSystemMonitorProbes() []probeSpec {
+	systemCalls := []string{"open", "openat", "execve", "execveat", "socket", "connect", "accept", "bind", "listen", "unlink", "unlinkat", "rmdir", "ptrace", "chown", "setuid", "setgid", "fchownat", "mount", "umount", "setxattr", "lsetxattr", "fsetxattr", "removexattr", "lremovexattr", "fremovexattr", "ioctl", "unshare", "setns"}
+	// {category, event}
+	sysTracepoints := [][2]string{{"syscalls", "sys_exit_openat"}}
+	sysKprobes := []string{"do_exit", "security_bprm_check", "security_file_open", "security_path_mknod", "security_path_unlink", "security_path_rmdir", "security_ptrace_access_check"}
+	// kernel functions traced on entry and return
+	sysRetKprobes := []string{"security_task_kill"}
+	netSyscalls := []string{"tcp_connect"}
+	netRetSyscalls := []string{"inet_csk_accept"}
+
+	probes := []probeSpec{}
+
+	for _, syscallName := range systemCalls {
+		probes = append(probes,
+			probeSpec{Name: "kprobe__" + syscallName, Kind: "kprobe", Target: "sys_" + syscallName, Class: eventClassOf(syscallName)},
+			probeSpec{Name: "kretprobe__" + syscallName, Kind: "kretprobe", Target: "sys_" + syscallName, Class: eventClassOf(syscallName)})
+	}
+	for _, sysTracepoint := range sysTracepoints {
+		probes = append(probes, probeSpec{Name: sysTracepoint[1], Kind: "tracepoint", Target: sysTracepoint[0] + "/" + sysTracepoint[1], Class: eventClassOf(sysTracepoint[1])})
+	}
+	for _, sysKprobe := range sysKprobes {
+		probes = append(probes, probeSpec{Name: "kprobe__" + sysKprobe, Kind: "kprobe", Target: sysKprobe, Class: eventClassOf(sysKprobe)})
+	}
+	for _, sysRetKprobe := range sysRetKprobes {
+		probes = append(probes,
+			probeSpec{Name: "kprobe__" + sysRetKprobe, Kind: "kprobe", Target: sysRetKprobe, Class: eventClassOf(sysRetKprobe)},
+			probeSpec{Name: "kretprobe__" + sysRetKprobe, Kind: "kretprobe", Target: sysRetKprobe, Class: eventClassOf(sysRetKprobe)})
+	}
+	for _, netSyscall := range netSyscalls {
+		probes = append(probes, probeSpec{Name: "kprobe__" + netSyscall, Kind: "kprobe", Target: netSyscall, Class: eventClassOf(netSyscall)})
+	}
+	for _, netRetSyscall := range netRetSyscalls {
+		probes = append(probes, probeSpec{Name: "kretprobe__" + netRetSyscall, Kind: "kretprobe", Target: netRetSyscall, Class: eventClassOf(netRetSyscall)})
+	}
+
+	return probes
+}
+
+// attachProbeLink attaches the program of a probe
+var attachProbeLink = func(mon *SystemMonitor, probe probeSpec) (link.Link, error) {
+	prog := mon.BpfModule.Programs[probe.Name]
+
+	switch probe.Kind {
+	case "kprobe":
+		return link.Kprobe(probe.Target, prog, nil)
+	case "kretprobe":
+		return link.Kretprobe(probe.Target, prog, nil)
+	case "tracepoint":
+		group, name, _ := strings.Cut(probe.Target, "/")
+		return link.Tracepoint(group, name, prog, nil)
+	}
+
+	return nil, fmt.Errorf("unknown probe type %s", probe.Kind)
+}
+
+// orderProbes orders the probes to attach (or reverses the order to detach), so that the return probes
+// never run without their entry probes (they look up the arguments saved by the entry probes)
+func orderProbes(probes []probeSpec, detach bool) []probeSpec {
+	ordered := make([]probeSpec, len(probes))
+	copy(ordered, probes)
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if detach {
+			return ordered[i].Kind == "kprobe" && ordered[j].Kind != "kprobe"
+		}
+		return ordered[i].Kind != "kprobe" && ordered[j].Kind == "kprobe"
+	})
+
+	return ordered
+}
+
+// initEventClasses Function
+func (mon *SystemMonitor) initEventClasses(probes []probeSpec) {
+	mon.ProbesLock.Lock()
+	defer mon.ProbesLock.Unlock()
+
+	mon.probeSpecs = probes
+	mon.EventClasses = map[string]*EventClassState{}
+
+	now := time.Now()
+
+	for _, class := range EventClasses {
+		state := &EventClassState{Attached: true, Demanded: true, Since: now}
+		for _, probe := range probes {
+			if probe.Class == class {
+				state.Probes++
+			}
+		}
+		mon.EventClasses[class] = state
+	}
+}
+
+// attachProbes attaches probes, and keeps them in Probes (ProbesLock should be held)
+func (mon *SystemMonitor) attachProbes(probes []probeSpec) error {
+	var attachErr error
+
+	for _, probe := range orderProbes(probes, false) {
+		probeLink, err := attachProbeLink(mon, probe)
+		if err != nil {
+			mon.Logger.Warnf("error loading %s %s: %v", probe.Kind, probe.Target, err)
+			if attachErr == nil {
+				attachErr = err
+			}
+			continue
+		}
+		mon.Probes[probe.Name] = probeLink
+	}
+
+	return attachErr
+}
+
+// detachProbes detaches probes (ProbesLock should be held)
+func (mon *SystemMonitor) detachProbes(probes []probeSpec) {
+	for _, probe := range orderProbes(probes, true) {
+		probeLink, ok := mon.Probes[probe.Name]
+		if !ok {
+			continue
+		}
+		if probeLink != nil {
+			if err := probeLink.Close(); err != nil {
+				mon.Logger.Warnf("error detaching %s %s: %v", probe.Kind, probe.Target, err)
+			}
+		}
+		delete(mon.Probes, probe.Name)
+	}
+}
+
+// getVisibilityDemand returns the event classes visible in a container on this node, or in the host
+func (mon *SystemMonitor) getVisibilityDemand() map[string]bool {
+	return mon.GetVisibilityDemand(nil, cfg.GlobalCfg.HostVisibility)
+}
+
+// GetVisibilityDemand returns the event classes which would be visible in a container on this node, or in the host,
+// with the given visibility of some namespaces (the other namespaces keep theirs) and of the host
+func (mon *SystemMonitor) GetVisibilityDemand(namespaces map[string]tp.Visibility, hostVisibility string) map[string]bool {
+	demand := map[string]bool{}
+
+	mon.BpfMapLock.RLock()
+	for namespace, val := range mon.NamespacePidsMap {
+		// no container of the namespace on this node
+		if len(val.NsKeys) == 0 || kl.ContainsElement(mon.UntrackedNamespaces, namespace) {
+			continue
+		}
+
+		file, network := val.File, val.Network
+		if visibility, ok := namespaces[namespace]; ok {
+			file, network = visibility.File, visibility.Network
+		}
+
+		demand[EventClassFile] = demand[EventClassFile] || file
+		demand[EventClassNetwork] = demand[EventClassNetwork] || network
+	}
+	mon.BpfMapLock.RUnlock()
+
+	if cfg.GlobalCfg.HostPolicy {
+		demand[EventClassFile] = demand[EventClassFile] || strings.Contains(hostVisibility, "file")
+		demand[EventClassNetwork] = demand[EventClassNetwork] || strings.Contains(hostVisibility, "network")
+	}
+
+	// the flow summaries are built from the network events
+	if mon.FlowTable != nil {
+		demand[EventClassNetwork] = true
+	}
+
+	return demand
+}
+
+// UpdateEventClasses attaches the probes of the event classes needed by the visibility or by the policies,
+// and detaches the probes of the classes which are idle for the detach delay
+func (mon *SystemMonitor) UpdateEventClasses(policyDemand map[string]bool) {
+	demand := mon.getVisibilityDemand()
+	for class, demanded := range policyDemand {
+		demand[class] = demand[class] || demanded
+	}
+
+	mon.ProbesLock.Lock()
+	defer mon.ProbesLock.Unlock()
+
+	// the probes are closed
+	if mon.probesClosed {
+		return
+	}
+
+	now := time.Now()
+
+	for _, class := range EventClasses {
+		state, ok := mon.EventClasses[class]
+		if !ok {
+			continue
+		}
+
+		probes := []probeSpec{}
+		for _, probe := range mon.probeSpecs {
+			if probe.Class == class {
+				probes = append(probes, probe)
+			}
+		}
+
+		state.Demanded = demand[class]
+
+		if state.Demanded {
+			state.idleSince = time.Time{}
+
+			if !state.Attached {
+				if err := mon.attachProbes(probes); err != nil {
+					mon.Logger.Warnf("Failed to attach some probes of the %s events (%s)", class, err.Error())
+				}
+				state.Attached = true
+				state.Since = now
+				mon.Logger.Printf("Attached the probes of the %s events", class)
+			}
+			continue
+		}
+
+		if !state.Attached {
+			continue
+		}
+
+		// keep the probes for a while (e.g., pods restarted in a rollout)
+		if state.idleSince.IsZero() {
+			state.idleSince = now
+		}
+		if now.Sub(state.idleSince) < cfg.GlobalCfg.ProbeDetachDelay {
+			continue
+		}
+
+		mon.detachProbes(probes)
+		state.Attached = false
+		state.Since = now
+		mon.Logger.Printf("Detached the probes of the %s events (no visibility or policy needs them)", class)
+	}
+}
+
+// RequestEventClassUpdate wakes up WatchEventClasses after a change of the visibility or of the policies
+func (mon *SystemMonitor) RequestEventClassUpdate() {
+	if mon.eventClassUpdate == nil {
+		return
+	}
+
+	select {
+	case mon.eventClassUpdate <- struct{}{}:
+	default: // an update is already requested
+	}
+}
+
+// WatchEventClasses keeps attaching (and detaching) the probes of the event classes on demand
+func (mon *SystemMonitor) WatchEventClasses(getPolicyDemand func() map[string]bool) {
+	if !cfg.GlobalCfg.DetachIdleProbes {
+		return
+	}
+
+	ticker := time.NewTicker(eventClassCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-StopChan:
+			return
+		case <-mon.eventClassUpdate:
+		case <-ticker.C:
+		}
+
+		mon.UpdateEventClasses(getPolicyDemand())
+	}
+}
+
+// GetEventClasses returns the state of the event classes
+func (mon *SystemMonitor) GetEventClasses() map[string]EventClassState {
+	mon.ProbesLock.Lock()
+	defer mon.ProbesLock.Unlock()
+
+	classes := map[string]EventClassState{}
+	for class, state := range mon.EventClasses {
+		classes[class] = *state
+	}
+
+	return classes
+}
+
+// closeProbes detaches all the probes
+func (mon *SystemMonitor) closeProbes() error {
+	mon.ProbesLock.Lock()
+	defer mon.ProbesLock.Unlock()
+
+	mon.probesClosed = true
+
+	for name, probeLink := range mon.Probes {
+		if probeLink == nil {
+			continue
+		}
+		if err := probeLink.Close(); err != nil {
+			return err
+		}
+		delete(mon.Probes, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cilium/ebpf/link"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// fakeLink is a probe which records its detachment
+type fakeLink struct {
+	link.Link
+
+	name   string
+	closed *[]string
+}
+
+func (l *fakeLink) Close() error {
+	*l.closed = append(*l.closed, l.name)
+	return nil
+}
+
+func TestEventClasses(t *testing.T) {
+	prevAttach, prevDelay, prevHostPolicy := attachProbeLink, cfg.GlobalCfg.ProbeDetachDelay, cfg.GlobalCfg.HostPolicy
+	defer func() {
+		attachProbeLink, cfg.GlobalCfg.ProbeDetachDelay, cfg.GlobalCfg.HostPolicy = prevAttach, prevDelay, prevHostPolicy
+	}()
+
+	attached, closed := []string{}, []string{}
+	attachProbeLink = func(mon *SystemMonitor, probe probeSpec) (link.Link, error) {
+		attached = append(attached, probe.Name)
+		return &fakeLink{name: probe.Name, closed: &closed}, nil
+	}
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	cfg.GlobalCfg.HostPolicy = false
+	cfg.GlobalCfg.ProbeDetachDelay = 0
+
+	mon := &SystemMonitor{Logger: &fd.Feeder{Node: &tp.Node{}}}
+	mon.BpfMapLock = new(sync.RWMutex)
+	mon.NamespacePidsMap = map[string]NsVisibility{}
+	mon.Probes = map[string]link.Link{}
+	mon.ProbesLock = new(sync.Mutex)
+
+	probes := getSystemMonitorProbes()
+	mon.initEventClasses(probes)
+	mon.ProbesLock.Lock()
+	_ = mon.attachProbes(probes)
+	mon.ProbesLock.Unlock()
+
+	if len(mon.Probes) != len(probes) {
+		t.Fatalf("[FAIL] Expected all the probes to be attached at first (%d/%d)", len(mon.Probes), len(probes))
+	}
+
+	// only the network events are visible on this node (a namespace without containers doesn't count)
+	mon.NamespacePidsMap["web"] = NsVisibility{NsKeys: []NsKey{{PidNS: 1, MntNS: 1}}, Process: true, Network: true}
+	mon.NamespacePidsMap["empty"] = NsVisibility{File: true}
+
+	mon.UpdateEventClasses(nil)
+
+	classes := mon.GetEventClasses()
+	if classes[EventClassFile].Attached || !classes[EventClassNetwork].Attached {
+		t.Fatalf("[FAIL] Expected the file probes to be detached (%+v)", classes)
+	}
+	if len(closed) != classes[EventClassFile].Probes {
+		t.Errorf("[FAIL] Unexpected number of detached probes (%d/%d)", len(closed), classes[EventClassFile].Probes)
+	}
+	for _, name := range []string{"kprobe__openat", "kretprobe__openat", "sys_exit_openat", "kprobe__security_file_open"} {
+		if _, ok := mon.Probes[name]; ok {
+			t.Errorf("[FAIL] Expected %s to be detached", name)
+		}
+	}
+	for _, name := range []string{"kprobe__execve", "kprobe__do_exit", "kprobe__connect", "kprobe__mount"} {
+		if _, ok := mon.Probes[name]; !ok {
+			t.Errorf("[FAIL] Expected %s to stay attached", name)
+		}
+	}
+
+	// the entry probes are detached before the return probes
+	entry, ret := -1, -1
+	for idx, name := range closed {
+		switch name {
+		case "kprobe__openat":
+			entry = idx
+		case "kretprobe__openat":
+			ret = idx
+		}
+	}
+	if entry < 0 || ret < entry {
+		t.Errorf("[FAIL] Expected the entry probe to be detached first (%v)", closed)
+	}
+
+	// a policy with file rules attaches the probes again, the return probes first
+	attached = []string{}
+	mon.UpdateEventClasses(map[string]bool{EventClassFile: true})
+
+	if classes := mon.GetEventClasses(); !classes[EventClassFile].Attached || !classes[EventClassFile].Demanded {
+		t.Fatalf("[FAIL] Expected the file probes to be attached again (%+v)", classes)
+	}
+	if len(attached) != classes[EventClassFile].Probes || attached[len(attached)-1][:8] != "kprobe__" || attached[0][:11] != "kretprobe__" {
+		t.Errorf("[FAIL] Unexpected attachment of the file probes (%v)", attached)
+	}
+
+	// the probes are kept during the detach delay
+	cfg.GlobalCfg.ProbeDetachDelay = time.Hour
+	mon.UpdateEventClasses(nil)
+
+	if classes := mon.GetEventClasses(); !classes[EventClassFile].Attached || classes[EventClassFile].Demanded {
+		t.Errorf("[FAIL] Expected the idle file probes to be kept for a while (%+v)", classes)
+	}
+
+	// the visibility of a namespace needs the probes as well
+	mon.NamespacePidsMap["web"] = NsVisibility{NsKeys: []NsKey{{PidNS: 1, MntNS: 1}}, File: true}
+	mon.UpdateEventClasses(nil)
+
+	if classes := mon.GetEventClasses(); !classes[EventClassFile].Demanded || classes[EventClassNetwork].Demanded {
+		t.Errorf("[FAIL] Unexpected demand of the event classes (%+v)", classes)
+	}
+
+	// nothing is attached once the probes are closed
+	if err := mon.closeProbes(); err != nil {
+		t.Fatalf("[FAIL] Failed to close the probes (%s)", err.Error())
+	}
+
+	attached = []string{}
+	cfg.GlobalCfg.ProbeDetachDelay = 0
+	mon.UpdateEventClasses(nil)
+	mon.UpdateEventClasses(map[string]bool{EventClassFile: true, EventClassNetwork: true})
+
+	if len(attached) != 0 || len(mon.Probes) != 0 {
+		t.Errorf("[FAIL] Unexpected probes after closing them (%v)", attached)
+	}
+
+	t.Log("[PASS] Attached the probes of the event classes on demand")
+}
+
+// BenchmarkFileEvents measures the cost of file operations with the file probes attached and detached
+// (it needs root and the BPF objects, e.g., sudo go test -run - -bench FileEvents ./monitor/)
+func BenchmarkFileEvents(b *testing.B) {
+	if os.Geteuid() != 0 {
+		b.Skip("[SKIP] The probes need root")
+	}
+
+	prevPolicy, prevHostPolicy, prevDelay := cfg.GlobalCfg.Policy, cfg.GlobalCfg.HostPolicy, cfg.GlobalCfg.ProbeDetachDelay
+	defer func() {
+		cfg.GlobalCfg.Policy, cfg.GlobalCfg.HostPolicy, cfg.GlobalCfg.ProbeDetachDelay = prevPolicy, prevHostPolicy, prevDelay
+	}()
+
+	cfg.GlobalCfg.Policy, cfg.GlobalCfg.HostPolicy = true, false
+	cfg.GlobalCfg.ProbeDetachDelay = 0
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	node := tp.Node{}
+	nodeLock := new(sync.RWMutex)
+	containers := map[string]tp.Container{}
+	containersLock := new(sync.RWMutex)
+	pidMap := map[string]tp.PidMap{}
+	pidMapLock := new(sync.RWMutex)
+	monitorLock := new(sync.RWMutex)
+
+	mon := NewSystemMonitor(&node, &nodeLock, &fd.Feeder{Node: &node}, &containers, &containersLock, &pidMap, &pidMapLock, &monitorLock)
+	if err := mon.InitBPF(); err != nil {
+		b.Skipf("[SKIP] Unable to load the system monitor (%s)", err.Error())
+	}
+	defer func() { _ = mon.DestroySystemMonitor() }()
+
+	path := filepath.Join(b.TempDir(), "data")
+	if err := os.WriteFile(path, []byte("data"), 0600); err != nil {
+		b.Fatal(err)
+	}
+
+	// a file-heavy workload
+	openFiles := func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			file, err := os.Open(filepath.Clean(path))
+			if err != nil {
+				b.Fatal(err)
+			}
+			_ = file.Close()
+		}
+	}
+
+	mon.UpdateEventClasses(map[string]bool{EventClassFile: true})
+	b.Run("attached", openFiles)
+
+	// no visibility and no policy needs the file events
+	mon.UpdateEventClasses(nil)
+	b.Run("detached", openFiles)
+}
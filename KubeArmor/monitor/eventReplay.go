@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"bytes"
+	"time"
+)
+
+// ================== //
+// == Event Replay == //
+// ================== //
+
+var (
+	// eventReplayWindow is the time the events of the containers which are not known yet are replayed (with
+	// -eventReplay) since they were emitted. The containers are registered from the events of their runtime, within
+	// a second or two of their first processes, so the events still unknown afterwards come from the containers
+	// which failed to be registered (reported with -strictAttribution instead) or from the processes outside any
+	// container, and replaying them longer would only loop them through the syscall channel.
+	eventReplayWindow = 5 * time.Second
+
+	// eventReplayDelay is the delay before an event is sent back to the syscall channel
+	eventReplayDelay = 1 * time.Second
+
+	// replayWarnInterval is the minimum interval between the warnings of the events dropped after their replay
+	replayWarnInterval = time.Minute
+)
+
+// eventReplay Structure tells when the replay of the events of unknown containers ends, and rate-limits the
+// warnings of the events dropped then (used by the replay loop only)
+type eventReplay struct {
+	timeout time.Duration
+
+	// nil without a clock converter, the kernel timestamps being compared with CLOCK_MONOTONIC then
+	clock     *ClockConverter
+	monotonic func() time.Duration
+
+	// events dropped since the last warning
+	dropped  uint64
+	lastWarn time.Time
+}
+
+// newEventReplay Function
+func newEventReplay(clock *ClockConverter, timeout time.Duration) *eventReplay {
+	return &eventReplay{timeout: timeout, clock: clock, monotonic: getMonotonicTime}
+}
+
+// expired checks if the replay of an event emitted at the given kernel timestamp is over, without recalibrating
+// the clock converter (the drift is left to the log of the event)
+func (er *eventReplay) expired(ktime uint64, now time.Time) bool {
+	if er.clock == nil {
+		return ktime != 0 && time.Duration(int64(er.monotonic())-int64(ktime)) > er.timeout
+	}
+	return now.After(er.clock.PeekWallTime(ktime).Add(er.timeout))
+}
+
+// drop counts a dropped event, and returns true with the number of the events dropped since the last warning if a
+// warning is due
+func (er *eventReplay) drop(now time.Time) (bool, uint64) {
+	er.dropped++
+
+	if !er.lastWarn.IsZero() && now.Sub(er.lastWarn) < replayWarnInterval {
+		return false, 0
+	}
+
+	dropped := er.dropped
+
+	er.dropped = 0
+	er.lastWarn = now
+
+	return true, dropped
+}
+
+// replayEvents Function sends the events of the containers which are not known yet back to the syscall channel
+// until the end of eventReplayWindow
+func (mon *SystemMonitor) replayEvents(replayChannel chan []byte) {
+	replay := newEventReplay(mon.Clock, eventReplayWindow)
+
+	for {
+		dataRaw, valid := <-replayChannel
+		if !valid {
+			continue
+		}
+		mon.replayEvent(replay, dataRaw)
+	}
+}
+
+// replayEvent Function replays an event, or drops it (or reports it with -strictAttribution) once its replay is over
+func (mon *SystemMonitor) replayEvent(replay *eventReplay, dataRaw []byte) {
+	dataBuff := bytes.NewBuffer(dataRaw)
+	ctx, err := readContextFromBuff(dataBuff)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	if replay.expired(ctx.Ts, now) {
+		// the container of the event is still unknown once replayed
+		if mon.Unattributed != nil {
+			args, _ := GetArgs(dataBuff, ctx.Argnum)
+			mon.ReportUnattributedActivity(ctx, args)
+			return
+		}
+
+		if warn, dropped := replay.drop(now); warn {
+			mon.Logger.Warnf("Dropped %d events of unknown containers after replaying them for %s", dropped, replay.timeout)
+		}
+		return
+	}
+
+	// Best effort replay
+	go func() {
+		time.Sleep(eventReplayDelay)
+		select {
+		case mon.SyscallChannel <- dataRaw:
+		default:
+			// channel is full, wait for a short time before retrying
+			time.Sleep(1 * time.Second)
+			mon.Logger.Warn("Event droped due to busy event channel")
+		}
+	}()
+}
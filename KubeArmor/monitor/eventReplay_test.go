@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"testing"
+	"time"
+
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestEventReplay(t *testing.T) {
+	boot := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	realtime := boot.Add(time.Hour)
+	monotonic := time.Hour
+
+	cc := &ClockConverter{
+		threshold: DefaultClockDriftThreshold,
+		realtime:  func() time.Time { return realtime },
+		monotonic: func() time.Duration { return monotonic },
+		lock:      new(sync.Mutex),
+	}
+	cc.offset = cc.currentOffset()
+
+	replay := newEventReplay(cc, 5*time.Second)
+
+	// events replayed up to the timeout
+	if replay.expired(uint64(monotonic-4*time.Second), realtime) {
+		t.Errorf("[FAIL] Unexpected end of the replay of an event of 4s ago")
+	}
+	if !replay.expired(uint64(monotonic-6*time.Second), realtime) {
+		t.Errorf("[FAIL] Expected the end of the replay of an event of 6s ago")
+	}
+
+	// after a suspend, the offset is left to be recalibrated by the log of the event
+	realtime = realtime.Add(time.Hour)
+	replay.expired(uint64(monotonic), realtime)
+
+	if _, resynced := cc.KtimeToWallTime(uint64(monotonic)); !resynced {
+		t.Errorf("[FAIL] Expected the recalibration to be left to the log of the event")
+	}
+
+	// without a clock converter, with CLOCK_MONOTONIC
+	replay = newEventReplay(nil, 5*time.Second)
+	replay.monotonic = func() time.Duration { return monotonic }
+
+	if replay.expired(uint64(monotonic-4*time.Second), time.Time{}) || !replay.expired(uint64(monotonic-6*time.Second), time.Time{}) {
+		t.Errorf("[FAIL] Unexpected end of the replay without a clock converter")
+	}
+
+	// a warning per interval, with the events dropped since the last one
+	now := realtime
+	if warn, dropped := replay.drop(now); !warn || dropped != 1 {
+		t.Errorf("[FAIL] Expected a warning for the first dropped event (%t, %d)", warn, dropped)
+	}
+	for idx := 0; idx < 9; idx++ {
+		if warn, _ := replay.drop(now.Add(time.Second)); warn {
+			t.Errorf("[FAIL] Unexpected warning within the interval")
+		}
+	}
+	if warn, dropped := replay.drop(now.Add(replayWarnInterval)); !warn || dropped != 10 {
+		t.Errorf("[FAIL] Expected a warning with the events dropped in the interval (%t, %d)", warn, dropped)
+	}
+
+	t.Log("[PASS] Replayed the events of unknown containers up to the timeout")
+}
+
+func TestReplayEvent(t *testing.T) {
+	prevDelay := eventReplayDelay
+	defer func() {
+		eventReplayDelay = prevDelay
+	}()
+	eventReplayDelay = 10 * time.Millisecond
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	logger := &fd.Feeder{Node: &tp.Node{NodeName: "worker-1"}}
+	logger.Output = "none"
+
+	mon := &SystemMonitor{Logger: logger, SyscallChannel: make(chan []byte, 4)}
+
+	monotonic := time.Hour
+
+	replay := newEventReplay(nil, eventReplayWindow)
+	replay.monotonic = func() time.Duration { return monotonic }
+
+	event := func(ts time.Duration) []byte {
+		buf := new(bytes.Buffer)
+		_ = binary.Write(buf, binary.LittleEndian, SyscallContext{Ts: uint64(ts), EventID: SysExecve})
+		return buf.Bytes()
+	}
+
+	// sent back to the syscall channel within the window, for the containers registered late
+	mon.replayEvent(replay, event(monotonic-time.Second))
+
+	select {
+	case <-mon.SyscallChannel:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("[FAIL] Expected the event to be replayed within the window")
+	}
+
+	// dropped with a warning once the window is over
+	mon.replayEvent(replay, event(monotonic-eventReplayWindow-time.Second))
+
+	select {
+	case <-mon.SyscallChannel:
+		t.Errorf("[FAIL] Unexpected replay of an event after the window")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if replay.lastWarn.IsZero() || replay.dropped != 0 {
+		t.Errorf("[FAIL] Expected a warning for the dropped event (%+v)", replay)
+	}
+
+	t.Log("[PASS] Replayed the events of unknown containers within the window only")
+}
@@ -0,0 +1,198 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ================== //
+// == Exec Session == //
+// ================== //
+
+// maxSessionDepth is the maximum number of ancestors looked up to find the session of a process
+const maxSessionDepth = 32
+
+// maxSessionCacheSize is the maximum number of the sessions of the processes unknown to the process tree by default
+const maxSessionCacheSize = 65536
+
+// runtimeShims are the names (comm) of the processes starting the processes of containers, both the main process
+// and the processes of kubectl exec (runc exec / containerd-shim exec / conmon exec)
+var runtimeShims = []string{"containerd-shim", "docker-containe", "conmon", "runc", "crun"}
+
+// sessionKey Structure
+type sessionKey struct {
+	ContainerID string
+	HostPID     uint32
+}
+
+// readProcStat returns the name (comm) and the parent of a process on the host
+var readProcStat = func(hostPid uint32) (string, uint32, error) {
+	data, err := os.ReadFile(kl.GetHostProcPath(strconv.FormatUint(uint64(hostPid), 10), "stat"))
+	if err != nil {
+		return "", 0, err
+	}
+
+	// pid (comm) state ppid ... (comm may contain spaces and parentheses)
+	stat := string(data)
+	start, end := strings.Index(stat, "("), strings.LastIndex(stat, ")")
+	if start < 0 || end < start {
+		return "", 0, errors.New("malformed stat of " + strconv.FormatUint(uint64(hostPid), 10))
+	}
+
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		return "", 0, errors.New("malformed stat of " + strconv.FormatUint(uint64(hostPid), 10))
+	}
+
+	ppid, err := strconv.ParseUint(fields[1], 10, 32)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return stat[start+1 : end], uint32(ppid), nil
+}
+
+// readProcCgroup returns the cgroups of a process on the host
+var readProcCgroup = func(hostPid uint32) string {
+	data, err := os.ReadFile(kl.GetHostProcPath(strconv.FormatUint(uint64(hostPid), 10), "cgroup"))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// isRuntimeShim checks if a process is a runtime shim
+func isRuntimeShim(comm string) bool {
+	for _, shim := range runtimeShims {
+		if strings.HasPrefix(comm, shim) {
+			return true
+		}
+	}
+	return false
+}
+
+// inContainerCgroup checks if a process is in the cgroup of a container (assumed so if the cgroups can't be read)
+func inContainerCgroup(hostPid uint32, containerID string) bool {
+	cgroup := readProcCgroup(hostPid)
+	return cgroup == "" || strings.Contains(cgroup, containerID)
+}
+
+// getContainerPid returns the host pid of the main process of a container
+func (mon *SystemMonitor) getContainerPid(containerID string) uint32 {
+	if mon.Containers == nil || mon.ContainersLock == nil {
+		return 0
+	}
+
+	Containers := *(mon.Containers)
+	ContainersLock := *(mon.ContainersLock)
+
+	ContainersLock.RLock()
+	defer ContainersLock.RUnlock()
+
+	return Containers[containerID].Pid
+}
+
+// lookupSession returns the session of a process known to the process tree (or resolved before)
+func (mon *SystemMonitor) lookupSession(containerID string, hostPid uint32) (string, bool) {
+	ActiveHostPidMap := *(mon.ActiveHostPidMap)
+	ActivePidMapLock := *(mon.ActivePidMapLock)
+
+	ActivePidMapLock.Lock()
+	if pidMap, ok := ActiveHostPidMap[containerID]; ok {
+		if node, ok := pidMap[hostPid]; ok {
+			ActivePidMapLock.Unlock()
+			return node.Session, true
+		}
+	}
+	ActivePidMapLock.Unlock()
+
+	mon.sessionCacheLock.RLock()
+	defer mon.sessionCacheLock.RUnlock()
+
+	session, ok := mon.sessionCache[sessionKey{ContainerID: containerID, HostPID: hostPid}]
+	return session, ok
+}
+
+// resolveSession walks the ancestors of a process on the host up to the process started by the runtime;
+// the processes started next to the main process of the container are in exec sessions
+func (mon *SystemMonitor) resolveSession(containerID string, hostPid uint32) string {
+	containerPid := mon.getContainerPid(containerID)
+
+	pid := hostPid
+	for depth := 0; depth < maxSessionDepth; depth++ {
+		if containerPid != 0 && pid == containerPid {
+			return "" // under the main process
+		}
+
+		if depth > 0 {
+			if session, ok := mon.lookupSession(containerID, pid); ok {
+				return session
+			}
+		}
+
+		_, ppid, err := readProcStat(pid)
+		if err != nil || ppid <= 1 {
+			return "" // exited, or orphaned
+		}
+
+		parentComm, _, err := readProcStat(ppid)
+		if err != nil {
+			return ""
+		}
+
+		if isRuntimeShim(parentComm) {
+			if containerPid == 0 || !inContainerCgroup(pid, containerID) {
+				return ""
+			}
+			return tp.ExecSession
+		}
+
+		pid = ppid
+	}
+
+	return ""
+}
+
+// GetSession returns the session of a process in a container (exec for the processes of kubectl exec),
+// inherited from the parent process across fork and exec
+func (mon *SystemMonitor) GetSession(containerID string, hostPid, hostPPid uint32) string {
+	if containerID == "" {
+		return ""
+	}
+
+	if session, ok := mon.lookupSession(containerID, hostPid); ok {
+		return session
+	}
+
+	if session, ok := mon.lookupSession(containerID, hostPPid); ok {
+		return session
+	}
+
+	session := mon.resolveSession(containerID, hostPid)
+
+	mon.sessionCacheLock.Lock()
+	defer mon.sessionCacheLock.Unlock()
+
+	if len(mon.sessionCache) >= mon.sessionCacheSize {
+		mon.sessionCache = map[sessionKey]string{}
+	}
+	mon.sessionCache[sessionKey{ContainerID: containerID, HostPID: hostPid}] = session
+
+	return session
+}
+
+// forgetSession removes the session of an exited process
+func (mon *SystemMonitor) forgetSession(containerID string, hostPid uint32) {
+	mon.sessionCacheLock.Lock()
+	defer mon.sessionCacheLock.Unlock()
+
+	delete(mon.sessionCache, sessionKey{ContainerID: containerID, HostPID: hostPid})
+}
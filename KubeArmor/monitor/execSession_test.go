@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// fakeProc is a process on the host as seen in /proc
+type fakeProc struct {
+	comm   string
+	ppid   uint32
+	cgroup string
+}
+
+// fakeProcs replaces /proc with the given processes
+func fakeProcs(procs map[uint32]fakeProc) func() {
+	prevStat, prevCgroup := readProcStat, readProcCgroup
+
+	readProcStat = func(hostPid uint32) (string, uint32, error) {
+		proc, ok := procs[hostPid]
+		if !ok {
+			return "", 0, errors.New("no such process")
+		}
+		return proc.comm, proc.ppid, nil
+	}
+	readProcCgroup = func(hostPid uint32) string {
+		return procs[hostPid].cgroup
+	}
+
+	return func() { readProcStat, readProcCgroup = prevStat, prevCgroup }
+}
+
+func TestExecSession(t *testing.T) {
+	const containerID = "3f4b6c1d2e"
+	const containerCgroup = "0::/kubepods/besteffort/pod-web/" + containerID + "\n"
+
+	// containerd-shim -> nginx (main process) -> worker, and containerd-shim -> runc exec -> bash (kubectl exec)
+	procs := map[uint32]fakeProc{
+		1:   {comm: "systemd", ppid: 0, cgroup: "0::/init.scope\n"},
+		100: {comm: "containerd-shim", ppid: 1, cgroup: "0::/system.slice/containerd.service\n"},
+		101: {comm: "nginx", ppid: 100, cgroup: containerCgroup},
+		102: {comm: "nginx", ppid: 101, cgroup: containerCgroup},
+		150: {comm: "runc", ppid: 100, cgroup: "0::/system.slice/containerd.service\n"},
+		200: {comm: "bash", ppid: 150, cgroup: containerCgroup},
+	}
+	defer fakeProcs(procs)()
+
+	node := tp.Node{}
+	nodeLock := new(sync.RWMutex)
+	containers := map[string]tp.Container{containerID: {ContainerID: containerID, Pid: 101}}
+	containersLock := new(sync.RWMutex)
+	activeHostPidMap := map[string]tp.PidMap{}
+	activePidMapLock := new(sync.RWMutex)
+	monitorLock := new(sync.RWMutex)
+
+	mon := NewSystemMonitor(&node, &nodeLock, nil, &containers, &containersLock, &activeHostPidMap, &activePidMapLock, &monitorLock)
+
+	if session := mon.GetSession(containerID, 102, 101); session != "" {
+		t.Errorf("[FAIL] Unexpected session of a worker of the main process (%q)", session)
+	}
+	if session := mon.GetSession(containerID, 101, 100); session != "" {
+		t.Errorf("[FAIL] Unexpected session of the main process (%q)", session)
+	}
+	if session := mon.GetSession(containerID, 200, 150); session != tp.ExecSession {
+		t.Errorf("[FAIL] Expected the shell of kubectl exec in an exec session (%q)", session)
+	}
+	if session := mon.GetSession("", 200, 150); session != "" {
+		t.Errorf("[FAIL] Unexpected session of a host process (%q)", session)
+	}
+
+	// bash is in the process tree, and its children inherit the session even once /proc doesn't tell anymore
+	mon.AddActivePid(containerID, mon.BuildPidNode(containerID, SyscallContext{HostPID: 200, HostPPID: 150}, "/bin/bash", []string{"bash"}))
+	delete(procs, 200)
+	delete(procs, 150)
+
+	child := mon.BuildPidNode(containerID, SyscallContext{HostPID: 201, HostPPID: 200}, "/usr/bin/curl", []string{"curl", "example.com"})
+	if child.Session != tp.ExecSession {
+		t.Errorf("[FAIL] Expected a child of the exec session to inherit the session (%q)", child.Session)
+	}
+	mon.AddActivePid(containerID, child)
+
+	// forked from curl without exec
+	if session := mon.GetSession(containerID, 202, 201); session != tp.ExecSession {
+		t.Errorf("[FAIL] Expected a fork in the exec session to inherit the session (%q)", session)
+	}
+
+	// a process started by the runtime outside of the container (e.g., a hook) isn't in an exec session
+	procs[300] = fakeProc{comm: "sh", ppid: 100, cgroup: "0::/system.slice/containerd.service\n"}
+	if session := mon.GetSession(containerID, 300, 100); session != "" {
+		t.Errorf("[FAIL] Unexpected session of a process out of the container (%q)", session)
+	}
+
+	t.Log("[PASS] Recognized the processes of the exec sessions")
+}
@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ================== //
+// == Flow Summary == //
+// ================== //
+
+// flow summary constants
+const (
+	DefaultFlowSummaryMaxFlows = 4096
+
+	// resource of the summary logs
+	FlowSummaryResource = "flow-summary"
+
+	// destination of the flows beyond the size of the table
+	FlowSummaryOther = "other"
+)
+
+// flowKey Structure
+type flowKey struct {
+	ContainerID string
+	Protocol    string
+	Destination string
+	Port        string
+}
+
+// flowRecord Structure
+type flowRecord struct {
+	// the log of the first connection, used as the base of the summary
+	Log tp.Log
+
+	Connections uint64
+}
+
+// FlowTable aggregates outgoing connections per (container, protocol, destination, port)
+type FlowTable struct {
+	// maximum number of flows, the others are summarized in a single bucket
+	MaxFlows int
+
+	flows     map[flowKey]*flowRecord
+	other     *flowRecord
+	since     time.Time
+	flowsLock *sync.Mutex
+}
+
+// NewFlowTable Function
+func NewFlowTable(maxFlows int) *FlowTable {
+	if maxFlows <= 0 {
+		maxFlows = DefaultFlowSummaryMaxFlows
+	}
+
+	ft := &FlowTable{}
+
+	ft.MaxFlows = maxFlows
+
+	ft.flows = map[flowKey]*flowRecord{}
+	ft.since = time.Now()
+	ft.flowsLock = new(sync.Mutex)
+
+	return ft
+}
+
+// Len Function
+func (ft *FlowTable) Len() int {
+	ft.flowsLock.Lock()
+	defer ft.flowsLock.Unlock()
+
+	return len(ft.flows)
+}
+
+// Record counts an outgoing connection
+func (ft *FlowTable) Record(log tp.Log, protocol, destination, port string) {
+	key := flowKey{ContainerID: log.ContainerID, Protocol: protocol, Destination: destination, Port: port}
+
+	ft.flowsLock.Lock()
+	defer ft.flowsLock.Unlock()
+
+	record, ok := ft.flows[key]
+	if !ok {
+		if len(ft.flows) >= ft.MaxFlows {
+			if ft.other == nil {
+				ft.other = &flowRecord{}
+			}
+			ft.other.Connections++
+			return
+		}
+
+		record = &flowRecord{Log: log}
+		ft.flows[key] = record
+	}
+
+	record.Connections++
+}
+
+// summaryLog builds the summary log of a flow
+func summaryLog(record *flowRecord, key flowKey, interval time.Duration) tp.Log {
+	log := record.Log
+
+	log.Timestamp, log.UpdatedTime = kl.GetDateTimeNow()
+
+	if log.ContainerID != "" {
+		log.Type = "ContainerLog"
+	} else {
+		log.Type = "HostLog"
+	}
+
+	// the processes vary across the connections of a flow
+	log.HostPPID, log.HostPID, log.PPID, log.PID = 0, 0, 0, 0
+
+	log.Operation = "Network"
+	log.Resource = FlowSummaryResource
+	log.Data = "protocol=" + key.Protocol + " remoteip=" + key.Destination + " port=" + key.Port +
+		" connections=" + strconv.FormatUint(record.Connections, 10) + " interval=" + interval.Round(time.Second).String()
+	log.Result = "Passed"
+
+	return log
+}
+
+// Flush returns the summaries of the flows since the last flush, and resets the counters
+func (ft *FlowTable) Flush() []tp.Log {
+	ft.flowsLock.Lock()
+	flows := ft.flows
+	other := ft.other
+	since := ft.since
+
+	ft.flows = map[flowKey]*flowRecord{}
+	ft.other = nil
+	ft.since = time.Now()
+	ft.flowsLock.Unlock()
+
+	interval := time.Since(since)
+
+	logs := []tp.Log{}
+
+	for key, record := range flows {
+		logs = append(logs, summaryLog(record, key, interval))
+	}
+
+	if other != nil {
+		// the flows of any containers and processes
+		other.Log.Source = FlowSummaryOther
+		other.Log.ProcessName = FlowSummaryOther
+		logs = append(logs, summaryLog(other, flowKey{Protocol: FlowSummaryOther, Destination: FlowSummaryOther, Port: FlowSummaryOther}, interval))
+	}
+
+	return logs
+}
+
+// SummarizeFlows Function
+func (mon *SystemMonitor) SummarizeFlows() {
+	if mon.FlowTable == nil || cfg.GlobalCfg.FlowSummaryInterval <= 0 {
+		return
+	}
+
+	MonitorLock := *(mon.MonitorLock)
+
+	for {
+		time.Sleep(cfg.GlobalCfg.FlowSummaryInterval)
+
+		// read monitor status
+		MonitorLock.RLock()
+		monStatus := mon.Status
+		MonitorLock.RUnlock()
+
+		if !monStatus {
+			break
+		}
+
+		for _, log := range mon.FlowTable.Flush() {
+			mon.Logger.PushSummaryLog(log)
+		}
+	}
+}
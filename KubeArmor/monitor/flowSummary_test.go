@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// countConnections returns the number of connections recorded in a flow table
+func countConnections(ft *FlowTable) uint64 {
+	ft.flowsLock.Lock()
+	defer ft.flowsLock.Unlock()
+
+	count := uint64(0)
+	for _, record := range ft.flows {
+		count += record.Connections
+	}
+	if ft.other != nil {
+		count += ft.other.Connections
+	}
+	return count
+}
+
+func TestFlowSummary(t *testing.T) {
+	cfg.GlobalCfg.FlowSummaryInterval = time.Minute
+	cfg.GlobalCfg.FlowSummaryMaxFlows = 2
+	defer func() {
+		cfg.GlobalCfg.FlowSummaryInterval = 0
+		cfg.GlobalCfg.FlowSummaryMaxFlows = 0
+	}()
+
+	node := tp.Node{}
+	nodeLock := new(sync.RWMutex)
+	containers := map[string]tp.Container{"nginx": {ContainerID: "nginx", NamespaceName: "default", EndPointName: "nginx-pod"}}
+	containersLock := new(sync.RWMutex)
+	activeHostPidMap := map[string]tp.PidMap{}
+	activePidMapLock := new(sync.RWMutex)
+	monitorLock := new(sync.RWMutex)
+
+	// raw logs are not pushed anywhere, only the summaries are checked
+	mon := NewSystemMonitor(&node, &nodeLock, nil, &containers, &containersLock, &activeHostPidMap, &activePidMapLock, &monitorLock)
+	if mon.FlowTable == nil {
+		t.Fatal("[FAIL] Expected a flow table")
+	}
+
+	StopChan = make(chan struct{})
+	defer close(StopChan)
+
+	go mon.UpdateLogs()
+
+	connect := func(containerID string, eventID int32, addr, port string, retval int64) {
+		msg := ContextCombined{ContainerID: containerID}
+		msg.ContextSys.EventID = eventID
+		msg.ContextSys.Retval = retval
+		msg.ContextArgs = []interface{}{"TCP", map[string]string{"sa_family": "AF_INET", "sin_addr": addr, "sin_port": port}}
+		mon.ContextChan <- msg
+	}
+
+	for i := 0; i < 1243; i++ {
+		connect("nginx", TCPConnect, "10.0.2.5", "5432", 0)
+	}
+	connect("nginx", TCPConnectv6, "fd00::1", "443", 0)
+
+	// accepted and failed connections are not counted
+	connect("nginx", TCPAccept, "10.0.2.9", "8080", 0)
+	connect("nginx", TCPConnect, "10.0.2.6", "5432", -111)
+
+	// beyond the size of the table
+	connect("", TCPConnect, "1.1.1.1", "53", 0)
+	connect("", TCPConnect, "8.8.8.8", "53", 0)
+
+	for i := 0; countConnections(mon.FlowTable) != 1246; i++ {
+		if i == 100 {
+			t.Fatalf("[FAIL] Expected 1246 connections (%d)", countConnections(mon.FlowTable))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	logs := mon.FlowTable.Flush()
+	if len(logs) != 3 {
+		t.Fatalf("[FAIL] Expected 3 summaries (%+v)", logs)
+	}
+
+	summaries := map[string]tp.Log{}
+	for _, log := range logs {
+		if log.Operation != "Network" || log.Resource != FlowSummaryResource || log.PID != 0 {
+			t.Errorf("[FAIL] Unexpected summary (%+v)", log)
+		}
+		summaries[log.Data[:len(log.Data)-len(" interval=0s")]] = log
+	}
+
+	if log, ok := summaries["protocol=TCP remoteip=10.0.2.5 port=5432 connections=1243"]; !ok || log.Type != "ContainerLog" || log.NamespaceName != "default" || log.PodName != "nginx-pod" {
+		t.Errorf("[FAIL] Expected the summary of the connections to 10.0.2.5:5432 (%+v)", summaries)
+	}
+	if _, ok := summaries["protocol=TCP remoteip=fd00::1 port=443 connections=1"]; !ok {
+		t.Errorf("[FAIL] Expected the summary of the connections to [fd00::1]:443 (%+v)", summaries)
+	}
+	if log, ok := summaries["protocol=other remoteip=other port=other connections=2"]; !ok || log.Type != "HostLog" {
+		t.Errorf("[FAIL] Expected the overflow to be summarized together (%+v)", summaries)
+	}
+
+	// the counters restart per flush
+	if logs := mon.FlowTable.Flush(); len(logs) != 0 {
+		t.Errorf("[FAIL] Expected no summaries after a flush (%+v)", logs)
+	}
+
+	t.Log("[PASS] Summarized the outgoing connections per destination")
+}
@@ -5,6 +5,7 @@ package monitor
 
 import (
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
@@ -34,6 +35,8 @@ func (mon *SystemMonitor) UpdateContainerInfoByContainerID(log tp.Log) tp.Log {
 		// update container info
 		log.ContainerName = val.ContainerName
 		log.ContainerImage = val.ContainerImage
+		log.ContainerType = val.ContainerType
+		log.Privileged = val.Privileged
 
 		// get merged directory
 		log.MergedDir = val.MergedDir
@@ -46,6 +49,7 @@ func (mon *SystemMonitor) UpdateContainerInfoByContainerID(log tp.Log) tp.Log {
 		log.FileVisibilityEnabled = val.FileVisibilityEnabled
 		log.NetworkVisibilityEnabled = val.NetworkVisibilityEnabled
 		log.CapabilitiesVisibilityEnabled = val.CapabilitiesVisibilityEnabled
+		log.SignalVisibilityEnabled = val.SignalVisibilityEnabled
 	}
 
 	return log
@@ -55,10 +59,12 @@ func (mon *SystemMonitor) UpdateContainerInfoByContainerID(log tp.Log) tp.Log {
 func (mon *SystemMonitor) BuildLogBase(eventID int32, msg ContextCombined) tp.Log {
 	log := tp.Log{}
 
-	timestamp, updatedTime := kl.GetDateTimeNow()
+	if mon.Clock != nil {
+		log.Timestamp, log.UpdatedTime, log.ClockResync = mon.Clock.GetDateTime(msg.ContextSys.Ts)
+	} else {
+		log.Timestamp, log.UpdatedTime = kl.GetDateTimeNow()
+	}
 
-	log.Timestamp = timestamp
-	log.UpdatedTime = updatedTime
 	log.ContainerID = msg.ContainerID
 
 	if log.ContainerID != "" {
@@ -72,6 +78,7 @@ func (mon *SystemMonitor) BuildLogBase(eventID int32, msg ContextCombined) tp.Lo
 		log.FileVisibilityEnabled = mon.Node.FileVisibilityEnabled
 		log.NetworkVisibilityEnabled = mon.Node.NetworkVisibilityEnabled
 		log.CapabilitiesVisibilityEnabled = mon.Node.CapabilitiesVisibilityEnabled
+		log.SignalVisibilityEnabled = mon.Node.SignalVisibilityEnabled
 	}
 
 	log.HostPPID = int32(msg.ContextSys.HostPPID)
@@ -92,6 +99,8 @@ func (mon *SystemMonitor) BuildLogBase(eventID int32, msg ContextCombined) tp.Lo
 	log.ParentProcessName = mon.GetExecPath(msg.ContainerID, msg.ContextSys.HostPPID)
 	log.ProcessName = mon.GetExecPath(msg.ContainerID, msg.ContextSys.HostPID)
 
+	log.Session = mon.GetSession(msg.ContainerID, msg.ContextSys.HostPID, msg.ContextSys.HostPPID)
+
 	return log
 }
 
@@ -118,6 +127,183 @@ func (mon *SystemMonitor) UpdateLogBase(eventID int32, log tp.Log) tp.Log {
 	return log
 }
 
+// getFdPath Function
+func getFdPath(hostPid uint32, fd string) string {
+	if data, err := os.Readlink(kl.GetHostProcPath(strconv.FormatUint(uint64(hostPid), 10), "fd", fd)); err == nil {
+		return data
+	}
+	return ""
+}
+
+// connectionArgs returns the protocol and the remote address of a connection (TCP connect/accept kprobes)
+func connectionArgs(msg ContextCombined) (string, map[string]string) {
+	var protocol string
+	var sockAddr map[string]string
+
+	if val, ok := msg.ContextArgs[0].(string); ok {
+		protocol = val
+	}
+	if val, ok := msg.ContextArgs[1].(map[string]string); ok {
+		sockAddr = val
+	}
+
+	return protocol, sockAddr
+}
+
+// updateConnectionLog Function (TCP connect/accept kprobes)
+func updateConnectionLog(log tp.Log, msg ContextCombined) tp.Log {
+	protocol, sockAddr := connectionArgs(msg)
+
+	log.Operation = "Network"
+	log.Resource = "remoteip=" + sockAddr["sin_addr"] + " port=" + sockAddr["sin_port"] + " protocol=" + protocol
+	if msg.ContextSys.EventID == TCPConnect || msg.ContextSys.EventID == TCPConnectv6 {
+		log.Data = "kprobe=tcp_connect"
+	} else {
+		log.Data = "kprobe=tcp_accept"
+	}
+	log.Data = log.Data + " domain=" + sockAddr["sa_family"]
+
+	return log
+}
+
+// updateFileAttributeLog Function (SYS_*XATTR, SYS_IOCTL)
+func updateFileAttributeLog(log tp.Log, msg ContextCombined) tp.Log {
+	var fileName string
+	var fd string
+
+	switch msg.ContextSys.EventID {
+	case SysFSetxattr, SysFRemovexattr, SysIoctl:
+		if val, ok := msg.ContextArgs[0].(int32); ok {
+			fd = strconv.Itoa(int(val))
+			fileName = getFdPath(msg.ContextSys.HostPID, fd)
+		}
+	default:
+		if val, ok := msg.ContextArgs[0].(string); ok {
+			fileName = val
+		}
+	}
+
+	log.Operation = "File"
+	log.Resource = fileName
+	log.Data = "syscall=" + GetSyscallName(int32(msg.ContextSys.EventID))
+
+	if fd != "" {
+		log.Data = log.Data + " fd=" + fd
+	}
+
+	if msg.ContextSys.EventID == SysIoctl {
+		var fileFlags string
+		if val, ok := msg.ContextArgs[1].(string); ok {
+			fileFlags = val
+		}
+		log.Data = log.Data + " cmd=FS_IOC_SETFLAGS flags=" + fileFlags
+	} else {
+		var xattrName string
+		if val, ok := msg.ContextArgs[1].(string); ok {
+			xattrName = val
+		}
+		log.Data = log.Data + " xattr=" + xattrName
+	}
+
+	return log
+}
+
+// updateNamespaceLog Function (SYS_UNSHARE, SYS_SETNS), returns false for the namespaces other than net
+func updateNamespaceLog(log tp.Log, msg ContextCombined) (tp.Log, bool) {
+	log.Operation = "Process"
+	log.Resource = "namespace=net"
+	log.Data = "syscall=" + GetSyscallName(int32(msg.ContextSys.EventID))
+
+	if msg.ContextSys.EventID == SysUnshare {
+		var nsFlags string
+		if val, ok := msg.ContextArgs[0].(string); ok {
+			nsFlags = val
+		}
+		log.Data = log.Data + " flags=" + nsFlags
+
+		return log, strings.Contains(nsFlags, "CLONE_NEWNET")
+	}
+
+	var fd string
+	var nsType string
+
+	if val, ok := msg.ContextArgs[0].(int32); ok {
+		fd = strconv.Itoa(int(val))
+	}
+	if val, ok := msg.ContextArgs[1].(string); ok {
+		nsType = val
+	}
+
+	// the namespace of the file descriptor (e.g., net:[4026531840])
+	nsName := getFdPath(msg.ContextSys.HostPID, fd)
+
+	log.Data = log.Data + " fd=" + fd + " nstype=" + nsType
+
+	if nsName != "" {
+		log.Data = log.Data + " ns=" + nsName
+	}
+
+	return log, nsType == "CLONE_NEWNET" || strings.HasPrefix(nsName, "net:")
+}
+
+// updateSignalLog Function (SECURITY_TASK_KILL), names the target process and, when it's elsewhere, its container
+func (mon *SystemMonitor) updateSignalLog(log tp.Log, msg ContextCombined) tp.Log {
+	var sig, pid, ppid int32
+	var pidNS, mntNS uint32
+	var comm string
+
+	if val, ok := msg.ContextArgs[0].(int32); ok {
+		sig = val
+	}
+	if val, ok := msg.ContextArgs[1].(int32); ok {
+		pid = val
+	}
+	if val, ok := msg.ContextArgs[2].(int32); ok {
+		ppid = val
+	}
+	if val, ok := msg.ContextArgs[3].(int32); ok {
+		pidNS = uint32(val)
+	}
+	if val, ok := msg.ContextArgs[4].(int32); ok {
+		mntNS = uint32(val)
+	}
+	if val, ok := msg.ContextArgs[5].(string); ok {
+		comm = val
+	}
+
+	// the processes in the host (or in unknown containers) have no container ID
+	targetContainerID := mon.LookupContainerID(pidNS, mntNS, 0, 0)
+
+	log.Operation = "Signal"
+	log.Resource = mon.GetExecPath(targetContainerID, uint32(pid))
+	if log.Resource == "" {
+		log.Resource = comm
+	}
+
+	scope := "cross-container"
+	if targetContainerID == msg.ContainerID {
+		scope = "same"
+	} else if targetContainerID == "" {
+		scope = "host"
+	}
+
+	log.Data = "lsm=" + GetSyscallName(int32(msg.ContextSys.EventID)) + " signal=" + kl.GetSignalName(int(sig)) +
+		" targetPid=" + strconv.Itoa(int(pid)) + " comm=" + comm + " scope=" + scope
+
+	if uint32(pid) == msg.ContextSys.HostPID {
+		log.Data = log.Data + " relation=self"
+	} else if uint32(ppid) == msg.ContextSys.HostPID {
+		log.Data = log.Data + " relation=child"
+	}
+
+	if scope == "cross-container" {
+		target := mon.UpdateContainerInfoByContainerID(tp.Log{ContainerID: targetContainerID})
+		log.Data = log.Data + " targetNamespace=" + target.NamespaceName + " targetPod=" + target.PodName + " targetContainer=" + target.ContainerName
+	}
+
+	return log
+}
+
 // UpdateLogs Function
 func (mon *SystemMonitor) UpdateLogs() {
 	for {
@@ -274,6 +460,24 @@ func (mon *SystemMonitor) UpdateLogs() {
 				log.Resource = fileName
 				log.Data = "syscall=" + GetSyscallName(int32(msg.ContextSys.EventID)) + " userid=" + strconv.Itoa(uid) + " group=" + strconv.Itoa(guid) + " mode=" + strconv.Itoa(mode)
 
+			case SysSetxattr, SysLSetxattr, SysFSetxattr, SysRemovexattr, SysLRemovexattr, SysFRemovexattr, SysIoctl:
+				if len(msg.ContextArgs) != 2 {
+					continue
+				}
+
+				log = updateFileAttributeLog(log, msg)
+
+			case SysUnshare, SysSetns:
+				if (msg.ContextSys.EventID == SysUnshare && len(msg.ContextArgs) != 1) ||
+					(msg.ContextSys.EventID == SysSetns && len(msg.ContextArgs) != 2) {
+					continue
+				}
+
+				var ok bool
+				if log, ok = updateNamespaceLog(log, msg); !ok {
+					continue
+				}
+
 			case SysSetuid, SysSetgid:
 				if len(msg.ContextArgs) != 1 {
 					continue
@@ -329,8 +533,8 @@ func (mon *SystemMonitor) UpdateLogs() {
 				log.Operation = "Syscall"
 				log.Data = "syscall=" + GetSyscallName(int32(msg.ContextSys.EventID)) + " target=" + target + " flag=" + strconv.Itoa(flags)
 
-			case SysClose:
-				if len(msg.ContextArgs) != 1 {
+			case SysClose: // fd, socket
+				if len(msg.ContextArgs) != 2 {
 					continue
 				}
 
@@ -344,6 +548,11 @@ func (mon *SystemMonitor) UpdateLogs() {
 				log.Resource = ""
 				log.Data = "syscall=" + GetSyscallName(int32(msg.ContextSys.EventID)) + " fd=" + fd
 
+				// evict the socket (if any) closed with its last fd
+				if val, ok := msg.ContextArgs[1].(uint64); ok && val != 0 {
+					mon.SocketTracker.RemoveSocket(val)
+				}
+
 			case SysPtrace:
 				if len(msg.ContextArgs) != 3 {
 					continue
@@ -369,8 +578,8 @@ func (mon *SystemMonitor) UpdateLogs() {
 				log.Operation = "Process"
 				log.Data = "syscall=" + GetSyscallName(int32(msg.ContextSys.EventID)) + " request=" + request + " pid=" + pid + " process=" + binary
 
-			case SysSocket: // domain, type, proto
-				if len(msg.ContextArgs) != 3 {
+			case SysSocket: // domain, type, proto, socket
+				if len(msg.ContextArgs) != 4 {
 					continue
 				}
 
@@ -392,31 +601,26 @@ func (mon *SystemMonitor) UpdateLogs() {
 				log.Resource = "domain=" + sockDomain + " type=" + sockType + " protocol=" + GetProtocol(sockProtocol)
 				log.Data = "syscall=" + GetSyscallName(int32(msg.ContextSys.EventID))
 
+				// remember the creator of the socket
+				if val, ok := msg.ContextArgs[3].(uint64); ok && msg.ContextSys.Retval >= 0 {
+					mon.SocketTracker.AddSocket(val, SocketOwner{HostPID: msg.ContextSys.HostPID, ProcessName: log.ProcessName})
+				}
+
 			case TCPConnect, TCPConnectv6, TCPAccept, TCPAcceptv6:
 				if len(msg.ContextArgs) != 2 {
 					continue
 				}
-				var sockAddr map[string]string
-				var protocol string
-				if val, ok := msg.ContextArgs[0].(string); ok {
-					protocol = val
-				}
 
-				if val, ok := msg.ContextArgs[1].(map[string]string); ok {
-					sockAddr = val
-				}
+				log = updateConnectionLog(log, msg)
 
-				log.Operation = "Network"
-				log.Resource = "remoteip=" + sockAddr["sin_addr"] + " port=" + sockAddr["sin_port"] + " protocol=" + protocol
-				if msg.ContextSys.EventID == TCPConnect || msg.ContextSys.EventID == TCPConnectv6 {
-					log.Data = "kprobe=tcp_connect"
-				} else {
-					log.Data = "kprobe=tcp_accept"
+				// summarized per destination, whether the connections are logged or not
+				if mon.FlowTable != nil && (msg.ContextSys.EventID == TCPConnect || msg.ContextSys.EventID == TCPConnectv6) && msg.ContextSys.Retval >= 0 {
+					protocol, sockAddr := connectionArgs(msg)
+					mon.FlowTable.Record(log, protocol, sockAddr["sin_addr"], sockAddr["sin_port"])
 				}
-				log.Data = log.Data + " domain=" + sockAddr["sa_family"]
 
-			case SysConnect: // fd, sockaddr
-				if len(msg.ContextArgs) != 2 {
+			case SysConnect: // fd, sockaddr, socket
+				if len(msg.ContextArgs) != 3 {
 					continue
 				}
 
@@ -431,20 +635,21 @@ func (mon *SystemMonitor) UpdateLogs() {
 				}
 
 				log.Operation = "Network"
-				log.Resource = ""
+				log.Resource = formatSockaddr(sockAddr)
 
-				for k, v := range sockAddr {
-					if log.Resource == "" {
-						log.Resource = k + "=" + v
-					} else {
-						log.Resource = log.Resource + " " + k + "=" + v
-					}
+				// connections to the sockets of the container runtimes
+				if socket := mon.runtimeSocketOf(msg, sockAddr); socket != "" {
+					log.Resource = log.Resource + " runtime_socket=" + socket
 				}
 
 				log.Data = "syscall=" + GetSyscallName(int32(msg.ContextSys.EventID)) + " fd=" + fd
 
-			case SysAccept: // fd, sockaddr
-				if len(msg.ContextArgs) != 2 {
+				if val, ok := msg.ContextArgs[2].(uint64); ok {
+					log.SocketCreator = mon.SocketTracker.GetSocketCreatorIfDiffers(val, msg.ContextSys.HostPID, log.ProcessName)
+				}
+
+			case SysAccept: // fd, sockaddr, socket, accepted socket
+				if len(msg.ContextArgs) != 4 {
 					continue
 				}
 
@@ -459,15 +664,16 @@ func (mon *SystemMonitor) UpdateLogs() {
 				}
 
 				log.Operation = "Network"
-				log.Resource = ""
+				log.Resource = formatSockaddr(sockAddr)
 				log.Data = "syscall=" + GetSyscallName(int32(msg.ContextSys.EventID)) + " fd=" + fd
 
-				for k, v := range sockAddr {
-					if log.Resource == "" {
-						log.Resource = k + "=" + v
-					} else {
-						log.Resource = log.Resource + " " + k + "=" + v
-					}
+				if val, ok := msg.ContextArgs[2].(uint64); ok {
+					log.SocketCreator = mon.SocketTracker.GetSocketCreatorIfDiffers(val, msg.ContextSys.HostPID, log.ProcessName)
+				}
+
+				// the accepted socket belongs to the current process
+				if val, ok := msg.ContextArgs[3].(uint64); ok && msg.ContextSys.Retval >= 0 {
+					mon.SocketTracker.AddSocket(val, SocketOwner{HostPID: msg.ContextSys.HostPID, ProcessName: log.ProcessName})
 				}
 
 			case SysBind: // fd, sockaddr
@@ -486,15 +692,7 @@ func (mon *SystemMonitor) UpdateLogs() {
 				}
 
 				log.Operation = "Network"
-				log.Resource = ""
-
-				for k, v := range sockAddr {
-					if log.Resource == "" {
-						log.Resource = k + "=" + v
-					} else {
-						log.Resource = log.Resource + " " + k + "=" + v
-					}
-				}
+				log.Resource = formatSockaddr(sockAddr)
 
 				log.Data = "syscall=" + GetSyscallName(int32(msg.ContextSys.EventID)) + " fd=" + fd
 
@@ -513,6 +711,13 @@ func (mon *SystemMonitor) UpdateLogs() {
 				log.Resource = ""
 				log.Data = "syscall=" + GetSyscallName(int32(msg.ContextSys.EventID)) + " fd=" + fd
 
+			case SecurityTaskKill:
+				if len(msg.ContextArgs) != 6 {
+					continue
+				}
+
+				log = mon.updateSignalLog(log, msg)
+
 			default:
 				continue
 			}
@@ -0,0 +1,455 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// nsFlags are the namespace flags of unshare/setns
+type nsFlags uint32
+
+// encodeArgs encodes syscall arguments the way the system monitor does
+func encodeArgs(args ...interface{}) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+
+	for _, arg := range args {
+		switch val := arg.(type) {
+		case string:
+			_ = binary.Write(buf, binary.LittleEndian, strT)
+			_ = binary.Write(buf, binary.LittleEndian, int32(len(val)+1))
+			buf.WriteString(val)
+			buf.WriteByte(0)
+		case int32:
+			_ = binary.Write(buf, binary.LittleEndian, intT)
+			_ = binary.Write(buf, binary.LittleEndian, val)
+		case uint32: // inode flags
+			_ = binary.Write(buf, binary.LittleEndian, fileFlagsT)
+			_ = binary.Write(buf, binary.LittleEndian, val)
+		case nsFlags:
+			_ = binary.Write(buf, binary.LittleEndian, nsFlagsT)
+			_ = binary.Write(buf, binary.LittleEndian, uint32(val))
+		case uint64: // socket ids
+			_ = binary.Write(buf, binary.LittleEndian, sockIDT)
+			_ = binary.Write(buf, binary.LittleEndian, val)
+		}
+	}
+
+	return buf
+}
+
+func TestFileAttributeLogs(t *testing.T) {
+	// a file opened by this process, for the fd-based syscalls
+	file, err := os.CreateTemp("", "kubearmor-xattr")
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create a file (%s)", err.Error())
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	fileFd := int32(file.Fd())
+
+	// policies
+	logger := &feeder.Feeder{}
+	logger.SecurityPolicies = map[string]tp.MatchPolicies{}
+	logger.SecurityPoliciesLock = new(sync.RWMutex)
+	logger.DefaultPostures = map[string]tp.DefaultPosture{}
+	logger.DefaultPosturesLock = new(sync.Mutex)
+
+	secPolicy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "protect-attributes"}}
+	secPolicy.Spec.File.MatchXattrs = []tp.FileXattrType{
+		{Name: "security.*", Operations: []string{"set"}, Severity: 7, Action: "Block"},
+		{Name: "user.checksum", Path: "/etc/", FromSource: []tp.MatchSourceType{{Path: "/usr/bin/setfattr"}}, Severity: 3, Action: "Audit"},
+	}
+	secPolicy.Spec.File.MatchImmutable = []tp.FileImmutableType{
+		{Path: file.Name(), Severity: 5, Action: "Audit"},
+	}
+
+	endPoint := tp.EndPoint{NamespaceName: "default", EndPointName: "nginx", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{secPolicy}
+	logger.UpdateSecurityPolicies("ADDED", endPoint)
+
+	for _, tc := range []struct {
+		name     string
+		eventID  int32
+		args     *bytes.Buffer
+		source   string
+		resource string
+		data     string
+		action   string
+	}{
+		{"setxattr", SysSetxattr, encodeArgs("/etc/passwd", "security.ima"), "/usr/bin/evmctl",
+			"/etc/passwd", "syscall=SYS_SETXATTR xattr=security.ima", "Audit (Block)"},
+		{"removexattr", SysRemovexattr, encodeArgs("/etc/passwd", "security.ima"), "/usr/bin/evmctl",
+			"/etc/passwd", "syscall=SYS_REMOVEXATTR xattr=security.ima", ""},
+		{"lsetxattr (fromSource)", SysLSetxattr, encodeArgs("/etc/hosts", "user.checksum"), "/usr/bin/setfattr",
+			"/etc/hosts", "syscall=SYS_LSETXATTR xattr=user.checksum", "Audit"},
+		{"lsetxattr (other source)", SysLSetxattr, encodeArgs("/etc/hosts", "user.checksum"), "/usr/bin/python3",
+			"/etc/hosts", "syscall=SYS_LSETXATTR xattr=user.checksum", ""},
+		{"fremovexattr", SysFRemovexattr, encodeArgs(fileFd, "user.checksum"), "/usr/bin/setfattr",
+			file.Name(), "syscall=SYS_FREMOVEXATTR fd=" + strconv.Itoa(int(fileFd)) + " xattr=user.checksum", ""},
+		{"ioctl", SysIoctl, encodeArgs(fileFd, uint32(0x80010)), "/usr/bin/chattr",
+			file.Name(), "syscall=SYS_IOCTL fd=" + strconv.Itoa(int(fileFd)) + " cmd=FS_IOC_SETFLAGS flags=FS_IMMUTABLE_FL|0x80000", "Audit"},
+	} {
+		args, err := GetArgs(tc.args, 2)
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to decode the arguments of %s (%s)", tc.name, err.Error())
+		}
+
+		msg := ContextCombined{ContainerID: "nginx", ContextArgs: args}
+		msg.ContextSys.EventID = tc.eventID
+		msg.ContextSys.HostPID = uint32(os.Getpid())
+
+		log := tp.Log{ContainerID: "nginx", NamespaceName: "default", PodName: "nginx", ProcessName: tc.source, Result: "Passed"}
+		log = updateFileAttributeLog(log, msg)
+
+		if log.Operation != "File" || log.Resource != tc.resource || log.Data != tc.data {
+			t.Errorf("[FAIL] Unexpected log for %s (%s, %s, %s)", tc.name, log.Operation, log.Resource, log.Data)
+			continue
+		}
+
+		log = logger.UpdateMatchedPolicy(log)
+
+		if tc.action == "" {
+			if log.Type == "MatchedPolicy" {
+				t.Errorf("[FAIL] Unexpected alert for %s (%s)", tc.name, log.PolicyName)
+			}
+		} else if log.Type != "MatchedPolicy" || log.PolicyName != "protect-attributes" || log.Action != tc.action {
+			t.Errorf("[FAIL] Expected an alert for %s (%s, %s, %s)", tc.name, log.Type, log.PolicyName, log.Action)
+		}
+	}
+
+	// Block rules are enforced by the BPF LSM enforcer, which denies the changes
+	logger.Enforcer = "BPFLSM"
+	logger.UpdateSecurityPolicies("ADDED", endPoint)
+
+	args, err := GetArgs(encodeArgs("/etc/passwd", "security.ima"), 2)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to decode the arguments of setxattr (%s)", err.Error())
+	}
+
+	msg := ContextCombined{ContainerID: "nginx", ContextArgs: args}
+	msg.ContextSys.EventID = SysSetxattr
+
+	log := tp.Log{ContainerID: "nginx", NamespaceName: "default", PodName: "nginx", ProcessName: "/usr/bin/evmctl", Result: "Permission denied"}
+	log = logger.UpdateMatchedPolicy(updateFileAttributeLog(log, msg))
+
+	if log.Type != "MatchedPolicy" || log.Action != "Block" || log.Enforcer != "BPFLSM" {
+		t.Errorf("[FAIL] Expected a blocked xattr change with BPFLSM (%s, %s, %s)", log.Type, log.Action, log.Enforcer)
+	}
+
+	t.Log("[PASS] Decoded and matched xattr and inode flag changes")
+}
+
+func TestNamespaceLogs(t *testing.T) {
+	// policies
+	logger := &feeder.Feeder{}
+	logger.SecurityPolicies = map[string]tp.MatchPolicies{}
+	logger.SecurityPoliciesLock = new(sync.RWMutex)
+	logger.DefaultPostures = map[string]tp.DefaultPosture{}
+	logger.DefaultPosturesLock = new(sync.Mutex)
+
+	secPolicy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "restrict-netns"}}
+	secPolicy.Spec.Process.MatchNamespaces = []tp.ProcessNamespaceType{
+		{Namespace: "net", Operations: []string{"unshare"}, Severity: 5, Action: "Block"},
+		{Namespace: "net", Operations: []string{"setns"}, FromSource: []tp.MatchSourceType{{Path: "/usr/bin/nsenter"}}, Severity: 3, Action: "Audit"},
+	}
+
+	endPoint := tp.EndPoint{NamespaceName: "default", EndPointName: "nginx", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{secPolicy}
+	logger.UpdateSecurityPolicies("ADDED", endPoint)
+
+	for _, tc := range []struct {
+		name    string
+		eventID int32
+		args    *bytes.Buffer
+		argNum  int32
+		source  string
+		data    string
+		netns   bool
+		action  string
+	}{
+		{"unshare", SysUnshare, encodeArgs(nsFlags(0x40000000)), 1, "/usr/bin/unshare",
+			"syscall=SYS_UNSHARE flags=CLONE_NEWNET", true, "Audit (Block)"},
+		{"unshare (mount)", SysUnshare, encodeArgs(nsFlags(0x00020000)), 1, "/usr/bin/unshare",
+			"syscall=SYS_UNSHARE flags=CLONE_NEWNS", false, ""},
+		{"setns (fromSource)", SysSetns, encodeArgs(int32(3), nsFlags(0x40000000)), 2, "/usr/bin/nsenter",
+			"syscall=SYS_SETNS fd=3 nstype=CLONE_NEWNET", true, "Audit"},
+		{"setns (other source)", SysSetns, encodeArgs(int32(3), nsFlags(0x40000000)), 2, "/usr/bin/python3",
+			"syscall=SYS_SETNS fd=3 nstype=CLONE_NEWNET", true, ""},
+	} {
+		args, err := GetArgs(tc.args, tc.argNum)
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to decode the arguments of %s (%s)", tc.name, err.Error())
+		}
+
+		msg := ContextCombined{ContainerID: "nginx", ContextArgs: args}
+		msg.ContextSys.EventID = tc.eventID
+		msg.ContextSys.HostPID = 0 // no such process, the namespace of the fd is unknown
+
+		log := tp.Log{ContainerID: "nginx", NamespaceName: "default", PodName: "nginx", ProcessName: tc.source, Result: "Passed"}
+		log, netns := updateNamespaceLog(log, msg)
+
+		if netns != tc.netns || log.Operation != "Process" || log.Resource != "namespace=net" || log.Data != tc.data {
+			t.Errorf("[FAIL] Unexpected log for %s (%t, %s, %s, %s)", tc.name, netns, log.Operation, log.Resource, log.Data)
+			continue
+		}
+
+		if !netns {
+			continue
+		}
+
+		log = logger.UpdateMatchedPolicy(log)
+
+		if tc.action == "" {
+			if log.Type == "MatchedPolicy" {
+				t.Errorf("[FAIL] Unexpected alert for %s (%s)", tc.name, log.PolicyName)
+			}
+		} else if log.Type != "MatchedPolicy" || log.PolicyName != "restrict-netns" || log.Action != tc.action {
+			t.Errorf("[FAIL] Expected an alert for %s (%s, %s, %s)", tc.name, log.Type, log.PolicyName, log.Action)
+		}
+	}
+
+	t.Log("[PASS] Decoded and matched network namespace operations")
+}
+
+func TestSocketArgs(t *testing.T) {
+	// accept: fd, sockaddr (none), socket, accepted socket
+	args, err := GetArgs(encodeArgs(int32(3), uint64(4242), uint64(1<<40)), 3)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to decode the arguments (%s)", err.Error())
+	}
+
+	if args[1] != uint64(4242) || args[2] != uint64(1<<40) {
+		t.Errorf("[FAIL] Unexpected socket ids (%v, %v)", args[1], args[2])
+	}
+
+	// close: fd, socket closed with its last fd
+	args, err = GetArgs(encodeArgs(int32(3), uint64(4242)), 2)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to decode the arguments (%s)", err.Error())
+	}
+
+	if args[1] != uint64(4242) {
+		t.Errorf("[FAIL] Unexpected socket id of the close (%v)", args[1])
+	}
+
+	t.Log("[PASS] Decoded the socket ids")
+}
+
+func TestSignalLogs(t *testing.T) {
+	// nginx (sender) and fluentd in other pods, and the host
+	node := tp.Node{}
+	nodeLock := new(sync.RWMutex)
+	containers := map[string]tp.Container{
+		"nginx":   {ContainerID: "nginx", ContainerName: "nginx", NamespaceName: "default", EndPointName: "nginx"},
+		"fluentd": {ContainerID: "fluentd", ContainerName: "fluentd", NamespaceName: "logging", EndPointName: "fluentd-x7k2p"},
+	}
+	containersLock := new(sync.RWMutex)
+	activeHostPidMap := map[string]tp.PidMap{
+		"nginx":   {4000001: {HostPID: 4000001, ExecPath: "/usr/bin/sleep"}, 4000002: {HostPID: 4000002, ExecPath: "/usr/sbin/nginx"}},
+		"fluentd": {4000100: {HostPID: 4000100, ExecPath: "/usr/bin/fluentd"}},
+	}
+	activePidMapLock := new(sync.RWMutex)
+	monitorLock := new(sync.RWMutex)
+
+	mon := NewSystemMonitor(&node, &nodeLock, nil, &containers, &containersLock, &activeHostPidMap, &activePidMapLock, &monitorLock)
+	mon.NsMap[NsKey{PidNS: 11, MntNS: 12}] = "nginx"
+	mon.NsMap[NsKey{PidNS: 21, MntNS: 22}] = "fluentd"
+
+	// policies
+	logger := &feeder.Feeder{}
+	logger.Enforcer = "BPFLSM"
+	logger.SecurityPolicies = map[string]tp.MatchPolicies{}
+	logger.SecurityPoliciesLock = new(sync.RWMutex)
+	logger.DefaultPostures = map[string]tp.DefaultPosture{}
+	logger.DefaultPosturesLock = new(sync.Mutex)
+
+	secPolicy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "protect-agents"}}
+	secPolicy.Spec.Process.MatchSignals = []tp.ProcessSignalType{
+		{Signals: []string{"KILL", "SIGSTOP"}, Scope: "cross-container", Severity: 8, Action: "Block"},
+		{Signals: []string{"15"}, ExcludeSelf: true, ExcludeChildren: true, Severity: 3, Action: "Audit"},
+		{Signals: []string{"SIGHUP"}, Target: []tp.MatchSourceType{{Path: "/usr/bin/fluentd"}}, FromSource: []tp.MatchSourceType{{Path: "/bin/kill"}}, Severity: 5, Action: "Audit"},
+	}
+
+	endPoint := tp.EndPoint{NamespaceName: "default", EndPointName: "nginx", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{secPolicy}
+	logger.UpdateSecurityPolicies("ADDED", endPoint)
+
+	for _, tc := range []struct {
+		name     string
+		args     *bytes.Buffer
+		source   string
+		result   string
+		resource string
+		data     string
+		action   string
+	}{
+		{"SIGKILL (cross-container)", encodeArgs(int32(9), int32(4000100), int32(1), int32(21), int32(22), "fluentd"), "/bin/sh", "Operation not permitted", "/usr/bin/fluentd",
+			"lsm=SECURITY_TASK_KILL signal=SIGKILL targetPid=4000100 comm=fluentd scope=cross-container targetNamespace=logging targetPod=fluentd-x7k2p targetContainer=fluentd", "Block"},
+		{"SIGTERM (self)", encodeArgs(int32(15), int32(4000000), int32(1), int32(11), int32(12), "sh"), "/bin/sh", "Passed", "sh",
+			"lsm=SECURITY_TASK_KILL signal=SIGTERM targetPid=4000000 comm=sh scope=same relation=self", ""},
+		{"SIGTERM (child)", encodeArgs(int32(15), int32(4000001), int32(4000000), int32(11), int32(12), "sleep"), "/bin/sh", "Passed", "/usr/bin/sleep",
+			"lsm=SECURITY_TASK_KILL signal=SIGTERM targetPid=4000001 comm=sleep scope=same relation=child", ""},
+		{"SIGTERM (same container)", encodeArgs(int32(15), int32(4000002), int32(1), int32(11), int32(12), "nginx"), "/bin/sh", "Passed", "/usr/sbin/nginx",
+			"lsm=SECURITY_TASK_KILL signal=SIGTERM targetPid=4000002 comm=nginx scope=same", "Audit"},
+		{"SIGHUP (fromSource)", encodeArgs(int32(1), int32(4000100), int32(1), int32(21), int32(22), "fluentd"), "/bin/kill", "Passed", "/usr/bin/fluentd",
+			"lsm=SECURITY_TASK_KILL signal=SIGHUP targetPid=4000100 comm=fluentd scope=cross-container targetNamespace=logging targetPod=fluentd-x7k2p targetContainer=fluentd", "Audit"},
+		{"SIGHUP (other source)", encodeArgs(int32(1), int32(4000100), int32(1), int32(21), int32(22), "fluentd"), "/bin/sh", "Passed", "/usr/bin/fluentd",
+			"lsm=SECURITY_TASK_KILL signal=SIGHUP targetPid=4000100 comm=fluentd scope=cross-container targetNamespace=logging targetPod=fluentd-x7k2p targetContainer=fluentd", ""},
+		{"SIGSTOP (host)", encodeArgs(int32(19), int32(4000200), int32(1), int32(-268435460), int32(-268435456), "agent"), "/bin/sh", "Passed", "agent",
+			"lsm=SECURITY_TASK_KILL signal=SIGSTOP targetPid=4000200 comm=agent scope=host", ""},
+	} {
+		args, err := GetArgs(tc.args, 6)
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to decode the arguments of %s (%s)", tc.name, err.Error())
+		}
+
+		msg := ContextCombined{ContainerID: "nginx", ContextArgs: args}
+		msg.ContextSys.EventID = SecurityTaskKill
+		msg.ContextSys.HostPID = 4000000
+
+		log := tp.Log{ContainerID: "nginx", NamespaceName: "default", PodName: "nginx", ProcessName: tc.source, Result: tc.result}
+		log = mon.updateSignalLog(log, msg)
+
+		if log.Operation != "Signal" || log.Resource != tc.resource || log.Data != tc.data {
+			t.Errorf("[FAIL] Unexpected log for %s (%s, %s, %s)", tc.name, log.Operation, log.Resource, log.Data)
+			continue
+		}
+
+		log = logger.UpdateMatchedPolicy(log)
+
+		if tc.action == "" {
+			if log.Type != "ContainerLog" {
+				t.Errorf("[FAIL] Unexpected alert for %s (%s, %s)", tc.name, log.Type, log.PolicyName)
+			}
+		} else if log.Type != "MatchedPolicy" || log.PolicyName != "protect-agents" || log.Action != tc.action {
+			t.Errorf("[FAIL] Expected an alert for %s (%s, %s, %s)", tc.name, log.Type, log.PolicyName, log.Action)
+		}
+	}
+
+	// Block rules are audited by the other enforcers
+	logger.Enforcer = "AppArmor"
+	logger.UpdateSecurityPolicies("ADDED", endPoint)
+
+	if policies := logger.SecurityPolicies["default_nginx"].Policies; len(policies) != 3 || policies[0].Action != "Audit (Block)" {
+		t.Errorf("[FAIL] Expected the signal rule to be audited with AppArmor (%v)", policies)
+	}
+
+	t.Log("[PASS] Decoded and matched signals to other processes")
+}
+
+func TestDualStackNetworkLogs(t *testing.T) {
+	// argument payloads captured from the system monitor (types, then little-endian values and raw sockaddrs)
+	for _, tc := range []struct {
+		name     string
+		payload  string
+		eventID  int32
+		resource string
+		data     string
+	}{
+		{"tcp_connect (IPv6)", "0a04000000544350000c0a0001bb0000000020010db800000000000000000000001000000000", TCPConnectv6,
+			"remoteip=2001:db8::10 port=443 protocol=TCP", "kprobe=tcp_connect domain=AF_INET6"},
+		{"tcp_accept (v4-mapped)", "0a04000000544350000c0a0000500000000000000000000000000000ffff0a00000500000000", TCPAcceptv6,
+			"remoteip=10.0.0.5 port=80 protocol=TCP", "kprobe=tcp_accept domain=AF_INET6"},
+		{"tcp_connect (IPv4)", "0a04000000544350000c020000500a0000050000000000000000", TCPConnect,
+			"remoteip=10.0.0.5 port=80 protocol=TCP", "kprobe=tcp_connect domain=AF_INET"},
+	} {
+		payload, _ := hex.DecodeString(tc.payload)
+
+		args, err := GetArgs(bytes.NewBuffer(payload), 2)
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to decode the arguments of %s (%s)", tc.name, err.Error())
+		}
+
+		msg := ContextCombined{ContainerID: "nginx", ContextArgs: args}
+		msg.ContextSys.EventID = tc.eventID
+
+		log := updateConnectionLog(tp.Log{ContainerID: "nginx"}, msg)
+		if log.Operation != "Network" || log.Resource != tc.resource || log.Data != tc.data {
+			t.Errorf("[FAIL] Unexpected log for %s (%s, %s)", tc.name, log.Resource, log.Data)
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		payload  string
+		resource string
+	}{
+		// UDP socket of a DNS client connected to a link-local resolver on the interface 2
+		{"connect (link-local)", "01050000000c0a00003500000000fe80000000000000000000000000000102000000", "sa_family=AF_INET6 sin_addr=fe80::1%2 sin_port=53"},
+		{"bind (any)", "01070000000c0a001f90000000000000000000000000000000000000000000000000", "sa_family=AF_INET6 sin_addr=:: sin_port=8080"},
+	} {
+		payload, _ := hex.DecodeString(tc.payload)
+
+		args, err := GetArgs(bytes.NewBuffer(payload), 2)
+		if err != nil {
+			t.Fatalf("[FAIL] Failed to decode the arguments of %s (%s)", tc.name, err.Error())
+		}
+
+		sockAddr, ok := args[1].(map[string]string)
+		if !ok || formatSockaddr(sockAddr) != tc.resource {
+			t.Errorf("[FAIL] Unexpected socket address for %s (%v)", tc.name, args[1])
+		}
+	}
+
+	// the connections of the dual-stack sockets to IPv4 peers are summarized with the IPv4 ones
+	flows := NewFlowTable(16)
+	for _, payload := range []string{"0a04000000544350000c0a0000500000000000000000000000000000ffff0a00000500000000", "0a04000000544350000c020000500a0000050000000000000000"} {
+		raw, _ := hex.DecodeString(payload)
+		args, _ := GetArgs(bytes.NewBuffer(raw), 2)
+		protocol, sockAddr := connectionArgs(ContextCombined{ContextArgs: args})
+		flows.Record(tp.Log{ContainerID: "nginx"}, protocol, sockAddr["sin_addr"], sockAddr["sin_port"])
+	}
+	if logs := flows.Flush(); len(logs) != 1 || !strings.HasPrefix(logs[0].Data, "protocol=TCP remoteip=10.0.0.5 port=80 connections=2") {
+		t.Errorf("[FAIL] Expected a single flow to 10.0.0.5 (%v)", logs)
+	}
+
+	// the protocol rules match the sockets of both families, ICMP rules not matching ICMPv6 (as in the enforcers)
+	logger := &feeder.Feeder{}
+	logger.Enforcer = "BPFLSM"
+	logger.SecurityPolicies = map[string]tp.MatchPolicies{}
+	logger.SecurityPoliciesLock = new(sync.RWMutex)
+	logger.DefaultPostures = map[string]tp.DefaultPosture{}
+	logger.DefaultPosturesLock = new(sync.Mutex)
+
+	secPolicy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "block-protocols"}}
+	secPolicy.Spec.Network.MatchProtocols = []tp.NetworkProtocolType{{Protocol: "tcp", Action: "Block"}, {Protocol: "icmp", Action: "Block"}}
+
+	endPoint := tp.EndPoint{NamespaceName: "default", EndPointName: "nginx", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{secPolicy}
+	logger.UpdateSecurityPolicies("ADDED", endPoint)
+
+	for _, tc := range []struct {
+		resource string
+		result   string
+		matched  bool
+	}{
+		{"domain=AF_INET type=SOCK_STREAM protocol=TCP", "Permission denied", true},
+		{"domain=AF_INET6 type=SOCK_STREAM protocol=TCP", "Permission denied", true},
+		// ping sockets, the denial of the ICMPv6 one not being attributed to the ICMP rule
+		{"domain=AF_INET type=SOCK_DGRAM protocol=ICMP", "Permission denied", true},
+		{"domain=AF_INET6 type=SOCK_DGRAM protocol=ICMPv6", "Permission denied", false},
+	} {
+		log := tp.Log{ContainerID: "nginx", NamespaceName: "default", PodName: "nginx", Type: "ContainerLog", ProcessName: "/bin/ping",
+			Operation: "Network", Resource: tc.resource, Data: "syscall=SYS_SOCKET", Result: tc.result}
+		log = logger.UpdateMatchedPolicy(log)
+
+		if matched := log.PolicyName == "block-protocols"; matched != tc.matched || (matched && log.Action != "Block") {
+			t.Errorf("[FAIL] Unexpected match of %s (%s, %s)", tc.resource, log.PolicyName, log.Action)
+		}
+	}
+
+	t.Log("[PASS] Decoded and matched the IPv4 and IPv6 network events")
+}
@@ -0,0 +1,213 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+)
+
+// ==================== //
+// == NsMap Adoption == //
+// ==================== //
+
+// bootIDPath is the path of the boot ID of the node
+var bootIDPath = "/proc/sys/kernel/random/boot_id"
+
+// nsMapState Structure
+type nsMapState struct {
+	BootID  string            `json:"bootID"`
+	Entries []nsMapStateEntry `json:"entries"`
+}
+
+// nsMapStateEntry Structure
+type nsMapStateEntry struct {
+	PidNS       uint32 `json:"pidNS"`
+	MntNS       uint32 `json:"mntNS"`
+	ContainerID string `json:"containerID"`
+}
+
+// nsMapAdoption keeps the entries of the pinned visibility map found at startup until the
+// containers are listed by the runtime handlers
+type nsMapAdoption struct {
+	pinned []NsKey
+	state  nsMapState
+}
+
+// readBootID returns the boot ID of the node ("" if unknown)
+func readBootID() string {
+	data, err := os.ReadFile(filepath.Clean(bootIDPath))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// loadNsMapState Function
+func loadNsMapState(path string) (nsMapState, error) {
+	state := nsMapState{}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return state, err
+	}
+
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+// saveNsMapState keeps the entries of NsMap with the boot ID for the next start
+func (mon *SystemMonitor) saveNsMapState() {
+	if mon.NsMapStatePath == "" {
+		return
+	}
+
+	mon.NsMapStateLock.Lock()
+	defer mon.NsMapStateLock.Unlock()
+
+	state := nsMapState{BootID: mon.BootID, Entries: []nsMapStateEntry{}}
+
+	mon.NsMapLock.RLock()
+	for key, containerID := range mon.NsMap {
+		state.Entries = append(state.Entries, nsMapStateEntry{PidNS: key.PidNS, MntNS: key.MntNS, ContainerID: containerID})
+	}
+	mon.NsMapLock.RUnlock()
+
+	if err := os.MkdirAll(filepath.Dir(mon.NsMapStatePath), 0750); err != nil {
+		mon.Logger.Warnf("Failed to save the NsMap state (%s)", err.Error())
+		return
+	}
+
+	if err := kl.WriteToFile(state, mon.NsMapStatePath); err != nil {
+		mon.Logger.Warnf("Failed to save the NsMap state (%s)", err.Error())
+	}
+}
+
+// planNsMapAdoption returns the pinned entries to adopt (key -> container id) and the ones to drop
+func planNsMapAdoption(pinned []NsKey, state nsMapState, bootID string, current map[NsKey]string, containers map[string]nsMapContainer) (map[NsKey]string, []NsKey) {
+	adopt := map[NsKey]string{}
+	drop := []NsKey{}
+
+	// namespace IDs are reused after a reboot, so the entries of another boot are all dropped
+	owners := map[NsKey]string{}
+	if bootID != "" && state.BootID == bootID {
+		for _, entry := range state.Entries {
+			owners[NsKey{PidNS: entry.PidNS, MntNS: entry.MntNS}] = entry.ContainerID
+		}
+	}
+
+	for _, key := range pinned {
+		// the host entry
+		if key.PidNS == 0 && key.MntNS == 0 {
+			continue
+		}
+
+		// registered again by the runtime handlers
+		if _, ok := current[key]; ok {
+			continue
+		}
+
+		containerID, ok := owners[key]
+		if !ok || isStaleNsMapEntry(key, containerID, containers) {
+			drop = append(drop, key)
+			continue
+		}
+
+		adopt[key] = containerID
+	}
+
+	return adopt, drop
+}
+
+// pinnedNsKeys returns the keys of the pinned visibility map
+func (mon *SystemMonitor) pinnedNsKeys() []NsKey {
+	keys := []NsKey{}
+
+	if mon.BpfNsVisibilityMap == nil {
+		return keys
+	}
+
+	var key NsKey
+	var value uint32
+
+	iter := mon.BpfNsVisibilityMap.Iterate()
+	for iter.Next(&key, &value) {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// dropPinnedNsKeys Function
+func (mon *SystemMonitor) dropPinnedNsKeys(keys []NsKey) {
+	if mon.BpfNsVisibilityMap == nil {
+		return
+	}
+
+	mon.BpfMapLock.Lock()
+	defer mon.BpfMapLock.Unlock()
+
+	for _, key := range keys {
+		if err := mon.BpfNsVisibilityMap.Delete(key); err != nil {
+			mon.Logger.Warnf("Cannot drop a pinned visibility map. nskey=%+v", key)
+		}
+	}
+}
+
+// startNsMapAdoption checks the entries of the visibility map pinned by the previous instance
+func (mon *SystemMonitor) startNsMapAdoption() {
+	mon.BootID = readBootID()
+	mon.NsMapStatePath = cfg.NsMapStatePath
+
+	pinned := mon.pinnedNsKeys()
+	if len(pinned) == 0 {
+		return
+	}
+
+	state, err := loadNsMapState(mon.NsMapStatePath)
+	if err != nil || mon.BootID == "" || state.BootID != mon.BootID {
+		// no way to tell the entries of this boot apart
+		mon.dropPinnedNsKeys(pinned)
+		mon.Logger.Printf("Dropped %d pinned visibility entries (the node was rebooted or no state was kept)", len(pinned))
+		return
+	}
+
+	mon.NsMapLock.Lock()
+	mon.nsMapAdoption = &nsMapAdoption{pinned: pinned, state: state}
+	mon.NsMapLock.Unlock()
+}
+
+// FinalizeNsMapAdoption validates the pinned entries against the containers listed by the runtime handlers
+func (mon *SystemMonitor) FinalizeNsMapAdoption() {
+	mon.NsMapLock.Lock()
+	adoption := mon.nsMapAdoption
+	mon.nsMapAdoption = nil
+
+	current := map[NsKey]string{}
+	for key, containerID := range mon.NsMap {
+		current[key] = containerID
+	}
+	mon.NsMapLock.Unlock()
+
+	if adoption == nil {
+		return
+	}
+
+	containers := mon.snapshotNsMapContainers()
+
+	adopt, drop := planNsMapAdoption(adoption.pinned, adoption.state, mon.BootID, current, containers)
+
+	for key, containerID := range adopt {
+		mon.AddContainerIDToNsMap(containerID, containers[containerID].Namespace, key.PidNS, key.MntNS)
+	}
+
+	mon.dropPinnedNsKeys(drop)
+
+	mon.Logger.Printf("Adopted %d pinned visibility entries, dropped %d", len(adopt), len(drop))
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestNsMapAdoption(t *testing.T) {
+	pid := uint32(os.Getpid())
+
+	pidNS := getProcNamespace(pid, "pid")
+	mntNS := getProcNamespace(pid, "mnt")
+	if pidNS == 0 || mntNS == 0 {
+		t.Skip("[SKIP] Unable to read the namespaces of the current process")
+	}
+
+	feeder.MsgLock = new(sync.RWMutex)
+	feeder.MsgStructs = make(map[string]feeder.MsgStruct)
+
+	logger := &feeder.Feeder{Node: &tp.Node{}}
+
+	// the state kept by the previous instance
+	previous := &SystemMonitor{Logger: logger, BootID: "boot-a", NsMapStatePath: t.TempDir() + "/nsmap.json"}
+	previous.NsMapLock = new(sync.RWMutex)
+	previous.NsMapStateLock = new(sync.Mutex)
+	previous.NsMap = map[NsKey]string{
+		{PidNS: pidNS, MntNS: mntNS}: "survivor",
+		{PidNS: 2001, MntNS: 2002}:   "removed",
+		{PidNS: 3001, MntNS: 3002}:   "moved",
+		{PidNS: 4001, MntNS: 4002}:   "replaced",
+	}
+	previous.saveNsMapState()
+
+	state, err := loadNsMapState(previous.NsMapStatePath)
+	if err != nil || state.BootID != "boot-a" || len(state.Entries) != 4 {
+		t.Fatalf("[FAIL] Failed to load the NsMap state (%+v, %v)", state, err)
+	}
+
+	pinned := []NsKey{{PidNS: 0, MntNS: 0}}
+	for key := range previous.NsMap {
+		pinned = append(pinned, key)
+	}
+
+	containers := map[string]tp.Container{
+		"survivor": {ContainerID: "survivor", NamespaceName: "default", PidNS: pidNS, MntNS: mntNS, Pid: pid},
+		"moved":    {ContainerID: "moved", NamespaceName: "default", PidNS: 3001, MntNS: 3003},
+		"new":      {ContainerID: "new", NamespaceName: "default", PidNS: 4001, MntNS: 4002},
+	}
+	containersLock := new(sync.RWMutex)
+
+	// the node was rebooted
+	adopt, drop := planNsMapAdoption(pinned, state, "boot-b", map[NsKey]string{}, map[string]nsMapContainer{"survivor": {PidNS: pidNS, MntNS: mntNS, Pid: pid}})
+	if len(adopt) != 0 || len(drop) != 4 {
+		t.Errorf("[FAIL] Expected the entries of another boot to be dropped (%v, %v)", adopt, drop)
+	}
+
+	// some containers survived a restart of KubeArmor
+	mon := &SystemMonitor{Logger: logger, BootID: "boot-a", Containers: &containers, ContainersLock: &containersLock}
+	mon.NsMapLock = new(sync.RWMutex)
+	mon.BpfMapLock = new(sync.RWMutex)
+	mon.NamespacePidsMap = map[string]NsVisibility{}
+
+	// the namespaces of a removed container were reused by a new one, registered already
+	mon.NsMap = map[NsKey]string{{PidNS: 4001, MntNS: 4002}: "new"}
+
+	adopt, drop = planNsMapAdoption(pinned, state, "boot-a", mon.NsMap, mon.snapshotNsMapContainers())
+	if len(adopt) != 1 || adopt[NsKey{PidNS: pidNS, MntNS: mntNS}] != "survivor" || len(drop) != 2 {
+		t.Errorf("[FAIL] Unexpected adoption (%v, %v)", adopt, drop)
+	}
+
+	mon.nsMapAdoption = &nsMapAdoption{pinned: pinned, state: state}
+	mon.FinalizeNsMapAdoption()
+
+	if cid := mon.LookupContainerID(pidNS, mntNS, 0, 0); cid != "survivor" {
+		t.Errorf("[FAIL] Expected the entry of the surviving container to be adopted (%s)", cid)
+	}
+	if cid := mon.LookupContainerID(4001, 4002, 0, 0); cid != "new" {
+		t.Errorf("[FAIL] Expected the entry of the new container to be kept (%s)", cid)
+	}
+	if len(mon.NsMap) != 2 || len(mon.NamespacePidsMap["default"].NsKeys) != 1 {
+		t.Errorf("[FAIL] Unexpected NsMap (%+v, %+v)", mon.NsMap, mon.NamespacePidsMap)
+	}
+
+	// adoption is done once
+	if mon.nsMapAdoption != nil {
+		t.Errorf("[FAIL] Expected the adoption to be finalized")
+	}
+
+	t.Log("[PASS] Adopted the valid pinned NsMap entries")
+}
@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ====================== //
+// == NsMap Collection == //
+// ====================== //
+
+// NsMapGCStats Structure
+type NsMapGCStats struct {
+	Passes    uint64
+	Evictions uint64
+
+	LastPass time.Time
+}
+
+// nsMapContainer keeps the fields of a container used to validate NsMap entries
+type nsMapContainer struct {
+	Namespace string
+
+	PidNS uint32
+	MntNS uint32
+	Pid   uint32
+}
+
+// getProcNamespace returns the inode of a namespace of a process (0 if the process is gone)
+func getProcNamespace(pid uint32, nsType string) uint32 {
+	data, err := os.Readlink(kl.GetProcPath(strconv.FormatUint(uint64(pid), 10), "ns", nsType))
+	if err != nil {
+		return 0
+	}
+
+	var ns uint32
+	if _, err := fmt.Sscanf(data, nsType+":[%d]\n", &ns); err != nil {
+		return 0
+	}
+
+	return ns
+}
+
+// isStaleNsMapEntry checks if an entry of NsMap doesn't belong to a live container anymore
+func isStaleNsMapEntry(key NsKey, containerID string, containers map[string]nsMapContainer) bool {
+	container, ok := containers[containerID]
+	if !ok {
+		return true
+	}
+
+	// the container was re-registered with other namespaces
+	if container.PidNS != key.PidNS || container.MntNS != key.MntNS {
+		return true
+	}
+
+	// the init process of the container isn't known (e.g., restored containers)
+	if container.Pid == 0 {
+		return false
+	}
+
+	// the init process is gone, or its pid was reused by a process in other namespaces
+	return getProcNamespace(container.Pid, "pid") != key.PidNS
+}
+
+// evictNsMapEntry removes an entry of NsMap unless it was updated after the validation
+func (mon *SystemMonitor) evictNsMapEntry(key NsKey, containerID string) bool {
+	mon.NsMapLock.Lock()
+	if val, ok := mon.NsMap[key]; !ok || val != containerID {
+		mon.NsMapLock.Unlock()
+		return false
+	}
+	delete(mon.NsMap, key)
+	mon.NsMapLock.Unlock()
+
+	defer mon.saveNsMapState()
+
+	mon.BpfMapLock.Lock()
+	defer mon.BpfMapLock.Unlock()
+
+	for namespace, val := range mon.NamespacePidsMap {
+		for i := range val.NsKeys {
+			if val.NsKeys[i] == key {
+				val.NsKeys = append(val.NsKeys[:i], val.NsKeys[i+1:]...)
+				mon.NamespacePidsMap[namespace] = val
+				mon.UpdateNsKeyMap("DELETED", key, tp.Visibility{})
+				return true
+			}
+		}
+	}
+
+	return true
+}
+
+// snapshotNsMapContainers Function
+func (mon *SystemMonitor) snapshotNsMapContainers() map[string]nsMapContainer {
+	containers := map[string]nsMapContainer{}
+
+	if mon.Containers != nil && mon.ContainersLock != nil {
+		ContainersLock := *(mon.ContainersLock)
+
+		ContainersLock.RLock()
+		for containerID, container := range *(mon.Containers) {
+			containers[containerID] = nsMapContainer{Namespace: container.NamespaceName, PidNS: container.PidNS, MntNS: container.MntNS, Pid: container.Pid}
+		}
+		ContainersLock.RUnlock()
+	}
+
+	return containers
+}
+
+// CollectStaleNsMapEntries evicts the entries of NsMap whose containers disappeared without destroy events
+func (mon *SystemMonitor) CollectStaleNsMapEntries() int {
+	// take snapshots so that no global lock is held during the validation
+	entries := map[NsKey]string{}
+
+	mon.NsMapLock.RLock()
+	for key, containerID := range mon.NsMap {
+		entries[key] = containerID
+	}
+	mon.NsMapLock.RUnlock()
+
+	containers := mon.snapshotNsMapContainers()
+
+	evictions := 0
+
+	for key, containerID := range entries {
+		if !isStaleNsMapEntry(key, containerID, containers) {
+			continue
+		}
+
+		if mon.evictNsMapEntry(key, containerID) {
+			evictions++
+		}
+	}
+
+	mon.NsMapLock.Lock()
+	mon.NsMapGCStats.Passes++
+	mon.NsMapGCStats.Evictions += uint64(evictions)
+	mon.NsMapGCStats.LastPass = time.Now()
+	mon.NsMapLock.Unlock()
+
+	return evictions
+}
+
+// GetNsMapGCStats Function
+func (mon *SystemMonitor) GetNsMapGCStats() NsMapGCStats {
+	mon.NsMapLock.RLock()
+	defer mon.NsMapLock.RUnlock()
+
+	return mon.NsMapGCStats
+}
+
+// CollectNsMap Function
+func (mon *SystemMonitor) CollectNsMap() {
+	if cfg.GlobalCfg.NsMapGCInterval <= 0 {
+		return
+	}
+
+	MonitorLock := *(mon.MonitorLock)
+
+	for {
+		time.Sleep(cfg.GlobalCfg.NsMapGCInterval)
+
+		// read monitor status
+		MonitorLock.RLock()
+		monStatus := mon.Status
+		MonitorLock.RUnlock()
+
+		if !monStatus {
+			break
+		}
+
+		if evictions := mon.CollectStaleNsMapEntries(); evictions > 0 {
+			mon.Logger.Warnf("Evicted %d stale NsMap entries (destroy events of containers may have been missed)", evictions)
+		}
+	}
+}
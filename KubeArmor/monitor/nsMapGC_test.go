@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestCollectStaleNsMapEntries(t *testing.T) {
+	pid := uint32(os.Getpid())
+
+	pidNS := getProcNamespace(pid, "pid")
+	mntNS := getProcNamespace(pid, "mnt")
+	if pidNS == 0 || mntNS == 0 {
+		t.Skip("[SKIP] Unable to read the namespaces of the current process")
+	}
+
+	containers := map[string]tp.Container{
+		"live":     {ContainerID: "live", PidNS: pidNS, MntNS: mntNS, Pid: pid},
+		"restored": {ContainerID: "restored", PidNS: 1001, MntNS: 1002},
+		"exited":   {ContainerID: "exited", PidNS: 2001, MntNS: 2002, Pid: 0x7fffffff},
+		"moved":    {ContainerID: "moved", PidNS: 3001, MntNS: 3003},
+	}
+	containersLock := new(sync.RWMutex)
+	monitorLock := new(sync.RWMutex)
+
+	mon := &SystemMonitor{Containers: &containers, ContainersLock: &containersLock, MonitorLock: &monitorLock}
+	mon.NsMapLock = new(sync.RWMutex)
+	mon.BpfMapLock = new(sync.RWMutex)
+	mon.NamespacePidsMap = map[string]NsVisibility{}
+
+	mon.NsMap = map[NsKey]string{
+		{PidNS: pidNS, MntNS: mntNS}: "live",
+		{PidNS: 1001, MntNS: 1002}:   "restored",
+		{PidNS: 2001, MntNS: 2002}:   "exited",
+		{PidNS: 3001, MntNS: 3002}:   "moved",
+		{PidNS: 4001, MntNS: 4002}:   "removed",
+	}
+	mon.NamespacePidsMap["default"] = NsVisibility{NsKeys: []NsKey{{PidNS: 4001, MntNS: 4002}, {PidNS: 1001, MntNS: 1002}}}
+
+	if evictions := mon.CollectStaleNsMapEntries(); evictions != 3 {
+		t.Errorf("[FAIL] Expected 3 evictions, got %d (%+v)", evictions, mon.NsMap)
+	}
+
+	for _, containerID := range []string{"live", "restored"} {
+		if cid := mon.LookupContainerID(containers[containerID].PidNS, containers[containerID].MntNS, 0, 0); cid != containerID {
+			t.Errorf("[FAIL] Evicted the entry of a live container (%s)", containerID)
+		}
+	}
+
+	if keys := mon.NamespacePidsMap["default"].NsKeys; len(keys) != 1 || keys[0].PidNS != 1001 {
+		t.Errorf("[FAIL] Unexpected namespace keys (%+v)", keys)
+	}
+
+	// nothing left to collect
+	if evictions := mon.CollectStaleNsMapEntries(); evictions != 0 {
+		t.Errorf("[FAIL] Expected no evictions, got %d", evictions)
+	}
+
+	if stats := mon.GetNsMapGCStats(); stats.Passes != 2 || stats.Evictions != 3 {
+		t.Errorf("[FAIL] Unexpected stats (%+v)", stats)
+	}
+
+	t.Log("[PASS] Collected the stale NsMap entries")
+}
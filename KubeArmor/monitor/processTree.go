@@ -4,13 +4,16 @@
 package monitor
 
 import (
+	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
 	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
 )
 
@@ -37,9 +40,18 @@ func (mon *SystemMonitor) AddContainerIDToNsMap(containerID string, namespace st
 	key := NsKey{PidNS: pidns, MntNS: mntns}
 
 	mon.NsMapLock.Lock()
+	shared := sharedPidNamespace(mon.NsMap, key, containerID)
 	mon.NsMap[key] = containerID
 	mon.NsMapLock.Unlock()
 
+	// the containers of a pod with shareProcessNamespace have the same PID namespace, but their own mount namespaces
+	if len(shared) > 0 && mon.Logger != nil {
+		mon.Logger.Printf("Detected a container sharing its PID namespace (%.12s/pidns=%d/mntns=%d) with %s, attributing its events by its mount namespace",
+			containerID, pidns, mntns, strings.Join(shared, ","))
+	}
+
+	defer mon.saveNsMapState()
+
 	mon.BpfMapLock.Lock()
 	if val, ok := mon.NamespacePidsMap[namespace]; ok {
 		// check if nskey already exist
@@ -60,6 +72,7 @@ func (mon *SystemMonitor) AddContainerIDToNsMap(containerID string, namespace st
 			Process:      val.Process,
 			Capabilities: val.Capability,
 			Network:      val.Network,
+			Signal:       val.Signal,
 		})
 	} else {
 		mon.NamespacePidsMap[namespace] = NsVisibility{
@@ -70,6 +83,28 @@ func (mon *SystemMonitor) AddContainerIDToNsMap(containerID string, namespace st
 		mon.UpdateNsKeyMap("ADDED", key, tp.Visibility{})
 	}
 	mon.BpfMapLock.Unlock()
+
+	// the namespace may need the probes of a detached event class
+	mon.RequestEventClassUpdate()
+}
+
+// sharedPidNamespace returns the other containers in the PID namespace of a container (NsMapLock should be held)
+func sharedPidNamespace(nsMap map[NsKey]string, key NsKey, containerID string) []string {
+	shared := []string{}
+
+	if key.PidNS == 0 {
+		return shared
+	}
+
+	for nsKey, val := range nsMap {
+		if nsKey.PidNS == key.PidNS && nsKey.MntNS != key.MntNS && val != containerID {
+			shared = append(shared, fmt.Sprintf("%.12s", val))
+		}
+	}
+
+	sort.Strings(shared)
+
+	return shared
 }
 
 // DeleteContainerIDFromNsMap Function
@@ -82,7 +117,13 @@ func (mon *SystemMonitor) DeleteContainerIDFromNsMap(containerID string, namespa
 	found := true
 	mon.NsMapLock.Lock()
 	if pidns != 0 && mntns != 0 {
-		delete(mon.NsMap, ns)
+		if val, ok := mon.NsMap[ns]; ok && val != containerID {
+			// the namespaces belong to another container now (e.g., the exited instance
+			// of a restarted container being garbage-collected)
+			found = false
+		} else {
+			delete(mon.NsMap, ns)
+		}
 	} else {
 		found = false
 		for key, val := range mon.NsMap {
@@ -99,6 +140,8 @@ func (mon *SystemMonitor) DeleteContainerIDFromNsMap(containerID string, namespa
 		return
 	}
 
+	defer mon.saveNsMapState()
+
 	mon.BpfMapLock.Lock()
 	defer mon.BpfMapLock.Unlock()
 	if val, ok := mon.NamespacePidsMap[namespace]; ok {
@@ -144,6 +187,9 @@ func (mon *SystemMonitor) BuildPidNode(containerID string, ctx SyscallContext, e
 		}
 	}
 
+	// the processes of kubectl exec, and their children
+	node.Session = mon.GetSession(containerID, ctx.HostPID, ctx.HostPPID)
+
 	node.Exited = false
 
 	return node
@@ -215,7 +261,7 @@ func (mon *SystemMonitor) GetParentExecPath(containerID string, hostPid uint32)
 
 	if ppid > 0 {
 		// just in case that it couldn't still get the full path
-		if data, err := os.Readlink("/proc/" + strconv.FormatUint(uint64(ppid), 10) + "/exe"); err == nil && data != "" && data != "/" {
+		if data, err := os.Readlink(kl.GetHostProcPath(strconv.FormatUint(uint64(ppid), 10), "exe")); err == nil && data != "" && data != "/" {
 			return data
 		}
 	}
@@ -240,7 +286,7 @@ func (mon *SystemMonitor) GetExecPath(containerID string, hostPid uint32) string
 	}
 
 	// just in case that it couldn't still get the full path
-	if data, err := os.Readlink("/proc/" + strconv.FormatUint(uint64(hostPid), 10) + "/exe"); err == nil && data != "" && data != "/" {
+	if data, err := os.Readlink(kl.GetHostProcPath(strconv.FormatUint(uint64(hostPid), 10), "exe")); err == nil && data != "" && data != "/" {
 		return data
 	}
 
@@ -265,7 +311,7 @@ func (mon *SystemMonitor) GetCommand(containerID string, hostPid uint32) string
 	}
 
 	// just in case that it couldn't still get the full path
-	if data, err := os.Readlink("/proc/" + strconv.FormatUint(uint64(hostPid), 10) + "/exe"); err == nil && data != "" && data != "/" {
+	if data, err := os.Readlink(kl.GetHostProcPath(strconv.FormatUint(uint64(hostPid), 10), "exe")); err == nil && data != "" && data != "/" {
 		return data
 	}
 
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+func TestSharedPidNamespace(t *testing.T) {
+	messages := make(chan *pb.Message, 16)
+	feeder.MsgLock = new(sync.RWMutex)
+	feeder.MsgStructs = map[string]feeder.MsgStruct{"test": {Filter: "all", Broadcast: messages}}
+	defer func() { feeder.MsgStructs = make(map[string]feeder.MsgStruct) }()
+
+	// the containers of a pod with shareProcessNamespace: the same PID namespace, their own mount namespaces
+	containers := map[string]tp.Container{
+		"nginx-0123":   {ContainerID: "nginx-0123", ContainerName: "nginx", NamespaceName: "default", EndPointName: "web", PidNS: 5001, MntNS: 5002},
+		"sidecar-4567": {ContainerID: "sidecar-4567", ContainerName: "sidecar", NamespaceName: "default", EndPointName: "web", PidNS: 5001, MntNS: 5003},
+	}
+	containersLock := new(sync.RWMutex)
+
+	mon := &SystemMonitor{Logger: &feeder.Feeder{Node: &tp.Node{}}, Containers: &containers, ContainersLock: &containersLock}
+	mon.NsMap = map[NsKey]string{}
+	mon.NsMapLock = new(sync.RWMutex)
+	mon.BpfMapLock = new(sync.RWMutex)
+	mon.NamespacePidsMap = map[string]NsVisibility{}
+
+	mon.AddContainerIDToNsMap("nginx-0123", "default", 5001, 5002)
+	mon.AddContainerIDToNsMap("sidecar-4567", "default", 5001, 5003)
+
+	// registering the sidecar doesn't take the events of nginx
+	for containerID, container := range containers {
+		log := mon.UpdateContainerInfoByContainerID(tp.Log{ContainerID: mon.LookupContainerID(container.PidNS, container.MntNS, 0, 0)})
+		if log.ContainerID != containerID || log.ContainerName != container.ContainerName {
+			t.Errorf("[FAIL] Expected the events of %s to be attributed to it (%s, %s)", containerID, log.ContainerID, log.ContainerName)
+		}
+	}
+
+	if keys := mon.NamespacePidsMap["default"].NsKeys; len(keys) != 2 {
+		t.Errorf("[FAIL] Expected the namespaces of both containers (%+v)", keys)
+	}
+
+	// the shared PID namespace is logged once the second container is registered
+	found := false
+	for !found {
+		select {
+		case msg := <-messages:
+			found = strings.Contains(msg.Message, "Detected a container sharing its PID namespace (sidecar-4567/pidns=5001/mntns=5003) with nginx-0123")
+		case <-time.After(5 * time.Second):
+			t.Fatalf("[FAIL] Expected the shared PID namespace to be logged")
+		}
+	}
+
+	// the same container registered again, and the containers with their own PID namespaces, share nothing
+	if shared := sharedPidNamespace(mon.NsMap, NsKey{PidNS: 5001, MntNS: 5002}, "nginx-0123"); len(shared) != 1 || shared[0] != "sidecar-4567" {
+		t.Errorf("[FAIL] Expected nginx to share its PID namespace with the sidecar (%v)", shared)
+	}
+	if shared := sharedPidNamespace(mon.NsMap, NsKey{PidNS: 6001, MntNS: 5002}, "redis"); len(shared) != 0 {
+		t.Errorf("[FAIL] Expected no shared PID namespace (%v)", shared)
+	}
+
+	// removing a container keeps the events of the other one
+	mon.DeleteContainerIDFromNsMap("sidecar-4567", "default", 5001, 5003)
+
+	if cid := mon.LookupContainerID(5001, 5002, 0, 0); cid != "nginx-0123" {
+		t.Errorf("[FAIL] Expected nginx to remain (%s)", cid)
+	}
+	if cid := mon.LookupContainerID(5001, 5003, 0, 0); cid != "" {
+		t.Errorf("[FAIL] Expected the sidecar to be removed (%s)", cid)
+	}
+
+	t.Log("[PASS] Attributed the events of the containers sharing a PID namespace")
+}
@@ -0,0 +1,318 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// ================== //
+// == Recent Execs == //
+// ================== //
+
+// recent exec constants
+const (
+	DefaultRecentExecs    = 128
+	DefaultRecentExecsMax = 65536
+
+	// maximum size of the arguments kept per execution
+	maxRecentExecArgsBytes = 4096
+)
+
+// recentExec Structure
+type recentExec struct {
+	// order of the execution among all the containers, used to evict the oldest ones first
+	seq uint64
+
+	record tp.ExecRecord
+}
+
+// execRing Structure keeps the recent executions of a container, from the oldest to the newest
+type execRing struct {
+	execs []recentExec
+	head  int
+	count int
+}
+
+// push Function adds an execution, and returns whether the oldest one is overwritten
+func (ring *execRing) push(exec recentExec, size int) bool {
+	// a slot freed by an eviction
+	if ring.count < len(ring.execs) {
+		ring.execs[(ring.head+ring.count)%len(ring.execs)] = exec
+		ring.count++
+		return false
+	}
+
+	// the ring grows up to its size, from the oldest to the newest
+	if len(ring.execs) < size {
+		execs := make([]recentExec, 0, len(ring.execs)+1)
+		execs = append(execs, ring.execs[ring.head:]...)
+		execs = append(execs, ring.execs[:ring.head]...)
+
+		ring.execs = append(execs, exec)
+		ring.head = 0
+		ring.count++
+		return false
+	}
+
+	ring.execs[ring.head] = exec
+	ring.head = (ring.head + 1) % len(ring.execs)
+	return true
+}
+
+// oldest Function
+func (ring *execRing) oldest() recentExec {
+	return ring.execs[ring.head]
+}
+
+// pop Function drops the oldest execution
+func (ring *execRing) pop() {
+	ring.execs[ring.head] = recentExec{}
+	ring.head = (ring.head + 1) % len(ring.execs)
+	ring.count--
+}
+
+// RecentExecs keeps the recent executions of each container, regardless of the visibility of the containers
+type RecentExecs struct {
+	// maximum number of the executions per container, and for all the containers
+	PerContainer int
+	Max          int
+
+	// redaction of the arguments (none|hash)
+	Redact string
+
+	rings map[string]*execRing
+	total int
+	seq   uint64
+
+	lock *sync.RWMutex
+}
+
+// NewRecentExecs Function
+func NewRecentExecs(perContainer, maxExecs int, redact string) *RecentExecs {
+	if perContainer <= 0 {
+		perContainer = DefaultRecentExecs
+	}
+	if maxExecs <= 0 {
+		maxExecs = DefaultRecentExecsMax
+	}
+
+	re := &RecentExecs{}
+
+	re.PerContainer = perContainer
+	re.Max = maxExecs
+	re.Redact = redact
+
+	re.rings = map[string]*execRing{}
+	re.lock = new(sync.RWMutex)
+
+	return re
+}
+
+// Len Function returns the number of the executions kept for all the containers
+func (re *RecentExecs) Len() int {
+	re.lock.RLock()
+	defer re.lock.RUnlock()
+
+	return re.total
+}
+
+// hashExecArgs returns the sha256 of the arguments of an execution
+func hashExecArgs(args []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(args, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// boundExecArgs returns the arguments of an execution up to maxRecentExecArgsBytes
+func boundExecArgs(args []string) []string {
+	bounded := []string{}
+	size := 0
+
+	for _, arg := range args {
+		if size+len(arg) > maxRecentExecArgsBytes {
+			bounded = append(bounded, arg[:maxRecentExecArgsBytes-size])
+			break
+		}
+		bounded = append(bounded, arg)
+		size += len(arg)
+	}
+
+	return bounded
+}
+
+// Record Function keeps an execution of a container, evicting the oldest execution of the container once its ring
+// is full, and the oldest execution of all the containers once they reach the maximum
+func (re *RecentExecs) Record(record tp.ExecRecord, args []string) {
+	record.ArgsHash = hashExecArgs(args)
+	if re.Redact == "hash" {
+		record.Redacted = true
+	} else {
+		record.Args = boundExecArgs(args)
+	}
+
+	re.lock.Lock()
+	defer re.lock.Unlock()
+
+	ring, ok := re.rings[record.ContainerID]
+	if !ok {
+		ring = &execRing{}
+		re.rings[record.ContainerID] = ring
+	}
+
+	re.seq++
+
+	if ring.push(recentExec{seq: re.seq, record: record}, re.PerContainer) {
+		return
+	}
+
+	re.total++
+
+	for re.total > re.Max {
+		re.evictOldest()
+	}
+}
+
+// evictOldest Function drops the oldest execution of all the containers (lock should be held)
+func (re *RecentExecs) evictOldest() {
+	oldestID := ""
+	var oldest *execRing
+
+	for containerID, ring := range re.rings {
+		if ring.count == 0 {
+			continue
+		}
+		if oldest == nil || ring.oldest().seq < oldest.oldest().seq {
+			oldestID, oldest = containerID, ring
+		}
+	}
+
+	if oldest == nil {
+		return
+	}
+
+	oldest.pop()
+	re.total--
+
+	if oldest.count == 0 {
+		delete(re.rings, oldestID)
+	}
+}
+
+// Query Function returns the executions of the given containers since the given time, from the oldest to the newest
+func (re *RecentExecs) Query(containerIDs []string, since time.Time) []tp.ExecRecord {
+	execs := []recentExec{}
+
+	re.lock.RLock()
+	for _, containerID := range containerIDs {
+		ring, ok := re.rings[containerID]
+		if !ok {
+			continue
+		}
+
+		for i := 0; i < ring.count; i++ {
+			exec := ring.execs[(ring.head+i)%len(ring.execs)]
+			if exec.record.Timestamp.Before(since) {
+				continue
+			}
+			execs = append(execs, exec)
+		}
+	}
+	re.lock.RUnlock()
+
+	sort.Slice(execs, func(i, j int) bool {
+		return execs[i].seq < execs[j].seq
+	})
+
+	records := make([]tp.ExecRecord, 0, len(execs))
+	for _, exec := range execs {
+		records = append(records, exec.record)
+	}
+
+	return records
+}
+
+// QueryContainer Function returns the executions of a container given by its ID (or a prefix of its ID, matching
+// all the containers with the prefix) since the given time, from the oldest to the newest
+func (re *RecentExecs) QueryContainer(containerID string, since time.Time) []tp.ExecRecord {
+	containerIDs := []string{}
+
+	re.lock.RLock()
+	if _, ok := re.rings[containerID]; ok {
+		containerIDs = append(containerIDs, containerID)
+	} else {
+		for id := range re.rings {
+			if strings.HasPrefix(id, containerID) {
+				containerIDs = append(containerIDs, id)
+			}
+		}
+	}
+	re.lock.RUnlock()
+
+	return re.Query(containerIDs, since)
+}
+
+// QueryPod Function returns the executions of the containers of a pod since the given time, including the
+// containers destroyed already, from the oldest to the newest
+func (re *RecentExecs) QueryPod(namespaceName, podName string, since time.Time) []tp.ExecRecord {
+	containerIDs := []string{}
+
+	re.lock.RLock()
+	for containerID, ring := range re.rings {
+		if ring.count == 0 {
+			continue
+		}
+		if record := ring.oldest().record; record.NamespaceName == namespaceName && record.PodName == podName {
+			containerIDs = append(containerIDs, containerID)
+		}
+	}
+	re.lock.RUnlock()
+
+	return re.Query(containerIDs, since)
+}
+
+// RecordExec Function keeps an execution of a container given by its pid node
+func (mon *SystemMonitor) RecordExec(containerID string, ctx SyscallContext, node tp.PidNode, args []string) {
+	if mon.RecentExecs == nil || containerID == "" {
+		return
+	}
+
+	record := tp.ExecRecord{
+		ContainerID:    containerID,
+		HostPID:        ctx.HostPID,
+		PID:            ctx.PID,
+		PPID:           ctx.PPID,
+		UID:            ctx.UID,
+		ExecPath:       node.ExecPath,
+		ParentExecPath: node.ParentExecPath,
+	}
+
+	record.Timestamp = time.Now()
+	if mon.Clock != nil {
+		record.Timestamp = mon.Clock.PeekWallTime(ctx.Ts)
+	}
+
+	if mon.Containers != nil && mon.ContainersLock != nil {
+		Containers := *(mon.Containers)
+		ContainersLock := *(mon.ContainersLock)
+
+		ContainersLock.RLock()
+		record.NamespaceName = Containers[containerID].NamespaceName
+		record.PodName = Containers[containerID].EndPointName
+		ContainersLock.RUnlock()
+	}
+
+	// the first argument is the path executed
+	if len(args) > 0 {
+		args = args[1:]
+	}
+
+	mon.RecentExecs.Record(record, args)
+}
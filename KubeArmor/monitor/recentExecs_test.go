@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+// execPaths returns the paths of the given executions
+func execPaths(records []tp.ExecRecord) string {
+	paths := []string{}
+	for _, record := range records {
+		paths = append(paths, record.ExecPath)
+	}
+	return strings.Join(paths, ",")
+}
+
+func TestRecentExecs(t *testing.T) {
+	re := NewRecentExecs(3, 5, "none")
+
+	start := time.Now()
+
+	exec := func(containerID, pod string, idx int) {
+		re.Record(tp.ExecRecord{
+			Timestamp:     start.Add(time.Duration(idx) * time.Second),
+			ContainerID:   containerID,
+			NamespaceName: "default",
+			PodName:       pod,
+			ExecPath:      "/bin/" + containerID + strconv.Itoa(idx),
+		}, []string{"-c", "id"})
+	}
+
+	// the ring of a container is filled past its capacity
+	for i := 0; i < 5; i++ {
+		exec("nginx", "web", i)
+	}
+
+	if got := execPaths(re.QueryContainer("nginx", time.Time{})); got != "/bin/nginx2,/bin/nginx3,/bin/nginx4" {
+		t.Errorf("[FAIL] Expected the 3 newest executions from the oldest to the newest (%s)", got)
+	}
+	if re.Len() != 3 {
+		t.Errorf("[FAIL] Expected 3 executions kept (%d)", re.Len())
+	}
+
+	// the other containers are kept together up to the maximum, and the oldest ones of all are evicted first
+	exec("redis", "db", 5)
+	exec("sidecar", "web", 6)
+	exec("redis", "db", 7)
+
+	if re.Len() != 5 {
+		t.Errorf("[FAIL] Expected at most 5 executions kept (%d)", re.Len())
+	}
+	if got := execPaths(re.QueryContainer("nginx", time.Time{})); got != "/bin/nginx3,/bin/nginx4" {
+		t.Errorf("[FAIL] Expected the oldest executions of nginx to be evicted (%s)", got)
+	}
+
+	// the executions of the containers of a pod, from the oldest to the newest
+	if got := execPaths(re.QueryPod("default", "web", time.Time{})); got != "/bin/nginx3,/bin/nginx4,/bin/sidecar6" {
+		t.Errorf("[FAIL] Expected the executions of the pod (%s)", got)
+	}
+
+	// the executions since a given time
+	if got := execPaths(re.QueryPod("default", "web", start.Add(5*time.Second))); got != "/bin/sidecar6" {
+		t.Errorf("[FAIL] Expected the executions since the given time (%s)", got)
+	}
+
+	// a container given by a prefix of its ID
+	if got := execPaths(re.QueryContainer("red", time.Time{})); got != "/bin/redis5,/bin/redis7" {
+		t.Errorf("[FAIL] Expected the executions of the container with the prefix (%s)", got)
+	}
+
+	if got := re.QueryContainer("unknown", time.Time{}); len(got) != 0 {
+		t.Errorf("[FAIL] Expected no executions of an unknown container (%s)", execPaths(got))
+	}
+
+	// a full ring overwrites its own oldest executions only
+	for i := 8; i < 13; i++ {
+		exec("redis", "db", i)
+	}
+
+	if got := execPaths(re.QueryContainer("redis", time.Time{})); got != "/bin/redis10,/bin/redis11,/bin/redis12" {
+		t.Errorf("[FAIL] Expected the 3 newest executions of redis (%s)", got)
+	}
+	if got := execPaths(re.QueryPod("default", "web", time.Time{})); got != "/bin/nginx4,/bin/sidecar6" {
+		t.Errorf("[FAIL] Expected the executions of the pod (%s)", got)
+	}
+
+	// the containers whose executions are all evicted are forgotten
+	exec("cache", "db", 13)
+	exec("cache", "db", 14)
+
+	if got := re.QueryPod("default", "web", time.Time{}); len(got) != 0 {
+		t.Errorf("[FAIL] Expected the executions of the pod to be evicted (%s)", execPaths(got))
+	}
+	if len(re.rings) != 2 || re.Len() != 5 {
+		t.Errorf("[FAIL] Expected only the rings of redis and cache (%d rings, %d executions)", len(re.rings), re.Len())
+	}
+
+	// the ring of a container refilled after evictions keeps the order
+	for i := 15; i < 19; i++ {
+		exec("nginx", "web", i)
+	}
+
+	if got := execPaths(re.QueryContainer("nginx", time.Time{})); got != "/bin/nginx16,/bin/nginx17,/bin/nginx18" {
+		t.Errorf("[FAIL] Expected the executions of the refilled ring in order (%s)", got)
+	}
+	if got := execPaths(re.QueryPod("default", "db", time.Time{})); got != "/bin/cache13,/bin/cache14" {
+		t.Errorf("[FAIL] Expected the executions of redis to be evicted (%s)", got)
+	}
+	if re.Len() != 5 {
+		t.Errorf("[FAIL] Expected at most 5 executions kept (%d)", re.Len())
+	}
+
+	t.Log("[PASS] Kept the recent executions within the bounds")
+}
+
+func TestRecentExecsRedaction(t *testing.T) {
+	args := []string{"-u", "admin", "-p", "secret"}
+
+	plain := NewRecentExecs(1, 1, "none")
+	plain.Record(tp.ExecRecord{ContainerID: "nginx", ExecPath: "/usr/bin/mysql"}, args)
+
+	redacted := NewRecentExecs(1, 1, "hash")
+	redacted.Record(tp.ExecRecord{ContainerID: "nginx", ExecPath: "/usr/bin/mysql"}, args)
+
+	record := plain.QueryContainer("nginx", time.Time{})[0]
+	if strings.Join(record.Args, " ") != "-u admin -p secret" || record.Redacted {
+		t.Errorf("[FAIL] Expected the arguments to be kept (%+v)", record)
+	}
+
+	hashed := redacted.QueryContainer("nginx", time.Time{})[0]
+	if len(hashed.Args) != 0 || !hashed.Redacted {
+		t.Errorf("[FAIL] Expected the arguments to be redacted (%+v)", hashed)
+	}
+
+	if hashed.ArgsHash == "" || hashed.ArgsHash != record.ArgsHash {
+		t.Errorf("[FAIL] Expected the same hash of the arguments (%s, %s)", hashed.ArgsHash, record.ArgsHash)
+	}
+
+	// long arguments are bounded
+	plain.Record(tp.ExecRecord{ContainerID: "nginx"}, []string{strings.Repeat("a", 3000), strings.Repeat("b", 3000)})
+
+	if bounded := plain.QueryContainer("nginx", time.Time{})[0].Args; len(bounded) != 2 || len(bounded[0])+len(bounded[1]) != maxRecentExecArgsBytes {
+		t.Errorf("[FAIL] Expected the arguments to be bounded (%d)", len(bounded))
+	}
+
+	t.Log("[PASS] Redacted the arguments of the recent executions")
+}
+
+func TestRecordExec(t *testing.T) {
+	containers := map[string]tp.Container{"nginx": {ContainerID: "nginx", NamespaceName: "default", EndPointName: "web"}}
+	containersLock := new(sync.RWMutex)
+
+	mon := &SystemMonitor{Containers: &containers, ContainersLock: &containersLock}
+
+	// disabled
+	mon.RecordExec("nginx", SyscallContext{}, tp.PidNode{ExecPath: "/bin/sh"}, []string{"sh"})
+
+	mon.RecentExecs = NewRecentExecs(4, 16, "none")
+
+	ctx := SyscallContext{HostPID: 1234, PID: 7, PPID: 1, UID: 1000}
+	mon.RecordExec("nginx", ctx, tp.PidNode{ExecPath: "/bin/sh", ParentExecPath: "/usr/sbin/nginx"}, []string{"sh", "-c", "id"})
+
+	// the executions of the host aren't kept
+	mon.RecordExec("", ctx, tp.PidNode{ExecPath: "/bin/sh"}, []string{"sh"})
+
+	records := mon.RecentExecs.QueryPod("default", "web", time.Time{})
+	if len(records) != 1 || mon.RecentExecs.Len() != 1 {
+		t.Fatalf("[FAIL] Expected an execution of the pod (%+v)", records)
+	}
+
+	record := records[0]
+	if record.ContainerID != "nginx" || record.HostPID != 1234 || record.UID != 1000 || record.ParentExecPath != "/usr/sbin/nginx" {
+		t.Errorf("[FAIL] Expected the execution of the container (%+v)", record)
+	}
+	if strings.Join(record.Args, " ") != "-c id" || record.Timestamp.IsZero() {
+		t.Errorf("[FAIL] Expected the arguments without the path and a timestamp (%+v)", record)
+	}
+
+	t.Log("[PASS] Recorded the executions of the containers")
+}
@@ -0,0 +1,199 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+)
+
+// ===================== //
+// == Runtime Sockets == //
+// ===================== //
+
+// SocketInode Structure
+type SocketInode struct {
+	Dev uint64
+	Ino uint64
+}
+
+// the interval to stat the sockets again on a miss, as the runtimes recreate them when they restart
+var runtimeSocketsRefreshInterval = 10 * time.Second
+
+// RuntimeSockets Structure
+type RuntimeSockets struct {
+	// paths of the sockets (as mounted in the daemon)
+	paths []string
+
+	// device + inode -> path
+	inodes    map[SocketInode]string
+	refreshed time.Time
+
+	lock *sync.RWMutex
+}
+
+// NewRuntimeSockets Function
+func NewRuntimeSockets() *RuntimeSockets {
+	return &RuntimeSockets{
+		inodes: map[SocketInode]string{},
+		lock:   new(sync.RWMutex),
+	}
+}
+
+// statSocket returns the device and the inode of a socket file
+func statSocket(path string) (SocketInode, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return SocketInode{}, false
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return SocketInode{}, false
+	}
+
+	return SocketInode{Dev: uint64(stat.Dev), Ino: stat.Ino}, true
+}
+
+// Update sets the sockets of the container runtimes, and identifies them by their inodes
+func (rs *RuntimeSockets) Update(paths []string) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+
+	rs.paths = []string{}
+	for _, path := range paths {
+		if path = filepath.Clean(path); !kl.ContainsElement(rs.paths, path) {
+			rs.paths = append(rs.paths, path)
+		}
+	}
+
+	rs.refresh()
+}
+
+// refresh stats the sockets again (the lock is held)
+func (rs *RuntimeSockets) refresh() {
+	rs.inodes = map[SocketInode]string{}
+	for _, path := range rs.paths {
+		if inode, ok := statSocket(path); ok {
+			if _, ok := rs.inodes[inode]; !ok {
+				rs.inodes[inode] = path
+			}
+		}
+	}
+	rs.refreshed = time.Now()
+}
+
+// Inodes returns the inodes of the sockets which exist
+func (rs *RuntimeSockets) Inodes() map[SocketInode]string {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+
+	inodes := map[SocketInode]string{}
+	for inode, path := range rs.inodes {
+		inodes[inode] = path
+	}
+
+	return inodes
+}
+
+// Lookup returns the runtime socket which a process connects to, given the path of the socket in the process
+// (e.g., /var/run/docker.sock mounted at /tmp/d.sock in a container)
+func (rs *RuntimeSockets) Lookup(hostPID uint32, sunPath string) string {
+	// abstract sockets
+	if sunPath == "" {
+		return ""
+	}
+
+	pid := strconv.FormatUint(uint64(hostPID), 10)
+
+	path := kl.GetHostProcPath(pid, "root", sunPath)
+	if !filepath.IsAbs(sunPath) {
+		path = kl.GetHostProcPath(pid, "cwd", sunPath)
+	}
+
+	inode, ok := statSocket(path)
+
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+
+	if !ok {
+		// the process is gone, only the path is left
+		if kl.ContainsElement(rs.paths, filepath.Clean(sunPath)) {
+			return filepath.Clean(sunPath)
+		}
+		return ""
+	}
+
+	socket, found := rs.inodes[inode]
+	if !found && time.Since(rs.refreshed) > runtimeSocketsRefreshInterval {
+		rs.refresh()
+		socket = rs.inodes[inode]
+	}
+
+	return socket
+}
+
+// ===================== //
+// == Daemon Identity == //
+// ===================== //
+
+// procPidInitIno is the inode of the pid namespace of the host (PROC_PID_INIT_INO)
+const procPidInitIno = 0xEFFFFFFC
+
+// DaemonIdentity Structure
+type DaemonIdentity struct {
+	PidNS uint32
+	MntNS uint32
+	PID   uint32 // in its pid namespace
+}
+
+// GetDaemonIdentity returns the namespaces of the daemon and its pid in them, as reported in the events
+func GetDaemonIdentity() DaemonIdentity {
+	identity := DaemonIdentity{PID: uint32(os.Getpid())}
+
+	if info, err := os.Stat("/proc/self/ns/pid"); err == nil {
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			identity.PidNS = uint32(stat.Ino)
+		}
+	}
+
+	if info, err := os.Stat("/proc/self/ns/mnt"); err == nil {
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			identity.MntNS = uint32(stat.Ino)
+		}
+	}
+
+	// the events of the host have no namespaces
+	if identity.PidNS == procPidInitIno {
+		identity.PidNS = 0
+		identity.MntNS = 0
+	}
+
+	return identity
+}
+
+// isDaemon checks if an event is from the daemon itself
+func (mon *SystemMonitor) isDaemon(ctx SyscallContext) bool {
+	return ctx.PidID == mon.Daemon.PidNS && ctx.MntID == mon.Daemon.MntNS && ctx.PID == mon.Daemon.PID
+}
+
+// runtimeSocketOf returns the runtime socket which a container connects to, except for the connections of the daemon
+func (mon *SystemMonitor) runtimeSocketOf(msg ContextCombined, sockAddr map[string]string) string {
+	if sockAddr["sa_family"] != "AF_UNIX" || msg.ContainerID == "" || mon.RuntimeSockets == nil {
+		return ""
+	}
+
+	// the daemon is identified by its namespaces and its pid, whatever the sockets it connects to
+	if mon.isDaemon(msg.ContextSys) {
+		return ""
+	}
+
+	return mon.RuntimeSockets.Lookup(msg.ContextSys.HostPID, sockAddr["sun_path"])
+}
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"net"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+)
+
+func TestRuntimeSocketLogs(t *testing.T) {
+	dir := t.TempDir()
+
+	// the socket of the runtime, and the same socket mounted at another path in a container
+	listener, err := net.Listen("unix", dir+"/containerd.sock")
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to listen on the runtime socket (%s)", err.Error())
+	}
+	defer listener.Close()
+
+	if err := os.Link(dir+"/containerd.sock", dir+"/mounted.sock"); err != nil {
+		t.Fatalf("[FAIL] Failed to mount the runtime socket (%s)", err.Error())
+	}
+
+	other, err := net.Listen("unix", dir+"/app.sock")
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to listen on another socket (%s)", err.Error())
+	}
+	defer other.Close()
+
+	node := tp.Node{}
+	nodeLock := new(sync.RWMutex)
+	containers := map[string]tp.Container{}
+	containersLock := new(sync.RWMutex)
+	activeHostPidMap := map[string]tp.PidMap{}
+	activePidMapLock := new(sync.RWMutex)
+	monitorLock := new(sync.RWMutex)
+
+	mon := NewSystemMonitor(&node, &nodeLock, nil, &containers, &containersLock, &activeHostPidMap, &activePidMapLock, &monitorLock)
+	mon.RuntimeSockets.Update([]string{dir + "/containerd.sock", dir + "/missing.sock"})
+	mon.Daemon = DaemonIdentity{PidNS: 11, MntNS: 12, PID: 7}
+
+	if inodes := mon.RuntimeSockets.Inodes(); len(inodes) != 1 {
+		t.Errorf("[FAIL] Expected the existing socket to be identified (%v)", inodes)
+	}
+
+	// policies
+	logger := &feeder.Feeder{}
+	logger.Enforcer = "BPFLSM"
+	logger.SecurityPolicies = map[string]tp.MatchPolicies{}
+	logger.SecurityPoliciesLock = new(sync.RWMutex)
+	logger.DefaultPostures = map[string]tp.DefaultPosture{}
+	logger.DefaultPosturesLock = new(sync.Mutex)
+
+	secPolicy := tp.SecurityPolicy{Metadata: map[string]string{"policyName": "block-runtime-sockets"}}
+	secPolicy.Spec.Network.MatchRuntimeSockets = []tp.NetworkRuntimeSocketType{
+		{FromSource: []tp.MatchSourceType{{Path: "/usr/bin/docker"}}, Severity: 5, Action: "Audit"},
+		{Severity: 9, Action: "Block"},
+	}
+
+	endPoint := tp.EndPoint{NamespaceName: "default", EndPointName: "ci-runner", PolicyEnabled: tp.KubeArmorPolicyEnabled}
+	endPoint.SecurityPolicies = []tp.SecurityPolicy{secPolicy}
+	logger.UpdateSecurityPolicies("ADDED", endPoint)
+
+	for _, tc := range []struct {
+		name    string
+		ctx     SyscallContext
+		sunPath string
+		source  string
+		result  string
+		socket  string
+		action  string
+	}{
+		{"the mounted runtime socket", SyscallContext{PidID: 21, MntID: 22, PID: 7}, dir + "/mounted.sock", "/usr/bin/curl", "Permission denied", dir + "/containerd.sock", "Block"},
+		{"the runtime socket from the source", SyscallContext{PidID: 21, MntID: 22, PID: 8}, dir + "/containerd.sock", "/usr/bin/docker", "Passed", dir + "/containerd.sock", "Audit"},
+		{"another socket", SyscallContext{PidID: 21, MntID: 22, PID: 7}, dir + "/app.sock", "/usr/bin/curl", "Passed", "", ""},
+		{"the daemon", SyscallContext{PidID: 11, MntID: 12, PID: 7}, dir + "/containerd.sock", "/usr/bin/curl", "Passed", "", ""},
+	} {
+		tc.ctx.HostPID = uint32(os.Getpid())
+
+		sockAddr := map[string]string{"sa_family": "AF_UNIX", "sun_path": tc.sunPath}
+		msg := ContextCombined{ContainerID: "ci-runner", ContextSys: tc.ctx}
+
+		socket := mon.runtimeSocketOf(msg, sockAddr)
+		if socket != tc.socket {
+			t.Errorf("[FAIL] Unexpected runtime socket for %s (%s)", tc.name, socket)
+			continue
+		}
+
+		log := tp.Log{ContainerID: "ci-runner", NamespaceName: "default", PodName: "ci-runner", ProcessName: tc.source, Result: tc.result, Operation: "Network"}
+		log.Resource = "sa_family=AF_UNIX sun_path=" + tc.sunPath
+		if socket != "" {
+			log.Resource = log.Resource + " runtime_socket=" + socket
+		}
+		log = logger.UpdateMatchedPolicy(log)
+
+		if tc.action == "" {
+			if log.Type != "ContainerLog" {
+				t.Errorf("[FAIL] Unexpected alert for %s (%s, %s)", tc.name, log.Type, log.PolicyName)
+			}
+		} else if log.Type != "MatchedPolicy" || log.PolicyName != "block-runtime-sockets" || log.Action != tc.action {
+			t.Errorf("[FAIL] Expected an alert for %s (%s, %s, %s)", tc.name, log.Type, log.PolicyName, log.Action)
+		}
+	}
+
+	// Block rules are audited by the other enforcers
+	logger.Enforcer = "AppArmor"
+	logger.UpdateSecurityPolicies("ADDED", endPoint)
+
+	if policies := logger.SecurityPolicies["default_ci-runner"].Policies; len(policies) != 2 || policies[1].Action != "Audit (Block)" {
+		t.Errorf("[FAIL] Expected the runtime socket rule to be audited with AppArmor (%v)", policies)
+	}
+
+	t.Log("[PASS] Attributed the connections to the runtime sockets")
+}
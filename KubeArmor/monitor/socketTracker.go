@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+)
+
+// ==================== //
+// == Socket Tracker == //
+// ==================== //
+
+// DefaultSocketTrackerSize is the maximum number of sockets tracked by default
+const DefaultSocketTrackerSize = 65536
+
+// SocketOwner Structure
+type SocketOwner struct {
+	HostPID     uint32
+	ProcessName string
+}
+
+// String Function
+func (so SocketOwner) String() string {
+	return so.ProcessName + " (pid=" + strconv.FormatUint(uint64(so.HostPID), 10) + ")"
+}
+
+// socketEntry Structure
+type socketEntry struct {
+	id    uint64
+	owner SocketOwner
+
+	// the processes known to hold the socket (its creator and the ones which used it)
+	holders map[uint32]struct{}
+}
+
+// SocketTracker keeps track of the process which created each socket, so that the sockets inherited across fork/exec
+// (at any depth) or passed over unix sockets (SCM_RIGHTS) can be attributed to their creator
+//
+// The sockets are identified by the cookies given to them on their creation by the system monitor, which are kept by
+// all the fds of a socket, unlike the fds themselves, and never reused, unlike the inode numbers of their files.
+type SocketTracker struct {
+	// socket id -> element of the eviction list
+	sockets map[uint64]*list.Element
+	order   *list.List
+
+	// host pid -> ids of the sockets held by the process
+	processes map[uint32]map[uint64]struct{}
+
+	socketsLock *sync.Mutex
+
+	// maximum number of tracked sockets
+	maxSize int
+}
+
+// NewSocketTracker Function
+func NewSocketTracker(maxSize int) *SocketTracker {
+	if maxSize <= 0 {
+		maxSize = DefaultSocketTrackerSize
+	}
+
+	st := &SocketTracker{}
+
+	st.sockets = map[uint64]*list.Element{}
+	st.order = list.New()
+
+	st.processes = map[uint32]map[uint64]struct{}{}
+
+	st.socketsLock = new(sync.Mutex)
+
+	st.maxSize = maxSize
+
+	return st
+}
+
+// Len Function
+func (st *SocketTracker) Len() int {
+	st.socketsLock.Lock()
+	defer st.socketsLock.Unlock()
+
+	return len(st.sockets)
+}
+
+// addHolder records a process holding a socket (lock should be held)
+func (st *SocketTracker) addHolder(entry *socketEntry, hostPID uint32) {
+	entry.holders[hostPID] = struct{}{}
+
+	if _, ok := st.processes[hostPID]; !ok {
+		st.processes[hostPID] = map[uint64]struct{}{}
+	}
+	st.processes[hostPID][entry.id] = struct{}{}
+}
+
+// evict forgets a socket (lock should be held)
+func (st *SocketTracker) evict(elem *list.Element) {
+	entry := elem.Value.(*socketEntry)
+
+	for hostPID := range entry.holders {
+		delete(st.processes[hostPID], entry.id)
+		if len(st.processes[hostPID]) == 0 {
+			delete(st.processes, hostPID)
+		}
+	}
+
+	st.order.Remove(elem)
+	delete(st.sockets, entry.id)
+}
+
+// AddSocket records the creator of a socket, evicting the oldest entries once full
+func (st *SocketTracker) AddSocket(id uint64, owner SocketOwner) {
+	if id == 0 {
+		return
+	}
+
+	st.socketsLock.Lock()
+	defer st.socketsLock.Unlock()
+
+	// the creation of the socket was reported again, the latest one wins
+	if elem, ok := st.sockets[id]; ok {
+		st.evict(elem)
+	}
+
+	for len(st.sockets) >= st.maxSize {
+		oldest := st.order.Front()
+		if oldest == nil {
+			break
+		}
+		st.evict(oldest)
+	}
+
+	entry := &socketEntry{id: id, owner: owner, holders: map[uint32]struct{}{}}
+	st.addHolder(entry, owner.HostPID)
+
+	st.sockets[id] = st.order.PushBack(entry)
+}
+
+// RemoveSocket forgets a socket once its last fd is closed
+func (st *SocketTracker) RemoveSocket(id uint64) {
+	st.socketsLock.Lock()
+	defer st.socketsLock.Unlock()
+
+	if elem, ok := st.sockets[id]; ok {
+		st.evict(elem)
+	}
+}
+
+// RemoveProcess forgets an exited process, and evicts the sockets no other known process holds
+func (st *SocketTracker) RemoveProcess(hostPID uint32) {
+	st.socketsLock.Lock()
+	defer st.socketsLock.Unlock()
+
+	for id := range st.processes[hostPID] {
+		elem, ok := st.sockets[id]
+		if !ok {
+			continue
+		}
+
+		entry := elem.Value.(*socketEntry)
+		if delete(entry.holders, hostPID); len(entry.holders) == 0 {
+			st.evict(elem)
+		}
+	}
+
+	delete(st.processes, hostPID)
+}
+
+// GetSocketCreator returns the creator of a socket
+func (st *SocketTracker) GetSocketCreator(id uint64) (SocketOwner, bool) {
+	st.socketsLock.Lock()
+	defer st.socketsLock.Unlock()
+
+	if elem, ok := st.sockets[id]; ok {
+		return elem.Value.(*socketEntry).owner, true
+	}
+
+	return SocketOwner{}, false
+}
+
+// GetSocketCreatorIfDiffers returns the creator of a socket used by the given process only if it is not the process,
+// keeping the socket while the process holds it
+func (st *SocketTracker) GetSocketCreatorIfDiffers(id uint64, hostPID uint32, processName string) string {
+	st.socketsLock.Lock()
+	defer st.socketsLock.Unlock()
+
+	elem, ok := st.sockets[id]
+	if !ok {
+		return ""
+	}
+
+	entry := elem.Value.(*socketEntry)
+	st.addHolder(entry, hostPID)
+
+	// the socket has been passed to another process or inherited across fork/exec
+	if entry.owner.HostPID != hostPID || entry.owner.ProcessName != processName {
+		return entry.owner.String()
+	}
+
+	return ""
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"testing"
+)
+
+func TestSocketTracker(t *testing.T) {
+	st := NewSocketTracker(2)
+
+	st.AddSocket(4242, SocketOwner{HostPID: 100, ProcessName: "/usr/bin/python3"})
+
+	// same process, nothing to report
+	if creator := st.GetSocketCreatorIfDiffers(4242, 100, "/usr/bin/python3"); creator != "" {
+		t.Errorf("[FAIL] Unexpected socket creator (%s)", creator)
+	}
+
+	// inherited across exec
+	if creator := st.GetSocketCreatorIfDiffers(4242, 100, "/usr/bin/curl"); creator != "/usr/bin/python3 (pid=100)" {
+		t.Errorf("[FAIL] Unexpected socket creator across exec (%s)", creator)
+	}
+
+	// inherited through fork, by a grandchild, or passed over a unix socket (with another fd)
+	for _, hostPID := range []uint32{101, 102, 200} {
+		if creator := st.GetSocketCreatorIfDiffers(4242, hostPID, "/usr/bin/curl"); creator != "/usr/bin/python3 (pid=100)" {
+			t.Errorf("[FAIL] Unexpected socket creator for pid %d (%s)", hostPID, creator)
+		}
+	}
+
+	// kept while the processes which used it are alive
+	st.RemoveProcess(100)
+	st.RemoveProcess(101)
+	st.RemoveProcess(102)
+	if _, ok := st.GetSocketCreator(4242); !ok {
+		t.Errorf("[FAIL] Socket was evicted while held by a process")
+	}
+
+	// evicted once they all exited
+	st.RemoveProcess(200)
+	if _, ok := st.GetSocketCreator(4242); ok {
+		t.Errorf("[FAIL] Socket was not evicted on exit")
+	}
+
+	// bounded in size
+	st.AddSocket(1, SocketOwner{HostPID: 100, ProcessName: "a"})
+	st.AddSocket(2, SocketOwner{HostPID: 100, ProcessName: "b"})
+	st.AddSocket(3, SocketOwner{HostPID: 100, ProcessName: "c"})
+
+	if st.Len() != 2 {
+		t.Errorf("[FAIL] Expected 2 tracked sockets, got %d", st.Len())
+	}
+	if _, ok := st.GetSocketCreator(1); ok {
+		t.Errorf("[FAIL] The oldest socket was not evicted")
+	}
+
+	// a creation reported again replaces the socket
+	st.AddSocket(3, SocketOwner{HostPID: 300, ProcessName: "d"})
+	if owner, _ := st.GetSocketCreator(3); owner.HostPID != 300 {
+		t.Errorf("[FAIL] Expected the latest socket, got %s", owner.String())
+	}
+
+	st.RemoveProcess(100)
+	if st.Len() != 1 || len(st.processes) != 1 {
+		t.Errorf("[FAIL] Expected 1 tracked socket and process, got %d and %d", st.Len(), len(st.processes))
+	}
+
+	// evicted once its last fd is closed, even while held by a process
+	st.RemoveSocket(3)
+	if _, ok := st.GetSocketCreator(3); ok || st.Len() != 0 || len(st.processes) != 0 {
+		t.Errorf("[FAIL] Socket was not evicted on close (%d sockets, %d processes)", st.Len(), len(st.processes))
+	}
+
+	t.Log("[PASS] Tracked socket creators")
+}
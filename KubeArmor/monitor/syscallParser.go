@@ -14,6 +14,8 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/netip"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -38,6 +40,9 @@ const (
 	ptraceReqT    uint8 = 23
 	mountFlagT    uint8 = 24
 	umountFlagT   uint8 = 25
+	fileFlagsT    uint8 = 26
+	nsFlagsT      uint8 = 27
+	sockIDT       uint8 = 28
 )
 
 // ======================= //
@@ -86,6 +91,13 @@ func readUInt32FromBuff(buff io.Reader) (uint32, error) {
 	return res, err
 }
 
+// readUInt64FromBuff Function
+func readUInt64FromBuff(buff io.Reader) (uint64, error) {
+	var res uint64
+	err := binary.Read(buff, binary.LittleEndian, &res)
+	return res, err
+}
+
 // readUInt32BigendFromBuff Function
 func readUInt32BigendFromBuff(buff io.Reader) (uint32, error) {
 	var res uint32
@@ -196,11 +208,25 @@ func readSockaddrFromBuff(buff io.Reader) (map[string]string, error) {
 			return nil, fmt.Errorf("error parsing sockaddr_in: %v", err)
 		}
 		res["sin_addr"] = readUint32IP(addr)
+
+		// sin_zero
+		if _, err := readByteSliceFromBuff(buff, 8); err != nil {
+			return nil, fmt.Errorf("error parsing sockaddr_in: %v", err)
+		}
 	case 10: // AF_INET6
-		// https://man7.org/linux/man-pages/man7/ipv6.7.html
+		/*
+			https://man7.org/linux/man-pages/man7/ipv6.7.html
+			struct sockaddr_in6 {
+				sa_family_t     sin6_family;   // AF_INET6
+				in_port_t       sin6_port;     // port number
+				uint32_t        sin6_flowinfo; // IPv6 flow information
+				struct in6_addr sin6_addr;     // IPv6 address
+				uint32_t        sin6_scope_id; // Scope ID
+			};
+		*/
 		port, err := readUInt16BigendFromBuff(buff)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing sockaddr_in: %v", err)
+			return nil, fmt.Errorf("error parsing sockaddr_in6: %v", err)
 		}
 
 		res["sin_port"] = strconv.Itoa(int(port))
@@ -212,16 +238,48 @@ func readSockaddrFromBuff(buff io.Reader) (map[string]string, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error parsing IPv6 IP: %v", err)
 		}
-		ipv6 := net.IP{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
-		n := copy(ipv6, addr)
-		if n != 16 {
-			return nil, fmt.Errorf("error Converting bytes to IPv6, copied only %d bytes out of 16", n)
+		scopeID, err := readUInt32FromBuff(buff)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing IPv6 scope ID: %v", err)
 		}
-		res["sin_addr"] = ipv6.String()
+		res["sin_addr"] = readIPv6(addr, scopeID)
 	}
 	return res, nil
 }
 
+// readIPv6 formats an IPv6 address, the v4-mapped addresses in their IPv4 form (as the connections of the dual-stack
+// sockets to IPv4 peers), and the link-local addresses with their zone (the index of the interface)
+func readIPv6(addr []byte, scopeID uint32) string {
+	ip, ok := netip.AddrFromSlice(addr)
+	if !ok {
+		return ""
+	}
+
+	ip = ip.Unmap()
+
+	if scopeID != 0 && ip.Is6() && (ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast()) {
+		ip = ip.WithZone(strconv.FormatUint(uint64(scopeID), 10))
+	}
+
+	return ip.String()
+}
+
+// formatSockaddr formats the fields of a socket address (key=value, in the order of the keys)
+func formatSockaddr(sockAddr map[string]string) string {
+	keys := make([]string, 0, len(sockAddr))
+	for k := range sockAddr {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]string, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, k+"="+sockAddr[k])
+	}
+
+	return strings.Join(fields, " ")
+}
+
 // getUnlinkAtFlag Function
 func getUnlinkAtFlag(flag uint32) string {
 	var f = ""
@@ -359,6 +417,85 @@ func getUmountFlags(flag uint32) string {
 	}
 }
 
+// getFileFlags Function
+func getFileFlags(flags uint32) string {
+	// inode flags set by FS_IOC_SETFLAGS (chattr)
+	// https://elixir.bootlin.com/linux/v5.15/source/include/uapi/linux/fs.h
+
+	var f []string
+
+	if flags&0x00000008 == 0x00000008 {
+		f = append(f, "FS_SYNC_FL")
+	}
+	if flags&0x00000010 == 0x00000010 {
+		f = append(f, "FS_IMMUTABLE_FL")
+	}
+	if flags&0x00000020 == 0x00000020 {
+		f = append(f, "FS_APPEND_FL")
+	}
+	if flags&0x00000040 == 0x00000040 {
+		f = append(f, "FS_NODUMP_FL")
+	}
+	if flags&0x00000080 == 0x00000080 {
+		f = append(f, "FS_NOATIME_FL")
+	}
+
+	// other flags (e.g., FS_EXTENT_FL)
+	if rest := flags &^ 0x000000F8; rest != 0 {
+		f = append(f, fmt.Sprintf("0x%x", rest))
+	}
+
+	if len(f) == 0 {
+		return "0"
+	}
+
+	return strings.Join(f, "|")
+}
+
+// getNamespaceFlags Function
+func getNamespaceFlags(flags uint32) string {
+	// namespace flags of unshare/setns
+	// https://elixir.bootlin.com/linux/v5.15/source/include/uapi/linux/sched.h
+
+	var f []string
+
+	if flags&0x00000080 == 0x00000080 {
+		f = append(f, "CLONE_NEWTIME")
+	}
+	if flags&0x00020000 == 0x00020000 {
+		f = append(f, "CLONE_NEWNS")
+	}
+	if flags&0x02000000 == 0x02000000 {
+		f = append(f, "CLONE_NEWCGROUP")
+	}
+	if flags&0x04000000 == 0x04000000 {
+		f = append(f, "CLONE_NEWUTS")
+	}
+	if flags&0x08000000 == 0x08000000 {
+		f = append(f, "CLONE_NEWIPC")
+	}
+	if flags&0x10000000 == 0x10000000 {
+		f = append(f, "CLONE_NEWUSER")
+	}
+	if flags&0x20000000 == 0x20000000 {
+		f = append(f, "CLONE_NEWPID")
+	}
+	if flags&0x40000000 == 0x40000000 {
+		f = append(f, "CLONE_NEWNET")
+	}
+
+	// other flags (e.g., CLONE_FILES)
+	if rest := flags &^ 0x7E020080; rest != 0 {
+		f = append(f, fmt.Sprintf("0x%x", rest))
+	}
+
+	if len(f) == 0 {
+		return "0"
+	}
+
+	return strings.Join(f, "|")
+}
+
 // getOpenFlags Function
 func getOpenFlags(flags uint32) string {
 	// readOpenFlags prints the `flags` bitmask argument of the `open` syscall
@@ -887,6 +1024,18 @@ func readArgFromBuff(dataBuff io.Reader) (interface{}, error) {
 			return nil, err
 		}
 		res = getUmountFlags(req)
+	case nsFlagsT:
+		flags, err := readUInt32FromBuff(dataBuff)
+		if err != nil {
+			return nil, err
+		}
+		res = getNamespaceFlags(flags)
+	case fileFlagsT:
+		flags, err := readUInt32FromBuff(dataBuff)
+		if err != nil {
+			return nil, err
+		}
+		res = getFileFlags(flags)
 	case sockDomT:
 		dom, err := readUInt32FromBuff(dataBuff)
 		if err != nil {
@@ -899,6 +1048,12 @@ func readArgFromBuff(dataBuff io.Reader) (interface{}, error) {
 			return nil, err
 		}
 		res = GetSocketType(t)
+	case sockIDT:
+		id, err := readUInt64FromBuff(dataBuff)
+		if err != nil {
+			return nil, err
+		}
+		res = id
 	default:
 		return nil, fmt.Errorf("error unknown argument type %v", at)
 	}
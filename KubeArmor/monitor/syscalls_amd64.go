@@ -17,6 +17,17 @@ const (
 	SysChown    = 92
 	SysFChownAt = 260
 
+	SysSetxattr     = 188
+	SysLSetxattr    = 189
+	SysFSetxattr    = 190
+	SysRemovexattr  = 197
+	SysLRemovexattr = 198
+	SysFRemovexattr = 199
+	SysIoctl        = 16
+
+	SysUnshare = 272
+	SysSetns   = 308
+
 	SysSetuid = 105
 	SysSetgid = 106
 
@@ -36,6 +47,7 @@ const (
 
 	DoExit            = 351
 	SecurityBprmCheck = 352
+	SecurityTaskKill  = 353
 
 	TCPConnect   = 400
 	TCPAccept    = 401
@@ -398,6 +410,7 @@ var syscalls = map[int32]string{
 
 	351: "DO_EXIT",
 	352: "SECURITY_BPRM_CHECK",
+	353: "SECURITY_TASK_KILL",
 	450: "FILE_OPEN",
 	451: "FILE_PERMISSION",
 	452: "FILE_MKNOD",
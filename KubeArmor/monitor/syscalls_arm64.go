@@ -20,6 +20,17 @@ const (
 	SysUnlinkAt = 35
 	SysFChownAt = 54
 
+	SysSetxattr     = 5
+	SysLSetxattr    = 6
+	SysFSetxattr    = 7
+	SysRemovexattr  = 14
+	SysLRemovexattr = 15
+	SysFRemovexattr = 16
+	SysIoctl        = 29
+
+	SysUnshare = 97
+	SysSetns   = 268
+
 	SysSetuid = 146
 	SysSetgid = 144
 
@@ -39,6 +50,7 @@ const (
 
 	DoExit            = 351
 	SecurityBprmCheck = 352
+	SecurityTaskKill  = 353
 
 	TCPConnect   = 400
 	TCPAccept    = 401
@@ -360,6 +372,7 @@ var syscalls = map[int32]string{
 	291: "SYS_STATX",
 
 	352: "SECURITY_BPRM_CHECK",
+	353: "SECURITY_TASK_KILL",
 	450: "FILE_OPEN",
 	451: "FILE_PERMISSION",
 	452: "FILE_MKNOD",
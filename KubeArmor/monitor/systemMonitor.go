@@ -14,7 +14,6 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-	"time"
 
 	cle "github.com/cilium/ebpf"
 
@@ -37,8 +36,26 @@ const (
 	visibilityOn     = uint32(0)
 	// how many event the channel can hold
 	SyscallChannelSize = 1 << 13 //8192
+	// how many pages the per-CPU buffer of the events has
+	eventBufferPages = 1024
 )
 
+// EventChannelSize returns how many events the channels in userspace can hold
+func EventChannelSize() int {
+	if cfg.GlobalCfg.EventChannelSize > 0 {
+		return cfg.GlobalCfg.EventChannelSize
+	}
+	return SyscallChannelSize
+}
+
+// EventBufferPages returns how many pages the per-CPU buffer of the events has
+func EventBufferPages() int {
+	if cfg.GlobalCfg.EventBufferPages > 0 {
+		return cfg.GlobalCfg.EventBufferPages
+	}
+	return eventBufferPages
+}
+
 // ======================= //
 // == Namespace Context == //
 // ======================= //
@@ -56,6 +73,7 @@ type NsVisibility struct {
 	Process    bool
 	Capability bool
 	Network    bool
+	Signal     bool
 }
 
 // ===================== //
@@ -124,6 +142,15 @@ type SystemMonitor struct {
 	NsMap     map[NsKey]string
 	NsMapLock *sync.RWMutex
 
+	// stale entries of NsMap collected
+	NsMapGCStats NsMapGCStats
+
+	// NsMap kept across restarts to validate the pinned visibility map
+	BootID         string
+	NsMapStatePath string
+	NsMapStateLock *sync.Mutex
+	nsMapAdoption  *nsMapAdoption
+
 	// system monitor
 	BpfModule            *cle.Collection
 	BpfNsVisibilityMap   *cle.Map
@@ -135,7 +162,14 @@ type SystemMonitor struct {
 	PinPath          string
 
 	// Probes Links
-	Probes map[string]link.Link
+	Probes     map[string]link.Link
+	ProbesLock *sync.Mutex
+
+	// event classes whose probes are detached while nothing needs them
+	probeSpecs       []probeSpec
+	probesClosed     bool
+	EventClasses     map[string]*EventClassState
+	eventClassUpdate chan struct{}
 
 	// context + args
 	ContextChan chan ContextCombined
@@ -149,6 +183,31 @@ type SystemMonitor struct {
 
 	execLogMap     map[uint32]tp.Log
 	execLogMapLock *sync.RWMutex
+
+	// socket -> creating process
+	SocketTracker *SocketTracker
+
+	// sockets of the container runtimes, and the identity of the daemon connecting to them
+	RuntimeSockets *RuntimeSockets
+	Daemon         DaemonIdentity
+
+	// sessions of the processes unknown to the process tree
+	sessionCache     map[sessionKey]string
+	sessionCacheSize int
+	sessionCacheLock *sync.RWMutex
+
+	// outgoing connections per destination (nil if disabled)
+	FlowTable *FlowTable
+
+	// recent executions per container for incident triage (nil if disabled)
+	RecentExecs *RecentExecs
+
+	// alerts of the events whose container can't be attributed (nil unless the strict attribution is enabled)
+	Unattributed *UnattributedActivity
+
+	// kernel timestamp -> wall-clock time
+	Clock *ClockConverter
+
 	// monitor lock
 	MonitorLock **sync.RWMutex
 
@@ -174,6 +233,7 @@ func NewSystemMonitor(node *tp.Node, nodeLock **sync.RWMutex, logger *fd.Feeder,
 
 	mon.NsMap = make(map[NsKey]string)
 	mon.NsMapLock = new(sync.RWMutex)
+	mon.NsMapStateLock = new(sync.Mutex)
 
 	mon.ContextChan = make(chan ContextCombined, 4096)
 
@@ -186,6 +246,37 @@ func NewSystemMonitor(node *tp.Node, nodeLock **sync.RWMutex, logger *fd.Feeder,
 	mon.execLogMap = map[uint32]tp.Log{}
 	mon.execLogMapLock = new(sync.RWMutex)
 
+	mon.SocketTracker = NewSocketTracker(cfg.GlobalCfg.EnrichmentCacheSize)
+
+	mon.RuntimeSockets = NewRuntimeSockets()
+	mon.Daemon = GetDaemonIdentity()
+
+	mon.sessionCache = map[sessionKey]string{}
+	mon.sessionCacheSize = cfg.GlobalCfg.EnrichmentCacheSize
+	if mon.sessionCacheSize <= 0 {
+		mon.sessionCacheSize = maxSessionCacheSize
+	}
+	mon.sessionCacheLock = new(sync.RWMutex)
+
+	if cfg.GlobalCfg.FlowSummaryInterval > 0 {
+		mon.FlowTable = NewFlowTable(cfg.GlobalCfg.FlowSummaryMaxFlows)
+	}
+
+	if cfg.GlobalCfg.RecentExecs > 0 {
+		mon.RecentExecs = NewRecentExecs(cfg.GlobalCfg.RecentExecs, cfg.GlobalCfg.RecentExecsMax, cfg.GlobalCfg.CaptureRedact)
+	}
+
+	if cfg.GlobalCfg.StrictAttribution {
+		mon.Unattributed = NewUnattributedActivity()
+	}
+
+	mon.Clock = NewClockConverter(DefaultClockDriftThreshold)
+
+	mon.Probes = make(map[string]link.Link)
+	mon.ProbesLock = new(sync.Mutex)
+	mon.EventClasses = map[string]*EventClassState{}
+	mon.eventClassUpdate = make(chan struct{}, 1)
+
 	mon.BpfMapLock = new(sync.RWMutex)
 	mon.NsVisibilityMap = make(map[NsKey]*cle.Map)
 	mon.NamespacePidsMap = make(map[string]NsVisibility)
@@ -193,7 +284,7 @@ func NewSystemMonitor(node *tp.Node, nodeLock **sync.RWMutex, logger *fd.Feeder,
 		Type:       cle.Array,
 		KeySize:    4,
 		ValueSize:  4,
-		MaxEntries: 4,
+		MaxEntries: 5,
 	}
 
 	// assign the value of untracked ns from GlobalCfg
@@ -208,24 +299,46 @@ func NewSystemMonitor(node *tp.Node, nodeLock **sync.RWMutex, logger *fd.Feeder,
 
 // InitBPFMaps Function
 func (mon *SystemMonitor) initBPFMaps() error {
-	visibilityMap, err := cle.NewMapWithOptions(
-		&cle.MapSpec{
-			Name:       "kubearmor_visibility",
-			Type:       cle.HashOfMaps,
-			KeySize:    8,
-			ValueSize:  4,
-			MaxEntries: 65535,
-			Pinning:    cle.PinByName,
-			InnerMap:   &mon.BpfVisibilityMapSpec,
-		}, cle.MapOptions{
-			PinPath: mon.PinPath,
-		})
+	spec := &cle.MapSpec{
+		Name:       "kubearmor_visibility",
+		Type:       cle.HashOfMaps,
+		KeySize:    8,
+		ValueSize:  4,
+		MaxEntries: 65535,
+		Pinning:    cle.PinByName,
+		InnerMap:   &mon.BpfVisibilityMapSpec,
+	}
+
+	visibilityMap, err := cle.NewMapWithOptions(spec, cle.MapOptions{PinPath: mon.PinPath})
 	mon.BpfNsVisibilityMap = visibilityMap
+	if err == nil && !mon.visibilityMapCompatible() {
+		// pinned by an older version, whose inner maps have fewer visibility classes
+		mon.Logger.Warn("Recreating the pinned map kubearmor_visibility for the new visibility classes")
+		mon.DestroyBPFMaps()
+
+		visibilityMap, err = cle.NewMapWithOptions(spec, cle.MapOptions{PinPath: mon.PinPath})
+		mon.BpfNsVisibilityMap = visibilityMap
+	}
+	if err == nil {
+		mon.startNsMapAdoption()
+	}
 	mon.UpdateHostVisibility()
 
 	return err
 }
 
+// visibilityMapCompatible checks if the inner maps of the visibility classes fit in the visibility map
+func (mon *SystemMonitor) visibilityMapCompatible() bool {
+	innerMap, err := cle.NewMap(&mon.BpfVisibilityMapSpec)
+	if err != nil {
+		return true
+	}
+	defer innerMap.Close()
+
+	// the key of the host, which is updated right after
+	return mon.BpfNsVisibilityMap.Put(NsKey{PidNS: 0, MntNS: 0}, innerMap) == nil
+}
+
 // DestroyBPFMaps Function
 func (mon *SystemMonitor) DestroyBPFMaps() {
 	if mon.BpfNsVisibilityMap == nil {
@@ -244,6 +357,11 @@ func (mon *SystemMonitor) DestroyBPFMaps() {
 
 // UpdateNsKeyMap Function
 func (mon *SystemMonitor) UpdateNsKeyMap(action string, nsKey NsKey, visibility tp.Visibility) {
+	// skip if the BPF maps are not loaded
+	if mon.BpfNsVisibilityMap == nil {
+		return
+	}
+
 	var err error
 
 	file := cle.MapKV{
@@ -262,6 +380,10 @@ func (mon *SystemMonitor) UpdateNsKeyMap(action string, nsKey NsKey, visibility
 		Key:   uint32(3),
 		Value: visibilityOff,
 	}
+	signal := cle.MapKV{
+		Key:   uint32(4),
+		Value: visibilityOff,
+	}
 	if visibility.File {
 		file.Value = visibilityOn
 	}
@@ -274,6 +396,9 @@ func (mon *SystemMonitor) UpdateNsKeyMap(action string, nsKey NsKey, visibility
 	if visibility.Network {
 		network.Value = visibilityOn
 	}
+	if visibility.Signal {
+		signal.Value = visibilityOn
+	}
 
 	if action == "ADDED" {
 		spec := mon.BpfVisibilityMapSpec
@@ -281,6 +406,7 @@ func (mon *SystemMonitor) UpdateNsKeyMap(action string, nsKey NsKey, visibility
 		spec.Contents = append(spec.Contents, process)
 		spec.Contents = append(spec.Contents, network)
 		spec.Contents = append(spec.Contents, capability)
+		spec.Contents = append(spec.Contents, signal)
 		visibilityMap, err := cle.NewMap(&spec)
 		if err != nil {
 			mon.Logger.Warnf("Cannot create bpf map %s", err)
@@ -315,6 +441,10 @@ func (mon *SystemMonitor) UpdateNsKeyMap(action string, nsKey NsKey, visibility
 		if err != nil {
 			mon.Logger.Warnf("Cannot update visibility map. nskey=%+v, value=%+v, scope=capability", nsKey, capability.Value)
 		}
+		err = visibilityMap.Put(signal.Key, signal.Value)
+		if err != nil {
+			mon.Logger.Warnf("Cannot update visibility map. nskey=%+v, value=%+v, scope=signal", nsKey, signal.Value)
+		}
 		mon.Logger.Printf("Updated visibility map with key=%+v for cid %s", nsKey, mon.NsMap[nsKey])
 	} else if action == "DELETED" {
 		err := mon.BpfNsVisibilityMap.Delete(nsKey)
@@ -349,12 +479,26 @@ func (mon *SystemMonitor) UpdateHostVisibility() {
 		if strings.Contains(visibilityParams, "capabilities") {
 			visibility.Capabilities = true
 		}
+		if strings.Contains(visibilityParams, "signal") {
+			visibility.Signal = true
+		}
 	}
 	mon.BpfMapLock.Lock()
 	defer mon.BpfMapLock.Unlock()
 	mon.UpdateNsKeyMap("ADDED", nsKey, visibility)
 }
 
+// selectBPFObject returns the object of the system monitor prebuilt for the architecture (e.g., BPF/arm64/), or the
+// one built on the node otherwise
+func selectBPFObject(bpfPath, name string) string {
+	archPath := filepath.Join(bpfPath, kl.BPFArch, name)
+	if _, err := os.Stat(filepath.Clean(archPath)); err == nil {
+		return archPath
+	}
+
+	return bpfPath + name
+}
+
 // InitBPF Function
 func (mon *SystemMonitor) InitBPF() error {
 	homeDir, err := filepath.Abs(filepath.Dir(os.Args[0]))
@@ -384,12 +528,17 @@ func (mon *SystemMonitor) InitBPF() error {
 		return fmt.Errorf("error removing memlock %v", err)
 	}
 
+	// the programs of the system monitor aren't built for every architecture
+	if err := kl.BPFFeatureError(kl.BPFFeatureSystemMonitor); err != nil {
+		return err
+	}
+
 	if cfg.GlobalCfg.Policy && !cfg.GlobalCfg.HostPolicy { // container only
-		bpfPath = bpfPath + "system_monitor.container.bpf.o"
+		bpfPath = selectBPFObject(bpfPath, "system_monitor.container.bpf.o")
 	} else if !cfg.GlobalCfg.Policy && cfg.GlobalCfg.HostPolicy { // host only
-		bpfPath = bpfPath + "system_monitor.host.bpf.o"
+		bpfPath = selectBPFObject(bpfPath, "system_monitor.host.bpf.o")
 	} else if cfg.GlobalCfg.Policy && cfg.GlobalCfg.HostPolicy { // container and host
-		bpfPath = bpfPath + "system_monitor.bpf.o"
+		bpfPath = selectBPFObject(bpfPath, "system_monitor.bpf.o")
 	}
 
 	err = mon.initBPFMaps()
@@ -415,62 +564,18 @@ func (mon *SystemMonitor) InitBPF() error {
 
 	mon.Logger.Print("Initialized the eBPF system monitor")
 
-	// sysPrefix := bcc.GetSyscallPrefix()
-	systemCalls := []string{"open", "openat", "execve", "execveat", "socket", "connect", "accept", "bind", "listen", "unlink", "unlinkat", "rmdir", "ptrace", "chown", "setuid", "setgid", "fchownat", "mount", "umount"}
-	// {category, event}
-	sysTracepoints := [][2]string{{"syscalls", "sys_exit_openat"}}
-	sysKprobes := []string{"do_exit", "security_bprm_check", "security_file_open", "security_path_mknod", "security_path_unlink", "security_path_rmdir", "security_ptrace_access_check"}
-	netSyscalls := []string{"tcp_connect"}
-	netRetSyscalls := []string{"inet_csk_accept"}
-
 	if mon.BpfModule != nil {
+		// all the probes are attached until the event classes which nothing needs are known
+		probes := getSystemMonitorProbes()
+		mon.initEventClasses(probes)
 
-		mon.Probes = make(map[string]link.Link)
+		mon.ProbesLock.Lock()
+		_ = mon.attachProbes(probes)
+		mon.ProbesLock.Unlock()
 
-		for _, syscallName := range systemCalls {
-			mon.Probes["kprobe__"+syscallName], err = link.Kprobe("sys_"+syscallName, mon.BpfModule.Programs["kprobe__"+syscallName], nil)
-			if err != nil {
-				mon.Logger.Warnf("error loading kprobe %s: %v", syscallName, err)
-			}
+		mon.SyscallChannel = make(chan []byte, EventChannelSize())
 
-			mon.Probes["kretprobe__"+syscallName], err = link.Kretprobe("sys_"+syscallName, mon.BpfModule.Programs["kretprobe__"+syscallName], nil)
-			if err != nil {
-				mon.Logger.Warnf("error loading kretprobe %s: %v", syscallName, err)
-			}
-
-		}
-
-		for _, sysTracepoint := range sysTracepoints {
-			mon.Probes[sysTracepoint[1]], err = link.Tracepoint(sysTracepoint[0], sysTracepoint[1], mon.BpfModule.Programs[sysTracepoint[1]], nil)
-			if err != nil {
-				mon.Logger.Warnf("error:%s: %v", sysTracepoint, err)
-			}
-		}
-
-		for _, sysKprobe := range sysKprobes {
-			mon.Probes["kprobe__"+sysKprobe], err = link.Kprobe(sysKprobe, mon.BpfModule.Programs["kprobe__"+sysKprobe], nil)
-			if err != nil {
-				mon.Logger.Warnf("error loading kprobe %s: %v", sysKprobe, err)
-			}
-		}
-
-		for _, netSyscall := range netSyscalls {
-			mon.Probes["kprobe__"+netSyscall], err = link.Kprobe(netSyscall, mon.BpfModule.Programs["kprobe__"+netSyscall], nil)
-			if err != nil {
-				mon.Logger.Warnf("error loading kprobe %s: %v", netSyscall, err)
-			}
-		}
-
-		for _, netRetSyscall := range netRetSyscalls {
-			mon.Probes["kretprobe__"+netRetSyscall], err = link.Kretprobe(netRetSyscall, mon.BpfModule.Programs["kretprobe__"+netRetSyscall], nil)
-			if err != nil {
-				mon.Logger.Warnf("error loading kretprobe %s: %v", netRetSyscall, err)
-			}
-		}
-
-		mon.SyscallChannel = make(chan []byte, SyscallChannelSize)
-
-		mon.SyscallPerfMap, err = perf.NewReader(mon.BpfModule.Maps["sys_events"], os.Getpagesize()*1024)
+		mon.SyscallPerfMap, err = perf.NewReader(mon.BpfModule.Maps["sys_events"], os.Getpagesize()*EventBufferPages())
 		if err != nil {
 			mon.Logger.Warnf("error initializing events perf map: %v", err)
 		}
@@ -501,10 +606,8 @@ func (mon *SystemMonitor) DestroySystemMonitor() error {
 		close(mon.ContextChan)
 	}
 
-	for _, link := range mon.Probes {
-		if err := link.Close(); err != nil {
-			return err
-		}
+	if err := mon.closeProbes(); err != nil {
+		return err
 	}
 
 	mon.DestroyBPFMaps()
@@ -548,39 +651,10 @@ func (mon *SystemMonitor) TraceSyscall() {
 	Containers := *(mon.Containers)
 	ContainersLock := *(mon.ContainersLock)
 
-	ReplayChannel := make(chan []byte, SyscallChannelSize)
-
-	go func() {
-		for {
-			dataRaw, valid := <-ReplayChannel
-			if !valid {
-				continue
-			}
-			dataBuff := bytes.NewBuffer(dataRaw)
-			ctx, err := readContextFromBuff(dataBuff)
-			if err != nil {
-				continue
-			}
+	ReplayChannel := make(chan []byte, EventChannelSize())
 
-			now := time.Now()
-			if now.After(time.Unix(int64(ctx.Ts), 0).Add(5 * time.Second)) {
-				mon.Logger.Warn("Event dropped due to replay timeout")
-				continue
-			}
+	go mon.replayEvents(ReplayChannel)
 
-			// Best effort replay
-			go func() {
-				time.Sleep(1 * time.Second)
-				select {
-				case mon.SyscallChannel <- dataRaw:
-				default:
-					// channel is full, wait for a short time before retrying
-					time.Sleep(1 * time.Second)
-					mon.Logger.Warn("Event droped due to busy event channel")
-				}
-			}()
-		}
-	}()
 	MonitorLock := *(mon.MonitorLock)
 
 	for {
@@ -622,7 +696,12 @@ func (mon *SystemMonitor) TraceSyscall() {
 			}
 
 			if ctx.PidID != 0 && ctx.MntID != 0 && containerID == "" {
-				ReplayChannel <- dataRaw
+				// without the replay, the events of the containers which are not known yet are dropped
+				if cfg.GlobalCfg.EventReplay {
+					ReplayChannel <- dataRaw
+				} else if mon.Unattributed != nil {
+					mon.ReportUnattributedActivity(ctx, args)
+				}
 				continue
 			}
 
@@ -668,6 +747,26 @@ func (mon *SystemMonitor) TraceSyscall() {
 				if len(args) != 5 {
 					continue
 				}
+			} else if ctx.EventID == SysSetxattr || ctx.EventID == SysLSetxattr || ctx.EventID == SysFSetxattr {
+				if len(args) != 2 {
+					continue
+				}
+			} else if ctx.EventID == SysRemovexattr || ctx.EventID == SysLRemovexattr || ctx.EventID == SysFRemovexattr {
+				if len(args) != 2 {
+					continue
+				}
+			} else if ctx.EventID == SysIoctl {
+				if len(args) != 2 {
+					continue
+				}
+			} else if ctx.EventID == SysUnshare {
+				if len(args) != 1 {
+					continue
+				}
+			} else if ctx.EventID == SysSetns {
+				if len(args) != 2 {
+					continue
+				}
 			} else if ctx.EventID == SysSetuid {
 				if len(args) != 1 {
 					continue
@@ -691,6 +790,9 @@ func (mon *SystemMonitor) TraceSyscall() {
 					pidNode := mon.BuildPidNode(containerID, ctx, args[0].(string), args[1].([]string))
 					mon.AddActivePid(containerID, pidNode)
 
+					// keep the execution whatever the visibility of the container is
+					mon.RecordExec(containerID, ctx, pidNode, args[1].([]string))
+
 					// if Policy is not set
 					if !cfg.GlobalCfg.Policy && containerID != "" {
 						continue
@@ -773,6 +875,9 @@ func (mon *SystemMonitor) TraceSyscall() {
 					pidNode := mon.BuildPidNode(containerID, ctx, args[1].(string), args[2].([]string))
 					mon.AddActivePid(containerID, pidNode)
 
+					// keep the execution whatever the visibility of the container is
+					mon.RecordExec(containerID, ctx, pidNode, args[2].([]string))
+
 					// if Policy is not set
 					if !cfg.GlobalCfg.Policy && containerID != "" {
 						continue
@@ -860,12 +965,24 @@ func (mon *SystemMonitor) TraceSyscall() {
 				continue
 			} else if ctx.EventID == DoExit {
 				mon.DeleteActivePid(containerID, ctx)
+				mon.forgetSession(containerID, ctx.HostPID)
+
+				// the sockets are kept until the last thread of the process exits
+				if len(args) == 1 {
+					if val, ok := args[0].(int32); ok && val == 1 {
+						mon.SocketTracker.RemoveProcess(ctx.HostPID)
+					}
+				}
 				continue
 			} else if ctx.EventID == SecurityBprmCheck {
 				if val, ok := args[0].(string); ok {
 					mon.UpdateExecPath(containerID, ctx.HostPID, val)
 				}
 				continue
+			} else if ctx.EventID == SecurityTaskKill {
+				if len(args) != 6 {
+					continue
+				}
 			} else if ctx.EventID == TCPConnect {
 				if len(args) != 2 {
 					continue
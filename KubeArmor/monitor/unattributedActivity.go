@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	kl "github.com/kubearmor/KubeArmor/KubeArmor/common"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// =========================== //
+// == Unattributed Activity == //
+// =========================== //
+
+// UnattributedActivityPolicyName is the policy name of the alerts of the events whose container can't be attributed
+const UnattributedActivityPolicyName = "kubearmor-unattributed-activity"
+
+// states of the unattributed events counted
+const (
+	UnattributedAlerted    = "alerted"
+	UnattributedSuppressed = "suppressed"
+)
+
+// unattributedAlertInterval is the minimum interval between the alerts of the same namespaces
+var unattributedAlertInterval = time.Minute
+
+// maxUnattributedKeys bounds the namespaces whose last alert is kept
+var maxUnattributedKeys = 4096
+
+// UnattributedActivity Structure rate-limits the alerts of the unattributed events per (pidns, mntns) pair
+type UnattributedActivity struct {
+	// namespaces -> time of the last alert
+	last map[NsKey]time.Time
+
+	// namespaces -> events suppressed since the last alert
+	suppressed map[NsKey]uint64
+
+	events *prometheus.CounterVec
+
+	lock *sync.Mutex
+}
+
+// NewUnattributedActivity Function
+func NewUnattributedActivity() *UnattributedActivity {
+	ua := &UnattributedActivity{}
+
+	ua.last = map[NsKey]time.Time{}
+	ua.suppressed = map[NsKey]uint64{}
+
+	ua.events = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kubearmor",
+		Name:      "unattributed_events_total",
+		Help:      "Number of the events in namespaces which can't be attributed to a container, per state (alerted|suppressed)",
+	}, []string{"state"})
+
+	ua.lock = new(sync.Mutex)
+
+	return ua
+}
+
+// Register Function serves the metrics with the given registry
+func (ua *UnattributedActivity) Register(registry *prometheus.Registry) {
+	registry.MustRegister(ua.events)
+}
+
+// allow Function checks if an alert is raised for an event of the given namespaces, and returns the number of the
+// events suppressed since the last alert of the namespaces
+func (ua *UnattributedActivity) allow(key NsKey, now time.Time) (bool, uint64) {
+	ua.lock.Lock()
+	defer ua.lock.Unlock()
+
+	if last, ok := ua.last[key]; ok && now.Sub(last) < unattributedAlertInterval {
+		ua.suppressed[key]++
+		ua.events.WithLabelValues(UnattributedSuppressed).Inc()
+		return false, 0
+	}
+
+	// the namespaces whose interval expired are forgotten first, then all of them
+	if _, ok := ua.last[key]; !ok && len(ua.last) >= maxUnattributedKeys {
+		for k, last := range ua.last {
+			if now.Sub(last) >= unattributedAlertInterval {
+				delete(ua.last, k)
+				delete(ua.suppressed, k)
+			}
+		}
+		if len(ua.last) >= maxUnattributedKeys {
+			ua.last = map[NsKey]time.Time{}
+			ua.suppressed = map[NsKey]uint64{}
+		}
+	}
+
+	suppressed := ua.suppressed[key]
+
+	ua.last[key] = now
+	delete(ua.suppressed, key)
+	ua.events.WithLabelValues(UnattributedAlerted).Inc()
+
+	return true, suppressed
+}
+
+// ReportUnattributedActivity Function raises an alert for an event in namespaces which can't be attributed to a
+// container (neither the host nor a registered container), at most once per interval for the same namespaces
+func (mon *SystemMonitor) ReportUnattributedActivity(ctx SyscallContext, args []interface{}) {
+	if mon.Unattributed == nil {
+		return
+	}
+
+	allowed, suppressed := mon.Unattributed.allow(NsKey{PidNS: ctx.PidID, MntNS: ctx.MntID}, time.Now())
+	if !allowed {
+		return
+	}
+
+	mon.Logger.PushSummaryLog(unattributedActivityLog(ctx, args, suppressed))
+}
+
+// unattributedActivityLog returns the alert of an unattributed event, with its raw namespaces
+func unattributedActivityLog(ctx SyscallContext, args []interface{}, suppressed uint64) tp.Log {
+	log := tp.Log{}
+
+	timestamp, updatedTime := kl.GetDateTimeNow()
+
+	log.Timestamp = timestamp
+	log.UpdatedTime = updatedTime
+
+	log.HostPPID = int32(ctx.HostPPID)
+	log.HostPID = int32(ctx.HostPID)
+	log.PPID = int32(ctx.PPID)
+	log.PID = int32(ctx.PID)
+	log.UID = int32(ctx.UID)
+
+	comm := strings.TrimRight(string(ctx.Comm[:]), "\x00")
+
+	log.Type = "MatchedHostPolicy"
+	log.PolicyName = UnattributedActivityPolicyName
+	log.Severity = "7"
+	log.Tags = "KUBEARMOR,UNATTRIBUTED_ACTIVITY"
+	log.ATags = strings.Split(log.Tags, ",")
+	log.Message = "Activity in namespaces which can't be attributed to a container"
+
+	log.Source = comm
+	log.ProcessName = comm
+	log.Operation = "Syscall"
+	log.Cwd = strings.TrimRight(string(ctx.Cwd[:]), "\x00") + "/"
+
+	// the path of the event, if any
+	for _, arg := range args {
+		if path, ok := arg.(string); ok {
+			log.Resource = path
+			break
+		}
+	}
+
+	log.Data = "syscall=" + GetSyscallName(ctx.EventID) +
+		" pidns=" + strconv.FormatUint(uint64(ctx.PidID), 10) +
+		" mntns=" + strconv.FormatUint(uint64(ctx.MntID), 10) +
+		" comm=" + comm +
+		" suppressed=" + strconv.FormatUint(suppressed, 10)
+
+	log.Enforcer = "KubeArmor"
+	log.Action = "Audit"
+	log.Result = "Unattributed"
+
+	return log
+}
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package monitor
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/kubearmor/KubeArmor/KubeArmor/config"
+	fd "github.com/kubearmor/KubeArmor/KubeArmor/feeder"
+	tp "github.com/kubearmor/KubeArmor/KubeArmor/types"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// gatherUnattributedEvents returns the unattributed events counted per state
+func gatherUnattributedEvents(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to gather the metrics (%s)", err.Error())
+	}
+
+	events := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "kubearmor_unattributed_events_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "state" {
+					events[label.GetValue()] = metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return events
+}
+
+func TestUnattributedActivity(t *testing.T) {
+	prevStrict, prevInterval := cfg.GlobalCfg.StrictAttribution, unattributedAlertInterval
+	defer func() {
+		cfg.GlobalCfg.StrictAttribution, unattributedAlertInterval = prevStrict, prevInterval
+	}()
+	unattributedAlertInterval = 300 * time.Millisecond
+
+	fd.MsgLock = new(sync.RWMutex)
+	fd.MsgStructs = make(map[string]fd.MsgStruct)
+
+	// subscribe to the alerts
+	alerts := make(chan *pb.Alert, 16)
+	fd.AlertLock = new(sync.RWMutex)
+	fd.AlertStructs = map[string]fd.AlertStruct{"test": {Filter: "all", Broadcast: alerts}}
+	defer func() { fd.AlertStructs = map[string]fd.AlertStruct{} }()
+
+	node := tp.Node{NodeName: "worker-1"}
+	nodeLock := new(sync.RWMutex)
+	containers := map[string]tp.Container{}
+	containersLock := new(sync.RWMutex)
+	pidMap := map[string]tp.PidMap{}
+	pidMapLock := new(sync.RWMutex)
+	monitorLock := new(sync.RWMutex)
+
+	logger := &fd.Feeder{Node: &node}
+	logger.Output = "none"
+	logger.SeverityRangesLock = new(sync.RWMutex)
+	logger.SinksLock = new(sync.RWMutex)
+
+	// disabled by default
+	cfg.GlobalCfg.StrictAttribution = false
+	if mon := NewSystemMonitor(&node, &nodeLock, logger, &containers, &containersLock, &pidMap, &pidMapLock, &monitorLock); mon.Unattributed != nil {
+		t.Errorf("[FAIL] Expected the strict attribution to be disabled")
+	}
+
+	cfg.GlobalCfg.StrictAttribution = true
+	mon := NewSystemMonitor(&node, &nodeLock, logger, &containers, &containersLock, &pidMap, &pidMapLock, &monitorLock)
+	if mon.Unattributed == nil {
+		t.Fatalf("[FAIL] Expected the strict attribution to be enabled")
+	}
+
+	registry := prometheus.NewRegistry()
+	mon.Unattributed.Register(registry)
+
+	// an exec in namespaces which aren't in NsMap
+	event := func(pidns, mntns uint32, path string) {
+		ctx := SyscallContext{PidID: pidns, MntID: mntns, HostPID: 4242, PID: 1, EventID: SysExecve}
+		copy(ctx.Comm[:], "miner")
+		mon.ReportUnattributedActivity(ctx, []interface{}{path, []string{path}})
+	}
+
+	expectAlert := func(what, data string) {
+		select {
+		case alert := <-alerts:
+			if alert.PolicyName != UnattributedActivityPolicyName || alert.HostPID != 4242 || alert.Resource != "/tmp/miner" || !strings.Contains(alert.Data, data) {
+				t.Errorf("[FAIL] Unexpected alert for %s (%+v)", what, alert)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("[FAIL] Expected an alert for %s", what)
+		}
+	}
+
+	expectNoAlert := func(what string) {
+		select {
+		case alert := <-alerts:
+			t.Errorf("[FAIL] Unexpected alert for %s (%+v)", what, alert)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	event(4026532001, 4026532002, "/tmp/miner")
+	expectAlert("the first event", "pidns=4026532001 mntns=4026532002 comm=miner suppressed=0")
+
+	// the same namespaces are rate-limited, the others aren't
+	event(4026532001, 4026532002, "/tmp/miner")
+	event(4026532001, 4026532002, "/tmp/miner")
+	expectNoAlert("the rate-limited events")
+
+	event(4026532003, 4026532004, "/tmp/miner")
+	expectAlert("the other namespaces", "pidns=4026532003 mntns=4026532004")
+
+	// the events suppressed meanwhile are reported with the next alert
+	time.Sleep(unattributedAlertInterval)
+
+	event(4026532001, 4026532002, "/tmp/miner")
+	expectAlert("the event after the interval", "suppressed=2")
+
+	if events := gatherUnattributedEvents(t, registry); events[UnattributedAlerted] != 3 || events[UnattributedSuppressed] != 2 {
+		t.Errorf("[FAIL] Unexpected number of the unattributed events (%v)", events)
+	}
+
+	t.Log("[PASS] Raised the alerts of the unattributed events")
+}
@@ -0,0 +1,613 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+// Package testutil provides test doubles for running KubeArmor without real dependencies
+package testutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	ctrver "github.com/containerd/containerd/api/services/version/v1"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// ======================= //
+// == Fake CRI Runtime == //
+// ======================= //
+
+// runtime flavors of the verbose info payload
+const (
+	FlavorCrio       = "cri-o"
+	FlavorContainerd = "containerd"
+)
+
+// FakeContainer Structure
+type FakeContainer struct {
+	ID    string
+	Name  string
+	Image string
+
+	// pod information (set as kubernetes labels)
+	Namespace string
+	PodName   string
+
+	// sandbox of the container (sandbox-<ID> if empty)
+	SandboxID string
+
+	// pid of the init process, used to look up the namespaces of the container
+	Pid int
+
+	AppArmorProfile string
+	RootPath        string
+
+	// mounts of the runtime spec
+	Mounts []specs.Mount
+
+	// user of the process, linux section (cgroups path, resources) of the runtime spec, and the privileged flag
+	User       specs.User
+	Linux      *specs.Linux
+	Privileged bool
+
+	State     pb.ContainerState
+	CreatedAt int64
+}
+
+// FakeSandbox Structure
+type FakeSandbox struct {
+	ID string
+
+	// metadata of the pod
+	Name      string
+	Namespace string
+	UID       string
+
+	Labels map[string]string
+}
+
+// Fault Structure
+type Fault struct {
+	// delay before replying, the request times out if it exceeds the deadline of the client
+	Delay time.Duration
+
+	// error to be returned
+	Err error
+
+	// return a malformed verbose info payload (ContainerStatus only)
+	MalformedInfo bool
+}
+
+// FakeRuntime is an in-process CRI runtime service serving scripted containers over a unix socket
+type FakeRuntime struct {
+	pb.UnimplementedRuntimeServiceServer
+
+	// flavor of the verbose info payload
+	Flavor string
+
+	containers     map[string]*FakeContainer
+	containersLock *sync.RWMutex
+
+	// sandbox ID -> sandbox (protected by containersLock)
+	sandboxes map[string]*FakeSandbox
+
+	// method name (e.g., ContainerStatus) -> fault
+	faults     map[string]Fault
+	faultsLock *sync.RWMutex
+
+	// subscribers of the container events (GetContainerEvents), unimplemented unless enabled
+	eventsEnabled bool
+	eventStreams  map[chan *pb.ContainerEventResponse]struct{}
+	eventsLock    *sync.RWMutex
+
+	// method name -> number of calls
+	calls     map[string]int
+	callsLock *sync.Mutex
+
+	socketPath string
+	listener   net.Listener
+	server     *grpc.Server
+}
+
+// NewFakeRuntime Function
+func NewFakeRuntime(flavor string) *FakeRuntime {
+	fr := &FakeRuntime{}
+
+	fr.Flavor = flavor
+
+	fr.containers = map[string]*FakeContainer{}
+	fr.containersLock = new(sync.RWMutex)
+
+	fr.sandboxes = map[string]*FakeSandbox{}
+
+	fr.faults = map[string]Fault{}
+	fr.faultsLock = new(sync.RWMutex)
+
+	fr.eventStreams = map[chan *pb.ContainerEventResponse]struct{}{}
+	fr.eventsLock = new(sync.RWMutex)
+
+	fr.calls = map[string]int{}
+	fr.callsLock = new(sync.Mutex)
+
+	return fr
+}
+
+// Start serves the runtime service on the given unix socket
+func (fr *FakeRuntime) Start(socketPath string) error {
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+
+	fr.socketPath = socketPath
+	fr.listener = listener
+
+	fr.server = grpc.NewServer()
+	pb.RegisterRuntimeServiceServer(fr.server, fr)
+
+	// containerd serves its own version service on the same socket
+	if fr.Flavor == FlavorContainerd {
+		ctrver.RegisterVersionServer(fr.server, fakeContainerdVersion{fr: fr})
+	}
+
+	go func() {
+		_ = fr.server.Serve(listener)
+	}()
+
+	return nil
+}
+
+// Endpoint returns the CRI socket in the format of the criSocket option
+func (fr *FakeRuntime) Endpoint() string {
+	return "unix://" + fr.socketPath
+}
+
+// Stop Function
+func (fr *FakeRuntime) Stop() {
+	if fr.server != nil {
+		fr.server.Stop()
+	}
+	_ = os.Remove(fr.socketPath)
+}
+
+// ========================= //
+// == Scripted Lifecycles == //
+// ========================= //
+
+// AddContainer adds a running container
+func (fr *FakeRuntime) AddContainer(container FakeContainer) {
+	fr.containersLock.Lock()
+	defer fr.containersLock.Unlock()
+
+	container.State = pb.ContainerState_CONTAINER_RUNNING
+	if container.CreatedAt == 0 {
+		container.CreatedAt = time.Now().UnixNano()
+	}
+
+	fr.containers[container.ID] = &container
+
+	fr.publishEvent(container.ID, pb.ContainerEventType_CONTAINER_STARTED_EVENT)
+}
+
+// AddSandbox adds a ready sandbox
+func (fr *FakeRuntime) AddSandbox(sandbox FakeSandbox) {
+	fr.containersLock.Lock()
+	defer fr.containersLock.Unlock()
+
+	fr.sandboxes[sandbox.ID] = &sandbox
+}
+
+// ExitContainer marks a container as exited, it is still listed until deleted
+func (fr *FakeRuntime) ExitContainer(containerID string) {
+	fr.containersLock.Lock()
+	defer fr.containersLock.Unlock()
+
+	if container, ok := fr.containers[containerID]; ok {
+		container.State = pb.ContainerState_CONTAINER_EXITED
+		container.Pid = 0
+
+		fr.publishEvent(containerID, pb.ContainerEventType_CONTAINER_STOPPED_EVENT)
+	}
+}
+
+// RestartContainer restarts a container with a new pid
+func (fr *FakeRuntime) RestartContainer(containerID string, pid int) {
+	fr.containersLock.Lock()
+	defer fr.containersLock.Unlock()
+
+	if container, ok := fr.containers[containerID]; ok {
+		container.State = pb.ContainerState_CONTAINER_RUNNING
+		container.Pid = pid
+
+		fr.publishEvent(containerID, pb.ContainerEventType_CONTAINER_STARTED_EVENT)
+	}
+}
+
+// DeleteContainer Function
+func (fr *FakeRuntime) DeleteContainer(containerID string) {
+	fr.containersLock.Lock()
+	defer fr.containersLock.Unlock()
+
+	if _, ok := fr.containers[containerID]; ok {
+		delete(fr.containers, containerID)
+
+		fr.publishEvent(containerID, pb.ContainerEventType_CONTAINER_DELETED_EVENT)
+	}
+}
+
+// =================== //
+// == Event Streams == //
+// =================== //
+
+// EnableEvents serves the container events, as the runtimes implementing GetContainerEvents do
+func (fr *FakeRuntime) EnableEvents() {
+	fr.eventsLock.Lock()
+	defer fr.eventsLock.Unlock()
+
+	fr.eventsEnabled = true
+}
+
+// DropEventStreams ends the current event streams, as a restart of the runtime would
+func (fr *FakeRuntime) DropEventStreams() {
+	fr.eventsLock.Lock()
+	defer fr.eventsLock.Unlock()
+
+	for events := range fr.eventStreams {
+		close(events)
+		delete(fr.eventStreams, events)
+	}
+}
+
+// EventStreams returns the number of the connected event streams
+func (fr *FakeRuntime) EventStreams() int {
+	fr.eventsLock.RLock()
+	defer fr.eventsLock.RUnlock()
+
+	return len(fr.eventStreams)
+}
+
+// publishEvent sends an event of a container to the subscribers, the ones not keeping up miss it
+func (fr *FakeRuntime) publishEvent(containerID string, eventType pb.ContainerEventType) {
+	fr.eventsLock.RLock()
+	defer fr.eventsLock.RUnlock()
+
+	event := &pb.ContainerEventResponse{
+		ContainerId:        containerID,
+		ContainerEventType: eventType,
+		CreatedAt:          time.Now().UnixNano(),
+	}
+
+	for events := range fr.eventStreams {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// Calls returns the number of calls of the given method
+func (fr *FakeRuntime) Calls(method string) int {
+	fr.callsLock.Lock()
+	defer fr.callsLock.Unlock()
+
+	return fr.calls[method]
+}
+
+// countCall Function
+func (fr *FakeRuntime) countCall(method string) {
+	fr.callsLock.Lock()
+	defer fr.callsLock.Unlock()
+
+	fr.calls[method]++
+}
+
+// SetFault injects a fault into the given method (ListContainers, ContainerStatus, Version or GetContainerEvents)
+func (fr *FakeRuntime) SetFault(method string, fault Fault) {
+	fr.faultsLock.Lock()
+	defer fr.faultsLock.Unlock()
+
+	fr.faults[method] = fault
+}
+
+// ClearFault Function
+func (fr *FakeRuntime) ClearFault(method string) {
+	fr.faultsLock.Lock()
+	defer fr.faultsLock.Unlock()
+
+	delete(fr.faults, method)
+}
+
+// applyFault returns the fault of a method after waiting for its delay
+func (fr *FakeRuntime) applyFault(ctx context.Context, method string) (Fault, error) {
+	fr.faultsLock.RLock()
+	fault, ok := fr.faults[method]
+	fr.faultsLock.RUnlock()
+
+	if !ok {
+		return Fault{}, nil
+	}
+
+	if fault.Delay > 0 {
+		select {
+		case <-ctx.Done():
+			return fault, status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+		case <-time.After(fault.Delay):
+		}
+	}
+
+	return fault, fault.Err
+}
+
+// ===================== //
+// == Runtime Service == //
+// ===================== //
+
+// Version Function
+func (fr *FakeRuntime) Version(ctx context.Context, req *pb.VersionRequest) (*pb.VersionResponse, error) {
+	if _, err := fr.applyFault(ctx, "Version"); err != nil {
+		return nil, err
+	}
+
+	return &pb.VersionResponse{
+		Version:           "0.1.0",
+		RuntimeName:       fr.Flavor,
+		RuntimeVersion:    "fake",
+		RuntimeApiVersion: "v1",
+	}, nil
+}
+
+// fakeContainerdVersion serves the version service of containerd
+type fakeContainerdVersion struct {
+	ctrver.UnimplementedVersionServer
+
+	fr *FakeRuntime
+}
+
+// Version Function
+func (v fakeContainerdVersion) Version(ctx context.Context, req *emptypb.Empty) (*ctrver.VersionResponse, error) {
+	if _, err := v.fr.applyFault(ctx, "Version"); err != nil {
+		return nil, err
+	}
+
+	return &ctrver.VersionResponse{Version: "fake", Revision: "0"}, nil
+}
+
+// ListContainers Function
+func (fr *FakeRuntime) ListContainers(ctx context.Context, req *pb.ListContainersRequest) (*pb.ListContainersResponse, error) {
+	fr.countCall("ListContainers")
+
+	if _, err := fr.applyFault(ctx, "ListContainers"); err != nil {
+		return nil, err
+	}
+
+	fr.containersLock.RLock()
+	defer fr.containersLock.RUnlock()
+
+	ids := []string{}
+	for id := range fr.containers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	res := &pb.ListContainersResponse{}
+	for _, id := range ids {
+		container := fr.containers[id]
+
+		if req.Filter != nil && req.Filter.State != nil && req.Filter.State.State != container.State {
+			continue
+		}
+
+		res.Containers = append(res.Containers, &pb.Container{
+			Id:        container.ID,
+			Metadata:  &pb.ContainerMetadata{Name: container.Name},
+			Image:     &pb.ImageSpec{Image: container.Image},
+			ImageRef:  container.Image,
+			State:     container.State,
+			CreatedAt: container.CreatedAt,
+			Labels:    container.labels(),
+		})
+	}
+
+	return res, nil
+}
+
+// ContainerStatus Function
+func (fr *FakeRuntime) ContainerStatus(ctx context.Context, req *pb.ContainerStatusRequest) (*pb.ContainerStatusResponse, error) {
+	fault, err := fr.applyFault(ctx, "ContainerStatus")
+	if err != nil {
+		return nil, err
+	}
+
+	fr.containersLock.RLock()
+	container, ok := fr.containers[req.ContainerId]
+	if ok {
+		copied := *container
+		container = &copied
+	}
+	fr.containersLock.RUnlock()
+
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "could not find container %q", req.ContainerId)
+	}
+
+	res := &pb.ContainerStatusResponse{
+		Status: &pb.ContainerStatus{
+			Id:        container.ID,
+			Metadata:  &pb.ContainerMetadata{Name: container.Name},
+			State:     container.State,
+			CreatedAt: container.CreatedAt,
+			Image:     &pb.ImageSpec{Image: container.Image},
+			ImageRef:  container.Image,
+			Labels:    container.labels(),
+		},
+	}
+
+	if req.Verbose {
+		info, err := fr.verboseInfo(container)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if fault.MalformedInfo {
+			info = info[:len(info)/2]
+		}
+		res.Info = map[string]string{"info": info}
+	}
+
+	return res, nil
+}
+
+// PodSandboxStatus Function
+func (fr *FakeRuntime) PodSandboxStatus(ctx context.Context, req *pb.PodSandboxStatusRequest) (*pb.PodSandboxStatusResponse, error) {
+	if _, err := fr.applyFault(ctx, "PodSandboxStatus"); err != nil {
+		return nil, err
+	}
+
+	fr.containersLock.RLock()
+	defer fr.containersLock.RUnlock()
+
+	sandbox, ok := fr.sandboxes[req.PodSandboxId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "could not find pod %q", req.PodSandboxId)
+	}
+
+	labels := map[string]string{
+		"io.kubernetes.pod.namespace": sandbox.Namespace,
+		"io.kubernetes.pod.name":      sandbox.Name,
+		"io.kubernetes.pod.uid":       sandbox.UID,
+	}
+	for k, v := range sandbox.Labels {
+		labels[k] = v
+	}
+
+	return &pb.PodSandboxStatusResponse{
+		Status: &pb.PodSandboxStatus{
+			Id:       sandbox.ID,
+			Metadata: &pb.PodSandboxMetadata{Name: sandbox.Name, Namespace: sandbox.Namespace, Uid: sandbox.UID},
+			State:    pb.PodSandboxState_SANDBOX_READY,
+			Labels:   labels,
+		},
+	}, nil
+}
+
+// labels Function
+func (fc *FakeContainer) labels() map[string]string {
+	labels := map[string]string{}
+
+	if fc.Namespace != "" {
+		labels["io.kubernetes.pod.namespace"] = fc.Namespace
+	}
+	if fc.PodName != "" {
+		labels["io.kubernetes.pod.name"] = fc.PodName
+	}
+	if fc.Name != "" {
+		labels["io.kubernetes.container.name"] = fc.Name
+	}
+
+	return labels
+}
+
+// verboseInfo returns the verbose info payload following the schema of the runtime flavor
+func (fr *FakeRuntime) verboseInfo(container *FakeContainer) (string, error) {
+	runtimeSpec := map[string]interface{}{
+		"process": map[string]interface{}{
+			"apparmorProfile": container.AppArmorProfile,
+			"user":            container.User,
+		},
+		"root": map[string]interface{}{
+			"path": container.RootPath,
+		},
+		"mounts": container.Mounts,
+	}
+	if container.Linux != nil {
+		runtimeSpec["linux"] = container.Linux
+	}
+
+	sandboxID := container.SandboxID
+	if sandboxID == "" {
+		sandboxID = "sandbox-" + container.ID
+	}
+
+	var info map[string]interface{}
+
+	switch fr.Flavor {
+	case FlavorCrio:
+		info = map[string]interface{}{
+			"sandboxID":   sandboxID,
+			"pid":         container.Pid,
+			"runtimeSpec": runtimeSpec,
+			"privileged":  container.Privileged,
+		}
+	case FlavorContainerd:
+		info = map[string]interface{}{
+			"sandboxID":   sandboxID,
+			"pid":         container.Pid,
+			"removing":    false,
+			"snapshotKey": container.ID,
+			"snapshotter": "overlayfs",
+			"runtimeType": "io.containerd.runc.v2",
+			"runtimeSpec": runtimeSpec,
+		}
+	default:
+		return "", errors.New("unknown runtime flavor " + fr.Flavor)
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// GetContainerEvents streams the events of the containers until the stream is dropped
+func (fr *FakeRuntime) GetContainerEvents(req *pb.GetEventsRequest, srv pb.RuntimeService_GetContainerEventsServer) error {
+	fr.countCall("GetContainerEvents")
+
+	if _, err := fr.applyFault(srv.Context(), "GetContainerEvents"); err != nil {
+		return err
+	}
+
+	events := make(chan *pb.ContainerEventResponse, 64)
+
+	fr.eventsLock.Lock()
+	if !fr.eventsEnabled {
+		fr.eventsLock.Unlock()
+		return status.Error(codes.Unimplemented, "method GetContainerEvents not implemented")
+	}
+	fr.eventStreams[events] = struct{}{}
+	fr.eventsLock.Unlock()
+
+	defer func() {
+		fr.eventsLock.Lock()
+		delete(fr.eventStreams, events)
+		fr.eventsLock.Unlock()
+	}()
+
+	for {
+		select {
+		case <-srv.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.Unavailable, "event stream dropped")
+			}
+			if err := srv.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
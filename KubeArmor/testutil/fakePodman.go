@@ -0,0 +1,287 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package testutil
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ================= //
+// == Fake Podman == //
+// ================= //
+
+// the libpod endpoints served by the fake
+const fakePodmanAPI = "/v4.0.0/libpod"
+
+// FakePodman is an in-process Podman API serving scripted containers over a unix socket
+type FakePodman struct {
+	containers     map[string]*FakeContainer
+	containersLock *sync.RWMutex
+
+	// subscribers of the container events
+	eventStreams map[chan map[string]interface{}]struct{}
+	eventsLock   *sync.RWMutex
+
+	socketPath string
+	listener   net.Listener
+	server     *http.Server
+}
+
+// NewFakePodman Function
+func NewFakePodman() *FakePodman {
+	fp := &FakePodman{}
+
+	fp.containers = map[string]*FakeContainer{}
+	fp.containersLock = new(sync.RWMutex)
+
+	fp.eventStreams = map[chan map[string]interface{}]struct{}{}
+	fp.eventsLock = new(sync.RWMutex)
+
+	return fp
+}
+
+// Start serves the Podman API on the given unix socket
+func (fp *FakePodman) Start(socketPath string) error {
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+
+	fp.socketPath = socketPath
+	fp.listener = listener
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(fakePodmanAPI+"/version", fp.version)
+	mux.HandleFunc(fakePodmanAPI+"/containers/", fp.containersHandler)
+	mux.HandleFunc(fakePodmanAPI+"/events", fp.events)
+
+	fp.server = &http.Server{Handler: mux, ReadHeaderTimeout: time.Second}
+
+	go func() {
+		_ = fp.server.Serve(listener)
+	}()
+
+	return nil
+}
+
+// Endpoint returns the socket in the format of the podmanSocket option
+func (fp *FakePodman) Endpoint() string {
+	return "unix://" + fp.socketPath
+}
+
+// Stop Function
+func (fp *FakePodman) Stop() {
+	fp.DropEventStreams()
+
+	if fp.server != nil {
+		_ = fp.server.Close()
+	}
+	_ = os.Remove(fp.socketPath)
+}
+
+// ========================= //
+// == Scripted Lifecycles == //
+// ========================= //
+
+// AddContainer adds a running container (podman run)
+func (fp *FakePodman) AddContainer(container FakeContainer) {
+	fp.containersLock.Lock()
+	defer fp.containersLock.Unlock()
+
+	fp.containers[container.ID] = &container
+
+	fp.publishEvent(container.ID, "start")
+}
+
+// StopContainer stops and removes a container (podman rm -f)
+func (fp *FakePodman) StopContainer(containerID string) {
+	fp.containersLock.Lock()
+	defer fp.containersLock.Unlock()
+
+	if _, ok := fp.containers[containerID]; ok {
+		delete(fp.containers, containerID)
+
+		fp.publishEvent(containerID, "died")
+		fp.publishEvent(containerID, "remove")
+	}
+}
+
+// =================== //
+// == Event Streams == //
+// =================== //
+
+// DropEventStreams ends the current event streams, as a restart of the Podman service would
+func (fp *FakePodman) DropEventStreams() {
+	fp.eventsLock.Lock()
+	defer fp.eventsLock.Unlock()
+
+	for events := range fp.eventStreams {
+		close(events)
+		delete(fp.eventStreams, events)
+	}
+}
+
+// EventStreams returns the number of the connected event streams
+func (fp *FakePodman) EventStreams() int {
+	fp.eventsLock.RLock()
+	defer fp.eventsLock.RUnlock()
+
+	return len(fp.eventStreams)
+}
+
+// publishEvent sends an event of a container to the subscribers in the format of libpod, the ones not keeping up
+// miss it
+func (fp *FakePodman) publishEvent(containerID, action string) {
+	fp.eventsLock.RLock()
+	defer fp.eventsLock.RUnlock()
+
+	event := map[string]interface{}{
+		"status": action,
+		"id":     containerID,
+		"Type":   "container",
+		"Action": action,
+		"Actor": map[string]interface{}{
+			"ID":         containerID,
+			"Attributes": map[string]string{},
+		},
+		"scope":    "local",
+		"time":     time.Now().Unix(),
+		"timeNano": time.Now().UnixNano(),
+	}
+
+	for events := range fp.eventStreams {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// ================ //
+// == Podman API == //
+// ================ //
+
+// writeJSON Function
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// version Function
+func (fp *FakePodman) version(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]string{"Version": "4.4.1", "APIVersion": "4.4.1"})
+}
+
+// containersHandler serves the listing (/containers/json) and the inspect output (/containers/{id}/json)
+func (fp *FakePodman) containersHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, fakePodmanAPI+"/containers/")
+
+	fp.containersLock.RLock()
+	defer fp.containersLock.RUnlock()
+
+	if path == "json" {
+		list := []map[string]interface{}{}
+		for _, container := range fp.containers {
+			list = append(list, map[string]interface{}{"Id": container.ID, "Names": []string{container.Name}, "State": "running"})
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return list[i]["Id"].(string) < list[j]["Id"].(string)
+		})
+
+		writeJSON(w, list)
+		return
+	}
+
+	containerID := strings.TrimSuffix(path, "/json")
+
+	container, ok := fp.containers[containerID]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		writeJSON(w, map[string]interface{}{"cause": "no such container", "response": http.StatusNotFound})
+		return
+	}
+
+	mounts := []map[string]interface{}{}
+	for _, mount := range container.Mounts {
+		mounts = append(mounts, map[string]interface{}{
+			"Type":        "bind",
+			"Source":      mount.Source,
+			"Destination": mount.Destination,
+			"Options":     mount.Options,
+			"RW":          true,
+		})
+	}
+
+	labels := map[string]string{}
+	if container.Namespace != "" {
+		labels["io.kubernetes.pod.namespace"] = container.Namespace
+		labels["io.kubernetes.pod.name"] = container.PodName
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"Id":              container.ID,
+		"Name":            container.Name,
+		"ImageName":       container.Image,
+		"State":           map[string]interface{}{"Status": "running", "Running": true, "Pid": container.Pid},
+		"AppArmorProfile": container.AppArmorProfile,
+		"GraphDriver":     map[string]interface{}{"Name": "overlay", "Data": map[string]string{"MergedDir": container.RootPath}},
+		"Config":          map[string]interface{}{"Labels": labels},
+		"Mounts":          mounts,
+	})
+}
+
+// events streams the container events as JSON lines
+func (fp *FakePodman) events(w http.ResponseWriter, r *http.Request) {
+	events := make(chan map[string]interface{}, 64)
+
+	fp.eventsLock.Lock()
+	fp.eventStreams[events] = struct{}{}
+	fp.eventsLock.Unlock()
+
+	defer func() {
+		fp.eventsLock.Lock()
+		delete(fp.eventStreams, events)
+		fp.eventsLock.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	encoder := json.NewEncoder(w)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
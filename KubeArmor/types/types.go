@@ -28,15 +28,46 @@ type Container struct {
 	EndPointName  string   `json:"endPointName"`
 	Labels        string   `json:"labels"`
 
+	// UID of the pod (from the sandbox of the runtime), and the type of the container in the pod
+	PodUID        string `json:"podUID,omitempty"`
+	ContainerType string `json:"containerType,omitempty"`
+
+	// runtime which registered the container (containerd, cri-o, docker, or podman)
+	Runtime string `json:"runtime,omitempty"`
+
 	AppArmorProfile string `json:"apparmorProfile"`
 
 	// == //
 
 	PidNS uint32 `json:"pidns"`
 	MntNS uint32 `json:"mntns"`
+	Pid   uint32 `json:"pid"`
+
+	// start time of the process (in clock ticks since boot), changed once the container is restarted in place
+	StartTime uint64 `json:"startTime,omitempty"`
+
+	// running or paused by the runtime (e.g., for checkpointing), the updates of a paused container are deferred
+	State string `json:"state,omitempty"`
 
 	MergedDir string `json:"mergedDir"`
 
+	// risky hostPath mounts (sensitive host paths, bidirectional propagation)
+	RiskyMounts []MountFinding `json:"riskyMounts,omitempty"`
+
+	// effective identity from the security context of the pod
+	SecurityContext SecurityIdentity `json:"securityContext,omitempty"`
+
+	// cgroup of the container (cgroupsPath of the runtime spec)
+	CgroupPath string `json:"cgroupPath,omitempty"`
+
+	// identity of the container process from the runtime (unknown if nil), and whether it runs privileged
+	UID        *uint32 `json:"uid,omitempty"`
+	GID        *uint32 `json:"gid,omitempty"`
+	Privileged bool    `json:"privileged,omitempty"`
+
+	// resource limits from the runtime
+	Resources ContainerResources `json:"resources,omitempty"`
+
 	// == //
 
 	PolicyEnabled int `json:"policyEnabled"`
@@ -45,6 +76,43 @@ type Container struct {
 	FileVisibilityEnabled         bool `json:"fileVisibilityEnabled"`
 	NetworkVisibilityEnabled      bool `json:"networkVisibilityEnabled"`
 	CapabilitiesVisibilityEnabled bool `json:"capabilitiesVisibilityEnabled"`
+	SignalVisibilityEnabled       bool `json:"signalVisibilityEnabled"`
+}
+
+// types of the containers in a pod
+const (
+	ContainerTypeRegular   = "regular"
+	ContainerTypeInit      = "init"
+	ContainerTypeEphemeral = "ephemeral"
+)
+
+// states of the containers
+const (
+	ContainerStateRunning = "running"
+	ContainerStatePaused  = "paused"
+)
+
+// MountFinding Structure
+type MountFinding struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Propagation string `json:"propagation,omitempty"`
+	Reason      string `json:"reason"`
+}
+
+// ContainerResources Structure (0 if unlimited)
+type ContainerResources struct {
+	MemoryLimit int64  `json:"memoryLimit,omitempty"`
+	CPUQuota    int64  `json:"cpuQuota,omitempty"`
+	CPUPeriod   uint64 `json:"cpuPeriod,omitempty"`
+	PidsLimit   int64  `json:"pidsLimit,omitempty"`
+}
+
+// SecurityIdentity Structure
+type SecurityIdentity struct {
+	RunAsUser  *int64 `json:"runAsUser,omitempty"`
+	RunAsGroup *int64 `json:"runAsGroup,omitempty"`
+	FsGroup    *int64 `json:"fsGroup,omitempty"`
 }
 
 // PodOwner struct
@@ -58,9 +126,12 @@ type PodOwner struct {
 type EndPoint struct {
 	NamespaceName string `json:"namespaceName"`
 
-	EndPointName  string   `json:"endPointName"`
-	Owner         PodOwner `json:"owner,omitempty"`
-	ContainerName string   `json:"containerName"`
+	EndPointName   string   `json:"endPointName"`
+	PodUID         string   `json:"podUID,omitempty"`
+	Owner          PodOwner `json:"owner,omitempty"`
+	ContainerName  string   `json:"containerName"`
+	ContainerImage string   `json:"containerImage,omitempty"`
+	ImageDigest    string   `json:"imageDigest,omitempty"` // the digest of the image resolved by the runtime
 
 	Labels     map[string]string `json:"labels"`
 	Identities []string          `json:"identities"`
@@ -76,10 +147,17 @@ type EndPoint struct {
 	PolicyEnabled  int            `json:"policyEnabled"`
 	DefaultPosture DefaultPosture `json:"defaultPosture"`
 
+	// default postures set by the annotations of the pod
+	PostureOverride DefaultPosture `json:"postureOverride"`
+
+	// effective identity from the security context of the pod
+	SecurityContext SecurityIdentity `json:"securityContext,omitempty"`
+
 	ProcessVisibilityEnabled      bool `json:"processVisibilityEnabled"`
 	FileVisibilityEnabled         bool `json:"fileVisibilityEnabled"`
 	NetworkVisibilityEnabled      bool `json:"networkVisibilityEnabled"`
 	CapabilitiesVisibilityEnabled bool `json:"capabilitiesVisibilityEnabled"`
+	SignalVisibilityEnabled       bool `json:"signalVisibilityEnabled"`
 }
 
 // Node Structure
@@ -101,6 +179,10 @@ type Node struct {
 
 	ContainerRuntimeVersion string `json:"containerRuntimeVersion"`
 
+	// runtime monitored by KubeArmor and its socket (e.g., containerd, unix:///run/containerd/containerd.sock)
+	ContainerRuntime       string `json:"containerRuntime"`
+	ContainerRuntimeSocket string `json:"containerRuntimeSocket"`
+
 	// == //
 
 	PolicyEnabled int `json:"policyEnabled"`
@@ -109,6 +191,7 @@ type Node struct {
 	FileVisibilityEnabled         bool `json:"fileVisibilityEnabled"`
 	NetworkVisibilityEnabled      bool `json:"networkVisibilityEnabled"`
 	CapabilitiesVisibilityEnabled bool `json:"capabilitiesVisibilityEnabled"`
+	SignalVisibilityEnabled       bool `json:"signalVisibilityEnabled"`
 }
 
 // ================ //
@@ -128,6 +211,12 @@ type K8sPod struct {
 	Labels          map[string]string
 	Containers      map[string]string
 	ContainerImages map[string]string
+
+	// types of the containers (container ID -> init, ephemeral or regular)
+	ContainerTypes map[string]string
+
+	// effective identities of the containers (container name -> identity)
+	SecurityContexts map[string]SecurityIdentity
 }
 
 // K8sPodEvent Structure
@@ -181,8 +270,32 @@ type K8sKubeArmorHostPolicies struct {
 // == Logging == //
 // ============= //
 
+// WriteCapture Structure
+type WriteCapture struct {
+	// lsm (sampled at the hook of the write), syscall (the write in progress), or fdinfo (the open handles only)
+	Source string `json:"source"`
+
+	// the handle of the write
+	FD     int32  `json:"fd,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Offset int64  `json:"offset,omitempty"`
+	Flags  string `json:"flags,omitempty"`
+
+	// the attempted size, and the first bytes of the buffer (base64)
+	Size      int64  `json:"size,omitempty"`
+	Sample    string `json:"sample,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Redacted  bool   `json:"redacted,omitempty"`
+
+	// writable handles of the process (fd=N path=... pos=N flags=N)
+	Handles []string `json:"handles,omitempty"`
+}
+
 // Log Structure
 type Log struct {
+	// version of the telemetry schema (major.minor)
+	SchemaVersion string `json:"schemaVersion"`
+
 	// updated time
 	Timestamp   int64  `json:"timestamp"`
 	UpdatedTime string `json:"updatedTime"`
@@ -202,6 +315,15 @@ type Log struct {
 	ContainerName  string `json:"containerName,omitempty"`
 	ContainerImage string `json:"containerImage,omitempty"`
 
+	// the container runs privileged
+	Privileged bool `json:"privileged,omitempty"`
+
+	// terminated if the container was destroyed before the event was emitted
+	ContainerState string `json:"containerState,omitempty"`
+
+	// init, ephemeral or regular
+	ContainerType string `json:"containerType,omitempty"`
+
 	// container merged directory
 	MergedDir string `json:"mergedDir,omitempty"`
 
@@ -219,14 +341,18 @@ type Log struct {
 	// enforcer
 	Enforcer string `json:"enforcer,omitempty"`
 
-	// policy
-	PolicyName string `json:"policyName,omitempty"`
+	// policy, its namespace (empty for the host policies), and the rule which matched (e.g., process/path:/bin/sh)
+	PolicyName      string `json:"policyName,omitempty"`
+	PolicyNamespace string `json:"policyNamespace,omitempty"`
+	MatchedRule     string `json:"matchedRule,omitempty"`
 
 	// severity, tags, message
-	Severity string   `json:"severity,omitempty"`
-	Tags     string   `json:"tags,omitempty"`
-	ATags    []string `json:"atags"`
-	Message  string   `json:"message,omitempty"`
+	Severity       string   `json:"severity,omitempty"`
+	PolicySeverity string   `json:"policySeverity,omitempty"`
+	SeverityLabel  string   `json:"severityLabel,omitempty"`
+	Tags           string   `json:"tags,omitempty"`
+	ATags          []string `json:"atags"`
+	Message        string   `json:"message,omitempty"`
 
 	// log
 	Type      string `json:"type"`
@@ -238,6 +364,30 @@ type Log struct {
 	Action    string `json:"action,omitempty"`
 	Result    string `json:"result"`
 
+	// creator of the socket if it differs from the current process
+	SocketCreator string `json:"socketCreator,omitempty"`
+
+	// session of the process (exec for the processes run by kubectl exec)
+	Session string `json:"session,omitempty"`
+
+	// timestamp computed with a recalibrated clock offset (e.g., after suspend/resume)
+	ClockResync bool `json:"clockResync,omitempty"`
+
+	// layer which set the default posture of an alert (e.g., namespace-annotation:payments)
+	PostureSource string `json:"postureSource,omitempty"`
+
+	// sample of a blocked write (captureOnBlock)
+	Capture *WriteCapture `json:"capture,omitempty"`
+
+	// whether the verdict of a Block rule was applied (Enforced, Failed, BestEffort)
+	EnforcementStatus string `json:"enforcementStatus,omitempty"`
+
+	// identity used to decide the ownership of an ownerOnly rule (e.g., uid=1000,fsGroup=2000)
+	OwnerIdentity string `json:"ownerIdentity,omitempty"`
+
+	// audited Block rule of a policy in the Audit mode or in its maturation period, which would have blocked the operation
+	WouldBeBlocked bool `json:"wouldBeBlocked,omitempty"`
+
 	// == //
 
 	PolicyEnabled int `json:"policyEnabled,omitempty"`
@@ -246,12 +396,16 @@ type Log struct {
 	FileVisibilityEnabled         bool `json:"fileVisibilityEnabled,omitempty"`
 	NetworkVisibilityEnabled      bool `json:"networkVisibilityEnabled,omitempty"`
 	CapabilitiesVisibilityEnabled bool `json:"capabilitiesVisibilityEnabled,omitempty"`
+	SignalVisibilityEnabled       bool `json:"signalVisibilityEnabled,omitempty"`
 }
 
 // MatchPolicy Structure
 type MatchPolicy struct {
 	PolicyName string
 
+	// namespace of the policy (empty for the host policies)
+	PolicyNamespace string
+
 	Severity string
 	Tags     []string
 	Message  string
@@ -269,7 +423,43 @@ type MatchPolicy struct {
 	Regexp *regexp.Regexp
 	Native bool
 
+	// target path of attribute rules (a file, or a directory with a trailing slash)
+	Target string
+	// operations of attribute rules (set, remove) and namespace rules (unshare, setns)
+	Operations []string
+	// sources excepted from fileless rules (exceptFromSource)
+	ExceptSources []string
+
+	// signals, scope of the targets (cross-container, host), and the targets excluded from signal rules
+	Signals         []string
+	SignalScope     string
+	ExcludeSelf     bool
+	ExcludeChildren bool
+
 	Action string
+
+	// report the matches of Allow rules (logAllowed)
+	LogAllowed bool
+
+	// Block rule audited by the Audit mode or the maturation period of the policy
+	WouldBeBlocked bool
+
+	// executions per minute and bucket size of Throttle rules
+	Rate  int
+	Burst int
+
+	// attach a sample of the blocked writes to the alerts (captureOnBlock)
+	CaptureOnBlock bool
+
+	// match the events in (or out of) the kubectl exec sessions only
+	OnlyExecSession    bool
+	ExcludeExecSession bool
+
+	// when the rules of the policy were delivered for the endpoint
+	Attached time.Time
+
+	// identity of the pod owning the files of ownerOnly rules (nil for the process uid only)
+	OwnerIdentity *SecurityIdentity
 }
 
 // MatchPolicies Structure
@@ -288,11 +478,28 @@ const (
 	KubeArmorPolicyAudited  = 2
 )
 
+// modes of the policies (the Block rules of the policies in the Audit mode are audited, as a dry-run)
+const (
+	PolicyModeAudit   = "Audit"
+	PolicyModeEnforce = "Enforce"
+)
+
+// ExecSession is the session of the processes run in a container by kubectl exec
+const ExecSession = "exec"
+
+// MatchExpressionType Structure (In, NotIn, Exists, DoesNotExist)
+type MatchExpressionType struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"`
+	Values   []string `json:"values,omitempty"`
+}
+
 // SelectorType Structure
 type SelectorType struct {
-	MatchLabels map[string]string `json:"matchLabels,omitempty"`
-	Containers  []string          `json:"containers,omitempty"`
-	Identities  []string          `json:"identities,omitempty"` // set during policy update
+	MatchLabels      map[string]string     `json:"matchLabels,omitempty"`
+	MatchExpressions []MatchExpressionType `json:"matchExpressions,omitempty"`
+	Containers       []string              `json:"containers,omitempty"`
+	Identities       []string              `json:"identities,omitempty"` // set during policy update
 }
 
 // MatchSourceType Structure
@@ -306,6 +513,14 @@ type ProcessPathType struct {
 	OwnerOnly  bool              `json:"ownerOnly,omitempty"`
 	FromSource []MatchSourceType `json:"fromSource,omitempty"`
 
+	// executions per minute and bucket size of Throttle rules
+	Rate  int `json:"rate,omitempty"`
+	Burst int `json:"burst,omitempty"`
+
+	// conditions on the kubectl exec sessions (evaluated in userspace)
+	OnlyExecSession    bool `json:"onlyExecSession,omitempty"`
+	ExcludeExecSession bool `json:"excludeExecSession,omitempty"`
+
 	Severity int      `json:"severity,omitempty"`
 	Tags     []string `json:"tags,omitempty"`
 	Message  string   `json:"message,omitempty"`
@@ -319,6 +534,10 @@ type ProcessDirectoryType struct {
 	OwnerOnly  bool              `json:"ownerOnly,omitempty"`
 	FromSource []MatchSourceType `json:"fromSource,omitempty"`
 
+	// conditions on the kubectl exec sessions (evaluated in userspace)
+	OnlyExecSession    bool `json:"onlyExecSession,omitempty"`
+	ExcludeExecSession bool `json:"excludeExecSession,omitempty"`
+
 	Severity int      `json:"severity,omitempty"`
 	Tags     []string `json:"tags,omitempty"`
 	Message  string   `json:"message,omitempty"`
@@ -330,17 +549,65 @@ type ProcessPatternType struct {
 	Pattern   string `json:"pattern"`
 	OwnerOnly bool   `json:"ownerOnly,omitempty"`
 
+	// conditions on the kubectl exec sessions (evaluated in userspace)
+	OnlyExecSession    bool `json:"onlyExecSession,omitempty"`
+	ExcludeExecSession bool `json:"excludeExecSession,omitempty"`
+
 	Severity int      `json:"severity,omitempty"`
 	Tags     []string `json:"tags,omitempty"`
 	Message  string   `json:"message,omitempty"`
 	Action   string   `json:"action,omitempty"`
 }
 
+// ProcessNamespaceType Structure
+type ProcessNamespaceType struct {
+	Namespace  string            `json:"namespace"`
+	Operations []string          `json:"operations,omitempty"`
+	FromSource []MatchSourceType `json:"fromSource,omitempty"`
+
+	Severity int      `json:"severity,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Message  string   `json:"message,omitempty"`
+	Action   string   `json:"action,omitempty"`
+}
+
+// ProcessSignalType Structure
+type ProcessSignalType struct {
+	Signals    []string          `json:"signals"`
+	Target     []MatchSourceType `json:"target,omitempty"`
+	Scope      string            `json:"scope,omitempty"`
+	FromSource []MatchSourceType `json:"fromSource,omitempty"`
+
+	// signals to the sender itself, or to its direct children
+	ExcludeSelf     bool `json:"excludeSelf,omitempty"`
+	ExcludeChildren bool `json:"excludeChildren,omitempty"`
+
+	Severity int      `json:"severity,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Message  string   `json:"message,omitempty"`
+	Action   string   `json:"action,omitempty"`
+}
+
+// ProcessFilelessType Structure
+type ProcessFilelessType struct {
+	ExceptFromSource []MatchSourceType `json:"exceptFromSource,omitempty"`
+
+	Severity int      `json:"severity,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Message  string   `json:"message,omitempty"`
+	Action   string   `json:"action"`
+}
+
 // ProcessType Structure
 type ProcessType struct {
 	MatchPaths       []ProcessPathType      `json:"matchPaths,omitempty"`
 	MatchDirectories []ProcessDirectoryType `json:"matchDirectories,omitempty"`
 	MatchPatterns    []ProcessPatternType   `json:"matchPatterns,omitempty"`
+	MatchNamespaces  []ProcessNamespaceType `json:"matchNamespaces,omitempty"`
+	MatchSignals     []ProcessSignalType    `json:"matchSignals,omitempty"`
+
+	// executions of files without a path (memfd, O_TMPFILE, deleted files)
+	BlockFileless *ProcessFilelessType `json:"blockFileless,omitempty"`
 
 	Severity int      `json:"severity,omitempty"`
 	Tags     []string `json:"tags,omitempty"`
@@ -350,10 +617,15 @@ type ProcessType struct {
 
 // FilePathType Structure
 type FilePathType struct {
-	Path       string            `json:"path"`
-	ReadOnly   bool              `json:"readOnly,omitempty"`
-	OwnerOnly  bool              `json:"ownerOnly,omitempty"`
-	FromSource []MatchSourceType `json:"fromSource,omitempty"`
+	Path           string            `json:"path"`
+	ReadOnly       bool              `json:"readOnly,omitempty"`
+	OwnerOnly      bool              `json:"ownerOnly,omitempty"`
+	CaptureOnBlock bool              `json:"captureOnBlock,omitempty"`
+	FromSource     []MatchSourceType `json:"fromSource,omitempty"`
+
+	// conditions on the kubectl exec sessions (evaluated in userspace)
+	OnlyExecSession    bool `json:"onlyExecSession,omitempty"`
+	ExcludeExecSession bool `json:"excludeExecSession,omitempty"`
 
 	Severity int      `json:"severity,omitempty"`
 	Tags     []string `json:"tags,omitempty"`
@@ -363,11 +635,16 @@ type FilePathType struct {
 
 // FileDirectoryType Structure
 type FileDirectoryType struct {
-	Directory  string            `json:"dir"`
-	ReadOnly   bool              `json:"readOnly,omitempty"`
-	Recursive  bool              `json:"recursive,omitempty"`
-	OwnerOnly  bool              `json:"ownerOnly,omitempty"`
-	FromSource []MatchSourceType `json:"fromSource,omitempty"`
+	Directory      string            `json:"dir"`
+	ReadOnly       bool              `json:"readOnly,omitempty"`
+	Recursive      bool              `json:"recursive,omitempty"`
+	OwnerOnly      bool              `json:"ownerOnly,omitempty"`
+	CaptureOnBlock bool              `json:"captureOnBlock,omitempty"`
+	FromSource     []MatchSourceType `json:"fromSource,omitempty"`
+
+	// conditions on the kubectl exec sessions (evaluated in userspace)
+	OnlyExecSession    bool `json:"onlyExecSession,omitempty"`
+	ExcludeExecSession bool `json:"excludeExecSession,omitempty"`
 
 	Severity int      `json:"severity,omitempty"`
 	Tags     []string `json:"tags,omitempty"`
@@ -377,9 +654,38 @@ type FileDirectoryType struct {
 
 // FilePatternType Structure
 type FilePatternType struct {
-	Pattern   string `json:"pattern"`
-	ReadOnly  bool   `json:"readOnly,omitempty"`
-	OwnerOnly bool   `json:"ownerOnly,omitempty"`
+	Pattern        string `json:"pattern"`
+	ReadOnly       bool   `json:"readOnly,omitempty"`
+	OwnerOnly      bool   `json:"ownerOnly,omitempty"`
+	CaptureOnBlock bool   `json:"captureOnBlock,omitempty"`
+
+	// conditions on the kubectl exec sessions (evaluated in userspace)
+	OnlyExecSession    bool `json:"onlyExecSession,omitempty"`
+	ExcludeExecSession bool `json:"excludeExecSession,omitempty"`
+
+	Severity int      `json:"severity,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Message  string   `json:"message,omitempty"`
+	Action   string   `json:"action,omitempty"`
+}
+
+// FileXattrType Structure
+type FileXattrType struct {
+	Name       string            `json:"name"`
+	Operations []string          `json:"operations,omitempty"`
+	Path       string            `json:"path,omitempty"`
+	FromSource []MatchSourceType `json:"fromSource,omitempty"`
+
+	Severity int      `json:"severity,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Message  string   `json:"message,omitempty"`
+	Action   string   `json:"action,omitempty"`
+}
+
+// FileImmutableType Structure
+type FileImmutableType struct {
+	Path       string            `json:"path,omitempty"`
+	FromSource []MatchSourceType `json:"fromSource,omitempty"`
 
 	Severity int      `json:"severity,omitempty"`
 	Tags     []string `json:"tags,omitempty"`
@@ -392,6 +698,8 @@ type FileType struct {
 	MatchPaths       []FilePathType      `json:"matchPaths,omitempty"`
 	MatchDirectories []FileDirectoryType `json:"matchDirectories,omitempty"`
 	MatchPatterns    []FilePatternType   `json:"matchPatterns,omitempty"`
+	MatchXattrs      []FileXattrType     `json:"matchXattrs,omitempty"`
+	MatchImmutable   []FileImmutableType `json:"matchImmutable,omitempty"`
 
 	Severity int      `json:"severity,omitempty"`
 	Tags     []string `json:"tags,omitempty"`
@@ -410,10 +718,23 @@ type NetworkProtocolType struct {
 	Action   string   `json:"action,omitempty"`
 }
 
+// NetworkRuntimeSocketType Structure
+type NetworkRuntimeSocketType struct {
+	FromSource []MatchSourceType `json:"fromSource,omitempty"`
+
+	Severity int      `json:"severity,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Message  string   `json:"message,omitempty"`
+	Action   string   `json:"action,omitempty"`
+}
+
 // NetworkType Structure
 type NetworkType struct {
 	MatchProtocols []NetworkProtocolType `json:"matchProtocols,omitempty"`
 
+	// connections to the sockets of the container runtimes
+	MatchRuntimeSockets []NetworkRuntimeSocketType `json:"matchRuntimeSockets,omitempty"`
+
 	Severity int      `json:"severity,omitempty"`
 	Tags     []string `json:"tags,omitempty"`
 	Message  string   `json:"message,omitempty"`
@@ -496,6 +817,33 @@ type SecuritySpec struct {
 	Tags     []string `json:"tags,omitempty"`
 	Message  string   `json:"message,omitempty"`
 	Action   string   `json:"action"`
+
+	// matched Allow rules are reported as aggregated telemetry
+	LogAllowed bool `json:"logAllowed,omitempty"`
+
+	// identity owning the files of ownerOnly rules (Pod or Process)
+	OwnerIdentity string `json:"ownerIdentity,omitempty"`
+
+	// Block rules are audited until the period elapses since the creation of the policy
+	MaturationPeriod string `json:"maturationPeriod,omitempty"`
+
+	// Block rules are audited in the Audit mode (Enforce by default)
+	Mode string `json:"mode,omitempty"`
+
+	// the policy is active within the windows of its schedule only (always if none)
+	Schedule *ScheduleType `json:"schedule,omitempty"`
+}
+
+// ScheduleWindowType Structure (cron expressions of the starts and of the ends of a window)
+type ScheduleWindowType struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// ScheduleType Structure
+type ScheduleType struct {
+	Windows  []ScheduleWindowType `json:"windows"`
+	TimeZone string               `json:"timeZone,omitempty"`
 }
 
 // SecurityPolicy Structure
@@ -510,8 +858,9 @@ type SecurityPolicy struct {
 
 // NodeSelectorType Structure
 type NodeSelectorType struct {
-	MatchLabels map[string]string `json:"matchLabels,omitempty"`
-	Identities  []string          `json:"identities,omitempty"` // set during policy update
+	MatchLabels      map[string]string     `json:"matchLabels,omitempty"`
+	MatchExpressions []MatchExpressionType `json:"matchExpressions,omitempty"`
+	Identities       []string              `json:"identities,omitempty"` // set during policy update
 }
 
 // HostSecuritySpec Structure
@@ -530,19 +879,225 @@ type HostSecuritySpec struct {
 	Tags     []string `json:"tags,omitempty"`
 	Message  string   `json:"message,omitempty"`
 	Action   string   `json:"action"`
+
+	// matched Allow rules are reported as aggregated telemetry
+	LogAllowed bool `json:"logAllowed,omitempty"`
+
+	// Block rules are audited until the period elapses since the creation of the policy
+	MaturationPeriod string `json:"maturationPeriod,omitempty"`
+
+	// Block rules are audited in the Audit mode (Enforce by default)
+	Mode string `json:"mode,omitempty"`
 }
 
+// SelfProtectionPolicyName is the name of the host policy protecting the local state of KubeArmor, whose Allow rules
+// exempt KubeArmor itself without turning the default posture of the host into an allow-list
+const SelfProtectionPolicyName = "kubearmor-self-protection"
+
 // HostSecurityPolicy Structure
 type HostSecurityPolicy struct {
 	Metadata map[string]string `json:"metadata"`
 	Spec     HostSecuritySpec  `json:"spec"`
 }
 
+// sources of default postures, from the lowest precedence
+const (
+	PostureSourceGlobal    = "global-config"
+	PostureSourceNamespace = "namespace-annotation"
+	PostureSourceEndPoint  = "endpoint-override"
+)
+
 // DefaultPosture Structure
 type DefaultPosture struct {
 	FileAction         string `json:"file,omitempty"`
 	NetworkAction      string `json:"network,omitempty"`
 	CapabilitiesAction string `json:"capabilties,omitempty"`
+
+	// layers which set the postures
+	FileSource         string `json:"-"`
+	NetworkSource      string `json:"-"`
+	CapabilitiesSource string `json:"-"`
+}
+
+// PostureLayer Structure
+type PostureLayer struct {
+	Source  string `json:"source"`
+	Posture string `json:"posture,omitempty"`
+	Applied bool   `json:"applied,omitempty"`
+}
+
+// PostureExplanation Structure
+type PostureExplanation struct {
+	Operation string         `json:"operation"`
+	Posture   string         `json:"posture"`
+	Source    string         `json:"source"`
+	Layers    []PostureLayer `json:"layers"`
+}
+
+// DegradedEndPoint Structure
+type DegradedEndPoint struct {
+	NamespaceName string `json:"namespaceName"`
+	EndPointName  string `json:"endPointName"`
+	ContainerName string `json:"containerName"`
+
+	Enforcer string    `json:"enforcer"`
+	Error    string    `json:"error"`
+	Since    time.Time `json:"since"`
+	Retries  int       `json:"retries"`
+}
+
+// AppliedEndPoint is the last update of the rules of an endpoint in the enforcer
+type AppliedEndPoint struct {
+	Enforcer string    `json:"enforcer"`
+	Policies []string  `json:"policies"`
+	Error    string    `json:"error,omitempty"`
+	Updated  time.Time `json:"updated"`
+}
+
+// PolicyEnforcement is the state of a policy of a container in the enforcer
+type PolicyEnforcement struct {
+	PolicyName string   `json:"policyName"`
+	Actions    []string `json:"actions"`
+
+	// loaded by the enforcer, or the reason why it isn't (e.g., an error of apparmor_parser)
+	Loaded bool   `json:"loaded"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ContainerEnforcement is the enforcement state of a container
+type ContainerEnforcement struct {
+	ContainerID   string `json:"containerID"`
+	ContainerName string `json:"containerName"`
+	NamespaceName string `json:"namespaceName"`
+	EndPointName  string `json:"endPointName"`
+
+	AppArmorProfile string `json:"apparmorProfile,omitempty"`
+	PolicyEnabled   bool   `json:"policyEnabled"`
+	Enforcer        string `json:"enforcer,omitempty"`
+
+	Policies []PolicyEnforcement `json:"policies"`
+
+	RiskyMounts []string `json:"riskyMounts,omitempty"`
+}
+
+// ExecRecord is a process execution kept for incident triage
+type ExecRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+
+	ContainerID   string `json:"containerID"`
+	NamespaceName string `json:"namespaceName"`
+	PodName       string `json:"podName"`
+
+	HostPID uint32 `json:"hostPid"`
+	PID     uint32 `json:"pid"`
+	PPID    uint32 `json:"ppid"`
+	UID     uint32 `json:"uid"`
+
+	ExecPath       string `json:"execPath"`
+	ParentExecPath string `json:"parentExecPath,omitempty"`
+
+	// sha256 of the arguments, which are only kept unless they are redacted
+	ArgsHash string   `json:"argsHash"`
+	Args     []string `json:"args,omitempty"`
+	Redacted bool     `json:"redacted,omitempty"`
+}
+
+// ContainerRetry is the retry state of a container which failed to be added
+type ContainerRetry struct {
+	ContainerID string `json:"containerID"`
+
+	Attempts     int       `json:"attempts"`
+	FirstFailure time.Time `json:"firstFailure"`
+	NextRetry    time.Time `json:"nextRetry,omitempty"`
+	LastError    string    `json:"lastError"`
+
+	// not retried anymore after the retry window
+	GaveUp bool `json:"gaveUp,omitempty"`
+}
+
+// RuntimeHandlerHealth is the state of the connection of a runtime handler
+type RuntimeHandlerHealth struct {
+	Runtime   string `json:"runtime"`
+	Socket    string `json:"socket"`
+	Connected bool   `json:"connected"`
+
+	// containers tracked by the handler
+	Containers int `json:"containers"`
+
+	// the last successful listing of the containers, and the error of the last listing which failed
+	LastList  time.Time `json:"lastList,omitempty"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// DaemonHealth is the state of the runtime handlers, the enforcer and the system monitor
+type DaemonHealth struct {
+	Runtimes []RuntimeHandlerHealth `json:"runtimes"`
+
+	EnforcerInitialized bool   `json:"enforcerInitialized"`
+	Enforcer            string `json:"enforcer,omitempty"`
+	MonitorInitialized  bool   `json:"monitorInitialized"`
+
+	// the features whose BPF programs are available on the architecture of the node
+	Features []BPFFeatureState `json:"features,omitempty"`
+}
+
+// BPFFeatureState is the availability of a feature with BPF programs on the architecture of the node
+type BPFFeatureState struct {
+	Feature   string `json:"feature"`
+	Arch      string `json:"arch"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// EffectiveRule Structure
+type EffectiveRule struct {
+	// processPath, processDirectory, processPattern, filePath, fileDirectory, filePattern, networkProtocol or capability
+	Kind   string `json:"kind"`
+	Entity string `json:"entity"`
+	Source string `json:"source,omitempty"`
+
+	Action         string `json:"action"`
+	OwnerOnly      bool   `json:"ownerOnly,omitempty"`
+	ReadOnly       bool   `json:"readOnly,omitempty"`
+	Recursive      bool   `json:"recursive,omitempty"`
+	CaptureOnBlock bool   `json:"captureOnBlock,omitempty"`
+
+	// the matches of the Allow rule are reported (logAllowed)
+	LogAllowed bool `json:"logAllowed,omitempty"`
+
+	// executions per minute and bucket size of Throttle rules
+	Rate  int `json:"rate,omitempty"`
+	Burst int `json:"burst,omitempty"`
+
+	// namespace/policy contributing the rule
+	Policy string `json:"policy"`
+
+	// the rule of another policy for the same entity (and source) takes precedence
+	Overridden   bool   `json:"overridden,omitempty"`
+	OverriddenBy string `json:"overriddenBy,omitempty"`
+}
+
+// EffectivePolicy is the merged rule set of the security policies of an endpoint
+type EffectivePolicy struct {
+	NamespaceName string `json:"namespaceName,omitempty"`
+	EndPointName  string `json:"endPointName,omitempty"`
+
+	// namespace/policy, in the order of the merge
+	Policies []string `json:"policies"`
+
+	// the sections with Allow rules (including the overridden ones), which are allow-lists under the default posture of block
+	ProcessAllowList      bool `json:"processAllowList,omitempty"`
+	FileAllowList         bool `json:"fileAllowList,omitempty"`
+	NetworkAllowList      bool `json:"networkAllowList,omitempty"`
+	CapabilitiesAllowList bool `json:"capabilitiesAllowList,omitempty"`
+
+	Rules []EffectiveRule `json:"rules"`
+}
+
+// SeverityRange Structure
+type SeverityRange struct {
+	Min int `json:"min,omitempty"`
+	Max int `json:"max,omitempty"`
 }
 
 // Visibility Structure
@@ -551,6 +1106,7 @@ type Visibility struct {
 	Process      bool `json:"process,omitempty"`
 	Network      bool `json:"network,omitempty"`
 	Capabilities bool `json:"capabilties,omitempty"`
+	Signal       bool `json:"signal,omitempty"`
 }
 
 // ================== //
@@ -598,6 +1154,9 @@ type PidNode struct {
 	Source string
 	Args   string
 
+	// session of the process (inherited from the parent)
+	Session string
+
 	Exited     bool
 	ExitedTime time.Time
 }
@@ -53,8 +53,13 @@ func GetClusterRole() *rbacv1.ClusterRole {
 			},
 			{
 				APIGroups: []string{"security.kubearmor.com"},
-				Resources: []string{"kubearmorpolicies", "kubearmorhostpolicies"},
-				Verbs:     []string{"get", "list", "watch", "update", "delete"},
+				Resources: []string{"kubearmorpolicies", "kubearmorhostpolicies", "kubearmorclusterpolicies"},
+				Verbs:     []string{"get", "list", "watch", "update", "patch", "delete"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"events"},
+				Verbs:     []string{"create", "get", "update"},
 			},
 			{
 				NonResourceURLs: []string{"/apis", "/apis/*"},
@@ -609,6 +614,11 @@ func GetKubeArmorControllerClusterRole() *rbacv1.ClusterRole {
 				Resources: []string{"pods"},
 				Verbs:     []string{"create", "delete", "get", "patch", "list", "watch", "update"},
 			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"nodes"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
 			{
 				APIGroups: []string{"security.kubearmor.com"},
 				Resources: []string{"kubearmorpolicies", "kubearmorhostpolicies"},
@@ -827,6 +837,13 @@ var KubeArmorControllerMutationFullName = "annotation.kubearmor.com"
 var KubeArmorControllerPodMutationPath = "/mutate-pods"
 var KubeArmorControllerPodMutationFailurePolicy = admissionregistrationv1.Ignore
 var KubeArmorControllerMutationSideEffect = admissionregistrationv1.SideEffectClassNoneOnDryRun
+var KubeArmorControllerPolicyMutationFullName = "action.kubearmor.com"
+var KubeArmorControllerPolicyMutationPath = "/mutate-kubearmorpolicies"
+var KubeArmorControllerPolicyMutationSideEffect = admissionregistrationv1.SideEffectClassNone
+var KubeArmorControllerHostPolicyMutationFullName = "severity.kubearmor.com"
+var KubeArmorControllerHostPolicyMutationPath = "/mutate-kubearmorhostpolicies"
+var KubeArmorControllerNamespaceMutationFullName = "visibility.kubearmor.com"
+var KubeArmorControllerNamespaceMutationPath = "/mutate-namespaces"
 
 // GetKubeArmorControllerMutationAdmissionConfiguration Function
 func GetKubeArmorControllerMutationAdmissionConfiguration(namespace string, caCert []byte) *admissionregistrationv1.MutatingWebhookConfiguration {
@@ -875,6 +892,86 @@ func GetKubeArmorControllerMutationAdmissionConfiguration(namespace string, caCe
 					},
 				},
 			},
+			{
+				Name:                    KubeArmorControllerPolicyMutationFullName,
+				AdmissionReviewVersions: []string{"v1"},
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service: &admissionregistrationv1.ServiceReference{
+						Namespace: namespace,
+						Name:      KubeArmorControllerWebhookServiceName,
+						Path:      &KubeArmorControllerPolicyMutationPath,
+					},
+					CABundle: caCert,
+				},
+				FailurePolicy: &KubeArmorControllerPodMutationFailurePolicy,
+				Rules: []admissionregistrationv1.RuleWithOperations{
+					{
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{"security.kubearmor.com"},
+							APIVersions: []string{"v1"},
+							Resources:   []string{"kubearmorpolicies"},
+						},
+						Operations: []admissionregistrationv1.OperationType{
+							admissionregistrationv1.Create,
+							admissionregistrationv1.Update,
+						},
+					},
+				},
+				SideEffects: &KubeArmorControllerPolicyMutationSideEffect,
+			},
+			{
+				Name:                    KubeArmorControllerHostPolicyMutationFullName,
+				AdmissionReviewVersions: []string{"v1"},
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service: &admissionregistrationv1.ServiceReference{
+						Namespace: namespace,
+						Name:      KubeArmorControllerWebhookServiceName,
+						Path:      &KubeArmorControllerHostPolicyMutationPath,
+					},
+					CABundle: caCert,
+				},
+				FailurePolicy: &KubeArmorControllerPodMutationFailurePolicy,
+				Rules: []admissionregistrationv1.RuleWithOperations{
+					{
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{"security.kubearmor.com"},
+							APIVersions: []string{"v1"},
+							Resources:   []string{"kubearmorhostpolicies"},
+						},
+						Operations: []admissionregistrationv1.OperationType{
+							admissionregistrationv1.Create,
+							admissionregistrationv1.Update,
+						},
+					},
+				},
+				SideEffects: &KubeArmorControllerPolicyMutationSideEffect,
+			},
+			{
+				Name:                    KubeArmorControllerNamespaceMutationFullName,
+				AdmissionReviewVersions: []string{"v1"},
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service: &admissionregistrationv1.ServiceReference{
+						Namespace: namespace,
+						Name:      KubeArmorControllerWebhookServiceName,
+						Path:      &KubeArmorControllerNamespaceMutationPath,
+					},
+					CABundle: caCert,
+				},
+				FailurePolicy: &KubeArmorControllerPodMutationFailurePolicy,
+				Rules: []admissionregistrationv1.RuleWithOperations{
+					{
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{""},
+							APIVersions: []string{"v1"},
+							Resources:   []string{"namespaces"},
+						},
+						Operations: []admissionregistrationv1.OperationType{
+							admissionregistrationv1.Create,
+						},
+					},
+				},
+				SideEffects: &KubeArmorControllerPolicyMutationSideEffect,
+			},
 		},
 	}
 }
@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+// Package client provides typed helpers to consume the gRPC APIs of KubeArmor
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ============ //
+// == Client == //
+// ============ //
+
+// client constants
+const (
+	// default gRPC port of KubeArmor
+	DefaultAddress = "localhost:32767"
+
+	// prefix of the addresses of unix domain sockets
+	UnixPrefix = "unix://"
+)
+
+// Options Structure
+type Options struct {
+	// TLS configuration (plaintext if nil and no CA file is given)
+	TLS *tls.Config
+
+	// CA certificate and optional client certificate, loaded into the TLS configuration
+	CAFile   string
+	CertFile string
+	KeyFile  string
+
+	// block until the connection is up, within the deadline of the context
+	Block bool
+
+	// additional dial options
+	DialOptions []grpc.DialOption
+}
+
+// Client Structure
+type Client struct {
+	// address of KubeArmor (host:port, or unix:///path/to/socket)
+	Address string
+
+	conn *grpc.ClientConn
+
+	logs     pb.LogServiceClient
+	policies pb.PolicyServiceClient
+	probe    pb.ProbeServiceClient
+	admin    pb.AdminServiceClient
+}
+
+// tlsConfig returns the TLS configuration of the options, or nil for plaintext
+func (opts Options) tlsConfig() (*tls.Config, error) {
+	config := opts.TLS
+
+	if opts.CAFile == "" && opts.CertFile == "" {
+		return config, nil
+	}
+
+	if config == nil {
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	} else {
+		config = config.Clone()
+	}
+
+	if opts.CAFile != "" {
+		ca, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no certificate found in the CA file")
+		}
+		config.RootCAs = pool
+	}
+
+	if opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the client certificate: %w", err)
+		}
+		config.Certificates = append(config.Certificates, cert)
+	}
+
+	return config, nil
+}
+
+// Connect creates a client of KubeArmor at the given address (DefaultAddress if empty)
+func Connect(ctx context.Context, address string, opts Options) (*Client, error) {
+	if address == "" {
+		address = DefaultAddress
+	}
+
+	tlsConfig, err := opts.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	dialOptions := []grpc.DialOption{}
+
+	if tlsConfig != nil {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	if opts.Block {
+		dialOptions = append(dialOptions, grpc.WithBlock())
+	}
+
+	dialOptions = append(dialOptions, opts.DialOptions...)
+
+	target := address
+	if strings.HasPrefix(address, "/") {
+		// a bare path is a unix domain socket
+		target = UnixPrefix + address
+	}
+
+	conn, err := grpc.DialContext(ctx, target, dialOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+
+	return NewClient(address, conn), nil
+}
+
+// NewClient creates a client over an existing connection
+func NewClient(address string, conn *grpc.ClientConn) *Client {
+	return &Client{
+		Address: address,
+
+		conn: conn,
+
+		logs:     pb.NewLogServiceClient(conn),
+		policies: pb.NewPolicyServiceClient(conn),
+		probe:    pb.NewProbeServiceClient(conn),
+		admin:    pb.NewAdminServiceClient(conn),
+	}
+}
+
+// Conn returns the underlying connection
+func (c *Client) Conn() *grpc.ClientConn {
+	return c.conn
+}
+
+// Close Function
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// HealthCheck checks that the log service of KubeArmor responds, and returns the health of its alert sinks
+func (c *Client) HealthCheck(ctx context.Context) ([]*pb.SinkStatus, error) {
+	nonce := int32(time.Now().UnixNano() & 0x7fffffff)
+
+	reply, err := c.logs.HealthCheck(ctx, &pb.NonceMessage{Nonce: nonce})
+	if err != nil {
+		return nil, err
+	}
+
+	if reply.Retval != nonce {
+		return nil, fmt.Errorf("unexpected health check reply (%d != %d)", reply.Retval, nonce)
+	}
+
+	return reply.Sinks, nil
+}
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	securityv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWatchAlertsReconnect(t *testing.T) {
+	fake, err := newFakeKubeArmor(t.TempDir())
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to start a fake KubeArmor (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	// each stream breaks after 2 alerts
+	fake.breakEach = 2
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := Connect(ctx, fake.Address, Options{})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to connect (%s)", err.Error())
+	}
+	defer client.Close()
+
+	since := time.Now()
+
+	fake.alerts <- &pb.Alert{Timestamp: since.Add(-time.Hour).Unix(), PolicyName: "before-since"}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		fake.alerts <- &pb.Alert{Timestamp: since.Unix(), PolicyName: name}
+	}
+
+	received := []string{}
+	disconnections := 0
+
+	errDone := errors.New("done")
+
+	err = client.WatchAlerts(ctx, WatchOptions{
+		Filter:       "policy",
+		Since:        since,
+		Backoff:      10 * time.Millisecond,
+		OnDisconnect: func(error) { disconnections++ },
+	}, func(alert *pb.Alert) error {
+		received = append(received, alert.PolicyName)
+		if len(received) == 5 {
+			return errDone
+		}
+		return nil
+	})
+
+	if err != errDone {
+		t.Errorf("[FAIL] Expected the error of the handler (%v)", err)
+	}
+
+	if len(received) != 5 || received[0] != "a" || received[4] != "e" {
+		t.Errorf("[FAIL] Unexpected alerts (%v)", received)
+	}
+
+	if disconnections != 2 || fake.Streams() != 3 {
+		t.Errorf("[FAIL] Expected 2 reconnections (%d, %d streams)", disconnections, fake.Streams())
+	}
+
+	// invalid filters are rejected instead of retried
+	if err := client.WatchAlerts(ctx, WatchOptions{Filter: "system"}, func(*pb.Alert) error { return nil }); err == nil {
+		t.Errorf("[FAIL] Expected the filter to be rejected")
+	}
+
+	t.Log("[PASS] Reconnected the alert stream")
+}
+
+func TestApplyPolicy(t *testing.T) {
+	fake, err := newFakeKubeArmor(t.TempDir())
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to start a fake KubeArmor (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := Connect(ctx, UnixPrefix+fake.Address, Options{Block: true})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to connect (%s)", err.Error())
+	}
+	defer client.Close()
+
+	policy := &securityv1.KubeArmorPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "container_namespace", Name: "block-sh"}}
+	policy.Spec.Selector.MatchLabels = map[string]string{"kubearmor.io/container.name": "nginx"}
+	policy.Spec.Process.MatchPaths = []securityv1.ProcessPathType{{Path: "/bin/sh"}}
+	policy.Spec.Action = "Block"
+
+	status, err := client.ApplyPolicy(ctx, policy)
+	if err != nil || status != pb.PolicyStatus_Applied {
+		t.Errorf("[FAIL] Failed to apply the policy (%v, %v)", status, err)
+	}
+
+	// the payload is the policy event decoded by KubeArmor
+	event := fake.policies[0]
+	object, _ := event["object"].(map[string]interface{})
+	metadata, _ := object["metadata"].(map[string]interface{})
+	spec, _ := object["spec"].(map[string]interface{})
+	process, _ := spec["process"].(map[string]interface{})
+
+	if event["type"] != "ADDED" || metadata["name"] != "block-sh" || spec["action"] != "Block" || len(process["matchPaths"].([]interface{})) != 1 {
+		t.Errorf("[FAIL] Unexpected policy event (%+v)", event)
+	}
+
+	// statuses other than applied, modified and deleted are errors
+	status, err = client.DeletePolicy(ctx, policy)
+
+	policyErr := &PolicyError{}
+	if status != pb.PolicyStatus_NotExist || !errors.As(err, &policyErr) || policyErr.Name != "block-sh" {
+		t.Errorf("[FAIL] Expected a policy error (%v, %v)", status, err)
+	}
+
+	t.Log("[PASS] Applied a policy")
+}
+
+func TestProbe(t *testing.T) {
+	fake, err := newFakeKubeArmor(t.TempDir())
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to start a fake KubeArmor (%s)", err.Error())
+	}
+	defer fake.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := Connect(ctx, fake.Address, Options{})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to connect (%s)", err.Error())
+	}
+	defer client.Close()
+
+	sinks, err := client.HealthCheck(ctx)
+	if err != nil || len(sinks) != 1 || sinks[0].Health != "up" {
+		t.Errorf("[FAIL] Unexpected health (%v, %v)", sinks, err)
+	}
+
+	probe, err := client.Probe(ctx)
+	if err != nil || probe.ContainerMap["nginx"].GetPolicyList()[0] != "block-sh" {
+		t.Errorf("[FAIL] Unexpected probe data (%v, %v)", probe, err)
+	}
+
+	health, err := client.Health(ctx)
+	if err != nil || len(health.Runtimes) != 1 || !health.Runtimes[0].Connected || !health.EnforcerInitialized {
+		t.Errorf("[FAIL] Unexpected health of the runtimes (%v, %v)", health, err)
+	}
+
+	// calls are bound to the context
+	expired, cancelExpired := context.WithCancel(ctx)
+	cancelExpired()
+
+	if _, err := client.Probe(expired); err == nil {
+		t.Errorf("[FAIL] Expected the call to be canceled")
+	}
+
+	t.Log("[PASS] Probed KubeArmor")
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	securityv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func ExampleClient_WatchAlerts() {
+	dir, _ := os.MkdirTemp("", "kubearmor")
+	defer os.RemoveAll(dir)
+
+	fake, _ := newFakeKubeArmor(dir)
+	defer fake.Stop()
+
+	fake.alerts <- &pb.Alert{PolicyName: "block-sh", ProcessName: "/bin/sh", Action: "Block"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// e.g., "localhost:32767" or "unix:///var/run/kubearmor.sock"
+	client, err := Connect(ctx, fake.Address, Options{})
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	// the stream is reopened if KubeArmor restarts
+	_ = client.WatchAlerts(ctx, WatchOptions{Filter: "policy"}, func(alert *pb.Alert) error {
+		fmt.Println(alert.PolicyName, alert.ProcessName, alert.Action)
+
+		// stop watching
+		cancel()
+		return nil
+	})
+
+	// Output: block-sh /bin/sh Block
+}
+
+func ExampleClient_ApplyPolicy() {
+	dir, _ := os.MkdirTemp("", "kubearmor")
+	defer os.RemoveAll(dir)
+
+	fake, _ := newFakeKubeArmor(dir)
+	defer fake.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := Connect(ctx, fake.Address, Options{})
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	policy := &securityv1.KubeArmorPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "container_namespace", Name: "block-sh"}}
+	policy.Spec.Selector.MatchLabels = map[string]string{"kubearmor.io/container.name": "nginx"}
+	policy.Spec.Process.MatchPaths = []securityv1.ProcessPathType{{Path: "/bin/sh"}}
+	policy.Spec.Action = "Block"
+
+	status, err := client.ApplyPolicy(ctx, policy)
+	if err != nil {
+		return
+	}
+
+	fmt.Println(status)
+
+	// Output: Applied
+}
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// fakeKubeArmor serves the gRPC APIs of KubeArmor on a unix domain socket
+type fakeKubeArmor struct {
+	pb.LogServiceServer
+	pb.PolicyServiceServer
+	pb.ProbeServiceServer
+	pb.AdminServiceServer
+
+	Address string
+
+	server *grpc.Server
+
+	// alerts to stream, and the number of alerts after which each stream breaks
+	alerts    chan *pb.Alert
+	breakEach int
+
+	streams int
+
+	// policy events received (decoded JSON payloads)
+	policies []map[string]interface{}
+
+	lock *sync.Mutex
+}
+
+// newFakeKubeArmor starts a fake KubeArmor in dir
+func newFakeKubeArmor(dir string) (*fakeKubeArmor, error) {
+	fake := &fakeKubeArmor{
+		Address: filepath.Join(dir, "kubearmor.sock"),
+		alerts:  make(chan *pb.Alert, 16),
+		lock:    new(sync.Mutex),
+	}
+
+	_ = os.Remove(fake.Address)
+
+	listener, err := net.Listen("unix", fake.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	fake.server = grpc.NewServer()
+	pb.RegisterLogServiceServer(fake.server, fake)
+	pb.RegisterPolicyServiceServer(fake.server, fake)
+	pb.RegisterProbeServiceServer(fake.server, fake)
+	pb.RegisterAdminServiceServer(fake.server, fake)
+
+	go func() { _ = fake.server.Serve(listener) }()
+
+	return fake, nil
+}
+
+// Stop Function
+func (fake *fakeKubeArmor) Stop() {
+	fake.server.Stop()
+}
+
+// Streams returns the number of WatchAlerts streams opened
+func (fake *fakeKubeArmor) Streams() int {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	return fake.streams
+}
+
+// HealthCheck Function
+func (fake *fakeKubeArmor) HealthCheck(ctx context.Context, nonce *pb.NonceMessage) (*pb.ReplyMessage, error) {
+	return &pb.ReplyMessage{Retval: nonce.Nonce, Sinks: []*pb.SinkStatus{{Name: "webhook", Health: "up"}}}, nil
+}
+
+// WatchAlerts Function
+func (fake *fakeKubeArmor) WatchAlerts(req *pb.RequestMessage, svr pb.LogService_WatchAlertsServer) error {
+	fake.lock.Lock()
+	fake.streams++
+	fake.lock.Unlock()
+
+	sent := 0
+
+	for {
+		select {
+		case <-svr.Context().Done():
+			return nil
+		case alert := <-fake.alerts:
+			if err := svr.Send(alert); err != nil {
+				return err
+			}
+
+			// the stream ends, as on a restart of KubeArmor
+			if sent++; fake.breakEach > 0 && sent == fake.breakEach {
+				return nil
+			}
+		}
+	}
+}
+
+// ContainerPolicy Function
+func (fake *fakeKubeArmor) ContainerPolicy(ctx context.Context, policy *pb.Policy) (*pb.Response, error) {
+	event := map[string]interface{}{}
+	if err := json.Unmarshal(policy.Policy, &event); err != nil {
+		return &pb.Response{Status: pb.PolicyStatus_Invalid}, nil
+	}
+
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	fake.policies = append(fake.policies, event)
+
+	if event["type"] == "DELETED" {
+		return &pb.Response{Status: pb.PolicyStatus_NotExist}, nil
+	}
+	return &pb.Response{Status: pb.PolicyStatus_Applied}, nil
+}
+
+// GetHealth Function
+func (fake *fakeKubeArmor) GetHealth(ctx context.Context, in *emptypb.Empty) (*pb.HealthResponse, error) {
+	return &pb.HealthResponse{
+		Runtimes:            []*pb.RuntimeHandlerHealth{{Runtime: "cri-o", Socket: "unix:///var/run/crio/crio.sock", Connected: true, Containers: 1}},
+		EnforcerInitialized: true,
+		Enforcer:            "AppArmor",
+		MonitorInitialized:  true,
+	}, nil
+}
+
+// GetProbeData Function
+func (fake *fakeKubeArmor) GetProbeData(ctx context.Context, in *emptypb.Empty) (*pb.ProbeResponse, error) {
+	return &pb.ProbeResponse{
+		ContainerList: []string{"nginx"},
+		ContainerMap:  map[string]*pb.ContainerData{"nginx": {PolicyList: []string{"block-sh"}, PolicyEnabled: 1}},
+	}, nil
+}
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	securityv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/grpc"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ============== //
+// == Policies == //
+// ============== //
+
+// policyEvent is the payload of the policy service of KubeArmor
+type policyEvent struct {
+	Type   string      `json:"type"`
+	Object policyEntry `json:"object"`
+}
+
+// policyEntry Structure
+type policyEntry struct {
+	Metadata metav1.ObjectMeta `json:"metadata"`
+	Spec     interface{}       `json:"spec"`
+}
+
+// PolicyError is returned when KubeArmor doesn't apply a policy
+type PolicyError struct {
+	Name   string
+	Status pb.PolicyStatus
+}
+
+// Error Function
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("policy %s not applied (%s)", e.Name, e.Status.String())
+}
+
+// sendPolicy sends a policy event to the policy service
+func sendPolicy(ctx context.Context, send func(ctx context.Context, in *pb.Policy, opts ...grpc.CallOption) (*pb.Response, error), eventType string, meta metav1.ObjectMeta, spec interface{}) (pb.PolicyStatus, error) {
+	if meta.Name == "" {
+		return pb.PolicyStatus_Invalid, &PolicyError{Status: pb.PolicyStatus_Invalid}
+	}
+
+	policy, err := json.Marshal(policyEvent{
+		Type: eventType,
+		Object: policyEntry{
+			Metadata: meta,
+			Spec:     spec,
+		},
+	})
+	if err != nil {
+		return pb.PolicyStatus_Invalid, err
+	}
+
+	res, err := send(ctx, &pb.Policy{Policy: policy})
+	if err != nil {
+		return pb.PolicyStatus_Failure, err
+	}
+
+	switch res.Status {
+	case pb.PolicyStatus_Applied, pb.PolicyStatus_Modified, pb.PolicyStatus_Deleted:
+		return res.Status, nil
+	default:
+		return res.Status, &PolicyError{Name: meta.Name, Status: res.Status}
+	}
+}
+
+// ApplyPolicy adds or updates a container policy in KubeArmor (in unorchestrated mode)
+func (c *Client) ApplyPolicy(ctx context.Context, policy *securityv1.KubeArmorPolicy) (pb.PolicyStatus, error) {
+	return sendPolicy(ctx, c.policies.ContainerPolicy, "ADDED", policy.ObjectMeta, policy.Spec)
+}
+
+// DeletePolicy removes a container policy from KubeArmor (in unorchestrated mode)
+func (c *Client) DeletePolicy(ctx context.Context, policy *securityv1.KubeArmorPolicy) (pb.PolicyStatus, error) {
+	return sendPolicy(ctx, c.policies.ContainerPolicy, "DELETED", policy.ObjectMeta, policy.Spec)
+}
+
+// ApplyHostPolicy adds or updates a host policy in KubeArmor
+func (c *Client) ApplyHostPolicy(ctx context.Context, policy *securityv1.KubeArmorHostPolicy) (pb.PolicyStatus, error) {
+	return sendPolicy(ctx, c.policies.HostPolicy, "ADDED", policy.ObjectMeta, policy.Spec)
+}
+
+// DeleteHostPolicy removes a host policy from KubeArmor
+func (c *Client) DeleteHostPolicy(ctx context.Context, policy *securityv1.KubeArmorHostPolicy) (pb.PolicyStatus, error) {
+	return sendPolicy(ctx, c.policies.HostPolicy, "DELETED", policy.ObjectMeta, policy.Spec)
+}
@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package client
+
+import (
+	"context"
+
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// =========== //
+// == Probe == //
+// =========== //
+
+// Probe returns the enforcement state of KubeArmor: the containers with their policies,
+// and the host policies
+func (c *Client) Probe(ctx context.Context) (*pb.ProbeResponse, error) {
+	return c.probe.GetProbeData(ctx, &emptypb.Empty{})
+}
+
+// Health returns the state of the connections of KubeArmor to the container runtimes, and
+// whether its enforcer and system monitor are initialized
+func (c *Client) Health(ctx context.Context) (*pb.HealthResponse, error) {
+	return c.probe.GetHealth(ctx, &emptypb.Empty{})
+}
+
+// ExplainPosture returns how the default posture of an operation (file, network or
+// capabilities) is resolved for a pod
+func (c *Client) ExplainPosture(ctx context.Context, namespace, pod, operation string) (*pb.PostureExplanation, error) {
+	return c.probe.ExplainPosture(ctx, &pb.PostureRequest{Namespace: namespace, Pod: pod, Operation: operation})
+}
+
+// TriggerResync resyncs the containers and policies of KubeArmor with the container runtimes
+// and the API server
+func (c *Client) TriggerResync(ctx context.Context) (*pb.ResyncResponse, error) {
+	return c.admin.TriggerResync(ctx, &emptypb.Empty{})
+}
@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/kubearmor/KubeArmor/protobuf"
+)
+
+// =========== //
+// == Watch == //
+// =========== //
+
+// watch constants
+const (
+	DefaultWatchBackoff    = time.Second
+	DefaultWatchMaxBackoff = 30 * time.Second
+)
+
+// WatchOptions Structure
+type WatchOptions struct {
+	// "all" (default), "policy" (alerts only) or "system" (logs only)
+	Filter string
+
+	// events older than Since are skipped (e.g., the checkpoint of a restarted consumer)
+	Since time.Time
+
+	// backoff between reconnections, doubled up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// stop at the first disconnection instead of reconnecting
+	DisableReconnect bool
+
+	// called when the stream breaks, before reconnecting
+	OnDisconnect func(err error)
+}
+
+// event is an alert or a log
+type event interface {
+	GetTimestamp() int64
+}
+
+// stream is a WatchAlerts or WatchLogs stream
+type stream[E event] interface {
+	Recv() (E, error)
+}
+
+// watch receives the events of the streams opened by open until the context is done or
+// the handler returns an error, and reopens the stream with backoff when it breaks
+func watch[E event](ctx context.Context, opts WatchOptions, open func(ctx context.Context) (stream[E], error), fn func(E) error) error {
+	backoff := opts.Backoff
+	if backoff <= 0 {
+		backoff = DefaultWatchBackoff
+	}
+
+	maxBackoff := opts.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultWatchMaxBackoff
+	}
+
+	since := int64(0)
+	if !opts.Since.IsZero() {
+		since = opts.Since.Unix()
+	}
+
+	delay := backoff
+
+	for {
+		streamCtx, cancel := context.WithCancel(ctx)
+
+		s, err := open(streamCtx)
+		for err == nil {
+			var e E
+			if e, err = s.Recv(); err != nil {
+				break
+			}
+
+			// the stream is up, start over with the initial backoff
+			delay = backoff
+
+			if e.GetTimestamp() < since {
+				continue
+			}
+
+			if err := fn(e); err != nil {
+				cancel()
+				return err
+			}
+		}
+
+		cancel()
+
+		// the stream is broken (or was closed by KubeArmor)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if opts.OnDisconnect != nil {
+			opts.OnDisconnect(err)
+		}
+
+		if opts.DisableReconnect {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		if delay *= 2; delay > maxBackoff {
+			delay = maxBackoff
+		}
+	}
+}
+
+// WatchAlerts passes the alerts of KubeArmor to fn until the context is done or fn returns an error,
+// and reconnects when the stream breaks
+func (c *Client) WatchAlerts(ctx context.Context, opts WatchOptions, fn func(*pb.Alert) error) error {
+	if opts.Filter == "" {
+		opts.Filter = "all"
+	}
+	if opts.Filter != "all" && opts.Filter != "policy" {
+		return fmt.Errorf("invalid filter for alerts (%s)", opts.Filter)
+	}
+
+	return watch(ctx, opts, func(ctx context.Context) (stream[*pb.Alert], error) {
+		return c.logs.WatchAlerts(ctx, &pb.RequestMessage{Filter: opts.Filter})
+	}, fn)
+}
+
+// WatchLogs passes the logs of KubeArmor to fn until the context is done or fn returns an error,
+// and reconnects when the stream breaks
+func (c *Client) WatchLogs(ctx context.Context, opts WatchOptions, fn func(*pb.Log) error) error {
+	if opts.Filter == "" {
+		opts.Filter = "all"
+	}
+	if opts.Filter != "all" && opts.Filter != "system" {
+		return fmt.Errorf("invalid filter for logs (%s)", opts.Filter)
+	}
+
+	return watch(ctx, opts, func(ctx context.Context) (stream[*pb.Log], error) {
+		return c.logs.WatchLogs(ctx, &pb.RequestMessage{Filter: opts.Filter})
+	}, fn)
+}
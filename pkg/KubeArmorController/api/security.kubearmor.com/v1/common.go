@@ -9,6 +9,26 @@ type SeverityType int
 
 type NodeSelectorType struct {
 	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+	// +kubebuilder:validation:optional
+	MatchExpressions []MatchExpressionType `json:"matchExpressions,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=In;NotIn;Exists;DoesNotExist
+type SelectorOperatorType string
+
+// MatchExpressionType is a requirement on the value of a label
+type MatchExpressionType struct {
+	// +kubebuilder:validation:MinLength=1
+	Key      string               `json:"key"`
+	Operator SelectorOperatorType `json:"operator"`
+	// +kubebuilder:validation:optional
+	Values []string `json:"values,omitempty"`
+}
+
+// NamespaceSelectorType selects namespaces by their labels, all of its labels and expressions should match
+type NamespaceSelectorType struct {
+	MatchLabels      map[string]string     `json:"matchLabels,omitempty"`
+	MatchExpressions []MatchExpressionType `json:"matchExpressions,omitempty"`
 }
 
 // +kubebuilder:validation:Pattern=^\/+.*[^\/]$
@@ -24,15 +44,32 @@ type MatchSourceType struct {
 	Path MatchPathType `json:"path,omitempty"`
 }
 
+// +kubebuilder:validation:Minimum:=1
+// +kubebuilder:validation:Maximum:=6000
+type ThrottleRateType int
+
+// +kubebuilder:validation:Minimum:=1
+// +kubebuilder:validation:Maximum:=1000
+type ThrottleBurstType int
+
 type ProcessPathType struct {
 	Path MatchPathType `json:"path"`
 
 	// +kubebuilder:validation:Optional
 	OwnerOnly bool `json:"ownerOnly,omitempty"`
+	// +kubebuilder:validation:Optional
+	OnlyExecSession bool `json:"onlyExecSession,omitempty"`
+	// +kubebuilder:validation:Optional
+	ExcludeExecSession bool `json:"excludeExecSession,omitempty"`
 
 	// +kubebuilder:validation:optional
 	FromSource []MatchSourceType `json:"fromSource,omitempty"`
 
+	// +kubebuilder:validation:optional
+	Rate ThrottleRateType `json:"rate,omitempty"`
+	// +kubebuilder:validation:optional
+	Burst ThrottleBurstType `json:"burst,omitempty"`
+
 	// +kubebuilder:validation:optional
 	Severity SeverityType `json:"severity,omitempty"`
 	// +kubebuilder:validation:optional
@@ -40,7 +77,7 @@ type ProcessPathType struct {
 	// +kubebuilder:validation:optional
 	Message string `json:"message,omitempty"`
 	// +kubebuilder:validation:optional
-	Action ActionType `json:"action,omitempty"`
+	Action ProcessActionType `json:"action,omitempty"`
 }
 
 type ProcessDirectoryType struct {
@@ -50,6 +87,10 @@ type ProcessDirectoryType struct {
 	Recursive bool `json:"recursive,omitempty"`
 	// +kubebuilder:validation:Optional
 	OwnerOnly bool `json:"ownerOnly,omitempty"`
+	// +kubebuilder:validation:Optional
+	OnlyExecSession bool `json:"onlyExecSession,omitempty"`
+	// +kubebuilder:validation:Optional
+	ExcludeExecSession bool `json:"excludeExecSession,omitempty"`
 
 	// +kubebuilder:validation:optional
 	FromSource []MatchSourceType `json:"fromSource,omitempty"`
@@ -69,6 +110,10 @@ type ProcessPatternType struct {
 
 	// +kubebuilder:validation:Optional
 	OwnerOnly bool `json:"ownerOnly,omitempty"`
+	// +kubebuilder:validation:Optional
+	OnlyExecSession bool `json:"onlyExecSession,omitempty"`
+	// +kubebuilder:validation:Optional
+	ExcludeExecSession bool `json:"excludeExecSession,omitempty"`
 
 	// +kubebuilder:validation:optional
 	Severity SeverityType `json:"severity,omitempty"`
@@ -80,10 +125,86 @@ type ProcessPatternType struct {
 	Action ActionType `json:"action,omitempty"`
 }
 
+// +kubebuilder:validation:Enum=net
+type NamespaceStringType string
+
+// +kubebuilder:validation:Enum=unshare;setns
+type NamespaceOperationType string
+
+type ProcessNamespaceType struct {
+	Namespace NamespaceStringType `json:"namespace"`
+
+	// +kubebuilder:validation:optional
+	Operations []NamespaceOperationType `json:"operations,omitempty"`
+
+	// +kubebuilder:validation:optional
+	FromSource []MatchSourceType `json:"fromSource,omitempty"`
+
+	// +kubebuilder:validation:optional
+	Severity SeverityType `json:"severity,omitempty"`
+	// +kubebuilder:validation:optional
+	Tags []string `json:"tags,omitempty"`
+	// +kubebuilder:validation:optional
+	Message string `json:"message,omitempty"`
+	// +kubebuilder:validation:optional
+	Action ActionType `json:"action,omitempty"`
+}
+
+type ProcessFilelessType struct {
+	// +kubebuilder:validation:optional
+	ExceptFromSource []MatchSourceType `json:"exceptFromSource,omitempty"`
+
+	// +kubebuilder:validation:optional
+	Severity SeverityType `json:"severity,omitempty"`
+	// +kubebuilder:validation:optional
+	Tags []string `json:"tags,omitempty"`
+	// +kubebuilder:validation:optional
+	Message string `json:"message,omitempty"`
+	// +kubebuilder:validation:optional
+	Action FilelessActionType `json:"action,omitempty"`
+}
+
+// +kubebuilder:validation:Pattern=^(SIG|sig)?[A-Za-z0-9]+$
+type SignalType string
+
+// +kubebuilder:validation:Enum=cross-container;host
+type SignalScopeType string
+
+type ProcessSignalType struct {
+	// +kubebuilder:validation:MinItems=1
+	Signals []SignalType `json:"signals"`
+
+	// +kubebuilder:validation:optional
+	Target []MatchSourceType `json:"target,omitempty"`
+	// +kubebuilder:validation:optional
+	Scope SignalScopeType `json:"scope,omitempty"`
+	// +kubebuilder:validation:optional
+	FromSource []MatchSourceType `json:"fromSource,omitempty"`
+
+	// +kubebuilder:validation:optional
+	ExcludeSelf bool `json:"excludeSelf,omitempty"`
+	// +kubebuilder:validation:optional
+	ExcludeChildren bool `json:"excludeChildren,omitempty"`
+
+	// +kubebuilder:validation:optional
+	Severity SeverityType `json:"severity,omitempty"`
+	// +kubebuilder:validation:optional
+	Tags []string `json:"tags,omitempty"`
+	// +kubebuilder:validation:optional
+	Message string `json:"message,omitempty"`
+	// +kubebuilder:validation:optional
+	Action SignalActionType `json:"action,omitempty"`
+}
+
 type ProcessType struct {
 	MatchPaths       []ProcessPathType      `json:"matchPaths,omitempty"`
 	MatchDirectories []ProcessDirectoryType `json:"matchDirectories,omitempty"`
 	MatchPatterns    []ProcessPatternType   `json:"matchPatterns,omitempty"`
+	MatchNamespaces  []ProcessNamespaceType `json:"matchNamespaces,omitempty"`
+	MatchSignals     []ProcessSignalType    `json:"matchSignals,omitempty"`
+
+	// +kubebuilder:validation:optional
+	BlockFileless *ProcessFilelessType `json:"blockFileless,omitempty"`
 
 	// +kubebuilder:validation:optional
 	Severity SeverityType `json:"severity,omitempty"`
@@ -102,6 +223,12 @@ type FilePathType struct {
 	ReadOnly bool `json:"readOnly,omitempty"`
 	// +kubebuilder:validation:Optional
 	OwnerOnly bool `json:"ownerOnly,omitempty"`
+	// +kubebuilder:validation:Optional
+	CaptureOnBlock bool `json:"captureOnBlock,omitempty"`
+	// +kubebuilder:validation:Optional
+	OnlyExecSession bool `json:"onlyExecSession,omitempty"`
+	// +kubebuilder:validation:Optional
+	ExcludeExecSession bool `json:"excludeExecSession,omitempty"`
 
 	// +kubebuilder:validation:optional
 	FromSource []MatchSourceType `json:"fromSource,omitempty"`
@@ -125,6 +252,12 @@ type FileDirectoryType struct {
 	ReadOnly bool `json:"readOnly,omitempty"`
 	// +kubebuilder:validation:Optional
 	OwnerOnly bool `json:"ownerOnly,omitempty"`
+	// +kubebuilder:validation:Optional
+	CaptureOnBlock bool `json:"captureOnBlock,omitempty"`
+	// +kubebuilder:validation:Optional
+	OnlyExecSession bool `json:"onlyExecSession,omitempty"`
+	// +kubebuilder:validation:Optional
+	ExcludeExecSession bool `json:"excludeExecSession,omitempty"`
 
 	// +kubebuilder:validation:optional
 	FromSource []MatchSourceType `json:"fromSource,omitempty"`
@@ -146,6 +279,53 @@ type FilePatternType struct {
 	ReadOnly bool `json:"readOnly,omitempty"`
 	// +kubebuilder:validation:Optional
 	OwnerOnly bool `json:"ownerOnly,omitempty"`
+	// +kubebuilder:validation:Optional
+	CaptureOnBlock bool `json:"captureOnBlock,omitempty"`
+	// +kubebuilder:validation:Optional
+	OnlyExecSession bool `json:"onlyExecSession,omitempty"`
+	// +kubebuilder:validation:Optional
+	ExcludeExecSession bool `json:"excludeExecSession,omitempty"`
+
+	// +kubebuilder:validation:optional
+	Severity SeverityType `json:"severity,omitempty"`
+	// +kubebuilder:validation:optional
+	Tags []string `json:"tags,omitempty"`
+	// +kubebuilder:validation:optional
+	Message string `json:"message,omitempty"`
+	// +kubebuilder:validation:optional
+	Action ActionType `json:"action,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=set;remove
+type XattrOperationType string
+
+type FileXattrType struct {
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:optional
+	Operations []XattrOperationType `json:"operations,omitempty"`
+	// +kubebuilder:validation:optional
+	Path MatchSyscallPathType `json:"path,omitempty"`
+
+	// +kubebuilder:validation:optional
+	FromSource []MatchSourceType `json:"fromSource,omitempty"`
+
+	// +kubebuilder:validation:optional
+	Severity SeverityType `json:"severity,omitempty"`
+	// +kubebuilder:validation:optional
+	Tags []string `json:"tags,omitempty"`
+	// +kubebuilder:validation:optional
+	Message string `json:"message,omitempty"`
+	// +kubebuilder:validation:optional
+	Action ActionType `json:"action,omitempty"`
+}
+
+type FileImmutableType struct {
+	// +kubebuilder:validation:optional
+	Path MatchSyscallPathType `json:"path,omitempty"`
+
+	// +kubebuilder:validation:optional
+	FromSource []MatchSourceType `json:"fromSource,omitempty"`
 
 	// +kubebuilder:validation:optional
 	Severity SeverityType `json:"severity,omitempty"`
@@ -161,6 +341,8 @@ type FileType struct {
 	MatchPaths       []FilePathType      `json:"matchPaths,omitempty"`
 	MatchDirectories []FileDirectoryType `json:"matchDirectories,omitempty"`
 	MatchPatterns    []FilePatternType   `json:"matchPatterns,omitempty"`
+	MatchXattrs      []FileXattrType     `json:"matchXattrs,omitempty"`
+	MatchImmutable   []FileImmutableType `json:"matchImmutable,omitempty"`
 
 	// +kubebuilder:validation:optional
 	Severity SeverityType `json:"severity,omitempty"`
@@ -172,7 +354,7 @@ type FileType struct {
 	Action ActionType `json:"action,omitempty"`
 }
 
-// +kubebuilder:validation:Pattern=(icmp|ICMP|tcp|TCP|udp|UDP|raw|RAW)$
+// +kubebuilder:validation:Pattern=(icmp|ICMP|tcp|TCP|udp|UDP|raw|RAW|packet|PACKET)$
 type MatchNetworkProtocolStringType string
 
 type MatchNetworkProtocolType struct {
@@ -205,8 +387,25 @@ type MatchHostNetworkProtocolType struct {
 	Action ActionType `json:"action,omitempty"`
 }
 
+type MatchRuntimeSocketType struct {
+	// +kubebuilder:validation:optional
+	FromSource []MatchSourceType `json:"fromSource,omitempty"`
+
+	// +kubebuilder:validation:optional
+	Severity SeverityType `json:"severity,omitempty"`
+	// +kubebuilder:validation:optional
+	Tags []string `json:"tags,omitempty"`
+	// +kubebuilder:validation:optional
+	Message string `json:"message,omitempty"`
+	// +kubebuilder:validation:optional
+	Action RuntimeSocketActionType `json:"action,omitempty"`
+}
+
 type NetworkType struct {
-	MatchProtocols []MatchNetworkProtocolType `json:"matchProtocols"`
+	// +kubebuilder:validation:optional
+	MatchProtocols []MatchNetworkProtocolType `json:"matchProtocols,omitempty"`
+	// +kubebuilder:validation:optional
+	MatchRuntimeSockets []MatchRuntimeSocketType `json:"matchRuntimeSockets,omitempty"`
 
 	// +kubebuilder:validation:optional
 	Severity SeverityType `json:"severity,omitempty"`
@@ -293,6 +492,29 @@ type HostCapabilitiesType struct {
 // +kubebuilder:validation:Enum=Allow;Audit;Block
 type ActionType string
 
+// +kubebuilder:validation:Enum=Allow;Audit;Block;Throttle
+type ProcessActionType string
+
+// +kubebuilder:validation:Enum=Audit;Block
+type FilelessActionType string
+
+// +kubebuilder:validation:Enum=Audit;Block
+type SignalActionType string
+
+// +kubebuilder:validation:Enum=Audit;Block
+type RuntimeSocketActionType string
+
+// +kubebuilder:validation:Enum=Pod;Process
+type OwnerIdentityType string
+
+// MaturationPeriodType is a duration (e.g., 24h, 90m) during which the Block rules of a new policy are audited
+// +kubebuilder:validation:Pattern=^([0-9]+(\.[0-9]+)?(ns|us|ms|s|m|h))+$
+type MaturationPeriodType string
+
+// PolicyModeType is the mode of a policy, whose Block rules are only audited in the Audit mode (Enforce by default)
+// +kubebuilder:validation:Enum=Audit;Enforce
+type PolicyModeType string
+
 // +kubebuilder:validation:Enum=read;write;open;close;stat;fstat;lstat;poll;lseek;mmap;mprotect;munmap;brk;rt_sigaction;rt_sigprocmask;rt_sigreturn;ioctl;pread64;pwrite64;readv;writev;access;pipe;select;sched_yield;mremap;msync;mincore;madvise;shmget;shmat;shmctl;dup;dup2;pause;nanosleep;getitimer;alarm;setitimer;getpid;sendfile;socket;connect;accept;sendto;recvfrom;sendmsg;recvmsg;shutdown;bind;listen;getsockname;getpeername;socketpair;setsockopt;getsockopt;clone;fork;vfork;execve;exit;wait4;kill;uname;semget;semop;semctl;shmdt;msgget;msgsnd;msgrcv;msgctl;fcntl;flock;fsync;fdatasync;truncate;ftruncate;getdents;getcwd;chdir;fchdir;rename;mkdir;rmdir;creat;link;unlink;symlink;readlink;chmod;fchmod;chown;fchown;lchown;umask;gettimeofday;getrlimit;getrusage;sysinfo;times;ptrace;getuid;syslog;getgid;setuid;setgid;geteuid;getegid;setpgid;getppid;getpgrp;setsid;setreuid;setregid;getgroups;setgroups;setresuid;getresuid;setresgid;getresgid;getpgid;setfsuid;setfsgid;getsid;capget;capset;rt_sigpending;rt_sigtimedwait;rt_sigqueueinfo;rt_sigsuspend;sigaltstack;utime;mknod;uselib;personality;ustat;statfs;fstatfs;sysfs;getpriority;setpriority;sched_setparam;sched_getparam;sched_setscheduler;sched_getscheduler;sched_get_priority_max;sched_get_priority_min;sched_rr_get_interval;mlock;munlock;mlockall;munlockall;vhangup;modify_ldt;pivot_root;_sysctl;prctl;arch_prctl;adjtimex;setrlimit;chroot;sync;acct;settimeofday;mount;umount2;swapon;swapoff;reboot;sethostname;setdomainname;iopl;ioperm;create_module;init_module;delete_module;get_kernel_syms;query_module;quotactl;nfsservctl;getpmsg;putpmsg;afs_syscall;tuxcall;security;gettid;readahead;setxattr;lsetxattr;fsetxattr;getxattr;lgetxattr;fgetxattr;listxattr;llistxattr;flistxattr;removexattr;lremovexattr;fremovexattr;tkill;time;futex;sched_setaffinity;sched_getaffinity;set_thread_area;io_setup;io_destroy;io_getevents;io_submit;io_cancel;get_thread_area;lookup_dcookie;epoll_create;epoll_ctl_old;epoll_wait_old;remap_file_pages;getdents64;set_tid_address;restart_syscall;semtimedop;fadvise64;timer_create;timer_settime;timer_gettime;timer_getoverrun;timer_delete;clock_settime;clock_gettime;clock_getres;clock_nanosleep;exit_group;epoll_wait;epoll_ctl;tgkill;utimes;vserver;mbind;set_mempolicy;get_mempolicy;mq_open;mq_unlink;mq_timedsend;mq_timedreceive;mq_notify;mq_getsetattr;kexec_load;waitid;add_key;request_key;keyctl;ioprio_set;ioprio_get;inotify_init;inotify_add_watch;inotify_rm_watch;migrate_pages;openat;mkdirat;mknodat;fchownat;futimesat;newfstatat;unlinkat;renameat;linkat;symlinkat;readlinkat;fchmodat;faccessat;pselect6;ppoll;unshare;set_robust_list;get_robust_list;splice;tee;sync_file_range;vmsplice;move_pages;utimensat;epoll_pwait;signalfd;timerfd_create;eventfd;fallocate;timerfd_settime;timerfd_gettime;accept4;signalfd4;eventfd2;epoll_create1;dup3;pipe2;inotify_init1;preadv;pwritev;rt_tgsigqueueinfo;perf_event_open;recvmmsg;fanotify_init;fanotify_mark;prlimit64;name_to_handle_at;open_by_handle_at;clock_adjtime;syncfs;sendmmsg;setns;getcpu;process_vm_readv;process_vm_writev;kcmp;finit_module;sched_setattr;sched_getattr;renameat2;seccomp;getrandom;memfd_create;kexec_file_load;bpf;execveat;userfaultfd;membarrier;mlock2;copy_file_range;preadv2;pwritev2;pkey_mprotect;pkey_alloc;pkey_free;statx;io_pgetevents;rseq
 type Syscall string
 
@@ -325,3 +547,43 @@ type SyscallsType struct {
 	// +kubebuilder:validation:optional
 	Message string `json:"message,omitempty"`
 }
+
+// PolicyCompatibilityAnnotation is the prefix of the annotations (prefix + node name)
+// in which each node reports how its enforcer differs from a policy
+const PolicyCompatibilityAnnotation = "compatibility.kubearmor.com/"
+
+// PolicyCompatibilityReport is the value of a compatibility annotation
+// +kubebuilder:object:generate=false
+type PolicyCompatibilityReport struct {
+	// name of the node, as its annotation may be hashed
+	Node string `json:"node,omitempty"`
+
+	Enforcer    string   `json:"enforcer"`
+	Differences []string `json:"differences,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// ExpectMissingAnnotation lists the matchPaths of Block rules which are expected not to exist
+// in containers (comma-separated, or "*" for all of them), so that no warning is reported for them
+const ExpectMissingAnnotation = "kubearmor.com/expectMissing"
+
+// RuleConsolidationAnnotation opts a policy into the approximate consolidation of its rules ("approximate"),
+// where the exact matchPaths of a directory are merged into a directory rule (which also matches the other entries)
+const RuleConsolidationAnnotation = "kubearmor.com/ruleConsolidation"
+
+type PolicyCondition struct {
+	Type string `json:"type"`
+	Node string `json:"node"`
+
+	// +kubebuilder:validation:optional
+	Enforcer string `json:"enforcer,omitempty"`
+
+	Status string `json:"status"`
+
+	// +kubebuilder:validation:optional
+	Reason string `json:"reason,omitempty"`
+	// +kubebuilder:validation:optional
+	Differences []string `json:"differences,omitempty"`
+	// +kubebuilder:validation:optional
+	Warnings []string `json:"warnings,omitempty"`
+}
@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// maximum length of the name part of annotations, and of the hash shortening the longer node names
+const (
+	maxAnnotationNameLength = 63
+	nodeNameHashLength      = 16
+)
+
+// PolicyCompatibilityKey returns the compatibility annotation of a node. The node names longer than the name part of
+// annotations are truncated and suffixed with their hash, the name of the node being kept in the report.
+func PolicyCompatibilityKey(nodeName string) string {
+	if len(nodeName) <= maxAnnotationNameLength {
+		return PolicyCompatibilityAnnotation + nodeName
+	}
+
+	sum := sha256.Sum256([]byte(nodeName))
+	hash := hex.EncodeToString(sum[:])[:nodeNameHashLength]
+
+	return PolicyCompatibilityAnnotation + nodeName[:maxAnnotationNameLength-nodeNameHashLength-1] + "-" + hash
+}
@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KubeArmorClusterPolicySpec defines the desired state of KubeArmorClusterPolicy
+type KubeArmorClusterPolicySpec struct {
+	NamespaceSelector NamespaceSelectorType `json:"namespaceSelector"`
+
+	KubeArmorPolicySpec `json:",inline"`
+}
+
+// KubeArmorClusterPolicyStatus defines the observed state of KubeArmorClusterPolicy
+type KubeArmorClusterPolicyStatus struct {
+	PolicyStatus string `json:"status,omitempty"`
+
+	// +kubebuilder:validation:optional
+	Conditions []PolicyCondition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// KubeArmorClusterPolicy is the Schema for the kubearmorclusterpolicies API
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:resource:scope=Cluster,shortName=csp
+// +kubebuilder:subresource:status
+type KubeArmorClusterPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KubeArmorClusterPolicySpec   `json:"spec,omitempty"`
+	Status KubeArmorClusterPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// KubeArmorClusterPolicyList contains a list of KubeArmorClusterPolicy
+type KubeArmorClusterPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KubeArmorClusterPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KubeArmorClusterPolicy{}, &KubeArmorClusterPolicyList{})
+}
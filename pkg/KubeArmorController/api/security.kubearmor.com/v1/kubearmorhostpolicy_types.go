@@ -26,12 +26,21 @@ type KubeArmorHostPolicySpec struct {
 	// +kubebuilder:validation:optional
 	Message string `json:"message,omitempty"`
 	// +kubebuilder:validation:optional
+	LogAllowed bool `json:"logAllowed,omitempty"`
+	// +kubebuilder:validation:optional
+	MaturationPeriod MaturationPeriodType `json:"maturationPeriod,omitempty"`
+	// +kubebuilder:validation:optional
+	Mode PolicyModeType `json:"mode,omitempty"`
+	// +kubebuilder:validation:optional
 	Action ActionType `json:"action,omitempty"`
 }
 
 // KubeArmorHostPolicyStatus defines the observed state of KubeArmorHostPolicy
 type KubeArmorHostPolicyStatus struct {
 	PolicyStatus string `json:"status,omitempty"`
+
+	// +kubebuilder:validation:optional
+	Conditions []PolicyCondition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -9,6 +9,8 @@ import (
 
 type SelectorType struct {
 	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+	// +kubebuilder:validation:optional
+	MatchExpressions []MatchExpressionType `json:"matchExpressions,omitempty"`
 }
 
 type MatchVolumeMountType struct {
@@ -61,12 +63,25 @@ type KubeArmorPolicySpec struct {
 	// +kubebuilder:validation:optional
 	Message string `json:"message,omitempty"`
 	// +kubebuilder:validation:optional
+	LogAllowed bool `json:"logAllowed,omitempty"`
+	// +kubebuilder:validation:optional
+	OwnerIdentity OwnerIdentityType `json:"ownerIdentity,omitempty"`
+	// +kubebuilder:validation:optional
+	MaturationPeriod MaturationPeriodType `json:"maturationPeriod,omitempty"`
+	// +kubebuilder:validation:optional
+	Mode PolicyModeType `json:"mode,omitempty"`
+	// +kubebuilder:validation:optional
+	Schedule *ScheduleType `json:"schedule,omitempty"`
+	// +kubebuilder:validation:optional
 	Action ActionType `json:"action,omitempty"`
 }
 
 // KubeArmorPolicyStatus defines the observed state of KubeArmorPolicy
 type KubeArmorPolicyStatus struct {
 	PolicyStatus string `json:"status,omitempty"`
+
+	// +kubebuilder:validation:optional
+	Conditions []PolicyCondition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
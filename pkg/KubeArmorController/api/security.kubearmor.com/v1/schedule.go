@@ -0,0 +1,298 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package v1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScheduleWindowType is a window during which a policy is active, from each time matched by Start until the next
+// time matched by End (cron expressions: minute hour day-of-month month day-of-week)
+type ScheduleWindowType struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// ScheduleType is the schedule of a policy, which is active within any of its windows only
+type ScheduleType struct {
+	// +kubebuilder:validation:MinItems=1
+	Windows []ScheduleWindowType `json:"windows"`
+
+	// IANA time zone of the windows (UTC by default)
+	// +kubebuilder:validation:optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// scheduleHorizon is how far the fire times of the cron expressions are searched (leap days included)
+const scheduleHorizon = 4*366 + 1
+
+// cronExpression is a parsed cron expression, each field being the set of the values matched
+// +kubebuilder:object:generate=false
+type cronExpression struct {
+	minute, hour, dom, month, dow uint64
+
+	// day-of-month and day-of-week are both matched if either is *, and either is matched otherwise
+	domAny, dowAny bool
+}
+
+// names of the months and of the days of the week
+var (
+	cronMonths = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+	cronDays   = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+// parseCronValue parses a value of a field (a number or, for months and days of the week, a name)
+func parseCronValue(value string, min, max int, names map[string]int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		if named, ok := names[strings.ToLower(value)]; ok {
+			n = named
+		} else {
+			return 0, fmt.Errorf("invalid value %q", value)
+		}
+	}
+
+	if n < min || n > max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", n, min, max)
+	}
+
+	return n, nil
+}
+
+// parseCronField parses a field of a cron expression (*, values, ranges and steps, separated by commas)
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	bits := uint64(0)
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			n, err := strconv.Atoi(part[idx+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part[idx+1:])
+			}
+			step = n
+			part = part[:idx]
+		}
+
+		low, high := min, max
+
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+
+			n, err := parseCronValue(bounds[0], min, max, names)
+			if err != nil {
+				return 0, err
+			}
+			low, high = n, n
+
+			if len(bounds) == 2 {
+				if high, err = parseCronValue(bounds[1], min, max, names); err != nil {
+					return 0, err
+				}
+				if high < low {
+					return 0, fmt.Errorf("invalid range %q", part)
+				}
+			} else if step > 1 {
+				// a/n is from a to the end of the range
+				high = max
+			}
+		}
+
+		for n := low; n <= high; n += step {
+			bits |= 1 << uint(n)
+		}
+	}
+
+	return bits, nil
+}
+
+// parseCronExpression parses a cron expression (minute hour day-of-month month day-of-week)
+func parseCronExpression(expr string) (cronExpression, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return cronExpression{}, fmt.Errorf("invalid cron expression %q, expected 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+
+	cron := cronExpression{domAny: fields[2] == "*", dowAny: fields[4] == "*"}
+
+	var err error
+
+	if cron.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return cronExpression{}, fmt.Errorf("invalid minute in %q (%s)", expr, err.Error())
+	}
+	if cron.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return cronExpression{}, fmt.Errorf("invalid hour in %q (%s)", expr, err.Error())
+	}
+	if cron.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return cronExpression{}, fmt.Errorf("invalid day-of-month in %q (%s)", expr, err.Error())
+	}
+	if cron.month, err = parseCronField(fields[3], 1, 12, cronMonths); err != nil {
+		return cronExpression{}, fmt.Errorf("invalid month in %q (%s)", expr, err.Error())
+	}
+	if cron.dow, err = parseCronField(fields[4], 0, 7, cronDays); err != nil {
+		return cronExpression{}, fmt.Errorf("invalid day-of-week in %q (%s)", expr, err.Error())
+	}
+
+	// 7 is Sunday as well
+	if cron.dow&(1<<7) != 0 {
+		cron.dow |= 1
+	}
+
+	return cron, nil
+}
+
+// matchesDay checks if the expression fires on the day of t
+func (cron cronExpression) matchesDay(t time.Time) bool {
+	if cron.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	dom := cron.dom&(1<<uint(t.Day())) != 0
+	dow := cron.dow&(1<<uint(t.Weekday())) != 0
+
+	if cron.domAny || cron.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// previous returns the latest time matched by the expression at or before t (false if none within the horizon)
+func (cron cronExpression) previous(t time.Time) (time.Time, bool) {
+	for d := 0; d <= scheduleHorizon; d++ {
+		day := time.Date(t.Year(), t.Month(), t.Day()-d, 0, 0, 0, 0, t.Location())
+		if !cron.matchesDay(day) {
+			continue
+		}
+
+		hour := 23
+		if d == 0 {
+			hour = t.Hour()
+		}
+
+		for ; hour >= 0; hour-- {
+			if cron.hour&(1<<uint(hour)) == 0 {
+				continue
+			}
+
+			minute := 59
+			if d == 0 && hour == t.Hour() {
+				minute = t.Minute()
+			}
+
+			for ; minute >= 0; minute-- {
+				if cron.minute&(1<<uint(minute)) != 0 {
+					return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, t.Location()), true
+				}
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// next returns the earliest time matched by the expression after t (false if none within the horizon)
+func (cron cronExpression) next(t time.Time) (time.Time, bool) {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for d := 0; d <= scheduleHorizon; d++ {
+		day := time.Date(t.Year(), t.Month(), t.Day()+d, 0, 0, 0, 0, t.Location())
+		if !cron.matchesDay(day) {
+			continue
+		}
+
+		hour := 0
+		if d == 0 {
+			hour = t.Hour()
+		}
+
+		for ; hour <= 23; hour++ {
+			if cron.hour&(1<<uint(hour)) == 0 {
+				continue
+			}
+
+			minute := 0
+			if d == 0 && hour == t.Hour() {
+				minute = t.Minute()
+			}
+
+			for ; minute <= 59; minute++ {
+				if cron.minute&(1<<uint(minute)) != 0 {
+					return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, t.Location()), true
+				}
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// PolicySchedule is a parsed schedule
+// +kubebuilder:object:generate=false
+type PolicySchedule struct {
+	windows  [][2]cronExpression
+	location *time.Location
+}
+
+// ParsePolicySchedule parses the windows and the time zone of a schedule
+func ParsePolicySchedule(schedule ScheduleType) (*PolicySchedule, error) {
+	if len(schedule.Windows) == 0 {
+		return nil, fmt.Errorf("invalid schedule, at least one window is needed")
+	}
+
+	ps := &PolicySchedule{location: time.UTC}
+
+	if schedule.TimeZone != "" {
+		location, err := time.LoadLocation(schedule.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule, unknown timeZone %q", schedule.TimeZone)
+		}
+		ps.location = location
+	}
+
+	for _, window := range schedule.Windows {
+		start, err := parseCronExpression(window.Start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule start, %s", err.Error())
+		}
+
+		end, err := parseCronExpression(window.End)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule end, %s", err.Error())
+		}
+
+		ps.windows = append(ps.windows, [2]cronExpression{start, end})
+	}
+
+	return ps, nil
+}
+
+// Active checks if now is within any of the windows of the schedule (the windows may overlap), and returns when the
+// next window starts or ends (zero if none does), at which the state may change
+func (ps *PolicySchedule) Active(now time.Time) (bool, time.Time) {
+	now = now.In(ps.location)
+
+	active := false
+	boundary := time.Time{}
+
+	for _, window := range ps.windows {
+		// a window is open if it started after it last ended (ending at the same minute, it is closed)
+		if start, ok := window[0].previous(now); ok {
+			if end, ok := window[1].previous(now); !ok || start.After(end) {
+				active = true
+			}
+		}
+
+		for _, cron := range window {
+			if next, ok := cron.next(now); ok && (boundary.IsZero() || next.Before(boundary)) {
+				boundary = next
+			}
+		}
+	}
+
+	return active, boundary
+}
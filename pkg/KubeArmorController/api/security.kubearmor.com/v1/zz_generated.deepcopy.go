@@ -64,6 +64,31 @@ func (in *FileDirectoryType) DeepCopy() *FileDirectoryType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileImmutableType) DeepCopyInto(out *FileImmutableType) {
+	*out = *in
+	if in.FromSource != nil {
+		in, out := &in.FromSource, &out.FromSource
+		*out = make([]MatchSourceType, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileImmutableType.
+func (in *FileImmutableType) DeepCopy() *FileImmutableType {
+	if in == nil {
+		return nil
+	}
+	out := new(FileImmutableType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FilePathType) DeepCopyInto(out *FilePathType) {
 	*out = *in
@@ -133,6 +158,20 @@ func (in *FileType) DeepCopyInto(out *FileType) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.MatchXattrs != nil {
+		in, out := &in.MatchXattrs, &out.MatchXattrs
+		*out = make([]FileXattrType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MatchImmutable != nil {
+		in, out := &in.MatchImmutable, &out.MatchImmutable
+		*out = make([]FileImmutableType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Tags != nil {
 		in, out := &in.Tags, &out.Tags
 		*out = make([]string, len(*in))
@@ -150,6 +189,36 @@ func (in *FileType) DeepCopy() *FileType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileXattrType) DeepCopyInto(out *FileXattrType) {
+	*out = *in
+	if in.Operations != nil {
+		in, out := &in.Operations, &out.Operations
+		*out = make([]XattrOperationType, len(*in))
+		copy(*out, *in)
+	}
+	if in.FromSource != nil {
+		in, out := &in.FromSource, &out.FromSource
+		*out = make([]MatchSourceType, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileXattrType.
+func (in *FileXattrType) DeepCopy() *FileXattrType {
+	if in == nil {
+		return nil
+	}
+	out := new(FileXattrType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostCapabilitiesType) DeepCopyInto(out *HostCapabilitiesType) {
 	*out = *in
@@ -204,13 +273,111 @@ func (in *HostNetworkType) DeepCopy() *HostNetworkType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeArmorClusterPolicy) DeepCopyInto(out *KubeArmorClusterPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeArmorClusterPolicy.
+func (in *KubeArmorClusterPolicy) DeepCopy() *KubeArmorClusterPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeArmorClusterPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KubeArmorClusterPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeArmorClusterPolicyList) DeepCopyInto(out *KubeArmorClusterPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KubeArmorClusterPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeArmorClusterPolicyList.
+func (in *KubeArmorClusterPolicyList) DeepCopy() *KubeArmorClusterPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeArmorClusterPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KubeArmorClusterPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeArmorClusterPolicySpec) DeepCopyInto(out *KubeArmorClusterPolicySpec) {
+	*out = *in
+	in.NamespaceSelector.DeepCopyInto(&out.NamespaceSelector)
+	in.KubeArmorPolicySpec.DeepCopyInto(&out.KubeArmorPolicySpec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeArmorClusterPolicySpec.
+func (in *KubeArmorClusterPolicySpec) DeepCopy() *KubeArmorClusterPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeArmorClusterPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeArmorClusterPolicyStatus) DeepCopyInto(out *KubeArmorClusterPolicyStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]PolicyCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeArmorClusterPolicyStatus.
+func (in *KubeArmorClusterPolicyStatus) DeepCopy() *KubeArmorClusterPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeArmorClusterPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeArmorHostPolicy) DeepCopyInto(out *KubeArmorHostPolicy) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeArmorHostPolicy.
@@ -292,6 +459,13 @@ func (in *KubeArmorHostPolicySpec) DeepCopy() *KubeArmorHostPolicySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeArmorHostPolicyStatus) DeepCopyInto(out *KubeArmorHostPolicyStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]PolicyCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeArmorHostPolicyStatus.
@@ -310,7 +484,7 @@ func (in *KubeArmorPolicy) DeepCopyInto(out *KubeArmorPolicy) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeArmorPolicy.
@@ -377,6 +551,11 @@ func (in *KubeArmorPolicySpec) DeepCopyInto(out *KubeArmorPolicySpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(ScheduleType)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeArmorPolicySpec.
@@ -392,6 +571,13 @@ func (in *KubeArmorPolicySpec) DeepCopy() *KubeArmorPolicySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeArmorPolicyStatus) DeepCopyInto(out *KubeArmorPolicyStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]PolicyCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeArmorPolicyStatus.
@@ -429,6 +615,26 @@ func (in *MatchCapabilitiesType) DeepCopy() *MatchCapabilitiesType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatchExpressionType) DeepCopyInto(out *MatchExpressionType) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchExpressionType.
+func (in *MatchExpressionType) DeepCopy() *MatchExpressionType {
+	if in == nil {
+		return nil
+	}
+	out := new(MatchExpressionType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MatchHostCapabilitiesType) DeepCopyInto(out *MatchHostCapabilitiesType) {
 	*out = *in
@@ -504,6 +710,31 @@ func (in *MatchNetworkProtocolType) DeepCopy() *MatchNetworkProtocolType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatchRuntimeSocketType) DeepCopyInto(out *MatchRuntimeSocketType) {
+	*out = *in
+	if in.FromSource != nil {
+		in, out := &in.FromSource, &out.FromSource
+		*out = make([]MatchSourceType, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchRuntimeSocketType.
+func (in *MatchRuntimeSocketType) DeepCopy() *MatchRuntimeSocketType {
+	if in == nil {
+		return nil
+	}
+	out := new(MatchRuntimeSocketType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MatchSourceType) DeepCopyInto(out *MatchSourceType) {
 	*out = *in
@@ -539,6 +770,35 @@ func (in *MatchVolumeMountType) DeepCopy() *MatchVolumeMountType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceSelectorType) DeepCopyInto(out *NamespaceSelectorType) {
+	*out = *in
+	if in.MatchLabels != nil {
+		in, out := &in.MatchLabels, &out.MatchLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MatchExpressions != nil {
+		in, out := &in.MatchExpressions, &out.MatchExpressions
+		*out = make([]MatchExpressionType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceSelectorType.
+func (in *NamespaceSelectorType) DeepCopy() *NamespaceSelectorType {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceSelectorType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkType) DeepCopyInto(out *NetworkType) {
 	*out = *in
@@ -549,6 +809,13 @@ func (in *NetworkType) DeepCopyInto(out *NetworkType) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.MatchRuntimeSockets != nil {
+		in, out := &in.MatchRuntimeSockets, &out.MatchRuntimeSockets
+		*out = make([]MatchRuntimeSocketType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Tags != nil {
 		in, out := &in.Tags, &out.Tags
 		*out = make([]string, len(*in))
@@ -576,6 +843,13 @@ func (in *NodeSelectorType) DeepCopyInto(out *NodeSelectorType) {
 			(*out)[key] = val
 		}
 	}
+	if in.MatchExpressions != nil {
+		in, out := &in.MatchExpressions, &out.MatchExpressions
+		*out = make([]MatchExpressionType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSelectorType.
@@ -588,6 +862,31 @@ func (in *NodeSelectorType) DeepCopy() *NodeSelectorType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyCondition) DeepCopyInto(out *PolicyCondition) {
+	*out = *in
+	if in.Differences != nil {
+		in, out := &in.Differences, &out.Differences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyCondition.
+func (in *PolicyCondition) DeepCopy() *PolicyCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProcessDirectoryType) DeepCopyInto(out *ProcessDirectoryType) {
 	*out = *in
@@ -613,6 +912,61 @@ func (in *ProcessDirectoryType) DeepCopy() *ProcessDirectoryType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProcessFilelessType) DeepCopyInto(out *ProcessFilelessType) {
+	*out = *in
+	if in.ExceptFromSource != nil {
+		in, out := &in.ExceptFromSource, &out.ExceptFromSource
+		*out = make([]MatchSourceType, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProcessFilelessType.
+func (in *ProcessFilelessType) DeepCopy() *ProcessFilelessType {
+	if in == nil {
+		return nil
+	}
+	out := new(ProcessFilelessType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProcessNamespaceType) DeepCopyInto(out *ProcessNamespaceType) {
+	*out = *in
+	if in.Operations != nil {
+		in, out := &in.Operations, &out.Operations
+		*out = make([]NamespaceOperationType, len(*in))
+		copy(*out, *in)
+	}
+	if in.FromSource != nil {
+		in, out := &in.FromSource, &out.FromSource
+		*out = make([]MatchSourceType, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProcessNamespaceType.
+func (in *ProcessNamespaceType) DeepCopy() *ProcessNamespaceType {
+	if in == nil {
+		return nil
+	}
+	out := new(ProcessNamespaceType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProcessPathType) DeepCopyInto(out *ProcessPathType) {
 	*out = *in
@@ -658,6 +1012,41 @@ func (in *ProcessPatternType) DeepCopy() *ProcessPatternType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProcessSignalType) DeepCopyInto(out *ProcessSignalType) {
+	*out = *in
+	if in.Signals != nil {
+		in, out := &in.Signals, &out.Signals
+		*out = make([]SignalType, len(*in))
+		copy(*out, *in)
+	}
+	if in.Target != nil {
+		in, out := &in.Target, &out.Target
+		*out = make([]MatchSourceType, len(*in))
+		copy(*out, *in)
+	}
+	if in.FromSource != nil {
+		in, out := &in.FromSource, &out.FromSource
+		*out = make([]MatchSourceType, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProcessSignalType.
+func (in *ProcessSignalType) DeepCopy() *ProcessSignalType {
+	if in == nil {
+		return nil
+	}
+	out := new(ProcessSignalType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProcessType) DeepCopyInto(out *ProcessType) {
 	*out = *in
@@ -682,6 +1071,25 @@ func (in *ProcessType) DeepCopyInto(out *ProcessType) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.MatchNamespaces != nil {
+		in, out := &in.MatchNamespaces, &out.MatchNamespaces
+		*out = make([]ProcessNamespaceType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MatchSignals != nil {
+		in, out := &in.MatchSignals, &out.MatchSignals
+		*out = make([]ProcessSignalType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BlockFileless != nil {
+		in, out := &in.BlockFileless, &out.BlockFileless
+		*out = new(ProcessFilelessType)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Tags != nil {
 		in, out := &in.Tags, &out.Tags
 		*out = make([]string, len(*in))
@@ -726,6 +1134,41 @@ func (in *SELinuxType) DeepCopy() *SELinuxType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleType) DeepCopyInto(out *ScheduleType) {
+	*out = *in
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = make([]ScheduleWindowType, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleType.
+func (in *ScheduleType) DeepCopy() *ScheduleType {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleWindowType) DeepCopyInto(out *ScheduleWindowType) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleWindowType.
+func (in *ScheduleWindowType) DeepCopy() *ScheduleWindowType {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleWindowType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SelectorType) DeepCopyInto(out *SelectorType) {
 	*out = *in
@@ -736,6 +1179,13 @@ func (in *SelectorType) DeepCopyInto(out *SelectorType) {
 			(*out)[key] = val
 		}
 	}
+	if in.MatchExpressions != nil {
+		in, out := &in.MatchExpressions, &out.MatchExpressions
+		*out = make([]MatchExpressionType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelectorType.
@@ -13,6 +13,7 @@ import (
 
 type SecurityV1Interface interface {
 	RESTClient() rest.Interface
+	KubeArmorClusterPoliciesGetter
 	KubeArmorHostPoliciesGetter
 	KubeArmorPoliciesGetter
 }
@@ -22,6 +23,10 @@ type SecurityV1Client struct {
 	restClient rest.Interface
 }
 
+func (c *SecurityV1Client) KubeArmorClusterPolicies() KubeArmorClusterPolicyInterface {
+	return newKubeArmorClusterPolicies(c)
+}
+
 func (c *SecurityV1Client) KubeArmorHostPolicies() KubeArmorHostPolicyInterface {
 	return newKubeArmorHostPolicies(c)
 }
@@ -40,6 +40,8 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=security.kubearmor.com, Version=v1
+	case v1.SchemeGroupVersion.WithResource("kubearmorclusterpolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Security().V1().KubeArmorClusterPolicies().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("kubearmorhostpolicies"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Security().V1().KubeArmorHostPolicies().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("kubearmorpolicies"):
@@ -11,6 +11,8 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// KubeArmorClusterPolicies returns a KubeArmorClusterPolicyInformer.
+	KubeArmorClusterPolicies() KubeArmorClusterPolicyInformer
 	// KubeArmorHostPolicies returns a KubeArmorHostPolicyInformer.
 	KubeArmorHostPolicies() KubeArmorHostPolicyInformer
 	// KubeArmorPolicies returns a KubeArmorPolicyInformer.
@@ -28,6 +30,11 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// KubeArmorClusterPolicies returns a KubeArmorClusterPolicyInformer.
+func (v *version) KubeArmorClusterPolicies() KubeArmorClusterPolicyInformer {
+	return &kubeArmorClusterPolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // KubeArmorHostPolicies returns a KubeArmorHostPolicyInformer.
 func (v *version) KubeArmorHostPolicies() KubeArmorHostPolicyInformer {
 	return &kubeArmorHostPolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package controllers
+
+import (
+	"sort"
+
+	securityv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	"github.com/kubearmor/KubeArmor/pkg/KubeArmorController/handlers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// policySelector converts the selector of a policy into a label selector
+func policySelector(selector securityv1.SelectorType) (labels.Selector, error) {
+	labelSelector := &metav1.LabelSelector{MatchLabels: selector.MatchLabels}
+	for _, expr := range selector.MatchExpressions {
+		labelSelector.MatchExpressions = append(labelSelector.MatchExpressions, metav1.LabelSelectorRequirement{
+			Key:      expr.Key,
+			Operator: metav1.LabelSelectorOperator(expr.Operator),
+			Values:   expr.Values,
+		})
+	}
+	return metav1.LabelSelectorAsSelector(labelSelector)
+}
+
+// appArmorConflictCondition reports the pods selected by a policy whose AppArmor profiles are left to another manager
+func appArmorConflictCondition(selector securityv1.SelectorType, pods []corev1.Pod) *securityv1.PolicyCondition {
+	podSelector, err := policySelector(selector)
+	if err != nil {
+		return nil
+	}
+
+	conflicts := []string{}
+	for _, pod := range pods {
+		manager, ok := pod.Annotations[handlers.AppArmorConflictAnnotation]
+		if !ok || !podSelector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		conflicts = append(conflicts, pod.Name+" ("+manager+")")
+	}
+
+	if len(conflicts) == 0 {
+		return nil
+	}
+	sort.Strings(conflicts)
+
+	return &securityv1.PolicyCondition{
+		Type:     "AppArmorConflict",
+		Status:   "True",
+		Reason:   "ForeignManager",
+		Warnings: conflicts,
+	}
+}
@@ -5,13 +5,20 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-logr/logr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	securityv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // KubeArmorHostPolicyReconciler reconciles a KubeArmorHostPolicy object
@@ -23,14 +30,60 @@ type KubeArmorHostPolicyReconciler struct {
 
 // +kubebuilder:rbac:groups=security.kubearmor.com,resources=kubearmorhostpolicies,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=security.kubearmor.com,resources=kubearmorhostpolicies/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
 
 func (r *KubeArmorHostPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("kubearmorhostpolicy", req.NamespacedName)
 
-	return ctrl.Result{}, nil
+	var policy securityv1.KubeArmorHostPolicy
+	if err := r.Get(ctx, req.NamespacedName, &policy); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// the reports of the deleted nodes are left behind by them
+	if err := pruneCompatibilityAnnotations(ctx, r.Client, &policy); err != nil {
+		log.Error(err, "Unable to prune the compatibility reports of the deleted nodes")
+	}
+
+	// aggregate the compatibility reports of the nodes
+	conditions := policyConditions(policy.Annotations)
+
+	// Block rules are audited until the maturation period elapses, check again then
+	matured, remaining := maturationCondition(policy.Spec.MaturationPeriod, policy.CreationTimestamp, time.Now())
+	if matured != nil {
+		conditions = append(conditions, *matured)
+	}
+
+	if !conditionsChanged(policy.Status.Conditions, conditions) {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	policy.Status.Conditions = conditions
+	if err := r.Status().Update(ctx, &policy); err != nil {
+		log.Error(err, "Unable to update the conditions of the policy")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: remaining}, nil
+}
+
+// allPolicies returns all the host policies, whose compatibility reports are pruned once a node is deleted
+func (r *KubeArmorHostPolicyReconciler) allPolicies(obj client.Object) []reconcile.Request {
+	var policies securityv1.KubeArmorHostPolicyList
+	if err := r.List(context.Background(), &policies); err != nil {
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, policy := range policies.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: policy.Name}})
+	}
+	return requests
 }
 
 func (r *KubeArmorHostPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&securityv1.KubeArmorHostPolicy{}).
+		Watches(&source.Kind{Type: &corev1.Node{}}, handler.EnqueueRequestsFromMapFunc(r.allPolicies), builder.WithPredicates(nodeDeleted)).
 		Complete(r)
 }
@@ -5,13 +5,21 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-logr/logr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	securityv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	"github.com/kubearmor/KubeArmor/pkg/KubeArmorController/handlers"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // KubeArmorPolicyReconciler reconciles a KubeArmorPolicy object
@@ -23,14 +31,95 @@ type KubeArmorPolicyReconciler struct {
 
 // +kubebuilder:rbac:groups=security.kubearmor.com,resources=kubearmorpolicies,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=security.kubearmor.com,resources=kubearmorpolicies/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
 
 func (r *KubeArmorPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("kubearmorpolicy", req.NamespacedName)
 
-	return ctrl.Result{}, nil
+	var policy securityv1.KubeArmorPolicy
+	if err := r.Get(ctx, req.NamespacedName, &policy); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// the reports of the deleted nodes are left behind by them
+	if err := pruneCompatibilityAnnotations(ctx, r.Client, &policy); err != nil {
+		log.Error(err, "Unable to prune the compatibility reports of the deleted nodes")
+	}
+
+	// aggregate the compatibility reports of the nodes
+	conditions := policyConditions(policy.Annotations)
+
+	// the pods selected by the policy, whose AppArmor profiles are left to another manager
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(policy.Namespace)); err != nil {
+		log.Error(err, "Unable to list the pods of the policy")
+	} else if conflict := appArmorConflictCondition(policy.Spec.Selector, pods.Items); conflict != nil {
+		conditions = append(conditions, *conflict)
+	}
+
+	// Block rules are audited until the maturation period elapses, check again then
+	matured, remaining := maturationCondition(policy.Spec.MaturationPeriod, policy.CreationTimestamp, time.Now())
+	if matured != nil {
+		conditions = append(conditions, *matured)
+	}
+
+	// the rules are enforced within the windows of the schedule only, check again when the next window starts or ends
+	active, boundary := scheduleCondition(policy.Spec.Schedule, time.Now())
+	if active != nil {
+		conditions = append(conditions, *active)
+	}
+
+	requeue := requeueAfter(remaining, boundary)
+
+	if !conditionsChanged(policy.Status.Conditions, conditions) {
+		return ctrl.Result{RequeueAfter: requeue}, nil
+	}
+
+	policy.Status.Conditions = conditions
+	if err := r.Status().Update(ctx, &policy); err != nil {
+		log.Error(err, "Unable to update the conditions of the policy")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: requeue}, nil
+}
+
+// allPolicies returns all the policies, whose compatibility reports are pruned once a node is deleted
+func (r *KubeArmorPolicyReconciler) allPolicies(obj client.Object) []reconcile.Request {
+	var policies securityv1.KubeArmorPolicyList
+	if err := r.List(context.Background(), &policies); err != nil {
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, policy := range policies.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: policy.Namespace, Name: policy.Name}})
+	}
+	return requests
+}
+
+// conflictPolicies returns the policies in the namespace of a pod left to another manager
+func (r *KubeArmorPolicyReconciler) conflictPolicies(obj client.Object) []reconcile.Request {
+	if _, ok := obj.GetAnnotations()[handlers.AppArmorConflictAnnotation]; !ok {
+		return nil
+	}
+
+	var policies securityv1.KubeArmorPolicyList
+	if err := r.List(context.Background(), &policies, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, policy := range policies.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: policy.Namespace, Name: policy.Name}})
+	}
+	return requests
 }
 
 func (r *KubeArmorPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&securityv1.KubeArmorPolicy{}).
+		Watches(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(r.conflictPolicies)).
+		Watches(&source.Kind{Type: &corev1.Node{}}, handler.EnqueueRequestsFromMapFunc(r.allPolicies), builder.WithPredicates(nodeDeleted)).
 		Complete(r)
 }
@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	securityv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// policyConditions aggregates the compatibility reports of the nodes into the conditions of a policy
+func policyConditions(annotations map[string]string) []securityv1.PolicyCondition {
+	conditions := []securityv1.PolicyCondition{}
+
+	for key, value := range annotations {
+		if !strings.HasPrefix(key, securityv1.PolicyCompatibilityAnnotation) {
+			continue
+		}
+
+		report := securityv1.PolicyCompatibilityReport{}
+		if err := json.Unmarshal([]byte(value), &report); err != nil {
+			continue
+		}
+
+		// the annotations of the nodes with long names are hashed
+		node := report.Node
+		if node == "" {
+			node = strings.TrimPrefix(key, securityv1.PolicyCompatibilityAnnotation)
+		}
+
+		condition := securityv1.PolicyCondition{
+			Type:     "Enforceable",
+			Node:     node,
+			Enforcer: report.Enforcer,
+			Status:   "True",
+			Reason:   "Compatible",
+		}
+
+		if len(report.Differences) > 0 {
+			condition.Status = "False"
+			condition.Reason = "Degraded"
+			condition.Differences = report.Differences
+		}
+
+		// advisories (e.g., matchPaths found in no container), which don't affect the enforcement
+		condition.Warnings = report.Warnings
+
+		conditions = append(conditions, condition)
+	}
+
+	sort.Slice(conditions, func(i, j int) bool {
+		return conditions[i].Node < conditions[j].Node
+	})
+
+	if len(conditions) == 0 {
+		return nil
+	}
+
+	return conditions
+}
+
+// conditionsChanged checks if the conditions of a policy need to be updated
+func conditionsChanged(current, conditions []securityv1.PolicyCondition) bool {
+	if len(current) == 0 && len(conditions) == 0 {
+		return false
+	}
+	return !reflect.DeepEqual(current, conditions)
+}
+
+// staleCompatibilityAnnotations returns the compatibility annotations of the nodes which don't exist anymore
+func staleCompatibilityAnnotations(annotations map[string]string, nodes []corev1.Node) []string {
+	keys := map[string]struct{}{}
+	for _, node := range nodes {
+		keys[securityv1.PolicyCompatibilityKey(node.Name)] = struct{}{}
+	}
+
+	stale := []string{}
+	for key := range annotations {
+		if !strings.HasPrefix(key, securityv1.PolicyCompatibilityAnnotation) {
+			continue
+		}
+		if _, ok := keys[key]; !ok {
+			stale = append(stale, key)
+		}
+	}
+
+	sort.Strings(stale)
+
+	return stale
+}
+
+// pruneCompatibilityAnnotations removes the compatibility annotations of the deleted nodes from a policy
+func pruneCompatibilityAnnotations(ctx context.Context, c client.Client, policy client.Object) error {
+	var nodes corev1.NodeList
+	if err := c.List(ctx, &nodes); err != nil {
+		return err
+	}
+
+	// no node seen yet, nothing is known to be deleted
+	if len(nodes.Items) == 0 {
+		return nil
+	}
+
+	stale := staleCompatibilityAnnotations(policy.GetAnnotations(), nodes.Items)
+	if len(stale) == 0 {
+		return nil
+	}
+
+	patch := client.MergeFrom(policy.DeepCopyObject().(client.Object))
+
+	annotations := map[string]string{}
+	for key, value := range policy.GetAnnotations() {
+		annotations[key] = value
+	}
+	for _, key := range stale {
+		delete(annotations, key)
+	}
+	policy.SetAnnotations(annotations)
+
+	return c.Patch(ctx, policy, patch)
+}
+
+// nodeDeleted passes the deletions of the nodes only, whose reports are pruned from the policies
+var nodeDeleted = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	UpdateFunc:  func(event.UpdateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return true },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
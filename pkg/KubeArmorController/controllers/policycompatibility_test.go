@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	securityv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// compatibilityReport returns the compatibility annotation of a node
+func compatibilityReport(t *testing.T, node string, differences ...string) (string, string) {
+	report, err := json.Marshal(securityv1.PolicyCompatibilityReport{Node: node, Enforcer: "BPFLSM", Differences: differences})
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to marshal the report (%s)", err.Error())
+	}
+	return securityv1.PolicyCompatibilityKey(node), string(report)
+}
+
+func TestPolicyCompatibilityKey(t *testing.T) {
+	if key := securityv1.PolicyCompatibilityKey("node-1"); key != "compatibility.kubearmor.com/node-1" {
+		t.Errorf("[FAIL] Unexpected key of a short node name (%s)", key)
+	}
+
+	// the long names of the nodes (e.g., FQDNs) sharing a prefix
+	prefix := "ip-10-0-1-23.ap-southeast-2.compute.internal.cluster-with-a-long-name."
+	first := securityv1.PolicyCompatibilityKey(prefix + "first")
+	second := securityv1.PolicyCompatibilityKey(prefix + "second")
+
+	for _, key := range []string{first, second} {
+		if name := strings.TrimPrefix(key, securityv1.PolicyCompatibilityAnnotation); len(name) != 63 || !strings.HasPrefix(prefix, name[:46]) {
+			t.Errorf("[FAIL] Unexpected key of a long node name (%s)", key)
+		}
+	}
+	if first == second || first != securityv1.PolicyCompatibilityKey(prefix+"first") {
+		t.Errorf("[FAIL] Expected the keys of the long node names to be distinct and stable (%s, %s)", first, second)
+	}
+
+	t.Log("[PASS] Hashed the long node names into the compatibility annotations")
+}
+
+func TestPruneCompatibilityAnnotations(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("[FAIL] Failed to build the scheme (%s)", err.Error())
+	}
+	if err := securityv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("[FAIL] Failed to build the scheme (%s)", err.Error())
+	}
+
+	longNode := "ip-10-0-1-23.ap-southeast-2.compute.internal.cluster-with-a-long-name.example"
+
+	annotations := map[string]string{"kubearmor.com/expectMissing": "*"}
+	for node, differences := range map[string][]string{"node-1": nil, longNode: {"network rules are audited"}, "node-2": nil} {
+		key, value := compatibilityReport(t, node, differences...)
+		annotations[key] = value
+	}
+
+	policy := &securityv1.KubeArmorPolicy{ObjectMeta: metav1.ObjectMeta{Name: "block-shell", Namespace: "default", Annotations: annotations}}
+	hostPolicy := &securityv1.KubeArmorHostPolicy{ObjectMeta: metav1.ObjectMeta{Name: "block-host-shell", Annotations: annotations}}
+
+	// node-2 is deleted
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: longNode}},
+		policy, hostPolicy,
+	).Build()
+
+	ctx := context.Background()
+
+	r := &KubeArmorPolicyReconciler{Client: c, Log: logr.Discard(), Scheme: scheme}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "block-shell"}}); err != nil {
+		t.Fatalf("[FAIL] Failed to reconcile the policy (%s)", err.Error())
+	}
+
+	hr := &KubeArmorHostPolicyReconciler{Client: c, Log: logr.Discard(), Scheme: scheme}
+	if _, err := hr.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "block-host-shell"}}); err != nil {
+		t.Fatalf("[FAIL] Failed to reconcile the host policy (%s)", err.Error())
+	}
+
+	var reconciled securityv1.KubeArmorPolicy
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "block-shell"}, &reconciled); err != nil {
+		t.Fatalf("[FAIL] Failed to get the policy (%s)", err.Error())
+	}
+
+	var reconciledHost securityv1.KubeArmorHostPolicy
+	if err := c.Get(ctx, types.NamespacedName{Name: "block-host-shell"}, &reconciledHost); err != nil {
+		t.Fatalf("[FAIL] Failed to get the host policy (%s)", err.Error())
+	}
+
+	for kind, obj := range map[string]struct {
+		annotations map[string]string
+		conditions  []securityv1.PolicyCondition
+	}{
+		"policy":      {reconciled.Annotations, reconciled.Status.Conditions},
+		"host policy": {reconciledHost.Annotations, reconciledHost.Status.Conditions},
+	} {
+		// the report of the deleted node is removed, the others are kept
+		if _, ok := obj.annotations[securityv1.PolicyCompatibilityKey("node-2")]; ok || len(obj.annotations) != 3 {
+			t.Errorf("[FAIL] Unexpected annotations of the %s (%v)", kind, obj.annotations)
+		}
+
+		// the conditions are named by the nodes, not by their hashed annotations
+		if len(obj.conditions) != 2 || obj.conditions[0].Node != longNode || obj.conditions[0].Reason != "Degraded" || obj.conditions[1].Node != "node-1" {
+			t.Errorf("[FAIL] Unexpected conditions of the %s (%+v)", kind, obj.conditions)
+		}
+	}
+
+	// nothing is pruned before the nodes are seen
+	if stale := staleCompatibilityAnnotations(annotations, nil); len(stale) != 3 {
+		t.Errorf("[FAIL] Expected every report to be stale without nodes (%v)", stale)
+	}
+	empty := fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy.DeepCopy()).Build()
+	if err := pruneCompatibilityAnnotations(ctx, empty, policy); err != nil || len(policy.Annotations) != 4 {
+		t.Errorf("[FAIL] Expected no pruning without nodes (%v, %v)", err, policy.Annotations)
+	}
+
+	t.Log("[PASS] Pruned the compatibility reports of the deleted nodes")
+}
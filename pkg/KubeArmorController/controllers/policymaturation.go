@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package controllers
+
+import (
+	"time"
+
+	securityv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maturationCondition reports whether the maturation period of a policy elapsed, and the time remaining until it does
+func maturationCondition(period securityv1.MaturationPeriodType, created metav1.Time, now time.Time) (*securityv1.PolicyCondition, time.Duration) {
+	if period == "" {
+		return nil, 0
+	}
+
+	duration, err := time.ParseDuration(string(period))
+	if err != nil {
+		return nil, 0
+	}
+
+	condition := &securityv1.PolicyCondition{
+		Type:   "Matured",
+		Status: "True",
+		Reason: "Matured",
+	}
+
+	remaining := created.Add(duration).Sub(now)
+	if remaining > 0 {
+		condition.Status = "False"
+		condition.Reason = "Maturing"
+		return condition, remaining
+	}
+
+	return condition, 0
+}
@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package controllers
+
+import (
+	"time"
+	// the time zones of the schedules, whatever the image
+	_ "time/tzdata"
+
+	securityv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+)
+
+// scheduleCondition reports whether a policy is within a window of its schedule, and the time remaining until the
+// next window starts or ends
+func scheduleCondition(schedule *securityv1.ScheduleType, now time.Time) (*securityv1.PolicyCondition, time.Duration) {
+	if schedule == nil {
+		return nil, 0
+	}
+
+	ps, err := securityv1.ParsePolicySchedule(*schedule)
+	if err != nil {
+		return &securityv1.PolicyCondition{
+			Type:     "Active",
+			Status:   "False",
+			Reason:   "InvalidSchedule",
+			Warnings: []string{err.Error()},
+		}, 0
+	}
+
+	condition := &securityv1.PolicyCondition{
+		Type:   "Active",
+		Status: "True",
+		Reason: "InSchedule",
+	}
+
+	active, boundary := ps.Active(now)
+	if !active {
+		condition.Status = "False"
+		condition.Reason = "OutOfSchedule"
+	}
+
+	if boundary.IsZero() {
+		return condition, 0
+	}
+
+	return condition, boundary.Sub(now)
+}
+
+// requeueAfter returns the earliest of the times after which the conditions change (0 if none)
+func requeueAfter(remaining ...time.Duration) time.Duration {
+	earliest := time.Duration(0)
+	for _, r := range remaining {
+		if r > 0 && (earliest == 0 || r < earliest) {
+			earliest = r
+		}
+	}
+	return earliest
+}
@@ -18,6 +18,9 @@ var kspCrdBytes []byte
 //go:embed KubeArmorHostPolicy.yaml
 var hspCrdBytes []byte
 
+//go:embed KubeArmorClusterPolicy.yaml
+var cspCrdBytes []byte
+
 // GetCRD returns the generated CRD. The CRD is generated by controller-gen
 // which is embedded at compile time using go:embed.
 func GetKspCRD() apiextensionsv1.CustomResourceDefinition {
@@ -37,3 +40,12 @@ func GetHspCRD() apiextensionsv1.CustomResourceDefinition {
 	}
 	return hsp
 }
+
+func GetCspCRD() apiextensionsv1.CustomResourceDefinition {
+	csp := apiextensionsv1.CustomResourceDefinition{}
+	err := yaml.Unmarshal(cspCrdBytes, &csp)
+	if err != nil {
+		log.Fatal("Error unmarshalling pregenerated CRD")
+	}
+	return csp
+}
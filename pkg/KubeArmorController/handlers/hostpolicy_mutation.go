@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	securityv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// HostPolicyDefaulter Structure
+type HostPolicyDefaulter struct {
+	Client  client.Client
+	decoder *admission.Decoder
+	Logger  logr.Logger
+
+	// lowest numeric severities of the named levels (the default table of KubeArmor if nil)
+	SeverityLabels map[string]int
+}
+
+// +kubebuilder:webhook:path=/mutate-kubearmorhostpolicies,mutating=true,failurePolicy=Ignore,groups=security.kubearmor.com,resources=kubearmorhostpolicies,verbs=create;update,versions=v1,name=severity.kubearmor.com,admissionReviewVersions=v1,sideEffects=None
+
+// Handle Host Policy Defaulting
+func (a *HostPolicyDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	policy := &securityv1.KubeArmorHostPolicy{}
+
+	// == Severity == //
+
+	raw, err := convertSeverityLabels(req.Object.Raw, a.SeverityLabels)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	if err := a.decoder.DecodeRaw(runtime.RawExtension{Raw: raw}, policy); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// == //
+
+	// send the mutation response
+	marshaledPolicy, err := json.Marshal(policy)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledPolicy)
+}
+
+// InjectDecoder gets a decoder injected for us
+func (a *HostPolicyDefaulter) InjectDecoder(d *admission.Decoder) error {
+	a.decoder = d
+	return nil
+}
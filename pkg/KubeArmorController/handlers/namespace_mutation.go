@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// NamespaceAnnotator Structure
+type NamespaceAnnotator struct {
+	Client  client.Client
+	decoder *admission.Decoder
+	Logger  logr.Logger
+
+	// visibility stamped on the namespaces created without the visibility annotation (nothing stamped if empty)
+	DefaultVisibility string
+}
+
+// +kubebuilder:webhook:path=/mutate-namespaces,mutating=true,failurePolicy=Ignore,groups="",resources=namespaces,verbs=create,versions=v1,name=visibility.kubearmor.com,admissionReviewVersions=v1,sideEffects=None
+
+// Handle Namespace Annotation
+func (a *NamespaceAnnotator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if a.DefaultVisibility == "" {
+		return admission.Allowed("no default visibility")
+	}
+
+	ns := &corev1.Namespace{}
+
+	if err := a.decoder.Decode(req, ns); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// == Visibility == //
+
+	// the namespaces annotated explicitly keep their visibility
+	if _, ok := ns.Annotations["kubearmor-visibility"]; ok {
+		return admission.Allowed("visibility annotated")
+	}
+
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
+	ns.Annotations["kubearmor-visibility"] = a.DefaultVisibility
+
+	// == //
+
+	// send the mutation response
+	marshaledNamespace, err := json.Marshal(ns)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledNamespace)
+}
+
+// InjectDecoder gets a decoder injected for us
+func (a *NamespaceAnnotator) InjectDecoder(d *admission.Decoder) error {
+	a.decoder = d
+	return nil
+}
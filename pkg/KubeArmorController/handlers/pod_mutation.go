@@ -22,11 +22,17 @@ type PodAnnotator struct {
 	decoder  *admission.Decoder
 	Logger   logr.Logger
 	Enforcer string
+
+	// annotation prefixes of the other managers of the AppArmor profiles, whose pods are never annotated
+	ForeignManagers []string
 }
 
 const k8sVisibility = "process,file,network,capabilities"
 const appArmorAnnotation = "container.apparmor.security.beta.kubernetes.io/"
 
+// AppArmorConflictAnnotation is annotated on the pods left to another manager, with the annotation prefix of the manager
+const AppArmorConflictAnnotation = "kubearmor-apparmor-conflict"
+
 // +kubebuilder:webhook:path=/mutate-pods,mutating=true,failurePolicy=Ignore,groups="",resources=pods,verbs=create;update,versions=v1,name=annotation.kubearmor.com,admissionReviewVersions=v1,sideEffects=NoneOnDryRun
 
 // Handle Pod Annotation
@@ -59,7 +65,14 @@ func (a *PodAnnotator) Handle(ctx context.Context, req admission.Request) admiss
 	// == LSM == //
 
 	if a.Enforcer == "AppArmor" {
-		appArmorAnnotator(pod)
+		if manager := foreignManager(pod, a.ForeignManagers); manager != "" {
+			// the conflict is reported in the status of the policies selecting the pod
+			pod.Annotations[AppArmorConflictAnnotation] = manager
+			a.Logger.Info("Skipped the AppArmor annotations of a pod managed by another manager", "pod", pod.Namespace+"/"+pod.Name, "manager", manager)
+		} else {
+			delete(pod.Annotations, AppArmorConflictAnnotation)
+			appArmorAnnotator(pod)
+		}
 	}
 
 	// == Exception == //
@@ -109,9 +122,20 @@ func (a *PodAnnotator) InjectDecoder(d *admission.Decoder) error {
 	return nil
 }
 
+// foreignManager returns the annotation prefix of the manager of the AppArmor profiles of a pod, if it's not KubeArmor
+func foreignManager(pod *corev1.Pod, managers []string) string {
+	for _, prefix := range managers {
+		for k := range pod.Annotations {
+			if strings.HasPrefix(k, prefix) {
+				return prefix
+			}
+		}
+	}
+	return ""
+}
+
 // == Add AppArmor annotations == //
 func appArmorAnnotator(pod *corev1.Pod) {
-	podAnnotations := map[string]string{}
 	var podOwnerName string
 
 	// podOwnerName is the pod name for static pods and parent object's name
@@ -134,31 +158,10 @@ func appArmorAnnotator(pod *corev1.Pod) {
 		podOwnerName = pod.ObjectMeta.Name
 	}
 
-	// Get existant kubearmor annotations
-	for k, v := range pod.Annotations {
-		if strings.HasPrefix(k, appArmorAnnotation) {
-			if v == "unconfined" {
-				containerName := strings.Split(k, "/")[1]
-				podAnnotations[containerName] = v
-			} else {
-				containerName := strings.Split(k, "/")[1]
-				podAnnotations[containerName] = strings.Split(v, "/")[1]
-			}
-		}
-	}
-
-	// Get the remaining containers / not addressed explecitly in the annotation
+	// Add kubearmor annotations to the containers not addressed explicitly, the existing annotations are kept as they are
 	for _, container := range pod.Spec.Containers {
-		if _, ok := podAnnotations[container.Name]; !ok {
-			podAnnotations[container.Name] = "kubearmor-" + pod.Namespace + "-" + podOwnerName + "-" + container.Name
-		}
-	}
-
-	// Add kubearmor annotations to the pod
-	for k, v := range podAnnotations {
-		if v == "unconfined" {
-			continue
+		if _, ok := pod.Annotations[appArmorAnnotation+container.Name]; !ok {
+			pod.Annotations[appArmorAnnotation+container.Name] = "localhost/kubearmor-" + pod.Namespace + "-" + podOwnerName + "-" + container.Name
 		}
-		pod.Annotations[appArmorAnnotation+k] = "localhost/" + v
 	}
 }
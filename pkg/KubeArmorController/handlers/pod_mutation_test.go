@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// annotatePod runs the pod webhook, and returns the paths patched by it
+func annotatePod(t *testing.T, a *PodAnnotator, pod *corev1.Pod) map[string]interface{} {
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to marshal the pod (%s)", err.Error())
+	}
+
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Namespace: pod.Namespace,
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+
+	resp := a.Handle(context.Background(), req)
+	if !resp.Allowed {
+		t.Fatalf("[FAIL] Expected the pod to be allowed (%v)", resp.Result)
+	}
+
+	patched := map[string]interface{}{}
+	for _, patch := range resp.Patches {
+		patched[patch.Path] = patch.Value
+	}
+	return patched
+}
+
+func TestPodAnnotatorForeignManagers(t *testing.T) {
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create the decoder (%s)", err.Error())
+	}
+
+	a := &PodAnnotator{Logger: logr.Discard(), Enforcer: "AppArmor", ForeignManagers: []string{"profiles.example.com/"}}
+	if err := a.InjectDecoder(decoder); err != nil {
+		t.Fatalf("[FAIL] Failed to inject the decoder (%s)", err.Error())
+	}
+
+	containers := []corev1.Container{{Name: "nginx"}, {Name: "sidecar"}}
+
+	// a pod annotated by a recognized foreign manager
+	foreign := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Annotations: map[string]string{
+			"profiles.example.com/binding": "web-profile",
+			appArmorAnnotation + "nginx":   "localhost/web-profile",
+			"kubearmor-policy":             "enabled",
+			"kubearmor-visibility":         k8sVisibility,
+		}},
+		Spec: corev1.PodSpec{Containers: containers},
+	}
+
+	patched := annotatePod(t, a, foreign)
+
+	for path := range patched {
+		if strings.Contains(path, "apparmor") && !strings.HasSuffix(path, AppArmorConflictAnnotation) {
+			t.Errorf("[FAIL] Expected the AppArmor annotations of the foreign pod not to be modified (%s)", path)
+		}
+	}
+	if manager := patched["/metadata/annotations/"+AppArmorConflictAnnotation]; manager != "profiles.example.com/" {
+		t.Errorf("[FAIL] Expected the conflict to be annotated (%v)", patched)
+	}
+
+	// the existing AppArmor annotations of the other pods are kept as they are
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default", Annotations: map[string]string{
+			appArmorAnnotation + "nginx": "runtime/default",
+		}},
+		Spec: corev1.PodSpec{Containers: containers},
+	}
+
+	patched = annotatePod(t, a, pod)
+
+	if _, ok := patched["/metadata/annotations/container.apparmor.security.beta.kubernetes.io~1nginx"]; ok {
+		t.Errorf("[FAIL] Expected the existing AppArmor annotation to be kept (%v)", patched)
+	}
+	if profile := patched["/metadata/annotations/container.apparmor.security.beta.kubernetes.io~1sidecar"]; profile != "localhost/kubearmor-default-api-sidecar" {
+		t.Errorf("[FAIL] Expected the profile of KubeArmor for the other container (%v)", profile)
+	}
+	if _, ok := patched["/metadata/annotations/"+AppArmorConflictAnnotation]; ok {
+		t.Errorf("[FAIL] Expected no conflict (%v)", patched)
+	}
+
+	t.Log("[PASS] Left the AppArmor annotations of the other managers alone")
+}
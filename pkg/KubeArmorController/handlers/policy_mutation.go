@@ -0,0 +1,354 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	securityv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// PolicyDefaulter Structure
+type PolicyDefaulter struct {
+	Client  client.Client
+	decoder *admission.Decoder
+	Logger  logr.Logger
+
+	// lowest numeric severities of the named levels (the default table of KubeArmor if nil)
+	SeverityLabels map[string]int
+}
+
+// +kubebuilder:webhook:path=/mutate-kubearmorpolicies,mutating=true,failurePolicy=Ignore,groups=security.kubearmor.com,resources=kubearmorpolicies,verbs=create;update,versions=v1,name=action.kubearmor.com,admissionReviewVersions=v1,sideEffects=None
+
+// Handle Policy Defaulting
+func (a *PolicyDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	policy := &securityv1.KubeArmorPolicy{}
+
+	// == Severity == //
+
+	raw, err := convertSeverityLabels(req.Object.Raw, a.SeverityLabels)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	if err := a.decoder.DecodeRaw(runtime.RawExtension{Raw: raw}, policy); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// == Action == //
+
+	if missing := inheritActions(&policy.Spec); len(missing) > 0 {
+		return admission.Denied(fmt.Sprintf("no action for %s, set spec.action or the action of the rules", strings.Join(missing, ", ")))
+	}
+
+	// == Xattrs == //
+
+	if err := validateXattrNames(policy.Spec.File); err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	// == Selector == //
+
+	if err := validateMatchExpressions(policy.Spec.Selector.MatchExpressions); err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	// == //
+
+	// send the mutation response
+	marshaledPolicy, err := json.Marshal(policy)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledPolicy)
+}
+
+// InjectDecoder gets a decoder injected for us
+func (a *PolicyDefaulter) InjectDecoder(d *admission.Decoder) error {
+	a.decoder = d
+	return nil
+}
+
+// == Severity labels == //
+
+// DefaultSeverityLabels is the default mapping table of the numeric severities of KubeArmor to named levels
+// (-severityLabels of KubeArmor)
+const DefaultSeverityLabels = "low:1-3,medium:4-6,high:7-8,critical:9-10"
+
+// ParseSeverityLabels parses a mapping table of KubeArmor (label:min-max,...), whose ranges are in 1-10 and don't
+// overlap, into the lowest numeric severities of its named levels
+func ParseSeverityLabels(table string) (map[string]int, error) {
+	labels := map[string]int{}
+	covered := map[int]string{}
+
+	for _, entry := range strings.Split(table, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		label, bounds, ok := strings.Cut(entry, ":")
+		if !ok || strings.TrimSpace(label) == "" {
+			return nil, fmt.Errorf("invalid severity label (%s), expected label:min-max", entry)
+		}
+		label = strings.ToLower(strings.TrimSpace(label))
+
+		minBound, maxBound, isRange := strings.Cut(bounds, "-")
+		if !isRange {
+			maxBound = minBound
+		}
+
+		minSeverity, err := strconv.Atoi(strings.TrimSpace(minBound))
+		if err != nil {
+			return nil, fmt.Errorf("invalid severity label (%s), expected label:min-max", entry)
+		}
+		maxSeverity, err := strconv.Atoi(strings.TrimSpace(maxBound))
+		if err != nil {
+			return nil, fmt.Errorf("invalid severity label (%s), expected label:min-max", entry)
+		}
+
+		if minSeverity < 1 || maxSeverity > 10 || minSeverity > maxSeverity {
+			return nil, fmt.Errorf("invalid range of severity label %s (%d-%d), expected a range in 1-10", label, minSeverity, maxSeverity)
+		}
+
+		for severity := minSeverity; severity <= maxSeverity; severity++ {
+			if other, ok := covered[severity]; ok {
+				return nil, fmt.Errorf("severity %d is mapped to both %s and %s", severity, other, label)
+			}
+			covered[severity] = label
+		}
+
+		labels[label] = minSeverity
+	}
+
+	return labels, nil
+}
+
+// defaultSeverityLabels are the named levels of the default mapping table
+var defaultSeverityLabels, _ = ParseSeverityLabels(DefaultSeverityLabels)
+
+// convertSeverityLabels replaces the named severities of a policy with the lowest numeric severities of their levels
+func convertSeverityLabels(raw []byte, labels map[string]int) ([]byte, error) {
+	if labels == nil {
+		labels = defaultSeverityLabels
+	}
+
+	object := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, err
+	}
+
+	converted := false
+
+	var convert func(value interface{}, path string) error
+	convert = func(value interface{}, path string) error {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			for key, child := range value {
+				if label, ok := child.(string); ok && key == "severity" {
+					severity, ok := labels[strings.ToLower(strings.TrimSpace(label))]
+					if !ok {
+						return fmt.Errorf("unknown severity (%s) at %s.severity, expected 1-10 or %s", label, path, severityLabelNames(labels))
+					}
+					value[key] = severity
+					converted = true
+					continue
+				}
+				if err := convert(child, path+"."+key); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for idx, child := range value {
+				if err := convert(child, fmt.Sprintf("%s[%d]", path, idx)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := convert(object["spec"], "spec"); err != nil {
+		return nil, err
+	}
+
+	if !converted {
+		return raw, nil
+	}
+
+	return json.Marshal(object)
+}
+
+// severityLabelNames lists the named levels in the order of their severities
+func severityLabelNames(labels map[string]int) string {
+	names := make([]string, 0, len(labels))
+	for label := range labels {
+		names = append(names, label)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return labels[names[i]] < labels[names[j]]
+	})
+
+	return strings.Join(names, ", ")
+}
+
+// == Match expressions == //
+
+// validateMatchExpressions rejects the expressions whose values don't fit their operators, which the
+// schema of the CRD can't express
+func validateMatchExpressions(expressions []securityv1.MatchExpressionType) error {
+	for idx, expression := range expressions {
+		switch expression.Operator {
+		case "In", "NotIn":
+			if len(expression.Values) == 0 {
+				return fmt.Errorf("no values at spec.selector.matchExpressions[%d] (%s %s), expected at least one value", idx, expression.Key, expression.Operator)
+			}
+		case "Exists", "DoesNotExist":
+			if len(expression.Values) > 0 {
+				return fmt.Errorf("values at spec.selector.matchExpressions[%d] (%s %s), expected no values", idx, expression.Key, expression.Operator)
+			}
+		}
+	}
+	return nil
+}
+
+// == Xattrs == //
+
+// validateXattrNames rejects the Block xattr rules whose names can't be matched by any enforcer of KubeArmor (only
+// the BPF LSM enforcer blocks the changes of xattrs, with an exact name, a namespace or any name)
+func validateXattrNames(file securityv1.FileType) error {
+	for idx, xattr := range file.MatchXattrs {
+		if xattr.Action != "Block" {
+			continue
+		}
+		if len(xattr.Name) <= 253 {
+			if xattr.Name == "*" || !strings.ContainsAny(xattr.Name, `*?[\`) {
+				continue
+			}
+			if ns := strings.TrimSuffix(xattr.Name, ".*"); ns != xattr.Name && ns != "" && !strings.ContainsAny(ns, `*?[\.`) {
+				continue
+			}
+		}
+		return fmt.Errorf("unenforceable name %s for file.matchXattrs[%d], block an exact name, a namespace (e.g., security.*) or *", xattr.Name, idx)
+	}
+	return nil
+}
+
+// == Inherit actions == //
+
+// inheritActions sets the action of the rules which omit it, from their section or else from
+// the policy, and returns the rules left without any action
+func inheritActions(spec *securityv1.KubeArmorPolicySpec) []string {
+	missing := []string{}
+
+	inherit := func(rule string, action *securityv1.ActionType, section securityv1.ActionType) {
+		if *action != "" {
+			return
+		}
+		if section != "" {
+			*action = section
+		} else if spec.Action != "" {
+			*action = spec.Action
+		} else {
+			missing = append(missing, rule)
+		}
+	}
+
+	for idx := range spec.Process.MatchPaths {
+		action := securityv1.ActionType(spec.Process.MatchPaths[idx].Action)
+		inherit(fmt.Sprintf("process.matchPaths[%d]", idx), &action, spec.Process.Action)
+		spec.Process.MatchPaths[idx].Action = securityv1.ProcessActionType(action)
+	}
+	for idx := range spec.Process.MatchDirectories {
+		inherit(fmt.Sprintf("process.matchDirectories[%d]", idx), &spec.Process.MatchDirectories[idx].Action, spec.Process.Action)
+	}
+	for idx := range spec.Process.MatchPatterns {
+		inherit(fmt.Sprintf("process.matchPatterns[%d]", idx), &spec.Process.MatchPatterns[idx].Action, spec.Process.Action)
+	}
+	for idx := range spec.Process.MatchNamespaces {
+		inherit(fmt.Sprintf("process.matchNamespaces[%d]", idx), &spec.Process.MatchNamespaces[idx].Action, spec.Process.Action)
+	}
+	if fileless := spec.Process.BlockFileless; fileless != nil {
+		// only Audit and Block are inherited by fileless rules
+		action := securityv1.ActionType(fileless.Action)
+		section := spec.Process.Action
+		if section == "Allow" {
+			section = ""
+		}
+		if action == "" && section == "" && spec.Action == "Allow" {
+			missing = append(missing, "process.blockFileless")
+		} else {
+			inherit("process.blockFileless", &action, section)
+			fileless.Action = securityv1.FilelessActionType(action)
+		}
+	}
+
+	for idx := range spec.Process.MatchSignals {
+		// only Audit and Block are inherited by signal rules
+		rule := fmt.Sprintf("process.matchSignals[%d]", idx)
+		action := securityv1.ActionType(spec.Process.MatchSignals[idx].Action)
+		section := spec.Process.Action
+		if section == "Allow" {
+			section = ""
+		}
+		if action == "" && section == "" && spec.Action == "Allow" {
+			missing = append(missing, rule)
+		} else {
+			inherit(rule, &action, section)
+			spec.Process.MatchSignals[idx].Action = securityv1.SignalActionType(action)
+		}
+	}
+
+	for idx := range spec.File.MatchPaths {
+		inherit(fmt.Sprintf("file.matchPaths[%d]", idx), &spec.File.MatchPaths[idx].Action, spec.File.Action)
+	}
+	for idx := range spec.File.MatchDirectories {
+		inherit(fmt.Sprintf("file.matchDirectories[%d]", idx), &spec.File.MatchDirectories[idx].Action, spec.File.Action)
+	}
+	for idx := range spec.File.MatchPatterns {
+		inherit(fmt.Sprintf("file.matchPatterns[%d]", idx), &spec.File.MatchPatterns[idx].Action, spec.File.Action)
+	}
+	for idx := range spec.File.MatchXattrs {
+		inherit(fmt.Sprintf("file.matchXattrs[%d]", idx), &spec.File.MatchXattrs[idx].Action, spec.File.Action)
+	}
+	for idx := range spec.File.MatchImmutable {
+		inherit(fmt.Sprintf("file.matchImmutable[%d]", idx), &spec.File.MatchImmutable[idx].Action, spec.File.Action)
+	}
+
+	for idx := range spec.Network.MatchProtocols {
+		inherit(fmt.Sprintf("network.matchProtocols[%d]", idx), &spec.Network.MatchProtocols[idx].Action, spec.Network.Action)
+	}
+	for idx := range spec.Network.MatchRuntimeSockets {
+		// only Audit and Block are inherited by runtime socket rules
+		rule := fmt.Sprintf("network.matchRuntimeSockets[%d]", idx)
+		action := securityv1.ActionType(spec.Network.MatchRuntimeSockets[idx].Action)
+		section := spec.Network.Action
+		if section == "Allow" {
+			section = ""
+		}
+		if action == "" && section == "" && spec.Action == "Allow" {
+			missing = append(missing, rule)
+		} else {
+			inherit(rule, &action, section)
+			spec.Network.MatchRuntimeSockets[idx].Action = securityv1.RuntimeSocketActionType(action)
+		}
+	}
+
+	for idx := range spec.Capabilities.MatchCapabilities {
+		inherit(fmt.Sprintf("capabilities.matchCapabilities[%d]", idx), &spec.Capabilities.MatchCapabilities[idx].Action, spec.Capabilities.Action)
+	}
+
+	return missing
+}
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 Authors of KubeArmor
+
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	securityv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestSeverityLabels(t *testing.T) {
+	// the default table of KubeArmor
+	raw, err := convertSeverityLabels([]byte(`{"spec":{"severity":"High","process":{"matchPaths":[{"path":"/bin/sh","severity":"critical"}]}}}`), nil)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to convert the named severities (%s)", err.Error())
+	}
+	if string(raw) != `{"spec":{"process":{"matchPaths":[{"path":"/bin/sh","severity":9}]},"severity":7}}` {
+		t.Errorf("[FAIL] Unexpected conversion with the default table (%s)", string(raw))
+	}
+
+	// a custom table
+	labels, err := ParseSeverityLabels("info:1-5, page:6-10")
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to parse the table (%s)", err.Error())
+	}
+
+	raw, err = convertSeverityLabels([]byte(`{"spec":{"severity":"page"}}`), labels)
+	if err != nil || string(raw) != `{"spec":{"severity":6}}` {
+		t.Errorf("[FAIL] Unexpected conversion with a custom table (%s, %v)", string(raw), err)
+	}
+
+	if _, err := convertSeverityLabels([]byte(`{"spec":{"severity":"high"}}`), labels); err == nil || !strings.Contains(err.Error(), "expected 1-10 or info, page") {
+		t.Errorf("[FAIL] Expected the labels of the default table to be unknown with a custom table (%v)", err)
+	}
+
+	// numeric severities are left as they are
+	if raw, err := convertSeverityLabels([]byte(`{"spec":{"severity":5}}`), labels); err != nil || string(raw) != `{"spec":{"severity":5}}` {
+		t.Errorf("[FAIL] Unexpected conversion of a numeric severity (%s, %v)", string(raw), err)
+	}
+
+	for _, table := range []string{"low", "low:0-3", "low:1-3,high:3-10", "low:a-b"} {
+		if _, err := ParseSeverityLabels(table); err == nil {
+			t.Errorf("[FAIL] Expected an error for the table %q", table)
+		}
+	}
+
+	t.Log("[PASS] Converted the named severities with the mapping table")
+}
+
+func TestHostPolicyDefaulter(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := securityv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("[FAIL] Failed to create the scheme (%s)", err.Error())
+	}
+
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatalf("[FAIL] Failed to create the decoder (%s)", err.Error())
+	}
+
+	labels, _ := ParseSeverityLabels("info:1-5,page:6-10")
+
+	a := &HostPolicyDefaulter{Logger: logr.Discard(), SeverityLabels: labels}
+	if err := a.InjectDecoder(decoder); err != nil {
+		t.Fatalf("[FAIL] Failed to inject the decoder (%s)", err.Error())
+	}
+
+	handle := func(raw string) admission.Response {
+		return a.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: []byte(raw)},
+		}})
+	}
+
+	resp := handle(`{"apiVersion":"security.kubearmor.com/v1","kind":"KubeArmorHostPolicy","metadata":{"name":"block-shell"},"spec":{"severity":"page","nodeSelector":{"matchLabels":{"kubernetes.io/hostname":"worker-1"}},"process":{"matchPaths":[{"path":"/bin/sh"}]},"action":"Block"}}`)
+	if !resp.Allowed {
+		t.Fatalf("[FAIL] Expected the host policy to be allowed (%v)", resp.Result)
+	}
+
+	severity := false
+	for _, patch := range resp.Patches {
+		if patch.Path == "/spec/severity" && patch.Value == float64(6) {
+			severity = true
+		}
+	}
+	if !severity {
+		t.Errorf("[FAIL] Expected the named severity of the host policy to be converted (%v)", resp.Patches)
+	}
+
+	if resp := handle(`{"apiVersion":"security.kubearmor.com/v1","kind":"KubeArmorHostPolicy","metadata":{"name":"block-shell"},"spec":{"severity":"urgent"}}`); resp.Allowed {
+		t.Errorf("[FAIL] Expected the unknown severity to be denied")
+	}
+
+	t.Log("[PASS] Converted the named severities of the host policies")
+}
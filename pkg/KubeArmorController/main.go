@@ -45,11 +45,23 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var defaultNamespaceVisibility string
+	var foreignAppArmorManagers string
+	var severityLabelTable string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&defaultNamespaceVisibility, "default-namespace-visibility", "",
+		"The visibility annotated on the namespaces created without the kubearmor-visibility annotation "+
+			"[process,file,network,capabilities,none] (nothing is annotated if empty).")
+	flag.StringVar(&foreignAppArmorManagers, "foreign-apparmor-managers", "",
+		"The comma-separated annotation prefixes of the other managers of the AppArmor profiles. "+
+			"The pods with these annotations are not annotated, and the conflict is reported in the status of the policies.")
+	flag.StringVar(&severityLabelTable, "severity-labels", handlers.DefaultSeverityLabels,
+		"The mapping table of the named severities accepted in the policies (format: label:min-max,...), "+
+			"to be set as the -severityLabels of KubeArmor. A named severity is converted to the lowest severity of its range.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -58,6 +70,12 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	severityLabels, err := handlers.ParseSeverityLabels(severityLabelTable)
+	if err != nil {
+		setupLog.Error(err, "invalid severity labels")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
@@ -97,6 +115,37 @@ func main() {
 			Client:   mgr.GetClient(),
 			Logger:   setupLog,
 			Enforcer: detectEnforcer(setupLog),
+
+			ForeignManagers: splitList(foreignAppArmorManagers),
+		},
+	})
+
+	setupLog.Info("Adding policy mutation webhook")
+	mgr.GetWebhookServer().Register("/mutate-kubearmorpolicies", &webhook.Admission{
+		Handler: &handlers.PolicyDefaulter{
+			Client: mgr.GetClient(),
+			Logger: setupLog,
+
+			SeverityLabels: severityLabels,
+		},
+	})
+
+	setupLog.Info("Adding host policy mutation webhook")
+	mgr.GetWebhookServer().Register("/mutate-kubearmorhostpolicies", &webhook.Admission{
+		Handler: &handlers.HostPolicyDefaulter{
+			Client: mgr.GetClient(),
+			Logger: setupLog,
+
+			SeverityLabels: severityLabels,
+		},
+	})
+
+	setupLog.Info("Adding namespace mutation webhook")
+	mgr.GetWebhookServer().Register("/mutate-namespaces", &webhook.Admission{
+		Handler: &handlers.NamespaceAnnotator{
+			Client:            mgr.GetClient(),
+			Logger:            setupLog,
+			DefaultVisibility: defaultNamespaceVisibility,
 		},
 	})
 
@@ -138,6 +187,17 @@ func main() {
 	}
 }
 
+// splitList splits a comma-separated list, without the empty entries
+func splitList(list string) []string {
+	entries := []string{}
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
 // detect the enforcer on the node
 func detectEnforcer(logger logr.Logger) string {
 	// assumption: all nodes have the same OSes
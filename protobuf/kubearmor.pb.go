@@ -242,38 +242,62 @@ type Alert struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Timestamp         int64     `protobuf:"varint,1,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
-	UpdatedTime       string    `protobuf:"bytes,2,opt,name=UpdatedTime,proto3" json:"UpdatedTime,omitempty"`
-	ClusterName       string    `protobuf:"bytes,3,opt,name=ClusterName,proto3" json:"ClusterName,omitempty"`
-	HostName          string    `protobuf:"bytes,4,opt,name=HostName,proto3" json:"HostName,omitempty"`
-	NamespaceName     string    `protobuf:"bytes,5,opt,name=NamespaceName,proto3" json:"NamespaceName,omitempty"`
-	Owner             *Podowner `protobuf:"bytes,31,opt,name=Owner,proto3" json:"Owner,omitempty"`
-	PodName           string    `protobuf:"bytes,6,opt,name=PodName,proto3" json:"PodName,omitempty"`
-	Labels            string    `protobuf:"bytes,29,opt,name=Labels,proto3" json:"Labels,omitempty"`
-	ContainerID       string    `protobuf:"bytes,7,opt,name=ContainerID,proto3" json:"ContainerID,omitempty"`
-	ContainerName     string    `protobuf:"bytes,8,opt,name=ContainerName,proto3" json:"ContainerName,omitempty"`
-	ContainerImage    string    `protobuf:"bytes,24,opt,name=ContainerImage,proto3" json:"ContainerImage,omitempty"`
-	HostPPID          int32     `protobuf:"varint,27,opt,name=HostPPID,proto3" json:"HostPPID,omitempty"`
-	HostPID           int32     `protobuf:"varint,9,opt,name=HostPID,proto3" json:"HostPID,omitempty"`
-	PPID              int32     `protobuf:"varint,10,opt,name=PPID,proto3" json:"PPID,"`
-	PID               int32     `protobuf:"varint,11,opt,name=PID,proto3" json:"PID,omitempty"`
-	UID               int32     `protobuf:"varint,12,opt,name=UID,proto3" json:"UID,"`
-	ParentProcessName string    `protobuf:"bytes,25,opt,name=ParentProcessName,proto3" json:"ParentProcessName,omitempty"`
-	ProcessName       string    `protobuf:"bytes,26,opt,name=ProcessName,proto3" json:"ProcessName,omitempty"`
-	PolicyName        string    `protobuf:"bytes,13,opt,name=PolicyName,proto3" json:"PolicyName,omitempty"`
-	Severity          string    `protobuf:"bytes,14,opt,name=Severity,proto3" json:"Severity,omitempty"`
-	Tags              string    `protobuf:"bytes,15,opt,name=Tags,proto3" json:"Tags,omitempty"`
-	ATags             []string  `protobuf:"bytes,30,rep,name=ATags,proto3" json:"ATags,omitempty"`
-	Message           string    `protobuf:"bytes,16,opt,name=Message,proto3" json:"Message,omitempty"`
-	Type              string    `protobuf:"bytes,17,opt,name=Type,proto3" json:"Type,omitempty"`
-	Source            string    `protobuf:"bytes,18,opt,name=Source,proto3" json:"Source,omitempty"`
-	Operation         string    `protobuf:"bytes,19,opt,name=Operation,proto3" json:"Operation,omitempty"`
-	Resource          string    `protobuf:"bytes,20,opt,name=Resource,proto3" json:"Resource,omitempty"`
-	Data              string    `protobuf:"bytes,21,opt,name=Data,proto3" json:"Data,omitempty"`
-	Enforcer          string    `protobuf:"bytes,28,opt,name=Enforcer,proto3" json:"Enforcer,omitempty"`
-	Action            string    `protobuf:"bytes,22,opt,name=Action,proto3" json:"Action,omitempty"`
-	Result            string    `protobuf:"bytes,23,opt,name=Result,proto3" json:"Result,omitempty"`
-	Cwd               string    `protobuf:"bytes,32,opt,name=Cwd,proto3" json:"Cwd,omitempty"`
+	Timestamp         int64         `protobuf:"varint,1,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
+	UpdatedTime       string        `protobuf:"bytes,2,opt,name=UpdatedTime,proto3" json:"UpdatedTime,omitempty"`
+	ClusterName       string        `protobuf:"bytes,3,opt,name=ClusterName,proto3" json:"ClusterName,omitempty"`
+	HostName          string        `protobuf:"bytes,4,opt,name=HostName,proto3" json:"HostName,omitempty"`
+	NamespaceName     string        `protobuf:"bytes,5,opt,name=NamespaceName,proto3" json:"NamespaceName,omitempty"`
+	Owner             *Podowner     `protobuf:"bytes,31,opt,name=Owner,proto3" json:"Owner,omitempty"`
+	PodName           string        `protobuf:"bytes,6,opt,name=PodName,proto3" json:"PodName,omitempty"`
+	Labels            string        `protobuf:"bytes,29,opt,name=Labels,proto3" json:"Labels,omitempty"`
+	ContainerID       string        `protobuf:"bytes,7,opt,name=ContainerID,proto3" json:"ContainerID,omitempty"`
+	ContainerName     string        `protobuf:"bytes,8,opt,name=ContainerName,proto3" json:"ContainerName,omitempty"`
+	ContainerImage    string        `protobuf:"bytes,24,opt,name=ContainerImage,proto3" json:"ContainerImage,omitempty"`
+	HostPPID          int32         `protobuf:"varint,27,opt,name=HostPPID,proto3" json:"HostPPID,omitempty"`
+	HostPID           int32         `protobuf:"varint,9,opt,name=HostPID,proto3" json:"HostPID,omitempty"`
+	PPID              int32         `protobuf:"varint,10,opt,name=PPID,proto3" json:"PPID,"`
+	PID               int32         `protobuf:"varint,11,opt,name=PID,proto3" json:"PID,omitempty"`
+	UID               int32         `protobuf:"varint,12,opt,name=UID,proto3" json:"UID,"`
+	ParentProcessName string        `protobuf:"bytes,25,opt,name=ParentProcessName,proto3" json:"ParentProcessName,omitempty"`
+	ProcessName       string        `protobuf:"bytes,26,opt,name=ProcessName,proto3" json:"ProcessName,omitempty"`
+	PolicyName        string        `protobuf:"bytes,13,opt,name=PolicyName,proto3" json:"PolicyName,omitempty"`
+	Severity          string        `protobuf:"bytes,14,opt,name=Severity,proto3" json:"Severity,omitempty"`
+	PolicySeverity    string        `protobuf:"bytes,34,opt,name=PolicySeverity,proto3" json:"PolicySeverity,omitempty"`
+	SeverityLabel     string        `protobuf:"bytes,38,opt,name=SeverityLabel,proto3" json:"SeverityLabel,omitempty"`
+	Tags              string        `protobuf:"bytes,15,opt,name=Tags,proto3" json:"Tags,omitempty"`
+	ATags             []string      `protobuf:"bytes,30,rep,name=ATags,proto3" json:"ATags,omitempty"`
+	Message           string        `protobuf:"bytes,16,opt,name=Message,proto3" json:"Message,omitempty"`
+	Type              string        `protobuf:"bytes,17,opt,name=Type,proto3" json:"Type,omitempty"`
+	Source            string        `protobuf:"bytes,18,opt,name=Source,proto3" json:"Source,omitempty"`
+	Operation         string        `protobuf:"bytes,19,opt,name=Operation,proto3" json:"Operation,omitempty"`
+	Resource          string        `protobuf:"bytes,20,opt,name=Resource,proto3" json:"Resource,omitempty"`
+	Data              string        `protobuf:"bytes,21,opt,name=Data,proto3" json:"Data,omitempty"`
+	Enforcer          string        `protobuf:"bytes,28,opt,name=Enforcer,proto3" json:"Enforcer,omitempty"`
+	Action            string        `protobuf:"bytes,22,opt,name=Action,proto3" json:"Action,omitempty"`
+	Result            string        `protobuf:"bytes,23,opt,name=Result,proto3" json:"Result,omitempty"`
+	Cwd               string        `protobuf:"bytes,32,opt,name=Cwd,proto3" json:"Cwd,omitempty"`
+	SocketCreator     string        `protobuf:"bytes,33,opt,name=SocketCreator,proto3" json:"SocketCreator,omitempty"`
+	ClockResync       bool          `protobuf:"varint,35,opt,name=ClockResync,proto3" json:"ClockResync,omitempty"`
+	PostureSource     string        `protobuf:"bytes,36,opt,name=PostureSource,proto3" json:"PostureSource,omitempty"`
+	Capture           *WriteCapture `protobuf:"bytes,37,opt,name=Capture,proto3" json:"Capture,omitempty"`
+	EnforcementStatus string        `protobuf:"bytes,39,opt,name=EnforcementStatus,proto3" json:"EnforcementStatus,omitempty"`
+	OwnerIdentity     string        `protobuf:"bytes,40,opt,name=OwnerIdentity,proto3" json:"OwnerIdentity,omitempty"`
+	Session           string        `protobuf:"bytes,41,opt,name=Session,proto3" json:"Session,omitempty"`
+	// version of the telemetry schema (major.minor)
+	SchemaVersion string `protobuf:"bytes,42,opt,name=SchemaVersion,proto3" json:"SchemaVersion,omitempty"`
+	// unique ID of the alert, set by the durable sink to dedup the re-delivered alerts
+	EventID string `protobuf:"bytes,43,opt,name=EventID,proto3" json:"EventID,omitempty"`
+	// the container runs privileged
+	Privileged bool `protobuf:"varint,44,opt,name=Privileged,proto3" json:"Privileged,omitempty"`
+	// namespace of the matched policy (empty for the host policies), and the rule which matched
+	PolicyNamespace string `protobuf:"bytes,45,opt,name=PolicyNamespace,proto3" json:"PolicyNamespace,omitempty"`
+	MatchedRule     string `protobuf:"bytes,46,opt,name=MatchedRule,proto3" json:"MatchedRule,omitempty"`
+	// terminated if the container was destroyed before the alert was emitted
+	ContainerState string `protobuf:"bytes,47,opt,name=ContainerState,proto3" json:"ContainerState,omitempty"`
+	// init, ephemeral or regular
+	ContainerType string `protobuf:"bytes,48,opt,name=ContainerType,proto3" json:"ContainerType,omitempty"`
+	// audited Block rule of a policy in the Audit mode or in its maturation period
+	WouldBeBlocked bool `protobuf:"varint,49,opt,name=WouldBeBlocked,proto3" json:"WouldBeBlocked,omitempty"`
 }
 
 func (x *Alert) Reset() {
@@ -448,6 +472,20 @@ func (x *Alert) GetSeverity() string {
 	return ""
 }
 
+func (x *Alert) GetPolicySeverity() string {
+	if x != nil {
+		return x.PolicySeverity
+	}
+	return ""
+}
+
+func (x *Alert) GetSeverityLabel() string {
+	if x != nil {
+		return x.SeverityLabel
+	}
+	return ""
+}
+
 func (x *Alert) GetTags() string {
 	if x != nil {
 		return x.Tags
@@ -532,6 +570,231 @@ func (x *Alert) GetCwd() string {
 	return ""
 }
 
+func (x *Alert) GetSocketCreator() string {
+	if x != nil {
+		return x.SocketCreator
+	}
+	return ""
+}
+
+func (x *Alert) GetClockResync() bool {
+	if x != nil {
+		return x.ClockResync
+	}
+	return false
+}
+
+func (x *Alert) GetPostureSource() string {
+	if x != nil {
+		return x.PostureSource
+	}
+	return ""
+}
+
+func (x *Alert) GetCapture() *WriteCapture {
+	if x != nil {
+		return x.Capture
+	}
+	return nil
+}
+
+func (x *Alert) GetEnforcementStatus() string {
+	if x != nil {
+		return x.EnforcementStatus
+	}
+	return ""
+}
+
+func (x *Alert) GetOwnerIdentity() string {
+	if x != nil {
+		return x.OwnerIdentity
+	}
+	return ""
+}
+
+func (x *Alert) GetSession() string {
+	if x != nil {
+		return x.Session
+	}
+	return ""
+}
+
+func (x *Alert) GetSchemaVersion() string {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return ""
+}
+
+func (x *Alert) GetEventID() string {
+	if x != nil {
+		return x.EventID
+	}
+	return ""
+}
+
+func (x *Alert) GetPrivileged() bool {
+	if x != nil {
+		return x.Privileged
+	}
+	return false
+}
+
+func (x *Alert) GetPolicyNamespace() string {
+	if x != nil {
+		return x.PolicyNamespace
+	}
+	return ""
+}
+
+func (x *Alert) GetMatchedRule() string {
+	if x != nil {
+		return x.MatchedRule
+	}
+	return ""
+}
+
+func (x *Alert) GetContainerState() string {
+	if x != nil {
+		return x.ContainerState
+	}
+	return ""
+}
+
+func (x *Alert) GetContainerType() string {
+	if x != nil {
+		return x.ContainerType
+	}
+	return ""
+}
+
+func (x *Alert) GetWouldBeBlocked() bool {
+	if x != nil {
+		return x.WouldBeBlocked
+	}
+	return false
+}
+
+// sample of a blocked write (captureOnBlock)
+type WriteCapture struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Source    string   `protobuf:"bytes,1,opt,name=Source,proto3" json:"Source,omitempty"`
+	FD        int32    `protobuf:"varint,2,opt,name=FD,proto3" json:"FD,omitempty"`
+	Path      string   `protobuf:"bytes,3,opt,name=Path,proto3" json:"Path,omitempty"`
+	Offset    int64    `protobuf:"varint,4,opt,name=Offset,proto3" json:"Offset,omitempty"`
+	Flags     string   `protobuf:"bytes,5,opt,name=Flags,proto3" json:"Flags,omitempty"`
+	Size      int64    `protobuf:"varint,6,opt,name=Size,proto3" json:"Size,omitempty"`
+	Sample    string   `protobuf:"bytes,7,opt,name=Sample,proto3" json:"Sample,omitempty"`
+	Truncated bool     `protobuf:"varint,8,opt,name=Truncated,proto3" json:"Truncated,omitempty"`
+	Redacted  bool     `protobuf:"varint,9,opt,name=Redacted,proto3" json:"Redacted,omitempty"`
+	Handles   []string `protobuf:"bytes,10,rep,name=Handles,proto3" json:"Handles,omitempty"`
+}
+
+func (x *WriteCapture) Reset() {
+	*x = WriteCapture{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kubearmor_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WriteCapture) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteCapture) ProtoMessage() {}
+
+func (x *WriteCapture) ProtoReflect() protoreflect.Message {
+	mi := &file_kubearmor_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteCapture.ProtoReflect.Descriptor instead.
+func (*WriteCapture) Descriptor() ([]byte, []int) {
+	return file_kubearmor_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WriteCapture) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *WriteCapture) GetFD() int32 {
+	if x != nil {
+		return x.FD
+	}
+	return 0
+}
+
+func (x *WriteCapture) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *WriteCapture) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *WriteCapture) GetFlags() string {
+	if x != nil {
+		return x.Flags
+	}
+	return ""
+}
+
+func (x *WriteCapture) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *WriteCapture) GetSample() string {
+	if x != nil {
+		return x.Sample
+	}
+	return ""
+}
+
+func (x *WriteCapture) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+func (x *WriteCapture) GetRedacted() bool {
+	if x != nil {
+		return x.Redacted
+	}
+	return false
+}
+
+func (x *WriteCapture) GetHandles() []string {
+	if x != nil {
+		return x.Handles
+	}
+	return nil
+}
+
 // log struct
 type Log struct {
 	state         protoimpl.MessageState
@@ -563,12 +826,24 @@ type Log struct {
 	Data              string    `protobuf:"bytes,17,opt,name=Data,proto3" json:"Data,omitempty"`
 	Result            string    `protobuf:"bytes,18,opt,name=Result,proto3" json:"Result,omitempty"`
 	Cwd               string    `protobuf:"bytes,25,opt,name=Cwd,proto3" json:"Cwd,omitempty"`
+	SocketCreator     string    `protobuf:"bytes,26,opt,name=SocketCreator,proto3" json:"SocketCreator,omitempty"`
+	ClockResync       bool      `protobuf:"varint,27,opt,name=ClockResync,proto3" json:"ClockResync,omitempty"`
+	// the source of the event (eBPF Monitor for visibility-only events)
+	Enforcer string `protobuf:"bytes,28,opt,name=Enforcer,proto3" json:"Enforcer,omitempty"`
+	// exec for the processes run by kubectl exec
+	Session string `protobuf:"bytes,29,opt,name=Session,proto3" json:"Session,omitempty"`
+	// version of the telemetry schema (major.minor)
+	SchemaVersion string `protobuf:"bytes,30,opt,name=SchemaVersion,proto3" json:"SchemaVersion,omitempty"`
+	// terminated if the container was destroyed before the log was emitted
+	ContainerState string `protobuf:"bytes,31,opt,name=ContainerState,proto3" json:"ContainerState,omitempty"`
+	// init, ephemeral or regular
+	ContainerType string `protobuf:"bytes,32,opt,name=ContainerType,proto3" json:"ContainerType,omitempty"`
 }
 
 func (x *Log) Reset() {
 	*x = Log{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_kubearmor_proto_msgTypes[4]
+		mi := &file_kubearmor_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -581,7 +856,7 @@ func (x *Log) String() string {
 func (*Log) ProtoMessage() {}
 
 func (x *Log) ProtoReflect() protoreflect.Message {
-	mi := &file_kubearmor_proto_msgTypes[4]
+	mi := &file_kubearmor_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -594,7 +869,7 @@ func (x *Log) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Log.ProtoReflect.Descriptor instead.
 func (*Log) Descriptor() ([]byte, []int) {
-	return file_kubearmor_proto_rawDescGZIP(), []int{4}
+	return file_kubearmor_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *Log) GetTimestamp() int64 {
@@ -772,65 +1047,84 @@ func (x *Log) GetCwd() string {
 	return ""
 }
 
-// request message
-type RequestMessage struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	Filter string `protobuf:"bytes,1,opt,name=Filter,proto3" json:"Filter,omitempty"`
+func (x *Log) GetSocketCreator() string {
+	if x != nil {
+		return x.SocketCreator
+	}
+	return ""
 }
 
-func (x *RequestMessage) Reset() {
-	*x = RequestMessage{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_kubearmor_proto_msgTypes[5]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *Log) GetClockResync() bool {
+	if x != nil {
+		return x.ClockResync
 	}
+	return false
 }
 
-func (x *RequestMessage) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Log) GetEnforcer() string {
+	if x != nil {
+		return x.Enforcer
+	}
+	return ""
 }
 
-func (*RequestMessage) ProtoMessage() {}
+func (x *Log) GetSession() string {
+	if x != nil {
+		return x.Session
+	}
+	return ""
+}
 
-func (x *RequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_kubearmor_proto_msgTypes[5]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+func (x *Log) GetSchemaVersion() string {
+	if x != nil {
+		return x.SchemaVersion
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use RequestMessage.ProtoReflect.Descriptor instead.
-func (*RequestMessage) Descriptor() ([]byte, []int) {
-	return file_kubearmor_proto_rawDescGZIP(), []int{5}
+func (x *Log) GetContainerState() string {
+	if x != nil {
+		return x.ContainerState
+	}
+	return ""
 }
 
-func (x *RequestMessage) GetFilter() string {
+func (x *Log) GetContainerType() string {
 	if x != nil {
-		return x.Filter
+		return x.ContainerType
 	}
 	return ""
 }
 
-// reply message
-type ReplyMessage struct {
+// policy event struct
+type PolicyEvent struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Retval int32 `protobuf:"varint,1,opt,name=Retval,proto3" json:"Retval,omitempty"`
-}
-
-func (x *ReplyMessage) Reset() {
-	*x = ReplyMessage{}
+	Timestamp     int64    `protobuf:"varint,1,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
+	UpdatedTime   string   `protobuf:"bytes,2,opt,name=UpdatedTime,proto3" json:"UpdatedTime,omitempty"`
+	ClusterName   string   `protobuf:"bytes,3,opt,name=ClusterName,proto3" json:"ClusterName,omitempty"`
+	HostName      string   `protobuf:"bytes,4,opt,name=HostName,proto3" json:"HostName,omitempty"`
+	Kind          string   `protobuf:"bytes,5,opt,name=Kind,proto3" json:"Kind,omitempty"`
+	NamespaceName string   `protobuf:"bytes,6,opt,name=NamespaceName,proto3" json:"NamespaceName,omitempty"`
+	PolicyName    string   `protobuf:"bytes,7,opt,name=PolicyName,proto3" json:"PolicyName,omitempty"`
+	Action        string   `protobuf:"bytes,8,opt,name=Action,proto3" json:"Action,omitempty"`
+	Reason        string   `protobuf:"bytes,9,opt,name=Reason,proto3" json:"Reason,omitempty"`
+	Endpoints     []string `protobuf:"bytes,10,rep,name=Endpoints,proto3" json:"Endpoints,omitempty"`
+	// how the enforcement on the node differs from the policy
+	Enforcer          string   `protobuf:"bytes,11,opt,name=Enforcer,proto3" json:"Enforcer,omitempty"`
+	Incompatibilities []string `protobuf:"bytes,12,rep,name=Incompatibilities,proto3" json:"Incompatibilities,omitempty"`
+	// advisories which don't block the policy (e.g., matchPaths found in no container)
+	Warnings []string `protobuf:"bytes,13,rep,name=Warnings,proto3" json:"Warnings,omitempty"`
+	// time spent on the last application of the policy on the node (in microseconds)
+	LastApplyDuration int64 `protobuf:"varint,14,opt,name=LastApplyDuration,proto3" json:"LastApplyDuration,omitempty"`
+	// rules of a host policy whose enforcement on the node started (or stopped)
+	RuleCount int32 `protobuf:"varint,15,opt,name=RuleCount,proto3" json:"RuleCount,omitempty"`
+}
+
+func (x *PolicyEvent) Reset() {
+	*x = PolicyEvent{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_kubearmor_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -838,13 +1132,13 @@ func (x *ReplyMessage) Reset() {
 	}
 }
 
-func (x *ReplyMessage) String() string {
+func (x *PolicyEvent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReplyMessage) ProtoMessage() {}
+func (*PolicyEvent) ProtoMessage() {}
 
-func (x *ReplyMessage) ProtoReflect() protoreflect.Message {
+func (x *PolicyEvent) ProtoReflect() protoreflect.Message {
 	mi := &file_kubearmor_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -856,28 +1150,458 @@ func (x *ReplyMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReplyMessage.ProtoReflect.Descriptor instead.
-func (*ReplyMessage) Descriptor() ([]byte, []int) {
+// Deprecated: Use PolicyEvent.ProtoReflect.Descriptor instead.
+func (*PolicyEvent) Descriptor() ([]byte, []int) {
 	return file_kubearmor_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *ReplyMessage) GetRetval() int32 {
+func (x *PolicyEvent) GetTimestamp() int64 {
 	if x != nil {
-		return x.Retval
+		return x.Timestamp
 	}
 	return 0
 }
 
-var File_kubearmor_proto protoreflect.FileDescriptor
+func (x *PolicyEvent) GetUpdatedTime() string {
+	if x != nil {
+		return x.UpdatedTime
+	}
+	return ""
+}
 
-var file_kubearmor_proto_rawDesc = []byte{
-	0x0a, 0x0f, 0x6b, 0x75, 0x62, 0x65, 0x61, 0x72, 0x6d, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x12, 0x06, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x22, 0x24, 0x0a, 0x0c, 0x4e, 0x6f, 0x6e,
-	0x63, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e,
-	0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x22,
-	0xe3, 0x01, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x54,
-	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
-	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x20, 0x0a, 0x0b, 0x55, 0x70, 0x64,
+func (x *PolicyEvent) GetClusterName() string {
+	if x != nil {
+		return x.ClusterName
+	}
+	return ""
+}
+
+func (x *PolicyEvent) GetHostName() string {
+	if x != nil {
+		return x.HostName
+	}
+	return ""
+}
+
+func (x *PolicyEvent) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *PolicyEvent) GetNamespaceName() string {
+	if x != nil {
+		return x.NamespaceName
+	}
+	return ""
+}
+
+func (x *PolicyEvent) GetPolicyName() string {
+	if x != nil {
+		return x.PolicyName
+	}
+	return ""
+}
+
+func (x *PolicyEvent) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *PolicyEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *PolicyEvent) GetEndpoints() []string {
+	if x != nil {
+		return x.Endpoints
+	}
+	return nil
+}
+
+func (x *PolicyEvent) GetEnforcer() string {
+	if x != nil {
+		return x.Enforcer
+	}
+	return ""
+}
+
+func (x *PolicyEvent) GetIncompatibilities() []string {
+	if x != nil {
+		return x.Incompatibilities
+	}
+	return nil
+}
+
+func (x *PolicyEvent) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+func (x *PolicyEvent) GetLastApplyDuration() int64 {
+	if x != nil {
+		return x.LastApplyDuration
+	}
+	return 0
+}
+
+func (x *PolicyEvent) GetRuleCount() int32 {
+	if x != nil {
+		return x.RuleCount
+	}
+	return 0
+}
+
+// request message
+type RequestMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Filter string `protobuf:"bytes,1,opt,name=Filter,proto3" json:"Filter,omitempty"`
+	// only the alerts (or logs) of the given enforcers (all if empty)
+	Enforcers []string `protobuf:"bytes,2,rep,name=Enforcers,proto3" json:"Enforcers,omitempty"`
+}
+
+func (x *RequestMessage) Reset() {
+	*x = RequestMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kubearmor_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestMessage) ProtoMessage() {}
+
+func (x *RequestMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_kubearmor_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestMessage.ProtoReflect.Descriptor instead.
+func (*RequestMessage) Descriptor() ([]byte, []int) {
+	return file_kubearmor_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RequestMessage) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+func (x *RequestMessage) GetEnforcers() []string {
+	if x != nil {
+		return x.Enforcers
+	}
+	return nil
+}
+
+// reply message
+type ReplyMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Retval int32 `protobuf:"varint,1,opt,name=Retval,proto3" json:"Retval,omitempty"`
+	// health of the alert sinks
+	Sinks []*SinkStatus `protobuf:"bytes,2,rep,name=Sinks,proto3" json:"Sinks,omitempty"`
+	// some endpoints are enforced in Audit only after enforcer errors
+	EnforcementDegraded bool `protobuf:"varint,3,opt,name=EnforcementDegraded,proto3" json:"EnforcementDegraded,omitempty"`
+	// a sink lags behind over the threshold (only with -sinkLagUnready)
+	SinksLagging bool `protobuf:"varint,4,opt,name=SinksLagging,proto3" json:"SinksLagging,omitempty"`
+}
+
+func (x *ReplyMessage) Reset() {
+	*x = ReplyMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kubearmor_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReplyMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplyMessage) ProtoMessage() {}
+
+func (x *ReplyMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_kubearmor_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplyMessage.ProtoReflect.Descriptor instead.
+func (*ReplyMessage) Descriptor() ([]byte, []int) {
+	return file_kubearmor_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReplyMessage) GetRetval() int32 {
+	if x != nil {
+		return x.Retval
+	}
+	return 0
+}
+
+func (x *ReplyMessage) GetSinks() []*SinkStatus {
+	if x != nil {
+		return x.Sinks
+	}
+	return nil
+}
+
+func (x *ReplyMessage) GetEnforcementDegraded() bool {
+	if x != nil {
+		return x.EnforcementDegraded
+	}
+	return false
+}
+
+func (x *ReplyMessage) GetSinksLagging() bool {
+	if x != nil {
+		return x.SinksLagging
+	}
+	return false
+}
+
+type SinkStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name    string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	Health  string `protobuf:"bytes,2,opt,name=Health,proto3" json:"Health,omitempty"`
+	Queued  int32  `protobuf:"varint,3,opt,name=Queued,proto3" json:"Queued,omitempty"`
+	Sent    uint64 `protobuf:"varint,4,opt,name=Sent,proto3" json:"Sent,omitempty"`
+	Dropped uint64 `protobuf:"varint,5,opt,name=Dropped,proto3" json:"Dropped,omitempty"`
+	// age of the oldest alert not sent yet, and the time the last sent alert waited
+	OldestQueuedAgeMs int64 `protobuf:"varint,6,opt,name=OldestQueuedAgeMs,proto3" json:"OldestQueuedAgeMs,omitempty"`
+	DeliveryLagMs     int64 `protobuf:"varint,7,opt,name=DeliveryLagMs,proto3" json:"DeliveryLagMs,omitempty"`
+}
+
+func (x *SinkStatus) Reset() {
+	*x = SinkStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kubearmor_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SinkStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SinkStatus) ProtoMessage() {}
+
+func (x *SinkStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_kubearmor_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SinkStatus.ProtoReflect.Descriptor instead.
+func (*SinkStatus) Descriptor() ([]byte, []int) {
+	return file_kubearmor_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SinkStatus) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SinkStatus) GetHealth() string {
+	if x != nil {
+		return x.Health
+	}
+	return ""
+}
+
+func (x *SinkStatus) GetQueued() int32 {
+	if x != nil {
+		return x.Queued
+	}
+	return 0
+}
+
+func (x *SinkStatus) GetSent() uint64 {
+	if x != nil {
+		return x.Sent
+	}
+	return 0
+}
+
+func (x *SinkStatus) GetDropped() uint64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+func (x *SinkStatus) GetOldestQueuedAgeMs() int64 {
+	if x != nil {
+		return x.OldestQueuedAgeMs
+	}
+	return 0
+}
+
+func (x *SinkStatus) GetDeliveryLagMs() int64 {
+	if x != nil {
+		return x.DeliveryLagMs
+	}
+	return 0
+}
+
+// request of the telemetry schema (the emitted major version if empty)
+type TelemetrySchemaRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version string `protobuf:"bytes,1,opt,name=Version,proto3" json:"Version,omitempty"`
+}
+
+func (x *TelemetrySchemaRequest) Reset() {
+	*x = TelemetrySchemaRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kubearmor_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TelemetrySchemaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TelemetrySchemaRequest) ProtoMessage() {}
+
+func (x *TelemetrySchemaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kubearmor_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TelemetrySchemaRequest.ProtoReflect.Descriptor instead.
+func (*TelemetrySchemaRequest) Descriptor() ([]byte, []int) {
+	return file_kubearmor_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TelemetrySchemaRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+// JSON schema of the alerts and the logs
+type TelemetrySchema struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version string `protobuf:"bytes,1,opt,name=Version,proto3" json:"Version,omitempty"`
+	Schema  string `protobuf:"bytes,2,opt,name=Schema,proto3" json:"Schema,omitempty"`
+}
+
+func (x *TelemetrySchema) Reset() {
+	*x = TelemetrySchema{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kubearmor_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TelemetrySchema) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TelemetrySchema) ProtoMessage() {}
+
+func (x *TelemetrySchema) ProtoReflect() protoreflect.Message {
+	mi := &file_kubearmor_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TelemetrySchema.ProtoReflect.Descriptor instead.
+func (*TelemetrySchema) Descriptor() ([]byte, []int) {
+	return file_kubearmor_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TelemetrySchema) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *TelemetrySchema) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+var File_kubearmor_proto protoreflect.FileDescriptor
+
+var file_kubearmor_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x6b, 0x75, 0x62, 0x65, 0x61, 0x72, 0x6d, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x06, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x22, 0x24, 0x0a, 0x0c, 0x4e, 0x6f, 0x6e,
+	0x63, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e,
+	0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x22,
+	0xe3, 0x01, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x20, 0x0a, 0x0b, 0x55, 0x70, 0x64,
 	0x61, 0x74, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
 	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x43,
 	0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
@@ -894,7 +1618,7 @@ var file_kubearmor_proto_rawDesc = []byte{
 	0x52, 0x65, 0x66, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
 	0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73,
 	0x70, 0x61, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x4e, 0x61, 0x6d, 0x65,
-	0x73, 0x70, 0x61, 0x63, 0x65, 0x22, 0x8b, 0x07, 0x0a, 0x05, 0x41, 0x6c, 0x65, 0x72, 0x74, 0x12,
+	0x73, 0x70, 0x61, 0x63, 0x65, 0x22, 0x87, 0x0c, 0x0a, 0x05, 0x41, 0x6c, 0x65, 0x72, 0x74, 0x12,
 	0x1c, 0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01,
 	0x28, 0x03, 0x52, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x20, 0x0a,
 	0x0b, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
@@ -933,109 +1657,250 @@ var file_kubearmor_proto_rawDesc = []byte{
 	0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x09, 0x52,
 	0x0a, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x53,
 	0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x53,
-	0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x54, 0x61, 0x67, 0x73, 0x18,
-	0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x54, 0x61, 0x67, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x41,
-	0x54, 0x61, 0x67, 0x73, 0x18, 0x1e, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x41, 0x54, 0x61, 0x67,
-	0x73, 0x12, 0x18, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x10, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x54,
-	0x79, 0x70, 0x65, 0x18, 0x11, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12,
-	0x16, 0x0a, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x12, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x4f, 0x70, 0x65, 0x72, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x13, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x4f, 0x70, 0x65, 0x72,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63,
-	0x65, 0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63,
-	0x65, 0x12, 0x12, 0x0a, 0x04, 0x44, 0x61, 0x74, 0x61, 0x18, 0x15, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x04, 0x44, 0x61, 0x74, 0x61, 0x12, 0x1a, 0x0a, 0x08, 0x45, 0x6e, 0x66, 0x6f, 0x72, 0x63, 0x65,
-	0x72, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x45, 0x6e, 0x66, 0x6f, 0x72, 0x63, 0x65,
-	0x72, 0x12, 0x16, 0x0a, 0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x16, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x52, 0x65, 0x73,
-	0x75, 0x6c, 0x74, 0x18, 0x17, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c,
-	0x74, 0x12, 0x10, 0x0a, 0x03, 0x43, 0x77, 0x64, 0x18, 0x20, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
-	0x43, 0x77, 0x64, 0x22, 0xd5, 0x05, 0x0a, 0x03, 0x4c, 0x6f, 0x67, 0x12, 0x1c, 0x0a, 0x09, 0x54,
-	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
-	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x20, 0x0a, 0x0b, 0x55, 0x70, 0x64,
-	0x61, 0x74, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
-	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x43,
-	0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x0b, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a,
-	0x08, 0x48, 0x6f, 0x73, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x08, 0x48, 0x6f, 0x73, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x4e, 0x61, 0x6d,
-	0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x0d, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12,
-	0x26, 0x0a, 0x05, 0x4f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x18, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10,
-	0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x50, 0x6f, 0x64, 0x6f, 0x77, 0x6e, 0x65, 0x72,
-	0x52, 0x05, 0x4f, 0x77, 0x6e, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x50, 0x6f, 0x64, 0x4e, 0x61,
-	0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x50, 0x6f, 0x64, 0x4e, 0x61, 0x6d,
-	0x65, 0x12, 0x16, 0x0a, 0x06, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x17, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x06, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x43, 0x6f, 0x6e,
-	0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x49, 0x44, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
-	0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x49, 0x44, 0x12, 0x24, 0x0a, 0x0d, 0x43,
-	0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x08, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x0d, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x4e, 0x61, 0x6d,
-	0x65, 0x12, 0x26, 0x0a, 0x0e, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x49, 0x6d,
-	0x61, 0x67, 0x65, 0x18, 0x13, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x43, 0x6f, 0x6e, 0x74, 0x61,
-	0x69, 0x6e, 0x65, 0x72, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x2c, 0x0a, 0x11, 0x50, 0x61, 0x72,
-	0x65, 0x6e, 0x74, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x14,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x50, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x50, 0x72, 0x6f, 0x63,
-	0x65, 0x73, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x50, 0x72, 0x6f, 0x63, 0x65,
-	0x73, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x15, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x50, 0x72,
-	0x6f, 0x63, 0x65, 0x73, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x48, 0x6f, 0x73,
-	0x74, 0x50, 0x50, 0x49, 0x44, 0x18, 0x16, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x48, 0x6f, 0x73,
-	0x74, 0x50, 0x50, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x48, 0x6f, 0x73, 0x74, 0x50, 0x49, 0x44,
-	0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x48, 0x6f, 0x73, 0x74, 0x50, 0x49, 0x44, 0x12,
-	0x12, 0x0a, 0x04, 0x50, 0x50, 0x49, 0x44, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x50,
-	0x50, 0x49, 0x44, 0x12, 0x10, 0x0a, 0x03, 0x50, 0x49, 0x44, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x05,
-	0x52, 0x03, 0x50, 0x49, 0x44, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x49, 0x44, 0x18, 0x0c, 0x20, 0x01,
-	0x28, 0x05, 0x52, 0x03, 0x55, 0x49, 0x44, 0x12, 0x12, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x18,
-	0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x53,
-	0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x53, 0x6f, 0x75,
-	0x72, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
-	0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x10, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x08, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x12, 0x0a,
-	0x04, 0x44, 0x61, 0x74, 0x61, 0x18, 0x11, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x44, 0x61, 0x74,
-	0x61, 0x12, 0x16, 0x0a, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x12, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x43, 0x77, 0x64,
-	0x18, 0x19, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x43, 0x77, 0x64, 0x22, 0x28, 0x0a, 0x0e, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a,
-	0x06, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x46,
-	0x69, 0x6c, 0x74, 0x65, 0x72, 0x22, 0x26, 0x0a, 0x0c, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x4d, 0x65,
+	0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x12, 0x26, 0x0a, 0x0e, 0x50, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x53, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x18, 0x22, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x53, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x12,
+	0x24, 0x0a, 0x0d, 0x53, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x4c, 0x61, 0x62, 0x65, 0x6c,
+	0x18, 0x26, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x53, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79,
+	0x4c, 0x61, 0x62, 0x65, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x54, 0x61, 0x67, 0x73, 0x18, 0x0f, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x54, 0x61, 0x67, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x41, 0x54, 0x61,
+	0x67, 0x73, 0x18, 0x1e, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x41, 0x54, 0x61, 0x67, 0x73, 0x12,
+	0x18, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x54, 0x79, 0x70,
+	0x65, 0x18, 0x11, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x12, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x53,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x13, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18,
+	0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x44, 0x61, 0x74, 0x61, 0x18, 0x15, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x44,
+	0x61, 0x74, 0x61, 0x12, 0x1a, 0x0a, 0x08, 0x45, 0x6e, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x72, 0x18,
+	0x1c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x45, 0x6e, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x72, 0x12,
+	0x16, 0x0a, 0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x16, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x18, 0x17, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x43, 0x77, 0x64, 0x18, 0x20, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x43, 0x77,
+	0x64, 0x12, 0x24, 0x0a, 0x0d, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x72, 0x65, 0x61, 0x74,
+	0x6f, 0x72, 0x18, 0x21, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74,
+	0x43, 0x72, 0x65, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x20, 0x0a, 0x0b, 0x43, 0x6c, 0x6f, 0x63, 0x6b,
+	0x52, 0x65, 0x73, 0x79, 0x6e, 0x63, 0x18, 0x23, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x43, 0x6c,
+	0x6f, 0x63, 0x6b, 0x52, 0x65, 0x73, 0x79, 0x6e, 0x63, 0x12, 0x24, 0x0a, 0x0d, 0x50, 0x6f, 0x73,
+	0x74, 0x75, 0x72, 0x65, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x24, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0d, 0x50, 0x6f, 0x73, 0x74, 0x75, 0x72, 0x65, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12,
+	0x2e, 0x0a, 0x07, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x18, 0x25, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x14, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x57, 0x72, 0x69, 0x74, 0x65, 0x43,
+	0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x52, 0x07, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x12,
+	0x2c, 0x0a, 0x11, 0x45, 0x6e, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x18, 0x27, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x45, 0x6e, 0x66, 0x6f,
+	0x72, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x24, 0x0a,
+	0x0d, 0x4f, 0x77, 0x6e, 0x65, 0x72, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x28,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x4f, 0x77, 0x6e, 0x65, 0x72, 0x49, 0x64, 0x65, 0x6e, 0x74,
+	0x69, 0x74, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x29,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x24, 0x0a,
+	0x0d, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x2a,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x18, 0x2b,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x12, 0x1e, 0x0a,
+	0x0a, 0x50, 0x72, 0x69, 0x76, 0x69, 0x6c, 0x65, 0x67, 0x65, 0x64, 0x18, 0x2c, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0a, 0x50, 0x72, 0x69, 0x76, 0x69, 0x6c, 0x65, 0x67, 0x65, 0x64, 0x12, 0x28, 0x0a,
+	0x0f, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65,
+	0x18, 0x2d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4e, 0x61,
+	0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x4d, 0x61, 0x74, 0x63, 0x68,
+	0x65, 0x64, 0x52, 0x75, 0x6c, 0x65, 0x18, 0x2e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x4d, 0x61,
+	0x74, 0x63, 0x68, 0x65, 0x64, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x43, 0x6f, 0x6e,
+	0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x65, 0x18, 0x2f, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0e, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74,
+	0x65, 0x12, 0x24, 0x0a, 0x0d, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x54, 0x79,
+	0x70, 0x65, 0x18, 0x30, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69,
+	0x6e, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x57, 0x6f, 0x75, 0x6c, 0x64,
+	0x42, 0x65, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x18, 0x31, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0e, 0x57, 0x6f, 0x75, 0x6c, 0x64, 0x42, 0x65, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x22,
+	0xf8, 0x01, 0x0a, 0x0c, 0x57, 0x72, 0x69, 0x74, 0x65, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65,
+	0x12, 0x16, 0x0a, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x46, 0x44, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x46, 0x44, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x61, 0x74, 0x68,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x50, 0x61, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06,
+	0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x4f, 0x66,
+	0x66, 0x73, 0x65, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x46, 0x6c, 0x61, 0x67, 0x73, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x46, 0x6c, 0x61, 0x67, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x69,
+	0x7a, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x16,
+	0x0a, 0x06, 0x53, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x53, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x54, 0x72, 0x75, 0x6e, 0x63, 0x61,
+	0x74, 0x65, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x54, 0x72, 0x75, 0x6e, 0x63,
+	0x61, 0x74, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x65, 0x64, 0x61, 0x63, 0x74, 0x65, 0x64,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x52, 0x65, 0x64, 0x61, 0x63, 0x74, 0x65, 0x64,
+	0x12, 0x18, 0x0a, 0x07, 0x48, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x07, 0x48, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x73, 0x22, 0xc7, 0x07, 0x0a, 0x03, 0x4c,
+	0x6f, 0x67, 0x12, 0x1c, 0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x12, 0x20, 0x0a, 0x0b, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69,
+	0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x4e, 0x61, 0x6d,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72,
+	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x48, 0x6f, 0x73, 0x74, 0x4e, 0x61, 0x6d, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x48, 0x6f, 0x73, 0x74, 0x4e, 0x61, 0x6d, 0x65,
+	0x12, 0x24, 0x0a, 0x0d, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x4e, 0x61, 0x6d,
+	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61,
+	0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x26, 0x0a, 0x05, 0x4f, 0x77, 0x6e, 0x65, 0x72, 0x18,
+	0x18, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x50,
+	0x6f, 0x64, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x52, 0x05, 0x4f, 0x77, 0x6e, 0x65, 0x72, 0x12, 0x18,
+	0x0a, 0x07, 0x50, 0x6f, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x50, 0x6f, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x4c, 0x61, 0x62, 0x65,
+	0x6c, 0x73, 0x18, 0x17, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73,
+	0x12, 0x20, 0x0a, 0x0b, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x49, 0x44, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72,
+	0x49, 0x44, 0x12, 0x24, 0x0a, 0x0d, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x4e,
+	0x61, 0x6d, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x43, 0x6f, 0x6e, 0x74, 0x61,
+	0x69, 0x6e, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x43, 0x6f, 0x6e, 0x74,
+	0x61, 0x69, 0x6e, 0x65, 0x72, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x13, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0e, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x49, 0x6d, 0x61, 0x67, 0x65,
+	0x12, 0x2c, 0x0a, 0x11, 0x50, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73,
+	0x73, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x50, 0x61, 0x72,
+	0x65, 0x6e, 0x74, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x20,
+	0x0a, 0x0b, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x15, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x4e, 0x61, 0x6d, 0x65,
+	0x12, 0x1a, 0x0a, 0x08, 0x48, 0x6f, 0x73, 0x74, 0x50, 0x50, 0x49, 0x44, 0x18, 0x16, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x08, 0x48, 0x6f, 0x73, 0x74, 0x50, 0x50, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07,
+	0x48, 0x6f, 0x73, 0x74, 0x50, 0x49, 0x44, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x48,
+	0x6f, 0x73, 0x74, 0x50, 0x49, 0x44, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x50, 0x49, 0x44, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x50, 0x50, 0x49, 0x44, 0x12, 0x10, 0x0a, 0x03, 0x50, 0x49,
+	0x44, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x50, 0x49, 0x44, 0x12, 0x10, 0x0a, 0x03,
+	0x55, 0x49, 0x44, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x55, 0x49, 0x44, 0x12, 0x12,
+	0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x0e, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x4f, 0x70,
+	0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x4f,
+	0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x52, 0x65, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x44, 0x61, 0x74, 0x61, 0x18, 0x11, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x44, 0x61, 0x74, 0x61, 0x12, 0x16, 0x0a, 0x06, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x18, 0x12, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x12, 0x10, 0x0a, 0x03, 0x43, 0x77, 0x64, 0x18, 0x19, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x43,
+	0x77, 0x64, 0x12, 0x24, 0x0a, 0x0d, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x72, 0x65, 0x61,
+	0x74, 0x6f, 0x72, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x53, 0x6f, 0x63, 0x6b, 0x65,
+	0x74, 0x43, 0x72, 0x65, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x20, 0x0a, 0x0b, 0x43, 0x6c, 0x6f, 0x63,
+	0x6b, 0x52, 0x65, 0x73, 0x79, 0x6e, 0x63, 0x18, 0x1b, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x43,
+	0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x73, 0x79, 0x6e, 0x63, 0x12, 0x1a, 0x0a, 0x08, 0x45, 0x6e,
+	0x66, 0x6f, 0x72, 0x63, 0x65, 0x72, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x45, 0x6e,
+	0x66, 0x6f, 0x72, 0x63, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x1d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x12, 0x24, 0x0a, 0x0d, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x26, 0x0a, 0x0e, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69,
+	0x6e, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x65, 0x18, 0x1f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e,
+	0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x24,
+	0x0a, 0x0d, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x18,
+	0x20, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72,
+	0x54, 0x79, 0x70, 0x65, 0x22, 0xe5, 0x03, 0x0a, 0x0b, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x12, 0x20, 0x0a, 0x0b, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69, 0x6d,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64,
+	0x54, 0x69, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x4e,
+	0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x43, 0x6c, 0x75, 0x73, 0x74,
+	0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x48, 0x6f, 0x73, 0x74, 0x4e, 0x61,
+	0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x48, 0x6f, 0x73, 0x74, 0x4e, 0x61,
+	0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x4b, 0x69, 0x6e, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x24, 0x0a, 0x0d, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x4e,
+	0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x0a,
+	0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0a, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x41, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x09,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09,
+	0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x09, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x45, 0x6e,
+	0x66, 0x6f, 0x72, 0x63, 0x65, 0x72, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x45, 0x6e,
+	0x66, 0x6f, 0x72, 0x63, 0x65, 0x72, 0x12, 0x2c, 0x0a, 0x11, 0x49, 0x6e, 0x63, 0x6f, 0x6d, 0x70,
+	0x61, 0x74, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x11, 0x49, 0x6e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x74, 0x69, 0x62, 0x69, 0x6c, 0x69,
+	0x74, 0x69, 0x65, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x57, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73,
+	0x18, 0x0d, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x57, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73,
+	0x12, 0x2c, 0x0a, 0x11, 0x4c, 0x61, 0x73, 0x74, 0x41, 0x70, 0x70, 0x6c, 0x79, 0x44, 0x75, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x4c, 0x61, 0x73,
+	0x74, 0x41, 0x70, 0x70, 0x6c, 0x79, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1c,
+	0x0a, 0x09, 0x52, 0x75, 0x6c, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x09, 0x52, 0x75, 0x6c, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x46, 0x0a, 0x0e,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x16,
+	0x0a, 0x06, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x45, 0x6e, 0x66, 0x6f, 0x72, 0x63,
+	0x65, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x45, 0x6e, 0x66, 0x6f, 0x72,
+	0x63, 0x65, 0x72, 0x73, 0x22, 0xa6, 0x01, 0x0a, 0x0c, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x4d, 0x65,
 	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x52, 0x65, 0x74, 0x76, 0x61, 0x6c, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x52, 0x65, 0x74, 0x76, 0x61, 0x6c, 0x32, 0xef, 0x01,
-	0x0a, 0x0a, 0x4c, 0x6f, 0x67, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x39, 0x0a, 0x0b,
-	0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x12, 0x14, 0x2e, 0x66, 0x65,
-	0x65, 0x64, 0x65, 0x72, 0x2e, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x1a, 0x14, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x79,
-	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x3a, 0x0a, 0x0d, 0x57, 0x61, 0x74, 0x63, 0x68,
-	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x12, 0x16, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65,
-	0x72, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
-	0x1a, 0x0f, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x30, 0x01, 0x12, 0x36, 0x0a, 0x0b, 0x57, 0x61, 0x74, 0x63, 0x68, 0x41, 0x6c, 0x65, 0x72,
-	0x74, 0x73, 0x12, 0x16, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x0d, 0x2e, 0x66, 0x65, 0x65,
-	0x64, 0x65, 0x72, 0x2e, 0x41, 0x6c, 0x65, 0x72, 0x74, 0x30, 0x01, 0x12, 0x32, 0x0a, 0x09, 0x57,
-	0x61, 0x74, 0x63, 0x68, 0x4c, 0x6f, 0x67, 0x73, 0x12, 0x16, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65,
-	0x72, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
-	0x1a, 0x0b, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x4c, 0x6f, 0x67, 0x30, 0x01, 0x32,
-	0xf0, 0x01, 0x0a, 0x0e, 0x50, 0x75, 0x73, 0x68, 0x4c, 0x6f, 0x67, 0x53, 0x65, 0x72, 0x76, 0x69,
-	0x63, 0x65, 0x12, 0x39, 0x0a, 0x0b, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65, 0x63,
-	0x6b, 0x12, 0x14, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x4e, 0x6f, 0x6e, 0x63, 0x65,
-	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x14, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72,
-	0x2e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x39, 0x0a,
-	0x0c, 0x50, 0x75, 0x73, 0x68, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x12, 0x0f, 0x2e,
-	0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x14,
-	0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x4d, 0x65, 0x73,
-	0x73, 0x61, 0x67, 0x65, 0x28, 0x01, 0x30, 0x01, 0x12, 0x35, 0x0a, 0x0a, 0x50, 0x75, 0x73, 0x68,
-	0x41, 0x6c, 0x65, 0x72, 0x74, 0x73, 0x12, 0x0d, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e,
-	0x41, 0x6c, 0x65, 0x72, 0x74, 0x1a, 0x14, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x52,
-	0x65, 0x70, 0x6c, 0x79, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x28, 0x01, 0x30, 0x01, 0x12,
-	0x31, 0x0a, 0x08, 0x50, 0x75, 0x73, 0x68, 0x4c, 0x6f, 0x67, 0x73, 0x12, 0x0b, 0x2e, 0x66, 0x65,
-	0x65, 0x64, 0x65, 0x72, 0x2e, 0x4c, 0x6f, 0x67, 0x1a, 0x14, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65,
-	0x72, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x28, 0x01,
-	0x30, 0x01, 0x42, 0x29, 0x5a, 0x27, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
-	0x2f, 0x6b, 0x75, 0x62, 0x65, 0x61, 0x72, 0x6d, 0x6f, 0x72, 0x2f, 0x4b, 0x75, 0x62, 0x65, 0x41,
-	0x72, 0x6d, 0x6f, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x52, 0x65, 0x74, 0x76, 0x61, 0x6c, 0x12, 0x28, 0x0a,
+	0x05, 0x53, 0x69, 0x6e, 0x6b, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x66,
+	0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x53, 0x69, 0x6e, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x52, 0x05, 0x53, 0x69, 0x6e, 0x6b, 0x73, 0x12, 0x30, 0x0a, 0x13, 0x45, 0x6e, 0x66, 0x6f, 0x72,
+	0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x44, 0x65, 0x67, 0x72, 0x61, 0x64, 0x65, 0x64, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x13, 0x45, 0x6e, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x44, 0x65, 0x67, 0x72, 0x61, 0x64, 0x65, 0x64, 0x12, 0x22, 0x0a, 0x0c, 0x53, 0x69, 0x6e,
+	0x6b, 0x73, 0x4c, 0x61, 0x67, 0x67, 0x69, 0x6e, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0c, 0x53, 0x69, 0x6e, 0x6b, 0x73, 0x4c, 0x61, 0x67, 0x67, 0x69, 0x6e, 0x67, 0x22, 0xd2, 0x01,
+	0x0a, 0x0a, 0x53, 0x69, 0x6e, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x12, 0x0a, 0x04,
+	0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65,
+	0x12, 0x16, 0x0a, 0x06, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x51, 0x75, 0x65, 0x75,
+	0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x51, 0x75, 0x65, 0x75, 0x65, 0x64,
+	0x12, 0x12, 0x0a, 0x04, 0x53, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04,
+	0x53, 0x65, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x44, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x44, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x12, 0x2c,
+	0x0a, 0x11, 0x4f, 0x6c, 0x64, 0x65, 0x73, 0x74, 0x51, 0x75, 0x65, 0x75, 0x65, 0x64, 0x41, 0x67,
+	0x65, 0x4d, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x4f, 0x6c, 0x64, 0x65, 0x73,
+	0x74, 0x51, 0x75, 0x65, 0x75, 0x65, 0x64, 0x41, 0x67, 0x65, 0x4d, 0x73, 0x12, 0x24, 0x0a, 0x0d,
+	0x44, 0x65, 0x6c, 0x69, 0x76, 0x65, 0x72, 0x79, 0x4c, 0x61, 0x67, 0x4d, 0x73, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0d, 0x44, 0x65, 0x6c, 0x69, 0x76, 0x65, 0x72, 0x79, 0x4c, 0x61, 0x67,
+	0x4d, 0x73, 0x22, 0x32, 0x0a, 0x16, 0x54, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x53,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x43, 0x0a, 0x0f, 0x54, 0x65, 0x6c, 0x65, 0x6d, 0x65,
+	0x74, 0x72, 0x79, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x12, 0x18, 0x0a, 0x07, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x56, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x32, 0xfe, 0x02, 0x0a, 0x0a,
+	0x4c, 0x6f, 0x67, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x39, 0x0a, 0x0b, 0x48, 0x65,
+	0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x12, 0x14, 0x2e, 0x66, 0x65, 0x65, 0x64,
+	0x65, 0x72, 0x2e, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a,
+	0x14, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x3a, 0x0a, 0x0d, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x12, 0x16, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x0f,
+	0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x30,
+	0x01, 0x12, 0x36, 0x0a, 0x0b, 0x57, 0x61, 0x74, 0x63, 0x68, 0x41, 0x6c, 0x65, 0x72, 0x74, 0x73,
+	0x12, 0x16, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x0d, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65,
+	0x72, 0x2e, 0x41, 0x6c, 0x65, 0x72, 0x74, 0x30, 0x01, 0x12, 0x32, 0x0a, 0x09, 0x57, 0x61, 0x74,
+	0x63, 0x68, 0x4c, 0x6f, 0x67, 0x73, 0x12, 0x16, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x0b,
+	0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x4c, 0x6f, 0x67, 0x30, 0x01, 0x12, 0x3e, 0x0a,
+	0x0d, 0x57, 0x61, 0x74, 0x63, 0x68, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x69, 0x65, 0x73, 0x12, 0x16,
+	0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x13, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e,
+	0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x30, 0x01, 0x12, 0x4d, 0x0a,
+	0x12, 0x47, 0x65, 0x74, 0x54, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x53, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x12, 0x1e, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x54, 0x65, 0x6c,
+	0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x54, 0x65, 0x6c,
+	0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x32, 0xf0, 0x01, 0x0a,
+	0x0e, 0x50, 0x75, 0x73, 0x68, 0x4c, 0x6f, 0x67, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12,
+	0x39, 0x0a, 0x0b, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x12, 0x14,
+	0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x1a, 0x14, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x39, 0x0a, 0x0c, 0x50, 0x75,
+	0x73, 0x68, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x12, 0x0f, 0x2e, 0x66, 0x65, 0x65,
+	0x64, 0x65, 0x72, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x14, 0x2e, 0x66, 0x65,
+	0x65, 0x64, 0x65, 0x72, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x28, 0x01, 0x30, 0x01, 0x12, 0x35, 0x0a, 0x0a, 0x50, 0x75, 0x73, 0x68, 0x41, 0x6c, 0x65,
+	0x72, 0x74, 0x73, 0x12, 0x0d, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x41, 0x6c, 0x65,
+	0x72, 0x74, 0x1a, 0x14, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x28, 0x01, 0x30, 0x01, 0x12, 0x31, 0x0a, 0x08,
+	0x50, 0x75, 0x73, 0x68, 0x4c, 0x6f, 0x67, 0x73, 0x12, 0x0b, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65,
+	0x72, 0x2e, 0x4c, 0x6f, 0x67, 0x1a, 0x14, 0x2e, 0x66, 0x65, 0x65, 0x64, 0x65, 0x72, 0x2e, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x28, 0x01, 0x30, 0x01, 0x42,
+	0x29, 0x5a, 0x27, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6b, 0x75,
+	0x62, 0x65, 0x61, 0x72, 0x6d, 0x6f, 0x72, 0x2f, 0x4b, 0x75, 0x62, 0x65, 0x41, 0x72, 0x6d, 0x6f,
+	0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -1050,40 +1915,51 @@ func file_kubearmor_proto_rawDescGZIP() []byte {
 	return file_kubearmor_proto_rawDescData
 }
 
-var file_kubearmor_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_kubearmor_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_kubearmor_proto_goTypes = []interface{}{
-	(*NonceMessage)(nil),   // 0: feeder.NonceMessage
-	(*Message)(nil),        // 1: feeder.Message
-	(*Podowner)(nil),       // 2: feeder.Podowner
-	(*Alert)(nil),          // 3: feeder.Alert
-	(*Log)(nil),            // 4: feeder.Log
-	(*RequestMessage)(nil), // 5: feeder.RequestMessage
-	(*ReplyMessage)(nil),   // 6: feeder.ReplyMessage
+	(*NonceMessage)(nil),           // 0: feeder.NonceMessage
+	(*Message)(nil),                // 1: feeder.Message
+	(*Podowner)(nil),               // 2: feeder.Podowner
+	(*Alert)(nil),                  // 3: feeder.Alert
+	(*WriteCapture)(nil),           // 4: feeder.WriteCapture
+	(*Log)(nil),                    // 5: feeder.Log
+	(*PolicyEvent)(nil),            // 6: feeder.PolicyEvent
+	(*RequestMessage)(nil),         // 7: feeder.RequestMessage
+	(*ReplyMessage)(nil),           // 8: feeder.ReplyMessage
+	(*SinkStatus)(nil),             // 9: feeder.SinkStatus
+	(*TelemetrySchemaRequest)(nil), // 10: feeder.TelemetrySchemaRequest
+	(*TelemetrySchema)(nil),        // 11: feeder.TelemetrySchema
 }
 var file_kubearmor_proto_depIdxs = []int32{
 	2,  // 0: feeder.Alert.Owner:type_name -> feeder.Podowner
-	2,  // 1: feeder.Log.Owner:type_name -> feeder.Podowner
-	0,  // 2: feeder.LogService.HealthCheck:input_type -> feeder.NonceMessage
-	5,  // 3: feeder.LogService.WatchMessages:input_type -> feeder.RequestMessage
-	5,  // 4: feeder.LogService.WatchAlerts:input_type -> feeder.RequestMessage
-	5,  // 5: feeder.LogService.WatchLogs:input_type -> feeder.RequestMessage
-	0,  // 6: feeder.PushLogService.HealthCheck:input_type -> feeder.NonceMessage
-	1,  // 7: feeder.PushLogService.PushMessages:input_type -> feeder.Message
-	3,  // 8: feeder.PushLogService.PushAlerts:input_type -> feeder.Alert
-	4,  // 9: feeder.PushLogService.PushLogs:input_type -> feeder.Log
-	6,  // 10: feeder.LogService.HealthCheck:output_type -> feeder.ReplyMessage
-	1,  // 11: feeder.LogService.WatchMessages:output_type -> feeder.Message
-	3,  // 12: feeder.LogService.WatchAlerts:output_type -> feeder.Alert
-	4,  // 13: feeder.LogService.WatchLogs:output_type -> feeder.Log
-	6,  // 14: feeder.PushLogService.HealthCheck:output_type -> feeder.ReplyMessage
-	6,  // 15: feeder.PushLogService.PushMessages:output_type -> feeder.ReplyMessage
-	6,  // 16: feeder.PushLogService.PushAlerts:output_type -> feeder.ReplyMessage
-	6,  // 17: feeder.PushLogService.PushLogs:output_type -> feeder.ReplyMessage
-	10, // [10:18] is the sub-list for method output_type
-	2,  // [2:10] is the sub-list for method input_type
-	2,  // [2:2] is the sub-list for extension type_name
-	2,  // [2:2] is the sub-list for extension extendee
-	0,  // [0:2] is the sub-list for field type_name
+	4,  // 1: feeder.Alert.Capture:type_name -> feeder.WriteCapture
+	2,  // 2: feeder.Log.Owner:type_name -> feeder.Podowner
+	9,  // 3: feeder.ReplyMessage.Sinks:type_name -> feeder.SinkStatus
+	0,  // 4: feeder.LogService.HealthCheck:input_type -> feeder.NonceMessage
+	7,  // 5: feeder.LogService.WatchMessages:input_type -> feeder.RequestMessage
+	7,  // 6: feeder.LogService.WatchAlerts:input_type -> feeder.RequestMessage
+	7,  // 7: feeder.LogService.WatchLogs:input_type -> feeder.RequestMessage
+	7,  // 8: feeder.LogService.WatchPolicies:input_type -> feeder.RequestMessage
+	10, // 9: feeder.LogService.GetTelemetrySchema:input_type -> feeder.TelemetrySchemaRequest
+	0,  // 10: feeder.PushLogService.HealthCheck:input_type -> feeder.NonceMessage
+	1,  // 11: feeder.PushLogService.PushMessages:input_type -> feeder.Message
+	3,  // 12: feeder.PushLogService.PushAlerts:input_type -> feeder.Alert
+	5,  // 13: feeder.PushLogService.PushLogs:input_type -> feeder.Log
+	8,  // 14: feeder.LogService.HealthCheck:output_type -> feeder.ReplyMessage
+	1,  // 15: feeder.LogService.WatchMessages:output_type -> feeder.Message
+	3,  // 16: feeder.LogService.WatchAlerts:output_type -> feeder.Alert
+	5,  // 17: feeder.LogService.WatchLogs:output_type -> feeder.Log
+	6,  // 18: feeder.LogService.WatchPolicies:output_type -> feeder.PolicyEvent
+	11, // 19: feeder.LogService.GetTelemetrySchema:output_type -> feeder.TelemetrySchema
+	8,  // 20: feeder.PushLogService.HealthCheck:output_type -> feeder.ReplyMessage
+	8,  // 21: feeder.PushLogService.PushMessages:output_type -> feeder.ReplyMessage
+	8,  // 22: feeder.PushLogService.PushAlerts:output_type -> feeder.ReplyMessage
+	8,  // 23: feeder.PushLogService.PushLogs:output_type -> feeder.ReplyMessage
+	14, // [14:24] is the sub-list for method output_type
+	4,  // [4:14] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_kubearmor_proto_init() }
@@ -1141,7 +2017,7 @@ func file_kubearmor_proto_init() {
 			}
 		}
 		file_kubearmor_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Log); i {
+			switch v := v.(*WriteCapture); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1153,7 +2029,7 @@ func file_kubearmor_proto_init() {
 			}
 		}
 		file_kubearmor_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RequestMessage); i {
+			switch v := v.(*Log); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1165,6 +2041,30 @@ func file_kubearmor_proto_init() {
 			}
 		}
 		file_kubearmor_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PolicyEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kubearmor_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RequestMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kubearmor_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ReplyMessage); i {
 			case 0:
 				return &v.state
@@ -1176,6 +2076,42 @@ func file_kubearmor_proto_init() {
 				return nil
 			}
 		}
+		file_kubearmor_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SinkStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kubearmor_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TelemetrySchemaRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kubearmor_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TelemetrySchema); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -1183,7 +2119,7 @@ func file_kubearmor_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_kubearmor_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
@@ -26,6 +26,8 @@ type LogServiceClient interface {
 	WatchMessages(ctx context.Context, in *RequestMessage, opts ...grpc.CallOption) (LogService_WatchMessagesClient, error)
 	WatchAlerts(ctx context.Context, in *RequestMessage, opts ...grpc.CallOption) (LogService_WatchAlertsClient, error)
 	WatchLogs(ctx context.Context, in *RequestMessage, opts ...grpc.CallOption) (LogService_WatchLogsClient, error)
+	WatchPolicies(ctx context.Context, in *RequestMessage, opts ...grpc.CallOption) (LogService_WatchPoliciesClient, error)
+	GetTelemetrySchema(ctx context.Context, in *TelemetrySchemaRequest, opts ...grpc.CallOption) (*TelemetrySchema, error)
 }
 
 type logServiceClient struct {
@@ -141,6 +143,47 @@ func (x *logServiceWatchLogsClient) Recv() (*Log, error) {
 	return m, nil
 }
 
+func (c *logServiceClient) WatchPolicies(ctx context.Context, in *RequestMessage, opts ...grpc.CallOption) (LogService_WatchPoliciesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &LogService_ServiceDesc.Streams[3], "/feeder.LogService/WatchPolicies", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &logServiceWatchPoliciesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type LogService_WatchPoliciesClient interface {
+	Recv() (*PolicyEvent, error)
+	grpc.ClientStream
+}
+
+type logServiceWatchPoliciesClient struct {
+	grpc.ClientStream
+}
+
+func (x *logServiceWatchPoliciesClient) Recv() (*PolicyEvent, error) {
+	m := new(PolicyEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *logServiceClient) GetTelemetrySchema(ctx context.Context, in *TelemetrySchemaRequest, opts ...grpc.CallOption) (*TelemetrySchema, error) {
+	out := new(TelemetrySchema)
+	err := c.cc.Invoke(ctx, "/feeder.LogService/GetTelemetrySchema", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LogServiceServer is the server API for LogService service.
 // All implementations should embed UnimplementedLogServiceServer
 // for forward compatibility
@@ -149,6 +192,8 @@ type LogServiceServer interface {
 	WatchMessages(*RequestMessage, LogService_WatchMessagesServer) error
 	WatchAlerts(*RequestMessage, LogService_WatchAlertsServer) error
 	WatchLogs(*RequestMessage, LogService_WatchLogsServer) error
+	WatchPolicies(*RequestMessage, LogService_WatchPoliciesServer) error
+	GetTelemetrySchema(context.Context, *TelemetrySchemaRequest) (*TelemetrySchema, error)
 }
 
 // UnimplementedLogServiceServer should be embedded to have forward compatible implementations.
@@ -167,6 +212,12 @@ func (UnimplementedLogServiceServer) WatchAlerts(*RequestMessage, LogService_Wat
 func (UnimplementedLogServiceServer) WatchLogs(*RequestMessage, LogService_WatchLogsServer) error {
 	return status.Errorf(codes.Unimplemented, "method WatchLogs not implemented")
 }
+func (UnimplementedLogServiceServer) WatchPolicies(*RequestMessage, LogService_WatchPoliciesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPolicies not implemented")
+}
+func (UnimplementedLogServiceServer) GetTelemetrySchema(context.Context, *TelemetrySchemaRequest) (*TelemetrySchema, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTelemetrySchema not implemented")
+}
 
 // UnsafeLogServiceServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to LogServiceServer will
@@ -260,6 +311,45 @@ func (x *logServiceWatchLogsServer) Send(m *Log) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _LogService_WatchPolicies_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RequestMessage)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LogServiceServer).WatchPolicies(m, &logServiceWatchPoliciesServer{stream})
+}
+
+type LogService_WatchPoliciesServer interface {
+	Send(*PolicyEvent) error
+	grpc.ServerStream
+}
+
+type logServiceWatchPoliciesServer struct {
+	grpc.ServerStream
+}
+
+func (x *logServiceWatchPoliciesServer) Send(m *PolicyEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _LogService_GetTelemetrySchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TelemetrySchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogServiceServer).GetTelemetrySchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/feeder.LogService/GetTelemetrySchema",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogServiceServer).GetTelemetrySchema(ctx, req.(*TelemetrySchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // LogService_ServiceDesc is the grpc.ServiceDesc for LogService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -271,6 +361,10 @@ var LogService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "HealthCheck",
 			Handler:    _LogService_HealthCheck_Handler,
 		},
+		{
+			MethodName: "GetTelemetrySchema",
+			Handler:    _LogService_GetTelemetrySchema_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -288,6 +382,11 @@ var LogService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _LogService_WatchLogs_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchPolicies",
+			Handler:       _LogService_WatchPolicies_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "kubearmor.proto",
 }
@@ -1,17 +1,17 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
 // versions:
 // - protoc-gen-go-grpc v1.2.0
-// - protoc             v3.19.4
+// - protoc             v4.23.4
 // source: policy.proto
 
 package protobuf
 
 import (
 	context "context"
-	empty "github.com/golang/protobuf/ptypes/empty"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
 // This is a compile-time assertion to ensure that this generated file
@@ -23,7 +23,12 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ProbeServiceClient interface {
-	GetProbeData(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ProbeResponse, error)
+	GetProbeData(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ProbeResponse, error)
+	ExplainPosture(ctx context.Context, in *PostureRequest, opts ...grpc.CallOption) (*PostureExplanation, error)
+	GetEnforcementState(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*EnforcementState, error)
+	GetHealth(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*HealthResponse, error)
+	GetContainerEnforcement(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ContainerEnforcementResponse, error)
+	GetRecentExecs(ctx context.Context, in *RecentExecsRequest, opts ...grpc.CallOption) (*RecentExecsResponse, error)
 }
 
 type probeServiceClient struct {
@@ -34,7 +39,7 @@ func NewProbeServiceClient(cc grpc.ClientConnInterface) ProbeServiceClient {
 	return &probeServiceClient{cc}
 }
 
-func (c *probeServiceClient) GetProbeData(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ProbeResponse, error) {
+func (c *probeServiceClient) GetProbeData(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ProbeResponse, error) {
 	out := new(ProbeResponse)
 	err := c.cc.Invoke(ctx, "/policy.ProbeService/getProbeData", in, out, opts...)
 	if err != nil {
@@ -43,20 +48,85 @@ func (c *probeServiceClient) GetProbeData(ctx context.Context, in *empty.Empty,
 	return out, nil
 }
 
+func (c *probeServiceClient) ExplainPosture(ctx context.Context, in *PostureRequest, opts ...grpc.CallOption) (*PostureExplanation, error) {
+	out := new(PostureExplanation)
+	err := c.cc.Invoke(ctx, "/policy.ProbeService/explainPosture", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *probeServiceClient) GetEnforcementState(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*EnforcementState, error) {
+	out := new(EnforcementState)
+	err := c.cc.Invoke(ctx, "/policy.ProbeService/getEnforcementState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *probeServiceClient) GetHealth(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*HealthResponse, error) {
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, "/policy.ProbeService/getHealth", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *probeServiceClient) GetContainerEnforcement(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ContainerEnforcementResponse, error) {
+	out := new(ContainerEnforcementResponse)
+	err := c.cc.Invoke(ctx, "/policy.ProbeService/getContainerEnforcement", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *probeServiceClient) GetRecentExecs(ctx context.Context, in *RecentExecsRequest, opts ...grpc.CallOption) (*RecentExecsResponse, error) {
+	out := new(RecentExecsResponse)
+	err := c.cc.Invoke(ctx, "/policy.ProbeService/getRecentExecs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ProbeServiceServer is the server API for ProbeService service.
 // All implementations should embed UnimplementedProbeServiceServer
 // for forward compatibility
 type ProbeServiceServer interface {
-	GetProbeData(context.Context, *empty.Empty) (*ProbeResponse, error)
+	GetProbeData(context.Context, *emptypb.Empty) (*ProbeResponse, error)
+	ExplainPosture(context.Context, *PostureRequest) (*PostureExplanation, error)
+	GetEnforcementState(context.Context, *emptypb.Empty) (*EnforcementState, error)
+	GetHealth(context.Context, *emptypb.Empty) (*HealthResponse, error)
+	GetContainerEnforcement(context.Context, *emptypb.Empty) (*ContainerEnforcementResponse, error)
+	GetRecentExecs(context.Context, *RecentExecsRequest) (*RecentExecsResponse, error)
 }
 
 // UnimplementedProbeServiceServer should be embedded to have forward compatible implementations.
 type UnimplementedProbeServiceServer struct {
 }
 
-func (UnimplementedProbeServiceServer) GetProbeData(context.Context, *empty.Empty) (*ProbeResponse, error) {
+func (UnimplementedProbeServiceServer) GetProbeData(context.Context, *emptypb.Empty) (*ProbeResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetProbeData not implemented")
 }
+func (UnimplementedProbeServiceServer) ExplainPosture(context.Context, *PostureRequest) (*PostureExplanation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExplainPosture not implemented")
+}
+func (UnimplementedProbeServiceServer) GetEnforcementState(context.Context, *emptypb.Empty) (*EnforcementState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEnforcementState not implemented")
+}
+func (UnimplementedProbeServiceServer) GetHealth(context.Context, *emptypb.Empty) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHealth not implemented")
+}
+func (UnimplementedProbeServiceServer) GetContainerEnforcement(context.Context, *emptypb.Empty) (*ContainerEnforcementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetContainerEnforcement not implemented")
+}
+func (UnimplementedProbeServiceServer) GetRecentExecs(context.Context, *RecentExecsRequest) (*RecentExecsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecentExecs not implemented")
+}
 
 // UnsafeProbeServiceServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to ProbeServiceServer will
@@ -70,7 +140,7 @@ func RegisterProbeServiceServer(s grpc.ServiceRegistrar, srv ProbeServiceServer)
 }
 
 func _ProbeService_GetProbeData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(empty.Empty)
+	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -82,7 +152,97 @@ func _ProbeService_GetProbeData_Handler(srv interface{}, ctx context.Context, de
 		FullMethod: "/policy.ProbeService/getProbeData",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ProbeServiceServer).GetProbeData(ctx, req.(*empty.Empty))
+		return srv.(ProbeServiceServer).GetProbeData(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProbeService_ExplainPosture_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PostureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProbeServiceServer).ExplainPosture(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/policy.ProbeService/explainPosture",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProbeServiceServer).ExplainPosture(ctx, req.(*PostureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProbeService_GetEnforcementState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProbeServiceServer).GetEnforcementState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/policy.ProbeService/getEnforcementState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProbeServiceServer).GetEnforcementState(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProbeService_GetHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProbeServiceServer).GetHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/policy.ProbeService/getHealth",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProbeServiceServer).GetHealth(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProbeService_GetContainerEnforcement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProbeServiceServer).GetContainerEnforcement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/policy.ProbeService/getContainerEnforcement",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProbeServiceServer).GetContainerEnforcement(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProbeService_GetRecentExecs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecentExecsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProbeServiceServer).GetRecentExecs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/policy.ProbeService/getRecentExecs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProbeServiceServer).GetRecentExecs(ctx, req.(*RecentExecsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -98,6 +258,26 @@ var ProbeService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "getProbeData",
 			Handler:    _ProbeService_GetProbeData_Handler,
 		},
+		{
+			MethodName: "explainPosture",
+			Handler:    _ProbeService_ExplainPosture_Handler,
+		},
+		{
+			MethodName: "getEnforcementState",
+			Handler:    _ProbeService_GetEnforcementState_Handler,
+		},
+		{
+			MethodName: "getHealth",
+			Handler:    _ProbeService_GetHealth_Handler,
+		},
+		{
+			MethodName: "getContainerEnforcement",
+			Handler:    _ProbeService_GetContainerEnforcement_Handler,
+		},
+		{
+			MethodName: "getRecentExecs",
+			Handler:    _ProbeService_GetRecentExecs_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "policy.proto",
@@ -223,6 +403,162 @@ var PolicyService_ServiceDesc = grpc.ServiceDesc{
 	Metadata: "policy.proto",
 }
 
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminServiceClient interface {
+	TriggerResync(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ResyncResponse, error)
+	PreviewConfigChange(ctx context.Context, in *ConfigPreviewRequest, opts ...grpc.CallOption) (*ConfigPreview, error)
+	SimulatePolicy(ctx context.Context, in *SimulationRequest, opts ...grpc.CallOption) (*SimulationResult, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) TriggerResync(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ResyncResponse, error) {
+	out := new(ResyncResponse)
+	err := c.cc.Invoke(ctx, "/policy.AdminService/triggerResync", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) PreviewConfigChange(ctx context.Context, in *ConfigPreviewRequest, opts ...grpc.CallOption) (*ConfigPreview, error) {
+	out := new(ConfigPreview)
+	err := c.cc.Invoke(ctx, "/policy.AdminService/previewConfigChange", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) SimulatePolicy(ctx context.Context, in *SimulationRequest, opts ...grpc.CallOption) (*SimulationResult, error) {
+	out := new(SimulationResult)
+	err := c.cc.Invoke(ctx, "/policy.AdminService/simulatePolicy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations should embed UnimplementedAdminServiceServer
+// for forward compatibility
+type AdminServiceServer interface {
+	TriggerResync(context.Context, *emptypb.Empty) (*ResyncResponse, error)
+	PreviewConfigChange(context.Context, *ConfigPreviewRequest) (*ConfigPreview, error)
+	SimulatePolicy(context.Context, *SimulationRequest) (*SimulationResult, error)
+}
+
+// UnimplementedAdminServiceServer should be embedded to have forward compatible implementations.
+type UnimplementedAdminServiceServer struct {
+}
+
+func (UnimplementedAdminServiceServer) TriggerResync(context.Context, *emptypb.Empty) (*ResyncResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TriggerResync not implemented")
+}
+func (UnimplementedAdminServiceServer) PreviewConfigChange(context.Context, *ConfigPreviewRequest) (*ConfigPreview, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PreviewConfigChange not implemented")
+}
+func (UnimplementedAdminServiceServer) SimulatePolicy(context.Context, *SimulationRequest) (*SimulationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SimulatePolicy not implemented")
+}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_TriggerResync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).TriggerResync(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/policy.AdminService/triggerResync",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).TriggerResync(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_PreviewConfigChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfigPreviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).PreviewConfigChange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/policy.AdminService/previewConfigChange",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).PreviewConfigChange(ctx, req.(*ConfigPreviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_SimulatePolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SimulationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).SimulatePolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/policy.AdminService/simulatePolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).SimulatePolicy(ctx, req.(*SimulationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "policy.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "triggerResync",
+			Handler:    _AdminService_TriggerResync_Handler,
+		},
+		{
+			MethodName: "previewConfigChange",
+			Handler:    _AdminService_PreviewConfigChange_Handler,
+		},
+		{
+			MethodName: "simulatePolicy",
+			Handler:    _AdminService_SimulatePolicy_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "policy.proto",
+}
+
 // PolicyStreamServiceClient is the client API for PolicyStreamService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.